@@ -0,0 +1,298 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/docutag/controller/internal/clients"
+	"github.com/docutag/controller/internal/config"
+	"github.com/docutag/controller/internal/handlers"
+	"github.com/docutag/controller/internal/storage"
+	"github.com/docutag/controller/pkg/logging"
+)
+
+// commands maps each subcommand to its entry point. args excludes the subcommand name.
+var commands = map[string]func(args []string) error{
+	"serve":                    serve,
+	"migrate":                  migrateCommand,
+	"sweep-tombstones":         sweepTombstonesCommand,
+	"backfill-effective-dates": backfillEffectiveDatesCommand,
+	"generate-mock-data":       generateMockDataCommand,
+	"reconcile":                reconcileCommand,
+}
+
+// dispatch runs the subcommand named by args[0]. Without one, for example when the
+// arguments start with a flag, the service is started exactly as before subcommands existed.
+func dispatch(args []string) error {
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		return serve(args)
+	}
+	run, ok := commands[args[0]]
+	if !ok {
+		names := make([]string, 0, len(commands))
+		for name := range commands {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return fmt.Errorf("unknown command %q: use one of %s", args[0], strings.Join(names, ", "))
+	}
+	return run(args[1:])
+}
+
+// startup holds what every subcommand needs once its flags and the configuration are loaded
+type startup struct {
+	cfg        *config.Config
+	configPath string
+	logLevel   *slog.LevelVar
+	logger     *slog.Logger
+}
+
+// newStartup sets up structured logging with JSON output at info until configuration is loaded.
+// The level lives in a LevelVar so SIGHUP and the admin API can change it later.
+func newStartup() *startup {
+	logLevel := new(slog.LevelVar)
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
+		Level: logLevel,
+	}))
+	slog.SetDefault(logger)
+	return &startup{logLevel: logLevel, logger: logger}
+}
+
+// load adds -config to fs, parses args and loads the configuration, then switches logging to
+// the configured format and level. -config takes precedence over CONTROLLER_CONFIG.
+func (st *startup) load(fs *flag.FlagSet, args []string) error {
+	configPath := fs.String("config", os.Getenv(config.ConfigFileEnv), "path to a YAML or JSON config file; environment variables override its values")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	st.configPath = *configPath
+
+	cfg, err := config.LoadFile(st.configPath)
+	if err != nil {
+		var validationErr *config.ValidationError
+		if errors.As(err, &validationErr) {
+			for _, problem := range validationErr.Problems {
+				st.logger.Error("invalid configuration", "problem", problem)
+			}
+		}
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+	st.cfg = cfg
+
+	// Both were validated with the config
+	if level, err := logging.ParseLevel(cfg.LogLevel); err == nil {
+		st.logLevel.Set(level)
+	}
+	if logHandler, err := logging.NewHandler(os.Stdout, cfg.LogFormat, st.logLevel); err == nil {
+		st.logger = slog.New(logHandler)
+		slog.SetDefault(st.logger)
+	}
+	return nil
+}
+
+// connString is the PostgreSQL connection string for the configured database
+func (st *startup) connString() string {
+	return fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=disable",
+		st.cfg.DBHost, st.cfg.DBPort, st.cfg.DBUser, st.cfg.DBPassword, st.cfg.DBName)
+}
+
+// openStorage opens storage with the configured tombstone rules. With AUTO_MIGRATE=false it
+// fails instead of applying pending migrations.
+func (st *startup) openStorage() (*storage.Storage, error) {
+	if !st.cfg.AutoMigrate {
+		if err := requireMigrated(st.connString()); err != nil {
+			return nil, err
+		}
+	}
+	store, err := storage.New(
+		st.connString(),
+		st.cfg.TombstoneTags,
+		st.cfg.TombstonePeriodLowScore,
+		st.cfg.TombstonePeriodTagBased,
+		st.cfg.TombstonePeriodManual,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize storage: %w", err)
+	}
+	return store, nil
+}
+
+// withStorage loads configuration from args, opens storage, runs fn and closes storage again
+func withStorage(name string, args []string, addFlags func(fs *flag.FlagSet), fn func(st *startup, store *storage.Storage) error) error {
+	st := newStartup()
+	fs := flag.NewFlagSet(name, flag.ExitOnError)
+	if addFlags != nil {
+		addFlags(fs)
+	}
+	if err := st.load(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return fmt.Errorf("%s: unexpected arguments %v", name, fs.Args())
+	}
+
+	store, err := st.openStorage()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := store.Close(); err != nil {
+			st.logger.Error("error closing storage", "error", err)
+		}
+	}()
+	return fn(st, store)
+}
+
+// migrateCommand applies, reverts or lists schema migrations: migrate up|down|status
+func migrateCommand(args []string) error {
+	st := newStartup()
+	fs := flag.NewFlagSet("migrate", flag.ExitOnError)
+	if err := st.load(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("migrate needs exactly one mode: up, down or status")
+	}
+	return runMigrate(fs.Arg(0), st.connString(), os.Stdout)
+}
+
+// sweepTombstonesCommand runs one pass of the reaper that hard-deletes requests whose
+// soft-delete grace period has elapsed, with their upstream scrapes, images and analyses
+func sweepTombstonesCommand(args []string) error {
+	return withStorage("sweep-tombstones", args, nil, func(st *startup, store *storage.Storage) error {
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		handler := handlers.New(
+			store,
+			clients.NewScraperClient(st.cfg.ScraperBaseURL),
+			clients.NewTextAnalyzerClient(st.cfg.TextAnalyzerBaseURL),
+			nil,
+			nil,
+			nil,
+			st.cfg.LinkScoreThreshold,
+			st.cfg.WebInterfaceURL,
+			st.cfg.ScraperBaseURL,
+			st.cfg.TombstonePeriodLowScore,
+			st.cfg.TombstonePeriodManual,
+		)
+		defer handler.Close()
+		gracePeriod := time.Duration(st.cfg.DeleteGracePeriodDays) * 24 * time.Hour
+		reaped, err := handler.ReapDeletedRequests(ctx, gracePeriod)
+		if err != nil {
+			return fmt.Errorf("failed to reap deleted requests: %w", err)
+		}
+		st.logger.Info("reaped deleted requests", "count", reaped, "grace_period_days", st.cfg.DeleteGracePeriodDays)
+		return nil
+	})
+}
+
+// reconcileCommand looks for scrape jobs, requests and upstream records that no longer agree
+// and prints the report as JSON. With -fix it also repairs what it can.
+func reconcileCommand(args []string) error {
+	var opts handlers.ReconcileOptions
+	addFlags := func(fs *flag.FlagSet) {
+		fs.BoolVar(&opts.Fix, "fix", false, "clear dangling job results and flag requests whose upstream data is missing")
+		fs.BoolVar(&opts.Audit, "audit", false, "record the report in the audit log even without -fix")
+		fs.StringVar(&opts.After, "after", "", "resume the request scan after this request ID")
+		fs.IntVar(&opts.Limit, "limit", 0, "most requests to check upstream; 0 checks them all")
+	}
+	return withStorage("reconcile", args, addFlags, func(st *startup, store *storage.Storage) error {
+		if opts.Limit < 0 {
+			return fmt.Errorf("--limit must not be negative, got %d", opts.Limit)
+		}
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		handler := handlers.New(
+			store,
+			clients.NewScraperClient(st.cfg.ScraperBaseURL),
+			clients.NewTextAnalyzerClient(st.cfg.TextAnalyzerBaseURL),
+			nil,
+			nil,
+			nil,
+			st.cfg.LinkScoreThreshold,
+			st.cfg.WebInterfaceURL,
+			st.cfg.ScraperBaseURL,
+			st.cfg.TombstonePeriodLowScore,
+			st.cfg.TombstonePeriodManual,
+		)
+		defer handler.Close()
+		handler.SetReconcile(st.cfg.ReconcileBatchSize, st.cfg.ReconcileUpstreamRate)
+
+		opts.Actor = storage.AuditActorSystem
+		report, err := handler.Reconcile(ctx, opts)
+		if err != nil {
+			return fmt.Errorf("failed to reconcile: %w", err)
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(report)
+	})
+}
+
+// backfillEffectiveDatesCommand recomputes effective_date of every request from its metadata
+func backfillEffectiveDatesCommand(args []string) error {
+	var batchSize int
+	addFlags := func(fs *flag.FlagSet) {
+		fs.IntVar(&batchSize, "batch-size", 500, "requests read and updated per transaction")
+	}
+	return withStorage("backfill-effective-dates", args, addFlags, func(st *startup, store *storage.Storage) error {
+		start := time.Now()
+		updated, err := store.BackfillEffectiveDates(batchSize)
+		if err != nil {
+			return fmt.Errorf("failed to backfill effective dates after updating %d requests: %w", updated, err)
+		}
+		st.logger.Info("backfilled effective dates", "updated", updated, "duration_ms", time.Since(start).Milliseconds())
+		return nil
+	})
+}
+
+// generateMockDataCommand fills the database with mock requests and scrape jobs. Flags that
+// are not given fall back to the MOCK_DATA_* settings.
+func generateMockDataCommand(args []string) error {
+	var flags storage.MockDataOptions
+	addFlags := func(fs *flag.FlagSet) {
+		fs.IntVar(&flags.Count, "count", 0, "number of mock requests to generate (default MOCK_DATA_COUNT)")
+		fs.IntVar(&flags.Days, "days", 0, "spread the requests over this many days before now (default MOCK_DATA_DAYS)")
+		fs.Int64Var(&flags.Seed, "seed", 0, "seed for reproducible data; 0 uses MOCK_DATA_SEED")
+		fs.BoolVar(&flags.Force, "force", false, "generate even when the database already has requests")
+	}
+	return withStorage("generate-mock-data", args, addFlags, func(st *startup, store *storage.Storage) error {
+		if flags.Count < 0 || flags.Days < 0 {
+			return fmt.Errorf("--count and --days must be positive, got %d and %d", flags.Count, flags.Days)
+		}
+		opts := mockDataOptions(st.cfg)
+		if flags.Count > 0 {
+			opts.Count = flags.Count
+		}
+		if flags.Days > 0 {
+			opts.Days = flags.Days
+		}
+		if flags.Seed != 0 {
+			opts.Seed = flags.Seed
+		}
+		opts.Force = opts.Force || flags.Force
+		return store.GenerateMockDataWith(opts)
+	})
+}
+
+// mockDataOptions returns the configured mock data settings
+func mockDataOptions(cfg *config.Config) storage.MockDataOptions {
+	return storage.MockDataOptions{
+		Count: cfg.MockDataCount,
+		Days:  cfg.MockDataDays,
+		Seed:  cfg.MockDataSeed,
+		Force: cfg.MockDataForce,
+	}
+}
@@ -2,21 +2,36 @@ package main
 
 import (
 	"context"
+	"flag"
 	"fmt"
 	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
+	"sync"
 	"syscall"
 	"time"
+	_ "time/tzdata" // The runtime image has no zoneinfo; requests may name any IANA time zone
 
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/docutag/controller/internal/clients"
 	"github.com/docutag/controller/internal/config"
+	"github.com/docutag/controller/internal/debugserver"
 	"github.com/docutag/controller/internal/handlers"
+	"github.com/docutag/controller/internal/httpmetrics"
+	"github.com/docutag/controller/internal/imagecache"
 	"github.com/docutag/controller/internal/queue"
+	"github.com/docutag/controller/internal/sanitize"
+	"github.com/docutag/controller/internal/settings"
 	"github.com/docutag/controller/internal/storage"
+	"github.com/docutag/controller/internal/telemetry"
+	"github.com/docutag/controller/internal/templates"
+	"github.com/docutag/controller/internal/tlsserver"
 	"github.com/docutag/controller/internal/urlcache"
+	"github.com/docutag/controller/internal/urlguard"
+	"github.com/docutag/controller/internal/urlnorm"
+	"github.com/docutag/controller/internal/webhooks"
 	"github.com/docutag/controller/pkg/logging"
 	"github.com/docutag/platform/pkg/metrics"
 	"github.com/docutag/platform/pkg/tracing"
@@ -28,7 +43,8 @@ func corsMiddleware(next http.Handler) http.Handler {
 		// Set CORS headers
 		w.Header().Set("Access-Control-Allow-Origin", "*")
 		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, traceparent, tracestate")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-API-Key, X-Request-ID, If-Match, traceparent, tracestate")
+		w.Header().Set("Access-Control-Expose-Headers", "X-Request-ID, Deprecation, Link, ETag")
 		w.Header().Set("Access-Control-Max-Age", "3600")
 
 		// Handle preflight OPTIONS request
@@ -42,76 +58,160 @@ func corsMiddleware(next http.Handler) http.Handler {
 	})
 }
 
-func main() {
-	// Setup structured logging with JSON output
-	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
-		Level: slog.LevelInfo,
-	}))
-	slog.SetDefault(logger)
-
-	logger.Info("controller service initializing", "version", "1.0.0")
+// httpShutdownTimeout bounds how long in-flight HTTP requests may take to finish on shutdown
+const httpShutdownTimeout = 15 * time.Second
 
-	// Load configuration
-	cfg, err := config.Load()
-	if err != nil {
-		logger.Error("failed to load configuration", "error", err)
+func main() {
+	if err := dispatch(os.Args[1:]); err != nil {
+		slog.Default().Error("controller service failed", "error", err)
 		os.Exit(1)
 	}
+}
 
-	// Initialize tracing
-	tp, err := tracing.InitTracer("docutab-controller")
-	if err != nil {
+// runEvery calls fn every interval on its own goroutine until ctx is cancelled. A pass in
+// progress is handed ctx, so it can stop early; wg is done once the goroutine has returned.
+func runEvery(ctx context.Context, wg *sync.WaitGroup, interval time.Duration, fn func(ctx context.Context)) {
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				fn(ctx)
+			}
+		}
+	}()
+}
+
+// serve starts the service and blocks until a shutdown signal arrives or a component fails.
+// Failures are returned instead of exiting the process, so every deferred cleanup runs.
+func serve(args []string) error {
+	st := newStartup()
+	st.logger.Info("controller service initializing", "version", "1.0.0")
+
+	fs := flag.NewFlagSet(os.Args[0], flag.ExitOnError)
+	if err := st.load(fs, args); err != nil {
+		return err
+	}
+	cfg, logger := st.cfg, st.logger
+
+	// Initialize tracing; when disabled, handlers and the worker run with a no-op tracer
+	tp, err := telemetry.InitTracing(telemetry.TracingConfig{
+		Enabled:     cfg.TracingEnabled,
+		SampleRatio: cfg.TracingSampleRatio,
+		Endpoint:    cfg.OTLPEndpoint,
+		ServiceName: cfg.TracingServiceName,
+	}, tracing.InitTracer)
+	switch {
+	case err != nil:
 		logger.Warn("failed to initialize tracer, continuing without tracing", "error", err)
-	} else {
+	case tp == nil:
+		logger.Info("tracing disabled")
+	default:
 		defer func() {
 			if err := tp.Shutdown(context.Background()); err != nil {
 				logger.Error("error shutting down tracer", "error", err)
 			}
 		}()
-		logger.Info("tracing initialized successfully")
+		logger.Info("tracing initialized successfully", "service", cfg.TracingServiceName, "sample_ratio", cfg.TracingSampleRatio)
 	}
 
-	// Construct PostgreSQL connection string
-	dbConnStr := fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=disable",
-		cfg.DBHost, cfg.DBPort, cfg.DBUser, cfg.DBPassword, cfg.DBName)
-
 	// Initialize storage with tombstone configuration
-	store, err := storage.New(
-		dbConnStr,
-		cfg.TombstoneTags,
-		cfg.TombstonePeriodLowScore,
-		cfg.TombstonePeriodTagBased,
-		cfg.TombstonePeriodManual,
-	)
+	store, err := st.openStorage()
 	if err != nil {
-		logger.Error("failed to initialize storage", "error", err)
-		os.Exit(1)
+		return err
 	}
-	defer store.Close()
+	defer func() {
+		if err := store.Close(); err != nil {
+			logger.Error("error closing storage", "error", err)
+		}
+	}()
 
 	// Initialize business metrics (needed before handler and storage metrics adapter)
 	businessMetrics := metrics.NewBusinessMetrics("controller")
 
+	// Latency and error metrics for scraper, textanalyzer and scheduler calls
+	if err := clients.RegisterMetrics(prometheus.DefaultRegisterer); err != nil {
+		logger.Warn("failed to register upstream client metrics", "error", err)
+	}
+
+	// Crawl, scrape outcome and task timeout counters, shared by the handlers and the worker
+	if err := queue.RegisterMetrics(prometheus.DefaultRegisterer); err != nil {
+		logger.Warn("failed to register queue metrics", "error", err)
+	}
+
 	// Set up metrics adapter for storage layer
 	metricsAdapter := storage.NewMetricsAdapter(businessMetrics)
 	store.SetBusinessMetrics(metricsAdapter)
 	logger.Info("storage metrics initialized")
 
+	store.SetMaxRequestVersions(cfg.MaxRequestVersions)
+	store.SetSlowQueryThreshold(time.Duration(cfg.SlowQueryThresholdMS) * time.Millisecond)
+
+	// Runtime settings start from configuration; overrides saved through the admin API win.
+	// Storage, handlers and the worker share one instance so updates apply without a restart.
+	runtimeSettings := settings.New(settings.Values{
+		LinkScoreThreshold:             cfg.LinkScoreThreshold,
+		MaxLinkDepth:                   cfg.MaxLinkDepth,
+		TombstonePeriodLowScore:        cfg.TombstonePeriodLowScore,
+		TombstonePeriodTagBased:        cfg.TombstonePeriodTagBased,
+		TombstonePeriodManual:          cfg.TombstonePeriodManual,
+		SevereQualityThreshold:         cfg.SevereQualityThreshold,
+		StandardQualityThreshold:       cfg.StandardQualityThreshold,
+		TombstonePeriodSevereQuality:   cfg.TombstonePeriodSevereQuality,
+		TombstonePeriodStandardQuality: cfg.TombstonePeriodStandardQuality,
+	})
+	if overrides, err := store.LoadSettingOverrides(); err != nil {
+		logger.Warn("failed to load runtime settings, using configuration", "error", err)
+	} else if _, _, err := runtimeSettings.Update(overrides, nil); err != nil {
+		logger.Warn("ignoring invalid stored runtime settings", "error", err)
+	} else if len(overrides) > 0 {
+		logger.Info("runtime settings loaded", "overridden", runtimeSettings.Overridden())
+	}
+	store.SetSettings(runtimeSettings)
+
+	// URL normalization rules are shared by the URL cache, handlers, worker and storage
+	urlnorm.SetTrackingParams(cfg.TrackingQueryParams)
+	logger.Info("URL normalization initialized", "tracking_params", len(cfg.TrackingQueryParams))
+
+	// Periodic jobs run on loopCtx, which shutdown cancels and waits on before the handler and
+	// storage close, so no pass runs against a closed database
+	loopCtx, cancelLoops := context.WithCancel(context.Background())
+	var loops sync.WaitGroup
+	stopLoops := func() {
+		cancelLoops()
+		loops.Wait()
+	}
+
 	// Initialize database metrics
 	dbMetrics := metrics.NewDatabaseMetrics("controller")
-	go func() {
-		ticker := time.NewTicker(15 * time.Second)
-		defer ticker.Stop()
-		for range ticker.C {
-			dbMetrics.UpdateDBStats(store.DB())
-		}
-	}()
+	runEvery(loopCtx, &loops, 15*time.Second, func(ctx context.Context) {
+		dbMetrics.UpdateDBStats(store.DB())
+	})
 	logger.Info("database metrics initialized")
 
+	// Periodically purge audit entries older than the retention window
+	runEvery(loopCtx, &loops, 1*time.Hour, func(ctx context.Context) {
+		cutoff := time.Now().UTC().Add(-time.Duration(cfg.AuditRetentionDays) * 24 * time.Hour)
+		removed, err := store.DeleteAuditEntriesBefore(cutoff)
+		if err != nil {
+			logger.Warn("failed to purge audit log", "error", err)
+			return
+		}
+		if removed > 0 {
+			logger.Info("purged expired audit entries", "count", removed, "retention_days", cfg.AuditRetentionDays)
+		}
+	})
+	logger.Info("audit log retention initialized", "retention_days", cfg.AuditRetentionDays)
+
 	// Generate mock data if enabled
 	if cfg.GenerateMockData {
 		logger.Info("mock data generation enabled")
-		if err := store.GenerateMockData(); err != nil {
+		if err := store.GenerateMockDataWith(mockDataOptions(cfg)); err != nil {
 			logger.Warn("failed to generate mock data", "error", err)
 		}
 	}
@@ -123,7 +223,8 @@ func main() {
 
 	// Initialize queue client
 	queueClient := queue.NewClient(queue.ClientConfig{
-		RedisAddr: cfg.RedisAddr,
+		RedisAddr:    cfg.RedisAddr,
+		UniqueWindow: time.Duration(cfg.ScrapeUniqueWindowMinutes) * time.Minute,
 	})
 	defer queueClient.Close()
 	logger.Info("queue client initialized", "redis_addr", cfg.RedisAddr)
@@ -147,17 +248,118 @@ func main() {
 		cfg.TombstonePeriodLowScore,
 		cfg.TombstonePeriodManual,
 		businessMetrics,
+		prometheus.DefaultRegisterer,
 	)
+	// Deferred after storage, so the metrics updater has stopped before the database closes
+	defer handler.Close()
+	// Deferred after the handler, so periodic jobs have stopped before either closes
+	defer stopLoops()
+	// Scrape targets and webhook targets are held to the same private-network rules
+	urlGuard := urlguard.New(cfg.AllowPrivateTargets)
+	handler.SetURLGuard(urlGuard)
+	handler.SetSettings(runtimeSettings)
+	handler.SetLogLevel(st.logLevel)
+	handler.SetStatsCacheTTL(time.Duration(cfg.StatsCacheTTLSeconds) * time.Second)
+	handler.SetMaxPageLimit(cfg.MaxPageLimit)
+	handler.SetBulkMaxRequests(cfg.BulkMaxRequests)
+	handler.SetNamespaces(cfg.NamespaceAPIKeys, cfg.PublicNamespace)
+	handler.SetLogSampleEvery(cfg.LogSampleEvery)
+
+	// Webhook subscribers receive events from the handlers and the worker through one pool
+	webhookDispatcher := webhooks.New(store, webhooks.Config{
+		Workers:                cfg.WebhookWorkers,
+		MaxAttempts:            cfg.WebhookMaxAttempts,
+		Timeout:                time.Duration(cfg.WebhookTimeoutSeconds) * time.Second,
+		MaxConsecutiveFailures: cfg.WebhookMaxConsecutiveFailures,
+		Guard:                  urlGuard,
+	}, logger)
+	webhookDispatcher.Start()
+	handler.SetWebhooks(webhookDispatcher)
+	if cfg.MaxQueuedJobs > 0 {
+		handler.SetQueueBackpressure(cfg.MaxQueuedJobs, cfg.BackpressureExemptSingleURL)
+		logger.Info("queue backpressure enabled", "max_queued_jobs", cfg.MaxQueuedJobs, "exempt_single_url", cfg.BackpressureExemptSingleURL)
+	}
+	if cfg.AllowPrivateTargets {
+		logger.Warn("private network scrape targets are allowed; do not enable this in production")
+	}
+	domainPolicy := urlguard.NewDomainPolicy(cfg.DomainAllowlist, cfg.DomainDenylist)
+	handler.SetDomainPolicy(domainPolicy)
+	if domainPolicy.Enabled() {
+		logger.Info("domain policy enabled",
+			"allowlist", domainPolicy.Allowlist(),
+			"denylist", domainPolicy.Denylist(),
+		)
+	}
+	if len(cfg.DomainScoreThresholds) > 0 {
+		handler.SetDomainScoreThresholds(settings.NewDomainThresholds(cfg.DomainScoreThresholds))
+		logger.Info("per-domain link score thresholds enabled", "domains", len(cfg.DomainScoreThresholds))
+	}
+	handler.SetDomainTagIncludeHost(cfg.DomainTagIncludeHost)
+
+	switch cfg.ImageCache {
+	case config.ImageCacheMemory:
+		handler.SetImageCache(imagecache.NewMemory(int64(cfg.ImageCacheMaxMB)<<20), int64(cfg.ImageCacheMaxItemMB)<<20)
+		logger.Info("image cache initialized", "mode", cfg.ImageCache, "max_mb", cfg.ImageCacheMaxMB)
+	case config.ImageCacheDisk:
+		diskCache, err := imagecache.NewDisk(cfg.ImageCacheDir, int64(cfg.ImageCacheMaxMB)<<20)
+		if err != nil {
+			return fmt.Errorf("failed to initialize image cache: %w", err)
+		}
+		handler.SetImageCache(diskCache, int64(cfg.ImageCacheMaxItemMB)<<20)
+		logger.Info("image cache initialized", "mode", cfg.ImageCache, "dir", cfg.ImageCacheDir, "max_mb", cfg.ImageCacheMaxMB)
+	}
+
+	handler.SetArchiveMaxBytes(int64(cfg.ArchiveMaxMB) << 20)
+	handler.SetRawHTMLMaxBytes(int64(cfg.RawHTMLMaxMB) << 20)
+	handler.SetRequireIfMatch(cfg.RequireIfMatch)
+
+	// A broken template stops startup here, naming the file and line, rather than failing pages
+	pageTemplates, err := templates.NewRenderer(cfg.TemplateDir, cfg.TemplateReload)
+	if err != nil {
+		return fmt.Errorf("failed to load page templates: %w", err)
+	}
+	handler.SetPageTemplates(pageTemplates)
+	if cfg.HTMLPolicy == config.HTMLPolicyStrict {
+		handler.SetHTMLSanitizer(sanitize.StrictPolicy())
+	}
+	if cfg.TemplateDir != "" {
+		logger.Info("page templates loaded", "dir", cfg.TemplateDir, "reload", cfg.TemplateReload)
+	}
+
+	if cfg.RespectRobotsTxt {
+		logger.Info("robots.txt checks enabled",
+			"user_agent", cfg.RobotsUserAgent,
+			"cache_ttl_minutes", cfg.RobotsCacheTTLMinutes,
+		)
+	}
 
 	// Initialize queue worker with tombstone configuration
 	worker := queue.NewWorker(
 		queue.WorkerConfig{
-			RedisAddr:               cfg.RedisAddr,
-			Concurrency:             cfg.WorkerConcurrency,
-			LinkScoreThreshold:      cfg.LinkScoreThreshold,
-			MaxLinkDepth:            cfg.MaxLinkDepth,
-			TombstonePeriodLowScore: cfg.TombstonePeriodLowScore,
-			MaxAnalysisWaitMinutes:  cfg.MaxAnalysisWaitMinutes,
+			RedisAddr:                      cfg.RedisAddr,
+			Concurrency:                    cfg.WorkerConcurrency,
+			LinkScoreThreshold:             cfg.LinkScoreThreshold,
+			MaxLinkDepth:                   cfg.MaxLinkDepth,
+			CrawlMaxPages:                  cfg.CrawlMaxPages,
+			TaskTimeout:                    time.Duration(cfg.TaskTimeoutMinutes) * time.Minute,
+			Webhooks:                       webhookDispatcher,
+			LogSampleEvery:                 cfg.LogSampleEvery,
+			TombstonePeriodLowScore:        cfg.TombstonePeriodLowScore,
+			SevereQualityThreshold:         cfg.SevereQualityThreshold,
+			StandardQualityThreshold:       cfg.StandardQualityThreshold,
+			TombstonePeriodSevereQuality:   cfg.TombstonePeriodSevereQuality,
+			TombstonePeriodStandardQuality: cfg.TombstonePeriodStandardQuality,
+			Settings:                       runtimeSettings,
+			MaxAnalysisWaitMinutes:         cfg.MaxAnalysisWaitMinutes,
+			AllowPrivateTargets:            cfg.AllowPrivateTargets,
+			DomainAllowlist:                cfg.DomainAllowlist,
+			DomainDenylist:                 cfg.DomainDenylist,
+			DomainScoreThresholds:          cfg.DomainScoreThresholds,
+			DomainTagIncludeHost:           cfg.DomainTagIncludeHost,
+			RespectRobotsTxt:               cfg.RespectRobotsTxt,
+			RobotsUserAgent:                cfg.RobotsUserAgent,
+			RobotsCacheTTL:                 time.Duration(cfg.RobotsCacheTTLMinutes) * time.Minute,
+			MetricsRegisterer:              prometheus.DefaultRegisterer,
 		},
 		store,
 		scraperClient,
@@ -168,205 +370,122 @@ func main() {
 		handler.PublishDocumentUpdate,            // Pass event publisher for SSE
 		handler.PublishDocumentUpdateWithDetails, // Pass detailed event publisher for lifecycle SSE
 	)
-	logger.Info("queue worker initialized",
-		"concurrency", cfg.WorkerConcurrency,
-		"max_link_depth", cfg.MaxLinkDepth,
-		"max_analysis_wait_minutes", cfg.MaxAnalysisWaitMinutes,
-	)
-
-	// Start worker in background
-	go func() {
-		logger.Info("starting queue worker")
-		if err := worker.Start(); err != nil {
-			logger.Error("queue worker failed", "error", err)
-			os.Exit(1)
-		}
-	}()
-
-	// Setup routes
-	mux := http.NewServeMux()
-	mux.HandleFunc("/health", handler.Health)
-	mux.Handle("/metrics", promhttp.Handler()) // Prometheus metrics endpoint
-	mux.HandleFunc("/api/scrape", handler.ScrapeURL)
-	mux.HandleFunc("/api/analyze", handler.AnalyzeText)
-	mux.HandleFunc("/api/score", handler.ScoreLink)
-	mux.HandleFunc("/api/search", handler.SearchTags)
-	mux.HandleFunc("/api/images/search", handler.SearchImageTags)
-	mux.HandleFunc("/api/requests/filter", handler.FilterRequests)
-	mux.HandleFunc("/api/extract-links", handler.ExtractLinks)
-	mux.HandleFunc("/api/tags/timeline", handler.GetTagTimeline)
-	mux.HandleFunc("/api/requests/", func(w http.ResponseWriter, r *http.Request) {
-		// Redirect /api/requests/filter to dedicated handler
-		if r.URL.Path == "/api/requests/filter" {
-			handler.FilterRequests(w, r)
+	// Periodically hard-delete requests whose soft-delete grace period has elapsed
+	gracePeriod := time.Duration(cfg.DeleteGracePeriodDays) * 24 * time.Hour
+	runEvery(loopCtx, &loops, 1*time.Hour, func(ctx context.Context) {
+		reaped, err := handler.ReapDeletedRequests(ctx, gracePeriod)
+		if err != nil {
+			logger.Warn("failed to reap deleted requests", "error", err)
 			return
 		}
-
-		// Handle /api/requests/timeline-extents
-		if r.URL.Path == "/api/requests/timeline-extents" {
-			handler.GetTimelineExtents(w, r)
-			return
-		}
-
-		// Handle /api/requests/{id}/seo-enabled
-		if len(r.URL.Path) > len("/api/requests/") && r.URL.Path[len(r.URL.Path)-12:] == "/seo-enabled" {
-			if r.Method == http.MethodPut {
-				handler.UpdateSEOEnabled(w, r)
-			} else {
-				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-			}
-			return
-		}
-
-		// Handle /api/requests/{id}/tombstone
-		if len(r.URL.Path) > len("/api/requests/") && r.URL.Path[len(r.URL.Path)-10:] == "/tombstone" {
-			if r.Method == http.MethodPut {
-				handler.TombstoneRequest(w, r)
-			} else if r.Method == http.MethodDelete {
-				handler.UntombstoneRequest(w, r)
-			} else {
-				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-			}
-			return
+		if reaped > 0 {
+			logger.Info("reaped deleted requests", "count", reaped, "grace_period_days", cfg.DeleteGracePeriodDays)
 		}
+	})
 
-		// Handle /api/requests/{id}/tags
-		if len(r.URL.Path) > len("/api/requests/") && r.URL.Path[len(r.URL.Path)-5:] == "/tags" {
-			if r.Method == http.MethodPut {
-				handler.UpdateRequestTags(w, r)
-			} else {
-				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	// Periodically revisit requests whose analysis retrieval timed out
+	if cfg.AnalysisRecoveryIntervalMinutes > 0 {
+		runEvery(loopCtx, &loops, time.Duration(cfg.AnalysisRecoveryIntervalMinutes)*time.Minute, func(ctx context.Context) {
+			if _, err := worker.RecoverTimedOutAnalyses(ctx, cfg.AnalysisRecoveryBatchSize); err != nil {
+				logger.Warn("analysis recovery sweep failed", "error", err)
 			}
-			return
-		}
+		})
+		logger.Info("analysis recovery sweep initialized",
+			"interval_minutes", cfg.AnalysisRecoveryIntervalMinutes,
+			"batch_size", cfg.AnalysisRecoveryBatchSize,
+		)
+	}
 
-		// Handle /api/requests/{id}/stream (SSE endpoint)
-		if len(r.URL.Path) > len("/api/requests/") && r.URL.Path[len(r.URL.Path)-7:] == "/stream" {
-			if r.Method == http.MethodGet {
-				handler.StreamRequestUpdates(w, r)
-			} else {
-				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-			}
-			return
-		}
+	if cfg.BackupDir != "" {
+		handler.SetBackups(cfg.BackupDir, time.Duration(cfg.BackupMinIntervalMinutes)*time.Minute, cfg.BackupRetention)
+	}
 
-		// Handle /api/requests/{id}
-		if r.Method == http.MethodGet {
-			handler.GetRequest(w, r)
-		} else if r.Method == http.MethodDelete {
-			handler.DeleteRequest(w, r)
-		} else {
-			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		}
-	})
-	mux.HandleFunc("/api/requests", handler.ListRequests)
-	mux.HandleFunc("/api/documents/", handler.GetDocumentImages) // Handles /api/documents/{scraper_uuid}/images
-	mux.HandleFunc("/api/images/", func(w http.ResponseWriter, r *http.Request) {
-		// Handle /api/images/{id}/tags
-		if len(r.URL.Path) > len("/api/images/") && r.URL.Path[len(r.URL.Path)-5:] == "/tags" {
-			if r.Method == http.MethodPut {
-				handler.UpdateImageTags(w, r)
-			} else {
-				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-			}
-			return
-		}
+	handler.SetReconcile(cfg.ReconcileBatchSize, cfg.ReconcileUpstreamRate)
+	handler.SetTextDedupWindow(time.Duration(cfg.TextDedupWindowMinutes) * time.Minute)
+	handler.SetBackfills(cfg.BackfillBatchSize, time.Duration(cfg.BackfillPauseMS)*time.Millisecond)
+	if err := handler.ResumeBackfills(); err != nil {
+		logger.Warn("failed to resume backfills", "error", err)
+	}
 
-		// Handle /api/images/{id}/tombstone
-		if len(r.URL.Path) > len("/api/images/") && r.URL.Path[len(r.URL.Path)-10:] == "/tombstone" {
-			if r.Method == http.MethodPut {
-				handler.TombstoneImage(w, r)
-			} else if r.Method == http.MethodDelete {
-				handler.UntombstoneImage(w, r)
-			} else {
-				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	// Periodically re-scrape stored URLs whose content is older than their domain's window
+	if cfg.StaleRescrapeEnabled {
+		handler.SetStaleRescrape(cfg.RescrapeAfter, cfg.RescrapeAfter[config.RescrapeAfterDefault], cfg.StaleRescrapeBatchSize)
+		runEvery(loopCtx, &loops, time.Duration(cfg.StaleRescrapeIntervalMinutes)*time.Minute, func(ctx context.Context) {
+			if _, err := handler.RescrapeStale(ctx, "schedule", false); err != nil {
+				logger.Warn("stale re-scrape pass failed", "error", err)
 			}
-			return
-		}
+		})
+		logger.Info("stale re-scrape scheduler initialized",
+			"interval_minutes", cfg.StaleRescrapeIntervalMinutes,
+			"batch_size", cfg.StaleRescrapeBatchSize,
+			"windows", cfg.RescrapeAfter,
+		)
+	}
 
-		// Handle GET /api/images/{id}
-		if r.Method == http.MethodGet {
-			handler.GetImage(w, r)
-		} else if r.Method == http.MethodDelete {
-			handler.DeleteImage(w, r)
-		} else {
-			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		}
-	})
+	logger.Info("queue worker initialized",
+		"concurrency", cfg.WorkerConcurrency,
+		"max_link_depth", cfg.MaxLinkDepth,
+		"max_analysis_wait_minutes", cfg.MaxAnalysisWaitMinutes,
+	)
 
-	// Async scrape request routes
-	mux.HandleFunc("/api/scrape-requests", func(w http.ResponseWriter, r *http.Request) {
-		if r.Method == http.MethodPost {
-			handler.CreateScrapeRequest(w, r)
-		} else if r.Method == http.MethodGet {
-			handler.ListScrapeRequests(w, r)
-		} else {
-			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	// Background components report fatal errors here; the first one shuts the service down
+	failed := make(chan error, 1)
+	fail := func(err error) {
+		select {
+		case failed <- err:
+		default:
 		}
-	})
+	}
 
-	// Async text analysis request route
-	mux.HandleFunc("/api/analyze-requests", handler.CreateTextAnalysisRequest)
-	mux.HandleFunc("/api/scrape-requests/", func(w http.ResponseWriter, r *http.Request) {
-		// Handle /api/scrape-requests/{id}/retry
-		if len(r.URL.Path) > len("/api/scrape-requests/") && r.URL.Path[len(r.URL.Path)-6:] == "/retry" {
-			handler.RetryScrapeRequest(w, r)
+	// Start the worker once Redis answers. /ready reports 503 until then, while the HTTP
+	// server below already serves health checks.
+	handler.SetReadinessCheck(worker.Ready)
+	workerCtx, cancelWorkerStart := context.WithCancel(context.Background())
+	defer cancelWorkerStart()
+	workerStarting := make(chan struct{})
+	go func() {
+		defer close(workerStarting)
+		logger.Info("starting queue worker")
+		if err := worker.StartWhenReady(workerCtx, queue.DefaultRedisRetry, queueClient.Ping); err != nil {
+			if workerCtx.Err() == nil {
+				fail(fmt.Errorf("queue worker failed to start: %w", err))
+			}
 			return
 		}
+		logger.Info("queue worker started")
+	}()
 
-		// Handle /api/scrape-requests/{id}
-		if r.Method == http.MethodGet {
-			handler.GetScrapeRequest(w, r)
-		} else if r.Method == http.MethodDelete {
-			handler.DeleteScrapeRequest(w, r)
-		} else {
-			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		}
-	})
-
-	// Scheduler routes
-	mux.HandleFunc("/api/scheduler/tasks", func(w http.ResponseWriter, r *http.Request) {
-		if r.Method == http.MethodGet {
-			handler.ListSchedulerTasks(w, r)
-		} else if r.Method == http.MethodPost {
-			handler.CreateSchedulerTask(w, r)
-		} else {
-			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		}
-	})
-	mux.HandleFunc("/api/scheduler/tasks/", func(w http.ResponseWriter, r *http.Request) {
-		if r.Method == http.MethodGet {
-			handler.GetSchedulerTask(w, r)
-		} else if r.Method == http.MethodPut {
-			handler.UpdateSchedulerTask(w, r)
-		} else if r.Method == http.MethodDelete {
-			handler.DeleteSchedulerTask(w, r)
-		} else {
-			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		}
-	})
-
-	// SEO routes (public-facing)
-	mux.HandleFunc("/content/", handler.ServeContent)            // Serve SEO-optimized content pages
-	mux.HandleFunc("/sitemap.xml", handler.ServeSitemap)         // XML sitemap for search engines
-	mux.HandleFunc("/images-sitemap.xml", handler.ServeImageSitemap) // XML image sitemap
-	mux.HandleFunc("/robots.txt", handler.ServeRobotsTxt)        // Robots.txt for crawlers
+	// Setup routes
+	mux := http.NewServeMux()
+	mux.Handle("GET /metrics", promhttp.Handler()) // Prometheus metrics endpoint
+	handler.RegisterRoutes(mux)
 
 	// Setup server with middleware chain (applied bottom-up, executes top-down):
-	// Execution order: CORS -> tracing -> metrics -> logging -> handlers
+	// Execution order: CORS -> tracing -> metrics -> request ID -> logging -> handlers
 	// This ensures tracing creates span BEFORE logging tries to read trace context
 	addr := fmt.Sprintf(":%d", cfg.Port)
-	var httpHandler http.Handler = mux
+	var httpHandler http.Handler = handlers.MethodNotAllowed(mux)
 
 	// Add HTTP request logging (innermost, executes last)
 	httpHandler = logging.HTTPLoggingMiddleware(logger)(httpHandler)
 
-	// Add HTTP metrics middleware
-	httpHandler = metrics.HTTPMiddleware("controller")(httpHandler)
+	// Assign a request ID before logging so both the log line and error bodies carry it
+	httpHandler = logging.RequestIDMiddleware(httpHandler)
+
+	// Add HTTP metrics middleware, labelled by route pattern rather than raw path
+	httpMetrics, err := httpmetrics.Middleware(prometheus.DefaultRegisterer, httpmetrics.Options{
+		Namespace: "controller",
+		Buckets:   cfg.HTTPLatencyBuckets,
+		Route:     httpmetrics.MuxRoute(mux),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to register HTTP metrics: %w", err)
+	}
+	httpHandler = httpMetrics(httpHandler)
 
 	// Wrap with tracing middleware if initialized (executes early to create span)
 	if tp != nil {
-		httpHandler = tracing.HTTPMiddleware("docutab-controller")(httpHandler)
+		httpHandler = tracing.HTTPMiddleware(cfg.TracingServiceName)(httpHandler)
 	}
 
 	// Apply CORS middleware (outermost, executes first)
@@ -377,6 +496,16 @@ func main() {
 		Handler: httpHandler,
 	}
 
+	// Terminate TLS when a certificate is configured; config validation guarantees both files are set
+	var certs *tlsserver.CertReloader
+	if cfg.TLSEnabled() {
+		certs, err = tlsserver.NewCertReloader(cfg.TLSCertFile, cfg.TLSKeyFile)
+		if err != nil {
+			return fmt.Errorf("failed to load TLS certificate %s / %s: %w", cfg.TLSCertFile, cfg.TLSKeyFile, err)
+		}
+		server.TLSConfig = tlsserver.Config(certs)
+	}
+
 	// Setup graceful shutdown
 	shutdown := make(chan os.Signal, 1)
 	signal.Notify(shutdown, os.Interrupt, syscall.SIGTERM)
@@ -391,26 +520,100 @@ func main() {
 			"db_host", cfg.DBHost,
 			"db_name", cfg.DBName,
 			"link_score_threshold", cfg.LinkScoreThreshold,
+			"tls", certs != nil,
 		)
 
-		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			logger.Error("server failed", "error", err)
-			os.Exit(1)
+		// The certificate comes from TLSConfig.GetCertificate, so no files are passed here
+		var serveErr error
+		if certs != nil {
+			serveErr = server.ListenAndServeTLS("", "")
+		} else {
+			serveErr = server.ListenAndServe()
+		}
+		if serveErr != nil && serveErr != http.ErrServerClosed {
+			fail(fmt.Errorf("server failed: %w", serveErr))
 		}
 	}()
 
-	// Wait for shutdown signal
-	<-shutdown
-	logger.Info("shutting down controller service")
+	if certs != nil && cfg.TLSRedirectHTTP {
+		redirectServer := &http.Server{
+			Addr:    fmt.Sprintf(":%d", cfg.TLSRedirectPort),
+			Handler: tlsserver.RedirectHandler(cfg.Port),
+		}
+		go func() {
+			logger.Info("redirecting plain HTTP to https", "port", cfg.TLSRedirectPort)
+			if err := redirectServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				fail(fmt.Errorf("redirect server failed: %w", err))
+			}
+		}()
+	}
+
+	// Profiles and runtime stats get their own listener; config validation keeps it on loopback
+	if cfg.EnablePprof {
+		debugServer := debugserver.NewServer(cfg.PprofAddr, worker)
+		go func() {
+			logger.Warn("pprof debug endpoints enabled", "addr", cfg.PprofAddr)
+			if err := debugServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				logger.Error("debug server failed", "error", err)
+			}
+		}()
+	}
 
-	// Shutdown worker
+	// SIGHUP re-reads the config file and environment and applies LOG_LEVEL, and re-reads
+	// the TLS certificate and key; other settings still need a restart or the admin settings API
+	reload := make(chan os.Signal, 1)
+	signal.Notify(reload, syscall.SIGHUP)
+	go func() {
+		for range reload {
+			reloaded, err := config.LoadFile(st.configPath)
+			if err != nil {
+				logger.Error("failed to reload configuration, keeping current settings", "error", err)
+				continue
+			}
+			if level, err := logging.ParseLevel(reloaded.LogLevel); err == nil {
+				st.logLevel.Set(level)
+			}
+			if certs != nil {
+				if err := certs.Reload(); err != nil {
+					logger.Error("failed to reload TLS certificate, keeping the current one", "error", err)
+				} else {
+					logger.Info("TLS certificate reloaded", "cert_file", cfg.TLSCertFile)
+				}
+			}
+			logger.Info("configuration reloaded", "log_level", logging.LevelName(st.logLevel.Level()))
+		}
+	}()
+
+	// Wait for a shutdown signal or a component that failed
+	var runErr error
+	select {
+	case sig := <-shutdown:
+		logger.Info("shutting down controller service", "signal", sig.String())
+	case runErr = <-failed:
+		logger.Error("shutting down controller service after a failure", "error", runErr)
+	}
+
+	// Stop taking requests and let in-flight ones finish
+	shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), httpShutdownTimeout)
+	defer cancelShutdown()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		logger.Warn("HTTP server did not shut down cleanly", "error", err)
+	}
+
+	// A worker still waiting for Redis gives up; one that started is stopped
+	cancelWorkerStart()
+	<-workerStarting
 	worker.Shutdown()
 	logger.Info("queue worker stopped")
 
-	// Close storage
-	if err := store.Close(); err != nil {
-		logger.Error("error closing storage", "error", err)
-	}
+	webhookDispatcher.Stop()
+	logger.Info("webhook dispatcher stopped")
+
+	stopLoops()
+	logger.Info("periodic jobs stopped")
 
+	// The handler's goroutines stop, then storage, the queue client, the URL cache and the
+	// tracer close in the deferred calls
 	logger.Info("controller service stopped")
+	return runErr
 }
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"text/tabwriter"
+	"time"
+
+	"github.com/docutag/controller/internal/storage"
+)
+
+// runMigrate handles the migrate subcommand: up applies pending migrations, down reverts the newest
+// applied one and status prints every migration with whether it is applied
+func runMigrate(mode, connStr string, out io.Writer) error {
+	db, err := storage.OpenPostgres(connStr)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	switch mode {
+	case "up":
+		return storage.RunPostgresMigrations(db)
+	case "down":
+		migration, err := storage.RollbackPostgresMigration(db)
+		if err != nil {
+			return err
+		}
+		if migration == nil {
+			slog.Default().Info("no migrations to roll back")
+		}
+		return nil
+	case "status":
+		statuses, err := storage.PostgresMigrationStatus(db)
+		if err != nil {
+			return err
+		}
+		return printMigrationStatus(out, statuses)
+	default:
+		return fmt.Errorf("unknown migrate mode %q: use up, down or status", mode)
+	}
+}
+
+// printMigrationStatus writes one line per migration
+func printMigrationStatus(out io.Writer, statuses []storage.MigrationStatus) error {
+	tw := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "VERSION\tNAME\tSTATUS\tAPPLIED AT")
+	for _, st := range statuses {
+		state, appliedAt := "pending", ""
+		if st.Applied {
+			state = "applied"
+			if st.AppliedAt != nil {
+				appliedAt = st.AppliedAt.UTC().Format(time.RFC3339)
+			}
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\n", st.Version, st.Name, state, appliedAt)
+	}
+	return tw.Flush()
+}
+
+// requireMigrated fails when the database has migrations this build would apply, so a
+// deployment with AUTO_MIGRATE=false never changes the schema as a side effect of starting
+func requireMigrated(connStr string) error {
+	db, err := storage.OpenPostgres(connStr)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	statuses, err := storage.PostgresMigrationStatus(db)
+	if err != nil {
+		return err
+	}
+	if pending := storage.PendingMigrations(statuses); pending > 0 {
+		return fmt.Errorf("%d schema migrations are pending and AUTO_MIGRATE is false; run `controller migrate up` first", pending)
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package clients
+
+import (
+	"mime"
+	"net/url"
+	"strings"
+)
+
+// ImageTag is the tag every stored image URL carries, alongside its domain
+const ImageTag = "image"
+
+// imageExtensions are the file extensions IsImageURL treats as images
+var imageExtensions = []string{
+	".jpg", ".jpeg", ".png", ".gif", ".webp",
+	".svg", ".bmp", ".ico", ".tiff", ".tif",
+}
+
+// IsImageURL reports whether the path of rawURL ends in an image file extension
+func IsImageURL(rawURL string) bool {
+	parsedURL, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+
+	// Get the path without query parameters
+	path := strings.ToLower(parsedURL.Path)
+	for _, ext := range imageExtensions {
+		if strings.HasSuffix(path, ext) {
+			return true
+		}
+	}
+	return false
+}
+
+// LocalImageScore is the score used for a URL IsImageURL matches, instead of asking the
+// scraper. Images are never held to the link score threshold, so only the category matters.
+func LocalImageScore(rawURL string) *ScoreResponse {
+	return &ScoreResponse{
+		URL: rawURL,
+		Score: LinkScore{
+			URL:        rawURL,
+			Score:      0,
+			Reason:     "Image URL detected by file extension - scoring skipped",
+			Categories: []string{ImageTag},
+		},
+	}
+}
+
+// IsImage reports whether the scorer classified the URL as an image
+func (r *ScoreResponse) IsImage() bool {
+	for _, category := range r.Score.Categories {
+		if category == ImageTag {
+			return true
+		}
+	}
+	return false
+}
+
+// ContentType returns the media type the scraper reported for the fetched resource in
+// metadata.content_type, lowercased and without parameters, or "" when it reported none
+func (r *ScraperResponse) ContentType() string {
+	value, _ := r.Metadata["content_type"].(string)
+	if value == "" {
+		return ""
+	}
+	mediaType, _, err := mime.ParseMediaType(value)
+	if err != nil {
+		return ""
+	}
+	return mediaType
+}
+
+// IsImage reports whether the fetched resource was an image, which catches image URLs
+// without a file extension such as CDN links
+func (r *ScraperResponse) IsImage() bool {
+	return strings.HasPrefix(r.ContentType(), "image/")
+}
+
+// WithImageTag returns tags with ImageTag first, adding it when missing
+func WithImageTag(tags []string) []string {
+	for _, tag := range tags {
+		if tag == ImageTag {
+			return tags
+		}
+	}
+	return append([]string{ImageTag}, tags...)
+}
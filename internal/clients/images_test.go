@@ -0,0 +1,106 @@
+package clients
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestIsImageURL(t *testing.T) {
+	tests := []struct {
+		name     string
+		url      string
+		expected bool
+	}{
+		// Image URLs
+		{"JPEG image", "https://example.com/photo.jpg", true},
+		{"JPEG uppercase", "https://example.com/photo.JPG", true},
+		{"PNG image", "https://example.com/image.png", true},
+		{"GIF image", "https://example.com/animation.gif", true},
+		{"WebP image", "https://example.com/modern.webp", true},
+		{"SVG image", "https://example.com/vector.svg", true},
+		{"BMP image", "https://example.com/bitmap.bmp", true},
+		{"ICO icon", "https://example.com/favicon.ico", true},
+		{"TIFF image", "https://example.com/scan.tiff", true},
+		{"Image with query", "https://example.com/photo.jpg?size=large", true},
+		{"Image with hash", "https://example.com/photo.png#fragment", true},
+
+		// Non-image URLs
+		{"HTML page", "https://example.com/article.html", false},
+		{"Plain URL", "https://example.com/page", false},
+		{"PDF document", "https://example.com/document.pdf", false},
+		{"Video file", "https://example.com/video.mp4", false},
+		{"JavaScript file", "https://example.com/script.js", false},
+		{"CSS file", "https://example.com/style.css", false},
+		{"Text file", "https://example.com/readme.txt", false},
+		{"Root path", "https://example.com/", false},
+		{"Path with jpg in name", "https://example.com/jpg-guide", false},
+		{"Path with png in dir", "https://example.com/png/article", false},
+
+		// Edge cases
+		{"Invalid URL", "not-a-url", false},
+		{"Empty URL", "", false},
+		{"URL without extension", "https://example.com/resource", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := IsImageURL(tt.url)
+			if result != tt.expected {
+				t.Errorf("IsImageURL(%q) = %v, want %v", tt.url, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestScraperResponseIsImage(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType interface{}
+		want        string
+	}{
+		{"JPEG", "image/jpeg", "image/jpeg"},
+		{"with parameters", "Image/PNG; charset=binary", "image/png"},
+		{"HTML", "text/html; charset=utf-8", "text/html"},
+		{"missing", nil, ""},
+		{"malformed", ";;", ""},
+		{"not a string", 42, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := &ScraperResponse{Metadata: map[string]interface{}{}}
+			if tt.contentType != nil {
+				resp.Metadata["content_type"] = tt.contentType
+			}
+			if got := resp.ContentType(); got != tt.want {
+				t.Errorf("ContentType() = %q, want %q", got, tt.want)
+			}
+			if got, want := resp.IsImage(), strings.HasPrefix(tt.want, "image/"); got != want {
+				t.Errorf("IsImage() = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestLocalImageScore(t *testing.T) {
+	score := LocalImageScore("https://example.com/photo.jpg")
+	if !score.IsImage() {
+		t.Errorf("Expected the local score to classify the URL as an image, got %v", score.Score.Categories)
+	}
+	if score.Score.Score != 0 || score.URL != "https://example.com/photo.jpg" {
+		t.Errorf("Unexpected local score %+v", score)
+	}
+}
+
+func TestWithImageTag(t *testing.T) {
+	if got := WithImageTag([]string{"media"}); !reflect.DeepEqual(got, []string{"image", "media"}) {
+		t.Errorf("Expected image to be prepended, got %v", got)
+	}
+	if got := WithImageTag([]string{"media", "image"}); !reflect.DeepEqual(got, []string{"media", "image"}) {
+		t.Errorf("Expected tags with image to be left alone, got %v", got)
+	}
+	if got := WithImageTag(nil); !reflect.DeepEqual(got, []string{"image"}) {
+		t.Errorf("Expected just the image tag, got %v", got)
+	}
+}
@@ -0,0 +1,130 @@
+package clients
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/docutag/controller/internal/promreg"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Downstream services, used as the "service" metric label
+const (
+	serviceScraper      = "scraper"
+	serviceTextAnalyzer = "textanalyzer"
+	serviceScheduler    = "scheduler"
+)
+
+// statusClassNetwork labels errors where no HTTP response was received
+const statusClassNetwork = "network"
+
+// ScoreBucketNone is the score_bucket label of a URL that was not scored, such as an image
+// or a scrape that failed or was answered before scoring
+const ScoreBucketNone = "none"
+
+// scoreBuckets are the score_bucket labels, one per 0.2 of link score
+var scoreBuckets = []string{"0.0-0.2", "0.2-0.4", "0.4-0.6", "0.6-0.8", "0.8-1.0"}
+
+// ScoreBucket returns the score_bucket metric label of a link score. Scores outside 0-1 fall
+// in the nearest bucket.
+func ScoreBucket(score float64) string {
+	if math.IsNaN(score) {
+		return ScoreBucketNone
+	}
+	i := int(score * float64(len(scoreBuckets)))
+	return scoreBuckets[max(0, min(i, len(scoreBuckets)-1))]
+}
+
+// upstreamMetrics are updated for every call the clients make to a downstream service
+type upstreamMetrics struct {
+	duration   *prometheus.HistogramVec
+	errors     *prometheus.CounterVec
+	linkScores prometheus.Histogram
+}
+
+func newUpstreamMetrics() *upstreamMetrics {
+	return &upstreamMetrics{
+		duration: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Name:    "controller_upstream_request_duration_seconds",
+				Help:    "Time until a downstream service responded, by service and operation",
+				Buckets: []float64{0.01, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60, 120, 300, 600},
+			},
+			[]string{"service", "operation"},
+		),
+		errors: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "controller_upstream_request_errors_total",
+				Help: "Failed downstream calls, by service, operation and status class (4xx, 5xx or network)",
+			},
+			[]string{"service", "operation", "status_class"},
+		),
+		linkScores: prometheus.NewHistogram(
+			prometheus.HistogramOpts{
+				Name:    "controller_link_score_observations",
+				Help:    "Link scores returned by the scraper, in the score buckets 0.0-0.2 to 0.8-1.0",
+				Buckets: []float64{0.2, 0.4, 0.6, 0.8, 1},
+			},
+		),
+	}
+}
+
+// upstream holds the collectors in use. Until RegisterMetrics is called they record into
+// collectors no registry exposes, so clients work the same in tests.
+var upstream atomic.Pointer[upstreamMetrics]
+
+func init() {
+	upstream.Store(newUpstreamMetrics())
+}
+
+// RegisterMetrics registers the upstream client metrics with reg. Collectors that reg
+// already has are reused, so calling it again (e.g. after tests swap the default registry)
+// is safe.
+func RegisterMetrics(reg prometheus.Registerer) error {
+	m := newUpstreamMetrics()
+	duration, err := promreg.RegisterOrReuse(reg, m.duration)
+	if err != nil {
+		return err
+	}
+	errorsTotal, err := promreg.RegisterOrReuse(reg, m.errors)
+	if err != nil {
+		return err
+	}
+	linkScores, err := promreg.RegisterOrReuse(reg, m.linkScores)
+	if err != nil {
+		return err
+	}
+	upstream.Store(&upstreamMetrics{duration: duration, errors: errorsTotal, linkScores: linkScores})
+	return nil
+}
+
+// doUpstream sends req and records how long the service took to respond and, for transport
+// errors and 4xx/5xx responses, an error. Every client request goes through here, so retry
+// and circuit-breaker metrics belong here too.
+func doUpstream(client *http.Client, service, operation string, req *http.Request) (*http.Response, error) {
+	m := upstream.Load()
+	start := time.Now()
+	resp, err := client.Do(req)
+	m.duration.WithLabelValues(service, operation).Observe(time.Since(start).Seconds())
+
+	switch {
+	case err != nil:
+		m.errors.WithLabelValues(service, operation, statusClassNetwork).Inc()
+	case resp.StatusCode >= http.StatusBadRequest:
+		m.errors.WithLabelValues(service, operation, statusClass(resp.StatusCode)).Inc()
+	}
+	return resp, err
+}
+
+// observeLinkScore records a score the scraper returned
+func observeLinkScore(score float64) {
+	upstream.Load().linkScores.Observe(score)
+}
+
+// statusClass returns "4xx" for 404 and so on
+func statusClass(status int) string {
+	return strconv.Itoa(status/100) + "xx"
+}
@@ -0,0 +1,119 @@
+package clients
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestUpstreamMetrics(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	if err := RegisterMetrics(reg); err != nil {
+		t.Fatalf("RegisterMetrics failed: %v", err)
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/score":
+			w.Write([]byte(`{"url":"https://example.com","score":{"score":0.8}}`))
+		default:
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}))
+	defer server.Close()
+
+	client := NewScraperClient(server.URL)
+	if _, err := client.ScoreLink(context.Background(), "https://example.com"); err != nil {
+		t.Fatalf("ScoreLink failed: %v", err)
+	}
+	if _, err := client.Scrape(context.Background(), "https://example.com"); err == nil {
+		t.Fatal("expected scrape error for 502")
+	}
+	if err := NewTextAnalyzerClient("http://127.0.0.1:1").DeleteAnalysis(context.Background(), "a1"); err == nil {
+		t.Fatal("expected network error")
+	}
+
+	// The registry exposes the collectors the clients record into; successful calls add no error series
+	if got, err := testutil.GatherAndCount(reg, "controller_upstream_request_errors_total"); err != nil || got != 2 {
+		t.Errorf("expected 2 exposed error series, got %d (err %v)", got, err)
+	}
+
+	m := upstream.Load()
+	if got := testutil.CollectAndCount(m.duration, "controller_upstream_request_duration_seconds"); got != 3 {
+		t.Errorf("expected 3 duration series, got %d", got)
+	}
+	if got := testutil.ToFloat64(m.errors.WithLabelValues(serviceScraper, "scrape", "5xx")); got != 1 {
+		t.Errorf("expected one 5xx scrape error, got %v", got)
+	}
+	if got := testutil.ToFloat64(m.errors.WithLabelValues(serviceTextAnalyzer, "delete_analysis", statusClassNetwork)); got != 1 {
+		t.Errorf("expected one network error, got %v", got)
+	}
+
+	// The score ScoreLink returned falls in the 0.6-0.8 bucket
+	want := `
+# HELP controller_link_score_observations Link scores returned by the scraper, in the score buckets 0.0-0.2 to 0.8-1.0
+# TYPE controller_link_score_observations histogram
+controller_link_score_observations_bucket{le="0.2"} 0
+controller_link_score_observations_bucket{le="0.4"} 0
+controller_link_score_observations_bucket{le="0.6"} 0
+controller_link_score_observations_bucket{le="0.8"} 1
+controller_link_score_observations_bucket{le="1"} 1
+controller_link_score_observations_bucket{le="+Inf"} 1
+controller_link_score_observations_sum 0.8
+controller_link_score_observations_count 1
+`
+	if err := testutil.GatherAndCompare(reg, strings.NewReader(want), "controller_link_score_observations"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestRegisterMetricsTwice(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	if err := RegisterMetrics(reg); err != nil {
+		t.Fatalf("first RegisterMetrics failed: %v", err)
+	}
+	first := upstream.Load()
+
+	if err := RegisterMetrics(reg); err != nil {
+		t.Fatalf("second RegisterMetrics failed: %v", err)
+	}
+	if upstream.Load().duration != first.duration {
+		t.Error("expected the already registered histogram to be reused")
+	}
+}
+
+func TestScoreBucket(t *testing.T) {
+	tests := []struct {
+		score float64
+		want  string
+	}{
+		{0, "0.0-0.2"},
+		{0.19, "0.0-0.2"},
+		{0.2, "0.2-0.4"},
+		{0.6, "0.6-0.8"},
+		{0.8, "0.8-1.0"},
+		{1, "0.8-1.0"},
+		{1.5, "0.8-1.0"},
+		{-0.1, "0.0-0.2"},
+		{math.NaN(), ScoreBucketNone},
+	}
+	for _, tt := range tests {
+		if got := ScoreBucket(tt.score); got != tt.want {
+			t.Errorf("ScoreBucket(%v) = %q, want %q", tt.score, got, tt.want)
+		}
+	}
+}
+
+func TestStatusClass(t *testing.T) {
+	for status, want := range map[int]string{200: "2xx", 404: "4xx", 503: "5xx"} {
+		if got := statusClass(status); got != want {
+			t.Errorf("statusClass(%d) = %q, want %q", status, got, want)
+		}
+	}
+}
@@ -7,6 +7,9 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
 	"time"
 
 	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
@@ -36,6 +39,16 @@ type Task struct {
 	NextRunAt   *time.Time `json:"next_run_at,omitempty"`
 }
 
+// SchedulerError is returned when the scheduler service responds with an unexpected status
+type SchedulerError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *SchedulerError) Error() string {
+	return fmt.Sprintf("scheduler service returned status %d: %s", e.StatusCode, e.Body)
+}
+
 // NewSchedulerClient creates a new scheduler client
 func NewSchedulerClient(baseURL string) *SchedulerClient {
 	return &SchedulerClient{
@@ -47,24 +60,64 @@ func NewSchedulerClient(baseURL string) *SchedulerClient {
 	}
 }
 
-// ListTasks retrieves all tasks from the scheduler
-func (c *SchedulerClient) ListTasks(ctx context.Context) ([]*Task, error) {
+// ListTasksOptions filters and paginates ListTasks
+type ListTasksOptions struct {
+	Limit  int    // Maximum tasks to return; 0 returns all
+	Offset int    // Tasks to skip
+	Status string // "enabled" or "disabled"; empty matches every task
+	Name   string // Case-insensitive substring of the task name
+}
+
+// TaskList is one page of scheduler tasks
+type TaskList struct {
+	Tasks          []*Task `json:"tasks"`
+	Total          int     `json:"total"` // Tasks matching the filters, before pagination
+	Count          int     `json:"count"` // Tasks in this page
+	Limit          int     `json:"limit,omitempty"`
+	Offset         int     `json:"offset"`
+	ClientFiltered bool    `json:"client_filtered,omitempty"` // The scheduler ignored the filters, so the controller applied them
+}
+
+// ListTasks retrieves tasks from the scheduler, passing the options through as query parameters.
+// Older schedulers return every task as a bare array; in that case, or when the scheduler returns
+// tasks that do not match the options, the filters and pagination are applied here instead.
+func (c *SchedulerClient) ListTasks(ctx context.Context, opts ListTasksOptions) (*TaskList, error) {
 	tracer := otel.Tracer("controller")
 	ctx, span := tracer.Start(ctx, "scheduler.ListTasks")
 	defer span.End()
 
-	span.SetAttributes(attribute.String("http.method", "GET"))
+	span.SetAttributes(
+		attribute.String("http.method", "GET"),
+		attribute.Int("scheduler.limit", opts.Limit),
+		attribute.Int("scheduler.offset", opts.Offset),
+	)
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet,
-		fmt.Sprintf("%s/api/tasks", c.baseURL),
-		nil)
+	query := url.Values{}
+	if opts.Limit > 0 {
+		query.Set("limit", strconv.Itoa(opts.Limit))
+	}
+	if opts.Offset > 0 {
+		query.Set("offset", strconv.Itoa(opts.Offset))
+	}
+	if opts.Status != "" {
+		query.Set("status", opts.Status)
+	}
+	if opts.Name != "" {
+		query.Set("name", opts.Name)
+	}
+	listURL := fmt.Sprintf("%s/api/tasks", c.baseURL)
+	if len(query) > 0 {
+		listURL += "?" + query.Encode()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, listURL, nil)
 	if err != nil {
 		span.RecordError(err)
 		span.SetStatus(codes.Error, "failed to create request")
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := doUpstream(c.httpClient, serviceScheduler, "list_tasks", req)
 	if err != nil {
 		span.RecordError(err)
 		span.SetStatus(codes.Error, "failed to send request")
@@ -83,19 +136,128 @@ func (c *SchedulerClient) ListTasks(ctx context.Context) ([]*Task, error) {
 
 	if resp.StatusCode != http.StatusOK {
 		span.SetStatus(codes.Error, fmt.Sprintf("status %d", resp.StatusCode))
-		return nil, fmt.Errorf("scheduler service returned status %d: %s", resp.StatusCode, string(body))
+		return nil, &SchedulerError{StatusCode: resp.StatusCode, Body: string(body)}
 	}
 
-	var tasks []*Task
-	if err := json.Unmarshal(body, &tasks); err != nil {
+	list, err := decodeTaskList(body, opts)
+	if err != nil {
 		span.RecordError(err)
 		span.SetStatus(codes.Error, "failed to unmarshal response")
 		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
 	}
 
-	span.SetAttributes(attribute.Int("scheduler.task_count", len(tasks)))
+	span.SetAttributes(
+		attribute.Int("scheduler.task_count", list.Count),
+		attribute.Bool("scheduler.client_filtered", list.ClientFiltered),
+	)
 	span.SetStatus(codes.Ok, "success")
-	return tasks, nil
+	return list, nil
+}
+
+// decodeTaskList accepts either a bare task array or a {"tasks": [...], "total": n, ...} envelope
+func decodeTaskList(body []byte, opts ListTasksOptions) (*TaskList, error) {
+	trimmed := bytes.TrimSpace(body)
+	if len(trimmed) > 0 && trimmed[0] == '[' {
+		var tasks []*Task
+		if err := json.Unmarshal(trimmed, &tasks); err != nil {
+			return nil, err
+		}
+		return applyTaskOptions(tasks, opts), nil
+	}
+
+	var envelope struct {
+		Tasks  []*Task `json:"tasks"`
+		Total  *int    `json:"total"`
+		Count  *int    `json:"count"`
+		Limit  *int    `json:"limit"`
+		Offset *int    `json:"offset"`
+	}
+	if err := json.Unmarshal(trimmed, &envelope); err != nil {
+		return nil, err
+	}
+
+	// A page that is too long or contains non-matching tasks means the filters were ignored
+	ignored := opts.Limit > 0 && len(envelope.Tasks) > opts.Limit
+	for _, task := range envelope.Tasks {
+		if !taskMatches(task, opts) {
+			ignored = true
+			break
+		}
+	}
+	if ignored {
+		return applyTaskOptions(envelope.Tasks, opts), nil
+	}
+
+	list := &TaskList{
+		Tasks:  envelope.Tasks,
+		Total:  len(envelope.Tasks),
+		Count:  len(envelope.Tasks),
+		Limit:  opts.Limit,
+		Offset: opts.Offset,
+	}
+	if list.Tasks == nil {
+		list.Tasks = []*Task{}
+	}
+	if envelope.Total != nil {
+		list.Total = *envelope.Total
+	}
+	if envelope.Count != nil {
+		list.Count = *envelope.Count
+	}
+	if envelope.Limit != nil {
+		list.Limit = *envelope.Limit
+	}
+	if envelope.Offset != nil {
+		list.Offset = *envelope.Offset
+	}
+	return list, nil
+}
+
+// applyTaskOptions filters and paginates a full task list locally
+func applyTaskOptions(tasks []*Task, opts ListTasksOptions) *TaskList {
+	matched := make([]*Task, 0, len(tasks))
+	for _, task := range tasks {
+		if taskMatches(task, opts) {
+			matched = append(matched, task)
+		}
+	}
+
+	page := matched
+	if opts.Offset >= len(page) {
+		page = page[:0]
+	} else {
+		page = page[opts.Offset:]
+	}
+	if opts.Limit > 0 && len(page) > opts.Limit {
+		page = page[:opts.Limit]
+	}
+
+	return &TaskList{
+		Tasks:          page,
+		Total:          len(matched),
+		Count:          len(page),
+		Limit:          opts.Limit,
+		Offset:         opts.Offset,
+		ClientFiltered: opts != (ListTasksOptions{}),
+	}
+}
+
+// taskMatches reports whether a task satisfies the status and name filters
+func taskMatches(task *Task, opts ListTasksOptions) bool {
+	switch opts.Status {
+	case "enabled":
+		if !task.Enabled {
+			return false
+		}
+	case "disabled":
+		if task.Enabled {
+			return false
+		}
+	}
+	if opts.Name != "" && !strings.Contains(strings.ToLower(task.Name), strings.ToLower(opts.Name)) {
+		return false
+	}
+	return true
 }
 
 // GetTask retrieves a specific task by ID
@@ -118,7 +280,7 @@ func (c *SchedulerClient) GetTask(ctx context.Context, id int64) (*Task, error)
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := doUpstream(c.httpClient, serviceScheduler, "get_task", req)
 	if err != nil {
 		span.RecordError(err)
 		span.SetStatus(codes.Error, "failed to send request")
@@ -137,7 +299,7 @@ func (c *SchedulerClient) GetTask(ctx context.Context, id int64) (*Task, error)
 
 	if resp.StatusCode != http.StatusOK {
 		span.SetStatus(codes.Error, fmt.Sprintf("status %d", resp.StatusCode))
-		return nil, fmt.Errorf("scheduler service returned status %d: %s", resp.StatusCode, string(body))
+		return nil, &SchedulerError{StatusCode: resp.StatusCode, Body: string(body)}
 	}
 
 	var task Task
@@ -180,7 +342,7 @@ func (c *SchedulerClient) CreateTask(ctx context.Context, task *Task) (*Task, er
 	}
 	req.Header.Set("Content-Type", "application/json")
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := doUpstream(c.httpClient, serviceScheduler, "create_task", req)
 	if err != nil {
 		span.RecordError(err)
 		span.SetStatus(codes.Error, "failed to send request")
@@ -199,7 +361,7 @@ func (c *SchedulerClient) CreateTask(ctx context.Context, task *Task) (*Task, er
 
 	if resp.StatusCode != http.StatusCreated {
 		span.SetStatus(codes.Error, fmt.Sprintf("status %d", resp.StatusCode))
-		return nil, fmt.Errorf("scheduler service returned status %d: %s", resp.StatusCode, string(body))
+		return nil, &SchedulerError{StatusCode: resp.StatusCode, Body: string(body)}
 	}
 
 	var createdTask Task
@@ -243,7 +405,7 @@ func (c *SchedulerClient) UpdateTask(ctx context.Context, id int64, task *Task)
 	}
 	req.Header.Set("Content-Type", "application/json")
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := doUpstream(c.httpClient, serviceScheduler, "update_task", req)
 	if err != nil {
 		span.RecordError(err)
 		span.SetStatus(codes.Error, "failed to send request")
@@ -262,7 +424,7 @@ func (c *SchedulerClient) UpdateTask(ctx context.Context, id int64, task *Task)
 
 	if resp.StatusCode != http.StatusOK {
 		span.SetStatus(codes.Error, fmt.Sprintf("status %d", resp.StatusCode))
-		return nil, fmt.Errorf("scheduler service returned status %d: %s", resp.StatusCode, string(body))
+		return nil, &SchedulerError{StatusCode: resp.StatusCode, Body: string(body)}
 	}
 
 	var updatedTask Task
@@ -296,7 +458,7 @@ func (c *SchedulerClient) DeleteTask(ctx context.Context, id int64) error {
 		return fmt.Errorf("failed to create request: %w", err)
 	}
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := doUpstream(c.httpClient, serviceScheduler, "delete_task", req)
 	if err != nil {
 		span.RecordError(err)
 		span.SetStatus(codes.Error, "failed to send request")
@@ -309,9 +471,83 @@ func (c *SchedulerClient) DeleteTask(ctx context.Context, id int64) error {
 	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
 		span.SetStatus(codes.Error, fmt.Sprintf("status %d", resp.StatusCode))
-		return fmt.Errorf("scheduler service returned status %d: %s", resp.StatusCode, string(body))
+		return &SchedulerError{StatusCode: resp.StatusCode, Body: string(body)}
 	}
 
 	span.SetStatus(codes.Ok, "success")
 	return nil
 }
+
+// TaskActionResult is the scheduler's response to a task action such as run, pause or resume
+type TaskActionResult struct {
+	StatusCode int
+	Body       json.RawMessage
+}
+
+// RunTask asks the scheduler to execute a task immediately
+func (c *SchedulerClient) RunTask(ctx context.Context, id int64) (*TaskActionResult, error) {
+	return c.taskAction(ctx, "scheduler.RunTask", id, "run")
+}
+
+// PauseTask stops a task from running on its schedule until it is resumed
+func (c *SchedulerClient) PauseTask(ctx context.Context, id int64) (*TaskActionResult, error) {
+	return c.taskAction(ctx, "scheduler.PauseTask", id, "pause")
+}
+
+// ResumeTask puts a paused task back on its schedule
+func (c *SchedulerClient) ResumeTask(ctx context.Context, id int64) (*TaskActionResult, error) {
+	return c.taskAction(ctx, "scheduler.ResumeTask", id, "resume")
+}
+
+// taskAction posts to /api/tasks/{id}/{action}. Any 2xx response is a success and is
+// returned with its status code so callers can pass it through unchanged.
+func (c *SchedulerClient) taskAction(ctx context.Context, spanName string, id int64, action string) (*TaskActionResult, error) {
+	tracer := otel.Tracer("controller")
+	ctx, span := tracer.Start(ctx, spanName)
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.Int64("scheduler.task_id", id),
+		attribute.String("scheduler.action", action),
+		attribute.String("http.method", "POST"),
+	)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost,
+		fmt.Sprintf("%s/api/tasks/%d/%s", c.baseURL, id, action),
+		nil)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to create request")
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := doUpstream(c.httpClient, serviceScheduler, action+"_task", req)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to send request")
+		return nil, fmt.Errorf("failed to send request to scheduler: %w", err)
+	}
+	defer resp.Body.Close()
+
+	span.SetAttributes(attribute.Int("http.status_code", resp.StatusCode))
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to read response")
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		span.SetStatus(codes.Error, fmt.Sprintf("status %d", resp.StatusCode))
+		return nil, &SchedulerError{StatusCode: resp.StatusCode, Body: string(body)}
+	}
+
+	result := &TaskActionResult{StatusCode: resp.StatusCode}
+	if len(body) > 0 && json.Valid(body) {
+		result.Body = body
+	}
+
+	span.SetStatus(codes.Ok, "success")
+	return result, nil
+}
@@ -4,9 +4,12 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
+	"strconv"
 	"time"
 
 	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
@@ -80,7 +83,7 @@ func (c *ScraperClient) Scrape(ctx context.Context, url string) (*ScraperRespons
 	}
 	req.Header.Set("Content-Type", "application/json")
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := doUpstream(c.httpClient, serviceScraper, "scrape", req)
 	if err != nil {
 		span.RecordError(err)
 		span.SetStatus(codes.Error, "failed to send request")
@@ -129,17 +132,32 @@ type ImageInfo struct {
 
 // ImageSearchRequest represents a request to search images by tags
 type ImageSearchRequest struct {
-	Tags []string `json:"tags"`
+	Tags              []string `json:"tags"`
+	Limit             int      `json:"limit,omitempty"`
+	Offset            int      `json:"offset,omitempty"`
+	IncludeTombstoned bool     `json:"include_tombstoned,omitempty"`
 }
 
 // ImageSearchResponse represents the response from image search
 type ImageSearchResponse struct {
-	Images []*ImageInfo `json:"images"`
-	Count  int          `json:"count"`
+	Images         []*ImageInfo `json:"images"`
+	Count          int          `json:"count"`           // Images in this page
+	Total          int          `json:"total,omitempty"` // Images matching before pagination
+	Limit          int          `json:"limit,omitempty"`
+	Offset         int          `json:"offset"`
+	ClientFiltered bool         `json:"client_filtered,omitempty"` // The scraper ignored the options, so the controller applied them
+}
+
+// ImageListOptions paginates image listings and controls whether tombstoned images are included
+type ImageListOptions struct {
+	Limit             int // Maximum images to return; 0 returns all
+	Offset            int // Images to skip
+	IncludeTombstoned bool
 }
 
 // SearchImagesByTags searches for images by tags using the scraper service
-func (c *ScraperClient) SearchImagesByTags(ctx context.Context, tags []string) (*ImageSearchResponse, error) {
+// The options are sent to the scraper and applied locally if it ignores them.
+func (c *ScraperClient) SearchImagesByTags(ctx context.Context, tags []string, opts ImageListOptions) (*ImageSearchResponse, error) {
 	tracer := otel.Tracer("controller")
 	ctx, span := tracer.Start(ctx, "scraper.SearchImagesByTags")
 	defer span.End()
@@ -149,7 +167,12 @@ func (c *ScraperClient) SearchImagesByTags(ctx context.Context, tags []string) (
 		attribute.String("http.method", "POST"),
 	)
 
-	reqBody := ImageSearchRequest{Tags: tags}
+	reqBody := ImageSearchRequest{
+		Tags:              tags,
+		Limit:             opts.Limit,
+		Offset:            opts.Offset,
+		IncludeTombstoned: opts.IncludeTombstoned,
+	}
 	jsonData, err := json.Marshal(reqBody)
 	if err != nil {
 		span.RecordError(err)
@@ -167,7 +190,7 @@ func (c *ScraperClient) SearchImagesByTags(ctx context.Context, tags []string) (
 	}
 	req.Header.Set("Content-Type", "application/json")
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := doUpstream(c.httpClient, serviceScraper, "search_images_by_tags", req)
 	if err != nil {
 		span.RecordError(err)
 		span.SetStatus(codes.Error, "failed to send request")
@@ -189,20 +212,24 @@ func (c *ScraperClient) SearchImagesByTags(ctx context.Context, tags []string) (
 		return nil, fmt.Errorf("scraper service returned status %d: %s", resp.StatusCode, string(body))
 	}
 
-	var searchResp ImageSearchResponse
-	if err := json.Unmarshal(body, &searchResp); err != nil {
+	searchResp, err := decodeImageList(body, opts)
+	if err != nil {
 		span.RecordError(err)
 		span.SetStatus(codes.Error, "failed to unmarshal response")
 		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
 	}
 
-	span.SetAttributes(attribute.Int("scraper.image_count", searchResp.Count))
+	span.SetAttributes(
+		attribute.Int("scraper.image_count", searchResp.Count),
+		attribute.Bool("scraper.client_filtered", searchResp.ClientFiltered),
+	)
 	span.SetStatus(codes.Ok, "success")
-	return &searchResp, nil
+	return searchResp, nil
 }
 
-// GetImagesByScrapeID retrieves images associated with a specific scrape ID
-func (c *ScraperClient) GetImagesByScrapeID(ctx context.Context, scrapeID string) (*ImageSearchResponse, error) {
+// GetImagesByScrapeID retrieves images associated with a specific scrape ID. The options are
+// sent as query parameters and applied locally if the scraper ignores them.
+func (c *ScraperClient) GetImagesByScrapeID(ctx context.Context, scrapeID string, opts ImageListOptions) (*ImageSearchResponse, error) {
 	tracer := otel.Tracer("controller")
 	ctx, span := tracer.Start(ctx, "scraper.GetImagesByScrapeID")
 	defer span.End()
@@ -212,16 +239,29 @@ func (c *ScraperClient) GetImagesByScrapeID(ctx context.Context, scrapeID string
 		attribute.String("http.method", "GET"),
 	)
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet,
-		fmt.Sprintf("%s/api/scrapes/%s/images", c.baseURL, scrapeID),
-		nil)
+	query := url.Values{}
+	if opts.Limit > 0 {
+		query.Set("limit", strconv.Itoa(opts.Limit))
+	}
+	if opts.Offset > 0 {
+		query.Set("offset", strconv.Itoa(opts.Offset))
+	}
+	if opts.IncludeTombstoned {
+		query.Set("include_tombstoned", "true")
+	}
+	listURL := fmt.Sprintf("%s/api/scrapes/%s/images", c.baseURL, scrapeID)
+	if len(query) > 0 {
+		listURL += "?" + query.Encode()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, listURL, nil)
 	if err != nil {
 		span.RecordError(err)
 		span.SetStatus(codes.Error, "failed to create request")
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := doUpstream(c.httpClient, serviceScraper, "get_images_by_scrape_id", req)
 	if err != nil {
 		span.RecordError(err)
 		span.SetStatus(codes.Error, "failed to send request")
@@ -243,16 +283,82 @@ func (c *ScraperClient) GetImagesByScrapeID(ctx context.Context, scrapeID string
 		return nil, fmt.Errorf("scraper service returned status %d: %s", resp.StatusCode, string(body))
 	}
 
-	var searchResp ImageSearchResponse
-	if err := json.Unmarshal(body, &searchResp); err != nil {
+	searchResp, err := decodeImageList(body, opts)
+	if err != nil {
 		span.RecordError(err)
 		span.SetStatus(codes.Error, "failed to unmarshal response")
 		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
 	}
 
-	span.SetAttributes(attribute.Int("scraper.image_count", searchResp.Count))
+	span.SetAttributes(
+		attribute.Int("scraper.image_count", searchResp.Count),
+		attribute.Bool("scraper.client_filtered", searchResp.ClientFiltered),
+	)
 	span.SetStatus(codes.Ok, "success")
-	return &searchResp, nil
+	return searchResp, nil
+}
+
+// decodeImageList reads an image listing and applies opts locally when the scraper did not.
+// A scraper that paginates reports "total"; without it the response is the full list.
+func decodeImageList(body []byte, opts ImageListOptions) (*ImageSearchResponse, error) {
+	var envelope struct {
+		Images []*ImageInfo `json:"images"`
+		Total  *int         `json:"total"`
+	}
+	if err := json.Unmarshal(body, &envelope); err != nil {
+		return nil, err
+	}
+
+	if envelope.Total == nil || (opts.Limit > 0 && len(envelope.Images) > opts.Limit) {
+		return applyImageOptions(envelope.Images, opts), nil
+	}
+
+	// The scraper paginated; drop any tombstoned images it still returned
+	images, dropped := filterTombstoned(envelope.Images, opts)
+	return &ImageSearchResponse{
+		Images:         images,
+		Count:          len(images),
+		Total:          *envelope.Total - dropped,
+		Limit:          opts.Limit,
+		Offset:         opts.Offset,
+		ClientFiltered: dropped > 0,
+	}, nil
+}
+
+// applyImageOptions filters and paginates a full image list locally
+func applyImageOptions(images []*ImageInfo, opts ImageListOptions) *ImageSearchResponse {
+	matched, dropped := filterTombstoned(images, opts)
+
+	page := matched
+	if opts.Offset >= len(page) {
+		page = page[:0]
+	} else {
+		page = page[opts.Offset:]
+	}
+	if opts.Limit > 0 && len(page) > opts.Limit {
+		page = page[:opts.Limit]
+	}
+
+	return &ImageSearchResponse{
+		Images:         page,
+		Count:          len(page),
+		Total:          len(matched),
+		Limit:          opts.Limit,
+		Offset:         opts.Offset,
+		ClientFiltered: dropped > 0 || len(page) != len(matched),
+	}
+}
+
+// filterTombstoned removes tombstoned images unless opts includes them, returning how many were removed
+func filterTombstoned(images []*ImageInfo, opts ImageListOptions) ([]*ImageInfo, int) {
+	kept := make([]*ImageInfo, 0, len(images))
+	for _, image := range images {
+		if image.TombstoneDatetime != nil && !opts.IncludeTombstoned {
+			continue
+		}
+		kept = append(kept, image)
+	}
+	return kept, len(images) - len(kept)
 }
 
 // GetImageByID retrieves a single image by ID from the scraper service
@@ -275,7 +381,7 @@ func (c *ScraperClient) GetImageByID(ctx context.Context, imageID string) (*Imag
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := doUpstream(c.httpClient, serviceScraper, "get_image", req)
 	if err != nil {
 		span.RecordError(err)
 		span.SetStatus(codes.Error, "failed to send request")
@@ -292,6 +398,10 @@ func (c *ScraperClient) GetImageByID(ctx context.Context, imageID string) (*Imag
 		return nil, fmt.Errorf("failed to read response body: %w", err)
 	}
 
+	if resp.StatusCode == http.StatusNotFound {
+		span.SetStatus(codes.Error, "image not found")
+		return nil, fmt.Errorf("%w: %s", ErrImageNotFound, imageID)
+	}
 	if resp.StatusCode != http.StatusOK {
 		span.SetStatus(codes.Error, fmt.Sprintf("status %d", resp.StatusCode))
 		return nil, fmt.Errorf("scraper service returned status %d: %s", resp.StatusCode, string(body))
@@ -308,6 +418,66 @@ func (c *ScraperClient) GetImageByID(ctx context.Context, imageID string) (*Imag
 	return &image, nil
 }
 
+// ErrImageNotFound is returned when the scraper has no image with the requested ID
+var ErrImageNotFound = errors.New("image not found")
+
+// ImageContent is an image's bytes streamed from the scraper. The caller must close Body.
+type ImageContent struct {
+	Body          io.ReadCloser
+	ContentType   string
+	ContentLength int64 // -1 when the scraper did not send a length
+}
+
+// GetImageContent streams an image's file from the scraper service without buffering it
+func (c *ScraperClient) GetImageContent(ctx context.Context, imageID string) (*ImageContent, error) {
+	tracer := otel.Tracer("controller")
+	ctx, span := tracer.Start(ctx, "scraper.GetImageContent")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("scraper.image_id", imageID),
+		attribute.String("http.method", "GET"),
+	)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet,
+		fmt.Sprintf("%s/api/images/%s/file", c.baseURL, url.PathEscape(imageID)),
+		nil)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to create request")
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := doUpstream(c.httpClient, serviceScraper, "get_image_content", req)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to send request")
+		return nil, fmt.Errorf("failed to send request to scraper: %w", err)
+	}
+
+	span.SetAttributes(attribute.Int("http.status_code", resp.StatusCode))
+
+	if resp.StatusCode == http.StatusNotFound {
+		resp.Body.Close()
+		span.SetStatus(codes.Error, "image not found")
+		return nil, fmt.Errorf("%w: %s", ErrImageNotFound, imageID)
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		resp.Body.Close()
+		span.SetStatus(codes.Error, fmt.Sprintf("status %d", resp.StatusCode))
+		return nil, fmt.Errorf("scraper service returned status %d: %s", resp.StatusCode, string(body))
+	}
+
+	span.SetAttributes(attribute.Int64("http.response_content_length", resp.ContentLength))
+	span.SetStatus(codes.Ok, "success")
+	return &ImageContent{
+		Body:          resp.Body,
+		ContentType:   resp.Header.Get("Content-Type"),
+		ContentLength: resp.ContentLength,
+	}, nil
+}
+
 // LinkScore represents a scored link with quality assessment
 type LinkScore struct {
 	URL                 string   `json:"url"`
@@ -358,7 +528,7 @@ func (c *ScraperClient) ScoreLink(ctx context.Context, url string) (*ScoreRespon
 	}
 	req.Header.Set("Content-Type", "application/json")
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := doUpstream(c.httpClient, serviceScraper, "score_link", req)
 	if err != nil {
 		span.RecordError(err)
 		span.SetStatus(codes.Error, "request failed")
@@ -389,6 +559,7 @@ func (c *ScraperClient) ScoreLink(ctx context.Context, url string) (*ScoreRespon
 		attribute.Float64("scraper.score", scoreResp.Score.Score),
 		attribute.Bool("scraper.is_recommended", scoreResp.Score.IsRecommended),
 	)
+	observeLinkScore(scoreResp.Score.Score)
 	span.SetStatus(codes.Ok, "success")
 	return &scoreResp, nil
 }
@@ -434,7 +605,7 @@ func (c *ScraperClient) ExtractLinks(ctx context.Context, url string) (*ExtractL
 	}
 	req.Header.Set("Content-Type", "application/json")
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := doUpstream(c.httpClient, serviceScraper, "extract_links", req)
 	if err != nil {
 		span.RecordError(err)
 		span.SetStatus(codes.Error, "failed to send request")
@@ -468,6 +639,84 @@ func (c *ScraperClient) ExtractLinks(ctx context.Context, url string) (*ExtractL
 	return &extractResp, nil
 }
 
+// ErrPeekUnsupported is returned by Peek when the scraper has no metadata-only endpoint
+var ErrPeekUnsupported = errors.New("scraper does not support peek")
+
+// PeekResponse is the page metadata the scraper reads without a full scrape
+type PeekResponse struct {
+	URL         string `json:"url"`
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description,omitempty"`
+	ContentType string `json:"content_type,omitempty"`
+}
+
+// Peek fetches a page's title, description and content type without scraping or storing it.
+// Scrapers without the endpoint answer 404 or 405, reported as ErrPeekUnsupported.
+func (c *ScraperClient) Peek(ctx context.Context, url string) (*PeekResponse, error) {
+	tracer := otel.Tracer("controller")
+	ctx, span := tracer.Start(ctx, "scraper.Peek")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("scraper.url", url),
+		attribute.String("http.method", "POST"),
+	)
+
+	jsonData, err := json.Marshal(ScraperRequest{URL: url})
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to marshal request")
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost,
+		fmt.Sprintf("%s/api/peek", c.baseURL),
+		bytes.NewBuffer(jsonData))
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to create request")
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := doUpstream(c.httpClient, serviceScraper, "peek", req)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to send request")
+		return nil, fmt.Errorf("failed to send request to scraper: %w", err)
+	}
+	defer resp.Body.Close()
+
+	span.SetAttributes(attribute.Int("http.status_code", resp.StatusCode))
+
+	if resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusMethodNotAllowed {
+		span.SetStatus(codes.Ok, "unsupported")
+		return nil, ErrPeekUnsupported
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to read response")
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		span.SetStatus(codes.Error, fmt.Sprintf("status %d", resp.StatusCode))
+		return nil, fmt.Errorf("scraper service returned status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var peekResp PeekResponse
+	if err := json.Unmarshal(body, &peekResp); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to unmarshal response")
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	span.SetStatus(codes.Ok, "success")
+	return &peekResp, nil
+}
+
 // DeleteScrape deletes a scrape by ID
 func (c *ScraperClient) DeleteScrape(ctx context.Context, scrapeID string) error {
 	tracer := otel.Tracer("controller")
@@ -488,7 +737,7 @@ func (c *ScraperClient) DeleteScrape(ctx context.Context, scrapeID string) error
 		return fmt.Errorf("failed to create request: %w", err)
 	}
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := doUpstream(c.httpClient, serviceScraper, "delete_scrape", req)
 	if err != nil {
 		span.RecordError(err)
 		span.SetStatus(codes.Error, "failed to send request")
@@ -508,6 +757,49 @@ func (c *ScraperClient) DeleteScrape(ctx context.Context, scrapeID string) error
 	return nil
 }
 
+// ScrapeExists reports whether the scraper still has a scrape, without fetching it
+func (c *ScraperClient) ScrapeExists(ctx context.Context, scrapeID string) (bool, error) {
+	tracer := otel.Tracer("controller")
+	ctx, span := tracer.Start(ctx, "scraper.ScrapeExists")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("scraper.scrape_id", scrapeID),
+		attribute.String("http.method", "HEAD"),
+	)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead,
+		fmt.Sprintf("%s/api/scrapes/%s", c.baseURL, scrapeID),
+		nil)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to create request")
+		return false, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := doUpstream(c.httpClient, serviceScraper, "scrape_exists", req)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to send request")
+		return false, fmt.Errorf("failed to send request to scraper: %w", err)
+	}
+	defer resp.Body.Close()
+
+	span.SetAttributes(attribute.Int("http.status_code", resp.StatusCode))
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		span.SetStatus(codes.Ok, "not found")
+		return false, nil
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		span.SetStatus(codes.Ok, "success")
+		return true, nil
+	default:
+		span.SetStatus(codes.Error, fmt.Sprintf("status %d", resp.StatusCode))
+		return false, fmt.Errorf("scraper service returned status %d", resp.StatusCode)
+	}
+}
+
 // DeleteImage deletes an image by ID
 func (c *ScraperClient) DeleteImage(ctx context.Context, imageID string) error {
 	tracer := otel.Tracer("controller")
@@ -528,7 +820,7 @@ func (c *ScraperClient) DeleteImage(ctx context.Context, imageID string) error {
 		return fmt.Errorf("failed to create request: %w", err)
 	}
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := doUpstream(c.httpClient, serviceScraper, "delete_image", req)
 	if err != nil {
 		span.RecordError(err)
 		span.SetStatus(codes.Error, "failed to send request")
@@ -568,7 +860,7 @@ func (c *ScraperClient) TombstoneImage(ctx context.Context, imageID string) erro
 		return fmt.Errorf("failed to create request: %w", err)
 	}
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := doUpstream(c.httpClient, serviceScraper, "tombstone_image", req)
 	if err != nil {
 		span.RecordError(err)
 		span.SetStatus(codes.Error, "failed to send request")
@@ -608,7 +900,7 @@ func (c *ScraperClient) UntombstoneImage(ctx context.Context, imageID string) er
 		return fmt.Errorf("failed to create request: %w", err)
 	}
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := doUpstream(c.httpClient, serviceScraper, "untombstone_image", req)
 	if err != nil {
 		span.RecordError(err)
 		span.SetStatus(codes.Error, "failed to send request")
@@ -686,7 +978,7 @@ func (c *ScraperClient) UpdateImageTags(ctx context.Context, imageID string, tag
 	}
 	req.Header.Set("Content-Type", "application/json")
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := doUpstream(c.httpClient, serviceScraper, "update_image_tags", req)
 	if err != nil {
 		span.RecordError(err)
 		span.SetStatus(codes.Error, "failed to send request")
@@ -3,9 +3,11 @@ package clients
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 )
 
 func TestScraperClient_Scrape(t *testing.T) {
@@ -212,6 +214,89 @@ func TestScraperClient_ExtractLinks(t *testing.T) {
 	}
 }
 
+func TestScraperClient_Peek(t *testing.T) {
+	tests := []struct {
+		name           string
+		mockStatusCode int
+		wantErr        error
+		expectError    bool
+	}{
+		{name: "metadata returned", mockStatusCode: http.StatusOK},
+		{name: "endpoint missing", mockStatusCode: http.StatusNotFound, wantErr: ErrPeekUnsupported, expectError: true},
+		{name: "method not allowed", mockStatusCode: http.StatusMethodNotAllowed, wantErr: ErrPeekUnsupported, expectError: true},
+		{name: "server error", mockStatusCode: http.StatusInternalServerError, expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/api/peek" || r.Method != http.MethodPost {
+					t.Errorf("Expected POST /api/peek, got %s %s", r.Method, r.URL.Path)
+				}
+				w.WriteHeader(tt.mockStatusCode)
+				json.NewEncoder(w).Encode(PeekResponse{URL: "https://example.com", Title: "Example", Description: "An example page", ContentType: "text/html"})
+			}))
+			defer server.Close()
+
+			result, err := NewScraperClient(server.URL).Peek(context.Background(), "https://example.com")
+			if tt.expectError {
+				if err == nil {
+					t.Fatal("Expected error but got none")
+				}
+				if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+					t.Errorf("Expected %v, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if result.Title != "Example" || result.Description != "An example page" || result.ContentType != "text/html" {
+				t.Errorf("Unexpected metadata: %+v", result)
+			}
+		})
+	}
+}
+
+func TestScraperClient_ScrapeExists(t *testing.T) {
+	tests := []struct {
+		name           string
+		mockStatusCode int
+		want           bool
+		expectError    bool
+	}{
+		{name: "scrape kept", mockStatusCode: http.StatusOK, want: true},
+		{name: "scrape gone", mockStatusCode: http.StatusNotFound, want: false},
+		{name: "server error", mockStatusCode: http.StatusInternalServerError, expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/api/scrapes/scrape-123" || r.Method != http.MethodHead {
+					t.Errorf("Expected HEAD /api/scrapes/scrape-123, got %s %s", r.Method, r.URL.Path)
+				}
+				w.WriteHeader(tt.mockStatusCode)
+			}))
+			defer server.Close()
+
+			exists, err := NewScraperClient(server.URL).ScrapeExists(context.Background(), "scrape-123")
+			if tt.expectError {
+				if err == nil {
+					t.Fatal("Expected error but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if exists != tt.want {
+				t.Errorf("Expected exists %v, got %v", tt.want, exists)
+			}
+		})
+	}
+}
+
 func TestScraperClient_SearchImagesByTags(t *testing.T) {
 	tests := []struct {
 		name           string
@@ -283,7 +368,7 @@ func TestScraperClient_SearchImagesByTags(t *testing.T) {
 			defer server.Close()
 
 			client := NewScraperClient(server.URL)
-			result, err := client.SearchImagesByTags(context.Background(), tt.tags)
+			result, err := client.SearchImagesByTags(context.Background(), tt.tags, ImageListOptions{})
 
 			if tt.expectError {
 				if err == nil {
@@ -373,7 +458,7 @@ func TestScraperClient_GetImagesByScrapeID(t *testing.T) {
 			defer server.Close()
 
 			client := NewScraperClient(server.URL)
-			result, err := client.GetImagesByScrapeID(context.Background(), tt.scrapeID)
+			result, err := client.GetImagesByScrapeID(context.Background(), tt.scrapeID, ImageListOptions{})
 
 			if tt.expectError {
 				if err == nil {
@@ -397,3 +482,49 @@ func TestScraperClient_GetImagesByScrapeID(t *testing.T) {
 	}
 }
 
+
+func TestScraperClient_ImageListOptions(t *testing.T) {
+	tombstoned := time.Now()
+	all := []*ImageInfo{{ID: "a"}, {ID: "b", TombstoneDatetime: &tombstoned}, {ID: "c"}, {ID: "d"}}
+
+	var gotQuery string
+	var gotSearch ImageSearchRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// An older scraper: ignores the options and returns every image without a total
+		if r.Method == http.MethodPost {
+			json.NewDecoder(r.Body).Decode(&gotSearch)
+		} else {
+			gotQuery = r.URL.RawQuery
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"images": all, "count": len(all)})
+	}))
+	defer server.Close()
+
+	client := NewScraperClient(server.URL)
+	opts := ImageListOptions{Limit: 2, Offset: 1}
+
+	result, err := client.GetImagesByScrapeID(context.Background(), "scrape-1", opts)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if gotQuery != "limit=2&offset=1" {
+		t.Errorf("Expected options in the query string, got %q", gotQuery)
+	}
+	if !result.ClientFiltered || result.Total != 3 || result.Count != 2 {
+		t.Errorf("Expected local pagination over 3 live images, got %+v", result)
+	}
+	if result.Images[0].ID != "c" || result.Images[1].ID != "d" {
+		t.Errorf("Expected images c and d, got %s and %s", result.Images[0].ID, result.Images[1].ID)
+	}
+
+	result, err = client.SearchImagesByTags(context.Background(), []string{"x"}, ImageListOptions{IncludeTombstoned: true})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !gotSearch.IncludeTombstoned {
+		t.Error("Expected include_tombstoned in the search body")
+	}
+	if result.Total != 4 || result.ClientFiltered {
+		t.Errorf("Expected every image when tombstoned images are included, got %+v", result)
+	}
+}
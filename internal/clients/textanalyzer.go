@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -117,7 +118,7 @@ func (c *TextAnalyzerClient) EnqueueAnalysis(ctx context.Context, text, original
 	}
 	req.Header.Set("Content-Type", "application/json")
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := doUpstream(c.httpClient, serviceTextAnalyzer, "enqueue_analysis", req)
 	if err != nil {
 		span.RecordError(err)
 		span.SetStatus(codes.Error, "failed to send request")
@@ -186,6 +187,10 @@ type AnalysisJobResult struct {
 	} `json:"analysis,omitempty"` // Analysis result with metadata when completed
 }
 
+// ErrAnalysisJobNotFound is returned when the text analyzer no longer knows an analysis job,
+// e.g. after its queue was flushed
+var ErrAnalysisJobNotFound = errors.New("analysis job not found")
+
 // GetAnalysisResult retrieves the result of a previously enqueued analysis job
 func (c *TextAnalyzerClient) GetAnalysisResult(ctx context.Context, jobID string) (*AnalysisJobResult, error) {
 	tracer := otel.Tracer("controller")
@@ -206,7 +211,7 @@ func (c *TextAnalyzerClient) GetAnalysisResult(ctx context.Context, jobID string
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := doUpstream(c.httpClient, serviceTextAnalyzer, "get_analysis_result", req)
 	if err != nil {
 		span.RecordError(err)
 		span.SetStatus(codes.Error, "failed to send request")
@@ -223,6 +228,10 @@ func (c *TextAnalyzerClient) GetAnalysisResult(ctx context.Context, jobID string
 		return nil, fmt.Errorf("failed to read response body: %w", err)
 	}
 
+	if resp.StatusCode == http.StatusNotFound {
+		span.SetStatus(codes.Error, "analysis job not found")
+		return nil, fmt.Errorf("%w: %s", ErrAnalysisJobNotFound, jobID)
+	}
 	if resp.StatusCode != http.StatusOK {
 		span.SetStatus(codes.Error, fmt.Sprintf("status %d", resp.StatusCode))
 		return nil, fmt.Errorf("text analyzer service returned status %d: %s", resp.StatusCode, string(body))
@@ -262,7 +271,7 @@ func (c *TextAnalyzerClient) DeleteAnalysis(ctx context.Context, analysisID stri
 		return fmt.Errorf("failed to create request: %w", err)
 	}
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := doUpstream(c.httpClient, serviceTextAnalyzer, "delete_analysis", req)
 	if err != nil {
 		span.RecordError(err)
 		span.SetStatus(codes.Error, "failed to send request")
@@ -3,6 +3,7 @@ package clients
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -219,3 +220,29 @@ func TestTextAnalyzerClient_NetworkError(t *testing.T) {
 		t.Error("Expected network error but got none")
 	}
 }
+
+func TestTextAnalyzerClient_GetAnalysisResultNotFound(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/jobs/known" {
+			json.NewEncoder(w).Encode(AnalysisJobResult{JobID: "known", Status: "processing"})
+			return
+		}
+		http.Error(w, "job not found", http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	client := NewTextAnalyzerClient(server.URL)
+
+	_, err := client.GetAnalysisResult(context.Background(), "flushed")
+	if !errors.Is(err, ErrAnalysisJobNotFound) {
+		t.Errorf("Expected ErrAnalysisJobNotFound, got %v", err)
+	}
+
+	result, err := client.GetAnalysisResult(context.Background(), "known")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if result.Status != "processing" {
+		t.Errorf("Expected status processing, got %s", result.Status)
+	}
+}
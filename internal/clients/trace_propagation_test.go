@@ -168,7 +168,7 @@ func TestSchedulerTracePropagation(t *testing.T) {
 	ctx, span := tracer.Start(ctx, "test.listTasks")
 	defer span.End()
 
-	_, err := client.ListTasks(ctx)
+	_, err := client.ListTasks(ctx, ListTasksOptions{})
 	if err != nil {
 		t.Fatalf("ListTasks failed: %v", err)
 	}
@@ -2,64 +2,249 @@ package config
 
 import (
 	"fmt"
+	"math"
+	"net"
+	"net/url"
 	"os"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
+
+	"github.com/docutag/controller/internal/httpmetrics"
+	"github.com/docutag/controller/internal/robots"
+	"github.com/docutag/controller/internal/settings"
+	"github.com/docutag/controller/internal/storage"
+	"github.com/docutag/controller/internal/telemetry"
+	"github.com/docutag/controller/internal/urlguard"
+	"github.com/docutag/controller/internal/urlnorm"
+	"github.com/docutag/controller/pkg/logging"
+	"go.yaml.in/yaml/v2"
 )
 
 // Config holds all configuration for the controller service
 type Config struct {
-	ScraperBaseURL      string
-	TextAnalyzerBaseURL string
-	SchedulerBaseURL    string
-	Port                int
-	DBHost              string  // PostgreSQL host
-	DBPort              int     // PostgreSQL port
-	DBUser              string  // PostgreSQL user
-	DBPassword          string  // PostgreSQL password
-	DBName              string  // PostgreSQL database name
-	LinkScoreThreshold  float64 // Minimum score for link recommendation (0.0-1.0)
-	GenerateMockData    bool    // Generate 6 months of mock historical data on startup (~600 documents)
-	WebInterfaceURL     string  // URL for the web interface (for footer links on static pages)
-	RedisAddr              string // Redis address for queue backend
-	WorkerConcurrency      int    // Number of concurrent workers for processing tasks
-	MaxLinkDepth           int    // Maximum depth for link extraction (0 = no links, 1 = extract only from root URL)
-	MaxAnalysisWaitMinutes int    // Maximum minutes to wait for analysis retrieval (0 = use default 60, can be set to 2 for tests)
+	ScraperBaseURL         string  `yaml:"scraper_base_url"`
+	TextAnalyzerBaseURL    string  `yaml:"textanalyzer_base_url"`
+	SchedulerBaseURL       string  `yaml:"scheduler_base_url"`
+	Port                   int     `yaml:"controller_port"`
+	DBHost                 string  `yaml:"db_host"`                   // PostgreSQL host
+	DBPort                 int     `yaml:"db_port"`                   // PostgreSQL port
+	DBUser                 string  `yaml:"db_user"`                   // PostgreSQL user
+	DBPassword             string  `yaml:"db_password"`               // PostgreSQL password
+	DBName                 string  `yaml:"db_name"`                   // PostgreSQL database name
+	AutoMigrate            bool    `yaml:"auto_migrate"`              // Apply pending migrations at startup; when false, startup fails until they are applied (default: true)
+	LinkScoreThreshold     float64 `yaml:"link_score_threshold"`      // Minimum score for link recommendation (0.0-1.0)
+	GenerateMockData       bool    `yaml:"generate_mock_data"`        // Generate mock historical data on startup when the database is empty (default: false)
+	MockDataCount          int     `yaml:"mock_data_count"`           // Requests generated as mock data (default: 600)
+	MockDataDays           int     `yaml:"mock_data_days"`            // Days the mock requests are spread over (default: 180)
+	MockDataSeed           int64   `yaml:"mock_data_seed"`            // Seed that makes mock data reproducible; 0 picks one from the clock (default: 0)
+	MockDataForce          bool    `yaml:"mock_data_force"`           // Generate mock data even when the database has requests (default: false)
+	WebInterfaceURL        string  `yaml:"web_interface_url"`         // URL for the web interface (for footer links on static pages)
+	RedisAddr              string  `yaml:"redis_addr"`                // Redis address for queue backend
+	WorkerConcurrency      int     `yaml:"worker_concurrency"`        // Number of concurrent workers for processing tasks
+	MaxLinkDepth           int     `yaml:"max_link_depth"`            // Maximum depth for link extraction (0 = no links, 1 = extract only from root URL)
+	MaxAnalysisWaitMinutes int     `yaml:"max_analysis_wait_minutes"` // Maximum minutes to wait for analysis retrieval (0 = use default 60, can be set to 2 for tests)
+	TaskTimeoutMinutes     int     `yaml:"task_timeout_minutes"`      // Minutes one queue task may run before its job fails with a task timeout (0 = unbounded, default: 15)
+
+	// Duplicate enqueue protection
+	ScrapeUniqueWindowMinutes int `yaml:"scrape_unique_window_minutes"` // Minutes a normalized URL stays claimed by the job that enqueued it (0 disables, default: 10)
+	TextDedupWindowMinutes    int `yaml:"text_dedup_window_minutes"`    // Minutes a submitted text is answered with its existing request instead of being analyzed again (0 disables, default: 1440)
+
+	// Crawl budget
+	CrawlMaxPages int `yaml:"crawl_max_pages"` // Pages one crawl may queue across all depths unless the request sets max_pages (0 = unlimited, default: 1000)
+
+	// Queue backpressure
+	MaxQueuedJobs               int  `yaml:"max_queued_jobs"`                // Reject scrape submissions with 503 once this many jobs are queued (0 disables, default: 0)
+	BackpressureExemptSingleURL bool `yaml:"backpressure_exempt_single_url"` // Keep accepting single-URL submissions without extract_links while saturated (default: false)
+
+	// Analysis recovery sweep for requests whose analysis retrieval timed out
+	AnalysisRecoveryIntervalMinutes int `yaml:"analysis_recovery_interval_minutes"` // Minutes between sweeps (0 disables, default: 30)
+	AnalysisRecoveryBatchSize       int `yaml:"analysis_recovery_batch_size"`       // Requests checked per sweep (default: 50)
 
 	// Tombstone configuration
-	TombstoneTags           []string // Tags that trigger auto-tombstone (default: low-quality,sparse-content)
-	TombstonePeriodLowScore int      // Days until deletion for low-score URLs (default: 30)
-	TombstonePeriodTagBased int      // Days until deletion for tagged content (default: 90)
-	TombstonePeriodManual   int      // Days until deletion for manual tombstones (default: 90)
+	TombstoneTags           []string `yaml:"tombstone_tags"`             // Tags that trigger auto-tombstone (default: low-quality,sparse-content)
+	TombstonePeriodLowScore int      `yaml:"tombstone_period_low_score"` // Days until deletion for low-score URLs (default: 30)
+	TombstonePeriodTagBased int      `yaml:"tombstone_period_tag_based"` // Days until deletion for tagged content (default: 90)
+	TombstonePeriodManual   int      `yaml:"tombstone_period_manual"`    // Days until deletion for manual tombstones (default: 90)
+
+	// Quality tombstone configuration, applied when analysis returns a quality score.
+	// Setting both thresholds to 0 disables quality tombstoning.
+	SevereQualityThreshold         float64 `yaml:"severe_quality_threshold"`          // Scores below this get the severe period and SEO disabled (default: 0.25)
+	StandardQualityThreshold       float64 `yaml:"standard_quality_threshold"`        // Scores below this get the standard period (default: 0.35)
+	TombstonePeriodSevereQuality   int     `yaml:"tombstone_period_severe_quality"`   // Days until deletion for severe quality issues (default: 7)
+	TombstonePeriodStandardQuality int     `yaml:"tombstone_period_standard_quality"` // Days until deletion for standard quality issues (default: 30)
+
+	// Audit configuration
+	AuditRetentionDays int `yaml:"audit_retention_days"` // Days to keep audit log entries (default: 365)
+
+	// Soft delete configuration
+	DeleteGracePeriodDays int `yaml:"delete_grace_period_days"` // Days a deleted request can be restored before it is hard-deleted (default: 7)
+
+	// Versioning configuration
+	MaxRequestVersions int `yaml:"max_request_versions"` // Snapshots kept per request before the oldest are pruned (default: 5)
+
+	// Dashboard statistics
+	StatsCacheTTLSeconds int `yaml:"stats_cache_ttl_seconds"` // Seconds GET /api/v1/stats reuses its last result (0 disables caching, default: 30)
+
+	// API pagination
+	MaxPageLimit int `yaml:"max_page_limit"` // Largest limit list endpoints return in one page; larger values are clamped (0 uses the default of 500)
+
+	// Bulk request actions
+	BulkMaxRequests int `yaml:"bulk_max_requests"` // Most requests one bulk tombstone or delete may affect; larger selections are refused (0 uses the default of 1000)
+
+	// Storage diagnostics
+	SlowQueryThresholdMS int `yaml:"slow_query_threshold_ms"` // Storage calls slower than this are logged as warnings (0 disables the log, default: 500)
+
+	// HTTP metrics
+	HTTPLatencyBuckets []float64 `yaml:"http_latency_buckets"` // Buckets of controller_http_request_duration_seconds in seconds, to line up with SLOs (default: 0.005 to 10)
+
+	// URL normalization configuration
+	TrackingQueryParams []string `yaml:"tracking_query_params"` // Query parameters stripped when normalizing URLs; "utm_*" style prefixes allowed
+
+	// Scrape target safety
+	AllowPrivateTargets bool `yaml:"allow_private_targets"` // Allow scraping localhost/private/link-local targets (development only, default: false)
+
+	// Domain policy ("*.example.com" wildcards; the denylist wins; both empty allows every domain)
+	DomainAllowlist []string `yaml:"domain_allowlist"` // Only these domains may be scraped or crawled
+	DomainDenylist  []string `yaml:"domain_denylist"`  // These domains are never scraped or crawled
+
+	// Per-domain link score thresholds, keyed by hostname without "www." (e.g. ourblog.com=0.1)
+	DomainScoreThresholds map[string]float64 `yaml:"domain_score_thresholds"`
+
+	// Domain tags
+	DomainTagIncludeHost bool `yaml:"domain_tag_include_host"` // Tag scraped requests with their full host as well as their registrable domain (default: false)
+
+	// Stale content re-scrape: refresh stored URL documents older than their domain's window
+	StaleRescrapeEnabled         bool                     `yaml:"stale_rescrape_enabled"`          // Run re-scrape passes in the background (default: false)
+	RescrapeAfter                map[string]time.Duration `yaml:"rescrape_after"`                  // Freshness window per domain; "default" covers every other domain (default: default=720h)
+	StaleRescrapeIntervalMinutes int                      `yaml:"stale_rescrape_interval_minutes"` // Minutes between background passes (default: 60)
+	StaleRescrapeBatchSize       int                      `yaml:"stale_rescrape_batch_size"`       // Re-scrapes one pass may queue (default: 50)
+
+	// Orphan reconciliation (POST /api/admin/reconcile and the reconcile command)
+	ReconcileBatchSize    int     `yaml:"reconcile_batch_size"`    // Rows read per query; 0 uses the default (default: 200)
+	ReconcileUpstreamRate float64 `yaml:"reconcile_upstream_rate"` // Upstream existence checks per second; 0 uses the default (default: 5)
+
+	// Column backfills (/api/admin/backfills and the backfill-effective-dates command)
+	BackfillBatchSize int `yaml:"backfill_batch_size"` // Requests read and updated per transaction; 0 uses the default (default: 500)
+	BackfillPauseMS   int `yaml:"backfill_pause_ms"`   // Sleep between batches; 0 uses the default (default: 100)
+
+	// Webhook delivery; subscriptions are managed through /api/v1/webhooks
+	WebhookWorkers                int `yaml:"webhook_workers"`                  // Deliveries made at once; 0 uses the default (default: 4)
+	WebhookMaxAttempts            int `yaml:"webhook_max_attempts"`             // Tries per delivery, including the first; 0 uses the default (default: 3)
+	WebhookTimeoutSeconds         int `yaml:"webhook_timeout_seconds"`          // Limit on each delivery attempt; 0 uses the default (default: 10)
+	WebhookMaxConsecutiveFailures int `yaml:"webhook_max_consecutive_failures"` // Failed deliveries in a row that disable a webhook; 0 never disables (default: 10)
+
+	// Namespaces: API callers only see documents and scrape jobs in their own namespace
+	NamespaceAPIKeys map[string]string `yaml:"namespace_api_keys"` // API key -> namespace; a mapped key cannot act in any other namespace
+	PublicNamespace  string            `yaml:"public_namespace"`   // Namespace served by the SEO pages and sitemap; empty means "default" (default: default)
+
+	// robots.txt
+	RespectRobotsTxt      bool   `yaml:"respect_robots_txt"`       // Skip queued scrapes that robots.txt disallows (default: false)
+	RobotsUserAgent       string `yaml:"robots_user_agent"`        // User agent matched against robots.txt groups (default: DocuTagBot)
+	RobotsCacheTTLMinutes int    `yaml:"robots_cache_ttl_minutes"` // Minutes each host's robots.txt is cached (default: 360)
+
+	// Image byte cache for GET /api/v1/images/{id}/content
+	ImageCache          string `yaml:"image_cache"`             // "memory", "disk" or "none" (default: memory)
+	ImageCacheDir       string `yaml:"image_cache_dir"`         // Directory for the disk cache (required when ImageCache is "disk")
+	ImageCacheMaxMB     int    `yaml:"image_cache_max_mb"`      // Total size of cached images (default: 128)
+	ImageCacheMaxItemMB int    `yaml:"image_cache_max_item_mb"` // Largest single image cached; bigger images are only streamed (default: 5)
+
+	// Request archives (GET /api/v1/requests/{id}/archive)
+	ArchiveMaxMB int `yaml:"archive_max_mb"` // Largest uncompressed archive, bigger ones get 413; 0 uses the default (default: 100)
+
+	// Raw HTML (GET /api/v1/requests/{id}/content?raw_html=true)
+	RawHTMLMaxMB int `yaml:"raw_html_max_mb"` // Largest decompressed raw HTML served, bigger ones get 413; 0 uses the default (default: 10)
+
+	// Optimistic concurrency on request tag and SEO updates
+	RequireIfMatch bool `yaml:"require_if_match"` // Reject updates without If-Match with 428; when false they overwrite unconditionally (default: false)
+
+	// SEO content page templates
+	TemplateDir    string `yaml:"template_dir"`    // Directory holding content.html and its partials; empty uses the built-in template
+	TemplateReload bool   `yaml:"template_reload"` // Parse the templates again on every page view, for developing them (default: false)
+	HTMLPolicy     string `yaml:"html_policy"`     // Sanitization of scraped HTML on content pages: "default" or "strict" (default: default)
+
+	// Database backups written by POST /api/admin/backup
+	BackupDir                string `yaml:"backup_dir"`                  // Directory snapshots are written to; empty disables backups
+	BackupMinIntervalMinutes int    `yaml:"backup_min_interval_minutes"` // Refuse a backup this many minutes after the newest one; 0 never refuses (default: 60)
+	BackupRetention          int    `yaml:"backup_retention"`            // Snapshots kept; older ones are deleted after each backup (default: 7)
+
+	// Tracing
+	TracingEnabled     bool    `yaml:"tracing_enabled"`      // Record and export traces; off installs a no-op tracer (default: true)
+	TracingSampleRatio float64 `yaml:"tracing_sample_ratio"` // Fraction of new traces recorded, 0.0-1.0; traces started upstream follow their parent (default: 1.0)
+	OTLPEndpoint       string  `yaml:"otlp_endpoint"`        // OTLP collector endpoint; empty keeps OTEL_EXPORTER_OTLP_ENDPOINT or the exporter default
+	TracingServiceName string  `yaml:"tracing_service_name"` // Service name traces are reported under (default: docutab-controller)
+
+	// Logging
+	LogLevel  string `yaml:"log_level"`  // debug, info, warn or error (default: info); changeable at runtime
+	LogFormat string `yaml:"log_format"` // json or text (default: json)
+	// After the first few, one in this many repetitive Info lines (queued crawl children, filtered links,
+	// URL cache hits) is logged at Info and the rest at Debug; 0 or 1 logs them all (default: 50)
+	LogSampleEvery int `yaml:"log_sample_every"`
+
+	// TLS termination; set both files to serve https on CONTROLLER_PORT
+	TLSCertFile     string `yaml:"tls_cert_file"`     // PEM certificate chain, re-read on SIGHUP
+	TLSKeyFile      string `yaml:"tls_key_file"`      // PEM private key, re-read on SIGHUP
+	TLSRedirectHTTP bool   `yaml:"tls_redirect_http"` // Also listen on TLS_REDIRECT_PORT and redirect plain HTTP to https (default: false)
+	TLSRedirectPort int    `yaml:"tls_redirect_port"` // Port of the plain HTTP redirect listener (default: 80)
+
+	// Profiling; served on its own loopback listener, never on CONTROLLER_PORT
+	EnablePprof bool   `yaml:"enable_pprof"` // Serve /debug/pprof/ and /debug/vars on PPROF_ADDR (default: false)
+	PprofAddr   string `yaml:"pprof_addr"`   // Loopback host:port of the debug listener (default: localhost:6060)
 }
 
-// Load reads configuration from environment variables
+// Image cache modes
+const (
+	ImageCacheMemory = "memory"
+	ImageCacheDisk   = "disk"
+	ImageCacheNone   = "none"
+)
+
+// HTML sanitization policies for content pages
+const (
+	HTMLPolicyDefault = "default" // Article formatting, tables, links and images
+	HTMLPolicyStrict  = "strict"  // Text formatting only
+)
+
+// RescrapeAfterDefault is the RESCRAPE_AFTER key whose window applies to unlisted domains
+const RescrapeAfterDefault = "default"
+
+// ConfigFileEnv names the environment variable holding an optional config file path
+const ConfigFileEnv = "CONTROLLER_CONFIG"
+
+// Load reads configuration from the file named by CONTROLLER_CONFIG, if set, and
+// from environment variables. See LoadFile for precedence.
 func Load() (*Config, error) {
-	config := &Config{
-		ScraperBaseURL:      getEnv("SCRAPER_BASE_URL", "http://localhost:8081"),
-		TextAnalyzerBaseURL: getEnv("TEXTANALYZER_BASE_URL", "http://localhost:8082"),
-		SchedulerBaseURL:    getEnv("SCHEDULER_BASE_URL", "http://localhost:8083"),
-		Port:                getEnvAsInt("CONTROLLER_PORT", 8080),
-		DBHost:              getEnv("DB_HOST", "localhost"),
-		DBPort:              getEnvAsInt("DB_PORT", 5432),
-		DBUser:              getEnv("DB_USER", "docutab"),
-		DBPassword:          getEnv("DB_PASSWORD", "docutab_dev_pass"),
-		DBName:              getEnv("DB_NAME", "docutab"),
-		LinkScoreThreshold:  getEnvAsFloat("LINK_SCORE_THRESHOLD", 0.5),
-		GenerateMockData:    getEnvAsBool("GENERATE_MOCK_DATA", false),
-		WebInterfaceURL:        getEnv("WEB_INTERFACE_URL", "http://localhost:5173"),
-		RedisAddr:              getEnv("REDIS_ADDR", "localhost:6379"),
-		WorkerConcurrency:      getEnvAsInt("WORKER_CONCURRENCY", 10),
-		MaxLinkDepth:           getEnvAsInt("MAX_LINK_DEPTH", 1),
-		MaxAnalysisWaitMinutes: getEnvAsInt("MAX_ANALYSIS_WAIT_MINUTES", 0), // 0 = use worker default (60)
+	return LoadFile(os.Getenv(ConfigFileEnv))
+}
 
-		// Tombstone configuration
-		TombstoneTags:           getEnvAsStringSlice("TOMBSTONE_TAGS", []string{"low-quality", "sparse-content"}),
-		TombstonePeriodLowScore: getEnvAsInt("TOMBSTONE_PERIOD_LOW_SCORE", 30),
-		TombstonePeriodTagBased: getEnvAsInt("TOMBSTONE_PERIOD_TAG_BASED", 90),
-		TombstonePeriodManual:   getEnvAsInt("TOMBSTONE_PERIOD_MANUAL", 90),
+// LoadFile builds the configuration in three layers, each overriding the last field
+// by field:
+//
+//  1. built-in defaults
+//  2. the YAML or JSON file at path, when path is not empty; keys are the lowercase
+//     environment variable names, e.g. link_score_threshold
+//  3. environment variables that are set and parse
+//
+// Environment variables win so secrets such as DB_PASSWORD can stay out of files
+// that are committed. With no file and no environment the defaults are used as is.
+func LoadFile(path string) (*Config, error) {
+	config := defaults()
+
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read config file: %w", err)
+		}
+		if err := yaml.UnmarshalStrict(data, config); err != nil {
+			return nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+		}
 	}
 
+	config.applyEnv()
+	config.ImageCache = strings.ToLower(config.ImageCache)
+	config.LogFormat = strings.ToLower(config.LogFormat)
+
 	if err := config.Validate(); err != nil {
 		return nil, err
 	}
@@ -67,59 +252,555 @@ func Load() (*Config, error) {
 	return config, nil
 }
 
-// Validate checks if the configuration is valid
+// defaults returns the configuration used when neither a file nor the environment sets a value
+func defaults() *Config {
+	return &Config{
+		ScraperBaseURL:         "http://localhost:8081",
+		TextAnalyzerBaseURL:    "http://localhost:8082",
+		SchedulerBaseURL:       "http://localhost:8083",
+		Port:                   8080,
+		DBHost:                 "localhost",
+		DBPort:                 5432,
+		DBUser:                 "docutab",
+		DBPassword:             "docutab_dev_pass",
+		DBName:                 "docutab",
+		AutoMigrate:            true,
+		LinkScoreThreshold:     0.5,
+		GenerateMockData:       false,
+		MockDataCount:          600,
+		MockDataDays:           180,
+		WebInterfaceURL:        "http://localhost:5173",
+		RedisAddr:              "localhost:6379",
+		WorkerConcurrency:      10,
+		MaxLinkDepth:           1,
+		MaxAnalysisWaitMinutes: 0, // 0 = use worker default (60)
+		TaskTimeoutMinutes:     15,
+
+		// Duplicate enqueue protection
+		ScrapeUniqueWindowMinutes: 10,
+		TextDedupWindowMinutes:    1440,
+
+		// Crawl budget
+		CrawlMaxPages: 1000,
+
+		// Stale content re-scrape
+		RescrapeAfter:                map[string]time.Duration{RescrapeAfterDefault: 720 * time.Hour},
+		StaleRescrapeIntervalMinutes: 60,
+		StaleRescrapeBatchSize:       50,
+
+		// Orphan reconciliation
+		ReconcileBatchSize:    200,
+		ReconcileUpstreamRate: 5,
+
+		// Column backfills
+		BackfillBatchSize: 500,
+		BackfillPauseMS:   100,
+
+		// Webhook delivery
+		WebhookWorkers:                4,
+		WebhookMaxAttempts:            3,
+		WebhookTimeoutSeconds:         10,
+		WebhookMaxConsecutiveFailures: 10,
+
+		// Namespaces
+		PublicNamespace: storage.DefaultNamespace,
+
+		// Queue backpressure
+		MaxQueuedJobs:               0,
+		BackpressureExemptSingleURL: false,
+
+		// Analysis recovery sweep
+		AnalysisRecoveryIntervalMinutes: 30,
+		AnalysisRecoveryBatchSize:       50,
+
+		// Tombstone configuration
+		TombstoneTags:           []string{"low-quality", "sparse-content"},
+		TombstonePeriodLowScore: 30,
+		TombstonePeriodTagBased: 90,
+		TombstonePeriodManual:   90,
+
+		// Quality tombstone configuration
+		SevereQualityThreshold:         0.25,
+		StandardQualityThreshold:       0.35,
+		TombstonePeriodSevereQuality:   7,
+		TombstonePeriodStandardQuality: 30,
+
+		// Audit configuration
+		AuditRetentionDays: 365,
+
+		// Soft delete configuration
+		DeleteGracePeriodDays: 7,
+
+		// Versioning configuration
+		MaxRequestVersions: 5,
+
+		// Dashboard statistics
+		StatsCacheTTLSeconds: 30,
+
+		// API pagination
+		MaxPageLimit: 500,
+
+		// Bulk request actions
+		BulkMaxRequests: 1000,
+
+		// Storage diagnostics
+		SlowQueryThresholdMS: 500,
+
+		// HTTP metrics
+		HTTPLatencyBuckets: httpmetrics.DefaultBuckets,
+
+		// URL normalization configuration
+		TrackingQueryParams: urlnorm.DefaultTrackingParams,
+
+		// Scrape target safety
+		AllowPrivateTargets: false,
+
+		// Domain policy
+		DomainAllowlist: nil,
+		DomainDenylist:  nil,
+
+		// Domain tags
+		DomainTagIncludeHost: false,
+
+		// robots.txt
+		RespectRobotsTxt:      false,
+		RobotsUserAgent:       robots.DefaultUserAgent,
+		RobotsCacheTTLMinutes: 360,
+
+		// Image byte cache
+		ImageCache:          ImageCacheMemory,
+		ImageCacheDir:       "",
+		ImageCacheMaxMB:     128,
+		ImageCacheMaxItemMB: 5,
+
+		// Request archives
+		ArchiveMaxMB: 100,
+
+		// Raw HTML
+		RawHTMLMaxMB: 10,
+
+		// Optimistic concurrency
+		RequireIfMatch: false,
+
+		// SEO content page templates
+		TemplateDir:    "",
+		TemplateReload: false,
+		HTMLPolicy:     HTMLPolicyDefault,
+
+		// Database backups
+		BackupMinIntervalMinutes: 60,
+		BackupRetention:          7,
+
+		// Tracing
+		TracingEnabled:     true,
+		TracingSampleRatio: 1.0,
+		TracingServiceName: telemetry.DefaultServiceName,
+
+		// Logging
+		LogLevel:       "info",
+		LogFormat:      logging.FormatJSON,
+		LogSampleEvery: logging.DefaultSampleEvery,
+
+		// TLS termination
+		TLSRedirectHTTP: false,
+		TLSRedirectPort: 80,
+
+		// Profiling
+		EnablePprof: false,
+		PprofAddr:   "localhost:6060",
+	}
+}
+
+// applyEnv overrides fields with the environment variables that are set
+func (c *Config) applyEnv() {
+	c.ScraperBaseURL = getEnv("SCRAPER_BASE_URL", c.ScraperBaseURL)
+	c.TextAnalyzerBaseURL = getEnv("TEXTANALYZER_BASE_URL", c.TextAnalyzerBaseURL)
+	c.SchedulerBaseURL = getEnv("SCHEDULER_BASE_URL", c.SchedulerBaseURL)
+	c.Port = getEnvAsInt("CONTROLLER_PORT", c.Port)
+	c.DBHost = getEnv("DB_HOST", c.DBHost)
+	c.DBPort = getEnvAsInt("DB_PORT", c.DBPort)
+	c.DBUser = getEnv("DB_USER", c.DBUser)
+	c.DBPassword = getEnv("DB_PASSWORD", c.DBPassword)
+	c.DBName = getEnv("DB_NAME", c.DBName)
+	c.AutoMigrate = getEnvAsBool("AUTO_MIGRATE", c.AutoMigrate)
+	c.LinkScoreThreshold = getEnvAsFloat("LINK_SCORE_THRESHOLD", c.LinkScoreThreshold)
+	c.GenerateMockData = getEnvAsBool("GENERATE_MOCK_DATA", c.GenerateMockData)
+	c.MockDataCount = getEnvAsInt("MOCK_DATA_COUNT", c.MockDataCount)
+	c.MockDataDays = getEnvAsInt("MOCK_DATA_DAYS", c.MockDataDays)
+	c.MockDataSeed = int64(getEnvAsInt("MOCK_DATA_SEED", int(c.MockDataSeed)))
+	c.MockDataForce = getEnvAsBool("MOCK_DATA_FORCE", c.MockDataForce)
+	c.WebInterfaceURL = getEnv("WEB_INTERFACE_URL", c.WebInterfaceURL)
+	c.RedisAddr = getEnv("REDIS_ADDR", c.RedisAddr)
+	c.WorkerConcurrency = getEnvAsInt("WORKER_CONCURRENCY", c.WorkerConcurrency)
+	c.MaxLinkDepth = getEnvAsInt("MAX_LINK_DEPTH", c.MaxLinkDepth)
+	c.MaxAnalysisWaitMinutes = getEnvAsInt("MAX_ANALYSIS_WAIT_MINUTES", c.MaxAnalysisWaitMinutes)
+	c.TaskTimeoutMinutes = getEnvAsInt("TASK_TIMEOUT_MINUTES", c.TaskTimeoutMinutes)
+
+	// Duplicate enqueue protection
+	c.ScrapeUniqueWindowMinutes = getEnvAsInt("SCRAPE_UNIQUE_WINDOW_MINUTES", c.ScrapeUniqueWindowMinutes)
+	c.TextDedupWindowMinutes = getEnvAsInt("TEXT_DEDUP_WINDOW_MINUTES", c.TextDedupWindowMinutes)
+
+	// Crawl budget
+	c.CrawlMaxPages = getEnvAsInt("CRAWL_MAX_PAGES", c.CrawlMaxPages)
+
+	// Queue backpressure
+	c.MaxQueuedJobs = getEnvAsInt("MAX_QUEUED_JOBS", c.MaxQueuedJobs)
+	c.BackpressureExemptSingleURL = getEnvAsBool("BACKPRESSURE_EXEMPT_SINGLE_URL", c.BackpressureExemptSingleURL)
+
+	// Analysis recovery sweep
+	c.AnalysisRecoveryIntervalMinutes = getEnvAsInt("ANALYSIS_RECOVERY_INTERVAL_MINUTES", c.AnalysisRecoveryIntervalMinutes)
+	c.AnalysisRecoveryBatchSize = getEnvAsInt("ANALYSIS_RECOVERY_BATCH_SIZE", c.AnalysisRecoveryBatchSize)
+
+	// Tombstone configuration
+	c.TombstoneTags = getEnvAsStringSlice("TOMBSTONE_TAGS", c.TombstoneTags)
+	c.TombstonePeriodLowScore = getEnvAsInt("TOMBSTONE_PERIOD_LOW_SCORE", c.TombstonePeriodLowScore)
+	c.TombstonePeriodTagBased = getEnvAsInt("TOMBSTONE_PERIOD_TAG_BASED", c.TombstonePeriodTagBased)
+	c.TombstonePeriodManual = getEnvAsInt("TOMBSTONE_PERIOD_MANUAL", c.TombstonePeriodManual)
+
+	// Quality tombstone configuration
+	c.SevereQualityThreshold = getEnvAsFloat("SEVERE_QUALITY_THRESHOLD", c.SevereQualityThreshold)
+	c.StandardQualityThreshold = getEnvAsFloat("STANDARD_QUALITY_THRESHOLD", c.StandardQualityThreshold)
+	c.TombstonePeriodSevereQuality = getEnvAsInt("TOMBSTONE_PERIOD_SEVERE_QUALITY", c.TombstonePeriodSevereQuality)
+	c.TombstonePeriodStandardQuality = getEnvAsInt("TOMBSTONE_PERIOD_STANDARD_QUALITY", c.TombstonePeriodStandardQuality)
+
+	// Audit configuration
+	c.AuditRetentionDays = getEnvAsInt("AUDIT_RETENTION_DAYS", c.AuditRetentionDays)
+
+	// Soft delete configuration
+	c.DeleteGracePeriodDays = getEnvAsInt("DELETE_GRACE_PERIOD_DAYS", c.DeleteGracePeriodDays)
+
+	// Versioning configuration
+	c.MaxRequestVersions = getEnvAsInt("MAX_REQUEST_VERSIONS", c.MaxRequestVersions)
+
+	// Dashboard statistics
+	c.StatsCacheTTLSeconds = getEnvAsInt("STATS_CACHE_TTL_SECONDS", c.StatsCacheTTLSeconds)
+
+	// API pagination
+	c.MaxPageLimit = getEnvAsInt("MAX_PAGE_LIMIT", c.MaxPageLimit)
+
+	// Bulk request actions
+	c.BulkMaxRequests = getEnvAsInt("BULK_MAX_REQUESTS", c.BulkMaxRequests)
+
+	// Storage diagnostics
+	c.SlowQueryThresholdMS = getEnvAsInt("SLOW_QUERY_THRESHOLD_MS", c.SlowQueryThresholdMS)
+
+	// HTTP metrics
+	c.HTTPLatencyBuckets = getEnvAsFloatSlice("HTTP_LATENCY_BUCKETS", c.HTTPLatencyBuckets)
+
+	// URL normalization configuration
+	c.TrackingQueryParams = getEnvAsStringSlice("TRACKING_QUERY_PARAMS", c.TrackingQueryParams)
+
+	// Scrape target safety
+	c.AllowPrivateTargets = getEnvAsBool("ALLOW_PRIVATE_TARGETS", c.AllowPrivateTargets)
+
+	// Domain policy
+	c.DomainAllowlist = getEnvAsStringSlice("DOMAIN_ALLOWLIST", c.DomainAllowlist)
+	c.DomainDenylist = getEnvAsStringSlice("DOMAIN_DENYLIST", c.DomainDenylist)
+	c.DomainScoreThresholds = getEnvAsFloatMap("DOMAIN_SCORE_THRESHOLDS", c.DomainScoreThresholds)
+
+	// Domain tags
+	c.DomainTagIncludeHost = getEnvAsBool("DOMAIN_TAG_INCLUDE_HOST", c.DomainTagIncludeHost)
+
+	// Stale content re-scrape
+	c.StaleRescrapeEnabled = getEnvAsBool("STALE_RESCRAPE_ENABLED", c.StaleRescrapeEnabled)
+	c.RescrapeAfter = getEnvAsDurationMap("RESCRAPE_AFTER", c.RescrapeAfter)
+	c.StaleRescrapeIntervalMinutes = getEnvAsInt("STALE_RESCRAPE_INTERVAL_MINUTES", c.StaleRescrapeIntervalMinutes)
+	c.StaleRescrapeBatchSize = getEnvAsInt("STALE_RESCRAPE_BATCH_SIZE", c.StaleRescrapeBatchSize)
+	c.ReconcileBatchSize = getEnvAsInt("RECONCILE_BATCH_SIZE", c.ReconcileBatchSize)
+	c.ReconcileUpstreamRate = getEnvAsFloat("RECONCILE_UPSTREAM_RATE", c.ReconcileUpstreamRate)
+	c.BackfillBatchSize = getEnvAsInt("BACKFILL_BATCH_SIZE", c.BackfillBatchSize)
+	c.BackfillPauseMS = getEnvAsInt("BACKFILL_PAUSE_MS", c.BackfillPauseMS)
+
+	// Webhook delivery
+	c.WebhookWorkers = getEnvAsInt("WEBHOOK_WORKERS", c.WebhookWorkers)
+	c.WebhookMaxAttempts = getEnvAsInt("WEBHOOK_MAX_ATTEMPTS", c.WebhookMaxAttempts)
+	c.WebhookTimeoutSeconds = getEnvAsInt("WEBHOOK_TIMEOUT_SECONDS", c.WebhookTimeoutSeconds)
+	c.WebhookMaxConsecutiveFailures = getEnvAsInt("WEBHOOK_MAX_CONSECUTIVE_FAILURES", c.WebhookMaxConsecutiveFailures)
+
+	// Namespaces
+	c.NamespaceAPIKeys = getEnvAsStringMap("NAMESPACE_API_KEYS", c.NamespaceAPIKeys)
+	c.PublicNamespace = getEnv("PUBLIC_NAMESPACE", c.PublicNamespace)
+
+	// robots.txt
+	c.RespectRobotsTxt = getEnvAsBool("RESPECT_ROBOTS_TXT", c.RespectRobotsTxt)
+	c.RobotsUserAgent = getEnv("ROBOTS_USER_AGENT", c.RobotsUserAgent)
+	c.RobotsCacheTTLMinutes = getEnvAsInt("ROBOTS_CACHE_TTL_MINUTES", c.RobotsCacheTTLMinutes)
+
+	// Image byte cache
+	c.ImageCache = strings.ToLower(getEnv("IMAGE_CACHE", c.ImageCache))
+	c.ImageCacheDir = getEnv("IMAGE_CACHE_DIR", c.ImageCacheDir)
+	c.ImageCacheMaxMB = getEnvAsInt("IMAGE_CACHE_MAX_MB", c.ImageCacheMaxMB)
+	c.ImageCacheMaxItemMB = getEnvAsInt("IMAGE_CACHE_MAX_ITEM_MB", c.ImageCacheMaxItemMB)
+
+	// Request archives
+	c.ArchiveMaxMB = getEnvAsInt("ARCHIVE_MAX_MB", c.ArchiveMaxMB)
+
+	// Raw HTML
+	c.RawHTMLMaxMB = getEnvAsInt("RAW_HTML_MAX_MB", c.RawHTMLMaxMB)
+
+	// Optimistic concurrency
+	c.RequireIfMatch = getEnvAsBool("REQUIRE_IF_MATCH", c.RequireIfMatch)
+
+	// SEO content page templates
+	c.TemplateDir = getEnv("TEMPLATE_DIR", c.TemplateDir)
+	c.TemplateReload = getEnvAsBool("TEMPLATE_RELOAD", c.TemplateReload)
+	c.HTMLPolicy = strings.ToLower(getEnv("HTML_POLICY", c.HTMLPolicy))
+
+	// Database backups
+	c.BackupDir = getEnv("BACKUP_DIR", c.BackupDir)
+	c.BackupMinIntervalMinutes = getEnvAsInt("BACKUP_MIN_INTERVAL_MINUTES", c.BackupMinIntervalMinutes)
+	c.BackupRetention = getEnvAsInt("BACKUP_RETENTION", c.BackupRetention)
+
+	// Tracing
+	c.TracingEnabled = getEnvAsBool("TRACING_ENABLED", c.TracingEnabled)
+	c.TracingSampleRatio = getEnvAsFloat("TRACING_SAMPLE_RATIO", c.TracingSampleRatio)
+	c.OTLPEndpoint = getEnv("OTLP_ENDPOINT", c.OTLPEndpoint)
+	c.TracingServiceName = getEnv("TRACING_SERVICE_NAME", c.TracingServiceName)
+
+	// Logging
+	c.LogLevel = getEnv("LOG_LEVEL", c.LogLevel)
+	c.LogFormat = getEnv("LOG_FORMAT", c.LogFormat)
+	c.LogSampleEvery = getEnvAsInt("LOG_SAMPLE_EVERY", c.LogSampleEvery)
+
+	// TLS termination
+	c.TLSCertFile = getEnv("TLS_CERT_FILE", c.TLSCertFile)
+	c.TLSKeyFile = getEnv("TLS_KEY_FILE", c.TLSKeyFile)
+	c.TLSRedirectHTTP = getEnvAsBool("TLS_REDIRECT_HTTP", c.TLSRedirectHTTP)
+	c.TLSRedirectPort = getEnvAsInt("TLS_REDIRECT_PORT", c.TLSRedirectPort)
+
+	// Profiling
+	c.EnablePprof = getEnvAsBool("ENABLE_PPROF", c.EnablePprof)
+	c.PprofAddr = getEnv("PPROF_ADDR", c.PprofAddr)
+}
+
+// ValidationError lists every problem found in a configuration
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return "invalid configuration: " + strings.Join(e.Problems, "; ")
+}
+
+// Validate checks the configuration and reports all problems at once, so a
+// misconfigured deployment can be fixed in one pass. It returns a *ValidationError.
 func (c *Config) Validate() error {
-	if c.ScraperBaseURL == "" {
-		return fmt.Errorf("SCRAPER_BASE_URL is required")
+	var problems []string
+	check := func(ok bool, format string, args ...interface{}) {
+		if !ok {
+			problems = append(problems, fmt.Sprintf(format, args...))
+		}
 	}
-	if c.TextAnalyzerBaseURL == "" {
-		return fmt.Errorf("TEXTANALYZER_BASE_URL is required")
+
+	checkServiceURL := func(name, value string, required bool) {
+		if value == "" {
+			check(!required, "%s is required", name)
+			return
+		}
+		check(validServiceURL(value), "%s must be an http or https URL with a host, got %q", name, value)
+	}
+	checkServiceURL("SCRAPER_BASE_URL", c.ScraperBaseURL, true)
+	checkServiceURL("TEXTANALYZER_BASE_URL", c.TextAnalyzerBaseURL, true)
+	checkServiceURL("SCHEDULER_BASE_URL", c.SchedulerBaseURL, true)
+	checkServiceURL("WEB_INTERFACE_URL", c.WebInterfaceURL, false)
+
+	check(c.Port > 0 && c.Port <= 65535, "CONTROLLER_PORT must be between 1 and 65535, got %d", c.Port)
+	check(c.DBHost != "", "DB_HOST is required")
+	check(c.DBPort > 0 && c.DBPort <= 65535, "DB_PORT must be between 1 and 65535, got %d", c.DBPort)
+	check(c.DBUser != "", "DB_USER is required")
+	check(c.DBName != "", "DB_NAME is required")
+	check(c.LinkScoreThreshold >= 0.0 && c.LinkScoreThreshold <= 1.0,
+		"LINK_SCORE_THRESHOLD must be between 0.0 and 1.0, got %g", c.LinkScoreThreshold)
+	check(c.MockDataCount >= 0, "MOCK_DATA_COUNT must be >= 0, got %d", c.MockDataCount)
+	check(c.MockDataDays >= 0, "MOCK_DATA_DAYS must be >= 0, got %d", c.MockDataDays)
+
+	// The queue is always on: API scrapes and the worker both go through Redis
+	check(c.RedisAddr != "", "REDIS_ADDR is required")
+	check(c.WorkerConcurrency > 0, "WORKER_CONCURRENCY must be greater than 0, got %d", c.WorkerConcurrency)
+	check(c.MaxLinkDepth >= 0, "MAX_LINK_DEPTH must be >= 0, got %d", c.MaxLinkDepth)
+	check(c.TaskTimeoutMinutes >= 0, "TASK_TIMEOUT_MINUTES must be >= 0, got %d", c.TaskTimeoutMinutes)
+	check(c.ScrapeUniqueWindowMinutes >= 0, "SCRAPE_UNIQUE_WINDOW_MINUTES must be >= 0, got %d", c.ScrapeUniqueWindowMinutes)
+	check(c.TextDedupWindowMinutes >= 0, "TEXT_DEDUP_WINDOW_MINUTES must be >= 0, got %d", c.TextDedupWindowMinutes)
+	check(c.CrawlMaxPages >= 0, "CRAWL_MAX_PAGES must be >= 0, got %d", c.CrawlMaxPages)
+	check(c.MaxQueuedJobs >= 0, "MAX_QUEUED_JOBS must be >= 0, got %d", c.MaxQueuedJobs)
+	check(c.AnalysisRecoveryIntervalMinutes >= 0,
+		"ANALYSIS_RECOVERY_INTERVAL_MINUTES must be >= 0, got %d", c.AnalysisRecoveryIntervalMinutes)
+	if c.AnalysisRecoveryIntervalMinutes > 0 {
+		check(c.AnalysisRecoveryBatchSize > 0,
+			"ANALYSIS_RECOVERY_BATCH_SIZE must be greater than 0, got %d", c.AnalysisRecoveryBatchSize)
 	}
-	if c.SchedulerBaseURL == "" {
-		return fmt.Errorf("SCHEDULER_BASE_URL is required")
+
+	check(len(c.TombstoneTags) > 0, "TOMBSTONE_TAGS must contain at least one tag")
+	check(c.TombstonePeriodLowScore > 0, "TOMBSTONE_PERIOD_LOW_SCORE must be greater than 0, got %d", c.TombstonePeriodLowScore)
+	check(c.TombstonePeriodTagBased > 0, "TOMBSTONE_PERIOD_TAG_BASED must be greater than 0, got %d", c.TombstonePeriodTagBased)
+	check(c.TombstonePeriodManual > 0, "TOMBSTONE_PERIOD_MANUAL must be greater than 0, got %d", c.TombstonePeriodManual)
+	check(c.SevereQualityThreshold >= 0.0 && c.SevereQualityThreshold <= 1.0,
+		"SEVERE_QUALITY_THRESHOLD must be between 0.0 and 1.0, got %g", c.SevereQualityThreshold)
+	check(c.StandardQualityThreshold >= 0.0 && c.StandardQualityThreshold <= 1.0,
+		"STANDARD_QUALITY_THRESHOLD must be between 0.0 and 1.0, got %g", c.StandardQualityThreshold)
+	check(settings.QualityThresholdsOrdered(c.SevereQualityThreshold, c.StandardQualityThreshold),
+		"SEVERE_QUALITY_THRESHOLD must be less than STANDARD_QUALITY_THRESHOLD unless both are 0, got %g and %g",
+		c.SevereQualityThreshold, c.StandardQualityThreshold)
+	if c.StandardQualityThreshold > 0 {
+		check(c.TombstonePeriodSevereQuality > 0,
+			"TOMBSTONE_PERIOD_SEVERE_QUALITY must be greater than 0, got %d", c.TombstonePeriodSevereQuality)
+		check(c.TombstonePeriodStandardQuality > 0,
+			"TOMBSTONE_PERIOD_STANDARD_QUALITY must be greater than 0, got %d", c.TombstonePeriodStandardQuality)
 	}
-	if c.Port <= 0 || c.Port > 65535 {
-		return fmt.Errorf("CONTROLLER_PORT must be between 1 and 65535")
+	check(c.AuditRetentionDays > 0, "AUDIT_RETENTION_DAYS must be greater than 0, got %d", c.AuditRetentionDays)
+	check(c.DeleteGracePeriodDays >= 0, "DELETE_GRACE_PERIOD_DAYS must be >= 0, got %d", c.DeleteGracePeriodDays)
+	check(c.MaxRequestVersions > 0, "MAX_REQUEST_VERSIONS must be greater than 0, got %d", c.MaxRequestVersions)
+	check(c.StatsCacheTTLSeconds >= 0, "STATS_CACHE_TTL_SECONDS must be >= 0, got %d", c.StatsCacheTTLSeconds)
+	check(c.MaxPageLimit >= 0, "MAX_PAGE_LIMIT must be >= 0, got %d", c.MaxPageLimit)
+	check(c.BulkMaxRequests >= 0, "BULK_MAX_REQUESTS must be >= 0, got %d", c.BulkMaxRequests)
+	check(c.SlowQueryThresholdMS >= 0, "SLOW_QUERY_THRESHOLD_MS must be >= 0, got %d", c.SlowQueryThresholdMS)
+	for i, bucket := range c.HTTPLatencyBuckets {
+		check(bucket > 0, "HTTP_LATENCY_BUCKETS: bucket %d must be a number of seconds greater than 0, got %g", i+1, bucket)
+		// Order is only checked between valid buckets, so a bad entry is reported once
+		if i > 0 && bucket > 0 && c.HTTPLatencyBuckets[i-1] > 0 {
+			check(bucket > c.HTTPLatencyBuckets[i-1], "HTTP_LATENCY_BUCKETS must be in increasing order, got %g after %g", bucket, c.HTTPLatencyBuckets[i-1])
+		}
 	}
-	if c.DBHost == "" {
-		return fmt.Errorf("DB_HOST is required")
+	if c.RespectRobotsTxt {
+		check(c.RobotsCacheTTLMinutes > 0, "ROBOTS_CACHE_TTL_MINUTES must be greater than 0, got %d", c.RobotsCacheTTLMinutes)
 	}
-	if c.DBPort <= 0 || c.DBPort > 65535 {
-		return fmt.Errorf("DB_PORT must be between 1 and 65535")
+
+	check(c.ArchiveMaxMB >= 0, "ARCHIVE_MAX_MB must be >= 0, got %d", c.ArchiveMaxMB)
+	check(c.RawHTMLMaxMB >= 0, "RAW_HTML_MAX_MB must be >= 0, got %d", c.RawHTMLMaxMB)
+
+	switch c.ImageCache {
+	case ImageCacheMemory, ImageCacheDisk:
+		check(c.ImageCacheMaxMB > 0, "IMAGE_CACHE_MAX_MB must be greater than 0, got %d", c.ImageCacheMaxMB)
+		check(c.ImageCacheMaxItemMB > 0, "IMAGE_CACHE_MAX_ITEM_MB must be greater than 0, got %d", c.ImageCacheMaxItemMB)
+		if c.ImageCache == ImageCacheDisk {
+			check(c.ImageCacheDir != "", "IMAGE_CACHE_DIR is required when IMAGE_CACHE is disk")
+		}
+	case ImageCacheNone, "":
+	default:
+		check(false, "IMAGE_CACHE must be memory, disk or none, got %q", c.ImageCache)
 	}
-	if c.DBUser == "" {
-		return fmt.Errorf("DB_USER is required")
+	switch c.HTMLPolicy {
+	case HTMLPolicyDefault, HTMLPolicyStrict, "":
+	default:
+		check(false, "HTML_POLICY must be default or strict, got %q", c.HTMLPolicy)
 	}
-	if c.DBName == "" {
-		return fmt.Errorf("DB_NAME is required")
+
+	if c.BackupDir != "" {
+		check(c.BackupMinIntervalMinutes >= 0, "BACKUP_MIN_INTERVAL_MINUTES must be >= 0, got %d", c.BackupMinIntervalMinutes)
+		check(c.BackupRetention > 0, "BACKUP_RETENTION must be greater than 0, got %d", c.BackupRetention)
 	}
-	if c.LinkScoreThreshold < 0.0 || c.LinkScoreThreshold > 1.0 {
-		return fmt.Errorf("LINK_SCORE_THRESHOLD must be between 0.0 and 1.0")
+
+	for _, pattern := range c.DomainAllowlist {
+		if err := urlguard.ValidateDomainPattern(pattern); err != nil {
+			check(false, "DOMAIN_ALLOWLIST: %v", err)
+		}
 	}
-	if c.RedisAddr == "" {
-		return fmt.Errorf("REDIS_ADDR is required")
+	for _, pattern := range c.DomainDenylist {
+		if err := urlguard.ValidateDomainPattern(pattern); err != nil {
+			check(false, "DOMAIN_DENYLIST: %v", err)
+		}
 	}
-	if c.WorkerConcurrency <= 0 {
-		return fmt.Errorf("WORKER_CONCURRENCY must be greater than 0")
+
+	if c.LogLevel != "" {
+		if _, err := logging.ParseLevel(c.LogLevel); err != nil {
+			check(false, "LOG_LEVEL: %v", err)
+		}
 	}
-	if c.MaxLinkDepth < 0 {
-		return fmt.Errorf("MAX_LINK_DEPTH must be >= 0")
+	switch c.LogFormat {
+	case logging.FormatJSON, logging.FormatText, "":
+	default:
+		check(false, "LOG_FORMAT must be json or text, got %q", c.LogFormat)
 	}
-	if len(c.TombstoneTags) == 0 {
-		return fmt.Errorf("TOMBSTONE_TAGS must contain at least one tag")
+	check(c.TracingSampleRatio >= 0 && c.TracingSampleRatio <= 1,
+		"TRACING_SAMPLE_RATIO must be between 0.0 and 1.0, got %g", c.TracingSampleRatio)
+	if c.TracingEnabled {
+		check(c.TracingServiceName != "", "TRACING_SERVICE_NAME is required when TRACING_ENABLED is true")
 	}
-	if c.TombstonePeriodLowScore <= 0 {
-		return fmt.Errorf("TOMBSTONE_PERIOD_LOW_SCORE must be greater than 0")
+	check(c.LogSampleEvery >= 0, "LOG_SAMPLE_EVERY must be >= 0, got %d", c.LogSampleEvery)
+
+	check((c.TLSCertFile == "") == (c.TLSKeyFile == ""),
+		"TLS_CERT_FILE and TLS_KEY_FILE must be set together")
+	if c.TLSRedirectHTTP {
+		check(c.TLSEnabled(), "TLS_REDIRECT_HTTP requires TLS_CERT_FILE and TLS_KEY_FILE")
+		check(c.TLSRedirectPort > 0 && c.TLSRedirectPort <= 65535,
+			"TLS_REDIRECT_PORT must be between 1 and 65535, got %d", c.TLSRedirectPort)
+		check(c.TLSRedirectPort != c.Port, "TLS_REDIRECT_PORT must differ from CONTROLLER_PORT")
 	}
-	if c.TombstonePeriodTagBased <= 0 {
-		return fmt.Errorf("TOMBSTONE_PERIOD_TAG_BASED must be greater than 0")
+	if c.EnablePprof {
+		check(isLoopbackAddr(c.PprofAddr), "PPROF_ADDR must be a loopback host:port such as localhost:6060, got %q", c.PprofAddr)
 	}
-	if c.TombstonePeriodManual <= 0 {
-		return fmt.Errorf("TOMBSTONE_PERIOD_MANUAL must be greater than 0")
+
+	domains := make([]string, 0, len(c.DomainScoreThresholds))
+	for domain := range c.DomainScoreThresholds {
+		domains = append(domains, domain)
+	}
+	sort.Strings(domains)
+	for _, domain := range domains {
+		threshold := c.DomainScoreThresholds[domain]
+		check(domain != "", "DOMAIN_SCORE_THRESHOLDS: domain is required")
+		check(threshold >= 0.0 && threshold <= 1.0,
+			"DOMAIN_SCORE_THRESHOLDS: threshold for %q must be between 0.0 and 1.0, got %g", domain, threshold)
+	}
+
+	if c.StaleRescrapeEnabled {
+		check(c.StaleRescrapeIntervalMinutes > 0, "STALE_RESCRAPE_INTERVAL_MINUTES must be > 0, got %d", c.StaleRescrapeIntervalMinutes)
+		check(c.StaleRescrapeBatchSize > 0, "STALE_RESCRAPE_BATCH_SIZE must be > 0, got %d", c.StaleRescrapeBatchSize)
+	}
+	check(c.ReconcileBatchSize >= 0, "RECONCILE_BATCH_SIZE must be >= 0, got %d", c.ReconcileBatchSize)
+	check(c.ReconcileUpstreamRate >= 0, "RECONCILE_UPSTREAM_RATE must be >= 0, got %g", c.ReconcileUpstreamRate)
+	check(c.BackfillBatchSize >= 0, "BACKFILL_BATCH_SIZE must be >= 0, got %d", c.BackfillBatchSize)
+	check(c.BackfillPauseMS >= 0, "BACKFILL_PAUSE_MS must be >= 0, got %d", c.BackfillPauseMS)
+	windows := make([]string, 0, len(c.RescrapeAfter))
+	for domain := range c.RescrapeAfter {
+		windows = append(windows, domain)
+	}
+	sort.Strings(windows)
+	for _, domain := range windows {
+		check(domain != "", "RESCRAPE_AFTER: domain is required")
+		check(c.RescrapeAfter[domain] > 0,
+			"RESCRAPE_AFTER: window for %q must be a positive duration such as 168h, got %s", domain, c.RescrapeAfter[domain])
+	}
+
+	check(c.WebhookWorkers >= 0, "WEBHOOK_WORKERS must be >= 0, got %d", c.WebhookWorkers)
+	check(c.WebhookMaxAttempts >= 0, "WEBHOOK_MAX_ATTEMPTS must be >= 0, got %d", c.WebhookMaxAttempts)
+	check(c.WebhookTimeoutSeconds >= 0, "WEBHOOK_TIMEOUT_SECONDS must be >= 0, got %d", c.WebhookTimeoutSeconds)
+	check(c.WebhookMaxConsecutiveFailures >= 0, "WEBHOOK_MAX_CONSECUTIVE_FAILURES must be >= 0, got %d", c.WebhookMaxConsecutiveFailures)
+
+	check(c.PublicNamespace == "" || storage.ValidNamespace(c.PublicNamespace),
+		"PUBLIC_NAMESPACE must be 1-%d lowercase letters, digits, '-' or '_', got %q", storage.MaxNamespaceLength, c.PublicNamespace)
+	keyed := make([]string, 0, len(c.NamespaceAPIKeys))
+	for key := range c.NamespaceAPIKeys {
+		keyed = append(keyed, key)
+	}
+	sort.Strings(keyed)
+	for i, key := range keyed {
+		// Keys are secrets, so problems name the entry by position rather than by key
+		check(key != "", "NAMESPACE_API_KEYS: API key is required")
+		check(storage.ValidNamespace(c.NamespaceAPIKeys[key]),
+			"NAMESPACE_API_KEYS: namespace of entry %d must be 1-%d lowercase letters, digits, '-' or '_', got %q",
+			i+1, storage.MaxNamespaceLength, c.NamespaceAPIKeys[key])
+	}
+
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
 	}
 	return nil
 }
 
+// TLSEnabled reports whether the controller terminates TLS itself
+func (c *Config) TLSEnabled() bool {
+	return c.TLSCertFile != "" && c.TLSKeyFile != ""
+}
+
+// validServiceURL reports whether value is an absolute http(s) URL with a host
+func validServiceURL(value string) bool {
+	u, err := url.Parse(value)
+	if err != nil {
+		return false
+	}
+	return (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
 func getEnv(key, defaultValue string) string {
 	value := os.Getenv(key)
 	if value == "" {
@@ -180,3 +861,83 @@ func getEnvAsStringSlice(key string, defaultValue []string) []string {
 	}
 	return result
 }
+
+// getEnvAsFloatSlice parses comma-separated numbers. Entries that are not numbers are kept as
+// NaN so Validate reports them instead of dropping them.
+func getEnvAsFloatSlice(key string, defaultValue []float64) []float64 {
+	entries := getEnvAsStringSlice(key, nil)
+	if entries == nil {
+		return defaultValue
+	}
+	result := make([]float64, len(entries))
+	for i, entry := range entries {
+		value, err := strconv.ParseFloat(entry, 64)
+		if err != nil {
+			value = math.NaN()
+		}
+		result[i] = value
+	}
+	return result
+}
+
+// isLoopbackAddr reports whether addr is a host:port whose host is localhost or a loopback IP
+func isLoopbackAddr(addr string) bool {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil || port == "" {
+		return false
+	}
+	if strings.EqualFold(host, "localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// getEnvAsFloatMap parses comma-separated key=value pairs. Entries that are not a key and a
+// number are kept with a NaN value so Validate reports them instead of dropping them.
+// getEnvAsDurationMap parses comma-separated name=duration pairs. A value that is not a
+// duration becomes 0 so validation reports it against its name.
+func getEnvAsDurationMap(key string, defaultValue map[string]time.Duration) map[string]time.Duration {
+	entries := getEnvAsStringSlice(key, nil)
+	if entries == nil {
+		return defaultValue
+	}
+	result := make(map[string]time.Duration, len(entries))
+	for _, entry := range entries {
+		name, valueStr, _ := strings.Cut(entry, "=")
+		value, _ := time.ParseDuration(strings.TrimSpace(valueStr))
+		result[strings.TrimSpace(name)] = value
+	}
+	return result
+}
+
+func getEnvAsFloatMap(key string, defaultValue map[string]float64) map[string]float64 {
+	entries := getEnvAsStringSlice(key, nil)
+	if entries == nil {
+		return defaultValue
+	}
+	result := make(map[string]float64, len(entries))
+	for _, entry := range entries {
+		name, valueStr, _ := strings.Cut(entry, "=")
+		value, err := strconv.ParseFloat(strings.TrimSpace(valueStr), 64)
+		if err != nil {
+			value = math.NaN()
+		}
+		result[strings.TrimSpace(name)] = value
+	}
+	return result
+}
+
+// getEnvAsStringMap parses comma-separated name=value pairs with surrounding spaces trimmed
+func getEnvAsStringMap(key string, defaultValue map[string]string) map[string]string {
+	entries := getEnvAsStringSlice(key, nil)
+	if entries == nil {
+		return defaultValue
+	}
+	result := make(map[string]string, len(entries))
+	for _, entry := range entries {
+		name, value, _ := strings.Cut(entry, "=")
+		result[strings.TrimSpace(name)] = strings.TrimSpace(value)
+	}
+	return result
+}
@@ -1,8 +1,13 @@
 package config
 
 import (
+	"errors"
 	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestLoad(t *testing.T) {
@@ -124,9 +129,387 @@ func TestValidate(t *testing.T) {
 				TombstonePeriodLowScore: 30,
 				TombstonePeriodTagBased: 90,
 				TombstonePeriodManual:   90,
+				AuditRetentionDays:      365,
+				MaxRequestVersions:      5,
 			},
 			expectError: false,
 		},
+		{
+			name: "invalid audit retention",
+			config: &Config{
+				ScraperBaseURL:          "http://localhost:8081",
+				TextAnalyzerBaseURL:     "http://localhost:8082",
+				SchedulerBaseURL:        "http://localhost:8083",
+				Port:                    8080,
+				DBHost:                  "localhost",
+				DBPort:                  5432,
+				DBUser:                  "postgres",
+				DBPassword:              "postgres",
+				DBName:                  "docutab",
+				RedisAddr:               "localhost:6379",
+				WorkerConcurrency:       10,
+				MaxLinkDepth:            1,
+				TombstoneTags:           []string{"low-quality"},
+				TombstonePeriodLowScore: 30,
+				TombstonePeriodTagBased: 90,
+				TombstonePeriodManual:   90,
+				AuditRetentionDays:      0,
+			},
+			expectError: true,
+		},
+		{
+			name: "invalid delete grace period",
+			config: &Config{
+				ScraperBaseURL:          "http://localhost:8081",
+				TextAnalyzerBaseURL:     "http://localhost:8082",
+				SchedulerBaseURL:        "http://localhost:8083",
+				Port:                    8080,
+				DBHost:                  "localhost",
+				DBPort:                  5432,
+				DBUser:                  "postgres",
+				DBPassword:              "postgres",
+				DBName:                  "docutab",
+				RedisAddr:               "localhost:6379",
+				WorkerConcurrency:       10,
+				MaxLinkDepth:            1,
+				TombstoneTags:           []string{"low-quality"},
+				TombstonePeriodLowScore: 30,
+				TombstonePeriodTagBased: 90,
+				TombstonePeriodManual:   90,
+				AuditRetentionDays:      365,
+				DeleteGracePeriodDays:   -1,
+			},
+			expectError: true,
+		},
+		{
+			name: "invalid max request versions",
+			config: &Config{
+				ScraperBaseURL:          "http://localhost:8081",
+				TextAnalyzerBaseURL:     "http://localhost:8082",
+				SchedulerBaseURL:        "http://localhost:8083",
+				Port:                    8080,
+				DBHost:                  "localhost",
+				DBPort:                  5432,
+				DBUser:                  "postgres",
+				DBPassword:              "postgres",
+				DBName:                  "docutab",
+				RedisAddr:               "localhost:6379",
+				WorkerConcurrency:       10,
+				MaxLinkDepth:            1,
+				TombstoneTags:           []string{"low-quality"},
+				TombstonePeriodLowScore: 30,
+				TombstonePeriodTagBased: 90,
+				TombstonePeriodManual:   90,
+				AuditRetentionDays:      365,
+				MaxRequestVersions:      0,
+			},
+			expectError: true,
+		},
+		{
+			name: "invalid domain allowlist pattern",
+			config: &Config{
+				ScraperBaseURL:          "http://localhost:8081",
+				TextAnalyzerBaseURL:     "http://localhost:8082",
+				SchedulerBaseURL:        "http://localhost:8083",
+				Port:                    8080,
+				DBHost:                  "localhost",
+				DBPort:                  5432,
+				DBUser:                  "postgres",
+				DBPassword:              "postgres",
+				DBName:                  "docutag",
+				RedisAddr:               "localhost:6379",
+				WorkerConcurrency:       10,
+				MaxLinkDepth:            1,
+				TombstoneTags:           []string{"low-quality"},
+				TombstonePeriodLowScore: 30,
+				TombstonePeriodTagBased: 90,
+				TombstonePeriodManual:   90,
+				AuditRetentionDays:      365,
+				MaxRequestVersions:      5,
+				DomainAllowlist:         []string{"https://example.com/"},
+			},
+			expectError: true,
+		},
+		{
+			name: "invalid robots cache ttl",
+			config: &Config{
+				ScraperBaseURL:          "http://localhost:8081",
+				TextAnalyzerBaseURL:     "http://localhost:8082",
+				SchedulerBaseURL:        "http://localhost:8083",
+				Port:                    8080,
+				DBHost:                  "localhost",
+				DBPort:                  5432,
+				DBUser:                  "postgres",
+				DBPassword:              "postgres",
+				DBName:                  "docutab",
+				RedisAddr:               "localhost:6379",
+				WorkerConcurrency:       10,
+				MaxLinkDepth:            1,
+				TombstoneTags:           []string{"low-quality"},
+				TombstonePeriodLowScore: 30,
+				TombstonePeriodTagBased: 90,
+				TombstonePeriodManual:   90,
+				AuditRetentionDays:      365,
+				MaxRequestVersions:      5,
+				RespectRobotsTxt:        true,
+				RobotsCacheTTLMinutes:   0,
+			},
+			expectError: true,
+		},
+		{
+			name: "valid domain lists",
+			config: &Config{
+				ScraperBaseURL:          "http://localhost:8081",
+				TextAnalyzerBaseURL:     "http://localhost:8082",
+				SchedulerBaseURL:        "http://localhost:8083",
+				Port:                    8080,
+				DBHost:                  "localhost",
+				DBPort:                  5432,
+				DBUser:                  "postgres",
+				DBPassword:              "postgres",
+				DBName:                  "docutag",
+				RedisAddr:               "localhost:6379",
+				WorkerConcurrency:       10,
+				MaxLinkDepth:            1,
+				TombstoneTags:           []string{"low-quality"},
+				TombstonePeriodLowScore: 30,
+				TombstonePeriodTagBased: 90,
+				TombstonePeriodManual:   90,
+				AuditRetentionDays:      365,
+				MaxRequestVersions:      5,
+				DomainAllowlist:         []string{"example.com", "*.example.com"},
+				DomainDenylist:          []string{"private.example.com"},
+			},
+			expectError: false,
+		},
+		{
+			name: "disk image cache without directory",
+			config: &Config{
+				ScraperBaseURL:          "http://localhost:8081",
+				TextAnalyzerBaseURL:     "http://localhost:8082",
+				SchedulerBaseURL:        "http://localhost:8083",
+				Port:                    8080,
+				DBHost:                  "localhost",
+				DBPort:                  5432,
+				DBUser:                  "postgres",
+				DBPassword:              "postgres",
+				DBName:                  "docutag",
+				RedisAddr:               "localhost:6379",
+				WorkerConcurrency:       10,
+				MaxLinkDepth:            1,
+				TombstoneTags:           []string{"low-quality"},
+				TombstonePeriodLowScore: 30,
+				TombstonePeriodTagBased: 90,
+				TombstonePeriodManual:   90,
+				AuditRetentionDays:      365,
+				MaxRequestVersions:      5,
+				ImageCache:              ImageCacheDisk,
+				ImageCacheMaxMB:         128,
+				ImageCacheMaxItemMB:     5,
+			},
+			expectError: true,
+		},
+		{
+			name: "unknown image cache mode",
+			config: &Config{
+				ScraperBaseURL:          "http://localhost:8081",
+				TextAnalyzerBaseURL:     "http://localhost:8082",
+				SchedulerBaseURL:        "http://localhost:8083",
+				Port:                    8080,
+				DBHost:                  "localhost",
+				DBPort:                  5432,
+				DBUser:                  "postgres",
+				DBPassword:              "postgres",
+				DBName:                  "docutag",
+				RedisAddr:               "localhost:6379",
+				WorkerConcurrency:       10,
+				MaxLinkDepth:            1,
+				TombstoneTags:           []string{"low-quality"},
+				TombstonePeriodLowScore: 30,
+				TombstonePeriodTagBased: 90,
+				TombstonePeriodManual:   90,
+				AuditRetentionDays:      365,
+				MaxRequestVersions:      5,
+				ImageCache:              "redis",
+			},
+			expectError: true,
+		},
+		{
+			name: "unknown HTML policy",
+			config: &Config{
+				ScraperBaseURL:          "http://localhost:8081",
+				TextAnalyzerBaseURL:     "http://localhost:8082",
+				SchedulerBaseURL:        "http://localhost:8083",
+				Port:                    8080,
+				DBHost:                  "localhost",
+				DBPort:                  5432,
+				DBUser:                  "postgres",
+				DBPassword:              "postgres",
+				DBName:                  "docutag",
+				RedisAddr:               "localhost:6379",
+				WorkerConcurrency:       10,
+				MaxLinkDepth:            1,
+				TombstoneTags:           []string{"low-quality"},
+				TombstonePeriodLowScore: 30,
+				TombstonePeriodTagBased: 90,
+				TombstonePeriodManual:   90,
+				AuditRetentionDays:      365,
+				MaxRequestVersions:      5,
+				HTMLPolicy:              "permissive",
+			},
+			expectError: true,
+		},
+		{
+			name: "analysis recovery without batch size",
+			config: &Config{
+				ScraperBaseURL:                  "http://localhost:8081",
+				TextAnalyzerBaseURL:             "http://localhost:8082",
+				SchedulerBaseURL:                "http://localhost:8083",
+				Port:                            8080,
+				DBHost:                          "localhost",
+				DBPort:                          5432,
+				DBUser:                          "postgres",
+				DBPassword:                      "postgres",
+				DBName:                          "docutag",
+				RedisAddr:                       "localhost:6379",
+				WorkerConcurrency:               10,
+				MaxLinkDepth:                    1,
+				TombstoneTags:                   []string{"low-quality"},
+				TombstonePeriodLowScore:         30,
+				TombstonePeriodTagBased:         90,
+				TombstonePeriodManual:           90,
+				AuditRetentionDays:              365,
+				MaxRequestVersions:              5,
+				AnalysisRecoveryIntervalMinutes: 30,
+				AnalysisRecoveryBatchSize:       0,
+			},
+			expectError: true,
+		},
+		{
+			name: "negative analysis recovery interval",
+			config: &Config{
+				ScraperBaseURL:                  "http://localhost:8081",
+				TextAnalyzerBaseURL:             "http://localhost:8082",
+				SchedulerBaseURL:                "http://localhost:8083",
+				Port:                            8080,
+				DBHost:                          "localhost",
+				DBPort:                          5432,
+				DBUser:                          "postgres",
+				DBPassword:                      "postgres",
+				DBName:                          "docutag",
+				RedisAddr:                       "localhost:6379",
+				WorkerConcurrency:               10,
+				MaxLinkDepth:                    1,
+				TombstoneTags:                   []string{"low-quality"},
+				TombstonePeriodLowScore:         30,
+				TombstonePeriodTagBased:         90,
+				TombstonePeriodManual:           90,
+				AuditRetentionDays:              365,
+				MaxRequestVersions:              5,
+				AnalysisRecoveryIntervalMinutes: -1,
+			},
+			expectError: true,
+		},
+		{
+			name: "negative stats cache TTL",
+			config: &Config{
+				ScraperBaseURL:          "http://localhost:8081",
+				TextAnalyzerBaseURL:     "http://localhost:8082",
+				SchedulerBaseURL:        "http://localhost:8083",
+				Port:                    8080,
+				DBHost:                  "localhost",
+				DBPort:                  5432,
+				DBUser:                  "postgres",
+				DBPassword:              "postgres",
+				DBName:                  "docutag",
+				RedisAddr:               "localhost:6379",
+				WorkerConcurrency:       10,
+				MaxLinkDepth:            1,
+				TombstoneTags:           []string{"low-quality"},
+				TombstonePeriodLowScore: 30,
+				TombstonePeriodTagBased: 90,
+				TombstonePeriodManual:   90,
+				AuditRetentionDays:      365,
+				MaxRequestVersions:      5,
+				StatsCacheTTLSeconds:    -1,
+			},
+			expectError: true,
+		},
+		{
+			name: "negative slow query threshold",
+			config: &Config{
+				ScraperBaseURL:          "http://localhost:8081",
+				TextAnalyzerBaseURL:     "http://localhost:8082",
+				SchedulerBaseURL:        "http://localhost:8083",
+				Port:                    8080,
+				DBHost:                  "localhost",
+				DBPort:                  5432,
+				DBUser:                  "postgres",
+				DBPassword:              "postgres",
+				DBName:                  "docutag",
+				RedisAddr:               "localhost:6379",
+				WorkerConcurrency:       10,
+				MaxLinkDepth:            1,
+				TombstoneTags:           []string{"low-quality"},
+				TombstonePeriodLowScore: 30,
+				TombstonePeriodTagBased: 90,
+				TombstonePeriodManual:   90,
+				AuditRetentionDays:      365,
+				MaxRequestVersions:      5,
+				SlowQueryThresholdMS:    -1,
+			},
+			expectError: true,
+		},
+		{
+			name: "negative max page limit",
+			config: &Config{
+				ScraperBaseURL:          "http://localhost:8081",
+				TextAnalyzerBaseURL:     "http://localhost:8082",
+				SchedulerBaseURL:        "http://localhost:8083",
+				Port:                    8080,
+				DBHost:                  "localhost",
+				DBPort:                  5432,
+				DBUser:                  "postgres",
+				DBPassword:              "postgres",
+				DBName:                  "docutag",
+				RedisAddr:               "localhost:6379",
+				WorkerConcurrency:       10,
+				MaxLinkDepth:            1,
+				TombstoneTags:           []string{"low-quality"},
+				TombstonePeriodLowScore: 30,
+				TombstonePeriodTagBased: 90,
+				TombstonePeriodManual:   90,
+				AuditRetentionDays:      365,
+				MaxRequestVersions:      5,
+				MaxPageLimit:            -1,
+			},
+			expectError: true,
+		},
+		{
+			name: "negative bulk max requests",
+			config: &Config{
+				ScraperBaseURL:          "http://localhost:8081",
+				TextAnalyzerBaseURL:     "http://localhost:8082",
+				SchedulerBaseURL:        "http://localhost:8083",
+				Port:                    8080,
+				DBHost:                  "localhost",
+				DBPort:                  5432,
+				DBUser:                  "postgres",
+				DBPassword:              "postgres",
+				DBName:                  "docutag",
+				RedisAddr:               "localhost:6379",
+				WorkerConcurrency:       10,
+				MaxLinkDepth:            1,
+				TombstoneTags:           []string{"low-quality"},
+				TombstonePeriodLowScore: 30,
+				TombstonePeriodTagBased: 90,
+				TombstonePeriodManual:   90,
+				AuditRetentionDays:      365,
+				MaxRequestVersions:      5,
+				BulkMaxRequests:         -1,
+			},
+			expectError: true,
+		},
 		{
 			name: "missing scraper URL",
 			config: &Config{
@@ -228,3 +611,459 @@ func TestValidate(t *testing.T) {
 		})
 	}
 }
+
+func validConfig() *Config {
+	return &Config{
+		ScraperBaseURL:                 "http://localhost:8081",
+		TextAnalyzerBaseURL:            "http://localhost:8082",
+		SchedulerBaseURL:               "http://localhost:8083",
+		WebInterfaceURL:                "http://localhost:5173",
+		Port:                           8080,
+		DBHost:                         "localhost",
+		DBPort:                         5432,
+		DBUser:                         "postgres",
+		DBName:                         "docutab",
+		LinkScoreThreshold:             0.5,
+		RedisAddr:                      "localhost:6379",
+		WorkerConcurrency:              10,
+		TombstoneTags:                  []string{"low-quality"},
+		TombstonePeriodLowScore:        30,
+		TombstonePeriodTagBased:        90,
+		TombstonePeriodManual:          90,
+		SevereQualityThreshold:         0.25,
+		StandardQualityThreshold:       0.35,
+		TombstonePeriodSevereQuality:   7,
+		TombstonePeriodStandardQuality: 30,
+		AuditRetentionDays:             365,
+		MaxRequestVersions:             5,
+		ImageCache:                     ImageCacheNone,
+	}
+}
+
+func TestValidateReportsEveryProblem(t *testing.T) {
+	tests := []struct {
+		name   string
+		mutate func(c *Config)
+		want   []string
+	}{
+		{"valid", func(c *Config) {}, nil},
+		{"threshold above one", func(c *Config) { c.LinkScoreThreshold = 1.5 }, []string{"LINK_SCORE_THRESHOLD"}},
+		{"negative threshold", func(c *Config) { c.LinkScoreThreshold = -0.1 }, []string{"LINK_SCORE_THRESHOLD"}},
+		{"negative mock data volume", func(c *Config) { c.MockDataCount = -1; c.MockDataDays = -1 }, []string{"MOCK_DATA_COUNT", "MOCK_DATA_DAYS"}},
+		{"empty scraper url", func(c *Config) { c.ScraperBaseURL = "" }, []string{"SCRAPER_BASE_URL is required"}},
+		{"scraper url without scheme", func(c *Config) { c.ScraperBaseURL = "localhost:8081" }, []string{"SCRAPER_BASE_URL"}},
+		{"analyzer url with ftp scheme", func(c *Config) { c.TextAnalyzerBaseURL = "ftp://analyzer" }, []string{"TEXTANALYZER_BASE_URL"}},
+		{"unparseable web url", func(c *Config) { c.WebInterfaceURL = "http://[::1" }, []string{"WEB_INTERFACE_URL"}},
+		{"port out of range", func(c *Config) { c.Port = 70000 }, []string{"CONTROLLER_PORT"}},
+		{"zero workers", func(c *Config) { c.WorkerConcurrency = 0 }, []string{"WORKER_CONCURRENCY"}},
+		{"zero tombstone periods", func(c *Config) {
+			c.TombstonePeriodLowScore = 0
+			c.TombstonePeriodTagBased = -1
+			c.TombstonePeriodManual = 0
+		}, []string{"TOMBSTONE_PERIOD_LOW_SCORE", "TOMBSTONE_PERIOD_TAG_BASED", "TOMBSTONE_PERIOD_MANUAL"}},
+		{"empty redis address", func(c *Config) { c.RedisAddr = "" }, []string{"REDIS_ADDR"}},
+		{"unknown log level", func(c *Config) { c.LogLevel = "verbose" }, []string{"LOG_LEVEL"}},
+		{"unknown log format", func(c *Config) { c.LogFormat = "xml" }, []string{"LOG_FORMAT"}},
+		{"negative log sample rate", func(c *Config) { c.LogSampleEvery = -1 }, []string{"LOG_SAMPLE_EVERY"}},
+		{"backups without retention", func(c *Config) {
+			c.BackupDir = "/var/backups/controller"
+			c.BackupRetention = 0
+		}, []string{"BACKUP_RETENTION"}},
+		{"tls cert without key", func(c *Config) { c.TLSCertFile = "/etc/controller/tls.crt" }, []string{"TLS_CERT_FILE and TLS_KEY_FILE"}},
+		{"tls key without cert", func(c *Config) { c.TLSKeyFile = "/etc/controller/tls.key" }, []string{"TLS_CERT_FILE and TLS_KEY_FILE"}},
+		{"tls redirect without tls", func(c *Config) {
+			c.TLSRedirectHTTP = true
+			c.TLSRedirectPort = 80
+		}, []string{"TLS_REDIRECT_HTTP"}},
+		{"tls redirect on the same port", func(c *Config) {
+			c.TLSCertFile = "/etc/controller/tls.crt"
+			c.TLSKeyFile = "/etc/controller/tls.key"
+			c.TLSRedirectHTTP = true
+			c.TLSRedirectPort = c.Port
+		}, []string{"TLS_REDIRECT_PORT"}},
+		{"negative task timeout", func(c *Config) { c.TaskTimeoutMinutes = -1 }, []string{"TASK_TIMEOUT_MINUTES"}},
+		{"negative scrape unique window", func(c *Config) { c.ScrapeUniqueWindowMinutes = -1 }, []string{"SCRAPE_UNIQUE_WINDOW_MINUTES"}},
+		{"negative text dedup window", func(c *Config) { c.TextDedupWindowMinutes = -1 }, []string{"TEXT_DEDUP_WINDOW_MINUTES"}},
+		{"negative crawl max pages", func(c *Config) { c.CrawlMaxPages = -1 }, []string{"CRAWL_MAX_PAGES"}},
+		{"negative max queued jobs", func(c *Config) { c.MaxQueuedJobs = -1 }, []string{"MAX_QUEUED_JOBS"}},
+		{"stale re-scrape without interval or batch", func(c *Config) {
+			c.StaleRescrapeEnabled = true
+			c.StaleRescrapeIntervalMinutes = 0
+			c.StaleRescrapeBatchSize = 0
+		}, []string{"STALE_RESCRAPE_INTERVAL_MINUTES", "STALE_RESCRAPE_BATCH_SIZE"}},
+		{"stale re-scrape settings ignored when disabled", func(c *Config) { c.StaleRescrapeBatchSize = 0 }, nil},
+		{"negative archive cap", func(c *Config) { c.ArchiveMaxMB = -1 }, []string{"ARCHIVE_MAX_MB"}},
+		{"negative raw HTML cap", func(c *Config) { c.RawHTMLMaxMB = -1 }, []string{"RAW_HTML_MAX_MB"}},
+		{"negative reconcile settings", func(c *Config) {
+			c.ReconcileBatchSize = -1
+			c.ReconcileUpstreamRate = -0.5
+		}, []string{"RECONCILE_BATCH_SIZE", "RECONCILE_UPSTREAM_RATE"}},
+		{"negative backfill settings", func(c *Config) {
+			c.BackfillBatchSize = -1
+			c.BackfillPauseMS = -1
+		}, []string{"BACKFILL_BATCH_SIZE", "BACKFILL_PAUSE_MS"}},
+		{"negative webhook settings", func(c *Config) {
+			c.WebhookWorkers = -1
+			c.WebhookMaxAttempts = -1
+			c.WebhookMaxConsecutiveFailures = -1
+		}, []string{"WEBHOOK_WORKERS", "WEBHOOK_MAX_ATTEMPTS", "WEBHOOK_MAX_CONSECUTIVE_FAILURES"}},
+		{"invalid public namespace", func(c *Config) { c.PublicNamespace = "Public Docs" }, []string{"PUBLIC_NAMESPACE"}},
+		{"invalid namespace for an api key", func(c *Config) {
+			c.NamespaceAPIKeys = map[string]string{"secret-key": "Team A", "": "team-b"}
+		}, []string{"NAMESPACE_API_KEYS: API key is required", "NAMESPACE_API_KEYS: namespace of entry 2"}},
+		{"pprof on a public address", func(c *Config) {
+			c.EnablePprof = true
+			c.PprofAddr = ":6060"
+		}, []string{"PPROF_ADDR"}},
+		{"pprof on loopback", func(c *Config) {
+			c.EnablePprof = true
+			c.PprofAddr = "127.0.0.1:6060"
+		}, nil},
+		{"pprof address ignored when disabled", func(c *Config) { c.PprofAddr = "0.0.0.0:6060" }, nil},
+		{"severe threshold equal to standard", func(c *Config) { c.SevereQualityThreshold = 0.35 }, []string{"SEVERE_QUALITY_THRESHOLD must be less"}},
+		{"standard threshold above one", func(c *Config) { c.StandardQualityThreshold = 1.2 }, []string{"STANDARD_QUALITY_THRESHOLD"}},
+		{"quality tombstoning disabled", func(c *Config) {
+			c.SevereQualityThreshold = 0
+			c.StandardQualityThreshold = 0
+			c.TombstonePeriodSevereQuality = 0
+			c.TombstonePeriodStandardQuality = 0
+		}, nil},
+		{"zero quality periods", func(c *Config) {
+			c.TombstonePeriodSevereQuality = 0
+			c.TombstonePeriodStandardQuality = 0
+		}, []string{"TOMBSTONE_PERIOD_SEVERE_QUALITY", "TOMBSTONE_PERIOD_STANDARD_QUALITY"}},
+		{"several at once", func(c *Config) {
+			c.ScraperBaseURL = ""
+			c.Port = -1
+			c.LinkScoreThreshold = 2
+			c.RedisAddr = ""
+		}, []string{"SCRAPER_BASE_URL", "CONTROLLER_PORT", "LINK_SCORE_THRESHOLD", "REDIS_ADDR"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := validConfig()
+			tt.mutate(cfg)
+
+			err := cfg.Validate()
+			if len(tt.want) == 0 {
+				if err != nil {
+					t.Fatalf("Expected no error, got: %v", err)
+				}
+				return
+			}
+
+			var validationErr *ValidationError
+			if !errors.As(err, &validationErr) {
+				t.Fatalf("Expected *ValidationError, got %v", err)
+			}
+			if len(validationErr.Problems) != len(tt.want) {
+				t.Fatalf("Expected %d problems, got %d: %v", len(tt.want), len(validationErr.Problems), validationErr.Problems)
+			}
+			for i, want := range tt.want {
+				if !strings.HasPrefix(validationErr.Problems[i], want) {
+					t.Errorf("Problem %d: expected it to start with %q, got %q", i, want, validationErr.Problems[i])
+				}
+			}
+		})
+	}
+}
+
+func writeConfigFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+	return path
+}
+
+func TestLoadFilePrecedence(t *testing.T) {
+	yamlFile := `
+scraper_base_url: http://file-scraper:8081
+link_score_threshold: 0.7
+worker_concurrency: 4
+tombstone_tags: [spam, thin]
+`
+
+	t.Run("file only", func(t *testing.T) {
+		cfg, err := LoadFile(writeConfigFile(t, "controller.yaml", yamlFile))
+		if err != nil {
+			t.Fatalf("Failed to load config: %v", err)
+		}
+		if cfg.ScraperBaseURL != "http://file-scraper:8081" {
+			t.Errorf("Expected ScraperBaseURL from file, got '%s'", cfg.ScraperBaseURL)
+		}
+		if cfg.LinkScoreThreshold != 0.7 {
+			t.Errorf("Expected LinkScoreThreshold 0.7, got %v", cfg.LinkScoreThreshold)
+		}
+		if cfg.WorkerConcurrency != 4 {
+			t.Errorf("Expected WorkerConcurrency 4, got %d", cfg.WorkerConcurrency)
+		}
+		if strings.Join(cfg.TombstoneTags, ",") != "spam,thin" {
+			t.Errorf("Expected TombstoneTags from file, got %v", cfg.TombstoneTags)
+		}
+		// Fields the file leaves out keep their defaults
+		if cfg.Port != 8080 {
+			t.Errorf("Expected default Port 8080, got %d", cfg.Port)
+		}
+	})
+
+	t.Run("env only", func(t *testing.T) {
+		t.Setenv("SCRAPER_BASE_URL", "http://env-scraper:8081")
+		t.Setenv("WORKER_CONCURRENCY", "6")
+
+		cfg, err := LoadFile("")
+		if err != nil {
+			t.Fatalf("Failed to load config: %v", err)
+		}
+		if cfg.ScraperBaseURL != "http://env-scraper:8081" {
+			t.Errorf("Expected ScraperBaseURL from env, got '%s'", cfg.ScraperBaseURL)
+		}
+		if cfg.WorkerConcurrency != 6 {
+			t.Errorf("Expected WorkerConcurrency 6, got %d", cfg.WorkerConcurrency)
+		}
+		if cfg.LinkScoreThreshold != 0.5 {
+			t.Errorf("Expected default LinkScoreThreshold 0.5, got %v", cfg.LinkScoreThreshold)
+		}
+	})
+
+	t.Run("env overrides file", func(t *testing.T) {
+		t.Setenv("WORKER_CONCURRENCY", "6")
+		t.Setenv("DB_PASSWORD", "from-env")
+
+		cfg, err := LoadFile(writeConfigFile(t, "controller.yaml", yamlFile+"db_password: from-file\n"))
+		if err != nil {
+			t.Fatalf("Failed to load config: %v", err)
+		}
+		if cfg.WorkerConcurrency != 6 {
+			t.Errorf("Expected env WorkerConcurrency 6, got %d", cfg.WorkerConcurrency)
+		}
+		if cfg.DBPassword != "from-env" {
+			t.Errorf("Expected DBPassword from env, got '%s'", cfg.DBPassword)
+		}
+		if cfg.LinkScoreThreshold != 0.7 {
+			t.Errorf("Expected file LinkScoreThreshold 0.7, got %v", cfg.LinkScoreThreshold)
+		}
+	})
+
+	t.Run("json file via CONTROLLER_CONFIG", func(t *testing.T) {
+		t.Setenv(ConfigFileEnv, writeConfigFile(t, "controller.json", `{"controller_port": 9191, "image_cache": "NONE"}`))
+
+		cfg, err := Load()
+		if err != nil {
+			t.Fatalf("Failed to load config: %v", err)
+		}
+		if cfg.Port != 9191 {
+			t.Errorf("Expected Port 9191, got %d", cfg.Port)
+		}
+		if cfg.ImageCache != ImageCacheNone {
+			t.Errorf("Expected ImageCache none, got '%s'", cfg.ImageCache)
+		}
+	})
+
+	t.Run("unknown key", func(t *testing.T) {
+		if _, err := LoadFile(writeConfigFile(t, "controller.yaml", "worker_concurency: 4\n")); err == nil {
+			t.Error("Expected error for misspelled key, got nil")
+		}
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		if _, err := LoadFile(filepath.Join(t.TempDir(), "missing.yaml")); err == nil {
+			t.Error("Expected error for missing file, got nil")
+		}
+	})
+
+	t.Run("file values are validated", func(t *testing.T) {
+		_, err := LoadFile(writeConfigFile(t, "controller.yaml", "link_score_threshold: 1.5\n"))
+		var validationErr *ValidationError
+		if !errors.As(err, &validationErr) {
+			t.Errorf("Expected *ValidationError, got %v", err)
+		}
+	})
+}
+
+func TestNamespaceConfig(t *testing.T) {
+	t.Setenv("NAMESPACE_API_KEYS", "key-a=team-a, key-b = team-b")
+	t.Setenv("PUBLIC_NAMESPACE", "public")
+
+	cfg, err := LoadFile("")
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	if len(cfg.NamespaceAPIKeys) != 2 || cfg.NamespaceAPIKeys["key-a"] != "team-a" || cfg.NamespaceAPIKeys["key-b"] != "team-b" {
+		t.Errorf("Unexpected NamespaceAPIKeys: %v", cfg.NamespaceAPIKeys)
+	}
+	if cfg.PublicNamespace != "public" {
+		t.Errorf("Expected PublicNamespace public, got %q", cfg.PublicNamespace)
+	}
+}
+
+func TestDomainScoreThresholds(t *testing.T) {
+	t.Run("env", func(t *testing.T) {
+		t.Setenv("DOMAIN_SCORE_THRESHOLDS", "ourblog.com=0.1, contentfarm.net = 0.9")
+
+		cfg, err := LoadFile("")
+		if err != nil {
+			t.Fatalf("Failed to load config: %v", err)
+		}
+		if len(cfg.DomainScoreThresholds) != 2 || cfg.DomainScoreThresholds["ourblog.com"] != 0.1 || cfg.DomainScoreThresholds["contentfarm.net"] != 0.9 {
+			t.Errorf("Unexpected DomainScoreThresholds: %v", cfg.DomainScoreThresholds)
+		}
+	})
+
+	t.Run("file", func(t *testing.T) {
+		cfg, err := LoadFile(writeConfigFile(t, "controller.yaml", "domain_score_thresholds:\n  ourblog.com: 0.1\n"))
+		if err != nil {
+			t.Fatalf("Failed to load config: %v", err)
+		}
+		if cfg.DomainScoreThresholds["ourblog.com"] != 0.1 {
+			t.Errorf("Unexpected DomainScoreThresholds: %v", cfg.DomainScoreThresholds)
+		}
+	})
+
+	tests := []struct {
+		name  string
+		value string
+		want  []string
+	}{
+		{"out of range", "ourblog.com=1.5", []string{`DOMAIN_SCORE_THRESHOLDS: threshold for "ourblog.com"`}},
+		{"not a number", "ourblog.com=high", []string{`DOMAIN_SCORE_THRESHOLDS: threshold for "ourblog.com"`}},
+		{"missing value", "ourblog.com", []string{`DOMAIN_SCORE_THRESHOLDS: threshold for "ourblog.com"`}},
+		{"missing domain", "=0.5", []string{"DOMAIN_SCORE_THRESHOLDS: domain is required"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("DOMAIN_SCORE_THRESHOLDS", tt.value)
+
+			_, err := LoadFile("")
+			var validationErr *ValidationError
+			if !errors.As(err, &validationErr) {
+				t.Fatalf("Expected *ValidationError, got %v", err)
+			}
+			if len(validationErr.Problems) != len(tt.want) {
+				t.Fatalf("Expected %d problems, got %v", len(tt.want), validationErr.Problems)
+			}
+			for i, want := range tt.want {
+				if !strings.HasPrefix(validationErr.Problems[i], want) {
+					t.Errorf("Problem %d: expected it to start with %q, got %q", i, want, validationErr.Problems[i])
+				}
+			}
+		})
+	}
+}
+
+func TestRescrapeAfter(t *testing.T) {
+	t.Run("env", func(t *testing.T) {
+		t.Setenv("RESCRAPE_AFTER", "news.example.com=168h, default = 720h")
+
+		cfg, err := LoadFile("")
+		if err != nil {
+			t.Fatalf("Failed to load config: %v", err)
+		}
+		if len(cfg.RescrapeAfter) != 2 || cfg.RescrapeAfter["news.example.com"] != 168*time.Hour || cfg.RescrapeAfter[RescrapeAfterDefault] != 720*time.Hour {
+			t.Errorf("Unexpected RescrapeAfter: %v", cfg.RescrapeAfter)
+		}
+	})
+
+	t.Run("file", func(t *testing.T) {
+		cfg, err := LoadFile(writeConfigFile(t, "controller.yaml", "stale_rescrape_enabled: true\nrescrape_after:\n  news.example.com: 24h\n"))
+		if err != nil {
+			t.Fatalf("Failed to load config: %v", err)
+		}
+		// File entries are added to the built-in default window rather than replacing it
+		if !cfg.StaleRescrapeEnabled || cfg.RescrapeAfter["news.example.com"] != 24*time.Hour || cfg.RescrapeAfter[RescrapeAfterDefault] != 720*time.Hour {
+			t.Errorf("Unexpected RescrapeAfter: %v (enabled %v)", cfg.RescrapeAfter, cfg.StaleRescrapeEnabled)
+		}
+	})
+
+	tests := []struct {
+		name  string
+		value string
+		want  []string
+	}{
+		{"not a duration", "news.example.com=weekly", []string{`RESCRAPE_AFTER: window for "news.example.com"`}},
+		{"negative", "news.example.com=-1h", []string{`RESCRAPE_AFTER: window for "news.example.com"`}},
+		{"missing value", "news.example.com", []string{`RESCRAPE_AFTER: window for "news.example.com"`}},
+		{"missing domain", "=24h", []string{"RESCRAPE_AFTER: domain is required"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("RESCRAPE_AFTER", tt.value)
+
+			_, err := LoadFile("")
+			var validationErr *ValidationError
+			if !errors.As(err, &validationErr) {
+				t.Fatalf("Expected *ValidationError, got %v", err)
+			}
+			if len(validationErr.Problems) != len(tt.want) {
+				t.Fatalf("Expected %d problems, got %v", len(tt.want), validationErr.Problems)
+			}
+			for i, want := range tt.want {
+				if !strings.HasPrefix(validationErr.Problems[i], want) {
+					t.Errorf("Problem %d: expected it to start with %q, got %q", i, want, validationErr.Problems[i])
+				}
+			}
+		})
+	}
+}
+
+func TestHTTPLatencyBuckets(t *testing.T) {
+	t.Run("env", func(t *testing.T) {
+		t.Setenv("HTTP_LATENCY_BUCKETS", "0.05, 0.2,1,3")
+
+		cfg, err := LoadFile("")
+		if err != nil {
+			t.Fatalf("Failed to load config: %v", err)
+		}
+		if want := []float64{0.05, 0.2, 1, 3}; !reflect.DeepEqual(cfg.HTTPLatencyBuckets, want) {
+			t.Errorf("Expected buckets %v, got %v", want, cfg.HTTPLatencyBuckets)
+		}
+	})
+
+	tests := []struct {
+		name  string
+		value string
+		want  string
+	}{
+		{"not a number", "0.1,fast", "HTTP_LATENCY_BUCKETS: bucket 2"},
+		{"zero", "0,1", "HTTP_LATENCY_BUCKETS: bucket 1"},
+		{"out of order", "1,0.5", "HTTP_LATENCY_BUCKETS must be in increasing order"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("HTTP_LATENCY_BUCKETS", tt.value)
+
+			_, err := LoadFile("")
+			var validationErr *ValidationError
+			if !errors.As(err, &validationErr) {
+				t.Fatalf("Expected *ValidationError, got %v", err)
+			}
+			if len(validationErr.Problems) != 1 || !strings.HasPrefix(validationErr.Problems[0], tt.want) {
+				t.Errorf("Expected one problem starting with %q, got %v", tt.want, validationErr.Problems)
+			}
+		})
+	}
+}
+
+func TestTracingConfig(t *testing.T) {
+	t.Setenv("TRACING_ENABLED", "false")
+	t.Setenv("TRACING_SAMPLE_RATIO", "0.25")
+	t.Setenv("OTLP_ENDPOINT", "http://collector:4318")
+	t.Setenv("TRACING_SERVICE_NAME", "controller-staging")
+
+	cfg, err := LoadFile("")
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	if cfg.TracingEnabled || cfg.TracingSampleRatio != 0.25 || cfg.OTLPEndpoint != "http://collector:4318" || cfg.TracingServiceName != "controller-staging" {
+		t.Errorf("Unexpected tracing config: enabled %v, ratio %g, endpoint %q, service %q",
+			cfg.TracingEnabled, cfg.TracingSampleRatio, cfg.OTLPEndpoint, cfg.TracingServiceName)
+	}
+
+	t.Setenv("TRACING_SAMPLE_RATIO", "1.5")
+	var validationErr *ValidationError
+	if _, err := LoadFile(""); !errors.As(err, &validationErr) || !strings.HasPrefix(validationErr.Problems[0], "TRACING_SAMPLE_RATIO") {
+		t.Errorf("Expected a TRACING_SAMPLE_RATIO problem, got %v", err)
+	}
+}
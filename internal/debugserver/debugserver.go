@@ -0,0 +1,91 @@
+// Package debugserver serves net/http/pprof profiles and a runtime stats snapshot on a
+// listener of its own, kept apart from the public API so profiles are never exposed with it.
+package debugserver
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	"time"
+)
+
+// WorkerStats reports queue worker pool occupancy; *queue.Worker satisfies it
+type WorkerStats interface {
+	ActiveTasks() int
+	Concurrency() int
+}
+
+// RuntimeStats is the body of GET /debug/vars
+type RuntimeStats struct {
+	Goroutines int              `json:"goroutines"`
+	Heap       HeapStats        `json:"heap"`
+	Worker     *WorkerPoolStats `json:"worker,omitempty"`
+}
+
+// HeapStats is a subset of runtime.MemStats
+type HeapStats struct {
+	AllocBytes    uint64  `json:"alloc_bytes"`
+	InuseBytes    uint64  `json:"inuse_bytes"`
+	SysBytes      uint64  `json:"sys_bytes"`
+	Objects       uint64  `json:"objects"`
+	NumGC         uint32  `json:"num_gc"`
+	PauseTotalSec float64 `json:"pause_total_seconds"`
+}
+
+// WorkerPoolStats reports how many worker slots are busy
+type WorkerPoolStats struct {
+	Active      int `json:"active"`
+	Concurrency int `json:"concurrency"`
+}
+
+// Handler returns the debug routes: the pprof index and profiles under /debug/pprof/ and
+// the runtime snapshot at /debug/vars. worker may be nil.
+func Handler(worker WorkerStats) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	mux.HandleFunc("GET /debug/vars", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(Snapshot(worker))
+	})
+	return mux
+}
+
+// Snapshot collects the current runtime stats. worker may be nil.
+func Snapshot(worker WorkerStats) RuntimeStats {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+
+	stats := RuntimeStats{
+		Goroutines: runtime.NumGoroutine(),
+		Heap: HeapStats{
+			AllocBytes:    mem.HeapAlloc,
+			InuseBytes:    mem.HeapInuse,
+			SysBytes:      mem.HeapSys,
+			Objects:       mem.HeapObjects,
+			NumGC:         mem.NumGC,
+			PauseTotalSec: time.Duration(mem.PauseTotalNs).Seconds(),
+		},
+	}
+	if worker != nil {
+		stats.Worker = &WorkerPoolStats{
+			Active:      worker.ActiveTasks(),
+			Concurrency: worker.Concurrency(),
+		}
+	}
+	return stats
+}
+
+// NewServer returns a server for Handler on addr. Profiles such as /debug/pprof/profile
+// run for up to a minute, so there is no write timeout.
+func NewServer(addr string, worker WorkerStats) *http.Server {
+	return &http.Server{
+		Addr:              addr,
+		Handler:           Handler(worker),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+}
@@ -0,0 +1,52 @@
+package debugserver
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type fakeWorker struct{ active, concurrency int }
+
+func (f fakeWorker) ActiveTasks() int { return f.active }
+func (f fakeWorker) Concurrency() int { return f.concurrency }
+
+func TestHandlerServesPprof(t *testing.T) {
+	h := Handler(nil)
+	for _, path := range []string{"/debug/pprof/", "/debug/pprof/goroutine?debug=1", "/debug/pprof/cmdline"} {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		if w.Code != http.StatusOK {
+			t.Errorf("GET %s: expected 200, got %d", path, w.Code)
+		}
+	}
+}
+
+func TestHandlerServesVars(t *testing.T) {
+	w := httptest.NewRecorder()
+	Handler(fakeWorker{active: 3, concurrency: 10}).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/debug/vars", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+
+	var stats RuntimeStats
+	if err := json.NewDecoder(w.Body).Decode(&stats); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if stats.Goroutines <= 0 {
+		t.Errorf("expected a goroutine count, got %d", stats.Goroutines)
+	}
+	if stats.Heap.AllocBytes == 0 || stats.Heap.SysBytes == 0 {
+		t.Errorf("expected heap stats, got %+v", stats.Heap)
+	}
+	if stats.Worker == nil || stats.Worker.Active != 3 || stats.Worker.Concurrency != 10 {
+		t.Errorf("expected worker 3/10, got %+v", stats.Worker)
+	}
+}
+
+func TestSnapshotWithoutWorker(t *testing.T) {
+	if stats := Snapshot(nil); stats.Worker != nil {
+		t.Errorf("expected no worker stats, got %+v", stats.Worker)
+	}
+}
@@ -0,0 +1,57 @@
+// Package domaintag derives the domain tags scraped requests are labelled with. Requests are
+// tagged with their registrable domain, so blog.example.co.uk and shop.example.co.uk share
+// the example.co.uk tag, and optionally with their full host as well.
+package domaintag
+
+import (
+	"net"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/publicsuffix"
+)
+
+// Host returns the URL's lowercased hostname without a leading "www.", or "" if the URL
+// cannot be parsed or has no host. Ports and IPv6 brackets are dropped.
+func Host(rawURL string) string {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+
+	host := strings.TrimSuffix(strings.ToLower(parsed.Hostname()), ".")
+	// Remove "www." prefix if present
+	return strings.TrimPrefix(host, "www.")
+}
+
+// Registrable returns the registrable domain (eTLD+1) of the URL's host according to the
+// public suffix list, e.g. example.co.uk for blog.example.co.uk. IP addresses, single-label
+// hosts such as localhost and hosts that are themselves a public suffix are returned as Host
+// returns them.
+func Registrable(rawURL string) string {
+	host := Host(rawURL)
+	if host == "" || net.ParseIP(host) != nil {
+		return host
+	}
+	domain, err := publicsuffix.EffectiveTLDPlusOne(host)
+	if err != nil {
+		return host
+	}
+	return domain
+}
+
+// Tags returns the domain tags of a URL: its registrable domain, followed by its full host
+// when withHost is set and the host is a subdomain. A URL without a host has no tags.
+func Tags(rawURL string, withHost bool) []string {
+	domain := Registrable(rawURL)
+	if domain == "" {
+		return nil
+	}
+	tags := []string{domain}
+	if withHost {
+		if host := Host(rawURL); host != domain {
+			tags = append(tags, host)
+		}
+	}
+	return tags
+}
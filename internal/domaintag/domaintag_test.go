@@ -0,0 +1,74 @@
+package domaintag
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestHost(t *testing.T) {
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{"https://www.example.com/page", "example.com"},
+		{"https://Blog.Example.COM/page", "blog.example.com"},
+		{"https://example.com:8443/page", "example.com"},
+		{"https://example.com./page", "example.com"},
+		{"http://[2001:db8::1]:8080/", "2001:db8::1"},
+		{"not a url\x7f", ""},
+		{"/relative/path", ""},
+	}
+	for _, tt := range tests {
+		if got := Host(tt.url); got != tt.want {
+			t.Errorf("Host(%q) = %q, want %q", tt.url, got, tt.want)
+		}
+	}
+}
+
+func TestRegistrable(t *testing.T) {
+	tests := []struct {
+		name string
+		url  string
+		want string
+	}{
+		{"bare domain", "https://example.com/", "example.com"},
+		{"www", "https://www.example.com/", "example.com"},
+		{"subdomain", "https://blog.example.com/post", "example.com"},
+		{"co.uk subdomain", "https://blog.example.co.uk/post", "example.co.uk"},
+		{"co.uk other subdomain", "https://shop.example.co.uk/", "example.co.uk"},
+		{"co.uk bare", "https://example.co.uk/", "example.co.uk"},
+		{"deep subdomain", "https://a.b.c.example.com.au/", "example.com.au"},
+		{"private suffix", "https://someone.github.io/repo", "someone.github.io"},
+		{"port", "https://blog.example.co.uk:8443/post", "example.co.uk"},
+		{"ipv4", "http://192.168.1.10:8080/", "192.168.1.10"},
+		{"ipv6", "http://[2001:db8::1]/", "2001:db8::1"},
+		{"single label", "http://localhost:3000/", "localhost"},
+		{"public suffix itself", "https://co.uk/", "co.uk"},
+		{"unparseable", "://", ""},
+	}
+	for _, tt := range tests {
+		if got := Registrable(tt.url); got != tt.want {
+			t.Errorf("%s: Registrable(%q) = %q, want %q", tt.name, tt.url, got, tt.want)
+		}
+	}
+}
+
+func TestTags(t *testing.T) {
+	tests := []struct {
+		name     string
+		url      string
+		withHost bool
+		want     []string
+	}{
+		{"registrable only", "https://blog.example.co.uk/", false, []string{"example.co.uk"}},
+		{"with host", "https://blog.example.co.uk/", true, []string{"example.co.uk", "blog.example.co.uk"}},
+		{"with host, no subdomain", "https://www.example.co.uk/", true, []string{"example.co.uk"}},
+		{"with host, ip", "http://10.0.0.1:8080/", true, []string{"10.0.0.1"}},
+		{"no host", "/relative", true, nil},
+	}
+	for _, tt := range tests {
+		if got := Tags(tt.url, tt.withHost); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: Tags(%q, %v) = %v, want %v", tt.name, tt.url, tt.withHost, got, tt.want)
+		}
+	}
+}
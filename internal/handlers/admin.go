@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/docutag/controller/internal/storage"
+	"github.com/docutag/controller/internal/urlguard"
+)
+
+// GetDomainPolicy handles GET /api/admin/domain-policy and returns the effective lists
+func (h *Handler) GetDomainPolicy(w http.ResponseWriter, r *http.Request) {
+	allowlist := h.domainPolicy.Allowlist()
+	if allowlist == nil {
+		allowlist = []string{}
+	}
+	denylist := h.domainPolicy.Denylist()
+	if denylist == nil {
+		denylist = []string{}
+	}
+
+	respondJSON(w, map[string]interface{}{
+		"enabled":   h.domainPolicy.Enabled(),
+		"allowlist": allowlist,
+		"denylist":  denylist,
+	}, http.StatusOK)
+}
+
+// CheckDomainPolicy handles POST /api/admin/domain-policy/check?url=
+// It reports whether the URL would be accepted without scraping anything.
+func (h *Handler) CheckDomainPolicy(w http.ResponseWriter, r *http.Request) {
+	target := r.URL.Query().Get("url")
+	if target == "" && r.Body != nil && r.ContentLength != 0 {
+		var body struct {
+			URL string `json:"url"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			respondErrorCode(w, ErrCodeInvalidRequestBody, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+		target = body.URL
+	}
+	if target == "" {
+		respondErrorCode(w, ErrCodeValidationFailed, "url is required", http.StatusBadRequest)
+		return
+	}
+
+	if _, err := urlguard.CheckURL(target); err != nil {
+		respondErrorCode(w, ErrCodeValidationFailed, fmt.Sprintf("Invalid URL: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	decision, err := h.domainPolicy.Evaluate(target)
+	if err != nil {
+		respondErrorCode(w, ErrCodeValidationFailed, fmt.Sprintf("Invalid URL: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	respondJSON(w, map[string]interface{}{
+		"url":             target,
+		"host":            decision.Host,
+		"allowed":         decision.Allowed,
+		"rule":            decision.Rule,
+		"matched_pattern": decision.MatchedPattern,
+	}, http.StatusOK)
+}
+
+// MigrationsResponse is returned by GET /api/admin/migrations
+type MigrationsResponse struct {
+	CurrentVersion int                       `json:"current_version"` // Newest applied migration, 0 if none
+	LatestVersion  int                       `json:"latest_version"`  // Newest migration this build knows
+	Pending        int                       `json:"pending"`
+	Migrations     []storage.MigrationStatus `json:"migrations"`
+}
+
+// ListMigrations handles GET /api/admin/migrations and reports which schema migrations are applied
+func (h *Handler) ListMigrations(w http.ResponseWriter, r *http.Request) {
+	statuses, err := h.storage.MigrationStatus()
+	if err != nil {
+		respondErrorCode(w, ErrCodeInternal, fmt.Sprintf("Failed to read migrations: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	resp := MigrationsResponse{Pending: storage.PendingMigrations(statuses), Migrations: statuses}
+	for _, st := range statuses {
+		resp.LatestVersion = max(resp.LatestVersion, st.Version)
+		if st.Applied {
+			resp.CurrentVersion = max(resp.CurrentVersion, st.Version)
+		}
+	}
+	respondJSON(w, resp, http.StatusOK)
+}
@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/docutag/controller/internal/urlguard"
+)
+
+func TestGetDomainPolicy(t *testing.T) {
+	t.Parallel()
+	h := &Handler{domainPolicy: urlguard.NewDomainPolicy([]string{"*.Example.com"}, []string{"ads.example.com."})}
+
+	w := httptest.NewRecorder()
+	serveRoute(h, w, httptest.NewRequest(http.MethodGet, "/api/admin/domain-policy", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", w.Code)
+	}
+
+	var resp struct {
+		Enabled   bool     `json:"enabled"`
+		Allowlist []string `json:"allowlist"`
+		Denylist  []string `json:"denylist"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if !resp.Enabled {
+		t.Error("expected policy to be enabled")
+	}
+	if len(resp.Allowlist) != 1 || resp.Allowlist[0] != "*.example.com" {
+		t.Errorf("unexpected allowlist: %v", resp.Allowlist)
+	}
+	if len(resp.Denylist) != 1 || resp.Denylist[0] != "ads.example.com" {
+		t.Errorf("unexpected denylist: %v", resp.Denylist)
+	}
+
+	// No policy configured reports empty lists rather than null
+	w = httptest.NewRecorder()
+	(&Handler{}).GetDomainPolicy(w, httptest.NewRequest(http.MethodGet, "/api/admin/domain-policy", nil))
+	if body := w.Body.String(); !bytes.Contains([]byte(body), []byte(`"allowlist":[]`)) {
+		t.Errorf("expected empty allowlist array, got %s", body)
+	}
+}
+
+func TestCheckDomainPolicy(t *testing.T) {
+	t.Parallel()
+	h := &Handler{domainPolicy: urlguard.NewDomainPolicy([]string{"*.example.com"}, []string{"ads.example.com"})}
+
+	tests := []struct {
+		name        string
+		target      string
+		wantStatus  int
+		wantAllowed bool
+		wantRule    string
+	}{
+		{name: "allowed subdomain", target: "https://news.example.com/a", wantStatus: http.StatusOK, wantAllowed: true},
+		{name: "denied", target: "https://ads.example.com/", wantStatus: http.StatusOK, wantRule: urlguard.RuleDomainDenied},
+		{name: "not allowed", target: "https://other.org/", wantStatus: http.StatusOK, wantRule: urlguard.RuleDomainNotAllowed},
+		{name: "missing url", target: "", wantStatus: http.StatusBadRequest},
+		{name: "bad scheme", target: "ftp://example.com/", wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/api/admin/domain-policy/check", nil)
+			q := req.URL.Query()
+			if tt.target != "" {
+				q.Set("url", tt.target)
+			}
+			req.URL.RawQuery = q.Encode()
+
+			w := httptest.NewRecorder()
+			serveRoute(h, w, req)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.wantStatus, w.Code, w.Body.String())
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			var resp struct {
+				Allowed bool   `json:"allowed"`
+				Rule    string `json:"rule"`
+			}
+			if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if resp.Allowed != tt.wantAllowed || resp.Rule != tt.wantRule {
+				t.Errorf("got allowed=%v rule=%q, want allowed=%v rule=%q", resp.Allowed, resp.Rule, tt.wantAllowed, tt.wantRule)
+			}
+		})
+	}
+}
+
+func TestScrapeRejectsDeniedDomain(t *testing.T) {
+	t.Parallel()
+	h := &Handler{
+		urlGuard:     urlguard.NewWithResolver(false, publicResolver{}),
+		domainPolicy: urlguard.NewDomainPolicy(nil, []string{"*.blocked.com"}),
+	}
+
+	body := bytes.NewBufferString(`{"url": "https://www.blocked.com/article"}`)
+	w := httptest.NewRecorder()
+	serveRoute(h, w, httptest.NewRequest(http.MethodPost, "/api/scrape-requests", body))
+	if w.Code != http.StatusForbidden {
+		t.Errorf("CreateScrapeRequest: expected status 403, got %d: %s", w.Code, w.Body.String())
+	}
+
+	body = bytes.NewBufferString(`{"url": "https://www.blocked.com/article"}`)
+	w = httptest.NewRecorder()
+	serveRoute(h, w, httptest.NewRequest(http.MethodPost, "/api/scrape", body))
+	if w.Code != http.StatusForbidden {
+		t.Errorf("ScrapeURL: expected status 403, got %d: %s", w.Code, w.Body.String())
+	}
+}
@@ -0,0 +1,381 @@
+package handlers
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/docutag/controller/internal/clients"
+	"github.com/docutag/controller/internal/storage"
+)
+
+// defaultArchiveMaxBytes caps GET /api/requests/{id}/archive when SetArchiveMaxBytes is given 0
+const defaultArchiveMaxBytes = 100 << 20
+
+// SetArchiveMaxBytes caps the uncompressed size of a request archive. Values <= 0 use the default.
+func (h *Handler) SetArchiveMaxBytes(maxBytes int64) {
+	if maxBytes <= 0 {
+		maxBytes = defaultArchiveMaxBytes
+	}
+	h.archiveMaxBytes = maxBytes
+}
+
+// archiveLimit returns the archive cap, falling back to the default on handlers built without one
+func (h *Handler) archiveLimit() int64 {
+	if h.archiveMaxBytes <= 0 {
+		return defaultArchiveMaxBytes
+	}
+	return h.archiveMaxBytes
+}
+
+// ArchiveManifest is manifest.json, the last file of a request archive
+type ArchiveManifest struct {
+	RequestID     string                `json:"request_id"`
+	CreatedAt     time.Time             `json:"created_at"`
+	Files         []ArchiveFile         `json:"files"`
+	SkippedImages []ArchiveSkippedImage `json:"skipped_images"`
+	ImagesError   string                `json:"images_error,omitempty"` // Set when the scraper could not list the images
+}
+
+// ArchiveFile describes one file of the archive
+type ArchiveFile struct {
+	Path        string `json:"path"`
+	ContentType string `json:"content_type"`
+	Size        int64  `json:"size"`
+	SHA256      string `json:"sha256"`
+	ImageID     string `json:"image_id,omitempty"`
+	SourceURL   string `json:"source_url,omitempty"`
+	Error       string `json:"error,omitempty"` // The image stream broke off; the file is incomplete
+}
+
+// ArchiveSkippedImage is an image left out because the scraper could not provide it
+type ArchiveSkippedImage struct {
+	ImageID string `json:"image_id"`
+	URL     string `json:"url,omitempty"`
+	Error   string `json:"error"`
+}
+
+// archiveEntry is one file waiting to be written. Images are streamed from body; everything
+// else is held in data.
+type archiveEntry struct {
+	path        string
+	contentType string
+	imageID     string
+	sourceURL   string
+	data        []byte
+	body        io.Reader
+	closer      io.Closer
+	size        int64 // -1 until known
+}
+
+// requestArchive collects the files of one request archive before any byte is sent, so the
+// size cap can still be answered with 413
+type requestArchive struct {
+	requestID   string
+	entries     []*archiveEntry
+	skipped     []ArchiveSkippedImage
+	imagesError string
+}
+
+func (a *requestArchive) addBytes(path, contentType string, data []byte) {
+	a.entries = append(a.entries, &archiveEntry{path: path, contentType: contentType, data: data, size: int64(len(data))})
+}
+
+// skip notes an image that could not be included
+func (a *requestArchive) skip(imageID, imageURL string, err error) {
+	a.skipped = append(a.skipped, ArchiveSkippedImage{ImageID: imageID, URL: imageURL, Error: err.Error()})
+}
+
+// remove drops an entry that turned out to be unreadable
+func (a *requestArchive) remove(entry *archiveEntry) {
+	for i, e := range a.entries {
+		if e == entry {
+			a.entries = append(a.entries[:i], a.entries[i+1:]...)
+			if e.closer != nil {
+				e.closer.Close()
+			}
+			return
+		}
+	}
+}
+
+// knownSize sums the sizes known so far
+func (a *requestArchive) knownSize() int64 {
+	var total int64
+	for _, e := range a.entries {
+		if e.size > 0 {
+			total += e.size
+		}
+	}
+	return total
+}
+
+// close releases the image streams that were opened
+func (a *requestArchive) close() {
+	for _, e := range a.entries {
+		if e.closer != nil {
+			e.closer.Close()
+		}
+	}
+}
+
+// write streams the archive as a zip, followed by its manifest
+func (a *requestArchive) write(w io.Writer, createdAt time.Time) error {
+	zw := zip.NewWriter(w)
+	manifest := ArchiveManifest{
+		RequestID:     a.requestID,
+		CreatedAt:     createdAt,
+		Files:         make([]ArchiveFile, 0, len(a.entries)),
+		SkippedImages: a.skipped,
+		ImagesError:   a.imagesError,
+	}
+	if manifest.SkippedImages == nil {
+		manifest.SkippedImages = []ArchiveSkippedImage{}
+	}
+
+	for _, e := range a.entries {
+		method := zip.Deflate
+		if e.imageID != "" {
+			method = zip.Store // Image formats are compressed already
+		}
+		fw, err := zw.CreateHeader(&zip.FileHeader{Name: e.path, Method: method, Modified: createdAt})
+		if err != nil {
+			return err
+		}
+		hash := sha256.New()
+		src := e.body
+		if src == nil {
+			src = bytes.NewReader(e.data)
+		}
+		n, copyErr := io.Copy(io.MultiWriter(fw, hash), src)
+		file := ArchiveFile{
+			Path:        e.path,
+			ContentType: e.contentType,
+			Size:        n,
+			SHA256:      hex.EncodeToString(hash.Sum(nil)),
+			ImageID:     e.imageID,
+			SourceURL:   e.sourceURL,
+		}
+		if copyErr != nil {
+			if e.body == nil {
+				return copyErr // The client went away
+			}
+			file.Error = copyErr.Error()
+			slog.Default().Warn("image stream broke off while archiving", "request_id", a.requestID, "image_id", e.imageID, "error", copyErr)
+		}
+		manifest.Files = append(manifest.Files, file)
+	}
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	fw, err := zw.CreateHeader(&zip.FileHeader{Name: "manifest.json", Method: zip.Deflate, Modified: createdAt})
+	if err != nil {
+		return err
+	}
+	if _, err := fw.Write(data); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// GetRequestArchive handles GET /api/requests/{id}/archive, streaming a zip with the request
+// JSON, its cleaned and raw text, its images and a manifest of hashes
+func (h *Handler) GetRequestArchive(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if id == "" {
+		respondErrorCode(w, ErrCodeValidationFailed, "Request ID is required", http.StatusBadRequest)
+		return
+	}
+	record, err := h.store(r).GetRequest(id)
+	if err != nil {
+		if err.Error() == "request not found" {
+			respondErrorCode(w, ErrCodeRequestNotFound, "Request not found", http.StatusNotFound)
+			return
+		}
+		respondErrorCode(w, ErrCodeInternal, fmt.Sprintf("Failed to get request: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	archive, err := newRequestArchive(record)
+	if err != nil {
+		respondErrorCode(w, ErrCodeInternal, fmt.Sprintf("Failed to encode request: %v", err), http.StatusInternalServerError)
+		return
+	}
+	defer archive.close()
+
+	maxBytes := h.archiveLimit()
+	h.openArchiveImages(r.Context(), archive, record.ScraperUUID, maxBytes)
+	if size := archive.knownSize(); size > maxBytes {
+		respondErrorDetails(w, ErrCodeArchiveTooLarge,
+			fmt.Sprintf("Archive would be larger than the %d byte limit", maxBytes),
+			http.StatusRequestEntityTooLarge,
+			map[string]interface{}{"size_bytes": size, "max_bytes": maxBytes})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", archiveFileName(record.ID)))
+	w.WriteHeader(http.StatusOK)
+	if err := archive.write(w, time.Now().UTC()); err != nil {
+		// Headers are already sent; the client sees a truncated zip
+		slog.Default().Warn("failed to stream request archive", "request_id", record.ID, "error", err)
+	}
+}
+
+// newRequestArchive adds the request JSON and its texts
+func newRequestArchive(record *storage.Request) (*requestArchive, error) {
+	archive := &requestArchive{requestID: record.ID}
+	data, err := json.MarshalIndent(newControllerResponse(record), "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	archive.addBytes("request.json", "application/json", data)
+
+	scraperMeta, _ := record.Metadata["scraper_metadata"].(map[string]interface{})
+	analyzerMeta, _ := record.Metadata["analyzer_metadata"].(map[string]interface{})
+	cleaned := getString(analyzerMeta, "cleaned_text", getString(analyzerMeta, "heuristic_cleaned_text", ""))
+	if cleaned != "" {
+		archive.addBytes("cleaned_text.txt", "text/plain; charset=utf-8", []byte(cleaned))
+	}
+	if raw := getString(scraperMeta, "raw_text", ""); raw != "" {
+		archive.addBytes("raw_text.txt", "text/plain; charset=utf-8", []byte(raw))
+	}
+	return archive, nil
+}
+
+// openArchiveImages lists the images of a scrape and opens their content, noting the ones that
+// fail as skipped. It stops as soon as the archive is known to exceed maxBytes. The scraper's
+// Content-Length is trusted for that check; an image sent without one is read into memory, up
+// to what is left of maxBytes, so the archive cannot outgrow the cap once streaming has started.
+func (h *Handler) openArchiveImages(ctx context.Context, archive *requestArchive, scraperUUID *string, maxBytes int64) {
+	if scraperUUID == nil || *scraperUUID == "" || h.scraper == nil {
+		return
+	}
+	list, err := h.scraper.GetImagesByScrapeID(ctx, *scraperUUID, clients.ImageListOptions{})
+	if err != nil {
+		archive.imagesError = err.Error()
+		return
+	}
+
+	var unsized []*archiveEntry
+	for i, image := range list.Images {
+		entry, err := h.openArchiveImage(ctx, image)
+		if err != nil {
+			archive.skip(image.ID, image.URL, err)
+			continue
+		}
+		entry.path = archiveImagePath(i+1, image, entry.contentType)
+		archive.entries = append(archive.entries, entry)
+		if entry.size < 0 {
+			unsized = append(unsized, entry)
+		}
+		if archive.knownSize() > maxBytes {
+			return
+		}
+	}
+
+	for _, entry := range unsized {
+		remaining := maxBytes - archive.knownSize()
+		data, err := io.ReadAll(io.LimitReader(entry.body, remaining+1))
+		if err != nil {
+			archive.remove(entry)
+			archive.skip(entry.imageID, entry.sourceURL, err)
+			continue
+		}
+		entry.data, entry.body, entry.size = data, nil, int64(len(data))
+		if entry.size > remaining {
+			return
+		}
+	}
+}
+
+// openArchiveImage opens an image's bytes, from inline data or the scraper's file endpoint
+func (h *Handler) openArchiveImage(ctx context.Context, image *clients.ImageInfo) (*archiveEntry, error) {
+	entry := &archiveEntry{imageID: image.ID, sourceURL: image.URL, size: -1}
+	var body io.Reader
+	if image.Base64Data != "" {
+		var decoded io.Reader
+		entry.contentType, decoded = decodeBase64Image(image.Base64Data)
+		data, err := io.ReadAll(decoded)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode image data: %w", err)
+		}
+		body, entry.size = bytes.NewReader(data), int64(len(data))
+	} else {
+		content, err := h.scraper.GetImageContent(ctx, image.ID)
+		if err != nil {
+			return nil, err
+		}
+		body, entry.closer = content.Body, content.Body
+		entry.contentType, entry.size = content.ContentType, content.ContentLength
+	}
+
+	buffered := bufio.NewReader(body)
+	if entry.contentType == "" || entry.contentType == "application/octet-stream" {
+		head, _ := buffered.Peek(512)
+		entry.contentType = http.DetectContentType(head)
+	}
+	entry.body = buffered
+	return entry, nil
+}
+
+// archiveImageExtensions maps image content types to file extensions
+var archiveImageExtensions = map[string]string{
+	"image/jpeg":    ".jpg",
+	"image/png":     ".png",
+	"image/gif":     ".gif",
+	"image/webp":    ".webp",
+	"image/svg+xml": ".svg",
+	"image/avif":    ".avif",
+	"image/bmp":     ".bmp",
+	"image/x-icon":  ".ico",
+}
+
+// archiveImagePath names an image file: images/<position>-<id><ext>. The extension comes from
+// the content type, else the source URL.
+func archiveImagePath(position int, image *clients.ImageInfo, contentType string) string {
+	mediaType, _, _ := strings.Cut(contentType, ";")
+	ext, ok := archiveImageExtensions[strings.TrimSpace(strings.ToLower(mediaType))]
+	if !ok {
+		ext = ".bin"
+		if u, err := url.Parse(image.URL); err == nil {
+			if e := strings.ToLower(path.Ext(u.Path)); len(e) > 1 && len(e) <= 5 {
+				ext = e
+			}
+		}
+	}
+	name := safeFileName(image.ID)
+	if name == "" {
+		name = "image"
+	}
+	return fmt.Sprintf("images/%03d-%s%s", position, name, ext)
+}
+
+// archiveFileName is the download name of a request's archive
+func archiveFileName(requestID string) string {
+	return "request-" + safeFileName(requestID) + ".zip"
+}
+
+// safeFileName keeps letters, digits, '-' and '_' so IDs cannot escape their folder
+func safeFileName(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_' {
+			return r
+		}
+		return -1
+	}, s)
+}
@@ -0,0 +1,171 @@
+package handlers
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/docutag/controller/internal/clients"
+	"github.com/docutag/controller/internal/storage"
+)
+
+// archiveScraper serves three images for scrape-1: one with a length, one streamed without
+// one, and one whose file is gone
+func archiveScraper(t *testing.T, chunked []byte) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/scrapes/{id}/images", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(clients.ImageSearchResponse{Count: 3, Images: []*clients.ImageInfo{
+			{ID: "img-sized", URL: "https://example.com/a.png"},
+			{ID: "img-chunked", URL: "https://example.com/b.jpg"},
+			{ID: "img-gone", URL: "https://example.com/c.gif"},
+		}})
+	})
+	mux.HandleFunc("GET /api/images/{id}/file", func(w http.ResponseWriter, r *http.Request) {
+		switch r.PathValue("id") {
+		case "img-sized":
+			w.Header().Set("Content-Type", "image/png")
+			w.Write(testPNG)
+		case "img-chunked":
+			w.Header().Set("Content-Type", "image/jpeg")
+			w.Write(chunked[:1])
+			w.(http.Flusher).Flush() // No Content-Length
+			w.Write(chunked[1:])
+		default:
+			http.NotFound(w, r)
+		}
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func archiveTestRequest() *storage.Request {
+	scrape := "scrape-1"
+	return &storage.Request{ID: "req-archive", SourceType: "url", ScraperUUID: &scrape, Tags: []string{"news"},
+		Metadata: map[string]interface{}{
+			"scraper_metadata":  map[string]interface{}{"title": "Archived", "raw_text": "<p>Raw page</p>"},
+			"analyzer_metadata": map[string]interface{}{"cleaned_text": "Cleaned page"},
+		}}
+}
+
+func TestRequestArchive(t *testing.T) {
+	t.Parallel()
+	chunked := bytes.Repeat([]byte{0xff, 0xd8, 0xff}, 100)
+	h := &Handler{scraper: clients.NewScraperClient(archiveScraper(t, chunked).URL)}
+
+	archive, err := newRequestArchive(archiveTestRequest())
+	if err != nil {
+		t.Fatalf("newRequestArchive failed: %v", err)
+	}
+	defer archive.close()
+	h.openArchiveImages(context.Background(), archive, archiveTestRequest().ScraperUUID, defaultArchiveMaxBytes)
+
+	var buf bytes.Buffer
+	if err := archive.write(&buf, archiveTestRequest().CreatedAt); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("Not a zip: %v", err)
+	}
+	files := map[string][]byte{}
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatalf("Failed to open %s: %v", f.Name, err)
+		}
+		files[f.Name], _ = io.ReadAll(rc)
+		rc.Close()
+	}
+
+	want := map[string][]byte{
+		"cleaned_text.txt":           []byte("Cleaned page"),
+		"raw_text.txt":               []byte("<p>Raw page</p>"),
+		"images/001-img-sized.png":   testPNG,
+		"images/002-img-chunked.jpg": chunked,
+	}
+	for name, data := range want {
+		if !bytes.Equal(files[name], data) {
+			t.Errorf("%s: expected %d bytes, got %d", name, len(data), len(files[name]))
+		}
+	}
+	var request ControllerResponse
+	if err := json.Unmarshal(files["request.json"], &request); err != nil || request.ID != "req-archive" {
+		t.Errorf("Expected the request JSON, got %s (%v)", files["request.json"], err)
+	}
+
+	var manifest ArchiveManifest
+	if err := json.Unmarshal(files["manifest.json"], &manifest); err != nil {
+		t.Fatalf("Failed to decode manifest: %v", err)
+	}
+	if len(manifest.Files) != 5 {
+		t.Fatalf("Expected 5 files in the manifest, got %+v", manifest.Files)
+	}
+	for _, f := range manifest.Files {
+		sum := sha256.Sum256(files[f.Path])
+		if f.SHA256 != hex.EncodeToString(sum[:]) || f.Size != int64(len(files[f.Path])) {
+			t.Errorf("%s: manifest hash or size does not match the file", f.Path)
+		}
+	}
+	if len(manifest.SkippedImages) != 1 || manifest.SkippedImages[0].ImageID != "img-gone" || manifest.SkippedImages[0].Error == "" {
+		t.Errorf("Expected img-gone to be skipped, got %+v", manifest.SkippedImages)
+	}
+}
+
+func TestRequestArchiveSizeCap(t *testing.T) {
+	t.Parallel()
+	chunked := bytes.Repeat([]byte{0xff}, 4096)
+	h := &Handler{scraper: clients.NewScraperClient(archiveScraper(t, chunked).URL)}
+
+	tests := []struct {
+		name     string
+		maxBytes int64
+		tooLarge bool
+	}{
+		{"everything fits", 1 << 20, false},
+		{"sized image over the cap", 100, true},
+		{"unsized image over the cap", int64(len(testPNG)) + 1000, true},
+	}
+	for _, tt := range tests {
+		archive, err := newRequestArchive(archiveTestRequest())
+		if err != nil {
+			t.Fatalf("newRequestArchive failed: %v", err)
+		}
+		h.openArchiveImages(context.Background(), archive, archiveTestRequest().ScraperUUID, tt.maxBytes)
+		if got := archive.knownSize() > tt.maxBytes; got != tt.tooLarge {
+			t.Errorf("%s: expected too large %v, got size %d", tt.name, tt.tooLarge, archive.knownSize())
+		}
+		archive.close()
+	}
+}
+
+func TestArchiveImagePath(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		image       clients.ImageInfo
+		contentType string
+		want        string
+	}{
+		{clients.ImageInfo{ID: "abc", URL: "https://example.com/x.png"}, "image/jpeg; charset=binary", "images/001-abc.jpg"},
+		{clients.ImageInfo{ID: "abc", URL: "https://example.com/x.tiff?v=1"}, "application/octet-stream", "images/001-abc.tiff"},
+		{clients.ImageInfo{ID: "../../etc/passwd", URL: ""}, "", "images/001-etcpasswd.bin"},
+		{clients.ImageInfo{ID: "/", URL: ""}, "image/png", "images/001-image.png"},
+	}
+	for _, tt := range tests {
+		if got := archiveImagePath(1, &tt.image, tt.contentType); got != tt.want {
+			t.Errorf("archiveImagePath(%q, %q) = %q, want %q", tt.image.ID, tt.contentType, got, tt.want)
+		}
+	}
+	if got := archiveFileName("a/b"); strings.Contains(got, "/") {
+		t.Errorf("Expected a file name without slashes, got %q", got)
+	}
+}
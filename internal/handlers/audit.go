@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/docutag/controller/internal/storage"
+)
+
+// auditActor identifies the caller of a mutating request.
+// API keys are never stored verbatim; only a short fingerprint is recorded.
+func auditActor(r *http.Request) string {
+	key := requestAPIKey(r)
+	if key == "" {
+		return "anonymous"
+	}
+
+	sum := sha256.Sum256([]byte(key))
+	return "apikey:" + hex.EncodeToString(sum[:])[:12]
+}
+
+// requestAPIKey returns the API key sent in X-API-Key or as a bearer token, or "" if there is none
+func requestAPIKey(r *http.Request) string {
+	if key := r.Header.Get("X-API-Key"); key != "" {
+		return key
+	}
+	if auth := r.Header.Get("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+		return strings.TrimPrefix(auth, "Bearer ")
+	}
+	return ""
+}
+
+// recordAudit writes an audit entry for a mutation made through the API.
+// Failures are logged and never surfaced to the caller.
+func (h *Handler) recordAudit(r *http.Request, action, entityType, entityID string, details map[string]interface{}) {
+	entry := &storage.AuditEntry{
+		Actor:      auditActor(r),
+		Action:     action,
+		EntityType: entityType,
+		EntityID:   entityID,
+		Details:    details,
+	}
+	if err := h.store(r).RecordAudit(entry); err != nil {
+		slog.Default().Warn("failed to record audit entry",
+			"action", action,
+			"entity_type", entityType,
+			"entity_id", entityID,
+			"error", err,
+		)
+	}
+}
+
+// ListAuditLog handles GET /api/audit?entity_id=&action=&limit=&offset=
+func (h *Handler) ListAuditLog(w http.ResponseWriter, r *http.Request) {
+	pg, err := h.pageFromQuery(r, defaultPageLimit)
+	if err != nil {
+		respondErrorCode(w, ErrCodeValidationFailed, err.Error(), http.StatusBadRequest)
+		return
+	}
+	query := r.URL.Query()
+	filter := storage.AuditFilter{
+		EntityID: query.Get("entity_id"),
+		Action:   query.Get("action"),
+		Limit:    pg.Limit,
+		Offset:   pg.Offset,
+	}
+
+	entries, err := h.store(r).ListAuditEntries(filter)
+	if err != nil {
+		respondErrorCode(w, ErrCodeInternal, fmt.Sprintf("Failed to list audit entries: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	respondJSON(w, map[string]interface{}{
+		"entries": entries,
+		"limit":   filter.Limit,
+		"offset":  filter.Offset,
+	}, http.StatusOK)
+}
@@ -0,0 +1,46 @@
+package handlers
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestAuditActor(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name    string
+		headers map[string]string
+		want    string
+	}{
+		{name: "no credentials", headers: nil, want: "anonymous"},
+		{name: "api key header", headers: map[string]string{"X-API-Key": "secret"}, want: "apikey:"},
+		{name: "bearer token", headers: map[string]string{"Authorization": "Bearer secret"}, want: "apikey:"},
+		{name: "basic auth ignored", headers: map[string]string{"Authorization": "Basic abc"}, want: "anonymous"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("DELETE", "/api/requests/1", nil)
+			for k, v := range tt.headers {
+				r.Header.Set(k, v)
+			}
+			got := auditActor(r)
+			if !strings.HasPrefix(got, tt.want) {
+				t.Errorf("auditActor() = %q, want prefix %q", got, tt.want)
+			}
+			if strings.Contains(got, "secret") {
+				t.Errorf("auditActor() leaked the raw key: %q", got)
+			}
+		})
+	}
+
+	// The same key must always map to the same fingerprint
+	a := httptest.NewRequest("GET", "/", nil)
+	a.Header.Set("X-API-Key", "secret")
+	b := httptest.NewRequest("GET", "/", nil)
+	b.Header.Set("Authorization", "Bearer secret")
+	if auditActor(a) != auditActor(b) {
+		t.Errorf("Expected identical fingerprints, got %q and %q", auditActor(a), auditActor(b))
+	}
+}
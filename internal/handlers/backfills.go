@@ -0,0 +1,248 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/docutag/controller/internal/storage"
+)
+
+const defaultBackfillPause = 100 * time.Millisecond
+
+var (
+	errBackfillUnknown    = errors.New("no such backfill")
+	errBackfillNotRunning = errors.New("backfill is not running in this process")
+
+	// errBackfillShutdown cancels runs when the handler closes, so they are recorded as
+	// interrupted and resume on the next start
+	errBackfillShutdown = errors.New("controller shutting down")
+)
+
+// backfillRunner runs storage backfills in the background, one run per backfill at a time
+type backfillRunner struct {
+	opts storage.BackfillOptions
+
+	mu      sync.Mutex
+	cancels map[string]context.CancelCauseFunc // Runs live in this process, by backfill name
+	wg      sync.WaitGroup
+}
+
+// SetBackfills sets how many requests a backfill updates per transaction and how long it
+// sleeps between batches. Values <= 0 use the defaults.
+func (h *Handler) SetBackfills(batchSize int, pause time.Duration) {
+	if pause <= 0 {
+		pause = defaultBackfillPause
+	}
+	h.backfills = &backfillRunner{
+		opts:    storage.BackfillOptions{BatchSize: batchSize, Pause: pause},
+		cancels: make(map[string]context.CancelCauseFunc),
+	}
+}
+
+// StartBackfill claims the named backfill and runs it in the background, resuming from its
+// checkpoint unless restart is set
+func (h *Handler) StartBackfill(name string, restart bool) (*storage.BackfillRun, error) {
+	b := storage.BackfillByName(name)
+	if b == nil {
+		return nil, errBackfillUnknown
+	}
+	br := h.backfills
+	br.mu.Lock()
+	defer br.mu.Unlock()
+	if _, ok := br.cancels[name]; ok {
+		return nil, storage.ErrBackfillRunning
+	}
+	run, err := h.storage.StartBackfill(name, restart)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancelCause(context.Background())
+	br.cancels[name] = cancel
+	br.wg.Add(1)
+	go func() {
+		defer br.wg.Done()
+		defer func() {
+			br.mu.Lock()
+			delete(br.cancels, name)
+			br.mu.Unlock()
+			cancel(nil)
+		}()
+		slog.Default().Info("backfill started", "backfill", name, "resume_after", run.LastID)
+		finished, err := h.storage.RunBackfill(ctx, b, br.opts)
+		if err != nil {
+			slog.Default().Error("backfill failed", "backfill", name, "error", err)
+			return
+		}
+		slog.Default().Info("backfill finished",
+			"backfill", name,
+			"status", finished.Status,
+			"processed", finished.Processed,
+			"updated", finished.Updated,
+		)
+	}()
+	return run, nil
+}
+
+// StopBackfill asks the named backfill's run in this process to stop after its current batch
+func (h *Handler) StopBackfill(name string) error {
+	br := h.backfills
+	br.mu.Lock()
+	defer br.mu.Unlock()
+	cancel, ok := br.cancels[name]
+	if !ok {
+		return errBackfillNotRunning
+	}
+	cancel(storage.ErrBackfillStopped)
+	return nil
+}
+
+// ResumeBackfills restarts the backfills that were interrupted by a shutdown, or whose
+// process died mid-run without recording it. Runs another replica is checkpointing are left alone.
+func (h *Handler) ResumeBackfills() error {
+	runs, err := h.storage.ListBackfillRuns()
+	if err != nil {
+		return err
+	}
+	for _, run := range runs {
+		if run.Status != storage.BackfillStatusInterrupted && run.Status != storage.BackfillStatusRunning {
+			continue
+		}
+		if _, err := h.StartBackfill(run.Name, false); err != nil && !errors.Is(err, storage.ErrBackfillRunning) {
+			slog.Default().Warn("failed to resume backfill", "backfill", run.Name, "error", err)
+		}
+	}
+	return nil
+}
+
+// close interrupts the running backfills and waits for their current batch to commit
+func (br *backfillRunner) close() {
+	br.mu.Lock()
+	for _, cancel := range br.cancels {
+		cancel(errBackfillShutdown)
+	}
+	br.mu.Unlock()
+	br.wg.Wait()
+}
+
+// BackfillState describes one backfill and its latest run
+type BackfillState struct {
+	Name        string               `json:"name"`
+	Description string               `json:"description"`
+	Active      bool                 `json:"active"`        // A run is live in this process
+	Run         *storage.BackfillRun `json:"run,omitempty"` // Absent until the backfill is first started
+}
+
+// BackfillsResponse is returned by GET /api/admin/backfills
+type BackfillsResponse struct {
+	Backfills []BackfillState `json:"backfills"`
+}
+
+// backfillStates lists every backfill with its checkpoint, or just the named one
+func (h *Handler) backfillStates(only string) ([]BackfillState, error) {
+	runs, err := h.storage.ListBackfillRuns()
+	if err != nil {
+		return nil, err
+	}
+	byName := make(map[string]*storage.BackfillRun, len(runs))
+	for _, run := range runs {
+		byName[run.Name] = run
+	}
+
+	h.backfills.mu.Lock()
+	defer h.backfills.mu.Unlock()
+	states := []BackfillState{}
+	for _, b := range storage.Backfills() {
+		if only != "" && b.Name != only {
+			continue
+		}
+		_, active := h.backfills.cancels[b.Name]
+		states = append(states, BackfillState{Name: b.Name, Description: b.Description, Active: active, Run: byName[b.Name]})
+	}
+	return states, nil
+}
+
+// ListBackfills handles GET /api/admin/backfills and reports each backfill's progress
+func (h *Handler) ListBackfills(w http.ResponseWriter, r *http.Request) {
+	if h.backfills == nil {
+		respondErrorCode(w, ErrCodeNotConfigured, "backfills are not configured", http.StatusServiceUnavailable)
+		return
+	}
+	states, err := h.backfillStates("")
+	if err != nil {
+		respondErrorCode(w, ErrCodeInternal, fmt.Sprintf("Failed to list backfills: %v", err), http.StatusInternalServerError)
+		return
+	}
+	respondJSON(w, BackfillsResponse{Backfills: states}, http.StatusOK)
+}
+
+// StartBackfillRun handles POST /api/admin/backfills/{name}/start?restart=
+func (h *Handler) StartBackfillRun(w http.ResponseWriter, r *http.Request) {
+	if h.backfills == nil {
+		respondErrorCode(w, ErrCodeNotConfigured, "backfills are not configured", http.StatusServiceUnavailable)
+		return
+	}
+	name := r.PathValue("name")
+	restart := false
+	if value := r.URL.Query().Get("restart"); value != "" {
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			respondErrorCode(w, ErrCodeValidationFailed, fmt.Sprintf("Invalid restart: must be true or false, got %q", value), http.StatusBadRequest)
+			return
+		}
+		restart = parsed
+	}
+
+	run, err := h.StartBackfill(name, restart)
+	switch {
+	case errors.Is(err, errBackfillUnknown):
+		respondErrorCode(w, ErrCodeNotFound, fmt.Sprintf("No backfill named %q", name), http.StatusNotFound)
+		return
+	case errors.Is(err, storage.ErrBackfillRunning):
+		respondErrorCode(w, ErrCodeInvalidState, fmt.Sprintf("Backfill %q is already running", name), http.StatusConflict)
+		return
+	case err != nil:
+		respondErrorCode(w, ErrCodeInternal, fmt.Sprintf("Failed to start backfill: %v", err), http.StatusInternalServerError)
+		return
+	}
+	h.recordAudit(r, storage.AuditActionStartBackfill, storage.AuditEntityBackfill, name, map[string]interface{}{
+		"restart":      restart,
+		"resume_after": run.LastID,
+	})
+	h.respondBackfillState(w, name, http.StatusAccepted)
+}
+
+// StopBackfillRun handles POST /api/admin/backfills/{name}/stop
+func (h *Handler) StopBackfillRun(w http.ResponseWriter, r *http.Request) {
+	if h.backfills == nil {
+		respondErrorCode(w, ErrCodeNotConfigured, "backfills are not configured", http.StatusServiceUnavailable)
+		return
+	}
+	name := r.PathValue("name")
+	if storage.BackfillByName(name) == nil {
+		respondErrorCode(w, ErrCodeNotFound, fmt.Sprintf("No backfill named %q", name), http.StatusNotFound)
+		return
+	}
+	if err := h.StopBackfill(name); err != nil {
+		respondErrorCode(w, ErrCodeInvalidState, fmt.Sprintf("Backfill %q is not running in this process", name), http.StatusConflict)
+		return
+	}
+	h.recordAudit(r, storage.AuditActionStopBackfill, storage.AuditEntityBackfill, name, nil)
+	h.respondBackfillState(w, name, http.StatusAccepted)
+}
+
+// respondBackfillState writes the named backfill's state after a start or stop
+func (h *Handler) respondBackfillState(w http.ResponseWriter, name string, status int) {
+	states, err := h.backfillStates(name)
+	if err != nil || len(states) != 1 {
+		respondErrorCode(w, ErrCodeInternal, fmt.Sprintf("Failed to read backfill: %v", err), http.StatusInternalServerError)
+		return
+	}
+	respondJSON(w, states[0], status)
+}
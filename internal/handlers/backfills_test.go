@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/docutag/controller/internal/storage"
+)
+
+func TestBackfillEndpointValidation(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name   string
+		path   string
+		status int
+		code   string
+	}{
+		{"unknown backfill", "/api/v1/admin/backfills/nope/start", http.StatusNotFound, ErrCodeNotFound},
+		{"bad restart flag", "/api/v1/admin/backfills/quality_score/start?restart=maybe", http.StatusBadRequest, ErrCodeValidationFailed},
+		{"stop unknown backfill", "/api/v1/admin/backfills/nope/stop", http.StatusNotFound, ErrCodeNotFound},
+		{"stop idle backfill", "/api/v1/admin/backfills/quality_score/stop", http.StatusConflict, ErrCodeInvalidState},
+	}
+	for _, tt := range tests {
+		h := &Handler{}
+		h.SetBackfills(0, 0)
+		w := httptest.NewRecorder()
+		serveRoute(h, w, httptest.NewRequest(http.MethodPost, tt.path, nil))
+		var resp ErrorResponse
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		if w.Code != tt.status || resp.Code != tt.code {
+			t.Errorf("%s: expected %d %s, got %d: %s", tt.name, tt.status, tt.code, w.Code, w.Body.String())
+		}
+	}
+
+	w := httptest.NewRecorder()
+	serveRoute(&Handler{}, w, httptest.NewRequest(http.MethodGet, "/api/v1/admin/backfills", nil))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected 503 without a backfill runner, got %d", w.Code)
+	}
+}
+
+func TestBackfillStartAndList(t *testing.T) {
+	t.Parallel()
+	handler, _, _, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	if err := handler.storage.SaveRequest(&storage.Request{ID: "bf-1", CreatedAt: time.Now().UTC(), SourceType: "text",
+		Metadata: map[string]interface{}{"quality_score": map[string]interface{}{"score": 0.9}}}); err != nil {
+		t.Fatalf("Failed to save request: %v", err)
+	}
+
+	w := httptest.NewRecorder()
+	serveRoute(handler, w, httptest.NewRequest(http.MethodPost, "/api/v1/admin/backfills/quality_score/start", nil))
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("Expected 202, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var state BackfillState
+	deadline := time.Now().Add(10 * time.Second)
+	for state.Run == nil || state.Run.Status != storage.BackfillStatusCompleted {
+		if time.Now().After(deadline) {
+			t.Fatalf("Backfill never completed: %+v", state.Run)
+		}
+		time.Sleep(10 * time.Millisecond)
+		w := httptest.NewRecorder()
+		serveRoute(handler, w, httptest.NewRequest(http.MethodGet, "/api/v1/admin/backfills", nil))
+		var resp BackfillsResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil || len(resp.Backfills) != len(storage.Backfills()) {
+			t.Fatalf("Expected every backfill listed, got %s", w.Body.String())
+		}
+		for _, s := range resp.Backfills {
+			if s.Name == storage.QualityScoreBackfill.Name {
+				state = s
+			}
+		}
+	}
+	if state.Run.Processed != 1 {
+		t.Errorf("Expected 1 request processed, got %+v", state.Run)
+	}
+}
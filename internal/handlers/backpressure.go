@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// queuedCountTTL is how long a queued-job count is reused before it is queried again
+	queuedCountTTL = 5 * time.Second
+
+	// backpressureRetryAfter is the Retry-After sent with a rejection; a saturated
+	// queue takes minutes to drain, so clients should not retry immediately
+	backpressureRetryAfter = 60 * time.Second
+)
+
+// queueBackpressure refuses new scrape submissions while more than maxQueued jobs are
+// waiting. The count is cached for queuedCountTTL so the check is cheap under load.
+type queueBackpressure struct {
+	maxQueued       int
+	exemptSingleURL bool // Single-URL submissions without link extraction bypass the limit
+	count           func() (int, error)
+	now             func() time.Time
+
+	mu      sync.Mutex
+	queued  int
+	expires time.Time
+}
+
+func newQueueBackpressure(maxQueued int, exemptSingleURL bool, count func() (int, error)) *queueBackpressure {
+	return &queueBackpressure{maxQueued: maxQueued, exemptSingleURL: exemptSingleURL, count: count, now: time.Now}
+}
+
+// saturated reports the queued-job count and whether it has reached the limit.
+// If the count cannot be read the submission is allowed; the error is logged.
+func (b *queueBackpressure) saturated() (queued int, full bool) {
+	if b == nil || b.maxQueued <= 0 {
+		return 0, false
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if !b.now().Before(b.expires) {
+		n, err := b.count()
+		if err != nil {
+			slog.Default().Warn("failed to count queued jobs, skipping backpressure check", "error", err)
+			return 0, false
+		}
+		b.queued = n
+		b.expires = b.now().Add(queuedCountTTL)
+	}
+	return b.queued, b.queued >= b.maxQueued
+}
+
+// SetQueueBackpressure rejects scrape submissions with 503 once maxQueued jobs are queued;
+// 0 disables the limit. With exemptSingleURL, POST /scrape-requests without extract_links
+// is always accepted.
+func (h *Handler) SetQueueBackpressure(maxQueued int, exemptSingleURL bool) {
+	if maxQueued <= 0 {
+		h.backpressure = nil
+		return
+	}
+	h.backpressure = newQueueBackpressure(maxQueued, exemptSingleURL, func() (int, error) {
+		return h.storage.CountScrapeJobsByStatus("queued")
+	})
+}
+
+// rejectIfSaturated writes a 503 with Retry-After and returns true when the queue is full.
+// singleURL marks a submission that the exemption applies to.
+func (h *Handler) rejectIfSaturated(w http.ResponseWriter, endpoint string, singleURL bool) bool {
+	if singleURL && h.backpressure != nil && h.backpressure.exemptSingleURL {
+		return false
+	}
+	queued, full := h.backpressure.saturated()
+	if !full {
+		return false
+	}
+
+	h.collectors().scrapeRequestsRejected.WithLabelValues(endpoint).Inc()
+	w.Header().Set("Retry-After", strconv.Itoa(int(backpressureRetryAfter.Seconds())))
+	respondErrorDetails(w, ErrCodeQueueSaturated,
+		fmt.Sprintf("Scrape queue is saturated (%d jobs queued, limit %d); retry later", queued, h.backpressure.maxQueued),
+		http.StatusServiceUnavailable,
+		map[string]interface{}{"queued": queued, "max_queued": h.backpressure.maxQueued})
+	return true
+}
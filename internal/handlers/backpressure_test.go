@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/docutag/controller/internal/urlguard"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestQueueBackpressureCachesCount(t *testing.T) {
+	t.Parallel()
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	queued, counts := 10, 0
+	b := newQueueBackpressure(10, false, func() (int, error) {
+		counts++
+		return queued, nil
+	})
+	b.now = func() time.Time { return now }
+
+	if n, full := b.saturated(); !full || n != 10 {
+		t.Fatalf("expected saturated at the limit, got %d, %v", n, full)
+	}
+
+	// The queue drains, but the cached count is reused until the TTL passes
+	queued = 3
+	now = now.Add(queuedCountTTL - time.Second)
+	if _, full := b.saturated(); !full || counts != 1 {
+		t.Fatalf("expected the cached count within the TTL, got %d queries", counts)
+	}
+
+	now = now.Add(2 * time.Second)
+	if n, full := b.saturated(); full || n != 3 || counts != 2 {
+		t.Errorf("expected a fresh count of 3 after the TTL, got %d, %v after %d queries", n, full, counts)
+	}
+}
+
+func TestQueueBackpressureDisabledAndErrors(t *testing.T) {
+	t.Parallel()
+	for _, b := range []*queueBackpressure{nil, newQueueBackpressure(0, false, nil)} {
+		if _, full := b.saturated(); full {
+			t.Error("expected a disabled limit never to saturate")
+		}
+	}
+
+	failing := newQueueBackpressure(1, false, func() (int, error) { return 0, errors.New("db down") })
+	if _, full := failing.saturated(); full {
+		t.Error("expected a failed count to allow the submission")
+	}
+}
+
+func TestScrapeSubmissionsRejectedWhenSaturated(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name       string
+		exempt     bool
+		path       string
+		body       string
+		endpoint   string
+		wantStatus int
+	}{
+		{"crawl seed", true, "/api/scrape-requests", `{"url":"https://example.com","extract_links":true}`, "scrape_requests", http.StatusServiceUnavailable},
+		{"single URL without exemption", false, "/api/scrape-requests", `{"url":"https://example.com"}`, "scrape_requests", http.StatusServiceUnavailable},
+		{"sitemap", true, "/api/scrape-requests/sitemap", `{"url":"https://example.com/sitemap.xml"}`, "sitemap", http.StatusServiceUnavailable},
+		{"invalid body is still a 400", false, "/api/scrape-requests", `{`, "", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := &Handler{
+				metrics:      unregisteredHandlerMetrics(),
+				urlGuard:     urlguard.NewWithResolver(false, publicResolver{}),
+				backpressure: newQueueBackpressure(100, tt.exempt, func() (int, error) { return 250, nil }),
+			}
+			w := httptest.NewRecorder()
+			serveRoute(h, w, httptest.NewRequest(http.MethodPost, tt.path, strings.NewReader(tt.body)))
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.wantStatus, w.Code, w.Body.String())
+			}
+			if tt.wantStatus != http.StatusServiceUnavailable {
+				return
+			}
+			if got := w.Header().Get("Retry-After"); got != "60" {
+				t.Errorf("expected Retry-After 60, got %q", got)
+			}
+			var resp ErrorResponse
+			if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if resp.Code != ErrCodeQueueSaturated || resp.Details["queued"] != float64(250) || resp.Details["max_queued"] != float64(100) {
+				t.Errorf("unexpected error response: %+v", resp)
+			}
+			if got := testutil.ToFloat64(h.metrics.scrapeRequestsRejected.WithLabelValues(tt.endpoint)); got != 1 {
+				t.Errorf("expected the %s rejection to be counted once, got %v", tt.endpoint, got)
+			}
+		})
+	}
+}
+
+func TestSingleURLExemptFromBackpressure(t *testing.T) {
+	t.Parallel()
+	h := &Handler{
+		urlGuard:     urlguard.NewWithResolver(false, publicResolver{}),
+		backpressure: newQueueBackpressure(100, true, func() (int, error) { return 250, nil }),
+	}
+	w := httptest.NewRecorder()
+	if h.rejectIfSaturated(w, "scrape_requests", true) {
+		t.Fatalf("expected an exempt single-URL submission to pass, got %d", w.Code)
+	}
+	if !h.rejectIfSaturated(w, "scrape_requests", false) {
+		t.Error("expected a crawl seed to be rejected")
+	}
+}
@@ -0,0 +1,201 @@
+package handlers
+
+import (
+	"compress/gzip"
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/docutag/controller/internal/storage"
+)
+
+// Backup files are named backupFilePrefix + UTC timestamp + backupFileSuffix, so name order is age order
+const (
+	backupFilePrefix      = "controller-"
+	backupFileSuffix      = ".jsonl.gz"
+	backupTimestampFormat = "20060102T150405Z"
+)
+
+// backups holds the snapshot directory, its limits and the lock that keeps backups from overlapping
+type backups struct {
+	dir         string
+	minInterval time.Duration // Refuse a backup this soon after the newest snapshot; 0 never refuses
+	retention   int           // Snapshots kept after a backup; older ones are deleted
+
+	running sync.Mutex
+	now     func() time.Time
+}
+
+// BackupResult describes a snapshot written by POST /api/admin/backup
+type BackupResult struct {
+	Path      string    `json:"path"`
+	SizeBytes int64     `json:"size_bytes"`
+	Tables    int       `json:"tables"`
+	Rows      int64     `json:"rows"`
+	CreatedAt time.Time `json:"created_at"`
+	Pruned    []string  `json:"pruned"` // Older snapshots deleted to honour the retention count
+}
+
+// SetBackups enables POST /api/admin/backup. Snapshots are written to dir, at most one per
+// minInterval, and only the newest retention are kept.
+func (h *Handler) SetBackups(dir string, minInterval time.Duration, retention int) {
+	h.backups = &backups{dir: dir, minInterval: minInterval, retention: retention, now: time.Now}
+}
+
+// CreateBackup handles POST /api/admin/backup and writes a consistent snapshot of the database
+func (h *Handler) CreateBackup(w http.ResponseWriter, r *http.Request) {
+	b := h.backups
+	if b == nil {
+		respondErrorCode(w, ErrCodeNotConfigured, "backups are not configured", http.StatusServiceUnavailable)
+		return
+	}
+	if !b.running.TryLock() {
+		respondErrorCode(w, ErrCodeInvalidState, "a backup is already running", http.StatusConflict)
+		return
+	}
+	defer b.running.Unlock()
+
+	snapshots, err := b.list()
+	if err != nil {
+		respondErrorCode(w, ErrCodeInternal, fmt.Sprintf("Failed to list backups: %v", err), http.StatusInternalServerError)
+		return
+	}
+	now := b.now().UTC()
+	if wait := b.retryAfter(snapshots, now); wait > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+		respondErrorDetails(w, ErrCodeRateLimited, "a backup ran too recently", http.StatusTooManyRequests, map[string]interface{}{
+			"latest":              filepath.Join(b.dir, snapshots[len(snapshots)-1]),
+			"retry_after_seconds": int(math.Ceil(wait.Seconds())),
+		})
+		return
+	}
+
+	result, err := b.write(r.Context(), h.storage, now)
+	if err != nil {
+		slog.Default().Error("database backup failed", "error", err)
+		respondErrorCode(w, ErrCodeInternal, fmt.Sprintf("Backup failed: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	result.Pruned = b.prune(append(snapshots, filepath.Base(result.Path)))
+	slog.Default().Info("database backup written",
+		"path", result.Path,
+		"size_bytes", result.SizeBytes,
+		"tables", result.Tables,
+		"rows", result.Rows,
+		"pruned", len(result.Pruned),
+	)
+	respondJSON(w, result, http.StatusCreated)
+}
+
+// write streams a gzipped snapshot to a temporary file and renames it into place once complete,
+// so a failed backup never leaves a truncated snapshot behind
+func (b *backups) write(ctx context.Context, store *storage.Storage, now time.Time) (*BackupResult, error) {
+	if err := os.MkdirAll(b.dir, 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create backup directory: %w", err)
+	}
+	path := filepath.Join(b.dir, backupFilePrefix+now.Format(backupTimestampFormat)+backupFileSuffix)
+
+	tmp, err := os.CreateTemp(b.dir, ".backup-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create backup file: %w", err)
+	}
+	defer os.Remove(tmp.Name()) // No-op once renamed
+
+	gz := gzip.NewWriter(tmp)
+	stats, err := store.Backup(ctx, gz)
+	if err == nil {
+		err = gz.Close()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return nil, fmt.Errorf("failed to move backup into place: %w", err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	return &BackupResult{
+		Path:      path,
+		SizeBytes: info.Size(),
+		Tables:    stats.Tables,
+		Rows:      stats.Rows,
+		CreatedAt: now,
+		Pruned:    []string{},
+	}, nil
+}
+
+// list returns the snapshot file names in dir, oldest first. A missing directory holds none.
+func (b *backups) list() ([]string, error) {
+	entries, err := os.ReadDir(b.dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, e := range entries {
+		if !e.IsDir() && strings.HasPrefix(e.Name(), backupFilePrefix) && strings.HasSuffix(e.Name(), backupFileSuffix) {
+			names = append(names, e.Name())
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// retryAfter returns how long until another backup is allowed, or 0 if one may run now
+func (b *backups) retryAfter(snapshots []string, now time.Time) time.Duration {
+	if b.minInterval <= 0 || len(snapshots) == 0 {
+		return 0
+	}
+	newest := snapshots[len(snapshots)-1]
+	taken, err := time.Parse(backupTimestampFormat, strings.TrimSuffix(strings.TrimPrefix(newest, backupFilePrefix), backupFileSuffix))
+	if err != nil {
+		return 0
+	}
+	return b.minInterval - now.Sub(taken)
+}
+
+// prune deletes all but the newest retention snapshots and returns the paths it removed
+func (b *backups) prune(snapshots []string) []string {
+	pruned := []string{}
+	if b.retention <= 0 || len(snapshots) <= b.retention {
+		return pruned
+	}
+	for _, name := range snapshots[:len(snapshots)-b.retention] {
+		path := filepath.Join(b.dir, name)
+		if err := os.Remove(path); err != nil {
+			slog.Default().Warn("failed to prune old backup", "path", path, "error", err)
+			continue
+		}
+		pruned = append(pruned, path)
+	}
+	return pruned
+}
+
+// CheckDatabaseIntegrity handles GET /api/admin/db/integrity and reports the results of
+// the storage consistency checks; "ok" is false when any of them found a problem
+func (h *Handler) CheckDatabaseIntegrity(w http.ResponseWriter, r *http.Request) {
+	report, err := h.storage.CheckIntegrity(r.Context())
+	if err != nil {
+		respondErrorCode(w, ErrCodeInternal, fmt.Sprintf("Integrity check failed: %v", err), http.StatusInternalServerError)
+		return
+	}
+	respondJSON(w, report, http.StatusOK)
+}
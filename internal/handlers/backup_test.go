@@ -0,0 +1,134 @@
+package handlers
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/docutag/controller/internal/storage"
+)
+
+func TestCreateBackupNotConfigured(t *testing.T) {
+	t.Parallel()
+	w := httptest.NewRecorder()
+	serveRoute(&Handler{}, w, httptest.NewRequest(http.MethodPost, "/api/v1/admin/backup", nil))
+
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("Expected 503, got %d", w.Code)
+	}
+}
+
+func TestCreateBackupRateLimited(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	now := time.Date(2026, 10, 16, 9, 30, 0, 0, time.UTC)
+	recent := backupFilePrefix + now.Add(-10*time.Minute).Format(backupTimestampFormat) + backupFileSuffix
+	if err := os.WriteFile(filepath.Join(dir, recent), nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	h := &Handler{}
+	h.SetBackups(dir, time.Hour, 3)
+	h.backups.now = func() time.Time { return now }
+
+	w := httptest.NewRecorder()
+	serveRoute(h, w, httptest.NewRequest(http.MethodPost, "/api/v1/admin/backup", nil))
+
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("Expected 429, got %d: %s", w.Code, w.Body.String())
+	}
+	if got := w.Header().Get("Retry-After"); got != "3000" {
+		t.Errorf("Expected Retry-After 3000, got %q", got)
+	}
+}
+
+func TestBackupPruneKeepsNewest(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	var names []string
+	for i := 0; i < 4; i++ {
+		name := backupFilePrefix + time.Date(2026, 10, 10+i, 0, 0, 0, 0, time.UTC).Format(backupTimestampFormat) + backupFileSuffix
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0o600); err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, name)
+	}
+	// Files that are not snapshots are left alone
+	if err := os.WriteFile(filepath.Join(dir, "notes.txt"), nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	b := &backups{dir: dir, retention: 2}
+	listed, err := b.list()
+	if err != nil {
+		t.Fatalf("list failed: %v", err)
+	}
+	pruned := b.prune(listed)
+	if len(pruned) != 2 || filepath.Base(pruned[0]) != names[0] || filepath.Base(pruned[1]) != names[1] {
+		t.Fatalf("Expected the two oldest snapshots to be pruned, got %v", pruned)
+	}
+
+	left, _ := b.list()
+	if len(left) != 2 || left[0] != names[2] || left[1] != names[3] {
+		t.Errorf("Expected the two newest snapshots to remain, got %v", left)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "notes.txt")); err != nil {
+		t.Errorf("Expected unrelated files to be kept: %v", err)
+	}
+}
+
+func TestCreateBackupWritesSnapshot(t *testing.T) {
+	t.Parallel()
+	h, _, _, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	if err := h.storage.SaveRequest(&storage.Request{ID: "backed-up", CreatedAt: time.Now(), SourceType: "text", Metadata: map[string]interface{}{}}); err != nil {
+		t.Fatalf("Failed to save request: %v", err)
+	}
+	h.SetBackups(t.TempDir(), time.Hour, 3)
+
+	w := httptest.NewRecorder()
+	serveRoute(h, w, httptest.NewRequest(http.MethodPost, "/api/v1/admin/backup", nil))
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var result BackupResult
+	if err := json.Unmarshal(w.Body.Bytes(), &result); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+
+	f, err := os.Open(result.Path)
+	if err != nil {
+		t.Fatalf("Failed to open snapshot: %v", err)
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatalf("Snapshot is not gzipped: %v", err)
+	}
+	found := false
+	err = storage.ReadBackup(gz, func(line storage.BackupLine) error {
+		var row struct {
+			ID string `json:"id"`
+		}
+		if line.Table == "requests" && json.Unmarshal(line.Row, &row) == nil && row.ID == "backed-up" {
+			found = true
+		}
+		return nil
+	})
+	if err != nil || !found {
+		t.Errorf("Expected the seeded request in the snapshot (err %v)", err)
+	}
+
+	// A second backup straight away is refused
+	w = httptest.NewRecorder()
+	serveRoute(h, w, httptest.NewRequest(http.MethodPost, "/api/v1/admin/backup", nil))
+	if w.Code != http.StatusTooManyRequests {
+		t.Errorf("Expected 429 for an immediate second backup, got %d", w.Code)
+	}
+}
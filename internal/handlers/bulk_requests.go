@@ -0,0 +1,202 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+
+	"github.com/docutag/controller/internal/storage"
+)
+
+// Bulk request actions
+const (
+	requestActionTombstone = "tombstone"
+	requestActionDelete    = "delete"
+)
+
+const (
+	// defaultBulkMaxRequests is the most requests one bulk call may affect unless configured otherwise
+	defaultBulkMaxRequests = 1000
+	// bulkRequestBatchSize is how many requests a bulk call processes before logging its progress
+	bulkRequestBatchSize = 100
+	// bulkRequestConcurrency bounds the requests a bulk call processes at once, since hard
+	// deletes call the scraper and text analyzer for each
+	bulkRequestConcurrency = 4
+)
+
+// BulkRequestsRequest selects requests with the same fields as FilterRequestsRequest. ConfirmCount
+// must equal the number of matches, so a filter broader than intended is refused rather than run.
+type BulkRequestsRequest struct {
+	FilterRequestsRequest
+	ConfirmCount *int `json:"confirm_count,omitempty"` // Required unless dry_run is set
+}
+
+// RequestOperationResult is the outcome of a bulk action for one request
+type RequestOperationResult struct {
+	RequestID string `json:"request_id"`
+	Status    string `json:"status"` // "succeeded", "failed", or "planned" in a dry run
+	Error     string `json:"error,omitempty"`
+}
+
+// BulkRequestsResponse summarizes a bulk tombstone or delete. A failure for one request does
+// not stop the others. A dry run lists the matches as planned.
+type BulkRequestsResponse struct {
+	Action     string                   `json:"action"`
+	MatchCount int                      `json:"match_count"`
+	Batches    int                      `json:"batches"`
+	Succeeded  int                      `json:"succeeded"`
+	Failed     int                      `json:"failed"`
+	Results    []RequestOperationResult `json:"results"`
+	DryRun     bool                     `json:"dry_run,omitempty"`
+}
+
+// SetBulkMaxRequests caps how many requests one bulk tombstone or delete may affect. Values
+// below 1 restore the default.
+func (h *Handler) SetBulkMaxRequests(max int) {
+	if max < 1 {
+		max = defaultBulkMaxRequests
+	}
+	h.bulkMaxRequests = max
+}
+
+// bulkRequestsMax returns the configured cap, falling back to the default for handlers built
+// without SetBulkMaxRequests
+func (h *Handler) bulkRequestsMax() int {
+	if h.bulkMaxRequests < 1 {
+		return defaultBulkMaxRequests
+	}
+	return h.bulkMaxRequests
+}
+
+// BulkTombstoneRequests handles POST /api/requests/bulk-tombstone
+func (h *Handler) BulkTombstoneRequests(w http.ResponseWriter, r *http.Request) {
+	h.bulkRequests(w, r, requestActionTombstone)
+}
+
+// BulkDeleteRequests handles POST /api/requests/bulk-delete. Like DeleteRequest it soft-deletes
+// unless ?hard=true is passed.
+func (h *Handler) BulkDeleteRequests(w http.ResponseWriter, r *http.Request) {
+	h.bulkRequests(w, r, requestActionDelete)
+}
+
+func (h *Handler) bulkRequests(w http.ResponseWriter, r *http.Request, action string) {
+	dryRun, err := dryRunFromQuery(r)
+	if err != nil {
+		respondErrorCode(w, ErrCodeValidationFailed, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var req BulkRequestsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondErrorCode(w, ErrCodeInvalidRequestBody, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if req.Limit != 0 || req.Offset != 0 {
+		respondErrorCode(w, ErrCodeValidationFailed, "limit and offset are not accepted; a bulk action applies to every match", http.StatusBadRequest)
+		return
+	}
+	if !dryRun && req.ConfirmCount == nil {
+		respondErrorCode(w, ErrCodeValidationFailed, "confirm_count is required; run with dry_run=true to get the match count", http.StatusBadRequest)
+		return
+	}
+
+	opts, err := filterOptions(req.FilterRequestsRequest, defaultMaxPageLimit)
+	if err != nil {
+		respondErrorCode(w, ErrCodeValidationFailed, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Fetch one more than the cap so an oversized selection is refused instead of truncated
+	maxRequests := h.bulkRequestsMax()
+	opts.Limit, opts.Offset = maxRequests+1, 0
+	records, err := h.store(r).FilterRequests(opts)
+	if err != nil {
+		respondErrorCode(w, ErrCodeInternal, fmt.Sprintf("Failed to filter requests: %v", err), http.StatusInternalServerError)
+		return
+	}
+	if len(records) > maxRequests {
+		respondErrorCode(w, ErrCodeValidationFailed,
+			fmt.Sprintf("The filter matches more than %d requests, the most one bulk call may affect; narrow the filter", maxRequests),
+			http.StatusBadRequest)
+		return
+	}
+	if !dryRun && *req.ConfirmCount != len(records) {
+		respondErrorCode(w, ErrCodeInvalidState,
+			fmt.Sprintf("confirm_count is %d but the filter matches %d requests", *req.ConfirmCount, len(records)),
+			http.StatusConflict)
+		return
+	}
+
+	hard := action == requestActionDelete && r.URL.Query().Get("hard") == "true"
+	resp := h.applyToRequests(r, records, action, hard, dryRun)
+	respondJSON(w, resp, http.StatusOK)
+}
+
+// applyToRequests tombstones or deletes records in batches, each processed with bounded
+// concurrency. Deletes go through deleteRecord, so hard deletes clean up the scraper and text
+// analyzer as DeleteRequest does. A dry run lists the records and stops there.
+func (h *Handler) applyToRequests(r *http.Request, records []*storage.Request, action string, hard, dryRun bool) *BulkRequestsResponse {
+	resp := &BulkRequestsResponse{
+		Action:     action,
+		MatchCount: len(records),
+		Results:    make([]RequestOperationResult, len(records)),
+		DryRun:     dryRun,
+	}
+	if dryRun {
+		for i, record := range records {
+			resp.Results[i] = RequestOperationResult{RequestID: record.ID, Status: "planned"}
+		}
+		return resp
+	}
+
+	op := func(record *storage.Request) error {
+		_, err := h.tombstoneRecord(r, record, "bulk")
+		return err
+	}
+	if action == requestActionDelete {
+		op = func(record *storage.Request) error {
+			_, err := h.deleteRecord(r, record, hard)
+			return err
+		}
+	}
+
+	sem := make(chan struct{}, bulkRequestConcurrency)
+	for start := 0; start < len(records); start += bulkRequestBatchSize {
+		end := min(start+bulkRequestBatchSize, len(records))
+		var wg sync.WaitGroup
+		for i := start; i < end; i++ {
+			wg.Add(1)
+			sem <- struct{}{}
+			go func(i int) {
+				defer wg.Done()
+				defer func() { <-sem }()
+
+				resp.Results[i] = RequestOperationResult{RequestID: records[i].ID, Status: "succeeded"}
+				if err := op(records[i]); err != nil {
+					resp.Results[i].Status = "failed"
+					resp.Results[i].Error = err.Error()
+				}
+			}(i)
+		}
+		wg.Wait()
+		resp.Batches++
+
+		for _, result := range resp.Results[start:end] {
+			if result.Status == "succeeded" {
+				resp.Succeeded++
+			} else {
+				resp.Failed++
+			}
+		}
+		slog.Default().Info("bulk request action progress",
+			"action", action,
+			"processed", end,
+			"total", len(records),
+			"succeeded", resp.Succeeded,
+			"failed", resp.Failed,
+		)
+	}
+	return resp
+}
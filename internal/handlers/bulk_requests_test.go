@@ -0,0 +1,151 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/docutag/controller/internal/clients"
+	"github.com/docutag/controller/internal/storage"
+)
+
+// countingUpstream stands in for the scraper and text analyzer, listing no images and counting
+// every call that would change something upstream
+func countingUpstream(t *testing.T, writes *int32) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			json.NewEncoder(w).Encode(clients.ImageSearchResponse{Images: []*clients.ImageInfo{}})
+			return
+		}
+		atomic.AddInt32(writes, 1)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestBulkRequestsValidation(t *testing.T) {
+	t.Parallel()
+	// Validation runs before storage is touched, so a bare handler is enough
+	h := &Handler{}
+
+	tests := []struct {
+		name string
+		path string
+		body string
+		code string
+	}{
+		{"invalid body", "/api/v1/requests/bulk-delete", `{"tags": "nope"}`, ErrCodeInvalidRequestBody},
+		{"missing confirm_count", "/api/v1/requests/bulk-delete", `{"tags": ["spam"]}`, ErrCodeValidationFailed},
+		{"limit", "/api/v1/requests/bulk-tombstone", `{"tags": ["spam"], "limit": 10, "confirm_count": 10}`, ErrCodeValidationFailed},
+		{"offset", "/api/v1/requests/bulk-tombstone", `{"tags": ["spam"], "offset": 10, "confirm_count": 1}`, ErrCodeValidationFailed},
+		{"invalid dry_run", "/api/v1/requests/bulk-tombstone?dry_run=perhaps", `{"tags": ["spam"]}`, ErrCodeValidationFailed},
+		{"invalid date", "/api/v1/requests/bulk-tombstone", `{"date_start": "last tuesday", "confirm_count": 1}`, ErrCodeValidationFailed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			serveRoute(h, w, httptest.NewRequest(http.MethodPost, tt.path, strings.NewReader(tt.body)))
+			if w.Code != http.StatusBadRequest {
+				t.Fatalf("Expected status 400, got %d: %s", w.Code, w.Body.String())
+			}
+			var resp ErrorResponse
+			if err := json.NewDecoder(w.Body).Decode(&resp); err != nil || resp.Code != tt.code {
+				t.Errorf("Expected %s, got %+v (err %v)", tt.code, resp, err)
+			}
+		})
+	}
+}
+
+func TestBulkRequests(t *testing.T) {
+	t.Parallel()
+	handler, _, _, cleanup := setupTestHandler(t)
+	defer cleanup()
+	var writes int32
+	upstream := countingUpstream(t, &writes)
+	handler.scraper = clients.NewScraperClient(upstream.URL)
+	handler.textAnalyzer = clients.NewTextAnalyzerClient(upstream.URL)
+
+	for i, id := range []string{"farm-1", "farm-2", "farm-3", "keeper"} {
+		tags := []string{"contentfarm.net"}
+		if id == "keeper" {
+			tags = []string{"example.com"}
+		}
+		scrapeID := "scrape-" + id
+		if err := handler.storage.SaveRequest(&storage.Request{
+			ID: id, CreatedAt: time.Now().Add(-time.Duration(i) * time.Minute), SourceType: "url", ScraperUUID: &scrapeID,
+			TextAnalyzerUUID: "analysis-" + id, Tags: tags, Metadata: map[string]interface{}{},
+		}); err != nil {
+			t.Fatalf("Failed to save request: %v", err)
+		}
+	}
+
+	post := func(path, body string) (*httptest.ResponseRecorder, BulkRequestsResponse) {
+		w := httptest.NewRecorder()
+		serveRoute(handler, w, httptest.NewRequest(http.MethodPost, path, strings.NewReader(body)))
+		var resp BulkRequestsResponse
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		return w, resp
+	}
+	remaining := func() int {
+		t.Helper()
+		records, err := handler.storage.ListRequests(100, 0)
+		if err != nil {
+			t.Fatalf("Failed to list requests: %v", err)
+		}
+		return len(records)
+	}
+
+	// A dry run reports the selection and changes nothing
+	w, resp := post("/api/v1/requests/bulk-delete?hard=true&dry_run=true", `{"tags": ["contentfarm.net"]}`)
+	if w.Code != http.StatusOK || !resp.DryRun || resp.MatchCount != 3 || len(resp.Results) != 3 || resp.Results[0].Status != "planned" {
+		t.Fatalf("Unexpected dry run: %d %s", w.Code, w.Body.String())
+	}
+	if n := remaining(); n != 4 {
+		t.Errorf("Expected a dry run to delete nothing, %d requests remain", n)
+	}
+	if n := atomic.LoadInt32(&writes); n != 0 {
+		t.Errorf("Expected a dry run to make no upstream changes, got %d", n)
+	}
+
+	// A confirm_count that does not match the selection is refused
+	if w, _ = post("/api/v1/requests/bulk-delete", `{"tags": ["contentfarm.net"], "confirm_count": 4}`); w.Code != http.StatusConflict {
+		t.Errorf("Expected status 409 for a mismatched confirm_count, got %d", w.Code)
+	}
+
+	// So is a selection over the cap
+	handler.SetBulkMaxRequests(2)
+	if w, _ = post("/api/v1/requests/bulk-tombstone", `{"tags": ["contentfarm.net"], "confirm_count": 3}`); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 over the cap, got %d", w.Code)
+	}
+	handler.SetBulkMaxRequests(0)
+
+	w, resp = post("/api/v1/requests/bulk-tombstone", `{"tags": ["contentfarm.net"], "confirm_count": 3}`)
+	if w.Code != http.StatusOK || resp.Succeeded != 3 || resp.Failed != 0 || resp.Batches != 1 {
+		t.Fatalf("Unexpected tombstone result: %d %s", w.Code, w.Body.String())
+	}
+	record, err := handler.storage.GetRequest("farm-2")
+	if err != nil {
+		t.Fatalf("Failed to get request: %v", err)
+	}
+	if record.Metadata["tombstone_datetime"] == nil {
+		t.Error("Expected the match to be tombstoned")
+	}
+
+	w, resp = post("/api/v1/requests/bulk-delete?hard=true", `{"tags": ["contentfarm.net"], "confirm_count": 3}`)
+	if w.Code != http.StatusOK || resp.Succeeded != 3 {
+		t.Fatalf("Unexpected delete result: %d %s", w.Code, w.Body.String())
+	}
+	if n := remaining(); n != 1 {
+		t.Errorf("Expected only the keeper to remain, got %d requests", n)
+	}
+	// Each hard delete removes the scrape and the analysis upstream
+	if n := atomic.LoadInt32(&writes); n != 6 {
+		t.Errorf("Expected 6 upstream deletions, got %d", n)
+	}
+}
@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/docutag/controller/internal/queue"
+	"github.com/docutag/controller/internal/storage"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// metricsTopDomains bounds the domain label: the largest domains get their own series and
+// the rest are summed under otherDomainLabel
+const metricsTopDomains = 20
+
+const otherDomainLabel = "other"
+
+// metricsOutcomeWindow is how far back the per-domain scrape outcomes reach
+const metricsOutcomeWindow = 24 * time.Hour
+
+// updateDomainMetrics replaces the per-domain gauges, so domains that drop out of the top
+// list lose their series instead of keeping a stale value
+func (h *Handler) updateDomainMetrics() {
+	top, other, err := h.storage.GetTopDomains(metricsTopDomains)
+	if err != nil {
+		slog.Default().Error("failed to count documents by domain", "error", err)
+		return
+	}
+
+	gauge := h.collectors().documentsByDomain
+	gauge.Reset()
+	for _, dc := range top {
+		gauge.WithLabelValues(dc.Domain).Set(float64(dc.Count))
+	}
+	gauge.WithLabelValues(otherDomainLabel).Set(float64(other))
+
+	h.updateScrapeOutcomeDomainMetrics()
+}
+
+// updateScrapeOutcomeDomainMetrics replaces the per-domain scrape outcome gauges. An outcome
+// whose query fails keeps its previous series.
+func (h *Handler) updateScrapeOutcomeDomainMetrics() {
+	since := time.Now().Add(-metricsOutcomeWindow)
+	queries := []struct {
+		outcome string
+		top     func(limit int, since time.Time) ([]storage.DomainCount, int, error)
+	}{
+		{queue.ScrapeOutcomeFailed, h.storage.GetTopFailedScrapeDomains},
+		{queue.ScrapeOutcomeBelowThreshold, h.storage.GetTopBelowThresholdDomains},
+	}
+	gauge := h.collectors().scrapeOutcomesByDomain
+	for _, q := range queries {
+		top, other, err := q.top(metricsTopDomains, since)
+		if err != nil {
+			slog.Default().Error("failed to count scrape outcomes by domain", "outcome", q.outcome, "error", err)
+			continue
+		}
+
+		gauge.DeletePartialMatch(prometheus.Labels{"outcome": q.outcome})
+		for _, dc := range top {
+			gauge.WithLabelValues(q.outcome, dc.Domain).Set(float64(dc.Count))
+		}
+		gauge.WithLabelValues(q.outcome, otherDomainLabel).Set(float64(other))
+	}
+}
@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/docutag/controller/internal/queue"
+	"github.com/docutag/controller/internal/storage"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestUpdateMetricsCorpusComposition(t *testing.T) {
+	t.Parallel()
+	handler, _, _, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	now := time.Now().UTC()
+	save := func(id, sourceType, url string, metadata map[string]interface{}) {
+		t.Helper()
+		req := &storage.Request{
+			ID:               id,
+			CreatedAt:        now,
+			SourceType:       sourceType,
+			TextAnalyzerUUID: "ta-" + id,
+			Tags:             []string{"metrics"},
+			SEOEnabled:       true,
+			Metadata:         metadata,
+		}
+		if url != "" {
+			req.SourceURL = &url
+		}
+		if err := handler.storage.SaveRequest(req); err != nil {
+			t.Fatalf("Failed to save request %s: %v", id, err)
+		}
+	}
+
+	// example.com has the most documents; "www." and case are folded into it
+	save("ex-1", "url", "https://example.com/a", nil)
+	save("ex-2", "url", "https://www.example.com/b", nil)
+	save("ex-3", "url", "https://EXAMPLE.com:8443/c", nil)
+	// 22 single-document domains: 19 fit in the top 20, the last 3 go to "other"
+	for i := 0; i < 22; i++ {
+		save(fmt.Sprintf("small-%02d", i), "url", fmt.Sprintf("https://d%02d.example.org/", i), nil)
+	}
+	// Neither tombstoned nor text documents are counted by domain
+	save("gone", "url", "https://example.com/gone", map[string]interface{}{
+		"tombstone_datetime": now.Add(-time.Hour).Format(time.RFC3339),
+	})
+	save("text-1", "text", "", nil)
+
+	job := &storage.ScrapeJob{ID: "metrics-job", URL: "https://example.com", Status: "failed", CreatedAt: now, UpdatedAt: now}
+	if err := handler.storage.SaveScrapeJob(job); err != nil {
+		t.Fatalf("Failed to save job: %v", err)
+	}
+
+	handler.updateMetrics()
+
+	if got := testutil.CollectAndCount(handler.metrics.documentsByDomain); got != metricsTopDomains+1 {
+		t.Errorf("expected %d domain series, got %d", metricsTopDomains+1, got)
+	}
+	gauges := []struct {
+		domain string
+		want   float64
+	}{
+		{"example.com", 3},
+		{"d00.example.org", 1},
+		{"d18.example.org", 1},
+		{otherDomainLabel, 3},
+	}
+	for _, g := range gauges {
+		if got := testutil.ToFloat64(handler.metrics.documentsByDomain.WithLabelValues(g.domain)); got != g.want {
+			t.Errorf("documents for %s: expected %v, got %v", g.domain, g.want, got)
+		}
+	}
+
+	bm := handler.businessMetrics
+	if got := testutil.ToFloat64(bm.DocumentsTotal.WithLabelValues("url")); got != 25 {
+		t.Errorf("expected 25 url documents, got %v", got)
+	}
+	if got := testutil.ToFloat64(bm.DocumentsWithSEO); got != 26 {
+		t.Errorf("expected 26 SEO-enabled documents, got %v", got)
+	}
+	if got := testutil.ToFloat64(bm.TombstonesPending); got != 1 {
+		t.Errorf("expected 1 tombstoned document, got %v", got)
+	}
+	if got := testutil.ToFloat64(bm.ScrapeJobsByStatus.WithLabelValues("failed")); got != 1 {
+		t.Errorf("expected 1 failed job, got %v", got)
+	}
+	if got := testutil.ToFloat64(bm.ScrapeJobsByStatus.WithLabelValues("pending")); got != 0 {
+		t.Errorf("expected 0 pending jobs, got %v", got)
+	}
+}
+
+func TestUpdateMetricsScrapeOutcomesByDomain(t *testing.T) {
+	t.Parallel()
+	handler, _, _, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	now := time.Now().UTC()
+	for i, url := range []string{"https://www.example.com/1", "https://example.com/2", "https://flaky.example.org/"} {
+		job := &storage.ScrapeJob{ID: fmt.Sprintf("failed-%d", i), URL: url, Status: "failed", CreatedAt: now, UpdatedAt: now}
+		if err := handler.storage.SaveScrapeJob(job); err != nil {
+			t.Fatalf("Failed to save job: %v", err)
+		}
+	}
+	// Failures older than the window and jobs that did not fail are left out
+	for _, job := range []*storage.ScrapeJob{
+		{ID: "failed-old", URL: "https://old.example.net/", Status: "failed", CreatedAt: now.Add(-48 * time.Hour), UpdatedAt: now.Add(-48 * time.Hour)},
+		{ID: "completed", URL: "https://example.com/ok", Status: "completed", CreatedAt: now, UpdatedAt: now},
+	} {
+		if err := handler.storage.SaveScrapeJob(job); err != nil {
+			t.Fatalf("Failed to save job: %v", err)
+		}
+	}
+
+	low := "https://spam.example.net/offer"
+	if err := handler.storage.SaveRequest(&storage.Request{
+		ID: "low-1", CreatedAt: now, SourceType: "url", SourceURL: &low,
+		Metadata: map[string]interface{}{
+			"below_threshold":    true,
+			"tombstone_datetime": now.Add(-time.Hour).Format(time.RFC3339),
+		},
+	}); err != nil {
+		t.Fatalf("Failed to save request: %v", err)
+	}
+
+	handler.updateMetrics()
+
+	gauges := []struct {
+		outcome, domain string
+		want            float64
+	}{
+		{queue.ScrapeOutcomeFailed, "example.com", 2},
+		{queue.ScrapeOutcomeFailed, "flaky.example.org", 1},
+		{queue.ScrapeOutcomeFailed, otherDomainLabel, 0},
+		{queue.ScrapeOutcomeBelowThreshold, "spam.example.net", 1},
+		{queue.ScrapeOutcomeBelowThreshold, otherDomainLabel, 0},
+	}
+	for _, g := range gauges {
+		if got := testutil.ToFloat64(handler.metrics.scrapeOutcomesByDomain.WithLabelValues(g.outcome, g.domain)); got != g.want {
+			t.Errorf("%s scrapes for %s: expected %v, got %v", g.outcome, g.domain, g.want, got)
+		}
+	}
+	if got := testutil.CollectAndCount(handler.metrics.scrapeOutcomesByDomain); got != len(gauges) {
+		t.Errorf("expected %d series, got %d", len(gauges), got)
+	}
+}
@@ -0,0 +1,146 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/docutag/controller/internal/clients"
+	"github.com/docutag/controller/internal/queue"
+	"github.com/docutag/controller/internal/storage"
+	"github.com/hibiken/asynq"
+)
+
+func TestWorkerCrawlBudget(t *testing.T) {
+	t.Parallel()
+	connStr, dbCleanup := setupTestDB(t, "crawl_budget_worker")
+	defer dbCleanup()
+
+	store, err := storage.New(connStr, []string{"low-quality", "sparse-content"}, 30, 90, 90)
+	if err != nil {
+		t.Fatalf("Failed to create storage: %v", err)
+	}
+	defer store.Close()
+
+	scraperMock := mockScraperServer()
+	defer scraperMock.Close()
+	textAnalyzerMock := mockTextAnalyzerServer()
+	defer textAnalyzerMock.Close()
+
+	worker := queue.NewWorker(queue.WorkerConfig{
+		RedisAddr:          "localhost:6379",
+		Concurrency:        1,
+		LinkScoreThreshold: 0.5,
+		MaxLinkDepth:       3,
+		CrawlMaxPages:      2,
+	}, store, clients.NewScraperClient(scraperMock.URL), clients.NewTextAnalyzerClient(textAnalyzerMock.URL), nil, nil, nil, nil, nil)
+
+	now := time.Now()
+	save := func(job *storage.ScrapeJob) {
+		t.Helper()
+		job.Status, job.ExtractLinks, job.CreatedAt, job.UpdatedAt = "completed", true, now, now
+		if err := store.SaveScrapeJob(job); err != nil {
+			t.Fatalf("Failed to save job %s: %v", job.ID, err)
+		}
+	}
+	extract := func(parentJobID, rootJobID, sourceURL string, depth int) error {
+		payload, _ := json.Marshal(queue.ExtractLinksTaskPayload{
+			ParentJobID: parentJobID,
+			SourceURL:   sourceURL,
+			ParentDepth: depth,
+			RootJobID:   rootJobID,
+		})
+		return worker.ProcessTask(context.Background(), asynq.NewTask(queue.TypeExtractLinks, payload))
+	}
+	childrenOf := func(rootJobID string) int {
+		t.Helper()
+		jobs, err := store.ListScrapeJobs(1000, 0)
+		if err != nil {
+			t.Fatalf("Failed to list jobs: %v", err)
+		}
+		n := 0
+		for _, job := range jobs {
+			if job.RootJobID != nil && *job.RootJobID == rootJobID {
+				n++
+			}
+		}
+		return n
+	}
+
+	t.Run("concurrent fan-out stops at max_pages", func(t *testing.T) {
+		// The root and three pages at depth 1 each extract 3 links: 12 wanted, 5 allowed
+		root := "budget-root"
+		save(&storage.ScrapeJob{ID: root, URL: "https://example.com", MaxPages: 5})
+		parents := []string{root}
+		for i := 0; i < 3; i++ {
+			id := fmt.Sprintf("budget-page-%d", i)
+			save(&storage.ScrapeJob{ID: id, URL: "https://example.com/page-" + id, ParentJobID: &root, RootJobID: &root, Depth: 1})
+			parents = append(parents, id)
+		}
+
+		var wg sync.WaitGroup
+		errs := make(chan error, len(parents))
+		for i, parent := range parents {
+			rootJobID := root
+			if parent == root {
+				rootJobID = "" // The seed's own task predates any root
+			}
+			wg.Add(1)
+			go func(parent, rootJobID string, depth int) {
+				defer wg.Done()
+				errs <- extract(parent, rootJobID, "https://example.com/"+parent, depth)
+			}(parent, rootJobID, min(i, 1))
+		}
+		wg.Wait()
+		close(errs)
+		for err := range errs {
+			if err != nil {
+				t.Fatalf("extract links task failed: %v", err)
+			}
+		}
+
+		if got := childrenOf(root); got != 5 {
+			t.Errorf("expected 5 jobs queued under the crawl, got %d", got)
+		}
+		job, err := store.GetScrapeJob(root)
+		if err != nil {
+			t.Fatalf("Failed to get root job: %v", err)
+		}
+		if !job.BudgetExhausted || job.PagesEnqueued != 5 {
+			t.Errorf("expected root to be exhausted after 5 pages, got exhausted=%v enqueued=%d", job.BudgetExhausted, job.PagesEnqueued)
+		}
+	})
+
+	t.Run("CRAWL_MAX_PAGES applies without max_pages", func(t *testing.T) {
+		root := "default-budget-root"
+		save(&storage.ScrapeJob{ID: root, URL: "https://example.com/default"})
+		if err := extract(root, "", "https://example.com/default", 0); err != nil {
+			t.Fatalf("extract links task failed: %v", err)
+		}
+		if got := childrenOf(root); got != 2 {
+			t.Errorf("expected the default budget of 2 pages, got %d", got)
+		}
+	})
+
+	t.Run("deleted root grants nothing", func(t *testing.T) {
+		root := "deleted-root"
+		parent := "orphaned-page"
+		save(&storage.ScrapeJob{ID: parent, URL: "https://example.com/orphaned", RootJobID: &root, Depth: 1})
+		if err := extract(parent, root, "https://example.com/orphaned", 1); err != nil {
+			t.Fatalf("extract links task failed: %v", err)
+		}
+		if got := childrenOf(root); got != 0 {
+			t.Errorf("expected no pages queued for a deleted root, got %d", got)
+		}
+		job, err := store.GetScrapeJob(parent)
+		if err != nil {
+			t.Fatalf("Failed to get parent job: %v", err)
+		}
+		if !job.BudgetExhausted {
+			t.Error("expected the orphaned parent to be flagged budget_exhausted")
+		}
+	})
+}
@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/docutag/controller/internal/clients"
+	"github.com/docutag/controller/internal/queue"
+	"github.com/docutag/controller/internal/storage"
+	"github.com/hibiken/asynq"
+)
+
+func TestWorkerHonoursEnqueuedCrawlParams(t *testing.T) {
+	t.Parallel()
+	connStr, dbCleanup := setupTestDB(t, "crawl_params_worker")
+	defer dbCleanup()
+
+	store, err := storage.New(connStr, []string{"low-quality", "sparse-content"}, 30, 90, 90)
+	if err != nil {
+		t.Fatalf("Failed to create storage: %v", err)
+	}
+	defer store.Close()
+
+	scraperMock := mockScraperServer()
+	defer scraperMock.Close()
+	textAnalyzerMock := mockTextAnalyzerServer()
+	defer textAnalyzerMock.Close()
+
+	// The crawl was submitted under a deeper limit and a lower threshold than the worker now has
+	params := &storage.CrawlParams{MaxDepth: 3, LinkScoreThreshold: 0.2, Queue: queue.QueueScrape}
+	worker := queue.NewWorker(queue.WorkerConfig{
+		RedisAddr:          "localhost:6379",
+		Concurrency:        1,
+		LinkScoreThreshold: 0.5,
+		MaxLinkDepth:       1,
+	}, store, clients.NewScraperClient(scraperMock.URL), clients.NewTextAnalyzerClient(textAnalyzerMock.URL), nil, nil, nil, nil, nil)
+
+	now := time.Now()
+	root := &storage.ScrapeJob{ID: "params-root", URL: "https://low-quality.com", ExtractLinks: true, Status: "queued", CreatedAt: now, UpdatedAt: now, CrawlParams: params}
+	if err := store.SaveScrapeJob(root); err != nil {
+		t.Fatalf("Failed to save job: %v", err)
+	}
+
+	// low-quality.com scores 0.3: below the worker's threshold but not the crawl's
+	payload, _ := json.Marshal(queue.ScrapeTaskPayload{JobID: root.ID, URL: root.URL, ExtractLinks: true, CrawlParams: params})
+	if err := worker.ProcessTask(context.Background(), asynq.NewTask(queue.TypeScrapeURL, payload)); err != nil {
+		t.Fatalf("scrape task failed: %v", err)
+	}
+	job, err := store.GetScrapeJob(root.ID)
+	if err != nil {
+		t.Fatalf("Failed to get job: %v", err)
+	}
+	if job.ResultRequestID == nil {
+		t.Fatalf("expected the scrape to produce a request, got status %s: %s", job.Status, job.ErrorMessage)
+	}
+	request, err := store.GetRequest(*job.ResultRequestID)
+	if err != nil {
+		t.Fatalf("Failed to get request: %v", err)
+	}
+	if request.Metadata["below_threshold"] == true || request.Metadata["threshold"] != 0.2 {
+		t.Errorf("expected the crawl threshold of 0.2 to apply, got metadata %v", request.Metadata)
+	}
+
+	// Children at depth 1 still extract links because the crawl allows depth 3
+	extract, _ := json.Marshal(queue.ExtractLinksTaskPayload{ParentJobID: root.ID, SourceURL: "https://example.com", CrawlParams: params})
+	if err := worker.ProcessTask(context.Background(), asynq.NewTask(queue.TypeExtractLinks, extract)); err != nil {
+		t.Fatalf("extract links task failed: %v", err)
+	}
+	jobs, err := store.ListScrapeJobs(100, 0)
+	if err != nil {
+		t.Fatalf("Failed to list jobs: %v", err)
+	}
+	children := 0
+	for _, child := range jobs {
+		if child.ParentJobID == nil || *child.ParentJobID != root.ID {
+			continue
+		}
+		children++
+		if !child.ExtractLinks {
+			t.Errorf("expected child %s to extract links under the crawl's max depth", child.URL)
+		}
+		if child.CrawlParams == nil || *child.CrawlParams != *params {
+			t.Errorf("expected child %s to inherit %+v, got %+v", child.URL, params, child.CrawlParams)
+		}
+	}
+	if children == 0 {
+		t.Fatal("expected link extraction to queue children")
+	}
+
+	// A job queued without params adopts the worker's current settings
+	legacy := &storage.ScrapeJob{ID: "params-legacy", URL: "https://example.com/legacy", Status: "queued", CreatedAt: now, UpdatedAt: now}
+	if err := store.SaveScrapeJob(legacy); err != nil {
+		t.Fatalf("Failed to save job: %v", err)
+	}
+	payload, _ = json.Marshal(queue.ScrapeTaskPayload{JobID: legacy.ID, URL: legacy.URL})
+	if err := worker.ProcessTask(context.Background(), asynq.NewTask(queue.TypeScrapeURL, payload)); err != nil {
+		t.Fatalf("scrape task failed: %v", err)
+	}
+	job, err = store.GetScrapeJob(legacy.ID)
+	if err != nil {
+		t.Fatalf("Failed to get job: %v", err)
+	}
+	want := storage.CrawlParams{MaxDepth: 1, LinkScoreThreshold: 0.5, Queue: queue.QueueScrape}
+	if job.CrawlParams == nil || *job.CrawlParams != want {
+		t.Errorf("expected recorded params %+v, got %+v", want, job.CrawlParams)
+	}
+}
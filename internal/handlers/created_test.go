@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRespondCreatedV1(t *testing.T) {
+	t.Parallel()
+	created := func(w http.ResponseWriter, r *http.Request) {
+		respondCreatedV1(w, r, "/scrape-requests/job-1", map[string]string{"id": "job-1"})
+	}
+
+	tests := []struct {
+		version    string
+		wantStatus int
+	}{
+		{APIVersionV1, http.StatusCreated},
+		{APIVersionUnversioned, http.StatusOK}, // The deprecated alias keeps its old status
+	}
+	for _, tt := range tests {
+		t.Run(tt.version, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			(&Handler{}).withAPIVersion(tt.version, created).ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/scrape-requests", nil))
+			if w.Code != tt.wantStatus {
+				t.Errorf("Expected status %d, got %d", tt.wantStatus, w.Code)
+			}
+			if got := w.Header().Get("Location"); got != "/api/v1/scrape-requests/job-1" {
+				t.Errorf("Expected Location /api/v1/scrape-requests/job-1, got %q", got)
+			}
+		})
+	}
+}
+
+func TestCreationEndpointsReturnLocation(t *testing.T) {
+	t.Parallel()
+	handler, _, _, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	tests := []struct {
+		name       string
+		path       string
+		body       string
+		wantStatus int
+		wantPrefix string
+	}{
+		{"scrape", "/api/v1/scrape", `{"url":"https://example.com"}`, http.StatusCreated, "/api/v1/requests/"},
+		{"analyze", "/api/v1/analyze", `{"text":"Some text to analyze."}`, http.StatusCreated, "/api/v1/requests/"},
+		{"scrape request", "/api/v1/scrape-requests", `{"url":"https://example.com/async"}`, http.StatusCreated, "/api/v1/scrape-requests/"},
+		{"text analysis request", "/api/v1/analyze-requests", `{"text":"Some text to analyze."}`, http.StatusCreated, "/api/v1/scrape-requests/"},
+		{"unversioned text analysis request", "/api/analyze-requests", `{"text":"More text to analyze."}`, http.StatusOK, "/api/v1/scrape-requests/"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, tt.path, bytes.NewBufferString(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			serveRoute(handler, w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tt.wantStatus, w.Code, w.Body.String())
+			}
+			var created struct {
+				ID string `json:"id"`
+			}
+			if err := json.NewDecoder(w.Body).Decode(&created); err != nil || created.ID == "" {
+				t.Fatalf("Expected a created resource with an ID, got %v", err)
+			}
+			location := w.Header().Get("Location")
+			if location != tt.wantPrefix+created.ID {
+				t.Fatalf("Expected Location %s%s, got %q", tt.wantPrefix, created.ID, location)
+			}
+
+			// The Location resolves to the resource just created
+			get := httptest.NewRecorder()
+			serveRoute(handler, get, httptest.NewRequest(http.MethodGet, location, nil))
+			if get.Code != http.StatusOK || !strings.Contains(get.Body.String(), created.ID) {
+				t.Errorf("Expected GET %s to return the resource, got %d: %s", location, get.Code, get.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,229 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+
+	"github.com/docutag/controller/internal/clients"
+	"github.com/docutag/controller/internal/storage"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Bulk image actions
+const (
+	imageActionTombstone   = "tombstone"
+	imageActionUntombstone = "untombstone"
+	imageActionDelete      = "delete"
+)
+
+// bulkImageConcurrency bounds the scraper calls made at once by a bulk image action
+const bulkImageConcurrency = 4
+
+// bulkImagePageSize is how many images a bulk image action lists from the scraper at a time
+const bulkImagePageSize = 500
+
+// ImageOperationResult is the outcome of a bulk action for one image
+type ImageOperationResult struct {
+	ImageID string `json:"image_id"`
+	Status  string `json:"status"` // "succeeded", "failed", or "planned" in a dry run
+	Error   string `json:"error,omitempty"`
+}
+
+// BulkImageResponse summarizes a bulk action on a document's images. A failure for one
+// image does not stop the others. A dry run lists the selected images as planned.
+type BulkImageResponse struct {
+	ScraperUUID string                 `json:"scraper_uuid"`
+	Action      string                 `json:"action"`
+	Total       int                    `json:"total"`
+	Succeeded   int                    `json:"succeeded"`
+	Failed      int                    `json:"failed"`
+	Results     []ImageOperationResult `json:"results"`
+	DryRun      bool                   `json:"dry_run,omitempty"`
+}
+
+// TombstoneRequestBody is the optional body of PUT and DELETE /api/requests/{id}/tombstone
+type TombstoneRequestBody struct {
+	CascadeImages bool `json:"cascade_images"` // Also tombstone (or untombstone) the images scraped with the request
+}
+
+// TombstoneRequestResponse reports a request tombstone change and, with cascade_images, what
+// happened to each of the request's images
+type TombstoneRequestResponse struct {
+	Message           string             `json:"message"`
+	TombstoneDatetime string             `json:"tombstone_datetime,omitempty"`
+	Images            *BulkImageResponse `json:"images,omitempty"`
+	ImagesError       string             `json:"images_error,omitempty"` // Set when the images could not be listed; the request change still stands
+}
+
+// documentImageIDs lists the images of a scrape an action applies to, following pages until the
+// total is reached. Tombstoning lists live images, untombstoning only tombstoned ones and
+// deleting all of them.
+func (h *Handler) documentImageIDs(ctx context.Context, scrapeID, action string) ([]string, error) {
+	var ids []string
+	opts := clients.ImageListOptions{Limit: bulkImagePageSize, IncludeTombstoned: action != imageActionTombstone}
+	for {
+		page, err := h.scraper.GetImagesByScrapeID(ctx, scrapeID, opts)
+		if err != nil {
+			return nil, err
+		}
+		for _, image := range page.Images {
+			if action == imageActionUntombstone && image.TombstoneDatetime == nil {
+				continue
+			}
+			ids = append(ids, image.ID)
+		}
+		opts.Offset += len(page.Images)
+		if len(page.Images) == 0 || opts.Offset >= page.Total {
+			return ids, nil
+		}
+	}
+}
+
+// applyToDocumentImages tombstones, untombstones or deletes the images of a scrape with bounded
+// concurrency, counting each outcome in ops. Tombstoning skips images that are already
+// tombstoned and untombstoning skips live ones. A dry run lists the images and stops there.
+func (h *Handler) applyToDocumentImages(ctx context.Context, scrapeID, action string, ops *prometheus.CounterVec, dryRun bool) (*BulkImageResponse, error) {
+	op := h.scraper.TombstoneImage
+	switch action {
+	case imageActionDelete:
+		op = h.scraper.DeleteImage
+	case imageActionUntombstone:
+		op = h.scraper.UntombstoneImage
+	}
+
+	ids, err := h.documentImageIDs(ctx, scrapeID, action)
+	if err != nil {
+		return nil, err
+	}
+	if dryRun {
+		resp := &BulkImageResponse{ScraperUUID: scrapeID, Action: action, Total: len(ids), Results: make([]ImageOperationResult, len(ids)), DryRun: true}
+		for i, id := range ids {
+			resp.Results[i] = ImageOperationResult{ImageID: id, Status: "planned"}
+		}
+		return resp, nil
+	}
+
+	results := make([]ImageOperationResult, len(ids))
+	sem := make(chan struct{}, bulkImageConcurrency)
+	var wg sync.WaitGroup
+	for i, id := range ids {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, id string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			results[i] = ImageOperationResult{ImageID: id, Status: "succeeded"}
+			if err := op(ctx, id); err != nil {
+				results[i].Status = "failed"
+				results[i].Error = err.Error()
+				ops.WithLabelValues(action, "failure").Inc()
+				return
+			}
+			h.evictImage(id)
+			ops.WithLabelValues(action, "success").Inc()
+		}(i, id)
+	}
+	wg.Wait()
+
+	resp := &BulkImageResponse{ScraperUUID: scrapeID, Action: action, Total: len(ids), Results: results}
+	for _, result := range results {
+		if result.Status == "succeeded" {
+			resp.Succeeded++
+		} else {
+			resp.Failed++
+		}
+	}
+	return resp, nil
+}
+
+// TombstoneDocumentImages handles PUT /api/documents/{uuid}/images/tombstone
+func (h *Handler) TombstoneDocumentImages(w http.ResponseWriter, r *http.Request) {
+	h.bulkDocumentImages(w, r, imageActionTombstone)
+}
+
+// DeleteDocumentImages handles DELETE /api/documents/{uuid}/images
+func (h *Handler) DeleteDocumentImages(w http.ResponseWriter, r *http.Request) {
+	h.bulkDocumentImages(w, r, imageActionDelete)
+}
+
+func (h *Handler) bulkDocumentImages(w http.ResponseWriter, r *http.Request, action string) {
+	scrapeID := r.PathValue("uuid")
+	if scrapeID == "" {
+		respondErrorCode(w, ErrCodeValidationFailed, "Scraper UUID is required", http.StatusBadRequest)
+		return
+	}
+
+	dryRun, err := dryRunFromQuery(r)
+	if err != nil {
+		respondErrorCode(w, ErrCodeValidationFailed, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	resp, err := h.applyToDocumentImages(r.Context(), scrapeID, action, h.collectors().imageOperations, dryRun)
+	if err != nil {
+		respondErrorCode(w, ErrCodeUpstreamError, fmt.Sprintf("Failed to list document images: %v", err), http.StatusBadGateway)
+		return
+	}
+	h.auditImageResults(r, resp, map[string]interface{}{"scraper_uuid": scrapeID})
+
+	respondJSON(w, resp, http.StatusOK)
+}
+
+// auditImageResults records an audit entry for each image a bulk action succeeded on
+func (h *Handler) auditImageResults(r *http.Request, resp *BulkImageResponse, details map[string]interface{}) {
+	auditAction := storage.AuditActionTombstone
+	switch resp.Action {
+	case imageActionDelete:
+		auditAction = storage.AuditActionDelete
+	case imageActionUntombstone:
+		auditAction = storage.AuditActionUntombstone
+	}
+	for _, result := range resp.Results {
+		if result.Status == "succeeded" {
+			h.recordAudit(r, auditAction, storage.AuditEntityImage, result.ImageID, details)
+		}
+	}
+}
+
+// cascadeRequestImages tombstones or untombstones the images scraped with a request, for
+// TombstoneRequest and UntombstoneRequest with cascade_images. Requests that were never scraped
+// have no images. A failure to list the images is returned as a message for images_error, since
+// the request's own tombstone change has already been saved.
+func (h *Handler) cascadeRequestImages(ctx context.Context, record *storage.Request, action string) (*BulkImageResponse, string) {
+	if record.ScraperUUID == nil || *record.ScraperUUID == "" {
+		return &BulkImageResponse{Action: action, Results: []ImageOperationResult{}}, ""
+	}
+
+	resp, err := h.applyToDocumentImages(ctx, *record.ScraperUUID, action, h.collectors().cascadedImageOperations, false)
+	if err != nil {
+		slog.Default().Warn("failed to list images for tombstone cascade",
+			"request_id", record.ID,
+			"scraper_uuid", *record.ScraperUUID,
+			"action", action,
+			"error", err,
+		)
+		return nil, fmt.Sprintf("Failed to list document images: %v", err)
+	}
+	return resp, ""
+}
+
+// deleteDocumentImages removes a scrape's images when its request is purged. Failures are
+// logged rather than returned so the rest of the purge still runs.
+func (h *Handler) deleteDocumentImages(ctx context.Context, scrapeID string) {
+	resp, err := h.applyToDocumentImages(ctx, scrapeID, imageActionDelete, h.collectors().imageOperations, false)
+	if err != nil {
+		slog.Default().Warn("failed to list images for deletion", "scraper_uuid", scrapeID, "error", err)
+		return
+	}
+	if resp.Failed > 0 {
+		slog.Default().Warn("failed to delete some document images",
+			"scraper_uuid", scrapeID,
+			"failed", resp.Failed,
+			"total", resp.Total,
+		)
+	}
+}
@@ -0,0 +1,289 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/docutag/controller/internal/clients"
+	"github.com/docutag/controller/internal/storage"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// bulkImageScraper lists a scrape's images and records tombstone and delete calls
+type bulkImageScraper struct {
+	images   []*clients.ImageInfo
+	failing  map[string]bool
+	inFlight atomic.Int32
+	maxSeen  atomic.Int32
+
+	mu        sync.Mutex
+	processed map[string]string // image ID -> method
+}
+
+func newBulkImageScraper(t *testing.T, count int, failing ...string) (*bulkImageScraper, *Handler) {
+	m := &bulkImageScraper{failing: map[string]bool{}, processed: map[string]string{}}
+	for i := 1; i <= count; i++ {
+		m.images = append(m.images, &clients.ImageInfo{ID: fmt.Sprintf("img-%d", i)})
+	}
+	for _, id := range failing {
+		m.failing[id] = true
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/scrapes/{id}/images", func(w http.ResponseWriter, r *http.Request) {
+		limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+		offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+		json.NewEncoder(w).Encode(mockImagePage(m.images, limit, offset, r.URL.Query().Get("include_tombstoned") == "true"))
+	})
+	record := func(w http.ResponseWriter, r *http.Request) {
+		current := m.inFlight.Add(1)
+		defer m.inFlight.Add(-1)
+		for {
+			seen := m.maxSeen.Load()
+			if current <= seen || m.maxSeen.CompareAndSwap(seen, current) {
+				break
+			}
+		}
+		time.Sleep(time.Millisecond)
+
+		id := r.PathValue("id")
+		if m.failing[id] {
+			http.Error(w, "boom", http.StatusInternalServerError)
+			return
+		}
+		m.mu.Lock()
+		m.processed[id] = r.Method
+		m.mu.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+	}
+	mux.HandleFunc("PUT /api/images/{id}/tombstone", record)
+	mux.HandleFunc("DELETE /api/images/{id}", record)
+	mux.HandleFunc("DELETE /api/images/{id}/tombstone", record)
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return m, &Handler{scraper: clients.NewScraperClient(server.URL), metrics: unregisteredHandlerMetrics()}
+}
+
+func TestApplyToDocumentImagesPartialFailure(t *testing.T) {
+	t.Parallel()
+	scraper, h := newBulkImageScraper(t, 10, "img-3", "img-7")
+
+	resp, err := h.applyToDocumentImages(context.Background(), "scrape-1", imageActionTombstone, h.metrics.imageOperations, false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if resp.Total != 10 || resp.Succeeded != 8 || resp.Failed != 2 {
+		t.Errorf("total/succeeded/failed = %d/%d/%d, want 10/8/2", resp.Total, resp.Succeeded, resp.Failed)
+	}
+	for _, result := range resp.Results {
+		wantFailed := result.ImageID == "img-3" || result.ImageID == "img-7"
+		if (result.Status == "failed") != wantFailed {
+			t.Errorf("%s: status %s", result.ImageID, result.Status)
+		}
+		if wantFailed && result.Error == "" {
+			t.Errorf("%s: expected a failure reason", result.ImageID)
+		}
+	}
+	if len(scraper.processed) != 8 {
+		t.Errorf("expected 8 images tombstoned upstream, got %d", len(scraper.processed))
+	}
+	if scraper.processed["img-1"] != http.MethodPut {
+		t.Errorf("expected tombstone via PUT, got %q", scraper.processed["img-1"])
+	}
+	if max := scraper.maxSeen.Load(); max > bulkImageConcurrency {
+		t.Errorf("expected at most %d concurrent scraper calls, saw %d", bulkImageConcurrency, max)
+	}
+}
+
+func TestApplyToDocumentImagesFollowsPages(t *testing.T) {
+	t.Parallel()
+	scraper, h := newBulkImageScraper(t, bulkImagePageSize+3)
+
+	resp, err := h.applyToDocumentImages(context.Background(), "scrape-1", imageActionDelete, h.metrics.imageOperations, false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.Total != bulkImagePageSize+3 || resp.Succeeded != resp.Total {
+		t.Errorf("expected every image across pages to be deleted, got %d of %d", resp.Succeeded, resp.Total)
+	}
+	if scraper.processed[fmt.Sprintf("img-%d", bulkImagePageSize+3)] != http.MethodDelete {
+		t.Error("expected the last image on the second page to be deleted")
+	}
+}
+
+func TestBulkDocumentImagesListingFailure(t *testing.T) {
+	t.Parallel()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+	h := &Handler{scraper: clients.NewScraperClient(server.URL)}
+
+	w := httptest.NewRecorder()
+	serveRoute(h, w, httptest.NewRequest(http.MethodDelete, "/api/v1/documents/scrape-1/images", nil))
+
+	if w.Code != http.StatusBadGateway {
+		t.Fatalf("expected status 502, got %d", w.Code)
+	}
+	var resp ErrorResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if resp.Code != ErrCodeUpstreamError {
+		t.Errorf("expected %s, got %s", ErrCodeUpstreamError, resp.Code)
+	}
+}
+
+func TestApplyToDocumentImagesUntombstone(t *testing.T) {
+	t.Parallel()
+	scraper, h := newBulkImageScraper(t, 4, "img-4")
+	tombstoned := time.Now().Add(-time.Hour)
+	for _, image := range scraper.images[1:] {
+		image.TombstoneDatetime = &tombstoned
+	}
+
+	resp, err := h.applyToDocumentImages(context.Background(), "scrape-1", imageActionUntombstone, h.metrics.cascadedImageOperations, false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Only the three tombstoned images are restored; the live one is left alone
+	if resp.Total != 3 || resp.Succeeded != 2 || resp.Failed != 1 {
+		t.Errorf("total/succeeded/failed = %d/%d/%d, want 3/2/1", resp.Total, resp.Succeeded, resp.Failed)
+	}
+	if _, ok := scraper.processed["img-1"]; ok {
+		t.Error("expected the live image not to be untombstoned")
+	}
+	if scraper.processed["img-2"] != http.MethodDelete {
+		t.Errorf("expected untombstone via DELETE, got %q", scraper.processed["img-2"])
+	}
+	if got := testutil.ToFloat64(h.metrics.cascadedImageOperations.WithLabelValues(imageActionUntombstone, "success")); got != 2 {
+		t.Errorf("expected 2 cascaded untombstones counted, got %v", got)
+	}
+}
+
+func TestCascadeRequestImages(t *testing.T) {
+	t.Parallel()
+	scraper, h := newBulkImageScraper(t, 3, "img-2")
+	scrapeID := "scrape-1"
+
+	resp, msg := h.cascadeRequestImages(context.Background(), &storage.Request{ID: "req-1", ScraperUUID: &scrapeID}, imageActionTombstone)
+	if msg != "" {
+		t.Fatalf("unexpected images error: %s", msg)
+	}
+	if resp.Action != imageActionTombstone || resp.Succeeded != 2 || resp.Failed != 1 {
+		t.Errorf("unexpected cascade result: %+v", resp)
+	}
+	if len(scraper.processed) != 2 {
+		t.Errorf("expected 2 images tombstoned upstream, got %d", len(scraper.processed))
+	}
+
+	// Text requests have no images and never call the scraper
+	resp, msg = h.cascadeRequestImages(context.Background(), &storage.Request{ID: "req-2"}, imageActionTombstone)
+	if msg != "" || resp.Total != 0 || resp.Results == nil {
+		t.Errorf("expected an empty result, got %+v, %q", resp, msg)
+	}
+
+	// A listing failure is reported rather than returned as an error
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer failing.Close()
+	h = &Handler{scraper: clients.NewScraperClient(failing.URL)}
+	if resp, msg = h.cascadeRequestImages(context.Background(), &storage.Request{ID: "req-1", ScraperUUID: &scrapeID}, imageActionTombstone); resp != nil || msg == "" {
+		t.Errorf("expected an images error, got %+v, %q", resp, msg)
+	}
+}
+
+func TestTombstoneRequestCascadeImages(t *testing.T) {
+	t.Parallel()
+	handler, _, _, cleanup := setupTestHandler(t)
+	defer cleanup()
+	scraper, bulk := newBulkImageScraper(t, 3, "img-3")
+	handler.scraper = bulk.scraper
+
+	scrapeID := "scrape-1"
+	if err := handler.storage.SaveRequest(&storage.Request{
+		ID: "cascade-req", CreatedAt: time.Now(), SourceType: "url", ScraperUUID: &scrapeID, Metadata: map[string]interface{}{},
+	}); err != nil {
+		t.Fatalf("Failed to save request: %v", err)
+	}
+
+	w := httptest.NewRecorder()
+	serveRoute(handler, w, httptest.NewRequest(http.MethodPut, "/api/v1/requests/cascade-req/tombstone", strings.NewReader(`{"cascade_images": true}`)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp TombstoneRequestResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if resp.TombstoneDatetime == "" || resp.Images == nil || resp.Images.Succeeded != 2 || resp.Images.Failed != 1 {
+		t.Errorf("unexpected response: %+v", resp)
+	}
+	if len(scraper.processed) != 2 {
+		t.Errorf("expected 2 images tombstoned upstream, got %d", len(scraper.processed))
+	}
+
+	// The image failure does not roll back the request's tombstone
+	record, err := handler.storage.GetRequest("cascade-req")
+	if err != nil {
+		t.Fatalf("Failed to get request: %v", err)
+	}
+	if record.Metadata["tombstone_datetime"] == nil {
+		t.Error("Expected the request to stay tombstoned")
+	}
+
+	// Without the flag only the request changes
+	w = httptest.NewRecorder()
+	serveRoute(handler, w, httptest.NewRequest(http.MethodDelete, "/api/v1/requests/cascade-req/tombstone", nil))
+	if w.Code != http.StatusOK || strings.Contains(w.Body.String(), `"images"`) {
+		t.Errorf("Expected a plain untombstone, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestBulkDocumentImagesDryRun(t *testing.T) {
+	t.Parallel()
+	for _, tt := range []struct{ method, path string }{
+		{http.MethodPut, "/api/v1/documents/scrape-1/images/tombstone?dry_run=true"},
+		{http.MethodDelete, "/api/v1/documents/scrape-1/images?dry_run=true"},
+	} {
+		t.Run(tt.method, func(t *testing.T) {
+			scraper, h := newBulkImageScraper(t, 3)
+
+			w := httptest.NewRecorder()
+			serveRoute(h, w, httptest.NewRequest(tt.method, tt.path, nil))
+			if w.Code != http.StatusOK {
+				t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+			}
+			var resp BulkImageResponse
+			if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if !resp.DryRun || resp.Total != 3 || resp.Succeeded != 0 || len(resp.Results) != 3 || resp.Results[0].Status != "planned" {
+				t.Errorf("unexpected dry run response: %+v", resp)
+			}
+			// Nothing is tombstoned or deleted upstream
+			if len(scraper.processed) != 0 || scraper.maxSeen.Load() != 0 {
+				t.Errorf("expected no upstream changes, got %v", scraper.processed)
+			}
+		})
+	}
+
+	w := httptest.NewRecorder()
+	serveRoute(&Handler{}, w, httptest.NewRequest(http.MethodDelete, "/api/v1/documents/scrape-1/images?dry_run=yes-please", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400 for an invalid dry_run, got %d", w.Code)
+	}
+}
@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/docutag/controller/internal/storage"
+)
+
+// DocumentLinksResponse lists the links found on a request's page when it was crawled
+type DocumentLinksResponse struct {
+	RequestID string                 `json:"request_id"`
+	JobID     string                 `json:"job_id,omitempty"` // Scrape job whose link extraction recorded the links
+	Total     int                    `json:"total"`            // Links the scraper found, including any not stored
+	Truncated bool                   `json:"truncated"`        // Only the first storage.MaxStoredDocumentLinks links were stored
+	Links     []storage.DocumentLink `json:"links"`
+}
+
+// GetRequestLinks handles GET /api/requests/{id}/links. Links are only recorded when a scrape
+// extracted them, so a request scraped without extract_links has an empty list.
+func (h *Handler) GetRequestLinks(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if id == "" {
+		respondErrorCode(w, ErrCodeValidationFailed, "Request ID is required", http.StatusBadRequest)
+		return
+	}
+
+	if _, err := h.store(r).GetRequest(id); err != nil {
+		if err.Error() == "request not found" {
+			respondErrorCode(w, ErrCodeRequestNotFound, "Request not found", http.StatusNotFound)
+			return
+		}
+		respondErrorCode(w, ErrCodeInternal, fmt.Sprintf("Failed to get request: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	job, links, err := h.store(r).GetDocumentLinks(id)
+	if err != nil {
+		respondErrorCode(w, ErrCodeInternal, fmt.Sprintf("Failed to get document links: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	response := DocumentLinksResponse{RequestID: id, Links: []storage.DocumentLink{}}
+	if job != nil {
+		response.JobID = job.ID
+		response.Links = links
+		response.Total = len(links)
+		if job.LinkSummary != nil && job.LinkSummary.Found > len(links) {
+			response.Total = job.LinkSummary.Found
+			response.Truncated = true
+		}
+	}
+	respondJSON(w, response, http.StatusOK)
+}
@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/docutag/controller/internal/clients"
+	"github.com/docutag/controller/internal/queue"
+	"github.com/docutag/controller/internal/storage"
+	"github.com/hibiken/asynq"
+)
+
+func TestGetRequestLinks(t *testing.T) {
+	t.Parallel()
+	handler, scraperMock, analyzerMock, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	links := []string{
+		"https://example.com/a",
+		"mailto:editor@example.com",
+		"https://example.com/a#comments",
+		"https://example.com/b",
+		"https://example.com/c",
+	}
+	scraper := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/extract-links" {
+			json.NewEncoder(w).Encode(clients.ExtractLinksResponse{URL: "https://example.com/", Links: links, Count: len(links)})
+			return
+		}
+		scraperMock.Config.Handler.ServeHTTP(w, r)
+	}))
+	defer scraper.Close()
+
+	sourceURL := "https://example.com/"
+	if err := handler.storage.SaveRequest(&storage.Request{ID: "links-req", CreatedAt: time.Now(), SourceType: "url", SourceURL: &sourceURL, Tags: []string{}, Metadata: map[string]interface{}{}}); err != nil {
+		t.Fatalf("Failed to save request: %v", err)
+	}
+	getLinks := func() DocumentLinksResponse {
+		t.Helper()
+		w := httptest.NewRecorder()
+		serveRoute(handler, w, httptest.NewRequest(http.MethodGet, "/api/requests/links-req/links", nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+		}
+		var resp DocumentLinksResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		return resp
+	}
+
+	// A request whose scrape did not extract links has none recorded
+	if resp := getLinks(); resp.JobID != "" || len(resp.Links) != 0 || resp.Truncated {
+		t.Errorf("Expected no links before extraction, got %+v", resp)
+	}
+
+	now := time.Now()
+	job := &storage.ScrapeJob{ID: "links-job", URL: sourceURL, ExtractLinks: true, MaxPages: 2, Status: "completed", CreatedAt: now, UpdatedAt: now}
+	if err := handler.storage.SaveScrapeJob(job); err != nil {
+		t.Fatalf("Failed to save job: %v", err)
+	}
+	if err := handler.storage.UpdateScrapeJobResult(job.ID, "links-req"); err != nil {
+		t.Fatalf("Failed to set job result: %v", err)
+	}
+
+	worker := queue.NewWorker(queue.WorkerConfig{RedisAddr: "localhost:6379", Concurrency: 1, LinkScoreThreshold: 0.5, MaxLinkDepth: 2},
+		handler.storage, clients.NewScraperClient(scraper.URL), clients.NewTextAnalyzerClient(analyzerMock.URL), nil, nil, nil, nil, nil)
+	payload, _ := json.Marshal(queue.ExtractLinksTaskPayload{ParentJobID: job.ID, SourceURL: sourceURL})
+	if err := worker.ProcessTask(context.Background(), asynq.NewTask(queue.TypeExtractLinks, payload)); err != nil {
+		t.Fatalf("extract links task failed: %v", err)
+	}
+
+	resp := getLinks()
+	if resp.JobID != job.ID || resp.Total != len(links) || resp.Truncated {
+		t.Fatalf("Expected every link from %s, got %+v", job.ID, resp)
+	}
+	want := []struct{ disposition, reason string }{
+		{storage.LinkDispositionEnqueued, ""},
+		{storage.LinkDispositionSkipped, "unscrapable"},
+		{storage.LinkDispositionSkipped, "duplicate"},
+		{storage.LinkDispositionEnqueued, ""},
+		{storage.LinkDispositionSkipped, "budget_exhausted"},
+	}
+	if len(resp.Links) != len(want) {
+		t.Fatalf("Expected %d links, got %+v", len(want), resp.Links)
+	}
+	for i, w := range want {
+		link := resp.Links[i]
+		if link.URL != links[i] || link.Disposition != w.disposition || link.Reason != w.reason {
+			t.Errorf("link %d: expected %s %s/%s, got %+v", i, links[i], w.disposition, w.reason, link)
+		}
+		if (link.Disposition == storage.LinkDispositionEnqueued) != (link.ChildJobID != nil) {
+			t.Errorf("link %d: expected a child job only for enqueued links, got %v", i, link.ChildJobID)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	serveRoute(handler, w, httptest.NewRequest(http.MethodGet, "/api/requests/missing/links", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for an unknown request, got %d", w.Code)
+	}
+}
@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/docutag/controller/internal/clients"
+	"github.com/docutag/controller/internal/queue"
+	"github.com/docutag/controller/internal/settings"
+	"github.com/docutag/controller/internal/storage"
+	"github.com/hibiken/asynq"
+)
+
+// The mock scraper scores low-quality.com 0.3 and example.com 0.8; the global threshold is 0.5
+var testDomainThresholds = map[string]float64{
+	"www.low-quality.com": 0.2,
+	"example.com":         0.9,
+}
+
+func TestScrapeURLDomainScoreThresholds(t *testing.T) {
+	t.Parallel()
+	handler, _, _, cleanup := setupTestHandler(t)
+	defer cleanup()
+	handler.SetDomainScoreThresholds(settings.NewDomainThresholds(testDomainThresholds))
+
+	tests := []struct {
+		name               string
+		url                string
+		wantBelowThreshold bool
+		wantThreshold      float64
+	}{
+		{"override admits low score", "https://low-quality.com", false, 0.2},
+		{"override rejects decent score", "https://example.com", true, 0.9},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			jsonData, _ := json.Marshal(ScrapeURLRequest{URL: tt.url})
+			req := httptest.NewRequest(http.MethodPost, "/api/scrape", bytes.NewBuffer(jsonData))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+
+			serveRoute(handler, w, req)
+
+			if w.Code != http.StatusCreated {
+				t.Fatalf("Expected status 201, got %d: %s", w.Code, w.Body.String())
+			}
+			var response ControllerResponse
+			if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+
+			belowThreshold := response.Metadata["below_threshold"] == true
+			if belowThreshold != tt.wantBelowThreshold {
+				t.Errorf("Expected below_threshold %v, got %v", tt.wantBelowThreshold, belowThreshold)
+			}
+			if got := response.Metadata["effective_threshold"]; got != tt.wantThreshold {
+				t.Errorf("Expected effective_threshold %v, got %v", tt.wantThreshold, got)
+			}
+			if got := response.Metadata["threshold"]; got != 0.5 {
+				t.Errorf("Expected global threshold 0.5, got %v", got)
+			}
+		})
+	}
+}
+
+func TestWorkerDomainScoreThresholds(t *testing.T) {
+	t.Parallel()
+	connStr, dbCleanup := setupTestDB(t, "domain_thresholds_worker")
+	defer dbCleanup()
+
+	store, err := storage.New(connStr, []string{"low-quality", "sparse-content"}, 30, 90, 90)
+	if err != nil {
+		t.Fatalf("Failed to create storage: %v", err)
+	}
+	defer store.Close()
+
+	scraperMock := mockScraperServer()
+	defer scraperMock.Close()
+	textAnalyzerMock := mockTextAnalyzerServer()
+	defer textAnalyzerMock.Close()
+
+	worker := queue.NewWorker(queue.WorkerConfig{
+		RedisAddr:             "localhost:6379",
+		Concurrency:           1,
+		LinkScoreThreshold:    0.5,
+		MaxLinkDepth:          1,
+		DomainScoreThresholds: testDomainThresholds,
+	}, store, clients.NewScraperClient(scraperMock.URL), clients.NewTextAnalyzerClient(textAnalyzerMock.URL), nil, nil, nil, nil, nil)
+
+	tests := []struct {
+		jobID              string
+		url                string
+		wantBelowThreshold bool
+		wantThreshold      float64
+	}{
+		{"job-admitted", "https://low-quality.com", false, 0.2},
+		{"job-rejected", "https://example.com", true, 0.9},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.jobID, func(t *testing.T) {
+			now := time.Now()
+			job := &storage.ScrapeJob{ID: tt.jobID, URL: tt.url, Status: "queued", CreatedAt: now, UpdatedAt: now}
+			if err := store.SaveScrapeJob(job); err != nil {
+				t.Fatalf("Failed to save job: %v", err)
+			}
+			payload, _ := json.Marshal(queue.ScrapeTaskPayload{JobID: tt.jobID, URL: tt.url})
+			if err := worker.ProcessTask(context.Background(), asynq.NewTask(queue.TypeScrapeURL, payload)); err != nil {
+				t.Fatalf("task failed: %v", err)
+			}
+
+			job, err := store.GetScrapeJob(tt.jobID)
+			if err != nil || job.ResultRequestID == nil {
+				t.Fatalf("expected job result, got %+v (err %v)", job, err)
+			}
+			record, err := store.GetRequest(*job.ResultRequestID)
+			if err != nil {
+				t.Fatalf("Failed to get request: %v", err)
+			}
+
+			belowThreshold := record.Metadata["below_threshold"] == true
+			if belowThreshold != tt.wantBelowThreshold {
+				t.Errorf("Expected below_threshold %v, got %v", tt.wantBelowThreshold, belowThreshold)
+			}
+			if got := record.Metadata["effective_threshold"]; got != tt.wantThreshold {
+				t.Errorf("Expected effective_threshold %v, got %v", tt.wantThreshold, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,22 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+)
+
+// dryRunFromQuery reads the dry_run query parameter that bulk and destructive endpoints accept.
+// A dry run goes through the same selection as the real call and reports what it would affect,
+// but writes nothing and makes no mutating upstream calls.
+func dryRunFromQuery(r *http.Request) (bool, error) {
+	value := r.URL.Query().Get("dry_run")
+	if value == "" {
+		return false, nil
+	}
+	dryRun, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("Invalid dry_run: must be true or false, got %q", value)
+	}
+	return dryRun, nil
+}
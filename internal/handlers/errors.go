@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/docutag/controller/internal/urlguard"
+	"github.com/docutag/controller/pkg/logging"
+)
+
+// Error codes returned in the "code" field of error responses. They are stable:
+// clients may match on them, so existing values must never change meaning.
+const (
+	ErrCodeValidationFailed      = "VALIDATION_FAILED"        // A parameter or field is missing or malformed
+	ErrCodeInvalidRequestBody    = "INVALID_REQUEST_BODY"     // The body is not valid JSON for the endpoint
+	ErrCodeMethodNotAllowed      = "METHOD_NOT_ALLOWED"       // The endpoint does not accept the HTTP method
+	ErrCodeNotFound              = "NOT_FOUND"                // A resource without a more specific code was not found
+	ErrCodeRequestNotFound       = "REQUEST_NOT_FOUND"        // No document request has the given ID
+	ErrCodeVersionNotFound       = "VERSION_NOT_FOUND"        // The request has no version with the given number
+	ErrCodeImageNotFound         = "IMAGE_NOT_FOUND"          // No image has the given ID
+	ErrCodeImageTombstoned       = "IMAGE_TOMBSTONED"         // The image is tombstoned and its content is no longer served
+	ErrCodeScrapeRequestNotFound = "SCRAPE_REQUEST_NOT_FOUND" // No scrape job has the given ID
+	ErrCodeSavedSearchNotFound   = "SAVED_SEARCH_NOT_FOUND"   // No saved search has the given ID
+	ErrCodeWebhookNotFound       = "WEBHOOK_NOT_FOUND"        // No webhook has the given ID
+	ErrCodeURLRejected           = "URL_REJECTED"             // The URL failed safety validation (scheme, private target, ...)
+	ErrCodeDomainNotAllowed      = "DOMAIN_NOT_ALLOWED"       // The URL's domain is blocked by the operator's domain policy
+	ErrCodeNamespaceForbidden    = "NAMESPACE_FORBIDDEN"      // The API key is confined to a different namespace than the one requested
+	ErrCodeInvalidState          = "INVALID_STATE"            // The resource is not in a state that allows the operation
+	ErrCodeDuplicateSlug         = "DUPLICATE_SLUG"           // The slug is already used by another request
+	ErrCodePreconditionFailed    = "PRECONDITION_FAILED"      // If-Match names a version the request has moved on from
+	ErrCodePreconditionRequired  = "PRECONDITION_REQUIRED"    // REQUIRE_IF_MATCH is on and the update carried no If-Match
+	ErrCodeRateLimited           = "RATE_LIMITED"             // The caller sent too many requests
+	ErrCodeArchiveTooLarge       = "ARCHIVE_TOO_LARGE"        // The request's archive would exceed ARCHIVE_MAX_MB
+	ErrCodeContentTooLarge       = "CONTENT_TOO_LARGE"        // The request's raw HTML exceeds RAW_HTML_MAX_MB
+	ErrCodeCorruptContent        = "CORRUPT_CONTENT"          // The request's stored compressed content cannot be decoded
+	ErrCodeUpstreamError         = "UPSTREAM_ERROR"           // A downstream service (scraper, analyzer, scheduler) returned an error
+	ErrCodeUpstreamUnavailable   = "UPSTREAM_UNAVAILABLE"     // A downstream service could not be reached
+	ErrCodeNotConfigured         = "NOT_CONFIGURED"           // The feature's integration is not configured
+	ErrCodeQueueSaturated        = "QUEUE_SATURATED"          // Too many scrape jobs are queued; retry after the Retry-After delay
+	ErrCodeInternal              = "INTERNAL_ERROR"           // An unexpected server-side failure
+)
+
+// ErrorResponse represents an error response. Error repeats Message for clients
+// written before codes were introduced and will be removed once they have migrated.
+type ErrorResponse struct {
+	Error     string                 `json:"error"`
+	Code      string                 `json:"code"`
+	Message   string                 `json:"message"`
+	RequestID string                 `json:"request_id,omitempty"`
+	Details   map[string]interface{} `json:"details,omitempty"`
+}
+
+// respondErrorCode writes an error response with a machine-readable code
+func respondErrorCode(w http.ResponseWriter, code, message string, status int) {
+	respondErrorDetails(w, code, message, status, nil)
+}
+
+// respondErrorDetails writes an error response with a code and structured details
+func respondErrorDetails(w http.ResponseWriter, code, message string, status int, details map[string]interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(ErrorResponse{
+		Error:     message,
+		Code:      code,
+		Message:   message,
+		RequestID: w.Header().Get(logging.RequestIDHeader),
+		Details:   details,
+	})
+}
+
+// upstreamErrorCode picks a code for a status relayed from a downstream service such as the scheduler
+func upstreamErrorCode(status int) string {
+	switch status {
+	case http.StatusBadRequest, http.StatusUnprocessableEntity:
+		return ErrCodeValidationFailed
+	case http.StatusNotFound:
+		return ErrCodeNotFound
+	case http.StatusConflict:
+		return ErrCodeInvalidState
+	case http.StatusTooManyRequests:
+		return ErrCodeRateLimited
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return ErrCodeUpstreamUnavailable
+	default:
+		return ErrCodeUpstreamError
+	}
+}
+
+// urlRejectionDetails exposes the urlguard rule that rejected a URL
+func urlRejectionDetails(err error) map[string]interface{} {
+	var validationErr *urlguard.ValidationError
+	if !errors.As(err, &validationErr) {
+		return nil
+	}
+	return map[string]interface{}{"rule": validationErr.Rule}
+}
@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/docutag/controller/internal/clients"
+	"github.com/docutag/controller/internal/urlguard"
+	"github.com/docutag/controller/pkg/logging"
+)
+
+func TestRespondErrorCode(t *testing.T) {
+	t.Parallel()
+	w := httptest.NewRecorder()
+	w.Header().Set(logging.RequestIDHeader, "req-123")
+
+	respondErrorCode(w, ErrCodeRequestNotFound, "Request not found", http.StatusNotFound)
+
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("expected status 404, got %d", w.Code)
+	}
+
+	var resp ErrorResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if resp.Code != ErrCodeRequestNotFound || resp.Message != "Request not found" || resp.RequestID != "req-123" {
+		t.Errorf("unexpected error response: %+v", resp)
+	}
+	// Legacy clients still read the top-level error string
+	if resp.Error != "Request not found" {
+		t.Errorf("expected legacy error string, got %q", resp.Error)
+	}
+}
+
+func TestErrorCodesFromHandlers(t *testing.T) {
+	t.Parallel()
+	h := &Handler{}
+
+	tests := []struct {
+		name     string
+		method   string
+		path     string
+		body     string
+		wantCode string
+		wantRule string
+	}{
+		{"method not allowed", http.MethodPost, "/api/requests/abc", "", ErrCodeMethodNotAllowed, ""},
+		{"invalid limit", http.MethodGet, "/api/audit?limit=0", "", ErrCodeValidationFailed, ""},
+		{"invalid body", http.MethodPost, "/api/scrape-requests", "{", ErrCodeInvalidRequestBody, ""},
+		{"private target", http.MethodPost, "/api/scrape-requests", `{"url":"http://127.0.0.1/"}`, ErrCodeURLRejected, urlguard.RulePrivateTarget},
+		{"scheduler not configured", http.MethodGet, "/api/scheduler/tasks/1", "", ErrCodeNotConfigured, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			serveRoute(h, w, httptest.NewRequest(tt.method, tt.path, bytes.NewBufferString(tt.body)))
+
+			var resp ErrorResponse
+			if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if resp.Code != tt.wantCode {
+				t.Errorf("expected code %s, got %s (%s)", tt.wantCode, resp.Code, resp.Message)
+			}
+			if tt.wantRule != "" && resp.Details["rule"] != tt.wantRule {
+				t.Errorf("expected rule %q in details, got %v", tt.wantRule, resp.Details)
+			}
+		})
+	}
+}
+
+func TestSchedulerErrorCode(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		err  error
+		want string
+	}{
+		{&clients.SchedulerError{StatusCode: http.StatusNotFound}, ErrCodeNotFound},
+		{&clients.SchedulerError{StatusCode: http.StatusBadRequest}, ErrCodeValidationFailed},
+		{&clients.SchedulerError{StatusCode: http.StatusConflict}, ErrCodeInvalidState},
+		{&clients.SchedulerError{StatusCode: http.StatusInternalServerError}, ErrCodeUpstreamError},
+		{&clients.SchedulerError{StatusCode: http.StatusServiceUnavailable}, ErrCodeUpstreamUnavailable},
+		{errors.New("connection refused"), ErrCodeUpstreamUnavailable},
+	}
+	for _, tt := range tests {
+		if got := schedulerErrorCode(tt.err); got != tt.want {
+			t.Errorf("schedulerErrorCode(%v) = %s, want %s", tt.err, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// SetRequireIfMatch makes tag and SEO updates fail with 428 unless they send If-Match. When
+// off, updates without the header overwrite whatever is stored, as before ETags existed.
+func (h *Handler) SetRequireIfMatch(require bool) {
+	h.requireIfMatch = require
+}
+
+// requestETag is the strong entity tag of a request at a row version
+func requestETag(version int64) string {
+	return strconv.Quote(strconv.FormatInt(version, 10))
+}
+
+// ifMatchVersion reads the If-Match header of an update. It returns the row version the
+// update is conditional on, or 0 for an unconditional update (no header, or "*"). A header
+// that cannot match any version fails the request with 412, and a missing one with 428 when
+// If-Match is required; ok is false once a response has been written.
+func (h *Handler) ifMatchVersion(w http.ResponseWriter, r *http.Request) (version int64, ok bool) {
+	header := strings.TrimSpace(r.Header.Get("If-Match"))
+	if header == "" {
+		if h.requireIfMatch {
+			respondErrorCode(w, ErrCodePreconditionRequired, "If-Match header is required; send the ETag from GET /api/v1/requests/{id}", http.StatusPreconditionRequired)
+			return 0, false
+		}
+		return 0, true
+	}
+	if header == "*" {
+		return 0, true
+	}
+	// Weak tags never match under If-Match's strong comparison, and we only issue one tag per
+	// version, so anything but a single quoted number is a mismatch
+	unquoted, err := strconv.Unquote(header)
+	if err == nil {
+		version, err = strconv.ParseInt(unquoted, 10, 64)
+	}
+	if err != nil || version <= 0 {
+		respondErrorCode(w, ErrCodePreconditionFailed, fmt.Sprintf("If-Match %s does not match the request's ETag", header), http.StatusPreconditionFailed)
+		return 0, false
+	}
+	return version, true
+}
+
+// respondVersionMismatch answers an update whose If-Match lost to a concurrent change with 412
+// and the request's current ETag, so the client can re-read and merge
+func respondVersionMismatch(w http.ResponseWriter, current int64) {
+	etag := requestETag(current)
+	w.Header().Set("ETag", etag)
+	respondErrorDetails(w, ErrCodePreconditionFailed, "The request was changed after it was read; fetch it again and retry",
+		http.StatusPreconditionFailed, map[string]interface{}{"current_etag": etag})
+}
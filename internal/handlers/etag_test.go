@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/docutag/controller/internal/storage"
+)
+
+func TestIfMatchPreconditions(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name    string
+		path    string
+		body    string
+		ifMatch string
+		require bool
+		want    int
+		code    string
+	}{
+		{"weak tag never matches", "/tags", `{"tags":["a"]}`, `W/"3"`, false, http.StatusPreconditionFailed, ErrCodePreconditionFailed},
+		{"unquoted tag", "/tags", `{"tags":["a"]}`, `3`, false, http.StatusPreconditionFailed, ErrCodePreconditionFailed},
+		{"tag list", "/tags", `{"tags":["a"]}`, `"3", "4"`, false, http.StatusPreconditionFailed, ErrCodePreconditionFailed},
+		{"missing when required", "/tags", `{"tags":["a"]}`, "", true, http.StatusPreconditionRequired, ErrCodePreconditionRequired},
+		{"SEO update missing when required", "/seo-enabled", `{"seo_enabled":true}`, "", true, http.StatusPreconditionRequired, ErrCodePreconditionRequired},
+	}
+	for _, tt := range tests {
+		h := &Handler{}
+		h.SetRequireIfMatch(tt.require)
+		req := httptest.NewRequest(http.MethodPut, "/api/v1/requests/req-1"+tt.path, strings.NewReader(tt.body))
+		if tt.ifMatch != "" {
+			req.Header.Set("If-Match", tt.ifMatch)
+		}
+		w := httptest.NewRecorder()
+		serveRoute(h, w, req)
+		var resp ErrorResponse
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		if w.Code != tt.want || resp.Code != tt.code {
+			t.Errorf("%s: expected %d %s, got %d: %s", tt.name, tt.want, tt.code, w.Code, w.Body.String())
+		}
+	}
+}
+
+// TestUpdateRequestTagsLostUpdate has two editors read the same version of a request and
+// save their tag edits in turn; the second must be refused rather than erase the first
+func TestUpdateRequestTagsLostUpdate(t *testing.T) {
+	t.Parallel()
+	handler, _, _, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	if err := handler.storage.SaveRequest(&storage.Request{ID: "etag-1", CreatedAt: time.Now().UTC(), SourceType: "text",
+		Tags: []string{"news"}, Metadata: map[string]interface{}{}}); err != nil {
+		t.Fatalf("Failed to save request: %v", err)
+	}
+
+	read := func() string {
+		w := httptest.NewRecorder()
+		serveRoute(handler, w, httptest.NewRequest(http.MethodGet, "/api/v1/requests/etag-1", nil))
+		if w.Code != http.StatusOK || w.Header().Get("ETag") == "" {
+			t.Fatalf("Expected 200 with an ETag, got %d %q", w.Code, w.Header().Get("ETag"))
+		}
+		return w.Header().Get("ETag")
+	}
+	save := func(etag, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPut, "/api/v1/requests/etag-1/tags", strings.NewReader(body))
+		req.Header.Set("If-Match", etag)
+		w := httptest.NewRecorder()
+		serveRoute(handler, w, req)
+		return w
+	}
+
+	alice, bob := read(), read()
+	first := save(alice, `{"tags":["news","politics"]}`)
+	if first.Code != http.StatusOK {
+		t.Fatalf("Expected the first save to succeed, got %d: %s", first.Code, first.Body.String())
+	}
+	second := save(bob, `{"tags":["news","sports"]}`)
+	if second.Code != http.StatusPreconditionFailed {
+		t.Fatalf("Expected 412 for the stale save, got %d: %s", second.Code, second.Body.String())
+	}
+	if got := second.Header().Get("ETag"); got != first.Header().Get("ETag") || got != read() {
+		t.Errorf("Expected the 412 to carry the current ETag %s, got %s", first.Header().Get("ETag"), got)
+	}
+
+	record, err := handler.storage.GetRequest("etag-1")
+	if err != nil {
+		t.Fatalf("Failed to get request: %v", err)
+	}
+	if strings.Join(record.Tags, ",") != "news,politics" {
+		t.Errorf("Expected the first editor's tags to survive, got %v", record.Tags)
+	}
+
+	// Retrying with the fresh ETag goes through
+	if w := save(read(), `{"tags":["news","politics","sports"]}`); w.Code != http.StatusOK {
+		t.Errorf("Expected the retried save to succeed, got %d: %s", w.Code, w.Body.String())
+	}
+}
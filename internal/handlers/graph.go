@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/docutag/controller/internal/storage"
+)
+
+const (
+	defaultGraphDepth    = 2
+	maxGraphDepth        = 5
+	defaultGraphMaxNodes = 200
+	maxGraphMaxNodes     = 1000
+)
+
+// LinkGraphResponse is the body of GET /api/graph
+type LinkGraphResponse struct {
+	Root  string `json:"root"`
+	Depth int    `json:"depth"`
+	*storage.LinkGraph
+}
+
+// GetLinkGraph handles GET /api/graph?root={request_id}&depth=2&max_nodes=200. It returns
+// the documents reachable from root by following the links crawls took, up to depth hops.
+func (h *Handler) GetLinkGraph(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	root := strings.TrimSpace(query.Get("root"))
+	if root == "" {
+		respondErrorCode(w, ErrCodeValidationFailed, "root is required", http.StatusBadRequest)
+		return
+	}
+
+	depth := defaultGraphDepth
+	if s := query.Get("depth"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 1 || n > maxGraphDepth {
+			respondErrorCode(w, ErrCodeValidationFailed, fmt.Sprintf("depth must be between 1 and %d", maxGraphDepth), http.StatusBadRequest)
+			return
+		}
+		depth = n
+	}
+	maxNodes := defaultGraphMaxNodes
+	if s := query.Get("max_nodes"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 1 || n > maxGraphMaxNodes {
+			respondErrorCode(w, ErrCodeValidationFailed, fmt.Sprintf("max_nodes must be between 1 and %d", maxGraphMaxNodes), http.StatusBadRequest)
+			return
+		}
+		maxNodes = n
+	}
+
+	if _, err := h.store(r).GetRequest(root); err != nil {
+		if err.Error() == "request not found" {
+			respondErrorCode(w, ErrCodeRequestNotFound, "Request not found", http.StatusNotFound)
+			return
+		}
+		respondErrorCode(w, ErrCodeInternal, fmt.Sprintf("Failed to get request: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	graph, err := h.store(r).GetLinkGraph(root, depth, maxNodes)
+	if err != nil {
+		respondErrorCode(w, ErrCodeInternal, fmt.Sprintf("Failed to build link graph: %v", err), http.StatusInternalServerError)
+		return
+	}
+	respondJSON(w, LinkGraphResponse{Root: root, Depth: depth, LinkGraph: graph}, http.StatusOK)
+}
@@ -0,0 +1,30 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestGetLinkGraphValidation(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name  string
+		query string
+	}{
+		{"missing root", ""},
+		{"depth zero", "?root=abc&depth=0"},
+		{"depth too large", "?root=abc&depth=6"},
+		{"depth not a number", "?root=abc&depth=two"},
+		{"max nodes too large", "?root=abc&max_nodes=5000"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			serveRoute(&Handler{}, w, httptest.NewRequest(http.MethodGet, "/api/graph"+tt.query, nil))
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("expected 400, got %d: %s", w.Code, w.Body.String())
+			}
+		})
+	}
+}
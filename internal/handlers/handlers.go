@@ -3,42 +3,85 @@ package handlers
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"log/slog"
 	"net/http"
-	"net/url"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/docutag/controller/internal/clients"
+	"github.com/docutag/controller/internal/domaintag"
 	"github.com/docutag/controller/internal/events"
+	"github.com/docutag/controller/internal/imagecache"
+	"github.com/docutag/controller/internal/language"
+	"github.com/docutag/controller/internal/pipeline"
 	"github.com/docutag/controller/internal/queue"
+	"github.com/docutag/controller/internal/sanitize"
 	"github.com/docutag/controller/internal/scraper_requests"
+	"github.com/docutag/controller/internal/settings"
 	internalslug "github.com/docutag/controller/internal/slug"
 	"github.com/docutag/controller/internal/storage"
+	"github.com/docutag/controller/internal/templates"
+	"github.com/docutag/controller/internal/urlguard"
+	"github.com/docutag/controller/internal/urlnorm"
+	"github.com/docutag/controller/internal/webhooks"
+	"github.com/docutag/controller/pkg/logging"
 	"github.com/docutag/platform/pkg/metrics"
 	"github.com/docutag/platform/pkg/tracing"
+	"github.com/prometheus/client_golang/prometheus"
 	"go.opentelemetry.io/otel/attribute"
 )
 
 // Handler contains all HTTP handlers
 type Handler struct {
-	storage                 *storage.Storage
-	scraper                 *clients.ScraperClient
-	textAnalyzer            *clients.TextAnalyzerClient
-	scheduler               *clients.SchedulerClient
-	linkScoreThreshold      float64
-	scrapeRequests          *scraper_requests.Manager // TODO: Remove after text analysis queue is implemented
-	queueClient             *queue.Client
-	urlCache                URLCache
-	webInterfaceURL         string
-	scraperBaseURL          string
-	businessMetrics         *metrics.BusinessMetrics
-	tombstonePeriodLowScore int // Days until deletion for low-score URLs
-	tombstonePeriodManual   int // Days until deletion for manual tombstones
-	broadcaster             *events.Broadcaster
+	storage                *storage.Storage
+	scraper                *clients.ScraperClient
+	textAnalyzer           *clients.TextAnalyzerClient
+	scheduler              *clients.SchedulerClient
+	settings               *settings.Settings        // Runtime-tunable thresholds and tombstone periods
+	domainThresholds       settings.DomainThresholds // Per-domain link score thresholds; nil uses the global one
+	domainTagIncludeHost   bool                      // Tag scraped requests with their full host as well as their registrable domain
+	scrapeRequests         *scraper_requests.Manager // TODO: Remove after text analysis queue is implemented
+	queueClient            *queue.Client
+	urlCache               URLCache
+	webInterfaceURL        string
+	scraperBaseURL         string
+	businessMetrics        *metrics.BusinessMetrics
+	metrics                *handlerMetrics // Registered with the registerer given to NewWithMetrics
+	broadcaster            *events.Broadcaster
+	urlGuard               *urlguard.Guard        // Rejects unsafe scrape targets
+	domainPolicy           *urlguard.DomainPolicy // Operator allow/deny lists; nil allows every domain
+	imageCache             imagecache.Cache       // Image bytes served by GetImageContent; nil disables caching
+	imageCacheMaxItemBytes int64                  // Largest image kept in imageCache
+	imageCacheTTL          time.Duration          // Age after which a cached image is revalidated with the scraper
+	statsCache             *statsCache            // Short-lived cache for GET /api/stats
+	imageSummaries         *imageSummaryCache     // Short-lived image lists for request detail views; nil disables
+	imageSitemap           *imageSitemapCache     // Last complete image sitemap; nil rebuilds it on every fetch
+	logLevel               *slog.LevelVar         // Process log level adjusted by the admin API; nil when not adjustable
+	backpressure           *queueBackpressure     // Rejects scrape submissions while the queue is saturated; nil disables
+	staleRescrape          *staleRescrape         // Freshness windows for re-scraping stored URLs; nil disables
+	reconcile              *reconciler            // Limits of orphan reconciliation runs; nil disables
+	backfills              *backfillRunner        // Background column backfills; nil disables
+	webhooks               *webhooks.Dispatcher   // Receives request.* events; nil publishes none
+	namespaceKeys          map[string]string      // API key -> the only namespace it may use
+	publicNamespace        string                 // Namespace served by the SEO pages; "" = storage.DefaultNamespace
+	pageTemplates          *templates.Renderer    // Templates of the SEO content pages; nil uses the built-in one
+	sanitizer              sanitize.Sanitizer     // Cleans scraped HTML on the SEO content pages; nil uses sanitize.DefaultPolicy
+	cacheHitLog            *logging.Sampler       // Samples "cache hit for URL" lines
+	readinessCheck         func() error           // Fails until the service can take traffic; nil is always ready
+	backups                *backups               // Snapshot directory and limits for POST /api/admin/backup; nil disables
+	maxPageLimit           int                    // Largest limit list endpoints return in one page
+	bulkMaxRequests        int                    // Most requests one bulk tombstone or delete may affect
+	archiveMaxBytes        int64                  // Largest uncompressed request archive; 0 uses the default
+	rawHTMLMaxBytes        int64                  // Largest raw HTML the content endpoint returns; 0 uses the default
+	requireIfMatch         bool                   // Reject tag and SEO updates that carry no If-Match
+	textDedupWindow        time.Duration          // Repeated text submissions within this window return the existing request; 0 disables
+	stopMetrics            context.CancelFunc     // Stops the metrics updater; nil when it was never started
+	metricsStopped         chan struct{}          // Closed once the metrics updater has returned
 }
 
 // URLCache defines the interface for URL caching
@@ -52,51 +95,153 @@ type URLCache interface {
 func New(store *storage.Storage, scraper *clients.ScraperClient, textAnalyzer *clients.TextAnalyzerClient, scheduler *clients.SchedulerClient, queueClient *queue.Client, urlCache URLCache, linkScoreThreshold float64, webInterfaceURL string, scraperBaseURL string, tombstonePeriodLowScore, tombstonePeriodManual int) *Handler {
 	// Initialize business metrics
 	businessMetrics := metrics.NewBusinessMetrics("controller")
-	return NewWithMetrics(store, scraper, textAnalyzer, scheduler, queueClient, urlCache, linkScoreThreshold, webInterfaceURL, scraperBaseURL, tombstonePeriodLowScore, tombstonePeriodManual, businessMetrics)
+	return NewWithMetrics(store, scraper, textAnalyzer, scheduler, queueClient, urlCache, linkScoreThreshold, webInterfaceURL, scraperBaseURL, tombstonePeriodLowScore, tombstonePeriodManual, businessMetrics, nil)
 }
 
-// NewWithMetrics creates a new Handler with provided business metrics
-func NewWithMetrics(store *storage.Storage, scraper *clients.ScraperClient, textAnalyzer *clients.TextAnalyzerClient, scheduler *clients.SchedulerClient, queueClient *queue.Client, urlCache URLCache, linkScoreThreshold float64, webInterfaceURL string, scraperBaseURL string, tombstonePeriodLowScore, tombstonePeriodManual int, businessMetrics *metrics.BusinessMetrics) *Handler {
+// NewWithMetrics creates a new Handler with provided business metrics. The handler's own
+// metrics are registered with reg (nil = default registry).
+func NewWithMetrics(store *storage.Storage, scraper *clients.ScraperClient, textAnalyzer *clients.TextAnalyzerClient, scheduler *clients.SchedulerClient, queueClient *queue.Client, urlCache URLCache, linkScoreThreshold float64, webInterfaceURL string, scraperBaseURL string, tombstonePeriodLowScore, tombstonePeriodManual int, businessMetrics *metrics.BusinessMetrics, reg prometheus.Registerer) *Handler {
 	h := &Handler{
-		storage:                 store,
-		scraper:                 scraper,
-		textAnalyzer:            textAnalyzer,
-		scheduler:               scheduler,
-		linkScoreThreshold:      linkScoreThreshold,
-		scrapeRequests:          scraper_requests.NewManager(), // TODO: Remove after text analysis queue is implemented
-		queueClient:             queueClient,
-		urlCache:                urlCache,
-		webInterfaceURL:         webInterfaceURL,
-		scraperBaseURL:          scraperBaseURL,
-		businessMetrics:         businessMetrics,
-		tombstonePeriodLowScore: tombstonePeriodLowScore,
-		tombstonePeriodManual:   tombstonePeriodManual,
-		broadcaster:             events.NewBroadcaster(),
-	}
-
-	// Start periodic metrics updater for gauges
-	go h.startMetricsUpdater()
+		storage:         store,
+		scraper:         scraper,
+		textAnalyzer:    textAnalyzer,
+		scheduler:       scheduler,
+		settings:        newHandlerSettings(linkScoreThreshold, tombstonePeriodLowScore, tombstonePeriodManual),
+		scrapeRequests:  scraper_requests.NewManager(), // TODO: Remove after text analysis queue is implemented
+		queueClient:     queueClient,
+		urlCache:        urlCache,
+		webInterfaceURL: webInterfaceURL,
+		scraperBaseURL:  scraperBaseURL,
+		businessMetrics: businessMetrics,
+		broadcaster:     events.NewBroadcaster(),
+		urlGuard:        urlguard.New(false),
+		statsCache:      newStatsCache(defaultStatsCacheTTL),
+		imageSummaries:  newImageSummaryCache(requestImagesCacheTTL, requestImagesCacheSize),
+		imageSitemap:    newImageSitemapCache(imageSitemapTTL),
+		maxPageLimit:    defaultMaxPageLimit,
+		bulkMaxRequests: defaultBulkMaxRequests,
+		textDedupWindow: defaultTextDedupWindow,
+	}
+	if reg == nil {
+		reg = prometheus.DefaultRegisterer
+	}
+	var err error
+	if h.metrics, err = newHandlerMetrics(reg); err != nil {
+		slog.Default().Warn("failed to register handler metrics", "error", err)
+	}
+	h.SetReconcile(defaultReconcileBatchSize, defaultReconcileUpstreamRate)
+	h.SetBackfills(0, defaultBackfillPause)
+	h.SetLogSampleEvery(logging.DefaultSampleEvery)
+
+	// Start periodic metrics updater for gauges; Close stops it
+	ctx, cancel := context.WithCancel(context.Background())
+	h.stopMetrics = cancel
+	h.metricsStopped = make(chan struct{})
+	go h.startMetricsUpdater(ctx)
 
 	return h
 }
 
+// Close stops the handler's background goroutines and waits for them to return. Storage and
+// the clients belong to the caller, so close them afterwards. Calling Close more than once is safe.
+func (h *Handler) Close() {
+	if h.stopMetrics != nil {
+		h.stopMetrics()
+		<-h.metricsStopped
+	}
+	if h.scrapeRequests != nil {
+		h.scrapeRequests.Close()
+	}
+	if h.backfills != nil {
+		h.backfills.close()
+	}
+}
+
+// SetLogSampleEvery sets how many repetitive Info lines, such as URL cache hits, are logged
+// per one at Info once the first few have been; the rest are logged at Debug. <= 1 logs them all.
+func (h *Handler) SetLogSampleEvery(every int) {
+	h.cacheHitLog = queue.NewLogSampler(slog.Default(), "cache hit for URL", "cache_hit", every, queue.LogSampleWindow)
+}
+
+// SetPageTemplates sets the templates the SEO content pages are rendered with
+func (h *Handler) SetPageTemplates(r *templates.Renderer) {
+	h.pageTemplates = r
+}
+
+// SetHTMLSanitizer sets the policy scraped HTML is cleaned with before it is served
+func (h *Handler) SetHTMLSanitizer(s sanitize.Sanitizer) {
+	h.sanitizer = s
+}
+
+// SetURLGuard replaces the validator used to reject unsafe scrape targets
+func (h *Handler) SetURLGuard(g *urlguard.Guard) {
+	h.urlGuard = g
+}
+
+// SetDomainPolicy sets the allowlist/denylist applied to scrape requests
+func (h *Handler) SetDomainPolicy(p *urlguard.DomainPolicy) {
+	h.domainPolicy = p
+}
+
+// SetDomainScoreThresholds sets per-domain link score thresholds that replace the global one
+func (h *Handler) SetDomainScoreThresholds(d settings.DomainThresholds) {
+	h.domainThresholds = d
+}
+
+// SetDomainTagIncludeHost tags scraped requests with their full host, such as
+// blog.example.com, next to their registrable domain
+func (h *Handler) SetDomainTagIncludeHost(include bool) {
+	h.domainTagIncludeHost = include
+}
+
+// linkScoreThreshold returns the threshold that applies to rawURL's domain
+func (h *Handler) linkScoreThreshold(rawURL string, current settings.Values) float64 {
+	threshold, _ := h.domainThresholds.LinkScoreThreshold(domaintag.Host(rawURL), current.LinkScoreThreshold)
+	return threshold
+}
+
+// scrapeGuard returns the handler's URL guard, defaulting to blocking private targets
+func (h *Handler) scrapeGuard() *urlguard.Guard {
+	if h.urlGuard == nil {
+		return urlguard.New(false)
+	}
+	return h.urlGuard
+}
+
+// validateScrapeURL checks that a URL is safe to hand to the scraper
+func (h *Handler) validateScrapeURL(ctx context.Context, rawURL string) error {
+	return h.scrapeGuard().Validate(ctx, rawURL)
+}
+
 // GetBusinessMetrics returns the business metrics instance
 func (h *Handler) GetBusinessMetrics() *metrics.BusinessMetrics {
 	return h.businessMetrics
 }
 
-// startMetricsUpdater periodically updates gauge metrics
-func (h *Handler) startMetricsUpdater() {
+// startMetricsUpdater periodically updates gauge metrics until ctx is cancelled
+func (h *Handler) startMetricsUpdater(ctx context.Context) {
+	defer close(h.metricsStopped)
 	ticker := time.NewTicker(15 * time.Second)
 	defer ticker.Stop()
 
-	for range ticker.C {
-		h.updateMetrics()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			h.updateMetrics()
+		}
 	}
 }
 
-// updateMetrics updates gauge metrics for queue and job status
+// updateMetrics updates gauge metrics for job status and corpus composition. Every figure
+// comes from a grouped aggregate query, so the ticker never scans rows in Go.
 func (h *Handler) updateMetrics() {
+	if h.storage == nil || h.businessMetrics == nil {
+		slog.Default().Error("skipping metrics update: handler has no storage or business metrics")
+		return
+	}
+
 	// Update queue length (if queue client is available)
 	if h.queueClient != nil {
 		// Note: Asynq doesn't provide a simple way to get queue length
@@ -104,15 +249,15 @@ func (h *Handler) updateMetrics() {
 		// For now, we'll skip this metric
 	}
 
-	// Update job status counts
-	statuses := []string{"pending", "processing", "completed", "failed", "queued"}
-	for _, status := range statuses {
-		count, err := h.storage.CountScrapeJobsByStatus(status)
-		if err != nil {
-			slog.Default().Error("failed to count jobs by status", "status", status, "error", err)
-			continue
+	// Update job status counts; statuses without jobs are reported as 0
+	jobCounts, err := h.storage.ScrapeJobStatusCounts()
+	if err != nil {
+		slog.Default().Error("failed to count jobs by status", "error", err)
+	} else {
+		statuses := []string{"pending", "processing", "completed", "failed", "queued"}
+		for _, status := range statuses {
+			h.businessMetrics.ScrapeJobsByStatus.WithLabelValues(status).Set(float64(jobCounts[status]))
 		}
-		h.businessMetrics.ScrapeJobsByStatus.WithLabelValues(status).Set(float64(count))
 	}
 
 	// Update document statistics
@@ -141,23 +286,31 @@ func (h *Handler) updateMetrics() {
 			}
 		}
 	}
+
+	h.updateDomainMetrics()
 }
 
 // ScrapeURLRequest represents a request to scrape a URL
 type ScrapeURLRequest struct {
-	URL          string `json:"url"`
-	ExtractLinks bool   `json:"extract_links,omitempty"`
+	URL             string `json:"url"`
+	ExtractLinks    bool   `json:"extract_links,omitempty"`
+	AllowDuplicates bool   `json:"allow_duplicates,omitempty"` // Keep a separate copy even if the content matches an existing request
+	OverrideRobots  bool   `json:"override_robots,omitempty"`  // Scrape even if robots.txt disallows the URL
+	MaxPages        int    `json:"max_pages,omitempty"`        // Crawl budget for extract_links jobs; 0 uses CRAWL_MAX_PAGES
+	SkipAnalysis    bool   `json:"skip_analysis,omitempty"`    // Archive the page without text analysis or quality tombstoning
 }
 
 // AnalyzeTextRequest represents a request to analyze text directly
 type AnalyzeTextRequest struct {
-	Text string `json:"text"`
+	Text  string `json:"text"`
+	Force bool   `json:"force,omitempty"` // Analyze even when the same text was submitted within TEXT_DEDUP_WINDOW_MINUTES
 }
 
 // SearchTagsRequest represents a request to search by tags
 type SearchTagsRequest struct {
-	Tags  []string `json:"tags"`
-	Fuzzy bool     `json:"fuzzy"`
+	Tags     []string `json:"tags"`
+	Fuzzy    bool     `json:"fuzzy"`
+	MatchAll bool     `json:"match_all"` // Match requests with every tag instead of any
 }
 
 // FilterRequestsRequest represents a request to filter requests
@@ -166,12 +319,94 @@ type FilterRequestsRequest struct {
 	Fuzzy      bool      `json:"fuzzy"`
 	DateStart  *string   `json:"date_start,omitempty"`
 	DateEnd    *string   `json:"date_end,omitempty"`
+	Timezone   string    `json:"timezone,omitempty"` // IANA zone for plain-date bounds; defaults to UTC
 	SourceType *string   `json:"source_type,omitempty"`
+	Language   *string   `json:"language,omitempty"` // e.g. "en" or "en-US"; "und" matches undetermined
+	Starred    *bool     `json:"starred,omitempty"`
+	CreatedBy  *string   `json:"created_by,omitempty"`
 	Limit      int       `json:"limit,omitempty"`
 	Offset     int       `json:"offset,omitempty"`
 }
 
-// ControllerResponse represents the response from the controller
+// SearchTagsResponse lists the IDs of requests matching a tag search
+type SearchTagsResponse struct {
+	RequestIDs []string `json:"request_ids"`
+	Count      int      `json:"count"`
+}
+
+// SearchDocumentsResponse is POST /api/search with return=full: a page of the matching
+// requests themselves, newest effective date first, and how many match in total
+type SearchDocumentsResponse struct {
+	Requests []ControllerResponse `json:"requests"`
+	Count    int                  `json:"count"` // Requests in this page
+	Total    int                  `json:"total"` // Requests matching before pagination
+	Limit    int                  `json:"limit"`
+	Offset   int                  `json:"offset"`
+}
+
+// TimelineExtentsResponse is the earliest effective date of any document
+type TimelineExtentsResponse struct {
+	EarliestDate string `json:"earliest_date"` // RFC3339
+}
+
+// RequestDuplicatesResponse lists the other URLs and jobs that produced a request's content
+type RequestDuplicatesResponse struct {
+	ID            string               `json:"id"`
+	ContentHash   string               `json:"content_hash"`
+	AlternateURLs []string             `json:"alternate_urls"`
+	DuplicateJobs []*storage.ScrapeJob `json:"duplicate_jobs"`
+}
+
+// RequestVersionListResponse lists the previous versions of a request
+type RequestVersionListResponse struct {
+	ID       string                    `json:"id"`
+	Versions []*storage.RequestVersion `json:"versions"`
+	Count    int                       `json:"count"`
+}
+
+// ScoreLinkResponse is a link's score and whether it meets the crawl threshold for its domain
+type ScoreLinkResponse struct {
+	URL            string            `json:"url"`
+	Score          LinkScoreResponse `json:"score"`
+	MeetsThreshold bool              `json:"meets_threshold"`
+	Threshold      float64           `json:"threshold"`
+}
+
+// LinkScoreResponse is the scraper's assessment of a link
+type LinkScoreResponse struct {
+	Score               float64  `json:"score"`
+	Reason              string   `json:"reason"`
+	Categories          []string `json:"categories"`
+	IsRecommended       bool     `json:"is_recommended"`
+	MaliciousIndicators []string `json:"malicious_indicators"`
+}
+
+// ExtractLinksResponse lists the links found on a page
+type ExtractLinksResponse struct {
+	URL   string   `json:"url"`
+	Links []string `json:"links"`
+	Count int      `json:"count"`
+}
+
+// RequestListResponse is a page of requests
+type RequestListResponse struct {
+	Requests []ControllerResponse `json:"requests"`
+	Count    int                  `json:"count"`
+	Limit    int                  `json:"limit"`
+	Offset   int                  `json:"offset"`
+}
+
+// ScrapeJobListResponse is a page of scrape jobs
+type ScrapeJobListResponse struct {
+	Requests []ScrapeJobResponse `json:"requests"`
+	Count    int                 `json:"count"`
+	Limit    int                 `json:"limit"`
+	Offset   int                 `json:"offset"`
+}
+
+// ControllerResponse represents the response from the controller. Every field is always
+// present except source_url, scraper_uuid, slug and language, which are omitted when unset;
+// tags is [] and metadata {} when empty, never null.
 type ControllerResponse struct {
 	ID               string                 `json:"id"`
 	CreatedAt        time.Time              `json:"created_at"`
@@ -181,283 +416,160 @@ type ControllerResponse struct {
 	ScraperUUID      *string                `json:"scraper_uuid,omitempty"`
 	TextAnalyzerUUID string                 `json:"textanalyzer_uuid"`
 	Tags             []string               `json:"tags"`
-	Metadata         map[string]interface{} `json:"metadata,omitempty"`
+	Metadata         map[string]interface{} `json:"metadata"`
 	Slug             *string                `json:"slug,omitempty"`
 	SEOEnabled       bool                   `json:"seo_enabled"`
+	Language         string                 `json:"language,omitempty"`
+	Starred          bool                   `json:"starred"`
+	CreatedBy        string                 `json:"created_by"` // API key fingerprint, client name, or worker that created the request
 }
 
-// ErrorResponse represents an error response
-type ErrorResponse struct {
-	Error string `json:"error"`
+// newControllerResponse converts a stored request to its API shape
+func newControllerResponse(record *storage.Request) ControllerResponse {
+	metadata := record.Metadata
+	if metadata == nil {
+		metadata = map[string]interface{}{}
+	}
+	return ControllerResponse{
+		ID:               record.ID,
+		CreatedAt:        record.CreatedAt,
+		EffectiveDate:    record.EffectiveDate,
+		SourceType:       record.SourceType,
+		SourceURL:        record.SourceURL,
+		ScraperUUID:      record.ScraperUUID,
+		TextAnalyzerUUID: record.TextAnalyzerUUID,
+		Tags:             nonNilStrings(record.Tags),
+		Metadata:         metadata,
+		Slug:             record.Slug,
+		SEOEnabled:       record.SEOEnabled,
+		Language:         record.Language,
+		Starred:          record.Starred,
+		CreatedBy:        record.CreatedBy,
+	}
 }
 
-// ScrapeURL handles URL scraping and text analysis with quality scoring
-func (h *Handler) ScrapeURL(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPost {
-		respondError(w, "Method not allowed", http.StatusMethodNotAllowed)
-		return
+// newRequestListResponse converts a page of stored requests to its API shape; an empty page is []
+func newRequestListResponse(records []*storage.Request, limit, offset int) RequestListResponse {
+	responses := make([]ControllerResponse, 0, len(records))
+	for _, record := range records {
+		responses = append(responses, newControllerResponse(record))
+	}
+	return RequestListResponse{
+		Requests: responses,
+		Count:    len(responses),
+		Limit:    limit,
+		Offset:   offset,
+	}
+}
+
+// nonNilStrings returns s, or an empty slice in place of nil so it encodes as [] rather than null
+func nonNilStrings(s []string) []string {
+	if s == nil {
+		return []string{}
 	}
+	return s
+}
 
+// ScrapeURL handles URL scraping and text analysis with quality scoring
+func (h *Handler) ScrapeURL(w http.ResponseWriter, r *http.Request) {
 	var req ScrapeURLRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondError(w, "Invalid request body", http.StatusBadRequest)
+		respondErrorCode(w, ErrCodeInvalidRequestBody, "Invalid request body", http.StatusBadRequest)
 		return
 	}
 
 	if req.URL == "" {
-		respondError(w, "URL is required", http.StatusBadRequest)
+		respondErrorCode(w, ErrCodeValidationFailed, "URL is required", http.StatusBadRequest)
 		return
 	}
 
-	// Score the link first to determine if it should be fully processed
-	scoreResp, err := h.scraper.ScoreLink(r.Context(), req.URL)
-	if err != nil {
-		respondError(w, fmt.Sprintf("Failed to score URL: %v", err), http.StatusInternalServerError)
+	if err := h.validateScrapeURL(r.Context(), req.URL); err != nil {
+		respondErrorDetails(w, ErrCodeURLRejected, fmt.Sprintf("URL rejected: %v", err), http.StatusBadRequest, urlRejectionDetails(err))
 		return
 	}
-
-	// Create controller request record
-	controllerID := uuid.New().String()
-
-	// Check if this is an image URL (skip threshold check for images)
-	isImageURL := false
-	for _, category := range scoreResp.Score.Categories {
-		if category == "image" {
-			isImageURL = true
-			break
-		}
-	}
-
-	// Check if score meets threshold (skip for image URLs)
-	if !isImageURL && scoreResp.Score.Score < h.linkScoreThreshold {
-		// Score is below threshold - mark for tombstoning and return scoring metadata only
-		tombstoneTime := time.Now().UTC().Add(time.Duration(h.tombstonePeriodLowScore) * 24 * time.Hour)
-
-		// Add domain name to tags
-		tags := scoreResp.Score.Categories
-		if domain := extractDomainTag(req.URL); domain != "" {
-			tags = append(tags, domain)
-		}
-
-		// Add 'scrape' tag to all scraped content
-		tags = append(tags, "scrape")
-
-		record := &storage.Request{
-			ID:         controllerID,
-			CreatedAt:  time.Now().UTC(),
-			SourceType: "url",
-			SourceURL:  &req.URL,
-			Tags:       tags,
-			SEOEnabled: false, // Disable SEO for below-threshold content
-			Metadata: map[string]interface{}{
-				"link_score": map[string]interface{}{
-					"score":                scoreResp.Score.Score,
-					"reason":               scoreResp.Score.Reason,
-					"categories":           scoreResp.Score.Categories,
-					"is_recommended":       scoreResp.Score.IsRecommended,
-					"malicious_indicators": scoreResp.Score.MaliciousIndicators,
-				},
-				"below_threshold":    true,
-				"threshold":          h.linkScoreThreshold,
-				"tombstone_datetime": tombstoneTime.Format(time.RFC3339), // Auto-tombstone low quality content
-			},
-		}
-
-		if err := h.storage.SaveRequest(record); err != nil {
-			respondError(w, fmt.Sprintf("Failed to save request: %v", err), http.StatusInternalServerError)
-			return
-		}
-
-		// Record tombstone metrics
-		if h.businessMetrics != nil {
-			h.businessMetrics.TombstonesCreatedTotal.WithLabelValues("low-score", "none").Inc()
-			h.businessMetrics.TombstoneDaysHistogram.WithLabelValues("low-score").Observe(float64(h.tombstonePeriodLowScore))
-		}
-		slog.Info("tombstone created",
-			"reason", "low-score",
-			"url", req.URL,
-			"score", scoreResp.Score.Score,
-			"threshold", h.linkScoreThreshold,
-			"period_days", h.tombstonePeriodLowScore,
-		)
-
-		response := ControllerResponse{
-			ID:            record.ID,
-			CreatedAt:     record.CreatedAt,
-			EffectiveDate: record.EffectiveDate,
-			SourceType:    record.SourceType,
-			SourceURL:     record.SourceURL,
-			Tags:          record.Tags,
-			Metadata:      record.Metadata,
-			Slug:          record.Slug,
-			SEOEnabled:    record.SEOEnabled,
-		}
-
-		respondJSON(w, response, http.StatusCreated)
+	if err := h.domainPolicy.Check(req.URL); err != nil {
+		respondErrorDetails(w, ErrCodeDomainNotAllowed, fmt.Sprintf("URL rejected: %v", err), http.StatusForbidden, urlRejectionDetails(err))
 		return
 	}
 
-	// Score meets or exceeds threshold - proceed with full scraping
-	scraperResp, err := h.scraper.Scrape(r.Context(), req.URL)
-	if err != nil {
-		respondError(w, fmt.Sprintf("Failed to scrape URL: %v", err), http.StatusInternalServerError)
+	if _, err := urlnorm.Normalize(req.URL); err != nil {
+		respondErrorCode(w, ErrCodeValidationFailed, fmt.Sprintf("Invalid URL: %v", err), http.StatusBadRequest)
 		return
 	}
 
-	// Build scraper metadata from the scraper response
-	scraperMetadata := make(map[string]interface{})
-	scraperMetadata["title"] = scraperResp.Title
-	scraperMetadata["content"] = scraperResp.Content
-	scraperMetadata["raw_text"] = scraperResp.RawText // Include original raw text
-	scraperMetadata["url"] = scraperResp.URL
-
-	// Also include fields from the scraper's Metadata (description, keywords, etc.)
-	if scraperResp.Metadata != nil {
-		for k, v := range scraperResp.Metadata {
-			scraperMetadata[k] = v
-		}
-	}
-
-	// Analyze the content (skip for image URLs)
-	var analyzerResp *clients.TextAnalyzerResponse
-	if !isImageURL {
-		analyzerResp, err = h.textAnalyzer.Analyze(r.Context(), scraperResp.Content)
-		if err != nil {
-			respondError(w, fmt.Sprintf("Failed to analyze text: %v", err), http.StatusInternalServerError)
-			return
-		}
-	}
-
-	// Build combined metadata
-	combinedMetadata := make(map[string]interface{})
-	combinedMetadata["scraper_metadata"] = scraperMetadata
-	if analyzerResp != nil {
-		combinedMetadata["analyzer_metadata"] = analyzerResp.Metadata
+	current := h.settings.Get()
+	deps := pipeline.Deps{
+		Scraper:          h.scraper,
+		TextAnalyzer:     h.textAnalyzer,
+		DomainThresholds: h.domainThresholds,
+		BusinessMetrics:  h.businessMetrics,
+		Save:             h.store(r).SaveRequest,
 	}
-
-	// Add link score from scraper response if available, otherwise use preliminary score
-	if scraperResp.Score != nil {
-		combinedMetadata["link_score"] = map[string]interface{}{
-			"score":                scraperResp.Score.Score,
-			"reason":               scraperResp.Score.Reason,
-			"categories":           scraperResp.Score.Categories,
-			"is_recommended":       scraperResp.Score.IsRecommended,
-			"malicious_indicators": scraperResp.Score.MaliciousIndicators,
-		}
-	} else {
-		// Fallback to preliminary score if scraper didn't return one
-		combinedMetadata["link_score"] = map[string]interface{}{
-			"score":                scoreResp.Score.Score,
-			"reason":               scoreResp.Score.Reason,
-			"categories":           scoreResp.Score.Categories,
-			"is_recommended":       scoreResp.Score.IsRecommended,
-			"malicious_indicators": scoreResp.Score.MaliciousIndicators,
-		}
-	}
-
-	// Get tags and analyzer UUID (handle nil for image URLs)
-	var tags []string
-	var analyzerUUID string
-	if analyzerResp != nil {
-		tags = analyzerResp.GetTags()
-		analyzerUUID = analyzerResp.ID
-	} else {
-		// For image URLs, use categories from link score as tags
-		if scraperResp.Score != nil {
-			tags = scraperResp.Score.Categories
-		}
+	if h.queueClient != nil {
+		deps.Retrieval = h.queueClient
+	}
+	result, err := pipeline.ProcessURL(r.Context(), deps, pipeline.Options{
+		URL:                     req.URL,
+		LinkScoreThreshold:      current.LinkScoreThreshold,
+		TombstonePeriodLowScore: current.TombstonePeriodLowScore,
+		Analysis:                pipeline.AnalyzeSync,
+		SkipAnalysis:            req.SkipAnalysis,
+		DomainTagIncludeHost:    h.domainTagIncludeHost,
+		CreatedBy:               requestCreator(r),
+	})
+	if err != nil {
+		queue.RecordScrapeOutcome(queue.ScrapeEntrySync, queue.ScrapeOutcomeFailed, clients.ScoreBucketNone)
+		respondPipelineError(w, err)
+		return
 	}
-
-	// Add domain name to tags
-	if domain := extractDomainTag(req.URL); domain != "" {
-		tags = append(tags, domain)
+	outcome := queue.ScrapeOutcomeCompleted
+	if result.BelowThreshold {
+		outcome = queue.ScrapeOutcomeBelowThreshold
 	}
+	queue.RecordScrapeOutcome(queue.ScrapeEntrySync, outcome, result.ScoreBucket())
 
-	// Add 'scrape' tag to all scraped content
-	tags = append(tags, "scrape")
-
-	// Extract slug from scraper response if available
-	var slug *string
-	if scraperResp.Slug != "" {
-		slug = &scraperResp.Slug
-	}
-
-	record := &storage.Request{
-		ID:               controllerID,
-		CreatedAt:        time.Now().UTC(),
-		SourceType:       "url",
-		SourceURL:        &req.URL,
-		ScraperUUID:      &scraperResp.ID,
-		TextAnalyzerUUID: analyzerUUID,
-		Tags:             tags,
-		Metadata:         combinedMetadata,
-		Slug:             slug,
-		SEOEnabled:       true, // Enable SEO by default
-	}
+	respondCreated(w, "/requests/"+result.Request.ID, newControllerResponse(result.Request))
+}
 
-	if err := h.storage.SaveRequest(record); err != nil {
-		respondError(w, fmt.Sprintf("Failed to save request: %v", err), http.StatusInternalServerError)
+// respondPipelineError reports a failed scrape pipeline step the way ScrapeURL always has
+func respondPipelineError(w http.ResponseWriter, err error) {
+	var stageErr *pipeline.StageError
+	if !errors.As(err, &stageErr) {
+		respondErrorCode(w, ErrCodeInternal, err.Error(), http.StatusInternalServerError)
 		return
 	}
-
-	// Enqueue analysis result retrieval task if text analysis was queued
-	if analyzerUUID != "" && h.queueClient != nil {
-		_, err := h.queueClient.EnqueueRetrieveAnalysis(r.Context(), controllerID, analyzerUUID, 0)
-		if err != nil {
-			// Log error but don't fail the request - retrieval can be retried manually if needed
-			slog.Warn("failed to enqueue analysis retrieval",
-				"request_id", controllerID,
-				"analysis_job_id", analyzerUUID,
-				"error", err,
-			)
-		} else {
-			slog.Info("enqueued analysis retrieval task",
-				"request_id", controllerID,
-				"analysis_job_id", analyzerUUID,
-			)
-		}
-	}
-
-	// Prepare response
-	response := ControllerResponse{
-		ID:               record.ID,
-		CreatedAt:        record.CreatedAt,
-		EffectiveDate:    record.EffectiveDate,
-		SourceType:       record.SourceType,
-		SourceURL:        record.SourceURL,
-		ScraperUUID:      record.ScraperUUID,
-		TextAnalyzerUUID: record.TextAnalyzerUUID,
-		Tags:             record.Tags,
-		Metadata:         record.Metadata,
-		Slug:             record.Slug,
-		SEOEnabled:       record.SEOEnabled,
+	switch stageErr.Stage {
+	case pipeline.StageScore:
+		respondErrorCode(w, ErrCodeUpstreamError, fmt.Sprintf("Failed to score URL: %v", stageErr.Err), http.StatusInternalServerError)
+	case pipeline.StageScrape:
+		respondErrorCode(w, ErrCodeUpstreamError, fmt.Sprintf("Failed to scrape URL: %v", stageErr.Err), http.StatusInternalServerError)
+	case pipeline.StageAnalyze:
+		respondErrorCode(w, ErrCodeUpstreamError, fmt.Sprintf("Failed to analyze text: %v", stageErr.Err), http.StatusInternalServerError)
+	default:
+		respondErrorCode(w, ErrCodeInternal, fmt.Sprintf("Failed to save request: %v", stageErr.Err), http.StatusInternalServerError)
 	}
-
-	respondJSON(w, response, http.StatusCreated)
 }
 
 // AnalyzeText handles direct text analysis
 func (h *Handler) AnalyzeText(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPost {
-		respondError(w, "Method not allowed", http.StatusMethodNotAllowed)
-		return
-	}
-
 	var req AnalyzeTextRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondError(w, "Invalid request body", http.StatusBadRequest)
+		respondErrorCode(w, ErrCodeInvalidRequestBody, "Invalid request body", http.StatusBadRequest)
 		return
 	}
 
 	if req.Text == "" {
-		respondError(w, "Text is required", http.StatusBadRequest)
+		respondErrorCode(w, ErrCodeValidationFailed, "Text is required", http.StatusBadRequest)
+		return
+	}
+	if h.respondDuplicateText(w, r, req.Text, req.Force) {
 		return
 	}
 
 	// Call text analyzer service
 	analyzerResp, err := h.textAnalyzer.Analyze(r.Context(), req.Text)
 	if err != nil {
-		respondError(w, fmt.Sprintf("Failed to analyze text: %v", err), http.StatusInternalServerError)
+		respondErrorCode(w, ErrCodeUpstreamError, fmt.Sprintf("Failed to analyze text: %v", err), http.StatusInternalServerError)
 		return
 	}
 
@@ -470,17 +582,11 @@ func (h *Handler) AnalyzeText(w http.ResponseWriter, r *http.Request) {
 
 	// Try to get cleaned_text from metadata
 	if cleanedText, ok := analyzerResp.Metadata["cleaned_text"].(string); ok && cleanedText != "" {
-		// Use first 100 chars of cleaned text for slug
-		textForSlug = cleanedText
-		if len(textForSlug) > 100 {
-			textForSlug = textForSlug[:100]
-		}
+		// Use the start of the cleaned text for slug
+		textForSlug = internalslug.Truncate(cleanedText, internalslug.MaxSourceRunes)
 	} else if req.Text != "" {
-		// Fallback to first 100 chars of original text
-		textForSlug = req.Text
-		if len(textForSlug) > 100 {
-			textForSlug = textForSlug[:100]
-		}
+		// Fallback to the start of the original text
+		textForSlug = internalslug.Truncate(req.Text, internalslug.MaxSourceRunes)
 	}
 
 	if textForSlug != "" {
@@ -499,156 +605,223 @@ func (h *Handler) AnalyzeText(w http.ResponseWriter, r *http.Request) {
 		},
 		Slug:             slug,
 		SEOEnabled:       true, // Enable SEO by default
+		CreatedBy:        requestCreator(r),
+		TextHash:         storage.TextHash(req.Text),
 	}
 
-	if err := h.storage.SaveRequest(record); err != nil {
-		respondError(w, fmt.Sprintf("Failed to save request: %v", err), http.StatusInternalServerError)
+	if err := h.store(r).SaveRequest(record); err != nil {
+		respondErrorCode(w, ErrCodeInternal, fmt.Sprintf("Failed to save request: %v", err), http.StatusInternalServerError)
 		return
 	}
 
 	// Prepare response
-	response := ControllerResponse{
-		ID:               record.ID,
-		CreatedAt:        record.CreatedAt,
-		EffectiveDate:    record.EffectiveDate,
-		SourceType:       record.SourceType,
-		TextAnalyzerUUID: record.TextAnalyzerUUID,
-		Tags:             record.Tags,
-		Metadata:         record.Metadata,
-		Slug:             record.Slug,
-		SEOEnabled:       record.SEOEnabled,
-	}
+	response := newControllerResponse(record)
 
-	respondJSON(w, response, http.StatusCreated)
+	respondCreated(w, "/requests/"+record.ID, response)
 }
 
-// SearchTags handles tag searching
+// SearchTags handles tag searching. By default it returns only the matching request IDs;
+// with ?return=full it returns a page of the requests themselves.
 func (h *Handler) SearchTags(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPost {
-		respondError(w, "Method not allowed", http.StatusMethodNotAllowed)
+	var req SearchTagsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondErrorCode(w, ErrCodeInvalidRequestBody, "Invalid request body", http.StatusBadRequest)
 		return
 	}
 
-	var req SearchTagsRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondError(w, "Invalid request body", http.StatusBadRequest)
+	if len(req.Tags) == 0 {
+		respondErrorCode(w, ErrCodeValidationFailed, "At least one tag is required", http.StatusBadRequest)
 		return
 	}
 
+	h.searchTags(w, r, req)
+}
+
+// GetSearchTags is SearchTags for clients that can only send GETs: the tags are a
+// comma-separated query parameter, and the response is the same as the POST's.
+func (h *Handler) GetSearchTags(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	var req SearchTagsRequest
+	for _, value := range query["tags"] {
+		for _, tag := range strings.Split(value, ",") {
+			if tag = strings.TrimSpace(tag); tag != "" {
+				req.Tags = append(req.Tags, tag)
+			}
+		}
+	}
 	if len(req.Tags) == 0 {
-		respondError(w, "At least one tag is required", http.StatusBadRequest)
+		respondErrorCode(w, ErrCodeValidationFailed, "At least one non-empty tag is required in tags", http.StatusBadRequest)
 		return
 	}
+	for _, flag := range []struct {
+		name  string
+		value *bool
+	}{{"fuzzy", &req.Fuzzy}, {"match_all", &req.MatchAll}} {
+		if value := query.Get(flag.name); value != "" {
+			parsed, err := strconv.ParseBool(value)
+			if err != nil {
+				respondErrorCode(w, ErrCodeValidationFailed, fmt.Sprintf("Invalid %s %q, use true or false", flag.name, value), http.StatusBadRequest)
+				return
+			}
+			*flag.value = parsed
+		}
+	}
 
-	requestIDs, err := h.storage.SearchByTags(req.Tags, req.Fuzzy)
+	// The URL holds the search, so keep shared caches from storing results per query string
+	w.Header().Set("Cache-Control", "private, no-store")
+	h.searchTags(w, r, req)
+}
+
+// searchTags runs a validated tag search for SearchTags and GetSearchTags
+func (h *Handler) searchTags(w http.ResponseWriter, r *http.Request, req SearchTagsRequest) {
+	switch r.URL.Query().Get("return") {
+	case "", "ids":
+	case "full":
+		h.searchDocuments(w, r, req)
+		return
+	default:
+		respondErrorCode(w, ErrCodeValidationFailed, fmt.Sprintf("Invalid return %q, use ids or full", r.URL.Query().Get("return")), http.StatusBadRequest)
+		return
+	}
+
+	search := h.store(r).SearchByTags
+	if req.MatchAll {
+		search = h.store(r).SearchByAllTags
+	}
+	requestIDs, err := search(req.Tags, req.Fuzzy)
 	if err != nil {
-		respondError(w, fmt.Sprintf("Failed to search tags: %v", err), http.StatusInternalServerError)
+		respondErrorCode(w, ErrCodeInternal, fmt.Sprintf("Failed to search tags: %v", err), http.StatusInternalServerError)
 		return
 	}
 
-	response := map[string]interface{}{
-		"request_ids": requestIDs,
-		"count":       len(requestIDs),
+	response := SearchTagsResponse{
+		RequestIDs: nonNilStrings(requestIDs),
+		Count:      len(requestIDs),
 	}
 
 	respondJSON(w, response, http.StatusOK)
 }
 
-// FilterRequests handles filtering requests with multiple criteria
-func (h *Handler) FilterRequests(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPost {
-		respondError(w, "Method not allowed", http.StatusMethodNotAllowed)
+// searchDocuments answers a tag search with full requests. It pages through the same matches
+// as the IDs-only search, so the two shapes of a search never disagree on what matches.
+func (h *Handler) searchDocuments(w http.ResponseWriter, r *http.Request, req SearchTagsRequest) {
+	pg, err := h.pageFromQuery(r, defaultFilterLimit)
+	if err != nil {
+		respondErrorCode(w, ErrCodeValidationFailed, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	requests, total, err := h.store(r).SearchRequestsByTags(req.Tags, req.Fuzzy, req.MatchAll, pg.Limit, pg.Offset)
+	if err != nil {
+		respondErrorCode(w, ErrCodeInternal, fmt.Sprintf("Failed to search tags: %v", err), http.StatusInternalServerError)
 		return
 	}
 
+	page := newRequestListResponse(requests, pg.Limit, pg.Offset)
+	respondJSON(w, SearchDocumentsResponse{
+		Requests: page.Requests,
+		Count:    page.Count,
+		Total:    total,
+		Limit:    page.Limit,
+		Offset:   page.Offset,
+	}, http.StatusOK)
+}
+
+// FilterRequests handles filtering requests with multiple criteria
+func (h *Handler) FilterRequests(w http.ResponseWriter, r *http.Request) {
 	var req FilterRequestsRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondError(w, "Invalid request body", http.StatusBadRequest)
+		respondErrorCode(w, ErrCodeInvalidRequestBody, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	opts, err := filterOptions(req, h.pageLimitMax())
+	if err != nil {
+		respondErrorCode(w, ErrCodeValidationFailed, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	// Parse date strings to time.Time if provided
+	h.respondFilteredRequests(w, r, opts)
+}
+
+// filterOptions validates a filter and converts it to storage options, clamping its limit to
+// maxLimit. Saved searches are checked with it too, so a filter that saves is a filter that runs.
+func filterOptions(req FilterRequestsRequest, maxLimit int) (storage.FilterOptions, error) {
+	// Parse date strings to time.Time if provided. Plain dates are whole days in the caller's zone.
+	loc, err := loadTimezone(req.Timezone)
+	if err != nil {
+		return storage.FilterOptions{}, err
+	}
 	var dateStart, dateEnd *time.Time
+	var endExclusive bool
 	if req.DateStart != nil && *req.DateStart != "" {
-		parsedStart, err := time.Parse(time.RFC3339, *req.DateStart)
+		parsedStart, _, err := parseDateBound(*req.DateStart, loc, false)
 		if err != nil {
-			respondError(w, fmt.Sprintf("Invalid date_start format (use RFC3339): %v", err), http.StatusBadRequest)
-			return
+			return storage.FilterOptions{}, fmt.Errorf("Invalid date_start format (use RFC3339 or YYYY-MM-DD): %v", err)
 		}
 		dateStart = &parsedStart
 	}
 	if req.DateEnd != nil && *req.DateEnd != "" {
-		parsedEnd, err := time.Parse(time.RFC3339, *req.DateEnd)
+		parsedEnd, dateOnly, err := parseDateBound(*req.DateEnd, loc, true)
 		if err != nil {
-			respondError(w, fmt.Sprintf("Invalid date_end format (use RFC3339): %v", err), http.StatusBadRequest)
-			return
+			return storage.FilterOptions{}, fmt.Errorf("Invalid date_end format (use RFC3339 or YYYY-MM-DD): %v", err)
 		}
-		dateEnd = &parsedEnd
+		dateEnd, endExclusive = &parsedEnd, dateOnly
 	}
 
-	// Set default limit if not specified
-	limit := req.Limit
-	if limit == 0 {
-		limit = 100
+	// Reduce the language to the primary subtag stored on requests
+	var lang *string
+	if req.Language != nil && *req.Language != "" {
+		code := language.Normalize(*req.Language)
+		if code == "" {
+			return storage.FilterOptions{}, fmt.Errorf("Invalid language, use a code such as \"en\" or \"pt-BR\"")
+		}
+		lang = &code
 	}
 
-	// Build filter options
-	opts := storage.FilterOptions{
-		Tags:       req.Tags,
-		Fuzzy:      req.Fuzzy,
-		DateStart:  dateStart,
-		DateEnd:    dateEnd,
-		SourceType: req.SourceType,
-		Limit:      limit,
-		Offset:     req.Offset,
+	var createdBy *string
+	if req.CreatedBy != nil && *req.CreatedBy != "" {
+		createdBy = req.CreatedBy
 	}
 
-	// Filter requests
-	requests, err := h.storage.FilterRequests(opts)
+	pg, err := boundPage(page{Limit: req.Limit, Offset: req.Offset}, defaultFilterLimit, maxLimit)
 	if err != nil {
-		respondError(w, fmt.Sprintf("Failed to filter requests: %v", err), http.StatusInternalServerError)
-		return
-	}
-
-	// Convert to response format
-	var responses []ControllerResponse
-	for _, record := range requests {
-		responses = append(responses, ControllerResponse{
-			ID:               record.ID,
-			CreatedAt:        record.CreatedAt,
-			EffectiveDate:    record.EffectiveDate,
-			SourceType:       record.SourceType,
-			SourceURL:        record.SourceURL,
-			ScraperUUID:      record.ScraperUUID,
-			TextAnalyzerUUID: record.TextAnalyzerUUID,
-			Tags:             record.Tags,
-			Metadata:         record.Metadata,
-			Slug:             record.Slug,
-		})
-	}
+		return storage.FilterOptions{}, err
+	}
+
+	return storage.FilterOptions{
+		Tags:             req.Tags,
+		Fuzzy:            req.Fuzzy,
+		DateStart:        dateStart,
+		DateEnd:          dateEnd,
+		DateEndExclusive: endExclusive,
+		SourceType:       req.SourceType,
+		Language:         lang,
+		Starred:          req.Starred,
+		CreatedBy:        createdBy,
+		Limit:            pg.Limit,
+		Offset:           pg.Offset,
+	}, nil
+}
 
-	response := map[string]interface{}{
-		"requests": responses,
-		"count":    len(responses),
-		"limit":    limit,
-		"offset":   req.Offset,
+// respondFilteredRequests runs a filter in the caller's namespace and writes the page of matching requests
+func (h *Handler) respondFilteredRequests(w http.ResponseWriter, r *http.Request, opts storage.FilterOptions) {
+	requests, err := h.store(r).FilterRequests(opts)
+	if err != nil {
+		respondErrorCode(w, ErrCodeInternal, fmt.Sprintf("Failed to filter requests: %v", err), http.StatusInternalServerError)
+		return
 	}
 
-	respondJSON(w, response, http.StatusOK)
+	respondJSON(w, newRequestListResponse(requests, opts.Limit, opts.Offset), http.StatusOK)
 }
 
 // GetTimelineExtents returns the earliest effective date from all documents.
 // This endpoint is optimized for timeline visualization and returns only the minimum date.
 // The client should compute maxDate as "now".
 func (h *Handler) GetTimelineExtents(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		respondError(w, "Method not allowed", http.StatusMethodNotAllowed)
-		return
-	}
-
-	earliestDate, err := h.storage.GetTimelineExtents()
+	earliestDate, err := h.store(r).GetTimelineExtents()
 	if err != nil {
-		respondError(w, fmt.Sprintf("Failed to get timeline extents: %v", err), http.StatusInternalServerError)
+		respondErrorCode(w, ErrCodeInternal, fmt.Sprintf("Failed to get timeline extents: %v", err), http.StatusInternalServerError)
 		return
 	}
 
@@ -658,8 +831,8 @@ func (h *Handler) GetTimelineExtents(w http.ResponseWriter, r *http.Request) {
 		earliestDate = &defaultDate
 	}
 
-	response := map[string]interface{}{
-		"earliest_date": earliestDate.Format(time.RFC3339),
+	response := TimelineExtentsResponse{
+		EarliestDate: earliestDate.Format(time.RFC3339),
 	}
 
 	respondJSON(w, response, http.StatusOK)
@@ -667,40 +840,39 @@ func (h *Handler) GetTimelineExtents(w http.ResponseWriter, r *http.Request) {
 
 // GetRequest retrieves a request by ID
 func (h *Handler) GetRequest(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		respondError(w, "Method not allowed", http.StatusMethodNotAllowed)
+	id := r.PathValue("id")
+	if id == "" {
+		respondErrorCode(w, ErrCodeValidationFailed, "Request ID is required", http.StatusBadRequest)
 		return
 	}
-
-	// Extract ID from URL path
-	id := r.URL.Path[len("/api/requests/"):]
-	if id == "" {
-		respondError(w, "Request ID is required", http.StatusBadRequest)
+	include, err := parseInclude(r.URL.Query().Get("include"), "images", "content")
+	if err != nil {
+		respondErrorCode(w, ErrCodeValidationFailed, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	record, err := h.storage.GetRequest(id)
+	record, err := h.store(r).GetRequest(id)
 	if err != nil {
 		if err.Error() == "request not found" {
-			respondError(w, "Request not found", http.StatusNotFound)
+			respondErrorCode(w, ErrCodeRequestNotFound, "Request not found", http.StatusNotFound)
 			return
 		}
-		respondError(w, fmt.Sprintf("Failed to get request: %v", err), http.StatusInternalServerError)
+		respondErrorCode(w, ErrCodeInternal, fmt.Sprintf("Failed to get request: %v", err), http.StatusInternalServerError)
 		return
 	}
 
-	response := ControllerResponse{
-		ID:               record.ID,
-		CreatedAt:        record.CreatedAt,
-		EffectiveDate:    record.EffectiveDate,
-		SourceType:       record.SourceType,
-		SourceURL:        record.SourceURL,
-		ScraperUUID:      record.ScraperUUID,
-		TextAnalyzerUUID: record.TextAnalyzerUUID,
-		Tags:             record.Tags,
-		Metadata:         record.Metadata,
-		Slug:             record.Slug,
-		SEOEnabled:       record.SEOEnabled,
+	response := newControllerResponse(record)
+	if !include["content"] {
+		// Full texts are served by GET /api/requests/{id}/content
+		response.Metadata = withoutBulkyContent(response.Metadata)
+	}
+	w.Header().Set("ETag", requestETag(record.RowVersion))
+
+	if include["images"] {
+		detail := RequestDetailResponse{ControllerResponse: response}
+		detail.Images, detail.ImagesError = h.requestImages(r.Context(), record.ScraperUUID)
+		respondJSON(w, detail, http.StatusOK)
+		return
 	}
 
 	respondJSON(w, response, http.StatusOK)
@@ -708,22 +880,9 @@ func (h *Handler) GetRequest(w http.ResponseWriter, r *http.Request) {
 
 // StreamRequestUpdates provides an SSE endpoint for document status updates
 func (h *Handler) StreamRequestUpdates(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		respondError(w, "Method not allowed", http.StatusMethodNotAllowed)
-		return
-	}
-
-	// Extract ID from URL path: /api/requests/{id}/stream
-	path := r.URL.Path
-	if !strings.HasSuffix(path, "/stream") {
-		respondError(w, "Invalid path", http.StatusBadRequest)
-		return
-	}
-
-	// Remove /api/requests/ prefix and /stream suffix to get ID
-	id := path[len("/api/requests/") : len(path)-len("/stream")]
+	id := r.PathValue("id")
 	if id == "" {
-		respondError(w, "Request ID is required", http.StatusBadRequest)
+		respondErrorCode(w, ErrCodeValidationFailed, "Request ID is required", http.StatusBadRequest)
 		return
 	}
 
@@ -741,7 +900,7 @@ func (h *Handler) StreamRequestUpdates(w http.ResponseWriter, r *http.Request) {
 	// Get flusher for streaming
 	flusher, ok := w.(http.Flusher)
 	if !ok {
-		respondError(w, "Streaming not supported", http.StatusInternalServerError)
+		respondErrorCode(w, ErrCodeInternal, "Streaming not supported", http.StatusInternalServerError)
 		return
 	}
 
@@ -788,213 +947,428 @@ func (h *Handler) PublishDocumentUpdateWithDetails(requestID, status, stage, mes
 	})
 }
 
-// UpdateSEOEnabled updates the SEO enabled status for a request
-func (h *Handler) UpdateSEOEnabled(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPut {
-		respondError(w, "Method not allowed", http.StatusMethodNotAllowed)
+// GetRequestDuplicates lists the alternate URLs whose content duplicated a request
+func (h *Handler) GetRequestDuplicates(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if id == "" {
+		respondErrorCode(w, ErrCodeValidationFailed, "Request ID is required", http.StatusBadRequest)
 		return
 	}
 
-	// Extract request ID from path: /api/requests/{id}/seo-enabled
-	path := r.URL.Path
-	parts := strings.Split(path, "/")
-	if len(parts) < 4 {
-		respondError(w, "Invalid request path", http.StatusBadRequest)
+	record, err := h.store(r).GetRequest(id)
+	if err != nil {
+		if err.Error() == "request not found" {
+			respondErrorCode(w, ErrCodeRequestNotFound, "Request not found", http.StatusNotFound)
+			return
+		}
+		respondErrorCode(w, ErrCodeInternal, fmt.Sprintf("Failed to get request: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	alternateURLs := []string{}
+	if urls, ok := record.Metadata["alternate_urls"].([]interface{}); ok {
+		for _, u := range urls {
+			if s, ok := u.(string); ok {
+				alternateURLs = append(alternateURLs, s)
+			}
+		}
+	}
+
+	jobs, err := h.store(r).ListDuplicateJobs(id)
+	if err != nil {
+		respondErrorCode(w, ErrCodeInternal, fmt.Sprintf("Failed to list duplicate jobs: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	if jobs == nil {
+		jobs = []*storage.ScrapeJob{}
+	}
+	respondJSON(w, RequestDuplicatesResponse{
+		ID:            record.ID,
+		ContentHash:   record.ContentHash,
+		AlternateURLs: alternateURLs,
+		DuplicateJobs: jobs,
+	}, http.StatusOK)
+}
+
+// GetRequestVersions handles GET /api/requests/{id}/versions and GET /api/requests/{id}/versions/{n}
+func (h *Handler) GetRequestVersions(w http.ResponseWriter, r *http.Request) {
+	// Routed for both /api/requests/{id}/versions and /api/requests/{id}/versions/{version}
+	id := r.PathValue("id")
+	if id == "" {
+		respondErrorCode(w, ErrCodeValidationFailed, "Request ID is required", http.StatusBadRequest)
+		return
+	}
+
+	// Versions of a soft-deleted request are hidden along with the request itself
+	if _, err := h.store(r).GetRequest(id); err != nil {
+		if err.Error() == "request not found" {
+			respondErrorCode(w, ErrCodeRequestNotFound, "Request not found", http.StatusNotFound)
+			return
+		}
+		respondErrorCode(w, ErrCodeInternal, fmt.Sprintf("Failed to get request: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	if versionStr := r.PathValue("version"); versionStr != "" {
+		n, err := strconv.Atoi(versionStr)
+		if err != nil || n <= 0 {
+			respondErrorCode(w, ErrCodeValidationFailed, "Invalid version number", http.StatusBadRequest)
+			return
+		}
+
+		version, err := h.store(r).GetRequestVersion(id, n)
+		if err != nil {
+			if err.Error() == "version not found" {
+				respondErrorCode(w, ErrCodeVersionNotFound, "Version not found", http.StatusNotFound)
+				return
+			}
+			respondErrorCode(w, ErrCodeInternal, fmt.Sprintf("Failed to get version: %v", err), http.StatusInternalServerError)
+			return
+		}
+
+		respondJSON(w, version, http.StatusOK)
+		return
+	}
+
+	versions, err := h.store(r).ListRequestVersions(id)
+	if err != nil {
+		respondErrorCode(w, ErrCodeInternal, fmt.Sprintf("Failed to list versions: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	if versions == nil {
+		versions = []*storage.RequestVersion{}
+	}
+	respondJSON(w, RequestVersionListResponse{
+		ID:       id,
+		Versions: versions,
+		Count:    len(versions),
+	}, http.StatusOK)
+}
+
+// UpdateSEOEnabled updates the SEO enabled status for a request
+func (h *Handler) UpdateSEOEnabled(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if id == "" {
+		respondErrorCode(w, ErrCodeValidationFailed, "Request ID is required", http.StatusBadRequest)
 		return
 	}
-	id := parts[3]
 
 	// Parse request body
 	var req struct {
 		SEOEnabled bool `json:"seo_enabled"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondError(w, "Invalid request body", http.StatusBadRequest)
+		respondErrorCode(w, ErrCodeInvalidRequestBody, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	version, ok := h.ifMatchVersion(w, r)
+	if !ok {
 		return
 	}
 
 	// Update SEO enabled status
-	if err := h.storage.UpdateSEOEnabled(id, req.SEOEnabled); err != nil {
+	if current, err := h.store(r).UpdateSEOEnabledIfVersion(id, req.SEOEnabled, version); err != nil {
+		if errors.Is(err, storage.ErrRequestVersionMismatch) {
+			respondVersionMismatch(w, current)
+			return
+		}
 		if strings.Contains(err.Error(), "not found") {
-			respondError(w, "Request not found", http.StatusNotFound)
+			respondErrorCode(w, ErrCodeRequestNotFound, "Request not found", http.StatusNotFound)
 			return
 		}
-		respondError(w, fmt.Sprintf("Failed to update SEO enabled status: %v", err), http.StatusInternalServerError)
+		respondErrorCode(w, ErrCodeInternal, fmt.Sprintf("Failed to update SEO enabled status: %v", err), http.StatusInternalServerError)
 		return
 	}
 
+	h.recordAudit(r, storage.AuditActionUpdateSEO, storage.AuditEntityRequest, id, map[string]interface{}{
+		"seo_enabled": req.SEOEnabled,
+	})
+
 	// Get updated request
-	record, err := h.storage.GetRequest(id)
+	record, err := h.store(r).GetRequest(id)
 	if err != nil {
-		respondError(w, fmt.Sprintf("Failed to get updated request: %v", err), http.StatusInternalServerError)
+		respondErrorCode(w, ErrCodeInternal, fmt.Sprintf("Failed to get updated request: %v", err), http.StatusInternalServerError)
 		return
 	}
 
-	response := ControllerResponse{
-		ID:               record.ID,
-		CreatedAt:        record.CreatedAt,
-		EffectiveDate:    record.EffectiveDate,
-		SourceType:       record.SourceType,
-		SourceURL:        record.SourceURL,
-		ScraperUUID:      record.ScraperUUID,
-		TextAnalyzerUUID: record.TextAnalyzerUUID,
-		Tags:             record.Tags,
-		Metadata:         record.Metadata,
-		Slug:             record.Slug,
-		SEOEnabled:       record.SEOEnabled,
-	}
+	response := newControllerResponse(record)
 
+	w.Header().Set("ETag", requestETag(record.RowVersion))
 	respondJSON(w, response, http.StatusOK)
 }
 
-// DeleteRequest deletes a request and all associated data from the controller and upstream services
+// DeleteRequest soft-deletes a request so it can be restored within the grace period.
+// Passing ?hard=true removes it immediately along with the upstream scrape and analysis.
 func (h *Handler) DeleteRequest(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodDelete {
-		respondError(w, "Method not allowed", http.StatusMethodNotAllowed)
+	id := r.PathValue("id")
+	if id == "" {
+		respondErrorCode(w, ErrCodeValidationFailed, "Request ID is required", http.StatusBadRequest)
 		return
 	}
 
-	// Extract ID from URL path
-	id := r.URL.Path[len("/api/requests/"):]
-	if id == "" {
-		respondError(w, "Request ID is required", http.StatusBadRequest)
+	// Get the request to find associated UUIDs before deletion
+	record, err := h.store(r).GetRequest(id)
+	if err != nil {
+		if err.Error() == "request not found" {
+			respondErrorCode(w, ErrCodeRequestNotFound, "Request not found", http.StatusNotFound)
+			return
+		}
+		respondErrorCode(w, ErrCodeInternal, fmt.Sprintf("Failed to get request: %v", err), http.StatusInternalServerError)
 		return
 	}
 
-	// Get the request to find associated UUIDs before deletion
-	record, err := h.storage.GetRequest(id)
+	hard := r.URL.Query().Get("hard") == "true"
+	deletedAt, err := h.deleteRecord(r, record, hard)
 	if err != nil {
 		if err.Error() == "request not found" {
-			respondError(w, "Request not found", http.StatusNotFound)
+			respondErrorCode(w, ErrCodeRequestNotFound, "Request not found", http.StatusNotFound)
 			return
 		}
-		respondError(w, fmt.Sprintf("Failed to get request: %v", err), http.StatusInternalServerError)
+		respondErrorCode(w, ErrCodeInternal, fmt.Sprintf("Failed to delete request: %v", err), http.StatusInternalServerError)
 		return
 	}
 
-	// Delete from upstream services first
+	if hard {
+		respondJSON(w, map[string]string{"message": "Request deleted successfully"}, http.StatusOK)
+		return
+	}
+	respondJSON(w, map[string]string{
+		"message":    "Request deleted successfully",
+		"deleted_at": deletedAt.Format(time.RFC3339),
+	}, http.StatusOK)
+}
+
+// deleteRecord soft-deletes a request, or with hard purges it along with its upstream scrape,
+// images and analysis, then records the deletion. deletedAt is only set for soft deletes.
+func (h *Handler) deleteRecord(r *http.Request, record *storage.Request, hard bool) (deletedAt *time.Time, err error) {
+	auditDetails := map[string]interface{}{"source_type": record.SourceType, "hard": hard}
+	if record.SourceURL != nil {
+		auditDetails["source_url"] = *record.SourceURL
+	}
+
+	if hard {
+		if err := h.purgeRequest(r.Context(), record); err != nil {
+			return nil, err
+		}
+	} else {
+		at, err := h.store(r).SoftDeleteRequest(record.ID)
+		if err != nil {
+			return nil, err
+		}
+		deletedAt = &at
+	}
+
+	h.recordAudit(r, storage.AuditActionDelete, storage.AuditEntityRequest, record.ID, auditDetails)
+	h.publishRequestWebhook(webhooks.EventRequestDeleted, record)
+	return deletedAt, nil
+}
+
+// RestoreRequest undoes a soft delete while the request is still within the grace period
+func (h *Handler) RestoreRequest(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if id == "" {
+		respondErrorCode(w, ErrCodeValidationFailed, "Request ID is required", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.store(r).RestoreRequest(id); err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			respondErrorCode(w, ErrCodeRequestNotFound, "Deleted request not found", http.StatusNotFound)
+			return
+		}
+		respondErrorCode(w, ErrCodeInternal, fmt.Sprintf("Failed to restore request: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	h.recordAudit(r, storage.AuditActionRestore, storage.AuditEntityRequest, id, nil)
+
+	respondJSON(w, map[string]string{"message": "Request restored successfully"}, http.StatusOK)
+}
+
+// ReapDeletedRequests hard-deletes requests that were soft-deleted longer ago than the grace period,
+// including their upstream scrape, images and analysis. Returns the number of requests removed.
+func (h *Handler) ReapDeletedRequests(ctx context.Context, gracePeriod time.Duration) (int, error) {
+	cutoff := time.Now().UTC().Add(-gracePeriod)
+	expired, err := h.storage.ListExpiredDeletedRequests(cutoff, 100)
+	if err != nil {
+		return 0, err
+	}
+
+	reaped := 0
+	for _, record := range expired {
+		if ctx.Err() != nil {
+			break
+		}
+		if err := h.purgeRequest(ctx, record); err != nil {
+			slog.Default().Warn("failed to reap deleted request", "request_id", record.ID, "error", err)
+			continue
+		}
+		reaped++
+
+		entry := &storage.AuditEntry{
+			Actor:      storage.AuditActorSystem,
+			Action:     storage.AuditActionPurge,
+			EntityType: storage.AuditEntityRequest,
+			EntityID:   record.ID,
+			Namespace:  record.Namespace, // The request is gone, so its namespace cannot be looked up
+			Details:    map[string]interface{}{"deleted_at": record.DeletedAt},
+		}
+		if err := h.storage.RecordAudit(entry); err != nil {
+			slog.Default().Warn("failed to record audit entry", "action", entry.Action, "entity_id", record.ID, "error", err)
+		}
+	}
+
+	return reaped, nil
+}
+
+// purgeRequest removes a request from upstream services and then from local storage
+func (h *Handler) purgeRequest(ctx context.Context, record *storage.Request) error {
+	// Delete from upstream services first, starting with the scrape's images
 	if record.ScraperUUID != nil && *record.ScraperUUID != "" {
-		if err := h.scraper.DeleteScrape(r.Context(), *record.ScraperUUID); err != nil {
+		h.deleteDocumentImages(ctx, *record.ScraperUUID)
+		if err := h.scraper.DeleteScrape(ctx, *record.ScraperUUID); err != nil {
 			slog.Default().Warn("failed to delete scrape", "scraper_uuid", *record.ScraperUUID, "error", err)
 		}
 	}
 
 	if record.TextAnalyzerUUID != "" {
-		if err := h.textAnalyzer.DeleteAnalysis(r.Context(), record.TextAnalyzerUUID); err != nil {
+		if err := h.textAnalyzer.DeleteAnalysis(ctx, record.TextAnalyzerUUID); err != nil {
 			slog.Default().Warn("failed to delete analysis", "text_analyzer_uuid", record.TextAnalyzerUUID, "error", err)
 		}
 	}
 
 	// Delete from local storage
-	if err := h.storage.DeleteRequest(id); err != nil {
-		respondError(w, fmt.Sprintf("Failed to delete request: %v", err), http.StatusInternalServerError)
-		return
-	}
-
-	respondJSON(w, map[string]string{"message": "Request deleted successfully"}, http.StatusOK)
+	return h.storage.DeleteRequest(record.ID)
 }
 
 // DeleteImage deletes an image from the scraper service
-func (h *Handler) DeleteImage(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodDelete {
-		respondError(w, "Method not allowed", http.StatusMethodNotAllowed)
-		return
-	}
-
-	// Extract image ID from URL path
-	imageID := r.URL.Path[len("/api/images/"):]
+func (h *Handler) DeleteImage(w http.ResponseWriter, r *http.Request) {
+	imageID := r.PathValue("id")
 	if imageID == "" {
-		respondError(w, "Image ID is required", http.StatusBadRequest)
+		respondErrorCode(w, ErrCodeValidationFailed, "Image ID is required", http.StatusBadRequest)
 		return
 	}
 
 	// Delete from scraper service
 	if err := h.scraper.DeleteImage(r.Context(), imageID); err != nil {
-		respondError(w, fmt.Sprintf("Failed to delete image: %v", err), http.StatusInternalServerError)
+		h.collectors().imageOperations.WithLabelValues(imageActionDelete, "failure").Inc()
+		respondErrorCode(w, ErrCodeInternal, fmt.Sprintf("Failed to delete image: %v", err), http.StatusInternalServerError)
 		return
 	}
+	h.collectors().imageOperations.WithLabelValues(imageActionDelete, "success").Inc()
+	h.evictImage(imageID)
+
+	h.recordAudit(r, storage.AuditActionDelete, storage.AuditEntityImage, imageID, nil)
 
 	respondJSON(w, map[string]string{"message": "Image deleted successfully"}, http.StatusOK)
 }
 
-// TombstoneRequest marks a request as scheduled for deletion by adding tombstone_datetime to metadata
+// TombstoneRequest marks a request as scheduled for deletion by adding tombstone_datetime to metadata.
+// With cascade_images the request's images are tombstoned in the scraper as well.
 func (h *Handler) TombstoneRequest(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPut && r.Method != http.MethodPatch {
-		respondError(w, "Method not allowed", http.StatusMethodNotAllowed)
+	id := r.PathValue("id")
+	if id == "" {
+		respondErrorCode(w, ErrCodeValidationFailed, "Request ID is required", http.StatusBadRequest)
 		return
 	}
 
-	// Extract ID from URL path
-	id := r.URL.Path[len("/api/requests/"):]
-	// Remove the "/tombstone" suffix
-	id = id[:len(id)-len("/tombstone")]
-	if id == "" {
-		respondError(w, "Request ID is required", http.StatusBadRequest)
+	// The body is optional; a bare PUT tombstones only the request
+	var body TombstoneRequestBody
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil && !errors.Is(err, io.EOF) {
+		respondErrorCode(w, ErrCodeInvalidRequestBody, "Invalid request body", http.StatusBadRequest)
 		return
 	}
 
 	// Get the existing request
-	record, err := h.storage.GetRequest(id)
+	record, err := h.store(r).GetRequest(id)
 	if err != nil {
 		if err.Error() == "request not found" {
-			respondError(w, "Request not found", http.StatusNotFound)
+			respondErrorCode(w, ErrCodeRequestNotFound, "Request not found", http.StatusNotFound)
 			return
 		}
-		respondError(w, fmt.Sprintf("Failed to get request: %v", err), http.StatusInternalServerError)
+		respondErrorCode(w, ErrCodeInternal, fmt.Sprintf("Failed to get request: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	tombstoneTime, err := h.tombstoneRecord(r, record, "manual")
+	if err != nil {
+		respondErrorCode(w, ErrCodeInternal, fmt.Sprintf("Failed to update request: %v", err), http.StatusInternalServerError)
 		return
 	}
 
-	// Add tombstone_datetime to metadata (configurable days from now)
+	resp := TombstoneRequestResponse{
+		Message:           "Request tombstoned successfully",
+		TombstoneDatetime: tombstoneTime.Format(time.RFC3339),
+	}
+	if body.CascadeImages {
+		resp.Images, resp.ImagesError = h.cascadeRequestImages(r.Context(), record, imageActionTombstone)
+		if resp.Images != nil {
+			h.auditImageResults(r, resp.Images, map[string]interface{}{"request_id": id, "scraper_uuid": resp.Images.ScraperUUID})
+		}
+	}
+	respondJSON(w, resp, http.StatusOK)
+}
+
+// tombstoneRecord adds tombstone_datetime to a request's metadata, the manual tombstone period
+// from now, and records the change. reason labels the metrics, log line and audit entry.
+func (h *Handler) tombstoneRecord(r *http.Request, record *storage.Request, reason string) (time.Time, error) {
 	if record.Metadata == nil {
 		record.Metadata = make(map[string]interface{})
 	}
-	tombstoneTime := time.Now().UTC().Add(time.Duration(h.tombstonePeriodManual) * 24 * time.Hour)
+	periodDays := h.settings.Get().TombstonePeriodManual
+	tombstoneTime := time.Now().UTC().Add(time.Duration(periodDays) * 24 * time.Hour)
 	record.Metadata["tombstone_datetime"] = tombstoneTime.Format(time.RFC3339)
 
-	// Update the request in storage
-	if err := h.storage.UpdateRequestMetadata(id, record.Metadata); err != nil {
-		respondError(w, fmt.Sprintf("Failed to update request: %v", err), http.StatusInternalServerError)
-		return
+	if err := h.store(r).UpdateRequestMetadataAs(record.ID, record.Metadata, auditActor(r)); err != nil {
+		return time.Time{}, err
 	}
 
 	// Record tombstone metrics
 	if h.businessMetrics != nil {
-		h.businessMetrics.TombstonesCreatedTotal.WithLabelValues("manual", "none").Inc()
-		h.businessMetrics.TombstoneDaysHistogram.WithLabelValues("manual").Observe(float64(h.tombstonePeriodManual))
+		h.businessMetrics.TombstonesCreatedTotal.WithLabelValues(reason, "none").Inc()
+		h.businessMetrics.TombstoneDaysHistogram.WithLabelValues(reason).Observe(float64(periodDays))
 	}
 	slog.Info("tombstone created",
-		"reason", "manual",
-		"request_id", id,
-		"period_days", h.tombstonePeriodManual,
+		"reason", reason,
+		"request_id", record.ID,
+		"period_days", periodDays,
 	)
-
-	respondJSON(w, map[string]string{"message": "Request tombstoned successfully"}, http.StatusOK)
+	h.recordAudit(r, storage.AuditActionTombstone, storage.AuditEntityRequest, record.ID, map[string]interface{}{
+		"reason":             reason,
+		"period_days":        periodDays,
+		"tombstone_datetime": record.Metadata["tombstone_datetime"],
+	})
+	h.publishRequestWebhook(webhooks.EventRequestTombstoned, record)
+	return tombstoneTime, nil
 }
 
-// UntombstoneRequest removes the tombstone from a request
+// UntombstoneRequest removes the tombstone from a request. With cascade_images the request's
+// tombstoned images are restored in the scraper as well.
 func (h *Handler) UntombstoneRequest(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodDelete {
-		respondError(w, "Method not allowed", http.StatusMethodNotAllowed)
+	id := r.PathValue("id")
+	if id == "" {
+		respondErrorCode(w, ErrCodeValidationFailed, "Request ID is required", http.StatusBadRequest)
 		return
 	}
 
-	// Extract ID from URL path
-	id := r.URL.Path[len("/api/requests/"):]
-	// Remove the "/tombstone" suffix
-	id = id[:len(id)-len("/tombstone")]
-	if id == "" {
-		respondError(w, "Request ID is required", http.StatusBadRequest)
+	// The body is optional; a bare DELETE restores only the request
+	var body TombstoneRequestBody
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil && !errors.Is(err, io.EOF) {
+		respondErrorCode(w, ErrCodeInvalidRequestBody, "Invalid request body", http.StatusBadRequest)
 		return
 	}
 
 	// Get the existing request
-	record, err := h.storage.GetRequest(id)
+	record, err := h.store(r).GetRequest(id)
 	if err != nil {
 		if err.Error() == "request not found" {
-			respondError(w, "Request not found", http.StatusNotFound)
+			respondErrorCode(w, ErrCodeRequestNotFound, "Request not found", http.StatusNotFound)
 			return
 		}
-		respondError(w, fmt.Sprintf("Failed to get request: %v", err), http.StatusInternalServerError)
+		respondErrorCode(w, ErrCodeInternal, fmt.Sprintf("Failed to get request: %v", err), http.StatusInternalServerError)
 		return
 	}
 
@@ -1004,81 +1378,69 @@ func (h *Handler) UntombstoneRequest(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Update the request in storage
-	if err := h.storage.UpdateRequestMetadata(id, record.Metadata); err != nil {
-		respondError(w, fmt.Sprintf("Failed to update request: %v", err), http.StatusInternalServerError)
+	if err := h.store(r).UpdateRequestMetadataAs(id, record.Metadata, auditActor(r)); err != nil {
+		respondErrorCode(w, ErrCodeInternal, fmt.Sprintf("Failed to update request: %v", err), http.StatusInternalServerError)
 		return
 	}
 
-	respondJSON(w, map[string]string{"message": "Request tombstone removed successfully"}, http.StatusOK)
+	h.recordAudit(r, storage.AuditActionUntombstone, storage.AuditEntityRequest, id, nil)
+
+	resp := TombstoneRequestResponse{Message: "Request tombstone removed successfully"}
+	if body.CascadeImages {
+		resp.Images, resp.ImagesError = h.cascadeRequestImages(r.Context(), record, imageActionUntombstone)
+		if resp.Images != nil {
+			h.auditImageResults(r, resp.Images, map[string]interface{}{"request_id": id, "scraper_uuid": resp.Images.ScraperUUID})
+		}
+	}
+	respondJSON(w, resp, http.StatusOK)
 }
 
 // TombstoneImage marks an image as scheduled for deletion
 func (h *Handler) TombstoneImage(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPut && r.Method != http.MethodPatch {
-		respondError(w, "Method not allowed", http.StatusMethodNotAllowed)
-		return
-	}
-
-	// Extract image ID from URL path
-	imageID := r.URL.Path[len("/api/images/"):]
-	// Remove the "/tombstone" suffix
-	imageID = imageID[:len(imageID)-len("/tombstone")]
+	imageID := r.PathValue("id")
 	if imageID == "" {
-		respondError(w, "Image ID is required", http.StatusBadRequest)
+		respondErrorCode(w, ErrCodeValidationFailed, "Image ID is required", http.StatusBadRequest)
 		return
 	}
 
 	// Tombstone via scraper service
 	if err := h.scraper.TombstoneImage(r.Context(), imageID); err != nil {
-		respondError(w, fmt.Sprintf("Failed to tombstone image: %v", err), http.StatusInternalServerError)
+		h.collectors().imageOperations.WithLabelValues(imageActionTombstone, "failure").Inc()
+		respondErrorCode(w, ErrCodeInternal, fmt.Sprintf("Failed to tombstone image: %v", err), http.StatusInternalServerError)
 		return
 	}
+	h.collectors().imageOperations.WithLabelValues(imageActionTombstone, "success").Inc()
+	h.evictImage(imageID)
+
+	h.recordAudit(r, storage.AuditActionTombstone, storage.AuditEntityImage, imageID, nil)
 
 	respondJSON(w, map[string]string{"message": "Image tombstoned successfully"}, http.StatusOK)
 }
 
 // UntombstoneImage removes the tombstone from an image
 func (h *Handler) UntombstoneImage(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodDelete {
-		respondError(w, "Method not allowed", http.StatusMethodNotAllowed)
-		return
-	}
-
-	// Extract image ID from URL path
-	imageID := r.URL.Path[len("/api/images/"):]
-	// Remove the "/tombstone" suffix
-	imageID = imageID[:len(imageID)-len("/tombstone")]
+	imageID := r.PathValue("id")
 	if imageID == "" {
-		respondError(w, "Image ID is required", http.StatusBadRequest)
+		respondErrorCode(w, ErrCodeValidationFailed, "Image ID is required", http.StatusBadRequest)
 		return
 	}
 
 	// Untombstone via scraper service
 	if err := h.scraper.UntombstoneImage(r.Context(), imageID); err != nil {
-		respondError(w, fmt.Sprintf("Failed to untombstone image: %v", err), http.StatusInternalServerError)
+		respondErrorCode(w, ErrCodeInternal, fmt.Sprintf("Failed to untombstone image: %v", err), http.StatusInternalServerError)
 		return
 	}
 
+	h.recordAudit(r, storage.AuditActionUntombstone, storage.AuditEntityImage, imageID, nil)
+
 	respondJSON(w, map[string]string{"message": "Image tombstone removed successfully"}, http.StatusOK)
 }
 
 // UpdateRequestTags updates the tags for a specific request
 func (h *Handler) UpdateRequestTags(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPut {
-		respondError(w, "Method not allowed", http.StatusMethodNotAllowed)
-		return
-	}
-
-	// Extract ID from URL path: /api/requests/{id}/tags
-	parts := strings.Split(r.URL.Path, "/")
-	if len(parts) < 4 {
-		respondError(w, "Invalid URL path", http.StatusBadRequest)
-		return
-	}
-	id := parts[len(parts)-2] // ID is second-to-last part
-
+	id := r.PathValue("id")
 	if id == "" {
-		respondError(w, "Request ID is required", http.StatusBadRequest)
+		respondErrorCode(w, ErrCodeValidationFailed, "Request ID is required", http.StatusBadRequest)
 		return
 	}
 
@@ -1087,40 +1449,43 @@ func (h *Handler) UpdateRequestTags(w http.ResponseWriter, r *http.Request) {
 		Tags []string `json:"tags"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondError(w, "Invalid request body", http.StatusBadRequest)
+		respondErrorCode(w, ErrCodeInvalidRequestBody, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	version, ok := h.ifMatchVersion(w, r)
+	if !ok {
 		return
 	}
 
 	// Update tags in storage
-	if err := h.storage.UpdateRequestTags(id, req.Tags); err != nil {
+	version, err := h.store(r).UpdateRequestTagsIfVersion(id, req.Tags, version)
+	if err != nil {
+		if errors.Is(err, storage.ErrRequestVersionMismatch) {
+			respondVersionMismatch(w, version)
+			return
+		}
 		if err.Error() == "request not found" {
-			respondError(w, "Request not found", http.StatusNotFound)
+			respondErrorCode(w, ErrCodeRequestNotFound, "Request not found", http.StatusNotFound)
 			return
 		}
-		respondError(w, fmt.Sprintf("Failed to update tags: %v", err), http.StatusInternalServerError)
+		respondErrorCode(w, ErrCodeInternal, fmt.Sprintf("Failed to update tags: %v", err), http.StatusInternalServerError)
 		return
 	}
 
+	h.recordAudit(r, storage.AuditActionUpdateTags, storage.AuditEntityRequest, id, map[string]interface{}{
+		"tags": req.Tags,
+	})
+
+	w.Header().Set("ETag", requestETag(version))
 	respondJSON(w, map[string]string{"message": "Tags updated successfully"}, http.StatusOK)
 }
 
 // UpdateImageTags updates the tags for a specific image
 func (h *Handler) UpdateImageTags(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPut {
-		respondError(w, "Method not allowed", http.StatusMethodNotAllowed)
-		return
-	}
-
-	// Extract ID from URL path: /api/images/{id}/tags
-	parts := strings.Split(r.URL.Path, "/")
-	if len(parts) < 4 {
-		respondError(w, "Invalid URL path", http.StatusBadRequest)
-		return
-	}
-	id := parts[len(parts)-2] // ID is second-to-last part
-
+	id := r.PathValue("id")
 	if id == "" {
-		respondError(w, "Image ID is required", http.StatusBadRequest)
+		respondErrorCode(w, ErrCodeValidationFailed, "Image ID is required", http.StatusBadRequest)
 		return
 	}
 
@@ -1129,167 +1494,127 @@ func (h *Handler) UpdateImageTags(w http.ResponseWriter, r *http.Request) {
 		Tags []string `json:"tags"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondError(w, "Invalid request body", http.StatusBadRequest)
+		respondErrorCode(w, ErrCodeInvalidRequestBody, "Invalid request body", http.StatusBadRequest)
 		return
 	}
 
 	// Update tags via scraper service
 	if err := h.scraper.UpdateImageTags(r.Context(), id, req.Tags); err != nil {
 		if strings.Contains(err.Error(), "image not found") {
-			respondError(w, "Image not found", http.StatusNotFound)
+			respondErrorCode(w, ErrCodeImageNotFound, "Image not found", http.StatusNotFound)
 			return
 		}
-		respondError(w, fmt.Sprintf("Failed to update image tags: %v", err), http.StatusInternalServerError)
+		respondErrorCode(w, ErrCodeInternal, fmt.Sprintf("Failed to update image tags: %v", err), http.StatusInternalServerError)
 		return
 	}
 
+	h.recordAudit(r, storage.AuditActionUpdateTags, storage.AuditEntityImage, id, map[string]interface{}{
+		"tags": req.Tags,
+	})
+
 	respondJSON(w, map[string]string{"message": "Image tags updated successfully"}, http.StatusOK)
 }
 
 // ListRequests lists all requests with pagination
 func (h *Handler) ListRequests(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		respondError(w, "Method not allowed", http.StatusMethodNotAllowed)
+	pg, err := h.pageFromQuery(r, defaultPageLimit)
+	if err != nil {
+		respondErrorCode(w, ErrCodeValidationFailed, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	// Parse query parameters
-	limit := 50
-	offset := 0
-
-	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
-		if parsedLimit, err := strconv.Atoi(limitStr); err == nil && parsedLimit > 0 {
-			limit = parsedLimit
-		}
-	}
-
-	if offsetStr := r.URL.Query().Get("offset"); offsetStr != "" {
-		if parsedOffset, err := strconv.Atoi(offsetStr); err == nil && parsedOffset >= 0 {
-			offset = parsedOffset
-		}
-	}
-
-	records, err := h.storage.ListRequests(limit, offset)
+	records, err := h.store(r).ListRequests(pg.Limit, pg.Offset)
 	if err != nil {
-		respondError(w, fmt.Sprintf("Failed to list requests: %v", err), http.StatusInternalServerError)
+		respondErrorCode(w, ErrCodeInternal, fmt.Sprintf("Failed to list requests: %v", err), http.StatusInternalServerError)
 		return
 	}
 
-	var responses []ControllerResponse
-	for _, record := range records {
-		responses = append(responses, ControllerResponse{
-			ID:               record.ID,
-			CreatedAt:        record.CreatedAt,
-			EffectiveDate:    record.EffectiveDate,
-			SourceType:       record.SourceType,
-			SourceURL:        record.SourceURL,
-			ScraperUUID:      record.ScraperUUID,
-			TextAnalyzerUUID: record.TextAnalyzerUUID,
-			Tags:             record.Tags,
-			Metadata:         record.Metadata,
-			Slug:             record.Slug,
-		})
-	}
-
-	response := map[string]interface{}{
-		"requests": responses,
-		"count":    len(responses),
-		"limit":    limit,
-		"offset":   offset,
-	}
-
-	respondJSON(w, response, http.StatusOK)
+	respondJSON(w, newRequestListResponse(records, pg.Limit, pg.Offset), http.StatusOK)
 }
 
 // SearchImageTagsRequest represents a request to search images by tags
 type SearchImageTagsRequest struct {
-	Tags []string `json:"tags"`
+	Tags              []string `json:"tags"`
+	Limit             int      `json:"limit,omitempty"`              // Default 50, max MAX_PAGE_LIMIT
+	Offset            int      `json:"offset,omitempty"`
+	IncludeTombstoned bool     `json:"include_tombstoned,omitempty"` // Tombstoned images are excluded by default
 }
 
 // SearchImageTags handles fuzzy search for images by tags
 func (h *Handler) SearchImageTags(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPost {
-		respondError(w, "Method not allowed", http.StatusMethodNotAllowed)
-		return
-	}
-
 	var req SearchImageTagsRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondError(w, "Invalid request body", http.StatusBadRequest)
+		respondErrorCode(w, ErrCodeInvalidRequestBody, "Invalid request body", http.StatusBadRequest)
 		return
 	}
 
 	if len(req.Tags) == 0 {
-		respondError(w, "At least one tag is required", http.StatusBadRequest)
+		respondErrorCode(w, ErrCodeValidationFailed, "At least one tag is required", http.StatusBadRequest)
 		return
 	}
 
-	// Call scraper service to search images by tags (fuzzy matching)
-	searchResp, err := h.scraper.SearchImagesByTags(r.Context(), req.Tags)
+	pg, err := boundPage(page{Limit: req.Limit, Offset: req.Offset}, defaultPageLimit, h.pageLimitMax())
 	if err != nil {
-		respondError(w, fmt.Sprintf("Failed to search images: %v", err), http.StatusInternalServerError)
+		respondErrorCode(w, ErrCodeValidationFailed, err.Error(), http.StatusBadRequest)
 		return
 	}
+	opts := clients.ImageListOptions{Limit: pg.Limit, Offset: pg.Offset, IncludeTombstoned: req.IncludeTombstoned}
 
-	response := map[string]interface{}{
-		"images": searchResp.Images,
-		"count":  searchResp.Count,
+	// Call scraper service to search images by tags (fuzzy matching)
+	searchResp, err := h.scraper.SearchImagesByTags(r.Context(), req.Tags, opts)
+	if err != nil {
+		respondErrorCode(w, ErrCodeInternal, fmt.Sprintf("Failed to search images: %v", err), http.StatusInternalServerError)
+		return
 	}
 
-	respondJSON(w, response, http.StatusOK)
+	respondJSON(w, searchResp, http.StatusOK)
 }
 
 // GetDocumentImages retrieves images associated with a document's scraper UUID
+// GET /api/documents/{uuid}/images?limit=&offset=&include_tombstoned=
 func (h *Handler) GetDocumentImages(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		respondError(w, "Method not allowed", http.StatusMethodNotAllowed)
+	scrapeID := r.PathValue("uuid")
+	if scrapeID == "" {
+		respondErrorCode(w, ErrCodeValidationFailed, "Scraper UUID is required", http.StatusBadRequest)
 		return
 	}
 
-	// Extract scraper UUID from URL path
-	// Path format: /api/documents/{uuid}/images
-	path := strings.TrimPrefix(r.URL.Path, "/api/documents/")
-	path = strings.TrimSuffix(path, "/images")
-	scrapeID := path
-
-	if scrapeID == "" || strings.Contains(scrapeID, "/") {
-		respondError(w, "Scraper UUID is required", http.StatusBadRequest)
+	pg, err := h.pageFromQuery(r, defaultPageLimit)
+	if err != nil {
+		respondErrorCode(w, ErrCodeValidationFailed, err.Error(), http.StatusBadRequest)
 		return
 	}
+	var includeTombstoned bool
+	if v := r.URL.Query().Get("include_tombstoned"); v != "" {
+		if includeTombstoned, err = strconv.ParseBool(v); err != nil {
+			respondErrorCode(w, ErrCodeValidationFailed, "Invalid include_tombstoned: must be true or false", http.StatusBadRequest)
+			return
+		}
+	}
+	opts := clients.ImageListOptions{Limit: pg.Limit, Offset: pg.Offset, IncludeTombstoned: includeTombstoned}
 
 	// Call scraper service to get images by scrape ID
-	searchResp, err := h.scraper.GetImagesByScrapeID(r.Context(), scrapeID)
+	searchResp, err := h.scraper.GetImagesByScrapeID(r.Context(), scrapeID, opts)
 	if err != nil {
-		respondError(w, fmt.Sprintf("Failed to retrieve images: %v", err), http.StatusInternalServerError)
+		respondErrorCode(w, ErrCodeInternal, fmt.Sprintf("Failed to retrieve images: %v", err), http.StatusInternalServerError)
 		return
 	}
 
-	response := map[string]interface{}{
-		"images": searchResp.Images,
-		"count":  searchResp.Count,
-	}
-
-	respondJSON(w, response, http.StatusOK)
+	respondJSON(w, searchResp, http.StatusOK)
 }
 
 // GetImage retrieves a single image by ID
 func (h *Handler) GetImage(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		respondError(w, "Method not allowed", http.StatusMethodNotAllowed)
-		return
-	}
-
-	// Extract image ID from URL path
-	imageID := r.URL.Path[len("/api/images/"):]
+	imageID := r.PathValue("id")
 	if imageID == "" {
-		respondError(w, "Image ID is required", http.StatusBadRequest)
+		respondErrorCode(w, ErrCodeValidationFailed, "Image ID is required", http.StatusBadRequest)
 		return
 	}
 
 	// Call scraper service to get image by ID
 	image, err := h.scraper.GetImageByID(r.Context(), imageID)
 	if err != nil {
-		respondError(w, fmt.Sprintf("Failed to retrieve image: %v", err), http.StatusInternalServerError)
+		respondErrorCode(w, ErrCodeInternal, fmt.Sprintf("Failed to retrieve image: %v", err), http.StatusInternalServerError)
 		return
 	}
 
@@ -1303,40 +1628,36 @@ type ScoreLinkRequest struct {
 
 // ScoreLink handles link quality scoring
 func (h *Handler) ScoreLink(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPost {
-		respondError(w, "Method not allowed", http.StatusMethodNotAllowed)
-		return
-	}
-
 	var req ScoreLinkRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondError(w, "Invalid request body", http.StatusBadRequest)
+		respondErrorCode(w, ErrCodeInvalidRequestBody, "Invalid request body", http.StatusBadRequest)
 		return
 	}
 
 	if req.URL == "" {
-		respondError(w, "URL is required", http.StatusBadRequest)
+		respondErrorCode(w, ErrCodeValidationFailed, "URL is required", http.StatusBadRequest)
 		return
 	}
 
 	// Call scraper service to score the link
 	scoreResp, err := h.scraper.ScoreLink(r.Context(), req.URL)
 	if err != nil {
-		respondError(w, fmt.Sprintf("Failed to score link: %v", err), http.StatusInternalServerError)
+		respondErrorCode(w, ErrCodeUpstreamError, fmt.Sprintf("Failed to score link: %v", err), http.StatusInternalServerError)
 		return
 	}
 
-	response := map[string]interface{}{
-		"url": scoreResp.URL,
-		"score": map[string]interface{}{
-			"score":                scoreResp.Score.Score,
-			"reason":               scoreResp.Score.Reason,
-			"categories":           scoreResp.Score.Categories,
-			"is_recommended":       scoreResp.Score.IsRecommended,
-			"malicious_indicators": scoreResp.Score.MaliciousIndicators,
+	threshold := h.linkScoreThreshold(req.URL, h.settings.Get())
+	response := ScoreLinkResponse{
+		URL: scoreResp.URL,
+		Score: LinkScoreResponse{
+			Score:               scoreResp.Score.Score,
+			Reason:              scoreResp.Score.Reason,
+			Categories:          nonNilStrings(scoreResp.Score.Categories),
+			IsRecommended:       scoreResp.Score.IsRecommended,
+			MaliciousIndicators: nonNilStrings(scoreResp.Score.MaliciousIndicators),
 		},
-		"meets_threshold": scoreResp.Score.Score >= h.linkScoreThreshold,
-		"threshold":       h.linkScoreThreshold,
+		MeetsThreshold: scoreResp.Score.Score >= threshold,
+		Threshold:      threshold,
 	}
 
 	respondJSON(w, response, http.StatusOK)
@@ -1349,33 +1670,28 @@ type ExtractLinksRequest struct {
 
 // ExtractLinks handles extracting links from a URL
 func (h *Handler) ExtractLinks(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPost {
-		respondError(w, "Method not allowed", http.StatusMethodNotAllowed)
-		return
-	}
-
 	var req ExtractLinksRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondError(w, "Invalid request body", http.StatusBadRequest)
+		respondErrorCode(w, ErrCodeInvalidRequestBody, "Invalid request body", http.StatusBadRequest)
 		return
 	}
 
 	if req.URL == "" {
-		respondError(w, "URL is required", http.StatusBadRequest)
+		respondErrorCode(w, ErrCodeValidationFailed, "URL is required", http.StatusBadRequest)
 		return
 	}
 
 	// Call scraper service to extract links
 	extractResp, err := h.scraper.ExtractLinks(r.Context(), req.URL)
 	if err != nil {
-		respondError(w, fmt.Sprintf("Failed to extract links: %v", err), http.StatusInternalServerError)
+		respondErrorCode(w, ErrCodeUpstreamError, fmt.Sprintf("Failed to extract links: %v", err), http.StatusInternalServerError)
 		return
 	}
 
-	response := map[string]interface{}{
-		"url":   extractResp.URL,
-		"links": extractResp.Links,
-		"count": extractResp.Count,
+	response := ExtractLinksResponse{
+		URL:   extractResp.URL,
+		Links: nonNilStrings(extractResp.Links),
+		Count: extractResp.Count,
 	}
 
 	respondJSON(w, response, http.StatusOK)
@@ -1383,19 +1699,34 @@ func (h *Handler) ExtractLinks(w http.ResponseWriter, r *http.Request) {
 
 // CreateScrapeRequest creates a new async scrape request
 func (h *Handler) CreateScrapeRequest(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPost {
-		respondError(w, "Method not allowed", http.StatusMethodNotAllowed)
-		return
-	}
-
 	var req ScrapeURLRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondError(w, "Invalid request body", http.StatusBadRequest)
+		respondErrorCode(w, ErrCodeInvalidRequestBody, "Invalid request body", http.StatusBadRequest)
 		return
 	}
 
 	if req.URL == "" {
-		respondError(w, "URL is required", http.StatusBadRequest)
+		respondErrorCode(w, ErrCodeValidationFailed, "URL is required", http.StatusBadRequest)
+		return
+	}
+	if req.MaxPages < 0 {
+		respondErrorCode(w, ErrCodeValidationFailed, "max_pages must not be negative", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.validateScrapeURL(r.Context(), req.URL); err != nil {
+		respondErrorDetails(w, ErrCodeURLRejected, fmt.Sprintf("URL rejected: %v", err), http.StatusBadRequest, urlRejectionDetails(err))
+		return
+	}
+	if err := h.domainPolicy.Check(req.URL); err != nil {
+		respondErrorDetails(w, ErrCodeDomainNotAllowed, fmt.Sprintf("URL rejected: %v", err), http.StatusForbidden, urlRejectionDetails(err))
+		return
+	}
+
+	// Normalize for lookups; the original URL is what gets scraped and stored
+	normalizedURL, err := urlnorm.Normalize(req.URL)
+	if err != nil {
+		respondErrorCode(w, ErrCodeValidationFailed, fmt.Sprintf("Invalid URL: %v", err), http.StatusBadRequest)
 		return
 	}
 
@@ -1412,16 +1743,20 @@ func (h *Handler) CreateScrapeRequest(w http.ResponseWriter, r *http.Request) {
 			// Continue with scraping even if cache check fails
 		} else if cachedScraperUUID != "" {
 			// Cache hit - URL was scraped recently (within 30 days)
-			slog.Info("cache hit for URL", "url", req.URL, "scraper_uuid", cachedScraperUUID)
+			h.cacheHitLog.Log(r.Context(), "url", req.URL, "scraper_uuid", cachedScraperUUID)
 			if h.businessMetrics != nil {
 				h.businessMetrics.ScrapeRequestsTotal.WithLabelValues("cached").Inc()
 			}
 
-			// Fetch the existing scraped data
-			existingData, err := h.storage.GetRequest(cachedScraperUUID)
+			// Fetch the existing scraped data by its normalized URL
+			existingData, err := h.store(r).FindRequestByNormalizedURL(normalizedURL)
+			if err == nil && existingData == nil {
+				err = fmt.Errorf("request not found")
+			}
 			if err != nil {
-				slog.Warn("cached scraper UUID not found in storage, proceeding with fresh scrape",
+				slog.Warn("cached URL not found in storage, proceeding with fresh scrape",
 					"url", req.URL,
+					"normalized_url", normalizedURL,
 					"scraper_uuid", cachedScraperUUID,
 					"error", err)
 				// Cache is stale, invalidate it and proceed with scraping
@@ -1442,12 +1777,18 @@ func (h *Handler) CreateScrapeRequest(w http.ResponseWriter, r *http.Request) {
 				if existingData.ScraperUUID != nil {
 					response["scraper_uuid"] = *existingData.ScraperUUID
 				}
+				queue.RecordScrapeOutcome(queue.ScrapeEntrySubmit, queue.ScrapeOutcomeCached, clients.ScoreBucketNone)
 				respondJSON(w, response, http.StatusOK)
 				return
 			}
 		}
 	}
 
+	// Cached results above are still served; only new jobs are refused while the queue is full
+	if h.rejectIfSaturated(w, "scrape_requests", !req.ExtractLinks) {
+		return
+	}
+
 	// Create scrape job in database
 	jobID := uuid.New().String()
 
@@ -1455,61 +1796,97 @@ func (h *Handler) CreateScrapeRequest(w http.ResponseWriter, r *http.Request) {
 	tracing.AddSpanAttributes(r, attribute.String("scrape_request_id", jobID))
 
 	job := &storage.ScrapeJob{
-		ID:           jobID,
-		URL:          req.URL,
-		ExtractLinks: req.ExtractLinks,
-		Status:       "queued",
-		CreatedAt:    time.Now(),
-		UpdatedAt:    time.Now(),
-	}
-
-	if err := h.storage.SaveScrapeJob(job); err != nil {
+		ID:              jobID,
+		URL:             req.URL,
+		ExtractLinks:    req.ExtractLinks,
+		AllowDuplicates: req.AllowDuplicates,
+		OverrideRobots:  req.OverrideRobots,
+		SkipAnalysis:    req.SkipAnalysis,
+		MaxPages:        req.MaxPages,
+		Status:          "queued",
+		CreatedAt:       time.Now(),
+		UpdatedAt:       time.Now(),
+		CreatedBy:       requestCreator(r),
+		CrawlParams:     queue.NewCrawlParams(h.settings.Get()),
+	}
+
+	if err := h.store(r).SaveScrapeJob(job); err != nil {
 		if h.businessMetrics != nil {
 			h.businessMetrics.ScrapeRequestsTotal.WithLabelValues("error").Inc()
 		}
-		respondError(w, fmt.Sprintf("Failed to create scrape job: %v", err), http.StatusInternalServerError)
+		queue.RecordScrapeOutcome(queue.ScrapeEntrySubmit, queue.ScrapeOutcomeFailed, clients.ScoreBucketNone)
+		respondErrorCode(w, ErrCodeInternal, fmt.Sprintf("Failed to create scrape job: %v", err), http.StatusInternalServerError)
 		return
 	}
 
-	// Record scrape job created (parent job)
-	if h.businessMetrics != nil {
-		h.businessMetrics.ScrapeJobsTotal.WithLabelValues("parent").Inc()
-	}
-
 	// Enqueue task to Asynq (skip if queueClient is nil for testing)
 	var taskID string
 	if h.queueClient != nil {
 		var err error
-		taskID, err = h.queueClient.EnqueueScrape(r.Context(), jobID, req.URL, req.ExtractLinks)
+		// The crawl runs with the settings in force now, whatever changes while it is in flight
+		ctx := queue.WithCrawlParams(queue.WithCreatedBy(r.Context(), job.CreatedBy), job.CrawlParams)
+		ctx = queue.WithSkipAnalysis(ctx, job.SkipAnalysis)
+		taskID, err = h.queueClient.EnqueueScrape(ctx, jobID, req.URL, req.ExtractLinks)
+		var duplicate *queue.DuplicateTaskError
+		if errors.As(err, &duplicate) {
+			// The client never saw this job; answer with the one already queued instead
+			if err := h.store(r).DeleteScrapeJob(jobID); err != nil {
+				slog.Default().Warn("failed to delete duplicate scrape job", "job_id", jobID, "error", err)
+			}
+			queue.RecordScrapeOutcome(queue.ScrapeEntrySubmit, queue.ScrapeOutcomeDuplicate, clients.ScoreBucketNone)
+			h.respondDuplicateScrape(w, r, duplicate.JobID)
+			return
+		}
 		if err != nil {
-			respondError(w, fmt.Sprintf("Failed to enqueue scrape task: %v", err), http.StatusInternalServerError)
+			queue.RecordScrapeOutcome(queue.ScrapeEntrySubmit, queue.ScrapeOutcomeFailed, clients.ScoreBucketNone)
+			respondErrorCode(w, ErrCodeInternal, fmt.Sprintf("Failed to enqueue scrape task: %v", err), http.StatusInternalServerError)
 			return
 		}
 
 		// Update job with Asynq task ID
-		if err := h.storage.UpdateScrapeJobTaskID(jobID, taskID); err != nil {
+		if err := h.store(r).UpdateScrapeJobTaskID(jobID, taskID); err != nil {
 			slog.Default().Warn("failed to update task id for job", "job_id", jobID, "error", err)
 		}
 	}
 
-	respondJSON(w, job, http.StatusOK)
+	// Record scrape job created (parent job)
+	if h.businessMetrics != nil {
+		h.businessMetrics.ScrapeJobsTotal.WithLabelValues("parent").Inc()
+	}
+	queue.RecordScrapeJobCreated("parent", job.CreatedBy)
+
+	respondCreatedV1(w, r, "/scrape-requests/"+job.ID, job)
 }
 
-// CreateTextAnalysisRequest creates a new async text analysis request
-func (h *Handler) CreateTextAnalysisRequest(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPost {
-		respondError(w, "Method not allowed", http.StatusMethodNotAllowed)
+// respondDuplicateScrape answers a submission whose URL another job enqueued within the
+// unique window with that job
+func (h *Handler) respondDuplicateScrape(w http.ResponseWriter, r *http.Request, existingJobID string) {
+	existing, err := h.store(r).GetScrapeJob(existingJobID)
+	if err != nil {
+		respondErrorCode(w, ErrCodeInternal, fmt.Sprintf("Failed to get existing scrape job: %v", err), http.StatusInternalServerError)
+		return
+	}
+	if existing == nil {
+		respondErrorCode(w, ErrCodeInternal, "Scrape already enqueued, but its job no longer exists", http.StatusInternalServerError)
 		return
 	}
+	slog.Default().Info("returning existing job for duplicate scrape submission", "job_id", existing.ID, "url", existing.URL)
+	respondJSON(w, existing, http.StatusOK)
+}
 
+// CreateTextAnalysisRequest creates a new async text analysis request
+func (h *Handler) CreateTextAnalysisRequest(w http.ResponseWriter, r *http.Request) {
 	var req AnalyzeTextRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondError(w, "Invalid request body", http.StatusBadRequest)
+		respondErrorCode(w, ErrCodeInvalidRequestBody, "Invalid request body", http.StatusBadRequest)
 		return
 	}
 
 	if req.Text == "" {
-		respondError(w, "Text is required", http.StatusBadRequest)
+		respondErrorCode(w, ErrCodeValidationFailed, "Text is required", http.StatusBadRequest)
+		return
+	}
+	if h.respondDuplicateText(w, r, req.Text, req.Force) {
 		return
 	}
 
@@ -1517,46 +1894,42 @@ func (h *Handler) CreateTextAnalysisRequest(w http.ResponseWriter, r *http.Reque
 	analysisReq, _ := h.scrapeRequests.CreateText(req.Text)
 
 	// Start background analysis
-	go h.processTextAnalysisRequest(analysisReq.ID, req.Text)
+	go h.processTextAnalysisRequest(analysisReq.ID, req.Text, "", requestCreator(r), h.store(r).Namespace())
 
-	respondJSON(w, analysisReq, http.StatusOK)
+	respondCreatedV1(w, r, "/scrape-requests/"+analysisReq.ID, analysisReq)
 }
 
 // ListScrapeRequests returns all active scrape requests
 func (h *Handler) ListScrapeRequests(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		respondError(w, "Method not allowed", http.StatusMethodNotAllowed)
+	pg, err := h.pageFromQuery(r, defaultPageLimit)
+	if err != nil {
+		respondErrorCode(w, ErrCodeValidationFailed, err.Error(), http.StatusBadRequest)
 		return
 	}
-
-	// Parse pagination parameters
-	limit := 50
-	offset := 0
-
-	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
-		if parsedLimit, err := strconv.Atoi(limitStr); err == nil && parsedLimit > 0 {
-			limit = parsedLimit
-		}
-	}
-
-	if offsetStr := r.URL.Query().Get("offset"); offsetStr != "" {
-		if parsedOffset, err := strconv.Atoi(offsetStr); err == nil && parsedOffset >= 0 {
-			offset = parsedOffset
-		}
+	expand, err := parseExpandResult(r)
+	if err != nil {
+		respondErrorCode(w, ErrCodeValidationFailed, err.Error(), http.StatusBadRequest)
+		return
 	}
 
 	// Query jobs from database
-	jobs, err := h.storage.ListScrapeJobs(limit, offset)
+	store := h.store(r)
+	jobs, err := store.ListScrapeJobs(pg.Limit, pg.Offset)
+	if err != nil {
+		respondErrorCode(w, ErrCodeInternal, fmt.Sprintf("Failed to list scrape jobs: %v", err), http.StatusInternalServerError)
+		return
+	}
+	responses, err := newScrapeJobResponses(store, jobs, expand)
 	if err != nil {
-		respondError(w, fmt.Sprintf("Failed to list scrape jobs: %v", err), http.StatusInternalServerError)
+		respondErrorCode(w, ErrCodeInternal, fmt.Sprintf("Failed to load job results: %v", err), http.StatusInternalServerError)
 		return
 	}
 
-	response := map[string]interface{}{
-		"requests": jobs,
-		"count":    len(jobs),
-		"limit":    limit,
-		"offset":   offset,
+	response := ScrapeJobListResponse{
+		Requests: responses,
+		Count:    len(responses),
+		Limit:    pg.Limit,
+		Offset:   pg.Offset,
 	}
 
 	respondJSON(w, response, http.StatusOK)
@@ -1565,14 +1938,15 @@ func (h *Handler) ListScrapeRequests(w http.ResponseWriter, r *http.Request) {
 // GetScrapeRequest returns a specific scrape request by ID
 // Checks both in-memory text analysis requests and database scrape jobs
 func (h *Handler) GetScrapeRequest(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		respondError(w, "Method not allowed", http.StatusMethodNotAllowed)
+	id := r.PathValue("id")
+	if id == "" {
+		respondErrorCode(w, ErrCodeValidationFailed, "Request ID is required", http.StatusBadRequest)
 		return
 	}
 
-	id := r.URL.Path[len("/api/scrape-requests/"):]
-	if id == "" {
-		respondError(w, "Request ID is required", http.StatusBadRequest)
+	expand, err := parseExpandResult(r)
+	if err != nil {
+		respondErrorCode(w, ErrCodeValidationFailed, err.Error(), http.StatusBadRequest)
 		return
 	}
 
@@ -1583,104 +1957,187 @@ func (h *Handler) GetScrapeRequest(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// If not found in memory, check database for scrape jobs
-	job, err := h.storage.GetScrapeJob(id)
+	store := h.store(r)
+	job, err := store.GetScrapeJob(id)
 	if err != nil {
-		respondError(w, fmt.Sprintf("Failed to get scrape job: %v", err), http.StatusInternalServerError)
+		respondErrorCode(w, ErrCodeInternal, fmt.Sprintf("Failed to get scrape job: %v", err), http.StatusInternalServerError)
 		return
 	}
 
 	if job == nil {
-		respondError(w, "Scrape request not found", http.StatusNotFound)
+		respondErrorCode(w, ErrCodeScrapeRequestNotFound, "Scrape request not found", http.StatusNotFound)
+		return
+	}
+
+	responses, err := newScrapeJobResponses(store, []*storage.ScrapeJob{job}, expand)
+	if err != nil {
+		respondErrorCode(w, ErrCodeInternal, fmt.Sprintf("Failed to load job result: %v", err), http.StatusInternalServerError)
 		return
 	}
+	respondJSON(w, responses[0], http.StatusOK)
+}
 
-	respondJSON(w, job, http.StatusOK)
+// RetryScrapeRequestBody optionally points a retried job at a corrected URL
+type RetryScrapeRequestBody struct {
+	URL string `json:"url,omitempty"`
 }
 
-// RetryScrapeRequest retries a failed scrape request
+// RetryScrapeRequest retries a failed scrape request. With a URL in the body the job is
+// retried against that URL, keeping its ID, parent and depth.
 func (h *Handler) RetryScrapeRequest(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPost {
-		respondError(w, "Method not allowed", http.StatusMethodNotAllowed)
+	id := r.PathValue("id")
+	if id == "" {
+		respondErrorCode(w, ErrCodeValidationFailed, "Request ID is required", http.StatusBadRequest)
 		return
 	}
 
-	id := r.URL.Path[len("/api/scrape-requests/"):len(r.URL.Path)-len("/retry")]
-	if id == "" {
-		respondError(w, "Request ID is required", http.StatusBadRequest)
+	// The body is optional; a bare POST retries the job as it was
+	var req RetryScrapeRequestBody
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		respondErrorCode(w, ErrCodeInvalidRequestBody, "Invalid request body", http.StatusBadRequest)
 		return
 	}
 
-	job, err := h.storage.GetScrapeJob(id)
+	job, err := h.store(r).GetScrapeJob(id)
 	if err != nil {
-		respondError(w, fmt.Sprintf("Failed to get scrape job: %v", err), http.StatusInternalServerError)
+		respondErrorCode(w, ErrCodeInternal, fmt.Sprintf("Failed to get scrape job: %v", err), http.StatusInternalServerError)
 		return
 	}
 
 	if job == nil {
-		respondError(w, "Scrape request not found", http.StatusNotFound)
+		respondErrorCode(w, ErrCodeScrapeRequestNotFound, "Scrape request not found", http.StatusNotFound)
 		return
 	}
 
 	// Only allow retrying failed requests
 	if job.Status != "failed" {
-		respondError(w, "Can only retry failed requests", http.StatusBadRequest)
+		if req.URL != "" {
+			respondErrorCode(w, ErrCodeInvalidState, "Can only change the URL of failed requests", http.StatusConflict)
+			return
+		}
+		respondErrorCode(w, ErrCodeInvalidState, "Can only retry failed requests", http.StatusBadRequest)
+		return
+	}
+
+	// A corrected URL gets the same checks as a new submission
+	retryURL, originalURL := job.URL, job.OriginalURL
+	if req.URL != "" {
+		if err := h.validateScrapeURL(r.Context(), req.URL); err != nil {
+			respondErrorDetails(w, ErrCodeURLRejected, fmt.Sprintf("URL rejected: %v", err), http.StatusBadRequest, urlRejectionDetails(err))
+			return
+		}
+		if err := h.domainPolicy.Check(req.URL); err != nil {
+			respondErrorDetails(w, ErrCodeDomainNotAllowed, fmt.Sprintf("URL rejected: %v", err), http.StatusForbidden, urlRejectionDetails(err))
+			return
+		}
+		normalizedURL, err := urlnorm.Normalize(req.URL)
+		if err != nil {
+			respondErrorCode(w, ErrCodeValidationFailed, fmt.Sprintf("Invalid URL: %v", err), http.StatusBadRequest)
+			return
+		}
+		retryURL = normalizedURL
+		if originalURL == nil && retryURL != job.URL {
+			originalURL = &job.URL
+		}
+	}
+
+	if h.rejectIfSaturated(w, "retry", !job.ExtractLinks) {
 		return
 	}
 
+	if retryURL != job.URL {
+		if err := h.store(r).UpdateScrapeJobURL(id, retryURL, originalURL); err != nil {
+			respondErrorCode(w, ErrCodeInternal, fmt.Sprintf("Failed to update job URL: %v", err), http.StatusInternalServerError)
+			return
+		}
+	}
+
 	// Reset job status
-	if err := h.storage.UpdateScrapeJobStatus(id, "queued", ""); err != nil {
-		respondError(w, fmt.Sprintf("Failed to update job status: %v", err), http.StatusInternalServerError)
+	if err := h.store(r).UpdateScrapeJobStatus(id, "queued", ""); err != nil {
+		respondErrorCode(w, ErrCodeInternal, fmt.Sprintf("Failed to update job status: %v", err), http.StatusInternalServerError)
 		return
 	}
 
 	// Re-enqueue task to Asynq (skip if queueClient is nil for testing)
 	if h.queueClient != nil {
-		taskID, err := h.queueClient.EnqueueScrape(r.Context(), id, job.URL, job.ExtractLinks)
+		ctx := queue.WithCreatedBy(r.Context(), job.CreatedBy)
+		if job.RootJobID != nil {
+			// Links found by the retried page still count against the original crawl
+			ctx = queue.WithRootJobID(ctx, *job.RootJobID)
+		}
+		if job.CrawlParams != nil {
+			ctx = queue.WithCrawlParams(ctx, job.CrawlParams)
+		}
+		ctx = queue.WithSkipAnalysis(ctx, job.SkipAnalysis)
+		taskID, err := h.queueClient.EnqueueScrape(ctx, id, retryURL, job.ExtractLinks)
+		var duplicate *queue.DuplicateTaskError
+		if errors.As(err, &duplicate) {
+			// Another job is already scraping the URL; leave this one as it was
+			if err := h.store(r).UpdateScrapeJobStatus(id, "failed", job.ErrorMessage); err != nil {
+				slog.Default().Warn("failed to restore job status", "job_id", id, "error", err)
+			}
+			if retryURL != job.URL {
+				if err := h.store(r).UpdateScrapeJobURL(id, job.URL, job.OriginalURL); err != nil {
+					slog.Default().Warn("failed to restore job URL", "job_id", id, "error", err)
+				}
+			}
+			queue.RecordScrapeOutcome(queue.ScrapeEntrySubmit, queue.ScrapeOutcomeDuplicate, clients.ScoreBucketNone)
+			h.respondDuplicateScrape(w, r, duplicate.JobID)
+			return
+		}
 		if err != nil {
-			respondError(w, fmt.Sprintf("Failed to enqueue scrape task: %v", err), http.StatusInternalServerError)
+			queue.RecordScrapeOutcome(queue.ScrapeEntrySubmit, queue.ScrapeOutcomeFailed, clients.ScoreBucketNone)
+			respondErrorCode(w, ErrCodeInternal, fmt.Sprintf("Failed to enqueue scrape task: %v", err), http.StatusInternalServerError)
 			return
 		}
 
 		// Update job with new Asynq task ID
-		if err := h.storage.UpdateScrapeJobTaskID(id, taskID); err != nil {
+		if err := h.store(r).UpdateScrapeJobTaskID(id, taskID); err != nil {
 			slog.Default().Warn("failed to update task id for job", "job_id", id, "error", err)
 		}
 	}
 
+	details := map[string]interface{}{
+		"url":     retryURL,
+		"retries": job.Retries,
+	}
+	if retryURL != job.URL {
+		details["previous_url"] = job.URL
+	}
+	h.recordAudit(r, storage.AuditActionRetry, storage.AuditEntityScrapeJob, id, details)
+
 	// Get updated job
-	updatedJob, _ := h.storage.GetScrapeJob(id)
+	updatedJob, _ := h.store(r).GetScrapeJob(id)
 	respondJSON(w, updatedJob, http.StatusOK)
 }
 
 // DeleteScrapeRequest deletes a scrape request
 func (h *Handler) DeleteScrapeRequest(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodDelete {
-		respondError(w, "Method not allowed", http.StatusMethodNotAllowed)
-		return
-	}
-
-	id := r.URL.Path[len("/api/scrape-requests/"):]
+	id := r.PathValue("id")
 	if id == "" {
-		respondError(w, "Request ID is required", http.StatusBadRequest)
+		respondErrorCode(w, ErrCodeValidationFailed, "Request ID is required", http.StatusBadRequest)
 		return
 	}
 
 	// Note: This only deletes the job record, not the actual task from Asynq
 	// In-flight tasks will continue processing
-	if err := h.storage.DeleteScrapeJob(id); err != nil {
+	if err := h.store(r).DeleteScrapeJob(id); err != nil {
 		if strings.Contains(err.Error(), "not found") {
-			respondError(w, "Scrape request not found", http.StatusNotFound)
+			respondErrorCode(w, ErrCodeScrapeRequestNotFound, "Scrape request not found", http.StatusNotFound)
 			return
 		}
-		respondError(w, fmt.Sprintf("Failed to delete scrape job: %v", err), http.StatusInternalServerError)
+		respondErrorCode(w, ErrCodeInternal, fmt.Sprintf("Failed to delete scrape job: %v", err), http.StatusInternalServerError)
 		return
 	}
 
+	h.recordAudit(r, storage.AuditActionCancel, storage.AuditEntityScrapeJob, id, nil)
+
 	respondJSON(w, map[string]string{"status": "deleted"}, http.StatusOK)
 }
 
-// processTextAnalysisRequest processes a text analysis request in the background
-func (h *Handler) processTextAnalysisRequest(id, text string) {
+// processTextAnalysisRequest processes a text analysis request in the background,
+// saving the document into namespace. A batchID is recorded in the document's metadata.
+func (h *Handler) processTextAnalysisRequest(id, text, batchID, createdBy, namespace string) {
 	// Update status to processing
 	h.scrapeRequests.UpdateStatus(id, scraper_requests.StatusProcessing, 30)
 
@@ -1703,15 +2160,9 @@ func (h *Handler) processTextAnalysisRequest(id, text string) {
 
 	// Try to get cleaned_text from metadata
 	if cleanedText, ok := analyzeResp.Metadata["cleaned_text"].(string); ok && cleanedText != "" {
-		textForSlug = cleanedText
-		if len(textForSlug) > 100 {
-			textForSlug = textForSlug[:100]
-		}
+		textForSlug = internalslug.Truncate(cleanedText, internalslug.MaxSourceRunes)
 	} else if text != "" {
-		textForSlug = text
-		if len(textForSlug) > 100 {
-			textForSlug = textForSlug[:100]
-		}
+		textForSlug = internalslug.Truncate(text, internalslug.MaxSourceRunes)
 	}
 
 	if textForSlug != "" {
@@ -1727,11 +2178,17 @@ func (h *Handler) processTextAnalysisRequest(id, text string) {
 		Tags:             analyzeResp.GetTags(),
 		Slug:             slug,
 		SEOEnabled:       true, // Enable SEO by default
+		CreatedBy:        createdBy,
+		Namespace:        namespace,
+		TextHash:         storage.TextHash(text),
 		Metadata: map[string]interface{}{
 			"analyzer_metadata": analyzeResp.Metadata,
 			"original_text":     text, // Store original submitted text
 		},
 	}
+	if batchID != "" {
+		req.Metadata["analysis_batch_id"] = batchID // Groups the batch's documents after its jobs expire
+	}
 
 	if err := h.storage.SaveRequest(req); err != nil {
 		h.scrapeRequests.SetFailed(id, fmt.Sprintf("Failed to save: %v", err))
@@ -1743,16 +2200,65 @@ func (h *Handler) processTextAnalysisRequest(id, text string) {
 	slog.Default().Info("text analysis request completed successfully", "request_id", id, "result_id", requestID)
 }
 
+// requireScheduler responds 503 and returns false when no scheduler client is configured
+func (h *Handler) requireScheduler(w http.ResponseWriter) bool {
+	if h.scheduler == nil {
+		respondErrorCode(w, ErrCodeNotConfigured, "scheduler integration not configured", http.StatusServiceUnavailable)
+		return false
+	}
+	return true
+}
+
+// schedulerErrorStatus maps a scheduler client error to the status returned to our caller.
+// Client errors from the scheduler (such as 404 for a missing task) are passed through;
+// scheduler failures and unreachable schedulers become 502.
+func schedulerErrorStatus(err error) int {
+	var schedErr *clients.SchedulerError
+	if errors.As(err, &schedErr) && schedErr.StatusCode >= 400 && schedErr.StatusCode < 500 {
+		return schedErr.StatusCode
+	}
+	return http.StatusBadGateway
+}
+
+// schedulerErrorCode is the error code matching schedulerErrorStatus
+func schedulerErrorCode(err error) string {
+	var schedErr *clients.SchedulerError
+	if errors.As(err, &schedErr) {
+		return upstreamErrorCode(schedErr.StatusCode)
+	}
+	return ErrCodeUpstreamUnavailable
+}
+
 // ListSchedulerTasks proxies the scheduler's list tasks endpoint
+// GET /api/scheduler/tasks?limit=&offset=&status=&name=
 func (h *Handler) ListSchedulerTasks(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		respondError(w, "Method not allowed", http.StatusMethodNotAllowed)
+	if !h.requireScheduler(w) {
+		return
+	}
+
+	query := r.URL.Query()
+	pg, err := h.pageFromQuery(r, defaultPageLimit)
+	if err != nil {
+		respondErrorCode(w, ErrCodeValidationFailed, err.Error(), http.StatusBadRequest)
+		return
+	}
+	opts := clients.ListTasksOptions{
+		Limit:  pg.Limit,
+		Offset: pg.Offset,
+		Status: query.Get("status"),
+		Name:   strings.TrimSpace(query.Get("name")),
+	}
+
+	switch opts.Status {
+	case "", "enabled", "disabled":
+	default:
+		respondErrorCode(w, ErrCodeValidationFailed, "Invalid status: must be enabled or disabled", http.StatusBadRequest)
 		return
 	}
 
-	tasks, err := h.scheduler.ListTasks(r.Context())
+	tasks, err := h.scheduler.ListTasks(r.Context(), opts)
 	if err != nil {
-		respondError(w, fmt.Sprintf("Failed to list tasks: %v", err), http.StatusInternalServerError)
+		respondErrorCode(w, schedulerErrorCode(err), fmt.Sprintf("Failed to list tasks: %v", err), schedulerErrorStatus(err))
 		return
 	}
 
@@ -1761,22 +2267,19 @@ func (h *Handler) ListSchedulerTasks(w http.ResponseWriter, r *http.Request) {
 
 // GetSchedulerTask proxies the scheduler's get task endpoint
 func (h *Handler) GetSchedulerTask(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		respondError(w, "Method not allowed", http.StatusMethodNotAllowed)
+	if !h.requireScheduler(w) {
 		return
 	}
 
-	// Extract task ID from path
-	idStr := r.URL.Path[len("/api/scheduler/tasks/"):]
-	id, err := strconv.ParseInt(idStr, 10, 64)
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
 	if err != nil {
-		respondError(w, "Invalid task ID", http.StatusBadRequest)
+		respondErrorCode(w, ErrCodeValidationFailed, "Invalid task ID", http.StatusBadRequest)
 		return
 	}
 
 	task, err := h.scheduler.GetTask(r.Context(), id)
 	if err != nil {
-		respondError(w, fmt.Sprintf("Failed to get task: %v", err), http.StatusInternalServerError)
+		respondErrorCode(w, schedulerErrorCode(err), fmt.Sprintf("Failed to get task: %v", err), schedulerErrorStatus(err))
 		return
 	}
 
@@ -1785,50 +2288,46 @@ func (h *Handler) GetSchedulerTask(w http.ResponseWriter, r *http.Request) {
 
 // CreateSchedulerTask proxies the scheduler's create task endpoint
 func (h *Handler) CreateSchedulerTask(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPost {
-		respondError(w, "Method not allowed", http.StatusMethodNotAllowed)
+	if !h.requireScheduler(w) {
 		return
 	}
 
 	var task clients.Task
 	if err := json.NewDecoder(r.Body).Decode(&task); err != nil {
-		respondError(w, "Invalid request body", http.StatusBadRequest)
+		respondErrorCode(w, ErrCodeInvalidRequestBody, "Invalid request body", http.StatusBadRequest)
 		return
 	}
 
 	createdTask, err := h.scheduler.CreateTask(r.Context(), &task)
 	if err != nil {
-		respondError(w, fmt.Sprintf("Failed to create task: %v", err), http.StatusInternalServerError)
+		respondErrorCode(w, schedulerErrorCode(err), fmt.Sprintf("Failed to create task: %v", err), schedulerErrorStatus(err))
 		return
 	}
 
-	respondJSON(w, createdTask, http.StatusCreated)
+	respondCreated(w, fmt.Sprintf("/scheduler/tasks/%d", createdTask.ID), createdTask)
 }
 
 // UpdateSchedulerTask proxies the scheduler's update task endpoint
 func (h *Handler) UpdateSchedulerTask(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPut {
-		respondError(w, "Method not allowed", http.StatusMethodNotAllowed)
+	if !h.requireScheduler(w) {
 		return
 	}
 
-	// Extract task ID from path
-	idStr := r.URL.Path[len("/api/scheduler/tasks/"):]
-	id, err := strconv.ParseInt(idStr, 10, 64)
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
 	if err != nil {
-		respondError(w, "Invalid task ID", http.StatusBadRequest)
+		respondErrorCode(w, ErrCodeValidationFailed, "Invalid task ID", http.StatusBadRequest)
 		return
 	}
 
 	var task clients.Task
 	if err := json.NewDecoder(r.Body).Decode(&task); err != nil {
-		respondError(w, "Invalid request body", http.StatusBadRequest)
+		respondErrorCode(w, ErrCodeInvalidRequestBody, "Invalid request body", http.StatusBadRequest)
 		return
 	}
 
 	updatedTask, err := h.scheduler.UpdateTask(r.Context(), id, &task)
 	if err != nil {
-		respondError(w, fmt.Sprintf("Failed to update task: %v", err), http.StatusInternalServerError)
+		respondErrorCode(w, schedulerErrorCode(err), fmt.Sprintf("Failed to update task: %v", err), schedulerErrorStatus(err))
 		return
 	}
 
@@ -1837,82 +2336,140 @@ func (h *Handler) UpdateSchedulerTask(w http.ResponseWriter, r *http.Request) {
 
 // DeleteSchedulerTask proxies the scheduler's delete task endpoint
 func (h *Handler) DeleteSchedulerTask(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodDelete {
-		respondError(w, "Method not allowed", http.StatusMethodNotAllowed)
+	if !h.requireScheduler(w) {
 		return
 	}
 
-	// Extract task ID from path
-	idStr := r.URL.Path[len("/api/scheduler/tasks/"):]
-	id, err := strconv.ParseInt(idStr, 10, 64)
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
 	if err != nil {
-		respondError(w, "Invalid task ID", http.StatusBadRequest)
+		respondErrorCode(w, ErrCodeValidationFailed, "Invalid task ID", http.StatusBadRequest)
 		return
 	}
 
 	if err := h.scheduler.DeleteTask(r.Context(), id); err != nil {
-		respondError(w, fmt.Sprintf("Failed to delete task: %v", err), http.StatusInternalServerError)
+		respondErrorCode(w, schedulerErrorCode(err), fmt.Sprintf("Failed to delete task: %v", err), schedulerErrorStatus(err))
 		return
 	}
 
 	w.WriteHeader(http.StatusNoContent)
 }
 
+// RunSchedulerTask proxies POST /api/scheduler/tasks/{id}/run
+func (h *Handler) RunSchedulerTask(w http.ResponseWriter, r *http.Request) {
+	h.proxySchedulerTaskAction(w, r, "run", func(ctx context.Context, id int64) (*clients.TaskActionResult, error) {
+		return h.scheduler.RunTask(ctx, id)
+	})
+}
+
+// PauseSchedulerTask proxies POST /api/scheduler/tasks/{id}/pause
+func (h *Handler) PauseSchedulerTask(w http.ResponseWriter, r *http.Request) {
+	h.proxySchedulerTaskAction(w, r, "pause", func(ctx context.Context, id int64) (*clients.TaskActionResult, error) {
+		return h.scheduler.PauseTask(ctx, id)
+	})
+}
+
+// ResumeSchedulerTask proxies POST /api/scheduler/tasks/{id}/resume
+func (h *Handler) ResumeSchedulerTask(w http.ResponseWriter, r *http.Request) {
+	h.proxySchedulerTaskAction(w, r, "resume", func(ctx context.Context, id int64) (*clients.TaskActionResult, error) {
+		return h.scheduler.ResumeTask(ctx, id)
+	})
+}
+
+// proxySchedulerTaskAction forwards a task action to the scheduler and relays its status code.
+// Scheduler error statuses are passed through as-is; only an unreachable scheduler becomes 502.
+func (h *Handler) proxySchedulerTaskAction(w http.ResponseWriter, r *http.Request, action string, call func(context.Context, int64) (*clients.TaskActionResult, error)) {
+	if !h.requireScheduler(w) {
+		return
+	}
+
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		respondErrorCode(w, ErrCodeValidationFailed, "Invalid task ID", http.StatusBadRequest)
+		return
+	}
+
+	result, err := call(r.Context(), id)
+	if err != nil {
+		status := http.StatusBadGateway
+		var schedErr *clients.SchedulerError
+		if errors.As(err, &schedErr) {
+			status = schedErr.StatusCode
+		}
+		respondErrorCode(w, schedulerErrorCode(err), fmt.Sprintf("Failed to %s task: %v", action, err), status)
+		return
+	}
+
+	if result.StatusCode == http.StatusNoContent {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	if len(result.Body) == 0 {
+		respondJSON(w, map[string]interface{}{"id": id, "action": action}, result.StatusCode)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(result.StatusCode)
+	w.Write(result.Body)
+}
+
 // Health check endpoint
 func (h *Handler) Health(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		respondError(w, "Method not allowed", http.StatusMethodNotAllowed)
-		return
+	scheduler := "configured"
+	if h.scheduler == nil {
+		scheduler = "not_configured"
 	}
 
 	response := map[string]string{
-		"status": "healthy",
+		"status":    "healthy",
+		"scheduler": scheduler,
 	}
 	respondJSON(w, response, http.StatusOK)
 }
 
 // GetTagTimeline returns tag frequency distribution over time buckets
 // This provides a scalable way to visualize tag trends without sending all documents
-// GET /api/tags/timeline?start_date=<RFC3339>&end_date=<RFC3339>&bucket_size=<duration>&max_tags=<int>
+// GET /api/tags/timeline?start_date=<RFC3339|YYYY-MM-DD>&end_date=<RFC3339|YYYY-MM-DD>&bucket_size=<duration>&max_tags=<int>&timezone=<IANA>&tags=<csv>
 func (h *Handler) GetTagTimeline(w http.ResponseWriter, r *http.Request) {
 	_, span := tracing.StartSpan(r.Context(), "GetTagTimeline")
 	defer span.End()
 
-	if r.Method != http.MethodGet {
-		respondError(w, "Method not allowed", http.StatusMethodNotAllowed)
-		return
-	}
-
 	// Parse query parameters
 	query := r.URL.Query()
 
+	// Plain dates are whole days in this zone, and bucket timestamps are reported in it
+	loc, err := loadTimezone(query.Get("timezone"))
+	if err != nil {
+		respondErrorCode(w, ErrCodeValidationFailed, err.Error(), http.StatusBadRequest)
+		return
+	}
+
 	// Parse start date (required)
 	startDateStr := query.Get("start_date")
 	if startDateStr == "" {
-		respondError(w, "start_date parameter is required", http.StatusBadRequest)
+		respondErrorCode(w, ErrCodeValidationFailed, "start_date parameter is required", http.StatusBadRequest)
 		return
 	}
-	startDate, err := time.Parse(time.RFC3339, startDateStr)
+	startDate, _, err := parseDateBound(startDateStr, loc, false)
 	if err != nil {
-		respondError(w, "invalid start_date format, use RFC3339", http.StatusBadRequest)
+		respondErrorCode(w, ErrCodeValidationFailed, "invalid start_date format, use RFC3339 or YYYY-MM-DD", http.StatusBadRequest)
 		return
 	}
 
 	// Parse end date (required)
 	endDateStr := query.Get("end_date")
 	if endDateStr == "" {
-		respondError(w, "end_date parameter is required", http.StatusBadRequest)
+		respondErrorCode(w, ErrCodeValidationFailed, "end_date parameter is required", http.StatusBadRequest)
 		return
 	}
-	endDate, err := time.Parse(time.RFC3339, endDateStr)
+	endDate, _, err := parseDateBound(endDateStr, loc, true)
 	if err != nil {
-		respondError(w, "invalid end_date format, use RFC3339", http.StatusBadRequest)
+		respondErrorCode(w, ErrCodeValidationFailed, "invalid end_date format, use RFC3339 or YYYY-MM-DD", http.StatusBadRequest)
 		return
 	}
 
 	// Validate date range
 	if endDate.Before(startDate) {
-		respondError(w, "end_date must be after start_date", http.StatusBadRequest)
+		respondErrorCode(w, ErrCodeValidationFailed, "end_date must be after start_date", http.StatusBadRequest)
 		return
 	}
 
@@ -1943,7 +2500,7 @@ func (h *Handler) GetTagTimeline(w http.ResponseWriter, r *http.Request) {
 		// Parse as Go duration string (e.g., "1h", "30m", "24h")
 		bucketSize, err = time.ParseDuration(bucketSizeStr)
 		if err != nil {
-			respondError(w, "invalid bucket_size format, use Go duration (e.g., 1h, 30m)", http.StatusBadRequest)
+			respondErrorCode(w, ErrCodeValidationFailed, "invalid bucket_size format, use Go duration (e.g., 1h, 30m)", http.StatusBadRequest)
 			return
 		}
 	}
@@ -1954,13 +2511,21 @@ func (h *Handler) GetTagTimeline(w http.ResponseWriter, r *http.Request) {
 	if maxTagsStr != "" {
 		maxTags, err = strconv.Atoi(maxTagsStr)
 		if err != nil || maxTags < 1 || maxTags > 100 {
-			respondError(w, "max_tags must be between 1 and 100", http.StatusBadRequest)
+			respondErrorCode(w, ErrCodeValidationFailed, "max_tags must be between 1 and 100", http.StatusBadRequest)
 			return
 		}
 	}
 
+	// Parse focus tags (optional): only documents carrying one of them are counted
+	var focusTags []string
+	for _, tag := range strings.Split(query.Get("tags"), ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			focusTags = append(focusTags, tag)
+		}
+	}
+
 	// Query storage
-	timeline, err := h.storage.GetTagTimeline(startDate, endDate, bucketSize, maxTags)
+	timeline, err := h.store(r).GetTagTimelineFiltered(startDate, endDate, bucketSize, maxTags, focusTags)
 	if err != nil {
 		slog.Default().Error("failed to get tag timeline",
 			"error", err,
@@ -1968,8 +2533,9 @@ func (h *Handler) GetTagTimeline(w http.ResponseWriter, r *http.Request) {
 			"end_date", endDate,
 			"bucket_size", bucketSize,
 			"max_tags", maxTags,
+			"focus_tags", focusTags,
 		)
-		respondError(w, "Failed to get tag timeline", http.StatusInternalServerError)
+		respondErrorCode(w, ErrCodeInternal, "Failed to get tag timeline", http.StatusInternalServerError)
 		return
 	}
 
@@ -1978,8 +2544,12 @@ func (h *Handler) GetTagTimeline(w http.ResponseWriter, r *http.Request) {
 		attribute.Int("tag_timeline.bucket_count", len(timeline.Buckets)),
 		attribute.Int("tag_timeline.total_documents", timeline.Stats.TotalDocuments),
 		attribute.Int("tag_timeline.total_unique_tags", timeline.Stats.TotalUniqueTags),
+		attribute.Int("tag_timeline.focus_tags", len(focusTags)),
 	)
 
+	for i := range timeline.Buckets {
+		timeline.Buckets[i].Timestamp = timeline.Buckets[i].Timestamp.In(loc)
+	}
 	respondJSON(w, timeline, http.StatusOK)
 }
 
@@ -1989,23 +2559,21 @@ func respondJSON(w http.ResponseWriter, data interface{}, status int) {
 	json.NewEncoder(w).Encode(data)
 }
 
-func respondError(w http.ResponseWriter, message string, status int) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(status)
-	json.NewEncoder(w).Encode(ErrorResponse{Error: message})
+// respondCreated answers a request that created a resource with 201 and a Location header
+// pointing at the resource's canonical GET path, given relative to APIPrefixV1
+func respondCreated(w http.ResponseWriter, path string, data interface{}) {
+	w.Header().Set("Location", APIPrefixV1+path)
+	respondJSON(w, data, http.StatusCreated)
 }
 
-// extractDomainTag extracts a clean domain name from a URL to use as a tag
-// Returns the domain name without "www." prefix, or empty string if parsing fails
-func extractDomainTag(urlStr string) string {
-	parsed, err := url.Parse(urlStr)
-	if err != nil {
-		return ""
+// respondCreatedV1 is respondCreated for endpoints that answered creations with 200 before
+// API versioning. The deprecated unversioned alias keeps the 200, so clients written against
+// it are unaffected; both get the Location header.
+func respondCreatedV1(w http.ResponseWriter, r *http.Request, path string, data interface{}) {
+	if APIVersion(r.Context()) == APIVersionUnversioned {
+		w.Header().Set("Location", APIPrefixV1+path)
+		respondJSON(w, data, http.StatusOK)
+		return
 	}
-
-	domain := parsed.Hostname()
-	// Remove "www." prefix if present
-	domain = strings.TrimPrefix(domain, "www.")
-
-	return domain
+	respondCreated(w, path, data)
 }
@@ -5,18 +5,31 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"sort"
+	"strconv"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/docutag/platform/pkg/metrics"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/docutag/controller/internal/clients"
+	"github.com/docutag/controller/internal/queue"
 	"github.com/docutag/controller/internal/storage"
+	"github.com/docutag/controller/internal/urlguard"
 )
 
+// publicResolver resolves every host to a public address so URL validation never needs real DNS
+type publicResolver struct{}
+
+func (publicResolver) LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error) {
+	return []net.IPAddr{{IP: net.ParseIP("93.184.216.34")}}, nil
+}
+
 // mockQueueClient is a test implementation of queue.Client
 type mockQueueClient struct{}
 
@@ -116,38 +129,21 @@ func mockScraperServer() *httptest.Server {
 				return
 			}
 
-			response := clients.ImageSearchResponse{
-				Images: []*clients.ImageInfo{
-					{
-						ID:      "img-1",
-						URL:     "https://example.com/image1.jpg",
-						AltText: "Test Image 1",
-						Summary: "A test image",
-						Tags:    req.Tags,
-					},
-				},
-				Count: 1,
-			}
+			images := mockImages("img", req.Tags)
 			w.WriteHeader(http.StatusOK)
-			json.NewEncoder(w).Encode(response)
+			json.NewEncoder(w).Encode(mockImagePage(images, req.Limit, req.Offset, req.IncludeTombstoned))
 
 		default:
 			// Handle dynamic routes like /api/scrapes/{id}/images
 			if len(r.URL.Path) > 13 && r.URL.Path[:13] == "/api/scrapes/" && len(r.URL.Path) > 20 && r.URL.Path[len(r.URL.Path)-7:] == "/images" {
-				response := clients.ImageSearchResponse{
-					Images: []*clients.ImageInfo{
-						{
-							ID:      "img-1",
-							URL:     "https://example.com/scrape-image.jpg",
-							AltText: "Scrape Image",
-							Summary: "Image from scrape",
-							Tags:    []string{"scraped"},
-						},
-					},
-					Count: 1,
-				}
+				query := r.URL.Query()
+				limit, _ := strconv.Atoi(query.Get("limit"))
+				offset, _ := strconv.Atoi(query.Get("offset"))
+				includeTombstoned := query.Get("include_tombstoned") == "true"
+
+				images := mockImages("scrape-img", []string{"scraped"})
 				w.WriteHeader(http.StatusOK)
-				json.NewEncoder(w).Encode(response)
+				json.NewEncoder(w).Encode(mockImagePage(images, limit, offset, includeTombstoned))
 				return
 			}
 
@@ -156,6 +152,43 @@ func mockScraperServer() *httptest.Server {
 	}))
 }
 
+// mockImages returns the mock scraper's image set: five images, the last one tombstoned
+func mockImages(prefix string, tags []string) []*clients.ImageInfo {
+	tombstoned := time.Now().Add(24 * time.Hour)
+	images := make([]*clients.ImageInfo, 5)
+	for i := range images {
+		images[i] = &clients.ImageInfo{
+			ID:      fmt.Sprintf("%s-%d", prefix, i+1),
+			URL:     fmt.Sprintf("https://example.com/%s-%d.jpg", prefix, i+1),
+			AltText: "Test Image",
+			Summary: "A test image",
+			Tags:    tags,
+		}
+	}
+	images[4].TombstoneDatetime = &tombstoned
+	return images
+}
+
+// mockImagePage paginates images the way a scraper supporting the list options does
+func mockImagePage(images []*clients.ImageInfo, limit, offset int, includeTombstoned bool) clients.ImageSearchResponse {
+	matched := []*clients.ImageInfo{}
+	for _, image := range images {
+		if image.TombstoneDatetime == nil || includeTombstoned {
+			matched = append(matched, image)
+		}
+	}
+	page := matched
+	if offset < len(page) {
+		page = page[offset:]
+	} else {
+		page = page[:0]
+	}
+	if limit > 0 && len(page) > limit {
+		page = page[:limit]
+	}
+	return clients.ImageSearchResponse{Images: page, Count: len(page), Total: len(matched), Limit: limit, Offset: offset}
+}
+
 // mockTextAnalyzerServer creates a mock text analyzer HTTP server
 func mockTextAnalyzerServer() *httptest.Server {
 	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -177,10 +210,25 @@ func mockTextAnalyzerServer() *httptest.Server {
 	}))
 }
 
-func setupTestHandler(t *testing.T) (*Handler, *httptest.Server, *httptest.Server, func()) {
-	// Reset Prometheus registry to avoid duplicate metrics registration across tests
-	prometheus.DefaultRegisterer = prometheus.NewRegistry()
+// newTestBusinessMetrics returns business metrics that no registry exposes. Tests use them
+// instead of metrics.NewBusinessMetrics, which registers with prometheus.DefaultRegisterer,
+// so handlers can be created in parallel tests.
+func newTestBusinessMetrics() *metrics.BusinessMetrics {
+	return &metrics.BusinessMetrics{
+		ScrapeRequestsTotal:    prometheus.NewCounterVec(prometheus.CounterOpts{Name: "test_scrape_requests_total"}, []string{"status"}),
+		ScrapeJobsTotal:        prometheus.NewCounterVec(prometheus.CounterOpts{Name: "test_scrape_jobs_total"}, []string{"type"}),
+		ScrapeJobsByStatus:     prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "test_scrape_jobs_by_status"}, []string{"status"}),
+		DocumentsTotal:         prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "test_documents_total"}, []string{"source_type"}),
+		DocumentsWithTags:      prometheus.NewGauge(prometheus.GaugeOpts{Name: "test_documents_with_tags"}),
+		UniqueTagsTotal:        prometheus.NewGauge(prometheus.GaugeOpts{Name: "test_unique_tags_total"}),
+		DocumentsWithSEO:       prometheus.NewGauge(prometheus.GaugeOpts{Name: "test_documents_with_seo"}),
+		TombstonesPending:      prometheus.NewGauge(prometheus.GaugeOpts{Name: "test_tombstones_pending"}),
+		TombstonesCreatedTotal: prometheus.NewCounterVec(prometheus.CounterOpts{Name: "test_tombstones_created_total"}, []string{"reason", "tag"}),
+		TombstoneDaysHistogram: prometheus.NewHistogramVec(prometheus.HistogramOpts{Name: "test_tombstone_days"}, []string{"reason"}),
+	}
+}
 
+func setupTestHandler(t *testing.T) (*Handler, *httptest.Server, *httptest.Server, func()) {
 	// Create a unique database file for each test to avoid interference
 	connStr, dbCleanup := setupTestDB(t, strings.ReplaceAll(t.Name(), "/", "_"))
 
@@ -196,9 +244,11 @@ func setupTestHandler(t *testing.T) (*Handler, *httptest.Server, *httptest.Serve
 	scraperClient := clients.NewScraperClient(scraperMock.URL)
 	textAnalyzerClient := clients.NewTextAnalyzerClient(textAnalyzerMock.URL)
 
-	handler := New(store, scraperClient, textAnalyzerClient, nil, nil, nil, 0.5, "", scraperMock.URL, 30, 90)
+	handler := NewWithMetrics(store, scraperClient, textAnalyzerClient, nil, nil, nil, 0.5, "", scraperMock.URL, 30, 90, newTestBusinessMetrics(), prometheus.NewRegistry())
+	handler.SetURLGuard(urlguard.NewWithResolver(false, publicResolver{}))
 
 	cleanup := func() {
+		handler.Close()
 		store.Close()
 		scraperMock.Close()
 		textAnalyzerMock.Close()
@@ -209,13 +259,14 @@ func setupTestHandler(t *testing.T) (*Handler, *httptest.Server, *httptest.Serve
 }
 
 func TestHealth(t *testing.T) {
+	t.Parallel()
 	handler, _, _, cleanup := setupTestHandler(t)
 	defer cleanup()
 
 	req := httptest.NewRequest(http.MethodGet, "/health", nil)
 	w := httptest.NewRecorder()
 
-	handler.Health(w, req)
+	serveRoute(handler, w, req)
 
 	if w.Code != http.StatusOK {
 		t.Errorf("Expected status 200, got %d", w.Code)
@@ -232,6 +283,7 @@ func TestHealth(t *testing.T) {
 }
 
 func TestScrapeURL(t *testing.T) {
+	t.Parallel()
 	handler, _, _, cleanup := setupTestHandler(t)
 	defer cleanup()
 
@@ -244,7 +296,7 @@ func TestScrapeURL(t *testing.T) {
 	req.Header.Set("Content-Type", "application/json")
 	w := httptest.NewRecorder()
 
-	handler.ScrapeURL(w, req)
+	serveRoute(handler, w, req)
 
 	if w.Code != http.StatusCreated {
 		t.Errorf("Expected status 201, got %d: %s", w.Code, w.Body.String())
@@ -258,6 +310,9 @@ func TestScrapeURL(t *testing.T) {
 	if response.ID == "" {
 		t.Error("Expected non-empty controller ID")
 	}
+	if got, want := w.Header().Get("Location"), "/api/v1/requests/"+response.ID; got != want {
+		t.Errorf("Expected Location %q, got %q", want, got)
+	}
 	if response.SourceType != "url" {
 		t.Errorf("Expected source_type 'url', got '%s'", response.SourceType)
 	}
@@ -293,6 +348,7 @@ func TestScrapeURL(t *testing.T) {
 }
 
 func TestAnalyzeText(t *testing.T) {
+	t.Parallel()
 	handler, _, _, cleanup := setupTestHandler(t)
 	defer cleanup()
 
@@ -305,7 +361,7 @@ func TestAnalyzeText(t *testing.T) {
 	req.Header.Set("Content-Type", "application/json")
 	w := httptest.NewRecorder()
 
-	handler.AnalyzeText(w, req)
+	serveRoute(handler, w, req)
 
 	if w.Code != http.StatusCreated {
 		t.Errorf("Expected status 201, got %d: %s", w.Code, w.Body.String())
@@ -319,6 +375,9 @@ func TestAnalyzeText(t *testing.T) {
 	if response.ID == "" {
 		t.Error("Expected non-empty controller ID")
 	}
+	if got, want := w.Header().Get("Location"), "/api/v1/requests/"+response.ID; got != want {
+		t.Errorf("Expected Location %q, got %q", want, got)
+	}
 	if response.SourceType != "text" {
 		t.Errorf("Expected source_type 'text', got '%s'", response.SourceType)
 	}
@@ -337,6 +396,7 @@ func TestAnalyzeText(t *testing.T) {
 }
 
 func TestSearchTags(t *testing.T) {
+	t.Parallel()
 	handler, _, _, cleanup := setupTestHandler(t)
 	defer cleanup()
 
@@ -346,7 +406,7 @@ func TestSearchTags(t *testing.T) {
 	req := httptest.NewRequest(http.MethodPost, "/api/scrape", bytes.NewBuffer(jsonData))
 	req.Header.Set("Content-Type", "application/json")
 	w := httptest.NewRecorder()
-	handler.ScrapeURL(w, req)
+	serveRoute(handler, w, req)
 
 	// Now search for the domain tag (which is added immediately, not via async analyzer)
 	time.Sleep(10 * time.Millisecond) // Small delay to ensure DB write completes
@@ -361,7 +421,7 @@ func TestSearchTags(t *testing.T) {
 	req.Header.Set("Content-Type", "application/json")
 	w = httptest.NewRecorder()
 
-	handler.SearchTags(w, req)
+	serveRoute(handler, w, req)
 
 	if w.Code != http.StatusOK {
 		t.Errorf("Expected status 200, got %d: %s", w.Code, w.Body.String())
@@ -379,7 +439,161 @@ func TestSearchTags(t *testing.T) {
 	}
 }
 
+func TestSearchTagsReturnShapes(t *testing.T) {
+	t.Parallel()
+	w := httptest.NewRecorder()
+	serveRoute(&Handler{}, w, httptest.NewRequest(http.MethodPost, "/api/v1/search?return=everything", strings.NewReader(`{"tags":["a"]}`)))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an unknown return, got %d: %s", w.Code, w.Body.String())
+	}
+
+	handler, _, _, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	base := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	for i, id := range []string{"shape-old", "shape-mid", "shape-new"} {
+		if err := handler.storage.SaveRequest(&storage.Request{ID: id, CreatedAt: base, EffectiveDate: base.AddDate(0, 0, i),
+			SourceType: "text", Tags: []string{"shape-test"}, SEOEnabled: true, Metadata: map[string]interface{}{}}); err != nil {
+			t.Fatalf("Failed to save request: %v", err)
+		}
+	}
+	search := func(query string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		serveRoute(handler, w, httptest.NewRequest(http.MethodPost, "/api/v1/search"+query, strings.NewReader(`{"tags":["shape-test"]}`)))
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: expected status 200, got %d: %s", query, w.Code, w.Body.String())
+		}
+		return w
+	}
+
+	var ids SearchTagsResponse
+	json.Unmarshal(search("").Body.Bytes(), &ids)
+	if ids.Count != 3 || len(ids.RequestIDs) != 3 {
+		t.Errorf("Expected three IDs by default, got %+v", ids)
+	}
+
+	var full SearchDocumentsResponse
+	json.Unmarshal(search("?return=full&limit=2&offset=1").Body.Bytes(), &full)
+	if full.Total != 3 || full.Count != 2 || full.Limit != 2 || full.Offset != 1 {
+		t.Fatalf("Unexpected page %+v", full)
+	}
+	if full.Requests[0].ID != "shape-mid" || full.Requests[1].ID != "shape-old" || full.Requests[0].Tags[0] != "shape-test" {
+		t.Errorf("Expected full documents newest first, got %+v", full.Requests)
+	}
+}
+
+// TestSearchTagsShapesAgree expects both shapes of a search to find the same requests,
+// tombstoned and SEO-disabled matches included
+func TestSearchTagsShapesAgree(t *testing.T) {
+	t.Parallel()
+	handler, _, _, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	base := time.Date(2025, 5, 1, 0, 0, 0, 0, time.UTC)
+	tombstoned := map[string]interface{}{"tombstone_datetime": time.Now().Add(-time.Hour).Format(time.RFC3339)}
+	for i, record := range []*storage.Request{
+		{ID: "agree-live", SEOEnabled: true, Metadata: map[string]interface{}{}},
+		{ID: "agree-tombstoned", SEOEnabled: true, Metadata: tombstoned},
+		{ID: "agree-hidden", SEOEnabled: false, Metadata: map[string]interface{}{}},
+	} {
+		record.CreatedAt, record.EffectiveDate = base, base.AddDate(0, 0, i)
+		record.SourceType, record.Tags = "text", []string{"agree-test", fmt.Sprintf("agree-%d", i)}
+		if err := handler.storage.SaveRequest(record); err != nil {
+			t.Fatalf("Failed to save request: %v", err)
+		}
+	}
+
+	for _, body := range []string{`{"tags":["agree-test"]}`, `{"tags":["agree-1","agree-2"]}`, `{"tags":["agree-test","agree-1"],"match_all":true}`, `{"tags":["agree-"],"fuzzy":true}`} {
+		search := func(query string) []byte {
+			w := httptest.NewRecorder()
+			serveRoute(handler, w, httptest.NewRequest(http.MethodPost, "/api/v1/search"+query, strings.NewReader(body)))
+			if w.Code != http.StatusOK {
+				t.Fatalf("%s%s: expected status 200, got %d: %s", body, query, w.Code, w.Body.String())
+			}
+			return w.Body.Bytes()
+		}
+
+		var ids SearchTagsResponse
+		json.Unmarshal(search(""), &ids)
+		var full SearchDocumentsResponse
+		json.Unmarshal(search("?return=full"), &full)
+
+		fullIDs := make([]string, 0, len(full.Requests))
+		for _, record := range full.Requests {
+			fullIDs = append(fullIDs, record.ID)
+		}
+		sort.Strings(fullIDs)
+		sort.Strings(ids.RequestIDs)
+		if strings.Join(fullIDs, ",") != strings.Join(ids.RequestIDs, ",") || full.Total != ids.Count {
+			t.Errorf("%s: IDs search found %v (%d), full search %v (total %d)", body, ids.RequestIDs, ids.Count, fullIDs, full.Total)
+		}
+		if body == `{"tags":["agree-test"]}` && len(fullIDs) != 3 {
+			t.Errorf("Expected the tombstoned and SEO-disabled matches in both shapes, got %v", fullIDs)
+		}
+	}
+}
+
+func TestGetSearchTagsValidation(t *testing.T) {
+	t.Parallel()
+	for _, query := range []string{"", "?tags=", "?tags=%20,%20,", "?tags=a&fuzzy=maybe", "?tags=a&match_all=2"} {
+		w := httptest.NewRecorder()
+		serveRoute(&Handler{}, w, httptest.NewRequest(http.MethodGet, "/api/v1/search"+query, nil))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%q: expected status 400, got %d: %s", query, w.Code, w.Body.String())
+		}
+	}
+}
+
+// TestGetSearchTagsParity runs the same searches through GET and POST and expects identical bodies
+func TestGetSearchTagsParity(t *testing.T) {
+	t.Parallel()
+	handler, _, _, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	base := time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC)
+	for i, tags := range [][]string{{"parity-go", "parity web"}, {"parity-go"}, {"parity web", "parity-rust"}} {
+		if err := handler.storage.SaveRequest(&storage.Request{ID: fmt.Sprintf("parity-%d", i), CreatedAt: base, EffectiveDate: base.AddDate(0, 0, i),
+			SourceType: "text", Tags: tags, SEOEnabled: true, Metadata: map[string]interface{}{}}); err != nil {
+			t.Fatalf("Failed to save request: %v", err)
+		}
+	}
+
+	tests := []struct {
+		get, post, query string
+	}{
+		{"tags=parity-go", `{"tags":["parity-go"]}`, ""},
+		{"tags=parity-go,parity%20web&match_all=true", `{"tags":["parity-go","parity web"],"match_all":true}`, ""},
+		{"tags=parity-&fuzzy=true&match_all=false", `{"tags":["parity-"],"fuzzy":true}`, ""},
+		{"tags=parity%20web&tags=parity-rust&match_all=1", `{"tags":["parity web","parity-rust"],"match_all":true}`, ""},
+		{"tags=parity-go,%20parity-rust", `{"tags":["parity-go","parity-rust"]}`, "&return=full&limit=2&offset=1"},
+	}
+	for _, tt := range tests {
+		get := httptest.NewRecorder()
+		serveRoute(handler, get, httptest.NewRequest(http.MethodGet, "/api/v1/search?"+tt.get+tt.query, nil))
+		post := httptest.NewRecorder()
+		serveRoute(handler, post, httptest.NewRequest(http.MethodPost, "/api/v1/search?"+strings.TrimPrefix(tt.query, "&"), strings.NewReader(tt.post)))
+		if get.Code != http.StatusOK || post.Code != http.StatusOK {
+			t.Fatalf("%s: expected 200 from both, got GET %d %s, POST %d %s", tt.get, get.Code, get.Body.String(), post.Code, post.Body.String())
+		}
+		if get.Body.String() != post.Body.String() {
+			t.Errorf("%s: GET and POST differ:\nGET  %s\nPOST %s", tt.get, get.Body.String(), post.Body.String())
+		}
+		if get.Header().Get("Cache-Control") != "private, no-store" {
+			t.Errorf("%s: expected private, no-store, got %q", tt.get, get.Header().Get("Cache-Control"))
+		}
+	}
+
+	var both SearchTagsResponse
+	w := httptest.NewRecorder()
+	serveRoute(handler, w, httptest.NewRequest(http.MethodGet, "/api/v1/search?tags=parity-go,parity%20web&match_all=true", nil))
+	json.Unmarshal(w.Body.Bytes(), &both)
+	if both.Count != 1 || both.RequestIDs[0] != "parity-0" {
+		t.Errorf("Expected only parity-0 to have both tags, got %+v", both)
+	}
+}
+
 func TestGetRequest(t *testing.T) {
+	t.Parallel()
 	handler, _, _, cleanup := setupTestHandler(t)
 	defer cleanup()
 
@@ -389,7 +603,7 @@ func TestGetRequest(t *testing.T) {
 	req := httptest.NewRequest(http.MethodPost, "/api/analyze", bytes.NewBuffer(jsonData))
 	req.Header.Set("Content-Type", "application/json")
 	w := httptest.NewRecorder()
-	handler.AnalyzeText(w, req)
+	serveRoute(handler, w, req)
 
 	var createResponse ControllerResponse
 	json.NewDecoder(w.Body).Decode(&createResponse)
@@ -398,7 +612,7 @@ func TestGetRequest(t *testing.T) {
 	req = httptest.NewRequest(http.MethodGet, "/api/requests/"+createResponse.ID, nil)
 	w = httptest.NewRecorder()
 
-	handler.GetRequest(w, req)
+	serveRoute(handler, w, req)
 
 	if w.Code != http.StatusOK {
 		t.Errorf("Expected status 200, got %d: %s", w.Code, w.Body.String())
@@ -415,13 +629,14 @@ func TestGetRequest(t *testing.T) {
 }
 
 func TestGetRequestNotFound(t *testing.T) {
+	t.Parallel()
 	handler, _, _, cleanup := setupTestHandler(t)
 	defer cleanup()
 
 	req := httptest.NewRequest(http.MethodGet, "/api/requests/non-existent-id", nil)
 	w := httptest.NewRecorder()
 
-	handler.GetRequest(w, req)
+	serveRoute(handler, w, req)
 
 	if w.Code != http.StatusNotFound {
 		t.Errorf("Expected status 404, got %d", w.Code)
@@ -429,6 +644,7 @@ func TestGetRequestNotFound(t *testing.T) {
 }
 
 func TestListRequests(t *testing.T) {
+	t.Parallel()
 	handler, _, _, cleanup := setupTestHandler(t)
 	defer cleanup()
 
@@ -439,7 +655,7 @@ func TestListRequests(t *testing.T) {
 		req := httptest.NewRequest(http.MethodPost, "/api/analyze", bytes.NewBuffer(jsonData))
 		req.Header.Set("Content-Type", "application/json")
 		w := httptest.NewRecorder()
-		handler.AnalyzeText(w, req)
+		serveRoute(handler, w, req)
 
 		if w.Code != http.StatusCreated {
 			t.Fatalf("Failed to create request %d: status %d, body: %s", i, w.Code, w.Body.String())
@@ -450,7 +666,7 @@ func TestListRequests(t *testing.T) {
 	req := httptest.NewRequest(http.MethodGet, "/api/requests?limit=10&offset=0", nil)
 	w := httptest.NewRecorder()
 
-	handler.ListRequests(w, req)
+	serveRoute(handler, w, req)
 
 	if w.Code != http.StatusOK {
 		t.Errorf("Expected status 200, got %d", w.Code)
@@ -472,13 +688,14 @@ func TestListRequests(t *testing.T) {
 }
 
 func TestScrapeURLInvalidMethod(t *testing.T) {
+	t.Parallel()
 	handler, _, _, cleanup := setupTestHandler(t)
 	defer cleanup()
 
 	req := httptest.NewRequest(http.MethodGet, "/api/scrape", nil)
 	w := httptest.NewRecorder()
 
-	handler.ScrapeURL(w, req)
+	serveRoute(handler, w, req)
 
 	if w.Code != http.StatusMethodNotAllowed {
 		t.Errorf("Expected status 405, got %d", w.Code)
@@ -486,6 +703,7 @@ func TestScrapeURLInvalidMethod(t *testing.T) {
 }
 
 func TestScrapeURLEmptyURL(t *testing.T) {
+	t.Parallel()
 	handler, _, _, cleanup := setupTestHandler(t)
 	defer cleanup()
 
@@ -496,7 +714,7 @@ func TestScrapeURLEmptyURL(t *testing.T) {
 	req.Header.Set("Content-Type", "application/json")
 	w := httptest.NewRecorder()
 
-	handler.ScrapeURL(w, req)
+	serveRoute(handler, w, req)
 
 	if w.Code != http.StatusBadRequest {
 		t.Errorf("Expected status 400, got %d", w.Code)
@@ -504,6 +722,7 @@ func TestScrapeURLEmptyURL(t *testing.T) {
 }
 
 func TestAnalyzeTextEmptyText(t *testing.T) {
+	t.Parallel()
 	handler, _, _, cleanup := setupTestHandler(t)
 	defer cleanup()
 
@@ -514,7 +733,7 @@ func TestAnalyzeTextEmptyText(t *testing.T) {
 	req.Header.Set("Content-Type", "application/json")
 	w := httptest.NewRecorder()
 
-	handler.AnalyzeText(w, req)
+	serveRoute(handler, w, req)
 
 	if w.Code != http.StatusBadRequest {
 		t.Errorf("Expected status 400, got %d", w.Code)
@@ -522,6 +741,7 @@ func TestAnalyzeTextEmptyText(t *testing.T) {
 }
 
 func TestScoreLink(t *testing.T) {
+	t.Parallel()
 	handler, _, _, cleanup := setupTestHandler(t)
 	defer cleanup()
 
@@ -534,7 +754,7 @@ func TestScoreLink(t *testing.T) {
 	req.Header.Set("Content-Type", "application/json")
 	w := httptest.NewRecorder()
 
-	handler.ScoreLink(w, req)
+	serveRoute(handler, w, req)
 
 	if w.Code != http.StatusOK {
 		t.Errorf("Expected status 200, got %d: %s", w.Code, w.Body.String())
@@ -564,6 +784,7 @@ func TestScoreLink(t *testing.T) {
 }
 
 func TestScoreLinkLowScore(t *testing.T) {
+	t.Parallel()
 	handler, _, _, cleanup := setupTestHandler(t)
 	defer cleanup()
 
@@ -576,7 +797,7 @@ func TestScoreLinkLowScore(t *testing.T) {
 	req.Header.Set("Content-Type", "application/json")
 	w := httptest.NewRecorder()
 
-	handler.ScoreLink(w, req)
+	serveRoute(handler, w, req)
 
 	if w.Code != http.StatusOK {
 		t.Errorf("Expected status 200, got %d: %s", w.Code, w.Body.String())
@@ -598,6 +819,7 @@ func TestScoreLinkLowScore(t *testing.T) {
 }
 
 func TestScrapeURLWithLowScore(t *testing.T) {
+	t.Parallel()
 	handler, _, _, cleanup := setupTestHandler(t)
 	defer cleanup()
 
@@ -610,7 +832,7 @@ func TestScrapeURLWithLowScore(t *testing.T) {
 	req.Header.Set("Content-Type", "application/json")
 	w := httptest.NewRecorder()
 
-	handler.ScrapeURL(w, req)
+	serveRoute(handler, w, req)
 
 	if w.Code != http.StatusCreated {
 		t.Errorf("Expected status 201, got %d: %s", w.Code, w.Body.String())
@@ -674,6 +896,7 @@ func TestScrapeURLWithLowScore(t *testing.T) {
 }
 
 func TestScrapeURLWithHighScore(t *testing.T) {
+	t.Parallel()
 	handler, _, _, cleanup := setupTestHandler(t)
 	defer cleanup()
 
@@ -686,7 +909,7 @@ func TestScrapeURLWithHighScore(t *testing.T) {
 	req.Header.Set("Content-Type", "application/json")
 	w := httptest.NewRecorder()
 
-	handler.ScrapeURL(w, req)
+	serveRoute(handler, w, req)
 
 	if w.Code != http.StatusCreated {
 		t.Errorf("Expected status 201, got %d: %s", w.Code, w.Body.String())
@@ -737,7 +960,69 @@ func TestScrapeURLWithHighScore(t *testing.T) {
 	}
 }
 
+// scrapeOutcomeCount reads one series of controller_scrape_outcomes_total from reg, or 0
+// before it exists
+func scrapeOutcomeCount(t *testing.T, reg prometheus.Gatherer, entry, outcome, bucket string) float64 {
+	t.Helper()
+	families, err := reg.Gather()
+	if err != nil {
+		t.Fatalf("Failed to gather metrics: %v", err)
+	}
+	want := map[string]string{"entry": entry, "outcome": outcome, "score_bucket": bucket}
+	for _, family := range families {
+		if family.GetName() != "controller_scrape_outcomes_total" {
+			continue
+		}
+	series:
+		for _, m := range family.GetMetric() {
+			for _, l := range m.GetLabel() {
+				if want[l.GetName()] != l.GetValue() {
+					continue series
+				}
+			}
+			return m.GetCounter().GetValue()
+		}
+	}
+	return 0
+}
+
+func TestScrapeURLRecordsOutcome(t *testing.T) {
+	// Not parallel: the queue's counters are shared by the package, and other scrapes would move them
+	reg := prometheus.NewRegistry()
+	if err := queue.RegisterMetrics(reg); err != nil {
+		t.Fatalf("Failed to register queue metrics: %v", err)
+	}
+	handler, _, _, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	tests := []struct {
+		url     string
+		outcome string
+		bucket  string
+	}{
+		{"https://example.com", queue.ScrapeOutcomeCompleted, "0.8-1.0"},
+		{"https://low-quality.com", queue.ScrapeOutcomeBelowThreshold, "0.2-0.4"},
+	}
+	for _, tt := range tests {
+		before := scrapeOutcomeCount(t, reg, queue.ScrapeEntrySync, tt.outcome, tt.bucket)
+
+		jsonData, _ := json.Marshal(ScrapeURLRequest{URL: tt.url})
+		req := httptest.NewRequest(http.MethodPost, "/api/scrape", bytes.NewBuffer(jsonData))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		serveRoute(handler, w, req)
+
+		if w.Code != http.StatusCreated {
+			t.Fatalf("Expected status 201 for %s, got %d: %s", tt.url, w.Code, w.Body.String())
+		}
+		if got := scrapeOutcomeCount(t, reg, queue.ScrapeEntrySync, tt.outcome, tt.bucket) - before; got != 1 {
+			t.Errorf("Expected one %s scrape in the %s bucket for %s, got %v", tt.outcome, tt.bucket, tt.url, got)
+		}
+	}
+}
+
 func TestScrapeURLWithImageURL(t *testing.T) {
+	t.Parallel()
 	handler, _, _, cleanup := setupTestHandler(t)
 	defer cleanup()
 
@@ -750,7 +1035,7 @@ func TestScrapeURLWithImageURL(t *testing.T) {
 	req.Header.Set("Content-Type", "application/json")
 	w := httptest.NewRecorder()
 
-	handler.ScrapeURL(w, req)
+	serveRoute(handler, w, req)
 
 	if w.Code != http.StatusCreated {
 		t.Errorf("Expected status 201, got %d: %s", w.Code, w.Body.String())
@@ -806,6 +1091,7 @@ func TestScrapeURLWithImageURL(t *testing.T) {
 }
 
 func TestExtractLinks(t *testing.T) {
+	t.Parallel()
 	handler, _, _, cleanup := setupTestHandler(t)
 	defer cleanup()
 
@@ -818,7 +1104,7 @@ func TestExtractLinks(t *testing.T) {
 	req.Header.Set("Content-Type", "application/json")
 	w := httptest.NewRecorder()
 
-	handler.ExtractLinks(w, req)
+	serveRoute(handler, w, req)
 
 	if w.Code != http.StatusOK {
 		t.Errorf("Expected status 200, got %d: %s", w.Code, w.Body.String())
@@ -844,13 +1130,14 @@ func TestExtractLinks(t *testing.T) {
 }
 
 func TestExtractLinksInvalidMethod(t *testing.T) {
+	t.Parallel()
 	handler, _, _, cleanup := setupTestHandler(t)
 	defer cleanup()
 
 	req := httptest.NewRequest(http.MethodGet, "/api/extract-links", nil)
 	w := httptest.NewRecorder()
 
-	handler.ExtractLinks(w, req)
+	serveRoute(handler, w, req)
 
 	if w.Code != http.StatusMethodNotAllowed {
 		t.Errorf("Expected status 405, got %d", w.Code)
@@ -858,6 +1145,7 @@ func TestExtractLinksInvalidMethod(t *testing.T) {
 }
 
 func TestExtractLinksEmptyURL(t *testing.T) {
+	t.Parallel()
 	handler, _, _, cleanup := setupTestHandler(t)
 	defer cleanup()
 
@@ -868,7 +1156,7 @@ func TestExtractLinksEmptyURL(t *testing.T) {
 	req.Header.Set("Content-Type", "application/json")
 	w := httptest.NewRecorder()
 
-	handler.ExtractLinks(w, req)
+	serveRoute(handler, w, req)
 
 	if w.Code != http.StatusBadRequest {
 		t.Errorf("Expected status 400, got %d", w.Code)
@@ -880,6 +1168,7 @@ func TestExtractLinksEmptyURL(t *testing.T) {
 // ============================================================================
 
 func TestCreateScrapeRequest(t *testing.T) {
+	t.Parallel()
 	handler, _, _, cleanup := setupTestHandler(t)
 	defer cleanup()
 
@@ -892,7 +1181,7 @@ func TestCreateScrapeRequest(t *testing.T) {
 	req.Header.Set("Content-Type", "application/json")
 	w := httptest.NewRecorder()
 
-	handler.CreateScrapeRequest(w, req)
+	serveRoute(handler, w, req)
 
 	if w.Code != http.StatusOK {
 		t.Errorf("Expected status 200, got %d: %s", w.Code, w.Body.String())
@@ -904,7 +1193,10 @@ func TestCreateScrapeRequest(t *testing.T) {
 	}
 
 	if response["id"] == nil || response["id"].(string) == "" {
-		t.Error("Expected non-empty scrape request ID")
+		t.Fatal("Expected non-empty scrape request ID")
+	}
+	if got, want := w.Header().Get("Location"), "/api/v1/scrape-requests/"+response["id"].(string); got != want {
+		t.Errorf("Expected Location %q, got %q", want, got)
 	}
 
 	if response["url"] != "https://example.com" {
@@ -930,6 +1222,7 @@ func TestCreateScrapeRequest(t *testing.T) {
 }
 
 func TestCreateScrapeRequestDuplicate(t *testing.T) {
+	t.Parallel()
 	handler, _, _, cleanup := setupTestHandler(t)
 	defer cleanup()
 
@@ -942,7 +1235,7 @@ func TestCreateScrapeRequestDuplicate(t *testing.T) {
 	req1 := httptest.NewRequest(http.MethodPost, "/api/scrape-requests", bytes.NewBuffer(jsonData))
 	req1.Header.Set("Content-Type", "application/json")
 	w1 := httptest.NewRecorder()
-	handler.CreateScrapeRequest(w1, req1)
+	serveRoute(handler, w1, req1)
 
 	var response1 map[string]interface{}
 	json.NewDecoder(w1.Body).Decode(&response1)
@@ -952,7 +1245,7 @@ func TestCreateScrapeRequestDuplicate(t *testing.T) {
 	req2 := httptest.NewRequest(http.MethodPost, "/api/scrape-requests", bytes.NewBuffer(jsonData))
 	req2.Header.Set("Content-Type", "application/json")
 	w2 := httptest.NewRecorder()
-	handler.CreateScrapeRequest(w2, req2)
+	serveRoute(handler, w2, req2)
 
 	var response2 map[string]interface{}
 	json.NewDecoder(w2.Body).Decode(&response2)
@@ -970,6 +1263,7 @@ func TestCreateScrapeRequestDuplicate(t *testing.T) {
 }
 
 func TestCreateScrapeRequestEmptyURL(t *testing.T) {
+	t.Parallel()
 	handler, _, _, cleanup := setupTestHandler(t)
 	defer cleanup()
 
@@ -980,14 +1274,69 @@ func TestCreateScrapeRequestEmptyURL(t *testing.T) {
 	req.Header.Set("Content-Type", "application/json")
 	w := httptest.NewRecorder()
 
-	handler.CreateScrapeRequest(w, req)
+	serveRoute(handler, w, req)
 
 	if w.Code != http.StatusBadRequest {
 		t.Errorf("Expected status 400, got %d", w.Code)
 	}
 }
 
+func TestCreateScrapeRequestInvalidURL(t *testing.T) {
+	t.Parallel()
+	handler, _, _, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	for _, rawURL := range []string{"example.com/no-scheme", "not a valid url"} {
+		reqBody := ScrapeURLRequest{URL: rawURL}
+		jsonData, _ := json.Marshal(reqBody)
+
+		req := httptest.NewRequest(http.MethodPost, "/api/scrape-requests", bytes.NewBuffer(jsonData))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+
+		serveRoute(handler, w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("URL %q: expected status 400, got %d", rawURL, w.Code)
+		}
+	}
+}
+
+func TestCreateScrapeRequestRejectsUnsafeTargets(t *testing.T) {
+	t.Parallel()
+	handler, _, _, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	tests := []struct {
+		url  string
+		rule string
+	}{
+		{"javascript:alert(1)", "scheme"},
+		{"FILE:///etc/passwd", "scheme"},
+		{"http://169.254.169.254/latest/meta-data/", "private-target"},
+		{"http://localhost:8080/admin", "private-target"},
+		{"http://[::1]/", "private-target"},
+	}
+
+	for _, tt := range tests {
+		jsonData, _ := json.Marshal(ScrapeURLRequest{URL: tt.url})
+		req := httptest.NewRequest(http.MethodPost, "/api/scrape-requests", bytes.NewBuffer(jsonData))
+		w := httptest.NewRecorder()
+
+		serveRoute(handler, w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("URL %q: expected status 400, got %d", tt.url, w.Code)
+			continue
+		}
+		if !strings.Contains(w.Body.String(), "rule: "+tt.rule) {
+			t.Errorf("URL %q: expected error naming rule %q, got %s", tt.url, tt.rule, w.Body.String())
+		}
+	}
+}
+
 func TestListScrapeRequests(t *testing.T) {
+	t.Parallel()
 	handler, _, _, cleanup := setupTestHandler(t)
 	defer cleanup()
 
@@ -1004,14 +1353,14 @@ func TestListScrapeRequests(t *testing.T) {
 		req := httptest.NewRequest(http.MethodPost, "/api/scrape-requests", bytes.NewBuffer(jsonData))
 		req.Header.Set("Content-Type", "application/json")
 		w := httptest.NewRecorder()
-		handler.CreateScrapeRequest(w, req)
+		serveRoute(handler, w, req)
 	}
 
 	// List requests
 	req := httptest.NewRequest(http.MethodGet, "/api/scrape-requests", nil)
 	w := httptest.NewRecorder()
 
-	handler.ListScrapeRequests(w, req)
+	serveRoute(handler, w, req)
 
 	if w.Code != http.StatusOK {
 		t.Errorf("Expected status 200, got %d", w.Code)
@@ -1042,6 +1391,7 @@ func TestListScrapeRequests(t *testing.T) {
 }
 
 func TestGetScrapeRequest(t *testing.T) {
+	t.Parallel()
 	handler, _, _, cleanup := setupTestHandler(t)
 	defer cleanup()
 
@@ -1051,7 +1401,7 @@ func TestGetScrapeRequest(t *testing.T) {
 	createReq := httptest.NewRequest(http.MethodPost, "/api/scrape-requests", bytes.NewBuffer(jsonData))
 	createReq.Header.Set("Content-Type", "application/json")
 	createW := httptest.NewRecorder()
-	handler.CreateScrapeRequest(createW, createReq)
+	serveRoute(handler, createW, createReq)
 
 	var createResponse map[string]interface{}
 	json.NewDecoder(createW.Body).Decode(&createResponse)
@@ -1061,7 +1411,7 @@ func TestGetScrapeRequest(t *testing.T) {
 	getReq := httptest.NewRequest(http.MethodGet, "/api/scrape-requests/"+id, nil)
 	getW := httptest.NewRecorder()
 
-	handler.GetScrapeRequest(getW, getReq)
+	serveRoute(handler, getW, getReq)
 
 	if getW.Code != http.StatusOK {
 		t.Errorf("Expected status 200, got %d: %s", getW.Code, getW.Body.String())
@@ -1082,13 +1432,14 @@ func TestGetScrapeRequest(t *testing.T) {
 }
 
 func TestGetScrapeRequestNotFound(t *testing.T) {
+	t.Parallel()
 	handler, _, _, cleanup := setupTestHandler(t)
 	defer cleanup()
 
 	req := httptest.NewRequest(http.MethodGet, "/api/scrape-requests/non-existent-id", nil)
 	w := httptest.NewRecorder()
 
-	handler.GetScrapeRequest(w, req)
+	serveRoute(handler, w, req)
 
 	if w.Code != http.StatusNotFound {
 		t.Errorf("Expected status 404, got %d", w.Code)
@@ -1096,6 +1447,7 @@ func TestGetScrapeRequestNotFound(t *testing.T) {
 }
 
 func TestDeleteScrapeRequest(t *testing.T) {
+	t.Parallel()
 	handler, _, _, cleanup := setupTestHandler(t)
 	defer cleanup()
 
@@ -1105,7 +1457,7 @@ func TestDeleteScrapeRequest(t *testing.T) {
 	createReq := httptest.NewRequest(http.MethodPost, "/api/scrape-requests", bytes.NewBuffer(jsonData))
 	createReq.Header.Set("Content-Type", "application/json")
 	createW := httptest.NewRecorder()
-	handler.CreateScrapeRequest(createW, createReq)
+	serveRoute(handler, createW, createReq)
 
 	var createResponse map[string]interface{}
 	json.NewDecoder(createW.Body).Decode(&createResponse)
@@ -1115,7 +1467,7 @@ func TestDeleteScrapeRequest(t *testing.T) {
 	deleteReq := httptest.NewRequest(http.MethodDelete, "/api/scrape-requests/"+id, nil)
 	deleteW := httptest.NewRecorder()
 
-	handler.DeleteScrapeRequest(deleteW, deleteReq)
+	serveRoute(handler, deleteW, deleteReq)
 
 	if deleteW.Code != http.StatusOK {
 		t.Errorf("Expected status 200, got %d: %s", deleteW.Code, deleteW.Body.String())
@@ -1130,7 +1482,7 @@ func TestDeleteScrapeRequest(t *testing.T) {
 	// Verify request is deleted
 	getReq := httptest.NewRequest(http.MethodGet, "/api/scrape-requests/"+id, nil)
 	getW := httptest.NewRecorder()
-	handler.GetScrapeRequest(getW, getReq)
+	serveRoute(handler, getW, getReq)
 
 	if getW.Code != http.StatusNotFound {
 		t.Errorf("Expected status 404 after delete, got %d", getW.Code)
@@ -1138,13 +1490,14 @@ func TestDeleteScrapeRequest(t *testing.T) {
 }
 
 func TestDeleteScrapeRequestNotFound(t *testing.T) {
+	t.Parallel()
 	handler, _, _, cleanup := setupTestHandler(t)
 	defer cleanup()
 
 	req := httptest.NewRequest(http.MethodDelete, "/api/scrape-requests/non-existent-id", nil)
 	w := httptest.NewRecorder()
 
-	handler.DeleteScrapeRequest(w, req)
+	serveRoute(handler, w, req)
 
 	if w.Code != http.StatusNotFound {
 		t.Errorf("Expected status 404, got %d", w.Code)
@@ -1152,6 +1505,7 @@ func TestDeleteScrapeRequestNotFound(t *testing.T) {
 }
 
 func TestRetryScrapeRequest(t *testing.T) {
+	t.Parallel()
 	handler, _, _, cleanup := setupTestHandler(t)
 	defer cleanup()
 
@@ -1161,7 +1515,7 @@ func TestRetryScrapeRequest(t *testing.T) {
 	createReq := httptest.NewRequest(http.MethodPost, "/api/scrape-requests", bytes.NewBuffer(jsonData))
 	createReq.Header.Set("Content-Type", "application/json")
 	createW := httptest.NewRecorder()
-	handler.CreateScrapeRequest(createW, createReq)
+	serveRoute(handler, createW, createReq)
 
 	var createResponse map[string]interface{}
 	json.NewDecoder(createW.Body).Decode(&createResponse)
@@ -1173,7 +1527,7 @@ func TestRetryScrapeRequest(t *testing.T) {
 	// Verify it failed
 	getReq := httptest.NewRequest(http.MethodGet, "/api/scrape-requests/"+id, nil)
 	getW := httptest.NewRecorder()
-	handler.GetScrapeRequest(getW, getReq)
+	serveRoute(handler, getW, getReq)
 
 	var getResponse map[string]interface{}
 	json.NewDecoder(getW.Body).Decode(&getResponse)
@@ -1186,7 +1540,7 @@ func TestRetryScrapeRequest(t *testing.T) {
 	retryReq := httptest.NewRequest(http.MethodPost, "/api/scrape-requests/"+id+"/retry", nil)
 	retryW := httptest.NewRecorder()
 
-	handler.RetryScrapeRequest(retryW, retryReq)
+	serveRoute(handler, retryW, retryReq)
 
 	if retryW.Code != http.StatusOK {
 		t.Errorf("Expected status 200, got %d: %s", retryW.Code, retryW.Body.String())
@@ -1206,13 +1560,14 @@ func TestRetryScrapeRequest(t *testing.T) {
 }
 
 func TestRetryScrapeRequestNotFound(t *testing.T) {
+	t.Parallel()
 	handler, _, _, cleanup := setupTestHandler(t)
 	defer cleanup()
 
 	req := httptest.NewRequest(http.MethodPost, "/api/scrape-requests/non-existent-id/retry", nil)
 	w := httptest.NewRecorder()
 
-	handler.RetryScrapeRequest(w, req)
+	serveRoute(handler, w, req)
 
 	if w.Code != http.StatusNotFound {
 		t.Errorf("Expected status 404, got %d", w.Code)
@@ -1220,6 +1575,7 @@ func TestRetryScrapeRequestNotFound(t *testing.T) {
 }
 
 func TestScrapeRequestMethodNotAllowed(t *testing.T) {
+	t.Parallel()
 	handler, _, _, cleanup := setupTestHandler(t)
 	defer cleanup()
 
@@ -1227,7 +1583,7 @@ func TestScrapeRequestMethodNotAllowed(t *testing.T) {
 	req := httptest.NewRequest(http.MethodPut, "/api/scrape-requests", nil)
 	w := httptest.NewRecorder()
 
-	handler.CreateScrapeRequest(w, req)
+	serveRoute(handler, w, req)
 
 	if w.Code != http.StatusMethodNotAllowed {
 		t.Errorf("Expected status 405, got %d", w.Code)
@@ -1235,6 +1591,7 @@ func TestScrapeRequestMethodNotAllowed(t *testing.T) {
 }
 
 func TestGetDocumentImages(t *testing.T) {
+	t.Parallel()
 	handler, _, _, cleanup := setupTestHandler(t)
 	defer cleanup()
 
@@ -1245,6 +1602,7 @@ func TestGetDocumentImages(t *testing.T) {
 		wantStatusCode int
 		wantErrMsg     string
 		checkResponse  func(t *testing.T, body []byte)
+		direct         bool // Call the handler without routing; the router never matches an empty {uuid}
 	}{
 		{
 			name:           "successful retrieval",
@@ -1278,6 +1636,7 @@ func TestGetDocumentImages(t *testing.T) {
 			path:           "/api/documents//images",
 			wantStatusCode: http.StatusBadRequest,
 			wantErrMsg:     "Scraper UUID is required",
+			direct:         true,
 		},
 		{
 			name:           "method not allowed",
@@ -1293,7 +1652,11 @@ func TestGetDocumentImages(t *testing.T) {
 			req := httptest.NewRequest(tt.method, tt.path, nil)
 			w := httptest.NewRecorder()
 
-			handler.GetDocumentImages(w, req)
+			if tt.direct {
+				handler.GetDocumentImages(w, req)
+			} else {
+				serveRoute(handler, w, req)
+			}
 
 			if w.Code != tt.wantStatusCode {
 				t.Errorf("Status code = %d, want %d. Body: %s", w.Code, tt.wantStatusCode, w.Body.String())
@@ -1318,6 +1681,7 @@ func TestGetDocumentImages(t *testing.T) {
 }
 
 func TestTombstoneRequest(t *testing.T) {
+	t.Parallel()
 	scraperServer := mockScraperServer()
 	defer scraperServer.Close()
 
@@ -1338,11 +1702,10 @@ func TestTombstoneRequest(t *testing.T) {
 	textAnalyzer := clients.NewTextAnalyzerClient(textanalyzerServer.URL)
 
 	handler := &Handler{
-		storage:               store,
-		scraper:               scraper,
-		textAnalyzer:          textAnalyzer,
-		linkScoreThreshold:    0.5,
-		tombstonePeriodManual: 90,
+		storage:      store,
+		scraper:      scraper,
+		textAnalyzer: textAnalyzer,
+		settings:     newHandlerSettings(0.5, 30, 90),
 	}
 
 	// First, create a request to tombstone
@@ -1362,7 +1725,7 @@ func TestTombstoneRequest(t *testing.T) {
 	r := httptest.NewRequest(http.MethodPut, "/api/requests/tombstone-req-1/tombstone", nil)
 	w := httptest.NewRecorder()
 
-	handler.TombstoneRequest(w, r)
+	serveRoute(handler, w, r)
 
 	if w.Code != http.StatusOK {
 		t.Errorf("Expected status 200, got %d. Body: %s", w.Code, w.Body.String())
@@ -1398,6 +1761,7 @@ func TestTombstoneRequest(t *testing.T) {
 }
 
 func TestTombstoneRequestNotFound(t *testing.T) {
+	t.Parallel()
 	scraperServer := mockScraperServer()
 	defer scraperServer.Close()
 
@@ -1418,16 +1782,16 @@ func TestTombstoneRequestNotFound(t *testing.T) {
 	textAnalyzer := clients.NewTextAnalyzerClient(textanalyzerServer.URL)
 
 	handler := &Handler{
-		storage:            store,
-		scraper:            scraper,
-		textAnalyzer:       textAnalyzer,
-		linkScoreThreshold: 0.5,
+		storage:      store,
+		scraper:      scraper,
+		textAnalyzer: textAnalyzer,
+		settings:     newHandlerSettings(0.5, 30, 90),
 	}
 
 	r := httptest.NewRequest(http.MethodPut, "/api/requests/non-existent/tombstone", nil)
 	w := httptest.NewRecorder()
 
-	handler.TombstoneRequest(w, r)
+	serveRoute(handler, w, r)
 
 	if w.Code != http.StatusNotFound {
 		t.Errorf("Expected status 404, got %d. Body: %s", w.Code, w.Body.String())
@@ -1435,6 +1799,7 @@ func TestTombstoneRequestNotFound(t *testing.T) {
 }
 
 func TestUntombstoneRequest(t *testing.T) {
+	t.Parallel()
 	scraperServer := mockScraperServer()
 	defer scraperServer.Close()
 
@@ -1455,10 +1820,10 @@ func TestUntombstoneRequest(t *testing.T) {
 	textAnalyzer := clients.NewTextAnalyzerClient(textanalyzerServer.URL)
 
 	handler := &Handler{
-		storage:            store,
-		scraper:            scraper,
-		textAnalyzer:       textAnalyzer,
-		linkScoreThreshold: 0.5,
+		storage:      store,
+		scraper:      scraper,
+		textAnalyzer: textAnalyzer,
+		settings:     newHandlerSettings(0.5, 30, 90),
 	}
 
 	// Create a request with tombstone_datetime
@@ -1480,7 +1845,7 @@ func TestUntombstoneRequest(t *testing.T) {
 	r := httptest.NewRequest(http.MethodDelete, "/api/requests/untombstone-req-1/tombstone", nil)
 	w := httptest.NewRecorder()
 
-	handler.UntombstoneRequest(w, r)
+	serveRoute(handler, w, r)
 
 	if w.Code != http.StatusOK {
 		t.Errorf("Expected status 200, got %d. Body: %s", w.Code, w.Body.String())
@@ -1498,6 +1863,7 @@ func TestUntombstoneRequest(t *testing.T) {
 }
 
 func TestDeleteRequest(t *testing.T) {
+	t.Parallel()
 	scraperServer := mockScraperServer()
 	defer scraperServer.Close()
 
@@ -1518,11 +1884,10 @@ func TestDeleteRequest(t *testing.T) {
 	textAnalyzer := clients.NewTextAnalyzerClient(textanalyzerServer.URL)
 
 	handler := &Handler{
-		storage:               store,
-		scraper:               scraper,
-		textAnalyzer:          textAnalyzer,
-		linkScoreThreshold:    0.5,
-		tombstonePeriodManual: 90,
+		storage:      store,
+		scraper:      scraper,
+		textAnalyzer: textAnalyzer,
+		settings:     newHandlerSettings(0.5, 30, 90),
 	}
 
 	// Create a request to delete
@@ -1542,7 +1907,7 @@ func TestDeleteRequest(t *testing.T) {
 	r := httptest.NewRequest(http.MethodDelete, "/api/requests/delete-req-1", nil)
 	w := httptest.NewRecorder()
 
-	handler.DeleteRequest(w, r)
+	serveRoute(handler, w, r)
 
 	if w.Code != http.StatusOK {
 		t.Errorf("Expected status 200, got %d. Body: %s", w.Code, w.Body.String())
@@ -1559,6 +1924,7 @@ func TestDeleteRequest(t *testing.T) {
 }
 
 func TestDeleteRequestNotFound(t *testing.T) {
+	t.Parallel()
 	scraperServer := mockScraperServer()
 	defer scraperServer.Close()
 
@@ -1579,16 +1945,16 @@ func TestDeleteRequestNotFound(t *testing.T) {
 	textAnalyzer := clients.NewTextAnalyzerClient(textanalyzerServer.URL)
 
 	handler := &Handler{
-		storage:            store,
-		scraper:            scraper,
-		textAnalyzer:       textAnalyzer,
-		linkScoreThreshold: 0.5,
+		storage:      store,
+		scraper:      scraper,
+		textAnalyzer: textAnalyzer,
+		settings:     newHandlerSettings(0.5, 30, 90),
 	}
 
 	r := httptest.NewRequest(http.MethodDelete, "/api/requests/non-existent", nil)
 	w := httptest.NewRecorder()
 
-	handler.DeleteRequest(w, r)
+	serveRoute(handler, w, r)
 
 	if w.Code != http.StatusNotFound {
 		t.Errorf("Expected status 404, got %d. Body: %s", w.Code, w.Body.String())
@@ -1596,6 +1962,7 @@ func TestDeleteRequestNotFound(t *testing.T) {
 }
 
 func TestGetTimelineExtents(t *testing.T) {
+	t.Parallel()
 	t.Run("empty database returns default date", func(t *testing.T) {
 		handler, _, _, cleanup := setupTestHandler(t)
 		defer cleanup()
@@ -1603,7 +1970,7 @@ func TestGetTimelineExtents(t *testing.T) {
 		req := httptest.NewRequest(http.MethodGet, "/api/requests/timeline-extents", nil)
 		w := httptest.NewRecorder()
 
-		handler.GetTimelineExtents(w, req)
+		serveRoute(handler, w, req)
 
 		if w.Code != http.StatusOK {
 			t.Errorf("Expected status 200, got %d: %s", w.Code, w.Body.String())
@@ -1655,10 +2022,10 @@ func TestGetTimelineExtents(t *testing.T) {
 		textAnalyzer := clients.NewTextAnalyzerClient(textanalyzerServer.URL)
 
 		handler := &Handler{
-			storage:            store,
-			scraper:            scraper,
-			textAnalyzer:       textAnalyzer,
-			linkScoreThreshold: 0.5,
+			storage:      store,
+			scraper:      scraper,
+			textAnalyzer: textAnalyzer,
+			settings:     newHandlerSettings(0.5, 30, 90),
 		}
 
 		// Create documents with different dates
@@ -1728,7 +2095,7 @@ func TestGetTimelineExtents(t *testing.T) {
 		req := httptest.NewRequest(http.MethodGet, "/api/requests/timeline-extents", nil)
 		w := httptest.NewRecorder()
 
-		handler.GetTimelineExtents(w, req)
+		serveRoute(handler, w, req)
 
 		if w.Code != http.StatusOK {
 			t.Errorf("Expected status 200, got %d: %s", w.Code, w.Body.String())
@@ -1767,7 +2134,7 @@ func TestGetTimelineExtents(t *testing.T) {
 			req := httptest.NewRequest(method, "/api/requests/timeline-extents", nil)
 			w := httptest.NewRecorder()
 
-			handler.GetTimelineExtents(w, req)
+			serveRoute(handler, w, req)
 
 			if w.Code != http.StatusMethodNotAllowed {
 				t.Errorf("Expected status 405 for %s method, got %d", method, w.Code)
@@ -1777,6 +2144,7 @@ func TestGetTimelineExtents(t *testing.T) {
 }
 
 func TestUpdateRequestTags(t *testing.T) {
+	t.Parallel()
 	t.Run("successfully update tags", func(t *testing.T) {
 		handler, _, _, cleanup := setupTestHandler(t)
 		defer cleanup()
@@ -1806,7 +2174,7 @@ func TestUpdateRequestTags(t *testing.T) {
 		req := httptest.NewRequest(http.MethodPut, "/api/requests/test-request-1/tags", bytes.NewReader(reqBody))
 		w := httptest.NewRecorder()
 
-		handler.UpdateRequestTags(w, req)
+		serveRoute(handler, w, req)
 
 		if w.Code != http.StatusOK {
 			t.Errorf("Expected status 200, got %d: %s", w.Code, w.Body.String())
@@ -1837,7 +2205,7 @@ func TestUpdateRequestTags(t *testing.T) {
 		req := httptest.NewRequest(http.MethodPut, "/api/requests/nonexistent/tags", bytes.NewReader(reqBody))
 		w := httptest.NewRecorder()
 
-		handler.UpdateRequestTags(w, req)
+		serveRoute(handler, w, req)
 
 		if w.Code != http.StatusNotFound {
 			t.Errorf("Expected status 404, got %d: %s", w.Code, w.Body.String())
@@ -1851,7 +2219,7 @@ func TestUpdateRequestTags(t *testing.T) {
 		req := httptest.NewRequest(http.MethodPut, "/api/requests/test-id/tags", bytes.NewReader([]byte("invalid json")))
 		w := httptest.NewRecorder()
 
-		handler.UpdateRequestTags(w, req)
+		serveRoute(handler, w, req)
 
 		if w.Code != http.StatusBadRequest {
 			t.Errorf("Expected status 400, got %d: %s", w.Code, w.Body.String())
@@ -1865,7 +2233,7 @@ func TestUpdateRequestTags(t *testing.T) {
 		req := httptest.NewRequest(http.MethodGet, "/api/requests/test-id/tags", nil)
 		w := httptest.NewRecorder()
 
-		handler.UpdateRequestTags(w, req)
+		serveRoute(handler, w, req)
 
 		if w.Code != http.StatusMethodNotAllowed {
 			t.Errorf("Expected status 405, got %d: %s", w.Code, w.Body.String())
@@ -1874,6 +2242,7 @@ func TestUpdateRequestTags(t *testing.T) {
 }
 
 func TestUpdateImageTags(t *testing.T) {
+	t.Parallel()
 	t.Run("successfully update image tags", func(t *testing.T) {
 		handler, scraperServer, _, cleanup := setupTestHandler(t)
 		defer cleanup()
@@ -1894,7 +2263,7 @@ func TestUpdateImageTags(t *testing.T) {
 		req := httptest.NewRequest(http.MethodPut, "/api/images/"+testImageID+"/tags", bytes.NewReader(reqBody))
 		w := httptest.NewRecorder()
 
-		handler.UpdateImageTags(w, req)
+		serveRoute(handler, w, req)
 
 		if w.Code != http.StatusOK {
 			t.Errorf("Expected status 200, got %d: %s", w.Code, w.Body.String())
@@ -1914,7 +2283,7 @@ func TestUpdateImageTags(t *testing.T) {
 		req := httptest.NewRequest(http.MethodPut, "/api/images/nonexistent/tags", bytes.NewReader(reqBody))
 		w := httptest.NewRecorder()
 
-		handler.UpdateImageTags(w, req)
+		serveRoute(handler, w, req)
 
 		if w.Code != http.StatusNotFound {
 			t.Errorf("Expected status 404, got %d: %s", w.Code, w.Body.String())
@@ -1928,7 +2297,7 @@ func TestUpdateImageTags(t *testing.T) {
 		req := httptest.NewRequest(http.MethodPut, "/api/images/test-id/tags", bytes.NewReader([]byte("invalid json")))
 		w := httptest.NewRecorder()
 
-		handler.UpdateImageTags(w, req)
+		serveRoute(handler, w, req)
 
 		if w.Code != http.StatusBadRequest {
 			t.Errorf("Expected status 400, got %d: %s", w.Code, w.Body.String())
@@ -1942,10 +2311,364 @@ func TestUpdateImageTags(t *testing.T) {
 		req := httptest.NewRequest(http.MethodGet, "/api/images/test-id/tags", nil)
 		w := httptest.NewRecorder()
 
-		handler.UpdateImageTags(w, req)
+		serveRoute(handler, w, req)
 
 		if w.Code != http.StatusMethodNotAllowed {
 			t.Errorf("Expected status 405, got %d: %s", w.Code, w.Body.String())
 		}
 	})
-}
\ No newline at end of file
+}
+func TestDeleteRequestSoftAndRestore(t *testing.T) {
+	t.Parallel()
+	scraperServer := mockScraperServer()
+	defer scraperServer.Close()
+
+	textanalyzerServer := mockTextAnalyzerServer()
+	defer textanalyzerServer.Close()
+
+	connStr, cleanup := setupTestDB(t, "test_soft_delete_restore")
+	defer cleanup()
+
+	store, err := storage.New(connStr, []string{"low-quality", "sparse-content"}, 30, 90, 90)
+	if err != nil {
+		t.Fatalf("Failed to create storage: %v", err)
+	}
+	defer store.Close()
+
+	handler := &Handler{
+		storage:      store,
+		scraper:      clients.NewScraperClient(scraperServer.URL),
+		textAnalyzer: clients.NewTextAnalyzerClient(textanalyzerServer.URL),
+	}
+
+	req := &storage.Request{
+		ID:               "soft-req-1",
+		CreatedAt:        time.Now().UTC(),
+		SourceType:       "text",
+		TextAnalyzerUUID: "analyzer-1",
+		Tags:             []string{"test"},
+		Metadata:         map[string]interface{}{},
+	}
+	if err := store.SaveRequest(req); err != nil {
+		t.Fatalf("Failed to save request: %v", err)
+	}
+
+	w := httptest.NewRecorder()
+	serveRoute(handler, w, httptest.NewRequest(http.MethodDelete, "/api/requests/soft-req-1", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d. Body: %s", w.Code, w.Body.String())
+	}
+
+	// Soft-deleted request is still reapable but hidden
+	expired, err := store.ListExpiredDeletedRequests(time.Now().UTC().Add(time.Minute), 10)
+	if err != nil || len(expired) != 1 {
+		t.Fatalf("Expected 1 soft-deleted request, got %d (err: %v)", len(expired), err)
+	}
+
+	w = httptest.NewRecorder()
+	serveRoute(handler, w, httptest.NewRequest(http.MethodPost, "/api/requests/soft-req-1/restore", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200 on restore, got %d. Body: %s", w.Code, w.Body.String())
+	}
+	if _, err := store.GetRequest("soft-req-1"); err != nil {
+		t.Errorf("Expected restored request to be visible, got %v", err)
+	}
+
+	// Restoring a live request is a 404
+	w = httptest.NewRecorder()
+	serveRoute(handler, w, httptest.NewRequest(http.MethodPost, "/api/requests/soft-req-1/restore", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 restoring a live request, got %d", w.Code)
+	}
+
+	// Hard delete removes the row immediately
+	w = httptest.NewRecorder()
+	serveRoute(handler, w, httptest.NewRequest(http.MethodDelete, "/api/requests/soft-req-1?hard=true", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200 on hard delete, got %d. Body: %s", w.Code, w.Body.String())
+	}
+	expired, err = store.ListExpiredDeletedRequests(time.Now().UTC().Add(time.Minute), 10)
+	if err != nil || len(expired) != 0 {
+		t.Errorf("Expected hard-deleted request to be gone, got %d (err: %v)", len(expired), err)
+	}
+}
+
+func TestReapDeletedRequests(t *testing.T) {
+	t.Parallel()
+	scraperServer := mockScraperServer()
+	defer scraperServer.Close()
+
+	textanalyzerServer := mockTextAnalyzerServer()
+	defer textanalyzerServer.Close()
+
+	connStr, cleanup := setupTestDB(t, "test_reap_deleted")
+	defer cleanup()
+
+	store, err := storage.New(connStr, []string{"low-quality", "sparse-content"}, 30, 90, 90)
+	if err != nil {
+		t.Fatalf("Failed to create storage: %v", err)
+	}
+	defer store.Close()
+
+	handler := &Handler{
+		storage:      store,
+		scraper:      clients.NewScraperClient(scraperServer.URL),
+		textAnalyzer: clients.NewTextAnalyzerClient(textanalyzerServer.URL),
+	}
+
+	req := &storage.Request{
+		ID:               "reap-req-1",
+		CreatedAt:        time.Now().UTC(),
+		SourceType:       "text",
+		TextAnalyzerUUID: "analyzer-1",
+		Metadata:         map[string]interface{}{},
+	}
+	if err := store.SaveRequest(req); err != nil {
+		t.Fatalf("Failed to save request: %v", err)
+	}
+	if _, err := store.SoftDeleteRequest("reap-req-1"); err != nil {
+		t.Fatalf("Failed to soft delete: %v", err)
+	}
+
+	// Still inside the grace period
+	reaped, err := handler.ReapDeletedRequests(context.Background(), time.Hour)
+	if err != nil {
+		t.Fatalf("ReapDeletedRequests failed: %v", err)
+	}
+	if reaped != 0 {
+		t.Errorf("Expected nothing reaped inside grace period, got %d", reaped)
+	}
+
+	// A cancelled pass, as on shutdown, purges nothing
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	reaped, err = handler.ReapDeletedRequests(cancelled, -time.Minute)
+	if err != nil {
+		t.Fatalf("ReapDeletedRequests failed: %v", err)
+	}
+	if reaped != 0 {
+		t.Errorf("Expected nothing reaped by a cancelled pass, got %d", reaped)
+	}
+
+	// Grace period elapsed
+	reaped, err = handler.ReapDeletedRequests(context.Background(), -time.Minute)
+	if err != nil {
+		t.Fatalf("ReapDeletedRequests failed: %v", err)
+	}
+	if reaped != 1 {
+		t.Errorf("Expected 1 request reaped, got %d", reaped)
+	}
+	if err := store.RestoreRequest("reap-req-1"); err == nil {
+		t.Error("Expected reaped request to be unrecoverable")
+	}
+}
+
+func TestGetRequestVersions(t *testing.T) {
+	t.Parallel()
+	connStr, cleanup := setupTestDB(t, "test_request_versions")
+	defer cleanup()
+
+	store, err := storage.New(connStr, []string{"low-quality", "sparse-content"}, 30, 90, 90)
+	if err != nil {
+		t.Fatalf("Failed to create storage: %v", err)
+	}
+	defer store.Close()
+
+	handler := &Handler{storage: store}
+
+	req := &storage.Request{
+		ID:               "versioned-req",
+		CreatedAt:        time.Now().UTC(),
+		SourceType:       "text",
+		TextAnalyzerUUID: "analyzer-1",
+		Tags:             []string{"before"},
+		Metadata:         map[string]interface{}{"title": "Before"},
+	}
+	if err := store.SaveRequest(req); err != nil {
+		t.Fatalf("Failed to save request: %v", err)
+	}
+	if _, err := store.SaveRequestVersion("versioned-req", storage.VersionReasonRescrape); err != nil {
+		t.Fatalf("Failed to save version: %v", err)
+	}
+
+	w := httptest.NewRecorder()
+	serveRoute(handler, w, httptest.NewRequest(http.MethodGet, "/api/requests/versioned-req/versions", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d. Body: %s", w.Code, w.Body.String())
+	}
+	var list struct {
+		Count    int                       `json:"count"`
+		Versions []*storage.RequestVersion `json:"versions"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&list); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if list.Count != 1 || len(list.Versions) != 1 {
+		t.Fatalf("Expected 1 version, got %d", list.Count)
+	}
+
+	w = httptest.NewRecorder()
+	serveRoute(handler, w, httptest.NewRequest(http.MethodGet, "/api/requests/versioned-req/versions/1", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d. Body: %s", w.Code, w.Body.String())
+	}
+	var version storage.RequestVersion
+	if err := json.NewDecoder(w.Body).Decode(&version); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if version.Metadata["title"] != "Before" {
+		t.Errorf("Expected snapshot metadata, got %v", version.Metadata)
+	}
+
+	tests := []struct {
+		path   string
+		status int
+	}{
+		{"/api/requests/versioned-req/versions/2", http.StatusNotFound},
+		{"/api/requests/versioned-req/versions/abc", http.StatusBadRequest},
+		{"/api/requests/missing/versions", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		w = httptest.NewRecorder()
+		serveRoute(handler, w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+		if w.Code != tt.status {
+			t.Errorf("GET %s: expected status %d, got %d", tt.path, tt.status, w.Code)
+		}
+	}
+}
+
+func TestImageListingPagination(t *testing.T) {
+	t.Parallel()
+	scraperServer := mockScraperServer()
+	defer scraperServer.Close()
+	h := &Handler{scraper: clients.NewScraperClient(scraperServer.URL)}
+
+	tests := []struct {
+		name      string
+		method    string
+		path      string
+		body      string
+		wantIDs   []string
+		wantTotal int
+	}{
+		{"document images page", http.MethodGet, "/api/v1/documents/scraper-test-uuid/images?limit=2&offset=1", "",
+			[]string{"scrape-img-2", "scrape-img-3"}, 4},
+		{"document images with tombstoned", http.MethodGet, "/api/v1/documents/scraper-test-uuid/images?offset=3&include_tombstoned=true", "",
+			[]string{"scrape-img-4", "scrape-img-5"}, 5},
+		{"search page", http.MethodPost, "/api/v1/images/search", `{"tags":["cat"],"limit":3}`,
+			[]string{"img-1", "img-2", "img-3"}, 4},
+		{"search with tombstoned", http.MethodPost, "/api/v1/images/search", `{"tags":["cat"],"offset":4,"include_tombstoned":true}`,
+			[]string{"img-5"}, 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			serveRoute(h, w, httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body)))
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+			}
+			var resp clients.ImageSearchResponse
+			if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+
+			var ids []string
+			for _, image := range resp.Images {
+				ids = append(ids, image.ID)
+			}
+			if strings.Join(ids, ",") != strings.Join(tt.wantIDs, ",") {
+				t.Errorf("images = %v, want %v", ids, tt.wantIDs)
+			}
+			if resp.Total != tt.wantTotal || resp.Count != len(tt.wantIDs) {
+				t.Errorf("total/count = %d/%d, want %d/%d", resp.Total, resp.Count, tt.wantTotal, len(tt.wantIDs))
+			}
+			if resp.ClientFiltered {
+				t.Error("the mock scraper applies the options, so nothing should be filtered locally")
+			}
+		})
+	}
+}
+
+func TestImageListingMaxPageLimit(t *testing.T) {
+	t.Parallel()
+	scraperServer := mockScraperServer()
+	defer scraperServer.Close()
+	h := &Handler{scraper: clients.NewScraperClient(scraperServer.URL)}
+	h.SetMaxPageLimit(2)
+
+	for _, tt := range []struct {
+		method, path, body string
+	}{
+		{http.MethodGet, "/api/v1/documents/scraper-test-uuid/images?limit=10", ""},
+		{http.MethodPost, "/api/v1/images/search", `{"tags":["cat"],"limit":10}`},
+	} {
+		w := httptest.NewRecorder()
+		serveRoute(h, w, httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body)))
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: expected status 200, got %d: %s", tt.path, w.Code, w.Body.String())
+		}
+		var resp clients.ImageSearchResponse
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		if resp.Limit != 2 || resp.Count != 2 {
+			t.Errorf("%s: expected the limit clamped to 2, got limit %d with %d images", tt.path, resp.Limit, resp.Count)
+		}
+	}
+}
+
+func TestImageListingValidation(t *testing.T) {
+	t.Parallel()
+	h := &Handler{}
+
+	tests := []struct {
+		method string
+		path   string
+		body   string
+	}{
+		{http.MethodGet, "/api/v1/documents/abc/images?limit=0", ""},
+		{http.MethodGet, "/api/v1/documents/abc/images?offset=-1", ""},
+		{http.MethodGet, "/api/v1/documents/abc/images?limit=ten", ""},
+		{http.MethodGet, "/api/v1/documents/abc/images?include_tombstoned=maybe", ""},
+		{http.MethodPost, "/api/v1/images/search", `{"tags":["cat"],"limit":-1}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path+tt.body, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			serveRoute(h, w, httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body)))
+
+			if w.Code != http.StatusBadRequest {
+				t.Fatalf("expected status 400, got %d", w.Code)
+			}
+			var resp ErrorResponse
+			if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if resp.Code != ErrCodeValidationFailed {
+				t.Errorf("expected %s, got %s", ErrCodeValidationFailed, resp.Code)
+			}
+		})
+	}
+}
+
+func TestFilterRequestsInvalidLanguage(t *testing.T) {
+	t.Parallel()
+	h := &Handler{}
+
+	w := httptest.NewRecorder()
+	serveRoute(h, w, httptest.NewRequest(http.MethodPost, "/api/v1/requests/filter", strings.NewReader(`{"language":"en glish"}`)))
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected status 400, got %d", w.Code)
+	}
+	var resp ErrorResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if resp.Code != ErrCodeValidationFailed {
+		t.Errorf("expected %s, got %s", ErrCodeValidationFailed, resp.Code)
+	}
+}
@@ -0,0 +1,143 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/docutag/controller/internal/storage"
+)
+
+// histogramBuckets are the bucket sizes GetRequestHistogram accepts
+var histogramBuckets = map[string]time.Duration{
+	"1h": time.Hour,
+	"6h": 6 * time.Hour,
+	"1d": 24 * time.Hour,
+	"7d": 7 * 24 * time.Hour,
+}
+
+const (
+	defaultHistogramBucket = "1d"
+	defaultHistogramRange  = 30 * 24 * time.Hour
+	maxHistogramBuckets    = 1000
+)
+
+// RequestHistogramResponse is the body of GET /api/requests/histogram
+type RequestHistogramResponse struct {
+	Start             time.Time                 `json:"start"`
+	End               time.Time                 `json:"end"`
+	Bucket            string                    `json:"bucket"`
+	Timezone          string                    `json:"timezone"`
+	GroupBy           string                    `json:"group_by,omitempty"`
+	IncludeTombstoned bool                      `json:"include_tombstoned"`
+	Buckets           []storage.HistogramBucket `json:"buckets"`
+}
+
+// parseHistogramOptions validates the histogram query parameters. Start is aligned down to a
+// bucket boundary in the requested time zone (UTC by default) so daily buckets begin at local
+// midnight. A plain end date includes that whole day.
+func parseHistogramOptions(query url.Values, now time.Time) (storage.HistogramOptions, string, error) {
+	get := func(key string) string { return strings.TrimSpace(query.Get(key)) }
+	opts := storage.HistogramOptions{}
+
+	loc, err := loadTimezone(get("timezone"))
+	if err != nil {
+		return opts, "", err
+	}
+	opts.Location = loc
+
+	bucketName := get("bucket")
+	if bucketName == "" {
+		bucketName = defaultHistogramBucket
+	}
+	bucket, ok := histogramBuckets[bucketName]
+	if !ok {
+		return opts, "", fmt.Errorf("bucket must be one of 1h, 6h, 1d, 7d")
+	}
+	opts.Bucket = bucket
+
+	opts.End = now.UTC()
+	if s := get("end"); s != "" {
+		end, _, err := parseDateBound(s, loc, true)
+		if err != nil {
+			return opts, "", fmt.Errorf("invalid end, use RFC3339 or YYYY-MM-DD")
+		}
+		opts.End = end
+	}
+	opts.Start = opts.End.Add(-defaultHistogramRange)
+	if s := get("start"); s != "" {
+		start, _, err := parseDateBound(s, loc, false)
+		if err != nil {
+			return opts, "", fmt.Errorf("invalid start, use RFC3339 or YYYY-MM-DD")
+		}
+		opts.Start = start
+	}
+	opts.Start = truncateInLocation(opts.Start, bucket, loc)
+	if !opts.End.After(opts.Start) {
+		return opts, "", fmt.Errorf("end must be after start")
+	}
+	if opts.End.Sub(opts.Start) > maxHistogramBuckets*bucket {
+		return opts, "", fmt.Errorf("range too large for bucket %s, at most %d buckets", bucketName, maxHistogramBuckets)
+	}
+
+	switch opts.GroupBy = get("group_by"); opts.GroupBy {
+	case "", storage.HistogramGroupSourceType:
+	case storage.HistogramGroupTag:
+		for _, tag := range strings.Split(get("tag"), ",") {
+			if tag = strings.TrimSpace(tag); tag != "" {
+				opts.Tags = append(opts.Tags, tag)
+			}
+		}
+		if len(opts.Tags) == 0 {
+			return opts, "", fmt.Errorf("tag parameter is required when group_by=tag")
+		}
+	default:
+		return opts, "", fmt.Errorf("group_by must be source_type or tag")
+	}
+
+	if s := get("include_tombstoned"); s != "" {
+		include, err := strconv.ParseBool(s)
+		if err != nil {
+			return opts, "", fmt.Errorf("include_tombstoned must be true or false")
+		}
+		opts.IncludeTombstoned = include
+	}
+
+	return opts, bucketName, nil
+}
+
+// truncateInLocation rounds t down to a multiple of d on the wall clock of loc, so a day
+// bucket starts at local midnight. The result is in UTC.
+func truncateInLocation(t time.Time, d time.Duration, loc *time.Location) time.Time {
+	local := t.In(loc)
+	wall := time.Date(local.Year(), local.Month(), local.Day(), local.Hour(), local.Minute(), local.Second(), local.Nanosecond(), time.UTC).Truncate(d)
+	return time.Date(wall.Year(), wall.Month(), wall.Day(), wall.Hour(), wall.Minute(), wall.Second(), wall.Nanosecond(), loc).UTC()
+}
+
+// GetRequestHistogram handles GET /api/requests/histogram
+func (h *Handler) GetRequestHistogram(w http.ResponseWriter, r *http.Request) {
+	opts, bucketName, err := parseHistogramOptions(r.URL.Query(), time.Now())
+	if err != nil {
+		respondErrorCode(w, ErrCodeValidationFailed, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	buckets, err := h.store(r).GetRequestHistogram(opts)
+	if err != nil {
+		respondErrorCode(w, ErrCodeInternal, fmt.Sprintf("Failed to get request histogram: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	respondJSON(w, RequestHistogramResponse{
+		Start:             opts.Start.In(opts.Location),
+		End:               opts.End.In(opts.Location),
+		Bucket:            bucketName,
+		Timezone:          opts.Location.String(),
+		GroupBy:           opts.GroupBy,
+		IncludeTombstoned: opts.IncludeTombstoned,
+		Buckets:           buckets,
+	}, http.StatusOK)
+}
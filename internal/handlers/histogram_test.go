@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestParseHistogramOptions(t *testing.T) {
+	t.Parallel()
+	now := time.Date(2025, 3, 15, 13, 45, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		query     string
+		wantErr   bool
+		wantStart time.Time
+		wantEnd   time.Time
+		wantTags  int
+	}{
+		{
+			name:      "defaults to thirty daily buckets ending now",
+			query:     "",
+			wantStart: time.Date(2025, 2, 13, 0, 0, 0, 0, time.UTC),
+			wantEnd:   now,
+		},
+		{
+			name:      "start aligned to bucket",
+			query:     "start=2025-03-01T10:30:00Z&end=2025-03-02T00:00:00Z&bucket=6h",
+			wantStart: time.Date(2025, 3, 1, 6, 0, 0, 0, time.UTC),
+			wantEnd:   time.Date(2025, 3, 2, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:      "plain dates include the whole end day",
+			query:     "start=2025-03-01&end=2025-03-08&bucket=1d&group_by=source_type",
+			wantStart: time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC),
+			wantEnd:   time.Date(2025, 3, 9, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:      "plain dates in a time zone",
+			query:     "start=2025-03-01&end=2025-03-08&timezone=America/Los_Angeles",
+			wantStart: time.Date(2025, 3, 1, 8, 0, 0, 0, time.UTC),
+			wantEnd:   time.Date(2025, 3, 9, 8, 0, 0, 0, time.UTC),
+		},
+		{
+			name:      "start aligned to local midnight",
+			query:     "start=2025-03-01T10:30:00Z&end=2025-03-08T00:00:00Z&timezone=America/Los_Angeles",
+			wantStart: time.Date(2025, 3, 1, 8, 0, 0, 0, time.UTC),
+			wantEnd:   time.Date(2025, 3, 8, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:      "group by tag",
+			query:     "start=2025-03-01&end=2025-03-08&group_by=tag&tag=go,%20news,",
+			wantStart: time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC),
+			wantEnd:   time.Date(2025, 3, 9, 0, 0, 0, 0, time.UTC),
+			wantTags:  2,
+		},
+		{name: "bucket not in allowlist", query: "bucket=30m", wantErr: true},
+		{name: "go duration rejected", query: "bucket=24h", wantErr: true},
+		{name: "invalid start", query: "start=yesterday", wantErr: true},
+		{name: "end before start", query: "start=2025-03-08&end=2025-03-01", wantErr: true},
+		{name: "too many buckets", query: "start=2020-01-01&end=2025-01-01&bucket=1h", wantErr: true},
+		{name: "unknown group", query: "group_by=domain", wantErr: true},
+		{name: "tag group without tag", query: "group_by=tag", wantErr: true},
+		{name: "invalid include flag", query: "include_tombstoned=maybe", wantErr: true},
+		{name: "unknown time zone", query: "timezone=Mars/Olympus_Mons", wantErr: true},
+		{name: "end before start in a time zone", query: "start=2025-03-02&end=2025-03-01T12:00:00Z&timezone=Asia/Tokyo", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, err := url.ParseQuery(tt.query)
+			if err != nil {
+				t.Fatalf("bad test query: %v", err)
+			}
+			opts, _, err := parseHistogramOptions(query, now)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected error, got options %+v", opts)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !opts.Start.Equal(tt.wantStart) {
+				t.Errorf("expected start %v, got %v", tt.wantStart, opts.Start)
+			}
+			if !opts.End.Equal(tt.wantEnd) {
+				t.Errorf("expected end %v, got %v", tt.wantEnd, opts.End)
+			}
+			if len(opts.Tags) != tt.wantTags {
+				t.Errorf("expected %d tags, got %v", tt.wantTags, opts.Tags)
+			}
+		})
+	}
+}
+
+func TestGetRequestHistogramRejectsInvalidBucket(t *testing.T) {
+	t.Parallel()
+	h := &Handler{}
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/requests/histogram?bucket=2d", nil)
+	w := httptest.NewRecorder()
+
+	serveRoute(h, w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d: %s", w.Code, w.Body.String())
+	}
+}
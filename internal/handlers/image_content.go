@@ -0,0 +1,261 @@
+package handlers
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/docutag/controller/internal/clients"
+	"github.com/docutag/controller/internal/imagecache"
+)
+
+// imageCacheControl lets browsers and proxies keep image bytes for a day; an image ID's
+// content does not change, and tombstoning is rare enough that a day's staleness is acceptable
+const imageCacheControl = "public, max-age=86400"
+
+// imageCacheTTL bounds how long a cached image is served without asking the scraper
+// again. The cache is per replica, so this is how soon an image tombstoned through
+// another replica stops being served here.
+const imageCacheTTL = 5 * time.Minute
+
+// rasterImageTypes are the content types passed through to clients. Anything else,
+// notably SVG or HTML that could run script in the API's origin, is served as an
+// opaque download.
+var rasterImageTypes = map[string]bool{
+	"image/avif":               true,
+	"image/bmp":                true,
+	"image/gif":                true,
+	"image/jpeg":               true,
+	"image/png":                true,
+	"image/tiff":               true,
+	"image/vnd.microsoft.icon": true,
+	"image/webp":               true,
+	"image/x-icon":             true,
+}
+
+// defaultImageCacheMaxItemBytes is the largest image kept in the cache when SetImageCache is given 0
+const defaultImageCacheMaxItemBytes = 5 << 20
+
+// maxRangeBufferBytes is the largest uncached image read into memory to answer a Range request.
+// Larger images are streamed whole with 200, which clients sending Range must accept.
+const maxRangeBufferBytes = 32 << 20
+
+// SetImageCache enables caching of image bytes served by GetImageContent. Images larger
+// than maxItemBytes are streamed without being cached.
+func (h *Handler) SetImageCache(cache imagecache.Cache, maxItemBytes int64) {
+	if maxItemBytes <= 0 {
+		maxItemBytes = defaultImageCacheMaxItemBytes
+	}
+	h.imageCache = cache
+	h.imageCacheMaxItemBytes = maxItemBytes
+	h.imageCacheTTL = imageCacheTTL
+}
+
+// evictImage drops an image from the byte cache after it is deleted or tombstoned
+func (h *Handler) evictImage(imageID string) {
+	if h.imageCache != nil {
+		h.imageCache.Remove(imageID)
+	}
+}
+
+// GetImageContent handles GET /api/images/{id}/content, streaming the image's bytes
+// from the scraper so clients never need to reach the scraper directly. HEAD requests get
+// the headers alone, and Range requests get 206 with the requested bytes.
+func (h *Handler) GetImageContent(w http.ResponseWriter, r *http.Request) {
+	imageID := r.PathValue("id")
+	if imageID == "" {
+		respondErrorCode(w, ErrCodeValidationFailed, "Image ID is required", http.StatusBadRequest)
+		return
+	}
+
+	// Entries older than the TTL fall through so the tombstone check below runs again
+	if h.imageCache != nil {
+		if item, ok := h.imageCache.Get(imageID); ok && time.Since(item.StoredAt) < h.imageCacheTTL {
+			slog.Debug("image cache hit", "image_id", imageID, "bytes", len(item.Data))
+			serveImageBytes(w, r, item.ContentType, item.Data, "HIT")
+			return
+		}
+	}
+
+	// Metadata tells us whether the image is tombstoned and may already carry the bytes
+	image, err := h.scraper.GetImageByID(r.Context(), imageID)
+	if err != nil {
+		if errors.Is(err, clients.ErrImageNotFound) {
+			respondErrorCode(w, ErrCodeImageNotFound, "Image not found", http.StatusNotFound)
+			return
+		}
+		respondErrorCode(w, ErrCodeUpstreamError, fmt.Sprintf("Failed to retrieve image: %v", err), http.StatusBadGateway)
+		return
+	}
+	if image.TombstoneDatetime != nil {
+		h.evictImage(imageID)
+		respondErrorCode(w, ErrCodeImageTombstoned, "Image has been tombstoned", http.StatusGone)
+		return
+	}
+
+	var (
+		body        io.Reader
+		contentType string
+		length      int64 = -1
+	)
+	if image.Base64Data != "" {
+		// Inline data is already in memory, so decode it whole to know its length
+		var decoded io.Reader
+		contentType, decoded = decodeBase64Image(image.Base64Data)
+		data, err := io.ReadAll(decoded)
+		if err != nil {
+			respondErrorCode(w, ErrCodeUpstreamError, fmt.Sprintf("Failed to decode image data: %v", err), http.StatusBadGateway)
+			return
+		}
+		body, length = bytes.NewReader(data), int64(len(data))
+	} else {
+		content, err := h.scraper.GetImageContent(r.Context(), imageID)
+		if err != nil {
+			if errors.Is(err, clients.ErrImageNotFound) {
+				respondErrorCode(w, ErrCodeImageNotFound, "Image content not found", http.StatusNotFound)
+				return
+			}
+			respondErrorCode(w, ErrCodeUpstreamError, fmt.Sprintf("Failed to retrieve image content: %v", err), http.StatusBadGateway)
+			return
+		}
+		defer content.Body.Close()
+		body, contentType, length = content.Body, content.ContentType, content.ContentLength
+	}
+
+	// Sniff the type when the source did not say, so browsers render the image
+	buffered := bufio.NewReader(body)
+	if contentType == "" || contentType == "application/octet-stream" {
+		head, _ := buffered.Peek(512)
+		contentType = http.DetectContentType(head)
+	}
+
+	// A known length is all HEAD needs, so the body is not read just to be discarded
+	if r.Method == http.MethodHead && length >= 0 {
+		writeImageHeaders(w, contentType, length, "MISS")
+		return
+	}
+
+	// Ranges, and HEAD without a length, are answered from the whole image
+	if r.Method == http.MethodHead || r.Header.Get("Range") != "" {
+		if length <= maxRangeBufferBytes {
+			data, err := io.ReadAll(io.LimitReader(buffered, maxRangeBufferBytes+1))
+			if err != nil {
+				respondErrorCode(w, ErrCodeUpstreamError, fmt.Sprintf("Failed to retrieve image content: %v", err), http.StatusBadGateway)
+				return
+			}
+			if len(data) <= maxRangeBufferBytes {
+				if h.imageCache != nil && int64(len(data)) <= h.imageCacheMaxItemBytes {
+					h.imageCache.Put(imageID, &imagecache.Item{ContentType: contentType, Data: data})
+				}
+				serveImageBytes(w, r, contentType, data, "MISS")
+				return
+			}
+			// Too large after all: stream what was read followed by the rest
+			buffered = bufio.NewReader(io.MultiReader(bytes.NewReader(data), buffered))
+		}
+	}
+
+	var (
+		dst     io.Writer = w
+		capture *cappedBuffer
+	)
+	if h.imageCache != nil && length <= h.imageCacheMaxItemBytes {
+		capture = &cappedBuffer{max: h.imageCacheMaxItemBytes}
+		dst = io.MultiWriter(w, capture)
+	}
+
+	writeImageHeaders(w, contentType, length, "MISS")
+	if _, err := io.Copy(dst, buffered); err != nil {
+		// Headers are already sent; the client sees a truncated body
+		slog.Default().Warn("failed to stream image content", "image_id", imageID, "error", err)
+		return
+	}
+
+	if capture != nil && !capture.overflow {
+		h.imageCache.Put(imageID, &imagecache.Item{ContentType: contentType, Data: capture.buf})
+	}
+}
+
+// setImageHeaders sets the headers shared by cached and streamed image responses.
+// Only raster image types are passed through; anything else is sent as an attachment
+// so a browser never renders it inline.
+func setImageHeaders(w http.ResponseWriter, contentType string, cacheStatus string) {
+	contentType, raster := rasterContentType(contentType)
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Security-Policy", "sandbox")
+	if !raster {
+		w.Header().Set("Content-Disposition", "attachment")
+	}
+	w.Header().Set("Cache-Control", imageCacheControl)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("X-Cache", cacheStatus)
+	w.Header().Set("Accept-Ranges", "bytes")
+}
+
+// writeImageHeaders starts a full 200 response for a streamed image
+func writeImageHeaders(w http.ResponseWriter, contentType string, length int64, cacheStatus string) {
+	setImageHeaders(w, contentType, cacheStatus)
+	if length >= 0 {
+		w.Header().Set("Content-Length", strconv.FormatInt(length, 10))
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// serveImageBytes answers from an image held in memory, letting http.ServeContent handle
+// HEAD and Range requests
+func serveImageBytes(w http.ResponseWriter, r *http.Request, contentType string, data []byte, cacheStatus string) {
+	setImageHeaders(w, contentType, cacheStatus)
+	http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(data))
+}
+
+// rasterContentType returns the media type of contentType when it is an allowed raster
+// image type, and application/octet-stream otherwise
+func rasterContentType(contentType string) (string, bool) {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err == nil && rasterImageTypes[mediaType] {
+		return mediaType, true
+	}
+	return "application/octet-stream", false
+}
+
+// decodeBase64Image returns a streaming decoder for base64 image data, which may be a
+// data URL ("data:image/png;base64,...") carrying its own content type
+func decodeBase64Image(data string) (string, io.Reader) {
+	contentType := ""
+	if rest, ok := strings.CutPrefix(data, "data:"); ok {
+		if meta, payload, found := strings.Cut(rest, ","); found {
+			contentType, _, _ = strings.Cut(meta, ";")
+			data = payload
+		}
+	}
+	return contentType, base64.NewDecoder(base64.StdEncoding, strings.NewReader(data))
+}
+
+// cappedBuffer collects up to max bytes of a stream for caching and gives up beyond that
+type cappedBuffer struct {
+	buf      []byte
+	max      int64
+	overflow bool
+}
+
+func (b *cappedBuffer) Write(p []byte) (int, error) {
+	if b.overflow {
+		return len(p), nil
+	}
+	if int64(len(b.buf)+len(p)) > b.max {
+		b.overflow = true
+		b.buf = nil
+		return len(p), nil
+	}
+	b.buf = append(b.buf, p...)
+	return len(p), nil
+}
@@ -0,0 +1,377 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/docutag/controller/internal/clients"
+	"github.com/docutag/controller/internal/imagecache"
+)
+
+// testPNG is a 1x1 transparent PNG
+var testPNG, _ = base64.StdEncoding.DecodeString(
+	"iVBORw0KGgoAAAANSUhEUgAAAAEAAAABCAYAAAAfFcSJAAAADUlEQVR42mNkYPhfDwAChwGA60e6kgAAAABJRU5ErkJggg==")
+
+// mockImageScraper serves image metadata and files like the scraper service
+type mockImageScraper struct {
+	images   map[string]clients.ImageInfo
+	files    map[string][]byte
+	types    map[string]string // Content-Type per file; image/png when unset
+	fileHits atomic.Int32
+	server   *httptest.Server
+}
+
+func newMockImageScraper(t *testing.T) *mockImageScraper {
+	m := &mockImageScraper{images: map[string]clients.ImageInfo{}, files: map[string][]byte{}, types: map[string]string{}}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/images/{id}", func(w http.ResponseWriter, r *http.Request) {
+		image, ok := m.images[r.PathValue("id")]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		json.NewEncoder(w).Encode(image)
+	})
+	mux.HandleFunc("GET /api/images/{id}/file", func(w http.ResponseWriter, r *http.Request) {
+		m.fileHits.Add(1)
+		data, ok := m.files[r.PathValue("id")]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		contentType, ok := m.types[r.PathValue("id")]
+		if !ok {
+			contentType = "image/png"
+		}
+		w.Header().Set("Content-Type", contentType)
+		w.Header().Set("Content-Length", fmt.Sprint(len(data)))
+		w.Write(data)
+	})
+	m.server = httptest.NewServer(mux)
+	t.Cleanup(m.server.Close)
+	return m
+}
+
+func (m *mockImageScraper) handler() *Handler {
+	return &Handler{scraper: clients.NewScraperClient(m.server.URL)}
+}
+
+func getImageContent(h *Handler, id string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	serveRoute(h, w, httptest.NewRequest(http.MethodGet, "/api/v1/images/"+id+"/content", nil))
+	return w
+}
+
+func TestGetImageContentStreamsAndCaches(t *testing.T) {
+	t.Parallel()
+	scraper := newMockImageScraper(t)
+	scraper.images["img-1"] = clients.ImageInfo{ID: "img-1", FilePath: "/data/img-1.png"}
+	scraper.files["img-1"] = testPNG
+
+	h := scraper.handler()
+	h.SetImageCache(imagecache.NewMemory(1<<20), 0)
+
+	w := getImageContent(h, "img-1")
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if !bytes.Equal(w.Body.Bytes(), testPNG) {
+		t.Error("response body does not match the scraper's image")
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "image/png" {
+		t.Errorf("expected image/png, got %s", ct)
+	}
+	if cc := w.Header().Get("Cache-Control"); cc != imageCacheControl {
+		t.Errorf("expected Cache-Control %q, got %q", imageCacheControl, cc)
+	}
+	if got := w.Header().Get("X-Cache"); got != "MISS" {
+		t.Errorf("expected cache miss, got %s", got)
+	}
+
+	w = getImageContent(h, "img-1")
+	if w.Code != http.StatusOK || !bytes.Equal(w.Body.Bytes(), testPNG) {
+		t.Fatalf("cached response differs: status %d", w.Code)
+	}
+	if got := w.Header().Get("X-Cache"); got != "HIT" {
+		t.Errorf("expected cache hit, got %s", got)
+	}
+	if hits := scraper.fileHits.Load(); hits != 1 {
+		t.Errorf("expected the scraper file endpoint to be called once, got %d", hits)
+	}
+}
+
+func TestGetImageContentWithoutCache(t *testing.T) {
+	t.Parallel()
+	scraper := newMockImageScraper(t)
+	scraper.images["img-1"] = clients.ImageInfo{ID: "img-1"}
+	scraper.files["img-1"] = testPNG
+	h := scraper.handler()
+
+	for i := 0; i < 2; i++ {
+		if w := getImageContent(h, "img-1"); w.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d", w.Code)
+		}
+	}
+	if hits := scraper.fileHits.Load(); hits != 2 {
+		t.Errorf("expected every request to reach the scraper, got %d", hits)
+	}
+}
+
+func TestGetImageContentSkipsCachingLargeImages(t *testing.T) {
+	t.Parallel()
+	scraper := newMockImageScraper(t)
+	scraper.images["img-1"] = clients.ImageInfo{ID: "img-1"}
+	scraper.files["img-1"] = testPNG
+
+	h := scraper.handler()
+	h.SetImageCache(imagecache.NewMemory(1<<20), int64(len(testPNG)-1))
+
+	getImageContent(h, "img-1")
+	w := getImageContent(h, "img-1")
+	if !bytes.Equal(w.Body.Bytes(), testPNG) {
+		t.Error("large image should still be streamed in full")
+	}
+	if got := w.Header().Get("X-Cache"); got != "MISS" {
+		t.Errorf("expected image above the item limit not to be cached, got %s", got)
+	}
+}
+
+func TestGetImageContentFromBase64(t *testing.T) {
+	t.Parallel()
+	scraper := newMockImageScraper(t)
+	scraper.images["img-1"] = clients.ImageInfo{ID: "img-1", Base64Data: base64.StdEncoding.EncodeToString(testPNG)}
+	scraper.images["img-2"] = clients.ImageInfo{ID: "img-2", Base64Data: "data:image/webp;base64," + base64.StdEncoding.EncodeToString(testPNG)}
+	h := scraper.handler()
+
+	w := getImageContent(h, "img-1")
+	if w.Code != http.StatusOK || !bytes.Equal(w.Body.Bytes(), testPNG) {
+		t.Fatalf("expected decoded PNG, got status %d", w.Code)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "image/png" {
+		t.Errorf("expected sniffed image/png, got %s", ct)
+	}
+
+	w = getImageContent(h, "img-2")
+	if ct := w.Header().Get("Content-Type"); ct != "image/webp" {
+		t.Errorf("expected content type from the data URL, got %s", ct)
+	}
+	if scraper.fileHits.Load() != 0 {
+		t.Error("images with inline data should not fetch the file")
+	}
+}
+
+func TestGetImageContentOnlyPassesRasterTypes(t *testing.T) {
+	t.Parallel()
+	scraper := newMockImageScraper(t)
+	svg := []byte(`<svg xmlns="http://www.w3.org/2000/svg"><script>alert(1)</script></svg>`)
+	scraper.images["png"] = clients.ImageInfo{ID: "png"}
+	scraper.files["png"] = testPNG
+	scraper.types["png"] = "image/png; charset=binary"
+	scraper.images["svg"] = clients.ImageInfo{ID: "svg"}
+	scraper.files["svg"] = svg
+	scraper.types["svg"] = "image/svg+xml"
+	scraper.images["html"] = clients.ImageInfo{ID: "html"}
+	scraper.files["html"] = []byte("<html><script>alert(1)</script></html>")
+	scraper.types["html"] = "text/html"
+	scraper.images["sniffed"] = clients.ImageInfo{ID: "sniffed"}
+	scraper.files["sniffed"] = []byte("<html><script>alert(1)</script></html>")
+	scraper.types["sniffed"] = "application/octet-stream"
+	scraper.images["data-url"] = clients.ImageInfo{ID: "data-url", Base64Data: "data:image/svg+xml;base64," + base64.StdEncoding.EncodeToString(svg)}
+
+	h := scraper.handler()
+	h.SetImageCache(imagecache.NewMemory(1<<20), 0)
+
+	tests := []struct {
+		id              string
+		wantType        string
+		wantDisposition string
+	}{
+		{"png", "image/png", ""},
+		{"svg", "application/octet-stream", "attachment"},
+		{"html", "application/octet-stream", "attachment"},
+		{"sniffed", "application/octet-stream", "attachment"},
+		{"data-url", "application/octet-stream", "attachment"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.id, func(t *testing.T) {
+			// The second request is served from the cache and must carry the same headers
+			for _, wantCache := range []string{"MISS", "HIT"} {
+				w := getImageContent(h, tt.id)
+				if w.Code != http.StatusOK {
+					t.Fatalf("expected status 200, got %d", w.Code)
+				}
+				if got := w.Header().Get("X-Cache"); got != wantCache {
+					t.Errorf("expected X-Cache %s, got %s", wantCache, got)
+				}
+				if ct := w.Header().Get("Content-Type"); ct != tt.wantType {
+					t.Errorf("expected Content-Type %s, got %s", tt.wantType, ct)
+				}
+				if got := w.Header().Get("Content-Disposition"); got != tt.wantDisposition {
+					t.Errorf("expected Content-Disposition %q, got %q", tt.wantDisposition, got)
+				}
+				if got := w.Header().Get("Content-Security-Policy"); got != "sandbox" {
+					t.Errorf("expected Content-Security-Policy sandbox, got %q", got)
+				}
+			}
+		})
+	}
+}
+
+func TestGetImageContentRevalidatesCachedImages(t *testing.T) {
+	t.Parallel()
+	scraper := newMockImageScraper(t)
+	scraper.images["img-1"] = clients.ImageInfo{ID: "img-1"}
+	scraper.files["img-1"] = testPNG
+
+	h := scraper.handler()
+	h.SetImageCache(imagecache.NewMemory(1<<20), 0)
+	if w := getImageContent(h, "img-1"); w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", w.Code)
+	}
+
+	// Another replica tombstones the image; this replica's cache still holds the bytes
+	tombstoned := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	scraper.images["img-1"] = clients.ImageInfo{ID: "img-1", TombstoneDatetime: &tombstoned}
+
+	if w := getImageContent(h, "img-1"); w.Code != http.StatusOK || w.Header().Get("X-Cache") != "HIT" {
+		t.Fatalf("expected a fresh entry to be served from the cache, got %d %s", w.Code, w.Header().Get("X-Cache"))
+	}
+
+	h.imageCacheTTL = time.Nanosecond
+	w := getImageContent(h, "img-1")
+	if w.Code != http.StatusGone {
+		t.Fatalf("expected status 410 once the entry is older than the TTL, got %d", w.Code)
+	}
+	if _, ok := h.imageCache.Get("img-1"); ok {
+		t.Error("expected the tombstoned image to be evicted")
+	}
+}
+
+func TestGetImageContentErrors(t *testing.T) {
+	t.Parallel()
+	scraper := newMockImageScraper(t)
+	tombstoned := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	scraper.images["gone"] = clients.ImageInfo{ID: "gone", TombstoneDatetime: &tombstoned}
+	scraper.files["gone"] = testPNG
+	scraper.images["no-file"] = clients.ImageInfo{ID: "no-file"}
+
+	h := scraper.handler()
+	h.SetImageCache(imagecache.NewMemory(1<<20), 0)
+
+	tests := []struct {
+		id         string
+		wantStatus int
+		wantCode   string
+	}{
+		{"gone", http.StatusGone, ErrCodeImageTombstoned},
+		{"missing", http.StatusNotFound, ErrCodeImageNotFound},
+		{"no-file", http.StatusNotFound, ErrCodeImageNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.id, func(t *testing.T) {
+			w := getImageContent(h, tt.id)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("expected status %d, got %d", tt.wantStatus, w.Code)
+			}
+			var resp ErrorResponse
+			if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if resp.Code != tt.wantCode {
+				t.Errorf("expected code %s, got %s", tt.wantCode, resp.Code)
+			}
+		})
+	}
+}
+
+// assertHeadMatchesGet checks that a HEAD response carries the headers of the GET response and no body
+func assertHeadMatchesGet(t *testing.T, get, head *httptest.ResponseRecorder, headers ...string) {
+	t.Helper()
+	if head.Code != get.Code {
+		t.Errorf("expected HEAD status %d, got %d", get.Code, head.Code)
+	}
+	for _, name := range headers {
+		if got, want := head.Header().Get(name), get.Header().Get(name); got != want {
+			t.Errorf("expected HEAD %s %q, got %q", name, want, got)
+		}
+	}
+	if head.Body.Len() != 0 {
+		t.Errorf("expected an empty HEAD body, got %d bytes", head.Body.Len())
+	}
+}
+
+func TestGetImageContentHead(t *testing.T) {
+	t.Parallel()
+	scraper := newMockImageScraper(t)
+	scraper.images["img-1"] = clients.ImageInfo{ID: "img-1"}
+	scraper.files["img-1"] = testPNG
+	scraper.images["inline"] = clients.ImageInfo{ID: "inline", Base64Data: base64.StdEncoding.EncodeToString(testPNG)}
+
+	for _, id := range []string{"img-1", "inline"} {
+		t.Run(id, func(t *testing.T) {
+			h := scraper.handler()
+			head := httptest.NewRecorder()
+			serveRoute(h, head, httptest.NewRequest(http.MethodHead, "/api/v1/images/"+id+"/content", nil))
+			get := getImageContent(h, id)
+
+			if get.Header().Get("Content-Length") != fmt.Sprint(len(testPNG)) {
+				t.Errorf("expected GET Content-Length %d, got %q", len(testPNG), get.Header().Get("Content-Length"))
+			}
+			assertHeadMatchesGet(t, get, head, "Content-Type", "Content-Length", "Cache-Control", "Accept-Ranges")
+		})
+	}
+}
+
+func TestGetImageContentRange(t *testing.T) {
+	t.Parallel()
+	scraper := newMockImageScraper(t)
+	scraper.images["img-1"] = clients.ImageInfo{ID: "img-1"}
+	scraper.files["img-1"] = testPNG
+
+	h := scraper.handler()
+	h.SetImageCache(imagecache.NewMemory(1<<20), 0)
+
+	getRange := func(rangeHeader string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/images/img-1/content", nil)
+		req.Header.Set("Range", rangeHeader)
+		w := httptest.NewRecorder()
+		serveRoute(h, w, req)
+		return w
+	}
+
+	// The first range is a cache miss, the second is served from the cache
+	for _, wantCache := range []string{"MISS", "HIT"} {
+		w := getRange("bytes=0-7")
+		if w.Code != http.StatusPartialContent {
+			t.Fatalf("expected status 206, got %d", w.Code)
+		}
+		if !bytes.Equal(w.Body.Bytes(), testPNG[:8]) {
+			t.Errorf("expected the first 8 bytes, got %x", w.Body.Bytes())
+		}
+		if got, want := w.Header().Get("Content-Range"), fmt.Sprintf("bytes 0-7/%d", len(testPNG)); got != want {
+			t.Errorf("expected Content-Range %q, got %q", want, got)
+		}
+		if got := w.Header().Get("X-Cache"); got != wantCache {
+			t.Errorf("expected X-Cache %s, got %s", wantCache, got)
+		}
+		if ct := w.Header().Get("Content-Type"); ct != "image/png" {
+			t.Errorf("expected image/png, got %s", ct)
+		}
+	}
+
+	w := getRange(fmt.Sprintf("bytes=%d-", len(testPNG)-4))
+	if w.Code != http.StatusPartialContent || !bytes.Equal(w.Body.Bytes(), testPNG[len(testPNG)-4:]) {
+		t.Errorf("expected the last 4 bytes with 206, got %d: %x", w.Code, w.Body.Bytes())
+	}
+
+	if w := getRange("bytes=5000-6000"); w.Code != http.StatusRequestedRangeNotSatisfiable {
+		t.Errorf("expected status 416 for a range past the end, got %d", w.Code)
+	}
+}
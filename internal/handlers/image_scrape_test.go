@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httputil"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/docutag/controller/internal/clients"
+)
+
+// imageScraperServer wraps mockScraperServer, counting /api/score calls and reporting an
+// image Content-Type for URLs under /cdn/, which carry no file extension
+func imageScraperServer(t *testing.T, scoreCalls *int32) *httptest.Server {
+	t.Helper()
+	inner := mockScraperServer()
+	t.Cleanup(inner.Close)
+	target, _ := url.Parse(inner.URL)
+	proxy := httputil.NewSingleHostReverseProxy(target)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/score" {
+			atomic.AddInt32(scoreCalls, 1)
+		}
+		if r.URL.Path == "/api/scrape" {
+			body, _ := io.ReadAll(r.Body)
+			r.Body = io.NopCloser(bytes.NewReader(body))
+			var req clients.ScraperRequest
+			if err := json.Unmarshal(body, &req); err == nil && strings.Contains(req.URL, "/cdn/") {
+				w.Header().Set("Content-Type", "application/json")
+				json.NewEncoder(w).Encode(clients.ScraperResponse{
+					ID:       "scraper-image-uuid",
+					URL:      req.URL,
+					Metadata: map[string]interface{}{"content_type": "image/jpeg; charset=binary"},
+				})
+				return
+			}
+		}
+		proxy.ServeHTTP(w, r)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestScrapeURLRecognisesImages(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name           string
+		url            string
+		wantScoreCalls int32
+	}{
+		{"by extension", "https://example.com/photo.jpg", 0},
+		{"by content type", "https://example.com/cdn/a1b2c3", 1},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			handler, _, _, cleanup := setupTestHandler(t)
+			defer cleanup()
+			var scoreCalls int32
+			handler.scraper = clients.NewScraperClient(imageScraperServer(t, &scoreCalls).URL)
+
+			jsonData, _ := json.Marshal(ScrapeURLRequest{URL: tt.url})
+			req := httptest.NewRequest(http.MethodPost, "/api/scrape", bytes.NewBuffer(jsonData))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			serveRoute(handler, w, req)
+
+			if w.Code != http.StatusCreated {
+				t.Fatalf("Expected status 201, got %d: %s", w.Code, w.Body.String())
+			}
+			var response ControllerResponse
+			if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+
+			if got := atomic.LoadInt32(&scoreCalls); got != tt.wantScoreCalls {
+				t.Errorf("Expected %d score calls, got %d", tt.wantScoreCalls, got)
+			}
+			if response.TextAnalyzerUUID != "" {
+				t.Errorf("Expected text analysis to be skipped, got analyzer UUID %q", response.TextAnalyzerUUID)
+			}
+			if len(response.Tags) == 0 || response.Tags[0] != clients.ImageTag {
+				t.Errorf("Expected the image tag first, got %v", response.Tags)
+			}
+			hasDomain := false
+			for _, tag := range response.Tags {
+				hasDomain = hasDomain || tag == "example.com"
+			}
+			if !hasDomain {
+				t.Errorf("Expected the domain tag, got %v", response.Tags)
+			}
+			if _, ok := response.Metadata["threshold"]; ok {
+				t.Errorf("Expected no threshold in metadata for an image, got %v", response.Metadata["threshold"])
+			}
+		})
+	}
+}
@@ -0,0 +1,159 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/docutag/controller/internal/clients"
+	"github.com/docutag/controller/internal/seo"
+	"github.com/docutag/controller/internal/storage"
+)
+
+const (
+	// sitemapMaxDocuments is the most documents the sitemaps list
+	sitemapMaxDocuments = 1000
+	// sitemapMaxImagesPerPage is the most images listed for one page, the limit search engines accept
+	sitemapMaxImagesPerPage = 1000
+	// imageSitemapFetchers is how many documents' images are requested from the scraper at once
+	imageSitemapFetchers = 8
+	// imageSitemapTTL is how long a built image sitemap is reused before the scraper is asked again
+	imageSitemapTTL = 15 * time.Minute
+)
+
+// imageSitemapEntries lists the live images of the public documents in requests. Documents
+// without a slug, with SEO disabled or that are tombstoned are skipped, as are images whose
+// tombstone time has passed. Each image is captioned with its alt text (or summary) and titled
+// with its document's title. complete is false when the scraper failed for some document.
+func (h *Handler) imageSitemapEntries(ctx context.Context, requests []*storage.Request, now time.Time) (entries []seo.ImageSitemapEntry, complete bool) {
+	var docs []*storage.Request
+	for _, req := range requests {
+		if req.Slug == nil || *req.Slug == "" || !req.SEOEnabled || req.ScraperUUID == nil || *req.ScraperUUID == "" {
+			continue
+		}
+		if requestTombstoned(req, now) {
+			continue
+		}
+		docs = append(docs, req)
+	}
+
+	// Fetch concurrently but keep each document's images in its slot so the output is stable
+	perDoc := make([][]seo.ImageSitemapEntry, len(docs))
+	failed := make([]bool, len(docs))
+	sem := make(chan struct{}, imageSitemapFetchers)
+	var wg sync.WaitGroup
+	for i, doc := range docs {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, doc *storage.Request) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			perDoc[i], failed[i] = h.documentSitemapImages(ctx, doc, now)
+		}(i, doc)
+	}
+	wg.Wait()
+
+	complete = true
+	for i := range docs {
+		entries = append(entries, perDoc[i]...)
+		complete = complete && !failed[i]
+	}
+	return entries, complete
+}
+
+// documentSitemapImages returns the sitemap entries of one document's live images
+func (h *Handler) documentSitemapImages(ctx context.Context, doc *storage.Request, now time.Time) ([]seo.ImageSitemapEntry, bool) {
+	ctx, cancel := context.WithTimeout(ctx, requestImagesTimeout)
+	defer cancel()
+	resp, err := h.scraper.GetImagesByScrapeID(ctx, *doc.ScraperUUID, clients.ImageListOptions{
+		Limit:             sitemapMaxImagesPerPage,
+		IncludeTombstoned: true, // Filtered below by tombstone time, so images tombstoned for later stay listed until then
+	})
+	if err != nil {
+		slog.Warn("failed to list images for image sitemap", "request_id", doc.ID, "scraper_uuid", *doc.ScraperUUID, "error", err)
+		return nil, true
+	}
+
+	scraperMeta, _ := doc.Metadata["scraper_metadata"].(map[string]interface{})
+	title := getString(scraperMeta, "title", "")
+
+	var entries []seo.ImageSitemapEntry
+	for _, image := range resp.Images {
+		if image.TombstoneDatetime != nil && !image.TombstoneDatetime.After(now) {
+			continue
+		}
+		loc := image.URL
+		if image.Slug != "" {
+			loc = fmt.Sprintf("%s/images/%s", h.scraperBaseURL, image.Slug)
+		}
+		if loc == "" {
+			continue
+		}
+		caption := image.AltText
+		if caption == "" {
+			caption = image.Summary
+		}
+		entries = append(entries, seo.ImageSitemapEntry{
+			Slug:     image.Slug,
+			PageSlug: *doc.Slug,
+			ImageURL: loc,
+			Caption:  caption,
+			Title:    title,
+		})
+	}
+	return entries, false
+}
+
+// requestTombstoned reports whether a request's tombstone time has passed
+func requestTombstoned(req *storage.Request, now time.Time) bool {
+	at, ok := req.Metadata["tombstone_datetime"].(string)
+	if !ok || at == "" {
+		return false
+	}
+	t, err := time.Parse(time.RFC3339, at)
+	return err == nil && !t.After(now)
+}
+
+// imageSitemapCache keeps the last complete image sitemap entries so crawler traffic does not
+// ask the scraper about every public document on each fetch
+type imageSitemapCache struct {
+	ttl time.Duration
+	now func() time.Time
+
+	mu      sync.Mutex
+	entries []seo.ImageSitemapEntry
+	expires time.Time
+}
+
+func newImageSitemapCache(ttl time.Duration) *imageSitemapCache {
+	return &imageSitemapCache{ttl: ttl, now: time.Now}
+}
+
+// get returns the cached entries, calling load when they are missing or expired. Only complete
+// results are cached, so a scraper outage is retried on the next fetch. A nil cache always loads.
+func (c *imageSitemapCache) get(load func() ([]seo.ImageSitemapEntry, bool, error)) ([]seo.ImageSitemapEntry, error) {
+	if c == nil {
+		entries, _, err := load()
+		return entries, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.entries != nil && c.now().Before(c.expires) {
+		return c.entries, nil
+	}
+
+	entries, complete, err := load()
+	if err != nil {
+		return nil, err
+	}
+	if complete {
+		if entries == nil {
+			entries = []seo.ImageSitemapEntry{}
+		}
+		c.entries, c.expires = entries, c.now().Add(c.ttl)
+	}
+	return entries, nil
+}
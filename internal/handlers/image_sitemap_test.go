@@ -0,0 +1,213 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/docutag/controller/internal/clients"
+	"github.com/docutag/controller/internal/seo"
+	"github.com/docutag/controller/internal/storage"
+)
+
+// sitemapScraperServer serves /api/scrapes/{id}/images for scrape "scrape-a" with a mix of live
+// images, an image tombstoned in the past and one scheduled for tombstoning later. Other scrapes
+// have no images, and "scrape-broken" fails.
+func sitemapScraperServer(t *testing.T, now time.Time, calls *int32) *httptest.Server {
+	t.Helper()
+	past, future := now.Add(-time.Hour), now.Add(time.Hour)
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/scrapes/{id}/images", func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(calls, 1)
+		var images []*clients.ImageInfo
+		switch r.PathValue("id") {
+		case "scrape-a":
+			images = []*clients.ImageInfo{
+				{ID: "img-live", URL: "https://example.com/live.jpg", AltText: "A live chart", Slug: "live-chart"},
+				{ID: "img-summary", URL: "https://example.com/summary.jpg", Summary: "A photo of a cat"},
+				{ID: "img-gone", URL: "https://example.com/gone.jpg", AltText: "Removed", Slug: "gone", TombstoneDatetime: &past},
+				{ID: "img-later", URL: "https://example.com/later.jpg", AltText: "Going soon", Slug: "later", TombstoneDatetime: &future},
+			}
+		case "scrape-broken":
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		if r.URL.Query().Get("include_tombstoned") != "true" {
+			t.Errorf("Expected the sitemap to ask for tombstoned images, got %s", r.URL.RawQuery)
+		}
+		json.NewEncoder(w).Encode(clients.ImageSearchResponse{Images: images, Count: len(images), Total: len(images)})
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+// sitemapRequest builds a public document for the image sitemap tests
+func sitemapRequest(id, slug, scrapeID, title string, seoEnabled bool) *storage.Request {
+	req := &storage.Request{
+		ID:         id,
+		SEOEnabled: seoEnabled,
+		Metadata:   map[string]interface{}{"scraper_metadata": map[string]interface{}{"title": title}},
+	}
+	if slug != "" {
+		req.Slug = &slug
+	}
+	if scrapeID != "" {
+		req.ScraperUUID = &scrapeID
+	}
+	return req
+}
+
+func TestImageSitemapEntries(t *testing.T) {
+	t.Parallel()
+	now := time.Now()
+	var calls int32
+	scraper := sitemapScraperServer(t, now, &calls)
+	h := &Handler{scraper: clients.NewScraperClient(scraper.URL), scraperBaseURL: "https://scraper.example.com"}
+
+	tombstoned := sitemapRequest("tombstoned", "old-article", "scrape-a", "Old", true)
+	tombstoned.Metadata["tombstone_datetime"] = now.Add(-time.Minute).Format(time.RFC3339)
+	scheduled := sitemapRequest("scheduled", "fading-article", "scrape-empty", "Fading", true)
+	scheduled.Metadata["tombstone_datetime"] = now.Add(24 * time.Hour).Format(time.RFC3339)
+
+	requests := []*storage.Request{
+		sitemapRequest("live", "article", "scrape-a", "An Article", true),
+		sitemapRequest("seo-off", "private", "scrape-a", "Private", false),
+		sitemapRequest("no-slug", "", "scrape-a", "No Slug", true),
+		sitemapRequest("text", "text-only", "", "Text", true),
+		tombstoned,
+		scheduled,
+	}
+
+	entries, complete := h.imageSitemapEntries(context.Background(), requests, now)
+	if !complete {
+		t.Error("Expected a complete result")
+	}
+	// Only the live and scheduled documents are asked about
+	if n := atomic.LoadInt32(&calls); n != 2 {
+		t.Errorf("Expected 2 scraper calls, got %d", n)
+	}
+
+	want := []seo.ImageSitemapEntry{
+		{Slug: "live-chart", PageSlug: "article", ImageURL: "https://scraper.example.com/images/live-chart", Caption: "A live chart", Title: "An Article"},
+		{PageSlug: "article", ImageURL: "https://example.com/summary.jpg", Caption: "A photo of a cat", Title: "An Article"},
+		{Slug: "later", PageSlug: "article", ImageURL: "https://scraper.example.com/images/later", Caption: "Going soon", Title: "An Article"},
+	}
+	if len(entries) != len(want) {
+		t.Fatalf("Expected %d entries, got %d: %+v", len(want), len(entries), entries)
+	}
+	for i := range want {
+		if entries[i] != want[i] {
+			t.Errorf("Entry %d: expected %+v, got %+v", i, want[i], entries[i])
+		}
+	}
+}
+
+func TestImageSitemapEntriesScraperFailure(t *testing.T) {
+	t.Parallel()
+	now := time.Now()
+	var calls int32
+	scraper := sitemapScraperServer(t, now, &calls)
+	h := &Handler{scraper: clients.NewScraperClient(scraper.URL)}
+
+	entries, complete := h.imageSitemapEntries(context.Background(), []*storage.Request{
+		sitemapRequest("live", "article", "scrape-a", "An Article", true),
+		sitemapRequest("broken", "broken-article", "scrape-broken", "Broken", true),
+	}, now)
+	if complete {
+		t.Error("Expected the result to be marked incomplete")
+	}
+	// The documents the scraper answered for are still listed
+	if len(entries) != 3 {
+		t.Errorf("Expected the 3 live images of the working document, got %d", len(entries))
+	}
+}
+
+func TestImageSitemapCache(t *testing.T) {
+	t.Parallel()
+	now := time.Now()
+	c := newImageSitemapCache(time.Minute)
+	c.now = func() time.Time { return now }
+
+	loads := 0
+	complete := false
+	load := func() ([]seo.ImageSitemapEntry, bool, error) {
+		loads++
+		return []seo.ImageSitemapEntry{{Slug: "img"}}, complete, nil
+	}
+
+	// Incomplete results are served but not cached
+	c.get(load)
+	c.get(load)
+	if loads != 2 {
+		t.Fatalf("Expected incomplete results to be rebuilt, got %d loads", loads)
+	}
+
+	complete = true
+	c.get(load)
+	if entries, _ := c.get(load); loads != 3 || len(entries) != 1 {
+		t.Errorf("Expected the complete result to be cached, got %d loads", loads)
+	}
+
+	now = now.Add(time.Minute)
+	c.get(load)
+	if loads != 4 {
+		t.Errorf("Expected the cache to expire after the TTL, got %d loads", loads)
+	}
+
+	failing := func() ([]seo.ImageSitemapEntry, bool, error) { return nil, false, errors.New("db down") }
+	now = now.Add(time.Minute)
+	if _, err := c.get(failing); err == nil {
+		t.Error("Expected the load error to be returned")
+	}
+}
+
+func TestServeImageSitemap(t *testing.T) {
+	t.Parallel()
+	handler, _, _, cleanup := setupTestHandler(t)
+	defer cleanup()
+	var calls int32
+	scraper := sitemapScraperServer(t, time.Now(), &calls)
+	handler.scraper = clients.NewScraperClient(scraper.URL)
+	handler.scraperBaseURL = "https://scraper.example.com"
+
+	slug, scrapeID := "image-article", "scrape-a"
+	if err := handler.storage.SaveRequest(&storage.Request{
+		ID: "image-article", CreatedAt: time.Now(), SourceType: "url", Slug: &slug, ScraperUUID: &scrapeID, SEOEnabled: true,
+		Metadata: map[string]interface{}{"scraper_metadata": map[string]interface{}{"title": "Charts & Cats"}},
+	}); err != nil {
+		t.Fatalf("Failed to save request: %v", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		w := httptest.NewRecorder()
+		serveRoute(handler, w, httptest.NewRequest(http.MethodGet, "/images-sitemap.xml", nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", w.Code)
+		}
+		body := w.Body.String()
+		for _, want := range []string{
+			"/content/image-article</loc>",
+			"<image:loc>https://scraper.example.com/images/live-chart</image:loc>",
+			"<image:caption>A live chart</image:caption>",
+			"<image:title>Charts &amp; Cats</image:title>",
+		} {
+			if !strings.Contains(body, want) {
+				t.Errorf("Expected image sitemap to contain %q:\n%s", want, body)
+			}
+		}
+		if strings.Contains(body, "images/gone") {
+			t.Error("Expected the tombstoned image to be left out")
+		}
+	}
+	// The second fetch is served from the cached snapshot
+	if n := atomic.LoadInt32(&calls); n != 1 {
+		t.Errorf("Expected 1 scraper call, got %d", n)
+	}
+}
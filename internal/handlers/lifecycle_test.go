@@ -0,0 +1,35 @@
+package handlers
+
+import (
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestCloseStopsBackgroundGoroutines(t *testing.T) {
+	// Not parallel: counts the goroutines of the whole test binary
+	before := runtime.NumGoroutine()
+	for i := 0; i < 10; i++ {
+		h := NewWithMetrics(nil, nil, nil, nil, nil, nil, 0.5, "", "", 30, 90, newTestBusinessMetrics(), prometheus.NewRegistry())
+		h.Close()
+		h.Close()
+	}
+
+	// Goroutines that were just told to stop may need a moment to be reaped
+	deadline := time.Now().Add(2 * time.Second)
+	for runtime.NumGoroutine() > before && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if after := runtime.NumGoroutine(); after > before {
+		t.Errorf("Expected closed handlers to leave no goroutines behind, went from %d to %d", before, after)
+	}
+}
+
+func TestUpdateMetricsWithoutStorage(t *testing.T) {
+	t.Parallel()
+	h := &Handler{}
+	h.updateMetrics() // Logs instead of panicking
+	h.Close()
+}
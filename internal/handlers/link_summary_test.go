@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/docutag/controller/internal/clients"
+	"github.com/docutag/controller/internal/queue"
+	"github.com/docutag/controller/internal/storage"
+	"github.com/hibiken/asynq"
+)
+
+func TestWorkerLinkExtractionSummary(t *testing.T) {
+	t.Parallel()
+	connStr, dbCleanup := setupTestDB(t, "link_summary_worker")
+	defer dbCleanup()
+
+	store, err := storage.New(connStr, []string{"low-quality", "sparse-content"}, 30, 90, 90)
+	if err != nil {
+		t.Fatalf("Failed to create storage: %v", err)
+	}
+	defer store.Close()
+
+	// One link for every reason a link can be dropped, plus two that fit the budget
+	links := []string{
+		"https://example.com/",                    // duplicate: the source page itself
+		"mailto:editor@example.com",               // unscrapable
+		"https://example.com/photo.jpg",           // unscrapable
+		"http://127.0.0.1/admin",                  // private_target
+		"https://ads.example.com/banner",          // domain_denied
+		"https://other.org/page",                  // domain_not_allowed
+		"https://example.com/a",                   // enqueued
+		"https://example.com/a?utm_source=digest", // duplicate after normalization
+		"https://example.com/b",                   // enqueued
+		"https://example.com/c",                   // budget_exhausted
+	}
+	scraperMock := mockScraperServer()
+	defer scraperMock.Close()
+	scraper := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/extract-links" {
+			json.NewEncoder(w).Encode(clients.ExtractLinksResponse{URL: "https://example.com/", Links: links, Count: len(links)})
+			return
+		}
+		scraperMock.Config.Handler.ServeHTTP(w, r)
+	}))
+	defer scraper.Close()
+	textAnalyzerMock := mockTextAnalyzerServer()
+	defer textAnalyzerMock.Close()
+
+	worker := queue.NewWorker(queue.WorkerConfig{
+		RedisAddr:          "localhost:6379",
+		Concurrency:        1,
+		LinkScoreThreshold: 0.5,
+		MaxLinkDepth:       2,
+		DomainAllowlist:    []string{"example.com", "*.example.com"},
+		DomainDenylist:     []string{"ads.example.com"},
+	}, store, clients.NewScraperClient(scraper.URL), clients.NewTextAnalyzerClient(textAnalyzerMock.URL), nil, nil, nil, nil, nil)
+
+	now := time.Now()
+	root := &storage.ScrapeJob{ID: "summary-root", URL: "https://example.com/", ExtractLinks: true, MaxPages: 2, Status: "completed", CreatedAt: now, UpdatedAt: now}
+	if err := store.SaveScrapeJob(root); err != nil {
+		t.Fatalf("Failed to save job: %v", err)
+	}
+
+	payload, _ := json.Marshal(queue.ExtractLinksTaskPayload{ParentJobID: root.ID, SourceURL: root.URL})
+	if err := worker.ProcessTask(context.Background(), asynq.NewTask(queue.TypeExtractLinks, payload)); err != nil {
+		t.Fatalf("extract links task failed: %v", err)
+	}
+
+	job, err := store.GetScrapeJob(root.ID)
+	if err != nil {
+		t.Fatalf("Failed to get job: %v", err)
+	}
+	summary := job.LinkSummary
+	if summary == nil {
+		t.Fatal("expected a link extraction summary on the parent job")
+	}
+	if summary.Found != len(links) || summary.Enqueued != 2 || summary.Failed != 0 {
+		t.Errorf("expected found %d, enqueued 2, failed 0; got %+v", len(links), summary)
+	}
+	want := map[string]int{
+		"duplicate":          2,
+		"unscrapable":        2,
+		"private_target":     1,
+		"domain_denied":      1,
+		"domain_not_allowed": 1,
+		"budget_exhausted":   1,
+	}
+	for reason, count := range want {
+		if got := summary.Skipped[reason]; got != count {
+			t.Errorf("skipped %s: expected %d, got %d", reason, count, got)
+		}
+	}
+	if len(summary.Skipped) != len(want) {
+		t.Errorf("unexpected skip reasons: %v", summary.Skipped)
+	}
+
+	// Scrape request responses serialize the job as is, so the summary reaches the API
+	data, _ := json.Marshal(job)
+	var body map[string]interface{}
+	if err := json.Unmarshal(data, &body); err != nil {
+		t.Fatalf("Failed to decode job: %v", err)
+	}
+	if s, ok := body["link_extraction_summary"].(map[string]interface{}); !ok || s["found"] != float64(len(links)) {
+		t.Errorf("expected link_extraction_summary in the job JSON, got %v", body["link_extraction_summary"])
+	}
+}
@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+
+	"github.com/docutag/controller/internal/storage"
+	"github.com/docutag/controller/pkg/logging"
+)
+
+// logLevelEntityID is the audit entity ID for log level changes
+const logLevelEntityID = "log_level"
+
+// LogLevelRequest changes the minimum level of emitted log records
+type LogLevelRequest struct {
+	Level string `json:"level"` // debug, info, warn or error
+}
+
+// LogLevelResponse reports the current log level
+type LogLevelResponse struct {
+	Level string `json:"level"`
+}
+
+// SetLogLevel lets the admin API adjust the level shared with the process logger
+func (h *Handler) SetLogLevel(level *slog.LevelVar) {
+	h.logLevel = level
+}
+
+// GetLogLevel handles GET /api/admin/log-level
+func (h *Handler) GetLogLevel(w http.ResponseWriter, r *http.Request) {
+	if h.logLevel == nil {
+		respondErrorCode(w, ErrCodeNotConfigured, "log level is not adjustable", http.StatusServiceUnavailable)
+		return
+	}
+	respondJSON(w, LogLevelResponse{Level: logging.LevelName(h.logLevel.Level())}, http.StatusOK)
+}
+
+// UpdateLogLevel handles PUT /api/admin/log-level. The change lasts until the next restart
+// or SIGHUP, which both apply LOG_LEVEL from configuration again.
+func (h *Handler) UpdateLogLevel(w http.ResponseWriter, r *http.Request) {
+	if h.logLevel == nil {
+		respondErrorCode(w, ErrCodeNotConfigured, "log level is not adjustable", http.StatusServiceUnavailable)
+		return
+	}
+
+	var req LogLevelRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondErrorCode(w, ErrCodeInvalidRequestBody, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	level, err := logging.ParseLevel(req.Level)
+	if err != nil {
+		respondErrorCode(w, ErrCodeValidationFailed, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	old := h.logLevel.Level()
+	h.logLevel.Set(level)
+	slog.Info("log level changed", "old", logging.LevelName(old), "new", logging.LevelName(level))
+
+	if old != level {
+		h.recordAudit(r, storage.AuditActionUpdateSettings, storage.AuditEntitySettings, logLevelEntityID, map[string]interface{}{
+			"changes": map[string]interface{}{
+				"log_level": map[string]interface{}{"old": logging.LevelName(old), "new": logging.LevelName(level)},
+			},
+		})
+	}
+
+	respondJSON(w, LogLevelResponse{Level: logging.LevelName(level)}, http.StatusOK)
+}
@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/docutag/controller/internal/storage"
+)
+
+func TestGetLogLevel(t *testing.T) {
+	t.Parallel()
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/admin/log-level", nil)
+	w := httptest.NewRecorder()
+	serveRoute(&Handler{}, w, req)
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected 503 without a level, got %d", w.Code)
+	}
+
+	level := new(slog.LevelVar)
+	level.Set(slog.LevelWarn)
+	h := &Handler{logLevel: level}
+	w = httptest.NewRecorder()
+	serveRoute(h, w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp LogLevelResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if resp.Level != "warn" {
+		t.Errorf("expected warn, got %q", resp.Level)
+	}
+}
+
+func TestUpdateLogLevelValidation(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name string
+		body string
+	}{
+		{"invalid json", `{"level":`},
+		{"missing level", `{}`},
+		{"unknown level", `{"level": "verbose"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			level := new(slog.LevelVar)
+			h := &Handler{logLevel: level}
+			req := httptest.NewRequest(http.MethodPut, "/api/v1/admin/log-level", strings.NewReader(tt.body))
+			w := httptest.NewRecorder()
+
+			serveRoute(h, w, req)
+
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("expected 400, got %d: %s", w.Code, w.Body.String())
+			}
+			if level.Level() != slog.LevelInfo {
+				t.Errorf("expected level unchanged, got %v", level.Level())
+			}
+		})
+	}
+}
+
+func TestUpdateLogLevel(t *testing.T) {
+	t.Parallel()
+	connStr, dbCleanup := setupTestDB(t, "log_level")
+	defer dbCleanup()
+
+	store, err := storage.New(connStr, []string{"low-quality", "sparse-content"}, 30, 90, 90)
+	if err != nil {
+		t.Fatalf("Failed to create storage: %v", err)
+	}
+	defer store.Close()
+
+	level := new(slog.LevelVar)
+	h := &Handler{storage: store, logLevel: level}
+	req := httptest.NewRequest(http.MethodPut, "/api/v1/admin/log-level", strings.NewReader(`{"level": "DEBUG"}`))
+	w := httptest.NewRecorder()
+
+	serveRoute(h, w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if level.Level() != slog.LevelDebug {
+		t.Errorf("expected debug level, got %v", level.Level())
+	}
+
+	entries, err := store.ListAuditEntries(storage.AuditFilter{EntityID: logLevelEntityID, Limit: 10})
+	if err != nil {
+		t.Fatalf("Failed to list audit entries: %v", err)
+	}
+	if len(entries) != 1 || entries[0].Action != storage.AuditActionUpdateSettings {
+		t.Errorf("expected one update_settings audit entry, got %+v", entries)
+	}
+}
@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/docutag/controller/internal/promreg"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// handlerMetrics are the collectors a Handler records into. Each handler registers them with
+// the registerer it was created with, so tests can give every handler a registry of its own.
+type handlerMetrics struct {
+	// API requests by version, so unversioned traffic can be watched until it drops to zero
+	// and the alias can be removed
+	apiRequests *prometheus.CounterVec
+	// Submissions refused because the queue was saturated
+	scrapeRequestsRejected *prometheus.CounterVec
+	// Image tombstones and deletions, single and bulk, by result
+	imageOperations *prometheus.CounterVec
+	// Image tombstones and untombstones made because their request was tombstoned or
+	// untombstoned with cascade_images, kept apart from direct image actions
+	cascadedImageOperations *prometheus.CounterVec
+	// Visible URL documents per domain
+	documentsByDomain *prometheus.GaugeVec
+	// Recent failed and below-threshold scrapes per domain. Counters keep no domain label, so
+	// this bounded gauge shows which domains fail or get tombstoned most.
+	scrapeOutcomesByDomain *prometheus.GaugeVec
+	// Re-scrapes queued by freshness passes, in all and by the most recent pass
+	staleRescrapesEnqueued       *prometheus.CounterVec
+	staleRescrapeLastRunEnqueued prometheus.Gauge
+}
+
+// newHandlerMetrics registers the handler metrics with reg, reusing collectors it already has.
+// The metrics are usable even when registering fails; they just aren't exported.
+func newHandlerMetrics(reg prometheus.Registerer) (*handlerMetrics, error) {
+	m := unregisteredHandlerMetrics()
+	var errs [8]error
+	m.apiRequests, errs[0] = promreg.RegisterOrReuse(reg, m.apiRequests)
+	m.scrapeRequestsRejected, errs[1] = promreg.RegisterOrReuse(reg, m.scrapeRequestsRejected)
+	m.imageOperations, errs[2] = promreg.RegisterOrReuse(reg, m.imageOperations)
+	m.cascadedImageOperations, errs[3] = promreg.RegisterOrReuse(reg, m.cascadedImageOperations)
+	m.documentsByDomain, errs[4] = promreg.RegisterOrReuse(reg, m.documentsByDomain)
+	m.scrapeOutcomesByDomain, errs[5] = promreg.RegisterOrReuse(reg, m.scrapeOutcomesByDomain)
+	m.staleRescrapesEnqueued, errs[6] = promreg.RegisterOrReuse(reg, m.staleRescrapesEnqueued)
+	m.staleRescrapeLastRunEnqueued, errs[7] = promreg.RegisterOrReuse(reg, m.staleRescrapeLastRunEnqueued)
+	return m, errors.Join(errs[:]...)
+}
+
+// unregisteredHandlerMetrics returns handler metrics that no registry exposes
+func unregisteredHandlerMetrics() *handlerMetrics {
+	return &handlerMetrics{
+		apiRequests: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "controller_api_requests_total",
+				Help: "API requests by API version and method",
+			},
+			[]string{"api_version", "method"},
+		),
+		scrapeRequestsRejected: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "controller_scrape_requests_rejected_total",
+				Help: "Scrape submissions rejected with 503 because too many jobs were queued, by endpoint",
+			},
+			[]string{"endpoint"},
+		),
+		imageOperations: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "controller_image_operations_total",
+				Help: "Images tombstoned or deleted through the controller, by action and result",
+			},
+			[]string{"action", "result"},
+		),
+		cascadedImageOperations: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "controller_cascaded_image_operations_total",
+				Help: "Images tombstoned or untombstoned by cascading from their request, by action and result",
+			},
+			[]string{"action", "result"},
+		),
+		documentsByDomain: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "controller_documents_by_domain",
+				Help: "Visible URL documents for the 20 largest domains, with the rest under \"other\"",
+			},
+			[]string{"domain"},
+		),
+		scrapeOutcomesByDomain: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "controller_scrape_outcomes_by_domain",
+				Help: "Scrapes in the last 24 hours that failed or scored below the threshold, by outcome, for the 20 domains with the most of each, with the rest under \"other\"",
+			},
+			[]string{"outcome", "domain"},
+		),
+		staleRescrapesEnqueued: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "controller_stale_rescrapes_enqueued_total",
+				Help: "Re-scrapes of stale requests queued by freshness passes, by trigger (schedule or manual)",
+			},
+			[]string{"trigger"},
+		),
+		staleRescrapeLastRunEnqueued: prometheus.NewGauge(
+			prometheus.GaugeOpts{
+				Name: "controller_stale_rescrape_last_run_enqueued",
+				Help: "Re-scrapes queued by the most recent freshness pass",
+			},
+		),
+	}
+}
+
+// discardedMetrics stand in for a Handler built without NewWithMetrics
+var discardedMetrics = unregisteredHandlerMetrics()
+
+// collectors returns the handler's metrics
+func (h *Handler) collectors() *handlerMetrics {
+	if h.metrics == nil {
+		return discardedMetrics
+	}
+	return h.metrics
+}
@@ -0,0 +1,43 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestHandlerMetricsRegisterWithRegisterer(t *testing.T) {
+	t.Parallel()
+	reg := prometheus.NewRegistry()
+	first := NewWithMetrics(nil, nil, nil, nil, nil, nil, 0.5, "", "", 30, 90, newTestBusinessMetrics(), reg)
+	defer first.Close()
+	// A second handler on the same registry shares the series instead of failing to register
+	second := NewWithMetrics(nil, nil, nil, nil, nil, nil, 0.5, "", "", 30, 90, newTestBusinessMetrics(), reg)
+	defer second.Close()
+
+	for _, h := range []*Handler{first, second} {
+		h.withAPIVersion(APIVersionV1, func(w http.ResponseWriter, r *http.Request) {}).
+			ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/v1/requests", nil))
+	}
+	if got := testutil.ToFloat64(first.metrics.apiRequests.WithLabelValues(APIVersionV1, http.MethodGet)); got != 2 {
+		t.Errorf("expected both handlers to count into one series, got %v", got)
+	}
+
+	families, err := reg.Gather()
+	if err != nil {
+		t.Fatalf("Failed to gather: %v", err)
+	}
+	found := false
+	for _, family := range families {
+		found = found || family.GetName() == "controller_api_requests_total"
+	}
+	if !found {
+		t.Error("expected controller_api_requests_total in the handler's registry")
+	}
+	if got, err := testutil.GatherAndCount(prometheus.DefaultGatherer, "controller_api_requests_total"); err != nil || got != 0 {
+		t.Errorf("expected nothing in the default registry, got %d series (err %v)", got, err)
+	}
+}
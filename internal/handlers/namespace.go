@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/docutag/controller/internal/queue"
+	"github.com/docutag/controller/internal/storage"
+)
+
+// NamespaceHeader selects the namespace of an API call made without a mapped API key
+const NamespaceHeader = "X-Namespace"
+
+// SetNamespaces configures tenancy. apiKeys maps API keys to the only namespace they may
+// act in; callers with any other key, or none, pick a namespace with the X-Namespace
+// header. publicNamespace is the namespace the SEO pages and sitemap serve ("" = default).
+func (h *Handler) SetNamespaces(apiKeys map[string]string, publicNamespace string) {
+	h.namespaceKeys = apiKeys
+	h.publicNamespace = publicNamespace
+}
+
+// resolveNamespace returns the namespace of an API call. It writes the error response
+// itself and returns false when the caller asked for a namespace it cannot use.
+func (h *Handler) resolveNamespace(w http.ResponseWriter, r *http.Request) (string, bool) {
+	requested := strings.TrimSpace(r.Header.Get(NamespaceHeader))
+	if requested != "" && !storage.ValidNamespace(requested) {
+		respondErrorCode(w, ErrCodeValidationFailed,
+			fmt.Sprintf("%s must be 1-%d lowercase letters, digits, '-' or '_'", NamespaceHeader, storage.MaxNamespaceLength),
+			http.StatusBadRequest)
+		return "", false
+	}
+
+	if key := requestAPIKey(r); key != "" {
+		if mapped, ok := h.namespaceKeys[key]; ok {
+			if requested != "" && requested != mapped {
+				respondErrorCode(w, ErrCodeNamespaceForbidden,
+					fmt.Sprintf("This API key may not use namespace %q", requested), http.StatusForbidden)
+				return "", false
+			}
+			return mapped, true
+		}
+	}
+
+	if requested != "" {
+		return requested, true
+	}
+	return storage.DefaultNamespace, true
+}
+
+// withNamespace resolves the caller's namespace before next runs. The namespace travels
+// in the request context, where store reads it and queued tasks pick it up.
+func (h *Handler) withNamespace(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		namespace, ok := h.resolveNamespace(w, r)
+		if !ok {
+			return
+		}
+		next(w, r.WithContext(queue.WithNamespace(r.Context(), namespace)))
+	}
+}
+
+// store returns the storage scoped to the namespace of an API call. Every request and
+// scrape job query an API handler makes goes through it.
+func (h *Handler) store(r *http.Request) *storage.Storage {
+	namespace := queue.Namespace(r.Context())
+	if namespace == "" {
+		namespace = storage.DefaultNamespace
+	}
+	return h.storage.WithNamespace(namespace)
+}
+
+// publicStore returns the storage scoped to the namespace the SEO pages serve
+func (h *Handler) publicStore() *storage.Storage {
+	if h.publicNamespace == "" {
+		return h.storage.WithNamespace(storage.DefaultNamespace)
+	}
+	return h.storage.WithNamespace(h.publicNamespace)
+}
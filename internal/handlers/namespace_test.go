@@ -0,0 +1,219 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/docutag/controller/internal/queue"
+	"github.com/docutag/controller/internal/storage"
+	"github.com/docutag/controller/internal/urlguard"
+)
+
+func TestResolveNamespace(t *testing.T) {
+	t.Parallel()
+	h := &Handler{}
+	h.SetNamespaces(map[string]string{"key-a": "team-a"}, "")
+
+	tests := []struct {
+		name       string
+		apiKey     string
+		header     string
+		wantStatus int
+		wantCode   string
+		want       string
+	}{
+		{name: "no key or header", wantStatus: http.StatusOK, want: storage.DefaultNamespace},
+		{name: "header", header: "team-b", wantStatus: http.StatusOK, want: "team-b"},
+		{name: "invalid header", header: "Team B", wantStatus: http.StatusBadRequest, wantCode: ErrCodeValidationFailed},
+		{name: "mapped key", apiKey: "key-a", wantStatus: http.StatusOK, want: "team-a"},
+		{name: "mapped key with its own namespace", apiKey: "key-a", header: "team-a", wantStatus: http.StatusOK, want: "team-a"},
+		{name: "mapped key with another namespace", apiKey: "key-a", header: "team-b", wantStatus: http.StatusForbidden, wantCode: ErrCodeNamespaceForbidden},
+		{name: "unmapped key", apiKey: "key-b", header: "team-b", wantStatus: http.StatusOK, want: "team-b"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got string
+			next := h.withNamespace(func(w http.ResponseWriter, r *http.Request) {
+				got = queue.Namespace(r.Context())
+				w.WriteHeader(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/api/requests", nil)
+			if tt.apiKey != "" {
+				req.Header.Set("X-API-Key", tt.apiKey)
+			}
+			if tt.header != "" {
+				req.Header.Set(NamespaceHeader, tt.header)
+			}
+			w := httptest.NewRecorder()
+			next(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("Expected status %d, got %d. Body: %s", tt.wantStatus, w.Code, w.Body.String())
+			}
+			if tt.wantCode != "" {
+				var resp ErrorResponse
+				if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+					t.Fatalf("Failed to decode error: %v", err)
+				}
+				if resp.Code != tt.wantCode {
+					t.Errorf("Expected error code %s, got %s", tt.wantCode, resp.Code)
+				}
+				return
+			}
+			if got != tt.want {
+				t.Errorf("Expected namespace %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestStoreDefaultsNamespace(t *testing.T) {
+	t.Parallel()
+	h := &Handler{storage: &storage.Storage{}}
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	if ns := h.store(req).Namespace(); ns != storage.DefaultNamespace {
+		t.Errorf("Expected the default namespace without one in the context, got %q", ns)
+	}
+	req = req.WithContext(queue.WithNamespace(req.Context(), "team-a"))
+	if ns := h.store(req).Namespace(); ns != "team-a" {
+		t.Errorf("Expected team-a, got %q", ns)
+	}
+
+	if ns := h.publicStore().Namespace(); ns != storage.DefaultNamespace {
+		t.Errorf("Expected the public namespace to default, got %q", ns)
+	}
+	h.SetNamespaces(nil, "public")
+	if ns := h.publicStore().Namespace(); ns != "public" {
+		t.Errorf("Expected the configured public namespace, got %q", ns)
+	}
+}
+
+func TestNamespaceIsolation(t *testing.T) {
+	t.Parallel()
+	h, _, _, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	now := time.Now().UTC()
+	for _, ns := range []string{"team-a", "team-b"} {
+		slug := "doc-" + ns
+		req := &storage.Request{
+			ID:         "req-" + ns,
+			CreatedAt:  now,
+			SourceType: "text",
+			Tags:       []string{"shared"},
+			SEOEnabled: true,
+			Slug:       &slug,
+			Metadata:   map[string]interface{}{},
+			Namespace:  ns,
+		}
+		if err := h.storage.SaveRequest(req); err != nil {
+			t.Fatalf("Failed to save request: %v", err)
+		}
+	}
+
+	get := func(path, namespace string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set(NamespaceHeader, namespace)
+		w := httptest.NewRecorder()
+		serveRoute(h, w, req)
+		return w
+	}
+
+	if w := get("/api/requests/req-team-b", "team-a"); w.Code != http.StatusNotFound {
+		t.Errorf("Expected another namespace's request to be 404, got %d", w.Code)
+	}
+	if w := get("/api/requests/req-team-a", "team-a"); w.Code != http.StatusOK {
+		t.Errorf("Expected the namespace's own request, got %d. Body: %s", w.Code, w.Body.String())
+	}
+
+	w := get("/api/requests", "team-a")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d. Body: %s", w.Code, w.Body.String())
+	}
+	var listed []ControllerResponse
+	if err := json.NewDecoder(w.Body).Decode(&listed); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(listed) != 1 || listed[0].ID != "req-team-a" {
+		t.Errorf("Expected only team-a's request in the list, got %+v", listed)
+	}
+
+	h.SetNamespaces(nil, "team-b")
+	w = httptest.NewRecorder()
+	serveRoute(h, w, httptest.NewRequest(http.MethodGet, "/sitemap.xml", nil))
+	if body := w.Body.String(); !strings.Contains(body, "doc-team-b") || strings.Contains(body, "doc-team-a") {
+		t.Errorf("Expected the sitemap to list only the public namespace, got %s", body)
+	}
+}
+
+func TestNamespaceIsolationOfWebhooksSearchesAndAudit(t *testing.T) {
+	t.Parallel()
+	h, _, _, cleanup := setupTestHandler(t)
+	defer cleanup()
+	h.SetURLGuard(urlguard.NewWithResolver(false, publicResolver{}))
+
+	if err := h.storage.SaveRequest(&storage.Request{ID: "req-team-b", CreatedAt: time.Now().UTC(), SourceType: "text", Metadata: map[string]interface{}{}, Namespace: "team-b"}); err != nil {
+		t.Fatalf("Failed to save request: %v", err)
+	}
+
+	do := func(method, path, namespace, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set(NamespaceHeader, namespace)
+		w := httptest.NewRecorder()
+		serveRoute(h, w, req)
+		return w
+	}
+	created := func(w *httptest.ResponseRecorder) string {
+		t.Helper()
+		if w.Code != http.StatusCreated {
+			t.Fatalf("Expected status 201, got %d: %s", w.Code, w.Body.String())
+		}
+		var body struct {
+			ID string `json:"id"`
+		}
+		json.NewDecoder(w.Body).Decode(&body)
+		return body.ID
+	}
+
+	hookID := created(do(http.MethodPost, "/api/webhooks", "team-b", `{"url": "https://hooks.example.com/b", "event_types": ["request.deleted"]}`))
+	searchID := created(do(http.MethodPost, "/api/saved-searches", "team-b", `{"name": "mine", "filter": {}}`))
+	if w := do(http.MethodPut, "/api/requests/req-team-b/star", "team-b", ""); w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	for _, path := range []string{"/api/webhooks/" + hookID, "/api/webhooks/" + hookID + "/deliveries", "/api/saved-searches/" + searchID} {
+		if w := do(http.MethodGet, path, "team-a", ""); w.Code != http.StatusNotFound {
+			t.Errorf("GET %s: expected another namespace's record to be 404, got %d", path, w.Code)
+		}
+	}
+	if w := do(http.MethodPut, "/api/webhooks/"+hookID, "team-a", `{"url": "https://hooks.example.com/a", "event_types": ["request.deleted"]}`); w.Code != http.StatusNotFound {
+		t.Errorf("Expected updating another namespace's webhook to be 404, got %d", w.Code)
+	}
+	if w := do(http.MethodPut, "/api/saved-searches/"+searchID, "team-a", `{"name": "taken", "filter": {}}`); w.Code != http.StatusNotFound {
+		t.Errorf("Expected updating another namespace's saved search to be 404, got %d", w.Code)
+	}
+	for _, path := range []string{"/api/webhooks/" + hookID, "/api/saved-searches/" + searchID} {
+		if w := do(http.MethodDelete, path, "team-a", ""); w.Code != http.StatusNotFound {
+			t.Errorf("DELETE %s: expected another namespace's record to be 404, got %d", path, w.Code)
+		}
+	}
+
+	for _, path := range []string{"/api/webhooks", "/api/saved-searches", "/api/audit"} {
+		w := do(http.MethodGet, path, "team-a", "")
+		if w.Code != http.StatusOK {
+			t.Fatalf("GET %s: expected status 200, got %d: %s", path, w.Code, w.Body.String())
+		}
+		if body := w.Body.String(); strings.Contains(body, hookID) || strings.Contains(body, searchID) || strings.Contains(body, "req-team-b") {
+			t.Errorf("GET %s: expected nothing from team-b, got %s", path, body)
+		}
+	}
+	if w := do(http.MethodGet, "/api/audit", "team-b", ""); !strings.Contains(w.Body.String(), "req-team-b") {
+		t.Errorf("Expected team-b to see its own audit entry, got %s", w.Body.String())
+	}
+}
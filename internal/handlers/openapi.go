@@ -0,0 +1,505 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+
+	"github.com/docutag/controller/internal/clients"
+	"github.com/docutag/controller/internal/openapi"
+	"github.com/docutag/controller/internal/settings"
+	"github.com/docutag/controller/internal/storage"
+)
+
+// apiVersion is the version reported in the OpenAPI document's info block
+const apiVersion = "1.0.0"
+
+var (
+	openAPIOnce sync.Once
+	openAPIJSON []byte
+	openAPIErr  error
+)
+
+// OpenAPIDocument describes the /api surface. Schemas are generated from the request
+// and response structs the handlers use, so field changes show up here automatically.
+func OpenAPIDocument() *openapi.Document {
+	b := openapi.NewBuilder(openapi.Info{
+		Title: "DocuTag Controller API",
+		Description: "Scrapes URLs, analyzes text, stores tagged documents and proxies the scheduler. " +
+			"Every path is also served without the /v1 segment as a deprecated alias.",
+		Version: apiVersion,
+	})
+	b.SetErrorBody(ErrorResponse{})
+
+	b.AddTag("processing", "Synchronous scraping, analysis and link tools")
+	b.AddTag("requests", "Stored documents")
+	b.AddTag("images", "Images extracted by the scraper")
+	b.AddTag("scrape-requests", "Asynchronous scrape and analysis jobs")
+	b.AddTag("scheduler", "Proxy to the scheduler service")
+	b.AddTag("webhooks", "Event subscriptions and their delivery logs")
+	b.AddTag("admin", "Operational endpoints")
+
+	message := openapi.Object("Confirmation with a single message field")
+	pagination := []openapi.Param{
+		{Name: "limit", Type: "integer", Description: "Maximum results to return, a positive integer clamped to MAX_PAGE_LIMIT"},
+		{Name: "offset", Type: "integer", Description: "Results to skip, a non-negative integer"},
+	}
+	expandResult := openapi.Param{Name: "expand", Description: "result to summarize the request each completed job created"}
+	dryRun := []openapi.Param{
+		{Name: "dry_run", Type: "boolean", Description: "Report what would be affected without changing anything"},
+	}
+
+	// Processing
+	b.Add(openapi.Op{Method: http.MethodPost, Path: "/api/v1/scrape", ID: "scrapeURL", Tag: "processing",
+		Summary:   "Scrape a URL and analyze its text",
+		Request:   ScrapeURLRequest{},
+		Responses: map[int]openapi.Body{http.StatusCreated: {Description: "Stored request", Value: ControllerResponse{}}}})
+	b.Add(openapi.Op{Method: http.MethodPost, Path: "/api/v1/analyze", ID: "analyzeText", Tag: "processing",
+		Summary:     "Analyze text directly",
+		Description: "Text submitted within TEXT_DEDUP_WINDOW_MINUTES of an identical one, ignoring case and whitespace, returns the existing request with 200 and duplicate: true unless force is set.",
+		Request:     AnalyzeTextRequest{},
+		Responses: map[int]openapi.Body{
+			http.StatusCreated: {Description: "Stored request", Value: ControllerResponse{}},
+			http.StatusOK:      {Description: "Existing request for the same text", Value: DuplicateTextResponse{}},
+		}})
+	b.Add(openapi.Op{Method: http.MethodPost, Path: "/api/v1/score", ID: "scoreLink", Tag: "processing",
+		Summary:   "Score a link's quality",
+		Request:   ScoreLinkRequest{},
+		Responses: map[int]openapi.Body{http.StatusOK: {Description: "Score and threshold", Value: ScoreLinkResponse{}}}})
+	b.Add(openapi.Op{Method: http.MethodPost, Path: "/api/v1/extract-links", ID: "extractLinks", Tag: "processing",
+		Summary:   "Extract links from a page",
+		Request:   ExtractLinksRequest{},
+		Responses: map[int]openapi.Body{http.StatusOK: {Description: "Extracted links", Value: ExtractLinksResponse{}}}})
+	b.Add(openapi.Op{Method: http.MethodPost, Path: "/api/v1/preview", ID: "previewURL", Tag: "processing",
+		Summary:   "Score a URL and read its title and description without scraping it",
+		Request:   PreviewRequest{},
+		Responses: map[int]openapi.Body{http.StatusOK: {Description: "Preview", Value: PreviewResponse{}}}})
+	b.Add(openapi.Op{Method: http.MethodPost, Path: "/api/v1/search", ID: "searchTags", Tag: "requests",
+		Summary:     "Find requests by tags",
+		Description: "Returns the matching request IDs, or with return=full a SearchDocumentsResponse page of the requests themselves.",
+		Query: []openapi.Param{
+			{Name: "return", Description: "ids (default) or full"},
+			{Name: "limit", Type: "integer", Description: "Page size with return=full (default 100)"},
+			{Name: "offset", Type: "integer", Description: "Requests to skip with return=full"},
+		},
+		Request:   SearchTagsRequest{},
+		Responses: map[int]openapi.Body{http.StatusOK: {Description: "Matching request IDs", Value: SearchTagsResponse{}}}})
+	b.Add(openapi.Op{Method: http.MethodGet, Path: "/api/v1/search", ID: "searchTagsGet", Tag: "requests",
+		Summary:     "Find requests by tags, for clients that can only send GETs",
+		Description: "Same search and response as POST /api/v1/search, with the body's fields as query parameters.",
+		Query: []openapi.Param{
+			{Name: "tags", Required: true, Description: "Comma-separated tags"},
+			{Name: "fuzzy", Type: "boolean", Description: "Match tags containing each term"},
+			{Name: "match_all", Type: "boolean", Description: "Require every tag instead of any"},
+			{Name: "return", Description: "ids (default) or full"},
+			{Name: "limit", Type: "integer", Description: "Page size with return=full (default 100)"},
+			{Name: "offset", Type: "integer", Description: "Requests to skip with return=full"},
+		},
+		Responses: map[int]openapi.Body{http.StatusOK: {Description: "Matching request IDs", Value: SearchTagsResponse{}}}})
+	b.Add(openapi.Op{Method: http.MethodGet, Path: "/api/v1/tags/timeline", ID: "getTagTimeline", Tag: "requests",
+		Summary: "Tag frequency over time",
+		Query: []openapi.Param{
+			{Name: "start_date", Description: "RFC3339 or YYYY-MM-DD start of the range", Required: true},
+			{Name: "end_date", Description: "RFC3339 or YYYY-MM-DD end of the range, exclusive; a plain date includes that day", Required: true},
+			{Name: "bucket_size", Description: "Go duration per bucket, e.g. 24h"},
+			{Name: "max_tags", Type: "integer", Description: "Tags per bucket (1-100)"},
+			{Name: "timezone", Description: "IANA time zone for plain dates and bucket timestamps, default UTC"},
+			{Name: "tags", Description: "Comma-separated tags to focus on; only documents carrying one are counted"},
+		},
+		Responses: map[int]openapi.Body{http.StatusOK: {Description: "Timeline buckets", Value: storage.TagTimelineResponse{}}}})
+	b.Add(openapi.Op{Method: http.MethodGet, Path: "/api/v1/stats", ID: "getGlobalStats", Tag: "requests",
+		Summary:   "Corpus-wide totals for the dashboard, cached briefly",
+		Responses: map[int]openapi.Body{http.StatusOK: {Description: "Global statistics", Value: storage.GlobalStats{}}}})
+	b.Add(openapi.Op{Method: http.MethodGet, Path: "/api/v1/graph", ID: "getLinkGraph", Tag: "requests",
+		Summary: "Documents reachable from a request through the links crawls followed",
+		Query: []openapi.Param{
+			{Name: "root", Description: "Request ID to start from", Required: true},
+			{Name: "depth", Type: "integer", Description: "Links to follow from the root, 1-5 (default 2)"},
+			{Name: "max_nodes", Type: "integer", Description: "Largest number of nodes returned, 1-1000 (default 200)"},
+		},
+		Responses: map[int]openapi.Body{http.StatusOK: {Description: "Link graph", Value: LinkGraphResponse{}}}})
+	b.Add(openapi.Op{Method: http.MethodGet, Path: "/api/v1/saved-searches", ID: "listSavedSearches", Tag: "requests",
+		Summary:   "List saved searches by name",
+		Query:     []openapi.Param{{Name: "owner", Description: "Only searches saved by this creator, e.g. apikey:3f2a9c1d8e7b"}},
+		Responses: map[int]openapi.Body{http.StatusOK: {Description: "Saved searches", Value: SavedSearchListResponse{}}}})
+	b.Add(openapi.Op{Method: http.MethodPost, Path: "/api/v1/saved-searches", ID: "createSavedSearch", Tag: "requests",
+		Summary:   "Save a named request filter",
+		Request:   SavedSearchRequest{},
+		Responses: map[int]openapi.Body{http.StatusCreated: {Description: "Saved search", Value: storage.SavedSearch{}}}})
+	b.Add(openapi.Op{Method: http.MethodGet, Path: "/api/v1/saved-searches/{id}", ID: "getSavedSearch", Tag: "requests",
+		Summary:   "Get a saved search",
+		Responses: map[int]openapi.Body{http.StatusOK: {Description: "Saved search", Value: storage.SavedSearch{}}}})
+	b.Add(openapi.Op{Method: http.MethodPut, Path: "/api/v1/saved-searches/{id}", ID: "updateSavedSearch", Tag: "requests",
+		Summary:   "Replace the name and filter of a saved search",
+		Request:   SavedSearchRequest{},
+		Responses: map[int]openapi.Body{http.StatusOK: {Description: "Saved search", Value: storage.SavedSearch{}}}})
+	b.Add(openapi.Op{Method: http.MethodDelete, Path: "/api/v1/saved-searches/{id}", ID: "deleteSavedSearch", Tag: "requests",
+		Summary:   "Delete a saved search",
+		Responses: map[int]openapi.Body{http.StatusOK: {Description: "Deleted", Value: message}}})
+	b.Add(openapi.Op{Method: http.MethodPost, Path: "/api/v1/saved-searches/{id}/run", ID: "runSavedSearch", Tag: "requests",
+		Summary:         "Run a saved search, optionally with a different limit or offset",
+		Request:         RunSavedSearchRequest{},
+		OptionalRequest: true,
+		Responses:       map[int]openapi.Body{http.StatusOK: {Description: "Page of requests", Value: RequestListResponse{}}}})
+
+	// Requests
+	b.Add(openapi.Op{Method: http.MethodGet, Path: "/api/v1/requests", ID: "listRequests", Tag: "requests",
+		Summary:   "List requests",
+		Query:     pagination,
+		Responses: map[int]openapi.Body{http.StatusOK: {Description: "Page of requests", Value: RequestListResponse{}}}})
+	b.Add(openapi.Op{Method: http.MethodPost, Path: "/api/v1/requests/filter", ID: "filterRequests", Tag: "requests",
+		Summary:   "Filter requests by tags, dates and source type",
+		Request:   FilterRequestsRequest{},
+		Responses: map[int]openapi.Body{http.StatusOK: {Description: "Page of requests", Value: RequestListResponse{}}}})
+	b.Add(openapi.Op{Method: http.MethodPost, Path: "/api/v1/requests/bulk-tombstone", ID: "bulkTombstoneRequests", Tag: "requests",
+		Summary:     "Tombstone every request matching a filter",
+		Description: "confirm_count must equal the number of matches. Selections larger than BULK_MAX_REQUESTS are refused.",
+		Query:       dryRun,
+		Request:     BulkRequestsRequest{},
+		Responses:   map[int]openapi.Body{http.StatusOK: {Description: "Per-request results", Value: BulkRequestsResponse{}}}})
+	b.Add(openapi.Op{Method: http.MethodPost, Path: "/api/v1/requests/bulk-delete", ID: "bulkDeleteRequests", Tag: "requests",
+		Summary:     "Delete every request matching a filter",
+		Description: "confirm_count must equal the number of matches. Selections larger than BULK_MAX_REQUESTS are refused.",
+		Query: append([]openapi.Param{
+			{Name: "hard", Type: "boolean", Description: "Purge right away with the upstream scrape, images and analysis instead of soft-deleting"},
+		}, dryRun...),
+		Request:   BulkRequestsRequest{},
+		Responses: map[int]openapi.Body{http.StatusOK: {Description: "Per-request results", Value: BulkRequestsResponse{}}}})
+	b.Add(openapi.Op{Method: http.MethodGet, Path: "/api/v1/requests/timeline-extents", ID: "getTimelineExtents", Tag: "requests",
+		Summary:   "Earliest document date; the range runs to now",
+		Responses: map[int]openapi.Body{http.StatusOK: {Description: "Earliest effective date", Value: TimelineExtentsResponse{}}}})
+	b.Add(openapi.Op{Method: http.MethodGet, Path: "/api/v1/requests/histogram", ID: "getRequestHistogram", Tag: "requests",
+		Summary: "Request counts per time bucket by effective date, with empty buckets included",
+		Query: []openapi.Param{
+			{Name: "start", Type: "string", Description: "RFC3339 or YYYY-MM-DD, default 30 days before end"},
+			{Name: "end", Type: "string", Description: "RFC3339 or YYYY-MM-DD (whole day included), default now"},
+			{Name: "bucket", Type: "string", Description: "1h, 6h, 1d or 7d, default 1d"},
+			{Name: "group_by", Type: "string", Description: "source_type or tag"},
+			{Name: "tag", Type: "string", Description: "Comma-separated tags to count when group_by=tag"},
+			{Name: "include_tombstoned", Type: "boolean", Description: "Count tombstoned requests"},
+			{Name: "timezone", Type: "string", Description: "IANA time zone buckets and plain dates follow, default UTC"},
+		},
+		Responses: map[int]openapi.Body{http.StatusOK: {Description: "Histogram", Value: RequestHistogramResponse{}}}})
+	b.Add(openapi.Op{Method: http.MethodGet, Path: "/api/v1/requests/{id}", ID: "getRequest", Tag: "requests",
+		Summary:     "Get a request",
+		Description: "Metadata leaves out original_text, scraper_metadata.content, scraper_metadata.raw_text and analyzer_metadata.cleaned_text; fetch them from /api/v1/requests/{id}/content or pass include=content.",
+		Query:       []openapi.Param{{Name: "include", Description: "Comma-separated: images to embed the scraper's image summaries, content to keep the full texts in metadata"}},
+		Responses:   map[int]openapi.Body{http.StatusOK: {Description: "Request, with images when include=images; the ETag header identifies its version", Value: RequestDetailResponse{}}}})
+	b.Add(openapi.Op{Method: http.MethodGet, Path: "/api/v1/requests/{id}/content", ID: "getRequestContent", Tag: "requests",
+		Summary:     "Get the full texts of a request",
+		Description: "Returns the submitted, cleaned, raw and extracted text that GET /api/v1/requests/{id} leaves out. raw_text_encoding is gzip+base64 while raw_text is still compressed. With raw_html=true the body is the raw HTML as text/plain, gzip-encoded for clients that accept it. Decompressed HTML over RAW_HTML_MAX_MB returns 413 CONTENT_TOO_LARGE.",
+		Query: []openapi.Param{
+			{Name: "decompress", Type: "boolean", Description: "Return compressed raw text as plain text"},
+			{Name: "raw_html", Type: "boolean", Description: "Return only the raw HTML as the response body"},
+		},
+		Responses: map[int]openapi.Body{http.StatusOK: {Description: "Texts; the ETag header identifies the request's version", Value: RequestContentResponse{}}}})
+	b.Add(openapi.Op{Method: http.MethodDelete, Path: "/api/v1/requests/{id}", ID: "deleteRequest", Tag: "requests",
+		Summary:   "Soft-delete a request, or purge it with hard=true",
+		Query:     []openapi.Param{{Name: "hard", Type: "boolean", Description: "Delete immediately instead of after the grace period"}},
+		Responses: map[int]openapi.Body{http.StatusOK: {Description: "Deleted", Value: message}}})
+	b.Add(openapi.Op{Method: http.MethodPost, Path: "/api/v1/requests/{id}/restore", ID: "restoreRequest", Tag: "requests",
+		Summary:   "Restore a soft-deleted request",
+		Responses: map[int]openapi.Body{http.StatusOK: {Description: "Restored", Value: message}}})
+	b.Add(openapi.Op{Method: http.MethodPut, Path: "/api/v1/requests/{id}/seo-enabled", ID: "updateSEOEnabled", Tag: "requests",
+		Summary:     "Enable or disable the public SEO page",
+		Description: "Send the request's ETag as If-Match to fail with 412 if it changed since it was read.",
+		Request:     openapi.Object("seo_enabled boolean"),
+		Responses:   map[int]openapi.Body{http.StatusOK: {Description: "Updated request", Value: ControllerResponse{}}}})
+	b.Add(openapi.Op{Method: http.MethodPut, Path: "/api/v1/requests/{id}/tombstone", ID: "tombstoneRequest", Tag: "requests",
+		Summary:         "Hide a request from listings, optionally tombstoning its images",
+		Request:         TombstoneRequestBody{},
+		OptionalRequest: true,
+		Responses:       map[int]openapi.Body{http.StatusOK: {Description: "Tombstoned", Value: TombstoneRequestResponse{}}}})
+	b.Add(openapi.Op{Method: http.MethodDelete, Path: "/api/v1/requests/{id}/tombstone", ID: "untombstoneRequest", Tag: "requests",
+		Summary:         "Remove a request's tombstone, optionally restoring its images",
+		Request:         TombstoneRequestBody{},
+		OptionalRequest: true,
+		Responses:       map[int]openapi.Body{http.StatusOK: {Description: "Tombstone removed", Value: TombstoneRequestResponse{}}}})
+	b.Add(openapi.Op{Method: http.MethodPut, Path: "/api/v1/requests/{id}/tags", ID: "updateRequestTags", Tag: "requests",
+		Summary:     "Replace a request's tags",
+		Description: "Send the request's ETag as If-Match to fail with 412 if it changed since it was read; the response carries the new ETag.",
+		Request:     openapi.Object("tags array of strings"),
+		Responses:   map[int]openapi.Body{http.StatusOK: {Description: "Updated", Value: message}}})
+	b.Add(openapi.Op{Method: http.MethodPost, Path: "/api/v1/requests/{id}/tags", ID: "modifyRequestTags", Tag: "requests",
+		Summary:   "Add and remove individual tags, keeping the others",
+		Request:   ModifyRequestTagsRequest{},
+		Responses: map[int]openapi.Body{http.StatusOK: {Description: "Tags afterwards", Value: RequestTagsResponse{}}}})
+	b.Add(openapi.Op{Method: http.MethodGet, Path: "/api/v1/requests/{id}/duplicates", ID: "getRequestDuplicates", Tag: "requests",
+		Summary:   "Alternate URLs and scrape jobs that duplicated a request",
+		Responses: map[int]openapi.Body{http.StatusOK: {Description: "Duplicates", Value: RequestDuplicatesResponse{}}}})
+	b.Add(openapi.Op{Method: http.MethodGet, Path: "/api/v1/requests/{id}/links", ID: "getRequestLinks", Tag: "requests",
+		Summary:   "Links found on the request's page and what the crawler did with each",
+		Responses: map[int]openapi.Body{http.StatusOK: {Description: "Document links", Value: DocumentLinksResponse{}}}})
+	b.Add(openapi.Op{Method: http.MethodGet, Path: "/api/v1/requests/{id}/versions", ID: "listRequestVersions", Tag: "requests",
+		Summary:   "List previous versions of a request",
+		Responses: map[int]openapi.Body{http.StatusOK: {Description: "Versions", Value: RequestVersionListResponse{}}}})
+	b.Add(openapi.Op{Method: http.MethodGet, Path: "/api/v1/requests/{id}/versions/{version}", ID: "getRequestVersion", Tag: "requests",
+		Summary:   "Get one version of a request",
+		Responses: map[int]openapi.Body{http.StatusOK: {Description: "Version snapshot", Value: storage.RequestVersion{}}}})
+	b.Add(openapi.Op{Method: http.MethodGet, Path: "/api/v1/requests/{id}/changes", ID: "listRequestChanges", Tag: "requests",
+		Summary:   "List recorded metadata changes of a request, oldest first",
+		Query:     pagination,
+		Responses: map[int]openapi.Body{http.StatusOK: {Description: "Changes", Value: RequestChangesResponse{}}}})
+	b.Add(openapi.Op{Method: http.MethodGet, Path: "/api/v1/requests/{id}/stream", ID: "streamRequestUpdates", Tag: "requests",
+		Summary:   "Server-sent events for a request's processing",
+		Responses: map[int]openapi.Body{http.StatusOK: {Description: "Event stream", Value: "", ContentType: "text/event-stream"}}})
+	b.Add(openapi.Op{Method: http.MethodGet, Path: "/api/v1/requests/{id}/status", ID: "getRequestStatus", Tag: "requests",
+		Summary:   "Scrape, analysis and tombstone state of a request in one view",
+		Responses: map[int]openapi.Body{http.StatusOK: {Description: "Processing status", Value: RequestStatusResponse{}}}})
+	b.Add(openapi.Op{Method: http.MethodGet, Path: "/api/v1/requests/{id}/archive", ID: "getRequestArchive", Tag: "requests",
+		Summary:     "Download a zip of the request, its texts and images with a manifest of hashes",
+		Description: "Contains request.json, cleaned_text.txt, raw_text.txt, images/ and manifest.json. Returns 413 ARCHIVE_TOO_LARGE when the files would exceed ARCHIVE_MAX_MB.",
+		Responses:   map[int]openapi.Body{http.StatusOK: {Description: "Zip archive", Value: "", ContentType: "application/zip"}}})
+	b.Add(openapi.Op{Method: http.MethodPut, Path: "/api/v1/requests/{id}/star", ID: "starRequest", Tag: "requests",
+		Summary:   "Star a request, removing any tombstone",
+		Responses: map[int]openapi.Body{http.StatusOK: {Description: "Starred", Value: StarResponse{}}}})
+	b.Add(openapi.Op{Method: http.MethodDelete, Path: "/api/v1/requests/{id}/star", ID: "unstarRequest", Tag: "requests",
+		Summary:   "Remove a request's star",
+		Responses: map[int]openapi.Body{http.StatusOK: {Description: "Unstarred", Value: StarResponse{}}}})
+
+	// Images
+	b.Add(openapi.Op{Method: http.MethodPost, Path: "/api/v1/images/search", ID: "searchImageTags", Tag: "images",
+		Summary:   "Find images by tags",
+		Request:   SearchImageTagsRequest{},
+		Responses: map[int]openapi.Body{http.StatusOK: {Description: "Page of matching images", Value: clients.ImageSearchResponse{}}}})
+	b.Add(openapi.Op{Method: http.MethodGet, Path: "/api/v1/documents/{uuid}/images", ID: "getDocumentImages", Tag: "images",
+		Summary: "Images extracted from a document",
+		Query: append(pagination,
+			openapi.Param{Name: "include_tombstoned", Type: "boolean", Description: "Include tombstoned images"},
+		),
+		Responses: map[int]openapi.Body{http.StatusOK: {Description: "Page of images", Value: clients.ImageSearchResponse{}}}})
+	b.Add(openapi.Op{Method: http.MethodPut, Path: "/api/v1/documents/{uuid}/images/tombstone", ID: "tombstoneDocumentImages", Tag: "images",
+		Summary:   "Tombstone every image of a document",
+		Query:     dryRun,
+		Responses: map[int]openapi.Body{http.StatusOK: {Description: "Per-image results", Value: BulkImageResponse{}}}})
+	b.Add(openapi.Op{Method: http.MethodDelete, Path: "/api/v1/documents/{uuid}/images", ID: "deleteDocumentImages", Tag: "images",
+		Summary:   "Delete every image of a document",
+		Query:     dryRun,
+		Responses: map[int]openapi.Body{http.StatusOK: {Description: "Per-image results", Value: BulkImageResponse{}}}})
+	b.Add(openapi.Op{Method: http.MethodGet, Path: "/api/v1/images/{id}", ID: "getImage", Tag: "images",
+		Summary:   "Get an image",
+		Responses: map[int]openapi.Body{http.StatusOK: {Description: "Image", Value: clients.ImageInfo{}}}})
+	b.Add(openapi.Op{Method: http.MethodGet, Path: "/api/v1/images/{id}/content", ID: "getImageContent", Tag: "images",
+		Summary:   "Image bytes, proxied from the scraper and cached",
+		Responses: map[int]openapi.Body{http.StatusOK: {Description: "Image file", Value: "", ContentType: "image/*"}}})
+	b.Add(openapi.Op{Method: http.MethodDelete, Path: "/api/v1/images/{id}", ID: "deleteImage", Tag: "images",
+		Summary:   "Delete an image",
+		Responses: map[int]openapi.Body{http.StatusOK: {Description: "Deleted", Value: message}}})
+	b.Add(openapi.Op{Method: http.MethodPut, Path: "/api/v1/images/{id}/tags", ID: "updateImageTags", Tag: "images",
+		Summary:   "Replace an image's tags",
+		Request:   openapi.Object("tags array of strings"),
+		Responses: map[int]openapi.Body{http.StatusOK: {Description: "Updated", Value: message}}})
+	b.Add(openapi.Op{Method: http.MethodPut, Path: "/api/v1/images/{id}/tombstone", ID: "tombstoneImage", Tag: "images",
+		Summary:   "Hide an image",
+		Responses: map[int]openapi.Body{http.StatusOK: {Description: "Tombstoned", Value: message}}})
+	b.Add(openapi.Op{Method: http.MethodDelete, Path: "/api/v1/images/{id}/tombstone", ID: "untombstoneImage", Tag: "images",
+		Summary:   "Remove an image's tombstone",
+		Responses: map[int]openapi.Body{http.StatusOK: {Description: "Tombstone removed", Value: message}}})
+
+	// Async scrape requests
+	b.Add(openapi.Op{Method: http.MethodGet, Path: "/api/v1/scrape-requests", ID: "listScrapeRequests", Tag: "scrape-requests",
+		Summary:   "List scrape jobs",
+		Query:     append(pagination, expandResult),
+		Responses: map[int]openapi.Body{http.StatusOK: {Description: "Page of scrape jobs", Value: ScrapeJobListResponse{}}}})
+	b.Add(openapi.Op{Method: http.MethodPost, Path: "/api/v1/scrape-requests", ID: "createScrapeRequest", Tag: "scrape-requests",
+		Summary: "Queue a URL for scraping",
+		Request: ScrapeURLRequest{},
+		Responses: map[int]openapi.Body{
+			http.StatusCreated: {Description: "Queued job", Value: storage.ScrapeJob{}},
+			http.StatusOK:      {Description: "The existing request for a duplicate URL", Value: storage.ScrapeJob{}},
+		}})
+	b.Add(openapi.Op{Method: http.MethodPost, Path: "/api/v1/scrape-requests/sitemap", ID: "ingestSitemap", Tag: "scrape-requests",
+		Summary:   "Queue every page listed in a sitemap",
+		Request:   SitemapIngestRequest{},
+		Responses: map[int]openapi.Body{http.StatusOK: {Description: "Ingest summary", Value: openapi.Object("Parent job ID, counts and child job IDs")}}})
+	b.Add(openapi.Op{Method: http.MethodGet, Path: "/api/v1/scrape-requests/{id}", ID: "getScrapeRequest", Tag: "scrape-requests",
+		Summary:   "Get a scrape job",
+		Query:     []openapi.Param{expandResult},
+		Responses: map[int]openapi.Body{http.StatusOK: {Description: "Scrape job", Value: ScrapeJobResponse{}}}})
+	b.Add(openapi.Op{Method: http.MethodDelete, Path: "/api/v1/scrape-requests/{id}", ID: "deleteScrapeRequest", Tag: "scrape-requests",
+		Summary:   "Delete a scrape job",
+		Responses: map[int]openapi.Body{http.StatusOK: {Description: "Deleted", Value: openapi.Object("status: deleted")}}})
+	b.Add(openapi.Op{Method: http.MethodPost, Path: "/api/v1/scrape-requests/{id}/retry", ID: "retryScrapeRequest", Tag: "scrape-requests",
+		Summary:         "Retry a failed scrape job, optionally against a corrected URL",
+		Request:         RetryScrapeRequestBody{},
+		OptionalRequest: true,
+		Responses:       map[int]openapi.Body{http.StatusOK: {Description: "Requeued job", Value: storage.ScrapeJob{}}}})
+	b.Add(openapi.Op{Method: http.MethodPost, Path: "/api/v1/analyze-requests", ID: "createTextAnalysisRequest", Tag: "scrape-requests",
+		Summary:     "Queue text for analysis",
+		Description: "Repeated text is answered with the existing request, as for POST /api/v1/analyze.",
+		Request:     AnalyzeTextRequest{},
+		Responses: map[int]openapi.Body{
+			http.StatusCreated: {Description: "Queued analysis", Value: openapi.Object("In-memory analysis request")},
+			http.StatusOK:      {Description: "Existing request for the same text", Value: DuplicateTextResponse{}},
+		}})
+	b.Add(openapi.Op{Method: http.MethodPost, Path: "/api/v1/analyze-requests/bulk", ID: "createTextAnalysisBatch", Tag: "scrape-requests",
+		Summary:     "Queue up to 100 texts for analysis as one batch",
+		Description: "Each text gets its own job. Empty texts and texts over 64 KiB are rejected per item; the others are still queued. Returns 400 when none can be queued.",
+		Request:     AnalyzeTextBatchRequest{},
+		Responses:   map[int]openapi.Body{http.StatusCreated: {Description: "Queued batch", Value: AnalyzeTextBatchResponse{}}}})
+	b.Add(openapi.Op{Method: http.MethodGet, Path: "/api/v1/analyze-requests", ID: "listTextAnalysisRequests", Tag: "scrape-requests",
+		Summary:   "Summarize tracked text analysis jobs",
+		Query:     []openapi.Param{{Name: "batch_id", Description: "Only the jobs of this batch, in submission order"}},
+		Responses: map[int]openapi.Body{http.StatusOK: {Description: "Jobs and counts by status", Value: TextBatchSummary{}}}})
+
+	// Scheduler
+	b.Add(openapi.Op{Method: http.MethodGet, Path: "/api/v1/scheduler/tasks", ID: "listSchedulerTasks", Tag: "scheduler",
+		Summary: "List scheduled tasks",
+		Query: append(pagination,
+			openapi.Param{Name: "status", Description: "enabled or disabled"},
+			openapi.Param{Name: "name", Description: "Case-insensitive name substring"},
+		),
+		Responses: map[int]openapi.Body{http.StatusOK: {Description: "Page of tasks", Value: clients.TaskList{}}}})
+	b.Add(openapi.Op{Method: http.MethodPost, Path: "/api/v1/scheduler/tasks", ID: "createSchedulerTask", Tag: "scheduler",
+		Summary:   "Create a task",
+		Request:   clients.Task{},
+		Responses: map[int]openapi.Body{http.StatusCreated: {Description: "Created task", Value: clients.Task{}}}})
+	b.Add(openapi.Op{Method: http.MethodGet, Path: "/api/v1/scheduler/tasks/{id}", ID: "getSchedulerTask", Tag: "scheduler",
+		Summary:   "Get a task",
+		Responses: map[int]openapi.Body{http.StatusOK: {Description: "Task", Value: clients.Task{}}}})
+	b.Add(openapi.Op{Method: http.MethodPut, Path: "/api/v1/scheduler/tasks/{id}", ID: "updateSchedulerTask", Tag: "scheduler",
+		Summary:   "Update a task",
+		Request:   clients.Task{},
+		Responses: map[int]openapi.Body{http.StatusOK: {Description: "Updated task", Value: clients.Task{}}}})
+	b.Add(openapi.Op{Method: http.MethodDelete, Path: "/api/v1/scheduler/tasks/{id}", ID: "deleteSchedulerTask", Tag: "scheduler",
+		Summary:   "Delete a task",
+		Responses: map[int]openapi.Body{http.StatusNoContent: {Description: "Deleted"}}})
+	for _, action := range []string{"run", "pause", "resume"} {
+		b.Add(openapi.Op{Method: http.MethodPost, Path: "/api/v1/scheduler/tasks/{id}/" + action, ID: action + "SchedulerTask", Tag: "scheduler",
+			Summary:   "Proxy the scheduler's " + action + " action",
+			Responses: map[int]openapi.Body{http.StatusOK: {Description: "Scheduler response, with its status code", Value: openapi.Object("Scheduler response body")}}})
+	}
+
+	// Admin
+	b.Add(openapi.Op{Method: http.MethodGet, Path: "/api/v1/audit", ID: "listAuditLog", Tag: "admin",
+		Summary: "List audit log entries",
+		Query: append(pagination,
+			openapi.Param{Name: "entity_id", Description: "Only entries for this entity"},
+			openapi.Param{Name: "action", Description: "Only entries with this action"},
+		),
+		Responses: map[int]openapi.Body{http.StatusOK: {Description: "Audit entries", Value: openapi.Object("entries, count, limit and offset")}}})
+	b.Add(openapi.Op{Method: http.MethodGet, Path: "/api/v1/admin/domain-policy", ID: "getDomainPolicy", Tag: "admin",
+		Summary:   "Show the configured domain allow and deny lists",
+		Responses: map[int]openapi.Body{http.StatusOK: {Description: "Domain policy", Value: openapi.Object("enabled, allowlist and denylist")}}})
+	b.Add(openapi.Op{Method: http.MethodPost, Path: "/api/v1/admin/domain-policy/check", ID: "checkDomainPolicy", Tag: "admin",
+		Summary:   "Check whether a URL passes the domain policy",
+		Query:     []openapi.Param{{Name: "url", Description: "URL to check; may be sent as a JSON body instead"}},
+		Responses: map[int]openapi.Body{http.StatusOK: {Description: "Decision", Value: openapi.Object("host, allowed, rule and matched_pattern")}}})
+	b.Add(openapi.Op{Method: http.MethodGet, Path: "/api/v1/admin/settings", ID: "getSettings", Tag: "admin",
+		Summary:   "Show runtime settings, their configured defaults and which are overridden",
+		Responses: map[int]openapi.Body{http.StatusOK: {Description: "Settings", Value: SettingsResponse{}}}})
+	b.Add(openapi.Op{Method: http.MethodPut, Path: "/api/v1/admin/settings", ID: "updateSettings", Tag: "admin",
+		Summary:     "Change runtime settings without a restart",
+		Description: "Send only the settings to change. A null value removes the override so the configured default applies.",
+		Request:     settings.Values{},
+		Responses:   map[int]openapi.Body{http.StatusOK: {Description: "Updated settings", Value: SettingsResponse{}}}})
+	b.Add(openapi.Op{Method: http.MethodGet, Path: "/api/v1/admin/log-level", ID: "getLogLevel", Tag: "admin",
+		Summary:   "Show the minimum level of emitted log records",
+		Responses: map[int]openapi.Body{http.StatusOK: {Description: "Log level", Value: LogLevelResponse{}}}})
+	b.Add(openapi.Op{Method: http.MethodPut, Path: "/api/v1/admin/log-level", ID: "updateLogLevel", Tag: "admin",
+		Summary:     "Change the log level until the next restart or SIGHUP",
+		Description: "Accepts debug, info, warn or error. Debug adds per-link crawl decisions and cache hits.",
+		Request:     LogLevelRequest{},
+		Responses:   map[int]openapi.Body{http.StatusOK: {Description: "New log level", Value: LogLevelResponse{}}}})
+	b.Add(openapi.Op{Method: http.MethodPost, Path: "/api/v1/admin/rescrape-stale", ID: "rescrapeStale", Tag: "admin",
+		Summary:     "Queue re-scrapes of stored URLs older than their freshness window",
+		Description: "Runs the same pass as the background scheduler. Returns 503 unless STALE_RESCRAPE_ENABLED is set and 409 while another pass is running.",
+		Query:       dryRun,
+		Responses:   map[int]openapi.Body{http.StatusOK: {Description: "Pass summary", Value: StaleRescrapeResult{}}}})
+	b.Add(openapi.Op{Method: http.MethodPost, Path: "/api/v1/admin/reconcile", ID: "reconcile", Tag: "admin",
+		Summary:     "Report scrape jobs, requests and upstream records that no longer agree",
+		Description: "With fix=true, clears job results pointing at deleted requests and flags requests whose scrape or analysis is missing upstream. Returns 409 while another reconciliation is running.",
+		Query: []openapi.Param{
+			{Name: "fix", Type: "boolean", Description: "Repair what can be repaired"},
+			{Name: "audit", Type: "boolean", Description: "Record the report in the audit log even without fix"},
+			{Name: "after", Description: "Resume the request scan after this request ID"},
+			{Name: "limit", Type: "integer", Description: "Most requests to check upstream; 0 checks them all"},
+		},
+		Responses: map[int]openapi.Body{http.StatusOK: {Description: "Reconciliation report", Value: ReconcileReport{}}}})
+	b.Add(openapi.Op{Method: http.MethodGet, Path: "/api/v1/admin/backfills", ID: "listBackfills", Tag: "admin",
+		Summary:   "Progress of each column backfill",
+		Responses: map[int]openapi.Body{http.StatusOK: {Description: "Backfills", Value: BackfillsResponse{}}}})
+	b.Add(openapi.Op{Method: http.MethodPost, Path: "/api/v1/admin/backfills/{name}/start", ID: "startBackfill", Tag: "admin",
+		Summary:     "Run a backfill in the background from its checkpoint",
+		Description: "A completed backfill starts over from the first request. Returns 404 for an unknown backfill and 409 while it is running.",
+		Query:       []openapi.Param{{Name: "restart", Type: "boolean", Description: "Discard the checkpoint and start from the first request"}},
+		Responses:   map[int]openapi.Body{http.StatusAccepted: {Description: "Backfill started", Value: BackfillState{}}}})
+	b.Add(openapi.Op{Method: http.MethodPost, Path: "/api/v1/admin/backfills/{name}/stop", ID: "stopBackfill", Tag: "admin",
+		Summary:     "Stop a running backfill after its current batch",
+		Description: "The checkpoint is kept, so starting the backfill again resumes it. Returns 409 unless it is running in this process.",
+		Responses:   map[int]openapi.Body{http.StatusAccepted: {Description: "Backfill stopping", Value: BackfillState{}}}})
+	b.Add(openapi.Op{Method: http.MethodPost, Path: "/api/v1/admin/backup", ID: "createBackup", Tag: "admin",
+		Summary:     "Write a consistent snapshot of the database to BACKUP_DIR",
+		Description: "Returns 429 when the newest snapshot is younger than BACKUP_MIN_INTERVAL_MINUTES, 503 unless BACKUP_DIR is set and 409 while another backup is running.",
+		Responses:   map[int]openapi.Body{http.StatusCreated: {Description: "Snapshot written", Value: BackupResult{}}}})
+	b.Add(openapi.Op{Method: http.MethodGet, Path: "/api/v1/admin/db/integrity", ID: "checkDatabaseIntegrity", Tag: "admin",
+		Summary:   "Run read-only database consistency checks",
+		Responses: map[int]openapi.Body{http.StatusOK: {Description: "Integrity report", Value: storage.IntegrityReport{}}}})
+	b.Add(openapi.Op{Method: http.MethodGet, Path: "/api/v1/admin/migrations", ID: "listMigrations", Tag: "admin",
+		Summary:   "List schema migrations and whether each is applied",
+		Responses: map[int]openapi.Body{http.StatusOK: {Description: "Migration status", Value: MigrationsResponse{}}}})
+
+	// Webhooks
+	b.Add(openapi.Op{Method: http.MethodGet, Path: "/api/v1/webhooks", ID: "listWebhooks", Tag: "webhooks",
+		Summary:   "List webhooks",
+		Responses: map[int]openapi.Body{http.StatusOK: {Description: "Webhooks", Value: WebhookListResponse{}}}})
+	b.Add(openapi.Op{Method: http.MethodPost, Path: "/api/v1/webhooks", ID: "createWebhook", Tag: "webhooks",
+		Summary:     "Subscribe a URL to events",
+		Description: "Event types: scrape.completed, scrape.failed, request.tombstoned, request.deleted. The signing secret is only returned here.",
+		Request:     WebhookRequest{},
+		Responses:   map[int]openapi.Body{http.StatusCreated: {Description: "Webhook with its secret", Value: WebhookResponse{}}}})
+	b.Add(openapi.Op{Method: http.MethodGet, Path: "/api/v1/webhooks/{id}", ID: "getWebhook", Tag: "webhooks",
+		Summary:   "Get a webhook",
+		Responses: map[int]openapi.Body{http.StatusOK: {Description: "Webhook", Value: storage.Webhook{}}}})
+	b.Add(openapi.Op{Method: http.MethodPut, Path: "/api/v1/webhooks/{id}", ID: "updateWebhook", Tag: "webhooks",
+		Summary:   "Replace a webhook's URL, event types and enabled flag, and optionally its secret",
+		Request:   WebhookRequest{},
+		Responses: map[int]openapi.Body{http.StatusOK: {Description: "Webhook", Value: storage.Webhook{}}}})
+	b.Add(openapi.Op{Method: http.MethodDelete, Path: "/api/v1/webhooks/{id}", ID: "deleteWebhook", Tag: "webhooks",
+		Summary:   "Delete a webhook and its delivery log",
+		Responses: map[int]openapi.Body{http.StatusOK: {Description: "Deleted", Value: message}}})
+	b.Add(openapi.Op{Method: http.MethodGet, Path: "/api/v1/webhooks/{id}/deliveries", ID: "listWebhookDeliveries", Tag: "webhooks",
+		Summary:   "Recent deliveries to a webhook, newest first",
+		Query:     []openapi.Param{{Name: "limit", Type: "integer", Description: "Deliveries returned, 1-100 (default 50)"}},
+		Responses: map[int]openapi.Body{http.StatusOK: {Description: "Delivery log", Value: WebhookDeliveriesResponse{}}}})
+	b.Add(openapi.Op{Method: http.MethodGet, Path: "/api/v1/openapi.json", ID: "getOpenAPI", Tag: "admin",
+		Summary:   "This document",
+		Responses: map[int]openapi.Body{http.StatusOK: {Description: "OpenAPI document", Value: openapi.Object("OpenAPI 3 document")}}})
+
+	return b.Document()
+}
+
+// ServeOpenAPI serves the OpenAPI document as JSON
+func (h *Handler) ServeOpenAPI(w http.ResponseWriter, r *http.Request) {
+	openAPIOnce.Do(func() {
+		openAPIJSON, openAPIErr = json.MarshalIndent(OpenAPIDocument(), "", "  ")
+	})
+	if openAPIErr != nil {
+		respondErrorCode(w, ErrCodeInternal, "Failed to build OpenAPI document", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(openAPIJSON)
+}
+
+// apiDocsPage renders the OpenAPI document with Redoc
+const apiDocsPage = `<!DOCTYPE html>
+<html>
+<head>
+  <title>DocuTag Controller API</title>
+  <meta charset="utf-8"/>
+  <meta name="viewport" content="width=device-width, initial-scale=1">
+</head>
+<body>
+  <redoc spec-url="/api/v1/openapi.json"></redoc>
+  <script src="https://cdn.redoc.ly/redoc/latest/bundles/redoc.standalone.js"></script>
+</body>
+</html>
+`
+
+// ServeAPIDocs serves a Redoc page for the OpenAPI document
+func (h *Handler) ServeAPIDocs(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write([]byte(apiDocsPage))
+}
@@ -0,0 +1,195 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestServeOpenAPI(t *testing.T) {
+	t.Parallel()
+	w := httptest.NewRecorder()
+	serveRoute(&Handler{}, w, httptest.NewRequest(http.MethodGet, "/api/v1/openapi.json", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", w.Code)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("expected application/json, got %s", ct)
+	}
+
+	var doc map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &doc); err != nil {
+		t.Fatalf("document is not valid JSON: %v", err)
+	}
+	validateOpenAPIDocument(t, doc)
+
+	paths := doc["paths"].(map[string]interface{})
+	for _, key := range []string{
+		"/api/v1/scrape",
+		"/api/v1/requests",
+		"/api/v1/requests/{id}",
+		"/api/v1/requests/{id}/versions/{version}",
+		"/api/v1/images/{id}",
+		"/api/v1/scrape-requests",
+		"/api/v1/scheduler/tasks/{id}",
+		"/api/v1/audit",
+	} {
+		if _, ok := paths[key]; !ok {
+			t.Errorf("expected path %s in document", key)
+		}
+	}
+}
+
+// validateOpenAPIDocument checks the structural rules of the OpenAPI 3.0 schema that
+// the generated document could plausibly break
+func validateOpenAPIDocument(t *testing.T, doc map[string]interface{}) {
+	t.Helper()
+
+	if v, _ := doc["openapi"].(string); !strings.HasPrefix(v, "3.0.") {
+		t.Errorf("expected openapi 3.0.x, got %q", v)
+	}
+	info, ok := doc["info"].(map[string]interface{})
+	if !ok || info["title"] == "" || info["version"] == "" {
+		t.Errorf("info must have a title and version, got %v", doc["info"])
+	}
+
+	schemas := map[string]interface{}{}
+	if components, ok := doc["components"].(map[string]interface{}); ok {
+		schemas, _ = components["schemas"].(map[string]interface{})
+	}
+
+	methods := map[string]bool{"get": true, "put": true, "post": true, "delete": true, "options": true, "head": true, "patch": true, "trace": true}
+	responseKey := regexp.MustCompile(`^([1-5][0-9X]{2}|default)$`)
+	operationIDs := map[string]string{}
+
+	paths, ok := doc["paths"].(map[string]interface{})
+	if !ok {
+		t.Fatal("paths must be an object")
+	}
+	for path, rawItem := range paths {
+		if !strings.HasPrefix(path, "/") {
+			t.Errorf("path %q must begin with /", path)
+		}
+		item := rawItem.(map[string]interface{})
+		for method, rawOp := range item {
+			if !methods[method] {
+				t.Errorf("%s: unknown method %q", path, method)
+				continue
+			}
+			op := rawOp.(map[string]interface{})
+			where := strings.ToUpper(method) + " " + path
+
+			id, _ := op["operationId"].(string)
+			if id == "" {
+				t.Errorf("%s: missing operationId", where)
+			} else if other, dup := operationIDs[id]; dup {
+				t.Errorf("%s: operationId %s already used by %s", where, id, other)
+			}
+			operationIDs[id] = where
+
+			declared := map[string]bool{}
+			params, _ := op["parameters"].([]interface{})
+			for _, rawParam := range params {
+				p := rawParam.(map[string]interface{})
+				if p["in"] == "path" {
+					if p["required"] != true {
+						t.Errorf("%s: path parameter %v must be required", where, p["name"])
+					}
+					declared[p["name"].(string)] = true
+				}
+				if p["schema"] == nil {
+					t.Errorf("%s: parameter %v has no schema", where, p["name"])
+				}
+			}
+			for _, m := range pathParamNames.FindAllStringSubmatch(path, -1) {
+				if !declared[m[1]] {
+					t.Errorf("%s: path parameter %s is not declared", where, m[1])
+				}
+			}
+
+			responses, _ := op["responses"].(map[string]interface{})
+			if len(responses) == 0 {
+				t.Errorf("%s: at least one response is required", where)
+			}
+			for code, rawResp := range responses {
+				if !responseKey.MatchString(code) {
+					t.Errorf("%s: invalid response key %q", where, code)
+				}
+				if resp := rawResp.(map[string]interface{}); resp["description"] == nil {
+					t.Errorf("%s: response %s needs a description", where, code)
+				}
+			}
+		}
+	}
+
+	// Every $ref must point at a registered component
+	var walk func(v interface{})
+	walk = func(v interface{}) {
+		switch v := v.(type) {
+		case map[string]interface{}:
+			if ref, ok := v["$ref"].(string); ok {
+				name := strings.TrimPrefix(ref, "#/components/schemas/")
+				if _, ok := schemas[name]; !ok || name == ref {
+					t.Errorf("unresolved reference %s", ref)
+				}
+			}
+			for _, child := range v {
+				walk(child)
+			}
+		case []interface{}:
+			for _, child := range v {
+				walk(child)
+			}
+		}
+	}
+	walk(doc)
+}
+
+var pathParamNames = regexp.MustCompile(`\{([^}]+)\}`)
+
+func TestOpenAPIOperationsAreRouted(t *testing.T) {
+	t.Parallel()
+	mux := http.NewServeMux()
+	(&Handler{}).RegisterRoutes(mux)
+
+	for _, op := range OpenAPIDocument().Operations() {
+		method, path := op[0], op[1]
+		concrete := pathParamNames.ReplaceAllString(path, "x")
+
+		_, pattern := mux.Handler(httptest.NewRequest(method, concrete, nil))
+		if pattern != method+" "+path {
+			t.Errorf("documented %s %s is routed to %q", method, path, pattern)
+		}
+	}
+}
+
+func TestOpenAPIErrorSchema(t *testing.T) {
+	t.Parallel()
+	doc := OpenAPIDocument()
+	s := doc.Components.Schemas["ErrorResponse"]
+	if s == nil {
+		t.Fatal("ErrorResponse schema missing")
+	}
+	for _, field := range []string{"error", "code", "message", "request_id"} {
+		if _, ok := s.Properties[field]; !ok {
+			t.Errorf("ErrorResponse schema missing %s", field)
+		}
+	}
+}
+
+func TestServeAPIDocs(t *testing.T) {
+	t.Parallel()
+	w := httptest.NewRecorder()
+	serveRoute(&Handler{}, w, httptest.NewRequest(http.MethodGet, "/api/v1/docs", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", w.Code)
+	}
+	if !strings.Contains(w.Body.String(), "/api/v1/openapi.json") {
+		t.Error("docs page should load /api/v1/openapi.json")
+	}
+}
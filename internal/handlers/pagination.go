@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+const (
+	// defaultPageLimit is the page size of list endpoints when the caller sends no limit
+	defaultPageLimit = 50
+	// defaultFilterLimit is the page size of POST /api/requests/filter and saved searches
+	defaultFilterLimit = 100
+	// defaultMaxPageLimit caps limit on list endpoints unless SetMaxPageLimit says otherwise
+	defaultMaxPageLimit = 500
+)
+
+// page is the effective limit and offset of one page of a list
+type page struct {
+	Limit  int
+	Offset int
+}
+
+// SetMaxPageLimit sets the largest limit list endpoints return in one page; larger values are
+// clamped to it. Values below 1 keep the default.
+func (h *Handler) SetMaxPageLimit(max int) {
+	if max < 1 {
+		max = defaultMaxPageLimit
+	}
+	h.maxPageLimit = max
+}
+
+// pageLimitMax returns the configured maximum page size, or the default when none was set
+func (h *Handler) pageLimitMax() int {
+	if h.maxPageLimit < 1 {
+		return defaultMaxPageLimit
+	}
+	return h.maxPageLimit
+}
+
+// pageFromQuery reads the limit and offset query parameters of r, falling back to defaultLimit
+func (h *Handler) pageFromQuery(r *http.Request, defaultLimit int) (page, error) {
+	return parsePage(r.URL.Query(), defaultLimit, h.pageLimitMax())
+}
+
+// parsePage reads limit and offset from query. A missing limit is defaultLimit and a limit above
+// maxLimit is clamped to it. A limit that is not a positive integer, or an offset that is not a
+// non-negative integer, is an error rather than a silent fallback to the default.
+func parsePage(query url.Values, defaultLimit, maxLimit int) (page, error) {
+	p := page{Limit: defaultLimit}
+	if s := query.Get("limit"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 1 {
+			return page{}, fmt.Errorf("Invalid limit: must be a positive integer, got %q", s)
+		}
+		p.Limit = n
+	}
+	if s := query.Get("offset"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 0 {
+			return page{}, fmt.Errorf("Invalid offset: must be a non-negative integer, got %q", s)
+		}
+		p.Offset = n
+	}
+	return boundPage(p, defaultLimit, maxLimit)
+}
+
+// boundPage applies defaultLimit to a zero limit and clamps the limit to maxLimit. It is used
+// directly for limits sent in JSON bodies, where 0 means the field was left out.
+func boundPage(p page, defaultLimit, maxLimit int) (page, error) {
+	if p.Limit < 0 {
+		return page{}, fmt.Errorf("Invalid limit: must not be negative, got %d", p.Limit)
+	}
+	if p.Offset < 0 {
+		return page{}, fmt.Errorf("Invalid offset: must not be negative, got %d", p.Offset)
+	}
+	if p.Limit == 0 {
+		p.Limit = defaultLimit
+	}
+	if p.Limit > maxLimit {
+		p.Limit = maxLimit
+	}
+	return p, nil
+}
@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestParsePage(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name    string
+		query   string
+		want    page
+		wantErr string
+	}{
+		{"defaults", "", page{Limit: 50}, ""},
+		{"explicit", "limit=10&offset=20", page{Limit: 10, Offset: 20}, ""},
+		{"at the maximum", "limit=500", page{Limit: 500}, ""},
+		{"clamped to the maximum", "limit=1000000", page{Limit: 500}, ""},
+		{"zero offset", "offset=0", page{Limit: 50}, ""},
+		{"empty values use the defaults", "limit=&offset=", page{Limit: 50}, ""},
+		{"zero limit", "limit=0", page{}, "Invalid limit"},
+		{"negative limit", "limit=-5", page{}, "Invalid limit"},
+		{"non-numeric limit", "limit=ten", page{}, "Invalid limit"},
+		{"fractional limit", "limit=2.5", page{}, "Invalid limit"},
+		{"negative offset", "offset=-1", page{}, "Invalid offset"},
+		{"non-numeric offset", "offset=abc", page{}, "Invalid offset"},
+		{"overflowing offset", "offset=99999999999999999999", page{}, "Invalid offset"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, err := url.ParseQuery(tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := parsePage(query, defaultPageLimit, defaultMaxPageLimit)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Expected an error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Expected %+v, got %+v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestBoundPage(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name    string
+		in      page
+		want    page
+		wantErr bool
+	}{
+		{"zero limit uses the default", page{}, page{Limit: defaultFilterLimit}, false},
+		{"kept", page{Limit: 20, Offset: 40}, page{Limit: 20, Offset: 40}, false},
+		{"clamped", page{Limit: 5000}, page{Limit: defaultMaxPageLimit}, false},
+		{"negative limit", page{Limit: -1}, page{}, true},
+		{"negative offset", page{Limit: 10, Offset: -1}, page{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := boundPage(tt.in, defaultFilterLimit, defaultMaxPageLimit)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Expected error %v, got %v", tt.wantErr, err)
+			}
+			if got != tt.want {
+				t.Errorf("Expected %+v, got %+v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestSetMaxPageLimit(t *testing.T) {
+	t.Parallel()
+	h := &Handler{}
+	h.SetMaxPageLimit(100)
+	got, err := h.pageFromQuery(httptest.NewRequest(http.MethodGet, "/api/requests?limit=250", nil), defaultPageLimit)
+	if err != nil || got.Limit != 100 {
+		t.Errorf("Expected the limit clamped to 100, got %+v (err %v)", got, err)
+	}
+
+	h.SetMaxPageLimit(0)
+	if h.maxPageLimit != defaultMaxPageLimit {
+		t.Errorf("Expected 0 to restore the default maximum, got %d", h.maxPageLimit)
+	}
+}
+
+func TestListEndpointsRejectInvalidPagination(t *testing.T) {
+	t.Parallel()
+	// Validation runs before storage is touched, so a bare handler is enough
+	h := &Handler{}
+
+	tests := []struct {
+		method string
+		path   string
+		body   string
+	}{
+		{http.MethodGet, "/api/v1/requests?limit=abc", ""},
+		{http.MethodGet, "/api/v1/requests?offset=-1", ""},
+		{http.MethodGet, "/api/v1/scrape-requests?limit=0", ""},
+		{http.MethodGet, "/api/v1/scrape-requests?offset=x", ""},
+		{http.MethodGet, "/api/v1/audit?limit=1.5", ""},
+		{http.MethodPost, "/api/v1/requests/filter", `{"limit": -1}`},
+		{http.MethodPost, "/api/v1/requests/filter", `{"offset": -10}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path+" "+tt.body, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			serveRoute(h, w, httptest.NewRequest(tt.method, tt.path, bytes.NewBufferString(tt.body)))
+			if w.Code != http.StatusBadRequest {
+				t.Fatalf("Expected status 400, got %d: %s", w.Code, w.Body.String())
+			}
+			var resp ErrorResponse
+			if err := json.NewDecoder(w.Body).Decode(&resp); err != nil || resp.Code != ErrCodeValidationFailed {
+				t.Errorf("Expected %s, got %+v (err %v)", ErrCodeValidationFailed, resp, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/docutag/controller/internal/clients"
+)
+
+// previewTimeout bounds a whole preview. Previews back interactive UI, so a slow page
+// yields a partial answer quickly rather than holding the request for the scrape timeout.
+const previewTimeout = 3 * time.Second
+
+// PreviewRequest asks for a preview of a URL
+type PreviewRequest struct {
+	URL string `json:"url"`
+}
+
+// PreviewResponse is what a scrape of the URL would start from. Title, description and
+// content type are omitted when the scraper cannot read them within the preview timeout.
+type PreviewResponse struct {
+	URL            string   `json:"url"`
+	Score          float64  `json:"score"`
+	Categories     []string `json:"categories"`
+	MeetsThreshold bool     `json:"meets_threshold"`
+	Threshold      float64  `json:"threshold"`
+	Title          string   `json:"title,omitempty"`
+	Description    string   `json:"description,omitempty"`
+	ContentType    string   `json:"content_type,omitempty"`
+	IsImage        bool     `json:"is_image"`
+}
+
+// PreviewURL scores a URL and reads its page metadata without scraping or storing anything.
+// It applies the same URL validation and domain policy as a scrape request.
+func (h *Handler) PreviewURL(w http.ResponseWriter, r *http.Request) {
+	var req PreviewRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondErrorCode(w, ErrCodeInvalidRequestBody, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if req.URL == "" {
+		respondErrorCode(w, ErrCodeValidationFailed, "URL is required", http.StatusBadRequest)
+		return
+	}
+	if err := h.validateScrapeURL(r.Context(), req.URL); err != nil {
+		respondErrorDetails(w, ErrCodeURLRejected, fmt.Sprintf("URL rejected: %v", err), http.StatusBadRequest, urlRejectionDetails(err))
+		return
+	}
+	if err := h.domainPolicy.Check(req.URL); err != nil {
+		respondErrorDetails(w, ErrCodeDomainNotAllowed, fmt.Sprintf("URL rejected: %v", err), http.StatusForbidden, urlRejectionDetails(err))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), previewTimeout)
+	defer cancel()
+
+	// Metadata is optional, so read it alongside the score rather than after it
+	type peekResult struct {
+		resp *clients.PeekResponse
+		err  error
+	}
+	peeked := make(chan peekResult, 1)
+	go func() {
+		resp, err := h.scraper.Peek(ctx, req.URL)
+		peeked <- peekResult{resp, err}
+	}()
+
+	scoreResp, err := h.scraper.ScoreLink(ctx, req.URL)
+	if err != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			respondErrorCode(w, ErrCodeUpstreamUnavailable, fmt.Sprintf("Scraper did not score the URL within %s", previewTimeout), http.StatusGatewayTimeout)
+			return
+		}
+		respondErrorCode(w, ErrCodeUpstreamError, fmt.Sprintf("Failed to score link: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	threshold := h.linkScoreThreshold(req.URL, h.settings.Get())
+	response := PreviewResponse{
+		URL:            req.URL,
+		Score:          scoreResp.Score.Score,
+		Categories:     scoreResp.Score.Categories,
+		MeetsThreshold: scoreResp.Score.Score >= threshold,
+		Threshold:      threshold,
+		IsImage:        slices.Contains(scoreResp.Score.Categories, "image"),
+	}
+
+	result := <-peeked
+	switch {
+	case result.err == nil:
+		response.Title = result.resp.Title
+		response.Description = result.resp.Description
+		response.ContentType = result.resp.ContentType
+		if strings.HasPrefix(result.resp.ContentType, "image/") {
+			response.IsImage = true
+		}
+	case !errors.Is(result.err, clients.ErrPeekUnsupported):
+		slog.Default().Warn("preview metadata unavailable, returning score only", "url", req.URL, "error", result.err)
+	}
+
+	respondJSON(w, response, http.StatusOK)
+}
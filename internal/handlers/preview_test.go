@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/docutag/controller/internal/clients"
+	"github.com/docutag/controller/internal/urlguard"
+)
+
+func TestPreviewURL(t *testing.T) {
+	t.Parallel()
+	scraperMock := mockScraperServer()
+	defer scraperMock.Close()
+
+	peekStatus := http.StatusOK
+	scraper := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/peek" {
+			w.WriteHeader(peekStatus)
+			json.NewEncoder(w).Encode(clients.PeekResponse{URL: "https://example.com", Title: "Example Page", Description: "A page about examples", ContentType: "text/html"})
+			return
+		}
+		if r.URL.Path == "/api/scrape" {
+			t.Error("preview must not scrape the page")
+		}
+		scraperMock.Config.Handler.ServeHTTP(w, r)
+	}))
+	defer scraper.Close()
+
+	h := &Handler{
+		scraper:      clients.NewScraperClient(scraper.URL),
+		settings:     newHandlerSettings(0.5, 30, 90),
+		urlGuard:     urlguard.NewWithResolver(false, publicResolver{}),
+		domainPolicy: urlguard.NewDomainPolicy(nil, []string{"*.blocked.com"}),
+	}
+	preview := func(url string) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(PreviewRequest{URL: url})
+		w := httptest.NewRecorder()
+		serveRoute(h, w, httptest.NewRequest(http.MethodPost, "/api/preview", bytes.NewReader(body)))
+		return w
+	}
+	decode := func(w *httptest.ResponseRecorder) PreviewResponse {
+		t.Helper()
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+		}
+		var resp PreviewResponse
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		return resp
+	}
+
+	resp := decode(preview("https://example.com"))
+	if resp.Score != 0.8 || !resp.MeetsThreshold || resp.IsImage {
+		t.Errorf("unexpected score fields: %+v", resp)
+	}
+	if resp.Title != "Example Page" || resp.Description != "A page about examples" || resp.ContentType != "text/html" {
+		t.Errorf("unexpected metadata: %+v", resp)
+	}
+
+	resp = decode(preview("https://low-quality.com"))
+	if resp.MeetsThreshold {
+		t.Errorf("expected a score of 0.3 to miss the threshold, got %+v", resp)
+	}
+
+	resp = decode(preview("https://example.com/photo.jpg"))
+	if !resp.IsImage {
+		t.Errorf("expected an image URL to be flagged, got %+v", resp)
+	}
+
+	// Without metadata the preview still answers with the score
+	for _, status := range []int{http.StatusNotFound, http.StatusInternalServerError} {
+		peekStatus = status
+		resp = decode(preview("https://example.com"))
+		if resp.Score != 0.8 || resp.Title != "" || resp.ContentType != "" {
+			t.Errorf("peek status %d: expected a score-only preview, got %+v", status, resp)
+		}
+	}
+
+	if w := preview("http://127.0.0.1/admin"); w.Code != http.StatusBadRequest {
+		t.Errorf("private target: expected status 400, got %d", w.Code)
+	}
+	if w := preview("https://www.blocked.com/article"); w.Code != http.StatusForbidden {
+		t.Errorf("denied domain: expected status 403, got %d", w.Code)
+	}
+	if w := preview(""); w.Code != http.StatusBadRequest {
+		t.Errorf("missing URL: expected status 400, got %d", w.Code)
+	}
+}
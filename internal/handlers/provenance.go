@@ -0,0 +1,40 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+)
+
+// maxClientNameLength bounds the X-Client-Name value stored as created_by
+const maxClientNameLength = 64
+
+// requestCreator identifies who created the records made by a request: the API key
+// fingerprint when one is presented, otherwise the caller's X-Client-Name (the scheduler
+// sends "scheduler"), otherwise "anonymous".
+func requestCreator(r *http.Request) string {
+	if actor := auditActor(r); actor != "anonymous" {
+		return actor
+	}
+	if name := sanitizeClientName(r.Header.Get("X-Client-Name")); name != "" {
+		return name
+	}
+	return "anonymous"
+}
+
+// sanitizeClientName returns name if it is a short identifier, or "" otherwise.
+// The "apikey:" prefix is reserved so a header cannot impersonate a key.
+func sanitizeClientName(name string) string {
+	name = strings.TrimSpace(name)
+	if name == "" || len(name) > maxClientNameLength || strings.HasPrefix(name, "apikey:") {
+		return ""
+	}
+	for _, c := range name {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case c == '.', c == '_', c == ':', c == '-':
+		default:
+			return ""
+		}
+	}
+	return name
+}
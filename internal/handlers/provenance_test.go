@@ -0,0 +1,43 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRequestCreator(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name     string
+		headers  map[string]string
+		expected string
+	}{
+		{"anonymous", nil, "anonymous"},
+		{"client name", map[string]string{"X-Client-Name": " reader-app "}, "reader-app"},
+		{"scheduler", map[string]string{"X-Client-Name": "scheduler"}, "scheduler"},
+		{"invalid client name", map[string]string{"X-Client-Name": "reader app\n"}, "anonymous"},
+		{"too long", map[string]string{"X-Client-Name": strings.Repeat("a", maxClientNameLength+1)}, "anonymous"},
+		{"reserved prefix", map[string]string{"X-Client-Name": "apikey:000000000000"}, "anonymous"},
+		{"api key wins", map[string]string{"X-API-Key": "secret", "X-Client-Name": "reader-app"}, auditActorForKey("secret")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/scrape-requests", nil)
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+			if got := requestCreator(req); got != tt.expected {
+				t.Errorf("requestCreator() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func auditActorForKey(key string) string {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("X-API-Key", key)
+	return auditActor(req)
+}
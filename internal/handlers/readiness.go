@@ -0,0 +1,26 @@
+package handlers
+
+import (
+	"net/http"
+)
+
+// SetReadinessCheck sets what GET /ready waits for, normally the queue worker having
+// started. A nil check reports ready as soon as the HTTP server is up.
+func (h *Handler) SetReadinessCheck(check func() error) {
+	h.readinessCheck = check
+}
+
+// Ready handles GET /ready. Unlike /health it answers 503 until the worker is processing
+// tasks, so orchestrators hold traffic while startup is still waiting for Redis.
+func (h *Handler) Ready(w http.ResponseWriter, r *http.Request) {
+	if h.readinessCheck != nil {
+		if err := h.readinessCheck(); err != nil {
+			respondJSON(w, map[string]string{
+				"status": "not_ready",
+				"reason": err.Error(),
+			}, http.StatusServiceUnavailable)
+			return
+		}
+	}
+	respondJSON(w, map[string]string{"status": "ready"}, http.StatusOK)
+}
@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestReady(t *testing.T) {
+	t.Parallel()
+	errNotStarted := errors.New("worker not yet started")
+	tests := []struct {
+		name       string
+		check      func() error
+		wantStatus int
+		want       map[string]string
+	}{
+		{"no check", nil, http.StatusOK, map[string]string{"status": "ready"}},
+		{"worker running", func() error { return nil }, http.StatusOK, map[string]string{"status": "ready"}},
+		{"worker starting", func() error { return errNotStarted }, http.StatusServiceUnavailable,
+			map[string]string{"status": "not_ready", "reason": "worker not yet started"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := &Handler{}
+			h.SetReadinessCheck(tt.check)
+
+			w := httptest.NewRecorder()
+			serveRoute(h, w, httptest.NewRequest(http.MethodGet, "/ready", nil))
+			if w.Code != tt.wantStatus {
+				t.Fatalf("expected status %d, got %d", tt.wantStatus, w.Code)
+			}
+
+			var response map[string]string
+			if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			for key, want := range tt.want {
+				if response[key] != want {
+					t.Errorf("expected %s %q, got %q", key, want, response[key])
+				}
+			}
+		})
+	}
+}
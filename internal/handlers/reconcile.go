@@ -0,0 +1,354 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/docutag/controller/internal/clients"
+	"github.com/docutag/controller/internal/storage"
+	"golang.org/x/time/rate"
+)
+
+const (
+	defaultReconcileBatchSize    = 200
+	defaultReconcileUpstreamRate = 5.0 // Upstream existence checks per second
+
+	// reconcileSampleSize caps the IDs listed per orphan class
+	reconcileSampleSize = 10
+
+	// reconcileAnalysisGrace is how long an analysis may stay queued or processing before
+	// reconciliation asks the text analyzer about it
+	reconcileAnalysisGrace = 2 * time.Hour
+)
+
+var (
+	errReconcileDisabled = errors.New("reconciliation is not configured")
+	errReconcileRunning  = errors.New("a reconciliation is already running")
+)
+
+// reconciler holds the reconciliation limits and the lock that keeps runs from overlapping
+type reconciler struct {
+	batchSize    int
+	upstreamRate float64
+
+	running sync.Mutex
+}
+
+// SetReconcile sets how many rows reconciliation reads per query and how many upstream
+// existence checks it makes per second. Values <= 0 use the defaults.
+func (h *Handler) SetReconcile(batchSize int, upstreamRate float64) {
+	if batchSize <= 0 {
+		batchSize = defaultReconcileBatchSize
+	}
+	if upstreamRate <= 0 {
+		upstreamRate = defaultReconcileUpstreamRate
+	}
+	h.reconcile = &reconciler{batchSize: batchSize, upstreamRate: upstreamRate}
+}
+
+// ReconcileOptions controls one reconciliation run
+type ReconcileOptions struct {
+	Fix   bool   // Repair what can be repaired; otherwise only report
+	Audit bool   // Record the report in the audit log even when nothing is fixed
+	After string // Resume the request scan after this request ID
+	Limit int    // Most requests checked upstream in this run; 0 checks them all
+	Actor string // Audit actor for the run and its fixes
+}
+
+// OrphanClass counts one kind of inconsistency
+type OrphanClass struct {
+	Count     int      `json:"count"`
+	Fixed     int      `json:"fixed"`
+	SampleIDs []string `json:"sample_ids"` // Up to 10 affected IDs
+}
+
+func (c *OrphanClass) add(id string) {
+	c.Count++
+	if len(c.SampleIDs) < reconcileSampleSize {
+		c.SampleIDs = append(c.SampleIDs, id)
+	}
+}
+
+// ReconcileOrphans lists the inconsistencies found between scrape jobs, requests and the
+// upstream services
+type ReconcileOrphans struct {
+	DanglingJobResults OrphanClass `json:"dangling_job_results"` // Completed jobs pointing at a deleted request (job IDs)
+	LostJobResults     OrphanClass `json:"lost_job_results"`     // Completed jobs whose result was purged or cleared (job IDs); report only
+	MissingScrapes     OrphanClass `json:"missing_scrapes"`      // Requests whose scrape the scraper no longer has (request IDs)
+	MissingAnalyses    OrphanClass `json:"missing_analyses"`     // Pending analyses the text analyzer no longer knows (request IDs)
+	UnresolvedAnalyses OrphanClass `json:"unresolved_analyses"`  // Analyses finished upstream but never applied (request IDs); report only
+}
+
+// ReconcileReport summarizes one reconciliation run
+type ReconcileReport struct {
+	Fix             bool             `json:"fix"`
+	StartedAt       time.Time        `json:"started_at"`
+	FinishedAt      time.Time        `json:"finished_at"`
+	RequestsScanned int              `json:"requests_scanned"`
+	UpstreamChecks  int              `json:"upstream_checks"`
+	UpstreamErrors  int              `json:"upstream_errors"`      // Checks that failed and were left undecided
+	NextAfter       string           `json:"next_after,omitempty"` // Pass as after to continue a run that stopped at its limit
+	Orphans         ReconcileOrphans `json:"orphans"`
+}
+
+// Reconcile looks for scrape jobs, requests and upstream records that no longer agree:
+// completed jobs whose request is gone, and requests whose scrape or pending analysis the
+// upstream services have lost. Upstream checks are rate limited. With Fix, dangling job
+// results are cleared and requests with missing upstream data are flagged in their metadata
+// (scrape_missing, analysis_missing). The report is recorded in the audit log with Fix or Audit.
+func (h *Handler) Reconcile(ctx context.Context, opts ReconcileOptions) (*ReconcileReport, error) {
+	rc := h.reconcile
+	if rc == nil {
+		return nil, errReconcileDisabled
+	}
+	if !rc.running.TryLock() {
+		return nil, errReconcileRunning
+	}
+	defer rc.running.Unlock()
+
+	report := &ReconcileReport{Fix: opts.Fix, StartedAt: time.Now().UTC()}
+	for _, c := range []*OrphanClass{&report.Orphans.DanglingJobResults, &report.Orphans.LostJobResults,
+		&report.Orphans.MissingScrapes, &report.Orphans.MissingAnalyses, &report.Orphans.UnresolvedAnalyses} {
+		c.SampleIDs = []string{}
+	}
+	if err := h.reconcileJobResults(rc, opts, report); err != nil {
+		return nil, err
+	}
+	if err := h.reconcileRequests(ctx, rc, opts, report); err != nil {
+		return nil, err
+	}
+	report.FinishedAt = time.Now().UTC()
+
+	if opts.Fix || opts.Audit {
+		h.auditReconcile(opts.Actor, report)
+	}
+	slog.Default().Info("reconciliation finished",
+		"fix", opts.Fix,
+		"requests_scanned", report.RequestsScanned,
+		"upstream_checks", report.UpstreamChecks,
+		"upstream_errors", report.UpstreamErrors,
+		"dangling_job_results", report.Orphans.DanglingJobResults.Count,
+		"lost_job_results", report.Orphans.LostJobResults.Count,
+		"missing_scrapes", report.Orphans.MissingScrapes.Count,
+		"missing_analyses", report.Orphans.MissingAnalyses.Count,
+		"unresolved_analyses", report.Orphans.UnresolvedAnalyses.Count,
+	)
+	return report, nil
+}
+
+// reconcileJobResults finds completed jobs whose result request is deleted or gone
+func (h *Handler) reconcileJobResults(rc *reconciler, opts ReconcileOptions, report *ReconcileReport) error {
+	after := ""
+	for {
+		orphans, err := h.storage.ListJobResultOrphans(after, rc.batchSize)
+		if err != nil {
+			return err
+		}
+		var dangling []string
+		for _, orphan := range orphans {
+			if orphan.RequestID == nil {
+				report.Orphans.LostJobResults.add(orphan.JobID)
+				continue
+			}
+			report.Orphans.DanglingJobResults.add(orphan.JobID)
+			dangling = append(dangling, orphan.JobID)
+		}
+		if opts.Fix && len(dangling) > 0 {
+			cleared, err := h.storage.ClearScrapeJobResults(dangling)
+			if err != nil {
+				return err
+			}
+			report.Orphans.DanglingJobResults.Fixed += int(cleared)
+		}
+		if len(orphans) < rc.batchSize {
+			return nil
+		}
+		after = orphans[len(orphans)-1].JobID
+	}
+}
+
+// reconcileRequests checks live requests against the scraper and the text analyzer in ID order
+func (h *Handler) reconcileRequests(ctx context.Context, rc *reconciler, opts ReconcileOptions, report *ReconcileReport) error {
+	limiter := rate.NewLimiter(rate.Limit(rc.upstreamRate), 1)
+	analysisCutoff := time.Now().Add(-reconcileAnalysisGrace)
+	after := opts.After
+	for {
+		requests, err := h.storage.ListRequestsForReconcile(after, rc.batchSize)
+		if err != nil {
+			return err
+		}
+		for _, req := range requests {
+			if opts.Limit > 0 && report.RequestsScanned >= opts.Limit {
+				report.NextAfter = after
+				return nil
+			}
+			if err := h.reconcileRequest(ctx, limiter, req, analysisCutoff, opts, report); err != nil {
+				// Only a cancelled context stops the run; report where it got to
+				report.NextAfter = after
+				return nil
+			}
+			report.RequestsScanned++
+			after = req.ID
+		}
+		if len(requests) < rc.batchSize {
+			return nil
+		}
+	}
+}
+
+// reconcileRequest checks one request's scrape and pending analysis upstream. It returns an
+// error only when ctx is done.
+func (h *Handler) reconcileRequest(ctx context.Context, limiter *rate.Limiter, req storage.ReconcileRequest, analysisCutoff time.Time, opts ReconcileOptions, report *ReconcileReport) error {
+	flags := map[string]interface{}{}
+
+	if req.ScraperUUID != "" && h.scraper != nil {
+		if err := limiter.Wait(ctx); err != nil {
+			return err
+		}
+		report.UpstreamChecks++
+		exists, err := h.scraper.ScrapeExists(ctx, req.ScraperUUID)
+		switch {
+		case err != nil:
+			report.UpstreamErrors++
+			slog.Default().Warn("failed to check scrape upstream", "request_id", req.ID, "scraper_uuid", req.ScraperUUID, "error", err)
+		case !exists:
+			report.Orphans.MissingScrapes.add(req.ID)
+			flags[storage.MetaScrapeMissing] = true
+		}
+	}
+
+	if analysisPending(req) && req.CreatedAt.Before(analysisCutoff) && h.textAnalyzer != nil {
+		if err := limiter.Wait(ctx); err != nil {
+			return err
+		}
+		report.UpstreamChecks++
+		result, err := h.textAnalyzer.GetAnalysisResult(ctx, req.TextAnalyzerUUID)
+		switch {
+		case errors.Is(err, clients.ErrAnalysisJobNotFound):
+			report.Orphans.MissingAnalyses.add(req.ID)
+			flags[storage.MetaAnalysisMissing] = true
+		case err != nil:
+			report.UpstreamErrors++
+			slog.Default().Warn("failed to check analysis upstream", "request_id", req.ID, "analysis_job_id", req.TextAnalyzerUUID, "error", err)
+		case result.Status == storage.AnalysisStatusCompleted || result.Status == storage.AnalysisStatusFailed:
+			report.Orphans.UnresolvedAnalyses.add(req.ID)
+		}
+	}
+
+	if !opts.Fix || len(flags) == 0 {
+		return nil
+	}
+	if err := h.flagRequest(req.ID, flags, opts.Actor); err != nil {
+		slog.Default().Warn("failed to flag request with missing upstream data", "request_id", req.ID, "error", err)
+		return nil
+	}
+	if _, ok := flags[storage.MetaScrapeMissing]; ok {
+		report.Orphans.MissingScrapes.Fixed++
+	}
+	if _, ok := flags[storage.MetaAnalysisMissing]; ok {
+		report.Orphans.MissingAnalyses.Fixed++
+	}
+	return nil
+}
+
+// analysisPending reports whether a request still waits on an analysis the worker may have
+// lost track of. Timed-out analyses are left to the worker's recovery sweep.
+func analysisPending(req storage.ReconcileRequest) bool {
+	if req.TextAnalyzerUUID == "" || req.AnalysisSkipped {
+		return false
+	}
+	switch req.AnalysisStatus {
+	case "", storage.AnalysisStatusQueued, storage.AnalysisStatusProcessing:
+		return true
+	}
+	return false
+}
+
+// flagRequest merges flags into a request's metadata, recording the change under actor
+func (h *Handler) flagRequest(id string, flags map[string]interface{}, actor string) error {
+	req, err := h.storage.GetRequest(id)
+	if err != nil {
+		return err
+	}
+	metadata := make(map[string]interface{}, len(req.Metadata)+len(flags))
+	for k, v := range req.Metadata {
+		metadata[k] = v
+	}
+	for k, v := range flags {
+		metadata[k] = v
+	}
+	return h.storage.UpdateRequestMetadataAs(id, metadata, actor)
+}
+
+// auditReconcile records the report of a run in the audit log
+func (h *Handler) auditReconcile(actor string, report *ReconcileReport) {
+	orphans := report.Orphans
+	counts := func(c OrphanClass) map[string]interface{} {
+		return map[string]interface{}{"count": c.Count, "fixed": c.Fixed, "sample_ids": c.SampleIDs}
+	}
+	entry := &storage.AuditEntry{
+		Actor:      actor,
+		Action:     storage.AuditActionReconcile,
+		EntityType: storage.AuditEntityReconciliation,
+		EntityID:   report.StartedAt.Format(time.RFC3339),
+		Details: map[string]interface{}{
+			"fix":                  report.Fix,
+			"requests_scanned":     report.RequestsScanned,
+			"upstream_checks":      report.UpstreamChecks,
+			"upstream_errors":      report.UpstreamErrors,
+			"dangling_job_results": counts(orphans.DanglingJobResults),
+			"lost_job_results":     counts(orphans.LostJobResults),
+			"missing_scrapes":      counts(orphans.MissingScrapes),
+			"missing_analyses":     counts(orphans.MissingAnalyses),
+			"unresolved_analyses":  counts(orphans.UnresolvedAnalyses),
+		},
+	}
+	if err := h.storage.RecordAudit(entry); err != nil {
+		slog.Default().Warn("failed to record reconciliation audit entry", "error", err)
+	}
+}
+
+// TriggerReconcile handles POST /api/admin/reconcile?fix=&audit=&after=&limit= and runs a
+// reconciliation now
+func (h *Handler) TriggerReconcile(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	opts := ReconcileOptions{After: query.Get("after"), Actor: auditActor(r)}
+	for name, target := range map[string]*bool{"fix": &opts.Fix, "audit": &opts.Audit} {
+		value := query.Get(name)
+		if value == "" {
+			continue
+		}
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			respondErrorCode(w, ErrCodeValidationFailed, fmt.Sprintf("Invalid %s: must be true or false, got %q", name, value), http.StatusBadRequest)
+			return
+		}
+		*target = parsed
+	}
+	if value := query.Get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 0 {
+			respondErrorCode(w, ErrCodeValidationFailed, fmt.Sprintf("Invalid limit: must be a non-negative integer, got %q", value), http.StatusBadRequest)
+			return
+		}
+		opts.Limit = limit
+	}
+
+	report, err := h.Reconcile(r.Context(), opts)
+	switch {
+	case errors.Is(err, errReconcileDisabled):
+		respondErrorCode(w, ErrCodeNotConfigured, "reconciliation is not configured", http.StatusServiceUnavailable)
+	case errors.Is(err, errReconcileRunning):
+		respondErrorCode(w, ErrCodeInvalidState, "A reconciliation is already running", http.StatusConflict)
+	case err != nil:
+		respondErrorCode(w, ErrCodeInternal, fmt.Sprintf("Failed to reconcile: %v", err), http.StatusInternalServerError)
+	default:
+		respondJSON(w, report, http.StatusOK)
+	}
+}
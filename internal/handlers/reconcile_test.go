@@ -0,0 +1,161 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/docutag/controller/internal/clients"
+	"github.com/docutag/controller/internal/storage"
+)
+
+func TestTriggerReconcileValidation(t *testing.T) {
+	t.Parallel()
+	for _, query := range []string{"fix=yes", "audit=1x", "limit=-1", "limit=ten"} {
+		w := httptest.NewRecorder()
+		serveRoute(&Handler{}, w, httptest.NewRequest(http.MethodPost, "/api/v1/admin/reconcile?"+query, nil))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status 400, got %d: %s", query, w.Code, w.Body.String())
+		}
+	}
+
+	w := httptest.NewRecorder()
+	serveRoute(&Handler{}, w, httptest.NewRequest(http.MethodPost, "/api/v1/admin/reconcile", nil))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected 503 without reconciliation limits, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestReconcileFindsAndFixesOrphans(t *testing.T) {
+	t.Parallel()
+	connStr, cleanup := setupTestDB(t, "test_reconcile_orphans")
+	defer cleanup()
+
+	store, err := storage.New(connStr, []string{"low-quality", "sparse-content"}, 30, 90, 90)
+	if err != nil {
+		t.Fatalf("Failed to create storage: %v", err)
+	}
+	defer store.Close()
+
+	// The scraper lost scrape-gone and the analyzer lost analysis-gone
+	scraper := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/scrape-gone") {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer scraper.Close()
+	analyzer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/analysis-gone"):
+			w.WriteHeader(http.StatusNotFound)
+		case strings.HasSuffix(r.URL.Path, "/analysis-done"):
+			json.NewEncoder(w).Encode(clients.AnalysisJobResult{JobID: "analysis-done", Status: "completed"})
+		default:
+			json.NewEncoder(w).Encode(clients.AnalysisJobResult{Status: "processing"})
+		}
+	}))
+	defer analyzer.Close()
+
+	handler := &Handler{storage: store, scraper: clients.NewScraperClient(scraper.URL), textAnalyzer: clients.NewTextAnalyzerClient(analyzer.URL)}
+	handler.SetReconcile(2, 1000)
+
+	old := time.Now().UTC().Add(-24 * time.Hour)
+	scrapeOK, scrapeGone := "scrape-ok", "scrape-gone"
+	for _, req := range []*storage.Request{
+		{ID: "rec-ok", ScraperUUID: &scrapeOK, TextAnalyzerUUID: "analysis-done", Metadata: map[string]interface{}{storage.MetaAnalysisStatus: storage.AnalysisStatusCompleted}},
+		{ID: "rec-scrape-gone", ScraperUUID: &scrapeGone, Metadata: map[string]interface{}{}},
+		{ID: "rec-analysis-gone", ScraperUUID: &scrapeOK, TextAnalyzerUUID: "analysis-gone", Metadata: map[string]interface{}{storage.MetaAnalysisStatus: storage.AnalysisStatusQueued}},
+		{ID: "rec-analysis-done", ScraperUUID: &scrapeOK, TextAnalyzerUUID: "analysis-done", Metadata: map[string]interface{}{storage.MetaAnalysisStatus: storage.AnalysisStatusProcessing}},
+		{ID: "rec-deleted", ScraperUUID: &scrapeGone, Metadata: map[string]interface{}{}},
+	} {
+		req.CreatedAt = old
+		req.SourceType = "url"
+		req.Tags = []string{}
+		if err := store.SaveRequest(req); err != nil {
+			t.Fatalf("Failed to save request: %v", err)
+		}
+	}
+	if _, err := store.SoftDeleteRequest("rec-deleted"); err != nil {
+		t.Fatalf("Failed to delete request: %v", err)
+	}
+	live, deleted := "rec-ok", "rec-deleted"
+	for _, job := range []*storage.ScrapeJob{
+		{ID: "rec-job-ok", Status: "completed", ResultRequestID: &live},
+		{ID: "rec-job-dangling", Status: "completed", ResultRequestID: &deleted},
+		{ID: "rec-job-lost", Status: "completed"},
+		{ID: "rec-job-skipped", Status: "completed", SkipReason: "robots_txt"},
+	} {
+		job.URL = "https://example.com/" + job.ID
+		job.CreatedAt = old
+		job.UpdatedAt = old
+		if err := store.SaveScrapeJob(job); err != nil {
+			t.Fatalf("Failed to save job: %v", err)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	serveRoute(handler, w, httptest.NewRequest(http.MethodPost, "/api/v1/admin/reconcile", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var report ReconcileReport
+	if err := json.Unmarshal(w.Body.Bytes(), &report); err != nil {
+		t.Fatalf("Failed to decode report: %v", err)
+	}
+	orphans := report.Orphans
+	if report.RequestsScanned != 4 || orphans.DanglingJobResults.Count != 1 || orphans.LostJobResults.Count != 1 ||
+		orphans.MissingScrapes.Count != 1 || orphans.MissingAnalyses.Count != 1 || orphans.UnresolvedAnalyses.Count != 1 {
+		t.Fatalf("Unexpected report %+v", report)
+	}
+	if orphans.MissingScrapes.SampleIDs[0] != "rec-scrape-gone" || orphans.DanglingJobResults.SampleIDs[0] != "rec-job-dangling" {
+		t.Errorf("Unexpected sample IDs %+v", orphans)
+	}
+	if orphans.MissingScrapes.Fixed != 0 {
+		t.Errorf("Expected nothing fixed without fix=true, got %+v", orphans.MissingScrapes)
+	}
+
+	// A limited run stops where it can be resumed
+	limited, err := handler.Reconcile(t.Context(), ReconcileOptions{Limit: 1})
+	if err != nil {
+		t.Fatalf("Reconcile failed: %v", err)
+	}
+	if limited.RequestsScanned != 1 || limited.NextAfter != "rec-analysis-done" {
+		t.Errorf("Expected to stop after the first request, got %+v", limited)
+	}
+
+	fixed, err := handler.Reconcile(t.Context(), ReconcileOptions{Fix: true, Actor: storage.AuditActorSystem})
+	if err != nil {
+		t.Fatalf("Reconcile failed: %v", err)
+	}
+	if fixed.Orphans.DanglingJobResults.Fixed != 1 || fixed.Orphans.MissingScrapes.Fixed != 1 || fixed.Orphans.MissingAnalyses.Fixed != 1 {
+		t.Fatalf("Expected the fixable orphans to be fixed, got %+v", fixed.Orphans)
+	}
+	job, err := store.GetScrapeJob("rec-job-dangling")
+	if err != nil {
+		t.Fatalf("Failed to get job: %v", err)
+	}
+	if job.ResultRequestID != nil {
+		t.Errorf("Expected the dangling result to be cleared, got %v", *job.ResultRequestID)
+	}
+	for id, key := range map[string]string{"rec-scrape-gone": storage.MetaScrapeMissing, "rec-analysis-gone": storage.MetaAnalysisMissing} {
+		req, err := store.GetRequest(id)
+		if err != nil {
+			t.Fatalf("Failed to get request: %v", err)
+		}
+		if req.Metadata[key] != true {
+			t.Errorf("Expected %s to be flagged with %s, got %v", id, key, req.Metadata)
+		}
+	}
+	entries, err := store.ListAuditEntries(storage.AuditFilter{Action: storage.AuditActionReconcile, Limit: 10})
+	if err != nil {
+		t.Fatalf("Failed to list audit entries: %v", err)
+	}
+	if len(entries) != 1 || entries[0].EntityType != storage.AuditEntityReconciliation {
+		t.Errorf("Expected one reconciliation audit entry, got %+v", entries)
+	}
+}
@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/docutag/controller/internal/storage"
+)
+
+// defaultRequestChangesLimit is how many changes GetRequestChanges returns without ?limit
+const defaultRequestChangesLimit = 20
+
+// RequestChangesResponse is the body of GET /api/requests/{id}/changes
+type RequestChangesResponse struct {
+	ID      string                   `json:"id"`
+	Changes []*storage.RequestChange `json:"changes"`
+	Count   int                      `json:"count"`
+	Limit   int                      `json:"limit"`
+	Offset  int                      `json:"offset"`
+}
+
+// GetRequestChanges handles GET /api/requests/{id}/changes?limit=&offset=, listing the recorded
+// metadata changes of a request oldest first. offset skips that many of the newest changes.
+func (h *Handler) GetRequestChanges(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	pg, err := h.pageFromQuery(r, defaultRequestChangesLimit)
+	if err != nil {
+		respondErrorCode(w, ErrCodeValidationFailed, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Changes of a soft-deleted request are hidden along with the request itself
+	if _, err := h.store(r).GetRequest(id); err != nil {
+		if err.Error() == "request not found" {
+			respondErrorCode(w, ErrCodeRequestNotFound, "Request not found", http.StatusNotFound)
+			return
+		}
+		respondErrorCode(w, ErrCodeInternal, fmt.Sprintf("Failed to get request: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	changes, err := h.store(r).ListRequestChanges(id, pg.Limit, pg.Offset)
+	if err != nil {
+		respondErrorCode(w, ErrCodeInternal, fmt.Sprintf("Failed to list changes: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	respondJSON(w, RequestChangesResponse{
+		ID:      id,
+		Changes: changes,
+		Count:   len(changes),
+		Limit:   pg.Limit,
+		Offset:  pg.Offset,
+	}, http.StatusOK)
+}
@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/docutag/controller/internal/storage"
+)
+
+func TestGetRequestChangesInvalidLimit(t *testing.T) {
+	t.Parallel()
+	// The page is validated before storage is touched, so a bare handler is enough
+	h := &Handler{}
+	w := httptest.NewRecorder()
+	serveRoute(h, w, httptest.NewRequest(http.MethodGet, "/api/v1/requests/any/changes?limit=0", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400, got %d", w.Code)
+	}
+}
+
+func TestGetRequestChanges(t *testing.T) {
+	t.Parallel()
+	handler, _, _, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	if err := handler.storage.SaveRequest(&storage.Request{
+		ID: "changing", CreatedAt: time.Now(), SourceType: "text", Metadata: map[string]interface{}{"title": "A"},
+	}); err != nil {
+		t.Fatalf("Failed to save request: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodPut, "/api/v1/requests/changing/tombstone", nil)
+	req.Header.Set("X-API-Key", "editor-key")
+	w := httptest.NewRecorder()
+	serveRoute(handler, w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Failed to tombstone: %d %s", w.Code, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	serveRoute(handler, w, httptest.NewRequest(http.MethodGet, "/api/v1/requests/changing/changes", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp RequestChangesResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if resp.Count != 1 || resp.Limit != defaultRequestChangesLimit {
+		t.Fatalf("Expected one change with the default limit, got %+v", resp)
+	}
+	change := resp.Changes[0]
+	if change.Actor != auditActor(req) {
+		t.Errorf("Expected the change to be attributed to the caller, got %q", change.Actor)
+	}
+	if len(change.Changes) != 1 || change.Changes[0].Key != "tombstone_datetime" || change.Changes[0].Type != storage.MetadataChangeAdded {
+		t.Errorf("Expected tombstone_datetime to be added, got %+v", change.Changes)
+	}
+
+	w = httptest.NewRecorder()
+	serveRoute(handler, w, httptest.NewRequest(http.MethodGet, "/api/v1/requests/missing/changes", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404, got %d", w.Code)
+	}
+}
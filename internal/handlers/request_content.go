@@ -0,0 +1,240 @@
+package handlers
+
+import (
+	"compress/gzip"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/docutag/controller/internal/pipeline"
+	"github.com/docutag/controller/internal/storage"
+)
+
+// rawTextEncodingGzip marks raw text stored the way pipeline.CompressHTML writes it
+const rawTextEncodingGzip = "gzip+base64"
+
+// defaultRawHTMLMaxBytes caps the raw HTML GET /api/requests/{id}/content returns when
+// SetRawHTMLMaxBytes is given 0
+const defaultRawHTMLMaxBytes = 10 << 20
+
+// SetRawHTMLMaxBytes caps the decompressed raw HTML GET /api/requests/{id}/content returns.
+// Values <= 0 use the default.
+func (h *Handler) SetRawHTMLMaxBytes(maxBytes int64) {
+	if maxBytes <= 0 {
+		maxBytes = defaultRawHTMLMaxBytes
+	}
+	h.rawHTMLMaxBytes = maxBytes
+}
+
+// rawHTMLLimit returns the raw HTML cap, falling back to the default on handlers built without one
+func (h *Handler) rawHTMLLimit() int64 {
+	if h.rawHTMLMaxBytes <= 0 {
+		return defaultRawHTMLMaxBytes
+	}
+	return h.rawHTMLMaxBytes
+}
+
+// bulkyContentFields are the metadata fields, by parent object ("" for the top level), that
+// GET /api/requests/{id} leaves out unless include=content asks for them
+var bulkyContentFields = map[string][]string{
+	"":                  {"original_text"},
+	"scraper_metadata":  {"content", "raw_text"},
+	"analyzer_metadata": {"cleaned_text"},
+}
+
+// RequestContentResponse is returned by GET /api/requests/{id}/content. Fields the request
+// does not have are omitted.
+type RequestContentResponse struct {
+	ID              string `json:"id"`
+	OriginalText    string `json:"original_text,omitempty"`     // Text submitted for analysis
+	CleanedText     string `json:"cleaned_text,omitempty"`      // The analyzer's cleaned text
+	RawText         string `json:"raw_text,omitempty"`          // The scraped page's raw text
+	RawTextEncoding string `json:"raw_text_encoding,omitempty"` // "gzip+base64" while raw_text is still compressed
+	Content         string `json:"content,omitempty"`           // The scraper's extracted content
+}
+
+// withoutBulkyContent returns metadata without bulkyContentFields. Objects that lose a field
+// are copied, so the stored request is left as it was.
+func withoutBulkyContent(metadata map[string]interface{}) map[string]interface{} {
+	stripped := make(map[string]interface{}, len(metadata))
+	for k, v := range metadata {
+		stripped[k] = v
+	}
+	for parent, fields := range bulkyContentFields {
+		target := stripped
+		if parent != "" {
+			obj, ok := stripped[parent].(map[string]interface{})
+			if !ok {
+				continue
+			}
+			target = make(map[string]interface{}, len(obj))
+			for k, v := range obj {
+				target[k] = v
+			}
+			stripped[parent] = target
+		}
+		for _, field := range fields {
+			delete(target, field)
+		}
+	}
+	return stripped
+}
+
+// newRequestContent collects the bulky text of a request. With decompress, raw text stored
+// gzipped and base64 encoded is returned as plain text if it fits in maxBytes.
+func newRequestContent(record *storage.Request, decompress bool, maxBytes int64) (*RequestContentResponse, error) {
+	text := func(parent, field string) string {
+		obj := record.Metadata
+		if parent != "" {
+			obj, _ = record.Metadata[parent].(map[string]interface{})
+		}
+		s, _ := obj[field].(string)
+		return s
+	}
+	resp := &RequestContentResponse{
+		ID:           record.ID,
+		OriginalText: text("", "original_text"),
+		CleanedText:  text("analyzer_metadata", "cleaned_text"),
+		RawText:      text("scraper_metadata", "raw_text"),
+		Content:      text("scraper_metadata", "content"),
+	}
+	if isGzipBase64(resp.RawText) {
+		resp.RawTextEncoding = rawTextEncodingGzip
+		if decompress {
+			plain, err := pipeline.DecompressHTML(resp.RawText, maxBytes)
+			if err != nil {
+				return nil, err
+			}
+			resp.RawText, resp.RawTextEncoding = plain, ""
+		}
+	}
+	return resp, nil
+}
+
+// isGzipBase64 reports whether s looks like base64 encoded gzip data, whose magic bytes
+// 1f 8b 08 always encode to "H4sI"
+func isGzipBase64(s string) bool {
+	return strings.HasPrefix(s, "H4sI")
+}
+
+// acceptsGzip reports whether the client's Accept-Encoding allows a gzip response
+func acceptsGzip(r *http.Request) bool {
+	for _, part := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		coding, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if coding != "gzip" && coding != "*" {
+			continue
+		}
+		if q, ok := strings.CutPrefix(strings.ReplaceAll(params, " ", ""), "q="); ok {
+			if v, err := strconv.ParseFloat(q, 64); err == nil && v == 0 {
+				continue
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// GetRequestContent handles GET /api/requests/{id}/content?decompress=&raw_html= and returns
+// the bulky text fields that GET /api/requests/{id} leaves out of the metadata
+func (h *Handler) GetRequestContent(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	flags := map[string]bool{"decompress": false, "raw_html": false}
+	for name := range flags {
+		if value := r.URL.Query().Get(name); value != "" {
+			parsed, err := strconv.ParseBool(value)
+			if err != nil {
+				respondErrorCode(w, ErrCodeValidationFailed, fmt.Sprintf("Invalid %s: must be true or false, got %q", name, value), http.StatusBadRequest)
+				return
+			}
+			flags[name] = parsed
+		}
+	}
+
+	record, err := h.store(r).GetRequest(id)
+	if err != nil {
+		if err.Error() == "request not found" {
+			respondErrorCode(w, ErrCodeRequestNotFound, "Request not found", http.StatusNotFound)
+			return
+		}
+		respondErrorCode(w, ErrCodeInternal, fmt.Sprintf("Failed to get request: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	if flags["raw_html"] {
+		h.respondRawHTML(w, r, record)
+		return
+	}
+	resp, err := newRequestContent(record, flags["decompress"], h.rawHTMLLimit())
+	if err != nil {
+		h.respondRawHTMLError(w, err)
+		return
+	}
+	w.Header().Set("ETag", requestETag(record.RowVersion))
+	respondJSON(w, resp, http.StatusOK)
+}
+
+// respondRawHTML writes the request's scraped raw HTML as the response body. It is served as
+// text/plain so browsers never render the scraped page on this origin. Compressed HTML is
+// checked against the size cap and then passed through as-is to clients that accept gzip;
+// stored plain HTML is gzipped for them on the way out.
+func (h *Handler) respondRawHTML(w http.ResponseWriter, r *http.Request, record *storage.Request) {
+	scraperMeta, _ := record.Metadata["scraper_metadata"].(map[string]interface{})
+	stored, _ := scraperMeta["raw_text"].(string)
+	if stored == "" {
+		respondErrorCode(w, ErrCodeNotFound, "Request has no raw HTML", http.StatusNotFound)
+		return
+	}
+
+	limit := h.rawHTMLLimit()
+	html := stored
+	if isGzipBase64(stored) {
+		plain, err := pipeline.DecompressHTML(stored, limit)
+		if err != nil {
+			h.respondRawHTMLError(w, err)
+			return
+		}
+		html = plain
+	} else if int64(len(html)) > limit {
+		h.respondRawHTMLError(w, pipeline.ErrHTMLTooLarge)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("ETag", requestETag(record.RowVersion))
+	w.Header().Add("Vary", "Accept-Encoding")
+	if !acceptsGzip(r) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(html))
+		return
+	}
+
+	w.Header().Set("Content-Encoding", "gzip")
+	if isGzipBase64(stored) {
+		compressed, _ := base64.StdEncoding.DecodeString(stored) // DecompressHTML has validated it
+		w.Header().Set("Content-Length", strconv.Itoa(len(compressed)))
+		w.WriteHeader(http.StatusOK)
+		w.Write(compressed)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	gz := gzip.NewWriter(w)
+	gz.Write([]byte(html))
+	gz.Close()
+}
+
+// respondRawHTMLError maps a DecompressHTML failure to its response
+func (h *Handler) respondRawHTMLError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, pipeline.ErrHTMLTooLarge):
+		respondErrorDetails(w, ErrCodeContentTooLarge, fmt.Sprintf("Raw HTML is larger than the %d byte limit", h.rawHTMLLimit()), http.StatusRequestEntityTooLarge,
+			map[string]interface{}{"max_bytes": h.rawHTMLLimit()})
+	case errors.Is(err, pipeline.ErrCorruptHTML):
+		respondErrorCode(w, ErrCodeCorruptContent, fmt.Sprintf("Stored raw HTML cannot be decompressed: %v", err), http.StatusInternalServerError)
+	default:
+		respondErrorCode(w, ErrCodeInternal, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,279 @@
+package handlers
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/docutag/controller/internal/storage"
+)
+
+// gzipBase64 compresses s the way pipeline.CompressHTML does
+func gzipBase64(t *testing.T, s string) string {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	if _, err := gz.Write([]byte(s)); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return base64.StdEncoding.EncodeToString(buf.Bytes())
+}
+
+func bulkyMetadata(rawText string) map[string]interface{} {
+	return map[string]interface{}{
+		"title":         "Example",
+		"original_text": "Submitted text",
+		"scraper_metadata": map[string]interface{}{
+			"title":    "Example",
+			"content":  "Extracted content",
+			"raw_text": rawText,
+		},
+		"analyzer_metadata": map[string]interface{}{
+			"synopsis":     "Short",
+			"cleaned_text": "Cleaned text",
+		},
+	}
+}
+
+func TestWithoutBulkyContent(t *testing.T) {
+	t.Parallel()
+	metadata := bulkyMetadata("Raw text")
+
+	stripped := withoutBulkyContent(metadata)
+	if _, ok := stripped["original_text"]; ok {
+		t.Error("Expected original_text to be removed")
+	}
+	scraper := stripped["scraper_metadata"].(map[string]interface{})
+	if _, ok := scraper["content"]; ok {
+		t.Error("Expected scraper_metadata.content to be removed")
+	}
+	if _, ok := scraper["raw_text"]; ok {
+		t.Error("Expected scraper_metadata.raw_text to be removed")
+	}
+	analyzer := stripped["analyzer_metadata"].(map[string]interface{})
+	if _, ok := analyzer["cleaned_text"]; ok {
+		t.Error("Expected analyzer_metadata.cleaned_text to be removed")
+	}
+	if stripped["title"] != "Example" || scraper["title"] != "Example" || analyzer["synopsis"] != "Short" {
+		t.Errorf("Expected other fields to be kept, got %v", stripped)
+	}
+
+	// The stored metadata is not modified
+	if metadata["original_text"] != "Submitted text" ||
+		metadata["scraper_metadata"].(map[string]interface{})["raw_text"] != "Raw text" ||
+		metadata["analyzer_metadata"].(map[string]interface{})["cleaned_text"] != "Cleaned text" {
+		t.Errorf("Expected the original metadata to be untouched, got %v", metadata)
+	}
+
+	// Metadata without the objects is returned as is
+	if got := withoutBulkyContent(map[string]interface{}{"scraper_metadata": "not an object"}); got["scraper_metadata"] != "not an object" {
+		t.Errorf("Expected a non-object scraper_metadata to be kept, got %v", got)
+	}
+}
+
+func TestNewRequestContent(t *testing.T) {
+	t.Parallel()
+	compressed := gzipBase64(t, "<p>Raw text</p>")
+
+	tests := []struct {
+		name         string
+		rawText      string
+		decompress   bool
+		wantRawText  string
+		wantEncoding string
+		wantErr      bool
+	}{
+		{"plain", "Raw text", false, "Raw text", "", false},
+		{"plain with decompress", "Raw text", true, "Raw text", "", false},
+		{"compressed", compressed, false, compressed, rawTextEncodingGzip, false},
+		{"compressed with decompress", compressed, true, "<p>Raw text</p>", "", false},
+		{"corrupt with decompress", "H4sI!!!!", true, "", "", true},
+		{"corrupt without decompress", "H4sI!!!!", false, "H4sI!!!!", rawTextEncodingGzip, false},
+	}
+	for _, tt := range tests {
+		record := &storage.Request{ID: "req-1", Metadata: bulkyMetadata(tt.rawText)}
+		resp, err := newRequestContent(record, tt.decompress, defaultRawHTMLMaxBytes)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("%s: expected an error", tt.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		want := RequestContentResponse{
+			ID:              "req-1",
+			OriginalText:    "Submitted text",
+			CleanedText:     "Cleaned text",
+			RawText:         tt.wantRawText,
+			RawTextEncoding: tt.wantEncoding,
+			Content:         "Extracted content",
+		}
+		if *resp != want {
+			t.Errorf("%s: expected %+v, got %+v", tt.name, want, *resp)
+		}
+	}
+
+	// A request without the texts has an empty response
+	resp, err := newRequestContent(&storage.Request{ID: "req-2"}, true, defaultRawHTMLMaxBytes)
+	if err != nil || *resp != (RequestContentResponse{ID: "req-2"}) {
+		t.Errorf("Expected only the ID, got %+v, %v", resp, err)
+	}
+}
+
+func TestGetRequestContent(t *testing.T) {
+	t.Parallel()
+	handler, _, _, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	if err := handler.storage.SaveRequest(&storage.Request{ID: "content-1", CreatedAt: time.Now().UTC(), SourceType: "url",
+		Metadata: bulkyMetadata("Raw text")}); err != nil {
+		t.Fatalf("Failed to save request: %v", err)
+	}
+
+	get := func(path string) (*httptest.ResponseRecorder, map[string]interface{}) {
+		w := httptest.NewRecorder()
+		serveRoute(handler, w, httptest.NewRequest(http.MethodGet, path, nil))
+		var body map[string]interface{}
+		json.Unmarshal(w.Body.Bytes(), &body)
+		return w, body
+	}
+
+	w, body := get("/api/v1/requests/content-1")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	metadata := body["metadata"].(map[string]interface{})
+	if _, ok := metadata["original_text"]; ok {
+		t.Error("Expected original_text to be left out by default")
+	}
+	if _, ok := metadata["scraper_metadata"].(map[string]interface{})["raw_text"]; ok {
+		t.Error("Expected scraper_metadata.raw_text to be left out by default")
+	}
+
+	_, body = get("/api/v1/requests/content-1?include=content")
+	if metadata := body["metadata"].(map[string]interface{}); metadata["original_text"] != "Submitted text" {
+		t.Errorf("Expected include=content to keep original_text, got %v", metadata)
+	}
+
+	w, body = get("/api/v1/requests/content-1/content")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if body["original_text"] != "Submitted text" || body["cleaned_text"] != "Cleaned text" ||
+		body["raw_text"] != "Raw text" || body["content"] != "Extracted content" {
+		t.Errorf("Expected the texts intact, got %v", body)
+	}
+	if w.Header().Get("ETag") == "" {
+		t.Error("Expected an ETag header")
+	}
+
+	if w, _ := get("/api/v1/requests/missing/content"); w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for a missing request, got %d", w.Code)
+	}
+}
+
+func TestGetRequestContentInvalidDecompress(t *testing.T) {
+	t.Parallel()
+	h := &Handler{}
+	w := httptest.NewRecorder()
+	serveRoute(h, w, httptest.NewRequest(http.MethodGet, "/api/v1/requests/req-1/content?decompress=maybe", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestRespondRawHTML(t *testing.T) {
+	t.Parallel()
+	html := "<html><body><p>" + strings.Repeat("Raw text ", 50) + "</p></body></html>"
+	compressed := gzipBase64(t, html)
+
+	tests := []struct {
+		name         string
+		stored       string
+		gzip         bool
+		maxBytes     int64
+		wantStatus   int
+		wantEncoding string
+	}{
+		{"plain", html, false, 0, http.StatusOK, ""},
+		{"plain gzipped on the way out", html, true, 0, http.StatusOK, "gzip"},
+		{"compressed inflated", compressed, false, 0, http.StatusOK, ""},
+		{"compressed passed through", compressed, true, 0, http.StatusOK, "gzip"},
+		{"compressed over the cap", compressed, true, int64(len(html) - 1), http.StatusRequestEntityTooLarge, ""},
+		{"plain over the cap", html, false, int64(len(html) - 1), http.StatusRequestEntityTooLarge, ""},
+		{"corrupt", "H4sI!!!!", false, 0, http.StatusInternalServerError, ""},
+		{"missing", "", false, 0, http.StatusNotFound, ""},
+	}
+	for _, tt := range tests {
+		h := &Handler{rawHTMLMaxBytes: tt.maxBytes}
+		record := &storage.Request{ID: "req-1", RowVersion: 3, Metadata: bulkyMetadata(tt.stored)}
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/requests/req-1/content?raw_html=true", nil)
+		if tt.gzip {
+			req.Header.Set("Accept-Encoding", "gzip, deflate")
+		}
+		w := httptest.NewRecorder()
+		h.respondRawHTML(w, req, record)
+
+		if w.Code != tt.wantStatus {
+			t.Errorf("%s: expected %d, got %d: %s", tt.name, tt.wantStatus, w.Code, w.Body.String())
+			continue
+		}
+		if tt.wantStatus != http.StatusOK {
+			continue
+		}
+		if got := w.Header().Get("Content-Encoding"); got != tt.wantEncoding {
+			t.Errorf("%s: expected Content-Encoding %q, got %q", tt.name, tt.wantEncoding, got)
+		}
+		if got := w.Header().Get("Content-Type"); got != "text/plain; charset=utf-8" {
+			t.Errorf("%s: expected text/plain, got %q", tt.name, got)
+		}
+		body := w.Body.Bytes()
+		if tt.wantEncoding == "gzip" {
+			gz, err := gzip.NewReader(bytes.NewReader(body))
+			if err != nil {
+				t.Fatalf("%s: body is not gzip: %v", tt.name, err)
+			}
+			if body, err = io.ReadAll(gz); err != nil {
+				t.Fatalf("%s: %v", tt.name, err)
+			}
+		}
+		if string(body) != html {
+			t.Errorf("%s: expected the original HTML, got %q", tt.name, body)
+		}
+	}
+}
+
+func TestAcceptsGzip(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		header string
+		want   bool
+	}{
+		{"", false},
+		{"gzip", true},
+		{"deflate, gzip;q=0.8", true},
+		{"*", true},
+		{"gzip;q=0", false},
+		{"gzip; q=0, br", false},
+		{"br, identity", false},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Accept-Encoding", tt.header)
+		if got := acceptsGzip(req); got != tt.want {
+			t.Errorf("acceptsGzip(%q) = %v, want %v", tt.header, got, tt.want)
+		}
+	}
+}
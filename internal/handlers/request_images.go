@@ -0,0 +1,155 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/docutag/controller/internal/clients"
+)
+
+const (
+	// requestImagesTimeout bounds the scraper call GET /api/requests/{id}?include=images makes,
+	// so a slow scraper delays the detail view by at most this long
+	requestImagesTimeout = 3 * time.Second
+	// requestImagesCacheTTL is how long an image list is reused for the same scraper UUID
+	requestImagesCacheTTL = 30 * time.Second
+	// requestImagesCacheSize is the most scraper UUIDs whose image lists are kept at once
+	requestImagesCacheSize = 1000
+)
+
+// RequestImage is the trimmed image summary embedded in a request's detail response
+type RequestImage struct {
+	ID         string `json:"id"`
+	URL        string `json:"url"`
+	AltText    string `json:"alt_text"`
+	Slug       string `json:"slug,omitempty"`
+	Tombstoned bool   `json:"tombstoned"`
+}
+
+// RequestDetailResponse is a request with the images the scraper stored for it, returned by
+// GET /api/requests/{id}?include=images
+type RequestDetailResponse struct {
+	ControllerResponse
+	Images      []RequestImage `json:"images"`
+	ImagesError string         `json:"images_error,omitempty"` // Set when the scraper could not be asked; images is then empty
+}
+
+// parseInclude reads the comma-separated include query parameter, rejecting values the
+// endpoint does not know so a typo is not silently ignored
+func parseInclude(value string, known ...string) (map[string]bool, error) {
+	return parseOptionList("include", value, known...)
+}
+
+// parseOptionList reads a comma-separated query parameter such as include or expand, rejecting
+// values not in known
+func parseOptionList(param, value string, known ...string) (map[string]bool, error) {
+	include := make(map[string]bool)
+	if value == "" {
+		return include, nil
+	}
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		valid := false
+		for _, k := range known {
+			valid = valid || part == k
+		}
+		if !valid {
+			return nil, fmt.Errorf("Invalid %s %q: must be one of %s", param, part, strings.Join(known, ", "))
+		}
+		include[part] = true
+	}
+	return include, nil
+}
+
+// requestImages returns the trimmed image list of a scraped request. Requests without a scraper
+// UUID have no images. A scraper failure is returned as a message for images_error rather than
+// an error, so the detail view still renders.
+func (h *Handler) requestImages(ctx context.Context, scraperUUID *string) ([]RequestImage, string) {
+	if scraperUUID == nil || *scraperUUID == "" {
+		return []RequestImage{}, ""
+	}
+	if images, ok := h.imageSummaries.get(*scraperUUID); ok {
+		return images, ""
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, requestImagesTimeout)
+	defer cancel()
+	resp, err := h.scraper.GetImagesByScrapeID(ctx, *scraperUUID, clients.ImageListOptions{IncludeTombstoned: true})
+	if err != nil {
+		slog.Warn("failed to load images for request detail", "scraper_uuid", *scraperUUID, "error", err)
+		return []RequestImage{}, fmt.Sprintf("Failed to retrieve images: %v", err)
+	}
+
+	images := make([]RequestImage, 0, len(resp.Images))
+	for _, image := range resp.Images {
+		images = append(images, RequestImage{
+			ID:         image.ID,
+			URL:        image.URL,
+			AltText:    image.AltText,
+			Slug:       image.Slug,
+			Tombstoned: image.TombstoneDatetime != nil,
+		})
+	}
+	h.imageSummaries.put(*scraperUUID, images)
+	return images, ""
+}
+
+// imageSummaryCache keeps recent image lists keyed by scraper UUID so a detail view polled in
+// a loop does not call the scraper on every request. Failures are not cached.
+type imageSummaryCache struct {
+	ttl     time.Duration
+	maxSize int
+	now     func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]cachedImageSummaries
+}
+
+type cachedImageSummaries struct {
+	images  []RequestImage
+	expires time.Time
+}
+
+func newImageSummaryCache(ttl time.Duration, maxSize int) *imageSummaryCache {
+	return &imageSummaryCache{ttl: ttl, maxSize: maxSize, now: time.Now, entries: make(map[string]cachedImageSummaries)}
+}
+
+// get returns the cached image list of scraperUUID if it has not expired. A nil cache never hits.
+func (c *imageSummaryCache) get(scraperUUID string) ([]RequestImage, bool) {
+	if c == nil {
+		return nil, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[scraperUUID]
+	if !ok || !c.now().Before(entry.expires) {
+		return nil, false
+	}
+	return entry.images, true
+}
+
+// put caches the image list of scraperUUID. When the cache is full, expired entries are dropped
+// first and, if that frees nothing, the whole cache is cleared.
+func (c *imageSummaryCache) put(scraperUUID string, images []RequestImage) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := c.now()
+	if _, ok := c.entries[scraperUUID]; !ok && len(c.entries) >= c.maxSize {
+		for key, entry := range c.entries {
+			if !now.Before(entry.expires) {
+				delete(c.entries, key)
+			}
+		}
+		if len(c.entries) >= c.maxSize {
+			c.entries = make(map[string]cachedImageSummaries)
+		}
+	}
+	c.entries[scraperUUID] = cachedImageSummaries{images: images, expires: now.Add(c.ttl)}
+}
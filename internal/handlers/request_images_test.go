@@ -0,0 +1,228 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httputil"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/docutag/controller/internal/clients"
+	"github.com/docutag/controller/internal/storage"
+)
+
+// countingImagesScraper wraps mockScraperServer, counting calls to its /api/scrapes/{id}/images route
+func countingImagesScraper(t *testing.T, calls *int32) *httptest.Server {
+	t.Helper()
+	inner := mockScraperServer()
+	t.Cleanup(inner.Close)
+	target, _ := url.Parse(inner.URL)
+	proxy := httputil.NewSingleHostReverseProxy(target)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/api/scrapes/") && strings.HasSuffix(r.URL.Path, "/images") {
+			atomic.AddInt32(calls, 1)
+		}
+		proxy.ServeHTTP(w, r)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestParseInclude(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		value   string
+		want    []string
+		wantErr bool
+	}{
+		{"", nil, false},
+		{"images", []string{"images"}, false},
+		{"images, images", []string{"images"}, false},
+		{"image", nil, true},
+		{"images,links", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := parseInclude(tt.value, "images")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Expected error %v, got %v", tt.wantErr, err)
+			}
+			if tt.wantErr {
+				return
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("Expected %v, got %v", tt.want, got)
+			}
+			for _, name := range tt.want {
+				if !got[name] {
+					t.Errorf("Expected %q to be included, got %v", name, got)
+				}
+			}
+		})
+	}
+}
+
+func TestRequestImages(t *testing.T) {
+	t.Parallel()
+	var calls int32
+	scraper := countingImagesScraper(t, &calls)
+	h := &Handler{
+		scraper:        clients.NewScraperClient(scraper.URL),
+		imageSummaries: newImageSummaryCache(time.Minute, 10),
+	}
+	uuid := "scraper-test-uuid"
+
+	images, msg := h.requestImages(context.Background(), &uuid)
+	if msg != "" {
+		t.Fatalf("Unexpected images error: %s", msg)
+	}
+	// The mock scraper has five images; the tombstoned one is included and flagged
+	if len(images) != 5 {
+		t.Fatalf("Expected 5 images, got %d", len(images))
+	}
+	first := images[0]
+	if first.ID != "scrape-img-1" || first.URL != "https://example.com/scrape-img-1.jpg" || first.AltText != "Test Image" || first.Tombstoned {
+		t.Errorf("Unexpected first image: %+v", first)
+	}
+	if !images[4].Tombstoned {
+		t.Errorf("Expected the last image to be flagged tombstoned, got %+v", images[4])
+	}
+
+	// A second detail view within the TTL is served from the cache
+	if again, _ := h.requestImages(context.Background(), &uuid); len(again) != 5 {
+		t.Errorf("Expected the cached 5 images, got %d", len(again))
+	}
+	if n := atomic.LoadInt32(&calls); n != 1 {
+		t.Errorf("Expected 1 scraper call, got %d", n)
+	}
+
+	// Requests that were never scraped have no images and do not call the scraper
+	for _, none := range []*string{nil, new(string)} {
+		images, msg := h.requestImages(context.Background(), none)
+		if images == nil || len(images) != 0 || msg != "" {
+			t.Errorf("Expected an empty list without error, got %v, %q", images, msg)
+		}
+	}
+	if n := atomic.LoadInt32(&calls); n != 1 {
+		t.Errorf("Expected no further scraper calls, got %d", n)
+	}
+}
+
+func TestRequestImagesScraperFailure(t *testing.T) {
+	t.Parallel()
+	var calls int32
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer failing.Close()
+	h := &Handler{
+		scraper:        clients.NewScraperClient(failing.URL),
+		imageSummaries: newImageSummaryCache(time.Minute, 10),
+	}
+	uuid := "scraper-test-uuid"
+
+	for i := 0; i < 2; i++ {
+		images, msg := h.requestImages(context.Background(), &uuid)
+		if images == nil || len(images) != 0 {
+			t.Errorf("Expected an empty list, got %v", images)
+		}
+		if msg == "" {
+			t.Error("Expected an images error")
+		}
+	}
+	// Failures are not cached, so the next view tries again
+	if n := atomic.LoadInt32(&calls); n < 2 {
+		t.Errorf("Expected each view to call the scraper, got %d calls", n)
+	}
+}
+
+func TestImageSummaryCache(t *testing.T) {
+	t.Parallel()
+	now := time.Now()
+	c := newImageSummaryCache(time.Minute, 2)
+	c.now = func() time.Time { return now }
+	images := []RequestImage{{ID: "img-1"}}
+
+	c.put("a", images)
+	if got, ok := c.get("a"); !ok || len(got) != 1 {
+		t.Fatalf("Expected a cache hit, got %v, %v", got, ok)
+	}
+
+	now = now.Add(time.Minute)
+	if _, ok := c.get("a"); ok {
+		t.Error("Expected the entry to expire after the TTL")
+	}
+
+	// A full cache drops expired entries to make room
+	c.put("b", images)
+	c.put("c", images)
+	if _, ok := c.entries["a"]; ok {
+		t.Error("Expected the expired entry to be evicted")
+	}
+	if _, ok := c.get("b"); !ok {
+		t.Error("Expected the live entry to be kept")
+	}
+
+	var nilCache *imageSummaryCache
+	nilCache.put("a", images)
+	if _, ok := nilCache.get("a"); ok {
+		t.Error("Expected a nil cache never to hit")
+	}
+}
+
+func TestGetRequestIncludeImages(t *testing.T) {
+	t.Parallel()
+	handler, _, _, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	scraperUUID := "scraper-test-uuid"
+	sourceURL := "https://example.com/with-images"
+	for _, record := range []*storage.Request{
+		{ID: "with-images", CreatedAt: time.Now(), SourceType: "url", SourceURL: &sourceURL, ScraperUUID: &scraperUUID},
+		{ID: "text-only", CreatedAt: time.Now(), SourceType: "text"},
+	} {
+		if err := handler.storage.SaveRequest(record); err != nil {
+			t.Fatalf("Failed to save request: %v", err)
+		}
+	}
+
+	get := func(path string) (*httptest.ResponseRecorder, map[string]interface{}) {
+		w := httptest.NewRecorder()
+		serveRoute(handler, w, httptest.NewRequest(http.MethodGet, path, nil))
+		var body map[string]interface{}
+		json.NewDecoder(w.Body).Decode(&body)
+		return w, body
+	}
+
+	w, body := get("/api/v1/requests/with-images?include=images")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	if images, ok := body["images"].([]interface{}); !ok || len(images) != 5 {
+		t.Errorf("Expected 5 embedded images, got %v", body["images"])
+	}
+	if body["id"] != "with-images" {
+		t.Errorf("Expected the request fields alongside the images, got %v", body)
+	}
+
+	_, body = get("/api/v1/requests/text-only?include=images")
+	if images, ok := body["images"].([]interface{}); !ok || len(images) != 0 {
+		t.Errorf("Expected an empty image list, got %v", body["images"])
+	}
+
+	// Without include the response is unchanged
+	if _, body = get("/api/v1/requests/with-images"); body["images"] != nil {
+		t.Errorf("Expected no images without include, got %v", body["images"])
+	}
+
+	if w, _ = get("/api/v1/requests/with-images?include=pictures"); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for an unknown include, got %d", w.Code)
+	}
+}
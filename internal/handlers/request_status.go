@@ -0,0 +1,179 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/docutag/controller/internal/storage"
+)
+
+// Overall processing phases reported by GetRequestStatus
+const (
+	PhaseScraping   = "scraping"
+	PhaseAnalyzing  = "analyzing"
+	PhaseComplete   = "complete"
+	PhaseFailed     = "failed"
+	PhaseTombstoned = "tombstoned"
+)
+
+// Analysis states derived from the metadata the worker writes
+const (
+	AnalysisStateNone       = "none" // No text analysis was requested, e.g. low-score records
+	AnalysisStateQueued     = storage.AnalysisStatusQueued
+	AnalysisStateProcessing = storage.AnalysisStatusProcessing
+	AnalysisStateCompleted  = storage.AnalysisStatusCompleted
+	AnalysisStateTimedOut   = storage.AnalysisStatusTimedOut
+	AnalysisStateFailed     = storage.AnalysisStatusFailed
+)
+
+// ScrapeStatus is the state of the scrape job that produced a request
+type ScrapeStatus struct {
+	JobID       string     `json:"job_id"`
+	Status      string     `json:"status"` // queued, processing, completed, failed
+	Error       string     `json:"error,omitempty"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+}
+
+// AnalysisStatus is the state of a request's text analysis
+type AnalysisStatus struct {
+	State          string `json:"state"`
+	JobID          string `json:"job_id,omitempty"`
+	ElapsedMinutes int    `json:"elapsed_minutes,omitempty"` // Set when retrieval timed out
+	CompletedAt    string `json:"completed_at,omitempty"`    // When the state became completed, failed or timed_out
+}
+
+// TombstoneStatus reports whether a request is tombstoned. A tombstone dated in the future is
+// scheduled but the request stays visible until then.
+type TombstoneStatus struct {
+	Tombstoned bool   `json:"tombstoned"`
+	Scheduled  bool   `json:"scheduled"`
+	At         string `json:"at,omitempty"`
+	Reason     string `json:"reason,omitempty"`
+}
+
+// RequestStatusResponse is a single view of where a request is in the processing pipeline
+type RequestStatusResponse struct {
+	RequestID    string          `json:"request_id"`
+	Phase        string          `json:"phase"`
+	Scrape       *ScrapeStatus   `json:"scrape,omitempty"` // Absent for text submissions
+	Analysis     AnalysisStatus  `json:"analysis"`
+	QualityScore *float64        `json:"quality_score,omitempty"`
+	LinkScore    *float64        `json:"link_score,omitempty"`
+	Tombstone    TombstoneStatus `json:"tombstone"`
+	SEOEnabled   bool            `json:"seo_enabled"`
+}
+
+// buildRequestStatus assembles the status view from a request and the scrape job that produced it, if any
+func buildRequestStatus(record *storage.Request, job *storage.ScrapeJob, now time.Time) *RequestStatusResponse {
+	resp := &RequestStatusResponse{
+		RequestID:    record.ID,
+		Analysis:     analysisStatus(record.Metadata),
+		QualityScore: metadataScore(record.Metadata, "quality_score"),
+		LinkScore:    metadataScore(record.Metadata, "link_score"),
+		SEOEnabled:   record.SEOEnabled,
+	}
+
+	if at, ok := record.Metadata["tombstone_datetime"].(string); ok && at != "" {
+		reason, _ := record.Metadata["tombstone_reason"].(string)
+		resp.Tombstone = TombstoneStatus{Scheduled: true, At: at, Reason: reason}
+		if t, err := time.Parse(time.RFC3339, at); err == nil && !t.After(now) {
+			resp.Tombstone.Tombstoned = true
+		}
+	}
+
+	if job != nil {
+		resp.Scrape = &ScrapeStatus{
+			JobID:       job.ID,
+			Status:      job.Status,
+			Error:       job.ErrorMessage,
+			CompletedAt: job.CompletedAt,
+		}
+	}
+
+	switch {
+	case resp.Tombstone.Tombstoned:
+		resp.Phase = PhaseTombstoned
+	case job != nil && job.Status == "failed":
+		resp.Phase = PhaseFailed
+	case job != nil && (job.Status == "queued" || job.Status == "processing"):
+		resp.Phase = PhaseScraping
+	case resp.Analysis.State == AnalysisStateQueued || resp.Analysis.State == AnalysisStateProcessing:
+		resp.Phase = PhaseAnalyzing
+	case resp.Analysis.State == AnalysisStateTimedOut || resp.Analysis.State == AnalysisStateFailed:
+		resp.Phase = PhaseFailed
+	default:
+		resp.Phase = PhaseComplete
+	}
+
+	return resp
+}
+
+// analysisStatus reads the textanalyzer_* and analysis_retrieval_* fields set by the worker
+func analysisStatus(metadata map[string]interface{}) AnalysisStatus {
+	status := AnalysisStatus{State: AnalysisStateNone}
+	status.JobID, _ = metadata["textanalyzer_job_id"].(string)
+	status.CompletedAt, _ = metadata[storage.MetaAnalysisCompletedAt].(string)
+
+	switch s, _ := metadata[storage.MetaAnalysisStatus].(string); s {
+	case storage.AnalysisStatusQueued, storage.AnalysisStatusProcessing, storage.AnalysisStatusCompleted, storage.AnalysisStatusTimedOut:
+		status.State = s
+	case storage.AnalysisStatusFailed:
+		status.State = AnalysisStateFailed
+		// Records written before timed_out existed say failed with the timeout flag set
+		if timedOut, _ := metadata["analysis_retrieval_timeout"].(bool); timedOut {
+			status.State = AnalysisStateTimedOut
+		}
+	}
+	if status.State == AnalysisStateTimedOut {
+		// Metadata round-trips through JSON, so the minutes come back as float64
+		switch elapsed := metadata["analysis_retrieval_elapsed_minutes"].(type) {
+		case float64:
+			status.ElapsedMinutes = int(elapsed)
+		case int:
+			status.ElapsedMinutes = elapsed
+		}
+	}
+
+	return status
+}
+
+// metadataScore returns the "score" field of a score object in metadata, such as quality_score or link_score
+func metadataScore(metadata map[string]interface{}, key string) *float64 {
+	obj, ok := metadata[key].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	score, ok := obj["score"].(float64)
+	if !ok {
+		return nil
+	}
+	return &score
+}
+
+// GetRequestStatus handles GET /api/requests/{id}/status
+func (h *Handler) GetRequestStatus(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if id == "" {
+		respondErrorCode(w, ErrCodeValidationFailed, "Request ID is required", http.StatusBadRequest)
+		return
+	}
+
+	record, err := h.store(r).GetRequest(id)
+	if err != nil {
+		if err.Error() == "request not found" {
+			respondErrorCode(w, ErrCodeRequestNotFound, "Request not found", http.StatusNotFound)
+			return
+		}
+		respondErrorCode(w, ErrCodeInternal, fmt.Sprintf("Failed to get request: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	job, err := h.store(r).GetScrapeJobByRequestID(id)
+	if err != nil {
+		respondErrorCode(w, ErrCodeInternal, fmt.Sprintf("Failed to get scrape job: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	respondJSON(w, buildRequestStatus(record, job, time.Now()), http.StatusOK)
+}
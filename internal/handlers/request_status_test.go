@@ -0,0 +1,179 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/docutag/controller/internal/storage"
+)
+
+func TestBuildRequestStatus(t *testing.T) {
+	t.Parallel()
+	completedAt := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	now := completedAt.Add(30 * 24 * time.Hour)
+	requestID := "req-1"
+
+	tests := []struct {
+		name         string
+		record       *storage.Request
+		job          *storage.ScrapeJob
+		wantPhase    string
+		wantAnalysis string
+	}{
+		{
+			name: "fresh scrape awaiting analysis",
+			record: &storage.Request{ID: requestID, SEOEnabled: true, Metadata: map[string]interface{}{
+				"textanalyzer_job_id": "ta-1",
+				"textanalyzer_status": "queued",
+				"link_score":          map[string]interface{}{"score": 0.8},
+			}},
+			job:          &storage.ScrapeJob{ID: "job-1", Status: "completed", CompletedAt: &completedAt, ResultRequestID: &requestID},
+			wantPhase:    PhaseAnalyzing,
+			wantAnalysis: AnalysisStateQueued,
+		},
+		{
+			name: "rescrape in progress",
+			record: &storage.Request{ID: requestID, Metadata: map[string]interface{}{
+				"textanalyzer_status": "completed",
+			}},
+			job:          &storage.ScrapeJob{ID: "job-2", Status: "processing", ResultRequestID: &requestID},
+			wantPhase:    PhaseScraping,
+			wantAnalysis: AnalysisStateCompleted,
+		},
+		{
+			name: "completed",
+			record: &storage.Request{ID: requestID, SEOEnabled: true, Metadata: map[string]interface{}{
+				"textanalyzer_job_id": "ta-1",
+				"textanalyzer_status": "completed",
+				"quality_score":       map[string]interface{}{"score": 0.72},
+				"link_score":          map[string]interface{}{"score": 0.8},
+			}},
+			job:          &storage.ScrapeJob{ID: "job-1", Status: "completed", CompletedAt: &completedAt, ResultRequestID: &requestID},
+			wantPhase:    PhaseComplete,
+			wantAnalysis: AnalysisStateCompleted,
+		},
+		{
+			name: "analyzer working on it",
+			record: &storage.Request{ID: requestID, Metadata: map[string]interface{}{
+				"textanalyzer_job_id": "ta-1",
+				"textanalyzer_status": "processing",
+			}},
+			job:          &storage.ScrapeJob{ID: "job-1", Status: "completed", CompletedAt: &completedAt, ResultRequestID: &requestID},
+			wantPhase:    PhaseAnalyzing,
+			wantAnalysis: AnalysisStateProcessing,
+		},
+		{
+			name: "analysis failed",
+			record: &storage.Request{ID: requestID, Metadata: map[string]interface{}{
+				"textanalyzer_status":   "failed",
+				"analysis_completed_at": "2025-01-01T12:05:00Z",
+			}},
+			job:          &storage.ScrapeJob{ID: "job-1", Status: "completed", CompletedAt: &completedAt, ResultRequestID: &requestID},
+			wantPhase:    PhaseFailed,
+			wantAnalysis: AnalysisStateFailed,
+		},
+		{
+			name: "analysis timed out",
+			record: &storage.Request{ID: requestID, Metadata: map[string]interface{}{
+				"textanalyzer_job_id":                "ta-1",
+				"textanalyzer_status":                "timed_out",
+				"analysis_retrieval_timeout":         true,
+				"analysis_retrieval_elapsed_minutes": float64(61),
+			}},
+			job:          &storage.ScrapeJob{ID: "job-1", Status: "completed", CompletedAt: &completedAt, ResultRequestID: &requestID},
+			wantPhase:    PhaseFailed,
+			wantAnalysis: AnalysisStateTimedOut,
+		},
+		{
+			name: "analysis timed out before timed_out existed",
+			record: &storage.Request{ID: requestID, Metadata: map[string]interface{}{
+				"textanalyzer_job_id":                "ta-1",
+				"textanalyzer_status":                "failed",
+				"analysis_retrieval_timeout":         true,
+				"analysis_retrieval_elapsed_minutes": float64(61),
+			}},
+			job:          &storage.ScrapeJob{ID: "job-1", Status: "completed", CompletedAt: &completedAt, ResultRequestID: &requestID},
+			wantPhase:    PhaseFailed,
+			wantAnalysis: AnalysisStateTimedOut,
+		},
+		{
+			name: "tombstoned low-quality record",
+			record: &storage.Request{ID: requestID, Metadata: map[string]interface{}{
+				"textanalyzer_status": "completed",
+				"quality_score":       map[string]interface{}{"score": 0.2},
+				"tombstone_datetime":  "2025-01-08T12:00:00Z",
+				"tombstone_reason":    "Low quality score: 0.20",
+			}},
+			job:          &storage.ScrapeJob{ID: "job-1", Status: "completed", ResultRequestID: &requestID},
+			wantPhase:    PhaseTombstoned,
+			wantAnalysis: AnalysisStateCompleted,
+		},
+		{
+			name: "tombstone scheduled in the future",
+			record: &storage.Request{ID: requestID, Metadata: map[string]interface{}{
+				"textanalyzer_status": "completed",
+				"quality_score":       map[string]interface{}{"score": 0.3},
+				"tombstone_datetime":  "2025-03-01T12:00:00Z",
+			}},
+			job:          &storage.ScrapeJob{ID: "job-1", Status: "completed", ResultRequestID: &requestID},
+			wantPhase:    PhaseComplete,
+			wantAnalysis: AnalysisStateCompleted,
+		},
+		{
+			name:         "text submission without analysis",
+			record:       &storage.Request{ID: requestID, Metadata: map[string]interface{}{}},
+			wantPhase:    PhaseComplete,
+			wantAnalysis: AnalysisStateNone,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status := buildRequestStatus(tt.record, tt.job, now)
+			if status.Phase != tt.wantPhase {
+				t.Errorf("expected phase %s, got %s", tt.wantPhase, status.Phase)
+			}
+			if status.Analysis.State != tt.wantAnalysis {
+				t.Errorf("expected analysis state %s, got %s", tt.wantAnalysis, status.Analysis.State)
+			}
+			if (tt.job == nil) != (status.Scrape == nil) {
+				t.Errorf("expected scrape section only when a job exists, got %+v", status.Scrape)
+			}
+			if status.SEOEnabled != tt.record.SEOEnabled {
+				t.Errorf("expected seo_enabled %v, got %v", tt.record.SEOEnabled, status.SEOEnabled)
+			}
+		})
+	}
+}
+
+func TestBuildRequestStatusDetails(t *testing.T) {
+	t.Parallel()
+	completedAt := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	record := &storage.Request{ID: "req-1", Metadata: map[string]interface{}{
+		"textanalyzer_job_id":                "ta-1",
+		"textanalyzer_status":                "failed",
+		"analysis_retrieval_timeout":         true,
+		"analysis_retrieval_elapsed_minutes": float64(61),
+		"quality_score":                      map[string]interface{}{"score": 0.4},
+		"tombstone_datetime":                 "2025-01-31T12:00:00Z",
+	}}
+	job := &storage.ScrapeJob{ID: "job-1", Status: "completed", CompletedAt: &completedAt}
+
+	status := buildRequestStatus(record, job, completedAt)
+
+	if status.Analysis.JobID != "ta-1" || status.Analysis.ElapsedMinutes != 61 {
+		t.Errorf("unexpected analysis details: %+v", status.Analysis)
+	}
+	if status.QualityScore == nil || *status.QualityScore != 0.4 {
+		t.Errorf("expected quality score 0.4, got %v", status.QualityScore)
+	}
+	if status.LinkScore != nil {
+		t.Errorf("expected no link score, got %v", *status.LinkScore)
+	}
+	if status.Tombstone.Tombstoned || !status.Tombstone.Scheduled || status.Tombstone.At != "2025-01-31T12:00:00Z" {
+		t.Errorf("unexpected tombstone state: %+v", status.Tombstone)
+	}
+	if status.Scrape.JobID != "job-1" || status.Scrape.CompletedAt == nil || !status.Scrape.CompletedAt.Equal(completedAt) {
+		t.Errorf("unexpected scrape state: %+v", status.Scrape)
+	}
+}
@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/docutag/controller/internal/storage"
+)
+
+// ModifyRequestTagsRequest is the body of POST /api/requests/{id}/tags
+type ModifyRequestTagsRequest struct {
+	Add    []string `json:"add,omitempty"`
+	Remove []string `json:"remove,omitempty"`
+}
+
+// RequestTagsResponse reports a request's tags after an add/remove. Added and Removed only
+// list tags that changed the set, so both are empty for a no-op.
+type RequestTagsResponse struct {
+	ID      string   `json:"id"`
+	Tags    []string `json:"tags"`
+	Added   []string `json:"added"`
+	Removed []string `json:"removed"`
+}
+
+// ModifyRequestTags adds and removes individual tags, leaving the request's other tags alone.
+// Unlike PUT /tags, two editors changing different tags at once cannot undo each other.
+func (h *Handler) ModifyRequestTags(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if id == "" {
+		respondErrorCode(w, ErrCodeValidationFailed, "Request ID is required", http.StatusBadRequest)
+		return
+	}
+
+	var req ModifyRequestTagsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondErrorCode(w, ErrCodeInvalidRequestBody, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if len(req.Add) == 0 && len(req.Remove) == 0 {
+		respondErrorCode(w, ErrCodeValidationFailed, "add or remove must list at least one tag", http.StatusBadRequest)
+		return
+	}
+	removing := make(map[string]bool, len(req.Remove))
+	for _, tag := range req.Remove {
+		removing[strings.TrimSpace(tag)] = true
+	}
+	for _, tag := range req.Add {
+		if tag = strings.TrimSpace(tag); tag != "" && removing[tag] {
+			respondErrorDetails(w, ErrCodeValidationFailed, fmt.Sprintf("Tag %q is both added and removed", tag),
+				http.StatusBadRequest, map[string]interface{}{"tag": tag})
+			return
+		}
+	}
+
+	change, err := h.store(r).ModifyRequestTags(id, req.Add, req.Remove)
+	if err != nil {
+		if err.Error() == "request not found" {
+			respondErrorCode(w, ErrCodeRequestNotFound, "Request not found", http.StatusNotFound)
+			return
+		}
+		respondErrorCode(w, ErrCodeInternal, fmt.Sprintf("Failed to update tags: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	if len(change.Added) > 0 || len(change.Removed) > 0 {
+		h.recordAudit(r, storage.AuditActionUpdateTags, storage.AuditEntityRequest, id, map[string]interface{}{
+			"added":   change.Added,
+			"removed": change.Removed,
+		})
+	}
+
+	w.Header().Set("ETag", requestETag(change.RowVersion))
+	respondJSON(w, RequestTagsResponse{
+		ID:      id,
+		Tags:    change.Tags,
+		Added:   nonNilStrings(change.Added),
+		Removed: nonNilStrings(change.Removed),
+	}, http.StatusOK)
+}
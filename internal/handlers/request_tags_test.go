@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/docutag/controller/internal/storage"
+)
+
+func TestModifyRequestTagsValidation(t *testing.T) {
+	t.Parallel()
+	for _, body := range []string{`{}`, `{"add":[],"remove":[]}`, `{"add":["draft"],"remove":[" draft"]}`, `not json`} {
+		w := httptest.NewRecorder()
+		serveRoute(&Handler{}, w, httptest.NewRequest(http.MethodPost, "/api/v1/requests/req-1/tags", strings.NewReader(body)))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status 400, got %d: %s", body, w.Code, w.Body.String())
+		}
+	}
+}
+
+func TestModifyRequestTags(t *testing.T) {
+	t.Parallel()
+	handler, _, _, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	if err := handler.storage.SaveRequest(&storage.Request{ID: "tagpost-1", CreatedAt: time.Now().UTC(), SourceType: "text",
+		Tags: []string{"news", "draft"}, Metadata: map[string]interface{}{}}); err != nil {
+		t.Fatalf("Failed to save request: %v", err)
+	}
+
+	post := func(body string) RequestTagsResponse {
+		t.Helper()
+		w := httptest.NewRecorder()
+		serveRoute(handler, w, httptest.NewRequest(http.MethodPost, "/api/v1/requests/tagpost-1/tags", strings.NewReader(body)))
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: expected status 200, got %d: %s", body, w.Code, w.Body.String())
+		}
+		var resp RequestTagsResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		return resp
+	}
+
+	tests := []struct {
+		name                 string
+		body                 string
+		tags, added, removed []string
+	}{
+		{"add only", `{"add":["verified"]}`, []string{"news", "draft", "verified"}, []string{"verified"}, []string{}},
+		{"remove only", `{"remove":["draft"]}`, []string{"news", "verified"}, []string{}, []string{"draft"}},
+		{"add existing", `{"add":["news"]}`, []string{"news", "verified"}, []string{}, []string{}},
+		{"remove missing", `{"remove":["draft"]}`, []string{"news", "verified"}, []string{}, []string{}},
+	}
+	for _, tt := range tests {
+		resp := post(tt.body)
+		if !reflect.DeepEqual(resp.Tags, tt.tags) || !reflect.DeepEqual(resp.Added, tt.added) || !reflect.DeepEqual(resp.Removed, tt.removed) {
+			t.Errorf("%s: unexpected response %+v", tt.name, resp)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	serveRoute(handler, w, httptest.NewRequest(http.MethodPost, "/api/v1/requests/tagpost-missing/tags", strings.NewReader(`{"add":["a"]}`)))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for an unknown request, got %d", w.Code)
+	}
+}
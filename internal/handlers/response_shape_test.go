@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/docutag/controller/internal/storage"
+)
+
+var updateGolden = flag.Bool("update", false, "rewrite the golden files in testdata/golden")
+
+// TestResponseShapes pins the exact encoding of the main response bodies, so a field that
+// appears, disappears or turns null is a deliberate change to the API rather than an accident.
+// Run with -update to rewrite the golden files after such a change.
+func TestResponseShapes(t *testing.T) {
+	created := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	sourceURL := "https://example.com/article"
+	scraperUUID := "7d0b3f4e-2c1a-4b8e-9f6d-5a4c3b2a1f0e"
+	slug := "example-article"
+	full := &storage.Request{
+		ID:               "0b6e8f62-3b1c-4a7e-9d55-1f0e2a9c7d41",
+		CreatedAt:        created,
+		EffectiveDate:    created.AddDate(0, 0, -2),
+		SourceType:       "url",
+		SourceURL:        &sourceURL,
+		ScraperUUID:      &scraperUUID,
+		TextAnalyzerUUID: "c4a1e2d3-5b6f-4a7e-8d9c-0b1a2f3e4d5c",
+		Tags:             []string{"go", "testing"},
+		Metadata:         map[string]interface{}{"title": "Example"},
+		Slug:             &slug,
+		SEOEnabled:       true,
+		Language:         "en",
+		Starred:          true,
+		CreatedBy:        "client:web",
+	}
+	// A text request before analysis: no tags, metadata or optional fields yet
+	bare := &storage.Request{
+		ID:            "5c1d9a3e-7f2b-4e60-8a14-b93d0c6e2f58",
+		CreatedAt:     created,
+		EffectiveDate: created,
+		SourceType:    "text",
+	}
+	avgQuality := 0.72
+
+	tests := []struct {
+		name string
+		body interface{}
+	}{
+		{"request_full", newControllerResponse(full)},
+		{"request_bare", newControllerResponse(bare)},
+		{"request_list", newRequestListResponse([]*storage.Request{full, bare}, 20, 0)},
+		{"request_list_empty", newRequestListResponse(nil, 20, 40)},
+		{"search_tags_empty", SearchTagsResponse{RequestIDs: nonNilStrings(nil)}},
+		{"timeline_extents", TimelineExtentsResponse{EarliestDate: created.Format(time.RFC3339)}},
+		{"stats", &storage.GlobalStats{
+			TotalRequests:       2,
+			RequestsBySource:    map[string]int{"text": 1, "url": 1},
+			UniqueTags:          2,
+			AddedLast24h:        1,
+			AddedLast7d:         2,
+			SEOEnabled:          1,
+			ScrapeJobsByStatus:  map[string]int{"completed": 1},
+			AverageQualityScore: &avgQuality,
+			GeneratedAt:         created,
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			respondJSON(w, tt.body, http.StatusOK)
+			var got bytes.Buffer
+			if err := json.Indent(&got, w.Body.Bytes(), "", "  "); err != nil {
+				t.Fatalf("Response is not JSON: %v", err)
+			}
+
+			path := filepath.Join("testdata", "golden", tt.name+".json")
+			if *updateGolden {
+				if err := os.WriteFile(path, got.Bytes(), 0o644); err != nil {
+					t.Fatalf("Failed to update golden file: %v", err)
+				}
+				return
+			}
+			want, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("Failed to read golden file (run with -update to create it): %v", err)
+			}
+			if !bytes.Equal(got.Bytes(), want) {
+				t.Errorf("Response shape changed; run with -update if intended\ngot:\n%s\nwant:\n%s", got.Bytes(), want)
+			}
+		})
+	}
+}
@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/docutag/controller/internal/storage"
+	"github.com/docutag/controller/internal/urlguard"
+)
+
+func TestRetryScrapeRequestWithModifiedURL(t *testing.T) {
+	t.Parallel()
+	handler, _, _, cleanup := setupTestHandler(t)
+	defer cleanup()
+	handler.SetDomainPolicy(urlguard.NewDomainPolicy(nil, []string{"*.blocked.com"}))
+
+	now := time.Now()
+	parentID := "retry-url-parent"
+	save := func(id, status string) {
+		t.Helper()
+		job := &storage.ScrapeJob{ID: id, URL: "https://exmaple.com/article", Status: status, ParentJobID: &parentID, Depth: 2, CreatedAt: now, UpdatedAt: now}
+		if err := handler.storage.SaveScrapeJob(job); err != nil {
+			t.Fatalf("Failed to save job: %v", err)
+		}
+		if status == "failed" {
+			if err := handler.storage.UpdateScrapeJobStatus(id, "failed", "no such host"); err != nil {
+				t.Fatalf("Failed to fail job: %v", err)
+			}
+		}
+	}
+	save(parentID, "completed")
+	retry := func(id, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		serveRoute(handler, w, httptest.NewRequest(http.MethodPost, "/api/scrape-requests/"+id+"/retry", strings.NewReader(body)))
+		return w
+	}
+
+	t.Run("failed job is retried against the corrected URL", func(t *testing.T) {
+		save("retry-url-ok", "failed")
+		if w := retry("retry-url-ok", `{"url": "https://Example.com/article?utm_source=mail"}`); w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+		}
+
+		job, err := handler.storage.GetScrapeJob("retry-url-ok")
+		if err != nil {
+			t.Fatalf("Failed to get job: %v", err)
+		}
+		if job.URL != "https://example.com/article" || job.Status != "queued" {
+			t.Errorf("expected the queued job to target the normalized URL, got %s (%s)", job.URL, job.Status)
+		}
+		if job.OriginalURL == nil || *job.OriginalURL != "https://exmaple.com/article" {
+			t.Errorf("expected original_url to keep the submitted URL, got %v", job.OriginalURL)
+		}
+		if job.ParentJobID == nil || *job.ParentJobID != parentID || job.Depth != 2 {
+			t.Errorf("expected parent and depth to be kept, got %v at depth %d", job.ParentJobID, job.Depth)
+		}
+
+		// A second correction still records the URL first submitted
+		if err := handler.storage.UpdateScrapeJobStatus("retry-url-ok", "failed", "timeout"); err != nil {
+			t.Fatalf("Failed to fail job: %v", err)
+		}
+		if w := retry("retry-url-ok", `{"url": "https://example.com/article/print"}`); w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+		}
+		job, _ = handler.storage.GetScrapeJob("retry-url-ok")
+		if job.OriginalURL == nil || *job.OriginalURL != "https://exmaple.com/article" {
+			t.Errorf("expected original_url to survive later corrections, got %v", job.OriginalURL)
+		}
+	})
+
+	t.Run("validation failures leave the job untouched", func(t *testing.T) {
+		save("retry-url-bad", "failed")
+		save("retry-url-done", "completed")
+		tests := []struct {
+			name, id, body string
+			wantStatus     int
+		}{
+			{"malformed body", "retry-url-bad", `{"url":`, http.StatusBadRequest},
+			{"unsafe target", "retry-url-bad", `{"url": "http://127.0.0.1/admin"}`, http.StatusBadRequest},
+			{"unsupported scheme", "retry-url-bad", `{"url": "ftp://example.com/file"}`, http.StatusBadRequest},
+			{"denied domain", "retry-url-bad", `{"url": "https://www.blocked.com/article"}`, http.StatusForbidden},
+			{"job not failed", "retry-url-done", `{"url": "https://example.com/other"}`, http.StatusConflict},
+		}
+		for _, tt := range tests {
+			if w := retry(tt.id, tt.body); w.Code != tt.wantStatus {
+				t.Errorf("%s: expected status %d, got %d: %s", tt.name, tt.wantStatus, w.Code, w.Body.String())
+			}
+		}
+
+		for _, id := range []string{"retry-url-bad", "retry-url-done"} {
+			job, err := handler.storage.GetScrapeJob(id)
+			if err != nil {
+				t.Fatalf("Failed to get job: %v", err)
+			}
+			if job.URL != "https://exmaple.com/article" || job.OriginalURL != nil {
+				t.Errorf("%s: expected the URL to be unchanged, got %s (original %v)", id, job.URL, job.OriginalURL)
+			}
+		}
+	})
+}
@@ -0,0 +1,226 @@
+package handlers
+
+import (
+	"net/http"
+)
+
+// route is one row of the API routing table. Paths are relative to the API prefix.
+type route struct {
+	methods []string
+	path    string
+	handler http.HandlerFunc
+}
+
+// apiRoutes lists every /api route. RegisterRoutes mounts the table under both
+// APIPrefixV1 and the deprecated unversioned prefix.
+func (h *Handler) apiRoutes() []route {
+	get := []string{http.MethodGet}
+	post := []string{http.MethodPost}
+	put := []string{http.MethodPut}
+	del := []string{http.MethodDelete}
+
+	return []route{
+		// Synchronous processing
+		{post, "/scrape", h.ScrapeURL},
+		{post, "/analyze", h.AnalyzeText},
+		{post, "/score", h.ScoreLink},
+		{post, "/extract-links", h.ExtractLinks},
+		{post, "/preview", h.PreviewURL},
+
+		// Search and timelines
+		{post, "/search", h.SearchTags},
+		{get, "/search", h.GetSearchTags},
+		{post, "/images/search", h.SearchImageTags},
+		{get, "/tags/timeline", h.GetTagTimeline},
+		{get, "/stats", h.GetGlobalStats},
+		{get, "/graph", h.GetLinkGraph},
+		{get, "/saved-searches", h.ListSavedSearches},
+		{post, "/saved-searches", h.CreateSavedSearch},
+		{get, "/saved-searches/{id}", h.GetSavedSearch},
+		{put, "/saved-searches/{id}", h.UpdateSavedSearch},
+		{del, "/saved-searches/{id}", h.DeleteSavedSearch},
+		{post, "/saved-searches/{id}/run", h.RunSavedSearch},
+
+		// Admin and audit
+		{get, "/audit", h.ListAuditLog},
+		{get, "/admin/domain-policy", h.GetDomainPolicy},
+		{post, "/admin/domain-policy/check", h.CheckDomainPolicy},
+		{get, "/admin/settings", h.GetSettings},
+		{put, "/admin/settings", h.UpdateSettings},
+		{get, "/admin/log-level", h.GetLogLevel},
+		{put, "/admin/log-level", h.UpdateLogLevel},
+		{post, "/admin/rescrape-stale", h.TriggerStaleRescrape},
+		{post, "/admin/reconcile", h.TriggerReconcile},
+		{get, "/admin/backfills", h.ListBackfills},
+		{post, "/admin/backfills/{name}/start", h.StartBackfillRun},
+		{post, "/admin/backfills/{name}/stop", h.StopBackfillRun},
+		{post, "/admin/backup", h.CreateBackup},
+		{get, "/admin/db/integrity", h.CheckDatabaseIntegrity},
+		{get, "/admin/migrations", h.ListMigrations},
+
+		// Webhooks
+		{get, "/webhooks", h.ListWebhooks},
+		{post, "/webhooks", h.CreateWebhook},
+		{get, "/webhooks/{id}", h.GetWebhook},
+		{put, "/webhooks/{id}", h.UpdateWebhook},
+		{del, "/webhooks/{id}", h.DeleteWebhook},
+		{get, "/webhooks/{id}/deliveries", h.ListWebhookDeliveries},
+
+		// API description
+		{get, "/openapi.json", h.ServeOpenAPI},
+		{get, "/docs", h.ServeAPIDocs},
+
+		// Requests
+		{get, "/requests", h.ListRequests},
+		{post, "/requests/filter", h.FilterRequests},
+		{post, "/requests/bulk-tombstone", h.BulkTombstoneRequests},
+		{post, "/requests/bulk-delete", h.BulkDeleteRequests},
+		{get, "/requests/timeline-extents", h.GetTimelineExtents},
+		{get, "/requests/histogram", h.GetRequestHistogram},
+		{get, "/requests/{id}", h.GetRequest},
+		{del, "/requests/{id}", h.DeleteRequest},
+		{put, "/requests/{id}/seo-enabled", h.UpdateSEOEnabled},
+		{put, "/requests/{id}/tombstone", h.TombstoneRequest},
+		{del, "/requests/{id}/tombstone", h.UntombstoneRequest},
+		{put, "/requests/{id}/tags", h.UpdateRequestTags},
+		{post, "/requests/{id}/tags", h.ModifyRequestTags},
+		{post, "/requests/{id}/restore", h.RestoreRequest},
+		{get, "/requests/{id}/duplicates", h.GetRequestDuplicates},
+		{get, "/requests/{id}/links", h.GetRequestLinks},
+		{get, "/requests/{id}/versions", h.GetRequestVersions},
+		{get, "/requests/{id}/versions/{version}", h.GetRequestVersions},
+		{get, "/requests/{id}/changes", h.GetRequestChanges},
+		{get, "/requests/{id}/stream", h.StreamRequestUpdates},
+		{get, "/requests/{id}/status", h.GetRequestStatus},
+		{get, "/requests/{id}/archive", h.GetRequestArchive},
+		{get, "/requests/{id}/content", h.GetRequestContent},
+		{put, "/requests/{id}/star", h.StarRequest},
+		{del, "/requests/{id}/star", h.UnstarRequest},
+
+		// Images
+		{get, "/documents/{uuid}/images", h.GetDocumentImages},
+		{del, "/documents/{uuid}/images", h.DeleteDocumentImages},
+		{put, "/documents/{uuid}/images/tombstone", h.TombstoneDocumentImages},
+		{get, "/images/{id}", h.GetImage},
+		{get, "/images/{id}/content", h.GetImageContent},
+		{del, "/images/{id}", h.DeleteImage},
+		{put, "/images/{id}/tags", h.UpdateImageTags},
+		{put, "/images/{id}/tombstone", h.TombstoneImage},
+		{del, "/images/{id}/tombstone", h.UntombstoneImage},
+
+		// Async scrape and text analysis requests
+		{get, "/scrape-requests", h.ListScrapeRequests},
+		{post, "/scrape-requests", h.CreateScrapeRequest},
+		{post, "/scrape-requests/sitemap", h.IngestSitemap},
+		{get, "/scrape-requests/{id}", h.GetScrapeRequest},
+		{del, "/scrape-requests/{id}", h.DeleteScrapeRequest},
+		{post, "/scrape-requests/{id}/retry", h.RetryScrapeRequest},
+		{get, "/analyze-requests", h.ListTextAnalysisRequests},
+		{post, "/analyze-requests", h.CreateTextAnalysisRequest},
+		{post, "/analyze-requests/bulk", h.CreateTextAnalysisBatch},
+
+		// Scheduler proxy
+		{get, "/scheduler/tasks", h.ListSchedulerTasks},
+		{post, "/scheduler/tasks", h.CreateSchedulerTask},
+		{get, "/scheduler/tasks/{id}", h.GetSchedulerTask},
+		{put, "/scheduler/tasks/{id}", h.UpdateSchedulerTask},
+		{del, "/scheduler/tasks/{id}", h.DeleteSchedulerTask},
+		{post, "/scheduler/tasks/{id}/run", h.RunSchedulerTask},
+		{post, "/scheduler/tasks/{id}/pause", h.PauseSchedulerTask},
+		{post, "/scheduler/tasks/{id}/resume", h.ResumeSchedulerTask},
+	}
+}
+
+// shadowedRoutes are fixed paths that share a prefix with an {id} route. Without an
+// explicit 405 these methods would reach the {id} handler with the fixed segment as the ID.
+var shadowedRoutes = []struct {
+	path    string
+	allow   string
+	methods []string
+}{
+	{"/requests/filter", "POST", []string{http.MethodGet, http.MethodDelete}},
+	{"/requests/bulk-tombstone", "POST", []string{http.MethodGet, http.MethodDelete}},
+	{"/requests/bulk-delete", "POST", []string{http.MethodGet, http.MethodDelete}},
+	{"/requests/timeline-extents", "GET, HEAD", []string{http.MethodDelete}},
+	{"/requests/histogram", "GET, HEAD", []string{http.MethodDelete}},
+	{"/images/search", "POST", []string{http.MethodGet, http.MethodDelete}},
+	{"/scrape-requests/sitemap", "POST", []string{http.MethodGet, http.MethodDelete}},
+}
+
+// RegisterRoutes registers every API, health, readiness and SEO route on mux. Patterns carry
+// their method, so handlers only run for the methods listed here.
+func (h *Handler) RegisterRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("GET /health", h.Health)
+	mux.HandleFunc("GET /ready", h.Ready)
+
+	routes := h.apiRoutes()
+	for _, prefix := range []struct {
+		path    string
+		version string
+	}{
+		{APIPrefixV1, APIVersionV1},
+		{APIPrefixUnversioned, APIVersionUnversioned},
+	} {
+		for _, rt := range routes {
+			handler := h.withAPIVersion(prefix.version, h.withNamespace(rt.handler))
+			for _, method := range rt.methods {
+				mux.Handle(method+" "+prefix.path+rt.path, handler)
+			}
+		}
+		for _, sr := range shadowedRoutes {
+			rejectMethods(mux, prefix.path+sr.path, sr.allow, sr.methods...)
+		}
+	}
+
+	// SEO routes (public-facing)
+	mux.HandleFunc("GET /content/{slug}", h.ServeContent)
+	mux.HandleFunc("GET /sitemap.xml", h.ServeSitemap)
+	mux.HandleFunc("GET /images-sitemap.xml", h.ServeImageSitemap)
+	mux.HandleFunc("GET /robots.txt", h.ServeRobotsTxt)
+}
+
+// rejectMethods answers 405 for the given methods on path, advertising allow
+func rejectMethods(mux *http.ServeMux, path, allow string, methods ...string) {
+	for _, method := range methods {
+		mux.HandleFunc(method+" "+path, func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Allow", allow)
+			respondErrorCode(w, ErrCodeMethodNotAllowed, "Method not allowed", http.StatusMethodNotAllowed)
+		})
+	}
+}
+
+// MethodNotAllowed wraps a method-aware mux so that a request whose path matches
+// a route but whose method does not gets the standard JSON error body. The mux
+// has already set the Allow header listing the methods the path accepts.
+func MethodNotAllowed(mux *http.ServeMux) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Only the mux's own fallback handlers report an empty pattern
+		if _, pattern := mux.Handler(r); pattern == "" {
+			w = &methodNotAllowedWriter{ResponseWriter: w}
+		}
+		mux.ServeHTTP(w, r)
+	})
+}
+
+// methodNotAllowedWriter replaces the mux's plain-text 405 with respondErrorCode
+type methodNotAllowedWriter struct {
+	http.ResponseWriter
+	replaced bool
+}
+
+func (w *methodNotAllowedWriter) WriteHeader(status int) {
+	if status != http.StatusMethodNotAllowed {
+		w.ResponseWriter.WriteHeader(status)
+		return
+	}
+	w.replaced = true
+	respondErrorCode(w.ResponseWriter, ErrCodeMethodNotAllowed, "Method not allowed", status)
+}
+
+func (w *methodNotAllowedWriter) Write(b []byte) (int, error) {
+	if w.replaced {
+		// Discard the mux's plain-text body
+		return len(b), nil
+	}
+	return w.ResponseWriter.Write(b)
+}
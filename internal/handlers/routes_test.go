@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRoutesMethodNotAllowed(t *testing.T) {
+	t.Parallel()
+	h := &Handler{}
+
+	tests := []struct {
+		method    string
+		path      string
+		wantAllow string
+	}{
+		{http.MethodPatch, "/api/requests/abc", "DELETE, GET, HEAD"},
+		{http.MethodPost, "/api/requests/abc/seo-enabled", "PUT"},
+		{http.MethodGet, "/api/requests/abc/tombstone", "DELETE, PUT"},
+		{http.MethodPut, "/api/scrape-requests", "GET, HEAD, POST"},
+		{http.MethodGet, "/api/scheduler/tasks/7/run", "POST"},
+		{http.MethodPost, "/health", "GET, HEAD"},
+		// Fixed paths are not handed to the sibling {id} route
+		{http.MethodDelete, "/api/requests/timeline-extents", "GET, HEAD"},
+		{http.MethodGet, "/api/requests/filter", "POST"},
+		{http.MethodGet, "/api/scrape-requests/sitemap", "POST"},
+		{http.MethodDelete, "/api/images/search", "POST"},
+		// The v1 prefix is routed the same way
+		{http.MethodPatch, "/api/v1/requests/abc", "DELETE, GET, HEAD"},
+		{http.MethodGet, "/api/v1/requests/filter", "POST"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			serveRoute(h, w, httptest.NewRequest(tt.method, tt.path, nil))
+
+			if w.Code != http.StatusMethodNotAllowed {
+				t.Fatalf("expected status 405, got %d: %s", w.Code, w.Body.String())
+			}
+			if got := w.Header().Get("Allow"); got != tt.wantAllow {
+				t.Errorf("Allow = %q, want %q", got, tt.wantAllow)
+			}
+			if got := w.Header().Get("Content-Type"); got != "application/json" {
+				t.Errorf("Content-Type = %q, want application/json", got)
+			}
+
+			var resp ErrorResponse
+			if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if resp.Code != ErrCodeMethodNotAllowed || resp.Error != "Method not allowed" {
+				t.Errorf("unexpected error response: %+v", resp)
+			}
+		})
+	}
+}
+
+func TestRoutesUnknownPath(t *testing.T) {
+	t.Parallel()
+	w := httptest.NewRecorder()
+	serveRoute(&Handler{}, w, httptest.NewRequest(http.MethodGet, "/api/does-not-exist", nil))
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected status 404, got %d", w.Code)
+	}
+}
+
+// pprof is served only by the separate debug listener, never by the public API routes
+func TestRoutesNoDebugEndpoints(t *testing.T) {
+	t.Parallel()
+	for _, path := range []string{"/debug/pprof/", "/debug/pprof/goroutine", "/debug/vars"} {
+		w := httptest.NewRecorder()
+		serveRoute(&Handler{}, w, httptest.NewRequest(http.MethodGet, path, nil))
+		if w.Code != http.StatusNotFound {
+			t.Errorf("GET %s: expected status 404, got %d", path, w.Code)
+		}
+	}
+}
+
+func TestAPIVersionPrefixes(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		path           string
+		wantDeprecated bool
+		wantLink       string
+	}{
+		{"/api/v1/openapi.json", false, ""},
+		{"/api/openapi.json", true, `</api/v1/openapi.json>; rel="successor-version"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			serveRoute(&Handler{}, w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("expected status 200, got %d", w.Code)
+			}
+			if got := w.Header().Get("Deprecation") != ""; got != tt.wantDeprecated {
+				t.Errorf("Deprecation header present = %v, want %v", got, tt.wantDeprecated)
+			}
+			if got := w.Header().Get("Link"); got != tt.wantLink {
+				t.Errorf("Link = %q, want %q", got, tt.wantLink)
+			}
+		})
+	}
+}
+
+func TestWithAPIVersion(t *testing.T) {
+	t.Parallel()
+	var got string
+	handler := (&Handler{}).withAPIVersion(APIVersionV1, func(w http.ResponseWriter, r *http.Request) {
+		got = APIVersion(r.Context())
+	})
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/v1/requests", nil))
+
+	if got != APIVersionV1 {
+		t.Errorf("APIVersion = %q, want %q", got, APIVersionV1)
+	}
+	if v := APIVersion(context.Background()); v != "" {
+		t.Errorf("expected no version outside /api, got %q", v)
+	}
+}
@@ -0,0 +1,200 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/docutag/controller/internal/storage"
+	"github.com/google/uuid"
+)
+
+// maxSavedSearchNameLength bounds the name of a saved search
+const maxSavedSearchNameLength = 200
+
+// SavedSearchRequest is the body of POST and PUT /api/saved-searches
+type SavedSearchRequest struct {
+	Name   string                `json:"name"`
+	Filter FilterRequestsRequest `json:"filter"`
+}
+
+// SavedSearchListResponse lists saved searches
+type SavedSearchListResponse struct {
+	SavedSearches []*storage.SavedSearch `json:"saved_searches"`
+	Count         int                    `json:"count"`
+}
+
+// RunSavedSearchRequest is the optional body of POST /api/saved-searches/{id}/run.
+// Non-nil fields replace the paging stored with the filter.
+type RunSavedSearchRequest struct {
+	Limit  *int `json:"limit,omitempty"`
+	Offset *int `json:"offset,omitempty"`
+}
+
+// decodeSavedSearch reads and validates a saved search body, returning the filter to store.
+// It writes the error response itself and returns false when the body is rejected.
+func decodeSavedSearch(w http.ResponseWriter, r *http.Request) (string, json.RawMessage, bool) {
+	var req SavedSearchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondErrorCode(w, ErrCodeInvalidRequestBody, "Invalid request body", http.StatusBadRequest)
+		return "", nil, false
+	}
+
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		respondErrorCode(w, ErrCodeValidationFailed, "name is required", http.StatusBadRequest)
+		return "", nil, false
+	}
+	if len(name) > maxSavedSearchNameLength {
+		respondErrorCode(w, ErrCodeValidationFailed, fmt.Sprintf("name must be at most %d characters", maxSavedSearchNameLength), http.StatusBadRequest)
+		return "", nil, false
+	}
+	if _, err := filterOptions(req.Filter, defaultMaxPageLimit); err != nil {
+		respondErrorCode(w, ErrCodeValidationFailed, err.Error(), http.StatusBadRequest)
+		return "", nil, false
+	}
+
+	filter, err := json.Marshal(req.Filter)
+	if err != nil {
+		respondErrorCode(w, ErrCodeInternal, fmt.Sprintf("Failed to encode filter: %v", err), http.StatusInternalServerError)
+		return "", nil, false
+	}
+	return name, filter, true
+}
+
+// CreateSavedSearch handles POST /api/saved-searches. The filter is checked the same way
+// POST /api/requests/filter checks it, so a saved search that saves also runs.
+func (h *Handler) CreateSavedSearch(w http.ResponseWriter, r *http.Request) {
+	name, filter, ok := decodeSavedSearch(w, r)
+	if !ok {
+		return
+	}
+
+	search := &storage.SavedSearch{
+		ID:     uuid.New().String(),
+		Name:   name,
+		Owner:  requestCreator(r),
+		Filter: filter,
+	}
+	if err := h.store(r).CreateSavedSearch(search); err != nil {
+		respondErrorCode(w, ErrCodeInternal, fmt.Sprintf("Failed to save search: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	respondCreated(w, "/saved-searches/"+search.ID, search)
+}
+
+// ListSavedSearches handles GET /api/saved-searches?owner=
+func (h *Handler) ListSavedSearches(w http.ResponseWriter, r *http.Request) {
+	searches, err := h.store(r).ListSavedSearches(strings.TrimSpace(r.URL.Query().Get("owner")))
+	if err != nil {
+		respondErrorCode(w, ErrCodeInternal, fmt.Sprintf("Failed to list saved searches: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	respondJSON(w, SavedSearchListResponse{SavedSearches: searches, Count: len(searches)}, http.StatusOK)
+}
+
+// getSavedSearch loads the saved search named by the {id} path value, writing a 404 if
+// there is none
+func (h *Handler) getSavedSearch(w http.ResponseWriter, r *http.Request) (*storage.SavedSearch, bool) {
+	search, err := h.store(r).GetSavedSearch(r.PathValue("id"))
+	if err != nil {
+		respondErrorCode(w, ErrCodeInternal, fmt.Sprintf("Failed to get saved search: %v", err), http.StatusInternalServerError)
+		return nil, false
+	}
+	if search == nil {
+		respondErrorCode(w, ErrCodeSavedSearchNotFound, "Saved search not found", http.StatusNotFound)
+		return nil, false
+	}
+	return search, true
+}
+
+// GetSavedSearch handles GET /api/saved-searches/{id}
+func (h *Handler) GetSavedSearch(w http.ResponseWriter, r *http.Request) {
+	search, ok := h.getSavedSearch(w, r)
+	if !ok {
+		return
+	}
+	respondJSON(w, search, http.StatusOK)
+}
+
+// UpdateSavedSearch handles PUT /api/saved-searches/{id}, replacing its name and filter
+func (h *Handler) UpdateSavedSearch(w http.ResponseWriter, r *http.Request) {
+	name, filter, ok := decodeSavedSearch(w, r)
+	if !ok {
+		return
+	}
+
+	search := &storage.SavedSearch{ID: r.PathValue("id"), Name: name, Filter: filter}
+	if err := h.store(r).UpdateSavedSearch(search); err != nil {
+		if err.Error() == "saved search not found" {
+			respondErrorCode(w, ErrCodeSavedSearchNotFound, "Saved search not found", http.StatusNotFound)
+			return
+		}
+		respondErrorCode(w, ErrCodeInternal, fmt.Sprintf("Failed to update saved search: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	respondJSON(w, search, http.StatusOK)
+}
+
+// DeleteSavedSearch handles DELETE /api/saved-searches/{id}
+func (h *Handler) DeleteSavedSearch(w http.ResponseWriter, r *http.Request) {
+	if err := h.store(r).DeleteSavedSearch(r.PathValue("id")); err != nil {
+		if err.Error() == "saved search not found" {
+			respondErrorCode(w, ErrCodeSavedSearchNotFound, "Saved search not found", http.StatusNotFound)
+			return
+		}
+		respondErrorCode(w, ErrCodeInternal, fmt.Sprintf("Failed to delete saved search: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	respondJSON(w, map[string]string{"message": "Saved search deleted successfully"}, http.StatusOK)
+}
+
+// RunSavedSearch handles POST /api/saved-searches/{id}/run. The response is the same page
+// POST /api/requests/filter returns for the stored filter.
+func (h *Handler) RunSavedSearch(w http.ResponseWriter, r *http.Request) {
+	var overrides RunSavedSearchRequest
+	if err := json.NewDecoder(r.Body).Decode(&overrides); err != nil && !errors.Is(err, io.EOF) {
+		respondErrorCode(w, ErrCodeInvalidRequestBody, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if overrides.Limit != nil && *overrides.Limit < 0 {
+		respondErrorCode(w, ErrCodeValidationFailed, "limit must not be negative", http.StatusBadRequest)
+		return
+	}
+	if overrides.Offset != nil && *overrides.Offset < 0 {
+		respondErrorCode(w, ErrCodeValidationFailed, "offset must not be negative", http.StatusBadRequest)
+		return
+	}
+
+	search, ok := h.getSavedSearch(w, r)
+	if !ok {
+		return
+	}
+
+	var filter FilterRequestsRequest
+	if err := json.Unmarshal(search.Filter, &filter); err != nil {
+		respondErrorCode(w, ErrCodeInternal, fmt.Sprintf("Failed to decode saved filter: %v", err), http.StatusInternalServerError)
+		return
+	}
+	if overrides.Limit != nil {
+		filter.Limit = *overrides.Limit
+	}
+	if overrides.Offset != nil {
+		filter.Offset = *overrides.Offset
+	}
+
+	opts, err := filterOptions(filter, h.pageLimitMax())
+	if err != nil {
+		// Only possible if the validation rules tightened after the search was saved
+		respondErrorCode(w, ErrCodeInvalidState, fmt.Sprintf("Saved filter is no longer valid: %v", err), http.StatusConflict)
+		return
+	}
+	h.respondFilteredRequests(w, r, opts)
+}
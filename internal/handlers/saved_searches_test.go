@@ -0,0 +1,133 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/docutag/controller/internal/storage"
+)
+
+func TestCreateSavedSearchValidation(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name string
+		body string
+	}{
+		{"invalid json", `{`},
+		{"missing name", `{"filter": {"tags": ["go"]}}`},
+		{"blank name", `{"name": "  ", "filter": {}}`},
+		{"bad date", `{"name": "recent", "filter": {"date_start": "yesterday"}}`},
+		{"bad language", `{"name": "english", "filter": {"language": "not a language"}}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			serveRoute(&Handler{}, w, httptest.NewRequest(http.MethodPost, "/api/saved-searches", strings.NewReader(tt.body)))
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("expected 400, got %d: %s", w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestSavedSearches(t *testing.T) {
+	t.Parallel()
+	handler, _, _, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	now := time.Now()
+	for i, tags := range [][]string{{"go", "news"}, {"go"}, {"rust"}} {
+		req := &storage.Request{ID: "saved-" + string(rune('a'+i)), CreatedAt: now.Add(time.Duration(i) * time.Second), SourceType: "text", Tags: tags, Metadata: map[string]interface{}{}}
+		if err := handler.storage.SaveRequest(req); err != nil {
+			t.Fatalf("Failed to save request: %v", err)
+		}
+	}
+
+	do := func(method, path, body string, headers map[string]string) *httptest.ResponseRecorder {
+		t.Helper()
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		w := httptest.NewRecorder()
+		serveRoute(handler, w, req)
+		return w
+	}
+
+	w := do(http.MethodPost, "/api/saved-searches", `{"name": "Go", "filter": {"tags": ["go"], "limit": 10}}`, map[string]string{"X-API-Key": "analyst-key"})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var search storage.SavedSearch
+	if err := json.Unmarshal(w.Body.Bytes(), &search); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if search.ID == "" || search.Name != "Go" || !strings.HasPrefix(search.Owner, "apikey:") {
+		t.Errorf("Unexpected saved search %+v", search)
+	}
+	if w := do(http.MethodPost, "/api/saved-searches", `{"name": "Rust", "filter": {"tags": ["rust"]}}`, nil); w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var list SavedSearchListResponse
+	w = do(http.MethodGet, "/api/saved-searches?owner="+search.Owner, "", nil)
+	if err := json.Unmarshal(w.Body.Bytes(), &list); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if list.Count != 1 || list.SavedSearches[0].ID != search.ID {
+		t.Errorf("Expected only the analyst's search, got %+v", list)
+	}
+
+	run := func(body string) RequestListResponse {
+		t.Helper()
+		w := do(http.MethodPost, "/api/saved-searches/"+search.ID+"/run", body, nil)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+		}
+		var resp RequestListResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		return resp
+	}
+	if resp := run(""); resp.Count != 2 || resp.Limit != 10 {
+		t.Errorf("Expected the stored filter to match 2 requests with limit 10, got %+v", resp)
+	}
+	if resp := run(`{"limit": 1, "offset": 1}`); resp.Count != 1 || resp.Limit != 1 || resp.Offset != 1 {
+		t.Errorf("Expected the overrides to page the results, got %+v", resp)
+	}
+
+	// Updates are validated like creation and keep the owner
+	if w := do(http.MethodPut, "/api/saved-searches/"+search.ID, `{"name": "Go", "filter": {"date_end": "soon"}}`, nil); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for an invalid filter, got %d", w.Code)
+	}
+	w = do(http.MethodPut, "/api/saved-searches/"+search.ID, `{"name": "Go news", "filter": {"tags": ["go", "news"]}}`, nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var updated storage.SavedSearch
+	json.Unmarshal(w.Body.Bytes(), &updated)
+	if updated.Name != "Go news" || updated.Owner != search.Owner {
+		t.Errorf("Unexpected updated search %+v", updated)
+	}
+	if resp := run(""); resp.Count != 1 {
+		t.Errorf("Expected the updated filter to match 1 request, got %d", resp.Count)
+	}
+
+	if w := do(http.MethodDelete, "/api/saved-searches/"+search.ID, "", nil); w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	for _, w := range []*httptest.ResponseRecorder{
+		do(http.MethodGet, "/api/saved-searches/"+search.ID, "", nil),
+		do(http.MethodPost, "/api/saved-searches/"+search.ID+"/run", "", nil),
+		do(http.MethodDelete, "/api/saved-searches/"+search.ID, "", nil),
+	} {
+		if w.Code != http.StatusNotFound || !strings.Contains(w.Body.String(), ErrCodeSavedSearchNotFound) {
+			t.Errorf("Expected 404 SAVED_SEARCH_NOT_FOUND, got %d: %s", w.Code, w.Body.String())
+		}
+	}
+}
@@ -0,0 +1,299 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/docutag/controller/internal/clients"
+)
+
+func TestSchedulerHandlersNotConfigured(t *testing.T) {
+	t.Parallel()
+	h := &Handler{}
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+	}{
+		{"list", http.MethodGet, "/api/scheduler/tasks", ""},
+		{"get", http.MethodGet, "/api/scheduler/tasks/1", ""},
+		{"create", http.MethodPost, "/api/scheduler/tasks", `{"name":"t"}`},
+		{"update", http.MethodPut, "/api/scheduler/tasks/1", `{"name":"t"}`},
+		{"delete", http.MethodDelete, "/api/scheduler/tasks/1", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, bytes.NewBufferString(tt.body))
+			w := httptest.NewRecorder()
+
+			serveRoute(h, w, req)
+
+			if w.Code != http.StatusServiceUnavailable {
+				t.Fatalf("expected status 503, got %d", w.Code)
+			}
+			if !strings.Contains(w.Body.String(), "scheduler integration not configured") {
+				t.Errorf("unexpected error body: %s", w.Body.String())
+			}
+		})
+	}
+}
+
+func TestSchedulerUpstreamErrorStatus(t *testing.T) {
+	t.Parallel()
+	scheduler := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/tasks/404":
+			http.Error(w, `{"error":"task not found"}`, http.StatusNotFound)
+		case "/api/tasks/400":
+			http.Error(w, `{"error":"invalid schedule"}`, http.StatusBadRequest)
+		default:
+			http.Error(w, "boom", http.StatusInternalServerError)
+		}
+	}))
+	defer scheduler.Close()
+
+	h := &Handler{scheduler: clients.NewSchedulerClient(scheduler.URL)}
+
+	tests := []struct {
+		path       string
+		wantStatus int
+	}{
+		{"/api/scheduler/tasks/404", http.StatusNotFound},
+		{"/api/scheduler/tasks/400", http.StatusBadRequest},
+		{"/api/scheduler/tasks/500", http.StatusBadGateway},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		serveRoute(h, w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+		if w.Code != tt.wantStatus {
+			t.Errorf("%s: expected status %d, got %d", tt.path, tt.wantStatus, w.Code)
+		}
+	}
+
+	// An unreachable scheduler is a gateway error, not an internal one
+	scheduler.Close()
+	w := httptest.NewRecorder()
+	serveRoute(h, w, httptest.NewRequest(http.MethodGet, "/api/scheduler/tasks", nil))
+	if w.Code != http.StatusBadGateway {
+		t.Errorf("unreachable scheduler: expected status 502, got %d", w.Code)
+	}
+}
+
+func TestHealthReportsScheduler(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name    string
+		handler *Handler
+		want    string
+	}{
+		{"not configured", &Handler{}, "not_configured"},
+		{"configured", &Handler{scheduler: clients.NewSchedulerClient("http://scheduler")}, "configured"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			serveRoute(tt.handler, w, httptest.NewRequest(http.MethodGet, "/health", nil))
+
+			var response map[string]string
+			if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if response["status"] != "healthy" {
+				t.Errorf("expected status healthy, got %q", response["status"])
+			}
+			if response["scheduler"] != tt.want {
+				t.Errorf("expected scheduler %q, got %q", tt.want, response["scheduler"])
+			}
+		})
+	}
+}
+
+func TestSchedulerTaskActions(t *testing.T) {
+	t.Parallel()
+	type call struct {
+		method string
+		path   string
+	}
+	var calls []call
+
+	scheduler := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls = append(calls, call{r.Method, r.URL.Path})
+		switch r.URL.Path {
+		case "/api/tasks/7/run":
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusAccepted)
+			w.Write([]byte(`{"id":7,"status":"triggered"}`))
+		case "/api/tasks/7/pause":
+			w.WriteHeader(http.StatusNoContent)
+		case "/api/tasks/7/resume":
+			w.WriteHeader(http.StatusOK)
+		case "/api/tasks/8/resume":
+			http.Error(w, `{"error":"task is not paused"}`, http.StatusConflict)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer scheduler.Close()
+
+	h := &Handler{scheduler: clients.NewSchedulerClient(scheduler.URL)}
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		wantStatus int
+		wantBody   string
+		wantCall   *call
+	}{
+		{"run passes through 202 and body", http.MethodPost, "/api/scheduler/tasks/7/run", http.StatusAccepted, `"status":"triggered"`, &call{http.MethodPost, "/api/tasks/7/run"}},
+		{"pause passes through 204", http.MethodPost, "/api/scheduler/tasks/7/pause", http.StatusNoContent, "", &call{http.MethodPost, "/api/tasks/7/pause"}},
+		{"resume with empty body", http.MethodPost, "/api/scheduler/tasks/7/resume", http.StatusOK, `"action":"resume"`, &call{http.MethodPost, "/api/tasks/7/resume"}},
+		{"upstream conflict passes through", http.MethodPost, "/api/scheduler/tasks/8/resume", http.StatusConflict, "task is not paused", &call{http.MethodPost, "/api/tasks/8/resume"}},
+		{"missing task", http.MethodPost, "/api/scheduler/tasks/9/run", http.StatusNotFound, "", &call{http.MethodPost, "/api/tasks/9/run"}},
+		{"invalid id", http.MethodPost, "/api/scheduler/tasks/abc/run", http.StatusBadRequest, "Invalid task ID", nil},
+		{"wrong method", http.MethodGet, "/api/scheduler/tasks/7/run", http.StatusMethodNotAllowed, "", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls = nil
+			w := httptest.NewRecorder()
+			serveRoute(h, w, httptest.NewRequest(tt.method, tt.path, nil))
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("expected status %d, got %d: %s", tt.wantStatus, w.Code, w.Body.String())
+			}
+			if tt.wantBody != "" && !strings.Contains(w.Body.String(), tt.wantBody) {
+				t.Errorf("expected body to contain %q, got %s", tt.wantBody, w.Body.String())
+			}
+			if tt.wantCall == nil {
+				if len(calls) != 0 {
+					t.Errorf("expected no scheduler call, got %v", calls)
+				}
+				return
+			}
+			if len(calls) != 1 || calls[0] != *tt.wantCall {
+				t.Errorf("expected scheduler call %v, got %v", *tt.wantCall, calls)
+			}
+		})
+	}
+
+	// Nil scheduler is reported the same way as for the CRUD endpoints
+	w := httptest.NewRecorder()
+	serveRoute(&Handler{}, w, httptest.NewRequest(http.MethodPost, "/api/scheduler/tasks/7/pause", nil))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected status 503 without a scheduler, got %d", w.Code)
+	}
+}
+
+func TestListSchedulerTasksPagination(t *testing.T) {
+	t.Parallel()
+	legacyTasks := `[
+		{"id":1,"name":"Nightly scrape","enabled":true},
+		{"id":2,"name":"Weekly report","enabled":false},
+		{"id":3,"name":"Hourly scrape","enabled":true},
+		{"id":4,"name":"Scrape archive","enabled":true}
+	]`
+
+	var gotQuery string
+	envelope := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotQuery = r.URL.RawQuery
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"tasks":[{"id":3,"name":"Hourly scrape","enabled":true}],"total":7,"count":1,"limit":1,"offset":2}`))
+	}))
+	defer envelope.Close()
+
+	legacy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(legacyTasks))
+	}))
+	defer legacy.Close()
+
+	t.Run("filters are passed through and metadata preserved", func(t *testing.T) {
+		h := &Handler{scheduler: clients.NewSchedulerClient(envelope.URL)}
+		w := httptest.NewRecorder()
+		serveRoute(h, w, httptest.NewRequest(http.MethodGet, "/api/scheduler/tasks?limit=1&offset=2&status=enabled&name=scrape", nil))
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+		}
+		if gotQuery != "limit=1&name=scrape&offset=2&status=enabled" {
+			t.Errorf("unexpected upstream query: %s", gotQuery)
+		}
+
+		var list clients.TaskList
+		if err := json.NewDecoder(w.Body).Decode(&list); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		if list.Total != 7 || list.Count != 1 || list.Offset != 2 || list.ClientFiltered {
+			t.Errorf("unexpected metadata: %+v", list)
+		}
+	})
+
+	t.Run("legacy array is filtered client-side", func(t *testing.T) {
+		h := &Handler{scheduler: clients.NewSchedulerClient(legacy.URL)}
+		w := httptest.NewRecorder()
+		serveRoute(h, w, httptest.NewRequest(http.MethodGet, "/api/scheduler/tasks?limit=1&offset=1&status=enabled&name=SCRAPE", nil))
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+		}
+
+		var list clients.TaskList
+		if err := json.NewDecoder(w.Body).Decode(&list); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		if !list.ClientFiltered {
+			t.Error("expected client_filtered to be set")
+		}
+		if list.Total != 3 || list.Count != 1 || len(list.Tasks) != 1 || list.Tasks[0].ID != 3 {
+			t.Errorf("unexpected page: total=%d count=%d tasks=%+v", list.Total, list.Count, list.Tasks)
+		}
+	})
+
+	t.Run("default limit", func(t *testing.T) {
+		h := &Handler{scheduler: clients.NewSchedulerClient(legacy.URL)}
+		w := httptest.NewRecorder()
+		serveRoute(h, w, httptest.NewRequest(http.MethodGet, "/api/scheduler/tasks", nil))
+
+		var list clients.TaskList
+		if err := json.NewDecoder(w.Body).Decode(&list); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		if list.Limit != 50 || list.Total != 4 || list.Count != 4 {
+			t.Errorf("unexpected metadata: %+v", list)
+		}
+	})
+
+	invalid := []struct {
+		query string
+		want  string
+	}{
+		{"limit=0", "Invalid limit"},
+		{"limit=abc", "Invalid limit"},
+		{"offset=-1", "Invalid offset"},
+		{"status=paused", "Invalid status"},
+	}
+	for _, tt := range invalid {
+		t.Run("invalid "+tt.query, func(t *testing.T) {
+			h := &Handler{scheduler: clients.NewSchedulerClient(legacy.URL)}
+			w := httptest.NewRecorder()
+			serveRoute(h, w, httptest.NewRequest(http.MethodGet, "/api/scheduler/tasks?"+tt.query, nil))
+
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("expected status 400, got %d", w.Code)
+			}
+			if !strings.Contains(w.Body.String(), tt.want) {
+				t.Errorf("expected body to contain %q, got %s", tt.want, w.Body.String())
+			}
+		})
+	}
+}
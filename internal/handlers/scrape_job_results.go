@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/docutag/controller/internal/storage"
+)
+
+// ScrapeJobResponse is a scrape job, with a summary of the request it created when the caller
+// asks for ?expand=result
+type ScrapeJobResponse struct {
+	*storage.ScrapeJob
+	Result        *storage.RequestSummary `json:"result,omitempty"`         // The request a completed job created
+	ResultMissing bool                    `json:"result_missing,omitempty"` // The job's request has since been deleted
+}
+
+// parseExpandResult reports whether the expand query parameter asks for job results
+func parseExpandResult(r *http.Request) (bool, error) {
+	expand, err := parseOptionList("expand", r.URL.Query().Get("expand"), "result")
+	if err != nil {
+		return false, err
+	}
+	return expand["result"], nil
+}
+
+// newScrapeJobResponses wraps jobs for the API. With expand, each completed job carries the
+// summary of the request it created, loaded for the whole page in one query.
+func newScrapeJobResponses(store *storage.Storage, jobs []*storage.ScrapeJob, expand bool) ([]ScrapeJobResponse, error) {
+	responses := make([]ScrapeJobResponse, 0, len(jobs))
+	var ids []string
+	for _, job := range jobs {
+		responses = append(responses, ScrapeJobResponse{ScrapeJob: job})
+		if expand && hasResult(job) {
+			ids = append(ids, *job.ResultRequestID)
+		}
+	}
+	if len(ids) == 0 {
+		return responses, nil
+	}
+
+	summaries, err := store.GetRequestSummaries(ids)
+	if err != nil {
+		return nil, err
+	}
+	for i, job := range jobs {
+		if !hasResult(job) {
+			continue
+		}
+		if summary, ok := summaries[*job.ResultRequestID]; ok {
+			responses[i].Result = summary
+		} else {
+			responses[i].ResultMissing = true
+		}
+	}
+	return responses, nil
+}
+
+// hasResult reports whether a job completed with a request of its own
+func hasResult(job *storage.ScrapeJob) bool {
+	return job.Status == "completed" && job.ResultRequestID != nil && *job.ResultRequestID != ""
+}
@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/docutag/controller/internal/scraper_requests"
+	"github.com/docutag/controller/internal/storage"
+)
+
+func TestScrapeJobsExpandResult(t *testing.T) {
+	t.Parallel()
+	connStr, cleanup := setupTestDB(t, "test_scrape_jobs_expand_result")
+	defer cleanup()
+
+	store, err := storage.New(connStr, []string{"low-quality", "sparse-content"}, 30, 90, 90)
+	if err != nil {
+		t.Fatalf("Failed to create storage: %v", err)
+	}
+	defer store.Close()
+	handler := &Handler{storage: store, scrapeRequests: scraper_requests.NewManager()}
+
+	now := time.Now().UTC()
+	slug := "expanded-article"
+	for _, id := range []string{"expand-req-live", "expand-req-deleted"} {
+		req := &storage.Request{ID: id, CreatedAt: now, SourceType: "url", Slug: &slug, SEOEnabled: true, Tags: []string{},
+			Metadata: map[string]interface{}{"scraper_metadata": map[string]interface{}{"title": "Expanded article"}}}
+		if id == "expand-req-deleted" {
+			req.Slug = nil
+		}
+		if err := store.SaveRequest(req); err != nil {
+			t.Fatalf("Failed to save request: %v", err)
+		}
+	}
+	if _, err := store.SoftDeleteRequest("expand-req-deleted"); err != nil {
+		t.Fatalf("Failed to delete request: %v", err)
+	}
+
+	live, deleted := "expand-req-live", "expand-req-deleted"
+	for i, job := range []*storage.ScrapeJob{
+		{ID: "expand-job-completed", Status: "completed", ResultRequestID: &live},
+		{ID: "expand-job-failed", Status: "failed", ErrorMessage: "scraper unavailable"},
+		{ID: "expand-job-deleted", Status: "completed", ResultRequestID: &deleted},
+	} {
+		job.URL = "https://example.com/" + job.ID
+		job.CreatedAt = now.Add(time.Duration(i) * time.Second)
+		job.UpdatedAt = job.CreatedAt
+		if err := store.SaveScrapeJob(job); err != nil {
+			t.Fatalf("Failed to save job: %v", err)
+		}
+	}
+
+	check := func(t *testing.T, job ScrapeJobResponse) {
+		t.Helper()
+		switch job.ID {
+		case "expand-job-completed":
+			if r := job.Result; r == nil || r.ID != live || r.Title != "Expanded article" || r.Slug == nil || *r.Slug != slug || !r.SEOEnabled || r.Tombstoned || job.ResultMissing {
+				t.Errorf("Expected the completed job's result summary, got %+v (result %+v)", job, r)
+			}
+		case "expand-job-failed":
+			if job.Result != nil || job.ResultMissing {
+				t.Errorf("Expected no result on the failed job, got %+v", job)
+			}
+		case "expand-job-deleted":
+			if job.Result != nil || !job.ResultMissing {
+				t.Errorf("Expected result_missing on the job whose request was deleted, got %+v", job)
+			}
+		}
+	}
+
+	t.Run("list", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		serveRoute(handler, w, httptest.NewRequest(http.MethodGet, "/api/v1/scrape-requests?expand=result", nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d. Body: %s", w.Code, w.Body.String())
+		}
+		var resp ScrapeJobListResponse
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		if resp.Count != 3 {
+			t.Fatalf("Expected 3 jobs, got %d", resp.Count)
+		}
+		for _, job := range resp.Requests {
+			check(t, job)
+		}
+	})
+
+	t.Run("get", func(t *testing.T) {
+		for _, id := range []string{"expand-job-completed", "expand-job-failed", "expand-job-deleted"} {
+			w := httptest.NewRecorder()
+			serveRoute(handler, w, httptest.NewRequest(http.MethodGet, "/api/v1/scrape-requests/"+id+"?expand=result", nil))
+			if w.Code != http.StatusOK {
+				t.Fatalf("Expected status 200, got %d. Body: %s", w.Code, w.Body.String())
+			}
+			var job ScrapeJobResponse
+			if err := json.NewDecoder(w.Body).Decode(&job); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+			check(t, job)
+		}
+	})
+
+	t.Run("not expanded", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		serveRoute(handler, w, httptest.NewRequest(http.MethodGet, "/api/v1/scrape-requests/expand-job-completed", nil))
+		var raw map[string]interface{}
+		if err := json.NewDecoder(w.Body).Decode(&raw); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		if _, ok := raw["result"]; ok || raw["result_request_id"] != live {
+			t.Errorf("Expected the plain job without a result summary, got %v", raw)
+		}
+	})
+}
+
+func TestScrapeJobsExpandInvalid(t *testing.T) {
+	t.Parallel()
+	for _, path := range []string{"/api/v1/scrape-requests?expand=results", "/api/v1/scrape-requests/some-job?expand=request"} {
+		w := httptest.NewRecorder()
+		serveRoute(&Handler{}, w, httptest.NewRequest(http.MethodGet, path, nil))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status 400, got %d. Body: %s", path, w.Code, w.Body.String())
+		}
+	}
+}
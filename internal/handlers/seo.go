@@ -2,30 +2,30 @@ package handlers
 
 import (
 	"fmt"
+	"html/template"
 	"log/slog"
 	"net/http"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 
+	"github.com/docutag/controller/internal/sanitize"
 	"github.com/docutag/controller/internal/seo"
+	"github.com/docutag/controller/internal/storage"
 	"github.com/docutag/controller/internal/templates"
 )
 
 // ServeContent serves SEO-optimized HTML content page
 func (h *Handler) ServeContent(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		return
-	}
-
-	// Extract slug from path: /content/{slug}
-	slug := strings.TrimPrefix(r.URL.Path, "/content/")
-	if slug == "" || slug == r.URL.Path {
+	slug := r.PathValue("slug")
+	if slug == "" {
 		http.Error(w, "Slug is required", http.StatusBadRequest)
 		return
 	}
 
 	// Get request by slug
-	request, err := h.storage.GetRequestBySlug(slug)
+	request, err := h.publicStore().GetRequestBySlug(slug)
 	if err != nil {
 		slog.Default().Error("error getting request by slug", "slug", slug, "error", err)
 		http.Error(w, "Not found", http.StatusNotFound)
@@ -51,8 +51,11 @@ func (h *Handler) ServeContent(w http.ResponseWriter, r *http.Request) {
 	// Get title, description, content from metadata
 	title := getString(scraperMeta, "title", "Untitled")
 	description := getString(scraperMeta, "description", "")
+	analyzerMeta, _ := request.Metadata["analyzer_metadata"].(map[string]interface{})
+	synopsis := sanitize.PlainText(getString(analyzerMeta, "synopsis", description))
 	rawContent := getString(textMeta, "content", getString(scraperMeta, "content", ""))
-	content := formatContentHTML(rawContent)
+	// Scraper and analyzer output is untrusted: it may still carry the page's own markup
+	content := h.contentSanitizer().Sanitize(formatContentHTML(rawContent))
 
 	// Get author and validate it's not a URL
 	author := getString(scraperMeta, "author", "")
@@ -71,6 +74,7 @@ func (h *Handler) ServeContent(w http.ResponseWriter, r *http.Request) {
 	// Select best thumbnail based on relevance score
 	var ogImage string
 	var bestImageSlug string
+	var pageImages []scoredPageImage
 	slog.Default().Debug("processing images for slug", "slug", slug, "scraper_base_url", h.scraperBaseURL)
 	if images, ok := scraperMeta["images"].([]interface{}); ok && len(images) > 0 {
 		slog.Default().Debug("found images in metadata", "count", len(images))
@@ -89,6 +93,12 @@ func (h *Handler) ServeContent(w http.ResponseWriter, r *http.Request) {
 					relevanceScore = score
 				}
 
+				altText, _ := img["alt_text"].(string)
+				pageImages = append(pageImages, scoredPageImage{
+					PageImage: templates.PageImage{URL: fmt.Sprintf("%s/images/%s", h.scraperBaseURL, imgSlug), AltText: altText},
+					score:     relevanceScore,
+				})
+
 				if relevanceScore > bestScore {
 					bestScore = relevanceScore
 					bestImageSlug = imgSlug
@@ -114,13 +124,13 @@ func (h *Handler) ServeContent(w http.ResponseWriter, r *http.Request) {
 
 	// Generate JSON-LD schema
 	schemaData := seo.ArticleData{
-		Title:         title,
-		Description:   description,
+		Title:         sanitize.PlainText(title),
+		Description:   synopsis,
 		Author:        author,
 		PublishedDate: request.CreatedAt,
 		ModifiedDate:  request.CreatedAt,
 		Keywords:      keywords,
-		Content:       content,
+		Content:       sanitize.PlainText(rawContent),
 		URL:           canonicalURL,
 	}
 
@@ -140,49 +150,101 @@ func (h *Handler) ServeContent(w http.ResponseWriter, r *http.Request) {
 		sourceURL = *request.SourceURL
 	}
 
+	publishedAt := request.EffectiveDate
+	if publishedAt.IsZero() {
+		publishedAt = request.CreatedAt
+	}
+
 	// Render HTML template
 	pageData := templates.ContentPageData{
 		Title:           title,
-		Description:     description,
-		Content:         content,
+		Synopsis:        synopsis,
+		ContentHTML:     template.HTML(content),
+		PublishedAt:     publishedAt,
+		Tags:            keywords,
+		Images:          sortPageImages(pageImages),
+		Canonical:       canonicalURL,
+		Related:         h.relatedPages(request, baseURL),
 		Author:          author,
-		Keywords:        keywords,
-		PublishedDate:   request.CreatedAt.Format("2006-01-02"),
-		CanonicalURL:    canonicalURL,
 		OGImage:         ogImage,
 		JSONLDSchema:    jsonLD,
 		BaseURL:         baseURL,
 		WebInterfaceURL: h.webInterfaceURL,
-		BestImageSlug:   bestImageSlug,   // Best scored image for mid-article insertion
-		RequestID:       request.ID,      // For linking to admin interface
-		ScraperBaseURL:  h.scraperBaseURL, // For image serving
-		SourceURL:       sourceURL,       // Original source URL
+		RequestID:       request.ID, // For linking to admin interface
+		SourceURL:       sourceURL,  // Original source URL
 	}
 
-	html, err := templates.RenderContentPage(pageData)
+	html, err := h.pageTemplates.RenderContent(pageData)
 	if err != nil {
 		slog.Default().Error("error rendering template", "error", err)
 		http.Error(w, "Internal server error", http.StatusInternalServerError)
 		return
 	}
 
-	// Set headers
-	w.Header().Set("Content-Type", "text/html; charset=utf-8")
-	w.Header().Set("Cache-Control", "public, max-age=3600")
+	writePublicBody(w, r, "text/html; charset=utf-8", "public, max-age=3600", []byte(html))
+}
 
-	w.WriteHeader(http.StatusOK)
-	w.Write([]byte(html))
+// contentSanitizer returns the sanitizer scraped content is cleaned with
+func (h *Handler) contentSanitizer() sanitize.Sanitizer {
+	if h.sanitizer == nil {
+		return sanitize.DefaultPolicy()
+	}
+	return h.sanitizer
 }
 
-// ServeSitemap generates and serves the XML sitemap
-func (h *Handler) ServeSitemap(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		return
+// maxRelatedPages is how many related documents a content page links to
+const maxRelatedPages = 5
+
+// scoredPageImage is a page image with the relevance score it is ordered by
+type scoredPageImage struct {
+	templates.PageImage
+	score float64
+}
+
+// sortPageImages orders images best scored first
+func sortPageImages(images []scoredPageImage) []templates.PageImage {
+	sort.SliceStable(images, func(i, j int) bool { return images[i].score > images[j].score })
+	sorted := make([]templates.PageImage, len(images))
+	for i, img := range images {
+		sorted[i] = img.PageImage
+	}
+	return sorted
+}
+
+// relatedPages finds other public documents sharing a tag with request. Failures only cost the
+// page its related links, so they are logged rather than returned.
+func (h *Handler) relatedPages(request *storage.Request, baseURL string) []templates.RelatedPage {
+	if len(request.Tags) == 0 {
+		return nil
+	}
+	// Fetch extra candidates, since the request itself and documents without a page are skipped
+	candidates, err := h.publicStore().FilterRequests(storage.FilterOptions{Tags: request.Tags, Limit: maxRelatedPages * 4})
+	if err != nil {
+		slog.Default().Warn("error finding related pages", "request_id", request.ID, "error", err)
+		return nil
+	}
+
+	var related []templates.RelatedPage
+	for _, c := range candidates {
+		if c.ID == request.ID || !c.SEOEnabled || c.Slug == nil || *c.Slug == "" {
+			continue
+		}
+		scraperMeta, _ := c.Metadata["scraper_metadata"].(map[string]interface{})
+		related = append(related, templates.RelatedPage{
+			Title: getString(scraperMeta, "title", "Untitled"),
+			URL:   fmt.Sprintf("%s/content/%s", baseURL, *c.Slug),
+		})
+		if len(related) == maxRelatedPages {
+			break
+		}
 	}
+	return related
+}
 
+// ServeSitemap generates and serves the XML sitemap
+func (h *Handler) ServeSitemap(w http.ResponseWriter, r *http.Request) {
 	// Get all requests with slugs
-	requests, err := h.storage.ListRequests(1000, 0) // Get up to 1000 entries
+	requests, err := h.publicStore().ListRequests(sitemapMaxDocuments, 0)
 	if err != nil {
 		slog.Default().Error("error listing requests for sitemap", "error", err)
 		http.Error(w, "Internal server error", http.StatusInternalServerError)
@@ -215,31 +277,26 @@ func (h *Handler) ServeSitemap(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Set headers
-	w.Header().Set("Content-Type", "application/xml; charset=utf-8")
-	w.Header().Set("Cache-Control", "public, max-age=3600")
-
-	w.WriteHeader(http.StatusOK)
-	w.Write(xmlData)
+	writePublicBody(w, r, "application/xml; charset=utf-8", "public, max-age=3600", xmlData)
 }
 
-// ServeImageSitemap generates and serves the XML image sitemap
+// ServeImageSitemap generates and serves the XML image sitemap. Images are stored in the
+// scraper service, so they are fetched per public document and the result cached briefly.
 func (h *Handler) ServeImageSitemap(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	entries, err := h.imageSitemap.get(func() ([]seo.ImageSitemapEntry, bool, error) {
+		requests, err := h.publicStore().ListRequests(sitemapMaxDocuments, 0)
+		if err != nil {
+			return nil, false, err
+		}
+		entries, complete := h.imageSitemapEntries(r.Context(), requests, time.Now())
+		return entries, complete, nil
+	})
+	if err != nil {
+		slog.Default().Error("error listing requests for image sitemap", "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
 		return
 	}
 
-	// Note: Images are stored in the Scraper service, not in the Controller database.
-	// For now, we generate an empty sitemap. In the future, this could query the Scraper
-	// service to get all images and include them in the sitemap.
-
-	// Build empty image sitemap entries for now
-	entries := make([]seo.ImageSitemapEntry, 0)
-
-	// TODO: Query Scraper service for images with slugs
-	// This would require an HTTP call to the Scraper service's image listing endpoint
-
 	// Generate image sitemap XML
 	baseURL := getBaseURL(r)
 	xmlData, err := seo.GenerateImageSitemap(baseURL, entries)
@@ -249,21 +306,11 @@ func (h *Handler) ServeImageSitemap(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Set headers
-	w.Header().Set("Content-Type", "application/xml; charset=utf-8")
-	w.Header().Set("Cache-Control", "public, max-age=3600")
-
-	w.WriteHeader(http.StatusOK)
-	w.Write(xmlData)
+	writePublicBody(w, r, "application/xml; charset=utf-8", "public, max-age=3600", xmlData)
 }
 
 // ServeRobotsTxt serves the robots.txt file
 func (h *Handler) ServeRobotsTxt(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		return
-	}
-
 	baseURL := getBaseURL(r)
 	robotsTxt := fmt.Sprintf(`User-agent: *
 Allow: /
@@ -272,11 +319,7 @@ Sitemap: %s/sitemap.xml
 Sitemap: %s/images-sitemap.xml
 `, baseURL, baseURL)
 
-	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
-	w.Header().Set("Cache-Control", "public, max-age=86400")
-
-	w.WriteHeader(http.StatusOK)
-	w.Write([]byte(robotsTxt))
+	writePublicBody(w, r, "text/plain; charset=utf-8", "public, max-age=86400", []byte(robotsTxt))
 }
 
 // ServeImage serves an image by slug from the scraper service
@@ -426,3 +469,15 @@ func insertImageInContent(content, baseURL, imageSlug string) string {
 
 	return result.String()
 }
+
+// writePublicBody writes a public page, sitemap or robots.txt with its length. HEAD requests,
+// which crawlers send to check a page before fetching it, get the same headers and no body.
+func writePublicBody(w http.ResponseWriter, r *http.Request, contentType, cacheControl string, body []byte) {
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Cache-Control", cacheControl)
+	w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+	w.WriteHeader(http.StatusOK)
+	if r.Method != http.MethodHead {
+		w.Write(body)
+	}
+}
@@ -1,11 +1,18 @@
 package handlers
 
 import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
 	"strings"
 	"testing"
+	"time"
+
+	"github.com/docutag/controller/internal/storage"
 )
 
 func TestInsertImageInContent(t *testing.T) {
+	t.Parallel()
 	tests := []struct {
 		name            string
 		content         string
@@ -126,6 +133,7 @@ func TestInsertImageInContent(t *testing.T) {
 }
 
 func TestInsertImageInContentHTML(t *testing.T) {
+	t.Parallel()
 	content := `<p>First paragraph.</p>
 <p>Second paragraph.</p>
 <p>Third paragraph.</p>
@@ -162,6 +170,7 @@ func TestInsertImageInContentHTML(t *testing.T) {
 }
 
 func TestInsertImageInContentPreservesFormatting(t *testing.T) {
+	t.Parallel()
 	content := `<p>Paragraph with <strong>bold</strong> text.</p>
 <p>Paragraph with <a href="https://example.com">link</a>.</p>
 <p>Paragraph with <em>italic</em> text.</p>
@@ -187,6 +196,7 @@ func TestInsertImageInContentPreservesFormatting(t *testing.T) {
 }
 
 func TestFormatContentHTML(t *testing.T) {
+	t.Parallel()
 	tests := []struct {
 		name     string
 		input    string
@@ -238,3 +248,82 @@ func TestFormatContentHTML(t *testing.T) {
 		})
 	}
 }
+
+func TestRobotsTxtHead(t *testing.T) {
+	t.Parallel()
+	h := &Handler{}
+	get, head := httptest.NewRecorder(), httptest.NewRecorder()
+	serveRoute(h, get, httptest.NewRequest(http.MethodGet, "/robots.txt", nil))
+	serveRoute(h, head, httptest.NewRequest(http.MethodHead, "/robots.txt", nil))
+
+	if get.Code != http.StatusOK || get.Header().Get("Content-Length") != strconv.Itoa(get.Body.Len()) {
+		t.Fatalf("expected GET 200 with its Content-Length, got %d, %q for %d bytes", get.Code, get.Header().Get("Content-Length"), get.Body.Len())
+	}
+	assertHeadMatchesGet(t, get, head, "Content-Type", "Content-Length", "Cache-Control")
+}
+
+func TestPublicEndpointsHead(t *testing.T) {
+	t.Parallel()
+	handler, _, _, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	slug := "head-article"
+	if err := handler.storage.SaveRequest(&storage.Request{
+		ID: "head-article", CreatedAt: time.Now(), SourceType: "text", Slug: &slug, SEOEnabled: true,
+		Metadata: map[string]interface{}{"scraper_metadata": map[string]interface{}{"title": "Checked by crawlers", "content": "Body text."}},
+	}); err != nil {
+		t.Fatalf("Failed to save request: %v", err)
+	}
+
+	for _, path := range []string{"/content/head-article", "/sitemap.xml", "/images-sitemap.xml", "/robots.txt"} {
+		t.Run(path, func(t *testing.T) {
+			get, head := httptest.NewRecorder(), httptest.NewRecorder()
+			serveRoute(handler, get, httptest.NewRequest(http.MethodGet, path, nil))
+			serveRoute(handler, head, httptest.NewRequest(http.MethodHead, path, nil))
+
+			if get.Code != http.StatusOK {
+				t.Fatalf("expected GET status 200, got %d", get.Code)
+			}
+			if got := get.Header().Get("Content-Length"); got != strconv.Itoa(get.Body.Len()) {
+				t.Errorf("expected Content-Length %d, got %q", get.Body.Len(), got)
+			}
+			assertHeadMatchesGet(t, get, head, "Content-Type", "Content-Length", "Cache-Control")
+		})
+	}
+}
+
+func TestServeContentSanitizesHTML(t *testing.T) {
+	t.Parallel()
+	handler, _, _, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	slug := "hostile-article"
+	content := "Intro with <em>emphasis</em> and <a href=\"https://example.com/ref\">a link</a>.<script>alert('content')</script>\n\n" +
+		"<img src=\"https://example.com/photo.jpg\" onerror=\"alert('img')\"> <a href=\"javascript:alert('href')\">bad link</a>"
+	if err := handler.storage.SaveRequest(&storage.Request{
+		ID: "hostile-article", CreatedAt: time.Now(), SourceType: "url", Slug: &slug, SEOEnabled: true,
+		Metadata: map[string]interface{}{
+			"scraper_metadata":  map[string]interface{}{"title": "Hostile", "content": content},
+			"analyzer_metadata": map[string]interface{}{"synopsis": "A <b>summary</b><script>alert('synopsis')</script>"},
+		},
+	}); err != nil {
+		t.Fatalf("Failed to save request: %v", err)
+	}
+
+	w := httptest.NewRecorder()
+	serveRoute(handler, w, httptest.NewRequest(http.MethodGet, "/content/hostile-article", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	page := w.Body.String()
+	for _, attack := range []string{"alert('content')", "alert('img')", "javascript:", "alert('synopsis')", "onerror"} {
+		if strings.Contains(page, attack) {
+			t.Errorf("Expected %q to be removed from the page", attack)
+		}
+	}
+	for _, kept := range []string{"<em>emphasis</em>", `href="https://example.com/ref"`, `<img src="https://example.com/photo.jpg">`, `content="A summary"`} {
+		if !strings.Contains(page, kept) {
+			t.Errorf("Expected the page to keep %q", kept)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/docutag/controller/internal/settings"
+	"github.com/docutag/controller/internal/storage"
+)
+
+// settingsEntityID is the audit entity ID for changes to runtime settings
+const settingsEntityID = "runtime"
+
+// SettingsResponse is returned by the admin settings endpoints
+type SettingsResponse struct {
+	Settings   settings.Values `json:"settings"`
+	Defaults   settings.Values `json:"defaults"`   // Values from configuration, used for keys without an override
+	Overridden []string        `json:"overridden"` // Keys currently overridden at runtime
+}
+
+// UpdateSettingsRequest is a partial set of settings; a null value restores the default
+type UpdateSettingsRequest map[string]json.RawMessage
+
+// newHandlerSettings builds the settings a Handler uses until SetSettings shares the process-wide ones
+func newHandlerSettings(linkScoreThreshold float64, tombstonePeriodLowScore, tombstonePeriodManual int) *settings.Settings {
+	defaults := settings.Default()
+	defaults.LinkScoreThreshold = linkScoreThreshold
+	defaults.TombstonePeriodLowScore = tombstonePeriodLowScore
+	defaults.TombstonePeriodManual = tombstonePeriodManual
+	return settings.New(defaults)
+}
+
+// SetSettings shares runtime settings with the handler so admin updates reach the worker and storage
+func (h *Handler) SetSettings(s *settings.Settings) {
+	h.settings = s
+}
+
+func (h *Handler) settingsResponse() SettingsResponse {
+	return SettingsResponse{
+		Settings:   h.settings.Get(),
+		Defaults:   h.settings.Defaults(),
+		Overridden: h.settings.Overridden(),
+	}
+}
+
+// GetSettings handles GET /api/admin/settings
+func (h *Handler) GetSettings(w http.ResponseWriter, r *http.Request) {
+	respondJSON(w, h.settingsResponse(), http.StatusOK)
+}
+
+// UpdateSettings handles PUT /api/admin/settings. Changes are validated together, stored,
+// and take effect for the next request or task without a restart.
+func (h *Handler) UpdateSettings(w http.ResponseWriter, r *http.Request) {
+	var changes UpdateSettingsRequest
+	if err := json.NewDecoder(r.Body).Decode(&changes); err != nil {
+		respondErrorCode(w, ErrCodeInvalidRequestBody, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if len(changes) == 0 {
+		respondErrorCode(w, ErrCodeValidationFailed, "At least one setting is required", http.StatusBadRequest)
+		return
+	}
+
+	actor := auditActor(r)
+	var persistErr error
+	before, after, err := h.settings.Update(changes, func(changes map[string]json.RawMessage, _ settings.Values) error {
+		persistErr = h.storage.SaveSettingOverrides(changes, actor)
+		return persistErr
+	})
+	if persistErr != nil {
+		respondErrorCode(w, ErrCodeInternal, fmt.Sprintf("Failed to save settings: %v", persistErr), http.StatusInternalServerError)
+		return
+	}
+	if err != nil {
+		respondErrorCode(w, ErrCodeValidationFailed, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	h.recordAudit(r, storage.AuditActionUpdateSettings, storage.AuditEntitySettings, settingsEntityID, map[string]interface{}{
+		"changes": settingsChanges(before, after),
+	})
+
+	respondJSON(w, h.settingsResponse(), http.StatusOK)
+}
+
+// settingsChanges lists the old and new value of every setting that differs
+func settingsChanges(before, after settings.Values) map[string]interface{} {
+	var old, updated map[string]interface{}
+	oldJSON, _ := json.Marshal(before)
+	newJSON, _ := json.Marshal(after)
+	_ = json.Unmarshal(oldJSON, &old)
+	_ = json.Unmarshal(newJSON, &updated)
+
+	changes := make(map[string]interface{})
+	for key, value := range updated {
+		if old[key] != value {
+			changes[key] = map[string]interface{}{"old": old[key], "new": value}
+		}
+	}
+	return changes
+}
@@ -0,0 +1,162 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/docutag/controller/internal/clients"
+	"github.com/docutag/controller/internal/queue"
+	"github.com/docutag/controller/internal/storage"
+	"github.com/hibiken/asynq"
+)
+
+func TestGetSettings(t *testing.T) {
+	t.Parallel()
+	h := &Handler{settings: newHandlerSettings(0.6, 30, 90)}
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/admin/settings", nil)
+	w := httptest.NewRecorder()
+
+	serveRoute(h, w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp SettingsResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if resp.Settings.LinkScoreThreshold != 0.6 || resp.Defaults.LinkScoreThreshold != 0.6 {
+		t.Errorf("expected threshold 0.6, got %+v", resp)
+	}
+	if len(resp.Overridden) != 0 {
+		t.Errorf("expected no overrides, got %v", resp.Overridden)
+	}
+}
+
+func TestUpdateSettingsValidation(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name string
+		body string
+	}{
+		{"invalid json", `{"link_score_threshold":`},
+		{"empty", `{}`},
+		{"unknown setting", `{"link_threshold": 0.4}`},
+		{"threshold out of range", `{"link_score_threshold": 1.5}`},
+		{"zero tombstone period", `{"tombstone_period_manual": 0}`},
+		{"negative depth", `{"max_link_depth": -1}`},
+		{"wrong type", `{"tombstone_period_low_score": "thirty"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// No storage: invalid changes must be rejected before anything is saved
+			h := &Handler{settings: newHandlerSettings(0.5, 30, 90)}
+			req := httptest.NewRequest(http.MethodPut, "/api/v1/admin/settings", strings.NewReader(tt.body))
+			w := httptest.NewRecorder()
+
+			serveRoute(h, w, req)
+
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("expected 400, got %d: %s", w.Code, w.Body.String())
+			}
+			if got := h.settings.Get().LinkScoreThreshold; got != 0.5 {
+				t.Errorf("expected threshold unchanged, got %v", got)
+			}
+		})
+	}
+}
+
+func TestUpdateSettingsAppliesToNextWorkerTask(t *testing.T) {
+	t.Parallel()
+	connStr, dbCleanup := setupTestDB(t, "settings_worker")
+	defer dbCleanup()
+
+	store, err := storage.New(connStr, []string{"low-quality", "sparse-content"}, 30, 90, 90)
+	if err != nil {
+		t.Fatalf("Failed to create storage: %v", err)
+	}
+	defer store.Close()
+
+	scraperMock := mockScraperServer()
+	defer scraperMock.Close()
+	scraperClient := clients.NewScraperClient(scraperMock.URL)
+
+	// The handler, worker and storage share one Settings, as in main
+	runtimeSettings := newHandlerSettings(0.5, 30, 90)
+	store.SetSettings(runtimeSettings)
+	h := &Handler{storage: store, scraper: scraperClient, settings: runtimeSettings}
+	worker := queue.NewWorker(queue.WorkerConfig{RedisAddr: "localhost:6379", Concurrency: 1, Settings: runtimeSettings},
+		store, scraperClient, nil, nil, nil, nil, nil, nil)
+
+	// runTask scrapes a URL the mock scores 0.3 and returns the saved low-score record
+	runTask := func(jobID string) *storage.Request {
+		t.Helper()
+		now := time.Now()
+		job := &storage.ScrapeJob{ID: jobID, URL: "https://low-quality.com", Status: "queued", CreatedAt: now, UpdatedAt: now}
+		if err := store.SaveScrapeJob(job); err != nil {
+			t.Fatalf("Failed to save job: %v", err)
+		}
+		payload, _ := json.Marshal(queue.ScrapeTaskPayload{JobID: jobID, URL: job.URL})
+		if err := worker.ProcessTask(context.Background(), asynq.NewTask(queue.TypeScrapeURL, payload)); err != nil {
+			t.Fatalf("task failed: %v", err)
+		}
+
+		job, err := store.GetScrapeJob(jobID)
+		if err != nil || job.ResultRequestID == nil {
+			t.Fatalf("expected job result, got %+v (err %v)", job, err)
+		}
+		record, err := store.GetRequest(*job.ResultRequestID)
+		if err != nil {
+			t.Fatalf("Failed to get request: %v", err)
+		}
+		return record
+	}
+
+	first := runTask("job-before")
+	if first.Metadata["threshold"] != 0.5 {
+		t.Errorf("expected threshold 0.5 before update, got %v", first.Metadata["threshold"])
+	}
+
+	body := `{"link_score_threshold": 0.4, "tombstone_period_low_score": 3}`
+	req := httptest.NewRequest(http.MethodPut, "/api/v1/admin/settings", strings.NewReader(body))
+	w := httptest.NewRecorder()
+	serveRoute(h, w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	second := runTask("job-after")
+	if second.Metadata["threshold"] != 0.4 {
+		t.Errorf("expected threshold 0.4 after update, got %v", second.Metadata["threshold"])
+	}
+	tombstone, err := time.Parse(time.RFC3339, second.Metadata["tombstone_datetime"].(string))
+	if err != nil {
+		t.Fatalf("invalid tombstone_datetime: %v", err)
+	}
+	if until := time.Until(tombstone); until > 4*24*time.Hour {
+		t.Errorf("expected a 3-day tombstone, got one %v away", until)
+	}
+
+	// The override survives a restart
+	overrides, err := store.LoadSettingOverrides()
+	if err != nil {
+		t.Fatalf("Failed to load overrides: %v", err)
+	}
+	if len(overrides) != 2 {
+		t.Errorf("expected 2 stored overrides, got %v", overrides)
+	}
+
+	entries, err := store.ListAuditEntries(storage.AuditFilter{Action: storage.AuditActionUpdateSettings, Limit: 10})
+	if err != nil {
+		t.Fatalf("Failed to list audit entries: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("expected 1 settings audit entry, got %d", len(entries))
+	}
+}
@@ -0,0 +1,230 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/docutag/controller/internal/queue"
+	"github.com/docutag/controller/internal/sitemap"
+	"github.com/docutag/controller/internal/storage"
+	"github.com/docutag/controller/internal/urlnorm"
+	"github.com/google/uuid"
+)
+
+const (
+	defaultSitemapLimit   = 500
+	sitemapFetchTimeout   = 30 * time.Second
+	sitemapEnqueueStagger = 500 * time.Millisecond // Delay added per job so a large sitemap doesn't hit the scraper at once
+)
+
+// SitemapIngestRequest represents a request to scrape every page listed in a sitemap
+type SitemapIngestRequest struct {
+	URL             string `json:"url"`
+	Limit           int    `json:"limit,omitempty"`            // Maximum page URLs to enqueue (default 500, capped at sitemap.HardMaxURLs)
+	AllowDuplicates bool   `json:"allow_duplicates,omitempty"` // Also enqueue URLs that were already scraped
+}
+
+// sitemapSelection is the outcome of filtering sitemap entries before enqueueing
+type sitemapSelection struct {
+	urls              []string
+	skippedDuplicates int
+	skippedPolicy     int
+}
+
+// IngestSitemap handles POST /api/scrape-requests/sitemap.
+// It fetches the sitemap (following index files), filters the entries through the same
+// rules used for crawled links, and creates one child scrape job per remaining URL under
+// a parent job representing the sitemap.
+func (h *Handler) IngestSitemap(w http.ResponseWriter, r *http.Request) {
+	var req SitemapIngestRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondErrorCode(w, ErrCodeInvalidRequestBody, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if req.URL == "" {
+		respondErrorCode(w, ErrCodeValidationFailed, "URL is required", http.StatusBadRequest)
+		return
+	}
+	if req.Limit < 0 {
+		respondErrorCode(w, ErrCodeValidationFailed, "limit must not be negative", http.StatusBadRequest)
+		return
+	}
+	limit := req.Limit
+	if limit == 0 {
+		limit = defaultSitemapLimit
+	}
+	if limit > sitemap.HardMaxURLs {
+		limit = sitemap.HardMaxURLs
+	}
+
+	if err := h.validateScrapeURL(r.Context(), req.URL); err != nil {
+		respondErrorDetails(w, ErrCodeURLRejected, fmt.Sprintf("URL rejected: %v", err), http.StatusBadRequest, urlRejectionDetails(err))
+		return
+	}
+	if err := h.domainPolicy.Check(req.URL); err != nil {
+		respondErrorDetails(w, ErrCodeDomainNotAllowed, fmt.Sprintf("URL rejected: %v", err), http.StatusForbidden, urlRejectionDetails(err))
+		return
+	}
+
+	if h.rejectIfSaturated(w, "sitemap", false) {
+		return
+	}
+
+	fetcher := sitemap.NewFetcher(sitemapFetchTimeout, h.validateScrapeURL)
+	result, err := fetcher.Fetch(r.Context(), req.URL, limit)
+	if err != nil {
+		respondErrorCode(w, ErrCodeUpstreamError, fmt.Sprintf("Failed to fetch sitemap: %v", err), http.StatusBadGateway)
+		return
+	}
+
+	selection := h.selectSitemapURLs(h.store(r), result.URLs, req.AllowDuplicates)
+
+	// Parent job groups the children; the sitemap itself has already been processed
+	now := time.Now()
+	createdBy := requestCreator(r)
+	ctx := queue.WithCreatedBy(r.Context(), createdBy)
+	parentID := uuid.New().String()
+	parent := &storage.ScrapeJob{
+		ID:          parentID,
+		URL:         req.URL,
+		Status:      "completed",
+		CreatedAt:   now,
+		UpdatedAt:   now,
+		CompletedAt: &now,
+		CreatedBy:   createdBy,
+	}
+	if err := h.store(r).SaveScrapeJob(parent); err != nil {
+		respondErrorCode(w, ErrCodeInternal, fmt.Sprintf("Failed to create scrape job: %v", err), http.StatusInternalServerError)
+		return
+	}
+	if h.businessMetrics != nil {
+		h.businessMetrics.ScrapeJobsTotal.WithLabelValues("parent").Inc()
+	}
+	queue.RecordScrapeJobCreated("parent", createdBy)
+
+	specs := make([]queue.ScrapeEnqueueSpec, 0, len(selection.urls))
+	for _, link := range selection.urls {
+		jobID := uuid.New().String()
+		job := &storage.ScrapeJob{
+			ID:              jobID,
+			URL:             link,
+			Status:          "queued",
+			CreatedAt:       time.Now(),
+			UpdatedAt:       time.Now(),
+			ParentJobID:     &parentID,
+			Depth:           1,
+			AllowDuplicates: req.AllowDuplicates,
+			CreatedBy:       createdBy,
+		}
+		if err := h.store(r).SaveScrapeJob(job); err != nil {
+			slog.Default().Error("failed to save sitemap scrape job", "url", link, "error", err)
+			continue
+		}
+		specs = append(specs, queue.ScrapeEnqueueSpec{
+			JobID:       jobID,
+			URL:         link,
+			ParentJobID: &parentID,
+			Depth:       1,
+			Delay:       time.Duration(len(specs)) * sitemapEnqueueStagger,
+		})
+	}
+
+	jobIDs := make([]string, 0, len(specs))
+	if h.queueClient == nil {
+		for _, spec := range specs {
+			jobIDs = append(jobIDs, spec.JobID)
+		}
+	} else {
+		for i, result := range h.queueClient.EnqueueScrapeBatch(ctx, specs) {
+			jobID := specs[i].JobID
+			if errors.Is(result.Err, queue.ErrDuplicateTask) {
+				// Another job queued this page moments ago
+				if err := h.store(r).DeleteScrapeJob(jobID); err != nil {
+					slog.Default().Warn("failed to delete duplicate sitemap scrape job", "job_id", jobID, "error", err)
+				}
+				selection.skippedDuplicates++
+				continue
+			}
+			if result.Err != nil {
+				slog.Default().Error("failed to enqueue sitemap scrape job", "job_id", jobID, "url", specs[i].URL, "error", result.Err)
+				if err := h.store(r).UpdateScrapeJobStatus(jobID, "failed", result.Err.Error()); err != nil {
+					slog.Default().Warn("failed to mark sitemap scrape job failed", "job_id", jobID, "error", err)
+				}
+				continue
+			}
+			if err := h.store(r).UpdateScrapeJobTaskID(jobID, result.TaskID); err != nil {
+				slog.Default().Warn("failed to update task id for job", "job_id", jobID, "error", err)
+			}
+			jobIDs = append(jobIDs, jobID)
+		}
+	}
+
+	slog.Default().Info("sitemap ingested",
+		"sitemap_url", req.URL,
+		"parent_job_id", parentID,
+		"sitemaps_fetched", result.SitemapsFetched,
+		"discovered", len(result.URLs),
+		"enqueued", len(jobIDs),
+		"skipped_duplicates", selection.skippedDuplicates,
+		"skipped_policy", selection.skippedPolicy,
+		"truncated", result.Truncated,
+	)
+
+	respondJSON(w, map[string]interface{}{
+		"id":                 parentID,
+		"url":                req.URL,
+		"limit":              limit,
+		"sitemaps_fetched":   result.SitemapsFetched,
+		"discovered":         len(result.URLs),
+		"enqueued":           len(jobIDs),
+		"skipped_duplicates": selection.skippedDuplicates,
+		"skipped_policy":     selection.skippedPolicy,
+		"truncated":          result.Truncated,
+		"job_ids":            jobIDs,
+	}, http.StatusOK)
+}
+
+// selectSitemapURLs drops entries that crawling would also skip (unscrapable, private or
+// outside the domain policy) and entries that normalize to a URL already seen in this
+// sitemap or, unless allowDuplicates is set, already stored as a request in store.
+func (h *Handler) selectSitemapURLs(store *storage.Storage, urls []string, allowDuplicates bool) sitemapSelection {
+	var selection sitemapSelection
+	seen := make(map[string]bool, len(urls))
+
+	for _, link := range urls {
+		if queue.ShouldSkipURL(link) || h.scrapeGuard().CheckStatic(link) != nil || h.domainPolicy.Check(link) != nil {
+			selection.skippedPolicy++
+			continue
+		}
+
+		normalized, err := urlnorm.Normalize(link)
+		if err != nil {
+			selection.skippedPolicy++
+			continue
+		}
+		if seen[normalized] {
+			selection.skippedDuplicates++
+			continue
+		}
+		seen[normalized] = true
+
+		if !allowDuplicates {
+			existing, err := store.FindRequestByNormalizedURL(normalized)
+			if err != nil {
+				slog.Default().Warn("failed to check for existing request", "url", link, "error", err)
+			} else if existing != nil {
+				selection.skippedDuplicates++
+				continue
+			}
+		}
+
+		selection.urls = append(selection.urls, link)
+	}
+
+	return selection
+}
@@ -0,0 +1,142 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/docutag/controller/internal/urlguard"
+)
+
+func TestSelectSitemapURLs(t *testing.T) {
+	t.Parallel()
+	h := &Handler{domainPolicy: urlguard.NewDomainPolicy(nil, []string{"*.ads.example"})}
+
+	selection := h.selectSitemapURLs(nil, []string{
+		"https://example.com/a",
+		"https://example.com/a/?utm_source=sitemap", // same page after normalization
+		"https://example.com/logo.png",
+		"mailto:hello@example.com",
+		"http://10.0.0.1/internal",
+		"https://banner.ads.example/",
+		"https://example.com/b",
+	}, true)
+
+	want := []string{"https://example.com/a", "https://example.com/b"}
+	if len(selection.urls) != len(want) || selection.urls[0] != want[0] || selection.urls[1] != want[1] {
+		t.Errorf("urls = %v, want %v", selection.urls, want)
+	}
+	if selection.skippedDuplicates != 1 {
+		t.Errorf("skippedDuplicates = %d, want 1", selection.skippedDuplicates)
+	}
+	if selection.skippedPolicy != 4 {
+		t.Errorf("skippedPolicy = %d, want 4", selection.skippedPolicy)
+	}
+}
+
+func TestIngestSitemapValidation(t *testing.T) {
+	t.Parallel()
+	missing := httptest.NewServer(http.NotFoundHandler())
+	defer missing.Close()
+
+	tests := []struct {
+		name       string
+		guard      *urlguard.Guard
+		body       string
+		wantStatus int
+	}{
+		{name: "missing url", body: `{}`, wantStatus: http.StatusBadRequest},
+		{name: "negative limit", body: `{"url": "https://example.com/sitemap.xml", "limit": -1}`, wantStatus: http.StatusBadRequest},
+		{name: "private target", body: `{"url": "http://127.0.0.1/sitemap.xml"}`, wantStatus: http.StatusBadRequest},
+		{
+			name:       "sitemap not found",
+			guard:      urlguard.NewWithResolver(true, publicResolver{}),
+			body:       fmt.Sprintf(`{"url": %q}`, missing.URL+"/sitemap.xml"),
+			wantStatus: http.StatusBadGateway,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := &Handler{urlGuard: tt.guard}
+			req := httptest.NewRequest(http.MethodPost, "/api/scrape-requests/sitemap", bytes.NewBufferString(tt.body))
+			w := httptest.NewRecorder()
+
+			serveRoute(h, w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("expected status %d, got %d: %s", tt.wantStatus, w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestIngestSitemap(t *testing.T) {
+	t.Parallel()
+	handler, _, _, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	var site *httptest.Server
+	site = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/sitemap.xml":
+			fmt.Fprintf(w, `<sitemapindex><sitemap><loc>%s/pages.xml</loc></sitemap></sitemapindex>`, site.URL)
+		case "/pages.xml":
+			fmt.Fprint(w, `<urlset>
+				<url><loc>https://example.com/one</loc></url>
+				<url><loc>https://example.com/one#top</loc></url>
+				<url><loc>https://example.com/two</loc></url>
+				<url><loc>https://example.com/photo.jpg</loc></url>
+			</urlset>`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer site.Close()
+
+	// The test site listens on loopback
+	handler.SetURLGuard(urlguard.NewWithResolver(true, publicResolver{}))
+
+	body, _ := json.Marshal(SitemapIngestRequest{URL: site.URL + "/sitemap.xml", Limit: 10})
+	req := httptest.NewRequest(http.MethodPost, "/api/scrape-requests/sitemap", bytes.NewBuffer(body))
+	w := httptest.NewRecorder()
+
+	serveRoute(handler, w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var resp struct {
+		ID                string   `json:"id"`
+		SitemapsFetched   int      `json:"sitemaps_fetched"`
+		Discovered        int      `json:"discovered"`
+		Enqueued          int      `json:"enqueued"`
+		SkippedDuplicates int      `json:"skipped_duplicates"`
+		SkippedPolicy     int      `json:"skipped_policy"`
+		JobIDs            []string `json:"job_ids"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+
+	if resp.SitemapsFetched != 2 || resp.Discovered != 4 || resp.Enqueued != 2 || resp.SkippedDuplicates != 1 || resp.SkippedPolicy != 1 {
+		t.Errorf("unexpected summary: %+v", resp)
+	}
+
+	children, err := handler.storage.GetChildJobs(resp.ID)
+	if err != nil {
+		t.Fatalf("failed to get child jobs: %v", err)
+	}
+	if len(children) != len(resp.JobIDs) {
+		t.Fatalf("expected %d child jobs, got %d", len(resp.JobIDs), len(children))
+	}
+	for _, child := range children {
+		if child.Depth != 1 || child.Status != "queued" {
+			t.Errorf("unexpected child job: depth=%d status=%s", child.Depth, child.Status)
+		}
+	}
+}
@@ -0,0 +1,204 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/docutag/controller/internal/clients"
+	"github.com/docutag/controller/internal/queue"
+	"github.com/docutag/controller/internal/storage"
+	"github.com/hibiken/asynq"
+)
+
+// countingAnalyzerServer is a text analyzer that only counts the calls it receives
+func countingAnalyzerServer(calls *int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(calls, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+}
+
+// assertArchived checks that a request was stored without analysis but is still found by its
+// score category and domain tags
+func assertArchived(t *testing.T, store *storage.Storage, record *storage.Request) {
+	t.Helper()
+	if !storage.AnalysisSkipped(record.Metadata) {
+		t.Errorf("Expected analysis_skipped in metadata, got %v", record.Metadata)
+	}
+	if _, ok := record.Metadata[storage.MetaAnalysisStatus]; ok {
+		t.Errorf("Expected no analysis status, got %v", record.Metadata[storage.MetaAnalysisStatus])
+	}
+	if record.TextAnalyzerUUID != "" {
+		t.Errorf("Expected no analyzer job, got %q", record.TextAnalyzerUUID)
+	}
+	for _, tag := range []string{"technical", "example.com"} {
+		ids, err := store.SearchByTags([]string{tag}, false)
+		if err != nil {
+			t.Fatalf("Tag search failed: %v", err)
+		}
+		found := false
+		for _, id := range ids {
+			found = found || id == record.ID
+		}
+		if !found {
+			t.Errorf("Expected a search for %q to find the archived request, got %v", tag, ids)
+		}
+	}
+}
+
+func TestScrapeURLSkipAnalysis(t *testing.T) {
+	t.Parallel()
+	handler, _, _, cleanup := setupTestHandler(t)
+	defer cleanup()
+	var analyzerCalls int32
+	analyzer := countingAnalyzerServer(&analyzerCalls)
+	defer analyzer.Close()
+	handler.textAnalyzer = clients.NewTextAnalyzerClient(analyzer.URL)
+
+	jsonData, _ := json.Marshal(ScrapeURLRequest{URL: "https://example.com/filing", SkipAnalysis: true})
+	req := httptest.NewRequest(http.MethodPost, "/api/scrape", bytes.NewBuffer(jsonData))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	serveRoute(handler, w, req)
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var response ControllerResponse
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if calls := atomic.LoadInt32(&analyzerCalls); calls != 0 {
+		t.Errorf("Expected no analyzer calls, got %d", calls)
+	}
+	record, err := handler.storage.GetRequest(response.ID)
+	if err != nil {
+		t.Fatalf("Failed to get request: %v", err)
+	}
+	assertArchived(t, handler.storage, record)
+}
+
+func TestWorkerSkipAnalysis(t *testing.T) {
+	t.Parallel()
+	handler, scraperMock, _, cleanup := setupTestHandler(t)
+	defer cleanup()
+	var analyzerCalls int32
+	analyzer := countingAnalyzerServer(&analyzerCalls)
+	defer analyzer.Close()
+
+	// The submission records the option on the job
+	jsonData, _ := json.Marshal(ScrapeURLRequest{URL: "https://example.com/filing", SkipAnalysis: true})
+	req := httptest.NewRequest(http.MethodPost, "/api/scrape-requests", bytes.NewBuffer(jsonData))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	serveRoute(handler, w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var submitted storage.ScrapeJob
+	if err := json.NewDecoder(w.Body).Decode(&submitted); err != nil {
+		t.Fatalf("Failed to decode job: %v", err)
+	}
+	job, err := handler.storage.GetScrapeJob(submitted.ID)
+	if err != nil || job == nil || !job.SkipAnalysis {
+		t.Fatalf("Expected the stored job to skip analysis, got %+v (err %v)", job, err)
+	}
+
+	worker := queue.NewWorker(queue.WorkerConfig{
+		RedisAddr:          "localhost:6379",
+		Concurrency:        1,
+		LinkScoreThreshold: 0.5,
+		MaxLinkDepth:       2,
+	}, handler.storage, clients.NewScraperClient(scraperMock.URL), clients.NewTextAnalyzerClient(analyzer.URL), nil, nil, nil, nil, nil)
+
+	payload, _ := json.Marshal(queue.ScrapeTaskPayload{JobID: job.ID, URL: job.URL, SkipAnalysis: true})
+	if err := worker.ProcessTask(context.Background(), asynq.NewTask(queue.TypeScrapeURL, payload)); err != nil {
+		t.Fatalf("scrape task failed: %v", err)
+	}
+	if calls := atomic.LoadInt32(&analyzerCalls); calls != 0 {
+		t.Errorf("Expected no analyzer calls, got %d", calls)
+	}
+	job, err = handler.storage.GetScrapeJob(job.ID)
+	if err != nil || job.ResultRequestID == nil {
+		t.Fatalf("Expected the scrape to produce a request, got %+v (err %v)", job, err)
+	}
+	record, err := handler.storage.GetRequest(*job.ResultRequestID)
+	if err != nil {
+		t.Fatalf("Failed to get request: %v", err)
+	}
+	assertArchived(t, handler.storage, record)
+
+	// Pages crawled from an archival scrape are archived too
+	extract, _ := json.Marshal(queue.ExtractLinksTaskPayload{ParentJobID: job.ID, SourceURL: job.URL, SkipAnalysis: true})
+	if err := worker.ProcessTask(context.Background(), asynq.NewTask(queue.TypeExtractLinks, extract)); err != nil {
+		t.Fatalf("extract links task failed: %v", err)
+	}
+	jobs, err := handler.storage.ListScrapeJobs(100, 0)
+	if err != nil {
+		t.Fatalf("Failed to list jobs: %v", err)
+	}
+	children := 0
+	for _, child := range jobs {
+		if child.ParentJobID == nil || *child.ParentJobID != job.ID {
+			continue
+		}
+		children++
+		if !child.SkipAnalysis {
+			t.Errorf("Expected child %s to inherit skip_analysis", child.URL)
+		}
+	}
+	if children == 0 {
+		t.Fatal("Expected link extraction to queue children")
+	}
+}
+
+func TestRetryKeepsSkipAnalysis(t *testing.T) {
+	t.Parallel()
+	handler, _, _, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("Failed to create miniredis: %v", err)
+	}
+	defer mr.Close()
+	handler.queueClient = queue.NewClient(queue.ClientConfig{RedisAddr: mr.Addr()})
+	defer handler.queueClient.Close()
+	inspector := asynq.NewInspector(asynq.RedisClientOpt{Addr: mr.Addr()})
+	defer inspector.Close()
+
+	now := time.Now()
+	job := &storage.ScrapeJob{ID: "retry-archive", URL: "https://example.com/filing", Status: "queued", SkipAnalysis: true, CreatedAt: now, UpdatedAt: now}
+	if err := handler.storage.SaveScrapeJob(job); err != nil {
+		t.Fatalf("Failed to save job: %v", err)
+	}
+	if err := handler.storage.UpdateScrapeJobStatus(job.ID, "failed", "no such host"); err != nil {
+		t.Fatalf("Failed to fail job: %v", err)
+	}
+
+	w := httptest.NewRecorder()
+	serveRoute(handler, w, httptest.NewRequest(http.MethodPost, "/api/scrape-requests/"+job.ID+"/retry", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	// The worker only learns about the option from the task payload
+	info, err := inspector.GetTaskInfo(queue.QueueScrape, job.ID)
+	if err != nil {
+		t.Fatalf("Failed to inspect the retried task: %v", err)
+	}
+	var payload queue.ScrapeTaskPayload
+	if err := json.Unmarshal(info.Payload, &payload); err != nil {
+		t.Fatalf("Failed to decode payload: %v", err)
+	}
+	if !payload.SkipAnalysis {
+		t.Error("Expected the retried task to keep skip_analysis")
+	}
+}
@@ -0,0 +1,209 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/docutag/controller/internal/domaintag"
+	"github.com/docutag/controller/internal/queue"
+	"github.com/docutag/controller/internal/settings"
+	"github.com/docutag/controller/internal/storage"
+	"github.com/google/uuid"
+)
+
+// staleCandidateFactor widens the candidate query so requests on domains with longer
+// windows do not crowd out due ones from a single pass
+const staleCandidateFactor = 4
+
+var (
+	errStaleRescrapeDisabled = errors.New("stale re-scrape is not enabled")
+	errStaleRescrapeRunning  = errors.New("a stale re-scrape pass is already running")
+)
+
+// staleRescrape holds the freshness windows and the lock that keeps passes from overlapping
+type staleRescrape struct {
+	windows   map[string]time.Duration // Keyed by normalized domain
+	fallback  time.Duration            // Window for unlisted domains; 0 leaves them alone
+	batchSize int
+
+	running sync.Mutex
+}
+
+// window returns the freshness window for a domain, or 0 if its requests are never refreshed
+func (s *staleRescrape) window(domain string) time.Duration {
+	if w, ok := s.windows[settings.NormalizeDomain(domain)]; ok && domain != "" {
+		return w
+	}
+	return s.fallback
+}
+
+// shortestWindow returns the smallest configured window, or 0 if there is none
+func (s *staleRescrape) shortestWindow() time.Duration {
+	shortest := s.fallback
+	for _, w := range s.windows {
+		if w > 0 && (shortest == 0 || w < shortest) {
+			shortest = w
+		}
+	}
+	return shortest
+}
+
+// StaleRescrapeResult summarizes one freshness pass. In a dry run Enqueued and RequestIDs
+// describe the re-scrapes that would have been queued.
+type StaleRescrapeResult struct {
+	Candidates int      `json:"candidates"` // Requests older than the shortest window
+	Enqueued   int      `json:"enqueued"`
+	Skipped    int      `json:"skipped"`   // Due requests refused by the domain policy, already queued or failing to enqueue
+	Saturated  bool     `json:"saturated"` // The pass stopped early because the scrape queue is full
+	RequestIDs []string `json:"request_ids"`
+	DryRun     bool     `json:"dry_run,omitempty"`
+}
+
+// SetStaleRescrape enables freshness passes. windows maps domains to how long a scrape
+// stays fresh; fallback applies to every other domain and 0 leaves them alone. A pass
+// queues at most batchSize re-scrapes.
+func (h *Handler) SetStaleRescrape(windows map[string]time.Duration, fallback time.Duration, batchSize int) {
+	normalized := make(map[string]time.Duration, len(windows))
+	for domain, w := range windows {
+		normalized[settings.NormalizeDomain(domain)] = w
+	}
+	h.staleRescrape = &staleRescrape{windows: normalized, fallback: fallback, batchSize: batchSize}
+}
+
+// RescrapeStale queues in-place re-scrapes of requests whose last scrape is older than
+// their domain's window, oldest first. The pass stops early once the scrape queue is
+// saturated so background refreshes never take capacity from new submissions. A dry run
+// selects the same requests but queues nothing.
+func (h *Handler) RescrapeStale(ctx context.Context, trigger string, dryRun bool) (StaleRescrapeResult, error) {
+	result := StaleRescrapeResult{RequestIDs: []string{}, DryRun: dryRun}
+	s := h.staleRescrape
+	if s == nil {
+		return result, errStaleRescrapeDisabled
+	}
+	if !s.running.TryLock() {
+		return result, errStaleRescrapeRunning
+	}
+	defer s.running.Unlock()
+
+	shortest := s.shortestWindow()
+	if shortest <= 0 || s.batchSize <= 0 {
+		return result, nil
+	}
+
+	now := time.Now()
+	candidates, err := h.storage.ListStaleRequests(now.Add(-shortest), s.batchSize*staleCandidateFactor)
+	if err != nil {
+		return result, err
+	}
+	result.Candidates = len(candidates)
+
+	current := h.settings.Get()
+	for _, candidate := range candidates {
+		if result.Enqueued >= s.batchSize || ctx.Err() != nil {
+			break
+		}
+		window := s.window(domaintag.Host(candidate.URL))
+		if window <= 0 || now.Sub(candidate.LastScrapedAt) < window {
+			continue
+		}
+		if _, full := h.backpressure.saturated(); full {
+			result.Saturated = true
+			break
+		}
+		if err := h.domainPolicy.Check(candidate.URL); err != nil {
+			result.Skipped++
+			continue
+		}
+
+		if dryRun {
+			result.Enqueued++
+			result.RequestIDs = append(result.RequestIDs, candidate.ID)
+			continue
+		}
+		if err := h.enqueueRescrape(ctx, candidate, queue.NewCrawlParams(current)); err != nil {
+			slog.Default().Warn("failed to queue stale re-scrape", "request_id", candidate.ID, "url", candidate.URL, "error", err)
+			result.Skipped++
+			continue
+		}
+		result.Enqueued++
+		result.RequestIDs = append(result.RequestIDs, candidate.ID)
+	}
+
+	if dryRun {
+		return result, nil
+	}
+	h.collectors().staleRescrapesEnqueued.WithLabelValues(trigger).Add(float64(result.Enqueued))
+	h.collectors().staleRescrapeLastRunEnqueued.Set(float64(result.Enqueued))
+	slog.Default().Info("stale re-scrape pass finished",
+		"trigger", trigger,
+		"candidates", result.Candidates,
+		"enqueued", result.Enqueued,
+		"skipped", result.Skipped,
+		"saturated", result.Saturated,
+	)
+	return result, nil
+}
+
+// enqueueRescrape creates and queues a job that refreshes a stored request in place
+func (h *Handler) enqueueRescrape(ctx context.Context, candidate storage.StaleRequest, params *storage.CrawlParams) error {
+	requestID := candidate.ID
+	job := &storage.ScrapeJob{
+		ID:          uuid.New().String(),
+		URL:         candidate.URL,
+		Status:      "queued",
+		CreatedAt:   time.Now(),
+		UpdatedAt:   time.Now(),
+		CreatedBy:   storage.CreatedByRescrape,
+		CrawlParams: params,
+		RescrapeOf:  &requestID,
+		Namespace:   candidate.Namespace,
+	}
+	if err := h.storage.SaveScrapeJob(job); err != nil {
+		return fmt.Errorf("failed to create scrape job: %w", err)
+	}
+	if h.queueClient == nil {
+		return nil
+	}
+
+	ctx = queue.WithCrawlParams(queue.WithCreatedBy(ctx, job.CreatedBy), params)
+	ctx = queue.WithNamespace(ctx, candidate.Namespace)
+	taskID, err := h.queueClient.EnqueueScrape(ctx, job.ID, job.URL, false)
+	if err != nil {
+		// A duplicate means the URL is being scraped already; either way this job never runs
+		if delErr := h.storage.DeleteScrapeJob(job.ID); delErr != nil {
+			slog.Default().Warn("failed to delete unqueued re-scrape job", "job_id", job.ID, "error", delErr)
+		}
+		return err
+	}
+	if err := h.storage.UpdateScrapeJobTaskID(job.ID, taskID); err != nil {
+		slog.Default().Warn("failed to update task id for job", "job_id", job.ID, "error", err)
+	}
+	queue.RecordScrapeJobCreated("rescrape", job.CreatedBy)
+	return nil
+}
+
+// TriggerStaleRescrape handles POST /api/admin/rescrape-stale and runs a freshness pass now
+func (h *Handler) TriggerStaleRescrape(w http.ResponseWriter, r *http.Request) {
+	dryRun, err := dryRunFromQuery(r)
+	if err != nil {
+		respondErrorCode(w, ErrCodeValidationFailed, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	result, err := h.RescrapeStale(r.Context(), "manual", dryRun)
+	switch {
+	case errors.Is(err, errStaleRescrapeDisabled):
+		respondErrorCode(w, ErrCodeNotConfigured, "stale re-scrape is not enabled", http.StatusServiceUnavailable)
+	case errors.Is(err, errStaleRescrapeRunning):
+		respondErrorCode(w, ErrCodeInvalidState, "A stale re-scrape pass is already running", http.StatusConflict)
+	case err != nil:
+		respondErrorCode(w, ErrCodeInternal, fmt.Sprintf("Failed to find stale requests: %v", err), http.StatusInternalServerError)
+	default:
+		respondJSON(w, result, http.StatusOK)
+	}
+}
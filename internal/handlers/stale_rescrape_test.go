@@ -0,0 +1,179 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/docutag/controller/internal/clients"
+	"github.com/docutag/controller/internal/queue"
+	"github.com/docutag/controller/internal/storage"
+	"github.com/hibiken/asynq"
+)
+
+func TestStaleRescrapeWindows(t *testing.T) {
+	t.Parallel()
+	h := &Handler{}
+	h.SetStaleRescrape(map[string]time.Duration{"www.News.example.com": 168 * time.Hour, "blog.example.com": 24 * time.Hour}, 720*time.Hour, 10)
+
+	tests := []struct {
+		domain string
+		want   time.Duration
+	}{
+		{"news.example.com", 168 * time.Hour},
+		{"blog.example.com", 24 * time.Hour},
+		{"other.example.com", 720 * time.Hour},
+		{"", 720 * time.Hour},
+	}
+	for _, tt := range tests {
+		if got := h.staleRescrape.window(tt.domain); got != tt.want {
+			t.Errorf("window(%q) = %s, want %s", tt.domain, got, tt.want)
+		}
+	}
+	if got := h.staleRescrape.shortestWindow(); got != 24*time.Hour {
+		t.Errorf("shortestWindow() = %s, want 24h", got)
+	}
+
+	// Without a fallback only listed domains are refreshed
+	h.SetStaleRescrape(map[string]time.Duration{"news.example.com": 168 * time.Hour}, 0, 10)
+	if got := h.staleRescrape.window("other.example.com"); got != 0 {
+		t.Errorf("expected unlisted domains to be left alone, got %s", got)
+	}
+}
+
+func TestTriggerStaleRescrapeDisabled(t *testing.T) {
+	t.Parallel()
+	w := httptest.NewRecorder()
+	serveRoute(&Handler{}, w, httptest.NewRequest(http.MethodPost, "/api/v1/admin/rescrape-stale", nil))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected 503 while disabled, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestStaleRescrapeRefreshesInPlace(t *testing.T) {
+	t.Parallel()
+	handler, scraperMock, analyzerMock, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	old := time.Now().UTC().Add(-10 * 24 * time.Hour)
+	save := func(id, rawURL string) {
+		t.Helper()
+		slug := id + "-slug"
+		req := &storage.Request{ID: id, CreatedAt: old, SourceType: "url", SourceURL: &rawURL, Tags: []string{"original"},
+			Metadata: map[string]interface{}{"title": "Old title"}, Slug: &slug, SEOEnabled: true}
+		if err := handler.storage.SaveRequest(req); err != nil {
+			t.Fatalf("Failed to save request: %v", err)
+		}
+	}
+	save("stale-news", "https://news.example.com/story")
+	save("stale-blog", "https://blog.example.com/post")
+
+	// News goes stale after a week, everything else after a month
+	handler.SetStaleRescrape(map[string]time.Duration{"news.example.com": 168 * time.Hour}, 720*time.Hour, 10)
+	w := httptest.NewRecorder()
+	serveRoute(handler, w, httptest.NewRequest(http.MethodPost, "/api/v1/admin/rescrape-stale", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var result StaleRescrapeResult
+	if err := json.Unmarshal(w.Body.Bytes(), &result); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if result.Candidates != 2 || result.Enqueued != 1 {
+		t.Fatalf("Expected 1 of 2 candidates to be queued, got %+v", result)
+	}
+
+	jobs, err := handler.storage.ListScrapeJobs(10, 0)
+	if err != nil {
+		t.Fatalf("Failed to list jobs: %v", err)
+	}
+	if len(jobs) != 1 || jobs[0].RescrapeOf == nil || *jobs[0].RescrapeOf != "stale-news" || jobs[0].CreatedBy != storage.CreatedByRescrape {
+		t.Fatalf("Expected one re-scrape job for stale-news, got %+v", jobs)
+	}
+
+	worker := queue.NewWorker(queue.WorkerConfig{RedisAddr: "localhost:6379", Concurrency: 1, LinkScoreThreshold: 0.5, MaxLinkDepth: 1},
+		handler.storage, clients.NewScraperClient(scraperMock.URL), clients.NewTextAnalyzerClient(analyzerMock.URL), nil, nil, nil, nil, nil)
+	payload, _ := json.Marshal(queue.ScrapeTaskPayload{JobID: jobs[0].ID, URL: jobs[0].URL})
+	if err := worker.ProcessTask(context.Background(), asynq.NewTask(queue.TypeScrapeURL, payload)); err != nil {
+		t.Fatalf("scrape task failed: %v", err)
+	}
+
+	job, err := handler.storage.GetScrapeJob(jobs[0].ID)
+	if err != nil {
+		t.Fatalf("Failed to get job: %v", err)
+	}
+	if job.ResultRequestID == nil || *job.ResultRequestID != "stale-news" {
+		t.Fatalf("Expected the job to point at the refreshed request, got %v (%s: %s)", job.ResultRequestID, job.Status, job.ErrorMessage)
+	}
+	request, err := handler.storage.GetRequest("stale-news")
+	if err != nil {
+		t.Fatalf("Failed to get request: %v", err)
+	}
+	if request.Metadata["title"] != "Example Page" || request.Slug == nil || *request.Slug != "stale-news-slug" {
+		t.Errorf("Expected new content under the old slug, got title %v, slug %v", request.Metadata["title"], request.Slug)
+	}
+	versions, err := handler.storage.ListRequestVersions("stale-news")
+	if err != nil {
+		t.Fatalf("Failed to list versions: %v", err)
+	}
+	if len(versions) != 1 || versions[0].Reason != storage.VersionReasonRescrape {
+		t.Errorf("Expected one rescrape version of the old content, got %+v", versions)
+	}
+
+	// The refreshed request is no longer stale, and a saturated queue stops the pass
+	handler.SetStaleRescrape(nil, time.Hour, 10)
+	handler.SetQueueBackpressure(1, false)
+	if err := handler.storage.SaveScrapeJob(&storage.ScrapeJob{ID: "stale-queued", URL: "https://example.com/q", Status: "queued", CreatedAt: time.Now(), UpdatedAt: time.Now()}); err != nil {
+		t.Fatalf("Failed to save job: %v", err)
+	}
+	result, err = handler.RescrapeStale(context.Background(), "schedule", false)
+	if err != nil {
+		t.Fatalf("RescrapeStale failed: %v", err)
+	}
+	if result.Candidates != 1 || result.Enqueued != 0 || !result.Saturated {
+		t.Errorf("Expected only stale-blog, held back by backpressure, got %+v", result)
+	}
+}
+
+func TestStaleRescrapeDryRun(t *testing.T) {
+	t.Parallel()
+	handler, _, _, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	old := time.Now().UTC().Add(-10 * 24 * time.Hour)
+	rawURL := "https://news.example.com/story"
+	if err := handler.storage.SaveRequest(&storage.Request{ID: "dry-stale", CreatedAt: old, SourceType: "url", SourceURL: &rawURL}); err != nil {
+		t.Fatalf("Failed to save request: %v", err)
+	}
+	handler.SetStaleRescrape(nil, 24*time.Hour, 10)
+
+	w := httptest.NewRecorder()
+	serveRoute(handler, w, httptest.NewRequest(http.MethodPost, "/api/v1/admin/rescrape-stale?dry_run=true", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var result StaleRescrapeResult
+	if err := json.Unmarshal(w.Body.Bytes(), &result); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if !result.DryRun || result.Enqueued != 1 || len(result.RequestIDs) != 1 || result.RequestIDs[0] != "dry-stale" {
+		t.Fatalf("Expected the dry run to report dry-stale, got %+v", result)
+	}
+
+	jobs, err := handler.storage.ListScrapeJobs(10, 0)
+	if err != nil {
+		t.Fatalf("Failed to list jobs: %v", err)
+	}
+	if len(jobs) != 0 {
+		t.Errorf("Expected a dry run to create no jobs, got %d", len(jobs))
+	}
+
+	w = httptest.NewRecorder()
+	serveRoute(handler, w, httptest.NewRequest(http.MethodPost, "/api/v1/admin/rescrape-stale?dry_run=maybe", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for an invalid dry_run, got %d", w.Code)
+	}
+}
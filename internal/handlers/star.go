@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/docutag/controller/internal/storage"
+)
+
+// StarResponse is returned when a request is starred or unstarred
+type StarResponse struct {
+	ID               string `json:"id"`
+	Starred          bool   `json:"starred"`
+	TombstoneRemoved bool   `json:"tombstone_removed"` // Starring clears any tombstone on the request
+}
+
+// StarRequest handles PUT /api/requests/{id}/star
+func (h *Handler) StarRequest(w http.ResponseWriter, r *http.Request) {
+	h.setStarred(w, r, true)
+}
+
+// UnstarRequest handles DELETE /api/requests/{id}/star
+func (h *Handler) UnstarRequest(w http.ResponseWriter, r *http.Request) {
+	h.setStarred(w, r, false)
+}
+
+func (h *Handler) setStarred(w http.ResponseWriter, r *http.Request, starred bool) {
+	id := r.PathValue("id")
+	if id == "" {
+		respondErrorCode(w, ErrCodeValidationFailed, "Request ID is required", http.StatusBadRequest)
+		return
+	}
+
+	tombstoneRemoved, err := h.store(r).SetStarred(id, starred)
+	if err != nil {
+		if err.Error() == "request not found" {
+			respondErrorCode(w, ErrCodeRequestNotFound, "Request not found", http.StatusNotFound)
+			return
+		}
+		respondErrorCode(w, ErrCodeInternal, fmt.Sprintf("Failed to update starred: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	action := storage.AuditActionStar
+	if !starred {
+		action = storage.AuditActionUnstar
+	}
+	h.recordAudit(r, action, storage.AuditEntityRequest, id, nil)
+	if tombstoneRemoved {
+		h.recordAudit(r, storage.AuditActionUntombstone, storage.AuditEntityRequest, id, map[string]interface{}{
+			"reason": "starred",
+		})
+	}
+
+	respondJSON(w, StarResponse{ID: id, Starred: starred, TombstoneRemoved: tombstoneRemoved}, http.StatusOK)
+}
@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/docutag/controller/internal/storage"
+)
+
+func TestStarRequestRemovesTombstone(t *testing.T) {
+	t.Parallel()
+	connStr, cleanup := setupTestDB(t, "test_star_request")
+	defer cleanup()
+
+	store, err := storage.New(connStr, []string{"low-quality", "sparse-content"}, 30, 90, 90)
+	if err != nil {
+		t.Fatalf("Failed to create storage: %v", err)
+	}
+	defer store.Close()
+
+	handler := &Handler{storage: store}
+
+	req := &storage.Request{
+		ID:               "star-req-1",
+		CreatedAt:        time.Now().UTC(),
+		SourceType:       "text",
+		TextAnalyzerUUID: "analyzer-1",
+		SEOEnabled:       true,
+		Metadata: map[string]interface{}{
+			"tombstone_datetime": time.Now().UTC().Add(-time.Hour).Format(time.RFC3339),
+			"tombstone_reason":   "Low quality score: 0.20",
+		},
+	}
+	if err := store.SaveRequest(req); err != nil {
+		t.Fatalf("Failed to save request: %v", err)
+	}
+
+	w := httptest.NewRecorder()
+	serveRoute(handler, w, httptest.NewRequest(http.MethodPut, "/api/v1/requests/star-req-1/star", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d. Body: %s", w.Code, w.Body.String())
+	}
+	var resp StarResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if !resp.Starred || !resp.TombstoneRemoved {
+		t.Errorf("Expected starred with tombstone removed, got %+v", resp)
+	}
+
+	record, err := store.GetRequest("star-req-1")
+	if err != nil {
+		t.Fatalf("Failed to get request: %v", err)
+	}
+	if !record.Starred {
+		t.Error("Expected request to be starred")
+	}
+	if _, ok := record.Metadata["tombstone_datetime"]; ok {
+		t.Errorf("Expected tombstone to be removed, got metadata %v", record.Metadata)
+	}
+
+	// Unstarring keeps the request untombstoned
+	w = httptest.NewRecorder()
+	serveRoute(handler, w, httptest.NewRequest(http.MethodDelete, "/api/v1/requests/star-req-1/star", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200 on unstar, got %d. Body: %s", w.Code, w.Body.String())
+	}
+	resp = StarResponse{}
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if resp.Starred || resp.TombstoneRemoved {
+		t.Errorf("Expected unstarred with no tombstone change, got %+v", resp)
+	}
+
+	w = httptest.NewRecorder()
+	serveRoute(handler, w, httptest.NewRequest(http.MethodPut, "/api/v1/requests/missing/star", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 for a missing request, got %d", w.Code)
+	}
+}
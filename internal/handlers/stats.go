@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/docutag/controller/internal/storage"
+)
+
+// defaultStatsCacheTTL is how long GetGlobalStats reuses a computed result unless SetStatsCacheTTL says otherwise
+const defaultStatsCacheTTL = 30 * time.Second
+
+// statsCache keeps the last global stats of each namespace for a short TTL so
+// dashboard polling does not re-run the aggregate queries on every request
+type statsCache struct {
+	ttl time.Duration
+	now func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]cachedStats // Keyed by namespace
+}
+
+type cachedStats struct {
+	stats   *storage.GlobalStats
+	expires time.Time
+}
+
+func newStatsCache(ttl time.Duration) *statsCache {
+	return &statsCache{ttl: ttl, now: time.Now, entries: make(map[string]cachedStats)}
+}
+
+// get returns the cached stats for namespace, calling load when they are missing or
+// expired. A TTL of 0 disables caching.
+func (c *statsCache) get(namespace string, load func() (*storage.GlobalStats, error)) (*storage.GlobalStats, error) {
+	if c == nil || c.ttl <= 0 {
+		return load()
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if entry, ok := c.entries[namespace]; ok && c.now().Before(entry.expires) {
+		return entry.stats, nil
+	}
+
+	stats, err := load()
+	if err != nil {
+		return nil, err
+	}
+	c.entries[namespace] = cachedStats{stats: stats, expires: c.now().Add(c.ttl)}
+	return stats, nil
+}
+
+// SetStatsCacheTTL sets how long GET /api/stats results are reused; 0 disables the cache
+func (h *Handler) SetStatsCacheTTL(ttl time.Duration) {
+	h.statsCache = newStatsCache(ttl)
+}
+
+// GetGlobalStats handles GET /api/stats
+func (h *Handler) GetGlobalStats(w http.ResponseWriter, r *http.Request) {
+	store := h.store(r)
+	stats, err := h.statsCache.get(store.Namespace(), store.GetGlobalStats)
+	if err != nil {
+		respondErrorCode(w, ErrCodeInternal, fmt.Sprintf("Failed to get stats: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	respondJSON(w, stats, http.StatusOK)
+}
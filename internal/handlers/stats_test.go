@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/docutag/controller/internal/storage"
+)
+
+func TestStatsCacheReusesUntilExpiry(t *testing.T) {
+	t.Parallel()
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	cache := newStatsCache(30 * time.Second)
+	cache.now = func() time.Time { return now }
+
+	loads := 0
+	load := func() (*storage.GlobalStats, error) {
+		loads++
+		return &storage.GlobalStats{TotalRequests: loads, GeneratedAt: now}, nil
+	}
+
+	first, err := cache.get(storage.DefaultNamespace, load)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	now = now.Add(29 * time.Second)
+	second, _ := cache.get(storage.DefaultNamespace, load)
+	if second != first || loads != 1 {
+		t.Fatalf("expected the cached result within the TTL, got %d loads", loads)
+	}
+
+	now = now.Add(2 * time.Second)
+	third, _ := cache.get(storage.DefaultNamespace, load)
+	if third.TotalRequests != 2 || loads != 2 {
+		t.Errorf("expected a reload after the TTL, got %d loads", loads)
+	}
+
+	other, _ := cache.get("team-a", load)
+	if other.TotalRequests != 3 || loads != 3 {
+		t.Errorf("expected each namespace to be cached separately, got %d loads", loads)
+	}
+}
+
+func TestStatsCacheDisabledAndErrors(t *testing.T) {
+	t.Parallel()
+	loads := 0
+	load := func() (*storage.GlobalStats, error) {
+		loads++
+		return &storage.GlobalStats{}, nil
+	}
+
+	for _, cache := range []*statsCache{nil, newStatsCache(0)} {
+		loads = 0
+		cache.get(storage.DefaultNamespace, load)
+		cache.get(storage.DefaultNamespace, load)
+		if loads != 2 {
+			t.Errorf("expected every call to load when caching is off, got %d loads", loads)
+		}
+	}
+
+	cache := newStatsCache(time.Minute)
+	if _, err := cache.get(storage.DefaultNamespace, func() (*storage.GlobalStats, error) { return nil, errors.New("db down") }); err == nil {
+		t.Fatal("expected the load error to be returned")
+	}
+	cache.get(storage.DefaultNamespace, load)
+	if loads != 3 {
+		t.Error("expected a failed load not to be cached")
+	}
+}
@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/docutag/controller/internal/clients"
+	"github.com/docutag/controller/internal/queue"
+	"github.com/docutag/controller/internal/storage"
+	"github.com/hibiken/asynq"
+)
+
+func TestWorkerScrapeTaskTimeout(t *testing.T) {
+	t.Parallel()
+	connStr, dbCleanup := setupTestDB(t, "task_timeout_worker")
+	defer dbCleanup()
+
+	store, err := storage.New(connStr, []string{"low-quality", "sparse-content"}, 30, 90, 90)
+	if err != nil {
+		t.Fatalf("Failed to create storage: %v", err)
+	}
+	defer store.Close()
+
+	// Scores come back at once, but the scrape itself hangs until the client gives up
+	scraperMock := mockScraperServer()
+	defer scraperMock.Close()
+	slowScraper := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/scrape" {
+			select {
+			case <-r.Context().Done():
+			case <-time.After(5 * time.Second):
+			}
+			return
+		}
+		scraperMock.Config.Handler.ServeHTTP(w, r)
+	}))
+	defer slowScraper.Close()
+	textAnalyzerMock := mockTextAnalyzerServer()
+	defer textAnalyzerMock.Close()
+
+	worker := queue.NewWorker(queue.WorkerConfig{
+		RedisAddr:          "localhost:6379",
+		Concurrency:        1,
+		LinkScoreThreshold: 0.5,
+		TaskTimeout:        200 * time.Millisecond,
+	}, store, clients.NewScraperClient(slowScraper.URL), clients.NewTextAnalyzerClient(textAnalyzerMock.URL), nil, nil, nil, nil, nil)
+
+	now := time.Now()
+	job := &storage.ScrapeJob{ID: "slow-job", URL: "https://example.com/slow", Status: "queued", CreatedAt: now, UpdatedAt: now}
+	if err := store.SaveScrapeJob(job); err != nil {
+		t.Fatalf("Failed to save job: %v", err)
+	}
+
+	payload, _ := json.Marshal(queue.ScrapeTaskPayload{JobID: job.ID, URL: job.URL})
+	start := time.Now()
+	err = worker.ProcessTask(context.Background(), asynq.NewTask(queue.TypeScrapeURL, payload))
+	if !errors.Is(err, queue.ErrTaskTimeout) {
+		t.Fatalf("expected a task timeout, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("expected the task to stop near its timeout, took %s", elapsed)
+	}
+
+	job, err = store.GetScrapeJob(job.ID)
+	if err != nil {
+		t.Fatalf("Failed to get job: %v", err)
+	}
+	if job.Status != "failed" || !strings.HasPrefix(job.ErrorMessage, "task timeout") {
+		t.Errorf("expected the job to fail with a task timeout, got status %q error %q", job.Status, job.ErrorMessage)
+	}
+	if job.ResultRequestID != nil {
+		t.Errorf("expected no document from a timed out scrape, got %s", *job.ResultRequestID)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"crypto/md5"
 	"database/sql"
 	"fmt"
+	"net/http"
 	"os"
 	"testing"
 	"time"
@@ -87,3 +88,11 @@ func getEnvOrDefault(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+// serveRoute dispatches req through the production routes, so path values and
+// method matching behave as they do in the server
+func serveRoute(h *Handler, w http.ResponseWriter, req *http.Request) {
+	mux := http.NewServeMux()
+	h.RegisterRoutes(mux)
+	MethodNotAllowed(mux).ServeHTTP(w, req)
+}
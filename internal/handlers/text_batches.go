@@ -0,0 +1,158 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/docutag/controller/internal/scraper_requests"
+)
+
+const (
+	// maxBatchTexts is the most texts one bulk analysis request may carry
+	maxBatchTexts = 100
+	// maxBatchTextBytes is the largest text a bulk analysis request accepts
+	maxBatchTextBytes = 64 << 10
+	// textBatchConcurrency bounds the texts of one batch analyzed at once
+	textBatchConcurrency = 4
+)
+
+// AnalyzeTextBatchRequest carries the texts of a bulk analysis request
+type AnalyzeTextBatchRequest struct {
+	Texts []string `json:"texts"`
+}
+
+// TextBatchItem is the outcome of one text in a bulk analysis request: a job, or why the
+// text was rejected
+type TextBatchItem struct {
+	Index int    `json:"index"` // Position in the submitted texts
+	JobID string `json:"job_id,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+// AnalyzeTextBatchResponse is returned by POST /api/analyze-requests/bulk
+type AnalyzeTextBatchResponse struct {
+	BatchID  string          `json:"batch_id"`
+	Accepted int             `json:"accepted"`
+	Rejected int             `json:"rejected"`
+	Items    []TextBatchItem `json:"items"` // One per submitted text, in order
+}
+
+// TextBatchSummary is returned by GET /api/analyze-requests
+type TextBatchSummary struct {
+	BatchID    string                            `json:"batch_id,omitempty"`
+	Total      int                               `json:"total"`
+	Pending    int                               `json:"pending"`
+	Processing int                               `json:"processing"`
+	Completed  int                               `json:"completed"`
+	Failed     int                               `json:"failed"`
+	Requests   []*scraper_requests.ScrapeRequest `json:"requests"`
+}
+
+// textBatchError explains why a text cannot be analyzed, or returns ""
+func textBatchError(text string) string {
+	switch {
+	case strings.TrimSpace(text) == "":
+		return "text is empty"
+	case len(text) > maxBatchTextBytes:
+		return fmt.Sprintf("text is %d bytes, over the %d byte limit", len(text), maxBatchTextBytes)
+	}
+	return ""
+}
+
+// CreateTextAnalysisBatch handles POST /api/analyze-requests/bulk. Each valid text gets its
+// own analysis job, processed exactly like a single text submission; empty and oversized
+// texts are reported per item without failing the others.
+func (h *Handler) CreateTextAnalysisBatch(w http.ResponseWriter, r *http.Request) {
+	var req AnalyzeTextBatchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondErrorCode(w, ErrCodeInvalidRequestBody, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if len(req.Texts) == 0 {
+		respondErrorCode(w, ErrCodeValidationFailed, "texts is required", http.StatusBadRequest)
+		return
+	}
+	if len(req.Texts) > maxBatchTexts {
+		respondErrorCode(w, ErrCodeValidationFailed, fmt.Sprintf("At most %d texts may be submitted at once, got %d", maxBatchTexts, len(req.Texts)), http.StatusBadRequest)
+		return
+	}
+
+	resp := AnalyzeTextBatchResponse{Items: make([]TextBatchItem, len(req.Texts))}
+	var valid []string
+	for i, text := range req.Texts {
+		resp.Items[i].Index = i
+		if msg := textBatchError(text); msg != "" {
+			resp.Items[i].Error = msg
+			resp.Rejected++
+			continue
+		}
+		valid = append(valid, text)
+	}
+	if len(valid) == 0 {
+		respondErrorDetails(w, ErrCodeValidationFailed, "No text can be analyzed", http.StatusBadRequest,
+			map[string]interface{}{"items": resp.Items})
+		return
+	}
+
+	batchID, jobs := h.scrapeRequests.CreateTextBatch(valid)
+	resp.BatchID = batchID
+	resp.Accepted = len(jobs)
+	next := 0
+	for i := range resp.Items {
+		if resp.Items[i].Error == "" {
+			resp.Items[i].JobID = jobs[next].ID
+			next++
+		}
+	}
+
+	// Analyze in the background, a few texts at a time so a batch does not flood the analyzer
+	createdBy, namespace := requestCreator(r), h.store(r).Namespace()
+	go func() {
+		sem := make(chan struct{}, textBatchConcurrency)
+		var wg sync.WaitGroup
+		for i, job := range jobs {
+			sem <- struct{}{}
+			wg.Add(1)
+			go func() {
+				defer func() { <-sem; wg.Done() }()
+				h.processTextAnalysisRequest(job.ID, valid[i], batchID, createdBy, namespace)
+			}()
+		}
+		wg.Wait()
+	}()
+
+	respondCreatedV1(w, r, "/analyze-requests?batch_id="+batchID, resp)
+}
+
+// ListTextAnalysisRequests handles GET /api/analyze-requests?batch_id= and summarizes the
+// tracked text analysis jobs, or one batch's in submission order. Jobs are kept for 24 hours;
+// their documents carry the batch ID in metadata.analysis_batch_id after that.
+func (h *Handler) ListTextAnalysisRequests(w http.ResponseWriter, r *http.Request) {
+	batchID := r.URL.Query().Get("batch_id")
+	requests := h.scrapeRequests.ListText(batchID)
+	if batchID != "" && len(requests) == 0 {
+		respondErrorCode(w, ErrCodeNotFound, "No tracked text analysis jobs have this batch ID", http.StatusNotFound)
+		return
+	}
+
+	summary := TextBatchSummary{BatchID: batchID, Total: len(requests), Requests: requests}
+	if summary.Requests == nil {
+		summary.Requests = []*scraper_requests.ScrapeRequest{}
+	}
+	for _, req := range requests {
+		switch req.Status {
+		case scraper_requests.StatusPending:
+			summary.Pending++
+		case scraper_requests.StatusProcessing:
+			summary.Processing++
+		case scraper_requests.StatusCompleted:
+			summary.Completed++
+		case scraper_requests.StatusFailed:
+			summary.Failed++
+		}
+	}
+	respondJSON(w, summary, http.StatusOK)
+}
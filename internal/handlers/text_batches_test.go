@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/docutag/controller/internal/clients"
+	"github.com/docutag/controller/internal/scraper_requests"
+	"github.com/docutag/controller/internal/storage"
+)
+
+func TestCreateTextAnalysisBatchValidation(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name string
+		body string
+	}{
+		{"no texts", `{"texts":[]}`},
+		{"too many texts", `{"texts":[` + strings.TrimSuffix(strings.Repeat(`"a",`, maxBatchTexts+1), ",") + `]}`},
+		{"every text invalid", `{"texts":["", "  "]}`},
+	}
+	for _, tt := range tests {
+		h := &Handler{scrapeRequests: scraper_requests.NewManager()}
+		w := httptest.NewRecorder()
+		serveRoute(h, w, httptest.NewRequest(http.MethodPost, "/api/v1/analyze-requests/bulk", strings.NewReader(tt.body)))
+		var resp ErrorResponse
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		if w.Code != http.StatusBadRequest || resp.Code != ErrCodeValidationFailed {
+			t.Errorf("%s: expected 400 %s, got %d: %s", tt.name, ErrCodeValidationFailed, w.Code, w.Body.String())
+		}
+		if len(h.scrapeRequests.ListText("")) != 0 {
+			t.Errorf("%s: expected no jobs to be created", tt.name)
+		}
+		h.scrapeRequests.Close()
+	}
+}
+
+// TestCreateTextAnalysisBatch queues a batch with two bad entries against an analyzer that is
+// down, so every queued job ends up failed without reaching storage
+func TestCreateTextAnalysisBatch(t *testing.T) {
+	t.Parallel()
+	analyzer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "down", http.StatusServiceUnavailable)
+	}))
+	defer analyzer.Close()
+	h := &Handler{
+		storage:        &storage.Storage{},
+		textAnalyzer:   clients.NewTextAnalyzerClient(analyzer.URL),
+		scrapeRequests: scraper_requests.NewManager(),
+	}
+	defer h.scrapeRequests.Close()
+
+	body := `{"texts":["first answer", "", "second answer", "` + strings.Repeat("x", maxBatchTextBytes+1) + `"]}`
+	w := httptest.NewRecorder()
+	serveRoute(h, w, httptest.NewRequest(http.MethodPost, "/api/v1/analyze-requests/bulk", strings.NewReader(body)))
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp AnalyzeTextBatchResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if resp.BatchID == "" || resp.Accepted != 2 || resp.Rejected != 2 || len(resp.Items) != 4 {
+		t.Fatalf("Unexpected response %+v", resp)
+	}
+	for i, item := range resp.Items {
+		if item.Index != i || (item.JobID == "") == (item.Error == "") {
+			t.Errorf("Item %d: expected exactly one of job ID and error, got %+v", i, item)
+		}
+	}
+	if w.Header().Get("Location") != "/api/v1/analyze-requests?batch_id="+resp.BatchID {
+		t.Errorf("Expected a Location pointing at the batch, got %q", w.Header().Get("Location"))
+	}
+
+	var summary TextBatchSummary
+	deadline := time.Now().Add(10 * time.Second)
+	for summary.Failed != 2 {
+		if time.Now().After(deadline) {
+			t.Fatalf("Jobs never failed: %+v", summary)
+		}
+		time.Sleep(10 * time.Millisecond)
+		w := httptest.NewRecorder()
+		serveRoute(h, w, httptest.NewRequest(http.MethodGet, "/api/v1/analyze-requests?batch_id="+resp.BatchID, nil))
+		if err := json.Unmarshal(w.Body.Bytes(), &summary); err != nil {
+			t.Fatalf("Failed to decode summary: %v", err)
+		}
+	}
+	if summary.Total != 2 || summary.Requests[0].ID != resp.Items[0].JobID || summary.Requests[1].ID != resp.Items[2].JobID {
+		t.Errorf("Expected the batch's jobs in submission order, got %+v", summary)
+	}
+
+	w = httptest.NewRecorder()
+	serveRoute(h, w, httptest.NewRequest(http.MethodGet, "/api/v1/analyze-requests?batch_id=missing", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for an unknown batch, got %d", w.Code)
+	}
+}
@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/docutag/controller/internal/storage"
+)
+
+// defaultTextDedupWindow is how long a submitted text is answered with its earlier request
+// unless configured otherwise
+const defaultTextDedupWindow = 24 * time.Hour
+
+// DuplicateTextResponse is the earlier request a repeated text submission is answered with
+type DuplicateTextResponse struct {
+	ControllerResponse
+	Duplicate bool `json:"duplicate"` // Always true
+}
+
+// SetTextDedupWindow sets how long an analyzed text is answered with its existing request
+// when it is submitted again. 0 disables the check.
+func (h *Handler) SetTextDedupWindow(window time.Duration) {
+	h.textDedupWindow = window
+}
+
+// respondDuplicateText answers a text submission with the request an identical text created
+// within the dedup window and returns true, or returns false when the text must be analyzed.
+// Texts are compared by storage.TextHash, so case and whitespace differences do not matter.
+func (h *Handler) respondDuplicateText(w http.ResponseWriter, r *http.Request, text string, force bool) bool {
+	if force || h.textDedupWindow <= 0 {
+		return false
+	}
+	hash := storage.TextHash(text)
+	if hash == "" {
+		return false
+	}
+	existing, err := h.store(r).FindRequestByTextHash(hash, time.Now().Add(-h.textDedupWindow))
+	if err != nil {
+		respondErrorCode(w, ErrCodeInternal, fmt.Sprintf("Failed to check for duplicate text: %v", err), http.StatusInternalServerError)
+		return true
+	}
+	if existing == nil {
+		return false
+	}
+	respondJSON(w, DuplicateTextResponse{ControllerResponse: newControllerResponse(existing), Duplicate: true}, http.StatusOK)
+	return true
+}
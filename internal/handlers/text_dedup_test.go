@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestAnalyzeTextDuplicate(t *testing.T) {
+	t.Parallel()
+	handler, _, _, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	analyze := func(path, body string) (*httptest.ResponseRecorder, DuplicateTextResponse) {
+		w := httptest.NewRecorder()
+		serveRoute(handler, w, httptest.NewRequest(http.MethodPost, path, strings.NewReader(body)))
+		var resp DuplicateTextResponse
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		return w, resp
+	}
+
+	w, first := analyze("/api/v1/analyze", `{"text":"Acme announces record quarter."}`)
+	if w.Code != http.StatusCreated || first.Duplicate {
+		t.Fatalf("Expected the first submission to be analyzed, got %d: %s", w.Code, w.Body.String())
+	}
+
+	tests := []struct {
+		name      string
+		path      string
+		body      string
+		duplicate bool
+	}{
+		{"identical text", "/api/v1/analyze", `{"text":"Acme announces record quarter."}`, true},
+		{"whitespace and case variant", "/api/v1/analyze", `{"text":"  acme ANNOUNCES\n record   quarter. "}`, true},
+		{"async submission", "/api/v1/analyze-requests", `{"text":"Acme announces record quarter."}`, true},
+		{"force", "/api/v1/analyze", `{"text":"Acme announces record quarter.","force":true}`, false},
+		{"different text", "/api/v1/analyze", `{"text":"Acme announces a new CEO."}`, false},
+	}
+	for _, tt := range tests {
+		w, resp := analyze(tt.path, tt.body)
+		if tt.duplicate {
+			if w.Code != http.StatusOK || !resp.Duplicate || resp.ID != first.ID {
+				t.Errorf("%s: expected 200 with duplicate of %s, got %d: %s", tt.name, first.ID, w.Code, w.Body.String())
+			}
+			continue
+		}
+		if w.Code != http.StatusCreated || resp.Duplicate || resp.ID == first.ID {
+			t.Errorf("%s: expected a new request, got %d: %s", tt.name, w.Code, w.Body.String())
+		}
+	}
+
+	// With the window disabled every submission is analyzed
+	handler.SetTextDedupWindow(0)
+	if w, resp := analyze("/api/v1/analyze", `{"text":"Acme announces record quarter."}`); w.Code != http.StatusCreated || resp.Duplicate {
+		t.Errorf("Expected a new request with dedup disabled, got %d: %s", w.Code, w.Body.String())
+	}
+}
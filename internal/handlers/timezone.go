@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// dateOnlyLayout is the layout of a date bound given without a time of day
+const dateOnlyLayout = "2006-01-02"
+
+// loadTimezone resolves a caller-supplied IANA zone name. An empty name means UTC.
+func loadTimezone(name string) (*time.Location, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return time.UTC, nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid timezone %q: use an IANA name such as America/Los_Angeles", name)
+	}
+	return loc, nil
+}
+
+// parseDateBound parses an RFC3339 timestamp or a plain date. A plain date names a whole day
+// in loc: as a start bound it is that day's midnight, and as an end bound it is the following
+// midnight, exclusive. The result is always in UTC, the zone effective dates are stored in.
+// dateOnly reports whether s was a plain date.
+func parseDateBound(s string, loc *time.Location, end bool) (t time.Time, dateOnly bool, err error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t.UTC(), false, nil
+	}
+	day, err := time.ParseInLocation(dateOnlyLayout, s, loc)
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	if end {
+		// AddDate keeps wall time, so a day with a DST change is still midnight to midnight
+		day = day.AddDate(0, 0, 1)
+	}
+	return day.UTC(), true, nil
+}
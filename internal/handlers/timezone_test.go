@@ -0,0 +1,143 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/docutag/controller/internal/storage"
+)
+
+func TestParseDateBound(t *testing.T) {
+	t.Parallel()
+	la, err := loadTimezone("America/Los_Angeles")
+	if err != nil {
+		t.Fatalf("Failed to load time zone: %v", err)
+	}
+
+	tests := []struct {
+		name         string
+		value        string
+		loc          *time.Location
+		end          bool
+		want         time.Time
+		wantDateOnly bool
+	}{
+		{"timestamp ignores the zone", "2024-03-01T23:30:00-08:00", la, false, time.Date(2024, 3, 2, 7, 30, 0, 0, time.UTC), false},
+		{"UTC start", "2024-03-01", time.UTC, false, time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), true},
+		{"UTC end", "2024-03-01", time.UTC, true, time.Date(2024, 3, 2, 0, 0, 0, 0, time.UTC), true},
+		{"local start", "2024-03-01", la, false, time.Date(2024, 3, 1, 8, 0, 0, 0, time.UTC), true},
+		{"local end", "2024-03-01", la, true, time.Date(2024, 3, 2, 8, 0, 0, 0, time.UTC), true},
+		// Clocks spring forward on March 10, so that day ends at 07:00 UTC rather than 08:00
+		{"local end on a DST change", "2024-03-10", la, true, time.Date(2024, 3, 11, 7, 0, 0, 0, time.UTC), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, dateOnly, err := parseDateBound(tt.value, tt.loc, tt.end)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if !got.Equal(tt.want) || got.Location() != time.UTC || dateOnly != tt.wantDateOnly {
+				t.Errorf("Expected %v (date only %v), got %v (date only %v)", tt.want, tt.wantDateOnly, got, dateOnly)
+			}
+		})
+	}
+
+	if _, _, err := parseDateBound("March 1st", time.UTC, false); err == nil {
+		t.Error("Expected an error for an unparseable date")
+	}
+}
+
+func TestTimezoneValidation(t *testing.T) {
+	t.Parallel()
+	// Validation runs before storage is touched, so a bare handler is enough
+	h := &Handler{}
+
+	tests := []struct {
+		method string
+		path   string
+		body   string
+	}{
+		{http.MethodPost, "/api/v1/requests/filter", `{"date_start": "2024-03-01", "timezone": "Pacific/Atlantis"}`},
+		{http.MethodGet, "/api/v1/requests/histogram?timezone=PST8PDT-ish", ""},
+		{http.MethodGet, "/api/v1/tags/timeline?start_date=2024-03-01&end_date=2024-03-02&timezone=Nowhere/Special", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			serveRoute(h, w, httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body)))
+			if w.Code != http.StatusBadRequest {
+				t.Fatalf("Expected status 400, got %d: %s", w.Code, w.Body.String())
+			}
+			var resp ErrorResponse
+			if err := json.NewDecoder(w.Body).Decode(&resp); err != nil || resp.Code != ErrCodeValidationFailed {
+				t.Errorf("Expected %s, got %+v (err %v)", ErrCodeValidationFailed, resp, err)
+			}
+		})
+	}
+}
+
+func TestFilterRequestsTimezone(t *testing.T) {
+	t.Parallel()
+	handler, _, _, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	// Late on March 1 in Los Angeles is already March 2 in UTC
+	if err := handler.storage.SaveRequest(&storage.Request{
+		ID: "late-evening", CreatedAt: time.Now(), SourceType: "url", SEOEnabled: true,
+		Metadata: map[string]interface{}{"scraper_metadata": map[string]interface{}{"publish_date": "2024-03-01T23:30:00-08:00"}},
+	}); err != nil {
+		t.Fatalf("Failed to save request: %v", err)
+	}
+
+	tests := []struct {
+		day      string
+		timezone string
+		want     int
+	}{
+		{"2024-03-01", "", 0},
+		{"2024-03-02", "", 1},
+		{"2024-03-01", "America/Los_Angeles", 1},
+		{"2024-03-02", "America/Los_Angeles", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.day+" "+tt.timezone, func(t *testing.T) {
+			body := fmt.Sprintf(`{"date_start": %q, "date_end": %q, "timezone": %q}`, tt.day, tt.day, tt.timezone)
+			w := httptest.NewRecorder()
+			serveRoute(handler, w, httptest.NewRequest(http.MethodPost, "/api/v1/requests/filter", strings.NewReader(body)))
+			if w.Code != http.StatusOK {
+				t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+			}
+			var resp RequestListResponse
+			if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+			if resp.Count != tt.want {
+				t.Errorf("Expected %d matches, got %d", tt.want, resp.Count)
+			}
+		})
+	}
+
+	// The histogram puts the document on the same day the filter does
+	for tz, want := range map[string]string{"UTC": "2024-03-02", "America/Los_Angeles": "2024-03-01"} {
+		w := httptest.NewRecorder()
+		serveRoute(handler, w, httptest.NewRequest(http.MethodGet,
+			"/api/v1/requests/histogram?start=2024-02-29&end=2024-03-03&timezone="+tz, nil))
+		var resp RequestHistogramResponse
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatalf("Failed to decode histogram: %v", err)
+		}
+		if resp.Timezone != tz {
+			t.Errorf("Expected timezone %s, got %s", tz, resp.Timezone)
+		}
+		for _, b := range resp.Buckets {
+			if day := b.Start.Format("2006-01-02"); (b.Count == 1) != (day == want) {
+				t.Errorf("%s: bucket %s has count %d, expected the document on %s", tz, day, b.Count, want)
+			}
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/docutag/controller/internal/telemetry"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// TestTracingSampleRatio swaps the global tracer provider and environment, so it stays sequential
+func TestTracingSampleRatio(t *testing.T) {
+	previous := otel.GetTracerProvider()
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+
+	tests := []struct {
+		name      string
+		cfg       telemetry.TracingConfig
+		wantSpans bool
+	}{
+		{"ratio 0", telemetry.TracingConfig{Enabled: true, SampleRatio: 0}, false},
+		{"ratio 1", telemetry.TracingConfig{Enabled: true, SampleRatio: 1}, true},
+		{"disabled", telemetry.TracingConfig{Enabled: false, SampleRatio: 1}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Restored after the test; InitTracing sets them for the provider it creates
+			t.Setenv("OTEL_TRACES_SAMPLER", "")
+			t.Setenv("OTEL_TRACES_SAMPLER_ARG", "")
+
+			recorder := tracetest.NewSpanRecorder()
+			tp, err := telemetry.InitTracing(tt.cfg, func(string) (*sdktrace.TracerProvider, error) {
+				return sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)), nil
+			})
+			if err != nil {
+				t.Fatalf("InitTracing failed: %v", err)
+			}
+			if (tp == nil) == tt.cfg.Enabled {
+				t.Fatalf("Expected a provider only when tracing is enabled, got %v", tp)
+			}
+
+			// The handler starts its span before validating, so a bare handler is enough
+			for i := 0; i < 20; i++ {
+				serveRoute(&Handler{}, httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/v1/tags/timeline", nil))
+			}
+			if got := len(recorder.Ended()); (got > 0) != tt.wantSpans {
+				t.Errorf("Expected spans recorded: %v, got %d spans", tt.wantSpans, got)
+			}
+		})
+	}
+}
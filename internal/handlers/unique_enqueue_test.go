@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/docutag/controller/internal/queue"
+	"github.com/docutag/controller/internal/storage"
+)
+
+func TestCreateScrapeRequestReturnsExistingJob(t *testing.T) {
+	t.Parallel()
+	handler, _, _, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("Failed to create miniredis: %v", err)
+	}
+	defer mr.Close()
+	handler.queueClient = queue.NewClient(queue.ClientConfig{RedisAddr: mr.Addr(), UniqueWindow: 10 * time.Minute})
+	defer handler.queueClient.Close()
+
+	submit := func(url string) *storage.ScrapeJob {
+		t.Helper()
+		body, _ := json.Marshal(ScrapeURLRequest{URL: url})
+		w := httptest.NewRecorder()
+		serveRoute(handler, w, httptest.NewRequest(http.MethodPost, "/api/scrape-requests", bytes.NewReader(body)))
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+		}
+		var job storage.ScrapeJob
+		if err := json.NewDecoder(w.Body).Decode(&job); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		return &job
+	}
+
+	first := submit("https://example.com/article")
+	second := submit("https://example.com/article?utm_source=newsletter")
+	if second.ID != first.ID {
+		t.Errorf("expected the duplicate submission to return job %s, got %s", first.ID, second.ID)
+	}
+
+	jobs, err := handler.storage.ListScrapeJobs(100, 0)
+	if err != nil {
+		t.Fatalf("Failed to list jobs: %v", err)
+	}
+	if len(jobs) != 1 {
+		t.Errorf("expected the duplicate job to be discarded, got %d jobs", len(jobs))
+	}
+}
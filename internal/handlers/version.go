@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strings"
+)
+
+// API prefixes. /api/v1 is canonical; the unversioned prefix is kept as a
+// deprecated alias for clients written before versioning.
+const (
+	APIPrefixV1          = "/api/v1"
+	APIPrefixUnversioned = "/api"
+)
+
+// API versions stored in the request context and used as the api_version metric label
+const (
+	APIVersionV1          = "v1"
+	APIVersionUnversioned = "unversioned"
+)
+
+type apiVersionKey struct{}
+
+// APIVersion returns the API version the request was routed through, or "" for
+// routes outside /api
+func APIVersion(ctx context.Context) string {
+	version, _ := ctx.Value(apiVersionKey{}).(string)
+	return version
+}
+
+// withAPIVersion records version in the request context and metrics. Requests on the
+// unversioned alias also get a Deprecation header and a Link to the v1 path.
+func (h *Handler) withAPIVersion(version string, next http.HandlerFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h.collectors().apiRequests.WithLabelValues(version, r.Method).Inc()
+
+		if version == APIVersionUnversioned {
+			successor := APIPrefixV1 + strings.TrimPrefix(r.URL.Path, APIPrefixUnversioned)
+			w.Header().Set("Deprecation", "true")
+			w.Header().Add("Link", "<"+successor+">; rel=\"successor-version\"")
+		}
+
+		next(w, r.WithContext(context.WithValue(r.Context(), apiVersionKey{}, version)))
+	})
+}
@@ -0,0 +1,240 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/docutag/controller/internal/storage"
+	"github.com/docutag/controller/internal/webhooks"
+	"github.com/google/uuid"
+)
+
+const (
+	defaultWebhookDeliveries = 50
+	minWebhookSecretLength   = 16
+)
+
+// WebhookRequest is the body of POST and PUT /api/webhooks
+type WebhookRequest struct {
+	URL        string   `json:"url"`
+	Secret     string   `json:"secret,omitempty"` // Generated when creating without one; kept when updating without one
+	EventTypes []string `json:"event_types"`
+	Enabled    *bool    `json:"enabled,omitempty"` // Default true; enabling a disabled webhook clears its failures
+}
+
+// WebhookResponse is a webhook. Secret is only filled in when the webhook is created.
+type WebhookResponse struct {
+	*storage.Webhook
+	Secret string `json:"secret,omitempty"`
+}
+
+// WebhookListResponse lists webhooks
+type WebhookListResponse struct {
+	Webhooks []*storage.Webhook `json:"webhooks"`
+	Count    int                `json:"count"`
+}
+
+// WebhookDeliveriesResponse is the recent delivery log of a webhook
+type WebhookDeliveriesResponse struct {
+	WebhookID  string                     `json:"webhook_id"`
+	Deliveries []*storage.WebhookDelivery `json:"deliveries"`
+	Count      int                        `json:"count"`
+	Limit      int                        `json:"limit"`
+	Offset     int                        `json:"offset"`
+}
+
+// SetWebhooks sets the dispatcher that request.* events are published to; nil publishes none
+func (h *Handler) SetWebhooks(d *webhooks.Dispatcher) {
+	h.webhooks = d
+}
+
+// publishRequestWebhook sends a request to the webhooks subscribed to eventType, in the
+// shape GET /api/requests/{id} returns it
+func (h *Handler) publishRequestWebhook(eventType string, record *storage.Request) {
+	if h.webhooks == nil {
+		return
+	}
+	h.webhooks.Publish(record.Namespace, eventType, newControllerResponse(record))
+}
+
+// decodeWebhook reads and validates a webhook body into hook. The target is held to the
+// same private-network rules as scrape targets. It writes the error response itself and
+// returns false when the body is rejected.
+func (h *Handler) decodeWebhook(w http.ResponseWriter, r *http.Request, hook *storage.Webhook) bool {
+	var req WebhookRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondErrorCode(w, ErrCodeInvalidRequestBody, "Invalid request body", http.StatusBadRequest)
+		return false
+	}
+
+	target, err := url.Parse(strings.TrimSpace(req.URL))
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+		respondErrorCode(w, ErrCodeValidationFailed, "url must be an absolute http or https URL", http.StatusBadRequest)
+		return false
+	}
+	if req.Secret != "" && len(req.Secret) < minWebhookSecretLength {
+		respondErrorCode(w, ErrCodeValidationFailed, fmt.Sprintf("secret must be at least %d characters", minWebhookSecretLength), http.StatusBadRequest)
+		return false
+	}
+	if len(req.EventTypes) == 0 {
+		respondErrorCode(w, ErrCodeValidationFailed, "event_types must list at least one of: "+strings.Join(webhooks.EventTypes, ", "), http.StatusBadRequest)
+		return false
+	}
+
+	eventTypes := make([]string, 0, len(req.EventTypes))
+	seen := make(map[string]bool, len(req.EventTypes))
+	for _, eventType := range req.EventTypes {
+		if !webhooks.ValidEventType(eventType) {
+			respondErrorCode(w, ErrCodeValidationFailed, fmt.Sprintf("Unknown event type %q, use one of: %s", eventType, strings.Join(webhooks.EventTypes, ", ")), http.StatusBadRequest)
+			return false
+		}
+		if !seen[eventType] {
+			seen[eventType] = true
+			eventTypes = append(eventTypes, eventType)
+		}
+	}
+
+	if err := h.scrapeGuard().Validate(r.Context(), target.String()); err != nil {
+		respondErrorDetails(w, ErrCodeURLRejected, fmt.Sprintf("URL rejected: %v", err), http.StatusBadRequest, urlRejectionDetails(err))
+		return false
+	}
+
+	hook.URL = target.String()
+	hook.Secret = req.Secret
+	hook.EventTypes = eventTypes
+	hook.Enabled = req.Enabled == nil || *req.Enabled
+	return true
+}
+
+// generateWebhookSecret returns a random secret for a webhook created without one
+func generateWebhookSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// CreateWebhook handles POST /api/webhooks. The response is the only time the secret is returned.
+func (h *Handler) CreateWebhook(w http.ResponseWriter, r *http.Request) {
+	hook := &storage.Webhook{ID: uuid.New().String(), CreatedBy: requestCreator(r)}
+	if !h.decodeWebhook(w, r, hook) {
+		return
+	}
+	if hook.Secret == "" {
+		secret, err := generateWebhookSecret()
+		if err != nil {
+			respondErrorCode(w, ErrCodeInternal, fmt.Sprintf("Failed to generate secret: %v", err), http.StatusInternalServerError)
+			return
+		}
+		hook.Secret = secret
+	}
+
+	if err := h.store(r).CreateWebhook(hook); err != nil {
+		respondErrorCode(w, ErrCodeInternal, fmt.Sprintf("Failed to create webhook: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	respondCreated(w, "/webhooks/"+hook.ID, WebhookResponse{Webhook: hook, Secret: hook.Secret})
+}
+
+// ListWebhooks handles GET /api/webhooks
+func (h *Handler) ListWebhooks(w http.ResponseWriter, r *http.Request) {
+	hooks, err := h.store(r).ListWebhooks()
+	if err != nil {
+		respondErrorCode(w, ErrCodeInternal, fmt.Sprintf("Failed to list webhooks: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	respondJSON(w, WebhookListResponse{Webhooks: hooks, Count: len(hooks)}, http.StatusOK)
+}
+
+// getWebhook loads the webhook named by the {id} path value, writing a 404 if there is none
+func (h *Handler) getWebhook(w http.ResponseWriter, r *http.Request) (*storage.Webhook, bool) {
+	hook, err := h.store(r).GetWebhook(r.PathValue("id"))
+	if err != nil {
+		respondErrorCode(w, ErrCodeInternal, fmt.Sprintf("Failed to get webhook: %v", err), http.StatusInternalServerError)
+		return nil, false
+	}
+	if hook == nil {
+		respondErrorCode(w, ErrCodeWebhookNotFound, "Webhook not found", http.StatusNotFound)
+		return nil, false
+	}
+	return hook, true
+}
+
+// GetWebhook handles GET /api/webhooks/{id}
+func (h *Handler) GetWebhook(w http.ResponseWriter, r *http.Request) {
+	hook, ok := h.getWebhook(w, r)
+	if !ok {
+		return
+	}
+	respondJSON(w, hook, http.StatusOK)
+}
+
+// UpdateWebhook handles PUT /api/webhooks/{id}
+func (h *Handler) UpdateWebhook(w http.ResponseWriter, r *http.Request) {
+	hook := &storage.Webhook{ID: r.PathValue("id")}
+	if !h.decodeWebhook(w, r, hook) {
+		return
+	}
+
+	if err := h.store(r).UpdateWebhook(hook); err != nil {
+		if err.Error() == "webhook not found" {
+			respondErrorCode(w, ErrCodeWebhookNotFound, "Webhook not found", http.StatusNotFound)
+			return
+		}
+		respondErrorCode(w, ErrCodeInternal, fmt.Sprintf("Failed to update webhook: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	respondJSON(w, hook, http.StatusOK)
+}
+
+// DeleteWebhook handles DELETE /api/webhooks/{id}
+func (h *Handler) DeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	if err := h.store(r).DeleteWebhook(r.PathValue("id")); err != nil {
+		if err.Error() == "webhook not found" {
+			respondErrorCode(w, ErrCodeWebhookNotFound, "Webhook not found", http.StatusNotFound)
+			return
+		}
+		respondErrorCode(w, ErrCodeInternal, fmt.Sprintf("Failed to delete webhook: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	respondJSON(w, map[string]string{"message": "Webhook deleted successfully"}, http.StatusOK)
+}
+
+// ListWebhookDeliveries handles GET /api/webhooks/{id}/deliveries?limit=50&offset=0, newest
+// first. No more than storage.MaxWebhookDeliveries are kept, so limit is clamped to that too.
+func (h *Handler) ListWebhookDeliveries(w http.ResponseWriter, r *http.Request) {
+	pg, err := parsePage(r.URL.Query(), defaultWebhookDeliveries, min(h.pageLimitMax(), storage.MaxWebhookDeliveries))
+	if err != nil {
+		respondErrorCode(w, ErrCodeValidationFailed, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	hook, ok := h.getWebhook(w, r)
+	if !ok {
+		return
+	}
+
+	deliveries, err := h.store(r).ListWebhookDeliveries(hook.ID, pg.Limit, pg.Offset)
+	if err != nil {
+		respondErrorCode(w, ErrCodeInternal, fmt.Sprintf("Failed to list deliveries: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	respondJSON(w, WebhookDeliveriesResponse{
+		WebhookID:  hook.ID,
+		Deliveries: deliveries,
+		Count:      len(deliveries),
+		Limit:      pg.Limit,
+		Offset:     pg.Offset,
+	}, http.StatusOK)
+}
@@ -0,0 +1,167 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/docutag/controller/internal/storage"
+	"github.com/docutag/controller/internal/urlguard"
+	"github.com/docutag/controller/internal/webhooks"
+)
+
+// privateResolver resolves every host to an internal address
+type privateResolver struct{}
+
+func (privateResolver) LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error) {
+	return []net.IPAddr{{IP: net.ParseIP("10.0.0.7")}}, nil
+}
+
+func TestCreateWebhookValidation(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name string
+		body string
+	}{
+		{"invalid json", `{`},
+		{"missing url", `{"event_types": ["scrape.completed"]}`},
+		{"relative url", `{"url": "/hooks", "event_types": ["scrape.completed"]}`},
+		{"ftp url", `{"url": "ftp://example.com/hooks", "event_types": ["scrape.completed"]}`},
+		{"no event types", `{"url": "https://example.com/hooks"}`},
+		{"unknown event type", `{"url": "https://example.com/hooks", "event_types": ["scrape.started"]}`},
+		{"short secret", `{"url": "https://example.com/hooks", "secret": "abc", "event_types": ["scrape.completed"]}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			serveRoute(&Handler{}, w, httptest.NewRequest(http.MethodPost, "/api/webhooks", strings.NewReader(tt.body)))
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("expected 400, got %d: %s", w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestCreateWebhookRejectsPrivateTargets(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name string
+		url  string
+		rule string
+	}{
+		{"loopback", "http://127.0.0.1:8080/hooks", urlguard.RulePrivateTarget},
+		{"localhost", "http://localhost/hooks", urlguard.RulePrivateTarget},
+		{"metadata service", "http://169.254.169.254/latest/meta-data", urlguard.RulePrivateTarget},
+		{"rfc1918", "https://192.168.1.20/hooks", urlguard.RulePrivateTarget},
+		{"resolves to rfc1918", "https://hooks.internal.example/hooks", urlguard.RulePrivateTarget},
+	}
+	h := &Handler{urlGuard: urlguard.NewWithResolver(false, privateResolver{})}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := `{"url": "` + tt.url + `", "event_types": ["scrape.completed"]}`
+			for _, method := range []string{http.MethodPost, http.MethodPut} {
+				path := "/api/webhooks"
+				if method == http.MethodPut {
+					path += "/hook-1"
+				}
+				w := httptest.NewRecorder()
+				serveRoute(h, w, httptest.NewRequest(method, path, strings.NewReader(body)))
+				if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), ErrCodeURLRejected) || !strings.Contains(w.Body.String(), tt.rule) {
+					t.Errorf("%s: expected 400 %s (%s), got %d: %s", method, ErrCodeURLRejected, tt.rule, w.Code, w.Body.String())
+				}
+			}
+		})
+	}
+}
+
+func TestWebhooks(t *testing.T) {
+	t.Parallel()
+	handler, _, _, cleanup := setupTestHandler(t)
+	defer cleanup()
+	handler.SetURLGuard(urlguard.NewWithResolver(false, publicResolver{}))
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		t.Helper()
+		w := httptest.NewRecorder()
+		serveRoute(handler, w, httptest.NewRequest(method, path, strings.NewReader(body)))
+		return w
+	}
+
+	w := do(http.MethodPost, "/api/webhooks", `{"url": "https://hooks.example.com/docutag", "event_types": ["request.deleted", "scrape.completed", "request.deleted"]}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var created struct {
+		storage.Webhook
+		Secret string `json:"secret"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &created); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if created.ID == "" || len(created.Secret) != 64 || !created.Enabled || len(created.EventTypes) != 2 {
+		t.Errorf("Expected an enabled webhook with a generated secret and 2 event types, got %+v", created)
+	}
+
+	// The secret is not returned after creation
+	w = do(http.MethodGet, "/api/webhooks/"+created.ID, "")
+	if w.Code != http.StatusOK || strings.Contains(w.Body.String(), created.Secret) {
+		t.Errorf("Expected the webhook without its secret, got %d: %s", w.Code, w.Body.String())
+	}
+
+	w = do(http.MethodPut, "/api/webhooks/"+created.ID, `{"url": "https://hooks.example.com/v2", "event_types": ["request.tombstoned"], "enabled": false}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	stored, err := handler.storage.GetWebhook(created.ID)
+	if err != nil {
+		t.Fatalf("GetWebhook failed: %v", err)
+	}
+	if stored.URL != "https://hooks.example.com/v2" || stored.Enabled || stored.Secret != created.Secret {
+		t.Errorf("Expected the update to keep the secret, got %+v", stored)
+	}
+
+	if _, err := handler.storage.RecordWebhookDelivery(&storage.WebhookDelivery{WebhookID: created.ID, EventID: "event-1", EventType: webhooks.EventRequestTombstoned, Attempts: 3, Error: "unexpected status 500", StatusCode: 500}, 10); err != nil {
+		t.Fatalf("RecordWebhookDelivery failed: %v", err)
+	}
+	w = do(http.MethodGet, "/api/webhooks/"+created.ID+"/deliveries", "")
+	var deliveries WebhookDeliveriesResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &deliveries); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if deliveries.Count != 1 || deliveries.Deliveries[0].EventID != "event-1" || deliveries.Deliveries[0].Success {
+		t.Errorf("Unexpected delivery log %+v", deliveries)
+	}
+	// Pages are bounded like every other list: a large limit is clamped, bad values are refused
+	json.Unmarshal(do(http.MethodGet, "/api/webhooks/"+created.ID+"/deliveries?limit=1000&offset=1", "").Body.Bytes(), &deliveries)
+	if deliveries.Limit != storage.MaxWebhookDeliveries || deliveries.Offset != 1 || deliveries.Count != 0 {
+		t.Errorf("Expected an empty page clamped to %d, got %+v", storage.MaxWebhookDeliveries, deliveries)
+	}
+	for _, query := range []string{"?limit=abc", "?limit=0", "?offset=-1"} {
+		if w := do(http.MethodGet, "/api/webhooks/"+created.ID+"/deliveries"+query, ""); w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status 400, got %d", query, w.Code)
+		}
+	}
+
+	var list WebhookListResponse
+	json.Unmarshal(do(http.MethodGet, "/api/webhooks", "").Body.Bytes(), &list)
+	if list.Count != 1 {
+		t.Errorf("Expected 1 webhook, got %d", list.Count)
+	}
+
+	if w := do(http.MethodDelete, "/api/webhooks/"+created.ID, ""); w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	for _, w := range []*httptest.ResponseRecorder{
+		do(http.MethodGet, "/api/webhooks/"+created.ID, ""),
+		do(http.MethodGet, "/api/webhooks/"+created.ID+"/deliveries", ""),
+		do(http.MethodDelete, "/api/webhooks/"+created.ID, ""),
+	} {
+		if w.Code != http.StatusNotFound || !strings.Contains(w.Body.String(), ErrCodeWebhookNotFound) {
+			t.Errorf("Expected 404 WEBHOOK_NOT_FOUND, got %d: %s", w.Code, w.Body.String())
+		}
+	}
+}
@@ -0,0 +1,122 @@
+// Package httpmetrics records Prometheus metrics for the HTTP API. Series are labelled with the
+// route pattern a request matched, e.g. "/api/v1/requests/{id}", rather than its raw path, so
+// the number of series stays bounded however many documents are served.
+package httpmetrics
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/docutag/controller/internal/promreg"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// DefaultBuckets are the latency buckets, in seconds, used when none are configured
+var DefaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// UnmatchedRoute is the path label of requests no route matched, such as scans for random paths
+const UnmatchedRoute = "unmatched"
+
+// Options configures Middleware
+type Options struct {
+	Namespace string                     // Metric name prefix, e.g. "controller"
+	Buckets   []float64                  // Latency histogram buckets in seconds; nil uses DefaultBuckets
+	Route     func(*http.Request) string // Route pattern of a request; "" when no route matches
+}
+
+// MuxRoute returns a Route function reporting the pattern mux would serve a request with. The
+// mux records the pattern only on the request it is handed, which middleware outside it never
+// sees once an inner layer has copied the request, so it is looked up again instead.
+func MuxRoute(mux *http.ServeMux) func(*http.Request) string {
+	return func(r *http.Request) string {
+		_, pattern := mux.Handler(r)
+		return pattern
+	}
+}
+
+// Middleware returns HTTP middleware recording, per method, path and status:
+//
+//	<namespace>_http_requests_total            requests served
+//	<namespace>_http_request_duration_seconds  time spent serving them (no status label)
+//
+// path is the matched route pattern without its method, or UnmatchedRoute. The collectors are
+// registered with reg; collectors reg already has are reused.
+func Middleware(reg prometheus.Registerer, opts Options) (func(http.Handler) http.Handler, error) {
+	buckets := opts.Buckets
+	if len(buckets) == 0 {
+		buckets = DefaultBuckets
+	}
+	requests, err := promreg.RegisterOrReuse(reg, prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: opts.Namespace,
+			Name:      "http_requests_total",
+			Help:      "HTTP requests served, by method, route pattern and status",
+		},
+		[]string{"method", "path", "status"},
+	))
+	if err != nil {
+		return nil, err
+	}
+	duration, err := promreg.RegisterOrReuse(reg, prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: opts.Namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "Time spent serving HTTP requests, by method and route pattern",
+			Buckets:   buckets,
+		},
+		[]string{"method", "path"},
+	))
+	if err != nil {
+		return nil, err
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			wrapped := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(wrapped, r)
+
+			path := UnmatchedRoute
+			if opts.Route != nil {
+				if pattern := routePath(opts.Route(r)); pattern != "" {
+					path = pattern
+				}
+			}
+			requests.WithLabelValues(r.Method, path, strconv.Itoa(wrapped.status)).Inc()
+			duration.WithLabelValues(r.Method, path).Observe(time.Since(start).Seconds())
+		})
+	}, nil
+}
+
+// routePath strips the method, and host if any, from a pattern such as "GET /api/v1/requests/{id}"
+func routePath(pattern string) string {
+	if i := strings.IndexByte(pattern, '/'); i > 0 {
+		return pattern[i:]
+	}
+	return pattern
+}
+
+// statusWriter captures the status code written by the handler
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (w *statusWriter) WriteHeader(status int) {
+	w.status = status
+	w.ResponseWriter.WriteHeader(status)
+}
+
+// Flush passes through to the underlying writer so streamed responses (SSE) still flush
+func (w *statusWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer
+func (w *statusWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,80 @@
+package httpmetrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestMiddlewareLabelsByRoute(t *testing.T) {
+	t.Parallel()
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/v1/requests/{id}", func(w http.ResponseWriter, r *http.Request) {
+		if r.PathValue("id") == "missing" {
+			http.NotFound(w, r)
+		}
+	})
+
+	reg := prometheus.NewRegistry()
+	middleware, err := Middleware(reg, Options{Namespace: "test", Buckets: []float64{0.1, 1}, Route: MuxRoute(mux)})
+	if err != nil {
+		t.Fatalf("Failed to create middleware: %v", err)
+	}
+	// Like main.go, a layer between the middleware and the mux hands it a copy of the request
+	handler := middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mux.ServeHTTP(w, r.WithContext(r.Context()))
+	}))
+
+	for _, path := range []string{
+		"/api/v1/requests/0b6e8f62-3b1c-4a7e-9d55-1f0e2a9c7d41",
+		"/api/v1/requests/5c1d9a3e-7f2b-4e60-8a14-b93d0c6e2f58",
+		"/api/v1/requests/missing",
+		"/wp-login.php",
+	} {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	// Both documents land in the same series
+	if got := testutil.CollectAndCount(reg, "test_http_request_duration_seconds"); got != 2 {
+		t.Errorf("Expected 2 duration series (the route and unmatched), got %d", got)
+	}
+	counts := map[[2]string]float64{
+		{"/api/v1/requests/{id}", "200"}: 2,
+		{"/api/v1/requests/{id}", "404"}: 1,
+		{UnmatchedRoute, "404"}:          1,
+	}
+	families, err := reg.Gather()
+	if err != nil {
+		t.Fatalf("Failed to gather: %v", err)
+	}
+	for _, family := range families {
+		if family.GetName() != "test_http_requests_total" {
+			continue
+		}
+		if len(family.GetMetric()) != len(counts) {
+			t.Errorf("Expected %d request series, got %d", len(counts), len(family.GetMetric()))
+		}
+		for _, m := range family.GetMetric() {
+			labels := map[string]string{}
+			for _, l := range m.GetLabel() {
+				labels[l.GetName()] = l.GetValue()
+			}
+			if want := counts[[2]string{labels["path"], labels["status"]}]; m.GetCounter().GetValue() != want {
+				t.Errorf("Expected %v requests for %v, got %v", want, labels, m.GetCounter().GetValue())
+			}
+		}
+	}
+}
+
+func TestMiddlewareReusesCollectors(t *testing.T) {
+	t.Parallel()
+	reg := prometheus.NewRegistry()
+	for i := 0; i < 2; i++ {
+		if _, err := Middleware(reg, Options{Namespace: "test"}); err != nil {
+			t.Fatalf("Expected registering twice to reuse the collectors, got %v", err)
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package imagecache
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// fileSuffix marks cache files so unrelated files in the directory are left alone
+const fileSuffix = ".img"
+
+// Disk is an LRU cache stored as one file per image in a directory. Each file holds
+// the content type on its first line followed by the image bytes; its modification time
+// is the item's StoredAt. Files left by a previous run are picked up on start, oldest first.
+type Disk struct {
+	dir string
+
+	mu  sync.Mutex
+	lru *lru
+}
+
+// NewDisk opens (creating if needed) a disk cache in dir holding at most maxBytes
+func NewDisk(dir string, maxBytes int64) (*Disk, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create image cache directory: %w", err)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read image cache directory: %w", err)
+	}
+
+	type existing struct {
+		key     string
+		size    int64
+		modTime int64
+	}
+	var files []existing
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), fileSuffix) {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		files = append(files, existing{
+			key:     strings.TrimSuffix(e.Name(), fileSuffix),
+			size:    info.Size(),
+			modTime: info.ModTime().UnixNano(),
+		})
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].modTime < files[j].modTime })
+
+	d := &Disk{dir: dir, lru: newLRU(maxBytes)}
+	for _, f := range files {
+		for _, evicted := range d.lru.add(f.key, f.size) {
+			os.Remove(d.path(evicted))
+		}
+	}
+	return d, nil
+}
+
+// key hashes an image ID into a safe file name
+func key(id string) string {
+	sum := sha256.Sum256([]byte(id))
+	return hex.EncodeToString(sum[:])
+}
+
+func (d *Disk) path(key string) string {
+	return filepath.Join(d.dir, key+fileSuffix)
+}
+
+// Get returns the cached image for id
+func (d *Disk) Get(id string) (*Item, bool) {
+	k := key(id)
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if !d.lru.touch(k) {
+		return nil, false
+	}
+
+	data, err := os.ReadFile(d.path(k))
+	var info os.FileInfo
+	if err == nil {
+		info, err = os.Stat(d.path(k))
+	}
+	if err != nil {
+		d.lru.remove(k)
+		return nil, false
+	}
+	contentType, body, ok := bytes.Cut(data, []byte("\n"))
+	if !ok {
+		d.lru.remove(k)
+		os.Remove(d.path(k))
+		return nil, false
+	}
+	return &Item{ContentType: string(contentType), Data: body, StoredAt: info.ModTime()}, true
+}
+
+// Put writes item to disk under id, evicting the least recently used images if needed.
+// Items larger than the whole budget are not cached. Write failures only skip caching.
+func (d *Disk) Put(id string, item *Item) {
+	k := key(id)
+	header := []byte(strings.ReplaceAll(item.ContentType, "\n", "") + "\n")
+	size := int64(len(header) + len(item.Data))
+	if size > d.lru.maxBytes {
+		return
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	// Write to a temporary file first so readers never see a partial image
+	tmp, err := os.CreateTemp(d.dir, "tmp-*")
+	if err != nil {
+		return
+	}
+	_, err = tmp.Write(header)
+	if err == nil {
+		_, err = tmp.Write(item.Data)
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), d.path(k))
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return
+	}
+
+	for _, evicted := range d.lru.add(k, size) {
+		os.Remove(d.path(evicted))
+	}
+}
+
+// Remove deletes id from the cache
+func (d *Disk) Remove(id string) {
+	k := key(id)
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.lru.remove(k)
+	os.Remove(d.path(k))
+}
@@ -0,0 +1,130 @@
+// Package imagecache keeps recently served image bytes so repeat requests for the
+// same image do not go back to the scraper. Entries are keyed by image ID and
+// evicted least-recently-used once the cache exceeds its byte budget.
+package imagecache
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// Item is a cached image. StoredAt is set by the cache when the item is put.
+type Item struct {
+	ContentType string
+	Data        []byte
+	StoredAt    time.Time
+}
+
+// Cache stores image bytes by image ID. Implementations are safe for concurrent use.
+type Cache interface {
+	Get(id string) (*Item, bool)
+	Put(id string, item *Item)
+	Remove(id string)
+}
+
+// entry is an element of an lru list
+type entry struct {
+	id   string
+	size int64
+}
+
+// lru tracks recency and total size; callers hold their own lock around it
+type lru struct {
+	maxBytes int64
+	size     int64
+	order    *list.List
+	elements map[string]*list.Element
+}
+
+func newLRU(maxBytes int64) *lru {
+	return &lru{maxBytes: maxBytes, order: list.New(), elements: make(map[string]*list.Element)}
+}
+
+// touch marks id as most recently used, reporting whether it is present
+func (l *lru) touch(id string) bool {
+	el, ok := l.elements[id]
+	if ok {
+		l.order.MoveToFront(el)
+	}
+	return ok
+}
+
+// add records id with its size and returns the IDs evicted to stay within budget
+func (l *lru) add(id string, size int64) []string {
+	l.remove(id)
+	l.elements[id] = l.order.PushFront(&entry{id: id, size: size})
+	l.size += size
+
+	var evicted []string
+	for l.size > l.maxBytes && l.order.Len() > 1 {
+		oldest := l.order.Back()
+		e := oldest.Value.(*entry)
+		l.remove(e.id)
+		evicted = append(evicted, e.id)
+	}
+	return evicted
+}
+
+// remove drops id, reporting whether it was present
+func (l *lru) remove(id string) bool {
+	el, ok := l.elements[id]
+	if !ok {
+		return false
+	}
+	l.order.Remove(el)
+	delete(l.elements, id)
+	l.size -= el.Value.(*entry).size
+	return true
+}
+
+// Memory is an in-process LRU cache
+type Memory struct {
+	mu    sync.Mutex
+	lru   *lru
+	items map[string]*Item
+}
+
+// NewMemory creates an in-memory cache holding at most maxBytes of image data
+func NewMemory(maxBytes int64) *Memory {
+	return &Memory{lru: newLRU(maxBytes), items: make(map[string]*Item)}
+}
+
+// Get returns the cached image for id
+func (m *Memory) Get(id string) (*Item, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if !m.lru.touch(id) {
+		return nil, false
+	}
+	return m.items[id], true
+}
+
+// Put caches item under id, evicting the least recently used images if needed.
+// Items larger than the whole budget are not cached.
+func (m *Memory) Put(id string, item *Item) {
+	size := int64(len(item.Data))
+	if size > m.lru.maxBytes {
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stored := *item
+	stored.StoredAt = time.Now()
+	m.items[id] = &stored
+	for _, evicted := range m.lru.add(id, size) {
+		delete(m.items, evicted)
+	}
+}
+
+// Remove drops id from the cache
+func (m *Memory) Remove(id string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.lru.remove(id)
+	delete(m.items, id)
+}
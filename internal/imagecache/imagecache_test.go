@@ -0,0 +1,106 @@
+package imagecache
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func testCaches(t *testing.T, maxBytes int64) map[string]func() Cache {
+	return map[string]func() Cache{
+		"memory": func() Cache { return NewMemory(maxBytes) },
+		"disk": func() Cache {
+			d, err := NewDisk(t.TempDir(), maxBytes)
+			if err != nil {
+				t.Fatalf("NewDisk: %v", err)
+			}
+			return d
+		},
+	}
+}
+
+func TestCacheGetPutRemove(t *testing.T) {
+	for name, newCache := range testCaches(t, 1024) {
+		t.Run(name, func(t *testing.T) {
+			c := newCache()
+			if _, ok := c.Get("a"); ok {
+				t.Fatal("expected miss on empty cache")
+			}
+
+			before := time.Now().Add(-time.Second)
+			c.Put("a", &Item{ContentType: "image/png", Data: []byte("png-bytes")})
+			item, ok := c.Get("a")
+			if !ok {
+				t.Fatal("expected hit after Put")
+			}
+			if item.ContentType != "image/png" || !bytes.Equal(item.Data, []byte("png-bytes")) {
+				t.Errorf("unexpected item: %q %q", item.ContentType, item.Data)
+			}
+			if item.StoredAt.Before(before) {
+				t.Errorf("expected StoredAt to be set by Put, got %v", item.StoredAt)
+			}
+
+			c.Remove("a")
+			if _, ok := c.Get("a"); ok {
+				t.Error("expected miss after Remove")
+			}
+		})
+	}
+}
+
+func TestCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	// Room for two 40-byte images (plus the disk cache's content type line)
+	for name, newCache := range testCaches(t, 100) {
+		t.Run(name, func(t *testing.T) {
+			c := newCache()
+			data := bytes.Repeat([]byte("x"), 40)
+
+			c.Put("a", &Item{ContentType: "image/png", Data: data})
+			c.Put("b", &Item{ContentType: "image/png", Data: data})
+			c.Get("a") // a is now more recent than b
+			c.Put("c", &Item{ContentType: "image/png", Data: data})
+
+			if _, ok := c.Get("b"); ok {
+				t.Error("expected b to be evicted")
+			}
+			for _, id := range []string{"a", "c"} {
+				if _, ok := c.Get(id); !ok {
+					t.Errorf("expected %s to be cached", id)
+				}
+			}
+		})
+	}
+}
+
+func TestCacheSkipsOversizedItems(t *testing.T) {
+	for name, newCache := range testCaches(t, 10) {
+		t.Run(name, func(t *testing.T) {
+			c := newCache()
+			c.Put("big", &Item{ContentType: "image/png", Data: bytes.Repeat([]byte("x"), 20)})
+			if _, ok := c.Get("big"); ok {
+				t.Error("expected oversized item not to be cached")
+			}
+		})
+	}
+}
+
+func TestDiskReloadsExistingFiles(t *testing.T) {
+	dir := t.TempDir()
+	d, err := NewDisk(dir, 1024)
+	if err != nil {
+		t.Fatalf("NewDisk: %v", err)
+	}
+	d.Put("a", &Item{ContentType: "image/jpeg", Data: []byte("jpeg-bytes")})
+
+	reopened, err := NewDisk(dir, 1024)
+	if err != nil {
+		t.Fatalf("NewDisk: %v", err)
+	}
+	item, ok := reopened.Get("a")
+	if !ok {
+		t.Fatal("expected item written by a previous cache to be found")
+	}
+	if item.ContentType != "image/jpeg" || string(item.Data) != "jpeg-bytes" {
+		t.Errorf("unexpected item: %q %q", item.ContentType, item.Data)
+	}
+}
@@ -0,0 +1,206 @@
+// Package language works out which language a document is written in. The text analyzer's
+// answer wins when it gives one; otherwise a lightweight stopword and script detector runs
+// over the cleaned content.
+package language
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Unknown is the BCP 47 "undetermined" code, stored when no language could be worked out
+const Unknown = "und"
+
+// Detection needs at least this many words, and at least this many stopword hits for the winner
+const (
+	minWords    = 5
+	minHits     = 3
+	maxDetected = 2000 // Words examined; more adds cost without changing the answer
+)
+
+// stopwords are frequent function words that tell Latin-script languages apart
+var stopwords = map[string][]string{
+	"en": {"the", "and", "of", "to", "is", "that", "with", "for", "this", "are", "was", "were", "have", "from", "which", "not", "but", "they", "you", "be"},
+	"es": {"el", "la", "los", "las", "de", "que", "y", "en", "del", "por", "con", "una", "para", "es", "se", "no", "al", "lo", "como", "más"},
+	"fr": {"le", "la", "les", "des", "et", "est", "une", "du", "que", "pour", "dans", "qui", "pas", "sur", "au", "avec", "ce", "sont", "il", "ne"},
+	"de": {"der", "die", "das", "und", "ist", "nicht", "mit", "den", "von", "zu", "ein", "eine", "auf", "für", "sich", "auch", "dem", "es", "im", "wird"},
+	"it": {"il", "di", "che", "e", "la", "per", "non", "una", "sono", "del", "della", "con", "gli", "le", "al", "anche", "è", "nel", "più", "questo"},
+	"pt": {"o", "os", "de", "que", "e", "do", "da", "em", "um", "uma", "para", "com", "não", "por", "mais", "as", "dos", "das", "ao", "se"},
+	"nl": {"de", "het", "een", "en", "van", "is", "dat", "op", "te", "niet", "zijn", "voor", "met", "die", "ook", "aan", "er", "maar", "bij", "wordt"},
+}
+
+// stopwordIndex maps each stopword to the languages that use it
+var stopwordIndex = func() map[string][]string {
+	index := make(map[string][]string)
+	for lang, words := range stopwords {
+		for _, w := range words {
+			index[w] = append(index[w], lang)
+		}
+	}
+	return index
+}()
+
+// names maps language names an upstream service might send instead of a code
+var names = map[string]string{
+	"english": "en", "spanish": "es", "french": "fr", "german": "de", "italian": "it",
+	"portuguese": "pt", "dutch": "nl", "russian": "ru", "chinese": "zh", "japanese": "ja",
+	"korean": "ko", "arabic": "ar",
+}
+
+// Normalize reduces a language tag such as "en-US", "pt_BR" or "English" to its lowercase
+// primary subtag. It returns "" for anything that is not a plausible language.
+func Normalize(tag string) string {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	if code, ok := names[tag]; ok {
+		return code
+	}
+	if i := strings.IndexAny(tag, "-_"); i >= 0 {
+		tag = tag[:i]
+	}
+	if len(tag) < 2 || len(tag) > 3 {
+		return ""
+	}
+	for _, r := range tag {
+		if r < 'a' || r > 'z' {
+			return ""
+		}
+	}
+	return tag
+}
+
+// FromMetadata returns the language recorded in request metadata, preferring the text
+// analyzer over the scraped page's declared language. It returns "" when none is recorded.
+func FromMetadata(metadata map[string]interface{}) string {
+	analyzer, _ := metadata["analyzer_metadata"].(map[string]interface{})
+	scraper, _ := metadata["scraper_metadata"].(map[string]interface{})
+
+	candidates := []interface{}{
+		analyzer["language"],
+		metadata["language"],
+		scraper["language"],
+		scraper["lang"],
+	}
+	for _, c := range candidates {
+		s, _ := c.(string)
+		if code := Normalize(s); code != "" && code != Unknown {
+			return code
+		}
+	}
+	return ""
+}
+
+// Resolve returns the language from metadata, falling back to detection over the cleaned,
+// scraped or submitted text held in metadata. It returns Unknown when neither works.
+func Resolve(metadata map[string]interface{}) string {
+	if code := FromMetadata(metadata); code != "" {
+		return code
+	}
+	return Detect(contentText(metadata))
+}
+
+// contentText returns the best text in metadata to detect a language from
+func contentText(metadata map[string]interface{}) string {
+	analyzer, _ := metadata["analyzer_metadata"].(map[string]interface{})
+	scraper, _ := metadata["scraper_metadata"].(map[string]interface{})
+
+	candidates := []interface{}{
+		analyzer["cleaned_text"],
+		analyzer["heuristic_cleaned_text"],
+		scraper["content"],
+		metadata["original_text"],
+	}
+	for _, c := range candidates {
+		if s, _ := c.(string); strings.TrimSpace(s) != "" {
+			return s
+		}
+	}
+	return ""
+}
+
+// Detect guesses the language of text. Non-Latin scripts are identified by their letters;
+// Latin-script text by counting stopwords. It returns Unknown when the text is too short
+// or no language clearly wins.
+func Detect(text string) string {
+	if code := detectScript(text); code != "" {
+		return code
+	}
+
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r)
+	})
+	if len(words) < minWords {
+		return Unknown
+	}
+	if len(words) > maxDetected {
+		words = words[:maxDetected]
+	}
+
+	hits := make(map[string]int)
+	for _, w := range words {
+		for _, lang := range stopwordIndex[w] {
+			hits[lang]++
+		}
+	}
+
+	best, bestHits, runnerUp := Unknown, 0, 0
+	for lang, n := range hits {
+		switch {
+		case n > bestHits:
+			best, bestHits, runnerUp = lang, n, bestHits
+		case n > runnerUp:
+			runnerUp = n
+		}
+	}
+	if bestHits < minHits || bestHits == runnerUp {
+		return Unknown
+	}
+	return best
+}
+
+// detectScript identifies languages by script when most letters are non-Latin
+func detectScript(text string) string {
+	counts := make(map[string]int)
+	letters := 0
+	for _, r := range text {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		letters++
+		switch {
+		case unicode.In(r, unicode.Hiragana, unicode.Katakana):
+			counts["ja"]++
+		case unicode.Is(unicode.Hangul, r):
+			counts["ko"]++
+		case unicode.Is(unicode.Han, r):
+			counts["zh"]++
+		case unicode.Is(unicode.Cyrillic, r):
+			counts["ru"]++
+		case unicode.Is(unicode.Arabic, r):
+			counts["ar"]++
+		case unicode.Is(unicode.Greek, r):
+			counts["el"]++
+		case unicode.Is(unicode.Hebrew, r):
+			counts["he"]++
+		}
+	}
+
+	nonLatin := 0
+	for _, n := range counts {
+		nonLatin += n
+	}
+	if letters == 0 || nonLatin*2 < letters {
+		return ""
+	}
+
+	// Japanese mixes kana with Han characters, so any kana means Japanese
+	if counts["ja"] > 0 {
+		return "ja"
+	}
+	best, bestCount := "", 0
+	for lang, n := range counts {
+		if n > bestCount {
+			best, bestCount = lang, n
+		}
+	}
+	return best
+}
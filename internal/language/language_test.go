@@ -0,0 +1,119 @@
+package language
+
+import "testing"
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"en", "en"},
+		{"en-US", "en"},
+		{"pt_BR", "pt"},
+		{" FR ", "fr"},
+		{"English", "en"},
+		{"und", "und"},
+		{"", ""},
+		{"e", ""},
+		{"english-ish", ""},
+		{"12", ""},
+	}
+
+	for _, tt := range tests {
+		if got := Normalize(tt.input); got != tt.expected {
+			t.Errorf("Normalize(%q) = %q, want %q", tt.input, got, tt.expected)
+		}
+	}
+}
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		expected string
+	}{
+		{"english", "The quick brown fox jumps over the lazy dog and this is the story of that fox, which was not tired.", "en"},
+		{"spanish", "El perro corre por el parque con los niños y la pelota, porque es un día de sol para todos.", "es"},
+		{"french", "Le chat est sur la table et il ne veut pas descendre pour manger avec les enfants dans la cuisine.", "fr"},
+		{"german", "Der Hund ist nicht im Garten, und die Katze sitzt auf dem Dach, weil es dort warm ist.", "de"},
+		{"dutch", "Het weer is vandaag niet goed, maar de kinderen spelen toch buiten met een bal op het plein.", "nl"},
+		{"russian", "Быстрая коричневая лиса прыгает через ленивую собаку.", "ru"},
+		{"japanese", "今日はとても良い天気です。公園に行きましょう。", "ja"},
+		{"chinese", "今天天气很好，我们去公园散步吧。", "zh"},
+		{"korean", "오늘은 날씨가 아주 좋습니다.", "ko"},
+		{"too short", "Hello there", Unknown},
+		{"no stopwords", "Kubernetes Prometheus Grafana Terraform Ansible Jenkins", Unknown},
+		{"empty", "", Unknown},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Detect(tt.text); got != tt.expected {
+				t.Errorf("Detect() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestResolve(t *testing.T) {
+	englishText := "This is the article text that was scraped from the page and it is written in English."
+
+	tests := []struct {
+		name     string
+		metadata map[string]interface{}
+		expected string
+	}{
+		{
+			name: "analyzer provided",
+			metadata: map[string]interface{}{
+				"analyzer_metadata": map[string]interface{}{"language": "de-DE", "cleaned_text": englishText},
+				"scraper_metadata":  map[string]interface{}{"lang": "en"},
+			},
+			expected: "de",
+		},
+		{
+			name: "page declared language",
+			metadata: map[string]interface{}{
+				"scraper_metadata": map[string]interface{}{"lang": "fr-CA", "content": englishText},
+			},
+			expected: "fr",
+		},
+		{
+			name: "analyzer undetermined falls back to detection",
+			metadata: map[string]interface{}{
+				"analyzer_metadata": map[string]interface{}{"language": "und", "cleaned_text": englishText},
+			},
+			expected: "en",
+		},
+		{
+			name: "detected from scraped content",
+			metadata: map[string]interface{}{
+				"scraper_metadata": map[string]interface{}{"content": englishText},
+			},
+			expected: "en",
+		},
+		{
+			name:     "detected from submitted text",
+			metadata: map[string]interface{}{"original_text": englishText},
+			expected: "en",
+		},
+		{
+			name:     "unknown",
+			metadata: map[string]interface{}{"scraper_metadata": map[string]interface{}{"title": "Untitled"}},
+			expected: Unknown,
+		},
+		{
+			name:     "nil metadata",
+			metadata: nil,
+			expected: Unknown,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Resolve(tt.metadata); got != tt.expected {
+				t.Errorf("Resolve() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
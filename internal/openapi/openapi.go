@@ -0,0 +1,252 @@
+// Package openapi assembles OpenAPI 3 documents in code. Schemas are generated by
+// reflection from the Go types handlers encode and decode, so the published
+// description follows the structs rather than a hand-maintained copy.
+package openapi
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Version is the OpenAPI specification version documents are written against
+const Version = "3.0.3"
+
+// Document is the root of an OpenAPI document
+type Document struct {
+	OpenAPI    string               `json:"openapi"`
+	Info       Info                 `json:"info"`
+	Paths      map[string]*PathItem `json:"paths"`
+	Components Components           `json:"components"`
+	Tags       []Tag                `json:"tags,omitempty"`
+}
+
+// Info describes the API
+type Info struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version"`
+}
+
+// Tag groups operations in documentation viewers
+type Tag struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+}
+
+// Components holds the reusable schemas referenced from operations
+type Components struct {
+	Schemas map[string]*Schema `json:"schemas"`
+}
+
+// PathItem holds the operations available on one path, keyed by lower-case method
+type PathItem map[string]*Operation
+
+// Operation is a single method on a path
+type Operation struct {
+	Summary     string               `json:"summary"`
+	Description string               `json:"description,omitempty"`
+	OperationID string               `json:"operationId"`
+	Tags        []string             `json:"tags,omitempty"`
+	Parameters  []*Parameter         `json:"parameters,omitempty"`
+	RequestBody *RequestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*Response `json:"responses"`
+	Deprecated  bool                 `json:"deprecated,omitempty"`
+}
+
+// Parameter is a path or query parameter
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+// RequestBody is an operation's JSON request body
+type RequestBody struct {
+	Required bool                  `json:"required"`
+	Content  map[string]*MediaType `json:"content"`
+}
+
+// Response is one documented response of an operation
+type Response struct {
+	Description string                `json:"description"`
+	Content     map[string]*MediaType `json:"content,omitempty"`
+}
+
+// MediaType wraps the schema of a body
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// Param describes a query parameter for Builder.Add
+type Param struct {
+	Name        string
+	Type        string // string, integer, number or boolean
+	Description string
+	Required    bool
+}
+
+// Body is a documented response: Value is an instance of the encoded type, or nil for no body
+type Body struct {
+	Description string
+	Value       interface{}
+	ContentType string // Defaults to application/json
+}
+
+// Op is an operation to add to a Builder
+type Op struct {
+	Method          string
+	Path            string // Go ServeMux style, e.g. /api/requests/{id}
+	ID              string // operationId; must be unique
+	Summary         string
+	Description     string
+	Tag             string
+	Query           []Param
+	Request         interface{} // Instance of the decoded request body type, if any
+	OptionalRequest bool        // The request body may be omitted
+	Responses       map[int]Body
+}
+
+// Builder accumulates operations and the schemas they reference
+type Builder struct {
+	doc       *Document
+	schemas   *generator
+	errorBody interface{}
+}
+
+// NewBuilder starts a document with the given info
+func NewBuilder(info Info) *Builder {
+	doc := &Document{
+		OpenAPI:    Version,
+		Info:       info,
+		Paths:      make(map[string]*PathItem),
+		Components: Components{Schemas: make(map[string]*Schema)},
+	}
+	return &Builder{doc: doc, schemas: newGenerator(doc.Components.Schemas)}
+}
+
+// SetErrorBody documents errorBody as every operation's default (error) response
+func (b *Builder) SetErrorBody(errorBody interface{}) {
+	b.errorBody = errorBody
+}
+
+// AddTag declares a tag with a description
+func (b *Builder) AddTag(name, description string) {
+	b.doc.Tags = append(b.doc.Tags, Tag{Name: name, Description: description})
+}
+
+// Schema returns the schema for v, registering named struct types as components
+func (b *Builder) Schema(v interface{}) *Schema {
+	return b.schemas.schemaOf(v)
+}
+
+var pathParamPattern = regexp.MustCompile(`\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// Add documents an operation. Path parameters are declared automatically from the path.
+func (b *Builder) Add(op Op) {
+	method := strings.ToLower(op.Method)
+	if method == "" {
+		method = strings.ToLower(http.MethodGet)
+	}
+
+	operation := &Operation{
+		Summary:     op.Summary,
+		Description: op.Description,
+		OperationID: op.ID,
+		Responses:   make(map[string]*Response),
+	}
+	if op.Tag != "" {
+		operation.Tags = []string{op.Tag}
+	}
+
+	for _, match := range pathParamPattern.FindAllStringSubmatch(op.Path, -1) {
+		operation.Parameters = append(operation.Parameters, &Parameter{
+			Name:     match[1],
+			In:       "path",
+			Required: true,
+			Schema:   &Schema{Type: "string"},
+		})
+	}
+	for _, q := range op.Query {
+		typ := q.Type
+		if typ == "" {
+			typ = "string"
+		}
+		operation.Parameters = append(operation.Parameters, &Parameter{
+			Name:        q.Name,
+			In:          "query",
+			Description: q.Description,
+			Required:    q.Required,
+			Schema:      &Schema{Type: typ},
+		})
+	}
+
+	if op.Request != nil {
+		operation.RequestBody = &RequestBody{
+			Required: !op.OptionalRequest,
+			Content:  map[string]*MediaType{"application/json": {Schema: b.Schema(op.Request)}},
+		}
+	}
+
+	for status, body := range op.Responses {
+		operation.Responses[fmt.Sprintf("%d", status)] = b.response(body)
+	}
+	if b.errorBody != nil {
+		operation.Responses["default"] = b.response(Body{Description: "Error", Value: b.errorBody})
+	}
+
+	item, ok := b.doc.Paths[op.Path]
+	if !ok {
+		item = &PathItem{}
+		b.doc.Paths[op.Path] = item
+	}
+	(*item)[method] = operation
+}
+
+// response converts a Body into a documented response
+func (b *Builder) response(body Body) *Response {
+	resp := &Response{Description: body.Description}
+	if resp.Description == "" {
+		resp.Description = "Success"
+	}
+	if body.Value == nil {
+		return resp
+	}
+
+	contentType := body.ContentType
+	if contentType == "" {
+		contentType = "application/json"
+	}
+	schema := &Schema{Type: "string"}
+	if contentType == "application/json" {
+		schema = b.Schema(body.Value)
+	}
+	resp.Content = map[string]*MediaType{contentType: {Schema: schema}}
+	return resp
+}
+
+// Document returns the assembled document
+func (b *Builder) Document() *Document {
+	return b.doc
+}
+
+// Operations lists every documented method and path, sorted by path then method
+func (d *Document) Operations() [][2]string {
+	var ops [][2]string
+	for path, item := range d.Paths {
+		for method := range *item {
+			ops = append(ops, [2]string{strings.ToUpper(method), path})
+		}
+	}
+	sort.Slice(ops, func(i, j int) bool {
+		if ops[i][1] != ops[j][1] {
+			return ops[i][1] < ops[j][1]
+		}
+		return ops[i][0] < ops[j][0]
+	})
+	return ops
+}
@@ -0,0 +1,106 @@
+package openapi
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+type testNode struct {
+	Name      string      `json:"name"`
+	CreatedAt time.Time   `json:"created_at"`
+	Parent    *string     `json:"parent,omitempty"`
+	Children  []*testNode `json:"children,omitempty"`
+	Secret    string      `json:"-"`
+	Meta      json.RawMessage
+	hidden    int
+}
+
+func TestSchemaFromStruct(t *testing.T) {
+	b := NewBuilder(Info{Title: "test", Version: "1"})
+	ref := b.Schema(testNode{})
+	if ref.Ref != "#/components/schemas/testNode" {
+		t.Fatalf("expected component reference, got %+v", ref)
+	}
+
+	s := b.Document().Components.Schemas["testNode"]
+	if s == nil {
+		t.Fatal("testNode was not registered as a component")
+	}
+
+	if got := s.Properties["created_at"]; got.Type != "string" || got.Format != "date-time" {
+		t.Errorf("expected date-time for time.Time, got %+v", got)
+	}
+	if got := s.Properties["parent"]; !got.Nullable || got.Type != "string" {
+		t.Errorf("expected nullable string for *string, got %+v", got)
+	}
+	if got := s.Properties["children"]; got.Type != "array" || got.Items.Ref != ref.Ref {
+		t.Errorf("expected array of self references, got %+v", got)
+	}
+	if _, ok := s.Properties["Meta"]; !ok {
+		t.Error("expected untagged field to use its Go name")
+	}
+	for _, name := range []string{"Secret", "hidden"} {
+		if _, ok := s.Properties[name]; ok {
+			t.Errorf("field %s should not be documented", name)
+		}
+	}
+
+	required := map[string]bool{}
+	for _, name := range s.Required {
+		required[name] = true
+	}
+	if !required["name"] || !required["created_at"] {
+		t.Errorf("expected fields without omitempty to be required, got %v", s.Required)
+	}
+	if required["parent"] || required["children"] {
+		t.Errorf("expected omitempty fields to be optional, got %v", s.Required)
+	}
+}
+
+func TestAddDeclaresPathParameters(t *testing.T) {
+	b := NewBuilder(Info{Title: "test", Version: "1"})
+	b.SetErrorBody(map[string]string{})
+	b.Add(Op{
+		Method:    "GET",
+		Path:      "/items/{id}/versions/{version}",
+		ID:        "getItemVersion",
+		Query:     []Param{{Name: "limit", Type: "integer"}},
+		Responses: map[int]Body{200: {Value: Object("item")}},
+	})
+
+	op := (*b.Document().Paths["/items/{id}/versions/{version}"])["get"]
+	if op == nil {
+		t.Fatal("operation not added")
+	}
+	if len(op.Parameters) != 3 {
+		t.Fatalf("expected 2 path parameters and 1 query parameter, got %d", len(op.Parameters))
+	}
+	for _, p := range op.Parameters[:2] {
+		if p.In != "path" || !p.Required {
+			t.Errorf("expected required path parameter, got %+v", p)
+		}
+	}
+	if _, ok := op.Responses["200"]; !ok {
+		t.Error("expected 200 response")
+	}
+	if _, ok := op.Responses["default"]; !ok {
+		t.Error("expected default error response")
+	}
+}
+
+func TestBuilderRequestBody(t *testing.T) {
+	type body struct {
+		URL string `json:"url"`
+	}
+	b := NewBuilder(Info{Title: "test", Version: "1"})
+	b.Add(Op{Method: "POST", Path: "/items", ID: "createItem", Request: body{}, Responses: map[int]Body{201: {}}})
+	b.Add(Op{Method: "POST", Path: "/items/{id}/retry", ID: "retryItem", Request: body{}, OptionalRequest: true, Responses: map[int]Body{200: {}}})
+
+	if rb := (*b.Document().Paths["/items"])["post"].RequestBody; rb == nil || !rb.Required {
+		t.Errorf("expected a required request body, got %+v", rb)
+	}
+	if rb := (*b.Document().Paths["/items/{id}/retry"])["post"].RequestBody; rb == nil || rb.Required {
+		t.Errorf("expected an optional request body, got %+v", rb)
+	}
+}
@@ -0,0 +1,69 @@
+package pipeline
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// CompressHTML compresses and base64 encodes HTML text
+func CompressHTML(html string) (string, error) {
+	if html == "" {
+		return "", nil
+	}
+
+	var buf bytes.Buffer
+	gzWriter := gzip.NewWriter(&buf)
+
+	if _, err := gzWriter.Write([]byte(html)); err != nil {
+		return "", fmt.Errorf("failed to write to gzip: %w", err)
+	}
+
+	if err := gzWriter.Close(); err != nil {
+		return "", fmt.Errorf("failed to close gzip writer: %w", err)
+	}
+
+	return base64.StdEncoding.EncodeToString(buf.Bytes()), nil
+}
+
+var (
+	// ErrCorruptHTML is returned by DecompressHTML for data CompressHTML did not produce
+	ErrCorruptHTML = errors.New("compressed HTML is corrupt")
+	// ErrHTMLTooLarge is returned by DecompressHTML for HTML over its size limit
+	ErrHTMLTooLarge = errors.New("decompressed HTML exceeds the size limit")
+)
+
+// DecompressHTML reverses CompressHTML. Data that is not base64 encoded gzip returns an error
+// wrapping ErrCorruptHTML, and HTML longer than maxBytes returns ErrHTMLTooLarge without being
+// inflated past the limit. maxBytes <= 0 means no limit.
+func DecompressHTML(encoded string, maxBytes int64) (string, error) {
+	if encoded == "" {
+		return "", nil
+	}
+
+	compressed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrCorruptHTML, err)
+	}
+	gzReader, err := gzip.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrCorruptHTML, err)
+	}
+	defer gzReader.Close()
+
+	var r io.Reader = gzReader
+	if maxBytes > 0 {
+		r = io.LimitReader(gzReader, maxBytes+1)
+	}
+	html, err := io.ReadAll(r)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrCorruptHTML, err)
+	}
+	if maxBytes > 0 && int64(len(html)) > maxBytes {
+		return "", ErrHTMLTooLarge
+	}
+	return string(html), nil
+}
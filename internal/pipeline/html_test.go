@@ -0,0 +1,76 @@
+package pipeline
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestCompressHTMLRoundTrip(t *testing.T) {
+	tests := []struct {
+		name string
+		html string
+	}{
+		{"empty", ""},
+		{"short", "<p>Hello</p>"},
+		{"unicode", "<p>Grüße, 世界 👋</p>"},
+		{"large", strings.Repeat("<div>Lorem ipsum dolor sit amet</div>\n", 10000)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			compressed, err := CompressHTML(tt.html)
+			if err != nil {
+				t.Fatalf("CompressHTML failed: %v", err)
+			}
+			if tt.html == "" && compressed != "" {
+				t.Errorf("Expected empty input to compress to \"\", got %q", compressed)
+			}
+			html, err := DecompressHTML(compressed, 0)
+			if err != nil {
+				t.Fatalf("DeCompressHTML failed: %v", err)
+			}
+			if html != tt.html {
+				t.Errorf("Expected the round trip to return the input, got %d bytes for %d", len(html), len(tt.html))
+			}
+		})
+	}
+}
+
+func TestDecompressHTMLCorrupt(t *testing.T) {
+	compressed, err := CompressHTML(strings.Repeat("<p>Hello</p>", 100))
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, _ := base64.StdEncoding.DecodeString(compressed)
+
+	tests := []struct {
+		name    string
+		encoded string
+	}{
+		{"invalid base64", "H4sI!!!not-base64"},
+		{"not gzip", base64.StdEncoding.EncodeToString([]byte("<p>plain</p>"))},
+		{"truncated", base64.StdEncoding.EncodeToString(raw[:len(raw)/2])},
+		{"bad checksum", base64.StdEncoding.EncodeToString(append(append([]byte{}, raw[:len(raw)-8]...), 0, 0, 0, 0, 0, 0, 0, 0))},
+	}
+	for _, tt := range tests {
+		if _, err := DecompressHTML(tt.encoded, 0); !errors.Is(err, ErrCorruptHTML) {
+			t.Errorf("%s: expected ErrCorruptHTML, got %v", tt.name, err)
+		}
+	}
+}
+
+func TestDecompressHTMLLimit(t *testing.T) {
+	html := strings.Repeat("a", 1000)
+	compressed, err := CompressHTML(html)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := DecompressHTML(compressed, 999); !errors.Is(err, ErrHTMLTooLarge) {
+		t.Errorf("Expected ErrHTMLTooLarge under the limit, got %v", err)
+	}
+	if got, err := DecompressHTML(compressed, 1000); err != nil || got != html {
+		t.Errorf("Expected HTML at exactly the limit to decompress, got %d bytes, %v", len(got), err)
+	}
+}
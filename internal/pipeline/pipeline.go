@@ -0,0 +1,416 @@
+// Package pipeline turns a URL into a stored request: it scores the link, stores a
+// tombstoned record for links below the score threshold, and otherwise scrapes the page,
+// hands its text to the text analyzer and saves the assembled record. The synchronous
+// POST /api/scrape handler and the scrape queue worker both run it, so the records they
+// store only differ where the caller asks them to.
+package pipeline
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/docutag/controller/internal/clients"
+	"github.com/docutag/controller/internal/domaintag"
+	"github.com/docutag/controller/internal/settings"
+	internalslug "github.com/docutag/controller/internal/slug"
+	"github.com/docutag/controller/internal/storage"
+	"github.com/docutag/platform/pkg/metrics"
+	"github.com/google/uuid"
+)
+
+// Analysis selects how a scraped page is handed to the text analyzer
+type Analysis int
+
+const (
+	// AnalyzeSync asks the analyzer while the request is being handled and stores its
+	// response as analyzer_metadata. A failure fails the whole scrape.
+	AnalyzeSync Analysis = iota
+	// AnalyzeAsync enqueues the text, compressed HTML and images for analysis and records
+	// the job as queued. A failure is logged and the request is saved without analysis.
+	AnalyzeAsync
+)
+
+// Stages reported by StageError
+const (
+	StageScore     = "score link"
+	StageScrape    = "scrape"
+	StageAnalyze   = "analyze text"
+	StageSave      = "save request"
+	StageSaveBelow = "save low-quality record"
+)
+
+// StageError is returned when a step of the pipeline fails, so callers can report the
+// failure in their own terms
+type StageError struct {
+	Stage string
+	Err   error
+}
+
+func (e *StageError) Error() string {
+	return "failed to " + e.Stage + ": " + e.Err.Error()
+}
+
+func (e *StageError) Unwrap() error {
+	return e.Err
+}
+
+// RetrievalQueue enqueues the task that collects a finished text analysis
+type RetrievalQueue interface {
+	EnqueueRetrieveAnalysis(ctx context.Context, requestID, analysisJobID string, attemptCount int) (string, error)
+}
+
+// Deps are the services a pipeline run talks to
+type Deps struct {
+	Scraper          *clients.ScraperClient
+	TextAnalyzer     *clients.TextAnalyzerClient
+	DomainThresholds settings.DomainThresholds // Per-domain link score thresholds; nil uses the global one
+	BusinessMetrics  *metrics.BusinessMetrics  // Optional
+	Retrieval        RetrievalQueue            // Optional; analysis results are not collected without it
+	Logger           *slog.Logger              // Defaults to slog.Default()
+
+	// Save stores a new record, or the refreshed one on a re-scrape
+	Save func(req *storage.Request) error
+}
+
+// Options describe one URL to process
+type Options struct {
+	URL string
+
+	// RequestID is the ID to store the record under; a new one is generated when empty.
+	// With Rescrape set it is the stored request being refreshed.
+	RequestID string
+	// Rescrape skips the score threshold: the stored document was accepted already
+	Rescrape bool
+
+	LinkScoreThreshold      float64 // Global threshold; DomainThresholds may override it
+	TombstonePeriodLowScore int     // Days before a below-threshold record is tombstoned
+
+	Analysis             Analysis
+	SkipAnalysis         bool // Archival scrape: store the page without analyzing it
+	DomainTagIncludeHost bool // Tag the full host as well as the registrable domain
+
+	CreatedBy string
+	Namespace string
+
+	// Checkpoint, when set, is called before each step that does work and stops the run
+	// with its error. The stage names what was about to happen.
+	Checkpoint func(stage string) error
+
+	// AfterScrape, when set, is called with the scrape and its content hash before anything
+	// is stored. Returning handled stops the run without saving a record.
+	AfterScrape func(resp *clients.ScraperResponse, contentHash string) (handled bool, err error)
+}
+
+// Result describes what a run stored
+type Result struct {
+	Request        *storage.Request // Nil when AfterScrape handled the scrape
+	BelowThreshold bool             // The link scored below its threshold and was stored tombstoned
+	Score          float64          // The link's preliminary score
+	IsImage        bool
+	AnalysisJobID  string // Text analyzer job to collect, if any
+}
+
+// ScoreBucket is the score_bucket metric label of the link's score. Images are not scored.
+func (r *Result) ScoreBucket() string {
+	if r.IsImage {
+		return clients.ScoreBucketNone
+	}
+	return clients.ScoreBucket(r.Score)
+}
+
+// ProcessURL scores, scrapes, analyzes and saves opts.URL
+func ProcessURL(ctx context.Context, deps Deps, opts Options) (*Result, error) {
+	logger := deps.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+	url := opts.URL
+
+	// Score the URL first; image files are recognised by extension without asking the scraper
+	var scoreResp *clients.ScoreResponse
+	if clients.IsImageURL(url) {
+		scoreResp = clients.LocalImageScore(url)
+	} else {
+		var err error
+		scoreResp, err = deps.Scraper.ScoreLink(ctx, url)
+		if err != nil {
+			return nil, &StageError{Stage: StageScore, Err: err}
+		}
+	}
+
+	// Check if this is an image URL (skip threshold check for images)
+	isImageURL := scoreResp.IsImage()
+
+	threshold, _ := deps.DomainThresholds.LinkScoreThreshold(domaintag.Host(url), opts.LinkScoreThreshold)
+	if !isImageURL && !opts.Rescrape && scoreResp.Score.Score < threshold {
+		record, err := saveBelowThreshold(deps, opts, scoreResp, threshold)
+		if err != nil {
+			return nil, err
+		}
+		logger.Info("tombstone created",
+			"reason", "low-score",
+			"url", url,
+			"score", scoreResp.Score.Score,
+			"threshold", threshold,
+			"period_days", opts.TombstonePeriodLowScore,
+		)
+		return &Result{Request: record, BelowThreshold: true, Score: scoreResp.Score.Score}, nil
+	}
+
+	// Score meets or exceeds threshold - proceed with full scraping
+	if err := checkpoint(opts, "scraping"); err != nil {
+		return nil, err
+	}
+	scrapeResp, err := deps.Scraper.Scrape(ctx, url)
+	if err != nil {
+		return nil, &StageError{Stage: StageScrape, Err: err}
+	}
+
+	// Nothing has been stored yet, so a run out of time can still fail cleanly
+	if err := checkpoint(opts, "storing the scrape"); err != nil {
+		return nil, err
+	}
+
+	hash := storage.TextHash(scrapeResp.Content)
+	if opts.AfterScrape != nil {
+		handled, err := opts.AfterScrape(scrapeResp, hash)
+		if err != nil {
+			return nil, err
+		}
+		if handled {
+			return &Result{Score: scoreResp.Score.Score, IsImage: isImageURL}, nil
+		}
+	}
+
+	// An image without a file extension is only recognised by the fetched Content-Type
+	isImageURL = isImageURL || scrapeResp.IsImage()
+
+	requestID := opts.RequestID
+	if requestID == "" {
+		requestID = uuid.New().String()
+	}
+
+	combinedMetadata := map[string]interface{}{
+		"scraper_metadata": scraperMetadata(scrapeResp),
+	}
+
+	// Analyze the content (skip for image URLs and archival scrapes)
+	var analyzerResp *clients.TextAnalyzerResponse
+	var analysisJobID string
+	if !isImageURL && !opts.SkipAnalysis {
+		switch opts.Analysis {
+		case AnalyzeSync:
+			analyzerResp, err = deps.TextAnalyzer.Analyze(ctx, scrapeResp.Content)
+			if err != nil {
+				return nil, &StageError{Stage: StageAnalyze, Err: err}
+			}
+			analysisJobID = analyzerResp.ID
+			combinedMetadata["analyzer_metadata"] = analyzerResp.Metadata
+		case AnalyzeAsync:
+			analysisJobID = enqueueAnalysis(ctx, deps, logger, url, scrapeResp)
+			if analysisJobID != "" {
+				combinedMetadata["textanalyzer_job_id"] = analysisJobID
+				storage.SetAnalysisStatus(combinedMetadata, storage.AnalysisStatusQueued, time.Now())
+			}
+		}
+	}
+	if opts.SkipAnalysis {
+		combinedMetadata[storage.MetaAnalysisSkipped] = true
+	}
+
+	// Add link score from scraper response if available, otherwise use preliminary score
+	score := scoreResp.Score
+	if scrapeResp.Score != nil {
+		score = *scrapeResp.Score
+	}
+	combinedMetadata["link_score"] = linkScoreMetadata(score)
+	if !isImageURL {
+		combinedMetadata["threshold"] = opts.LinkScoreThreshold
+		combinedMetadata["effective_threshold"] = threshold
+	}
+
+	// Tags from a synchronous analysis; otherwise the link score categories, until the
+	// analysis result adds its own
+	var tags []string
+	if analyzerResp != nil {
+		tags = analyzerResp.GetTags()
+	} else {
+		tags = normalizedCategories(score.Categories)
+	}
+	if isImageURL {
+		tags = clients.WithImageTag(tags)
+	}
+	tags = append(tags, domaintag.Tags(url, opts.DomainTagIncludeHost)...)
+
+	// Add 'scrape' tag to all scraped content
+	tags = append(tags, "scrape")
+
+	// Use the scraper's slug, or generate one from the title or URL
+	slug := scrapeResp.Slug
+	if slug == "" {
+		slugSource := scrapeResp.Title
+		if slugSource == "" {
+			slugSource = url
+		}
+		slug = internalslug.GenerateWithFallback(slugSource, requestID)
+	}
+
+	record := &storage.Request{
+		ID:               requestID,
+		CreatedAt:        time.Now().UTC(),
+		SourceType:       "url",
+		SourceURL:        &url,
+		ScraperUUID:      &scrapeResp.ID,
+		TextAnalyzerUUID: analysisJobID,
+		Tags:             tags,
+		Metadata:         combinedMetadata,
+		Slug:             &slug,
+		SEOEnabled:       true, // Enable SEO by default
+		ContentHash:      hash,
+		CreatedBy:        opts.CreatedBy,
+		Namespace:        opts.Namespace,
+	}
+
+	if err := checkpoint(opts, "saving the document"); err != nil {
+		return nil, err
+	}
+	if err := deps.Save(record); err != nil {
+		return nil, &StageError{Stage: StageSave, Err: err}
+	}
+
+	// Enqueue analysis result retrieval task if text analysis was queued
+	if analysisJobID != "" && deps.Retrieval != nil {
+		if _, err := deps.Retrieval.EnqueueRetrieveAnalysis(ctx, requestID, analysisJobID, 0); err != nil {
+			// Log error but don't fail the scrape - retrieval can be retried manually if needed
+			logger.Warn("failed to enqueue analysis retrieval",
+				"request_id", requestID,
+				"analysis_job_id", analysisJobID,
+				"error", err,
+			)
+		} else {
+			logger.Info("enqueued analysis retrieval task",
+				"request_id", requestID,
+				"analysis_job_id", analysisJobID,
+			)
+		}
+	}
+
+	return &Result{Request: record, Score: scoreResp.Score.Score, IsImage: isImageURL, AnalysisJobID: analysisJobID}, nil
+}
+
+// saveBelowThreshold stores the scoring metadata of a low-quality link as a record that is
+// tombstoned after the low-score period, and records the tombstone metrics
+func saveBelowThreshold(deps Deps, opts Options, scoreResp *clients.ScoreResponse, threshold float64) (*storage.Request, error) {
+	url := opts.URL
+	tombstoneTime := time.Now().UTC().Add(time.Duration(opts.TombstonePeriodLowScore) * 24 * time.Hour)
+
+	tags := normalizedCategories(scoreResp.Score.Categories)
+	tags = append(tags, domaintag.Tags(url, opts.DomainTagIncludeHost)...)
+
+	// Add 'scrape' tag to all scraped content
+	tags = append(tags, "scrape")
+
+	record := &storage.Request{
+		ID:         uuid.New().String(),
+		CreatedAt:  time.Now().UTC(),
+		SourceType: "url",
+		SourceURL:  &url,
+		Tags:       tags,
+		SEOEnabled: false, // Disable SEO for below-threshold content
+		CreatedBy:  opts.CreatedBy,
+		Namespace:  opts.Namespace,
+		Metadata: map[string]interface{}{
+			"link_score":          linkScoreMetadata(scoreResp.Score),
+			"below_threshold":     true,
+			"threshold":           opts.LinkScoreThreshold,
+			"effective_threshold": threshold,
+			"tombstone_datetime":  tombstoneTime.Format(time.RFC3339), // Auto-tombstone low quality content
+		},
+	}
+
+	if err := deps.Save(record); err != nil {
+		return nil, &StageError{Stage: StageSaveBelow, Err: err}
+	}
+
+	if deps.BusinessMetrics != nil {
+		deps.BusinessMetrics.TombstonesCreatedTotal.WithLabelValues("low-score", "none").Inc()
+		deps.BusinessMetrics.TombstoneDaysHistogram.WithLabelValues("low-score").Observe(float64(opts.TombstonePeriodLowScore))
+	}
+	return record, nil
+}
+
+// enqueueAnalysis hands the scraped text, compressed HTML and images to the text analyzer
+// and returns the job ID, or "" when the analyzer could not take it
+func enqueueAnalysis(ctx context.Context, deps Deps, logger *slog.Logger, url string, scrapeResp *clients.ScraperResponse) string {
+	images := make([]string, 0, len(scrapeResp.Images))
+	for _, img := range scrapeResp.Images {
+		images = append(images, img.URL)
+	}
+
+	compressedRawText, err := CompressHTML(scrapeResp.RawText)
+	if err != nil {
+		logger.Warn("failed to compress raw text",
+			"url", url,
+			"error", err,
+		)
+		compressedRawText = "" // Continue without compressed HTML
+	}
+
+	jobID, err := deps.TextAnalyzer.EnqueueAnalysis(ctx, scrapeResp.Content, compressedRawText, images)
+	if err != nil {
+		// Log error but don't fail the scrape - analysis can be retried later
+		logger.Warn("failed to enqueue text analysis",
+			"url", url,
+			"error", err,
+		)
+		return ""
+	}
+	logger.Info("enqueued text analysis job",
+		"job_id", jobID,
+		"url", url,
+		"image_count", len(images),
+		"has_compressed_html", compressedRawText != "",
+	)
+	return jobID
+}
+
+// scraperMetadata stores the scraped page's text alongside the scraper's own metadata
+// (description, keywords, etc.)
+func scraperMetadata(resp *clients.ScraperResponse) map[string]interface{} {
+	metadata := map[string]interface{}{
+		"title":    resp.Title,
+		"content":  resp.Content,
+		"raw_text": resp.RawText,
+		"url":      resp.URL,
+	}
+	for k, v := range resp.Metadata {
+		metadata[k] = v
+	}
+	return metadata
+}
+
+func linkScoreMetadata(score clients.LinkScore) map[string]interface{} {
+	return map[string]interface{}{
+		"score":                score.Score,
+		"reason":               score.Reason,
+		"categories":           score.Categories,
+		"is_recommended":       score.IsRecommended,
+		"malicious_indicators": score.MaliciousIndicators,
+	}
+}
+
+func normalizedCategories(categories []string) []string {
+	tags := make([]string, 0, len(categories))
+	for _, cat := range categories {
+		tags = append(tags, clients.NormalizeTag(cat))
+	}
+	return tags
+}
+
+func checkpoint(opts Options, stage string) error {
+	if opts.Checkpoint == nil {
+		return nil
+	}
+	return opts.Checkpoint(stage)
+}
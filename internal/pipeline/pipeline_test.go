@@ -0,0 +1,487 @@
+package pipeline
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/docutag/controller/internal/clients"
+	"github.com/docutag/controller/internal/storage"
+)
+
+// upstream mocks the scraper and text analyzer with fixed responses
+type upstream struct {
+	score         clients.LinkScore
+	scrape        clients.ScraperResponse
+	analyzeStatus int // Status the analyzer answers with; 0 accepts the job
+
+	analyzeRequests []clients.TextAnalyzerRequest
+}
+
+func (u *upstream) start(t *testing.T) Deps {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/score":
+			var req clients.ScoreRequest
+			json.NewDecoder(r.Body).Decode(&req)
+			score := u.score
+			score.URL = req.URL
+			json.NewEncoder(w).Encode(clients.ScoreResponse{URL: req.URL, Score: score})
+		case "/api/scrape":
+			json.NewEncoder(w).Encode(u.scrape)
+		case "/api/analyze":
+			var req clients.TextAnalyzerRequest
+			json.NewDecoder(r.Body).Decode(&req)
+			u.analyzeRequests = append(u.analyzeRequests, req)
+			if u.analyzeStatus != 0 {
+				w.WriteHeader(u.analyzeStatus)
+				return
+			}
+			w.WriteHeader(http.StatusAccepted)
+			json.NewEncoder(w).Encode(clients.TextAnalyzerQueueResponse{JobID: "analyzer-job-1", Status: "queued", Message: "Analysis queued for processing"})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	return Deps{
+		Scraper:      clients.NewScraperClient(server.URL),
+		TextAnalyzer: clients.NewTextAnalyzerClient(server.URL),
+	}
+}
+
+// recorder collects the records a run saves and the retrievals it enqueues
+type recorder struct {
+	saved      []*storage.Request
+	retrievals []string
+}
+
+func (r *recorder) save(req *storage.Request) error {
+	r.saved = append(r.saved, req)
+	return nil
+}
+
+func (r *recorder) EnqueueRetrieveAnalysis(ctx context.Context, requestID, analysisJobID string, attemptCount int) (string, error) {
+	r.retrievals = append(r.retrievals, requestID+"/"+analysisJobID)
+	return "task-1", nil
+}
+
+func newUpstream() *upstream {
+	return &upstream{
+		score: clients.LinkScore{
+			Score:               0.8,
+			Reason:              "High quality content",
+			Categories:          []string{"technical-deep-dive-article", "education"},
+			IsRecommended:       true,
+			MaliciousIndicators: []string{},
+		},
+		scrape: clients.ScraperResponse{
+			ID:       "scraper-uuid-1",
+			URL:      "https://blog.example.com/post",
+			Title:    "An Example Post",
+			Content:  "The main text of the post.",
+			RawText:  "<p>The main text of the post.</p>",
+			Images:   []clients.ImageInfo{{URL: "https://blog.example.com/a.png"}},
+			Metadata: map[string]interface{}{"description": "An example"},
+			Slug:     "an-example-post",
+		},
+	}
+}
+
+// handlerOptions and workerOptions are the options the scrape handler and the scrape
+// worker run the pipeline with
+func handlerOptions(url string) Options {
+	return Options{
+		URL:                     url,
+		LinkScoreThreshold:      0.5,
+		TombstonePeriodLowScore: 30,
+		Analysis:                AnalyzeSync,
+		CreatedBy:               "tester",
+		Namespace:               "default",
+	}
+}
+
+func workerOptions(url string) Options {
+	opts := handlerOptions(url)
+	opts.Analysis = AnalyzeAsync
+	return opts
+}
+
+// run processes url with opts against u and returns the one record saved
+func run(t *testing.T, u *upstream, opts Options) (*storage.Request, *recorder) {
+	t.Helper()
+	rec := &recorder{}
+	deps := u.start(t)
+	deps.Save = rec.save
+	deps.Retrieval = rec
+	result, err := ProcessURL(context.Background(), deps, opts)
+	if err != nil {
+		t.Fatalf("ProcessURL: %v", err)
+	}
+	if len(rec.saved) != 1 || result.Request != rec.saved[0] {
+		t.Fatalf("Expected one saved record returned in the result, saved %d", len(rec.saved))
+	}
+	return rec.saved[0], rec
+}
+
+// comparable strips what legitimately differs between two runs: the generated ID, the
+// creation time and the metadata that belongs to one analysis mode
+func comparable(req *storage.Request) storage.Request {
+	c := *req
+	c.ID = ""
+	c.CreatedAt = time.Time{}
+	c.Metadata = make(map[string]interface{}, len(req.Metadata))
+	for k, v := range req.Metadata {
+		switch k {
+		case "analyzer_metadata", "textanalyzer_job_id", storage.MetaAnalysisStatus:
+			continue
+		}
+		c.Metadata[k] = v
+	}
+	return c
+}
+
+func TestProcessURLHandlerAndWorkerSaveTheSameRecord(t *testing.T) {
+	// A synchronous analysis supplies the handler's tags, and the queued stub has none; the
+	// worker tags a record with its link score categories until the analysis result arrives.
+	// analyzedTags lists both where they differ.
+	type analyzedTags struct{ handler, worker []string }
+	articleTags := &analyzedTags{
+		handler: []string{"example.com", "scrape"},
+		worker:  []string{"technical-deep", "education", "example.com", "scrape"},
+	}
+	tests := []struct {
+		name   string
+		url    string
+		modify func(u *upstream)
+		tags   *analyzedTags
+	}{
+		{"article", "https://blog.example.com/post", nil, articleTags},
+		{"below threshold", "https://blog.example.com/post", func(u *upstream) { u.score.Score = 0.2 }, nil},
+		{"no scraper slug", "https://blog.example.com/post", func(u *upstream) { u.scrape.Slug = "" }, articleTags},
+		{"no scraper score", "https://blog.example.com/post", func(u *upstream) { u.scrape.Score = nil }, articleTags},
+		{"scraper score", "https://blog.example.com/post", func(u *upstream) {
+			u.scrape.Score = &clients.LinkScore{Score: 0.9, Categories: []string{"news-current-events-politics"}}
+		}, &analyzedTags{
+			handler: []string{"example.com", "scrape"},
+			worker:  []string{"news-current", "example.com", "scrape"},
+		}},
+		{"image", "https://blog.example.com/photo.png", nil, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u := newUpstream()
+			if tt.modify != nil {
+				tt.modify(u)
+			}
+			fromHandler, _ := run(t, u, handlerOptions(tt.url))
+			fromWorker, _ := run(t, u, workerOptions(tt.url))
+
+			a, b := comparable(fromHandler), comparable(fromWorker)
+			if tt.tags != nil {
+				if !reflect.DeepEqual(a.Tags, tt.tags.handler) || !reflect.DeepEqual(b.Tags, tt.tags.worker) {
+					t.Errorf("Expected handler tags %v and worker tags %v, got %v and %v", tt.tags.handler, tt.tags.worker, a.Tags, b.Tags)
+				}
+				a.Tags, b.Tags = nil, nil
+			}
+			if !reflect.DeepEqual(a, b) {
+				t.Errorf("Records differ\nhandler: %+v\nworker:  %+v", a, b)
+			}
+		})
+	}
+}
+
+// handlerRecord is the part of a record pinned in testdata/handler_records.json: what
+// POST /api/scrape saved for the responses in newUpstream before it ran on the pipeline
+type handlerRecord struct {
+	Tags             []string `json:"tags"`
+	Slug             string   `json:"slug"`
+	MetadataKeys     []string `json:"metadata_keys"`
+	TextAnalyzerUUID string   `json:"textanalyzer_uuid"`
+	SEOEnabled       bool     `json:"seo_enabled"`
+}
+
+// TestProcessURLReproducesHandlerRecords checks the handler's options against the records the
+// handler saved itself. Below-threshold records are left out: the handler stored their link
+// score categories as they came, and the pipeline normalizes them as the worker always did.
+func TestProcessURLReproducesHandlerRecords(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("testdata", "handler_records.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fixtures map[string]handlerRecord
+	if err := json.Unmarshal(data, &fixtures); err != nil {
+		t.Fatalf("Failed to parse fixtures: %v", err)
+	}
+
+	options := map[string]func() Options{
+		"article": func() Options { return handlerOptions("https://blog.example.com/post") },
+		"skipped analysis": func() Options {
+			opts := handlerOptions("https://blog.example.com/post")
+			opts.SkipAnalysis = true
+			return opts
+		},
+		"image": func() Options { return handlerOptions("https://blog.example.com/photo.png") },
+	}
+	if len(fixtures) != len(options) {
+		t.Fatalf("Expected %d fixtures, got %d", len(options), len(fixtures))
+	}
+	for name, want := range fixtures {
+		t.Run(name, func(t *testing.T) {
+			newOptions, ok := options[name]
+			if !ok {
+				t.Fatalf("No options for fixture %q", name)
+			}
+			req, _ := run(t, newUpstream(), newOptions())
+
+			got := handlerRecord{
+				Tags:             req.Tags,
+				TextAnalyzerUUID: req.TextAnalyzerUUID,
+				SEOEnabled:       req.SEOEnabled,
+			}
+			if req.Slug != nil {
+				got.Slug = *req.Slug
+			}
+			for k := range req.Metadata {
+				got.MetadataKeys = append(got.MetadataKeys, k)
+			}
+			slices.Sort(got.MetadataKeys)
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("Record differs from the handler's\n got %+v\nwant %+v", got, want)
+			}
+		})
+	}
+}
+
+func TestProcessURLRecord(t *testing.T) {
+	u := newUpstream()
+	req, rec := run(t, u, workerOptions("https://blog.example.com/post"))
+
+	wantTags := []string{"technical-deep", "education", "example.com", "scrape"}
+	if !reflect.DeepEqual(req.Tags, wantTags) {
+		t.Errorf("Expected tags %v, got %v", wantTags, req.Tags)
+	}
+	if req.Slug == nil || *req.Slug != "an-example-post" {
+		t.Errorf("Expected the scraper's slug, got %v", req.Slug)
+	}
+	if req.ContentHash != storage.TextHash("The main text of the post.") {
+		t.Errorf("Expected the content hash, got %q", req.ContentHash)
+	}
+	if !req.SEOEnabled || req.SourceType != "url" || *req.SourceURL != "https://blog.example.com/post" || *req.ScraperUUID != "scraper-uuid-1" {
+		t.Errorf("Unexpected record %+v", req)
+	}
+	if req.CreatedBy != "tester" || req.Namespace != "default" {
+		t.Errorf("Expected the caller's provenance, got %q/%q", req.CreatedBy, req.Namespace)
+	}
+	if req.CreatedAt.Location() != time.UTC {
+		t.Errorf("Expected a UTC creation time, got %v", req.CreatedAt)
+	}
+
+	scraper := req.Metadata["scraper_metadata"].(map[string]interface{})
+	if scraper["title"] != "An Example Post" || scraper["raw_text"] != "<p>The main text of the post.</p>" || scraper["description"] != "An example" {
+		t.Errorf("Unexpected scraper metadata %v", scraper)
+	}
+	if req.Metadata["threshold"] != 0.5 || req.Metadata["effective_threshold"] != 0.5 {
+		t.Errorf("Expected the thresholds, got %v", req.Metadata)
+	}
+
+	// The worker enqueues the text, compressed HTML and images and records the job as queued
+	if req.TextAnalyzerUUID != "analyzer-job-1" || req.Metadata["textanalyzer_job_id"] != "analyzer-job-1" ||
+		req.Metadata[storage.MetaAnalysisStatus] != storage.AnalysisStatusQueued {
+		t.Errorf("Expected a queued analysis, got %q %v", req.TextAnalyzerUUID, req.Metadata)
+	}
+	if len(u.analyzeRequests) != 1 || u.analyzeRequests[0].Images[0] != "https://blog.example.com/a.png" {
+		t.Fatalf("Expected one analysis request with the images, got %+v", u.analyzeRequests)
+	}
+	if html, err := DecompressHTML(u.analyzeRequests[0].OriginalHTML, 0); err != nil || html != "<p>The main text of the post.</p>" {
+		t.Errorf("Expected the compressed raw text, got %q, %v", html, err)
+	}
+	if !reflect.DeepEqual(rec.retrievals, []string{req.ID + "/analyzer-job-1"}) {
+		t.Errorf("Expected the retrieval to be enqueued, got %v", rec.retrievals)
+	}
+
+	// The handler stores the analyzer's response
+	req, _ = run(t, newUpstream(), handlerOptions("https://blog.example.com/post"))
+	if analyzer, ok := req.Metadata["analyzer_metadata"].(map[string]interface{}); !ok || analyzer["status"] != "queued" {
+		t.Errorf("Expected analyzer_metadata, got %v", req.Metadata)
+	}
+	if req.TextAnalyzerUUID != "analyzer-job-1" {
+		t.Errorf("Expected the analyzer ID, got %q", req.TextAnalyzerUUID)
+	}
+	// The analyzer's tags replace the link score categories; the queued stub has none
+	if want := []string{"example.com", "scrape"}; !reflect.DeepEqual(req.Tags, want) {
+		t.Errorf("Expected tags %v, got %v", want, req.Tags)
+	}
+}
+
+func TestProcessURLBelowThreshold(t *testing.T) {
+	u := newUpstream()
+	u.score.Score = 0.2
+	opts := workerOptions("https://blog.example.com/post")
+	opts.DomainTagIncludeHost = true
+	req, _ := run(t, u, opts)
+
+	wantTags := []string{"technical-deep", "education", "example.com", "blog.example.com", "scrape"}
+	if !reflect.DeepEqual(req.Tags, wantTags) {
+		t.Errorf("Expected tags %v, got %v", wantTags, req.Tags)
+	}
+	if req.SEOEnabled || req.Metadata["below_threshold"] != true || req.Metadata["tombstone_datetime"] == nil {
+		t.Errorf("Expected a tombstoned record, got %+v", req)
+	}
+	if req.ScraperUUID != nil || len(u.analyzeRequests) != 0 {
+		t.Error("Expected a below-threshold link not to be scraped or analyzed")
+	}
+
+	// A domain threshold applies to the link's host
+	u = newUpstream()
+	u.score.Score = 0.6
+	rec := &recorder{}
+	deps := u.start(t)
+	deps.Save = rec.save
+	deps.DomainThresholds = map[string]float64{"blog.example.com": 0.7}
+	result, err := ProcessURL(context.Background(), deps, workerOptions("https://blog.example.com/post"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.BelowThreshold || result.Request.Metadata["effective_threshold"] != 0.7 || result.Request.Metadata["threshold"] != 0.5 {
+		t.Errorf("Expected the domain threshold to apply, got %+v", result.Request.Metadata)
+	}
+
+	// A re-scrape is not held to the threshold and keeps its ID
+	opts = workerOptions("https://blog.example.com/post")
+	opts.RequestID = "existing-id"
+	opts.Rescrape = true
+	req, _ = run(t, u, opts)
+	if req.ID != "existing-id" || req.Metadata["below_threshold"] != nil {
+		t.Errorf("Expected the stored request to be refreshed, got %+v", req)
+	}
+}
+
+func TestProcessURLFallbackSlug(t *testing.T) {
+	u := newUpstream()
+	u.scrape.Slug = ""
+	req, _ := run(t, u, handlerOptions("https://blog.example.com/post"))
+	if req.Slug == nil || *req.Slug != "an-example-post" {
+		t.Errorf("Expected a slug generated from the title, got %v", req.Slug)
+	}
+
+	u.scrape.Title = ""
+	req, _ = run(t, u, handlerOptions("https://blog.example.com/post"))
+	if req.Slug == nil || *req.Slug != "httpsblogexamplecompost" {
+		t.Errorf("Expected a slug generated from the URL, got %v", req.Slug)
+	}
+}
+
+func TestProcessURLSkipAnalysis(t *testing.T) {
+	for _, analysis := range []Analysis{AnalyzeSync, AnalyzeAsync} {
+		u := newUpstream()
+		opts := handlerOptions("https://blog.example.com/post")
+		opts.Analysis = analysis
+		opts.SkipAnalysis = true
+		req, rec := run(t, u, opts)
+		if len(u.analyzeRequests) != 0 || req.TextAnalyzerUUID != "" || len(rec.retrievals) != 0 {
+			t.Errorf("Expected no analysis, got %+v", req)
+		}
+		if req.Metadata[storage.MetaAnalysisSkipped] != true {
+			t.Errorf("Expected the record to be marked as skipping analysis, got %v", req.Metadata)
+		}
+	}
+}
+
+func TestProcessURLAnalyzerFailure(t *testing.T) {
+	// A synchronous analysis failure fails the scrape
+	u := newUpstream()
+	u.analyzeStatus = http.StatusServiceUnavailable
+	rec := &recorder{}
+	deps := u.start(t)
+	deps.Save = rec.save
+	_, err := ProcessURL(context.Background(), deps, handlerOptions("https://blog.example.com/post"))
+	var stageErr *StageError
+	if !errors.As(err, &stageErr) || stageErr.Stage != StageAnalyze || len(rec.saved) != 0 {
+		t.Errorf("Expected an analyze stage error and nothing saved, got %v", err)
+	}
+
+	// An asynchronous one is logged and the request saved without analysis
+	req, rec := run(t, u, workerOptions("https://blog.example.com/post"))
+	if req.TextAnalyzerUUID != "" || req.Metadata["textanalyzer_job_id"] != nil || len(rec.retrievals) != 0 {
+		t.Errorf("Expected no analysis job, got %+v", req)
+	}
+}
+
+func TestProcessURLHooks(t *testing.T) {
+	u := newUpstream()
+	deps := u.start(t)
+	rec := &recorder{}
+	deps.Save = rec.save
+
+	// A checkpoint error stops the run with that error
+	stop := errors.New("stopped")
+	opts := workerOptions("https://blog.example.com/post")
+	var stages []string
+	opts.Checkpoint = func(stage string) error {
+		stages = append(stages, stage)
+		if stage == "saving the document" {
+			return stop
+		}
+		return nil
+	}
+	if _, err := ProcessURL(context.Background(), deps, opts); err != stop || len(rec.saved) != 0 {
+		t.Errorf("Expected the checkpoint error and nothing saved, got %v", err)
+	}
+	if want := []string{"scraping", "storing the scrape", "saving the document"}; !reflect.DeepEqual(stages, want) {
+		t.Errorf("Expected checkpoints %v, got %v", want, stages)
+	}
+
+	// A scrape handled by AfterScrape is not saved
+	opts = workerOptions("https://blog.example.com/post")
+	var gotHash string
+	opts.AfterScrape = func(resp *clients.ScraperResponse, hash string) (bool, error) {
+		gotHash = hash
+		return true, nil
+	}
+	result, err := ProcessURL(context.Background(), deps, opts)
+	if err != nil || result.Request != nil || len(rec.saved) != 0 {
+		t.Errorf("Expected nothing saved, got %+v, %v", result, err)
+	}
+	if gotHash != storage.TextHash("The main text of the post.") {
+		t.Errorf("Expected the content hash, got %q", gotHash)
+	}
+}
+
+func TestStageError(t *testing.T) {
+	cause := errors.New("connection refused")
+	err := error(&StageError{Stage: StageScrape, Err: cause})
+	if err.Error() != "failed to scrape: connection refused" {
+		t.Errorf("Unexpected message %q", err.Error())
+	}
+	if !errors.Is(err, cause) {
+		t.Error("Expected the cause to be unwrapped")
+	}
+}
+
+func TestResultScoreBucket(t *testing.T) {
+	tests := []struct {
+		result Result
+		want   string
+	}{
+		{Result{Score: 0.85}, "0.8-1.0"},
+		{Result{Score: 0.3, BelowThreshold: true}, "0.2-0.4"},
+		{Result{Score: 0.9, IsImage: true}, clients.ScoreBucketNone},
+	}
+	for _, tt := range tests {
+		if got := tt.result.ScoreBucket(); got != tt.want {
+			t.Errorf("ScoreBucket() of %+v = %q, want %q", tt.result, got, tt.want)
+		}
+	}
+}
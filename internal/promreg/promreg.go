@@ -0,0 +1,23 @@
+// Package promreg registers Prometheus collectors so that registering the same metrics twice,
+// as a second handler or worker in one process does, shares the series instead of failing.
+package promreg
+
+import (
+	"errors"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// RegisterOrReuse registers c, returning the collector reg already has when c is a duplicate
+func RegisterOrReuse[C prometheus.Collector](reg prometheus.Registerer, c C) (C, error) {
+	if err := reg.Register(c); err != nil {
+		var already prometheus.AlreadyRegisteredError
+		if errors.As(err, &already) {
+			if existing, ok := already.ExistingCollector.(C); ok {
+				return existing, nil
+			}
+		}
+		return c, err
+	}
+	return c, nil
+}
@@ -0,0 +1,29 @@
+package promreg
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestRegisterOrReuse(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	opts := prometheus.CounterOpts{Name: "promreg_test_total", Help: "Test counter"}
+
+	first, err := RegisterOrReuse(reg, prometheus.NewCounter(opts))
+	if err != nil {
+		t.Fatalf("first registration failed: %v", err)
+	}
+	second, err := RegisterOrReuse(reg, prometheus.NewCounter(opts))
+	if err != nil {
+		t.Fatalf("duplicate registration failed: %v", err)
+	}
+	if second != first {
+		t.Error("expected the duplicate to reuse the registered counter")
+	}
+
+	// A different collector under the same name is still refused
+	if _, err := RegisterOrReuse(reg, prometheus.NewGauge(prometheus.GaugeOpts{Name: "promreg_test_total", Help: "Test gauge"})); err == nil {
+		t.Error("expected a conflicting collector to be refused")
+	}
+}
@@ -0,0 +1,44 @@
+package queue
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/hibiken/asynq"
+)
+
+func TestWorkerActiveTasks(t *testing.T) {
+	w := NewWorker(WorkerConfig{RedisAddr: "localhost:6379", Concurrency: 4}, nil, nil, nil, nil, nil, nil, nil, nil)
+	if w.Concurrency() != 4 {
+		t.Errorf("expected concurrency 4, got %d", w.Concurrency())
+	}
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	w.mux.HandleFunc("test:block", func(ctx context.Context, t *asynq.Task) error {
+		close(started)
+		<-release
+		return nil
+	})
+
+	done := make(chan error, 1)
+	go func() { done <- w.ProcessTask(context.Background(), asynq.NewTask("test:block", nil)) }()
+
+	select {
+	case <-started:
+	case <-time.After(time.Second):
+		t.Fatal("task did not start")
+	}
+	if got := w.ActiveTasks(); got != 1 {
+		t.Errorf("expected 1 active task while processing, got %d", got)
+	}
+
+	close(release)
+	if err := <-done; err != nil {
+		t.Fatalf("ProcessTask failed: %v", err)
+	}
+	if got := w.ActiveTasks(); got != 0 {
+		t.Errorf("expected 0 active tasks after completion, got %d", got)
+	}
+}
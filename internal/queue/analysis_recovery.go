@@ -0,0 +1,196 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/docutag/controller/internal/clients"
+	"github.com/docutag/controller/internal/pipeline"
+	"github.com/docutag/controller/internal/storage"
+)
+
+// Outcomes of recovering one timed-out analysis
+const (
+	recoveryRecovered = "recovered" // The analyzer had finished; its result was applied
+	recoveryRequeued  = "requeued"  // The analyzer lost the job; a fresh analysis was enqueued
+	recoveryPending   = "pending"   // The analyzer is still working on the job
+	recoveryFailed    = "failed"
+)
+
+// AnalysisRecoverySummary counts the outcomes of one recovery sweep
+type AnalysisRecoverySummary struct {
+	Checked   int `json:"checked"`
+	Recovered int `json:"recovered"`
+	Requeued  int `json:"requeued"`
+	Pending   int `json:"pending"`
+	Failed    int `json:"failed"`
+}
+
+// RecoverTimedOutAnalyses revisits up to limit requests whose analysis retrieval timed out.
+// Finished analyses are applied exactly as the retrieval task would have; jobs the text analyzer
+// no longer knows are re-enqueued from the stored scrape content.
+func (w *Worker) RecoverTimedOutAnalyses(ctx context.Context, limit int) (*AnalysisRecoverySummary, error) {
+	requests, err := w.storage.ListRequestsWithAnalysisTimeout(limit)
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &AnalysisRecoverySummary{}
+	for _, req := range requests {
+		if ctx.Err() != nil {
+			break
+		}
+		outcome, err := w.recoverAnalysis(ctx, req)
+		if err != nil {
+			w.logger.Warn("failed to recover timed-out analysis",
+				"request_id", req.ID,
+				"error", err,
+			)
+		}
+
+		summary.Checked++
+		switch outcome {
+		case recoveryRecovered:
+			summary.Recovered++
+		case recoveryRequeued:
+			summary.Requeued++
+		case recoveryPending:
+			summary.Pending++
+		default:
+			summary.Failed++
+		}
+	}
+
+	if summary.Checked > 0 {
+		w.logger.Info("analysis recovery sweep finished",
+			"checked", summary.Checked,
+			"recovered", summary.Recovered,
+			"requeued", summary.Requeued,
+			"pending", summary.Pending,
+			"failed", summary.Failed,
+		)
+	}
+
+	return summary, nil
+}
+
+// recoverAnalysis re-queries the text analyzer for one timed-out request and acts on the answer
+func (w *Worker) recoverAnalysis(ctx context.Context, req *storage.Request) (string, error) {
+	jobID, _ := req.Metadata["textanalyzer_job_id"].(string)
+	if jobID == "" {
+		jobID = req.TextAnalyzerUUID
+	}
+
+	var result *clients.AnalysisJobResult
+	var err error
+	if jobID != "" {
+		result, err = w.textAnalyzerClient.GetAnalysisResult(ctx, jobID)
+	} else {
+		err = clients.ErrAnalysisJobNotFound
+	}
+
+	outcome := recoveryAction(result, err)
+	switch outcome {
+	case recoveryRecovered:
+		err = w.applyAnalysisResult(ctx, req.ID, jobID, result)
+	case recoveryRequeued:
+		err = w.requeueAnalysis(ctx, req)
+	}
+	if err != nil {
+		outcome = recoveryFailed
+	}
+	if outcome == recoveryPending || outcome == recoveryFailed {
+		// Move the request to the back of the sweep order so others get a turn
+		w.markRecoveryChecked(req)
+	}
+
+	return outcome, err
+}
+
+// recoveryAction decides what to do with a timed-out request from the analyzer's answer
+func recoveryAction(result *clients.AnalysisJobResult, err error) string {
+	switch {
+	case errors.Is(err, clients.ErrAnalysisJobNotFound):
+		return recoveryRequeued
+	case err != nil:
+		return recoveryFailed
+	case result.Status == "completed" && result.Analysis != nil && result.Analysis.Metadata != nil:
+		return recoveryRecovered
+	default:
+		return recoveryPending
+	}
+}
+
+// requeueAnalysis enqueues a fresh text analysis from the request's stored scrape content
+// and schedules its retrieval, restarting the timeout clock
+func (w *Worker) requeueAnalysis(ctx context.Context, req *storage.Request) error {
+	content, rawText := storedAnalysisInput(req.Metadata)
+	if content == "" {
+		return fmt.Errorf("no stored content to re-analyze")
+	}
+
+	compressedRawText := ""
+	if rawText != "" {
+		compressed, err := pipeline.CompressHTML(rawText)
+		if err != nil {
+			w.logger.Warn("failed to compress raw text", "request_id", req.ID, "error", err)
+		} else {
+			compressedRawText = compressed
+		}
+	}
+
+	jobID, err := w.textAnalyzerClient.EnqueueAnalysis(ctx, content, compressedRawText, nil)
+	if err != nil {
+		return fmt.Errorf("failed to enqueue analysis: %w", err)
+	}
+
+	req.Metadata["textanalyzer_job_id"] = jobID
+	storage.SetAnalysisStatus(req.Metadata, storage.AnalysisStatusQueued, time.Now())
+	clearAnalysisTimeout(req.Metadata)
+	if err := w.storage.UpdateRequestMetadataAs(req.ID, req.Metadata, storage.AuditActorWorker); err != nil {
+		return err
+	}
+	if err := w.storage.UpdateTextAnalyzerUUID(req.ID, jobID); err != nil {
+		return err
+	}
+
+	if w.queueClient != nil {
+		if _, err := w.queueClient.EnqueueRetrieveAnalysis(ctx, req.ID, jobID, 0); err != nil {
+			return fmt.Errorf("failed to enqueue analysis retrieval: %w", err)
+		}
+	}
+
+	w.logger.Info("re-enqueued text analysis for timed-out request",
+		"request_id", req.ID,
+		"analysis_job_id", jobID,
+	)
+	return nil
+}
+
+// markRecoveryChecked records when the sweep last looked at a request
+func (w *Worker) markRecoveryChecked(req *storage.Request) {
+	req.Metadata["analysis_recovery_checked_at"] = time.Now().UTC().Format(time.RFC3339)
+	if err := w.storage.UpdateRequestMetadataAs(req.ID, req.Metadata, storage.AuditActorWorker); err != nil {
+		w.logger.Warn("failed to record analysis recovery check", "request_id", req.ID, "error", err)
+	}
+}
+
+// storedAnalysisInput returns the scraped content and raw text originally sent to the analyzer
+func storedAnalysisInput(metadata map[string]interface{}) (content, rawText string) {
+	scraperMetadata, ok := metadata["scraper_metadata"].(map[string]interface{})
+	if !ok {
+		return "", ""
+	}
+	content, _ = scraperMetadata["content"].(string)
+	rawText, _ = scraperMetadata["raw_text"].(string)
+	return content, rawText
+}
+
+// clearAnalysisTimeout removes the markers left when analysis retrieval gave up
+func clearAnalysisTimeout(metadata map[string]interface{}) {
+	delete(metadata, "analysis_retrieval_timeout")
+	delete(metadata, "analysis_retrieval_elapsed_minutes")
+	delete(metadata, "analysis_recovery_checked_at")
+}
@@ -0,0 +1,68 @@
+package queue
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/docutag/controller/internal/clients"
+)
+
+func TestRecoveryAction(t *testing.T) {
+	completed := &clients.AnalysisJobResult{}
+	if err := json.Unmarshal([]byte(`{"status":"completed","analysis":{"metadata":{"tags":["go"]}}}`), completed); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		result *clients.AnalysisJobResult
+		err    error
+		want   string
+	}{
+		{"completed result is applied", completed, nil, recoveryRecovered},
+		{"completed without analysis stays pending", &clients.AnalysisJobResult{Status: "completed"}, nil, recoveryPending},
+		{"still processing", &clients.AnalysisJobResult{Status: "processing"}, nil, recoveryPending},
+		{"unknown job is requeued", nil, fmt.Errorf("lookup: %w", clients.ErrAnalysisJobNotFound), recoveryRequeued},
+		{"analyzer unreachable", nil, errors.New("connection refused"), recoveryFailed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := recoveryAction(tt.result, tt.err); got != tt.want {
+				t.Errorf("recoveryAction() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStoredAnalysisInput(t *testing.T) {
+	metadata := map[string]interface{}{
+		"scraper_metadata": map[string]interface{}{
+			"content":  "Article body",
+			"raw_text": "<p>Article body</p>",
+		},
+	}
+	content, rawText := storedAnalysisInput(metadata)
+	if content != "Article body" || rawText != "<p>Article body</p>" {
+		t.Errorf("unexpected input: %q, %q", content, rawText)
+	}
+
+	if content, _ := storedAnalysisInput(map[string]interface{}{}); content != "" {
+		t.Errorf("expected no content without scraper metadata, got %q", content)
+	}
+}
+
+func TestClearAnalysisTimeout(t *testing.T) {
+	metadata := map[string]interface{}{
+		"analysis_retrieval_timeout":         true,
+		"analysis_retrieval_elapsed_minutes": 61,
+		"analysis_recovery_checked_at":       "2025-01-01T00:00:00Z",
+		"textanalyzer_job_id":                "ta-1",
+	}
+	clearAnalysisTimeout(metadata)
+
+	if len(metadata) != 1 || metadata["textanalyzer_job_id"] != "ta-1" {
+		t.Errorf("expected only the timeout markers to be removed, got %v", metadata)
+	}
+}
@@ -0,0 +1,159 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/docutag/controller/internal/clients"
+	"github.com/docutag/controller/internal/settings"
+	"github.com/docutag/controller/internal/storage"
+	"github.com/hibiken/asynq"
+)
+
+// fakeAnalyzer serves /api/jobs/{id} with whatever status the test last set for the job
+type fakeAnalyzer struct {
+	mu       sync.Mutex
+	statuses map[string]string
+}
+
+func (f *fakeAnalyzer) set(jobID, status string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.statuses[jobID] = status
+}
+
+func (f *fakeAnalyzer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	status := f.statuses[r.PathValue("id")]
+	f.mu.Unlock()
+
+	result := map[string]interface{}{"job_id": r.PathValue("id"), "status": status}
+	switch status {
+	case "completed":
+		result["analysis"] = map[string]interface{}{
+			"id":       "analysis-1",
+			"metadata": map[string]interface{}{"quality_score": map[string]interface{}{"score": 0.9}},
+		}
+	case "failed":
+		result["message"] = "model unavailable"
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// setupRetrievalWorker returns a worker wired to test storage and a fake text analyzer
+func setupRetrievalWorker(t *testing.T) (*Worker, *fakeAnalyzer) {
+	t.Helper()
+	store := setupTestStorage(t)
+
+	analyzer := &fakeAnalyzer{statuses: map[string]string{}}
+	mux := http.NewServeMux()
+	mux.Handle("GET /api/jobs/{id}", analyzer)
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	return &Worker{
+		storage:                store,
+		textAnalyzerClient:     clients.NewTextAnalyzerClient(server.URL),
+		settings:               settings.New(settings.Default()),
+		logger:                 slog.Default(),
+		maxAnalysisWaitMinutes: 60,
+	}, analyzer
+}
+
+// saveQueuedRequest stores a request whose analysis the worker has just enqueued
+func saveQueuedRequest(t *testing.T, store *storage.Storage, id, jobID string) {
+	t.Helper()
+	metadata := map[string]interface{}{"textanalyzer_job_id": jobID}
+	storage.SetAnalysisStatus(metadata, storage.AnalysisStatusQueued, time.Now())
+	if err := store.SaveRequest(&storage.Request{ID: id, CreatedAt: time.Now(), SourceType: "url", Metadata: metadata}); err != nil {
+		t.Fatalf("Failed to save request: %v", err)
+	}
+}
+
+// retrieveTask builds a retrieval task for an analysis enqueued at enqueuedAt
+func retrieveTask(t *testing.T, requestID, jobID string, attempt int, enqueuedAt time.Time) *asynq.Task {
+	t.Helper()
+	payload, err := json.Marshal(RetrieveAnalysisTaskPayload{
+		RequestID:     requestID,
+		AnalysisJobID: jobID,
+		AttemptCount:  attempt,
+		EnqueuedAt:    enqueuedAt.UnixNano(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return asynq.NewTask(TypeRetrieveAnalysis, payload)
+}
+
+// analysisState returns the stored analysis status and completion time of a request
+func analysisState(t *testing.T, store *storage.Storage, id string) (status, completedAt string) {
+	t.Helper()
+	req, err := store.GetRequest(id)
+	if err != nil {
+		t.Fatalf("Failed to get request: %v", err)
+	}
+	status, _ = req.Metadata[storage.MetaAnalysisStatus].(string)
+	completedAt, _ = req.Metadata[storage.MetaAnalysisCompletedAt].(string)
+	return status, completedAt
+}
+
+func TestHandleRetrieveAnalysisStatusTransitions(t *testing.T) {
+	w, analyzer := setupRetrievalWorker(t)
+	ctx := context.Background()
+	enqueuedAt := time.Now()
+
+	saveQueuedRequest(t, w.storage, "req-ok", "ta-ok")
+	if status, completedAt := analysisState(t, w.storage, "req-ok"); status != storage.AnalysisStatusQueued || completedAt != "" {
+		t.Fatalf("Expected a fresh request to be queued without a completion time, got %q, %q", status, completedAt)
+	}
+
+	// Each attempt that finds the analyzer still busy retries, leaving the request processing
+	for attempt, analyzerStatus := range []string{"queued", "processing"} {
+		analyzer.set("ta-ok", analyzerStatus)
+		if err := w.handleRetrieveAnalysis(ctx, retrieveTask(t, "req-ok", "ta-ok", attempt+1, enqueuedAt)); err == nil {
+			t.Fatalf("Expected a retry while the analyzer reports %s", analyzerStatus)
+		}
+		if status, completedAt := analysisState(t, w.storage, "req-ok"); status != storage.AnalysisStatusProcessing || completedAt != "" {
+			t.Errorf("Attempt %d: expected processing without a completion time, got %q, %q", attempt+1, status, completedAt)
+		}
+	}
+
+	analyzer.set("ta-ok", "completed")
+	if err := w.handleRetrieveAnalysis(ctx, retrieveTask(t, "req-ok", "ta-ok", 3, enqueuedAt)); err != nil {
+		t.Fatalf("Expected the completed analysis to be applied, got %v", err)
+	}
+	if status, completedAt := analysisState(t, w.storage, "req-ok"); status != storage.AnalysisStatusCompleted || completedAt == "" {
+		t.Errorf("Expected completed with a completion time, got %q, %q", status, completedAt)
+	}
+
+	// A failed analysis is final and stops retrying
+	saveQueuedRequest(t, w.storage, "req-failed", "ta-failed")
+	analyzer.set("ta-failed", "failed")
+	if err := w.handleRetrieveAnalysis(ctx, retrieveTask(t, "req-failed", "ta-failed", 1, enqueuedAt)); err != nil {
+		t.Fatalf("Expected a failed analysis to stop retrying, got %v", err)
+	}
+	if status, completedAt := analysisState(t, w.storage, "req-failed"); status != storage.AnalysisStatusFailed || completedAt == "" {
+		t.Errorf("Expected failed with a completion time, got %q, %q", status, completedAt)
+	}
+
+	// Retrieval that runs past the wait limit times out without asking the analyzer
+	saveQueuedRequest(t, w.storage, "req-slow", "ta-slow")
+	longAgo := enqueuedAt.Add(-time.Duration(w.maxAnalysisWaitMinutes+1) * time.Minute)
+	if err := w.handleRetrieveAnalysis(ctx, retrieveTask(t, "req-slow", "ta-slow", 40, longAgo)); err != nil {
+		t.Fatalf("Expected a timed out retrieval to stop retrying, got %v", err)
+	}
+	if status, completedAt := analysisState(t, w.storage, "req-slow"); status != storage.AnalysisStatusTimedOut || completedAt == "" {
+		t.Errorf("Expected timed_out with a completion time, got %q, %q", status, completedAt)
+	}
+	req, _ := w.storage.GetRequest("req-slow")
+	if timedOut, _ := req.Metadata["analysis_retrieval_timeout"].(bool); !timedOut {
+		t.Error("Expected the timeout flag the recovery sweep looks for")
+	}
+}
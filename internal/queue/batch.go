@@ -0,0 +1,50 @@
+package queue
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// batchEnqueueConcurrency bounds the Redis round trips a batch has in flight at once.
+// Asynq has no pipelined enqueue, so a batch overlaps its enqueues instead.
+const batchEnqueueConcurrency = 16
+
+// ScrapeEnqueueSpec describes one scrape task of a batch
+type ScrapeEnqueueSpec struct {
+	JobID        string
+	URL          string
+	ExtractLinks bool
+	ParentJobID  *string
+	Depth        int
+	Delay        time.Duration // Runnable after this delay; increase it per item to spread load on the scraper
+}
+
+// ScrapeEnqueueResult is the outcome of enqueueing one spec of a batch
+type ScrapeEnqueueResult struct {
+	TaskID string
+	Err    error // Includes a *DuplicateTaskError when another job holds the URL
+}
+
+// EnqueueScrapeBatch enqueues every spec as EnqueueScrapeWithParentIn would, with up to
+// batchEnqueueConcurrency enqueues in flight. Results are in the order of specs; one
+// failed item does not stop the others.
+func (c *Client) EnqueueScrapeBatch(ctx context.Context, specs []ScrapeEnqueueSpec) []ScrapeEnqueueResult {
+	results := make([]ScrapeEnqueueResult, len(specs))
+	sem := make(chan struct{}, batchEnqueueConcurrency)
+	var wg sync.WaitGroup
+	for i, spec := range specs {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			taskID, err := c.EnqueueScrapeWithParentIn(ctx, spec.JobID, spec.URL, spec.ExtractLinks, spec.ParentJobID, spec.Depth, spec.Delay)
+			results[i] = ScrapeEnqueueResult{TaskID: taskID, Err: err}
+		}()
+	}
+	wg.Wait()
+	return results
+}
@@ -0,0 +1,160 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/hibiken/asynq"
+)
+
+func TestEnqueueScrapeBatch(t *testing.T) {
+	client, mr := setupUniqueClient(t, 10*time.Minute)
+	inspector := asynq.NewInspector(asynq.RedisClientOpt{Addr: mr.Addr()})
+	defer inspector.Close()
+
+	// One page is already queued by another job before the batch runs
+	if _, err := client.EnqueueScrape(context.Background(), "earlier-job", "https://example.com/page-3", false); err != nil {
+		t.Fatalf("enqueue failed: %v", err)
+	}
+
+	parentID := "parent"
+	specs := make([]ScrapeEnqueueSpec, 40)
+	for i := range specs {
+		specs[i] = ScrapeEnqueueSpec{
+			JobID:       fmt.Sprintf("job-%02d", i),
+			URL:         fmt.Sprintf("https://example.com/page-%d", i),
+			ParentJobID: &parentID,
+			Depth:       1,
+			Delay:       time.Duration(i) * time.Minute,
+		}
+	}
+
+	start := time.Now()
+	results := client.EnqueueScrapeBatch(context.Background(), specs)
+	if len(results) != len(specs) {
+		t.Fatalf("expected %d results, got %d", len(specs), len(results))
+	}
+
+	for i, result := range results {
+		if i == 3 {
+			var duplicate *DuplicateTaskError
+			if !errors.As(result.Err, &duplicate) || duplicate.JobID != "earlier-job" || result.TaskID != "" {
+				t.Errorf("expected item 3 to report the earlier job, got %+v", result)
+			}
+			continue
+		}
+		if result.Err != nil || result.TaskID != specs[i].JobID {
+			t.Errorf("item %d: expected task %s, got %+v", i, specs[i].JobID, result)
+			continue
+		}
+
+		info, err := inspector.GetTaskInfo("scrape", result.TaskID)
+		if err != nil {
+			t.Fatalf("failed to inspect task %s: %v", result.TaskID, err)
+		}
+		if i == 0 {
+			if info.State != asynq.TaskStatePending {
+				t.Errorf("expected the first item to run at once, got %s", info.State)
+			}
+			continue
+		}
+		// Each item keeps its own delay, so the batch still spreads load on the scraper
+		wantAt := start.Add(specs[i].Delay)
+		if info.State != asynq.TaskStateScheduled || info.NextProcessAt.Before(wantAt.Add(-time.Second)) || info.NextProcessAt.After(wantAt.Add(5*time.Second)) {
+			t.Errorf("item %d: expected to be scheduled at about %s, got %s at %s", i, wantAt, info.State, info.NextProcessAt)
+		}
+	}
+}
+
+func BenchmarkEnqueueScrape(b *testing.B) {
+	const links = 200
+	enqueueAll := map[string]func(c *Client, specs []ScrapeEnqueueSpec){
+		"sequential": func(c *Client, specs []ScrapeEnqueueSpec) {
+			for _, spec := range specs {
+				c.EnqueueScrapeWithParentIn(context.Background(), spec.JobID, spec.URL, false, spec.ParentJobID, spec.Depth, 0)
+			}
+		},
+		"batch": func(c *Client, specs []ScrapeEnqueueSpec) {
+			c.EnqueueScrapeBatch(context.Background(), specs)
+		},
+	}
+
+	for _, name := range []string{"sequential", "batch"} {
+		b.Run(name, func(b *testing.B) {
+			mr, err := miniredis.Run()
+			if err != nil {
+				b.Fatalf("Failed to create miniredis: %v", err)
+			}
+			defer mr.Close()
+			// Simulate the round trip to a Redis that is not on the same host
+			addr := latencyProxy(b, mr.Addr(), time.Millisecond)
+			client := NewClient(ClientConfig{RedisAddr: addr, UniqueWindow: 10 * time.Minute})
+			defer client.Close()
+
+			parentID := "parent"
+			for n := 0; n < b.N; n++ {
+				specs := make([]ScrapeEnqueueSpec, links)
+				for i := range specs {
+					specs[i] = ScrapeEnqueueSpec{
+						JobID:       fmt.Sprintf("%s-%d-%d", name, n, i),
+						URL:         fmt.Sprintf("https://example.com/%s/%d/%d", name, n, i),
+						ParentJobID: &parentID,
+						Depth:       1,
+					}
+				}
+				enqueueAll[name](client, specs)
+			}
+		})
+	}
+}
+
+// latencyProxy forwards TCP connections to target, delaying every request by latency
+func latencyProxy(tb testing.TB, target string, latency time.Duration) string {
+	tb.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		tb.Fatalf("Failed to listen: %v", err)
+	}
+	tb.Cleanup(func() { ln.Close() })
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			upstream, err := net.Dial("tcp", target)
+			if err != nil {
+				conn.Close()
+				continue
+			}
+			go func() {
+				defer upstream.Close()
+				buf := make([]byte, 32*1024)
+				for {
+					n, err := conn.Read(buf)
+					if n > 0 {
+						time.Sleep(latency)
+						if _, err := upstream.Write(buf[:n]); err != nil {
+							return
+						}
+					}
+					if err != nil {
+						return
+					}
+				}
+			}()
+			go func() {
+				defer conn.Close()
+				io.Copy(conn, upstream)
+			}()
+		}
+	}()
+	return ln.Addr().String()
+}
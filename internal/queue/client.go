@@ -6,7 +6,9 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/docutag/controller/internal/storage"
 	"github.com/hibiken/asynq"
+	"github.com/redis/go-redis/v9"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/trace"
 )
@@ -18,14 +20,33 @@ const (
 	TypeRetrieveAnalysis = "retrieve:analysis"
 )
 
+// Queue name constants
+const (
+	QueueScrape            = "scrape"
+	QueueAnalysisRetrieval = "analysis-retrieval"
+	QueueLinkExtraction    = "link-extraction"
+)
+
+// queuePriorities are the queues the worker serves: higher value = higher priority
+var queuePriorities = map[string]int{
+	QueueScrape:            6, // URL scraping tasks (highest priority)
+	QueueAnalysisRetrieval: 4, // Text analysis result retrieval (medium priority)
+	QueueLinkExtraction:    3, // Link extraction and processing (lower priority)
+}
+
 // ScrapeTaskPayload represents the payload for a scrape task
 type ScrapeTaskPayload struct {
-	JobID        string  `json:"job_id"`
-	URL          string  `json:"url"`
-	ExtractLinks bool    `json:"extract_links"`
-	ParentJobID  *string `json:"parent_job_id,omitempty"`
-	Depth        int     `json:"depth"`
-	RequestID    string  `json:"request_id,omitempty"` // Optional: for SSE events to user
+	JobID        string               `json:"job_id"`
+	URL          string               `json:"url"`
+	ExtractLinks bool                 `json:"extract_links"`
+	ParentJobID  *string              `json:"parent_job_id,omitempty"`
+	Depth        int                  `json:"depth"`
+	RequestID    string               `json:"request_id,omitempty"`    // Optional: for SSE events to user
+	CreatedBy    string               `json:"created_by,omitempty"`    // Originator, inherited by child crawl jobs
+	Namespace    string               `json:"namespace,omitempty"`     // Namespace the job and its document belong to, inherited by child crawl jobs
+	RootJobID    string               `json:"root_job_id,omitempty"`   // First job of the crawl; empty for the root itself
+	CrawlParams  *storage.CrawlParams `json:"crawl_params,omitempty"`  // Crawl settings fixed at submission; nil on tasks queued before they were recorded
+	SkipAnalysis bool                 `json:"skip_analysis,omitempty"` // Store the document without text analysis, inherited by child crawl jobs
 	// Tracing and timing fields
 	TraceID    string `json:"trace_id,omitempty"`
 	SpanID     string `json:"span_id,omitempty"`
@@ -34,10 +55,15 @@ type ScrapeTaskPayload struct {
 
 // ExtractLinksTaskPayload represents the payload for a link extraction task
 type ExtractLinksTaskPayload struct {
-	ParentJobID string `json:"parent_job_id"`
-	SourceURL   string `json:"source_url"`
-	ParentDepth int    `json:"parent_depth"`
-	RequestID   string `json:"request_id,omitempty"` // Optional: for SSE events to user
+	ParentJobID  string               `json:"parent_job_id"`
+	SourceURL    string               `json:"source_url"`
+	ParentDepth  int                  `json:"parent_depth"`
+	RequestID    string               `json:"request_id,omitempty"`    // Optional: for SSE events to user
+	CreatedBy    string               `json:"created_by,omitempty"`    // Originator of the parent job
+	Namespace    string               `json:"namespace,omitempty"`     // Namespace of the parent job
+	RootJobID    string               `json:"root_job_id,omitempty"`   // Crawl whose budget new children count against; empty when the parent is the root
+	CrawlParams  *storage.CrawlParams `json:"crawl_params,omitempty"`  // Crawl settings the children are queued with
+	SkipAnalysis bool                 `json:"skip_analysis,omitempty"` // Children are stored without text analysis
 	// Tracing and timing fields
 	TraceID    string `json:"trace_id,omitempty"`
 	SpanID     string `json:"span_id,omitempty"`
@@ -57,28 +83,36 @@ type RetrieveAnalysisTaskPayload struct {
 
 // Client wraps the Asynq client for enqueueing tasks
 type Client struct {
-	client *asynq.Client
-	tracer trace.Tracer
+	client       *asynq.Client
+	rdb          redis.UniversalClient // Shared with the Asynq client; also holds URL claims
+	uniqueWindow time.Duration         // How long a URL stays claimed by the job that enqueued it
+	tracer       trace.Tracer
 }
 
 // ClientConfig contains configuration for the queue client
 type ClientConfig struct {
-	RedisAddr string
+	RedisAddr    string
+	UniqueWindow time.Duration // Refuse a second scrape of the same normalized URL within this window (0 disables)
 }
 
 // NewClient creates a new queue client
 func NewClient(cfg ClientConfig) *Client {
-	redisOpt := asynq.RedisClientOpt{
+	rdb := redis.NewClient(&redis.Options{
 		Addr: cfg.RedisAddr,
-	}
-
-	client := asynq.NewClient(redisOpt)
+	})
 
 	return &Client{
-		client: client,
+		client:       asynq.NewClientFromRedisClient(rdb),
+		rdb:          rdb,
+		uniqueWindow: cfg.UniqueWindow,
 	}
 }
 
+// Ping checks that Redis answers
+func (c *Client) Ping(ctx context.Context) error {
+	return c.rdb.Ping(ctx).Err()
+}
+
 // EnqueueScrape enqueues a scrape job to the queue
 func (c *Client) EnqueueScrape(ctx context.Context, jobID, url string, extractLinks bool) (string, error) {
 	return c.EnqueueScrapeWithParent(ctx, jobID, url, extractLinks, nil, 0)
@@ -86,6 +120,19 @@ func (c *Client) EnqueueScrape(ctx context.Context, jobID, url string, extractLi
 
 // EnqueueScrapeWithParent enqueues a scrape job with parent and depth tracking
 func (c *Client) EnqueueScrapeWithParent(ctx context.Context, jobID, url string, extractLinks bool, parentJobID *string, depth int) (string, error) {
+	return c.EnqueueScrapeWithParentIn(ctx, jobID, url, extractLinks, parentJobID, depth, 0)
+}
+
+// EnqueueScrapeWithParentIn enqueues a child scrape job that becomes runnable after delay.
+// Bulk ingests use increasing delays to spread load on the scraper.
+//
+// Only one job may enqueue a given normalized URL (with the same extract_links) per unique
+// window; others get a *DuplicateTaskError naming the job that did.
+func (c *Client) EnqueueScrapeWithParentIn(ctx context.Context, jobID, url string, extractLinks bool, parentJobID *string, depth int, delay time.Duration) (string, error) {
+	if err := c.claimURL(ctx, jobID, url, extractLinks); err != nil {
+		return "", err
+	}
+
 	// Create task payload with trace context
 	payload := ScrapeTaskPayload{
 		JobID:        jobID,
@@ -93,6 +140,11 @@ func (c *Client) EnqueueScrapeWithParent(ctx context.Context, jobID, url string,
 		ExtractLinks: extractLinks,
 		ParentJobID:  parentJobID,
 		Depth:        depth,
+		CreatedBy:    CreatedBy(ctx),
+		Namespace:    Namespace(ctx),
+		RootJobID:    RootJobID(ctx),
+		CrawlParams:  CrawlParamsFrom(ctx),
+		SkipAnalysis: SkipAnalysis(ctx),
 		EnqueuedAt:   time.Now().UnixNano(), // Record enqueue time for queue wait metrics
 	}
 
@@ -125,14 +177,17 @@ func (c *Client) EnqueueScrapeWithParent(ctx context.Context, jobID, url string,
 		asynq.TaskID(jobID),                   // Use job ID as task ID for correlation
 		asynq.MaxRetry(12),                    // Max 12 retries over 24 hours
 		asynq.Timeout(3 * time.Hour),          // 3 hour timeout per task (handles service overload scenarios)
-		asynq.Queue("scrape"),                 // Scrape queue (high priority)
+		asynq.Queue(scrapeQueue(ctx)),         // Scrape queue (high priority)
 		asynq.Retention(7 * 24 * time.Hour),   // Keep completed tasks for 7 days
-		asynq.Unique(time.Minute),             // Prevent duplicate tasks within 1 minute
+	}
+	if delay > 0 {
+		opts = append(opts, asynq.ProcessIn(delay))
 	}
 
 	// Enqueue the task
 	info, err := c.client.Enqueue(task, opts...)
 	if err != nil {
+		c.releaseURL(ctx, jobID, url, extractLinks)
 		return "", fmt.Errorf("failed to enqueue task: %w", err)
 	}
 
@@ -145,6 +200,11 @@ func (c *Client) EnqueueScrapeWithDelay(ctx context.Context, jobID, url string,
 		JobID:        jobID,
 		URL:          url,
 		ExtractLinks: extractLinks,
+		CreatedBy:    CreatedBy(ctx),
+		Namespace:    Namespace(ctx),
+		RootJobID:    RootJobID(ctx),
+		CrawlParams:  CrawlParamsFrom(ctx),
+		SkipAnalysis: SkipAnalysis(ctx),
 		EnqueuedAt:   time.Now().UnixNano(),
 	}
 
@@ -166,7 +226,7 @@ func (c *Client) EnqueueScrapeWithDelay(ctx context.Context, jobID, url string,
 		asynq.ProcessIn(delay),              // Delay execution
 		asynq.MaxRetry(12),                  // Max 12 retries over 24 hours
 		asynq.Timeout(3 * time.Hour),        // 3 hour timeout per task
-		asynq.Queue("scrape"),               // Scrape queue (high priority)
+		asynq.Queue(scrapeQueue(ctx)),       // Scrape queue (high priority)
 	}
 
 	info, err := c.client.Enqueue(task, opts...)
@@ -180,11 +240,16 @@ func (c *Client) EnqueueScrapeWithDelay(ctx context.Context, jobID, url string,
 // EnqueueExtractLinks enqueues a link extraction task
 func (c *Client) EnqueueExtractLinks(ctx context.Context, parentJobID, sourceURL string, parentDepth int, requestID string) (string, error) {
 	payload := ExtractLinksTaskPayload{
-		ParentJobID: parentJobID,
-		SourceURL:   sourceURL,
-		ParentDepth: parentDepth,
-		RequestID:   requestID,
-		EnqueuedAt:  time.Now().UnixNano(),
+		ParentJobID:  parentJobID,
+		SourceURL:    sourceURL,
+		ParentDepth:  parentDepth,
+		RequestID:    requestID,
+		CreatedBy:    CreatedBy(ctx),
+		Namespace:    Namespace(ctx),
+		RootJobID:    RootJobID(ctx),
+		CrawlParams:  CrawlParamsFrom(ctx),
+		SkipAnalysis: SkipAnalysis(ctx),
+		EnqueuedAt:   time.Now().UnixNano(),
 	}
 
 	// Add tracing context if available
@@ -211,7 +276,7 @@ func (c *Client) EnqueueExtractLinks(ctx context.Context, parentJobID, sourceURL
 	opts := []asynq.Option{
 		asynq.MaxRetry(12),                 // Max 12 retries over 24 hours
 		asynq.Timeout(1 * time.Hour),       // 1 hour timeout for link extraction
-		asynq.Queue("link-extraction"),     // Link extraction queue (lower priority)
+		asynq.Queue(QueueLinkExtraction),   // Link extraction queue (lower priority)
 		asynq.ProcessIn(1 * time.Second),   // Small delay to ensure parent task fully completes
 	}
 
@@ -284,7 +349,7 @@ func (c *Client) EnqueueRetrieveAnalysis(ctx context.Context, requestID, analysi
 		asynq.ProcessIn(delay),              // Delay for exponential backoff
 		asynq.MaxRetry(12),                  // Max 12 retries over 24 hours
 		asynq.Timeout(3 * time.Hour),        // 3 hour timeout - includes waiting for AI processing (Ollama)
-		asynq.Queue("analysis-retrieval"),   // Analysis retrieval queue (medium priority)
+		asynq.Queue(QueueAnalysisRetrieval), // Analysis retrieval queue (medium priority)
 		asynq.Retention(7 * 24 * time.Hour), // Keep completed tasks for 7 days
 	}
 
@@ -298,5 +363,5 @@ func (c *Client) EnqueueRetrieveAnalysis(ctx context.Context, requestID, analysi
 
 // Close closes the client connection
 func (c *Client) Close() error {
-	return c.client.Close()
+	return c.rdb.Close()
 }
@@ -0,0 +1,26 @@
+package queue
+
+import "context"
+
+type rootJobIDKey struct{}
+
+// WithRootJobID returns a context whose enqueued tasks belong to the crawl started by rootJobID
+func WithRootJobID(ctx context.Context, rootJobID string) context.Context {
+	return context.WithValue(ctx, rootJobIDKey{}, rootJobID)
+}
+
+// RootJobID returns the crawl root stored by WithRootJobID, or "" if there is none
+func RootJobID(ctx context.Context) string {
+	rootJobID, _ := ctx.Value(rootJobIDKey{}).(string)
+	return rootJobID
+}
+
+// crawlRootJobID is the root whose budget links extracted from parentJobID count against.
+// Without a root in the context the parent started the crawl, as do tasks queued before
+// crawl budgets existed.
+func crawlRootJobID(ctx context.Context, parentJobID string) string {
+	if rootJobID := RootJobID(ctx); rootJobID != "" {
+		return rootJobID
+	}
+	return parentJobID
+}
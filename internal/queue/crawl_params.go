@@ -0,0 +1,51 @@
+package queue
+
+import (
+	"context"
+
+	"github.com/docutag/controller/internal/settings"
+	"github.com/docutag/controller/internal/storage"
+)
+
+type crawlParamsKey struct{}
+
+// NewCrawlParams snapshots the crawl settings a new crawl should run with
+func NewCrawlParams(values settings.Values) *storage.CrawlParams {
+	return &storage.CrawlParams{
+		MaxDepth:           values.MaxLinkDepth,
+		LinkScoreThreshold: values.LinkScoreThreshold,
+		Queue:              QueueScrape,
+	}
+}
+
+// WithCrawlParams returns a context whose enqueued tasks carry params, so every
+// descendant of the crawl runs with them
+func WithCrawlParams(ctx context.Context, params *storage.CrawlParams) context.Context {
+	return context.WithValue(ctx, crawlParamsKey{}, params)
+}
+
+// CrawlParamsFrom returns the params stored by WithCrawlParams, or nil if there are none
+func CrawlParamsFrom(ctx context.Context) *storage.CrawlParams {
+	params, _ := ctx.Value(crawlParamsKey{}).(*storage.CrawlParams)
+	return params
+}
+
+// scrapeQueue is the queue a scrape task enqueued under ctx runs on. Only queues the
+// worker serves are honoured, so a stale payload cannot strand its children.
+func scrapeQueue(ctx context.Context) string {
+	if params := CrawlParamsFrom(ctx); params != nil {
+		if _, ok := queuePriorities[params.Queue]; ok {
+			return params.Queue
+		}
+	}
+	return QueueScrape
+}
+
+// crawlParams returns the params the task behind ctx runs with: those it was enqueued
+// with, or for tasks queued before params were recorded, a snapshot of the current settings
+func (w *Worker) crawlParams(ctx context.Context) *storage.CrawlParams {
+	if params := CrawlParamsFrom(ctx); params != nil {
+		return params
+	}
+	return NewCrawlParams(w.settings.Get())
+}
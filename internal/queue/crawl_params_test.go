@@ -0,0 +1,70 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/docutag/controller/internal/storage"
+	"github.com/hibiken/asynq"
+)
+
+func TestEnqueueCarriesCrawlParams(t *testing.T) {
+	client, mr := setupUniqueClient(t, time.Minute)
+	inspector := asynq.NewInspector(asynq.RedisClientOpt{Addr: mr.Addr()})
+	defer inspector.Close()
+
+	params := &storage.CrawlParams{MaxDepth: 4, LinkScoreThreshold: 0.25, Queue: QueueScrape}
+	ctx := WithCrawlParams(context.Background(), params)
+	if _, err := client.EnqueueScrape(ctx, "job-1", "https://example.com/a", true); err != nil {
+		t.Fatalf("enqueue failed: %v", err)
+	}
+
+	// A queue the worker does not serve would strand the task, so it falls back to scrape
+	stale := WithCrawlParams(context.Background(), &storage.CrawlParams{MaxDepth: 1, Queue: "retired"})
+	if _, err := client.EnqueueScrape(stale, "job-2", "https://example.com/b", true); err != nil {
+		t.Fatalf("enqueue failed: %v", err)
+	}
+
+	for jobID, want := range map[string]storage.CrawlParams{"job-1": *params, "job-2": {MaxDepth: 1, Queue: "retired"}} {
+		info, err := inspector.GetTaskInfo(QueueScrape, jobID)
+		if err != nil {
+			t.Fatalf("failed to inspect task %s: %v", jobID, err)
+		}
+		var payload ScrapeTaskPayload
+		if err := json.Unmarshal(info.Payload, &payload); err != nil {
+			t.Fatalf("failed to decode payload: %v", err)
+		}
+		if payload.CrawlParams == nil || *payload.CrawlParams != want {
+			t.Errorf("%s: expected params %+v in the payload, got %+v", jobID, want, payload.CrawlParams)
+		}
+	}
+}
+
+func TestEnqueueCarriesSkipAnalysis(t *testing.T) {
+	client, mr := setupUniqueClient(t, time.Minute)
+	inspector := asynq.NewInspector(asynq.RedisClientOpt{Addr: mr.Addr()})
+	defer inspector.Close()
+
+	if _, err := client.EnqueueScrape(WithSkipAnalysis(context.Background(), true), "job-archive", "https://example.com/a", false); err != nil {
+		t.Fatalf("enqueue failed: %v", err)
+	}
+	if _, err := client.EnqueueScrape(context.Background(), "job-plain", "https://example.com/b", false); err != nil {
+		t.Fatalf("enqueue failed: %v", err)
+	}
+
+	for jobID, want := range map[string]bool{"job-archive": true, "job-plain": false} {
+		info, err := inspector.GetTaskInfo(QueueScrape, jobID)
+		if err != nil {
+			t.Fatalf("failed to inspect task %s: %v", jobID, err)
+		}
+		var payload ScrapeTaskPayload
+		if err := json.Unmarshal(info.Payload, &payload); err != nil {
+			t.Fatalf("failed to decode payload: %v", err)
+		}
+		if payload.SkipAnalysis != want {
+			t.Errorf("%s: expected skip_analysis %v in the payload, got %v", jobID, want, payload.SkipAnalysis)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package queue
+
+import "testing"
+
+func TestContentHash(t *testing.T) {
+	base := contentHash("The quick brown fox jumps over the lazy dog.")
+
+	tests := []struct {
+		name    string
+		content string
+		same    bool
+	}{
+		{"identical", "The quick brown fox jumps over the lazy dog.", true},
+		{"extra whitespace", "  The quick   brown fox\n\njumps over\tthe lazy dog.  ", true},
+		{"different case", "THE QUICK BROWN FOX JUMPS OVER THE LAZY DOG.", true},
+		{"different content", "The quick brown fox jumps over the lazy cat.", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := contentHash(tt.content)
+			if (got == base) != tt.same {
+				t.Errorf("contentHash(%q) == base is %v, want %v", tt.content, got == base, tt.same)
+			}
+		})
+	}
+
+	if len(base) != 64 {
+		t.Errorf("Expected 64-character hex digest, got %d characters", len(base))
+	}
+
+	for _, empty := range []string{"", "   ", "\n\t"} {
+		if got := contentHash(empty); got != "" {
+			t.Errorf("Expected empty hash for blank content %q, got %q", empty, got)
+		}
+	}
+}
+
+func TestSameURL(t *testing.T) {
+	tests := []struct {
+		name     string
+		a, b     string
+		expected bool
+	}{
+		{"identical", "https://example.com/a", "https://example.com/a", true},
+		{"tracking params", "https://example.com/a?utm_source=x", "https://example.com/a", true},
+		{"fragment and host case", "https://EXAMPLE.com/a#top", "https://example.com/a", true},
+		{"different path", "https://example.com/a", "https://example.com/b", false},
+		{"different real param", "https://example.com/a?id=1", "https://example.com/a?id=2", false},
+		{"unparseable compared verbatim", "not a url", "not a url", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sameURL(tt.a, tt.b); got != tt.expected {
+				t.Errorf("sameURL(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,41 @@
+package queue
+
+import (
+	"testing"
+
+	"github.com/docutag/controller/internal/urlguard"
+)
+
+func TestLinkSkipReason(t *testing.T) {
+	w := &Worker{
+		domainPolicy: urlguard.NewDomainPolicy([]string{"*.example.com"}, []string{"ads.example.com"}),
+	}
+	seen := map[string]bool{}
+
+	tests := []struct {
+		name string
+		link string
+		want string
+	}{
+		{"allowed", "https://news.example.com/story", ""},
+		{"duplicate after normalization", "https://NEWS.example.com/story/?utm_source=x", skipReasonDuplicate},
+		{"image", "https://news.example.com/photo.jpg", skipReasonUnscrapable},
+		{"private target", "http://127.0.0.1/admin", skipReasonPrivateTarget},
+		{"denied domain", "https://ads.example.com/banner", skipReasonDomainDenied},
+		{"outside allowlist", "https://other.org/page", skipReasonDomainNotAllowed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := w.linkSkipReason(tt.link, seen); got != tt.want {
+				t.Errorf("linkSkipReason(%q) = %q, want %q", tt.link, got, tt.want)
+			}
+		})
+	}
+
+	// Without a policy every public domain is followed
+	open := &Worker{}
+	if got := open.linkSkipReason("https://other.org/page", map[string]bool{}); got != "" {
+		t.Errorf("linkSkipReason without policy = %q, want no skip", got)
+	}
+}
@@ -0,0 +1,144 @@
+package queue
+
+import (
+	"errors"
+	"log/slog"
+	"sync/atomic"
+	"time"
+
+	"github.com/docutag/controller/internal/promreg"
+	"github.com/docutag/controller/pkg/logging"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Reasons an extracted link is not queued, or a queued job is not scraped
+const (
+	skipReasonUnscrapable      = "unscrapable"
+	skipReasonPrivateTarget    = "private_target"
+	skipReasonDuplicate        = "duplicate"
+	skipReasonDomainDenied     = "domain_denied"
+	skipReasonDomainNotAllowed = "domain_not_allowed"
+	skipReasonRobotsTxt        = "robots_txt"
+	skipReasonBudgetExhausted  = "budget_exhausted"
+)
+
+// Terminal states of a scrape, the "outcome" label of controller_scrape_outcomes_total
+const (
+	ScrapeOutcomeCompleted      = "completed"
+	ScrapeOutcomeFailed         = "failed"
+	ScrapeOutcomeBelowThreshold = "below_threshold" // Stored tombstoned without scraping
+	ScrapeOutcomeCached         = "cached"          // Answered with the request a recent scrape stored
+	ScrapeOutcomeDuplicate      = "duplicate"       // Answered with a queued job or stored request for the same page
+)
+
+// Where a scrape ends, the "entry" label of controller_scrape_outcomes_total
+const (
+	ScrapeEntrySync   = "sync"   // POST /api/v1/scrape
+	ScrapeEntrySubmit = "submit" // POST /api/v1/scrape-requests, before a job is queued
+	ScrapeEntryWorker = "worker" // The scrape task
+)
+
+// queueMetrics are the queue's counters. Handlers record scrape outcomes and job creations
+// too, so they are shared by the package rather than owned by a Worker.
+type queueMetrics struct {
+	crawlLinksSkipped *prometheus.CounterVec // Extracted links dropped before queueing, by reason
+	scrapeJobsSkipped *prometheus.CounterVec // Queued jobs completed without scraping, by reason
+	scrapeJobsCreated *prometheus.CounterVec // Jobs by kind and by the source that created them
+	// Scrapes by where and how they ended, and by the link score bucket of the URL. Domains
+	// are left out to keep the series bounded; the top domains are in
+	// controller_scrape_outcomes_by_domain.
+	scrapeOutcomes  *prometheus.CounterVec
+	logLinesSampled *prometheus.CounterVec // Noisy log lines demoted to Debug by sampling, by line
+	taskTimeouts    *prometheus.CounterVec // Tasks abandoned at the task timeout, by task type
+}
+
+func newQueueMetrics() *queueMetrics {
+	return &queueMetrics{
+		crawlLinksSkipped: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "controller_crawl_links_skipped_total",
+				Help: "Extracted links that were not queued for scraping, by reason",
+			},
+			[]string{"reason"},
+		),
+		scrapeJobsSkipped: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "controller_scrape_jobs_skipped_total",
+				Help: "Scrape jobs completed without scraping, by reason",
+			},
+			[]string{"reason"},
+		),
+		scrapeJobsCreated: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "controller_scrape_jobs_created_total",
+				Help: "Scrape jobs created, by kind (parent, child or rescrape) and source (client, api_key, scheduler, worker:crawl, ...)",
+			},
+			[]string{"kind", "source"},
+		),
+		scrapeOutcomes: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "controller_scrape_outcomes_total",
+				Help: "Scrapes that reached a terminal state, by entry (sync, submit or worker), outcome (completed, failed, below_threshold, cached or duplicate) and score_bucket (0.0-0.2 to 0.8-1.0, or none)",
+			},
+			[]string{"entry", "outcome", "score_bucket"},
+		),
+		logLinesSampled: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "controller_log_lines_sampled_total",
+				Help: "Repetitive log lines logged at debug instead of info by log sampling, by line (child_queued, links_filtered or cache_hit)",
+			},
+			[]string{"line"},
+		),
+		taskTimeouts: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "controller_task_timeouts_total",
+				Help: "Tasks that exceeded the worker task timeout, by task type",
+			},
+			[]string{"task_type"},
+		),
+	}
+}
+
+// counters holds the collectors in use. Until RegisterMetrics is called they record into
+// collectors no registry exposes, so tests can read them without a registry.
+var counters atomic.Pointer[queueMetrics]
+
+func init() {
+	counters.Store(newQueueMetrics())
+}
+
+// RegisterMetrics registers the queue metrics with reg. Collectors that reg already has are
+// reused, so calling it again is safe. Log samplers created earlier keep counting into the
+// collectors they were created with.
+func RegisterMetrics(reg prometheus.Registerer) error {
+	m := newQueueMetrics()
+	var errs [6]error
+	m.crawlLinksSkipped, errs[0] = promreg.RegisterOrReuse(reg, m.crawlLinksSkipped)
+	m.scrapeJobsSkipped, errs[1] = promreg.RegisterOrReuse(reg, m.scrapeJobsSkipped)
+	m.scrapeJobsCreated, errs[2] = promreg.RegisterOrReuse(reg, m.scrapeJobsCreated)
+	m.scrapeOutcomes, errs[3] = promreg.RegisterOrReuse(reg, m.scrapeOutcomes)
+	m.logLinesSampled, errs[4] = promreg.RegisterOrReuse(reg, m.logLinesSampled)
+	m.taskTimeouts, errs[5] = promreg.RegisterOrReuse(reg, m.taskTimeouts)
+	counters.Store(m)
+	return errors.Join(errs[:]...)
+}
+
+// RecordScrapeOutcome counts a scrape that ended at entry with outcome. scoreBucket is
+// clients.ScoreBucket of the URL's link score, or clients.ScoreBucketNone when it has none.
+func RecordScrapeOutcome(entry, outcome, scoreBucket string) {
+	counters.Load().scrapeOutcomes.WithLabelValues(entry, outcome, scoreBucket).Inc()
+}
+
+// LogSampleWindow is how often sampled lines that do not belong to one crawl are summarized
+const LogSampleWindow = time.Minute
+
+// NewLogSampler returns a sampler for a noisy Info line that logs the first few occurrences,
+// then every `every`-th, and counts the rest in controller_log_lines_sampled_total under line
+func NewLogSampler(logger *slog.Logger, msg, line string, every int, window time.Duration) *logging.Sampler {
+	return logging.NewSampler(logger, msg, logging.SamplerOptions{
+		First:      logging.DefaultSampleFirst,
+		Every:      every,
+		Window:     window,
+		Suppressed: counters.Load().logLinesSampled.WithLabelValues(line),
+	})
+}
@@ -0,0 +1,57 @@
+package queue
+
+import (
+	"testing"
+
+	"github.com/docutag/controller/internal/clients"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestRecordScrapeOutcome(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	if err := RegisterMetrics(reg); err != nil {
+		t.Fatalf("Failed to register metrics: %v", err)
+	}
+	completed := counters.Load().scrapeOutcomes.WithLabelValues(ScrapeEntryWorker, ScrapeOutcomeCompleted, "0.8-1.0")
+	before := testutil.ToFloat64(completed)
+
+	RecordScrapeOutcome(ScrapeEntryWorker, ScrapeOutcomeCompleted, clients.ScoreBucket(0.9))
+	RecordScrapeOutcome(ScrapeEntrySync, ScrapeOutcomeBelowThreshold, clients.ScoreBucket(0.1))
+	RecordScrapeOutcome(ScrapeEntrySubmit, ScrapeOutcomeCached, clients.ScoreBucketNone)
+
+	if got := testutil.ToFloat64(completed) - before; got != 1 {
+		t.Errorf("Expected one completed scrape in the 0.8-1.0 bucket, got %v", got)
+	}
+
+	// Every series carries exactly the bounded labels
+	allowed := map[string]map[string]bool{
+		"entry":        {ScrapeEntrySync: true, ScrapeEntrySubmit: true, ScrapeEntryWorker: true},
+		"outcome":      {ScrapeOutcomeCompleted: true, ScrapeOutcomeFailed: true, ScrapeOutcomeBelowThreshold: true, ScrapeOutcomeCached: true, ScrapeOutcomeDuplicate: true},
+		"score_bucket": {"0.0-0.2": true, "0.2-0.4": true, "0.4-0.6": true, "0.6-0.8": true, "0.8-1.0": true, clients.ScoreBucketNone: true},
+	}
+	families, err := reg.Gather()
+	if err != nil {
+		t.Fatalf("Failed to gather: %v", err)
+	}
+	series := 0
+	for _, family := range families {
+		if family.GetName() != "controller_scrape_outcomes_total" {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			series++
+			if len(metric.GetLabel()) != len(allowed) {
+				t.Errorf("Expected labels %v, got %v", allowed, metric.GetLabel())
+			}
+			for _, l := range metric.GetLabel() {
+				if !allowed[l.GetName()][l.GetValue()] {
+					t.Errorf("Unexpected label %s=%q", l.GetName(), l.GetValue())
+				}
+			}
+		}
+	}
+	if series < 3 {
+		t.Errorf("Expected at least 3 outcome series, got %d", series)
+	}
+}
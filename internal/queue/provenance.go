@@ -0,0 +1,73 @@
+package queue
+
+import (
+	"context"
+	"strings"
+
+	"github.com/docutag/controller/internal/storage"
+)
+
+type createdByKey struct{}
+
+// WithCreatedBy returns a context whose enqueued tasks carry createdBy as their originator
+func WithCreatedBy(ctx context.Context, createdBy string) context.Context {
+	return context.WithValue(ctx, createdByKey{}, createdBy)
+}
+
+// CreatedBy returns the originator stored by WithCreatedBy, or "" if there is none
+func CreatedBy(ctx context.Context) string {
+	createdBy, _ := ctx.Value(createdByKey{}).(string)
+	return createdBy
+}
+
+// childCreatedBy is the originator recorded on jobs the crawler queues. Children inherit the
+// originator of the crawl; tasks queued before provenance was tracked fall back to the crawler.
+func childCreatedBy(ctx context.Context) string {
+	if createdBy := CreatedBy(ctx); createdBy != "" {
+		return createdBy
+	}
+	return storage.CreatedByCrawler
+}
+
+type namespaceKey struct{}
+
+// WithNamespace returns a context whose enqueued tasks, and the records they create, belong to namespace
+func WithNamespace(ctx context.Context, namespace string) context.Context {
+	return context.WithValue(ctx, namespaceKey{}, namespace)
+}
+
+// Namespace returns the namespace stored by WithNamespace, or "" if there is none
+func Namespace(ctx context.Context) string {
+	namespace, _ := ctx.Value(namespaceKey{}).(string)
+	return namespace
+}
+
+// taskNamespace is the namespace a task's records and child jobs are saved into. Tasks
+// queued before namespaces were tracked belong to the default namespace.
+func taskNamespace(ctx context.Context) string {
+	if namespace := Namespace(ctx); namespace != "" {
+		return namespace
+	}
+	return storage.DefaultNamespace
+}
+
+// MetricSource maps a created_by value to a bounded metric label: API keys and named clients
+// are collapsed so each caller does not become its own series.
+func MetricSource(createdBy string) string {
+	switch {
+	case createdBy == "":
+		return storage.CreatedByUnknown
+	case createdBy == storage.CreatedByUnknown, createdBy == storage.CreatedByCrawler,
+		createdBy == storage.CreatedByScheduler, createdBy == storage.CreatedByRescrape, createdBy == "anonymous":
+		return createdBy
+	case strings.HasPrefix(createdBy, "apikey:"):
+		return "api_key"
+	default:
+		return "client"
+	}
+}
+
+// RecordScrapeJobCreated counts a new scrape job of the given kind ("parent", "child" or "rescrape") by source
+func RecordScrapeJobCreated(kind, createdBy string) {
+	counters.Load().scrapeJobsCreated.WithLabelValues(kind, MetricSource(createdBy)).Inc()
+}
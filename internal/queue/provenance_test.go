@@ -0,0 +1,62 @@
+package queue
+
+import (
+	"context"
+	"testing"
+
+	"github.com/docutag/controller/internal/storage"
+)
+
+func TestCreatedByContext(t *testing.T) {
+	ctx := context.Background()
+	if got := CreatedBy(ctx); got != "" {
+		t.Errorf("expected empty creator, got %q", got)
+	}
+	if got := childCreatedBy(ctx); got != storage.CreatedByCrawler {
+		t.Errorf("expected children of unattributed crawls to be %q, got %q", storage.CreatedByCrawler, got)
+	}
+
+	ctx = WithCreatedBy(ctx, "reader-app")
+	if got := CreatedBy(ctx); got != "reader-app" {
+		t.Errorf("expected reader-app, got %q", got)
+	}
+	if got := childCreatedBy(ctx); got != "reader-app" {
+		t.Errorf("expected children to inherit reader-app, got %q", got)
+	}
+}
+
+func TestNamespaceContext(t *testing.T) {
+	ctx := context.Background()
+	if got := taskNamespace(ctx); got != storage.DefaultNamespace {
+		t.Errorf("expected tasks queued without a namespace to use %q, got %q", storage.DefaultNamespace, got)
+	}
+
+	ctx = WithNamespace(ctx, "team-a")
+	if got := Namespace(ctx); got != "team-a" {
+		t.Errorf("expected team-a, got %q", got)
+	}
+	if got := taskNamespace(ctx); got != "team-a" {
+		t.Errorf("expected children to inherit team-a, got %q", got)
+	}
+}
+
+func TestMetricSource(t *testing.T) {
+	tests := []struct {
+		createdBy string
+		expected  string
+	}{
+		{"", "unknown"},
+		{"unknown", "unknown"},
+		{"anonymous", "anonymous"},
+		{"scheduler", "scheduler"},
+		{"worker:crawl", "worker:crawl"},
+		{"apikey:0123456789ab", "api_key"},
+		{"reader-app", "client"},
+	}
+
+	for _, tt := range tests {
+		if got := MetricSource(tt.createdBy); got != tt.expected {
+			t.Errorf("MetricSource(%q) = %q, want %q", tt.createdBy, got, tt.expected)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package queue
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/docutag/controller/internal/clients"
+	"github.com/docutag/controller/internal/settings"
+	"github.com/docutag/controller/internal/storage"
+)
+
+// analyzerResult builds a completed analysis result carrying the given quality score
+func analyzerResult(t *testing.T, score float64) *clients.AnalysisJobResult {
+	t.Helper()
+	body := fmt.Sprintf(`{"status":"completed","analysis":{"metadata":{"quality_score":{"score":%g}}}}`, score)
+	result := &clients.AnalysisJobResult{}
+	if err := json.Unmarshal([]byte(body), result); err != nil {
+		t.Fatal(err)
+	}
+	return result
+}
+
+func TestApplyQualityTombstone(t *testing.T) {
+	configured := settings.Default()
+	configured.SevereQualityThreshold = 0.2
+	configured.StandardQualityThreshold = 0.4
+	configured.TombstonePeriodSevereQuality = 3
+	configured.TombstonePeriodStandardQuality = 14
+
+	disabled := configured
+	disabled.SevereQualityThreshold = 0
+	disabled.StandardQualityThreshold = 0
+
+	tests := []struct {
+		name       string
+		values     settings.Values
+		score      float64
+		wantPeriod int
+		wantSEO    bool
+	}{
+		{"just below severe", configured, 0.19, 3, false},
+		{"at severe", configured, 0.2, 14, true},
+		{"just below standard", configured, 0.39, 14, true},
+		{"at standard", configured, 0.4, 0, true},
+		{"above standard", configured, 0.9, 0, true},
+		{"no score", configured, 0, 0, true},
+		{"defaults below severe", settings.Default(), 0.24, 7, false},
+		{"defaults below standard", settings.Default(), 0.3, 30, true},
+		{"disabled low score", disabled, 0.01, 0, true},
+		{"disabled mid score", disabled, 0.3, 0, true},
+	}
+
+	w := &Worker{logger: slog.Default()}
+	now := time.Date(2025, 10, 1, 12, 0, 0, 0, time.UTC)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := &storage.Request{ID: "req-1", SEOEnabled: true, Metadata: map[string]interface{}{}}
+			score := analysisQualityScore(analyzerResult(t, tt.score))
+
+			got := w.applyQualityTombstone(req, score, tt.values, now)
+			if got != tt.wantPeriod {
+				t.Errorf("period = %d, want %d", got, tt.wantPeriod)
+			}
+			if req.SEOEnabled != tt.wantSEO {
+				t.Errorf("SEOEnabled = %v, want %v", req.SEOEnabled, tt.wantSEO)
+			}
+
+			tombstone, tombstoned := req.Metadata["tombstone_datetime"]
+			if tt.wantPeriod == 0 {
+				if tombstoned {
+					t.Errorf("expected no tombstone, got %v", tombstone)
+				}
+				return
+			}
+			want := now.AddDate(0, 0, tt.wantPeriod).Format(time.RFC3339)
+			if tombstone != want {
+				t.Errorf("tombstone_datetime = %v, want %s", tombstone, want)
+			}
+		})
+	}
+}
+
+func TestAnalysisQualityScoreMissing(t *testing.T) {
+	if got := analysisQualityScore(&clients.AnalysisJobResult{Status: "completed"}); got != 0 {
+		t.Errorf("expected 0 without analysis, got %v", got)
+	}
+}
+
+func TestApplyQualityTombstoneSkipsArchivalRequests(t *testing.T) {
+	w := &Worker{logger: slog.Default()}
+	req := &storage.Request{ID: "req-1", SEOEnabled: true, Metadata: map[string]interface{}{storage.MetaAnalysisSkipped: true}}
+
+	if got := w.applyQualityTombstone(req, 0.01, settings.Default(), time.Now()); got != 0 {
+		t.Errorf("expected no tombstone period for a request stored without analysis, got %d", got)
+	}
+	if _, ok := req.Metadata["tombstone_datetime"]; ok || !req.SEOEnabled {
+		t.Errorf("expected the request untouched, got SEO %v and metadata %v", req.SEOEnabled, req.Metadata)
+	}
+}
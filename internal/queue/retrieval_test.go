@@ -145,3 +145,26 @@ func min(a, b int) int {
 	}
 	return b
 }
+
+// TestHasCompletedAnalysis verifies that only a re-analysis (not the first one) triggers a version snapshot
+func TestHasCompletedAnalysis(t *testing.T) {
+	tests := []struct {
+		name     string
+		metadata map[string]interface{}
+		expected bool
+	}{
+		{"nil metadata", nil, false},
+		{"fresh scrape", map[string]interface{}{"textanalyzer_status": "queued"}, false},
+		{"failed analysis", map[string]interface{}{"textanalyzer_status": "failed"}, false},
+		{"completed analysis", map[string]interface{}{"textanalyzer_status": "completed"}, true},
+		{"non-string status", map[string]interface{}{"textanalyzer_status": true}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := hasCompletedAnalysis(tt.metadata); got != tt.expected {
+				t.Errorf("hasCompletedAnalysis(%v) = %v, want %v", tt.metadata, got, tt.expected)
+			}
+		})
+	}
+}
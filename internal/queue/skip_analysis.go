@@ -0,0 +1,17 @@
+package queue
+
+import "context"
+
+type skipAnalysisKey struct{}
+
+// WithSkipAnalysis returns a context whose enqueued scrape tasks store their documents
+// without text analysis, as archival ingestions want
+func WithSkipAnalysis(ctx context.Context, skip bool) context.Context {
+	return context.WithValue(ctx, skipAnalysisKey{}, skip)
+}
+
+// SkipAnalysis reports whether WithSkipAnalysis asked for analysis to be skipped
+func SkipAnalysis(ctx context.Context) bool {
+	skip, _ := ctx.Value(skipAnalysisKey{}).(bool)
+	return skip
+}
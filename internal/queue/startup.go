@@ -0,0 +1,95 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// ErrWorkerNotStarted is reported by Worker.Ready until the worker is processing tasks
+var ErrWorkerNotStarted = errors.New("worker not yet started")
+
+// errWorkerStopped is reported by Worker.Ready once the worker has shut down
+var errWorkerStopped = errors.New("worker stopped")
+
+// Worker lifecycle states, read by Ready
+const (
+	workerStateStarting int32 = iota // Waiting for Redis, or not started yet
+	workerStateRunning
+	workerStateStopped
+)
+
+// RedisRetry bounds how long startup waits for Redis to answer
+type RedisRetry struct {
+	Attempts     int           // Pings before giving up; values below 1 mean one ping
+	InitialDelay time.Duration // Wait after the first failed ping, doubled after each further failure
+	MaxDelay     time.Duration // Longest wait between pings
+}
+
+// DefaultRedisRetry waits a little over a minute in total before startup fails
+var DefaultRedisRetry = RedisRetry{Attempts: 10, InitialDelay: 500 * time.Millisecond, MaxDelay: 10 * time.Second}
+
+// WaitForRedis calls ping until it succeeds, backing off between attempts as retry says.
+// It returns the last ping error once every attempt has failed, or the context's error if
+// ctx ends first.
+func WaitForRedis(ctx context.Context, retry RedisRetry, ping func(context.Context) error, logger *slog.Logger) error {
+	attempts := max(retry.Attempts, 1)
+	delay := retry.InitialDelay
+
+	var err error
+	for attempt := 1; ; attempt++ {
+		if err = ping(ctx); err == nil {
+			if attempt > 1 {
+				logger.Info("redis reachable", "attempts", attempt)
+			}
+			return nil
+		}
+		if attempt >= attempts {
+			return fmt.Errorf("redis unreachable after %d attempts: %w", attempts, err)
+		}
+
+		logger.Warn("redis not reachable yet, retrying",
+			"attempt", attempt,
+			"max_attempts", attempts,
+			"retry_in", delay.String(),
+			"error", err,
+		)
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+
+		delay *= 2
+		if retry.MaxDelay > 0 && delay > retry.MaxDelay {
+			delay = retry.MaxDelay
+		}
+	}
+}
+
+// StartWhenReady waits for ping to reach Redis and then starts the worker. Errors are
+// returned to the caller, which decides how the process shuts down; until the worker
+// starts, Ready reports ErrWorkerNotStarted.
+func (w *Worker) StartWhenReady(ctx context.Context, retry RedisRetry, ping func(context.Context) error) error {
+	if err := WaitForRedis(ctx, retry, ping, w.logger); err != nil {
+		return err
+	}
+	return w.Start()
+}
+
+// Ready returns nil while the worker is processing tasks, ErrWorkerNotStarted before it
+// has started, and an error once it has stopped
+func (w *Worker) Ready() error {
+	switch w.state.Load() {
+	case workerStateRunning:
+		return nil
+	case workerStateStopped:
+		return errWorkerStopped
+	default:
+		return ErrWorkerNotStarted
+	}
+}
@@ -0,0 +1,83 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+// fakeDialer fails the first failures pings, then succeeds
+type fakeDialer struct {
+	failures int
+	calls    int
+}
+
+var errRedisDown = errors.New("connection refused")
+
+func (d *fakeDialer) ping(context.Context) error {
+	d.calls++
+	if d.calls <= d.failures {
+		return errRedisDown
+	}
+	return nil
+}
+
+var fastRetry = RedisRetry{Attempts: 4, InitialDelay: time.Millisecond, MaxDelay: 2 * time.Millisecond}
+
+func TestWaitForRedisRetriesUntilReachable(t *testing.T) {
+	dialer := &fakeDialer{failures: 2}
+	if err := WaitForRedis(context.Background(), fastRetry, dialer.ping, slog.Default()); err != nil {
+		t.Fatalf("Expected Redis to be reached, got %v", err)
+	}
+	if dialer.calls != 3 {
+		t.Errorf("Expected 3 pings, got %d", dialer.calls)
+	}
+}
+
+func TestWaitForRedisGivesUp(t *testing.T) {
+	dialer := &fakeDialer{failures: 100}
+	err := WaitForRedis(context.Background(), fastRetry, dialer.ping, slog.Default())
+	if !errors.Is(err, errRedisDown) {
+		t.Fatalf("Expected the last ping error, got %v", err)
+	}
+	if dialer.calls != fastRetry.Attempts {
+		t.Errorf("Expected %d pings, got %d", fastRetry.Attempts, dialer.calls)
+	}
+}
+
+func TestWaitForRedisStopsWhenCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	dialer := &fakeDialer{failures: 100}
+	slow := RedisRetry{Attempts: 10, InitialDelay: time.Hour}
+
+	done := make(chan error, 1)
+	go func() { done <- WaitForRedis(ctx, slow, dialer.ping, slog.Default()) }()
+	cancel()
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("Expected context.Canceled, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("WaitForRedis kept waiting after the context was cancelled")
+	}
+}
+
+func TestStartWhenReadyReturnsRedisError(t *testing.T) {
+	w := &Worker{logger: slog.Default()}
+	if err := w.Ready(); !errors.Is(err, ErrWorkerNotStarted) {
+		t.Fatalf("Expected ErrWorkerNotStarted before startup, got %v", err)
+	}
+
+	dialer := &fakeDialer{failures: 100}
+	err := w.StartWhenReady(context.Background(), fastRetry, dialer.ping)
+	if !errors.Is(err, errRedisDown) {
+		t.Fatalf("Expected the Redis error to reach the caller, got %v", err)
+	}
+	if err := w.Ready(); !errors.Is(err, ErrWorkerNotStarted) {
+		t.Errorf("Expected the worker to stay not ready, got %v", err)
+	}
+}
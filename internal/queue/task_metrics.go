@@ -0,0 +1,108 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/docutag/controller/internal/promreg"
+	"github.com/hibiken/asynq"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Task outcomes, the "outcome" label of the task histograms
+const (
+	taskOutcomeSuccess        = "success"
+	taskOutcomeRetryableError = "retryable_error" // Failed; asynq will retry it
+	taskOutcomePermanentError = "permanent_error" // Failed for good, or gave up without an error to stop retries
+	taskOutcomeSkipped        = "skipped"         // Completed without doing the work, e.g. disallowed by robots.txt
+)
+
+// taskMetricNames are the "task_type" labels of the task types
+var taskMetricNames = map[string]string{
+	TypeScrapeURL:        "scrape",
+	TypeExtractLinks:     "extract_links",
+	TypeRetrieveAnalysis: "retrieve_analysis",
+}
+
+// taskMetrics time every task the worker processes
+type taskMetrics struct {
+	queueWait  *prometheus.HistogramVec
+	processing *prometheus.HistogramVec
+}
+
+// newTaskMetrics registers the task histograms with reg, reusing collectors it already has. The
+// metrics are usable even when registering fails; they just aren't exported.
+func newTaskMetrics(reg prometheus.Registerer) (*taskMetrics, error) {
+	queueWait, waitErr := promreg.RegisterOrReuse(reg, prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "controller_task_queue_wait_seconds",
+			Help:    "Time tasks spent queued before a worker picked them up, by task type and outcome",
+			Buckets: []float64{0.1, 0.5, 1, 5, 15, 30, 60, 300, 900, 1800, 3600, 7200},
+		},
+		[]string{"task_type", "outcome"},
+	))
+	processing, processingErr := promreg.RegisterOrReuse(reg, prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "controller_task_processing_seconds",
+			Help:    "Time workers spent processing tasks, by task type and outcome",
+			Buckets: []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60, 120, 300, 600},
+		},
+		[]string{"task_type", "outcome"},
+	))
+	return &taskMetrics{queueWait: queueWait, processing: processing}, errors.Join(waitErr, processingErr)
+}
+
+// taskOutcomeKey carries a *string a handler fills in with markTaskOutcome
+type taskOutcomeKey struct{}
+
+// markTaskOutcome records the outcome of a task that returns nil without succeeding, such as
+// one skipped or given up on. It does nothing outside a task observed by taskMetrics.
+func markTaskOutcome(ctx context.Context, outcome string) {
+	if o, ok := ctx.Value(taskOutcomeKey{}).(*string); ok {
+		*o = outcome
+	}
+}
+
+// observe times next, recording how long the task waited in the queue and how long it took,
+// labelled with its outcome
+func (m *taskMetrics) observe(taskType string, next asynq.HandlerFunc) asynq.HandlerFunc {
+	name := taskMetricNames[taskType]
+	if name == "" {
+		name = taskType
+	}
+	return func(ctx context.Context, t *asynq.Task) error {
+		start := time.Now()
+		// Every task payload carries the time it was queued
+		var payload struct {
+			EnqueuedAt int64 `json:"enqueued_at"`
+		}
+		json.Unmarshal(t.Payload(), &payload)
+
+		outcome := taskOutcomeSuccess
+		err := next(context.WithValue(ctx, taskOutcomeKey{}, &outcome), t)
+		if err != nil {
+			outcome = classifyTaskError(ctx, err)
+		}
+
+		m.processing.WithLabelValues(name, outcome).Observe(time.Since(start).Seconds())
+		if payload.EnqueuedAt > 0 {
+			m.queueWait.WithLabelValues(name, outcome).Observe(start.Sub(time.Unix(0, payload.EnqueuedAt)).Seconds())
+		}
+		return err
+	}
+}
+
+// classifyTaskError reports whether asynq will retry a failed task
+func classifyTaskError(ctx context.Context, err error) string {
+	if errors.Is(err, asynq.SkipRetry) {
+		return taskOutcomePermanentError
+	}
+	retried, ok := asynq.GetRetryCount(ctx)
+	maxRetry, _ := asynq.GetMaxRetry(ctx)
+	if ok && retried >= maxRetry {
+		return taskOutcomePermanentError
+	}
+	return taskOutcomeRetryableError
+}
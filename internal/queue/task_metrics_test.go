@@ -0,0 +1,83 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/hibiken/asynq"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestTaskMetricsOutcomes(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	m, err := newTaskMetrics(reg)
+	if err != nil {
+		t.Fatalf("Failed to register task metrics: %v", err)
+	}
+
+	handlers := map[string]asynq.HandlerFunc{
+		taskOutcomeSuccess: func(ctx context.Context, t *asynq.Task) error { return nil },
+		taskOutcomeRetryableError: func(ctx context.Context, t *asynq.Task) error {
+			return errors.New("scraper unavailable")
+		},
+		taskOutcomePermanentError: func(ctx context.Context, t *asynq.Task) error {
+			return fmt.Errorf("invalid payload: %w", asynq.SkipRetry)
+		},
+		taskOutcomeSkipped: func(ctx context.Context, t *asynq.Task) error {
+			markTaskOutcome(ctx, taskOutcomeSkipped)
+			return nil
+		},
+	}
+	payload, _ := json.Marshal(ScrapeTaskPayload{URL: "https://example.com", EnqueuedAt: time.Now().Add(-2 * time.Minute).UnixNano()})
+	for _, handler := range handlers {
+		m.observe(TypeScrapeURL, handler)(context.Background(), asynq.NewTask(TypeScrapeURL, payload))
+	}
+	// Without an enqueue time only the processing time is known
+	m.observe(TypeExtractLinks, handlers[taskOutcomeSuccess])(context.Background(), asynq.NewTask(TypeExtractLinks, []byte(`{}`)))
+
+	families, err := reg.Gather()
+	if err != nil {
+		t.Fatalf("Failed to gather: %v", err)
+	}
+	samples := map[string]map[[2]string]uint64{}
+	for _, family := range families {
+		samples[family.GetName()] = map[[2]string]uint64{}
+		for _, metric := range family.GetMetric() {
+			labels := map[string]string{}
+			for _, l := range metric.GetLabel() {
+				labels[l.GetName()] = l.GetValue()
+			}
+			h := metric.GetHistogram()
+			samples[family.GetName()][[2]string{labels["task_type"], labels["outcome"]}] = h.GetSampleCount()
+
+			if family.GetName() == "controller_task_queue_wait_seconds" && h.GetSampleSum() < 120 {
+				t.Errorf("Expected a queue wait of at least 2 minutes for %v, got %vs", labels, h.GetSampleSum())
+			}
+		}
+	}
+
+	processing := samples["controller_task_processing_seconds"]
+	queueWait := samples["controller_task_queue_wait_seconds"]
+	for outcome := range handlers {
+		key := [2]string{"scrape", outcome}
+		if processing[key] != 1 || queueWait[key] != 1 {
+			t.Errorf("Expected one processing and one queue wait sample for %v, got %d and %d", key, processing[key], queueWait[key])
+		}
+	}
+	if key := [2]string{"extract_links", taskOutcomeSuccess}; processing[key] != 1 || queueWait[key] != 0 {
+		t.Errorf("Expected a processing sample and no queue wait sample for %v, got %d and %d", key, processing[key], queueWait[key])
+	}
+}
+
+func TestTaskMetricsReuseCollectors(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	for i := 0; i < 2; i++ {
+		if _, err := newTaskMetrics(reg); err != nil {
+			t.Fatalf("Expected registering twice to reuse the collectors, got %v", err)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/hibiken/asynq"
+)
+
+// ErrTaskTimeout marks a task that ran past the worker's task timeout. Jobs failed by it
+// carry a "task timeout" error rather than whatever upstream call happened to be cut off.
+var ErrTaskTimeout = errors.New("task timeout")
+
+// errTaskInterrupted marks a task cancelled because the worker is shutting down
+var errTaskInterrupted = errors.New("task interrupted by worker shutdown")
+
+// boundTask gives every task a context that expires after the task timeout and is
+// cancelled when the worker shuts down, so no task can hold a worker slot indefinitely
+func (w *Worker) boundTask(next asynq.Handler) asynq.Handler {
+	return asynq.HandlerFunc(func(ctx context.Context, t *asynq.Task) error {
+		var cancel context.CancelFunc
+		if w.taskTimeout > 0 {
+			ctx, cancel = context.WithTimeout(ctx, w.taskTimeout)
+		} else {
+			ctx, cancel = context.WithCancel(ctx)
+		}
+		defer cancel()
+		stop := context.AfterFunc(w.tasksCtx, cancel)
+		defer stop()
+
+		err := next.ProcessTask(ctx, t)
+		if errors.Is(err, ErrTaskTimeout) {
+			counters.Load().taskTimeouts.WithLabelValues(t.Type()).Inc()
+		}
+		return err
+	})
+}
+
+// taskStopped returns nil while ctx is live. Once the task timeout has passed or the
+// worker is shutting down it returns an ErrTaskTimeout or errTaskInterrupted error
+// naming the stage that was about to run, so the task stops before doing more work.
+func (w *Worker) taskStopped(ctx context.Context, stage string) error {
+	switch {
+	case ctx.Err() == nil:
+		return nil
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		return fmt.Errorf("%w after %s before %s", ErrTaskTimeout, w.taskTimeout, stage)
+	default:
+		return fmt.Errorf("%w before %s", errTaskInterrupted, stage)
+	}
+}
+
+// taskError reclassifies err when the task's context ended while it was in flight;
+// an upstream call cut off by the deadline reports ErrTaskTimeout, not its own error
+func (w *Worker) taskError(ctx context.Context, err error) error {
+	if err == nil || errors.Is(err, ErrTaskTimeout) || errors.Is(err, errTaskInterrupted) {
+		return err
+	}
+	switch {
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		return fmt.Errorf("%w after %s: %v", ErrTaskTimeout, w.taskTimeout, err)
+	case ctx.Err() != nil:
+		return fmt.Errorf("%w: %v", errTaskInterrupted, err)
+	}
+	return err
+}
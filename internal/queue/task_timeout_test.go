@@ -0,0 +1,85 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hibiken/asynq"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// blockUntilDone stands in for a slow upstream call that only returns when its context ends
+func blockUntilDone(w *Worker) asynq.HandlerFunc {
+	return func(ctx context.Context, t *asynq.Task) error {
+		<-ctx.Done()
+		return w.taskError(ctx, fmt.Errorf("failed to scrape: %w", ctx.Err()))
+	}
+}
+
+func TestWorkerTaskTimeout(t *testing.T) {
+	w := NewWorker(WorkerConfig{RedisAddr: "localhost:6379", Concurrency: 1, TaskTimeout: 50 * time.Millisecond}, nil, nil, nil, nil, nil, nil, nil, nil)
+	w.mux.HandleFunc("test:slow", blockUntilDone(w))
+	before := testutil.ToFloat64(counters.Load().taskTimeouts.WithLabelValues("test:slow"))
+
+	start := time.Now()
+	err := w.ProcessTask(context.Background(), asynq.NewTask("test:slow", nil))
+	if !errors.Is(err, ErrTaskTimeout) {
+		t.Fatalf("expected a task timeout, got %v", err)
+	}
+	if !strings.HasPrefix(err.Error(), "task timeout") {
+		t.Errorf("expected the error to start with the timeout class, got %q", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("expected the task to stop at its timeout, took %s", elapsed)
+	}
+	if got := testutil.ToFloat64(counters.Load().taskTimeouts.WithLabelValues("test:slow")); got != before+1 {
+		t.Errorf("expected the timeout to be counted, got %v -> %v", before, got)
+	}
+}
+
+func TestWorkerShutdownCancelsTasks(t *testing.T) {
+	w := NewWorker(WorkerConfig{RedisAddr: "localhost:6379", Concurrency: 1}, nil, nil, nil, nil, nil, nil, nil, nil)
+	started := make(chan struct{})
+	slow := blockUntilDone(w)
+	w.mux.HandleFunc("test:slow", func(ctx context.Context, t *asynq.Task) error {
+		close(started)
+		return slow(ctx, t)
+	})
+
+	done := make(chan error, 1)
+	go func() { done <- w.ProcessTask(context.Background(), asynq.NewTask("test:slow", nil)) }()
+	<-started
+	w.Shutdown()
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, errTaskInterrupted) || errors.Is(err, ErrTaskTimeout) {
+			t.Errorf("expected the task to be interrupted, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Shutdown did not cancel the task in flight")
+	}
+}
+
+func TestTaskStopped(t *testing.T) {
+	w := &Worker{taskTimeout: time.Minute}
+	if err := w.taskStopped(context.Background(), "scraping"); err != nil {
+		t.Errorf("expected a live context to continue, got %v", err)
+	}
+
+	expired, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+	if err := w.taskStopped(expired, "scraping"); !errors.Is(err, ErrTaskTimeout) || !strings.Contains(err.Error(), "before scraping") {
+		t.Errorf("expected a timeout naming the stage, got %v", err)
+	}
+
+	cancelled, cancelNow := context.WithCancel(context.Background())
+	cancelNow()
+	if err := w.taskStopped(cancelled, "scraping"); !errors.Is(err, errTaskInterrupted) {
+		t.Errorf("expected an interruption, got %v", err)
+	}
+}
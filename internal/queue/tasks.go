@@ -1,22 +1,24 @@
 package queue
 
 import (
-	"bytes"
-	"compress/gzip"
 	"context"
-	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
-	"net/url"
 	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/hibiken/asynq"
 	"github.com/docutag/controller/internal/clients"
-	internalslug "github.com/docutag/controller/internal/slug"
+	"github.com/docutag/controller/internal/language"
+	"github.com/docutag/controller/internal/pipeline"
+	"github.com/docutag/controller/internal/settings"
 	"github.com/docutag/controller/internal/storage"
+	"github.com/docutag/controller/internal/urlguard"
+	"github.com/docutag/controller/internal/urlnorm"
+	"github.com/docutag/controller/internal/webhooks"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/trace"
@@ -110,6 +112,43 @@ func (w *Worker) handleScrapeTask(ctx context.Context, t *asynq.Task) error {
 		))
 	}
 
+	// Records and follow-up tasks are attributed to whoever queued this scrape, in their namespace
+	ctx = WithCreatedBy(ctx, payload.CreatedBy)
+	ctx = WithNamespace(ctx, payload.Namespace)
+	ctx = WithRootJobID(ctx, payload.RootJobID)
+	ctx = WithSkipAnalysis(ctx, payload.SkipAnalysis)
+
+	// The crawl keeps the parameters it started with; a job queued without them adopts
+	// the current settings and records them, so its descendants inherit them too
+	ctx = WithCrawlParams(ctx, w.crawlParams(ctx))
+	if payload.CrawlParams == nil {
+		if err := w.storage.UpdateScrapeJobCrawlParams(jobID, CrawlParamsFrom(ctx)); err != nil {
+			w.logger.Warn("failed to record crawl params", "job_id", jobID, "error", err)
+		}
+	}
+
+	// Honour robots.txt before contacting the scraper
+	if !w.robotsAllowed(ctx, jobID, url) {
+		if err := w.storage.UpdateScrapeJobSkipped(jobID, skipReasonRobotsTxt); err != nil {
+			w.logger.Error("failed to record skipped job", "job_id", jobID, "error", err)
+		}
+		counters.Load().scrapeJobsSkipped.WithLabelValues(skipReasonRobotsTxt).Inc()
+		w.logger.Info("skipping scrape disallowed by robots.txt",
+			"job_id", jobID,
+			"url", url,
+			"user_agent", w.robots.UserAgent(),
+		)
+
+		if w.eventPublisherWithDetails != nil && payload.RequestID != "" {
+			w.eventPublisherWithDetails(payload.RequestID, "scrape_skipped", "scraping", "Disallowed by robots.txt", map[string]interface{}{
+				"url":         url,
+				"skip_reason": skipReasonRobotsTxt,
+			})
+		}
+		markTaskOutcome(ctx, taskOutcomeSkipped)
+		return nil // Not an error; retrying would give the same answer
+	}
+
 	// Update job status to processing
 	if err := w.storage.UpdateScrapeJobStatus(jobID, "processing", ""); err != nil {
 		w.logger.Error("failed to update job status", "job_id", jobID, "error", err)
@@ -124,7 +163,15 @@ func (w *Worker) handleScrapeTask(ctx context.Context, t *asynq.Task) error {
 	}
 
 	// Execute the scrape workflow
-	err := w.processScrape(ctx, jobID, url, extractLinks, payload.RequestID)
+	err := w.taskError(ctx, w.processScrape(ctx, jobID, url, extractLinks, payload.RequestID))
+	if errors.Is(err, errTaskInterrupted) {
+		// Shutdown is not the job's fault: leave it queued for the retry asynq schedules
+		if updateErr := w.storage.UpdateScrapeJobStatus(jobID, "queued", ""); updateErr != nil {
+			w.logger.Error("failed to requeue interrupted job", "job_id", jobID, "error", updateErr)
+		}
+		w.logger.Warn("scrape task interrupted by shutdown", "job_id", jobID, "error", err)
+		return err
+	}
 	if err != nil {
 		// Update job status to failed
 		errMsg := err.Error()
@@ -143,6 +190,12 @@ func (w *Worker) handleScrapeTask(ctx context.Context, t *asynq.Task) error {
 				"error": errMsg,
 			})
 		}
+		w.publishJobWebhook(webhooks.EventScrapeFailed, jobID)
+
+		// Only the last attempt ends the scrape; earlier ones are retried
+		if classifyTaskError(ctx, err) == taskOutcomePermanentError {
+			RecordScrapeOutcome(ScrapeEntryWorker, ScrapeOutcomeFailed, clients.ScoreBucketNone)
+		}
 
 		w.logger.Error("scrape task failed", "job_id", jobID, "error", err)
 		return err // Asynq will retry
@@ -152,271 +205,173 @@ func (w *Worker) handleScrapeTask(ctx context.Context, t *asynq.Task) error {
 	if w.eventPublisherWithDetails != nil && payload.RequestID != "" {
 		w.eventPublisherWithDetails(payload.RequestID, "scraped", "scraping", "Scraping completed", nil)
 	}
+	w.publishJobWebhook(webhooks.EventScrapeCompleted, jobID)
 
 	w.logger.Info("scrape task completed", "job_id", jobID)
 	return nil
 }
 
-// processScrape contains the main scraping logic
-func (w *Worker) processScrape(ctx context.Context, jobID, url string, extractLinks bool, requestID string) error {
-	// Score the URL first
-	scoreResp, err := w.scraperClient.ScoreLink(ctx, url)
+// publishJobWebhook sends the job, as it is now stored, to the webhooks subscribed to eventType
+func (w *Worker) publishJobWebhook(eventType, jobID string) {
+	if w.webhooks == nil {
+		return
+	}
+	job, err := w.storage.GetScrapeJob(jobID)
 	if err != nil {
-		return fmt.Errorf("failed to score link: %w", err)
+		w.logger.Warn("failed to load job for webhook event", "job_id", jobID, "event_type", eventType, "error", err)
+		return
 	}
-
-	// Check if this is an image URL (skip threshold check for images)
-	isImageURL := false
-	for _, category := range scoreResp.Score.Categories {
-		if category == "image" {
-			isImageURL = true
-			break
-		}
+	if job == nil {
+		return // Deleted while the task ran
 	}
+	w.webhooks.Publish(job.Namespace, eventType, job)
+}
 
-	// Check score threshold (skip for image URLs)
-	if !isImageURL && scoreResp.Score.Score < w.linkScoreThreshold {
-		// Save a tombstoned record for low-quality content
-		tombstoneTime := time.Now().UTC().Add(time.Duration(w.tombstonePeriodLowScore) * 24 * time.Hour)
-		newRequestID := uuid.New().String()
-
-		// Add domain name to tags, normalizing categories
-		tags := make([]string, 0, len(scoreResp.Score.Categories))
-		for _, cat := range scoreResp.Score.Categories {
-			tags = append(tags, clients.NormalizeTag(cat))
-		}
-		if domain := extractDomainTag(url); domain != "" {
-			tags = append(tags, domain)
-		}
-
-		// Add 'scrape' tag to all scraped content
-		tags = append(tags, "scrape")
-
-		record := &storage.Request{
-			ID:         newRequestID,
-			CreatedAt:  time.Now().UTC(),
-			SourceType: "url",
-			SourceURL:  &url,
-			Tags:       tags,
-			SEOEnabled: false, // Disable SEO for below-threshold content
-			Metadata: map[string]interface{}{
-				"link_score": map[string]interface{}{
-					"score":                scoreResp.Score.Score,
-					"reason":               scoreResp.Score.Reason,
-					"categories":           scoreResp.Score.Categories,
-					"is_recommended":       scoreResp.Score.IsRecommended,
-					"malicious_indicators": scoreResp.Score.MaliciousIndicators,
-				},
-				"below_threshold":    true,
-				"threshold":          w.linkScoreThreshold,
-				"tombstone_datetime": tombstoneTime.Format(time.RFC3339),
-			},
-		}
-
-		if err := w.storage.SaveRequest(record); err != nil {
-			return fmt.Errorf("failed to save low-quality record: %w", err)
-		}
-
-		// Update job with result
-		if err := w.storage.UpdateScrapeJobResult(jobID, newRequestID); err != nil {
-			return fmt.Errorf("failed to update job result: %w", err)
-		}
-
-		// Record tombstone metrics
-		if w.businessMetrics != nil {
-			w.businessMetrics.TombstonesCreatedTotal.WithLabelValues("low-score", "none").Inc()
-			w.businessMetrics.TombstoneDaysHistogram.WithLabelValues("low-score").Observe(float64(w.tombstonePeriodLowScore))
-		}
-
-		w.logger.Info("low-quality URL marked for tombstoning",
-			"url", url,
-			"score", scoreResp.Score.Score,
-			"threshold", w.linkScoreThreshold,
-		)
-		return nil
+// robotsAllowed reports whether the job may be scraped under robots.txt.
+// Jobs are always allowed when the check is disabled or the job was submitted with override_robots.
+func (w *Worker) robotsAllowed(ctx context.Context, jobID, url string) bool {
+	if w.robots == nil {
+		return true
 	}
 
-	// Scrape the URL
-	scrapeResp, err := w.scraperClient.Scrape(ctx, url)
+	job, err := w.storage.GetScrapeJob(jobID)
 	if err != nil {
-		return fmt.Errorf("failed to scrape: %w", err)
-	}
-
-	// Build scraper metadata
-	scraperMetadata := make(map[string]interface{})
-	scraperMetadata["title"] = scrapeResp.Title
-	scraperMetadata["content"] = scrapeResp.Content
-	scraperMetadata["raw_text"] = scrapeResp.RawText
-	scraperMetadata["url"] = scrapeResp.URL
-
-	// Include fields from the scraper's Metadata
-	if scrapeResp.Metadata != nil {
-		for k, v := range scrapeResp.Metadata {
-			scraperMetadata[k] = v
-		}
-	}
-
-	// Extract image URLs from scraper response for textanalyzer
-	images := make([]string, 0, len(scrapeResp.Images))
-	for _, img := range scrapeResp.Images {
-		images = append(images, img.URL)
+		w.logger.Warn("failed to load job for robots.txt check", "job_id", jobID, "error", err)
+	} else if job != nil && job.OverrideRobots {
+		return true
 	}
 
-	// Enqueue text analysis (skip for image URLs)
-	var textAnalyzerJobID string
-	if !isImageURL {
-		// Compress the raw text for storage and AI enrichment
-		compressedRawText, err := compressHTML(scrapeResp.RawText)
-		if err != nil {
-			w.logger.Warn("failed to compress raw text",
-				"url", url,
-				"error", err,
-			)
-			compressedRawText = "" // Continue without compressed HTML
-		}
+	return w.robots.Allowed(ctx, url)
+}
 
-		jobID, err := w.textAnalyzerClient.EnqueueAnalysis(ctx, scrapeResp.Content, compressedRawText, images)
-		if err != nil {
-			// Log error but don't fail the scrape - analysis can be retried later
-			w.logger.Warn("failed to enqueue text analysis",
-				"url", url,
-				"error", err,
-			)
-		} else {
-			textAnalyzerJobID = jobID
-			w.logger.Info("enqueued text analysis job",
-				"job_id", jobID,
-				"url", url,
-				"image_count", len(images),
-				"has_compressed_html", compressedRawText != "",
-			)
-		}
+// rescrapeTarget returns the ID of the request a job re-scrapes, or nil for an ordinary scrape
+func (w *Worker) rescrapeTarget(jobID string) *string {
+	job, err := w.storage.GetScrapeJob(jobID)
+	if err != nil {
+		w.logger.Warn("failed to load job for re-scrape check", "job_id", jobID, "error", err)
+		return nil
 	}
-
-	// Combine metadata
-	combinedMetadata := make(map[string]interface{})
-	combinedMetadata["scraper_metadata"] = scraperMetadata
-	if textAnalyzerJobID != "" {
-		combinedMetadata["textanalyzer_job_id"] = textAnalyzerJobID
-		combinedMetadata["textanalyzer_status"] = "queued"
+	if job == nil {
+		return nil
 	}
+	return job.RescrapeOf
+}
 
-	// Add link score
-	if scrapeResp.Score != nil {
-		combinedMetadata["link_score"] = map[string]interface{}{
-			"score":                scrapeResp.Score.Score,
-			"reason":               scrapeResp.Score.Reason,
-			"categories":           scrapeResp.Score.Categories,
-			"is_recommended":       scrapeResp.Score.IsRecommended,
-			"malicious_indicators": scrapeResp.Score.MaliciousIndicators,
-		}
-	} else {
-		combinedMetadata["link_score"] = map[string]interface{}{
-			"score":                scoreResp.Score.Score,
-			"reason":               scoreResp.Score.Reason,
-			"categories":           scoreResp.Score.Categories,
-			"is_recommended":       scoreResp.Score.IsRecommended,
-			"malicious_indicators": scoreResp.Score.MaliciousIndicators,
-		}
+// refreshRequest replaces the content of an existing request with a fresh scrape,
+// snapshotting the previous content first so it stays recoverable
+func (w *Worker) refreshRequest(req *storage.Request) error {
+	version, err := w.storage.SaveRequestVersion(req.ID, storage.VersionReasonRescrape)
+	if err != nil {
+		return fmt.Errorf("failed to snapshot request before re-scrape: %w", err)
 	}
-
-	// Save to database
-	newRequestID := uuid.New().String()
-
-	// Get initial tags from link score categories (normalized)
-	// Analyzer tags will be added later when textanalyzer completes
-	var tags []string
-	if scrapeResp.Score != nil {
-		tags = make([]string, 0, len(scrapeResp.Score.Categories))
-		for _, cat := range scrapeResp.Score.Categories {
-			tags = append(tags, clients.NormalizeTag(cat))
-		}
-	} else if scoreResp != nil {
-		tags = make([]string, 0, len(scoreResp.Score.Categories))
-		for _, cat := range scoreResp.Score.Categories {
-			tags = append(tags, clients.NormalizeTag(cat))
-		}
+	if err := w.storage.RefreshScrapedRequest(req); err != nil {
+		return fmt.Errorf("failed to refresh request: %w", err)
 	}
+	w.logger.Info("refreshed request from re-scrape", "request_id", req.ID, "version", version.Version)
+	return nil
+}
 
-	// Add domain name to tags
-	if domain := extractDomainTag(url); domain != "" {
-		tags = append(tags, domain)
+// processScrape contains the main scraping logic
+func (w *Worker) processScrape(ctx context.Context, jobID, url string, extractLinks bool, requestID string) error {
+	// A re-scrape refreshes the request it points at instead of storing a new one
+	rescrapeOf := w.rescrapeTarget(jobID)
+
+	current := w.settings.Get()
+	params := w.crawlParams(ctx)
+	deps := pipeline.Deps{
+		Scraper:          w.scraperClient,
+		TextAnalyzer:     w.textAnalyzerClient,
+		DomainThresholds: w.domainThresholds,
+		BusinessMetrics:  w.businessMetrics,
+		Logger:           w.logger,
+		Save:             w.storage.SaveRequest,
+	}
+	if w.queueClient != nil {
+		deps.Retrieval = w.queueClient
+	}
+	opts := pipeline.Options{
+		URL:                     url,
+		LinkScoreThreshold:      params.LinkScoreThreshold,
+		TombstonePeriodLowScore: current.TombstonePeriodLowScore,
+		Analysis:                pipeline.AnalyzeAsync,
+		SkipAnalysis:            SkipAnalysis(ctx),
+		DomainTagIncludeHost:    w.domainTagIncludeHost,
+		CreatedBy:               CreatedBy(ctx),
+		Namespace:               taskNamespace(ctx),
+		Checkpoint: func(stage string) error {
+			return w.taskStopped(ctx, stage)
+		},
+		// Detect the same content already stored under a different URL
+		AfterScrape: func(resp *clients.ScraperResponse, hash string) (bool, error) {
+			if hash == "" {
+				return false, nil
+			}
+			return w.resolveDuplicate(ctx, jobID, url, hash, resp.ID)
+		},
 	}
-
-	// Add 'scrape' tag to all scraped content
-	tags = append(tags, "scrape")
-
-	// Extract slug from scraper response if available
-	var slug *string
-	if scrapeResp.Slug != "" {
-		slug = &scrapeResp.Slug
-	} else {
-		// Generate slug from title or URL
-		slugSource := scrapeResp.Title
-		if slugSource == "" {
-			slugSource = url
-		}
-		generatedSlug := internalslug.GenerateWithFallback(slugSource, newRequestID)
-		slug = &generatedSlug
+	if rescrapeOf != nil {
+		// A stored document being refreshed was accepted already, so only new URLs are held
+		// to the threshold, and content matching itself is not a duplicate
+		opts.RequestID = *rescrapeOf
+		opts.Rescrape = true
+		opts.AfterScrape = nil
+		deps.Save = w.refreshRequest
 	}
 
-	req := &storage.Request{
-		ID:               newRequestID,
-		CreatedAt:        time.Now(),
-		SourceType:       "url",
-		SourceURL:        &url,
-		ScraperUUID:      &scrapeResp.ID,
-		TextAnalyzerUUID: textAnalyzerJobID, // Store the job ID for async tracking
-		Tags:             tags,
-		Metadata:         combinedMetadata,
-		Slug:             slug,
-		SEOEnabled:       true, // Enable SEO by default
+	result, err := pipeline.ProcessURL(ctx, deps, opts)
+	if err != nil {
+		return err
 	}
-
-	if err := w.storage.SaveRequest(req); err != nil {
-		return fmt.Errorf("failed to save request: %w", err)
+	if result.Request == nil {
+		// Completed as a duplicate of an existing request
+		RecordScrapeOutcome(ScrapeEntryWorker, ScrapeOutcomeDuplicate, result.ScoreBucket())
+		return nil
 	}
+	newRequestID := result.Request.ID
 
 	// Update job with result
 	if err := w.storage.UpdateScrapeJobResult(jobID, newRequestID); err != nil {
 		return fmt.Errorf("failed to update job result: %w", err)
 	}
 
+	if result.BelowThreshold {
+		RecordScrapeOutcome(ScrapeEntryWorker, ScrapeOutcomeBelowThreshold, result.ScoreBucket())
+		w.recordAudit(storage.AuditActionTombstone, newRequestID, map[string]interface{}{
+			"reason":      "low-score",
+			"url":         url,
+			"score":       result.Score,
+			"period_days": current.TombstonePeriodLowScore,
+		})
+		return nil
+	}
+
+	RecordScrapeOutcome(ScrapeEntryWorker, ScrapeOutcomeCompleted, result.ScoreBucket())
 	w.logger.Info("scrape job completed successfully",
 		"job_id", jobID,
 		"request_id", newRequestID,
 	)
 
-	// Enqueue analysis result retrieval task if text analysis was enqueued
-	if textAnalyzerJobID != "" && w.queueClient != nil {
-		_, err := w.queueClient.EnqueueRetrieveAnalysis(ctx, newRequestID, textAnalyzerJobID, 0)
-		if err != nil {
-			// Log error but don't fail the scrape - retrieval can be retried manually if needed
-			w.logger.Warn("failed to enqueue analysis retrieval",
-				"request_id", newRequestID,
-				"analysis_job_id", textAnalyzerJobID,
-				"error", err,
-			)
-		} else {
-			w.logger.Info("enqueued analysis retrieval task",
-				"request_id", newRequestID,
-				"analysis_job_id", textAnalyzerJobID,
-			)
-		}
-	}
-
 	// Populate URL cache with scraper UUID for 30-day caching
-	if w.urlCache != nil && scrapeResp.ID != "" {
-		if err := w.urlCache.Set(ctx, url, scrapeResp.ID); err != nil {
+	if scraperUUID := *result.Request.ScraperUUID; w.urlCache != nil && scraperUUID != "" {
+		if err := w.urlCache.Set(ctx, url, scraperUUID); err != nil {
 			// Log error but don't fail the task
-			w.logger.Warn("failed to populate URL cache", "url", url, "scraper_uuid", scrapeResp.ID, "error", err)
+			w.logger.Warn("failed to populate URL cache", "url", url, "scraper_uuid", scraperUUID, "error", err)
 		} else {
-			w.logger.Info("URL cached for 30 days", "url", url, "scraper_uuid", scrapeResp.ID)
+			w.logger.Debug("URL cached for 30 days", "url", url, "scraper_uuid", scraperUUID)
 		}
 	}
 
-	// Extract links if requested (skip for image URLs)
-	if extractLinks && !isImageURL {
+	// Extract links if requested (skip for image URLs). The document is already saved, so
+	// a task out of time stops here rather than failing a job that produced a result.
+	if ctx.Err() != nil {
+		w.logger.Warn("skipping link extraction, task context ended",
+			"job_id", jobID,
+			"url", url,
+			"error", ctx.Err(),
+		)
+		return nil
+	}
+	if extractLinks && !result.IsImage {
 		// Get current job to check depth
 		job, err := w.storage.GetScrapeJob(jobID)
 		if err != nil {
@@ -424,11 +379,11 @@ func (w *Worker) processScrape(ctx context.Context, jobID, url string, extractLi
 				"job_id", jobID,
 				"error", err,
 			)
-		} else if job != nil && job.Depth < w.maxLinkDepth {
+		} else if job != nil && job.Depth < params.MaxDepth {
 			w.logger.Info("queueing link extraction task",
 				"url", url,
 				"depth", job.Depth,
-				"max_depth", w.maxLinkDepth,
+				"max_depth", params.MaxDepth,
 			)
 			// Enqueue link extraction as a separate task, preserving trace context
 			if w.queueClient != nil {
@@ -443,7 +398,7 @@ func (w *Worker) processScrape(ctx context.Context, jobID, url string, extractLi
 		} else if job != nil {
 			w.logger.Info("skipping link extraction, max depth reached",
 				"url", url,
-				"max_depth", w.maxLinkDepth,
+				"max_depth", params.MaxDepth,
 			)
 		}
 	}
@@ -451,54 +406,134 @@ func (w *Worker) processScrape(ctx context.Context, jobID, url string, extractLi
 	return nil
 }
 
-// isImageURL checks if a URL points to an image file
-func isImageURL(rawURL string) bool {
-	parsedURL, err := url.Parse(rawURL)
+// resolveDuplicate checks whether scraped content already exists under a different URL.
+// On a match the URL is recorded as an alternate of the existing request, the job is completed
+// pointing at it, and true is returned so no new request is saved.
+func (w *Worker) resolveDuplicate(ctx context.Context, jobID, url, hash, scraperUUID string) (bool, error) {
+	job, err := w.storage.GetScrapeJob(jobID)
 	if err != nil {
-		return false
+		w.logger.Warn("failed to load job for duplicate check", "job_id", jobID, "error", err)
+		return false, nil
+	}
+	if job != nil && job.AllowDuplicates {
+		return false, nil
 	}
 
-	// Get the path without query parameters
-	path := strings.ToLower(parsedURL.Path)
+	// Only documents the job's namespace can see count as duplicates
+	existing, err := w.storage.WithNamespace(taskNamespace(ctx)).FindRequestByContentHash(hash)
+	if err != nil {
+		w.logger.Warn("failed to check for duplicate content", "url", url, "error", err)
+		return false, nil
+	}
+	if existing == nil || (existing.SourceURL != nil && sameURL(*existing.SourceURL, url)) {
+		return false, nil
+	}
 
-	// Common image extensions
-	imageExtensions := []string{
-		".jpg", ".jpeg", ".png", ".gif", ".webp",
-		".svg", ".bmp", ".ico", ".tiff", ".tif",
+	if err := w.storage.AddAlternateURL(existing.ID, url); err != nil {
+		return false, fmt.Errorf("failed to record alternate URL: %w", err)
+	}
+	if err := w.storage.UpdateScrapeJobDuplicate(jobID, existing.ID); err != nil {
+		return false, fmt.Errorf("failed to update job result: %w", err)
 	}
 
-	for _, ext := range imageExtensions {
-		if strings.HasSuffix(path, ext) {
-			return true
+	// The scraper stored its own copy of the duplicate; drop it
+	if scraperUUID != "" {
+		if err := w.scraperClient.DeleteScrape(ctx, scraperUUID); err != nil {
+			w.logger.Warn("failed to delete duplicate scrape", "scraper_uuid", scraperUUID, "error", err)
 		}
 	}
 
-	return false
+	w.logger.Info("scraped content duplicates existing request",
+		"job_id", jobID,
+		"url", url,
+		"duplicate_of", existing.ID,
+	)
+	return true, nil
 }
 
-// shouldSkipURL checks if a URL should be skipped for scraping
-// Returns true if the URL is not scrapeable (non-HTTP/HTTPS, mailto, tel, etc.)
-func shouldSkipURL(rawURL string) bool {
-	parsedURL, err := url.Parse(rawURL)
-	if err != nil {
-		return true // Skip invalid URLs
+// sameURL reports whether two URLs normalize to the same address.
+// URLs that cannot be normalized are compared verbatim.
+func sameURL(a, b string) bool {
+	normalizedA, errA := urlnorm.Normalize(a)
+	normalizedB, errB := urlnorm.Normalize(b)
+	if errA != nil || errB != nil {
+		return a == b
 	}
+	return normalizedA == normalizedB
+}
 
-	// Only allow http and https schemes
-	scheme := strings.ToLower(parsedURL.Scheme)
-	if scheme != "http" && scheme != "https" {
+// contentHash returns a SHA-256 of the whitespace-collapsed, lowercased content,
+// or an empty string when there is no content to compare
+func contentHash(content string) string {
+	return storage.TextHash(content)
+}
+
+// hasCompletedAnalysis reports whether the request already holds the results of a finished text analysis
+func hasCompletedAnalysis(metadata map[string]interface{}) bool {
+	status, _ := metadata[storage.MetaAnalysisStatus].(string)
+	return status == storage.AnalysisStatusCompleted
+}
+
+// ShouldSkipURL checks if a URL should be skipped for scraping or crawling
+// Returns true if the URL is not scrapeable (non-HTTP/HTTPS, mailto, tel, etc.)
+func ShouldSkipURL(rawURL string) bool {
+	// Only allow parseable http and https URLs with a hostname
+	if _, err := urlguard.CheckURL(rawURL); err != nil {
 		return true
 	}
 
 	// Skip image URLs
-	if isImageURL(rawURL) {
+	if clients.IsImageURL(rawURL) {
 		return true
 	}
 
 	return false
 }
 
-// extractAndQueueLinks extracts links and queues them for scraping
+// linkSkipReason returns why an extracted link should not be queued, or "" to queue it.
+// Queued links are recorded in seen so later duplicates are dropped.
+func (w *Worker) linkSkipReason(link string, seen map[string]bool) string {
+	if ShouldSkipURL(link) {
+		return skipReasonUnscrapable
+	}
+	// Never follow links into private networks unless explicitly allowed
+	if err := w.linkGuard().CheckStatic(link); err != nil {
+		return skipReasonPrivateTarget
+	}
+	// Keep crawls inside the configured domain policy
+	decision, err := w.domainPolicy.Evaluate(link)
+	if err != nil {
+		return skipReasonUnscrapable
+	}
+	switch decision.Rule {
+	case urlguard.RuleDomainDenied:
+		return skipReasonDomainDenied
+	case urlguard.RuleDomainNotAllowed:
+		return skipReasonDomainNotAllowed
+	}
+
+	normalized, err := urlnorm.Normalize(link)
+	if err != nil {
+		return skipReasonUnscrapable
+	}
+	if seen[normalized] {
+		return skipReasonDuplicate
+	}
+	seen[normalized] = true
+	return ""
+}
+
+// linkGuard returns the worker's URL guard, defaulting to blocking private targets
+func (w *Worker) linkGuard() *urlguard.Guard {
+	if w.urlGuard == nil {
+		return urlguard.New(false)
+	}
+	return w.urlGuard
+}
+
+// extractAndQueueLinks extracts links and queues them for scraping, recording on the parent
+// job how many were found and why the rest were skipped, and what became of each link.
+// Returns the number of jobs queued.
 func (w *Worker) extractAndQueueLinks(ctx context.Context, parentJobID, sourceURL string, parentDepth int, requestID string) (int, error) {
 	extractResp, err := w.scraperClient.ExtractLinks(ctx, sourceURL)
 	if err != nil {
@@ -509,24 +544,73 @@ func (w *Worker) extractAndQueueLinks(ctx context.Context, parentJobID, sourceUR
 		return 0, fmt.Errorf("failed to extract links: %w", err)
 	}
 
-	// Filter out URLs that should not be scraped (images, mailto, tel, etc.)
+	// Filter out URLs that should not be scraped (images, mailto, tel, etc.), links outside
+	// the domain policy, and links that normalize to an address already seen (including the source page)
+	seen := make(map[string]bool)
+	if normalizedSource, err := urlnorm.Normalize(sourceURL); err == nil {
+		seen[normalizedSource] = true
+	}
+	skipped := make(map[string]int)
 	var scrapableLinks []string
+	// records holds every link in page order; linkRecords[i] is the record of scrapableLinks[i]
+	records := make([]storage.DocumentLink, 0, len(extractResp.Links))
+	var linkRecords []int
 	for _, link := range extractResp.Links {
-		if !shouldSkipURL(link) {
-			scrapableLinks = append(scrapableLinks, link)
+		if reason := w.linkSkipReason(link, seen); reason != "" {
+			w.logger.Debug("skipping extracted link",
+				"source_url", sourceURL,
+				"url", link,
+				"reason", reason,
+			)
+			skipped[reason]++
+			counters.Load().crawlLinksSkipped.WithLabelValues(reason).Inc()
+			records = append(records, storage.DocumentLink{URL: link, Disposition: storage.LinkDispositionSkipped, Reason: reason})
+			continue
 		}
+		linkRecords = append(linkRecords, len(records))
+		records = append(records, storage.DocumentLink{URL: link})
+		scrapableLinks = append(scrapableLinks, link)
 	}
 
-	skippedCount := len(extractResp.Links) - len(scrapableLinks)
-	if skippedCount > 0 {
-		w.logger.Info("filtered out non-scrapable URLs",
+	if len(skipped) > 0 {
+		// Every page of a crawl filters links, so the line is sampled; the summary keeps the totals
+		w.filteredLinksLog.Log(ctx,
 			"source_url", sourceURL,
-			"skipped_count", skippedCount,
+			"skipped_count", len(extractResp.Links)-len(scrapableLinks),
+			"skipped_by_reason", skipped,
 		)
+		for reason, n := range skipped {
+			w.filteredLinksLog.Add("skipped_"+reason, n)
+		}
 	}
 
-	// Process all extracted links (no limit)
+	// Queue only as many links as the crawl budget allows. The reservation locks the root
+	// job, so concurrent extractions under one crawl cannot overshoot it together.
 	links := scrapableLinks
+	rootJobID := crawlRootJobID(ctx, parentJobID)
+	if err := w.taskStopped(ctx, "queueing links"); err != nil {
+		return 0, err
+	}
+	if len(links) > 0 {
+		granted, err := w.storage.ReserveCrawlBudget(rootJobID, parentJobID, len(links), w.crawlMaxPages)
+		if err != nil {
+			return 0, fmt.Errorf("failed to reserve crawl budget: %w", err)
+		}
+		if dropped := len(links) - granted; dropped > 0 {
+			w.logger.Info("crawl budget exhausted, dropping extracted links",
+				"root_job_id", rootJobID,
+				"parent_job_id", parentJobID,
+				"dropped", dropped,
+			)
+			counters.Load().crawlLinksSkipped.WithLabelValues(skipReasonBudgetExhausted).Add(float64(dropped))
+			skipped[skipReasonBudgetExhausted] += dropped
+			for _, i := range linkRecords[granted:] {
+				records[i].Disposition = storage.LinkDispositionSkipped
+				records[i].Reason = skipReasonBudgetExhausted
+			}
+			links = links[:granted]
+		}
+	}
 
 	w.logger.Info("queueing extracted links for scraping",
 		"link_count", len(links),
@@ -534,9 +618,18 @@ func (w *Worker) extractAndQueueLinks(ctx context.Context, parentJobID, sourceUR
 	)
 
 	childDepth := parentDepth + 1
-	shouldExtractLinks := childDepth < w.maxLinkDepth
-
+	params := w.crawlParams(ctx)
+	shouldExtractLinks := childDepth < params.MaxDepth
+	createdBy := childCreatedBy(ctx)
+	namespace := taskNamespace(ctx)
+	skipAnalysis := SkipAnalysis(ctx)
+
+	summary := &storage.LinkExtractionSummary{Found: len(extractResp.Links), Skipped: skipped}
+	childLog := NewLogSampler(w.logger, "queued child job", "child_queued", w.logSampleEvery, 0)
+	specs := make([]ScrapeEnqueueSpec, 0, len(links))
+	specRecords := make([]int, 0, len(links))
 	for i, link := range links {
+		record := &records[linkRecords[i]]
 		jobID := uuid.New().String()
 		job := &storage.ScrapeJob{
 			ID:           jobID,
@@ -546,7 +639,12 @@ func (w *Worker) extractAndQueueLinks(ctx context.Context, parentJobID, sourceUR
 			CreatedAt:    time.Now(),
 			UpdatedAt:    time.Now(),
 			ParentJobID:  &parentJobID,
+			RootJobID:    &rootJobID,
 			Depth:        childDepth,
+			CreatedBy:    createdBy,
+			Namespace:    namespace,
+			CrawlParams:  params,
+			SkipAnalysis: skipAnalysis,
 		}
 
 		if err := w.storage.SaveScrapeJob(job); err != nil {
@@ -554,42 +652,108 @@ func (w *Worker) extractAndQueueLinks(ctx context.Context, parentJobID, sourceUR
 				"url", link,
 				"error", err,
 			)
+			summary.Failed++
+			record.Disposition = storage.LinkDispositionFailed
 			continue
 		}
+		RecordScrapeJobCreated("child", createdBy)
+		record.ChildJobID = &job.ID
+		specRecords = append(specRecords, linkRecords[i])
+		specs = append(specs, ScrapeEnqueueSpec{
+			JobID:        jobID,
+			URL:          link,
+			ExtractLinks: shouldExtractLinks,
+			ParentJobID:  &parentJobID,
+			Depth:        childDepth,
+		})
+	}
 
-		// Enqueue to Asynq with delay to spread load
-		if w.queueClient != nil {
-			// Use background context to start fresh trace for child scrape
-			// This prevents trace tree explosion with deep link extraction
-			// Parent-child relationship still tracked via ParentJobID in DB
-			childCtx := context.Background()
-			taskID, err := w.queueClient.EnqueueScrapeWithParent(childCtx, jobID, link, shouldExtractLinks, &parentJobID, childDepth)
-			if err != nil {
+	// Enqueue the saved children to Asynq in one batch
+	if w.queueClient == nil {
+		summary.Enqueued = len(specs)
+		for _, i := range specRecords {
+			records[i].Disposition = storage.LinkDispositionEnqueued
+		}
+	} else if len(specs) > 0 {
+		// Use background context to start fresh trace for child scrape
+		// This prevents trace tree explosion with deep link extraction
+		// Parent-child relationship still tracked via ParentJobID in DB
+		childCtx := WithRootJobID(WithCreatedBy(context.Background(), createdBy), rootJobID)
+		childCtx = WithNamespace(childCtx, namespace)
+		childCtx = WithCrawlParams(childCtx, params)
+		childCtx = WithSkipAnalysis(childCtx, skipAnalysis)
+		for i, result := range w.queueClient.EnqueueScrapeBatch(childCtx, specs) {
+			spec := specs[i]
+			record := &records[specRecords[i]]
+			if errors.Is(result.Err, ErrDuplicateTask) {
+				// Another job queued the page within the unique window; keep the child for the record
+				if err := w.storage.UpdateScrapeJobSkipped(spec.JobID, skipReasonDuplicate); err != nil {
+					w.logger.Warn("failed to record skipped job", "job_id", spec.JobID, "error", err)
+				}
+				counters.Load().scrapeJobsSkipped.WithLabelValues(skipReasonDuplicate).Inc()
+				RecordScrapeOutcome(ScrapeEntryWorker, ScrapeOutcomeDuplicate, clients.ScoreBucketNone)
+				skipped[skipReasonDuplicate]++
+				record.Disposition = storage.LinkDispositionSkipped
+				record.Reason = skipReasonDuplicate
+				childLog.Add("duplicates", 1)
+				continue
+			}
+			if result.Err != nil {
 				w.logger.Error("failed to enqueue task",
-					"url", link,
-					"error", err,
+					"url", spec.URL,
+					"error", result.Err,
 				)
+				childLog.Add("failed", 1)
+				summary.Failed++
+				record.Disposition = storage.LinkDispositionFailed
 				continue
 			}
+			summary.Enqueued++
+			record.Disposition = storage.LinkDispositionEnqueued
 
 			// Update job with task ID
-			if err := w.storage.UpdateScrapeJobTaskID(jobID, taskID); err != nil {
+			if err := w.storage.UpdateScrapeJobTaskID(spec.JobID, result.TaskID); err != nil {
 				w.logger.Warn("failed to update task ID",
-					"job_id", jobID,
+					"job_id", spec.JobID,
 					"error", err,
 				)
 			}
 
-			w.logger.Info("queued child job",
-				"job_id", jobID,
-				"url", link,
+			childLog.Log(ctx,
+				"job_id", spec.JobID,
+				"url", spec.URL,
 				"extract_links", shouldExtractLinks,
-				"progress", fmt.Sprintf("%d/%d", i+1, len(links)),
+				"progress", fmt.Sprintf("%d/%d", i+1, len(specs)),
 			)
 		}
+		childLog.Flush(ctx,
+			"parent_job_id", parentJobID,
+			"root_job_id", rootJobID,
+			"batch_size", len(specs),
+		)
+	}
+
+	// Keep the accounting on the parent so a crawl report can explain every dropped link
+	if err := w.storage.SaveLinkExtractionSummary(parentJobID, summary); err != nil {
+		w.logger.Warn("failed to save link extraction summary",
+			"job_id", parentJobID,
+			"error", err,
+		)
+	}
+	if truncated, err := w.storage.SaveDocumentLinks(parentJobID, records); err != nil {
+		w.logger.Warn("failed to save document links",
+			"job_id", parentJobID,
+			"error", err,
+		)
+	} else if truncated {
+		w.logger.Info("stored the first links of a large page",
+			"job_id", parentJobID,
+			"found", len(records),
+			"stored", storage.MaxStoredDocumentLinks,
+		)
 	}
 
-	return len(links), nil
+	return summary.Enqueued, nil
 }
 
 // handleExtractLinksTask processes a link extraction task
@@ -608,6 +772,14 @@ func (w *Worker) handleExtractLinksTask(ctx context.Context, t *asynq.Task) erro
 		queueWaitTime = time.Since(enqueuedTime)
 	}
 
+	ctx = WithCreatedBy(ctx, payload.CreatedBy)
+	ctx = WithNamespace(ctx, payload.Namespace)
+	ctx = WithRootJobID(ctx, payload.RootJobID)
+	ctx = WithSkipAnalysis(ctx, payload.SkipAnalysis)
+	if payload.CrawlParams != nil {
+		ctx = WithCrawlParams(ctx, payload.CrawlParams)
+	}
+
 	w.logger.Info("processing extract links task",
 		"parent_job_id", payload.ParentJobID,
 		"source_url", payload.SourceURL,
@@ -684,6 +856,7 @@ func (w *Worker) handleExtractLinksTask(ctx context.Context, t *asynq.Task) erro
 
 	// Extract and queue links - this runs in its own task with its own context
 	linkCount, err := w.extractAndQueueLinks(ctx, payload.ParentJobID, payload.SourceURL, payload.ParentDepth, payload.RequestID)
+	err = w.taskError(ctx, err)
 
 	if err != nil {
 		// Publish link extraction failed event
@@ -706,6 +879,43 @@ func (w *Worker) handleExtractLinksTask(ctx context.Context, t *asynq.Task) erro
 	return nil
 }
 
+// applyQualityTombstone tombstones req when its quality score is below the configured thresholds.
+// Below the severe threshold the severe period applies and SEO is disabled; below the standard
+// threshold the standard period applies and SEO stays enabled. A score of 0 means the analyzer
+// produced none, so thresholds of 0 disable quality tombstoning. Returns the period applied,
+// or 0 when req was left alone.
+func (w *Worker) applyQualityTombstone(req *storage.Request, qualityScore float64, current settings.Values, now time.Time) int {
+	// Archival requests are kept whatever an analysis later makes of them
+	if storage.AnalysisSkipped(req.Metadata) {
+		return 0
+	}
+	if qualityScore <= 0 || qualityScore >= current.StandardQualityThreshold {
+		return 0
+	}
+
+	periodDays := current.TombstonePeriodStandardQuality
+	seoEnabled := true
+	if qualityScore < current.SevereQualityThreshold {
+		periodDays = current.TombstonePeriodSevereQuality
+		seoEnabled = false
+	}
+
+	w.logger.Info("applying quality tombstone",
+		"request_id", req.ID,
+		"quality_score", qualityScore,
+		"severe", !seoEnabled,
+		"period_days", periodDays,
+	)
+
+	if req.Metadata == nil {
+		req.Metadata = make(map[string]interface{})
+	}
+	req.Metadata["tombstone_datetime"] = now.Add(time.Duration(periodDays) * 24 * time.Hour).Format(time.RFC3339)
+	req.Metadata["tombstone_reason"] = fmt.Sprintf("Low quality score: %.2f", qualityScore)
+	req.SEOEnabled = seoEnabled
+	return periodDays
+}
+
 // handleRetrieveAnalysis processes a text analysis result retrieval task
 func (w *Worker) handleRetrieveAnalysis(ctx context.Context, t *asynq.Task) error {
 	// Parse payload
@@ -818,8 +1028,8 @@ func (w *Worker) handleRetrieveAnalysis(ctx context.Context, t *asynq.Task) erro
 			}
 			req.Metadata["analysis_retrieval_timeout"] = true
 			req.Metadata["analysis_retrieval_elapsed_minutes"] = int(elapsedMinutes)
-			req.Metadata["textanalyzer_status"] = "failed"
-			w.storage.UpdateRequestMetadata(payload.RequestID, req.Metadata)
+			storage.SetAnalysisStatus(req.Metadata, storage.AnalysisStatusTimedOut, time.Now())
+			w.storage.UpdateRequestMetadataAs(payload.RequestID, req.Metadata, storage.AuditActorWorker)
 
 			// Publish event for failed status
 			if w.eventPublisherWithDetails != nil {
@@ -829,6 +1039,7 @@ func (w *Worker) handleRetrieveAnalysis(ctx context.Context, t *asynq.Task) erro
 				})
 			}
 		}
+		markTaskOutcome(ctx, taskOutcomePermanentError)
 		return nil // Return success to stop retrying
 	}
 
@@ -848,8 +1059,27 @@ func (w *Worker) handleRetrieveAnalysis(ctx context.Context, t *asynq.Task) erro
 		"status", result.Status,
 	)
 
+	// The analyzer gave up on the job, so retrying would only wait for the timeout
+	if result.Status == "failed" {
+		w.logger.Warn("text analysis failed",
+			"analysis_job_id", payload.AnalysisJobID,
+			"request_id", payload.RequestID,
+			"message", result.Message,
+		)
+		w.updateAnalysisStatus(payload.RequestID, storage.AnalysisStatusFailed, "")
+		if w.eventPublisherWithDetails != nil {
+			w.eventPublisherWithDetails(payload.RequestID, "enrichment_failed", "enriching", "Enrichment failed", map[string]interface{}{
+				"reason":  "analysis_failed",
+				"message": result.Message,
+			})
+		}
+		markTaskOutcome(ctx, taskOutcomePermanentError)
+		return nil // Return success to stop retrying
+	}
+
 	// If analysis not completed yet, return error to trigger retry
 	if result.Status != "completed" {
+		w.markAnalysisProcessing(payload.RequestID)
 		w.logger.Info("analysis not yet completed, will retry later",
 			"analysis_job_id", payload.AnalysisJobID,
 			"status", result.Status,
@@ -858,30 +1088,70 @@ func (w *Worker) handleRetrieveAnalysis(ctx context.Context, t *asynq.Task) erro
 		return fmt.Errorf("analysis not completed (status: %s)", result.Status)
 	}
 
-	// Extract quality score and other metadata from result
-	qualityScore := 0.0
+	return w.applyAnalysisResult(ctx, payload.RequestID, payload.AnalysisJobID, result)
+}
+
+// markAnalysisProcessing moves a request's analysis from queued to processing the first time
+// retrieval finds the analyzer still working on it. Later attempts leave the record alone.
+func (w *Worker) markAnalysisProcessing(requestID string) {
+	w.updateAnalysisStatus(requestID, storage.AnalysisStatusProcessing, storage.AnalysisStatusQueued)
+}
+
+// updateAnalysisStatus records a new analysis status on a request. With from set, only a
+// request currently in that status is changed. Failures are logged, since the status is
+// informational and never worth failing the task over.
+func (w *Worker) updateAnalysisStatus(requestID, status, from string) {
+	req, err := w.storage.GetRequest(requestID)
+	if err != nil {
+		w.logger.Warn("failed to get request for analysis status", "request_id", requestID, "status", status, "error", err)
+		return
+	}
+	if req.Metadata == nil {
+		req.Metadata = make(map[string]interface{})
+	}
+	if current, _ := req.Metadata[storage.MetaAnalysisStatus].(string); from != "" && current != from {
+		return
+	}
+	storage.SetAnalysisStatus(req.Metadata, status, time.Now())
+	if err := w.storage.UpdateRequestMetadataAs(requestID, req.Metadata, storage.AuditActorWorker); err != nil {
+		w.logger.Warn("failed to update analysis status", "request_id", requestID, "status", status, "error", err)
+	}
+}
+
+// analysisQualityScore returns the analyzer's quality score, or 0 when the result has none
+func analysisQualityScore(result *clients.AnalysisJobResult) float64 {
 	if result.Analysis != nil && result.Analysis.Metadata != nil {
 		if scoreVal, ok := result.Analysis.Metadata["quality_score"].(map[string]interface{}); ok {
 			if score, ok := scoreVal["score"].(float64); ok {
-				qualityScore = score
+				return score
 			}
 		}
 	}
+	return 0
+}
+
+// applyAnalysisResult stores a completed text analysis on its request: analyzer metadata,
+// merged AI tags, quality score and quality-based tombstoning. It is shared by the retrieval
+// task and the analysis recovery sweep.
+func (w *Worker) applyAnalysisResult(ctx context.Context, requestID, analysisJobID string, result *clients.AnalysisJobResult) error {
+	// Extract quality score and other metadata from result
+	qualityScore := analysisQualityScore(result)
 
 	w.logger.Info("analysis completed, updating request",
-		"request_id", payload.RequestID,
+		"request_id", requestID,
 		"quality_score", qualityScore,
 	)
 
 	// Get the current request to update it
-	req, err := w.storage.GetRequest(payload.RequestID)
+	req, err := w.storage.GetRequest(requestID)
 	if err != nil {
 		w.logger.Error("failed to get request",
-			"request_id", payload.RequestID,
+			"request_id", requestID,
 			"error", err,
 		)
 		// Don't retry if request not found - it may have been deleted
 		if err.Error() == "request not found" {
+			markTaskOutcome(ctx, taskOutcomeSkipped)
 			return nil
 		}
 		return fmt.Errorf("failed to get request: %w", err)
@@ -901,14 +1171,27 @@ func (w *Worker) handleRetrieveAnalysis(ctx context.Context, t *asynq.Task) erro
 	// Check if analysis result is available
 	if result.Analysis == nil || result.Analysis.Metadata == nil {
 		slog.Default().Warn("textanalyzer analysis not yet available",
-			"analysis_job_id", payload.AnalysisJobID,
+			"analysis_job_id", analysisJobID,
 			"status", result.Status)
 		return fmt.Errorf("analysis not yet available: %s", result.Status)
 	}
 
+	// Re-analysis overwrites the previous enrichment, so keep a snapshot of it first.
+	// A first analysis only adds to the scraped record and needs no snapshot.
+	if hasCompletedAnalysis(req.Metadata) {
+		version, err := w.storage.SaveRequestVersion(requestID, storage.VersionReasonAnalysis)
+		if err != nil {
+			return fmt.Errorf("failed to snapshot request before analysis update: %w", err)
+		}
+		w.logger.Info("saved request version before analysis update",
+			"request_id", requestID,
+			"version", version.Version,
+		)
+	}
+
 	// Debug: log what fields are in the result
 	slog.Default().Info("textanalyzer result fields",
-		"analysis_job_id", payload.AnalysisJobID,
+		"analysis_job_id", analysisJobID,
 		"has_tags", result.Analysis.Metadata["tags"] != nil,
 		"has_synopsis", result.Analysis.Metadata["synopsis"] != nil,
 		"has_cleaned_text", result.Analysis.Metadata["cleaned_text"] != nil,
@@ -919,27 +1202,27 @@ func (w *Worker) handleRetrieveAnalysis(ctx context.Context, t *asynq.Task) erro
 	// Debug: Log the actual field types and lengths from textanalyzer response
 	if ct, ok := result.Analysis.Metadata["cleaned_text"].(string); ok {
 		slog.Default().Info("textanalyzer cleaned_text details",
-			"request_id", payload.RequestID,
+			"request_id", requestID,
 			"type", "string",
 			"length", len(ct),
 			"first_50", ct[:min(50, len(ct))],
 		)
 	} else {
 		slog.Default().Warn("cleaned_text is not a string or doesn't exist",
-			"request_id", payload.RequestID,
+			"request_id", requestID,
 			"value_type", fmt.Sprintf("%T", result.Analysis.Metadata["cleaned_text"]),
 		)
 	}
 	if hct, ok := result.Analysis.Metadata["heuristic_cleaned_text"].(string); ok {
 		slog.Default().Info("textanalyzer heuristic_cleaned_text details",
-			"request_id", payload.RequestID,
+			"request_id", requestID,
 			"type", "string",
 			"length", len(hct),
 			"first_50", hct[:min(50, len(hct))],
 		)
 	} else {
 		slog.Default().Warn("heuristic_cleaned_text is not a string or doesn't exist",
-			"request_id", payload.RequestID,
+			"request_id", requestID,
 			"value_type", fmt.Sprintf("%T", result.Analysis.Metadata["heuristic_cleaned_text"]),
 		)
 	}
@@ -962,7 +1245,7 @@ func (w *Worker) handleRetrieveAnalysis(ctx context.Context, t *asynq.Task) erro
 	if cleanedText, ok := result.Analysis.Metadata["cleaned_text"].(string); ok {
 		analyzerMetadata["cleaned_text"] = cleanedText
 		slog.Default().Info("extracted cleaned_text from textanalyzer",
-			"request_id", payload.RequestID,
+			"request_id", requestID,
 			"length", len(cleanedText),
 			"first_100", cleanedText[:min(100, len(cleanedText))],
 		)
@@ -970,7 +1253,7 @@ func (w *Worker) handleRetrieveAnalysis(ctx context.Context, t *asynq.Task) erro
 	if heuristicCleanedText, ok := result.Analysis.Metadata["heuristic_cleaned_text"].(string); ok {
 		analyzerMetadata["heuristic_cleaned_text"] = heuristicCleanedText
 		slog.Default().Info("extracted heuristic_cleaned_text from textanalyzer",
-			"request_id", payload.RequestID,
+			"request_id", requestID,
 			"length", len(heuristicCleanedText),
 			"first_100", heuristicCleanedText[:min(100, len(heuristicCleanedText))],
 		)
@@ -984,6 +1267,9 @@ func (w *Worker) handleRetrieveAnalysis(ctx context.Context, t *asynq.Task) erro
 	if scoreData, ok := result.Analysis.Metadata["quality_score"].(map[string]interface{}); ok {
 		req.Metadata["quality_score"] = scoreData
 	}
+	if lang, ok := result.Analysis.Metadata["language"].(string); ok {
+		analyzerMetadata["language"] = lang
+	}
 
 	// Merge AI tags with existing computed tags
 	if len(aiTags) > 0 {
@@ -1007,9 +1293,9 @@ func (w *Worker) handleRetrieveAnalysis(ctx context.Context, t *asynq.Task) erro
 			req.Tags = append(req.Tags, tagsToAdd...)
 
 			// Persist merged tags to database
-			if err := w.storage.UpdateRequestTags(payload.RequestID, req.Tags); err != nil {
+			if err := w.storage.UpdateRequestTags(requestID, req.Tags); err != nil {
 				w.logger.Error("failed to update request tags with AI tags",
-					"request_id", payload.RequestID,
+					"request_id", requestID,
 					"ai_tags", aiTags,
 					"error", err,
 				)
@@ -1017,20 +1303,21 @@ func (w *Worker) handleRetrieveAnalysis(ctx context.Context, t *asynq.Task) erro
 			}
 
 			w.logger.Info("merged AI tags with computed tags",
-				"request_id", payload.RequestID,
+				"request_id", requestID,
 				"added_tags", tagsToAdd,
 				"total_tags", len(req.Tags),
 			)
 		}
 	}
 
-	// Update textanalyzer status to completed
-	req.Metadata["textanalyzer_status"] = "completed"
+	// Update textanalyzer status to completed, clearing any earlier timeout left by retrieval
+	storage.SetAnalysisStatus(req.Metadata, storage.AnalysisStatusCompleted, time.Now())
+	clearAnalysisTimeout(req.Metadata)
 
 	// Debug: Log what we're about to save
 	if am, ok := req.Metadata["analyzer_metadata"].(map[string]interface{}); ok {
 		slog.Default().Info("saving analyzer_metadata",
-			"request_id", payload.RequestID,
+			"request_id", requestID,
 			"heuristic_length", len(fmt.Sprintf("%v", am["heuristic_cleaned_text"])),
 			"cleaned_length", len(fmt.Sprintf("%v", am["cleaned_text"])),
 			"has_synopsis", am["synopsis"] != nil,
@@ -1038,61 +1325,28 @@ func (w *Worker) handleRetrieveAnalysis(ctx context.Context, t *asynq.Task) erro
 	}
 
 	// Apply two-tier tombstoning based on quality score
-	const (
-		SEVERE_QUALITY_THRESHOLD   = 0.25 // Below this: 7-day tombstone + SEOEnabled=false
-		STANDARD_QUALITY_THRESHOLD = 0.35 // Below this: 30-day tombstone + SEOEnabled=true
-	)
-
-	seoEnabledChanged := false
-	if qualityScore > 0 && qualityScore < STANDARD_QUALITY_THRESHOLD {
-		now := time.Now()
-		var tombstoneDate time.Time
-		var seoEnabled bool
-
-		if qualityScore < SEVERE_QUALITY_THRESHOLD {
-			// Severe quality issues: 7-day tombstone, hide from SEO immediately
-			tombstoneDate = now.Add(7 * 24 * time.Hour)
-			seoEnabled = false
-			w.logger.Info("applying severe quality tombstone (7 days, SEO disabled)",
-				"request_id", payload.RequestID,
-				"quality_score", qualityScore,
-			)
-		} else {
-			// Standard quality issues: 30-day tombstone, keep in SEO
-			tombstoneDate = now.Add(30 * 24 * time.Hour)
-			seoEnabled = true
-			w.logger.Info("applying standard quality tombstone (30 days, SEO enabled)",
-				"request_id", payload.RequestID,
-				"quality_score", qualityScore,
-			)
-		}
-
-		req.Metadata["tombstone_datetime"] = tombstoneDate.Format(time.RFC3339)
-		req.Metadata["tombstone_reason"] = fmt.Sprintf("Low quality score: %.2f", qualityScore)
-
-		if req.SEOEnabled != seoEnabled {
-			seoEnabledChanged = true
-			req.SEOEnabled = seoEnabled
-		}
-	}
+	seoEnabledBefore := req.SEOEnabled
+	qualityPeriodDays := w.applyQualityTombstone(req, qualityScore, w.settings.Get(), time.Now())
+	qualityTombstoned := qualityPeriodDays > 0
+	seoEnabledChanged := req.SEOEnabled != seoEnabledBefore
 
 	// Debug: Log analyzer_metadata BEFORE saving to database
 	if am, ok := req.Metadata["analyzer_metadata"].(map[string]interface{}); ok {
 		slog.Default().Info("BEFORE database save - analyzer_metadata contents",
-			"request_id", payload.RequestID,
+			"request_id", requestID,
 			"has_cleaned_text", am["cleaned_text"] != nil,
 			"has_heuristic_cleaned_text", am["heuristic_cleaned_text"] != nil,
 		)
 		if ct, ok := am["cleaned_text"].(string); ok {
 			slog.Default().Info("BEFORE save - cleaned_text",
-				"request_id", payload.RequestID,
+				"request_id", requestID,
 				"length", len(ct),
 				"first_100", ct[:min(100, len(ct))],
 			)
 		}
 		if hct, ok := am["heuristic_cleaned_text"].(string); ok {
 			slog.Default().Info("BEFORE save - heuristic_cleaned_text",
-				"request_id", payload.RequestID,
+				"request_id", requestID,
 				"length", len(hct),
 				"first_100", hct[:min(100, len(hct))],
 			)
@@ -1100,72 +1354,59 @@ func (w *Worker) handleRetrieveAnalysis(ctx context.Context, t *asynq.Task) erro
 	}
 
 	// Update the request metadata in database
-	if err := w.storage.UpdateRequestMetadata(payload.RequestID, req.Metadata); err != nil {
+	if err := w.storage.UpdateRequestMetadataAs(requestID, req.Metadata, storage.AuditActorWorker); err != nil {
 		w.logger.Error("failed to update request metadata",
-			"request_id", payload.RequestID,
+			"request_id", requestID,
 			"error", err,
 		)
 		return fmt.Errorf("failed to update request metadata: %w", err)
 	}
 
+	// The analyzer's language, or detection over its cleaned text, replaces the language guessed at scrape time
+	if lang := language.Resolve(req.Metadata); lang != req.Language {
+		if err := w.storage.UpdateRequestLanguage(requestID, lang); err != nil {
+			w.logger.Error("failed to update request language",
+				"request_id", requestID,
+				"error", err,
+			)
+			return fmt.Errorf("failed to update request language: %w", err)
+		}
+	}
+
 	// Update SEO enabled if it changed
 	if seoEnabledChanged {
-		if err := w.storage.UpdateSEOEnabled(payload.RequestID, req.SEOEnabled); err != nil {
+		if err := w.storage.UpdateSEOEnabled(requestID, req.SEOEnabled); err != nil {
 			w.logger.Error("failed to update SEO enabled",
-				"request_id", payload.RequestID,
+				"request_id", requestID,
 				"error", err,
 			)
 			return fmt.Errorf("failed to update SEO enabled: %w", err)
 		}
 	}
 
+	if qualityTombstoned {
+		w.recordAudit(storage.AuditActionTombstone, requestID, map[string]interface{}{
+			"reason":             "low-quality",
+			"quality_score":      qualityScore,
+			"period_days":        qualityPeriodDays,
+			"tombstone_datetime": req.Metadata["tombstone_datetime"],
+			"seo_enabled":        req.SEOEnabled,
+		})
+	}
+
 	// Publish event for completed status AFTER database updates
 	// This ensures the frontend fetches the document with all the new data
 	if w.eventPublisherWithDetails != nil {
-		w.eventPublisherWithDetails(payload.RequestID, "enriched", "enriching", "Document enrichment completed", map[string]interface{}{
+		w.eventPublisherWithDetails(requestID, "enriched", "enriching", "Document enrichment completed", map[string]interface{}{
 			"quality_score": qualityScore,
 		})
 	}
 
 	w.logger.Info("request updated with analysis results",
-		"request_id", payload.RequestID,
+		"request_id", requestID,
 		"quality_score", qualityScore,
 		"seo_enabled", req.SEOEnabled,
 	)
 
 	return nil
 }
-
-// extractDomainTag extracts a domain tag from a URL
-func extractDomainTag(rawURL string) string {
-	parsedURL, err := url.Parse(rawURL)
-	if err != nil {
-		return ""
-	}
-
-	domain := parsedURL.Hostname()
-	// Remove www. prefix if present
-	domain = strings.TrimPrefix(domain, "www.")
-
-	return domain
-}
-
-// compressHTML compresses and base64 encodes HTML text
-func compressHTML(html string) (string, error) {
-	if html == "" {
-		return "", nil
-	}
-
-	var buf bytes.Buffer
-	gzWriter := gzip.NewWriter(&buf)
-
-	if _, err := gzWriter.Write([]byte(html)); err != nil {
-		return "", fmt.Errorf("failed to write to gzip: %w", err)
-	}
-
-	if err := gzWriter.Close(); err != nil {
-		return "", fmt.Errorf("failed to close gzip writer: %w", err)
-	}
-
-	return base64.StdEncoding.EncodeToString(buf.Bytes()), nil
-}
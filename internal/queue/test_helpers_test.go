@@ -0,0 +1,69 @@
+package queue
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/docutag/controller/internal/storage"
+	_ "github.com/lib/pq"
+)
+
+// setupTestStorage creates a throwaway PostgreSQL database and opens storage on it.
+// Tests skip when PostgreSQL is not available; set TEST_DB_* to point at one.
+func setupTestStorage(t *testing.T) *storage.Storage {
+	t.Helper()
+
+	host := getEnvOrDefault("TEST_DB_HOST", "localhost")
+	port := getEnvOrDefault("TEST_DB_PORT", "5432")
+	user := getEnvOrDefault("TEST_DB_USER", "postgres")
+	password := getEnvOrDefault("TEST_DB_PASSWORD", "postgres")
+	connStr := func(dbName string) string {
+		return fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=disable connect_timeout=5",
+			host, port, user, password, dbName)
+	}
+
+	adminDB, err := sql.Open("postgres", connStr("postgres"))
+	if err != nil {
+		t.Skipf("Could not connect to PostgreSQL for testing: %v (set TEST_DB_* env vars if needed)", err)
+	}
+	defer adminDB.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := adminDB.PingContext(ctx); err != nil {
+		t.Skipf("Could not ping PostgreSQL for testing: %v", err)
+	}
+
+	dbName := fmt.Sprintf("test_queue_%d", time.Now().UnixNano())
+	if _, err := adminDB.Exec(fmt.Sprintf("CREATE DATABASE %s", dbName)); err != nil {
+		t.Skipf("Could not create test database: %v", err)
+	}
+
+	store, err := storage.New(connStr(dbName), []string{"low-quality", "sparse-content"}, 30, 90, 90)
+	if err != nil {
+		t.Fatalf("Failed to create test storage: %v", err)
+	}
+
+	t.Cleanup(func() {
+		store.Close()
+		adminDB, err := sql.Open("postgres", connStr("postgres"))
+		if err != nil {
+			return
+		}
+		defer adminDB.Close()
+		adminDB.Exec(fmt.Sprintf("SELECT pg_terminate_backend(pid) FROM pg_stat_activity WHERE datname = '%s'", dbName))
+		adminDB.Exec(fmt.Sprintf("DROP DATABASE IF EXISTS %s", dbName))
+	})
+	return store
+}
+
+func getEnvOrDefault(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
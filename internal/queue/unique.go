@@ -0,0 +1,95 @@
+package queue
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/docutag/controller/internal/storage"
+	"github.com/docutag/controller/internal/urlnorm"
+	"github.com/hibiken/asynq"
+	"github.com/redis/go-redis/v9"
+)
+
+// uniqueKeyPrefix namespaces the Redis keys that claim a URL for one scrape job
+const uniqueKeyPrefix = "controller:unique:scrape:"
+
+// ErrDuplicateTask is returned, wrapped in a DuplicateTaskError, when a scrape for the
+// same URL was enqueued by another job within the unique window
+var ErrDuplicateTask = asynq.ErrDuplicateTask
+
+// DuplicateTaskError names the job that already holds the claim on a URL
+type DuplicateTaskError struct {
+	JobID string // Job whose task is already queued for the URL
+}
+
+func (e *DuplicateTaskError) Error() string {
+	return fmt.Sprintf("scrape already enqueued by job %s", e.JobID)
+}
+
+func (e *DuplicateTaskError) Unwrap() error {
+	return ErrDuplicateTask
+}
+
+// uniqueKey identifies a scrape by its namespace, normalized URL and whether it extracts
+// links, so a crawl seed and a plain scrape of the same page do not block each other and
+// namespaces never see each other's jobs. The default namespace keeps the key it had before
+// namespaces existed, so claims taken across an upgrade still hold.
+func uniqueKey(namespace, rawURL string, extractLinks bool) string {
+	normalized, err := urlnorm.Normalize(rawURL)
+	if err != nil {
+		normalized = rawURL
+	}
+	if namespace != "" && namespace != storage.DefaultNamespace {
+		normalized = namespace + "|" + normalized
+	}
+	sum := sha256.Sum256([]byte(normalized + "|" + strconv.FormatBool(extractLinks)))
+	return uniqueKeyPrefix + hex.EncodeToString(sum[:])
+}
+
+// claimURL reserves the URL for jobID for the unique window. Re-enqueueing the job that
+// holds the claim, as a retry does, is allowed; any other job gets a DuplicateTaskError.
+func (c *Client) claimURL(ctx context.Context, jobID, rawURL string, extractLinks bool) error {
+	if c.rdb == nil || c.uniqueWindow <= 0 {
+		return nil
+	}
+	key := uniqueKey(Namespace(ctx), rawURL, extractLinks)
+
+	// The claim can expire between SETNX and GET; one more attempt settles it
+	for attempt := 0; attempt < 2; attempt++ {
+		claimed, err := c.rdb.SetNX(ctx, key, jobID, c.uniqueWindow).Result()
+		if err != nil {
+			return fmt.Errorf("failed to claim URL: %w", err)
+		}
+		if claimed {
+			return nil
+		}
+
+		holder, err := c.rdb.Get(ctx, key).Result()
+		if errors.Is(err, redis.Nil) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read URL claim: %w", err)
+		}
+		if holder == jobID {
+			return nil
+		}
+		return &DuplicateTaskError{JobID: holder}
+	}
+	return nil
+}
+
+// releaseURL drops jobID's claim after its enqueue failed, so a resubmission is not refused
+func (c *Client) releaseURL(ctx context.Context, jobID, rawURL string, extractLinks bool) {
+	if c.rdb == nil || c.uniqueWindow <= 0 {
+		return
+	}
+	key := uniqueKey(Namespace(ctx), rawURL, extractLinks)
+	if holder, err := c.rdb.Get(ctx, key).Result(); err == nil && holder == jobID {
+		c.rdb.Del(ctx, key)
+	}
+}
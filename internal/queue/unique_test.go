@@ -0,0 +1,108 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+func setupUniqueClient(t *testing.T, window time.Duration) (*Client, *miniredis.Miniredis) {
+	t.Helper()
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("Failed to create miniredis: %v", err)
+	}
+	t.Cleanup(mr.Close)
+
+	client := NewClient(ClientConfig{RedisAddr: mr.Addr(), UniqueWindow: window})
+	t.Cleanup(func() { client.Close() })
+	return client, mr
+}
+
+func TestEnqueueScrapeUniqueByNormalizedURL(t *testing.T) {
+	client, mr := setupUniqueClient(t, 10*time.Minute)
+	ctx := context.Background()
+
+	if _, err := client.EnqueueScrape(ctx, "job-1", "https://Example.com/article?utm_source=x#top", false); err != nil {
+		t.Fatalf("first enqueue failed: %v", err)
+	}
+
+	// The same page under another spelling is refused and names the job that holds it
+	_, err := client.EnqueueScrape(ctx, "job-2", "https://example.com/article", false)
+	var duplicate *DuplicateTaskError
+	if !errors.As(err, &duplicate) || duplicate.JobID != "job-1" {
+		t.Fatalf("expected a duplicate of job-1, got %v", err)
+	}
+	if !errors.Is(err, ErrDuplicateTask) {
+		t.Errorf("expected the error to match ErrDuplicateTask, got %v", err)
+	}
+
+	parentID := "parent"
+	if _, err := client.EnqueueScrapeWithParent(ctx, "job-3", "https://example.com/article", false, &parentID, 1); !errors.Is(err, ErrDuplicateTask) {
+		t.Errorf("expected child enqueues to be deduplicated too, got %v", err)
+	}
+
+	// A crawl seed is a different task from a plain scrape of the same page
+	if _, err := client.EnqueueScrape(ctx, "job-4", "https://example.com/article", true); err != nil {
+		t.Errorf("expected extract_links to be part of the key, got %v", err)
+	}
+
+	// Once the window passes the URL can be queued again
+	mr.FastForward(11 * time.Minute)
+	if _, err := client.EnqueueScrape(ctx, "job-5", "https://example.com/article", false); err != nil {
+		t.Errorf("expected the claim to expire with the window, got %v", err)
+	}
+}
+
+func TestClaimURL(t *testing.T) {
+	client, _ := setupUniqueClient(t, time.Minute)
+	ctx := context.Background()
+
+	if err := client.claimURL(ctx, "job-1", "https://example.com", false); err != nil {
+		t.Fatalf("claim failed: %v", err)
+	}
+	if err := client.claimURL(ctx, "job-1", "https://example.com", false); err != nil {
+		t.Errorf("expected the holder to re-enqueue its own job, got %v", err)
+	}
+
+	client.releaseURL(ctx, "job-2", "https://example.com", false)
+	if err := client.claimURL(ctx, "job-2", "https://example.com", false); !errors.Is(err, ErrDuplicateTask) {
+		t.Errorf("expected only the holder to release the claim, got %v", err)
+	}
+
+	client.releaseURL(ctx, "job-1", "https://example.com", false)
+	if err := client.claimURL(ctx, "job-2", "https://example.com", false); err != nil {
+		t.Errorf("expected a released URL to be claimable, got %v", err)
+	}
+
+	disabled, _ := setupUniqueClient(t, 0)
+	for _, jobID := range []string{"job-1", "job-2"} {
+		if err := disabled.claimURL(ctx, jobID, "https://example.com", false); err != nil {
+			t.Errorf("expected no claims with the window disabled, got %v", err)
+		}
+	}
+}
+
+func TestClaimURLPerNamespace(t *testing.T) {
+	client, _ := setupUniqueClient(t, time.Minute)
+	teamA := WithNamespace(context.Background(), "team-a")
+	teamB := WithNamespace(context.Background(), "team-b")
+
+	if err := client.claimURL(teamA, "job-1", "https://example.com", false); err != nil {
+		t.Fatalf("claim failed: %v", err)
+	}
+	if err := client.claimURL(teamB, "job-2", "https://example.com", false); err != nil {
+		t.Errorf("expected another namespace to claim the URL independently, got %v", err)
+	}
+	if err := client.claimURL(teamA, "job-3", "https://example.com", false); !errors.Is(err, ErrDuplicateTask) {
+		t.Errorf("expected the claim to hold within its namespace, got %v", err)
+	}
+
+	// The default namespace shares the key used before namespaces existed
+	if uniqueKey("", "https://example.com", false) != uniqueKey("default", "https://example.com", false) {
+		t.Error("expected the default namespace to keep the unscoped key")
+	}
+}
@@ -5,12 +5,19 @@ import (
 	"fmt"
 	"log"
 	"log/slog"
+	"sync/atomic"
 	"time"
 
 	"github.com/hibiken/asynq"
 	"github.com/docutag/controller/internal/clients"
+	"github.com/docutag/controller/internal/robots"
+	"github.com/docutag/controller/internal/settings"
 	"github.com/docutag/controller/internal/storage"
+	"github.com/docutag/controller/internal/urlguard"
+	"github.com/docutag/controller/internal/webhooks"
+	"github.com/docutag/controller/pkg/logging"
 	"github.com/docutag/platform/pkg/metrics"
+	"github.com/prometheus/client_golang/prometheus"
 )
 
 // URLCache defines the interface for URL caching
@@ -59,32 +66,63 @@ type EventPublisherWithDetails func(requestID, status, stage, message string, me
 
 // Worker wraps the Asynq server for processing tasks
 type Worker struct {
-	server                  *asynq.Server
-	mux                     *asynq.ServeMux
-	storage                 *storage.Storage
-	scraperClient           *clients.ScraperClient
-	textAnalyzerClient      *clients.TextAnalyzerClient
-	linkScoreThreshold      float64
-	concurrency             int
-	logger                  *slog.Logger
-	queueClient             *Client
-	maxLinkDepth            int
-	urlCache                URLCache
-	tombstonePeriodLowScore   int // Days until deletion for low-score URLs
+	server                    *asynq.Server
+	mux                       *asynq.ServeMux
+	storage                   *storage.Storage
+	scraperClient             *clients.ScraperClient
+	textAnalyzerClient        *clients.TextAnalyzerClient
+	settings                  *settings.Settings        // Runtime-tunable thresholds, crawl depth and tombstone periods
+	domainThresholds          settings.DomainThresholds // Per-domain link score thresholds; nil uses the global one
+	domainTagIncludeHost      bool                      // Tag scraped requests with their full host as well as their registrable domain
+	concurrency               int
+	logger                    *slog.Logger
+	queueClient               *Client
+	urlCache                  URLCache
 	maxAnalysisWaitMinutes    int // Maximum minutes to wait for analysis retrieval before giving up
 	businessMetrics           *metrics.BusinessMetrics
 	eventPublisher            EventPublisher
 	eventPublisherWithDetails EventPublisherWithDetails
+	urlGuard                  *urlguard.Guard        // Rejects unsafe link targets during crawls
+	domainPolicy              *urlguard.DomainPolicy // Keeps crawls inside the allowed domains (nil allows all)
+	robots                    *robots.Checker        // robots.txt pre-check; nil when RESPECT_ROBOTS_TXT is off
+	webhooks                  *webhooks.Dispatcher   // Receives scrape.* events; nil publishes nothing
+	activeTasks               atomic.Int64           // Tasks currently being processed
+	state                     atomic.Int32           // workerStateStarting, workerStateRunning or workerStateStopped
+	crawlMaxPages             int                    // Default pages queued per crawl; 0 is unlimited
+	taskTimeout               time.Duration          // Longest a single task may run; 0 is unbounded
+	tasksCtx                  context.Context        // Parent of every task context, cancelled on shutdown
+	cancelTasks               context.CancelFunc
+	logSampleEvery            int              // After the first few, every Nth child queued by a crawl is logged at Info
+	filteredLinksLog          *logging.Sampler // Samples "filtered out extracted links" across parents
+	taskMetrics               *taskMetrics     // Queue wait and processing histograms by task type and outcome
 }
 
 // WorkerConfig contains configuration for the queue worker
 type WorkerConfig struct {
-	RedisAddr               string
-	Concurrency             int
-	LinkScoreThreshold      float64
-	MaxLinkDepth            int
-	TombstonePeriodLowScore int // Days until deletion for low-score URLs
-	MaxAnalysisWaitMinutes  int // Maximum minutes to wait for analysis retrieval (0 = unlimited, default 60)
+	RedisAddr                      string
+	Concurrency                    int
+	LinkScoreThreshold             float64
+	MaxLinkDepth                   int
+	TombstonePeriodLowScore        int                   // Days until deletion for low-score URLs
+	SevereQualityThreshold         float64               // Quality score below which the severe period applies and SEO is disabled
+	StandardQualityThreshold       float64               // Quality score below which the standard period applies (0 with severe 0 disables)
+	TombstonePeriodSevereQuality   int                   // Days until deletion for severe quality issues (0 = default 7)
+	TombstonePeriodStandardQuality int                   // Days until deletion for standard quality issues (0 = default 30)
+	Settings                       *settings.Settings    // Shared runtime settings; when nil the values above are fixed
+	MaxAnalysisWaitMinutes         int                   // Maximum minutes to wait for analysis retrieval (0 = unlimited, default 60)
+	AllowPrivateTargets            bool                  // Allow crawling loopback/private/link-local hosts (development only)
+	DomainAllowlist                []string              // Only crawl these domains ("*.example.com" wildcards); empty allows all
+	DomainDenylist                 []string              // Never crawl these domains; takes precedence over the allowlist
+	DomainScoreThresholds          map[string]float64    // Link score thresholds that replace the global one for these domains
+	DomainTagIncludeHost           bool                  // Tag requests with their full host as well as their registrable domain
+	RespectRobotsTxt               bool                  // Skip jobs whose URL robots.txt disallows, unless the job overrides it
+	RobotsUserAgent                string                // User agent matched against robots.txt groups
+	RobotsCacheTTL                 time.Duration         // How long each host's robots.txt is cached
+	CrawlMaxPages                  int                   // Pages queued per crawl unless the root job sets max_pages (0 = unlimited)
+	TaskTimeout                    time.Duration         // Longest a single task may run before its job fails with a task timeout (0 = unbounded)
+	Webhooks                       *webhooks.Dispatcher  // Delivers scrape.completed and scrape.failed events (nil = none)
+	LogSampleEvery                 int                   // Log every Nth repetitive crawl line at Info after the first few; <= 1 logs them all
+	MetricsRegisterer              prometheus.Registerer // Registry for the task histograms, shared with the HTTP metrics (nil = default registry)
 }
 
 // NewWorker creates a new queue worker
@@ -109,11 +147,7 @@ func NewWorker(
 
 		// Queue priority: higher value = higher priority
 		// Named queues for clarity: scrape tasks get highest priority, link extraction is lower
-		Queues: map[string]int{
-			"scrape":             6, // URL scraping tasks (highest priority)
-			"analysis-retrieval": 4, // Text analysis result retrieval (medium priority)
-			"link-extraction":    3, // Link extraction and processing (lower priority)
-		},
+		Queues: queuePriorities,
 
 		// StrictPriority: false means queues are processed proportionally
 		// true would mean scrape queue must be empty before processing link-extraction
@@ -164,23 +198,59 @@ func NewWorker(
 		maxAnalysisWait = 60 // Default: 60 minutes for production
 	}
 
+	runtimeSettings := cfg.Settings
+	if runtimeSettings == nil {
+		defaults := settings.Default()
+		defaults.LinkScoreThreshold = cfg.LinkScoreThreshold
+		defaults.MaxLinkDepth = cfg.MaxLinkDepth
+		defaults.TombstonePeriodLowScore = cfg.TombstonePeriodLowScore
+		defaults.SevereQualityThreshold = cfg.SevereQualityThreshold
+		defaults.StandardQualityThreshold = cfg.StandardQualityThreshold
+		if cfg.TombstonePeriodSevereQuality > 0 {
+			defaults.TombstonePeriodSevereQuality = cfg.TombstonePeriodSevereQuality
+		}
+		if cfg.TombstonePeriodStandardQuality > 0 {
+			defaults.TombstonePeriodStandardQuality = cfg.TombstonePeriodStandardQuality
+		}
+		runtimeSettings = settings.New(defaults)
+	}
+
 	w := &Worker{
-		server:                  server,
-		mux:                     mux,
-		storage:                 storage,
-		scraperClient:           scraperClient,
-		textAnalyzerClient:      textAnalyzerClient,
-		linkScoreThreshold:      cfg.LinkScoreThreshold,
-		concurrency:             cfg.Concurrency,
-		logger:                  slog.Default(),
-		queueClient:             queueClient,
-		maxLinkDepth:            cfg.MaxLinkDepth,
-		urlCache:                urlCache,
-		tombstonePeriodLowScore:   cfg.TombstonePeriodLowScore,
+		server:                    server,
+		mux:                       mux,
+		storage:                   storage,
+		scraperClient:             scraperClient,
+		textAnalyzerClient:        textAnalyzerClient,
+		settings:                  runtimeSettings,
+		concurrency:               cfg.Concurrency,
+		logger:                    slog.Default(),
+		queueClient:               queueClient,
+		urlCache:                  urlCache,
 		maxAnalysisWaitMinutes:    maxAnalysisWait,
 		businessMetrics:           businessMetrics,
 		eventPublisher:            eventPublisher,
 		eventPublisherWithDetails: eventPublisherWithDetails,
+		urlGuard:                  urlguard.New(cfg.AllowPrivateTargets),
+		domainPolicy:              urlguard.NewDomainPolicy(cfg.DomainAllowlist, cfg.DomainDenylist),
+		domainThresholds:          settings.NewDomainThresholds(cfg.DomainScoreThresholds),
+		domainTagIncludeHost:      cfg.DomainTagIncludeHost,
+		crawlMaxPages:             cfg.CrawlMaxPages,
+		taskTimeout:               cfg.TaskTimeout,
+		webhooks:                  cfg.Webhooks,
+		logSampleEvery:            cfg.LogSampleEvery,
+	}
+	w.tasksCtx, w.cancelTasks = context.WithCancel(context.Background())
+	w.filteredLinksLog = NewLogSampler(w.logger, "filtered out extracted links", "links_filtered", cfg.LogSampleEvery, LogSampleWindow)
+	if cfg.RespectRobotsTxt {
+		w.robots = robots.NewChecker(cfg.RobotsUserAgent, cfg.RobotsCacheTTL, w.logger)
+	}
+	registerer := cfg.MetricsRegisterer
+	if registerer == nil {
+		registerer = prometheus.DefaultRegisterer
+	}
+	var err error
+	if w.taskMetrics, err = newTaskMetrics(registerer); err != nil {
+		w.logger.Warn("failed to register task metrics", "error", err)
 	}
 
 	// Register task handlers
@@ -191,34 +261,87 @@ func NewWorker(
 
 // registerHandlers registers all task handlers with the worker
 func (w *Worker) registerHandlers() {
+	w.mux.Use(w.trackActive, w.boundTask)
+
 	// Register the scrape URL handler
-	w.mux.HandleFunc(TypeScrapeURL, w.handleScrapeTask)
-	w.mux.HandleFunc(TypeExtractLinks, w.handleExtractLinksTask)
-	w.mux.HandleFunc(TypeRetrieveAnalysis, w.handleRetrieveAnalysis)
+	w.mux.HandleFunc(TypeScrapeURL, w.taskMetrics.observe(TypeScrapeURL, w.handleScrapeTask))
+	w.mux.HandleFunc(TypeExtractLinks, w.taskMetrics.observe(TypeExtractLinks, w.handleExtractLinksTask))
+	w.mux.HandleFunc(TypeRetrieveAnalysis, w.taskMetrics.observe(TypeRetrieveAnalysis, w.handleRetrieveAnalysis))
 }
 
-// Start starts the worker to begin processing tasks
+// Start starts processing tasks in the background and returns once the worker is running.
+// Signals are left to the caller, which stops the worker with Shutdown.
 func (w *Worker) Start() error {
 	w.logger.Info("starting asynq worker",
 		"concurrency", w.concurrency,
-		"queues", map[string]int{"scrape": 6, "analysis-retrieval": 4, "link-extraction": 3},
+		"queues", queuePriorities,
 	)
 
-	// Run is blocking - starts processing tasks
-	if err := w.server.Run(w.mux); err != nil {
+	if err := w.server.Start(w.mux); err != nil {
 		return fmt.Errorf("asynq server error: %w", err)
 	}
+	w.state.CompareAndSwap(workerStateStarting, workerStateRunning)
 
 	return nil
 }
 
-// Shutdown gracefully shuts down the worker
+// Shutdown stops pulling new tasks, cancels the ones in flight and waits for them to return
 func (w *Worker) Shutdown() {
-	w.logger.Info("shutting down asynq worker")
+	w.state.Store(workerStateStopped)
+	w.logger.Info("shutting down asynq worker", "active_tasks", w.ActiveTasks())
+	w.server.Stop()
+	w.cancelTasks()
 	w.server.Shutdown()
+	w.filteredLinksLog.Flush(context.Background())
+}
+
+// ProcessTask runs a task through the worker's handlers without the Asynq server,
+// so tasks can be processed synchronously in tests
+func (w *Worker) ProcessTask(ctx context.Context, t *asynq.Task) error {
+	return w.mux.ProcessTask(ctx, t)
+}
+
+// trackActive counts tasks in flight so ActiveTasks can report pool occupancy
+func (w *Worker) trackActive(next asynq.Handler) asynq.Handler {
+	return asynq.HandlerFunc(func(ctx context.Context, t *asynq.Task) error {
+		w.activeTasks.Add(1)
+		defer w.activeTasks.Add(-1)
+		return next.ProcessTask(ctx, t)
+	})
+}
+
+// ActiveTasks returns the number of tasks currently being processed
+func (w *Worker) ActiveTasks() int {
+	return int(w.activeTasks.Load())
+}
+
+// Concurrency returns the maximum number of tasks processed at once
+func (w *Worker) Concurrency() int {
+	return w.concurrency
 }
 
 // Server returns the underlying Asynq server (for testing)
 func (w *Worker) Server() *asynq.Server {
 	return w.server
 }
+
+// recordAudit writes a best-effort audit entry for a worker-driven mutation of a request
+func (w *Worker) recordAudit(action, requestID string, details map[string]interface{}) {
+	if w.storage == nil {
+		return
+	}
+	entry := &storage.AuditEntry{
+		Actor:      storage.AuditActorWorker,
+		Action:     action,
+		EntityType: storage.AuditEntityRequest,
+		EntityID:   requestID,
+		Details:    details,
+	}
+	if err := w.storage.RecordAudit(entry); err != nil {
+		w.logger.Warn("failed to record audit entry",
+			"action", action,
+			"request_id", requestID,
+			"error", err,
+		)
+	}
+}
@@ -0,0 +1,152 @@
+package robots
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultUserAgent is the product token matched against robots.txt groups
+	DefaultUserAgent = "DocuTagBot"
+	// DefaultCacheTTL is how long a host's robots.txt is reused before it is fetched again
+	DefaultCacheTTL = 6 * time.Hour
+
+	fetchTimeout    = 10 * time.Second
+	failureCacheTTL = 5 * time.Minute // Retry hosts whose robots.txt could not be fetched sooner
+	maxCachedHosts  = 10000
+)
+
+// cacheEntry is a host's parsed robots.txt and when it stops being reused
+type cacheEntry struct {
+	rules     *Rules
+	expiresAt time.Time
+}
+
+// Checker fetches robots.txt per host, caches it, and reports whether URLs may be scraped
+type Checker struct {
+	userAgent string
+	ttl       time.Duration
+	client    *http.Client
+	logger    *slog.Logger
+
+	mu    sync.Mutex
+	cache map[string]cacheEntry
+	now   func() time.Time
+}
+
+// NewChecker creates a checker for the given user agent. A zero ttl uses DefaultCacheTTL.
+func NewChecker(userAgent string, ttl time.Duration, logger *slog.Logger) *Checker {
+	if userAgent == "" {
+		userAgent = DefaultUserAgent
+	}
+	if ttl <= 0 {
+		ttl = DefaultCacheTTL
+	}
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &Checker{
+		userAgent: userAgent,
+		ttl:       ttl,
+		client:    &http.Client{Timeout: fetchTimeout},
+		logger:    logger,
+		cache:     make(map[string]cacheEntry),
+		now:       time.Now,
+	}
+}
+
+// UserAgent returns the user agent sent when fetching robots.txt and matched against its groups
+func (c *Checker) UserAgent() string {
+	return c.userAgent
+}
+
+// Allowed reports whether rawURL may be scraped. It fails open: when robots.txt
+// cannot be fetched the URL is allowed and the failure is logged.
+func (c *Checker) Allowed(ctx context.Context, rawURL string) bool {
+	parsedURL, err := url.Parse(rawURL)
+	if err != nil || parsedURL.Host == "" {
+		return true
+	}
+
+	rules := c.rulesFor(ctx, parsedURL)
+	path := parsedURL.EscapedPath()
+	if parsedURL.RawQuery != "" {
+		path += "?" + parsedURL.RawQuery
+	}
+	return rules.Allowed(c.userAgent, path)
+}
+
+// rulesFor returns the cached rules for the URL's scheme and host, fetching them when missing or expired
+func (c *Checker) rulesFor(ctx context.Context, u *url.URL) *Rules {
+	key := strings.ToLower(u.Scheme + "://" + u.Host)
+
+	c.mu.Lock()
+	entry, ok := c.cache[key]
+	c.mu.Unlock()
+	if ok && c.now().Before(entry.expiresAt) {
+		return entry.rules
+	}
+
+	rules, err := c.fetch(ctx, key+"/robots.txt")
+	ttl := c.ttl
+	if err != nil {
+		c.logger.Warn("failed to fetch robots.txt, allowing scrape",
+			"host", u.Host,
+			"error", err,
+		)
+		rules = AllowAll()
+		ttl = failureCacheTTL
+	}
+
+	c.mu.Lock()
+	if len(c.cache) >= maxCachedHosts {
+		c.evictExpiredLocked()
+	}
+	if len(c.cache) < maxCachedHosts {
+		c.cache[key] = cacheEntry{rules: rules, expiresAt: c.now().Add(ttl)}
+	}
+	c.mu.Unlock()
+
+	return rules
+}
+
+// evictExpiredLocked drops expired entries; c.mu must be held
+func (c *Checker) evictExpiredLocked() {
+	now := c.now()
+	for key, entry := range c.cache {
+		if !now.Before(entry.expiresAt) {
+			delete(c.cache, key)
+		}
+	}
+}
+
+// fetch downloads and parses a robots.txt file. Per RFC 9309 a 4xx response means
+// there are no restrictions; 5xx and network errors are returned to the caller.
+func (c *Checker) fetch(ctx context.Context, robotsURL string) (*Rules, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, robotsURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create robots.txt request: %w", err)
+	}
+	req.Header.Set("User-Agent", c.userAgent)
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch robots.txt: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return Parse(resp.Body), nil
+	case resp.StatusCode >= 400 && resp.StatusCode < 500:
+		return AllowAll(), nil
+	default:
+		return nil, fmt.Errorf("robots.txt returned status %d", resp.StatusCode)
+	}
+}
@@ -0,0 +1,79 @@
+package robots
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCheckerAllowed(t *testing.T) {
+	var fetches atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/robots.txt" {
+			http.NotFound(w, r)
+			return
+		}
+		fetches.Add(1)
+		if got := r.Header.Get("User-Agent"); got != "TestBot/1.0" {
+			t.Errorf("expected User-Agent TestBot/1.0, got %q", got)
+		}
+		w.Write([]byte("User-agent: testbot\nDisallow: /blocked\n"))
+	}))
+	defer server.Close()
+
+	c := NewChecker("TestBot/1.0", time.Hour, nil)
+	ctx := context.Background()
+
+	if !c.Allowed(ctx, server.URL+"/article") {
+		t.Error("expected /article to be allowed")
+	}
+	if c.Allowed(ctx, server.URL+"/blocked/page?x=1") {
+		t.Error("expected /blocked/page to be disallowed")
+	}
+	if n := fetches.Load(); n != 1 {
+		t.Errorf("expected robots.txt to be fetched once, got %d", n)
+	}
+
+	// Expired entries are fetched again
+	c.now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+	c.Allowed(ctx, server.URL+"/article")
+	if n := fetches.Load(); n != 2 {
+		t.Errorf("expected robots.txt to be refetched after the TTL, got %d fetches", n)
+	}
+}
+
+func TestCheckerFailsOpen(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+	}{
+		{"missing robots.txt", http.StatusNotFound},
+		{"forbidden robots.txt", http.StatusForbidden},
+		{"server error", http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+			}))
+			defer server.Close()
+
+			c := NewChecker("", 0, nil)
+			if !c.Allowed(context.Background(), server.URL+"/page") {
+				t.Errorf("expected status %d to allow scraping", tt.status)
+			}
+		})
+	}
+
+	// Unreachable host
+	server := httptest.NewServer(http.NotFoundHandler())
+	unreachable := server.URL
+	server.Close()
+	if !NewChecker("", 0, nil).Allowed(context.Background(), unreachable+"/page") {
+		t.Error("expected an unreachable host to allow scraping")
+	}
+}
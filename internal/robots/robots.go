@@ -0,0 +1,185 @@
+// Package robots parses robots.txt files and answers whether a path may be crawled,
+// following the matching rules of RFC 9309.
+package robots
+
+import (
+	"bufio"
+	"io"
+	"strings"
+)
+
+// maxRobotsBytes is the most of a robots.txt file that is parsed (RFC 9309 requires at least 500 KiB)
+const maxRobotsBytes = 512 * 1024
+
+// rule is a single Allow or Disallow line
+type rule struct {
+	allow   bool
+	pattern string
+}
+
+// group is the set of rules that apply to one or more user agents
+type group struct {
+	agents []string
+	rules  []rule
+}
+
+// Rules is a parsed robots.txt file
+type Rules struct {
+	groups []group
+}
+
+// AllowAll returns rules that permit every path, used when robots.txt is missing
+func AllowAll() *Rules {
+	return &Rules{}
+}
+
+// Parse reads a robots.txt file. Unknown directives and malformed lines are ignored,
+// so Parse never fails; an unreadable body yields whatever was read before the error.
+func Parse(r io.Reader) *Rules {
+	rules := &Rules{}
+	scanner := bufio.NewScanner(io.LimitReader(r, maxRobotsBytes))
+	scanner.Buffer(make([]byte, 0, 64*1024), maxRobotsBytes)
+
+	var current *group
+	inAgentLines := false
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+
+		switch key {
+		case "user-agent":
+			// Consecutive user-agent lines share one group
+			if current == nil || !inAgentLines {
+				rules.groups = append(rules.groups, group{})
+				current = &rules.groups[len(rules.groups)-1]
+			}
+			current.agents = append(current.agents, strings.ToLower(value))
+			inAgentLines = true
+		case "allow", "disallow":
+			inAgentLines = false
+			if current == nil {
+				continue
+			}
+			// An empty Disallow allows everything and so adds nothing
+			if value == "" {
+				continue
+			}
+			current.rules = append(current.rules, rule{allow: key == "allow", pattern: value})
+		default:
+			// Sitemap, crawl-delay and other directives do not end the user-agent block
+		}
+	}
+
+	return rules
+}
+
+// Allowed reports whether userAgent may fetch path (the URL path plus any query).
+// The group for the most specific matching user agent is used, falling back to "*".
+// Within the group the longest matching rule wins, and Allow wins a tie.
+func (r *Rules) Allowed(userAgent, path string) bool {
+	if path == "" {
+		path = "/"
+	}
+	// robots.txt itself is always allowed
+	if path == "/robots.txt" {
+		return true
+	}
+
+	rules := r.rulesFor(userAgent)
+	if len(rules) == 0 {
+		return true
+	}
+
+	bestLen := -1
+	allowed := true
+	for _, rl := range rules {
+		if !matchPattern(rl.pattern, path) {
+			continue
+		}
+		length := len(rl.pattern)
+		if length > bestLen || (length == bestLen && rl.allow) {
+			bestLen = length
+			allowed = rl.allow
+		}
+	}
+	return allowed
+}
+
+// rulesFor merges every group naming the user agent's product token,
+// or every "*" group when none does
+func (r *Rules) rulesFor(userAgent string) []rule {
+	token := productToken(userAgent)
+
+	var specific, wildcard []rule
+	for _, g := range r.groups {
+		for _, agent := range g.agents {
+			if agent == "*" {
+				wildcard = append(wildcard, g.rules...)
+				break
+			}
+			if token != "" && agent == token {
+				specific = append(specific, g.rules...)
+				break
+			}
+		}
+	}
+	if specific != nil {
+		return specific
+	}
+	return wildcard
+}
+
+// productToken extracts the name robots.txt groups match against, e.g.
+// "DocuTagBot/1.0 (+https://docutag.app)" -> "docutagbot"
+func productToken(userAgent string) string {
+	token := strings.ToLower(strings.TrimSpace(userAgent))
+	if i := strings.IndexAny(token, "/ ;("); i >= 0 {
+		token = token[:i]
+	}
+	return token
+}
+
+// matchPattern matches a robots.txt path pattern where "*" matches any sequence
+// of characters and a trailing "$" anchors the end of the path
+func matchPattern(pattern, path string) bool {
+	anchored := strings.HasSuffix(pattern, "$")
+	if anchored {
+		pattern = strings.TrimSuffix(pattern, "$")
+	}
+
+	parts := strings.Split(pattern, "*")
+	// Without wildcards this is a prefix (or exact, when anchored) match
+	if len(parts) == 1 {
+		if anchored {
+			return path == pattern
+		}
+		return strings.HasPrefix(path, pattern)
+	}
+
+	if !strings.HasPrefix(path, parts[0]) {
+		return false
+	}
+	pos := len(parts[0])
+	last := len(parts) - 1
+	for i := 1; i < last; i++ {
+		idx := strings.Index(path[pos:], parts[i])
+		if idx < 0 {
+			return false
+		}
+		pos += idx + len(parts[i])
+	}
+
+	tail := parts[last]
+	if anchored {
+		return len(path)-pos >= len(tail) && strings.HasSuffix(path, tail)
+	}
+	return strings.Contains(path[pos:], tail)
+}
@@ -0,0 +1,136 @@
+package robots
+
+import (
+	"strings"
+	"testing"
+)
+
+const sampleRobots = `
+# Example robots.txt
+User-agent: *
+Disallow: /private/
+Allow: /private/public-report.html
+Disallow: /*.pdf$
+Disallow: /search?
+Disallow: /tmp
+
+User-agent: DocuTagBot
+User-agent: OtherBot
+Disallow: /no-docutag/
+Allow: /
+
+User-agent: BlockedBot
+Disallow: /
+
+Sitemap: https://example.com/sitemap.xml
+`
+
+func TestAllowedPrecedence(t *testing.T) {
+	rules := Parse(strings.NewReader(`
+User-agent: *
+Disallow: /page
+Allow: /page
+Disallow: /folder/
+Allow: /folder/page
+Allow: /a
+Disallow: /a/b
+`))
+
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"/page", true},          // equal length: Allow wins the tie
+		{"/folder/other", false}, // only the Disallow matches
+		{"/folder/page", true},   // longer Allow beats shorter Disallow
+		{"/a/b/c", false},        // longer Disallow beats shorter Allow
+		{"/a/x", true},
+		{"/unlisted", true},
+	}
+	for _, tt := range tests {
+		if got := rules.Allowed("DocuTagBot", tt.path); got != tt.want {
+			t.Errorf("Allowed(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestAllowedWildcards(t *testing.T) {
+	tests := []struct {
+		pattern string
+		path    string
+		want    bool // whether the pattern matches
+	}{
+		{"/*.pdf$", "/docs/report.pdf", true},
+		{"/*.pdf$", "/docs/report.pdf?download=1", false},
+		{"/*.pdf", "/docs/report.pdf?download=1", true},
+		{"/fish*", "/fish.html", true},
+		{"/fish*", "/Fish.html", false},
+		{"/*/archive/", "/2024/archive/index.html", true},
+		{"/*/archive/", "/archive/", false},
+		{"/*?", "/search?q=1", true},
+		{"/*?", "/search", false},
+		{"/exact$", "/exact", true},
+		{"/exact$", "/exact/more", false},
+		{"*", "/anything", true},
+		{"/a*b*c$", "/a-b-c", true},
+		{"/a*b*c$", "/a-c-b", false},
+	}
+	for _, tt := range tests {
+		if got := matchPattern(tt.pattern, tt.path); got != tt.want {
+			t.Errorf("matchPattern(%q, %q) = %v, want %v", tt.pattern, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestAllowedUserAgentGroups(t *testing.T) {
+	rules := Parse(strings.NewReader(sampleRobots))
+
+	tests := []struct {
+		name      string
+		userAgent string
+		path      string
+		want      bool
+	}{
+		{"wildcard group disallows", "SomeCrawler/2.0", "/private/data", false},
+		{"wildcard group allow override", "SomeCrawler/2.0", "/private/public-report.html", true},
+		{"wildcard group anchored pattern", "SomeCrawler/2.0", "/files/a.pdf", false},
+		{"wildcard group query pattern", "SomeCrawler/2.0", "/search?q=go", false},
+		{"wildcard group prefix", "SomeCrawler/2.0", "/tmp-files/x", false},
+		{"specific group ignores wildcard rules", "DocuTagBot/1.0 (+https://docutag.app)", "/private/data", true},
+		{"specific group disallow", "docutagbot", "/no-docutag/page", false},
+		{"shared group", "OtherBot", "/no-docutag/page", false},
+		{"fully blocked agent", "BlockedBot", "/", false},
+		{"robots.txt always allowed", "BlockedBot", "/robots.txt", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := rules.Allowed(tt.userAgent, tt.path); got != tt.want {
+				t.Errorf("Allowed(%q, %q) = %v, want %v", tt.userAgent, tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseEdgeCases(t *testing.T) {
+	// Rules before any user-agent line are ignored
+	rules := Parse(strings.NewReader("Disallow: /\n"))
+	if !rules.Allowed("DocuTagBot", "/page") {
+		t.Error("rules outside a group should be ignored")
+	}
+
+	// An empty Disallow allows everything
+	rules = Parse(strings.NewReader("User-agent: *\nDisallow:\n"))
+	if !rules.Allowed("DocuTagBot", "/page") {
+		t.Error("empty Disallow should allow everything")
+	}
+
+	// Directives and agents are case-insensitive; comments are stripped
+	rules = Parse(strings.NewReader("USER-AGENT: DocuTagBot # us\nDISALLOW: /x # no\n"))
+	if rules.Allowed("DocuTagBot", "/x/y") {
+		t.Error("expected /x/y to be disallowed")
+	}
+
+	if !AllowAll().Allowed("DocuTagBot", "/anything") {
+		t.Error("AllowAll should allow everything")
+	}
+}
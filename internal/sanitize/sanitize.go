@@ -0,0 +1,233 @@
+// Package sanitize cleans untrusted HTML, such as scraped pages and analyzer output, before it
+// is served from our own domain.
+package sanitize
+
+import (
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// Sanitizer cleans untrusted HTML so it can be embedded in a page as-is
+type Sanitizer interface {
+	Sanitize(s string) string
+}
+
+// Policy is an allowlist Sanitizer. Elements it does not list are removed but their text is
+// kept; scripts, styles and embedded content are removed along with their content. Attributes
+// it does not list, including every inline event handler and style, are dropped, and href and
+// src attributes must be relative or use one of its URL schemes.
+type Policy struct {
+	elements map[string]map[string]bool // element -> allowed attributes
+	schemes  map[string]bool
+}
+
+// NewPolicy returns a policy allowing elements, each with the listed attributes, and URLs with
+// the given schemes
+func NewPolicy(elements map[string][]string, schemes ...string) *Policy {
+	p := &Policy{elements: make(map[string]map[string]bool, len(elements)), schemes: make(map[string]bool, len(schemes))}
+	for name, attrs := range elements {
+		allowed := make(map[string]bool, len(attrs))
+		for _, attr := range attrs {
+			allowed[attr] = true
+		}
+		p.elements[name] = allowed
+	}
+	for _, scheme := range schemes {
+		p.schemes[strings.ToLower(scheme)] = true
+	}
+	return p
+}
+
+// DefaultPolicy allows article formatting, tables, links and images
+func DefaultPolicy() *Policy {
+	elements := map[string][]string{
+		"a":    {"href", "title"},
+		"img":  {"src", "alt", "title", "width", "height"},
+		"ol":   {"start"},
+		"td":   {"colspan", "rowspan"},
+		"th":   {"colspan", "rowspan"},
+		"abbr": {"title"},
+	}
+	for _, name := range []string{
+		"p", "br", "hr", "h1", "h2", "h3", "h4", "h5", "h6", "div", "span",
+		"strong", "b", "em", "i", "u", "s", "del", "ins", "sub", "sup", "small", "mark",
+		"code", "pre", "blockquote", "q", "cite", "ul", "li", "dl", "dt", "dd",
+		"figure", "figcaption", "table", "caption", "thead", "tbody", "tfoot", "tr",
+	} {
+		elements[name] = nil
+	}
+	return NewPolicy(elements, "http", "https", "mailto")
+}
+
+// StrictPolicy allows text formatting only: no links, images or tables
+func StrictPolicy() *Policy {
+	elements := map[string][]string{}
+	for _, name := range []string{
+		"p", "br", "strong", "b", "em", "i", "code", "pre", "blockquote", "ul", "ol", "li",
+	} {
+		elements[name] = nil
+	}
+	return NewPolicy(elements)
+}
+
+// dropContent holds elements removed together with everything inside them
+var dropContent = map[string]bool{
+	"script": true, "style": true, "iframe": true, "frame": true, "frameset": true, "object": true,
+	"embed": true, "applet": true, "noscript": true, "template": true, "textarea": true,
+	"select": true, "svg": true, "math": true, "head": true, "title": true,
+}
+
+// voidElements have no closing tag
+var voidElements = map[string]bool{"br": true, "hr": true, "img": true}
+
+// urlAttributes hold URLs that are checked against the policy's schemes
+var urlAttributes = map[string]bool{"href": true, "src": true, "cite": true}
+
+// Sanitize returns s with every element, attribute and URL the policy does not allow removed.
+// Text is re-escaped and elements left open are closed, so the result is well-formed.
+func (p *Policy) Sanitize(s string) string {
+	var b strings.Builder
+	var open []string // allowed elements written and not yet closed
+	walk(s, func(text string) {
+		b.WriteString(html.EscapeString(text))
+	}, func(t tag) {
+		allowed, ok := p.elements[t.name]
+		if !ok {
+			return
+		}
+		if t.closing {
+			// Close the element, and any left open inside it; a stray closing tag is dropped
+			for i := len(open) - 1; i >= 0; i-- {
+				if open[i] == t.name {
+					for j := len(open) - 1; j >= i; j-- {
+						b.WriteString("</" + open[j] + ">")
+					}
+					open = open[:i]
+					break
+				}
+			}
+			return
+		}
+
+		b.WriteString("<" + t.name)
+		for _, a := range t.attrs {
+			if !allowed[a.name] || (urlAttributes[a.name] && !p.allowedURL(a.value)) {
+				continue
+			}
+			b.WriteString(" " + a.name + `="` + html.EscapeString(a.value) + `"`)
+		}
+		if t.name == "a" {
+			b.WriteString(` rel="nofollow noopener noreferrer"`)
+		}
+		b.WriteString(">")
+		if !voidElements[t.name] {
+			open = append(open, t.name)
+		}
+	})
+	for i := len(open) - 1; i >= 0; i-- {
+		b.WriteString("</" + open[i] + ">")
+	}
+	return b.String()
+}
+
+// allowedURL reports whether a URL is relative or uses one of the policy's schemes. Browsers
+// ignore whitespace and control characters inside a scheme ("java\tscript:"), so they are
+// removed before the scheme is read.
+func (p *Policy) allowedURL(u string) bool {
+	u = strings.Map(func(r rune) rune {
+		if r <= ' ' || r == 0x7f {
+			return -1
+		}
+		return r
+	}, u)
+	colon := strings.IndexByte(u, ':')
+	if colon < 0 || strings.ContainsAny(u[:colon], "/?#") {
+		return true
+	}
+	return p.schemes[strings.ToLower(u[:colon])]
+}
+
+// inlineElements are removed from plain text without separating the words around them
+var inlineElements = map[string]bool{
+	"a": true, "abbr": true, "b": true, "cite": true, "code": true, "del": true, "em": true,
+	"i": true, "ins": true, "mark": true, "q": true, "s": true, "small": true, "span": true,
+	"strong": true, "sub": true, "sup": true, "u": true,
+}
+
+// PlainText returns the text of s with all markup removed and entities decoded, for contexts
+// that are not HTML such as JSON-LD and feed summaries. Line breaks are kept; other runs of
+// whitespace are collapsed.
+func PlainText(s string) string {
+	var b strings.Builder
+	walk(s, func(text string) {
+		b.WriteString(text)
+	}, func(t tag) {
+		if t.name == "br" {
+			b.WriteString("\n")
+		} else if !inlineElements[t.name] {
+			b.WriteString(" ")
+		}
+	})
+
+	lines := strings.Split(b.String(), "\n")
+	for i, line := range lines {
+		lines[i] = strings.Join(strings.Fields(line), " ")
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
+
+// attribute is one attribute of a tag, with its value unescaped
+type attribute struct {
+	name, value string
+}
+
+// tag is an opening or closing tag. Comments, doctypes and processing instructions are not.
+type tag struct {
+	name    string // lower case
+	closing bool
+	attrs   []attribute
+}
+
+// walk splits s into unescaped text and tags using the HTML tokenizer, which reads the content
+// of script and style elements as raw text the way browsers do. Comments and declarations are
+// skipped, and so are elements in dropContent together with everything up to their closing tag.
+func walk(s string, onText func(string), onTag func(tag)) {
+	z := html.NewTokenizer(strings.NewReader(s))
+	skip, depth := "", 0 // element whose content is being dropped, and how deeply it is nested
+	for {
+		tt := z.Next()
+		if tt == html.ErrorToken {
+			// io.EOF, including input cut off inside a tag, which is dropped
+			return
+		}
+		tok := z.Token()
+		switch tt {
+		case html.TextToken:
+			if skip == "" {
+				onText(tok.Data)
+			}
+		case html.StartTagToken, html.SelfClosingTagToken, html.EndTagToken:
+			t := tag{name: tok.Data, closing: tt == html.EndTagToken}
+			for _, a := range tok.Attr {
+				t.attrs = append(t.attrs, attribute{name: a.Key, value: a.Val})
+			}
+			switch {
+			case skip != "":
+				if t.name == skip && tt == html.StartTagToken {
+					depth++
+				} else if t.name == skip && t.closing {
+					if depth--; depth == 0 {
+						skip = ""
+					}
+				}
+			case dropContent[t.name]:
+				if tt == html.StartTagToken {
+					skip, depth = t.name, 1
+				}
+			default:
+				onTag(t)
+			}
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package sanitize
+
+import "testing"
+
+func TestDefaultPolicySanitize(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		// Attacks
+		{"script tag", `<p>Hi</p><script>alert(1)</script><p>there</p>`, `<p>Hi</p><p>there</p>`},
+		{"script with odd case and attributes", `<SCRIPT type="text/javascript" >alert(1)</SCRIPT >ok`, `ok`},
+		{"onerror attribute", `<img src="https://example.com/a.png" onerror="alert(1)">`, `<img src="https://example.com/a.png">`},
+		{"unquoted event handler", `<p onclick=alert(1) class=x>text</p>`, `<p>text</p>`},
+		{"javascript href", `<a href="javascript:alert(1)">click</a>`, `<a rel="nofollow noopener noreferrer">click</a>`},
+		{"obfuscated javascript href", `<a href="  JaVa&#x09;Script&colon;alert(1)">click</a>`, `<a rel="nofollow noopener noreferrer">click</a>`},
+		{"data URI image", `<img src="data:image/svg+xml;base64,PHN2Zz4=" alt="x">`, `<img alt="x">`},
+		{"style attribute and element", `<style>body{display:none}</style><p style="position:fixed">a</p>`, `<p>a</p>`},
+		{"iframe", `<iframe src="https://evil.example"></iframe>after`, `after`},
+		{"svg with script", `<svg><script>alert(1)</script><circle/></svg>done`, `done`},
+		{"less-than inside script", `<p>Intro</p><script>for (var i=0;i<n;i++){f(i)}</script><p>Main article body</p>`,
+			`<p>Intro</p><p>Main article body</p>`},
+		{"closing tag inside script string", `<script>var s = "<p>x</p>";</script><p>after</p>`, `<p>after</p>`},
+		{"less-than inside style", `<style>a<b{color:red}</style><p>styled</p>`, `<p>styled</p>`},
+		{"nested dropped elements", `<object><object></object>hidden</object>shown`, `shown`},
+		{"self-closing dropped element", `<svg/><p>kept</p>`, `<p>kept</p>`},
+		{"comment", `a<!-- <script>alert(1)</script> -->b`, `ab`},
+		{"unterminated tag", `text<img src=x onerror=alert(1)`, `text`},
+		{"escaped markup stays text", `&lt;script&gt;alert(1)&lt;/script&gt;`, `&lt;script&gt;alert(1)&lt;/script&gt;`},
+		{"quote in attribute", `<a href='https://example.com/"onmouseover="x'>a</a>`,
+			`<a href="https://example.com/&#34;onmouseover=&#34;x" rel="nofollow noopener noreferrer">a</a>`},
+
+		// Formatting that survives
+		{"paragraphs and emphasis", `<p>Some <em>emphasis</em> and <strong>bold</strong>.</p>`, `<p>Some <em>emphasis</em> and <strong>bold</strong>.</p>`},
+		{"link", `<a href="https://example.com/page" title="Page" target="_blank">link</a>`,
+			`<a href="https://example.com/page" title="Page" rel="nofollow noopener noreferrer">link</a>`},
+		{"relative link", `<a href="/content/other">other</a>`, `<a href="/content/other" rel="nofollow noopener noreferrer">other</a>`},
+		{"http image", `<img src="http://example.com/a.jpg" alt="A photo">`, `<img src="http://example.com/a.jpg" alt="A photo">`},
+		{"line breaks", `one<br>two<br/>three`, `one<br>two<br>three`},
+		{"unknown element keeps its text", `<article><font color="red">text</font></article>`, `text`},
+		{"unclosed elements are closed", `<p><em>open`, `<p><em>open</em></p>`},
+		{"stray closing tag", `a</em>b`, `ab`},
+		{"bare less-than", `1 < 2 & 3 > 2`, `1 &lt; 2 &amp; 3 &gt; 2`},
+	}
+	p := DefaultPolicy()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := p.Sanitize(tt.input); got != tt.want {
+				t.Errorf("Sanitize(%q)\n got %q\nwant %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStrictPolicySanitize(t *testing.T) {
+	t.Parallel()
+	input := `<p>See <a href="https://example.com">this</a> <img src="https://example.com/a.png"> <em>now</em></p>`
+	want := `<p>See this  <em>now</em></p>`
+	if got := StrictPolicy().Sanitize(input); got != want {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+}
+
+func TestPlainText(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{`A <b>bold</b> claim &amp; more`, `A bold claim & more`},
+		{`<p>First</p><p>Second</p>`, `First Second`},
+		{"Line one<br>Line two\n\nNext  paragraph", "Line one\nLine two\n\nNext paragraph"},
+		{`Summary<script>alert("x")</script>`, `Summary`},
+		{`<p>Intro</p><script>for (var i=0;i<n;i++){f(i)}</script><p>Main article body</p>`, `Intro Main article body`},
+		{`<img src=x onerror=alert(1)>caption`, `caption`},
+	}
+	for _, tt := range tests {
+		if got := PlainText(tt.input); got != tt.want {
+			t.Errorf("PlainText(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
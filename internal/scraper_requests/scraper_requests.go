@@ -1,6 +1,7 @@
 package scraper_requests
 
 import (
+	"sort"
 	"sync"
 	"time"
 
@@ -31,6 +32,9 @@ type ScrapeRequest struct {
 	ResultRequestID  string              `json:"result_request_id,omitempty"` // Controller request ID when completed
 	ErrorMessage     string              `json:"error_message,omitempty"`
 	ExpiresAt        time.Time           `json:"expires_at"` // Auto-cleanup after 24 hours
+	BatchID          string              `json:"batch_id,omitempty"` // Set on text requests submitted together through the bulk endpoint
+
+	batchIndex int // Position within the batch's submission
 }
 
 // Manager handles in-memory scrape request tracking
@@ -38,6 +42,10 @@ type Manager struct {
 	requests map[string]*ScrapeRequest // keyed by request ID
 	urlMap   map[string]string         // URL -> request ID mapping for duplicate detection
 	mu       sync.RWMutex
+
+	quit      chan struct{} // Closed by Close to stop the cleanup goroutine
+	done      chan struct{} // Closed when the cleanup goroutine has returned
+	closeOnce sync.Once
 }
 
 // NewManager creates a new scrape request manager
@@ -45,9 +53,11 @@ func NewManager() *Manager {
 	m := &Manager{
 		requests: make(map[string]*ScrapeRequest),
 		urlMap:   make(map[string]string),
+		quit:     make(chan struct{}),
+		done:     make(chan struct{}),
 	}
 
-	// Start cleanup goroutine
+	// Start cleanup goroutine; Close stops it
 	go m.cleanupExpired()
 
 	return m
@@ -112,6 +122,63 @@ func (m *Manager) CreateText(text string) (*ScrapeRequest, bool) {
 	return req, true
 }
 
+// CreateTextBatch creates one text analysis request per text, all sharing a new batch ID,
+// and returns them in the order of texts
+func (m *Manager) CreateTextBatch(texts []string) (string, []*ScrapeRequest) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	batchID := uuid.New().String()
+	now := time.Now()
+	requests := make([]*ScrapeRequest, 0, len(texts))
+	for i, text := range texts {
+		req := &ScrapeRequest{
+			ID:         uuid.New().String(),
+			SourceType: "text",
+			Text:       text,
+			Status:     StatusPending,
+			Progress:   0,
+			CreatedAt:  now,
+			UpdatedAt:  now,
+			ExpiresAt:  now.Add(24 * time.Hour),
+			BatchID:    batchID,
+			batchIndex: i,
+		}
+		m.requests[req.ID] = req
+		requests = append(requests, req)
+	}
+
+	return batchID, requests
+}
+
+// ListText returns copies of the text requests, oldest first. With a batch ID only that
+// batch's requests are returned, in submission order.
+func (m *Manager) ListText(batchID string) []*ScrapeRequest {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var requests []*ScrapeRequest
+	for _, req := range m.requests {
+		if req.SourceType != "text" || (batchID != "" && req.BatchID != batchID) {
+			continue
+		}
+		copied := *req
+		requests = append(requests, &copied)
+	}
+	sort.Slice(requests, func(i, j int) bool {
+		a, b := requests[i], requests[j]
+		if !a.CreatedAt.Equal(b.CreatedAt) {
+			return a.CreatedAt.Before(b.CreatedAt)
+		}
+		if a.BatchID != b.BatchID {
+			return a.BatchID < b.BatchID
+		}
+		return a.batchIndex < b.batchIndex
+	})
+
+	return requests
+}
+
 // Get retrieves a scrape request by ID
 func (m *Manager) Get(id string) (*ScrapeRequest, bool) {
 	m.mu.RLock()
@@ -226,10 +293,17 @@ func (m *Manager) Retry(id string) bool {
 
 // cleanupExpired removes expired scrape requests
 func (m *Manager) cleanupExpired() {
+	defer close(m.done)
 	ticker := time.NewTicker(1 * time.Minute)
 	defer ticker.Stop()
 
-	for range ticker.C {
+	for {
+		select {
+		case <-m.quit:
+			return
+		case <-ticker.C:
+		}
+
 		m.mu.Lock()
 		now := time.Now()
 
@@ -246,3 +320,13 @@ func (m *Manager) cleanupExpired() {
 		m.mu.Unlock()
 	}
 }
+
+// Close stops the cleanup goroutine and waits for it to return. Tracked requests stay
+// readable. Calling Close more than once is safe.
+func (m *Manager) Close() {
+	if m.quit == nil {
+		return
+	}
+	m.closeOnce.Do(func() { close(m.quit) })
+	<-m.done
+}
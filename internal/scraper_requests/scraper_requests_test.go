@@ -529,3 +529,41 @@ func TestTextRequestRetry(t *testing.T) {
 		t.Error("Expected error message to be cleared after retry")
 	}
 }
+
+func TestCreateTextBatch(t *testing.T) {
+	manager := NewManager()
+	defer manager.Close()
+	manager.CreateText("unbatched")
+
+	batchID, requests := manager.CreateTextBatch([]string{"first", "second", "third"})
+	if batchID == "" || len(requests) != 3 {
+		t.Fatalf("Expected a batch ID and 3 requests, got %q and %d", batchID, len(requests))
+	}
+	other, _ := manager.CreateTextBatch([]string{"elsewhere"})
+	if other == batchID {
+		t.Error("Expected each batch to get its own ID")
+	}
+
+	listed := manager.ListText(batchID)
+	if len(listed) != 3 {
+		t.Fatalf("Expected 3 requests in the batch, got %d", len(listed))
+	}
+	for i, req := range listed {
+		if req.ID != requests[i].ID || req.BatchID != batchID || req.SourceType != "text" {
+			t.Errorf("Request %d: expected %s in batch %s, got %+v", i, requests[i].ID, batchID, req)
+		}
+	}
+
+	// Listed requests are copies, so later status changes do not race with readers
+	manager.SetCompleted(requests[0].ID, "result-1")
+	if listed[0].Status != StatusPending {
+		t.Errorf("Expected the listed copy to keep its status, got %s", listed[0].Status)
+	}
+
+	if all := manager.ListText(""); len(all) != 5 {
+		t.Errorf("Expected all 5 text requests, got %d", len(all))
+	}
+	if none := manager.ListText("missing"); len(none) != 0 {
+		t.Errorf("Expected no requests for an unknown batch, got %d", len(none))
+	}
+}
@@ -52,9 +52,11 @@ type SitemapEntry struct {
 
 // ImageSitemapEntry represents a single image entry for sitemap generation
 type ImageSitemapEntry struct {
-	Slug    string
-	Caption string
-	Title   string
+	Slug     string // Image slug; the image is served from {baseURL}/images/{slug} unless ImageURL is set
+	PageSlug string // Slug of the content page the image appears on; "" lists the image under its own slug
+	ImageURL string // Absolute image URL, e.g. on the scraper service
+	Caption  string
+	Title    string
 }
 
 // GenerateSitemap creates an XML sitemap from content entries
@@ -92,28 +94,29 @@ func GenerateImageSitemap(baseURL string, entries []ImageSitemapEntry) ([]byte,
 		URLs:       make([]ImageURL, 0),
 	}
 
-	// Group images by their parent content slug
-	imagesBySlug := make(map[string][]Image)
+	// Group images under their content page, keeping pages in the order they first appear
+	pageIndex := make(map[string]int)
 	for _, entry := range entries {
 		img := Image{
-			Loc:     fmt.Sprintf("%s/images/%s", baseURL, entry.Slug),
+			Loc:     entry.ImageURL,
 			Caption: entry.Caption,
 			Title:   entry.Title,
 		}
+		if img.Loc == "" {
+			img.Loc = fmt.Sprintf("%s/images/%s", baseURL, entry.Slug)
+		}
 
-		// For now, group all images under a common page
-		// In a full implementation, you'd track which images belong to which content
-		slug := entry.Slug
-		imagesBySlug[slug] = append(imagesBySlug[slug], img)
-	}
-
-	// Create URL entries with images
-	for slug, images := range imagesBySlug {
-		imageURL := ImageURL{
-			Loc:    fmt.Sprintf("%s/content/%s", baseURL, slug),
-			Images: images,
+		slug := entry.PageSlug
+		if slug == "" {
+			slug = entry.Slug
+		}
+		i, ok := pageIndex[slug]
+		if !ok {
+			i = len(urlset.URLs)
+			pageIndex[slug] = i
+			urlset.URLs = append(urlset.URLs, ImageURL{Loc: fmt.Sprintf("%s/content/%s", baseURL, slug)})
 		}
-		urlset.URLs = append(urlset.URLs, imageURL)
+		urlset.URLs[i].Images = append(urlset.URLs[i].Images, img)
 	}
 
 	output, err := xml.MarshalIndent(urlset, "", "  ")
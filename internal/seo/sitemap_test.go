@@ -1,6 +1,7 @@
 package seo
 
 import (
+	"encoding/xml"
 	"strings"
 	"testing"
 	"time"
@@ -166,3 +167,48 @@ func TestSitemapXMLEncoding(t *testing.T) {
 		t.Error("Sitemap did not properly encode special characters")
 	}
 }
+
+func TestGenerateImageSitemapGroupsByPage(t *testing.T) {
+	entries := []ImageSitemapEntry{
+		{Slug: "chart", PageSlug: "article-b", ImageURL: "https://scraper.example.com/images/chart", Caption: "Sales & growth", Title: "Article B"},
+		{Slug: "photo", PageSlug: "article-a", ImageURL: "https://scraper.example.com/images/photo", Caption: "A photo", Title: "Article A"},
+		{Slug: "diagram", PageSlug: "article-b", ImageURL: "https://scraper.example.com/images/diagram", Title: "Article B"},
+	}
+
+	xmlData, err := GenerateImageSitemap("https://example.com", entries)
+	if err != nil {
+		t.Fatalf("Failed to generate image sitemap: %v", err)
+	}
+
+	var urlset struct {
+		URLs []struct {
+			Loc    string `xml:"loc"`
+			Images []struct {
+				Loc     string `xml:"loc"`
+				Caption string `xml:"caption"`
+				Title   string `xml:"title"`
+			} `xml:"image"`
+		} `xml:"url"`
+	}
+	if err := xml.Unmarshal(xmlData, &urlset); err != nil {
+		t.Fatalf("Image sitemap is not valid XML: %v", err)
+	}
+
+	// Pages keep the order their first image appeared in, with all of their images
+	if len(urlset.URLs) != 2 {
+		t.Fatalf("Expected 2 pages, got %d", len(urlset.URLs))
+	}
+	b, a := urlset.URLs[0], urlset.URLs[1]
+	if b.Loc != "https://example.com/content/article-b" || a.Loc != "https://example.com/content/article-a" {
+		t.Errorf("Unexpected page order: %s, %s", b.Loc, a.Loc)
+	}
+	if len(b.Images) != 2 || b.Images[0].Loc != "https://scraper.example.com/images/chart" || b.Images[1].Loc != "https://scraper.example.com/images/diagram" {
+		t.Errorf("Unexpected images for article-b: %+v", b.Images)
+	}
+	if b.Images[0].Caption != "Sales & growth" || b.Images[0].Title != "Article B" {
+		t.Errorf("Expected caption and title to round-trip, got %+v", b.Images[0])
+	}
+	if len(a.Images) != 1 || a.Images[0].Caption != "A photo" {
+		t.Errorf("Unexpected images for article-a: %+v", a.Images)
+	}
+}
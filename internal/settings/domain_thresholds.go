@@ -0,0 +1,34 @@
+package settings
+
+import "strings"
+
+// DomainThresholds are link score thresholds for particular domains. Keys are hostnames
+// without a leading "www.", the same form used for a scraped request's domain tag.
+type DomainThresholds map[string]float64
+
+// NewDomainThresholds normalizes the domains in thresholds. Nil is returned when there are none.
+func NewDomainThresholds(thresholds map[string]float64) DomainThresholds {
+	if len(thresholds) == 0 {
+		return nil
+	}
+	d := make(DomainThresholds, len(thresholds))
+	for domain, threshold := range thresholds {
+		d[NormalizeDomain(domain)] = threshold
+	}
+	return d
+}
+
+// NormalizeDomain lowercases domain and strips surrounding space, a trailing dot and a "www." prefix
+func NormalizeDomain(domain string) string {
+	domain = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(domain)), ".")
+	return strings.TrimPrefix(domain, "www.")
+}
+
+// LinkScoreThreshold returns the threshold configured for domain, or fallback when the
+// domain has no override. overridden reports which one was returned.
+func (d DomainThresholds) LinkScoreThreshold(domain string, fallback float64) (threshold float64, overridden bool) {
+	if threshold, ok := d[NormalizeDomain(domain)]; ok && domain != "" {
+		return threshold, true
+	}
+	return fallback, false
+}
@@ -0,0 +1,36 @@
+package settings
+
+import "testing"
+
+func TestDomainThresholds(t *testing.T) {
+	d := NewDomainThresholds(map[string]float64{
+		"OurBlog.com":         0.1,
+		"www.contentfarm.net": 0.9,
+	})
+
+	tests := []struct {
+		domain         string
+		want           float64
+		wantOverridden bool
+	}{
+		{"ourblog.com", 0.1, true},
+		{"www.ourblog.com", 0.1, true},
+		{"contentfarm.net", 0.9, true},
+		{"news.ourblog.com", 0.5, false},
+		{"example.com", 0.5, false},
+		{"", 0.5, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.domain, func(t *testing.T) {
+			got, overridden := d.LinkScoreThreshold(tt.domain, 0.5)
+			if got != tt.want || overridden != tt.wantOverridden {
+				t.Errorf("LinkScoreThreshold(%q) = %v, %v; want %v, %v", tt.domain, got, overridden, tt.want, tt.wantOverridden)
+			}
+		})
+	}
+
+	var none DomainThresholds
+	if got, overridden := none.LinkScoreThreshold("ourblog.com", 0.5); got != 0.5 || overridden {
+		t.Errorf("nil thresholds should fall back, got %v, %v", got, overridden)
+	}
+}
@@ -0,0 +1,215 @@
+// Package settings holds the values operators can tune while the controller is running.
+// Handlers, the worker and storage share one Settings and read a fresh snapshot each time
+// they need a value, so an update through the admin API applies to the next request or task.
+package settings
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// Values are the runtime-tunable settings. JSON names are the keys used by the admin API
+// and the settings table.
+type Values struct {
+	LinkScoreThreshold             float64 `json:"link_score_threshold"`              // Minimum link score to scrape a URL (0.0-1.0)
+	MaxLinkDepth                   int     `json:"max_link_depth"`                    // Maximum crawl depth for link extraction
+	TombstonePeriodLowScore        int     `json:"tombstone_period_low_score"`        // Days until deletion for low-score URLs
+	TombstonePeriodTagBased        int     `json:"tombstone_period_tag_based"`        // Days until deletion for tagged content
+	TombstonePeriodManual          int     `json:"tombstone_period_manual"`           // Days until deletion for manual tombstones
+	SevereQualityThreshold         float64 `json:"severe_quality_threshold"`          // Quality score below which content is tombstoned quickly and hidden from SEO
+	StandardQualityThreshold       float64 `json:"standard_quality_threshold"`        // Quality score below which content is tombstoned
+	TombstonePeriodSevereQuality   int     `json:"tombstone_period_severe_quality"`   // Days until deletion below the severe quality threshold
+	TombstonePeriodStandardQuality int     `json:"tombstone_period_standard_quality"` // Days until deletion below the standard quality threshold
+}
+
+// knownKeys are the JSON names of the Values fields
+var knownKeys = func() map[string]bool {
+	encoded, _ := json.Marshal(Values{})
+	fields := make(map[string]json.RawMessage)
+	_ = json.Unmarshal(encoded, &fields)
+	keys := make(map[string]bool, len(fields))
+	for key := range fields {
+		keys[key] = true
+	}
+	return keys
+}()
+
+// Default returns the built-in values used when configuration does not provide one
+func Default() Values {
+	return Values{
+		LinkScoreThreshold:             0.5,
+		MaxLinkDepth:                   1,
+		TombstonePeriodLowScore:        30,
+		TombstonePeriodTagBased:        90,
+		TombstonePeriodManual:          90,
+		SevereQualityThreshold:         0.25,
+		StandardQualityThreshold:       0.35,
+		TombstonePeriodSevereQuality:   7,
+		TombstonePeriodStandardQuality: 30,
+	}
+}
+
+// Validate checks the values are in range, reporting every problem at once
+func (v Values) Validate() error {
+	var problems []string
+	check := func(ok bool, format string, args ...interface{}) {
+		if !ok {
+			problems = append(problems, fmt.Sprintf(format, args...))
+		}
+	}
+
+	check(v.LinkScoreThreshold >= 0 && v.LinkScoreThreshold <= 1, "link_score_threshold must be between 0.0 and 1.0")
+	check(v.MaxLinkDepth >= 0, "max_link_depth must be >= 0")
+	check(v.TombstonePeriodLowScore > 0, "tombstone_period_low_score must be greater than 0")
+	check(v.TombstonePeriodTagBased > 0, "tombstone_period_tag_based must be greater than 0")
+	check(v.TombstonePeriodManual > 0, "tombstone_period_manual must be greater than 0")
+	check(v.SevereQualityThreshold >= 0 && v.SevereQualityThreshold <= 1, "severe_quality_threshold must be between 0.0 and 1.0")
+	check(v.StandardQualityThreshold >= 0 && v.StandardQualityThreshold <= 1, "standard_quality_threshold must be between 0.0 and 1.0")
+	check(QualityThresholdsOrdered(v.SevereQualityThreshold, v.StandardQualityThreshold),
+		"severe_quality_threshold must be less than standard_quality_threshold unless both are 0")
+	if v.StandardQualityThreshold > 0 {
+		// Periods only matter while quality tombstoning is enabled
+		check(v.TombstonePeriodSevereQuality > 0, "tombstone_period_severe_quality must be greater than 0")
+		check(v.TombstonePeriodStandardQuality > 0, "tombstone_period_standard_quality must be greater than 0")
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("%s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// QualityThresholdsOrdered reports whether the severe quality threshold is below the standard one.
+// Both set to 0 is also accepted and disables quality tombstoning.
+func QualityThresholdsOrdered(severe, standard float64) bool {
+	return severe < standard || (severe == 0 && standard == 0)
+}
+
+// Apply returns v with overrides applied. Keys are the JSON names of Values fields.
+func Apply(v Values, overrides map[string]json.RawMessage) (Values, error) {
+	if len(overrides) == 0 {
+		return v, nil
+	}
+
+	encoded, err := json.Marshal(v)
+	if err != nil {
+		return v, err
+	}
+	fields := make(map[string]json.RawMessage)
+	if err := json.Unmarshal(encoded, &fields); err != nil {
+		return v, err
+	}
+	for key, raw := range overrides {
+		if !knownKeys[key] {
+			return v, fmt.Errorf("unknown setting %q", key)
+		}
+		fields[key] = raw
+	}
+
+	merged, err := json.Marshal(fields)
+	if err != nil {
+		return v, err
+	}
+	var result Values
+	decoder := json.NewDecoder(bytes.NewReader(merged))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&result); err != nil {
+		return v, fmt.Errorf("invalid setting value: %w", err)
+	}
+	return result, nil
+}
+
+// snapshot is an immutable view of the current settings
+type snapshot struct {
+	values    Values
+	overrides map[string]json.RawMessage
+}
+
+// Settings holds the current values over a set of defaults. Reads are lock-free;
+// updates are serialized.
+type Settings struct {
+	defaults Values
+	current  atomic.Pointer[snapshot]
+	mu       sync.Mutex
+}
+
+// New returns Settings with no overrides
+func New(defaults Values) *Settings {
+	s := &Settings{defaults: defaults}
+	s.current.Store(&snapshot{values: defaults})
+	return s
+}
+
+// Get returns the current values
+func (s *Settings) Get() Values {
+	return s.current.Load().values
+}
+
+// Defaults returns the values used for settings without an override
+func (s *Settings) Defaults() Values {
+	return s.defaults
+}
+
+// Overridden returns the sorted keys of settings that differ from configuration
+func (s *Settings) Overridden() []string {
+	overrides := s.current.Load().overrides
+	keys := make([]string, 0, len(overrides))
+	for key := range overrides {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Update applies changes to the current overrides. A change whose value is JSON null
+// removes the override so the default applies again. persist, when not nil, is called
+// with the changes and the resulting values before they take effect; an error from it
+// leaves the settings unchanged. Update returns the values before and after the change.
+func (s *Settings) Update(changes map[string]json.RawMessage, persist func(changes map[string]json.RawMessage, values Values) error) (before, after Values, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	current := s.current.Load()
+	overrides := make(map[string]json.RawMessage, len(current.overrides)+len(changes))
+	for key, raw := range current.overrides {
+		overrides[key] = raw
+	}
+	for key, raw := range changes {
+		if isNull(raw) {
+			delete(overrides, key)
+		} else {
+			overrides[key] = raw
+		}
+	}
+
+	for key := range changes {
+		if !knownKeys[key] {
+			return current.values, current.values, fmt.Errorf("unknown setting %q", key)
+		}
+	}
+
+	values, err := Apply(s.defaults, overrides)
+	if err != nil {
+		return current.values, current.values, err
+	}
+	if err := values.Validate(); err != nil {
+		return current.values, current.values, err
+	}
+	if persist != nil {
+		if err := persist(changes, values); err != nil {
+			return current.values, current.values, err
+		}
+	}
+
+	s.current.Store(&snapshot{values: values, overrides: overrides})
+	return current.values, values, nil
+}
+
+func isNull(raw json.RawMessage) bool {
+	return len(bytes.TrimSpace(raw)) == 0 || bytes.Equal(bytes.TrimSpace(raw), []byte("null"))
+}
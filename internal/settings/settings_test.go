@@ -0,0 +1,156 @@
+package settings
+
+import (
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+)
+
+func changes(t *testing.T, body string) map[string]json.RawMessage {
+	t.Helper()
+	var c map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(body), &c); err != nil {
+		t.Fatalf("bad test body: %v", err)
+	}
+	return c
+}
+
+func TestDefaultIsValid(t *testing.T) {
+	if err := Default().Validate(); err != nil {
+		t.Errorf("Default() is invalid: %v", err)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		mutate func(v *Values)
+	}{
+		{"threshold above one", func(v *Values) { v.LinkScoreThreshold = 1.2 }},
+		{"negative depth", func(v *Values) { v.MaxLinkDepth = -1 }},
+		{"zero low score period", func(v *Values) { v.TombstonePeriodLowScore = 0 }},
+		{"zero tag period", func(v *Values) { v.TombstonePeriodTagBased = 0 }},
+		{"zero manual period", func(v *Values) { v.TombstonePeriodManual = 0 }},
+		{"severe above standard", func(v *Values) { v.SevereQualityThreshold = 0.5 }},
+		{"standard above one", func(v *Values) { v.StandardQualityThreshold = 1.5 }},
+		{"severe equal to standard", func(v *Values) { v.SevereQualityThreshold = v.StandardQualityThreshold }},
+		{"negative severe", func(v *Values) { v.SevereQualityThreshold = -0.1 }},
+		{"zero severe quality period", func(v *Values) { v.TombstonePeriodSevereQuality = 0 }},
+		{"zero standard quality period", func(v *Values) { v.TombstonePeriodStandardQuality = 0 }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := Default()
+			tt.mutate(&v)
+			if err := v.Validate(); err == nil {
+				t.Error("expected validation error, got nil")
+			}
+		})
+	}
+}
+
+func TestValidateAllowsDisabledQualityThresholds(t *testing.T) {
+	v := Default()
+	v.SevereQualityThreshold = 0
+	v.StandardQualityThreshold = 0
+	if err := v.Validate(); err != nil {
+		t.Errorf("both thresholds 0 should be valid, got %v", err)
+	}
+
+	v.StandardQualityThreshold = 0.2
+	if err := v.Validate(); err != nil {
+		t.Errorf("disabling only the severe tier should be valid, got %v", err)
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	s := New(Default())
+
+	before, after, err := s.Update(changes(t, `{"link_score_threshold": 0.3, "max_link_depth": 2}`), nil)
+	if err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	if before.LinkScoreThreshold != 0.5 || after.LinkScoreThreshold != 0.3 {
+		t.Errorf("expected threshold 0.5 -> 0.3, got %v -> %v", before.LinkScoreThreshold, after.LinkScoreThreshold)
+	}
+	if got := s.Get(); got.MaxLinkDepth != 2 || got.TombstonePeriodManual != 90 {
+		t.Errorf("unexpected values after update: %+v", got)
+	}
+	if got := s.Overridden(); len(got) != 2 || got[0] != "link_score_threshold" || got[1] != "max_link_depth" {
+		t.Errorf("unexpected overridden keys: %v", got)
+	}
+
+	// null restores the default
+	if _, _, err := s.Update(changes(t, `{"link_score_threshold": null}`), nil); err != nil {
+		t.Fatalf("reset failed: %v", err)
+	}
+	if got := s.Get(); got.LinkScoreThreshold != 0.5 || got.MaxLinkDepth != 2 {
+		t.Errorf("expected threshold reset and depth kept, got %+v", got)
+	}
+	if got := s.Overridden(); len(got) != 1 {
+		t.Errorf("expected one override after reset, got %v", got)
+	}
+}
+
+func TestUpdateRejects(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+	}{
+		{"unknown key", `{"link_score_treshold": 0.3}`},
+		{"unknown key reset", `{"link_score_treshold": null}`},
+		{"wrong type", `{"max_link_depth": "two"}`},
+		{"out of range", `{"link_score_threshold": 2}`},
+		{"severe above standard", `{"severe_quality_threshold": 0.4}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := New(Default())
+			if _, _, err := s.Update(changes(t, tt.body), nil); err == nil {
+				t.Fatal("expected error, got nil")
+			}
+			if s.Get() != Default() || len(s.Overridden()) != 0 {
+				t.Errorf("rejected update changed settings: %+v", s.Get())
+			}
+		})
+	}
+}
+
+func TestUpdatePersistFailureLeavesValues(t *testing.T) {
+	s := New(Default())
+	persistErr := errors.New("database down")
+
+	_, _, err := s.Update(changes(t, `{"link_score_threshold": 0.3}`), func(map[string]json.RawMessage, Values) error {
+		return persistErr
+	})
+	if !errors.Is(err, persistErr) {
+		t.Fatalf("expected persist error, got %v", err)
+	}
+	if s.Get().LinkScoreThreshold != 0.5 {
+		t.Errorf("expected threshold unchanged, got %v", s.Get().LinkScoreThreshold)
+	}
+}
+
+func TestConcurrentAccess(t *testing.T) {
+	s := New(Default())
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			_, _, _ = s.Update(map[string]json.RawMessage{"max_link_depth": json.RawMessage("3")}, nil)
+		}()
+		go func() {
+			defer wg.Done()
+			_ = s.Get()
+		}()
+	}
+	wg.Wait()
+
+	if s.Get().MaxLinkDepth != 3 {
+		t.Errorf("expected max_link_depth 3, got %d", s.Get().MaxLinkDepth)
+	}
+}
@@ -0,0 +1,250 @@
+// Package sitemap fetches and parses XML sitemaps (https://www.sitemaps.org/protocol.html)
+// so that a whole site can be ingested from its sitemap rather than crawled from the homepage.
+package sitemap
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Limits applied to every fetch regardless of the caller's limit
+const (
+	HardMaxURLs     = 10000            // Never return more page URLs than this
+	MaxSitemaps     = 50               // Child sitemaps followed from index files
+	MaxIndexDepth   = 3                // Index files nested inside index files
+	MaxSitemapBytes = 50 * 1024 * 1024 // Uncompressed size limit from the sitemap protocol
+)
+
+// Document is the content of a single sitemap file.
+// A <urlset> fills URLs; a <sitemapindex> fills Sitemaps.
+type Document struct {
+	URLs     []string
+	Sitemaps []string
+}
+
+// IsIndex reports whether the document is a sitemap index
+func (d *Document) IsIndex() bool {
+	return len(d.Sitemaps) > 0
+}
+
+// Parse reads a sitemap or sitemap index, transparently decompressing gzip content.
+// Entries are returned in document order with surrounding whitespace trimmed.
+func Parse(r io.Reader) (*Document, error) {
+	br := bufio.NewReader(r)
+	if magic, err := br.Peek(2); err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open gzip sitemap: %w", err)
+		}
+		defer gz.Close()
+		return parseXML(io.LimitReader(gz, MaxSitemapBytes))
+	}
+	return parseXML(io.LimitReader(br, MaxSitemapBytes))
+}
+
+func parseXML(r io.Reader) (*Document, error) {
+	decoder := xml.NewDecoder(r)
+	// Sitemaps are almost always UTF-8; accept a declared charset rather than failing on it
+	decoder.CharsetReader = func(charset string, input io.Reader) (io.Reader, error) {
+		return input, nil
+	}
+
+	doc := &Document{}
+	root := ""
+	for {
+		tok, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse sitemap XML: %w", err)
+		}
+
+		start, ok := tok.(xml.StartElement)
+		if !ok {
+			continue
+		}
+
+		if root == "" {
+			root = start.Name.Local
+			if root != "urlset" && root != "sitemapindex" {
+				return nil, fmt.Errorf("unexpected root element <%s>, want <urlset> or <sitemapindex>", root)
+			}
+			continue
+		}
+
+		// Only <url> and <sitemap> entries carry a <loc> we care about
+		if start.Name.Local != "url" && start.Name.Local != "sitemap" {
+			continue
+		}
+		var entry struct {
+			Loc string `xml:"loc"`
+		}
+		if err := decoder.DecodeElement(&entry, &start); err != nil {
+			return nil, fmt.Errorf("failed to parse sitemap entry: %w", err)
+		}
+		loc := strings.TrimSpace(entry.Loc)
+		if loc == "" {
+			continue
+		}
+		if root == "sitemapindex" {
+			doc.Sitemaps = append(doc.Sitemaps, loc)
+		} else {
+			doc.URLs = append(doc.URLs, loc)
+		}
+	}
+
+	if root == "" {
+		return nil, fmt.Errorf("sitemap is empty")
+	}
+	return doc, nil
+}
+
+// Result is the outcome of fetching a sitemap and its children
+type Result struct {
+	URLs            []string // Page URLs in discovery order, at most the requested limit
+	SitemapsFetched int      // Sitemap files downloaded, including the root
+	Truncated       bool     // More URLs or child sitemaps existed than were read
+}
+
+// Fetcher downloads sitemaps over HTTP
+type Fetcher struct {
+	client *http.Client
+	// validate is called for every sitemap URL before it is requested, including redirects
+	validate func(ctx context.Context, rawURL string) error
+}
+
+// NewFetcher creates a fetcher. validate may be nil; when set, any sitemap URL it
+// rejects aborts the fetch (for the root) or is skipped (for children).
+func NewFetcher(timeout time.Duration, validate func(ctx context.Context, rawURL string) error) *Fetcher {
+	f := &Fetcher{validate: validate}
+	f.client = &http.Client{
+		Timeout: timeout,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= 5 {
+				return fmt.Errorf("stopped after %d redirects", len(via))
+			}
+			if f.validate != nil {
+				return f.validate(req.Context(), req.URL.String())
+			}
+			return nil
+		},
+	}
+	return f
+}
+
+// Fetch downloads the sitemap at rawURL, following index files, and returns up to
+// limit page URLs (capped at HardMaxURLs). Failing child sitemaps are skipped;
+// only a failure of the root sitemap is returned as an error.
+func (f *Fetcher) Fetch(ctx context.Context, rawURL string, limit int) (*Result, error) {
+	if limit <= 0 || limit > HardMaxURLs {
+		limit = HardMaxURLs
+	}
+
+	result := &Result{}
+	root, err := f.fetchOne(ctx, rawURL)
+	if err != nil {
+		return nil, err
+	}
+	result.SitemapsFetched++
+
+	type pending struct {
+		url   string
+		depth int
+	}
+	var queue []pending
+	visited := map[string]bool{rawURL: true}
+
+	collect := func(doc *Document, depth int) {
+		for _, u := range doc.URLs {
+			if len(result.URLs) >= limit {
+				result.Truncated = true
+				return
+			}
+			result.URLs = append(result.URLs, u)
+		}
+		for _, child := range doc.Sitemaps {
+			if visited[child] {
+				continue
+			}
+			visited[child] = true
+			if depth+1 > MaxIndexDepth {
+				result.Truncated = true
+				continue
+			}
+			queue = append(queue, pending{url: child, depth: depth + 1})
+		}
+	}
+	collect(root, 0)
+
+	followed := 0
+	for len(queue) > 0 && len(result.URLs) < limit && followed < MaxSitemaps {
+		next := queue[0]
+		queue = queue[1:]
+		followed++
+
+		doc, err := f.fetchOne(ctx, next.url)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			// One broken child should not sink the whole ingest
+			continue
+		}
+		result.SitemapsFetched++
+		collect(doc, next.depth)
+	}
+	if len(queue) > 0 {
+		result.Truncated = true
+	}
+
+	return result, nil
+}
+
+func (f *Fetcher) fetchOne(ctx context.Context, rawURL string) (*Document, error) {
+	if f.validate != nil {
+		if err := f.validate(ctx, rawURL); err != nil {
+			return nil, err
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create sitemap request: %w", err)
+	}
+	req.Header.Set("Accept", "application/xml, text/xml, application/x-gzip;q=0.9, */*;q=0.8")
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch sitemap %s: %w", rawURL, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch sitemap %s: status %d", rawURL, resp.StatusCode)
+	}
+
+	// Read at most the protocol limit (compressed bodies are smaller still)
+	body, err := io.ReadAll(io.LimitReader(resp.Body, MaxSitemapBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read sitemap %s: %w", rawURL, err)
+	}
+	if len(body) > MaxSitemapBytes {
+		return nil, fmt.Errorf("sitemap %s exceeds %d bytes", rawURL, MaxSitemapBytes)
+	}
+
+	doc, err := Parse(bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", rawURL, err)
+	}
+	return doc, nil
+}
@@ -0,0 +1,190 @@
+package sitemap
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func openFixture(t *testing.T, name string) *os.File {
+	t.Helper()
+	f, err := os.Open("testdata/" + name)
+	if err != nil {
+		t.Fatalf("failed to open fixture %s: %v", name, err)
+	}
+	t.Cleanup(func() { f.Close() })
+	return f
+}
+
+func TestParseURLSet(t *testing.T) {
+	doc, err := Parse(openFixture(t, "urlset.xml"))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	want := []string{
+		"https://example.com/",
+		"https://example.com/articles/first",
+		"https://example.com/articles/second?ref=feed&page=2",
+	}
+	if !reflect.DeepEqual(doc.URLs, want) {
+		t.Errorf("URLs = %v, want %v", doc.URLs, want)
+	}
+	if doc.IsIndex() {
+		t.Error("urlset should not be reported as an index")
+	}
+}
+
+func TestParseIndex(t *testing.T) {
+	doc, err := Parse(openFixture(t, "index.xml"))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if !doc.IsIndex() {
+		t.Fatal("expected a sitemap index")
+	}
+	if len(doc.Sitemaps) != 3 || len(doc.URLs) != 0 {
+		t.Errorf("got %d sitemaps and %d urls, want 3 and 0", len(doc.Sitemaps), len(doc.URLs))
+	}
+	if doc.Sitemaps[1] != "{{BASE}}/sitemap-posts.xml.gz" {
+		t.Errorf("unexpected second sitemap %q", doc.Sitemaps[1])
+	}
+}
+
+func TestParseGzip(t *testing.T) {
+	doc, err := Parse(openFixture(t, "posts.xml.gz"))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	want := []string{"https://example.com/posts/1", "https://example.com/posts/2", "https://example.com/posts/3"}
+	if !reflect.DeepEqual(doc.URLs, want) {
+		t.Errorf("URLs = %v, want %v", doc.URLs, want)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	if _, err := Parse(openFixture(t, "not_a_sitemap.xml")); err == nil {
+		t.Error("expected error for non-sitemap root element")
+	}
+	if _, err := Parse(strings.NewReader("")); err == nil {
+		t.Error("expected error for empty input")
+	}
+	if _, err := Parse(strings.NewReader("<urlset><url><loc>https://example.com/</loc>")); err == nil {
+		t.Error("expected error for truncated XML")
+	}
+}
+
+// newSitemapServer serves the fixtures, substituting its own address into the index
+func newSitemapServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/sitemap.xml":
+			data, _ := os.ReadFile("testdata/index.xml")
+			w.Write([]byte(strings.ReplaceAll(string(data), "{{BASE}}", server.URL)))
+		case "/sitemap-pages.xml":
+			http.ServeFile(w, r, "testdata/pages.xml")
+		case "/sitemap-posts.xml.gz":
+			http.ServeFile(w, r, "testdata/posts.xml.gz")
+		case "/loop.xml":
+			fmt.Fprintf(w, `<sitemapindex><sitemap><loc>%s/loop.xml</loc></sitemap><sitemap><loc>%s/sitemap-pages.xml</loc></sitemap></sitemapindex>`, server.URL, server.URL)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestFetchFollowsIndex(t *testing.T) {
+	server := newSitemapServer(t)
+	f := NewFetcher(5*time.Second, nil)
+
+	result, err := f.Fetch(context.Background(), server.URL+"/sitemap.xml", 0)
+	if err != nil {
+		t.Fatalf("Fetch failed: %v", err)
+	}
+
+	want := []string{
+		"https://example.com/about",
+		"https://example.com/contact",
+		"https://example.com/posts/1",
+		"https://example.com/posts/2",
+		"https://example.com/posts/3",
+	}
+	if !reflect.DeepEqual(result.URLs, want) {
+		t.Errorf("URLs = %v, want %v", result.URLs, want)
+	}
+	// Root plus two children; the missing child is skipped
+	if result.SitemapsFetched != 3 {
+		t.Errorf("SitemapsFetched = %d, want 3", result.SitemapsFetched)
+	}
+	if result.Truncated {
+		t.Error("result should not be truncated")
+	}
+}
+
+func TestFetchLimit(t *testing.T) {
+	server := newSitemapServer(t)
+	f := NewFetcher(5*time.Second, nil)
+
+	result, err := f.Fetch(context.Background(), server.URL+"/sitemap.xml", 3)
+	if err != nil {
+		t.Fatalf("Fetch failed: %v", err)
+	}
+	if len(result.URLs) != 3 {
+		t.Errorf("got %d URLs, want 3", len(result.URLs))
+	}
+	if !result.Truncated {
+		t.Error("expected result to be truncated")
+	}
+}
+
+func TestFetchIgnoresIndexLoops(t *testing.T) {
+	server := newSitemapServer(t)
+	f := NewFetcher(5*time.Second, nil)
+
+	result, err := f.Fetch(context.Background(), server.URL+"/loop.xml", 0)
+	if err != nil {
+		t.Fatalf("Fetch failed: %v", err)
+	}
+	if len(result.URLs) != 2 || result.SitemapsFetched != 2 {
+		t.Errorf("got %d URLs from %d sitemaps, want 2 from 2", len(result.URLs), result.SitemapsFetched)
+	}
+}
+
+func TestFetchErrors(t *testing.T) {
+	server := newSitemapServer(t)
+
+	if _, err := NewFetcher(5*time.Second, nil).Fetch(context.Background(), server.URL+"/nope.xml", 0); err == nil {
+		t.Error("expected error for missing root sitemap")
+	}
+
+	rejected := errors.New("rejected")
+	f := NewFetcher(5*time.Second, func(ctx context.Context, rawURL string) error {
+		if strings.HasSuffix(rawURL, ".gz") {
+			return rejected
+		}
+		return nil
+	})
+	result, err := f.Fetch(context.Background(), server.URL+"/sitemap.xml", 0)
+	if err != nil {
+		t.Fatalf("Fetch failed: %v", err)
+	}
+	if len(result.URLs) != 2 {
+		t.Errorf("rejected child sitemap should be skipped, got %d URLs", len(result.URLs))
+	}
+
+	f = NewFetcher(5*time.Second, func(ctx context.Context, rawURL string) error { return rejected })
+	if _, err := f.Fetch(context.Background(), server.URL+"/sitemap.xml", 0); !errors.Is(err, rejected) {
+		t.Errorf("expected validation error for root, got %v", err)
+	}
+}
@@ -4,11 +4,25 @@ import (
 	"regexp"
 	"strings"
 	"unicode"
+	"unicode/utf8"
 
 	"golang.org/x/text/transform"
 	"golang.org/x/text/unicode/norm"
 )
 
+const (
+	// MaxLength is the longest slug Generate and WithSuffix return
+	MaxLength = 80
+
+	// MaxSourceRunes is how much of a long text is worth turning into a slug
+	MaxSourceRunes = 100
+)
+
+var (
+	nonSlugChars = regexp.MustCompile("[^a-z0-9-]+")
+	hyphenRuns   = regexp.MustCompile("-+")
+)
+
 // Generate creates a URL-friendly slug from a string
 func Generate(s string) string {
 	if s == "" {
@@ -26,24 +40,15 @@ func Generate(s string) string {
 	s = strings.ReplaceAll(s, "_", "-")
 
 	// Remove all non-alphanumeric characters except hyphens
-	reg := regexp.MustCompile("[^a-z0-9-]+")
-	s = reg.ReplaceAllString(s, "")
+	s = nonSlugChars.ReplaceAllString(s, "")
 
 	// Remove consecutive hyphens
-	reg = regexp.MustCompile("-+")
-	s = reg.ReplaceAllString(s, "-")
+	s = hyphenRuns.ReplaceAllString(s, "-")
 
 	// Trim hyphens from start and end
 	s = strings.Trim(s, "-")
 
-	// Limit length to 100 characters
-	if len(s) > 100 {
-		s = s[:100]
-		// Trim any trailing hyphen after truncation
-		s = strings.TrimRight(s, "-")
-	}
-
-	return s
+	return truncate(s, MaxLength)
 }
 
 // GenerateWithFallback generates a slug, falling back to a default if the input produces an empty slug
@@ -55,11 +60,77 @@ func GenerateWithFallback(s, fallback string) string {
 	return slug
 }
 
-// transliterate converts unicode characters to ASCII equivalents
+// WithSuffix appends a hyphen and suffix to slug, shortening slug at a word boundary so the
+// result stays within MaxLength. It is used to make a slug that is already taken unique.
+func WithSuffix(slug, suffix string) string {
+	suffix = Generate(suffix)
+	if suffix == "" {
+		return slug
+	}
+	if slug == "" {
+		return suffix
+	}
+	base := truncate(slug, MaxLength-len(suffix)-1)
+	if base == "" {
+		return truncate(suffix, MaxLength)
+	}
+	return base + "-" + suffix
+}
+
+// Truncate returns the first n runes of s, so a long text can be cut down before Generate
+// without splitting a multi-byte character
+func Truncate(s string, n int) string {
+	if n <= 0 {
+		return ""
+	}
+	if len(s) <= n { // Every rune takes at least one byte
+		return s
+	}
+	for i := range s {
+		if n == 0 {
+			return s[:i]
+		}
+		n--
+	}
+	return s
+}
+
+// truncate cuts a generated slug to at most max bytes, at the last hyphen when there is one
+// so words are not split. Generated slugs are ASCII, so bytes are characters.
+func truncate(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+	if max <= 0 {
+		return ""
+	}
+	cut := s[:max]
+	if s[max] != '-' {
+		if i := strings.LastIndexByte(cut, '-'); i > 0 {
+			cut = cut[:i]
+		}
+	}
+	return strings.TrimRight(cut, "-")
+}
+
+// transliterate converts unicode characters to ASCII equivalents: letters with a conventional
+// romanization are spelled out, and diacritics are stripped from the rest
 func transliterate(s string) string {
+	var b strings.Builder
+	b.Grow(len(s))
+	for _, r := range s {
+		if r < utf8.RuneSelf {
+			b.WriteRune(r)
+		} else if latin, ok := romanizations[r]; ok {
+			b.WriteString(latin)
+		} else {
+			b.WriteRune(r)
+		}
+	}
+
 	// Normalize unicode characters to NFD form (decomposed)
 	t := transform.Chain(norm.NFD, transform.RemoveFunc(isMn), norm.NFC)
-	result, _, _ := transform.String(t, s)
+	result, _, _ := transform.String(t, b.String())
 	return result
 }
 
@@ -67,3 +138,24 @@ func transliterate(s string) string {
 func isMn(r rune) bool {
 	return unicode.Is(unicode.Mn, r)
 }
+
+// romanizations spells out lowercase letters that do not decompose into an ASCII letter and
+// a diacritic. Scripts without an entry, such as CJK, are dropped, so text written only in
+// them falls back to GenerateWithFallback's default.
+var romanizations = map[rune]string{
+	// Latin letters without a decomposition
+	'ß': "ss", 'æ': "ae", 'œ': "oe", 'ø': "o", 'đ': "d", 'ð': "d", 'þ': "th", 'ł': "l", 'ı': "i",
+
+	// Cyrillic (Russian, Ukrainian, Belarusian)
+	'а': "a", 'б': "b", 'в': "v", 'г': "g", 'ґ': "g", 'д': "d", 'е': "e", 'ё': "yo", 'є': "ye",
+	'ж': "zh", 'з': "z", 'и': "i", 'і': "i", 'ї': "yi", 'й': "y", 'к': "k", 'л': "l", 'м': "m",
+	'н': "n", 'о': "o", 'п': "p", 'р': "r", 'с': "s", 'т': "t", 'у': "u", 'ў': "u", 'ф': "f",
+	'х': "kh", 'ц': "ts", 'ч': "ch", 'ш': "sh", 'щ': "shch", 'ъ': "", 'ы': "y", 'ь': "", 'э': "e",
+	'ю': "yu", 'я': "ya",
+
+	// Greek
+	'α': "a", 'β': "v", 'γ': "g", 'δ': "d", 'ε': "e", 'ζ': "z", 'η': "i", 'θ': "th", 'ι': "i",
+	'κ': "k", 'λ': "l", 'μ': "m", 'ν': "n", 'ξ': "x", 'ο': "o", 'π': "p", 'ρ': "r", 'σ': "s",
+	'ς': "s", 'τ': "t", 'υ': "y", 'φ': "f", 'χ': "ch", 'ψ': "ps", 'ω': "o",
+	'ά': "a", 'έ': "e", 'ή': "i", 'ί': "i", 'ό': "o", 'ύ': "y", 'ώ': "o", 'ϊ': "i", 'ϋ': "y",
+}
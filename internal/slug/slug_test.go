@@ -0,0 +1,124 @@
+package slug
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestGenerate(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"ascii", "Hello, World!", "hello-world"},
+		{"underscores and runs", "snake_case  --  title", "snake-case-title"},
+		{"diacritics", "Crème Brûlée à la Française", "creme-brulee-a-la-francaise"},
+		{"german", "Straße über Köln", "strasse-uber-koln"},
+		{"polish", "Łódź Zażółć", "lodz-zazolc"},
+		{"russian", "Привет, мир! Щука и ёж", "privet-mir-shchuka-i-yozh"},
+		{"ukrainian", "Київ — столиця України", "kiyiv-stolitsya-ukrayini"},
+		{"greek", "Καλημέρα κόσμε", "kalimera-kosme"},
+		{"cjk is dropped", "東京 Tokyo 2024", "tokyo-2024"},
+		{"emoji is dropped", "🚀 Launch day 🎉", "launch-day"},
+		{"only cjk", "日本語のタイトル", ""},
+		{"empty", "", ""},
+	}
+	for _, tt := range tests {
+		if got := Generate(tt.in); got != tt.want {
+			t.Errorf("%s: Generate(%q) = %q, want %q", tt.name, tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestGenerateMaxLength(t *testing.T) {
+	title := strings.Repeat("word ", 40)
+	got := Generate(title)
+	if len(got) > MaxLength {
+		t.Fatalf("Expected at most %d characters, got %d: %q", MaxLength, len(got), got)
+	}
+	if strings.HasSuffix(got, "-") || !strings.HasSuffix(got, "word") {
+		t.Errorf("Expected the slug to end on a whole word, got %q", got)
+	}
+
+	// A single long word is cut where it must be
+	if got := Generate(strings.Repeat("a", 200)); len(got) != MaxLength {
+		t.Errorf("Expected a hyphenless slug cut at %d, got %d characters", MaxLength, len(got))
+	}
+
+	// A word ending exactly at the limit is kept
+	exact := strings.Repeat("a", MaxLength) + " tail"
+	if got := Generate(exact); got != strings.Repeat("a", MaxLength) {
+		t.Errorf("Expected the word ending at the limit to be kept, got %q", got)
+	}
+}
+
+func TestGenerateWithFallback(t *testing.T) {
+	if got := GenerateWithFallback("Hello", "fallback-id"); got != "hello" {
+		t.Errorf("Expected the natural slug, got %q", got)
+	}
+	if got := GenerateWithFallback("日本語", "550e8400-e29b-41d4"); got != "550e8400-e29b-41d4" {
+		t.Errorf("Expected the fallback for a title without Latin letters, got %q", got)
+	}
+}
+
+func TestWithSuffix(t *testing.T) {
+	tests := []struct {
+		name   string
+		slug   string
+		suffix string
+		want   string
+	}{
+		{"short", "hello-world", "550e8400", "hello-world-550e8400"},
+		{"empty slug", "", "550e8400", "550e8400"},
+		{"empty suffix", "hello-world", "", "hello-world"},
+		{"suffix is slugged", "hello", "AB_12", "hello-ab-12"},
+	}
+	for _, tt := range tests {
+		if got := WithSuffix(tt.slug, tt.suffix); got != tt.want {
+			t.Errorf("%s: WithSuffix(%q, %q) = %q, want %q", tt.name, tt.slug, tt.suffix, got, tt.want)
+		}
+	}
+
+	// A slug at the limit is shortened at a word boundary to make room
+	long := Generate(strings.Repeat("word ", 40))
+	got := WithSuffix(long, "550e8400e29b41d4a716446655440000")
+	if len(got) > MaxLength {
+		t.Fatalf("Expected at most %d characters, got %d: %q", MaxLength, len(got), got)
+	}
+	if !strings.HasSuffix(got, "-word-550e8400e29b41d4a716446655440000") {
+		t.Errorf("Expected whole words followed by the suffix, got %q", got)
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		n    int
+		want string
+	}{
+		{"ascii", "hello world", 5, "hello"},
+		{"shorter than n", "hi", 5, "hi"},
+		{"cyrillic", "Привет мир", 6, "Привет"},
+		{"cjk", "東京都の天気", 2, "東京"},
+		{"emoji", "🚀🎉✨", 2, "🚀🎉"},
+		{"zero", "hello", 0, ""},
+	}
+	for _, tt := range tests {
+		got := Truncate(tt.in, tt.n)
+		if got != tt.want {
+			t.Errorf("%s: Truncate(%q, %d) = %q, want %q", tt.name, tt.in, tt.n, got, tt.want)
+		}
+		if !utf8.ValidString(got) {
+			t.Errorf("%s: Truncate returned invalid UTF-8 %q", tt.name, got)
+		}
+	}
+
+	// Byte slicing at MaxSourceRunes would split these runes
+	text := strings.Repeat("ж", 150)
+	if got := Generate(Truncate(text, MaxSourceRunes)); got != truncate(strings.Repeat("zh", MaxSourceRunes), MaxLength) {
+		t.Errorf("Expected a clean slug from truncated Cyrillic text, got %q", got)
+	}
+}
@@ -0,0 +1,74 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+)
+
+// ListRequestsWithAnalysisTimeout returns requests whose analysis retrieval gave up
+// (metadata analysis_retrieval_timeout = true). Requests never checked by the recovery
+// sweep come first, then those checked longest ago, so a batch limit still rotates
+// through every stuck request.
+func (s *Storage) ListRequestsWithAnalysisTimeout(limit int) ([]*Request, error) {
+	defer s.timeQuery("ListRequestsWithAnalysisTimeout", "limit", limit)()
+	rows, err := s.db.Query(`
+		SELECT id, created_at, source_type, source_url, scraper_uuid, textanalyzer_uuid, metadata_json
+		FROM requests
+		WHERE metadata_json->>'analysis_retrieval_timeout' = 'true' AND `+notDeletedPredicate+`
+		ORDER BY metadata_json->>'analysis_recovery_checked_at' ASC NULLS FIRST, created_at ASC
+		LIMIT $1
+	`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list requests with analysis timeout: %w", err)
+	}
+	defer rows.Close()
+
+	var requests []*Request
+	for rows.Next() {
+		var req Request
+		var metadataJSON sql.NullString
+
+		if err := rows.Scan(&req.ID, &req.CreatedAt, &req.SourceType, &req.SourceURL, &req.ScraperUUID, &req.TextAnalyzerUUID, &metadataJSON); err != nil {
+			return nil, fmt.Errorf("failed to scan request: %w", err)
+		}
+
+		if metadataJSON.Valid && metadataJSON.String != "" {
+			if err := json.Unmarshal([]byte(metadataJSON.String), &req.Metadata); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal metadata: %w", err)
+			}
+		}
+
+		requests = append(requests, &req)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+
+	return requests, nil
+}
+
+// UpdateTextAnalyzerUUID points a request at a new text analyzer job, e.g. after its analysis is re-enqueued
+func (s *Storage) UpdateTextAnalyzerUUID(id, jobID string) error {
+	defer s.timeQuery("UpdateTextAnalyzerUUID", "id", id)()
+	result, err := s.db.Exec(`
+		UPDATE requests
+		SET textanalyzer_uuid = $1, row_version = row_version + 1
+		WHERE id = $2
+	`, jobID, id)
+	if err != nil {
+		return fmt.Errorf("failed to update textanalyzer uuid: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("request not found")
+	}
+
+	return nil
+}
@@ -0,0 +1,105 @@
+package storage
+
+import (
+	"testing"
+	"time"
+)
+
+func TestListRequestsWithAnalysisTimeout(t *testing.T) {
+	t.Parallel()
+	store, cleanup := setupTestStorage(t)
+	defer cleanup()
+
+	now := time.Now().UTC()
+	fixtures := []struct {
+		id       string
+		metadata map[string]interface{}
+	}{
+		{"timed-out-old", map[string]interface{}{"analysis_retrieval_timeout": true, "textanalyzer_job_id": "ta-1"}},
+		{"timed-out-checked", map[string]interface{}{
+			"analysis_retrieval_timeout":   true,
+			"analysis_recovery_checked_at": now.Format(time.RFC3339),
+		}},
+		{"timed-out-new", map[string]interface{}{"analysis_retrieval_timeout": true}},
+		{"completed", map[string]interface{}{"textanalyzer_status": "completed"}},
+		{"deleted", map[string]interface{}{"analysis_retrieval_timeout": true}},
+	}
+	for i, f := range fixtures {
+		req := &Request{
+			ID:               f.id,
+			CreatedAt:        now.Add(time.Duration(i) * time.Minute),
+			SourceType:       "url",
+			TextAnalyzerUUID: "ta-" + f.id,
+			Metadata:         f.metadata,
+		}
+		if err := store.SaveRequest(req); err != nil {
+			t.Fatalf("Failed to save request %s: %v", f.id, err)
+		}
+	}
+	if _, err := store.SoftDeleteRequest("deleted"); err != nil {
+		t.Fatalf("Failed to soft delete request: %v", err)
+	}
+
+	requests, err := store.ListRequestsWithAnalysisTimeout(10)
+	if err != nil {
+		t.Fatalf("ListRequestsWithAnalysisTimeout failed: %v", err)
+	}
+
+	var ids []string
+	for _, req := range requests {
+		ids = append(ids, req.ID)
+	}
+	want := []string{"timed-out-old", "timed-out-new", "timed-out-checked"}
+	if len(ids) != len(want) {
+		t.Fatalf("Expected %v, got %v", want, ids)
+	}
+	for i := range want {
+		if ids[i] != want[i] {
+			t.Errorf("Expected %v, got %v", want, ids)
+			break
+		}
+	}
+	if requests[0].Metadata["textanalyzer_job_id"] != "ta-1" {
+		t.Errorf("Expected metadata to be loaded, got %v", requests[0].Metadata)
+	}
+
+	limited, err := store.ListRequestsWithAnalysisTimeout(1)
+	if err != nil {
+		t.Fatalf("ListRequestsWithAnalysisTimeout failed: %v", err)
+	}
+	if len(limited) != 1 {
+		t.Errorf("Expected limit to be applied, got %d requests", len(limited))
+	}
+}
+
+func TestUpdateTextAnalyzerUUID(t *testing.T) {
+	t.Parallel()
+	store, cleanup := setupTestStorage(t)
+	defer cleanup()
+
+	req := &Request{
+		ID:               "requeued",
+		CreatedAt:        time.Now().UTC(),
+		SourceType:       "url",
+		TextAnalyzerUUID: "ta-old",
+		Metadata:         map[string]interface{}{},
+	}
+	if err := store.SaveRequest(req); err != nil {
+		t.Fatalf("Failed to save request: %v", err)
+	}
+
+	if err := store.UpdateTextAnalyzerUUID("requeued", "ta-new"); err != nil {
+		t.Fatalf("UpdateTextAnalyzerUUID failed: %v", err)
+	}
+	got, err := store.GetRequest("requeued")
+	if err != nil {
+		t.Fatalf("GetRequest failed: %v", err)
+	}
+	if got.TextAnalyzerUUID != "ta-new" {
+		t.Errorf("Expected ta-new, got %s", got.TextAnalyzerUUID)
+	}
+
+	if err := store.UpdateTextAnalyzerUUID("missing", "ta-new"); err == nil {
+		t.Error("Expected error for missing request")
+	}
+}
@@ -0,0 +1,48 @@
+package storage
+
+import "time"
+
+// Values of the textanalyzer_status metadata field, which tracks a request's text analysis:
+// queued when the worker enqueues it, processing once retrieval finds the analyzer still
+// working on it, and finally completed, failed or timed_out
+const (
+	AnalysisStatusQueued     = "queued"
+	AnalysisStatusProcessing = "processing"
+	AnalysisStatusCompleted  = "completed"
+	AnalysisStatusFailed     = "failed"
+	AnalysisStatusTimedOut   = "timed_out"
+)
+
+// Metadata keys written alongside the analysis status
+const (
+	MetaAnalysisStatus      = "textanalyzer_status"
+	MetaAnalysisCompletedAt = "analysis_completed_at" // RFC 3339 time the status became final
+	MetaAnalysisSkipped     = "analysis_skipped"      // true on archival requests submitted with skip_analysis
+)
+
+// IsFinalAnalysisStatus reports whether status is one retrieval stops at
+func IsFinalAnalysisStatus(status string) bool {
+	switch status {
+	case AnalysisStatusCompleted, AnalysisStatusFailed, AnalysisStatusTimedOut:
+		return true
+	}
+	return false
+}
+
+// AnalysisSkipped reports whether a request was stored without text analysis on purpose.
+// Such requests never get an analysis status or a quality tombstone.
+func AnalysisSkipped(metadata map[string]interface{}) bool {
+	skipped, _ := metadata[MetaAnalysisSkipped].(bool)
+	return skipped
+}
+
+// SetAnalysisStatus records status in metadata. A final status is stamped with
+// analysis_completed_at; any other status clears the stamp, e.g. when analysis is re-enqueued.
+func SetAnalysisStatus(metadata map[string]interface{}, status string, at time.Time) {
+	metadata[MetaAnalysisStatus] = status
+	if IsFinalAnalysisStatus(status) {
+		metadata[MetaAnalysisCompletedAt] = at.UTC().Format(time.RFC3339)
+	} else {
+		delete(metadata, MetaAnalysisCompletedAt)
+	}
+}
@@ -0,0 +1,22 @@
+package storage
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSetAnalysisStatus(t *testing.T) {
+	at := time.Date(2025, 6, 1, 8, 30, 0, 0, time.FixedZone("CEST", 2*60*60))
+	metadata := map[string]interface{}{}
+
+	SetAnalysisStatus(metadata, AnalysisStatusCompleted, at)
+	if metadata[MetaAnalysisCompletedAt] != "2025-06-01T06:30:00Z" {
+		t.Errorf("Expected a UTC completion time, got %v", metadata[MetaAnalysisCompletedAt])
+	}
+
+	// Re-enqueueing clears the completion time of the earlier analysis
+	SetAnalysisStatus(metadata, AnalysisStatusQueued, at)
+	if _, ok := metadata[MetaAnalysisCompletedAt]; ok || metadata[MetaAnalysisStatus] != AnalysisStatusQueued {
+		t.Errorf("Expected queued without a completion time, got %v", metadata)
+	}
+}
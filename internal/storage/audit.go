@@ -0,0 +1,203 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+)
+
+// Audit actors used when a mutation is not initiated by an API caller
+const (
+	AuditActorWorker = "worker"
+	AuditActorSystem = "system"
+)
+
+// Audit actions recorded for mutating operations
+const (
+	AuditActionDelete      = "delete"
+	AuditActionTombstone   = "tombstone"
+	AuditActionUntombstone = "untombstone"
+	AuditActionUpdateTags  = "update_tags"
+	AuditActionUpdateSEO   = "update_seo"
+	AuditActionRetry       = "retry"
+	AuditActionCancel      = "cancel"
+	AuditActionRestore     = "restore"
+	AuditActionPurge       = "purge"
+	AuditActionStar        = "star"
+	AuditActionUnstar      = "unstar"
+
+	AuditActionUpdateMetadata = "update_metadata"
+
+	AuditActionUpdateSettings = "update_settings"
+
+	AuditActionReconcile = "reconcile"
+
+	AuditActionStartBackfill = "start_backfill"
+	AuditActionStopBackfill  = "stop_backfill"
+)
+
+// Audit entity types
+const (
+	AuditEntityRequest   = "request"
+	AuditEntityImage     = "image"
+	AuditEntityScrapeJob = "scrape_job"
+	AuditEntitySettings  = "settings"
+
+	AuditEntityReconciliation = "reconciliation"
+	AuditEntityBackfill       = "backfill"
+)
+
+// AuditEntry represents a single recorded mutation
+type AuditEntry struct {
+	ID         int64                  `json:"id"`
+	Timestamp  time.Time              `json:"timestamp"`
+	Actor      string                 `json:"actor"`       // API key fingerprint, "worker" or "system"
+	Action     string                 `json:"action"`      // delete, tombstone, untombstone, update_tags, ...
+	EntityType string                 `json:"entity_type"` // request, image, scrape_job, settings
+	EntityID   string                 `json:"entity_id"`
+	Namespace  string                 `json:"namespace"` // That of the request or scrape job, else of the caller
+	Details    map[string]interface{} `json:"details,omitempty"`
+}
+
+// AuditFilter contains the query options for listing audit entries
+type AuditFilter struct {
+	EntityID string
+	Action   string
+	Limit    int
+	Offset   int
+}
+
+// auditExecer is satisfied by both *sql.DB and *sql.Tx
+type auditExecer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}
+
+// insertAuditEntry writes an entry into its own namespace when set, else into that of the
+// request or scrape job it describes, else into fallback
+func insertAuditEntry(db auditExecer, entry *AuditEntry, fallback string) error {
+	if entry.Timestamp.IsZero() {
+		entry.Timestamp = time.Now().UTC()
+	}
+
+	var detailsJSON interface{}
+	if len(entry.Details) > 0 {
+		data, err := json.Marshal(entry.Details)
+		if err != nil {
+			return fmt.Errorf("failed to marshal audit details: %w", err)
+		}
+		detailsJSON = string(data)
+	}
+
+	_, err := db.Exec(`
+		INSERT INTO audit_log (created_at, actor, action, entity_type, entity_id, details_json, namespace)
+		VALUES ($1, $2, $3, $4, $5, $6, COALESCE(
+			NULLIF($7, ''),
+			CASE $4::text
+				WHEN 'request' THEN (SELECT namespace FROM requests WHERE id = $5)
+				WHEN 'scrape_job' THEN (SELECT namespace FROM scrape_jobs WHERE id = $5)
+			END,
+			$8
+		))
+	`, entry.Timestamp, entry.Actor, entry.Action, entry.EntityType, entry.EntityID, detailsJSON, entry.Namespace, fallback)
+	if err != nil {
+		return fmt.Errorf("failed to insert audit entry: %w", err)
+	}
+
+	return nil
+}
+
+// RecordAudit writes an audit entry. Entries about shared entities such as images and settings
+// go to s's namespace. Callers treat failures as best-effort and only log them.
+func (s *Storage) RecordAudit(entry *AuditEntry) error {
+	defer s.timeQuery("RecordAudit", "action", entry.Action)()
+	return insertAuditEntry(s.db, entry, s.namespaceFor(""))
+}
+
+// recordAuditTx writes an audit entry inside an existing transaction.
+// The insert runs under a savepoint so that a failed audit write never aborts the caller's transaction.
+func recordAuditTx(tx *sql.Tx, entry *AuditEntry) {
+	if _, err := tx.Exec("SAVEPOINT audit_entry"); err != nil {
+		slog.Default().Warn("failed to create audit savepoint", "action", entry.Action, "entity_id", entry.EntityID, "error", err)
+		return
+	}
+
+	if err := insertAuditEntry(tx, entry, DefaultNamespace); err != nil {
+		slog.Default().Warn("failed to record audit entry", "action", entry.Action, "entity_id", entry.EntityID, "error", err)
+		tx.Exec("ROLLBACK TO SAVEPOINT audit_entry")
+		return
+	}
+
+	tx.Exec("RELEASE SAVEPOINT audit_entry")
+}
+
+// ListAuditEntries returns the audit entries in s's namespace matching the filter, newest first
+func (s *Storage) ListAuditEntries(filter AuditFilter) ([]*AuditEntry, error) {
+	defer s.timeQuery("ListAuditEntries", "entity_id", filter.EntityID, "action", filter.Action, "limit", filter.Limit)()
+	conditions := []string{s.inNamespace("")}
+	var args []interface{}
+
+	if filter.EntityID != "" {
+		args = append(args, filter.EntityID)
+		conditions = append(conditions, fmt.Sprintf("entity_id = $%d", len(args)))
+	}
+	if filter.Action != "" {
+		args = append(args, filter.Action)
+		conditions = append(conditions, fmt.Sprintf("action = $%d", len(args)))
+	}
+
+	query := `SELECT id, created_at, actor, action, entity_type, entity_id, namespace, details_json FROM audit_log`
+	query += " WHERE " + strings.Join(conditions, " AND ")
+
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = 50
+	}
+	args = append(args, limit, filter.Offset)
+	query += fmt.Sprintf(" ORDER BY created_at DESC, id DESC LIMIT $%d OFFSET $%d", len(args)-1, len(args))
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list audit entries: %w", err)
+	}
+	defer rows.Close()
+
+	entries := []*AuditEntry{}
+	for rows.Next() {
+		entry := &AuditEntry{}
+		var detailsJSON sql.NullString
+		if err := rows.Scan(&entry.ID, &entry.Timestamp, &entry.Actor, &entry.Action, &entry.EntityType, &entry.EntityID, &entry.Namespace, &detailsJSON); err != nil {
+			return nil, fmt.Errorf("failed to scan audit entry: %w", err)
+		}
+		if detailsJSON.Valid && detailsJSON.String != "" {
+			if err := json.Unmarshal([]byte(detailsJSON.String), &entry.Details); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal audit details: %w", err)
+			}
+		}
+		entries = append(entries, entry)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating audit entries: %w", err)
+	}
+
+	return entries, nil
+}
+
+// DeleteAuditEntriesBefore removes audit entries older than the cutoff and returns how many were removed
+func (s *Storage) DeleteAuditEntriesBefore(cutoff time.Time) (int64, error) {
+	defer s.timeQuery("DeleteAuditEntriesBefore", "cutoff", cutoff)()
+	result, err := s.db.Exec("DELETE FROM audit_log WHERE created_at < $1", cutoff)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete old audit entries: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return rowsAffected, nil
+}
@@ -0,0 +1,124 @@
+package storage
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAuditLogRecordAndList(t *testing.T) {
+	t.Parallel()
+	store, cleanup := setupTestStorage(t)
+	defer cleanup()
+
+	entries := []*AuditEntry{
+		{Actor: "apikey:abc", Action: AuditActionTombstone, EntityType: AuditEntityRequest, EntityID: "req-1", Details: map[string]interface{}{"reason": "manual"}},
+		{Actor: "apikey:abc", Action: AuditActionUpdateTags, EntityType: AuditEntityRequest, EntityID: "req-1"},
+		{Actor: AuditActorWorker, Action: AuditActionTombstone, EntityType: AuditEntityRequest, EntityID: "req-2"},
+	}
+	for _, entry := range entries {
+		if err := store.RecordAudit(entry); err != nil {
+			t.Fatalf("Failed to record audit entry: %v", err)
+		}
+	}
+
+	byEntity, err := store.ListAuditEntries(AuditFilter{EntityID: "req-1"})
+	if err != nil {
+		t.Fatalf("Failed to list audit entries: %v", err)
+	}
+	if len(byEntity) != 2 {
+		t.Fatalf("Expected 2 entries for req-1, got %d", len(byEntity))
+	}
+
+	byAction, err := store.ListAuditEntries(AuditFilter{Action: AuditActionTombstone})
+	if err != nil {
+		t.Fatalf("Failed to list audit entries: %v", err)
+	}
+	if len(byAction) != 2 {
+		t.Fatalf("Expected 2 tombstone entries, got %d", len(byAction))
+	}
+
+	both, err := store.ListAuditEntries(AuditFilter{EntityID: "req-1", Action: AuditActionTombstone})
+	if err != nil {
+		t.Fatalf("Failed to list audit entries: %v", err)
+	}
+	if len(both) != 1 {
+		t.Fatalf("Expected 1 entry, got %d", len(both))
+	}
+	if both[0].Details["reason"] != "manual" {
+		t.Errorf("Expected details reason 'manual', got %v", both[0].Details["reason"])
+	}
+
+	paged, err := store.ListAuditEntries(AuditFilter{Limit: 1, Offset: 1})
+	if err != nil {
+		t.Fatalf("Failed to list audit entries: %v", err)
+	}
+	if len(paged) != 1 {
+		t.Errorf("Expected 1 entry with limit 1, got %d", len(paged))
+	}
+}
+
+func TestAuditLogTagBasedTombstone(t *testing.T) {
+	t.Parallel()
+	store, cleanup := setupTestStorage(t)
+	defer cleanup()
+
+	req := &Request{
+		ID:               "audit-tag-tombstone",
+		CreatedAt:        time.Now(),
+		SourceType:       "url",
+		TextAnalyzerUUID: "ta-1",
+		Tags:             []string{"news"},
+		Metadata:         map[string]interface{}{},
+	}
+	if err := store.SaveRequest(req); err != nil {
+		t.Fatalf("Failed to save request: %v", err)
+	}
+
+	if err := store.UpdateRequestTags(req.ID, []string{"news", "low-quality"}); err != nil {
+		t.Fatalf("Failed to update tags: %v", err)
+	}
+
+	entries, err := store.ListAuditEntries(AuditFilter{EntityID: req.ID, Action: AuditActionTombstone})
+	if err != nil {
+		t.Fatalf("Failed to list audit entries: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("Expected 1 tombstone audit entry, got %d", len(entries))
+	}
+	if entries[0].Actor != AuditActorSystem {
+		t.Errorf("Expected actor %q, got %q", AuditActorSystem, entries[0].Actor)
+	}
+	if entries[0].Details["tag"] != "low-quality" {
+		t.Errorf("Expected tag 'low-quality', got %v", entries[0].Details["tag"])
+	}
+}
+
+func TestDeleteAuditEntriesBefore(t *testing.T) {
+	t.Parallel()
+	store, cleanup := setupTestStorage(t)
+	defer cleanup()
+
+	old := &AuditEntry{Timestamp: time.Now().Add(-400 * 24 * time.Hour), Actor: "a", Action: AuditActionDelete, EntityType: AuditEntityRequest, EntityID: "old"}
+	recent := &AuditEntry{Actor: "a", Action: AuditActionDelete, EntityType: AuditEntityRequest, EntityID: "recent"}
+	for _, entry := range []*AuditEntry{old, recent} {
+		if err := store.RecordAudit(entry); err != nil {
+			t.Fatalf("Failed to record audit entry: %v", err)
+		}
+	}
+
+	removed, err := store.DeleteAuditEntriesBefore(time.Now().Add(-365 * 24 * time.Hour))
+	if err != nil {
+		t.Fatalf("Failed to purge audit entries: %v", err)
+	}
+	if removed != 1 {
+		t.Errorf("Expected 1 entry removed, got %d", removed)
+	}
+
+	remaining, err := store.ListAuditEntries(AuditFilter{})
+	if err != nil {
+		t.Fatalf("Failed to list audit entries: %v", err)
+	}
+	if len(remaining) != 1 || remaining[0].EntityID != "recent" {
+		t.Errorf("Expected only the recent entry to remain, got %+v", remaining)
+	}
+}
@@ -0,0 +1,341 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// defaultBackfillBatchSize is how many requests a backfill reads and updates at a time
+const defaultBackfillBatchSize = 500
+
+// backfillStaleAfter is how long a running backfill may go without checkpointing before
+// it is taken to have died with its process and may be claimed again
+const backfillStaleAfter = 2 * time.Minute
+
+// Backfill run statuses
+const (
+	BackfillStatusRunning     = "running"
+	BackfillStatusStopped     = "stopped"     // Stopped by an operator; resumes only when started again
+	BackfillStatusInterrupted = "interrupted" // The process shut down mid-run; resumes on the next start
+	BackfillStatusCompleted   = "completed"
+	BackfillStatusFailed      = "failed"
+)
+
+var (
+	// ErrBackfillRunning is returned by StartBackfill while another run of the backfill is live
+	ErrBackfillRunning = errors.New("backfill is already running")
+
+	// ErrBackfillStopped is the cancel cause that makes RunBackfill record a run as stopped
+	// rather than interrupted
+	ErrBackfillStopped = errors.New("backfill stopped")
+)
+
+// BackfillRow is what a backfill sees of one request
+type BackfillRow struct {
+	ID        string
+	CreatedAt time.Time
+	Metadata  map[string]interface{}
+}
+
+// Backfill recomputes one requests column from each request's metadata
+type Backfill struct {
+	Name        string
+	Description string
+	column      string
+	derive      func(row BackfillRow) interface{} // The column's value for row; nil stores NULL
+}
+
+// EffectiveDateBackfill recomputes effective_date with extractEffectiveDate
+var EffectiveDateBackfill = &Backfill{
+	Name:        "effective_date",
+	Description: "Recompute effective_date from the publish dates in each request's metadata",
+	column:      "effective_date",
+	derive: func(row BackfillRow) interface{} {
+		return extractEffectiveDate(row.Metadata, row.CreatedAt)
+	},
+}
+
+// QualityScoreBackfill copies the analyzer's quality score out of metadata into quality_score
+var QualityScoreBackfill = &Backfill{
+	Name:        "quality_score",
+	Description: "Copy the quality score in each request's metadata into the quality_score column",
+	column:      "quality_score",
+	derive: func(row BackfillRow) interface{} {
+		return extractQualityScore(row.Metadata)
+	},
+}
+
+// Backfills lists every backfill that can be run, by name order
+func Backfills() []*Backfill {
+	return []*Backfill{EffectiveDateBackfill, QualityScoreBackfill}
+}
+
+// BackfillByName returns the named backfill, or nil when there is none
+func BackfillByName(name string) *Backfill {
+	for _, b := range Backfills() {
+		if b.Name == name {
+			return b
+		}
+	}
+	return nil
+}
+
+// extractQualityScore returns metadata's quality_score.score, or nil when it has none
+func extractQualityScore(metadata map[string]interface{}) interface{} {
+	obj, ok := metadata["quality_score"].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	score, ok := obj["score"].(float64)
+	if !ok {
+		return nil
+	}
+	return score
+}
+
+// BackfillRun is the checkpoint of a backfill's latest run
+type BackfillRun struct {
+	Name        string     `json:"name"`
+	Status      string     `json:"status"`
+	LastID      string     `json:"last_id"`   // Requests up to this ID have been processed
+	Processed   int64      `json:"processed"` // Requests read so far in this run
+	Updated     int64      `json:"updated"`   // Requests whose column changed
+	Error       string     `json:"error,omitempty"`
+	StartedAt   time.Time  `json:"started_at"`
+	UpdatedAt   time.Time  `json:"updated_at"` // Last checkpoint
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+}
+
+// BackfillOptions controls how fast RunBackfill goes
+type BackfillOptions struct {
+	BatchSize int           // Requests read and updated per transaction; <= 0 uses 500
+	Pause     time.Duration // Sleep between batches to spare the database
+}
+
+const backfillRunColumns = `name, status, last_id, processed, updated, error, started_at, updated_at, completed_at`
+
+func scanBackfillRun(row interface{ Scan(...interface{}) error }) (*BackfillRun, error) {
+	run := &BackfillRun{}
+	var errMsg sql.NullString
+	var completedAt sql.NullTime
+	if err := row.Scan(&run.Name, &run.Status, &run.LastID, &run.Processed, &run.Updated, &errMsg, &run.StartedAt, &run.UpdatedAt, &completedAt); err != nil {
+		return nil, err
+	}
+	run.Error = errMsg.String
+	if completedAt.Valid {
+		run.CompletedAt = &completedAt.Time
+	}
+	return run, nil
+}
+
+// StartBackfill claims the named backfill for a run. A stopped, interrupted or failed run
+// resumes from its checkpoint; a completed one, or any run when restart is set, starts over
+// from the first request. Returns ErrBackfillRunning while another run is checkpointing.
+func (s *Storage) StartBackfill(name string, restart bool) (*BackfillRun, error) {
+	defer s.timeQuery("StartBackfill", "name", name)()
+	run, err := scanBackfillRun(s.db.QueryRow(`
+		INSERT INTO backfill_runs (name, status) VALUES ($1, $2)
+		ON CONFLICT (name) DO UPDATE SET
+			status = $2,
+			last_id = CASE WHEN $3::boolean OR backfill_runs.status = $4 THEN '' ELSE backfill_runs.last_id END,
+			processed = CASE WHEN $3::boolean OR backfill_runs.status = $4 THEN 0 ELSE backfill_runs.processed END,
+			updated = CASE WHEN $3::boolean OR backfill_runs.status = $4 THEN 0 ELSE backfill_runs.updated END,
+			started_at = CASE WHEN $3::boolean OR backfill_runs.status = $4 THEN NOW() ELSE backfill_runs.started_at END,
+			error = NULL,
+			updated_at = NOW(),
+			completed_at = NULL
+		WHERE backfill_runs.status <> $2 OR backfill_runs.updated_at < NOW() - $5::float8 * INTERVAL '1 second'
+		RETURNING `+backfillRunColumns,
+		name, BackfillStatusRunning, restart, BackfillStatusCompleted, backfillStaleAfter.Seconds()))
+	if err == sql.ErrNoRows {
+		return nil, ErrBackfillRunning
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim backfill: %w", err)
+	}
+	return run, nil
+}
+
+// ListBackfillRuns returns the checkpoint of every backfill that has been run
+func (s *Storage) ListBackfillRuns() ([]*BackfillRun, error) {
+	defer s.timeQuery("ListBackfillRuns")()
+	rows, err := s.db.Query(`SELECT ` + backfillRunColumns + ` FROM backfill_runs ORDER BY name`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query backfill runs: %w", err)
+	}
+	defer rows.Close()
+
+	var runs []*BackfillRun
+	for rows.Next() {
+		run, err := scanBackfillRun(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan backfill run: %w", err)
+		}
+		runs = append(runs, run)
+	}
+	return runs, rows.Err()
+}
+
+// RunBackfill works through the requests after the backfill's checkpoint in id order,
+// deleted ones included, and must follow a successful StartBackfill. Each batch's updates
+// and its checkpoint commit together, so a run cut short anywhere resumes without skipping
+// or repeating work. Cancelling ctx ends the run after the current batch, recorded as
+// stopped when the cause is ErrBackfillStopped and as interrupted otherwise. Returns the
+// run as last recorded.
+func (s *Storage) RunBackfill(ctx context.Context, b *Backfill, opts BackfillOptions) (*BackfillRun, error) {
+	defer s.timeQuery("RunBackfill", "name", b.Name)()
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = defaultBackfillBatchSize
+	}
+
+	run, err := scanBackfillRun(s.db.QueryRow(`SELECT `+backfillRunColumns+` FROM backfill_runs WHERE name = $1`, b.Name))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read backfill checkpoint: %w", err)
+	}
+	for {
+		if ctx.Err() != nil {
+			status := BackfillStatusInterrupted
+			if errors.Is(context.Cause(ctx), ErrBackfillStopped) {
+				status = BackfillStatusStopped
+			}
+			return s.finishBackfill(run, status, nil)
+		}
+
+		read, err := s.backfillBatch(b, run, opts.BatchSize)
+		if err != nil {
+			return s.finishBackfill(run, BackfillStatusFailed, err)
+		}
+		if read < opts.BatchSize {
+			return s.finishBackfill(run, BackfillStatusCompleted, nil)
+		}
+
+		if opts.Pause > 0 {
+			select {
+			case <-ctx.Done():
+			case <-time.After(opts.Pause):
+			}
+		}
+	}
+}
+
+// backfillBatch applies b to the batchSize requests after run's checkpoint and advances the
+// checkpoint in the same transaction. Returns how many requests it read.
+func (s *Storage) backfillBatch(b *Backfill, run *BackfillRun, batchSize int) (int, error) {
+	var read, updated int
+	var lastID string
+	err := s.inTx("RunBackfill", func(tx *sql.Tx) error {
+		read, updated, lastID = 0, 0, run.LastID
+		rows, err := tx.Query(`
+			SELECT id, created_at, metadata_json
+			FROM requests
+			WHERE id > $1 AND `+s.inNamespace("")+`
+			ORDER BY id
+			LIMIT $2
+		`, run.LastID, batchSize)
+		if err != nil {
+			return fmt.Errorf("failed to query requests: %w", err)
+		}
+		var batch []BackfillRow
+		for rows.Next() {
+			var row BackfillRow
+			var metadataJSON sql.NullString
+			if err := rows.Scan(&row.ID, &row.CreatedAt, &metadataJSON); err != nil {
+				rows.Close()
+				return fmt.Errorf("failed to scan request: %w", err)
+			}
+			read++
+			lastID = row.ID
+			row.Metadata = map[string]interface{}{}
+			if metadataJSON.Valid && metadataJSON.String != "" {
+				if err := json.Unmarshal([]byte(metadataJSON.String), &row.Metadata); err != nil {
+					slog.Default().Warn("skipping request with unreadable metadata", "backfill", b.Name, "request_id", row.ID, "error", err)
+					continue
+				}
+			}
+			batch = append(batch, row)
+		}
+		if err := rows.Err(); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to read requests: %w", err)
+		}
+		rows.Close()
+
+		for _, row := range batch {
+			res, err := tx.Exec(`
+				UPDATE requests SET `+b.column+` = $1, row_version = row_version + 1
+				WHERE id = $2 AND `+b.column+` IS DISTINCT FROM $1
+			`, b.derive(row), row.ID)
+			if err != nil {
+				return fmt.Errorf("failed to update %s of %s: %w", b.column, row.ID, err)
+			}
+			n, _ := res.RowsAffected()
+			updated += int(n)
+		}
+
+		if _, err := tx.Exec(`
+			UPDATE backfill_runs
+			SET last_id = $2, processed = processed + $3, updated = updated + $4, updated_at = NOW()
+			WHERE name = $1
+		`, b.Name, lastID, read, updated); err != nil {
+			return fmt.Errorf("failed to checkpoint backfill: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	run.LastID = lastID
+	run.Processed += int64(read)
+	run.Updated += int64(updated)
+	run.UpdatedAt = time.Now()
+	return read, nil
+}
+
+// finishBackfill records how run ended. cause is returned alongside the run; a failure to
+// record the status is returned when there is no cause.
+func (s *Storage) finishBackfill(run *BackfillRun, status string, cause error) (*BackfillRun, error) {
+	run.Status = status
+	var errMsg sql.NullString
+	if cause != nil {
+		run.Error = cause.Error()
+		errMsg = sql.NullString{String: run.Error, Valid: true}
+	}
+	var completedAt sql.NullTime
+	if status == BackfillStatusCompleted {
+		now := time.Now()
+		run.CompletedAt = &now
+		completedAt = sql.NullTime{Time: now, Valid: true}
+	}
+	_, err := s.db.Exec(`
+		UPDATE backfill_runs SET status = $2, error = $3, completed_at = $4, updated_at = NOW()
+		WHERE name = $1
+	`, run.Name, status, errMsg, completedAt)
+	if cause != nil {
+		if err != nil {
+			slog.Default().Warn("failed to record backfill failure", "backfill", run.Name, "error", err)
+		}
+		return run, cause
+	}
+	if err != nil {
+		return run, fmt.Errorf("failed to record backfill status: %w", err)
+	}
+	return run, nil
+}
+
+// BackfillEffectiveDates runs EffectiveDateBackfill to completion, resuming an unfinished
+// run from its checkpoint. Returns the number of requests whose date changed in the run.
+func (s *Storage) BackfillEffectiveDates(batchSize int) (int, error) {
+	if _, err := s.StartBackfill(EffectiveDateBackfill.Name, false); err != nil {
+		return 0, err
+	}
+	run, err := s.RunBackfill(context.Background(), EffectiveDateBackfill, BackfillOptions{BatchSize: batchSize})
+	if run == nil {
+		return 0, err
+	}
+	return int(run.Updated), err
+}
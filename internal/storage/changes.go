@@ -0,0 +1,167 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"time"
+	"unicode/utf8"
+)
+
+// metadataChangeMaxValue is the longest string value, in characters, kept whole in a change.
+// Longer values such as cleaned_text are cut to this length and flagged as truncated.
+const metadataChangeMaxValue = 200
+
+// Kinds of metadata change
+const (
+	MetadataChangeAdded   = "added"
+	MetadataChangeRemoved = "removed"
+	MetadataChangeChanged = "changed"
+)
+
+// MetadataChange is one changed metadata key. Keys inside a nested object are joined with a
+// dot, e.g. "scraper_metadata.title". Old and New hold scalar values; lists and objects nested
+// deeper are reported as changed without their values, with Truncated set.
+type MetadataChange struct {
+	Key       string      `json:"key"`
+	Type      string      `json:"type"` // added, removed or changed
+	Old       interface{} `json:"old,omitempty"`
+	New       interface{} `json:"new,omitempty"`
+	Truncated bool        `json:"truncated,omitempty"`
+}
+
+// RequestChange is one recorded metadata mutation of a request
+type RequestChange struct {
+	Timestamp time.Time        `json:"timestamp"`
+	Actor     string           `json:"actor"`
+	Changes   []MetadataChange `json:"changes"`
+}
+
+// DiffMetadata compares two metadata maps and returns the changed keys sorted by key. Nested
+// objects are compared key by key one level deep; anything deeper is compared as a whole.
+func DiffMetadata(before, after map[string]interface{}) []MetadataChange {
+	changes := []MetadataChange{}
+	for _, key := range unionKeys(before, after) {
+		oldValue, hadOld := before[key]
+		newValue, hasNew := after[key]
+		oldMap, oldIsMap := oldValue.(map[string]interface{})
+		newMap, newIsMap := newValue.(map[string]interface{})
+		if oldIsMap && newIsMap {
+			for _, child := range unionKeys(oldMap, newMap) {
+				o, hadO := oldMap[child]
+				n, hasN := newMap[child]
+				if change, ok := diffValue(key+"."+child, o, hadO, n, hasN); ok {
+					changes = append(changes, change)
+				}
+			}
+			continue
+		}
+		if change, ok := diffValue(key, oldValue, hadOld, newValue, hasNew); ok {
+			changes = append(changes, change)
+		}
+	}
+	return changes
+}
+
+// diffValue describes the change of one key, reporting false when the value is unchanged
+func diffValue(key string, oldValue interface{}, hadOld bool, newValue interface{}, hasNew bool) (MetadataChange, bool) {
+	if hadOld == hasNew && reflect.DeepEqual(oldValue, newValue) {
+		return MetadataChange{}, false
+	}
+	change := MetadataChange{Key: key, Type: MetadataChangeChanged}
+	switch {
+	case !hadOld:
+		change.Type = MetadataChangeAdded
+	case !hasNew:
+		change.Type = MetadataChangeRemoved
+	}
+	var truncatedOld, truncatedNew bool
+	change.Old, truncatedOld = changeValue(oldValue)
+	change.New, truncatedNew = changeValue(newValue)
+	change.Truncated = truncatedOld || truncatedNew
+	return change, true
+}
+
+// changeValue returns a value as recorded in a change: scalars as they are, long strings cut
+// to metadataChangeMaxValue characters, and lists and objects left out
+func changeValue(v interface{}) (interface{}, bool) {
+	switch v := v.(type) {
+	case nil, bool, float64, json.Number:
+		return v, false
+	case string:
+		if utf8.RuneCountInString(v) <= metadataChangeMaxValue {
+			return v, false
+		}
+		return string([]rune(v)[:metadataChangeMaxValue]), true
+	default:
+		return nil, true
+	}
+}
+
+// unionKeys returns the keys of a and b, sorted
+func unionKeys(a, b map[string]interface{}) []string {
+	keys := make([]string, 0, len(a)+len(b))
+	for key := range a {
+		keys = append(keys, key)
+	}
+	for key := range b {
+		if _, ok := a[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// recordMetadataChangeTx records the difference between two metadata_json values in the audit
+// log. Like recordAuditTx it never fails the caller's transaction; the change is logged and lost.
+func recordMetadataChangeTx(tx *sql.Tx, requestID, actor, beforeJSON, afterJSON string) {
+	var before, after map[string]interface{}
+	if beforeJSON != "" {
+		json.Unmarshal([]byte(beforeJSON), &before)
+	}
+	json.Unmarshal([]byte(afterJSON), &after)
+
+	changes := DiffMetadata(before, after)
+	if len(changes) == 0 {
+		return
+	}
+	recordAuditTx(tx, &AuditEntry{
+		Actor:      actor,
+		Action:     AuditActionUpdateMetadata,
+		EntityType: AuditEntityRequest,
+		EntityID:   requestID,
+		Details:    map[string]interface{}{"changes": changes},
+	})
+}
+
+// ListRequestChanges returns the most recent recorded metadata changes of a request, oldest
+// first. offset skips that many of the newest changes.
+func (s *Storage) ListRequestChanges(requestID string, limit, offset int) ([]*RequestChange, error) {
+	entries, err := s.ListAuditEntries(AuditFilter{
+		EntityID: requestID,
+		Action:   AuditActionUpdateMetadata,
+		Limit:    limit,
+		Offset:   offset,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list request changes: %w", err)
+	}
+
+	changes := make([]*RequestChange, 0, len(entries))
+	for i := len(entries) - 1; i >= 0; i-- {
+		entry := entries[i]
+		change := &RequestChange{Timestamp: entry.Timestamp, Actor: entry.Actor, Changes: []MetadataChange{}}
+		// Details come back as generic JSON, so round-trip them into the typed form
+		if data, err := json.Marshal(entry.Details["changes"]); err == nil {
+			json.Unmarshal(data, &change.Changes)
+		}
+		if change.Changes == nil {
+			change.Changes = []MetadataChange{}
+		}
+		changes = append(changes, change)
+	}
+	return changes, nil
+}
@@ -0,0 +1,131 @@
+package storage
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDiffMetadata(t *testing.T) {
+	t.Parallel()
+	long := strings.Repeat("é", metadataChangeMaxValue+50)
+
+	tests := []struct {
+		name   string
+		before map[string]interface{}
+		after  map[string]interface{}
+		want   []MetadataChange
+	}{
+		{
+			name:   "unchanged",
+			before: map[string]interface{}{"title": "A", "score": 0.5, "tags": []interface{}{"go"}},
+			after:  map[string]interface{}{"title": "A", "score": 0.5, "tags": []interface{}{"go"}},
+			want:   []MetadataChange{},
+		},
+		{
+			name:   "scalars added, removed and changed",
+			before: map[string]interface{}{"score": 0.41, "status": "pending", "stale": true},
+			after:  map[string]interface{}{"score": 0.78, "status": "pending", "reviewed": false},
+			want: []MetadataChange{
+				{Key: "reviewed", Type: MetadataChangeAdded, New: false},
+				{Key: "score", Type: MetadataChangeChanged, Old: 0.41, New: 0.78},
+				{Key: "stale", Type: MetadataChangeRemoved, Old: true},
+			},
+		},
+		{
+			name: "nested maps one level deep",
+			before: map[string]interface{}{"scraper_metadata": map[string]interface{}{
+				"title": "Old", "author": "Ann", "extra": map[string]interface{}{"a": 1.0},
+			}},
+			after: map[string]interface{}{"scraper_metadata": map[string]interface{}{
+				"title": "New", "author": "Ann", "extra": map[string]interface{}{"a": 2.0},
+			}},
+			want: []MetadataChange{
+				{Key: "scraper_metadata.extra", Type: MetadataChangeChanged, Truncated: true},
+				{Key: "scraper_metadata.title", Type: MetadataChangeChanged, Old: "Old", New: "New"},
+			},
+		},
+		{
+			name:   "a map replacing a scalar is reported whole",
+			before: map[string]interface{}{"analysis": "pending"},
+			after:  map[string]interface{}{"analysis": map[string]interface{}{"score": 1.0}},
+			want:   []MetadataChange{{Key: "analysis", Type: MetadataChangeChanged, Old: "pending", Truncated: true}},
+		},
+		{
+			name:   "long strings are truncated",
+			before: map[string]interface{}{},
+			after:  map[string]interface{}{"cleaned_text": long},
+			want: []MetadataChange{
+				{Key: "cleaned_text", Type: MetadataChangeAdded, New: long[:2*metadataChangeMaxValue], Truncated: true},
+			},
+		},
+		{
+			name:   "nil before",
+			before: nil,
+			after:  map[string]interface{}{"title": "A"},
+			want:   []MetadataChange{{Key: "title", Type: MetadataChangeAdded, New: "A"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := DiffMetadata(tt.before, tt.after)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Expected %+v, got %+v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestUpdateRequestMetadataRecordsChanges(t *testing.T) {
+	t.Parallel()
+	store, cleanup := setupTestStorage(t)
+	defer cleanup()
+
+	if err := store.SaveRequest(&Request{
+		ID: "changes-req", CreatedAt: time.Now(), SourceType: "url", TextAnalyzerUUID: "ta-changes-req",
+		Metadata: map[string]interface{}{"quality_score": 0.4, "scraper_metadata": map[string]interface{}{"title": "Draft"}},
+	}); err != nil {
+		t.Fatalf("Failed to save request: %v", err)
+	}
+
+	updates := []struct {
+		actor    string
+		metadata map[string]interface{}
+	}{
+		{AuditActorWorker, map[string]interface{}{"quality_score": 0.8, "scraper_metadata": map[string]interface{}{"title": "Draft"}}},
+		// Rewriting the same metadata is not a change
+		{AuditActorWorker, map[string]interface{}{"quality_score": 0.8, "scraper_metadata": map[string]interface{}{"title": "Draft"}}},
+		{"apikey:3f2a9c1d8e7b", map[string]interface{}{"quality_score": 0.8, "scraper_metadata": map[string]interface{}{"title": "Final"}}},
+	}
+	for _, u := range updates {
+		if err := store.UpdateRequestMetadataAs("changes-req", u.metadata, u.actor); err != nil {
+			t.Fatalf("UpdateRequestMetadataAs failed: %v", err)
+		}
+	}
+
+	changes, err := store.ListRequestChanges("changes-req", 10, 0)
+	if err != nil {
+		t.Fatalf("ListRequestChanges failed: %v", err)
+	}
+	if len(changes) != 2 {
+		t.Fatalf("Expected 2 recorded changes, got %d", len(changes))
+	}
+	// Oldest first
+	if changes[0].Actor != AuditActorWorker || len(changes[0].Changes) != 1 || changes[0].Changes[0].Key != "quality_score" {
+		t.Errorf("Unexpected first change: %+v", changes[0])
+	}
+	if got := changes[1].Changes; changes[1].Actor != "apikey:3f2a9c1d8e7b" || len(got) != 1 ||
+		got[0].Key != "scraper_metadata.title" || got[0].Old != "Draft" || got[0].New != "Final" {
+		t.Errorf("Unexpected second change: %+v", changes[1])
+	}
+
+	// A limit keeps the most recent changes
+	if changes, _ = store.ListRequestChanges("changes-req", 1, 0); len(changes) != 1 || changes[0].Actor == AuditActorWorker {
+		t.Errorf("Expected only the latest change, got %+v", changes)
+	}
+
+	if err := store.UpdateRequestMetadataAs("missing", map[string]interface{}{}, AuditActorSystem); err == nil || err.Error() != "request not found" {
+		t.Errorf("Expected request not found, got %v", err)
+	}
+}
@@ -0,0 +1,71 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// ReserveCrawlBudget claims up to want page slots from the crawl budget of rootJobID and
+// returns how many were granted. The budget is the root's max_pages, or defaultBudget when
+// that is 0; a budget of 0 or less is unlimited. When fewer than want are granted, the root
+// and parentJobID are flagged budget_exhausted. A deleted root grants nothing, since the crawl
+// it owned is gone. The root row is locked for the duration, so concurrent extractions under
+// one crawl never exceed the budget between them.
+func (s *Storage) ReserveCrawlBudget(rootJobID, parentJobID string, want, defaultBudget int) (int, error) {
+	defer s.timeQuery("ReserveCrawlBudget", "root_job_id", rootJobID, "want", want)()
+	if want <= 0 {
+		return 0, nil
+	}
+
+	var granted int
+	err := s.inTx("ReserveCrawlBudget", func(tx *sql.Tx) error {
+		var maxPages, enqueued int
+		err := tx.QueryRow(`
+			SELECT max_pages, pages_enqueued
+			FROM scrape_jobs
+			WHERE id = $1
+			FOR UPDATE
+		`, rootJobID).Scan(&maxPages, &enqueued)
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			// The root was deleted mid-crawl, so its pages stop here
+			granted = 0
+		case err != nil:
+			return fmt.Errorf("failed to read crawl budget: %w", err)
+		default:
+			budget := maxPages
+			if budget <= 0 {
+				budget = defaultBudget
+			}
+			granted = want
+			if budget > 0 {
+				granted = min(want, max(budget-enqueued, 0))
+			}
+
+			_, err = tx.Exec(`
+				UPDATE scrape_jobs
+				SET pages_enqueued = pages_enqueued + $2,
+					budget_exhausted = budget_exhausted OR $3
+				WHERE id = $1
+			`, rootJobID, granted, granted < want)
+			if err != nil {
+				return fmt.Errorf("failed to update crawl budget: %w", err)
+			}
+		}
+		if granted < want && parentJobID != rootJobID {
+			if _, err := tx.Exec(`
+				UPDATE scrape_jobs
+				SET budget_exhausted = true, updated_at = NOW()
+				WHERE id = $1
+			`, parentJobID); err != nil {
+				return fmt.Errorf("failed to flag parent job: %w", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return granted, nil
+}
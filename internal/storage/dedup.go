@@ -0,0 +1,137 @@
+package storage
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// TextHash returns a SHA-256 of the whitespace-collapsed, lowercased text, or an empty
+// string when there is no text to compare
+func TextHash(text string) string {
+	normalized := strings.ToLower(strings.Join(strings.Fields(text), " "))
+	if normalized == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
+
+// FindRequestByTextHash returns the oldest live request created since the given time whose
+// submitted text has the given TextHash, or nil if none exists
+func (s *Storage) FindRequestByTextHash(textHash string, since time.Time) (*Request, error) {
+	defer s.timeQuery("FindRequestByTextHash", "text_hash", textHash)()
+	var id string
+	err := s.db.QueryRow(`
+		SELECT id
+		FROM requests
+		WHERE text_hash = $1 AND created_at >= $2 AND `+notDeletedPredicate+` AND `+s.inNamespace("")+`
+		ORDER BY created_at ASC
+		LIMIT 1
+	`, textHash, since).Scan(&id)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query request by text hash: %w", err)
+	}
+
+	return s.GetRequest(id)
+}
+
+// FindRequestByContentHash returns the oldest live request with the given content hash, or nil if none exists
+func (s *Storage) FindRequestByContentHash(contentHash string) (*Request, error) {
+	defer s.timeQuery("FindRequestByContentHash", "content_hash", contentHash)()
+	var id string
+	err := s.db.QueryRow(`
+		SELECT id
+		FROM requests
+		WHERE content_hash = $1 AND `+notDeletedPredicate+` AND `+s.inNamespace("")+`
+		ORDER BY created_at ASC
+		LIMIT 1
+	`, contentHash).Scan(&id)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query request by content hash: %w", err)
+	}
+
+	return s.GetRequest(id)
+}
+
+// FindRequestByNormalizedURL returns the newest live request whose normalized source URL matches, or nil if none exists
+func (s *Storage) FindRequestByNormalizedURL(normalizedURL string) (*Request, error) {
+	defer s.timeQuery("FindRequestByNormalizedURL", "normalized_url", normalizedURL)()
+	var id string
+	err := s.db.QueryRow(`
+		SELECT id
+		FROM requests
+		WHERE normalized_url = $1 AND `+notDeletedPredicate+` AND `+s.inNamespace("")+`
+		ORDER BY created_at DESC
+		LIMIT 1
+	`, normalizedURL).Scan(&id)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query request by normalized URL: %w", err)
+	}
+
+	return s.GetRequest(id)
+}
+
+// AddAlternateURL appends a URL to the request's metadata.alternate_urls array.
+// The update is atomic and a URL that is already listed is not added twice.
+func (s *Storage) AddAlternateURL(id, alternateURL string) error {
+	defer s.timeQuery("AddAlternateURL", "id", id)()
+	_, err := s.db.Exec(`
+		UPDATE requests
+		SET metadata_json = jsonb_set(
+			COALESCE(metadata_json, '{}'::jsonb),
+			'{alternate_urls}',
+			COALESCE(metadata_json->'alternate_urls', '[]'::jsonb) || to_jsonb($1::text)
+		),
+		row_version = row_version + 1
+		WHERE id = $2
+		  AND NOT (COALESCE(metadata_json->'alternate_urls', '[]'::jsonb) ? $1)
+	`, alternateURL, id)
+	if err != nil {
+		return fmt.Errorf("failed to add alternate URL: %w", err)
+	}
+
+	return nil
+}
+
+// ListDuplicateJobs returns scrape jobs that resolved to the given request as duplicates
+func (s *Storage) ListDuplicateJobs(requestID string) ([]*ScrapeJob, error) {
+	defer s.timeQuery("ListDuplicateJobs", "request_id", requestID)()
+	rows, err := s.db.Query(`
+		SELECT `+scrapeJobColumns+`
+		FROM scrape_jobs
+		WHERE duplicate_of = $1 AND `+s.inNamespace("")+`
+		ORDER BY created_at ASC
+	`, requestID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list duplicate jobs: %w", err)
+	}
+	defer rows.Close()
+
+	jobs := []*ScrapeJob{}
+	for rows.Next() {
+		job, err := s.scanScrapeJob(rows)
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, job)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating duplicate jobs: %w", err)
+	}
+
+	return jobs, nil
+}
@@ -0,0 +1,226 @@
+package storage
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFindRequestByContentHash(t *testing.T) {
+	t.Parallel()
+	store, cleanup := setupTestStorage(t)
+	defer cleanup()
+
+	url := "https://example.com/original"
+	req := &Request{
+		ID:               "hash-original",
+		CreatedAt:        time.Now().UTC(),
+		SourceType:       "url",
+		SourceURL:        &url,
+		TextAnalyzerUUID: "ta-1",
+		Metadata:         map[string]interface{}{},
+		ContentHash:      "abc123",
+	}
+	if err := store.SaveRequest(req); err != nil {
+		t.Fatalf("Failed to save request: %v", err)
+	}
+
+	found, err := store.FindRequestByContentHash("abc123")
+	if err != nil {
+		t.Fatalf("FindRequestByContentHash failed: %v", err)
+	}
+	if found == nil || found.ID != "hash-original" {
+		t.Fatalf("Expected hash-original, got %+v", found)
+	}
+	if found.ContentHash != "abc123" {
+		t.Errorf("Expected content hash to round-trip, got %q", found.ContentHash)
+	}
+
+	missing, err := store.FindRequestByContentHash("does-not-exist")
+	if err != nil {
+		t.Fatalf("FindRequestByContentHash failed: %v", err)
+	}
+	if missing != nil {
+		t.Errorf("Expected nil for unknown hash, got %+v", missing)
+	}
+
+	// Soft-deleted requests are not dedup targets
+	if _, err := store.SoftDeleteRequest("hash-original"); err != nil {
+		t.Fatalf("Failed to soft delete: %v", err)
+	}
+	found, err = store.FindRequestByContentHash("abc123")
+	if err != nil {
+		t.Fatalf("FindRequestByContentHash failed: %v", err)
+	}
+	if found != nil {
+		t.Errorf("Expected deleted request to be ignored, got %+v", found)
+	}
+}
+
+func TestAddAlternateURL(t *testing.T) {
+	t.Parallel()
+	store, cleanup := setupTestStorage(t)
+	defer cleanup()
+
+	req := &Request{
+		ID:               "alt-1",
+		CreatedAt:        time.Now().UTC(),
+		SourceType:       "url",
+		TextAnalyzerUUID: "ta-1",
+		Metadata:         map[string]interface{}{"title": "Original"},
+	}
+	if err := store.SaveRequest(req); err != nil {
+		t.Fatalf("Failed to save request: %v", err)
+	}
+
+	for _, u := range []string{"https://mirror.example/a", "https://mirror.example/a", "https://other.example/b"} {
+		if err := store.AddAlternateURL("alt-1", u); err != nil {
+			t.Fatalf("AddAlternateURL failed: %v", err)
+		}
+	}
+
+	got, err := store.GetRequest("alt-1")
+	if err != nil {
+		t.Fatalf("GetRequest failed: %v", err)
+	}
+	alternates, ok := got.Metadata["alternate_urls"].([]interface{})
+	if !ok {
+		t.Fatalf("Expected alternate_urls array, got %T", got.Metadata["alternate_urls"])
+	}
+	if len(alternates) != 2 {
+		t.Errorf("Expected 2 unique alternate URLs, got %v", alternates)
+	}
+	if got.Metadata["title"] != "Original" {
+		t.Error("Expected existing metadata to be preserved")
+	}
+}
+
+func TestUpdateScrapeJobDuplicate(t *testing.T) {
+	t.Parallel()
+	store, cleanup := setupTestStorage(t)
+	defer cleanup()
+
+	req := &Request{
+		ID:               "dup-target",
+		CreatedAt:        time.Now().UTC(),
+		SourceType:       "url",
+		TextAnalyzerUUID: "ta-1",
+		Metadata:         map[string]interface{}{},
+	}
+	if err := store.SaveRequest(req); err != nil {
+		t.Fatalf("Failed to save request: %v", err)
+	}
+
+	job := &ScrapeJob{
+		ID:        "dup-job",
+		URL:       "https://mirror.example/a",
+		Status:    "processing",
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+	if err := store.SaveScrapeJob(job); err != nil {
+		t.Fatalf("Failed to save job: %v", err)
+	}
+
+	if err := store.UpdateScrapeJobDuplicate("dup-job", "dup-target"); err != nil {
+		t.Fatalf("UpdateScrapeJobDuplicate failed: %v", err)
+	}
+
+	got, err := store.GetScrapeJob("dup-job")
+	if err != nil {
+		t.Fatalf("GetScrapeJob failed: %v", err)
+	}
+	if got.Status != "completed" {
+		t.Errorf("Expected status completed, got %s", got.Status)
+	}
+	if got.DuplicateOf == nil || *got.DuplicateOf != "dup-target" {
+		t.Errorf("Expected duplicate_of dup-target, got %v", got.DuplicateOf)
+	}
+	if got.ResultRequestID == nil || *got.ResultRequestID != "dup-target" {
+		t.Errorf("Expected result_request_id dup-target, got %v", got.ResultRequestID)
+	}
+
+	jobs, err := store.ListDuplicateJobs("dup-target")
+	if err != nil {
+		t.Fatalf("ListDuplicateJobs failed: %v", err)
+	}
+	if len(jobs) != 1 || jobs[0].ID != "dup-job" {
+		t.Errorf("Expected dup-job in duplicate list, got %v", jobs)
+	}
+
+	if err := store.UpdateScrapeJobDuplicate("missing-job", "dup-target"); err == nil {
+		t.Error("Expected error for missing job")
+	}
+}
+
+func TestFindRequestByNormalizedURL(t *testing.T) {
+	t.Parallel()
+	store, cleanup := setupTestStorage(t)
+	defer cleanup()
+
+	url := "https://Example.com/article?utm_source=newsletter#intro"
+	req := &Request{
+		ID:               "normalized-1",
+		CreatedAt:        time.Now().UTC(),
+		SourceType:       "url",
+		SourceURL:        &url,
+		TextAnalyzerUUID: "ta-1",
+		Metadata:         map[string]interface{}{},
+	}
+	if err := store.SaveRequest(req); err != nil {
+		t.Fatalf("Failed to save request: %v", err)
+	}
+
+	got, err := store.GetRequest("normalized-1")
+	if err != nil {
+		t.Fatalf("GetRequest failed: %v", err)
+	}
+	if got.SourceURL == nil || *got.SourceURL != url {
+		t.Errorf("Expected original URL to be preserved, got %v", got.SourceURL)
+	}
+	if got.NormalizedURL == nil || *got.NormalizedURL != "https://example.com/article" {
+		t.Errorf("Expected normalized URL to be derived, got %v", got.NormalizedURL)
+	}
+
+	found, err := store.FindRequestByNormalizedURL("https://example.com/article")
+	if err != nil {
+		t.Fatalf("FindRequestByNormalizedURL failed: %v", err)
+	}
+	if found == nil || found.ID != "normalized-1" {
+		t.Errorf("Expected normalized-1, got %+v", found)
+	}
+
+	missing, err := store.FindRequestByNormalizedURL("https://example.com/other")
+	if err != nil {
+		t.Fatalf("FindRequestByNormalizedURL failed: %v", err)
+	}
+	if missing != nil {
+		t.Errorf("Expected nil for unknown URL, got %+v", missing)
+	}
+}
+
+func TestFindRequestByTextHash(t *testing.T) {
+	t.Parallel()
+	store, cleanup := setupTestStorage(t)
+	defer cleanup()
+
+	hash := TextHash("A press release.")
+	req := &Request{ID: "text-original", CreatedAt: time.Now().UTC().Add(-2 * time.Hour), SourceType: "text",
+		Metadata: map[string]interface{}{}, TextHash: hash}
+	if err := store.SaveRequest(req); err != nil {
+		t.Fatalf("Failed to save request: %v", err)
+	}
+
+	if hash != TextHash("  a PRESS\n release. ") {
+		t.Fatalf("Expected case and whitespace variants to share a hash")
+	}
+	found, err := store.FindRequestByTextHash(hash, time.Now().Add(-3*time.Hour))
+	if err != nil || found == nil || found.ID != "text-original" {
+		t.Fatalf("Expected text-original, got %+v (err %v)", found, err)
+	}
+
+	// Requests older than the window are not duplicates
+	found, err = store.FindRequestByTextHash(hash, time.Now().Add(-time.Hour))
+	if err != nil || found != nil {
+		t.Errorf("Expected no match outside the window, got %+v (err %v)", found, err)
+	}
+}
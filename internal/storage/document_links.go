@@ -0,0 +1,110 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// MaxStoredDocumentLinks is the number of links kept per page; links beyond it are counted
+// in the job's link extraction summary but not stored
+const MaxStoredDocumentLinks = 500
+
+// What link extraction did with a link
+const (
+	LinkDispositionEnqueued = "enqueued" // Queued as child job ChildJobID
+	LinkDispositionSkipped  = "skipped"  // Not scraped; Reason says why
+	LinkDispositionFailed   = "failed"   // The child job could not be saved or enqueued
+)
+
+// DocumentLink is one link found on a scraped page
+type DocumentLink struct {
+	URL         string  `json:"url"`
+	Disposition string  `json:"disposition"`
+	Reason      string  `json:"reason,omitempty"`       // Skip reason, e.g. duplicate or domain_denied
+	ChildJobID  *string `json:"child_job_id,omitempty"` // Job created for the link, if any
+}
+
+// SaveDocumentLinks replaces the links recorded for a job's page with links, in page order.
+// Only the first MaxStoredDocumentLinks are kept; it reports whether any were dropped.
+func (s *Storage) SaveDocumentLinks(jobID string, links []DocumentLink) (truncated bool, err error) {
+	defer s.timeQuery("SaveDocumentLinks", "job_id", jobID, "links", len(links))()
+	if len(links) > MaxStoredDocumentLinks {
+		links = links[:MaxStoredDocumentLinks]
+		truncated = true
+	}
+
+	err = s.inTx("SaveDocumentLinks", func(tx *sql.Tx) error {
+		// A retried extraction replaces what an earlier attempt recorded
+		if _, err := tx.Exec("DELETE FROM document_links WHERE job_id = $1", jobID); err != nil {
+			return fmt.Errorf("failed to delete old document links: %w", err)
+		}
+
+		stmt, err := tx.Prepare(`
+			INSERT INTO document_links (job_id, position, url, disposition, reason, child_job_id)
+			VALUES ($1, $2, $3, $4, NULLIF($5, ''), $6)
+		`)
+		if err != nil {
+			return fmt.Errorf("failed to prepare document link insert: %w", err)
+		}
+		defer stmt.Close()
+		for i, link := range links {
+			if _, err := stmt.Exec(jobID, i, link.URL, link.Disposition, link.Reason, link.ChildJobID); err != nil {
+				return fmt.Errorf("failed to insert document link: %w", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+	return truncated, nil
+}
+
+// GetDocumentLinks returns the links recorded for the most recent scrape job that produced
+// requestID and extracted links, with that job. The job is nil when no links were recorded.
+func (s *Storage) GetDocumentLinks(requestID string) (*ScrapeJob, []DocumentLink, error) {
+	defer s.timeQuery("GetDocumentLinks", "request_id", requestID)()
+	var jobID string
+	err := s.db.QueryRow(`
+		SELECT j.id
+		FROM scrape_jobs j
+		WHERE j.result_request_id = $1
+		  AND `+s.inNamespace("j")+`
+		  AND EXISTS (SELECT 1 FROM document_links d WHERE d.job_id = j.id)
+		ORDER BY j.created_at DESC
+		LIMIT 1
+	`, requestID).Scan(&jobID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to find job with document links: %w", err)
+	}
+
+	job, err := s.GetScrapeJob(jobID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rows, err := s.db.Query(`
+		SELECT url, disposition, COALESCE(reason, ''), child_job_id
+		FROM document_links
+		WHERE job_id = $1
+		ORDER BY position
+	`, jobID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to query document links: %w", err)
+	}
+	defer rows.Close()
+
+	links := []DocumentLink{}
+	for rows.Next() {
+		var link DocumentLink
+		if err := rows.Scan(&link.URL, &link.Disposition, &link.Reason, &link.ChildJobID); err != nil {
+			return nil, nil, fmt.Errorf("failed to scan document link: %w", err)
+		}
+		links = append(links, link)
+	}
+	return job, links, rows.Err()
+}
@@ -0,0 +1,57 @@
+package storage
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestSaveDocumentLinksCapsAndReplaces(t *testing.T) {
+	t.Parallel()
+	store, cleanup := setupTestStorage(t)
+	defer cleanup()
+
+	sourceURL := "https://example.com/"
+	if err := store.SaveRequest(&Request{ID: "links-req", CreatedAt: time.Now(), SourceType: "url", SourceURL: &sourceURL, Tags: []string{}, Metadata: map[string]interface{}{}}); err != nil {
+		t.Fatalf("Failed to save request: %v", err)
+	}
+	job := &ScrapeJob{ID: "links-job", URL: sourceURL, Status: "completed", CreatedAt: time.Now(), UpdatedAt: time.Now()}
+	if err := store.SaveScrapeJob(job); err != nil {
+		t.Fatalf("Failed to save job: %v", err)
+	}
+	if err := store.UpdateScrapeJobResult(job.ID, "links-req"); err != nil {
+		t.Fatalf("Failed to set job result: %v", err)
+	}
+
+	links := make([]DocumentLink, MaxStoredDocumentLinks+1)
+	for i := range links {
+		links[i] = DocumentLink{URL: fmt.Sprintf("https://example.com/%d", i), Disposition: LinkDispositionSkipped, Reason: "duplicate"}
+	}
+	truncated, err := store.SaveDocumentLinks(job.ID, links)
+	if err != nil {
+		t.Fatalf("SaveDocumentLinks failed: %v", err)
+	}
+	if !truncated {
+		t.Error("Expected the links beyond the cap to be reported as truncated")
+	}
+	_, stored, err := store.GetDocumentLinks("links-req")
+	if err != nil {
+		t.Fatalf("GetDocumentLinks failed: %v", err)
+	}
+	if len(stored) != MaxStoredDocumentLinks || stored[MaxStoredDocumentLinks-1].URL != links[MaxStoredDocumentLinks-1].URL {
+		t.Fatalf("Expected the first %d links in order, got %d", MaxStoredDocumentLinks, len(stored))
+	}
+
+	// A retried extraction replaces the earlier record
+	childID := "child-job"
+	if _, err := store.SaveDocumentLinks(job.ID, []DocumentLink{{URL: "https://example.com/new", Disposition: LinkDispositionEnqueued, ChildJobID: &childID}}); err != nil {
+		t.Fatalf("SaveDocumentLinks failed: %v", err)
+	}
+	got, stored, err := store.GetDocumentLinks("links-req")
+	if err != nil {
+		t.Fatalf("GetDocumentLinks failed: %v", err)
+	}
+	if got == nil || got.ID != job.ID || len(stored) != 1 || stored[0].ChildJobID == nil || *stored[0].ChildJobID != childID || stored[0].Reason != "" {
+		t.Errorf("Expected only the retried extraction's link, got job %v and %+v", got, stored)
+	}
+}
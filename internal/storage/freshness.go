@@ -0,0 +1,111 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/docutag/controller/internal/language"
+)
+
+// StaleRequest is a stored URL document due to be checked for fresh content
+type StaleRequest struct {
+	ID            string
+	URL           string
+	LastScrapedAt time.Time
+	Namespace     string // The re-scrape job is queued in the request's namespace
+}
+
+// ListStaleRequests returns SEO-enabled URL requests last scraped before cutoff, oldest
+// first. Deleted and tombstoned requests are left alone, as are requests that already
+// have a re-scrape queued or running.
+func (s *Storage) ListStaleRequests(cutoff time.Time, limit int) ([]StaleRequest, error) {
+	defer s.timeQuery("ListStaleRequests", "limit", limit)()
+	rows, err := s.db.Query(`
+		SELECT r.id, r.source_url, COALESCE(r.scraped_at, r.created_at), r.namespace
+		FROM requests r
+		WHERE r.source_type = 'url'
+		  AND `+s.inNamespace("r")+`
+		  AND r.source_url IS NOT NULL
+		  AND r.seo_enabled = true
+		  AND r.deleted_at IS NULL
+		  AND r.metadata_json->>'tombstone_datetime' IS NULL
+		  AND COALESCE(r.scraped_at, r.created_at) < $1
+		  AND NOT EXISTS (
+			SELECT 1 FROM scrape_jobs j
+			WHERE j.rescrape_of = r.id AND j.status IN ('queued', 'processing')
+		  )
+		ORDER BY COALESCE(r.scraped_at, r.created_at) ASC
+		LIMIT $2
+	`, cutoff, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list stale requests: %w", err)
+	}
+	defer rows.Close()
+
+	var stale []StaleRequest
+	for rows.Next() {
+		var req StaleRequest
+		if err := rows.Scan(&req.ID, &req.URL, &req.LastScrapedAt, &req.Namespace); err != nil {
+			return nil, fmt.Errorf("failed to scan stale request: %w", err)
+		}
+		stale = append(stale, req)
+	}
+	return stale, rows.Err()
+}
+
+// RefreshScrapedRequest replaces the scraped content of an existing request with req's:
+// scraper and analyzer IDs, tags, metadata, content hash and language. The slug, SEO
+// flag, star and provenance of the stored request are kept. Callers snapshot the request
+// with SaveRequestVersion first if the old content should stay recoverable.
+func (s *Storage) RefreshScrapedRequest(req *Request) error {
+	defer s.timeQuery("RefreshScrapedRequest", "id", req.ID)()
+	tagsJSON, err := json.Marshal(req.Tags)
+	if err != nil {
+		return fmt.Errorf("failed to marshal tags: %w", err)
+	}
+	metadataJSON, err := json.Marshal(req.Metadata)
+	if err != nil {
+		return fmt.Errorf("failed to marshal metadata: %w", err)
+	}
+
+	var contentHash *string
+	if req.ContentHash != "" {
+		contentHash = &req.ContentHash
+	}
+	if req.Language == "" {
+		req.Language = language.Resolve(req.Metadata)
+	}
+
+	return s.inTx("RefreshScrapedRequest", func(tx *sql.Tx) error {
+		// The effective date is re-derived from the new metadata, falling back to the original creation time
+		var createdAt time.Time
+		var before sql.NullString
+		err := tx.QueryRow(`SELECT created_at, metadata_json FROM requests WHERE id = $1 AND deleted_at IS NULL FOR UPDATE`, req.ID).Scan(&createdAt, &before)
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("request not found")
+		}
+		if err != nil {
+			return fmt.Errorf("failed to query request: %w", err)
+		}
+		effectiveDate := extractEffectiveDate(req.Metadata, createdAt)
+
+		if _, err := tx.Exec(`
+			UPDATE requests
+			SET scraper_uuid = $2, textanalyzer_uuid = $3, tags_json = $4, metadata_json = $5,
+			    content_hash = $6, language = $7, effective_date = $8, scraped_at = $9,
+			    quality_score = $10, row_version = row_version + 1
+			WHERE id = $1
+		`, req.ID, req.ScraperUUID, req.TextAnalyzerUUID, string(tagsJSON), string(metadataJSON),
+			contentHash, req.Language, effectiveDate, time.Now(), extractQualityScore(req.Metadata)); err != nil {
+			return fmt.Errorf("failed to refresh request: %w", err)
+		}
+		recordMetadataChangeTx(tx, req.ID, AuditActorWorker, before.String, string(metadataJSON))
+
+		if _, err := tx.Exec("DELETE FROM tags WHERE request_id = $1", req.ID); err != nil {
+			return fmt.Errorf("failed to delete old tag associations: %w", err)
+		}
+		return s.insertTags(tx, req.ID, req.Tags)
+	})
+}
@@ -0,0 +1,82 @@
+package storage
+
+import (
+	"testing"
+	"time"
+)
+
+func TestListStaleRequests(t *testing.T) {
+	t.Parallel()
+	store, cleanup := setupTestStorage(t)
+	defer cleanup()
+
+	old := time.Now().UTC().Add(-60 * 24 * time.Hour)
+	save := func(id string, createdAt time.Time, seo bool, metadata map[string]interface{}) {
+		t.Helper()
+		sourceURL := "https://news.example.com/" + id
+		req := &Request{ID: id, CreatedAt: createdAt, SourceType: "url", SourceURL: &sourceURL, Tags: []string{"news"}, Metadata: metadata, SEOEnabled: seo}
+		if err := store.SaveRequest(req); err != nil {
+			t.Fatalf("Failed to save request: %v", err)
+		}
+	}
+	save("stale-oldest", old.Add(-time.Hour), true, map[string]interface{}{})
+	save("stale-due", old, true, map[string]interface{}{})
+	save("stale-fresh", time.Now().UTC(), true, map[string]interface{}{})
+	save("stale-no-seo", old, false, map[string]interface{}{})
+	save("stale-tombstoned", old, true, map[string]interface{}{"tombstone_datetime": time.Now().Format(time.RFC3339)})
+	save("stale-deleted", old, true, map[string]interface{}{})
+	if _, err := store.SoftDeleteRequest("stale-deleted"); err != nil {
+		t.Fatalf("Failed to delete request: %v", err)
+	}
+
+	cutoff := time.Now().UTC().Add(-30 * 24 * time.Hour)
+	stale, err := store.ListStaleRequests(cutoff, 10)
+	if err != nil {
+		t.Fatalf("ListStaleRequests failed: %v", err)
+	}
+	if len(stale) != 2 || stale[0].ID != "stale-oldest" || stale[1].ID != "stale-due" {
+		t.Fatalf("Expected stale-oldest then stale-due, got %+v", stale)
+	}
+
+	// A queued re-scrape takes the request out of later passes
+	target := "stale-oldest"
+	job := &ScrapeJob{ID: "stale-job", URL: stale[0].URL, Status: "queued", CreatedAt: time.Now(), UpdatedAt: time.Now(), RescrapeOf: &target}
+	if err := store.SaveScrapeJob(job); err != nil {
+		t.Fatalf("Failed to save job: %v", err)
+	}
+	stale, err = store.ListStaleRequests(cutoff, 10)
+	if err != nil {
+		t.Fatalf("ListStaleRequests failed: %v", err)
+	}
+	if len(stale) != 1 || stale[0].ID != "stale-due" {
+		t.Fatalf("Expected only stale-due while stale-oldest is queued, got %+v", stale)
+	}
+
+	// Refreshing the content resets the request's last scrape time
+	refreshed := &Request{ID: "stale-due", Tags: []string{"news", "updated"}, Metadata: map[string]interface{}{"title": "Fresh"}, ContentHash: "abc123"}
+	if err := store.RefreshScrapedRequest(refreshed); err != nil {
+		t.Fatalf("RefreshScrapedRequest failed: %v", err)
+	}
+	stale, err = store.ListStaleRequests(cutoff, 10)
+	if err != nil {
+		t.Fatalf("ListStaleRequests failed: %v", err)
+	}
+	if len(stale) != 0 {
+		t.Errorf("Expected no stale requests after the refresh, got %+v", stale)
+	}
+
+	got, err := store.GetRequest("stale-due")
+	if err != nil {
+		t.Fatalf("GetRequest failed: %v", err)
+	}
+	if got.Metadata["title"] != "Fresh" || len(got.Tags) != 2 || got.ContentHash != "abc123" {
+		t.Errorf("Expected refreshed content, got tags %v, metadata %v, hash %q", got.Tags, got.Metadata, got.ContentHash)
+	}
+	if got.CreatedAt.Sub(old).Abs() > time.Second {
+		t.Errorf("Expected created_at to be kept, got %v", got.CreatedAt)
+	}
+
+	if err := store.RefreshScrapedRequest(&Request{ID: "missing"}); err == nil {
+		t.Error("Expected an error refreshing a missing request")
+	}
+}
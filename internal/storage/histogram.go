@@ -0,0 +1,174 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Ways GetRequestHistogram can split each bucket
+const (
+	HistogramGroupSourceType = "source_type"
+	HistogramGroupTag        = "tag"
+)
+
+// HistogramOptions selects the range, bucket size and grouping for GetRequestHistogram
+type HistogramOptions struct {
+	Start             time.Time // Inclusive; the first bucket starts here
+	End               time.Time // Exclusive
+	Bucket            time.Duration
+	Location          *time.Location // Zone whole-day buckets follow across DST changes; nil means UTC
+	GroupBy           string         // "", HistogramGroupSourceType or HistogramGroupTag
+	Tags              []string       // Tags to count when grouping by tag
+	IncludeTombstoned bool
+}
+
+// HistogramBucket counts requests whose effective_date falls between Start and the next bucket's Start
+type HistogramBucket struct {
+	Start  time.Time      `json:"start"`
+	Count  int            `json:"count"`
+	Groups map[string]int `json:"groups,omitempty"`
+}
+
+// GetRequestHistogram counts requests per time bucket by effective_date. Every bucket in the
+// range is returned, including empty ones, and every group key seen in the range appears in
+// every bucket so chart series line up. Soft-deleted requests are always excluded.
+func (s *Storage) GetRequestHistogram(opts HistogramOptions) ([]HistogramBucket, error) {
+	defer s.timeQuery("GetRequestHistogram", "start", opts.Start, "end", opts.End, "bucket", opts.Bucket, "group_by", opts.GroupBy)()
+	if opts.Bucket <= 0 {
+		return nil, fmt.Errorf("bucket must be positive")
+	}
+
+	buckets := newHistogramBuckets(opts.Start, opts.End, opts.Bucket, opts.Location)
+	if len(buckets) == 0 {
+		return buckets, nil
+	}
+
+	filter := `r.effective_date >= $1 AND r.effective_date < $2 AND ` + notDeletedPredicateAliased + ` AND ` + s.inNamespace("r")
+	if !opts.IncludeTombstoned {
+		filter += ` AND (r.metadata_json->>'tombstone_datetime' IS NULL
+			OR (r.metadata_json->>'tombstone_datetime')::timestamp > NOW())`
+	}
+	// Buckets are located by their start times rather than by arithmetic on a fixed length, since
+	// a day bucket in a zone with DST is 23 or 25 hours long twice a year
+	bucketExpr := `width_bucket(r.effective_date, $3::timestamptz[]) - 1`
+	starts := make([]string, len(buckets))
+	for i, b := range buckets {
+		starts[i] = b.Start.UTC().Format(time.RFC3339Nano)
+	}
+
+	// Totals are always split by source type; the split is dropped unless requested
+	rows, err := s.db.Query(`
+		SELECT `+bucketExpr+` AS bucket, r.source_type, COUNT(*)
+		FROM requests r
+		WHERE `+filter+`
+		GROUP BY bucket, r.source_type
+	`, opts.Start, opts.End, pq.Array(starts))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query request histogram: %w", err)
+	}
+	bySource, err := scanHistogramCounts(rows)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query request histogram: %w", err)
+	}
+
+	var groups []histogramCount
+	switch opts.GroupBy {
+	case HistogramGroupSourceType:
+		groups = bySource
+	case HistogramGroupTag:
+		rows, err := s.db.Query(`
+			SELECT `+bucketExpr+` AS bucket, t.tag, COUNT(DISTINCT r.id)
+			FROM requests r
+			INNER JOIN tags t ON t.request_id = r.id
+			WHERE `+filter+` AND t.tag = ANY($4)
+			GROUP BY bucket, t.tag
+		`, opts.Start, opts.End, pq.Array(starts), pq.Array(opts.Tags))
+		if err != nil {
+			return nil, fmt.Errorf("failed to query tag histogram: %w", err)
+		}
+		if groups, err = scanHistogramCounts(rows); err != nil {
+			return nil, fmt.Errorf("failed to query tag histogram: %w", err)
+		}
+	}
+
+	for _, c := range bySource {
+		if c.bucket >= 0 && c.bucket < len(buckets) {
+			buckets[c.bucket].Count += c.count
+		}
+	}
+
+	if opts.GroupBy != "" {
+		keys := make([]string, 0, len(opts.Tags))
+		if opts.GroupBy == HistogramGroupTag {
+			keys = append(keys, opts.Tags...)
+		}
+		for _, c := range groups {
+			keys = append(keys, c.key)
+		}
+		fillHistogramGroups(buckets, keys)
+		for _, c := range groups {
+			if c.bucket >= 0 && c.bucket < len(buckets) {
+				buckets[c.bucket].Groups[c.key] += c.count
+			}
+		}
+	}
+
+	return buckets, nil
+}
+
+// histogramCount is one (bucket index, group key, count) row
+type histogramCount struct {
+	bucket int
+	key    string
+	count  int
+}
+
+// scanHistogramCounts reads (bucket, key, count) rows and closes rows
+func scanHistogramCounts(rows *sql.Rows) ([]histogramCount, error) {
+	defer rows.Close()
+	var counts []histogramCount
+	for rows.Next() {
+		var c histogramCount
+		if err := rows.Scan(&c.bucket, &c.key, &c.count); err != nil {
+			return nil, err
+		}
+		counts = append(counts, c)
+	}
+	return counts, rows.Err()
+}
+
+// newHistogramBuckets returns zeroed buckets covering [start, end). Sizes of whole days step
+// by calendar day in loc, so each bucket runs from local midnight to local midnight; bucket
+// starts are reported in loc.
+func newHistogramBuckets(start, end time.Time, size time.Duration, loc *time.Location) []HistogramBucket {
+	if loc == nil {
+		loc = time.UTC
+	}
+	next := func(t time.Time) time.Time { return t.Add(size) }
+	if days := int(size / (24 * time.Hour)); days > 0 && size%(24*time.Hour) == 0 {
+		next = func(t time.Time) time.Time { return t.AddDate(0, 0, days) }
+	}
+
+	buckets := []HistogramBucket{}
+	for t := start.In(loc); t.Before(end); t = next(t) {
+		buckets = append(buckets, HistogramBucket{Start: t})
+	}
+	return buckets
+}
+
+// fillHistogramGroups gives every bucket a zero count for each key
+func fillHistogramGroups(buckets []HistogramBucket, keys []string) {
+	for i := range buckets {
+		if buckets[i].Groups == nil {
+			buckets[i].Groups = make(map[string]int, len(keys))
+		}
+		for _, key := range keys {
+			if _, ok := buckets[i].Groups[key]; !ok {
+				buckets[i].Groups[key] = 0
+			}
+		}
+	}
+}
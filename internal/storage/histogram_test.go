@@ -0,0 +1,180 @@
+package storage
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNewHistogramBuckets(t *testing.T) {
+	t.Parallel()
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	buckets := newHistogramBuckets(start, start.Add(3*24*time.Hour), 24*time.Hour, nil)
+	if len(buckets) != 3 {
+		t.Fatalf("expected 3 buckets, got %d", len(buckets))
+	}
+	for i, b := range buckets {
+		if want := start.Add(time.Duration(i) * 24 * time.Hour); !b.Start.Equal(want) {
+			t.Errorf("bucket %d: expected start %v, got %v", i, want, b.Start)
+		}
+		if b.Count != 0 {
+			t.Errorf("bucket %d: expected zero count, got %d", i, b.Count)
+		}
+	}
+
+	// A partial trailing bucket is still included
+	if got := len(newHistogramBuckets(start, start.Add(25*time.Hour), 24*time.Hour, nil)); got != 2 {
+		t.Errorf("expected 2 buckets for a partial range, got %d", got)
+	}
+	if got := len(newHistogramBuckets(start, start, time.Hour, nil)); got != 0 {
+		t.Errorf("expected no buckets for an empty range, got %d", got)
+	}
+}
+
+func TestNewHistogramBucketsAcrossDST(t *testing.T) {
+	t.Parallel()
+	la, err := time.LoadLocation("America/Los_Angeles")
+	if err != nil {
+		t.Skipf("time zone data unavailable: %v", err)
+	}
+	// Clocks in Los Angeles spring forward on 2024-03-10, making that day 23 hours long
+	start := time.Date(2024, 3, 9, 0, 0, 0, 0, la)
+	buckets := newHistogramBuckets(start, time.Date(2024, 3, 12, 0, 0, 0, 0, la), 24*time.Hour, la)
+	if len(buckets) != 3 {
+		t.Fatalf("expected 3 buckets, got %d", len(buckets))
+	}
+	for i, b := range buckets {
+		if want := time.Date(2024, 3, 9+i, 0, 0, 0, 0, la); !b.Start.Equal(want) || b.Start.Location() != la {
+			t.Errorf("bucket %d: expected start %v, got %v", i, want, b.Start)
+		}
+	}
+}
+
+func TestGetRequestHistogramTimeZone(t *testing.T) {
+	t.Parallel()
+	store, cleanup := setupTestStorage(t)
+	defer cleanup()
+	la, err := time.LoadLocation("America/Los_Angeles")
+	if err != nil {
+		t.Skipf("time zone data unavailable: %v", err)
+	}
+
+	// Late on March 1 in Los Angeles is already March 2 in UTC
+	req := &Request{
+		ID: "late-evening", CreatedAt: time.Now(), SourceType: "url", TextAnalyzerUUID: "ta-late-evening",
+		Metadata: map[string]interface{}{"scraper_metadata": map[string]interface{}{"publish_date": "2024-03-01T23:30:00-08:00"}},
+	}
+	if err := store.SaveRequest(req); err != nil {
+		t.Fatalf("Failed to save request: %v", err)
+	}
+
+	tests := []struct {
+		loc  *time.Location
+		want int // Index of the bucket holding the document, counting from February 29
+	}{
+		{time.UTC, 2},
+		{la, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.loc.String(), func(t *testing.T) {
+			start := time.Date(2024, 2, 29, 0, 0, 0, 0, tt.loc)
+			buckets, err := store.GetRequestHistogram(HistogramOptions{
+				Start: start, End: start.AddDate(0, 0, 4), Bucket: 24 * time.Hour, Location: tt.loc,
+			})
+			if err != nil {
+				t.Fatalf("GetRequestHistogram failed: %v", err)
+			}
+			for i, b := range buckets {
+				want := 0
+				if i == tt.want {
+					want = 1
+				}
+				if b.Count != want {
+					t.Errorf("bucket %d (%s): expected %d, got %d", i, b.Start.Format("2006-01-02"), want, b.Count)
+				}
+			}
+		})
+	}
+}
+
+func TestGetRequestHistogram(t *testing.T) {
+	t.Parallel()
+	store, cleanup := setupTestStorage(t)
+	defer cleanup()
+
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	day := 24 * time.Hour
+	now := time.Now().UTC()
+	requests := []*Request{
+		{ID: "day0-url", EffectiveDate: start.Add(2 * time.Hour), SourceType: "url", Tags: []string{"go"}},
+		{ID: "day0-text", EffectiveDate: start.Add(20 * time.Hour), SourceType: "text", Tags: []string{"go", "news"}},
+		{ID: "day2-url", EffectiveDate: start.Add(2*day + time.Hour), SourceType: "url", Tags: []string{"news"}},
+		{
+			ID: "day2-tombstoned", EffectiveDate: start.Add(2*day + 3*time.Hour), SourceType: "url", Tags: []string{"go"},
+			Metadata: map[string]interface{}{"tombstone_datetime": now.Add(-time.Hour).Format(time.RFC3339)},
+		},
+		{ID: "day2-deleted", EffectiveDate: start.Add(2*day + 4*time.Hour), SourceType: "url", Tags: []string{"go"}},
+		{ID: "out-of-range", EffectiveDate: start.Add(5 * day), SourceType: "url", Tags: []string{"go"}},
+	}
+	for _, req := range requests {
+		req.CreatedAt = now
+		req.TextAnalyzerUUID = "ta-" + req.ID
+		if req.Metadata == nil {
+			req.Metadata = map[string]interface{}{}
+		}
+		if err := store.SaveRequest(req); err != nil {
+			t.Fatalf("Failed to save request %s: %v", req.ID, err)
+		}
+	}
+	if _, err := store.SoftDeleteRequest("day2-deleted"); err != nil {
+		t.Fatalf("Failed to soft delete request: %v", err)
+	}
+
+	opts := HistogramOptions{Start: start, End: start.Add(3 * day), Bucket: day}
+
+	buckets, err := store.GetRequestHistogram(opts)
+	if err != nil {
+		t.Fatalf("GetRequestHistogram failed: %v", err)
+	}
+	if len(buckets) != 3 {
+		t.Fatalf("expected 3 buckets, got %d", len(buckets))
+	}
+	for i, want := range []int{2, 0, 1} {
+		if buckets[i].Count != want {
+			t.Errorf("bucket %d: expected %d, got %d", i, want, buckets[i].Count)
+		}
+		if buckets[i].Groups != nil {
+			t.Errorf("bucket %d: expected no groups without group_by, got %v", i, buckets[i].Groups)
+		}
+	}
+
+	opts.IncludeTombstoned = true
+	opts.GroupBy = HistogramGroupSourceType
+	buckets, err = store.GetRequestHistogram(opts)
+	if err != nil {
+		t.Fatalf("GetRequestHistogram failed: %v", err)
+	}
+	if buckets[2].Count != 2 || buckets[2].Groups["url"] != 2 {
+		t.Errorf("expected tombstoned request included in day 2, got %+v", buckets[2])
+	}
+	if count, ok := buckets[1].Groups["text"]; !ok || count != 0 {
+		t.Errorf("expected empty bucket to carry a zero text group, got %v", buckets[1].Groups)
+	}
+
+	opts.IncludeTombstoned = false
+	opts.GroupBy = HistogramGroupTag
+	opts.Tags = []string{"go", "missing"}
+	buckets, err = store.GetRequestHistogram(opts)
+	if err != nil {
+		t.Fatalf("GetRequestHistogram failed: %v", err)
+	}
+	if buckets[0].Groups["go"] != 2 || buckets[2].Groups["go"] != 0 {
+		t.Errorf("unexpected go counts: %v, %v", buckets[0].Groups, buckets[2].Groups)
+	}
+	if _, ok := buckets[0].Groups["news"]; ok {
+		t.Errorf("expected only requested tags in groups, got %v", buckets[0].Groups)
+	}
+	if count, ok := buckets[1].Groups["missing"]; !ok || count != 0 {
+		t.Errorf("expected requested tag with no matches to be zero, got %v", buckets[1].Groups)
+	}
+}
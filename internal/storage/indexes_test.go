@@ -0,0 +1,81 @@
+package storage
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+// plannedIndexes returns the indexes the planner uses for query. Sequential scans are
+// disabled for the transaction, so an empty test database still shows whether an index
+// can serve the query at all.
+func plannedIndexes(t *testing.T, store *Storage, query string, args ...interface{}) map[string]bool {
+	t.Helper()
+	tx, err := store.db.Begin()
+	if err != nil {
+		t.Fatalf("Failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+	if _, err := tx.Exec("SET LOCAL enable_seqscan = off"); err != nil {
+		t.Fatalf("Failed to disable sequential scans: %v", err)
+	}
+
+	var plan string
+	if err := tx.QueryRow("EXPLAIN (FORMAT JSON) "+query, args...).Scan(&plan); err != nil {
+		t.Fatalf("Failed to explain query: %v", err)
+	}
+	var nodes []struct {
+		Plan map[string]interface{} `json:"Plan"`
+	}
+	if err := json.Unmarshal([]byte(plan), &nodes); err != nil {
+		t.Fatalf("Failed to parse plan: %v", err)
+	}
+
+	indexes := map[string]bool{}
+	var walk func(node map[string]interface{})
+	walk = func(node map[string]interface{}) {
+		if name, ok := node["Index Name"].(string); ok {
+			indexes[name] = true
+		}
+		children, _ := node["Plans"].([]interface{})
+		for _, child := range children {
+			if c, ok := child.(map[string]interface{}); ok {
+				walk(c)
+			}
+		}
+	}
+	for _, n := range nodes {
+		walk(n.Plan)
+	}
+	return indexes
+}
+
+func TestHotQueriesUseIndexes(t *testing.T) {
+	t.Parallel()
+	base, cleanup := setupTestStorage(t)
+	defer cleanup()
+	store := base.WithNamespace(DefaultNamespace)
+
+	tests := []struct {
+		name    string
+		indexes []string // Any of these serves the query
+		build   func() (string, []interface{})
+	}{
+		{"exact tag search", []string{"idx_tags_tag_request_id", "idx_tags_tag"}, func() (string, []interface{}) {
+			return store.searchByTagsQuery([]string{"go"}, false)
+		}},
+		{"unfiltered listing", []string{"idx_requests_listing", "idx_requests_namespace"}, func() (string, []interface{}) {
+			return store.filterRequestsQuery(FilterOptions{Limit: 20})
+		}},
+	}
+	for _, tt := range tests {
+		query, args := tt.build()
+		used := plannedIndexes(t, store, query, args...)
+		found := false
+		for _, index := range tt.indexes {
+			found = found || used[index]
+		}
+		if !found {
+			t.Errorf("%s: expected the plan to use one of %v, got %v", tt.name, tt.indexes, used)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package storage
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// GraphNode is a document in the link graph
+type GraphNode struct {
+	ID     string `json:"id"`
+	Slug   string `json:"slug,omitempty"`
+	Title  string `json:"title,omitempty"`
+	Domain string `json:"domain,omitempty"`
+}
+
+// GraphEdge records that the page of From linked to To
+type GraphEdge struct {
+	From         string    `json:"from"`
+	To           string    `json:"to"`
+	DiscoveredAt time.Time `json:"discovered_at"` // When the crawl first queued To from From
+}
+
+// LinkGraph is the part of the crawl graph reachable from a root request
+type LinkGraph struct {
+	Nodes     []GraphNode `json:"nodes"`
+	Edges     []GraphEdge `json:"edges"`
+	Truncated bool        `json:"truncated"` // maxNodes was reached before the depth limit
+}
+
+// GetLinkGraph walks the crawl graph outward from rootID for up to depth links. Edges come
+// from the parent/child scrape job chain: a child job's result request was linked from its
+// parent job's result request. Each level is one query and the nodes are loaded in one more,
+// so a walk costs depth+1 queries however wide it gets. Requests already visited are not
+// expanded again, so pages linking to each other cannot loop. Deleted requests are left out.
+func (s *Storage) GetLinkGraph(rootID string, depth, maxNodes int) (*LinkGraph, error) {
+	defer s.timeQuery("GetLinkGraph", "root", rootID, "depth", depth)()
+	graph := &LinkGraph{Nodes: []GraphNode{}, Edges: []GraphEdge{}}
+
+	visited := map[string]bool{rootID: true}
+	order := []string{rootID}
+	frontier := []string{rootID}
+	for level := 0; level < depth && len(frontier) > 0 && !graph.Truncated; level++ {
+		rows, err := s.db.Query(`
+			SELECT DISTINCT ON (p.result_request_id, c.result_request_id)
+				p.result_request_id, c.result_request_id, c.created_at
+			FROM scrape_jobs p
+			JOIN scrape_jobs c ON c.parent_job_id = p.id
+			JOIN requests r ON r.id = c.result_request_id AND r.deleted_at IS NULL AND `+s.inNamespace("r")+`
+			WHERE p.result_request_id = ANY($1)
+			  AND c.result_request_id <> p.result_request_id
+			ORDER BY p.result_request_id, c.result_request_id, c.created_at
+		`, pq.Array(frontier))
+		if err != nil {
+			return nil, fmt.Errorf("failed to query link graph edges: %w", err)
+		}
+
+		var next []string
+		for rows.Next() {
+			var edge GraphEdge
+			if err := rows.Scan(&edge.From, &edge.To, &edge.DiscoveredAt); err != nil {
+				rows.Close()
+				return nil, fmt.Errorf("failed to scan link graph edge: %w", err)
+			}
+			if !visited[edge.To] {
+				if len(order) >= maxNodes {
+					graph.Truncated = true
+					continue
+				}
+				visited[edge.To] = true
+				order = append(order, edge.To)
+				next = append(next, edge.To)
+			}
+			graph.Edges = append(graph.Edges, edge)
+		}
+		err = rows.Err()
+		rows.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to query link graph edges: %w", err)
+		}
+		frontier = next
+	}
+
+	rows, err := s.db.Query(`
+		SELECT id, COALESCE(slug, ''), COALESCE(metadata_json->>'title', ''),
+		       COALESCE(lower(substring(source_url from '^[a-zA-Z]+://(?:www\.)?([^/:?#]+)')), '')
+		FROM requests
+		WHERE id = ANY($1) AND deleted_at IS NULL AND `+s.inNamespace("")+`
+	`, pq.Array(order))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query link graph nodes: %w", err)
+	}
+	defer rows.Close()
+
+	nodes := make(map[string]GraphNode, len(order))
+	for rows.Next() {
+		var node GraphNode
+		if err := rows.Scan(&node.ID, &node.Slug, &node.Title, &node.Domain); err != nil {
+			return nil, fmt.Errorf("failed to scan link graph node: %w", err)
+		}
+		nodes[node.ID] = node
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to query link graph nodes: %w", err)
+	}
+
+	// Nodes are listed in the order they were reached, root first
+	for _, id := range order {
+		if node, ok := nodes[id]; ok {
+			graph.Nodes = append(graph.Nodes, node)
+		}
+	}
+	return graph, nil
+}
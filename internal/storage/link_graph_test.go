@@ -0,0 +1,88 @@
+package storage
+
+import (
+	"testing"
+	"time"
+)
+
+func TestGetLinkGraph(t *testing.T) {
+	t.Parallel()
+	store, cleanup := setupTestStorage(t)
+	defer cleanup()
+
+	// a links to b and c, b links back to a, and c links to d
+	now := time.Now()
+	for _, id := range []string{"a", "b", "c", "d"} {
+		sourceURL := "https://www.Example.com/" + id
+		slug := "page-" + id
+		req := &Request{ID: "graph-" + id, CreatedAt: now, SourceType: "url", SourceURL: &sourceURL, Slug: &slug, Tags: []string{}, Metadata: map[string]interface{}{"title": "Page " + id}}
+		if err := store.SaveRequest(req); err != nil {
+			t.Fatalf("Failed to save request: %v", err)
+		}
+	}
+	job := func(id, parent, result string) {
+		t.Helper()
+		j := &ScrapeJob{ID: id, URL: "https://example.com/" + result, Status: "completed", CreatedAt: now, UpdatedAt: now}
+		if parent != "" {
+			j.ParentJobID = &parent
+		}
+		if err := store.SaveScrapeJob(j); err != nil {
+			t.Fatalf("Failed to save job: %v", err)
+		}
+		if err := store.UpdateScrapeJobResult(id, "graph-"+result); err != nil {
+			t.Fatalf("Failed to set job result: %v", err)
+		}
+	}
+	job("job-a", "", "a")
+	job("job-b", "job-a", "b")
+	job("job-c", "job-a", "c")
+	job("job-b-a", "job-b", "a")
+	job("job-c-d", "job-c", "d")
+
+	ids := func(graph *LinkGraph) []string {
+		var out []string
+		for _, n := range graph.Nodes {
+			out = append(out, n.ID)
+		}
+		return out
+	}
+
+	graph, err := store.GetLinkGraph("graph-a", 1, 100)
+	if err != nil {
+		t.Fatalf("GetLinkGraph failed: %v", err)
+	}
+	if got := ids(graph); len(got) != 3 || got[0] != "graph-a" || len(graph.Edges) != 2 || graph.Truncated {
+		t.Errorf("depth 1: expected a, b and c with 2 edges, got %v and %+v", got, graph.Edges)
+	}
+	if n := graph.Nodes[0]; n.Slug != "page-a" || n.Title != "Page a" || n.Domain != "example.com" {
+		t.Errorf("Unexpected root node %+v", n)
+	}
+
+	// The cycle back to a is an edge, not another expansion
+	graph, err = store.GetLinkGraph("graph-a", 5, 100)
+	if err != nil {
+		t.Fatalf("GetLinkGraph failed: %v", err)
+	}
+	if got := ids(graph); len(got) != 4 || len(graph.Edges) != 4 || graph.Truncated {
+		t.Errorf("depth 5: expected 4 nodes and 4 edges, got %v and %+v", got, graph.Edges)
+	}
+
+	graph, err = store.GetLinkGraph("graph-a", 2, 2)
+	if err != nil {
+		t.Fatalf("GetLinkGraph failed: %v", err)
+	}
+	if got := ids(graph); len(got) != 2 || len(graph.Edges) != 1 || !graph.Truncated {
+		t.Errorf("max 2 nodes: expected a truncated graph of 2 nodes and 1 edge, got %v and %+v", got, graph.Edges)
+	}
+
+	if _, err := store.SoftDeleteRequest("graph-d"); err != nil {
+		t.Fatalf("Failed to delete request: %v", err)
+	}
+	graph, err = store.GetLinkGraph("graph-a", 2, 100)
+	if err != nil {
+		t.Fatalf("GetLinkGraph failed: %v", err)
+	}
+	if got := ids(graph); len(got) != 3 || len(graph.Edges) != 3 {
+		t.Errorf("Expected the deleted request and its edge to be left out, got %v and %+v", got, graph.Edges)
+	}
+}
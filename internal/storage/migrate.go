@@ -0,0 +1,151 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// MigrationStatus reports whether one migration has been applied to the database
+type MigrationStatus struct {
+	Version   int        `json:"version"`
+	Name      string     `json:"name"`
+	Applied   bool       `json:"applied"`
+	AppliedAt *time.Time `json:"applied_at,omitempty"`
+}
+
+// OpenPostgres opens a connection pool to connStr and checks it can reach the database.
+// Migrations are not run; New does that.
+func OpenPostgres(connStr string) (*sql.DB, error) {
+	db, err := sql.Open("postgres", connStr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+
+	// Configure connection pool for PostgreSQL
+	db.SetMaxOpenConns(25)
+	db.SetMaxIdleConns(5)
+	db.SetConnMaxLifetime(5 * time.Minute)
+
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to ping database: %w", err)
+	}
+	return db, nil
+}
+
+// ensureSchemaVersionTable creates the table that records applied migrations
+func ensureSchemaVersionTable(db *sql.DB) error {
+	_, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS controller_schema_version (
+			version INTEGER PRIMARY KEY,
+			applied_at TIMESTAMPTZ DEFAULT NOW()
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to create controller_schema_version table: %w", err)
+	}
+	return nil
+}
+
+// PostgresMigrationStatus lists every known migration in version order with whether it has
+// been applied to db
+func PostgresMigrationStatus(db *sql.DB) ([]MigrationStatus, error) {
+	if err := ensureSchemaVersionTable(db); err != nil {
+		return nil, err
+	}
+	rows, err := db.Query("SELECT version, applied_at FROM controller_schema_version")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read applied migrations: %w", err)
+	}
+	defer rows.Close()
+
+	applied := make(map[int]*time.Time)
+	for rows.Next() {
+		var version int
+		var appliedAt sql.NullTime
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan applied migration: %w", err)
+		}
+		if appliedAt.Valid {
+			applied[version] = &appliedAt.Time
+		} else {
+			applied[version] = nil
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read applied migrations: %w", err)
+	}
+
+	statuses := make([]MigrationStatus, 0, len(postgresMigrations))
+	for _, m := range postgresMigrations {
+		appliedAt, ok := applied[m.Version]
+		statuses = append(statuses, MigrationStatus{Version: m.Version, Name: m.Name, Applied: ok, AppliedAt: appliedAt})
+	}
+	return statuses, nil
+}
+
+// PendingMigrations counts the statuses that have not been applied
+func PendingMigrations(statuses []MigrationStatus) int {
+	pending := 0
+	for _, st := range statuses {
+		if !st.Applied {
+			pending++
+		}
+	}
+	return pending
+}
+
+// RollbackPostgresMigration reverses the newest applied migration and returns it, or nil if
+// none is applied. The down step and the removal of its version row commit together.
+func RollbackPostgresMigration(db *sql.DB) (*Migration, error) {
+	if err := ensureSchemaVersionTable(db); err != nil {
+		return nil, err
+	}
+	var version int
+	if err := db.QueryRow("SELECT COALESCE(MAX(version), 0) FROM controller_schema_version").Scan(&version); err != nil {
+		return nil, fmt.Errorf("failed to get current schema version: %w", err)
+	}
+	if version == 0 {
+		return nil, nil
+	}
+
+	var migration *Migration
+	for i := range postgresMigrations {
+		if postgresMigrations[i].Version == version {
+			migration = &postgresMigrations[i]
+		}
+	}
+	if migration == nil {
+		return nil, fmt.Errorf("schema version %d is newer than this build knows how to roll back", version)
+	}
+	if migration.Down == "" {
+		return nil, fmt.Errorf("migration %d (%s) has no down step", migration.Version, migration.Name)
+	}
+
+	slog.Default().Info("rolling back migration", "version", migration.Version, "name", migration.Name)
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction for migration %d: %w", migration.Version, err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(migration.Down); err != nil {
+		return nil, fmt.Errorf("failed to roll back migration %d (%s): %w", migration.Version, migration.Name, err)
+	}
+	if _, err := tx.Exec("DELETE FROM controller_schema_version WHERE version = $1", migration.Version); err != nil {
+		return nil, fmt.Errorf("failed to unrecord migration %d: %w", migration.Version, err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit rollback of migration %d: %w", migration.Version, err)
+	}
+	slog.Default().Info("migration rolled back", "version", migration.Version, "name", migration.Name)
+	return migration, nil
+}
+
+// MigrationStatus lists every known migration with whether it has been applied
+func (s *Storage) MigrationStatus() ([]MigrationStatus, error) {
+	defer s.timeQuery("MigrationStatus")()
+	return PostgresMigrationStatus(s.db)
+}
@@ -0,0 +1,71 @@
+package storage
+
+import "testing"
+
+func TestMigrationsHaveDownSteps(t *testing.T) {
+	t.Parallel()
+	previous := 0
+	for _, m := range postgresMigrations {
+		if m.Version != previous+1 {
+			t.Errorf("Expected migration %d after %d, got %d", previous+1, previous, m.Version)
+		}
+		if m.Down == "" {
+			t.Errorf("Migration %d (%s) has no down step", m.Version, m.Name)
+		}
+		previous = m.Version
+	}
+}
+
+func TestRollbackAndReapplyMigrations(t *testing.T) {
+	t.Parallel()
+	store, cleanup := setupTestStorage(t)
+	defer cleanup()
+	latest := postgresMigrations[len(postgresMigrations)-1].Version
+
+	tableExists := func(name string) bool {
+		t.Helper()
+		var exists bool
+		if err := store.db.QueryRow("SELECT to_regclass($1) IS NOT NULL", name).Scan(&exists); err != nil {
+			t.Fatalf("Failed to look up %s: %v", name, err)
+		}
+		return exists
+	}
+
+	statuses, err := store.MigrationStatus()
+	if err != nil {
+		t.Fatalf("MigrationStatus failed: %v", err)
+	}
+	if PendingMigrations(statuses) != 0 || len(statuses) != latest {
+		t.Fatalf("Expected all %d migrations applied, got %d pending of %d", latest, PendingMigrations(statuses), len(statuses))
+	}
+
+	// Roll back past the migration that created document_links
+	const steps = 5
+	for i := 0; i < steps; i++ {
+		m, err := RollbackPostgresMigration(store.db)
+		if err != nil {
+			t.Fatalf("Rollback %d failed: %v", i+1, err)
+		}
+		if m == nil || m.Version != latest-i {
+			t.Fatalf("Expected migration %d to be rolled back, got %+v", latest-i, m)
+		}
+	}
+	if tableExists("document_links") || tableExists("idx_tags_tag_request_id") {
+		t.Errorf("Expected the rolled back table and index to be gone")
+	}
+	statuses, _ = store.MigrationStatus()
+	if PendingMigrations(statuses) != steps || statuses[latest-steps-1].Applied != true || statuses[latest-1].Applied {
+		t.Errorf("Expected the newest %d migrations pending, got %+v", steps, statuses[latest-steps-1:])
+	}
+
+	if err := RunPostgresMigrations(store.db); err != nil {
+		t.Fatalf("Re-applying migrations failed: %v", err)
+	}
+	if !tableExists("document_links") || !tableExists("idx_tags_tag_request_id") {
+		t.Errorf("Expected re-applied migrations to recreate the table and index")
+	}
+	statuses, _ = store.MigrationStatus()
+	if PendingMigrations(statuses) != 0 {
+		t.Errorf("Expected nothing pending after re-applying, got %d", PendingMigrations(statuses))
+	}
+}
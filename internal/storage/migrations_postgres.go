@@ -9,11 +9,13 @@ import (
 // PostgreSQL-specific migrations
 // These migrations are designed for PostgreSQL and use PostgreSQL-specific features like SERIAL, JSONB, etc.
 
-// Migration represents a database schema migration
+// Migration represents a database schema migration. Down reverses SQL; it may lose the data
+// SQL added, such as a dropped column's values.
 type Migration struct {
 	Version int
 	Name    string
 	SQL     string
+	Down    string
 }
 
 var postgresMigrations = []Migration{
@@ -41,6 +43,9 @@ var postgresMigrations = []Migration{
 			CREATE INDEX IF NOT EXISTS idx_requests_scraper_uuid ON requests(scraper_uuid);
 			CREATE INDEX IF NOT EXISTS idx_requests_textanalyzer_uuid ON requests(textanalyzer_uuid);
 		`,
+		Down: `
+			DROP TABLE IF EXISTS requests;
+		`,
 	},
 	{
 		Version: 2,
@@ -56,6 +61,9 @@ var postgresMigrations = []Migration{
 			CREATE INDEX IF NOT EXISTS idx_tags_tag ON tags(tag);
 			CREATE INDEX IF NOT EXISTS idx_tags_request_id ON tags(request_id);
 		`,
+		Down: `
+			DROP TABLE IF EXISTS tags;
+		`,
 	},
 	{
 		Version: 3,
@@ -80,6 +88,9 @@ var postgresMigrations = []Migration{
 			)
 			WHERE effective_date IS NULL;
 		`,
+		Down: `
+			ALTER TABLE requests DROP COLUMN IF EXISTS effective_date;
+		`,
 	},
 	{
 		Version: 4,
@@ -91,6 +102,9 @@ var postgresMigrations = []Migration{
 			-- Create unique partial index on slug for fast lookups (only non-NULL slugs)
 			CREATE UNIQUE INDEX IF NOT EXISTS idx_requests_slug ON requests(slug) WHERE slug IS NOT NULL;
 		`,
+		Down: `
+			ALTER TABLE requests DROP COLUMN IF EXISTS slug;
+		`,
 	},
 	{
 		Version: 5,
@@ -102,6 +116,9 @@ var postgresMigrations = []Migration{
 			-- Create index on seo_enabled for filtering
 			CREATE INDEX IF NOT EXISTS idx_requests_seo_enabled ON requests(seo_enabled);
 		`,
+		Down: `
+			ALTER TABLE requests DROP COLUMN IF EXISTS seo_enabled;
+		`,
 	},
 	{
 		Version: 6,
@@ -129,6 +146,9 @@ var postgresMigrations = []Migration{
 			CREATE INDEX IF NOT EXISTS idx_scrape_jobs_url ON scrape_jobs(url);
 			CREATE INDEX IF NOT EXISTS idx_scrape_jobs_asynq_task_id ON scrape_jobs(asynq_task_id);
 		`,
+		Down: `
+			DROP TABLE IF EXISTS scrape_jobs;
+		`,
 	},
 	{
 		Version: 7,
@@ -152,27 +172,502 @@ var postgresMigrations = []Migration{
 				END IF;
 			END $$;
 		`,
+		Down: `
+			ALTER TABLE scrape_jobs DROP CONSTRAINT IF EXISTS fk_scrape_jobs_parent;
+			ALTER TABLE scrape_jobs DROP COLUMN IF EXISTS parent_job_id, DROP COLUMN IF EXISTS depth;
+		`,
+	},
+	{
+		Version: 8,
+		Name:    "add_audit_log",
+		SQL: `
+			-- Audit trail of mutating operations (who changed what and when)
+			CREATE TABLE IF NOT EXISTS audit_log (
+				id BIGSERIAL PRIMARY KEY,
+				created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+				actor TEXT NOT NULL,
+				action TEXT NOT NULL,
+				entity_type TEXT NOT NULL,
+				entity_id TEXT NOT NULL,
+				details_json JSONB
+			);
+
+			CREATE INDEX IF NOT EXISTS idx_audit_log_created_at ON audit_log(created_at DESC);
+			CREATE INDEX IF NOT EXISTS idx_audit_log_entity_id ON audit_log(entity_id);
+			CREATE INDEX IF NOT EXISTS idx_audit_log_action ON audit_log(action);
+		`,
+		Down: `
+			DROP TABLE IF EXISTS audit_log;
+		`,
+	},
+	{
+		Version: 9,
+		Name:    "add_soft_delete",
+		SQL: `
+			-- Soft delete: rows are hidden immediately and hard-deleted after a grace period
+			ALTER TABLE requests ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ;
+
+			-- Partial index so the reaper can find expired rows without scanning live ones
+			CREATE INDEX IF NOT EXISTS idx_requests_deleted_at ON requests(deleted_at) WHERE deleted_at IS NOT NULL;
+		`,
+		Down: `
+			ALTER TABLE requests DROP COLUMN IF EXISTS deleted_at;
+		`,
+	},
+	{
+		Version: 10,
+		Name:    "add_content_hash_dedup",
+		SQL: `
+			-- Normalized content hash for detecting the same article published at different URLs
+			ALTER TABLE requests ADD COLUMN IF NOT EXISTS content_hash TEXT;
+			CREATE INDEX IF NOT EXISTS idx_requests_content_hash ON requests(content_hash) WHERE content_hash IS NOT NULL;
+
+			-- Per-job opt-out and the existing request a duplicate job resolved to
+			ALTER TABLE scrape_jobs ADD COLUMN IF NOT EXISTS allow_duplicates BOOLEAN NOT NULL DEFAULT false;
+			ALTER TABLE scrape_jobs ADD COLUMN IF NOT EXISTS duplicate_of TEXT;
+		`,
+		Down: `
+			ALTER TABLE requests DROP COLUMN IF EXISTS content_hash;
+			ALTER TABLE scrape_jobs DROP COLUMN IF EXISTS allow_duplicates, DROP COLUMN IF EXISTS duplicate_of;
+		`,
+	},
+	{
+		Version: 11,
+		Name:    "add_request_versions",
+		SQL: `
+			-- Snapshots of a request taken before its content is overwritten
+			CREATE TABLE IF NOT EXISTS request_versions (
+				id BIGSERIAL PRIMARY KEY,
+				request_id TEXT NOT NULL REFERENCES requests(id) ON DELETE CASCADE,
+				version INTEGER NOT NULL,
+				captured_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+				reason TEXT NOT NULL,
+				metadata_json JSONB,
+				tags_json TEXT,
+				slug TEXT,
+				UNIQUE (request_id, version)
+			);
+
+			CREATE INDEX IF NOT EXISTS idx_request_versions_request_id ON request_versions(request_id, version DESC);
+		`,
+		Down: `
+			DROP TABLE IF EXISTS request_versions;
+		`,
+	},
+	{
+		Version: 12,
+		Name:    "add_normalized_url",
+		SQL: `
+			-- Canonical form of source_url (tracking params, fragment, default port removed) used for lookups
+			ALTER TABLE requests ADD COLUMN IF NOT EXISTS normalized_url TEXT;
+			CREATE INDEX IF NOT EXISTS idx_requests_normalized_url ON requests(normalized_url) WHERE normalized_url IS NOT NULL;
+		`,
+		Down: `
+			ALTER TABLE requests DROP COLUMN IF EXISTS normalized_url;
+		`,
+	},
+	{
+		Version: 13,
+		Name:    "add_scrape_job_robots_columns",
+		SQL: `
+			-- Scrapes that bypass robots.txt, and why a completed job was not scraped (e.g. robots_txt)
+			ALTER TABLE scrape_jobs ADD COLUMN IF NOT EXISTS override_robots BOOLEAN NOT NULL DEFAULT false;
+			ALTER TABLE scrape_jobs ADD COLUMN IF NOT EXISTS skip_reason TEXT;
+		`,
+		Down: `
+			ALTER TABLE scrape_jobs DROP COLUMN IF EXISTS override_robots, DROP COLUMN IF EXISTS skip_reason;
+		`,
+	},
+	{
+		Version: 14,
+		Name:    "add_scrape_jobs_result_request_id_index",
+		SQL: `
+			-- Lets a request's status view find the scrape job that produced it
+			CREATE INDEX IF NOT EXISTS idx_scrape_jobs_result_request_id ON scrape_jobs(result_request_id) WHERE result_request_id IS NOT NULL;
+		`,
+		Down: `
+			DROP INDEX IF EXISTS idx_scrape_jobs_result_request_id;
+		`,
+	},
+	{
+		Version: 15,
+		Name:    "add_analysis_timeout_index",
+		SQL: `
+			-- Partial index so the analysis recovery sweep only touches requests whose retrieval timed out
+			CREATE INDEX IF NOT EXISTS idx_requests_analysis_timeout ON requests(created_at)
+				WHERE metadata_json->>'analysis_retrieval_timeout' = 'true';
+		`,
+		Down: `
+			DROP INDEX IF EXISTS idx_requests_analysis_timeout;
+		`,
+	},
+	{
+		Version: 16,
+		Name:    "add_request_language",
+		SQL: `
+			-- Primary language subtag (e.g. "en"), or "und" when it could not be determined
+			ALTER TABLE requests ADD COLUMN IF NOT EXISTS language TEXT;
+			CREATE INDEX IF NOT EXISTS idx_requests_language ON requests(language) WHERE language IS NOT NULL;
+
+			-- Backfill from languages already recorded in metadata, analyzer first, then the page's declared language
+			UPDATE requests r
+			SET language = src.code
+			FROM (
+				SELECT id, lower(split_part(replace(COALESCE(
+					NULLIF(metadata_json->'analyzer_metadata'->>'language', 'und'),
+					NULLIF(metadata_json->>'language', 'und'),
+					metadata_json->'scraper_metadata'->>'language',
+					metadata_json->'scraper_metadata'->>'lang'
+				), '_', '-'), '-', 1)) AS code
+				FROM requests
+				WHERE language IS NULL
+			) src
+			WHERE r.id = src.id
+			  AND src.code ~ '^[a-z]{2,3}$'
+			  AND src.code <> 'und';
+		`,
+		Down: `
+			ALTER TABLE requests DROP COLUMN IF EXISTS language;
+		`,
+	},
+	{
+		Version: 17,
+		Name:    "add_request_starred",
+		SQL: `
+			-- Editor favourites; existing rows start unstarred
+			ALTER TABLE requests ADD COLUMN IF NOT EXISTS starred BOOLEAN NOT NULL DEFAULT false;
+			CREATE INDEX IF NOT EXISTS idx_requests_starred ON requests(effective_date DESC) WHERE starred;
+		`,
+		Down: `
+			ALTER TABLE requests DROP COLUMN IF EXISTS starred;
+		`,
+	},
+	{
+		Version: 18,
+		Name:    "add_created_by",
+		SQL: `
+			-- Provenance: the client, API key or worker that created each record. Rows from before
+			-- this migration cannot be attributed and default to 'unknown'.
+			ALTER TABLE requests ADD COLUMN IF NOT EXISTS created_by TEXT NOT NULL DEFAULT 'unknown';
+			ALTER TABLE scrape_jobs ADD COLUMN IF NOT EXISTS created_by TEXT NOT NULL DEFAULT 'unknown';
+			CREATE INDEX IF NOT EXISTS idx_requests_created_by ON requests(created_by);
+		`,
+		Down: `
+			ALTER TABLE requests DROP COLUMN IF EXISTS created_by;
+			ALTER TABLE scrape_jobs DROP COLUMN IF EXISTS created_by;
+		`,
+	},
+	{
+		Version: 19,
+		Name:    "create_settings",
+		SQL: `
+			-- Runtime overrides for tunable settings; keys without a row use the configured default
+			CREATE TABLE IF NOT EXISTS settings (
+				key TEXT PRIMARY KEY,
+				value JSONB NOT NULL,
+				updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+				updated_by TEXT NOT NULL
+			);
+		`,
+		Down: `
+			DROP TABLE IF EXISTS settings;
+		`,
+	},
+	{
+		Version: 20,
+		Name:    "add_crawl_budget",
+		SQL: `
+			-- Crawl budgets: descendants point at the first job of their crawl, and the root
+			-- counts the pages queued under it against max_pages (0 = configured default)
+			ALTER TABLE scrape_jobs ADD COLUMN IF NOT EXISTS root_job_id TEXT;
+			ALTER TABLE scrape_jobs ADD COLUMN IF NOT EXISTS max_pages INTEGER NOT NULL DEFAULT 0;
+			ALTER TABLE scrape_jobs ADD COLUMN IF NOT EXISTS pages_enqueued INTEGER NOT NULL DEFAULT 0;
+			ALTER TABLE scrape_jobs ADD COLUMN IF NOT EXISTS budget_exhausted BOOLEAN NOT NULL DEFAULT false;
+			CREATE INDEX IF NOT EXISTS idx_scrape_jobs_root_job_id ON scrape_jobs(root_job_id);
+		`,
+		Down: `
+			ALTER TABLE scrape_jobs DROP COLUMN IF EXISTS root_job_id, DROP COLUMN IF EXISTS max_pages,
+				DROP COLUMN IF EXISTS pages_enqueued, DROP COLUMN IF EXISTS budget_exhausted;
+		`,
+	},
+	{
+		Version: 21,
+		Name:    "add_link_extraction_summary",
+		SQL: `
+			-- What link extraction found on a page and why links were not queued
+			ALTER TABLE scrape_jobs ADD COLUMN IF NOT EXISTS link_extraction_summary JSONB;
+		`,
+		Down: `
+			ALTER TABLE scrape_jobs DROP COLUMN IF EXISTS link_extraction_summary;
+		`,
+	},
+	{
+		Version: 22,
+		Name:    "add_crawl_params",
+		SQL: `
+			-- Depth limit, link score threshold and queue a job's crawl ran with
+			ALTER TABLE scrape_jobs ADD COLUMN IF NOT EXISTS crawl_params JSONB;
+		`,
+		Down: `
+			ALTER TABLE scrape_jobs DROP COLUMN IF EXISTS crawl_params;
+		`,
+	},
+	{
+		Version: 23,
+		Name:    "add_scrape_job_original_url",
+		SQL: `
+			-- URL a job was first submitted with, kept when a retry points it at a corrected URL
+			ALTER TABLE scrape_jobs ADD COLUMN IF NOT EXISTS original_url TEXT;
+		`,
+		Down: `
+			ALTER TABLE scrape_jobs DROP COLUMN IF EXISTS original_url;
+		`,
+	},
+	{
+		Version: 24,
+		Name:    "add_stale_rescrape",
+		SQL: `
+			-- When a URL request's content was last refreshed in place; NULL means at created_at
+			ALTER TABLE requests ADD COLUMN IF NOT EXISTS scraped_at TIMESTAMPTZ;
+			CREATE INDEX IF NOT EXISTS idx_requests_last_scraped ON requests(COALESCE(scraped_at, created_at))
+				WHERE source_type = 'url' AND deleted_at IS NULL;
+
+			-- Request a scrape job refreshes in place instead of creating a new one
+			ALTER TABLE scrape_jobs ADD COLUMN IF NOT EXISTS rescrape_of TEXT;
+			CREATE INDEX IF NOT EXISTS idx_scrape_jobs_rescrape_of ON scrape_jobs(rescrape_of) WHERE rescrape_of IS NOT NULL;
+		`,
+		Down: `
+			ALTER TABLE requests DROP COLUMN IF EXISTS scraped_at;
+			ALTER TABLE scrape_jobs DROP COLUMN IF EXISTS rescrape_of;
+		`,
+	},
+	{
+		Version: 25,
+		Name:    "add_document_links",
+		SQL: `
+			-- Links found on a scraped page and what the crawler did with each, in page order
+			CREATE TABLE IF NOT EXISTS document_links (
+				job_id TEXT NOT NULL REFERENCES scrape_jobs(id) ON DELETE CASCADE,
+				position INTEGER NOT NULL,
+				url TEXT NOT NULL,
+				disposition TEXT NOT NULL,
+				reason TEXT,
+				child_job_id TEXT,
+				PRIMARY KEY (job_id, position)
+			);
+		`,
+		Down: `
+			DROP TABLE IF EXISTS document_links;
+		`,
+	},
+	{
+		Version: 26,
+		Name:    "add_saved_searches",
+		SQL: `
+			-- Named request filters. owner is the creator's identity, not a reference, so
+			-- removing an API key leaves its searches in place
+			CREATE TABLE IF NOT EXISTS saved_searches (
+				id TEXT PRIMARY KEY,
+				name TEXT NOT NULL,
+				owner TEXT NOT NULL,
+				filter_json JSONB NOT NULL,
+				created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+				updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+			);
+
+			CREATE INDEX IF NOT EXISTS idx_saved_searches_owner ON saved_searches(owner, name);
+		`,
+		Down: `
+			DROP TABLE IF EXISTS saved_searches;
+		`,
+	},
+	{
+		Version: 27,
+		Name:    "add_webhooks",
+		SQL: `
+			-- Webhook subscriptions receive every event of the types they list
+			CREATE TABLE IF NOT EXISTS webhooks (
+				id TEXT PRIMARY KEY,
+				url TEXT NOT NULL,
+				secret TEXT NOT NULL,
+				event_types TEXT[] NOT NULL,
+				enabled BOOLEAN NOT NULL DEFAULT TRUE,
+				consecutive_failures INTEGER NOT NULL DEFAULT 0,
+				disabled_at TIMESTAMPTZ,
+				created_by TEXT NOT NULL DEFAULT '',
+				created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+				updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+			);
+
+			-- Recent delivery attempts per subscription; older rows are pruned as new ones arrive
+			CREATE TABLE IF NOT EXISTS webhook_deliveries (
+				id BIGSERIAL PRIMARY KEY,
+				webhook_id TEXT NOT NULL REFERENCES webhooks(id) ON DELETE CASCADE,
+				event_id TEXT NOT NULL,
+				event_type TEXT NOT NULL,
+				success BOOLEAN NOT NULL,
+				status_code INTEGER NOT NULL DEFAULT 0,
+				attempts INTEGER NOT NULL,
+				error TEXT NOT NULL DEFAULT '',
+				duration_ms BIGINT NOT NULL DEFAULT 0,
+				created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+			);
+
+			CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_webhook ON webhook_deliveries(webhook_id, created_at DESC);
+		`,
+		Down: `
+			DROP TABLE IF EXISTS webhook_deliveries;
+			DROP TABLE IF EXISTS webhooks;
+		`,
+	},
+	{
+		Version: 28,
+		Name:    "add_namespaces",
+		SQL: `
+			-- Tenancy: API callers only see rows in their own namespace. Existing rows
+			-- belong to the default namespace.
+			ALTER TABLE requests ADD COLUMN IF NOT EXISTS namespace TEXT NOT NULL DEFAULT 'default';
+			ALTER TABLE scrape_jobs ADD COLUMN IF NOT EXISTS namespace TEXT NOT NULL DEFAULT 'default';
+
+			CREATE INDEX IF NOT EXISTS idx_requests_namespace ON requests(namespace, effective_date DESC);
+			CREATE INDEX IF NOT EXISTS idx_scrape_jobs_namespace ON scrape_jobs(namespace, created_at DESC);
+		`,
+		Down: `
+			ALTER TABLE requests DROP COLUMN IF EXISTS namespace;
+			ALTER TABLE scrape_jobs DROP COLUMN IF EXISTS namespace;
+		`,
+	},
+	{
+		Version: 29,
+		Name:    "add_hot_path_indexes",
+		SQL: `
+			-- Exact tag search reads request IDs straight from the index
+			CREATE INDEX IF NOT EXISTS idx_tags_tag_request_id ON tags(tag, request_id);
+
+			-- FilterRequests lists live, SEO-enabled documents newest first
+			CREATE INDEX IF NOT EXISTS idx_requests_listing ON requests(namespace, effective_date DESC)
+				WHERE deleted_at IS NULL AND seo_enabled;
+
+			-- Lookups and freshness passes by the URL as submitted
+			CREATE INDEX IF NOT EXISTS idx_requests_source_url ON requests(source_url) WHERE source_url IS NOT NULL;
+
+			-- Job lists filtered by status, newest first
+			CREATE INDEX IF NOT EXISTS idx_scrape_jobs_status_created_at ON scrape_jobs(status, created_at DESC);
+		`,
+		Down: `
+			DROP INDEX IF EXISTS idx_tags_tag_request_id;
+			DROP INDEX IF EXISTS idx_requests_listing;
+			DROP INDEX IF EXISTS idx_requests_source_url;
+			DROP INDEX IF EXISTS idx_scrape_jobs_status_created_at;
+		`,
+	},
+	{
+		Version: 30,
+		Name:    "add_scrape_job_skip_analysis",
+		SQL: `
+			-- Archival scrapes store the page without text analysis or quality tombstones
+			ALTER TABLE scrape_jobs ADD COLUMN IF NOT EXISTS skip_analysis BOOLEAN NOT NULL DEFAULT false;
+		`,
+		Down: `
+			ALTER TABLE scrape_jobs DROP COLUMN IF EXISTS skip_analysis;
+		`,
+	},
+	{
+		Version: 31,
+		Name:    "add_request_row_version",
+		SQL: `
+			-- Bumped on every change to a request; served as its ETag for If-Match updates
+			ALTER TABLE requests ADD COLUMN IF NOT EXISTS row_version BIGINT NOT NULL DEFAULT 1;
+		`,
+		Down: `
+			ALTER TABLE requests DROP COLUMN IF EXISTS row_version;
+		`,
+	},
+	{
+		Version: 32,
+		Name:    "add_backfill_runs",
+		SQL: `
+			-- Quality score copied out of metadata_json so it can be indexed; existing rows are
+			-- filled by the quality_score backfill
+			ALTER TABLE requests ADD COLUMN IF NOT EXISTS quality_score DOUBLE PRECISION;
+
+			-- One checkpoint per backfill, so a run resumes after a restart where it stopped
+			CREATE TABLE IF NOT EXISTS backfill_runs (
+				name TEXT PRIMARY KEY,
+				status TEXT NOT NULL,
+				last_id TEXT NOT NULL DEFAULT '',
+				processed BIGINT NOT NULL DEFAULT 0,
+				updated BIGINT NOT NULL DEFAULT 0,
+				error TEXT,
+				started_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+				updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+				completed_at TIMESTAMPTZ
+			);
+		`,
+		Down: `
+			DROP TABLE IF EXISTS backfill_runs;
+			ALTER TABLE requests DROP COLUMN IF EXISTS quality_score;
+		`,
+	},
+	{
+		Version: 33,
+		Name:    "add_text_hash_dedup",
+		SQL: `
+			-- Hash of the normalized submitted text, so repeated text submissions are found by index
+			ALTER TABLE requests ADD COLUMN IF NOT EXISTS text_hash TEXT;
+			CREATE INDEX IF NOT EXISTS idx_requests_text_hash ON requests(text_hash, created_at) WHERE text_hash IS NOT NULL;
+		`,
+		Down: `
+			DROP INDEX IF EXISTS idx_requests_text_hash;
+			ALTER TABLE requests DROP COLUMN IF EXISTS text_hash;
+		`,
+	},
+	{
+		Version: 34,
+		Name:    "add_namespace_to_webhooks_audit_and_saved_searches",
+		SQL: `
+			-- Tenancy for webhooks, saved searches and the audit log. Existing webhooks and
+			-- searches belong to the default namespace; audit entries take the namespace of
+			-- the request or scrape job they describe.
+			ALTER TABLE webhooks ADD COLUMN IF NOT EXISTS namespace TEXT NOT NULL DEFAULT 'default';
+			ALTER TABLE saved_searches ADD COLUMN IF NOT EXISTS namespace TEXT NOT NULL DEFAULT 'default';
+			ALTER TABLE audit_log ADD COLUMN IF NOT EXISTS namespace TEXT NOT NULL DEFAULT 'default';
+
+			UPDATE audit_log a SET namespace = r.namespace
+			FROM requests r
+			WHERE a.entity_type = 'request' AND a.entity_id = r.id AND r.namespace <> 'default';
+			UPDATE audit_log a SET namespace = j.namespace
+			FROM scrape_jobs j
+			WHERE a.entity_type = 'scrape_job' AND a.entity_id = j.id AND j.namespace <> 'default';
+
+			CREATE INDEX IF NOT EXISTS idx_webhooks_namespace ON webhooks(namespace, created_at);
+			CREATE INDEX IF NOT EXISTS idx_audit_log_namespace ON audit_log(namespace, created_at DESC);
+			DROP INDEX IF EXISTS idx_saved_searches_owner;
+			CREATE INDEX IF NOT EXISTS idx_saved_searches_owner ON saved_searches(namespace, owner, name);
+		`,
+		Down: `
+			DROP INDEX IF EXISTS idx_saved_searches_owner;
+			CREATE INDEX IF NOT EXISTS idx_saved_searches_owner ON saved_searches(owner, name);
+			DROP INDEX IF EXISTS idx_audit_log_namespace;
+			DROP INDEX IF EXISTS idx_webhooks_namespace;
+			ALTER TABLE audit_log DROP COLUMN IF EXISTS namespace;
+			ALTER TABLE saved_searches DROP COLUMN IF EXISTS namespace;
+			ALTER TABLE webhooks DROP COLUMN IF EXISTS namespace;
+		`,
 	},
 }
 
 // RunPostgresMigrations executes all pending PostgreSQL migrations
 func RunPostgresMigrations(db *sql.DB) error {
 	slog.Default().Info("creating controller_schema_version table")
-	// Create controller_schema_version table if it doesn't exist
-	_, err := db.Exec(`
-		CREATE TABLE IF NOT EXISTS controller_schema_version (
-			version INTEGER PRIMARY KEY,
-			applied_at TIMESTAMPTZ DEFAULT NOW()
-		)
-	`)
-	if err != nil {
-		return fmt.Errorf("failed to create controller_schema_version table: %w", err)
+	if err := ensureSchemaVersionTable(db); err != nil {
+		return err
 	}
 
 	slog.Default().Info("checking current schema version")
 	// Get current version
 	var currentVersion int
-	err = db.QueryRow("SELECT COALESCE(MAX(version), 0) FROM controller_schema_version").Scan(&currentVersion)
+	err := db.QueryRow("SELECT COALESCE(MAX(version), 0) FROM controller_schema_version").Scan(&currentVersion)
 	if err != nil {
 		return fmt.Errorf("failed to get current schema version: %w", err)
 	}
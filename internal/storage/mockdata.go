@@ -0,0 +1,357 @@
+package storage
+
+import (
+	"fmt"
+	"log/slog"
+	"math/rand"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MockDataOptions controls what GenerateMockDataWith creates
+type MockDataOptions struct {
+	Count int       // Number of requests to create (default: 600)
+	Days  int       // Requests are spread over this many days before End (default: 180)
+	Seed  int64     // The same seed, count, days and end always produce the same data; 0 picks one from the clock
+	Force bool      // Generate even when the database already contains requests
+	End   time.Time // Newest possible creation time (default: now)
+}
+
+// mockJobShares is the share of extra scrape jobs per status, on top of the completed job
+// every URL request gets, relative to the number of requests
+var mockJobShares = []struct {
+	status string
+	share  float64
+}{
+	{"failed", 0.08},
+	{"queued", 0.02},
+	{"processing", 0.01},
+}
+
+// GenerateMockData generates 6 months of realistic historical data for testing
+func (s *Storage) GenerateMockData() error {
+	return s.GenerateMockDataWith(MockDataOptions{})
+}
+
+// GenerateMockDataWith generates opts.Count realistic requests spread over opts.Days, with
+// analyzer metadata and the scrape jobs that would have produced them. Unless opts.Force is
+// set nothing is generated when the database already contains requests. Requests whose ID
+// already exists, as on a forced rerun with the same seed, are skipped.
+func (s *Storage) GenerateMockDataWith(opts MockDataOptions) error {
+	defer s.timeQuery("GenerateMockData")()
+	if opts.Count <= 0 {
+		opts.Count = 600
+	}
+	if opts.Days <= 0 {
+		opts.Days = 180
+	}
+	if opts.End.IsZero() {
+		opts.End = time.Now()
+	}
+	if opts.Seed == 0 {
+		opts.Seed = time.Now().UnixNano()
+	}
+	slog.Default().Info("generating mock historical data", "count", opts.Count, "days", opts.Days, "seed", opts.Seed)
+
+	// Check if we already have data
+	var count int
+	err := s.db.QueryRow("SELECT COUNT(*) FROM requests").Scan(&count)
+	if err != nil {
+		return fmt.Errorf("failed to count existing requests: %w", err)
+	}
+
+	if count > 0 && !opts.Force {
+		slog.Default().Info("database already contains requests, skipping mock data generation", "count", count)
+		return nil
+	}
+
+	// Sample data for generating realistic entries
+	sampleURLs := []string{
+		"https://example.com/article/technology-trends-2024",
+		"https://news.example.org/science/quantum-computing-breakthrough",
+		"https://blog.example.net/programming/golang-best-practices",
+		"https://research.example.edu/papers/artificial-intelligence",
+		"https://docs.example.io/guides/docker-deployment",
+		"https://medium.example.com/data-science/machine-learning-basics",
+		"https://github.example.dev/projects/open-source-tools",
+		"https://stackoverflow.example.com/questions/database-optimization",
+		"https://arxiv.example.org/papers/distributed-systems",
+		"https://dev.example.to/tutorials/kubernetes-intro",
+	}
+
+	sampleTags := [][]string{
+		{"technology", "trends", "future"},
+		{"science", "quantum", "research"},
+		{"programming", "golang", "best-practices"},
+		{"ai", "machine-learning", "research"},
+		{"devops", "docker", "deployment"},
+		{"data-science", "ml", "tutorial"},
+		{"open-source", "tools", "development"},
+		{"database", "optimization", "performance"},
+		{"distributed-systems", "architecture", "scalability"},
+		{"kubernetes", "containers", "cloud"},
+	}
+
+	// Tags the text analyzer adds on top of the computed ones, per sample
+	sampleAITags := [][]string{
+		{"innovation", "forecast", "Technology"},
+		{"physics", "qubits", "computing"},
+		{"go", "code-quality", "software-engineering"},
+		{"neural-networks", "deep-learning", "AI"},
+		{"containers", "ci-cd", "infrastructure"},
+		{"statistics", "python", "data-analysis"},
+		{"github", "community", "open-source"},
+		{"sql", "indexing", "postgres"},
+		{"consensus", "microservices", "reliability"},
+		{"orchestration", "helm", "devops"},
+	}
+
+	sampleTitles := []string{
+		"Technology Trends to Watch in 2024",
+		"Breakthrough in Quantum Computing Research",
+		"Go Programming Best Practices",
+		"Advances in Artificial Intelligence",
+		"Docker Deployment Strategies",
+		"Machine Learning Fundamentals",
+		"Top Open Source Development Tools",
+		"Database Optimization Techniques",
+		"Distributed Systems Architecture",
+		"Getting Started with Kubernetes",
+	}
+
+	sampleAuthors := []string{
+		"Dr. Jane Smith",
+		"Prof. John Doe",
+		"Alice Johnson",
+		"Bob Wilson",
+		"Carol Martinez",
+		"David Chen",
+		"Emma Brown",
+		"Frank Taylor",
+		"Grace Lee",
+		"Henry Anderson",
+	}
+
+	sampleErrors := []string{
+		"scraper returned status 404",
+		"scraper request timed out after 60s",
+		"robots.txt disallows the URL",
+		"scraper returned status 503",
+	}
+
+	// By default 600 mock requests spanning 6 months (180 days), ~3.3 documents per day.
+	// Every random choice, IDs included, comes from rng so a seed reproduces the data.
+	rng := rand.New(rand.NewSource(opts.Seed))
+	newID := func() string {
+		return uuid.Must(uuid.NewRandomFromReader(rng)).String()
+	}
+	daysToGenerate := float64(opts.Days)
+	created, skipped, jobs := 0, 0, 0
+
+	saveJob := func(job *ScrapeJob) error {
+		if exists, err := s.rowExists("scrape_jobs", job.ID); err != nil || exists {
+			return err
+		}
+		if err := s.SaveScrapeJob(job); err != nil {
+			return fmt.Errorf("failed to save mock scrape job: %w", err)
+		}
+		jobs++
+		return nil
+	}
+
+	for i := 0; i < opts.Count; i++ {
+		// Random timestamp within the requested period
+		daysAgo := rng.Float64() * daysToGenerate
+		hoursAgo := daysAgo * 24
+		createdAt := opts.End.Add(-time.Duration(hoursAgo) * time.Hour).UTC().Truncate(time.Second)
+
+		// Randomly choose between URL scrape (70%) and text ingestion (30%)
+		isURL := rng.Float64() < 0.7
+		idx := rng.Intn(len(sampleURLs))
+
+		var sourceType string
+		var sourceURL *string
+		var scraperUUID *string
+
+		if isURL {
+			sourceType = "url"
+			url := sampleURLs[idx]
+			sourceURL = &url
+			scraperUUIDStr := newID()
+			scraperUUID = &scraperUUIDStr
+		} else {
+			sourceType = "text"
+		}
+
+		// Generate metadata with varying quality scores and occasional tombstones
+		metadata := make(map[string]interface{})
+
+		// Link score (quality): higher quality more likely
+		qualityScore := 0.3 + rng.Float64()*0.7 // Range 0.3-1.0
+
+		metadata["link_score"] = map[string]interface{}{
+			"score": qualityScore,
+		}
+
+		// Analyzer output in the shape the worker stores it
+		aiTags := make([]interface{}, 0, len(sampleAITags[idx]))
+		for _, tag := range sampleAITags[idx] {
+			if rng.Float64() < 0.8 {
+				aiTags = append(aiTags, tag)
+			}
+		}
+		metadata["analyzer_metadata"] = map[string]interface{}{
+			"ai_tags":  aiTags,
+			"synopsis": fmt.Sprintf("%s: an overview of %s.", sampleTitles[idx], strings.Join(sampleTags[idx], ", ")),
+			"language": "en",
+		}
+		metadata["quality_score"] = map[string]interface{}{
+			"score": qualityScore,
+		}
+		metadata["textanalyzer_status"] = "completed"
+
+		// Add scraper metadata for URL sources
+		if isURL {
+			scraperMetadata := map[string]interface{}{
+				"title":        sampleTitles[idx],
+				"author":       sampleAuthors[rng.Intn(len(sampleAuthors))],
+				"publish_date": createdAt.Format(time.RFC3339),
+			}
+
+			// 30% chance of having images
+			if rng.Float64() < 0.3 {
+				scraperMetadata["images"] = []map[string]interface{}{
+					{
+						"url":      fmt.Sprintf("https://example.com/images/%s.jpg", newID()[:8]),
+						"alt_text": sampleTitles[idx],
+					},
+				}
+			}
+
+			metadata["scraper_metadata"] = scraperMetadata
+		}
+
+		// 15% chance of being tombstoned
+		if rng.Float64() < 0.15 {
+			tombstoneTime := createdAt.Add(time.Duration(rng.Intn(72)) * time.Hour) // Tombstoned 0-3 days after creation
+			metadata["tombstone_datetime"] = tombstoneTime.Format(time.RFC3339)
+		}
+
+		// Generate slug for URL-based requests
+		var slug *string
+		if isURL {
+			// Use title as slug base
+			slugBase := sampleTitles[idx]
+			// Simple slug generation (lowercase, replace spaces with hyphens, remove special chars)
+			generatedSlug := strings.ToLower(slugBase)
+			generatedSlug = strings.ReplaceAll(generatedSlug, " ", "-")
+			// Add random suffix to ensure uniqueness
+			generatedSlug = fmt.Sprintf("%s-%d", generatedSlug, rng.Intn(10000))
+			slug = &generatedSlug
+		}
+
+		// SEO enabled by default (90% of documents)
+		seoEnabled := rng.Float64() < 0.9
+
+		// Computed tags merged with the AI tags, ignoring case, as the worker does
+		tags := append([]string{}, sampleTags[idx]...)
+		seen := make(map[string]bool)
+		for _, tag := range tags {
+			seen[strings.ToLower(tag)] = true
+		}
+		for _, tag := range aiTags {
+			if name := tag.(string); !seen[strings.ToLower(name)] {
+				seen[strings.ToLower(name)] = true
+				tags = append(tags, name)
+			}
+		}
+
+		// Create request
+		req := &Request{
+			ID:               newID(),
+			CreatedAt:        createdAt,
+			SourceType:       sourceType,
+			SourceURL:        sourceURL,
+			ScraperUUID:      scraperUUID,
+			TextAnalyzerUUID: newID(),
+			Tags:             tags,
+			Metadata:         metadata,
+			Slug:             slug,
+			SEOEnabled:       seoEnabled,
+			Language:         "en",
+		}
+
+		exists, err := s.rowExists("requests", req.ID)
+		if err != nil {
+			return err
+		}
+		if exists {
+			skipped++
+		} else {
+			if err := s.SaveRequest(req); err != nil {
+				return fmt.Errorf("failed to save mock request: %w", err)
+			}
+			created++
+		}
+
+		// The job that scraped a URL request, completed a few seconds to minutes later
+		if isURL {
+			completedAt := createdAt
+			resultID := req.ID
+			err := saveJob(&ScrapeJob{
+				ID:              newID(),
+				URL:             *sourceURL,
+				Status:          "completed",
+				CreatedAt:       createdAt.Add(-time.Duration(5+rng.Intn(300)) * time.Second),
+				UpdatedAt:       completedAt,
+				CompletedAt:     &completedAt,
+				ResultRequestID: &resultID,
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	// Jobs that never produced a request. Failed ones are spread over the period; queued and
+	// processing ones are recent, as they would be on a live system.
+	for _, js := range mockJobShares {
+		for i := 0; i < int(float64(opts.Count)*js.share); i++ {
+			createdAt := opts.End.Add(-time.Duration(rng.Float64()*daysToGenerate*24) * time.Hour).UTC().Truncate(time.Second)
+			if js.status != "failed" {
+				createdAt = opts.End.Add(-time.Duration(rng.Intn(3600)) * time.Second).UTC().Truncate(time.Second)
+			}
+			job := &ScrapeJob{
+				ID:        newID(),
+				URL:       sampleURLs[rng.Intn(len(sampleURLs))],
+				Status:    js.status,
+				CreatedAt: createdAt,
+				UpdatedAt: createdAt,
+			}
+			if js.status == "failed" {
+				completedAt := createdAt.Add(time.Duration(1+rng.Intn(120)) * time.Second)
+				job.UpdatedAt = completedAt
+				job.CompletedAt = &completedAt
+				job.Retries = rng.Intn(4)
+				job.ErrorMessage = sampleErrors[rng.Intn(len(sampleErrors))]
+			}
+			if err := saveJob(job); err != nil {
+				return err
+			}
+		}
+	}
+
+	slog.Default().Info("generated mock requests", "count", created, "skipped_existing", skipped, "scrape_jobs", jobs, "days", opts.Days, "seed", opts.Seed)
+	return nil
+}
+
+// rowExists reports whether table has a row with id
+func (s *Storage) rowExists(table, id string) (bool, error) {
+	var exists bool
+	if err := s.db.QueryRow("SELECT EXISTS (SELECT 1 FROM "+table+" WHERE id = $1)", id).Scan(&exists); err != nil {
+		return false, fmt.Errorf("failed to check for existing %s row: %w", table, err)
+	}
+	return exists, nil
+}
@@ -0,0 +1,96 @@
+package storage
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+// mockSnapshot lists the ID and dates of every request, and the ID and status of every
+// scrape job, in ID order
+func mockSnapshot(t *testing.T, store *Storage) []string {
+	t.Helper()
+	rows, err := store.db.Query(`
+		SELECT id || ' ' || to_char(created_at AT TIME ZONE 'UTC', 'YYYY-MM-DD HH24:MI:SS') || ' ' || to_char(effective_date AT TIME ZONE 'UTC', 'YYYY-MM-DD HH24:MI:SS') FROM requests
+		UNION ALL
+		SELECT id || ' ' || status FROM scrape_jobs
+		ORDER BY 1
+	`)
+	if err != nil {
+		t.Fatalf("Failed to query mock data: %v", err)
+	}
+	defer rows.Close()
+	var snapshot []string
+	for rows.Next() {
+		var line string
+		if err := rows.Scan(&line); err != nil {
+			t.Fatalf("Failed to scan mock data: %v", err)
+		}
+		snapshot = append(snapshot, line)
+	}
+	return snapshot
+}
+
+func TestGenerateMockDataIsDeterministic(t *testing.T) {
+	t.Parallel()
+	first, cleanupFirst := setupTestStorage(t)
+	defer cleanupFirst()
+	second, cleanupSecond := setupTestStorage(t)
+	defer cleanupSecond()
+
+	opts := MockDataOptions{Count: 100, Days: 30, Seed: 42, End: time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)}
+	for _, store := range []*Storage{first, second} {
+		if err := store.GenerateMockDataWith(opts); err != nil {
+			t.Fatalf("GenerateMockDataWith failed: %v", err)
+		}
+	}
+	snapshot := mockSnapshot(t, first)
+	if !reflect.DeepEqual(snapshot, mockSnapshot(t, second)) {
+		t.Fatalf("Expected the same seed to generate identical data")
+	}
+
+	var requests, jobs int
+	first.db.QueryRow("SELECT COUNT(*) FROM requests").Scan(&requests)
+	first.db.QueryRow("SELECT COUNT(DISTINCT status) FROM scrape_jobs").Scan(&jobs)
+	if requests != opts.Count {
+		t.Errorf("Expected %d requests, got %d", opts.Count, requests)
+	}
+	if jobs != 4 {
+		t.Errorf("Expected scrape jobs in all 4 statuses, got %d", jobs)
+	}
+
+	list, err := first.ListRequests(1, 0)
+	if err != nil || len(list) != 1 {
+		t.Fatalf("Failed to list a mock request: %v", err)
+	}
+	analyzer, _ := list[0].Metadata["analyzer_metadata"].(map[string]interface{})
+	if _, ok := analyzer["synopsis"].(string); !ok {
+		t.Errorf("Expected analyzer_metadata.synopsis, got %v", list[0].Metadata["analyzer_metadata"])
+	}
+	if _, ok := analyzer["ai_tags"].([]interface{}); !ok {
+		t.Errorf("Expected analyzer_metadata.ai_tags, got %v", list[0].Metadata["analyzer_metadata"])
+	}
+	if _, ok := list[0].Metadata["quality_score"].(map[string]interface{}); !ok {
+		t.Errorf("Expected quality_score, got %v", list[0].Metadata["quality_score"])
+	}
+
+	// Without force a populated database is left alone; with force the same seed adds nothing
+	// new, while another seed adds a second set
+	if err := first.GenerateMockDataWith(MockDataOptions{Count: 5, Seed: 7}); err != nil {
+		t.Fatalf("GenerateMockDataWith failed: %v", err)
+	}
+	opts.Force = true
+	if err := first.GenerateMockDataWith(opts); err != nil {
+		t.Fatalf("Forced rerun failed: %v", err)
+	}
+	if got := mockSnapshot(t, first); !reflect.DeepEqual(got, snapshot) {
+		t.Errorf("Expected reruns with the same seed to leave the data unchanged, got %d rows instead of %d", len(got), len(snapshot))
+	}
+	if err := first.GenerateMockDataWith(MockDataOptions{Count: 5, Seed: 7, Force: true}); err != nil {
+		t.Fatalf("Forced run with another seed failed: %v", err)
+	}
+	first.db.QueryRow("SELECT COUNT(*) FROM requests").Scan(&requests)
+	if requests != opts.Count+5 {
+		t.Errorf("Expected %d requests after a forced run, got %d", opts.Count+5, requests)
+	}
+}
@@ -0,0 +1,65 @@
+package storage
+
+import "github.com/lib/pq"
+
+// DefaultNamespace holds every row created without a namespace, including all rows that
+// existed before namespaces were introduced
+const DefaultNamespace = "default"
+
+// MaxNamespaceLength is the longest namespace name accepted
+const MaxNamespaceLength = 64
+
+// ValidNamespace reports whether ns is a usable namespace name: 1 to MaxNamespaceLength
+// lowercase letters, digits, '-' and '_'
+func ValidNamespace(ns string) bool {
+	if ns == "" || len(ns) > MaxNamespaceLength {
+		return false
+	}
+	for _, c := range ns {
+		if !(c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || c == '-' || c == '_') {
+			return false
+		}
+	}
+	return true
+}
+
+// WithNamespace returns a Storage sharing s's connection whose request and scrape job
+// queries only see rows in namespace ns, and which saves new rows into ns. An empty ns
+// returns an unscoped Storage, as used by the worker and maintenance tasks.
+func (s *Storage) WithNamespace(ns string) *Storage {
+	scoped := *s
+	scoped.namespace = ns
+	return &scoped
+}
+
+// Namespace returns the namespace s is scoped to, or "" if it is unscoped
+func (s *Storage) Namespace() string {
+	return s.namespace
+}
+
+// inNamespace returns the predicate that limits a query to s's namespace, qualified with
+// the table alias when there is one. It is TRUE when s is unscoped, so it can be appended
+// to any WHERE clause next to notDeletedPredicate.
+func (s *Storage) inNamespace(alias string) string {
+	if s.namespace == "" {
+		return "TRUE"
+	}
+	column := "namespace"
+	if alias != "" {
+		column = alias + ".namespace"
+	}
+	return column + " = " + pq.QuoteLiteral(s.namespace)
+}
+
+// namespaceFor returns the namespace a new row is saved into: its own when set, else s's,
+// else the default
+func (s *Storage) namespaceFor(ns string) string {
+	switch {
+	case ns != "":
+		return ns
+	case s.namespace != "":
+		return s.namespace
+	default:
+		return DefaultNamespace
+	}
+}
@@ -0,0 +1,183 @@
+package storage
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestValidNamespace(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		namespace string
+		want      bool
+	}{
+		{"default", true},
+		{"team-a", true},
+		{"team_b2", true},
+		{"", false},
+		{"Team", false},
+		{"team a", false},
+		{"team.a", false},
+		{strings.Repeat("a", MaxNamespaceLength), true},
+		{strings.Repeat("a", MaxNamespaceLength+1), false},
+	}
+	for _, tt := range tests {
+		if got := ValidNamespace(tt.namespace); got != tt.want {
+			t.Errorf("ValidNamespace(%q) = %v, want %v", tt.namespace, got, tt.want)
+		}
+	}
+}
+
+func TestNamespaceIsolation(t *testing.T) {
+	t.Parallel()
+	store, cleanup := setupTestStorage(t)
+	defer cleanup()
+
+	now := time.Now().UTC()
+	for _, ns := range []string{"team-a", "team-b"} {
+		slug := "doc-" + ns
+		req := &Request{
+			ID:         "req-" + ns,
+			CreatedAt:  now,
+			SourceType: "text",
+			Tags:       []string{"shared", ns},
+			SEOEnabled: true,
+			Slug:       &slug,
+			Metadata:   map[string]interface{}{},
+		}
+		// The record's namespace wins over the storage's
+		if err := store.WithNamespace(ns).SaveRequest(req); err != nil {
+			t.Fatalf("Failed to save request: %v", err)
+		}
+		job := &ScrapeJob{ID: "job-" + ns, URL: "https://example.com/" + ns, Status: "queued", CreatedAt: now, UpdatedAt: now, Namespace: ns}
+		if err := store.SaveScrapeJob(job); err != nil {
+			t.Fatalf("Failed to save scrape job: %v", err)
+		}
+	}
+
+	teamA := store.WithNamespace("team-a")
+
+	if req, err := teamA.GetRequest("req-team-b"); err == nil && req != nil {
+		t.Error("Expected another namespace's request to be hidden")
+	}
+	req, err := teamA.GetRequest("req-team-a")
+	if err != nil || req.Namespace != "team-a" {
+		t.Fatalf("Expected team-a's request, got %+v (%v)", req, err)
+	}
+
+	ids, err := teamA.SearchByTags([]string{"shared"}, false)
+	if err != nil {
+		t.Fatalf("SearchByTags failed: %v", err)
+	}
+	if len(ids) != 1 || ids[0] != "req-team-a" {
+		t.Errorf("Expected the tag search to find only team-a's request, got %v", ids)
+	}
+
+	filtered, err := teamA.FilterRequests(FilterOptions{Tags: []string{"shared"}, Limit: 10})
+	if err != nil {
+		t.Fatalf("FilterRequests failed: %v", err)
+	}
+	if len(filtered) != 1 || filtered[0].ID != "req-team-a" {
+		t.Errorf("Expected the filter to find only team-a's request, got %d", len(filtered))
+	}
+
+	if bySlug, err := teamA.GetRequestBySlug("doc-team-b"); err == nil && bySlug != nil {
+		t.Error("Expected another namespace's slug to be hidden")
+	}
+
+	timeline, err := teamA.GetTagTimeline(now.Add(-time.Hour), now.Add(time.Hour), time.Hour, 10)
+	if err != nil {
+		t.Fatalf("GetTagTimeline failed: %v", err)
+	}
+	if timeline.Stats.TotalDocuments != 1 {
+		t.Errorf("Expected the timeline to count 1 document, got %d", timeline.Stats.TotalDocuments)
+	}
+
+	jobs, err := teamA.ListScrapeJobs(10, 0)
+	if err != nil {
+		t.Fatalf("ListScrapeJobs failed: %v", err)
+	}
+	if len(jobs) != 1 || jobs[0].ID != "job-team-a" {
+		t.Errorf("Expected only team-a's scrape job, got %d", len(jobs))
+	}
+
+	// Unscoped storage, as the worker uses, sees every namespace
+	all, err := store.ListScrapeJobs(10, 0)
+	if err != nil {
+		t.Fatalf("ListScrapeJobs failed: %v", err)
+	}
+	if len(all) != 2 {
+		t.Errorf("Expected unscoped storage to list both jobs, got %d", len(all))
+	}
+}
+
+func TestNamespaceIsolationOfWebhooksSearchesAndAudit(t *testing.T) {
+	t.Parallel()
+	store, cleanup := setupTestStorage(t)
+	defer cleanup()
+
+	now := time.Now().UTC()
+	for _, ns := range []string{"team-a", "team-b"} {
+		scoped := store.WithNamespace(ns)
+		if err := scoped.SaveRequest(&Request{ID: "req-" + ns, CreatedAt: now, SourceType: "text", Metadata: map[string]interface{}{}}); err != nil {
+			t.Fatalf("Failed to save request: %v", err)
+		}
+		hook := &Webhook{ID: "hook-" + ns, URL: "https://hooks.example.com/" + ns, Secret: "0123456789abcdef", EventTypes: []string{"request.deleted"}, Enabled: true}
+		if err := scoped.CreateWebhook(hook); err != nil {
+			t.Fatalf("CreateWebhook failed: %v", err)
+		}
+		if err := scoped.CreateSavedSearch(&SavedSearch{ID: "search-" + ns, Name: ns, Owner: "anonymous", Filter: []byte(`{}`)}); err != nil {
+			t.Fatalf("CreateSavedSearch failed: %v", err)
+		}
+		// Entries about a request land in its namespace, even when recorded unscoped
+		if err := store.RecordAudit(&AuditEntry{Actor: AuditActorWorker, Action: AuditActionTombstone, EntityType: AuditEntityRequest, EntityID: "req-" + ns}); err != nil {
+			t.Fatalf("RecordAudit failed: %v", err)
+		}
+		// Entries about shared entities land in the caller's namespace
+		if err := scoped.RecordAudit(&AuditEntry{Actor: "anonymous", Action: AuditActionDelete, EntityType: AuditEntityImage, EntityID: "img-" + ns}); err != nil {
+			t.Fatalf("RecordAudit failed: %v", err)
+		}
+	}
+
+	teamA := store.WithNamespace("team-a")
+
+	if hook, err := teamA.GetWebhook("hook-team-b"); err != nil || hook != nil {
+		t.Errorf("Expected another namespace's webhook to be hidden, got %+v (%v)", hook, err)
+	}
+	if hooks, _ := teamA.ListWebhooks(); len(hooks) != 1 || hooks[0].Namespace != "team-a" {
+		t.Errorf("Expected only team-a's webhook, got %d", len(hooks))
+	}
+	if err := teamA.DeleteWebhook("hook-team-b"); err == nil {
+		t.Error("Expected deleting another namespace's webhook to fail")
+	}
+	if active, _ := store.ListActiveWebhooks("team-b", "request.deleted"); len(active) != 1 || active[0].ID != "hook-team-b" {
+		t.Errorf("Expected only team-b's webhook to receive team-b events, got %d", len(active))
+	}
+
+	if search, err := teamA.GetSavedSearch("search-team-b"); err != nil || search != nil {
+		t.Errorf("Expected another namespace's saved search to be hidden, got %+v (%v)", search, err)
+	}
+	if searches, _ := teamA.ListSavedSearches(""); len(searches) != 1 || searches[0].ID != "search-team-a" {
+		t.Errorf("Expected only team-a's saved search, got %d", len(searches))
+	}
+	if err := teamA.UpdateSavedSearch(&SavedSearch{ID: "search-team-b", Name: "taken", Filter: []byte(`{}`)}); err == nil {
+		t.Error("Expected updating another namespace's saved search to fail")
+	}
+	if err := teamA.DeleteSavedSearch("search-team-b"); err == nil {
+		t.Error("Expected deleting another namespace's saved search to fail")
+	}
+
+	entries, err := teamA.ListAuditEntries(AuditFilter{})
+	if err != nil {
+		t.Fatalf("ListAuditEntries failed: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("Expected team-a's 2 audit entries, got %d", len(entries))
+	}
+	for _, entry := range entries {
+		if entry.Namespace != "team-a" || (entry.EntityID != "req-team-a" && entry.EntityID != "img-team-a") {
+			t.Errorf("Unexpected audit entry %+v", entry)
+		}
+	}
+}
@@ -0,0 +1,9 @@
+package storage
+
+// Values of created_by for records not created directly by an API client
+const (
+	CreatedByUnknown   = "unknown"         // Created before provenance was tracked, or by an unidentified path
+	CreatedByCrawler   = "worker:crawl"    // Queued by the worker while following links, with no known originator
+	CreatedByScheduler = "scheduler"       // Created by a scheduler task
+	CreatedByRescrape  = "worker:rescrape" // Queued by a freshness pass to refresh a stale request
+)
@@ -0,0 +1,55 @@
+package storage
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCreatedBy(t *testing.T) {
+	t.Parallel()
+	store, cleanup := setupTestStorage(t)
+	defer cleanup()
+
+	now := time.Now().UTC()
+	for _, req := range []*Request{
+		{ID: "from-client", CreatedBy: "reader-app"},
+		{ID: "from-crawler", CreatedBy: CreatedByCrawler},
+		{ID: "unattributed"},
+	} {
+		req.CreatedAt = now
+		req.SourceType = "text"
+		req.Metadata = map[string]interface{}{}
+		if err := store.SaveRequest(req); err != nil {
+			t.Fatalf("Failed to save request %s: %v", req.ID, err)
+		}
+	}
+
+	req, err := store.GetRequest("unattributed")
+	if err != nil {
+		t.Fatalf("Failed to get request: %v", err)
+	}
+	if req.CreatedBy != CreatedByUnknown {
+		t.Errorf("expected created_by %q, got %q", CreatedByUnknown, req.CreatedBy)
+	}
+
+	creator := CreatedByCrawler
+	results, err := store.FilterRequests(FilterOptions{CreatedBy: &creator, Limit: 10})
+	if err != nil {
+		t.Fatalf("FilterRequests failed: %v", err)
+	}
+	if len(results) != 1 || results[0].ID != "from-crawler" {
+		t.Errorf("expected only from-crawler, got %d results", len(results))
+	}
+
+	job := &ScrapeJob{ID: "job-1", URL: "https://example.com", Status: "queued", CreatedAt: now, UpdatedAt: now}
+	if err := store.SaveScrapeJob(job); err != nil {
+		t.Fatalf("Failed to save scrape job: %v", err)
+	}
+	saved, err := store.GetScrapeJob("job-1")
+	if err != nil {
+		t.Fatalf("Failed to get scrape job: %v", err)
+	}
+	if saved.CreatedBy != CreatedByUnknown {
+		t.Errorf("expected job created_by %q, got %q", CreatedByUnknown, saved.CreatedBy)
+	}
+}
@@ -0,0 +1,51 @@
+package storage
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// DefaultSlowQueryThreshold is how long a storage call may take before it is logged as slow
+const DefaultSlowQueryThreshold = 500 * time.Millisecond
+
+// storageOperationDuration records how long each exported Storage method took, by method
+var storageOperationDuration = promauto.NewHistogramVec(
+	prometheus.HistogramOpts{
+		Name:    "controller_storage_operation_duration_seconds",
+		Help:    "Time spent in storage methods, by method",
+		Buckets: []float64{0.001, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30},
+	},
+	[]string{"method"},
+)
+
+// SetSlowQueryThreshold sets how long a storage call may take before a warning is logged.
+// Zero or less disables the warning; durations are still recorded in the histogram.
+func (s *Storage) SetSlowQueryThreshold(d time.Duration) {
+	s.slowQueryThreshold = d
+}
+
+// timeQuery starts timing a storage call. The returned func records the duration and logs
+// a warning with the given key/value params when the call was slow. Use it as the first
+// line of a method:
+//
+//	defer s.timeQuery("GetRequest", "id", id)()
+func (s *Storage) timeQuery(method string, params ...any) func() {
+	start := time.Now()
+	return func() {
+		elapsed := time.Since(start)
+		storageOperationDuration.WithLabelValues(method).Observe(elapsed.Seconds())
+
+		if s.slowQueryThreshold <= 0 || elapsed < s.slowQueryThreshold {
+			return
+		}
+		attrs := append([]any{
+			"method", method,
+			"duration_ms", elapsed.Milliseconds(),
+			"threshold_ms", s.slowQueryThreshold.Milliseconds(),
+		}, params...)
+		slog.Default().Warn("slow storage query", attrs...)
+	}
+}
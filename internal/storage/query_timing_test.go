@@ -0,0 +1,98 @@
+package storage
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// captureLogs routes the default logger into a buffer for the duration of the test
+func captureLogs(t *testing.T) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	prev := slog.Default()
+	slog.SetDefault(slog.New(slog.NewJSONHandler(&buf, nil)))
+	t.Cleanup(func() { slog.SetDefault(prev) })
+	return &buf
+}
+
+// histogramSampleCount returns how many durations were observed for method
+func histogramSampleCount(t *testing.T, method string) uint64 {
+	t.Helper()
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(storageOperationDuration)
+	families, err := reg.Gather()
+	if err != nil {
+		t.Fatalf("Gather failed: %v", err)
+	}
+	for _, mf := range families {
+		for _, m := range mf.GetMetric() {
+			for _, l := range m.GetLabel() {
+				if l.GetName() == "method" && l.GetValue() == method {
+					return m.GetHistogram().GetSampleCount()
+				}
+			}
+		}
+	}
+	return 0
+}
+
+func TestTimeQuerySlowWarning(t *testing.T) {
+	logs := captureLogs(t)
+	s := &Storage{slowQueryThreshold: 10 * time.Millisecond}
+
+	slowQuery := func(id string) {
+		defer s.timeQuery("SlowFakeQuery", "id", id)()
+		time.Sleep(25 * time.Millisecond)
+	}
+	slowQuery("req-1")
+
+	var entry map[string]interface{}
+	if err := json.Unmarshal(logs.Bytes(), &entry); err != nil {
+		t.Fatalf("expected one JSON log line, got %q: %v", logs.String(), err)
+	}
+	if entry["level"] != "WARN" || entry["msg"] != "slow storage query" {
+		t.Errorf("unexpected log entry: %v", entry)
+	}
+	if entry["method"] != "SlowFakeQuery" || entry["id"] != "req-1" {
+		t.Errorf("expected method and params in log entry, got %v", entry)
+	}
+	if ms, _ := entry["duration_ms"].(float64); ms < 25 {
+		t.Errorf("expected duration_ms >= 25, got %v", entry["duration_ms"])
+	}
+	if entry["threshold_ms"] != float64(10) {
+		t.Errorf("expected threshold_ms 10, got %v", entry["threshold_ms"])
+	}
+
+	if got := histogramSampleCount(t, "SlowFakeQuery"); got != 1 {
+		t.Errorf("expected 1 histogram sample, got %d", got)
+	}
+}
+
+func TestTimeQueryFastOrDisabled(t *testing.T) {
+	logs := captureLogs(t)
+
+	fast := &Storage{slowQueryThreshold: time.Second}
+	fast.timeQuery("FastFakeQuery")()
+
+	disabled := &Storage{}
+	func() {
+		defer disabled.timeQuery("UnloggedFakeQuery")()
+		time.Sleep(5 * time.Millisecond)
+	}()
+
+	if logs.Len() != 0 {
+		t.Errorf("expected no warnings, got %q", logs.String())
+	}
+	// Durations are recorded even when the warning is off
+	if got := histogramSampleCount(t, "FastFakeQuery"); got != 1 {
+		t.Errorf("expected 1 sample for FastFakeQuery, got %d", got)
+	}
+	if got := histogramSampleCount(t, "UnloggedFakeQuery"); got != 1 {
+		t.Errorf("expected 1 sample for UnloggedFakeQuery, got %d", got)
+	}
+}
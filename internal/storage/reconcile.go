@@ -0,0 +1,117 @@
+package storage
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Metadata flags set by reconciliation on requests whose upstream data is gone
+const (
+	MetaScrapeMissing   = "scrape_missing"   // The scraper no longer has the request's scrape
+	MetaAnalysisMissing = "analysis_missing" // The text analyzer no longer knows the request's analysis job
+)
+
+// ReconcileRequest is the part of a live request reconciliation checks against upstream services
+type ReconcileRequest struct {
+	ID               string
+	ScraperUUID      string
+	TextAnalyzerUUID string
+	AnalysisStatus   string // textanalyzer_status metadata; "" on requests from before it was tracked
+	AnalysisSkipped  bool
+	CreatedAt        time.Time
+}
+
+// ListRequestsForReconcile returns up to limit live requests with IDs after afterID, in ID order,
+// so callers can walk every request in batches
+func (s *Storage) ListRequestsForReconcile(afterID string, limit int) ([]ReconcileRequest, error) {
+	defer s.timeQuery("ListRequestsForReconcile", "after", afterID, "limit", limit)()
+	rows, err := s.db.Query(`
+		SELECT id, COALESCE(scraper_uuid, ''), COALESCE(textanalyzer_uuid, ''),
+		       COALESCE(metadata_json->>'`+MetaAnalysisStatus+`', ''),
+		       COALESCE(metadata_json->>'`+MetaAnalysisSkipped+`' = 'true', false),
+		       created_at
+		FROM requests
+		WHERE id > $1 AND `+notDeletedPredicate+` AND `+s.inNamespace("")+`
+		ORDER BY id
+		LIMIT $2
+	`, afterID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list requests: %w", err)
+	}
+	defer rows.Close()
+
+	var requests []ReconcileRequest
+	for rows.Next() {
+		var req ReconcileRequest
+		if err := rows.Scan(&req.ID, &req.ScraperUUID, &req.TextAnalyzerUUID, &req.AnalysisStatus, &req.AnalysisSkipped, &req.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan request: %w", err)
+		}
+		requests = append(requests, req)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list requests: %w", err)
+	}
+	return requests, nil
+}
+
+// JobResultOrphan is a completed scrape job whose result is unusable
+type JobResultOrphan struct {
+	JobID string
+	// RequestID is the soft-deleted request the job still points at. It is nil when the job
+	// has no result at all, which is what a hard delete leaves behind: the foreign key clears
+	// the reference.
+	RequestID *string
+}
+
+// ListJobResultOrphans returns up to limit completed jobs with IDs after afterID, in ID order,
+// that point at a soft-deleted request or lost their result. Jobs that were skipped or found
+// to duplicate another request never had a result of their own and are not listed.
+func (s *Storage) ListJobResultOrphans(afterID string, limit int) ([]JobResultOrphan, error) {
+	defer s.timeQuery("ListJobResultOrphans", "after", afterID, "limit", limit)()
+	rows, err := s.db.Query(`
+		SELECT j.id, j.result_request_id
+		FROM scrape_jobs j
+		LEFT JOIN requests r ON r.id = j.result_request_id
+		WHERE j.status = 'completed' AND j.id > $1 AND `+s.inNamespace("j")+`
+		  AND (r.deleted_at IS NOT NULL
+		       OR (j.result_request_id IS NULL AND COALESCE(j.skip_reason, '') = '' AND j.duplicate_of IS NULL))
+		ORDER BY j.id
+		LIMIT $2
+	`, afterID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list job result orphans: %w", err)
+	}
+	defer rows.Close()
+
+	var orphans []JobResultOrphan
+	for rows.Next() {
+		var orphan JobResultOrphan
+		if err := rows.Scan(&orphan.JobID, &orphan.RequestID); err != nil {
+			return nil, fmt.Errorf("failed to scan job result orphan: %w", err)
+		}
+		orphans = append(orphans, orphan)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list job result orphans: %w", err)
+	}
+	return orphans, nil
+}
+
+// ClearScrapeJobResults removes the result reference of the given jobs and returns how many
+// were changed
+func (s *Storage) ClearScrapeJobResults(jobIDs []string) (int64, error) {
+	if len(jobIDs) == 0 {
+		return 0, nil
+	}
+	defer s.timeQuery("ClearScrapeJobResults", "count", len(jobIDs))()
+	result, err := s.db.Exec(`
+		UPDATE scrape_jobs SET result_request_id = NULL, updated_at = NOW()
+		WHERE id = ANY($1) AND result_request_id IS NOT NULL AND `+s.inNamespace("")+`
+	`, pq.Array(jobIDs))
+	if err != nil {
+		return 0, fmt.Errorf("failed to clear scrape job results: %w", err)
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,80 @@
+package storage
+
+import (
+	"testing"
+	"time"
+)
+
+func TestReconcileQueries(t *testing.T) {
+	t.Parallel()
+	store, cleanup := setupTestStorage(t)
+	defer cleanup()
+
+	now := time.Now()
+	scrape := "scrape-1"
+	for _, req := range []*Request{
+		{ID: "recq-a", CreatedAt: now, SourceType: "url", ScraperUUID: &scrape, TextAnalyzerUUID: "analysis-1", Tags: []string{},
+			Metadata: map[string]interface{}{MetaAnalysisStatus: AnalysisStatusQueued}},
+		{ID: "recq-b", CreatedAt: now, SourceType: "url", Tags: []string{}, Metadata: map[string]interface{}{MetaAnalysisSkipped: true}},
+		{ID: "recq-c", CreatedAt: now, SourceType: "url", Tags: []string{}, Metadata: map[string]interface{}{}},
+	} {
+		if err := store.SaveRequest(req); err != nil {
+			t.Fatalf("Failed to save request: %v", err)
+		}
+	}
+	if _, err := store.SoftDeleteRequest("recq-c"); err != nil {
+		t.Fatalf("Failed to delete request: %v", err)
+	}
+
+	first, err := store.ListRequestsForReconcile("", 1)
+	if err != nil {
+		t.Fatalf("ListRequestsForReconcile failed: %v", err)
+	}
+	if len(first) != 1 || first[0].ID != "recq-a" || first[0].ScraperUUID != scrape || first[0].TextAnalyzerUUID != "analysis-1" || first[0].AnalysisStatus != AnalysisStatusQueued {
+		t.Fatalf("Unexpected first page %+v", first)
+	}
+	rest, err := store.ListRequestsForReconcile("recq-a", 10)
+	if err != nil {
+		t.Fatalf("ListRequestsForReconcile failed: %v", err)
+	}
+	if len(rest) != 1 || rest[0].ID != "recq-b" || !rest[0].AnalysisSkipped || rest[0].ScraperUUID != "" {
+		t.Fatalf("Expected only the live skipped request after recq-a, got %+v", rest)
+	}
+
+	live, deleted := "recq-a", "recq-c"
+	for _, job := range []*ScrapeJob{
+		{ID: "recq-job-live", Status: "completed", ResultRequestID: &live},
+		{ID: "recq-job-dangling", Status: "completed", ResultRequestID: &deleted},
+		{ID: "recq-job-lost", Status: "completed"},
+		{ID: "recq-job-duplicate", Status: "completed", DuplicateOf: &live},
+		{ID: "recq-job-queued", Status: "queued"},
+	} {
+		job.URL = "https://example.com/" + job.ID
+		job.CreatedAt = now
+		job.UpdatedAt = now
+		if err := store.SaveScrapeJob(job); err != nil {
+			t.Fatalf("Failed to save job: %v", err)
+		}
+	}
+
+	orphans, err := store.ListJobResultOrphans("", 10)
+	if err != nil {
+		t.Fatalf("ListJobResultOrphans failed: %v", err)
+	}
+	if len(orphans) != 2 || orphans[0].JobID != "recq-job-dangling" || orphans[0].RequestID == nil || *orphans[0].RequestID != deleted ||
+		orphans[1].JobID != "recq-job-lost" || orphans[1].RequestID != nil {
+		t.Fatalf("Expected the dangling and lost jobs, got %+v", orphans)
+	}
+
+	cleared, err := store.ClearScrapeJobResults([]string{"recq-job-dangling", "recq-job-lost"})
+	if err != nil || cleared != 1 {
+		t.Fatalf("Expected one job cleared, got %d, %v", cleared, err)
+	}
+	job, err := store.GetScrapeJob("recq-job-dangling")
+	if err != nil {
+		t.Fatalf("Failed to get job: %v", err)
+	}
+	if job.ResultRequestID != nil {
+		t.Errorf("Expected the result to be cleared, got %v", *job.ResultRequestID)
+	}
+}
@@ -0,0 +1,50 @@
+package storage
+
+import (
+	"fmt"
+
+	"github.com/lib/pq"
+)
+
+// RequestSummary is the slim view of a request shown next to the scrape job that created it
+type RequestSummary struct {
+	ID         string  `json:"request_id"`
+	Slug       *string `json:"slug,omitempty"`
+	Title      string  `json:"title"`
+	SEOEnabled bool    `json:"seo_enabled"`
+	Tombstoned bool    `json:"tombstoned"` // Tombstone date has passed
+}
+
+// GetRequestSummaries returns the summaries of the live requests among ids in one query, keyed
+// by ID. Deleted requests, and requests in other namespaces, are absent from the map.
+func (s *Storage) GetRequestSummaries(ids []string) (map[string]*RequestSummary, error) {
+	summaries := make(map[string]*RequestSummary, len(ids))
+	if len(ids) == 0 {
+		return summaries, nil
+	}
+	defer s.timeQuery("GetRequestSummaries", "count", len(ids))()
+
+	rows, err := s.db.Query(`
+		SELECT id, slug, seo_enabled,
+		       COALESCE(metadata_json->'scraper_metadata'->>'title', metadata_json->>'title', ''),
+		       COALESCE((metadata_json->>'tombstone_datetime')::timestamp <= NOW(), false)
+		FROM requests
+		WHERE id = ANY($1) AND `+notDeletedPredicate+` AND `+s.inNamespace("")+`
+	`, pq.Array(ids))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query request summaries: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var summary RequestSummary
+		if err := rows.Scan(&summary.ID, &summary.Slug, &summary.SEOEnabled, &summary.Title, &summary.Tombstoned); err != nil {
+			return nil, fmt.Errorf("failed to scan request summary: %w", err)
+		}
+		summaries[summary.ID] = &summary
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to query request summaries: %w", err)
+	}
+	return summaries, nil
+}
@@ -0,0 +1,48 @@
+package storage
+
+import (
+	"testing"
+	"time"
+)
+
+func TestGetRequestSummaries(t *testing.T) {
+	t.Parallel()
+	store, cleanup := setupTestStorage(t)
+	defer cleanup()
+
+	now := time.Now()
+	slug := "summary-live"
+	for _, req := range []*Request{
+		{ID: "summary-live", CreatedAt: now, SourceType: "url", Slug: &slug, SEOEnabled: true, Tags: []string{},
+			Metadata: map[string]interface{}{"scraper_metadata": map[string]interface{}{"title": "Live page"}}},
+		{ID: "summary-tombstoned", CreatedAt: now, SourceType: "url", Tags: []string{},
+			Metadata: map[string]interface{}{"tombstone_datetime": now.Add(-time.Hour).UTC().Format(time.RFC3339)}},
+		{ID: "summary-deleted", CreatedAt: now, SourceType: "url", Tags: []string{}, Metadata: map[string]interface{}{}},
+	} {
+		if err := store.SaveRequest(req); err != nil {
+			t.Fatalf("Failed to save request: %v", err)
+		}
+	}
+	if _, err := store.SoftDeleteRequest("summary-deleted"); err != nil {
+		t.Fatalf("Failed to delete request: %v", err)
+	}
+
+	summaries, err := store.GetRequestSummaries([]string{"summary-live", "summary-tombstoned", "summary-deleted", "summary-missing"})
+	if err != nil {
+		t.Fatalf("GetRequestSummaries failed: %v", err)
+	}
+	if len(summaries) != 2 {
+		t.Fatalf("Expected the live and tombstoned requests, got %v", summaries)
+	}
+	if s := summaries["summary-live"]; s.Title != "Live page" || s.Slug == nil || *s.Slug != slug || !s.SEOEnabled || s.Tombstoned {
+		t.Errorf("Unexpected live summary %+v", s)
+	}
+	if s := summaries["summary-tombstoned"]; !s.Tombstoned || s.Slug != nil {
+		t.Errorf("Unexpected tombstoned summary %+v", s)
+	}
+
+	empty, err := store.GetRequestSummaries(nil)
+	if err != nil || len(empty) != 0 {
+		t.Errorf("Expected no summaries for no IDs, got %v, %v", empty, err)
+	}
+}
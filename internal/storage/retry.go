@@ -0,0 +1,71 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Write transactions that fail on a transient conflict are retried this many times in total,
+// waiting writeRetryDelay after the first failure and doubling the wait after each further one
+const (
+	writeRetryAttempts = 4
+	writeRetryDelay    = 20 * time.Millisecond
+)
+
+// transientConflict reports whether err is a conflict between concurrent transactions that
+// succeeds when the transaction is simply run again: a serialization failure, a deadlock,
+// or a lock that could not be taken
+func transientConflict(err error) bool {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return false
+	}
+	switch pqErr.Code {
+	case "40001", "40P01", "55P03": // serialization_failure, deadlock_detected, lock_not_available
+		return true
+	}
+	return false
+}
+
+// inTx runs fn in a transaction and commits it. When the transaction fails on a transient
+// conflict it is rolled back and run again from the start, so fn must not have effects
+// outside tx. Other errors are returned as fn returned them.
+func (s *Storage) inTx(method string, fn func(tx *sql.Tx) error) error {
+	delay := writeRetryDelay
+	for attempt := 1; ; attempt++ {
+		err := s.runTx(fn)
+		if err == nil || !transientConflict(err) || attempt >= writeRetryAttempts {
+			return err
+		}
+		slog.Default().Warn("retrying storage transaction after a conflict",
+			"method", method,
+			"attempt", attempt,
+			"retry_in", delay.String(),
+			"error", err,
+		)
+		time.Sleep(delay)
+		delay *= 2
+	}
+}
+
+// runTx makes one attempt at the transaction for inTx
+func (s *Storage) runTx(fn func(tx *sql.Tx) error) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
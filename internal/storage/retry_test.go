@@ -0,0 +1,110 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+func TestTransientConflict(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"serialization failure", &pq.Error{Code: "40001"}, true},
+		{"deadlock", fmt.Errorf("failed to update tags: %w", &pq.Error{Code: "40P01"}), true},
+		{"lock not available", &pq.Error{Code: "55P03"}, true},
+		{"unique violation", &pq.Error{Code: "23505"}, false},
+		{"not a postgres error", errors.New("request not found"), false},
+	}
+	for _, tt := range tests {
+		if got := transientConflict(tt.err); got != tt.want {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.want, got)
+		}
+	}
+}
+
+func TestInTxRetriesConflicts(t *testing.T) {
+	t.Parallel()
+	store, cleanup := setupTestStorage(t)
+	defer cleanup()
+
+	calls := 0
+	err := store.inTx("test", func(tx *sql.Tx) error {
+		calls++
+		if calls < 3 {
+			return &pq.Error{Code: "40001"}
+		}
+		return nil
+	})
+	if err != nil || calls != 3 {
+		t.Fatalf("Expected success on the third attempt, got %v after %d calls", err, calls)
+	}
+
+	calls = 0
+	err = store.inTx("test", func(tx *sql.Tx) error {
+		calls++
+		return errors.New("boom")
+	})
+	if err == nil || calls != 1 {
+		t.Errorf("Expected other errors to be returned without retrying, got %v after %d calls", err, calls)
+	}
+}
+
+// Reads run on their own pooled connections, so a long write transaction holding a row lock
+// does not hold them up
+func TestReadsNotBlockedByWriteTransaction(t *testing.T) {
+	t.Parallel()
+	store, cleanup := setupTestStorage(t)
+	defer cleanup()
+
+	req := &Request{ID: "locked", CreatedAt: time.Now(), SourceType: "text", Metadata: map[string]interface{}{}}
+	if err := store.SaveRequest(req); err != nil {
+		t.Fatalf("Failed to save request: %v", err)
+	}
+
+	tx, err := store.db.Begin()
+	if err != nil {
+		t.Fatalf("Failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+	if _, err := tx.Exec(`UPDATE requests SET metadata_json = '{"title":"pending"}' WHERE id = $1`, req.ID); err != nil {
+		t.Fatalf("Failed to lock request: %v", err)
+	}
+
+	const readers = 8
+	var wg sync.WaitGroup
+	errs := make(chan error, readers)
+	for i := 0; i < readers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			got, err := store.GetRequest(req.ID)
+			if err == nil && got.Metadata["title"] != nil {
+				err = errors.New("read saw an uncommitted write")
+			}
+			errs <- err
+		}()
+	}
+
+	done := make(chan struct{})
+	go func() { wg.Wait(); close(done) }()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Reads waited for the open write transaction")
+	}
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Errorf("Read failed: %v", err)
+		}
+	}
+}
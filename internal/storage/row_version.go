@@ -0,0 +1,25 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// ErrRequestVersionMismatch is returned by the IfVersion updates when the request changed
+// after the caller read it
+var ErrRequestVersionMismatch = errors.New("request version mismatch")
+
+// versionConflict explains why a version-guarded update matched no row: the request is gone,
+// or it has moved on to the returned version
+func (s *Storage) versionConflict(tx *sql.Tx, id string) (int64, error) {
+	var current int64
+	err := tx.QueryRow(`SELECT row_version FROM requests WHERE id = $1 AND `+s.inNamespace(""), id).Scan(&current)
+	if err == sql.ErrNoRows {
+		return 0, fmt.Errorf("request not found")
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to read request version: %w", err)
+	}
+	return current, ErrRequestVersionMismatch
+}
@@ -0,0 +1,50 @@
+package storage
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestUpdateRequestTagsIfVersion(t *testing.T) {
+	t.Parallel()
+	store, cleanup := setupTestStorage(t)
+	defer cleanup()
+
+	req := &Request{ID: "rowv-1", CreatedAt: time.Now(), SourceType: "text", Tags: []string{"a"}, Metadata: map[string]interface{}{}}
+	if err := store.SaveRequest(req); err != nil {
+		t.Fatalf("Failed to save request: %v", err)
+	}
+	got, err := store.GetRequest(req.ID)
+	if err != nil {
+		t.Fatalf("GetRequest failed: %v", err)
+	}
+	read := got.RowVersion
+
+	next, err := store.UpdateRequestTagsIfVersion(req.ID, []string{"a", "b"}, read)
+	if err != nil || next != read+1 {
+		t.Fatalf("Expected version %d, got %d, %v", read+1, next, err)
+	}
+	current, err := store.UpdateRequestTagsIfVersion(req.ID, []string{"a", "c"}, read)
+	if !errors.Is(err, ErrRequestVersionMismatch) || current != next {
+		t.Fatalf("Expected a mismatch at version %d, got %d, %v", next, current, err)
+	}
+	if _, err := store.UpdateSEOEnabledIfVersion(req.ID, true, read); !errors.Is(err, ErrRequestVersionMismatch) {
+		t.Errorf("Expected a mismatch for the SEO update, got %v", err)
+	}
+	if _, err := store.UpdateRequestTagsIfVersion("rowv-missing", []string{"a"}, 1); err == nil || err.Error() != "request not found" {
+		t.Errorf("Expected request not found, got %v", err)
+	}
+
+	// Unguarded writes still bump the version
+	if err := store.UpdateRequestMetadata(req.ID, map[string]interface{}{"k": "v"}); err != nil {
+		t.Fatalf("UpdateRequestMetadata failed: %v", err)
+	}
+	got, err = store.GetRequest(req.ID)
+	if err != nil {
+		t.Fatalf("GetRequest failed: %v", err)
+	}
+	if got.RowVersion != next+1 || len(got.Tags) != 2 || got.Tags[1] != "b" {
+		t.Errorf("Expected version %d with tags [a b], got %d %v", next+1, got.RowVersion, got.Tags)
+	}
+}
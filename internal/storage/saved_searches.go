@@ -0,0 +1,124 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// SavedSearch is a named request filter that can be run again later
+type SavedSearch struct {
+	ID        string          `json:"id"`
+	Name      string          `json:"name"`
+	Owner     string          `json:"owner"`  // created_by of whoever saved it
+	Filter    json.RawMessage `json:"filter"` // Body accepted by POST /api/requests/filter
+	Namespace string          `json:"namespace"`
+	CreatedAt time.Time       `json:"created_at"`
+	UpdatedAt time.Time       `json:"updated_at"`
+}
+
+const savedSearchColumns = "id, name, owner, filter_json, namespace, created_at, updated_at"
+
+func scanSavedSearch(row interface{ Scan(...any) error }) (*SavedSearch, error) {
+	var search SavedSearch
+	var filter []byte
+	if err := row.Scan(&search.ID, &search.Name, &search.Owner, &filter, &search.Namespace, &search.CreatedAt, &search.UpdatedAt); err != nil {
+		return nil, err
+	}
+	search.Filter = json.RawMessage(filter)
+	return &search, nil
+}
+
+// CreateSavedSearch stores a new saved search in s's namespace unless it names its own,
+// setting its timestamps
+func (s *Storage) CreateSavedSearch(search *SavedSearch) error {
+	defer s.timeQuery("CreateSavedSearch", "id", search.ID)()
+	now := time.Now().UTC()
+	search.CreatedAt, search.UpdatedAt = now, now
+	search.Namespace = s.namespaceFor(search.Namespace)
+	_, err := s.db.Exec(`
+		INSERT INTO saved_searches (id, name, owner, filter_json, namespace, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`, search.ID, search.Name, search.Owner, string(search.Filter), search.Namespace, search.CreatedAt, search.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create saved search: %w", err)
+	}
+	return nil
+}
+
+// GetSavedSearch returns a saved search, or nil if there is none with the ID
+func (s *Storage) GetSavedSearch(id string) (*SavedSearch, error) {
+	defer s.timeQuery("GetSavedSearch", "id", id)()
+	search, err := scanSavedSearch(s.db.QueryRow(`SELECT `+savedSearchColumns+` FROM saved_searches WHERE id = $1 AND `+s.inNamespace(""), id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get saved search: %w", err)
+	}
+	return search, nil
+}
+
+// ListSavedSearches returns the saved searches in s's namespace ordered by name, only those of
+// owner when it is not empty
+func (s *Storage) ListSavedSearches(owner string) ([]*SavedSearch, error) {
+	defer s.timeQuery("ListSavedSearches", "owner", owner)()
+	rows, err := s.db.Query(`
+		SELECT `+savedSearchColumns+`
+		FROM saved_searches
+		WHERE ($1 = '' OR owner = $1) AND `+s.inNamespace("")+`
+		ORDER BY name, created_at
+	`, owner)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list saved searches: %w", err)
+	}
+	defer rows.Close()
+
+	searches := []*SavedSearch{}
+	for rows.Next() {
+		search, err := scanSavedSearch(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan saved search: %w", err)
+		}
+		searches = append(searches, search)
+	}
+	return searches, rows.Err()
+}
+
+// UpdateSavedSearch replaces the name and filter of a saved search. The owner is kept.
+func (s *Storage) UpdateSavedSearch(search *SavedSearch) error {
+	defer s.timeQuery("UpdateSavedSearch", "id", search.ID)()
+	row := s.db.QueryRow(`
+		UPDATE saved_searches
+		SET name = $2, filter_json = $3, updated_at = NOW()
+		WHERE id = $1 AND `+s.inNamespace("")+`
+		RETURNING owner, namespace, created_at, updated_at
+	`, search.ID, search.Name, string(search.Filter))
+	err := row.Scan(&search.Owner, &search.Namespace, &search.CreatedAt, &search.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("saved search not found")
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update saved search: %w", err)
+	}
+	return nil
+}
+
+// DeleteSavedSearch removes a saved search
+func (s *Storage) DeleteSavedSearch(id string) error {
+	defer s.timeQuery("DeleteSavedSearch", "id", id)()
+	result, err := s.db.Exec("DELETE FROM saved_searches WHERE id = $1 AND "+s.inNamespace(""), id)
+	if err != nil {
+		return fmt.Errorf("failed to delete saved search: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("saved search not found")
+	}
+	return nil
+}
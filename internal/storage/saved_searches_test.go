@@ -0,0 +1,65 @@
+package storage
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestSavedSearchCRUD(t *testing.T) {
+	t.Parallel()
+	store, cleanup := setupTestStorage(t)
+	defer cleanup()
+
+	search := &SavedSearch{ID: "search-1", Name: "Recent Go", Owner: "apikey:0123456789ab", Filter: json.RawMessage(`{"tags":["go"],"fuzzy":false}`)}
+	if err := store.CreateSavedSearch(search); err != nil {
+		t.Fatalf("CreateSavedSearch failed: %v", err)
+	}
+	if err := store.CreateSavedSearch(&SavedSearch{ID: "search-2", Name: "All", Owner: "anonymous", Filter: json.RawMessage(`{}`)}); err != nil {
+		t.Fatalf("CreateSavedSearch failed: %v", err)
+	}
+
+	got, err := store.GetSavedSearch("search-1")
+	if err != nil {
+		t.Fatalf("GetSavedSearch failed: %v", err)
+	}
+	var filter map[string]interface{}
+	if err := json.Unmarshal(got.Filter, &filter); err != nil || filter["tags"] == nil {
+		t.Errorf("Expected the stored filter back, got %s (%v)", got.Filter, err)
+	}
+	if got, err := store.GetSavedSearch("missing"); err != nil || got != nil {
+		t.Errorf("Expected nil for a missing search, got %+v, %v", got, err)
+	}
+
+	all, err := store.ListSavedSearches("")
+	if err != nil {
+		t.Fatalf("ListSavedSearches failed: %v", err)
+	}
+	if len(all) != 2 || all[0].Name != "All" {
+		t.Errorf("Expected both searches ordered by name, got %d", len(all))
+	}
+	mine, err := store.ListSavedSearches("apikey:0123456789ab")
+	if err != nil {
+		t.Fatalf("ListSavedSearches failed: %v", err)
+	}
+	if len(mine) != 1 || mine[0].ID != "search-1" {
+		t.Errorf("Expected only the owner's search, got %d", len(mine))
+	}
+
+	update := &SavedSearch{ID: "search-1", Name: "Go", Filter: json.RawMessage(`{"tags":["golang"]}`)}
+	if err := store.UpdateSavedSearch(update); err != nil {
+		t.Fatalf("UpdateSavedSearch failed: %v", err)
+	}
+	if update.Owner != "apikey:0123456789ab" {
+		t.Errorf("Expected the owner to be kept, got %q", update.Owner)
+	}
+	if err := store.UpdateSavedSearch(&SavedSearch{ID: "missing", Name: "x", Filter: json.RawMessage(`{}`)}); err == nil {
+		t.Error("Expected an error updating a missing search")
+	}
+
+	if err := store.DeleteSavedSearch("search-1"); err != nil {
+		t.Fatalf("DeleteSavedSearch failed: %v", err)
+	}
+	if err := store.DeleteSavedSearch("search-1"); err == nil || err.Error() != "saved search not found" {
+		t.Errorf("Expected not found deleting twice, got %v", err)
+	}
+}
@@ -2,6 +2,8 @@ package storage
 
 import (
 	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"time"
 )
@@ -21,20 +23,79 @@ type ScrapeJob struct {
 	AsynqTaskID     string     `json:"asynq_task_id,omitempty"`
 	ParentJobID     *string    `json:"parent_job_id,omitempty"`
 	Depth           int        `json:"depth"`
+	AllowDuplicates bool       `json:"allow_duplicates,omitempty"` // Save even if the content matches an existing request
+	DuplicateOf     *string    `json:"duplicate_of,omitempty"`     // Existing request the scraped content duplicated
+	OverrideRobots  bool       `json:"override_robots,omitempty"`  // Scrape even if robots.txt disallows the URL
+	SkipReason      string     `json:"skip_reason,omitempty"`      // Why a completed job was not scraped (e.g. robots_txt)
+	CreatedBy       string     `json:"created_by"`                 // Client, API key or worker that queued the job
+	RootJobID       *string    `json:"root_job_id,omitempty"`      // First job of the crawl this job descends from; nil on the root itself
+	MaxPages        int        `json:"max_pages,omitempty"`        // Crawl budget set on a root job; 0 uses the configured default
+	PagesEnqueued   int        `json:"pages_enqueued,omitempty"`   // Descendants queued so far, counted on the root job
+	BudgetExhausted bool       `json:"budget_exhausted,omitempty"` // Links were dropped because the crawl budget ran out
+	LinkSummary     *LinkExtractionSummary `json:"link_extraction_summary,omitempty"` // What link extraction found on the page; nil until it has run
+	CrawlParams     *CrawlParams           `json:"crawl_params,omitempty"`            // Crawl settings fixed when the crawl started; nil on jobs from before they were recorded
+	OriginalURL     *string                `json:"original_url,omitempty"`            // URL first submitted, set once a retry changes URL
+	RescrapeOf      *string                `json:"rescrape_of,omitempty"`             // Request whose content this job refreshes in place
+	Namespace       string                 `json:"namespace"`                         // Tenant the job and its result belong to; inherited by crawl children
+	SkipAnalysis    bool                   `json:"skip_analysis,omitempty"`           // Store the page without text analysis or quality tombstoning; inherited by crawl children
 	ChildJobs       []*ScrapeJob `json:"child_jobs,omitempty"`
 }
 
+// LinkExtractionSummary accounts for every link the scraper found on a page:
+// Found = Enqueued + Failed + the sum of Skipped
+type LinkExtractionSummary struct {
+	Found    int            `json:"found"`             // Links the scraper returned
+	Enqueued int            `json:"enqueued"`          // Child jobs queued for scraping
+	Failed   int            `json:"failed,omitempty"`  // Child jobs that could not be saved or enqueued
+	Skipped  map[string]int `json:"skipped,omitempty"` // Links not queued, by reason (unscrapable, duplicate, budget_exhausted, ...)
+}
+
+// CrawlParams are the crawl settings in force when a crawl's root job was submitted. They
+// travel with every task of the crawl, so a crawl stays consistent if settings change mid-flight.
+type CrawlParams struct {
+	MaxDepth           int     `json:"max_depth"`            // Links are extracted from pages shallower than this
+	LinkScoreThreshold float64 `json:"link_score_threshold"` // Global threshold; per-domain overrides still apply on top
+	Queue              string  `json:"queue"`                // Asynq queue the job's scrape task runs on
+}
+
+// scrapeJobColumns is the column list scanScrapeJob expects, in order
+const scrapeJobColumns = `
+			id, url, extract_links, status, retries,
+			created_at, updated_at, completed_at,
+			error_message, result_request_id, asynq_task_id,
+			parent_job_id, depth, allow_duplicates, duplicate_of,
+			override_robots, skip_reason, created_by,
+			root_job_id, max_pages, pages_enqueued, budget_exhausted,
+			link_extraction_summary, crawl_params, original_url, rescrape_of,
+			namespace, skip_analysis`
+
 // SaveScrapeJob inserts a new scrape job into the database
 func (s *Storage) SaveScrapeJob(job *ScrapeJob) error {
+	defer s.timeQuery("SaveScrapeJob", "id", job.ID)()
 	query := `
 		INSERT INTO scrape_jobs (
 			id, url, extract_links, status, retries,
 			created_at, updated_at, completed_at,
 			error_message, result_request_id, asynq_task_id,
-			parent_job_id, depth
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+			parent_job_id, depth, allow_duplicates, override_robots, created_by,
+			root_job_id, max_pages, crawl_params, rescrape_of, namespace, skip_analysis
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22)
 	`
 
+	if job.CreatedBy == "" {
+		job.CreatedBy = CreatedByUnknown
+	}
+	job.Namespace = s.namespaceFor(job.Namespace)
+	var crawlParams *string
+	if job.CrawlParams != nil {
+		data, err := json.Marshal(job.CrawlParams)
+		if err != nil {
+			return fmt.Errorf("failed to encode crawl params: %w", err)
+		}
+		encoded := string(data)
+		crawlParams = &encoded
+	}
+
 	_, err := s.db.Exec(
 		query,
 		job.ID,
@@ -50,6 +111,15 @@ func (s *Storage) SaveScrapeJob(job *ScrapeJob) error {
 		job.AsynqTaskID,
 		job.ParentJobID,
 		job.Depth,
+		job.AllowDuplicates,
+		job.OverrideRobots,
+		job.CreatedBy,
+		job.RootJobID,
+		job.MaxPages,
+		crawlParams,
+		job.RescrapeOf,
+		job.Namespace,
+		job.SkipAnalysis,
 	)
 
 	if err != nil {
@@ -61,59 +131,43 @@ func (s *Storage) SaveScrapeJob(job *ScrapeJob) error {
 
 // GetScrapeJob retrieves a scrape job by ID
 func (s *Storage) GetScrapeJob(id string) (*ScrapeJob, error) {
+	defer s.timeQuery("GetScrapeJob", "id", id)()
 	query := `
-		SELECT
-			id, url, extract_links, status, retries,
-			created_at, updated_at, completed_at,
-			error_message, result_request_id, asynq_task_id,
-			parent_job_id, depth
+		SELECT `+scrapeJobColumns+`
 		FROM scrape_jobs
-		WHERE id = $1
+		WHERE id = $1 AND `+s.inNamespace("")+`
 	`
 
-	job := &ScrapeJob{}
-	var completedAt sql.NullTime
-	var errorMessage sql.NullString
-	var resultRequestID sql.NullString
-	var asynqTaskID sql.NullString
-	var parentJobID sql.NullString
-
-	err := s.db.QueryRow(query, id).Scan(
-		&job.ID,
-		&job.URL,
-		&job.ExtractLinks,
-		&job.Status,
-		&job.Retries,
-		&job.CreatedAt,
-		&job.UpdatedAt,
-		&completedAt,
-		&errorMessage,
-		&resultRequestID,
-		&asynqTaskID,
-		&parentJobID,
-		&job.Depth,
-	)
-
-	if err == sql.ErrNoRows {
+	job, err := s.scanScrapeJob(s.db.QueryRow(query, id))
+	if errors.Is(err, sql.ErrNoRows) {
 		return nil, nil
 	}
 	if err != nil {
-		return nil, fmt.Errorf("failed to get scrape job: %w", err)
-	}
-	if completedAt.Valid {
-		job.CompletedAt = &completedAt.Time
-	}
-	if errorMessage.Valid {
-		job.ErrorMessage = errorMessage.String
-	}
-	if resultRequestID.Valid {
-		job.ResultRequestID = &resultRequestID.String
+		return nil, err
 	}
-	if asynqTaskID.Valid {
-		job.AsynqTaskID = asynqTaskID.String
+
+	return job, nil
+}
+
+// GetScrapeJobByRequestID retrieves the most recent scrape job that produced the given request.
+// Jobs that merely resolved to it as a duplicate are ignored. Returns nil when the request was
+// not created by a scrape job.
+func (s *Storage) GetScrapeJobByRequestID(requestID string) (*ScrapeJob, error) {
+	defer s.timeQuery("GetScrapeJobByRequestID", "request_id", requestID)()
+	query := `
+		SELECT `+scrapeJobColumns+`
+		FROM scrape_jobs
+		WHERE result_request_id = $1 AND duplicate_of IS NULL AND `+s.inNamespace("")+`
+		ORDER BY created_at DESC
+		LIMIT 1
+	`
+
+	job, err := s.scanScrapeJob(s.db.QueryRow(query, requestID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
 	}
-	if parentJobID.Valid {
-		job.ParentJobID = &parentJobID.String
+	if err != nil {
+		return nil, err
 	}
 
 	return job, nil
@@ -121,14 +175,11 @@ func (s *Storage) GetScrapeJob(id string) (*ScrapeJob, error) {
 
 // ListScrapeJobs retrieves scrape jobs with pagination (only top-level, no parent)
 func (s *Storage) ListScrapeJobs(limit, offset int) ([]*ScrapeJob, error) {
+	defer s.timeQuery("ListScrapeJobs", "limit", limit, "offset", offset)()
 	query := `
-		SELECT
-			id, url, extract_links, status, retries,
-			created_at, updated_at, completed_at,
-			error_message, result_request_id, asynq_task_id,
-			parent_job_id, depth
+		SELECT `+scrapeJobColumns+`
 		FROM scrape_jobs
-		WHERE parent_job_id IS NULL
+		WHERE parent_job_id IS NULL AND `+s.inNamespace("")+`
 		ORDER BY created_at DESC
 		LIMIT $1 OFFSET $2
 	`
@@ -167,14 +218,11 @@ func (s *Storage) ListScrapeJobs(limit, offset int) ([]*ScrapeJob, error) {
 
 // GetChildJobs retrieves all child jobs for a parent job
 func (s *Storage) GetChildJobs(parentID string) ([]*ScrapeJob, error) {
+	defer s.timeQuery("GetChildJobs", "parent_id", parentID)()
 	query := `
-		SELECT
-			id, url, extract_links, status, retries,
-			created_at, updated_at, completed_at,
-			error_message, result_request_id, asynq_task_id,
-			parent_job_id, depth
+		SELECT `+scrapeJobColumns+`
 		FROM scrape_jobs
-		WHERE parent_job_id = $1
+		WHERE parent_job_id = $1 AND `+s.inNamespace("")+`
 		ORDER BY created_at ASC
 	`
 
@@ -210,6 +258,13 @@ func (s *Storage) scanScrapeJob(row interface {
 	var resultRequestID sql.NullString
 	var asynqTaskID sql.NullString
 	var parentJobID sql.NullString
+	var duplicateOf sql.NullString
+	var skipReason sql.NullString
+	var rootJobID sql.NullString
+	var linkSummary []byte
+	var crawlParams []byte
+	var originalURL sql.NullString
+	var rescrapeOf sql.NullString
 
 	err := row.Scan(
 		&job.ID,
@@ -225,10 +280,37 @@ func (s *Storage) scanScrapeJob(row interface {
 		&asynqTaskID,
 		&parentJobID,
 		&job.Depth,
+		&job.AllowDuplicates,
+		&duplicateOf,
+		&job.OverrideRobots,
+		&skipReason,
+		&job.CreatedBy,
+		&rootJobID,
+		&job.MaxPages,
+		&job.PagesEnqueued,
+		&job.BudgetExhausted,
+		&linkSummary,
+		&crawlParams,
+		&originalURL,
+		&rescrapeOf,
+		&job.Namespace,
+		&job.SkipAnalysis,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to scan scrape job: %w", err)
 	}
+	if linkSummary != nil {
+		job.LinkSummary = &LinkExtractionSummary{}
+		if err := json.Unmarshal(linkSummary, job.LinkSummary); err != nil {
+			return nil, fmt.Errorf("failed to decode link extraction summary: %w", err)
+		}
+	}
+	if crawlParams != nil {
+		job.CrawlParams = &CrawlParams{}
+		if err := json.Unmarshal(crawlParams, job.CrawlParams); err != nil {
+			return nil, fmt.Errorf("failed to decode crawl params: %w", err)
+		}
+	}
 	if completedAt.Valid {
 		job.CompletedAt = &completedAt.Time
 	}
@@ -244,12 +326,73 @@ func (s *Storage) scanScrapeJob(row interface {
 	if parentJobID.Valid {
 		job.ParentJobID = &parentJobID.String
 	}
+	if duplicateOf.Valid {
+		job.DuplicateOf = &duplicateOf.String
+	}
+	if skipReason.Valid {
+		job.SkipReason = skipReason.String
+	}
+	if rootJobID.Valid {
+		job.RootJobID = &rootJobID.String
+	}
+	if originalURL.Valid {
+		job.OriginalURL = &originalURL.String
+	}
+	if rescrapeOf.Valid {
+		job.RescrapeOf = &rescrapeOf.String
+	}
 
 	return job, nil
 }
 
+// SaveLinkExtractionSummary records what link extraction found on the job's page,
+// replacing the summary of any earlier extraction
+func (s *Storage) SaveLinkExtractionSummary(id string, summary *LinkExtractionSummary) error {
+	defer s.timeQuery("SaveLinkExtractionSummary", "id", id)()
+	data, err := json.Marshal(summary)
+	if err != nil {
+		return fmt.Errorf("failed to encode link extraction summary: %w", err)
+	}
+
+	result, err := s.db.Exec(`
+		UPDATE scrape_jobs
+		SET link_extraction_summary = $2, updated_at = $3
+		WHERE id = $1
+	`, id, string(data), time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to save link extraction summary: %w", err)
+	}
+	if rows, err := result.RowsAffected(); err == nil && rows == 0 {
+		return fmt.Errorf("scrape job not found: %s", id)
+	}
+	return nil
+}
+
+// UpdateScrapeJobCrawlParams records the crawl parameters the job's task runs with
+func (s *Storage) UpdateScrapeJobCrawlParams(id string, params *CrawlParams) error {
+	defer s.timeQuery("UpdateScrapeJobCrawlParams", "id", id)()
+	data, err := json.Marshal(params)
+	if err != nil {
+		return fmt.Errorf("failed to encode crawl params: %w", err)
+	}
+
+	result, err := s.db.Exec(`
+		UPDATE scrape_jobs
+		SET crawl_params = $2
+		WHERE id = $1
+	`, id, string(data))
+	if err != nil {
+		return fmt.Errorf("failed to update crawl params: %w", err)
+	}
+	if rows, err := result.RowsAffected(); err == nil && rows == 0 {
+		return fmt.Errorf("scrape job not found: %s", id)
+	}
+	return nil
+}
+
 // UpdateScrapeJobStatus updates the status of a scrape job
 func (s *Storage) UpdateScrapeJobStatus(id, status string, errorMessage string) error {
+	defer s.timeQuery("UpdateScrapeJobStatus", "id", id, "status", status)()
 	now := time.Now()
 	var completedAt *time.Time
 
@@ -283,6 +426,7 @@ func (s *Storage) UpdateScrapeJobStatus(id, status string, errorMessage string)
 
 // UpdateScrapeJobResult updates the result request ID when a job completes
 func (s *Storage) UpdateScrapeJobResult(id string, resultRequestID string) error {
+	defer s.timeQuery("UpdateScrapeJobResult", "id", id)()
 	now := time.Now()
 	query := `
 		UPDATE scrape_jobs
@@ -307,8 +451,81 @@ func (s *Storage) UpdateScrapeJobResult(id string, resultRequestID string) error
 	return nil
 }
 
+// UpdateScrapeJobDuplicate completes a job whose content duplicated an existing request
+func (s *Storage) UpdateScrapeJobDuplicate(id string, existingRequestID string) error {
+	defer s.timeQuery("UpdateScrapeJobDuplicate", "id", id)()
+	now := time.Now()
+	query := `
+		UPDATE scrape_jobs
+		SET status = $1, result_request_id = $2, duplicate_of = $2, updated_at = $3, completed_at = $4
+		WHERE id = $5
+	`
+
+	result, err := s.db.Exec(query, "completed", existingRequestID, now, now, id)
+	if err != nil {
+		return fmt.Errorf("failed to update scrape job duplicate: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("scrape job not found")
+	}
+
+	return nil
+}
+
+// UpdateScrapeJobSkipped completes a job without scraping it, recording why
+func (s *Storage) UpdateScrapeJobSkipped(id string, reason string) error {
+	defer s.timeQuery("UpdateScrapeJobSkipped", "id", id)()
+	now := time.Now()
+	query := `
+		UPDATE scrape_jobs
+		SET status = $1, skip_reason = $2, updated_at = $3, completed_at = $4
+		WHERE id = $5
+	`
+
+	result, err := s.db.Exec(query, "completed", reason, now, now, id)
+	if err != nil {
+		return fmt.Errorf("failed to update scrape job skip reason: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("scrape job not found")
+	}
+
+	return nil
+}
+
+// UpdateScrapeJobURL points a job at url, recording originalURL as the URL it was first
+// submitted with (nil clears it)
+func (s *Storage) UpdateScrapeJobURL(id, url string, originalURL *string) error {
+	defer s.timeQuery("UpdateScrapeJobURL", "id", id)()
+	result, err := s.db.Exec(`
+		UPDATE scrape_jobs
+		SET url = $2, original_url = $3, updated_at = $4
+		WHERE id = $1
+	`, id, url, originalURL, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to update scrape job URL: %w", err)
+	}
+	if rows, err := result.RowsAffected(); err == nil && rows == 0 {
+		return fmt.Errorf("scrape job not found: %s", id)
+	}
+	return nil
+}
+
 // UpdateScrapeJobTaskID updates the Asynq task ID for a job
 func (s *Storage) UpdateScrapeJobTaskID(id string, taskID string) error {
+	defer s.timeQuery("UpdateScrapeJobTaskID", "id", id)()
 	query := `
 		UPDATE scrape_jobs
 		SET asynq_task_id = $1, updated_at = $2
@@ -334,6 +551,7 @@ func (s *Storage) UpdateScrapeJobTaskID(id string, taskID string) error {
 
 // IncrementScrapeJobRetries increments the retry count for a job
 func (s *Storage) IncrementScrapeJobRetries(id string) error {
+	defer s.timeQuery("IncrementScrapeJobRetries", "id", id)()
 	query := `
 		UPDATE scrape_jobs
 		SET retries = retries + 1, updated_at = $1
@@ -359,7 +577,8 @@ func (s *Storage) IncrementScrapeJobRetries(id string) error {
 
 // DeleteScrapeJob deletes a scrape job
 func (s *Storage) DeleteScrapeJob(id string) error {
-	query := `DELETE FROM scrape_jobs WHERE id = $1`
+	defer s.timeQuery("DeleteScrapeJob", "id", id)()
+	query := `DELETE FROM scrape_jobs WHERE id = $1 AND ` + s.inNamespace("")
 
 	result, err := s.db.Exec(query, id)
 	if err != nil {
@@ -380,7 +599,8 @@ func (s *Storage) DeleteScrapeJob(id string) error {
 
 // CountScrapeJobsByStatus counts jobs by status
 func (s *Storage) CountScrapeJobsByStatus(status string) (int, error) {
-	query := `SELECT COUNT(*) FROM scrape_jobs WHERE status = $1`
+	defer s.timeQuery("CountScrapeJobsByStatus", "status", status)()
+	query := `SELECT COUNT(*) FROM scrape_jobs WHERE status = $1 AND ` + s.inNamespace("")
 
 	var count int
 	err := s.db.QueryRow(query, status).Scan(&count)
@@ -26,6 +26,7 @@ func setupTestStorage(t *testing.T) (*Storage, func()) {
 }
 
 func TestScrapeJobCRUD(t *testing.T) {
+	t.Parallel()
 	store, cleanup := setupTestStorage(t)
 	defer cleanup()
 
@@ -80,6 +81,7 @@ func TestScrapeJobCRUD(t *testing.T) {
 }
 
 func TestScrapeJobParentChild(t *testing.T) {
+	t.Parallel()
 	store, cleanup := setupTestStorage(t)
 	defer cleanup()
 
@@ -166,6 +168,7 @@ func TestScrapeJobParentChild(t *testing.T) {
 }
 
 func TestListScrapeJobsOnlyParents(t *testing.T) {
+	t.Parallel()
 	store, cleanup := setupTestStorage(t)
 	defer cleanup()
 
@@ -282,6 +285,7 @@ func TestListScrapeJobsOnlyParents(t *testing.T) {
 }
 
 func TestUpdateScrapeJobStatus(t *testing.T) {
+	t.Parallel()
 	store, cleanup := setupTestStorage(t)
 	defer cleanup()
 
@@ -342,6 +346,7 @@ func TestUpdateScrapeJobStatus(t *testing.T) {
 }
 
 func TestIncrementScrapeJobRetries(t *testing.T) {
+	t.Parallel()
 	store, cleanup := setupTestStorage(t)
 	defer cleanup()
 
@@ -379,7 +384,114 @@ func TestIncrementScrapeJobRetries(t *testing.T) {
 	}
 }
 
+func TestUpdateScrapeJobSkipped(t *testing.T) {
+	t.Parallel()
+	store, cleanup := setupTestStorage(t)
+	defer cleanup()
+
+	jobID := "robots-job-001"
+	job := &ScrapeJob{
+		ID:        jobID,
+		URL:       "https://example.com/disallowed",
+		Status:    "queued",
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+	if err := store.SaveScrapeJob(job); err != nil {
+		t.Fatalf("Failed to save job: %v", err)
+	}
+
+	if err := store.UpdateScrapeJobSkipped(jobID, "robots_txt"); err != nil {
+		t.Fatalf("Failed to mark job skipped: %v", err)
+	}
+
+	retrieved, err := store.GetScrapeJob(jobID)
+	if err != nil {
+		t.Fatalf("Failed to retrieve job: %v", err)
+	}
+	if retrieved.Status != "completed" {
+		t.Errorf("Expected status completed, got %s", retrieved.Status)
+	}
+	if retrieved.SkipReason != "robots_txt" {
+		t.Errorf("Expected skip reason robots_txt, got %q", retrieved.SkipReason)
+	}
+	if retrieved.CompletedAt == nil {
+		t.Error("Expected completed_at to be set")
+	}
+	if retrieved.ResultRequestID != nil {
+		t.Error("Expected no result request for a skipped job")
+	}
+
+	if err := store.UpdateScrapeJobSkipped("missing-job", "robots_txt"); err == nil {
+		t.Error("Expected error for missing job")
+	}
+}
+
+func TestSaveLinkExtractionSummary(t *testing.T) {
+	t.Parallel()
+	store, cleanup := setupTestStorage(t)
+	defer cleanup()
+
+	jobID := "summary-job-001"
+	job := &ScrapeJob{ID: jobID, URL: "https://example.com", Status: "completed", CreatedAt: time.Now(), UpdatedAt: time.Now()}
+	if err := store.SaveScrapeJob(job); err != nil {
+		t.Fatalf("Failed to save job: %v", err)
+	}
+
+	retrieved, err := store.GetScrapeJob(jobID)
+	if err != nil {
+		t.Fatalf("Failed to retrieve job: %v", err)
+	}
+	if retrieved.LinkSummary != nil {
+		t.Errorf("Expected no summary before extraction, got %+v", retrieved.LinkSummary)
+	}
+
+	summary := &LinkExtractionSummary{Found: 240, Enqueued: 31, Failed: 1, Skipped: map[string]int{"duplicate": 200, "budget_exhausted": 8}}
+	if err := store.SaveLinkExtractionSummary(jobID, summary); err != nil {
+		t.Fatalf("Failed to save summary: %v", err)
+	}
+	retrieved, err = store.GetScrapeJob(jobID)
+	if err != nil {
+		t.Fatalf("Failed to retrieve job: %v", err)
+	}
+	got := retrieved.LinkSummary
+	if got == nil || got.Found != 240 || got.Enqueued != 31 || got.Failed != 1 || got.Skipped["duplicate"] != 200 || got.Skipped["budget_exhausted"] != 8 {
+		t.Errorf("Expected the saved summary back, got %+v", got)
+	}
+
+	if err := store.SaveLinkExtractionSummary("missing-job", summary); err == nil {
+		t.Error("Expected error for missing job")
+	}
+}
+
+func TestScrapeJobOverrideRobots(t *testing.T) {
+	t.Parallel()
+	store, cleanup := setupTestStorage(t)
+	defer cleanup()
+
+	job := &ScrapeJob{
+		ID:             "override-job-001",
+		URL:            "https://example.com/page",
+		Status:         "queued",
+		CreatedAt:      time.Now(),
+		UpdatedAt:      time.Now(),
+		OverrideRobots: true,
+	}
+	if err := store.SaveScrapeJob(job); err != nil {
+		t.Fatalf("Failed to save job: %v", err)
+	}
+
+	retrieved, err := store.GetScrapeJob(job.ID)
+	if err != nil {
+		t.Fatalf("Failed to retrieve job: %v", err)
+	}
+	if !retrieved.OverrideRobots {
+		t.Error("Expected override_robots to round-trip")
+	}
+}
+
 func TestDeleteScrapeJob(t *testing.T) {
+	t.Parallel()
 	store, cleanup := setupTestStorage(t)
 	defer cleanup()
 
@@ -425,6 +537,7 @@ func TestDeleteScrapeJob(t *testing.T) {
 }
 
 func TestMultiLevelHierarchy(t *testing.T) {
+	t.Parallel()
 	store, cleanup := setupTestStorage(t)
 	defer cleanup()
 
@@ -524,3 +637,55 @@ func TestMultiLevelHierarchy(t *testing.T) {
 		t.Errorf("Expected 0 children for child2, got %d", len(child2Children))
 	}
 }
+
+func TestGetScrapeJobByRequestID(t *testing.T) {
+	t.Parallel()
+	store, cleanup := setupTestStorage(t)
+	defer cleanup()
+
+	req := &Request{
+		ID:               "status-request",
+		CreatedAt:        time.Now().UTC(),
+		SourceType:       "url",
+		TextAnalyzerUUID: "ta-1",
+		Metadata:         map[string]interface{}{},
+	}
+	if err := store.SaveRequest(req); err != nil {
+		t.Fatalf("Failed to save request: %v", err)
+	}
+
+	for _, id := range []string{"status-job", "status-dup-job"} {
+		job := &ScrapeJob{
+			ID:        id,
+			URL:       "https://example.com/" + id,
+			Status:    "processing",
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
+		}
+		if err := store.SaveScrapeJob(job); err != nil {
+			t.Fatalf("Failed to save job: %v", err)
+		}
+	}
+	if err := store.UpdateScrapeJobResult("status-job", "status-request"); err != nil {
+		t.Fatalf("Failed to set job result: %v", err)
+	}
+	if err := store.UpdateScrapeJobDuplicate("status-dup-job", "status-request"); err != nil {
+		t.Fatalf("Failed to mark job duplicate: %v", err)
+	}
+
+	job, err := store.GetScrapeJobByRequestID("status-request")
+	if err != nil {
+		t.Fatalf("GetScrapeJobByRequestID failed: %v", err)
+	}
+	if job == nil || job.ID != "status-job" {
+		t.Fatalf("Expected the producing job status-job, got %+v", job)
+	}
+
+	job, err = store.GetScrapeJobByRequestID("no-such-request")
+	if err != nil {
+		t.Fatalf("Expected no error for unknown request, got %v", err)
+	}
+	if job != nil {
+		t.Errorf("Expected nil job for unknown request, got %s", job.ID)
+	}
+}
@@ -0,0 +1,61 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/docutag/controller/internal/settings"
+)
+
+// SetSettings shares runtime settings with storage, replacing the defaults given to New
+func (s *Storage) SetSettings(rs *settings.Settings) {
+	s.settings = rs
+}
+
+// LoadSettingOverrides returns the stored setting overrides keyed by setting name
+func (s *Storage) LoadSettingOverrides() (map[string]json.RawMessage, error) {
+	defer s.timeQuery("LoadSettingOverrides")()
+	rows, err := s.db.Query(`SELECT key, value FROM settings`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load settings: %w", err)
+	}
+	defer rows.Close()
+
+	overrides := make(map[string]json.RawMessage)
+	for rows.Next() {
+		var key string
+		var value []byte
+		if err := rows.Scan(&key, &value); err != nil {
+			return nil, fmt.Errorf("failed to scan setting: %w", err)
+		}
+		overrides[key] = json.RawMessage(value)
+	}
+	return overrides, rows.Err()
+}
+
+// SaveSettingOverrides stores setting overrides in one transaction. A JSON null value
+// deletes the override so the configured default applies again.
+func (s *Storage) SaveSettingOverrides(changes map[string]json.RawMessage, updatedBy string) error {
+	defer s.timeQuery("SaveSettingOverrides", "keys", len(changes))()
+	return s.inTx("SaveSettingOverrides", func(tx *sql.Tx) error {
+		for key, value := range changes {
+			if string(value) == "null" {
+				if _, err := tx.Exec(`DELETE FROM settings WHERE key = $1`, key); err != nil {
+					return fmt.Errorf("failed to reset setting %s: %w", key, err)
+				}
+				continue
+			}
+			_, err := tx.Exec(`
+				INSERT INTO settings (key, value, updated_at, updated_by)
+				VALUES ($1, $2, NOW(), $3)
+				ON CONFLICT (key) DO UPDATE
+				SET value = EXCLUDED.value, updated_at = EXCLUDED.updated_at, updated_by = EXCLUDED.updated_by
+			`, key, string(value), updatedBy)
+			if err != nil {
+				return fmt.Errorf("failed to save setting %s: %w", key, err)
+			}
+		}
+		return nil
+	})
+}
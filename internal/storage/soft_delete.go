@@ -0,0 +1,107 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Shared query fragments that hide soft-deleted requests.
+// Every query that lists, searches or looks up requests must include one of these.
+const (
+	notDeletedPredicate        = "deleted_at IS NULL"
+	notDeletedPredicateAliased = "r.deleted_at IS NULL"
+)
+
+// SoftDeleteRequest marks a request as deleted without removing it.
+// The request disappears from all list, search and SEO queries until it is restored or reaped.
+func (s *Storage) SoftDeleteRequest(id string) (time.Time, error) {
+	defer s.timeQuery("SoftDeleteRequest", "id", id)()
+	deletedAt := time.Now().UTC()
+
+	result, err := s.db.Exec(`
+		UPDATE requests
+		SET deleted_at = $1
+		WHERE id = $2 AND `+notDeletedPredicate+` AND `+s.inNamespace(""), deletedAt, id)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to soft delete request: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return time.Time{}, fmt.Errorf("request not found")
+	}
+
+	return deletedAt, nil
+}
+
+// RestoreRequest clears the deleted marker of a soft-deleted request
+func (s *Storage) RestoreRequest(id string) error {
+	defer s.timeQuery("RestoreRequest", "id", id)()
+	result, err := s.db.Exec(`
+		UPDATE requests
+		SET deleted_at = NULL, row_version = row_version + 1
+		WHERE id = $1 AND deleted_at IS NOT NULL AND `+s.inNamespace("")+`
+	`, id)
+	if err != nil {
+		return fmt.Errorf("failed to restore request: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("deleted request not found")
+	}
+
+	return nil
+}
+
+// ListExpiredDeletedRequests returns soft-deleted requests whose deleted_at is older than the cutoff
+func (s *Storage) ListExpiredDeletedRequests(cutoff time.Time, limit int) ([]*Request, error) {
+	defer s.timeQuery("ListExpiredDeletedRequests", "cutoff", cutoff, "limit", limit)()
+	rows, err := s.db.Query(`
+		SELECT id, created_at, source_type, source_url, scraper_uuid, textanalyzer_uuid, metadata_json, deleted_at, namespace
+		FROM requests
+		WHERE deleted_at IS NOT NULL AND deleted_at < $1
+		ORDER BY deleted_at ASC
+		LIMIT $2
+	`, cutoff, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list expired deleted requests: %w", err)
+	}
+	defer rows.Close()
+
+	var requests []*Request
+	for rows.Next() {
+		var req Request
+		var metadataJSON sql.NullString
+		var deletedAt time.Time
+
+		if err := rows.Scan(&req.ID, &req.CreatedAt, &req.SourceType, &req.SourceURL, &req.ScraperUUID, &req.TextAnalyzerUUID, &metadataJSON, &deletedAt, &req.Namespace); err != nil {
+			return nil, fmt.Errorf("failed to scan deleted request: %w", err)
+		}
+		req.DeletedAt = &deletedAt
+
+		if metadataJSON.Valid && metadataJSON.String != "" {
+			if err := json.Unmarshal([]byte(metadataJSON.String), &req.Metadata); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal metadata: %w", err)
+			}
+		}
+
+		requests = append(requests, &req)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+
+	return requests, nil
+}
@@ -0,0 +1,207 @@
+package storage
+
+import (
+	"testing"
+	"time"
+)
+
+// saveSoftDeleteFixture saves one live and one soft-deleted request sharing the same tag
+func saveSoftDeleteFixture(t *testing.T, store *Storage) {
+	t.Helper()
+
+	effective := time.Date(2024, 3, 15, 12, 0, 0, 0, time.UTC)
+	liveSlug := "live-article"
+	deletedSlug := "deleted-article"
+
+	for _, req := range []*Request{
+		{
+			ID:               "live-1",
+			CreatedAt:        effective,
+			EffectiveDate:    effective,
+			SourceType:       "url",
+			TextAnalyzerUUID: "ta-live",
+			Tags:             []string{"shared", "live-only"},
+			Metadata:         map[string]interface{}{},
+			Slug:             &liveSlug,
+			SEOEnabled:       true,
+		},
+		{
+			ID:               "deleted-1",
+			CreatedAt:        effective.Add(-48 * time.Hour),
+			EffectiveDate:    effective.Add(-48 * time.Hour),
+			SourceType:       "url",
+			TextAnalyzerUUID: "ta-deleted",
+			Tags:             []string{"shared", "deleted-only"},
+			Metadata:         map[string]interface{}{},
+			Slug:             &deletedSlug,
+			SEOEnabled:       true,
+		},
+	} {
+		if err := store.SaveRequest(req); err != nil {
+			t.Fatalf("Failed to save request %s: %v", req.ID, err)
+		}
+	}
+
+	if _, err := store.SoftDeleteRequest("deleted-1"); err != nil {
+		t.Fatalf("Failed to soft delete request: %v", err)
+	}
+}
+
+func TestSoftDeleteHidesRequest(t *testing.T) {
+	t.Parallel()
+	store, cleanup := setupTestStorage(t)
+	defer cleanup()
+
+	saveSoftDeleteFixture(t, store)
+
+	t.Run("get by id", func(t *testing.T) {
+		_, err := store.GetRequest("deleted-1")
+		if err == nil || err.Error() != "request not found" {
+			t.Errorf("Expected 'request not found', got %v", err)
+		}
+	})
+
+	t.Run("search by tags", func(t *testing.T) {
+		ids, err := store.SearchByTags([]string{"shared"}, false)
+		if err != nil {
+			t.Fatalf("SearchByTags failed: %v", err)
+		}
+		if len(ids) != 1 || ids[0] != "live-1" {
+			t.Errorf("Expected only live-1, got %v", ids)
+		}
+
+		ids, err = store.SearchByTags([]string{"deleted"}, true)
+		if err != nil {
+			t.Fatalf("Fuzzy SearchByTags failed: %v", err)
+		}
+		if len(ids) != 0 {
+			t.Errorf("Expected no fuzzy matches for deleted tags, got %v", ids)
+		}
+	})
+
+	t.Run("filter", func(t *testing.T) {
+		results, err := store.FilterRequests(FilterOptions{Tags: []string{"shared"}})
+		if err != nil {
+			t.Fatalf("FilterRequests failed: %v", err)
+		}
+		if len(results) != 1 || results[0].ID != "live-1" {
+			t.Errorf("Expected only live-1 from tag filter, got %d results", len(results))
+		}
+
+		results, err = store.FilterRequests(FilterOptions{})
+		if err != nil {
+			t.Fatalf("FilterRequests failed: %v", err)
+		}
+		if len(results) != 1 {
+			t.Errorf("Expected 1 result without filters, got %d", len(results))
+		}
+	})
+
+	t.Run("list used by sitemap", func(t *testing.T) {
+		results, err := store.ListRequests(100, 0)
+		if err != nil {
+			t.Fatalf("ListRequests failed: %v", err)
+		}
+		for _, req := range results {
+			if req.ID == "deleted-1" {
+				t.Error("Deleted request must not appear in list or sitemap")
+			}
+		}
+	})
+
+	t.Run("slug lookup", func(t *testing.T) {
+		req, err := store.GetRequestBySlug("deleted-article")
+		if err != nil {
+			t.Fatalf("GetRequestBySlug failed: %v", err)
+		}
+		if req != nil {
+			t.Error("Expected nil for slug of deleted request")
+		}
+	})
+
+	t.Run("timeline extents", func(t *testing.T) {
+		earliest, err := store.GetTimelineExtents()
+		if err != nil {
+			t.Fatalf("GetTimelineExtents failed: %v", err)
+		}
+		if earliest == nil || !earliest.Equal(time.Date(2024, 3, 15, 12, 0, 0, 0, time.UTC)) {
+			t.Errorf("Expected earliest date from live request only, got %v", earliest)
+		}
+	})
+
+	t.Run("tag timeline", func(t *testing.T) {
+		start := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+		end := time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC)
+		timeline, err := store.GetTagTimeline(start, end, 31*24*time.Hour, 10)
+		if err != nil {
+			t.Fatalf("GetTagTimeline failed: %v", err)
+		}
+		if timeline.Stats.TotalDocuments != 1 {
+			t.Errorf("Expected 1 document in timeline, got %d", timeline.Stats.TotalDocuments)
+		}
+		for _, bucket := range timeline.Buckets {
+			for _, entry := range bucket.Tags {
+				if entry.Tag == "deleted-only" {
+					t.Error("Deleted request's tags must not appear in timeline")
+				}
+			}
+		}
+	})
+}
+
+func TestRestoreRequest(t *testing.T) {
+	t.Parallel()
+	store, cleanup := setupTestStorage(t)
+	defer cleanup()
+
+	saveSoftDeleteFixture(t, store)
+
+	if err := store.RestoreRequest("deleted-1"); err != nil {
+		t.Fatalf("Failed to restore request: %v", err)
+	}
+
+	if _, err := store.GetRequest("deleted-1"); err != nil {
+		t.Errorf("Expected restored request to be visible, got %v", err)
+	}
+
+	if err := store.RestoreRequest("live-1"); err == nil {
+		t.Error("Expected error restoring a request that is not deleted")
+	}
+
+	if _, err := store.SoftDeleteRequest("deleted-1"); err != nil {
+		t.Fatalf("Failed to soft delete again: %v", err)
+	}
+	if _, err := store.SoftDeleteRequest("deleted-1"); err == nil {
+		t.Error("Expected error soft-deleting an already deleted request")
+	}
+}
+
+func TestListExpiredDeletedRequests(t *testing.T) {
+	t.Parallel()
+	store, cleanup := setupTestStorage(t)
+	defer cleanup()
+
+	saveSoftDeleteFixture(t, store)
+
+	expired, err := store.ListExpiredDeletedRequests(time.Now().UTC().Add(-time.Hour), 10)
+	if err != nil {
+		t.Fatalf("ListExpiredDeletedRequests failed: %v", err)
+	}
+	if len(expired) != 0 {
+		t.Errorf("Expected no requests past the grace period yet, got %d", len(expired))
+	}
+
+	expired, err = store.ListExpiredDeletedRequests(time.Now().UTC().Add(time.Hour), 10)
+	if err != nil {
+		t.Fatalf("ListExpiredDeletedRequests failed: %v", err)
+	}
+	if len(expired) != 1 || expired[0].ID != "deleted-1" {
+		t.Fatalf("Expected deleted-1 to be expired, got %v", expired)
+	}
+	if expired[0].DeletedAt == nil {
+		t.Error("Expected DeletedAt to be populated")
+	}
+	if expired[0].TextAnalyzerUUID != "ta-deleted" {
+		t.Errorf("Expected upstream UUID for cleanup, got %q", expired[0].TextAnalyzerUUID)
+	}
+}
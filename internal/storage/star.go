@@ -0,0 +1,39 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// SetStarred stars or unstars a request. Starring also removes any tombstone, active or
+// scheduled, since an editor has marked the document as wanted; tombstoneRemoved reports
+// whether one was cleared. Unstarring leaves tombstones alone.
+func (s *Storage) SetStarred(id string, starred bool) (tombstoneRemoved bool, err error) {
+	defer s.timeQuery("SetStarred", "id", id)()
+	err = s.db.QueryRow(`
+		WITH prev AS (
+			SELECT id, metadata_json ? 'tombstone_datetime' AS tombstoned
+			FROM requests
+			WHERE id = $2 AND `+notDeletedPredicate+` AND `+s.inNamespace("")+`
+			FOR UPDATE
+		)
+		UPDATE requests r
+		SET starred = $1,
+		    metadata_json = CASE
+		        WHEN $1::boolean THEN r.metadata_json - 'tombstone_datetime' - 'tombstone_reason'
+		        ELSE r.metadata_json
+		    END,
+		    row_version = r.row_version + 1
+		FROM prev
+		WHERE r.id = prev.id
+		RETURNING $1::boolean AND COALESCE(prev.tombstoned, false)
+	`, starred, id).Scan(&tombstoneRemoved)
+	if err == sql.ErrNoRows {
+		return false, fmt.Errorf("request not found")
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to update starred: %w", err)
+	}
+
+	return tombstoneRemoved, nil
+}
@@ -0,0 +1,97 @@
+package storage
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSetStarred(t *testing.T) {
+	t.Parallel()
+	store, cleanup := setupTestStorage(t)
+	defer cleanup()
+
+	now := time.Now().UTC()
+	requests := []*Request{
+		{ID: "plain", Metadata: map[string]interface{}{}},
+		{ID: "tombstoned", Metadata: map[string]interface{}{
+			"tombstone_datetime": now.Add(-time.Hour).Format(time.RFC3339),
+			"tombstone_reason":   "manual",
+			"title":              "kept",
+		}},
+		{ID: "scheduled", Metadata: map[string]interface{}{
+			"tombstone_datetime": now.Add(24 * time.Hour).Format(time.RFC3339),
+		}},
+	}
+	for _, req := range requests {
+		req.CreatedAt = now
+		req.SourceType = "text"
+		req.SEOEnabled = true
+		if err := store.SaveRequest(req); err != nil {
+			t.Fatalf("Failed to save request %s: %v", req.ID, err)
+		}
+	}
+
+	tests := []struct {
+		id            string
+		starred       bool
+		wantRemoved   bool
+		wantTombstone bool
+	}{
+		{"plain", true, false, false},
+		{"tombstoned", true, true, false},
+		{"scheduled", true, true, false},
+		{"plain", false, false, false},
+	}
+	for _, tt := range tests {
+		removed, err := store.SetStarred(tt.id, tt.starred)
+		if err != nil {
+			t.Fatalf("SetStarred(%s, %v) failed: %v", tt.id, tt.starred, err)
+		}
+		if removed != tt.wantRemoved {
+			t.Errorf("SetStarred(%s, %v): expected tombstoneRemoved %v, got %v", tt.id, tt.starred, tt.wantRemoved, removed)
+		}
+
+		req, err := store.GetRequest(tt.id)
+		if err != nil {
+			t.Fatalf("Failed to get request %s: %v", tt.id, err)
+		}
+		if req.Starred != tt.starred {
+			t.Errorf("%s: expected starred %v, got %v", tt.id, tt.starred, req.Starred)
+		}
+		if _, ok := req.Metadata["tombstone_datetime"]; ok != tt.wantTombstone {
+			t.Errorf("%s: expected tombstone present %v, got metadata %v", tt.id, tt.wantTombstone, req.Metadata)
+		}
+	}
+
+	tombstoned, err := store.GetRequest("tombstoned")
+	if err != nil {
+		t.Fatalf("Failed to get request: %v", err)
+	}
+	if _, ok := tombstoned.Metadata["tombstone_reason"]; ok || tombstoned.Metadata["title"] != "kept" {
+		t.Errorf("Expected only tombstone fields removed, got %v", tombstoned.Metadata)
+	}
+
+	// Unstarring never restores or removes tombstones
+	if _, err := store.SetStarred("tombstoned", false); err != nil {
+		t.Fatalf("SetStarred failed: %v", err)
+	}
+
+	starred := true
+	filtered, err := store.FilterRequests(FilterOptions{Starred: &starred, Limit: 10})
+	if err != nil {
+		t.Fatalf("FilterRequests failed: %v", err)
+	}
+	if len(filtered) != 1 || filtered[0].ID != "scheduled" || !filtered[0].Starred {
+		t.Errorf("Expected only the scheduled request to be starred, got %+v", filtered)
+	}
+
+	if _, err := store.SetStarred("missing", true); err == nil || err.Error() != "request not found" {
+		t.Errorf("Expected request not found, got %v", err)
+	}
+	if _, err := store.SoftDeleteRequest("plain"); err != nil {
+		t.Fatalf("Failed to soft delete: %v", err)
+	}
+	if _, err := store.SetStarred("plain", true); err == nil {
+		t.Error("Expected starring a soft-deleted request to fail")
+	}
+}
@@ -0,0 +1,74 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"sync"
+
+	"github.com/lib/pq"
+)
+
+// insertTagsQuery adds one tags row per element of the array in a single statement, so the
+// SQL and its placeholders stay the same whatever the number of tags
+const insertTagsQuery = `INSERT INTO tags (request_id, tag) SELECT $1, unnest($2::text[])`
+
+// stmtCache holds statements prepared once on the pool and shared by every Storage scoped
+// from the same New call
+type stmtCache struct {
+	db *sql.DB
+
+	mu    sync.Mutex
+	stmts map[string]*sql.Stmt // Keyed by SQL
+}
+
+func newStmtCache(db *sql.DB) *stmtCache {
+	return &stmtCache{db: db, stmts: make(map[string]*sql.Stmt)}
+}
+
+// prepare returns query prepared for use in tx, preparing it on the pool the first time.
+// A nil cache prepares the statement on tx each time.
+func (c *stmtCache) prepare(tx *sql.Tx, query string) (*sql.Stmt, error) {
+	if c == nil {
+		return tx.Prepare(query)
+	}
+	c.mu.Lock()
+	stmt, ok := c.stmts[query]
+	if !ok {
+		var err error
+		if stmt, err = c.db.Prepare(query); err != nil {
+			c.mu.Unlock()
+			return nil, err
+		}
+		c.stmts[query] = stmt
+	}
+	c.mu.Unlock()
+	return tx.Stmt(stmt), nil
+}
+
+// close closes every cached statement
+func (c *stmtCache) close() {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for query, stmt := range c.stmts {
+		stmt.Close()
+		delete(c.stmts, query)
+	}
+}
+
+// insertTags records tags for requestID in one multi-row insert
+func (s *Storage) insertTags(tx *sql.Tx, requestID string, tags []string) error {
+	if len(tags) == 0 {
+		return nil
+	}
+	stmt, err := s.stmts.prepare(tx, insertTagsQuery)
+	if err != nil {
+		return fmt.Errorf("failed to prepare tag insert: %w", err)
+	}
+	if _, err := stmt.Exec(requestID, pq.Array(tags)); err != nil {
+		return fmt.Errorf("failed to insert tags: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,113 @@
+package storage
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+)
+
+// storedTags reads the tag rows of a request, sorted
+func storedTags(t testing.TB, store *Storage, requestID string) []string {
+	t.Helper()
+	rows, err := store.db.Query("SELECT tag FROM tags WHERE request_id = $1", requestID)
+	if err != nil {
+		t.Fatalf("Failed to query tags: %v", err)
+	}
+	defer rows.Close()
+	tags := []string{}
+	for rows.Next() {
+		var tag string
+		if err := rows.Scan(&tag); err != nil {
+			t.Fatalf("Failed to scan tag: %v", err)
+		}
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+	return tags
+}
+
+func TestTagRowsMatchRequestTags(t *testing.T) {
+	t.Parallel()
+	store, cleanup := setupTestStorage(t)
+	defer cleanup()
+
+	req := &Request{ID: "tagged", CreatedAt: time.Now(), SourceType: "text", Metadata: map[string]interface{}{},
+		Tags: []string{"go", "databases", "o'reilly", "go"}}
+	if err := store.SaveRequest(req); err != nil {
+		t.Fatalf("Failed to save request: %v", err)
+	}
+	if got, want := storedTags(t, store, req.ID), []string{"databases", "go", "go", "o'reilly"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("After save: expected tag rows %v, got %v", want, got)
+	}
+
+	// The cached statement is reused, and an empty list clears the rows
+	for _, tags := range [][]string{{"postgres", "sql"}, {}, {"again"}} {
+		if err := store.UpdateRequestTags(req.ID, tags); err != nil {
+			t.Fatalf("Failed to update tags to %v: %v", tags, err)
+		}
+		want := append([]string{}, tags...)
+		sort.Strings(want)
+		if got := storedTags(t, store, req.ID); !reflect.DeepEqual(got, want) {
+			t.Errorf("After update: expected tag rows %v, got %v", want, got)
+		}
+	}
+
+	if err := store.SaveRequest(&Request{ID: "untagged", CreatedAt: time.Now(), SourceType: "text", Metadata: map[string]interface{}{}}); err != nil {
+		t.Fatalf("Failed to save request without tags: %v", err)
+	}
+	if got := storedTags(t, store, "untagged"); len(got) != 0 {
+		t.Errorf("Expected no tag rows, got %v", got)
+	}
+}
+
+// BenchmarkTagInsert compares the multi-row insert used by SaveRequest with one INSERT per tag
+func BenchmarkTagInsert(b *testing.B) {
+	connStr, dbCleanup := setupTestDB(b, "bench_tags")
+	defer dbCleanup()
+	store, err := New(connStr, nil, 30, 90, 90)
+	if err != nil {
+		b.Fatalf("Failed to create storage: %v", err)
+	}
+	defer store.Close()
+
+	tags := make([]string, 40)
+	for i := range tags {
+		tags[i] = fmt.Sprintf("tag-%d", i)
+	}
+
+	b.Run("SaveRequest", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			req := &Request{ID: fmt.Sprintf("bench-%d", i), CreatedAt: time.Now(), SourceType: "text", Tags: tags, Metadata: map[string]interface{}{}}
+			if err := store.SaveRequest(req); err != nil {
+				b.Fatalf("SaveRequest failed: %v", err)
+			}
+		}
+	})
+	b.Run("RowByRow", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			req := &Request{ID: fmt.Sprintf("bench-rows-%d", i), CreatedAt: time.Now(), SourceType: "text", Metadata: map[string]interface{}{}}
+			if err := store.SaveRequest(req); err != nil {
+				b.Fatalf("SaveRequest failed: %v", err)
+			}
+			tx, err := store.db.Begin()
+			if err != nil {
+				b.Fatal(err)
+			}
+			stmt, err := tx.Prepare("INSERT INTO tags (request_id, tag) VALUES ($1, $2)")
+			if err != nil {
+				b.Fatal(err)
+			}
+			for _, tag := range tags {
+				if _, err := stmt.Exec(req.ID, tag); err != nil {
+					b.Fatal(err)
+				}
+			}
+			stmt.Close()
+			if err := tx.Commit(); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
@@ -0,0 +1,216 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// GlobalStats summarizes the whole corpus for the dashboard landing page.
+// Counts exclude soft-deleted requests.
+type GlobalStats struct {
+	TotalRequests       int            `json:"total_requests"`
+	RequestsBySource    map[string]int `json:"requests_by_source_type"`
+	UniqueTags          int            `json:"unique_tags"`
+	AddedLast24h        int            `json:"added_last_24h"`
+	AddedLast7d         int            `json:"added_last_7d"`
+	Tombstoned          int            `json:"tombstoned"`  // Tombstone date has passed
+	SEOEnabled          int            `json:"seo_enabled"` // Enabled and not tombstoned
+	ScrapeJobsByStatus  map[string]int `json:"scrape_jobs_by_status"`
+	AverageQualityScore *float64       `json:"average_quality_score"` // Null until a request has been analyzed
+	GeneratedAt         time.Time      `json:"generated_at"`
+}
+
+// GetGlobalStats computes corpus-wide figures in four aggregate queries
+func (s *Storage) GetGlobalStats() (*GlobalStats, error) {
+	defer s.timeQuery("GetGlobalStats")()
+	stats := &GlobalStats{
+		RequestsBySource:   make(map[string]int),
+		ScrapeJobsByStatus: make(map[string]int),
+		GeneratedAt:        time.Now().UTC(),
+	}
+
+	// Request totals, recency, tombstone/SEO state and quality in one pass
+	var avgQuality sql.NullFloat64
+	err := s.db.QueryRow(`
+		SELECT
+			COUNT(*),
+			COUNT(*) FILTER (WHERE created_at >= NOW() - INTERVAL '24 hours'),
+			COUNT(*) FILTER (WHERE created_at >= NOW() - INTERVAL '7 days'),
+			COUNT(*) FILTER (WHERE (metadata_json->>'tombstone_datetime')::timestamp <= NOW()),
+			COUNT(*) FILTER (WHERE seo_enabled = true
+				AND (metadata_json->>'tombstone_datetime' IS NULL OR (metadata_json->>'tombstone_datetime')::timestamp > NOW())),
+			AVG((metadata_json->'quality_score'->>'score')::float8)
+				FILTER (WHERE jsonb_typeof(metadata_json->'quality_score'->'score') = 'number')
+		FROM requests
+		WHERE `+notDeletedPredicate+` AND `+s.inNamespace("")+`
+	`).Scan(&stats.TotalRequests, &stats.AddedLast24h, &stats.AddedLast7d, &stats.Tombstoned, &stats.SEOEnabled, &avgQuality)
+	if err != nil {
+		return nil, fmt.Errorf("failed to aggregate requests: %w", err)
+	}
+	if avgQuality.Valid {
+		stats.AverageQualityScore = &avgQuality.Float64
+	}
+
+	rows, err := s.db.Query(`
+		SELECT source_type, COUNT(*)
+		FROM requests
+		WHERE ` + notDeletedPredicate + ` AND ` + s.inNamespace("") + `
+		GROUP BY source_type
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count by source type: %w", err)
+	}
+	if err := scanCounts(rows, stats.RequestsBySource); err != nil {
+		return nil, fmt.Errorf("failed to count by source type: %w", err)
+	}
+
+	err = s.db.QueryRow(`
+		SELECT COUNT(DISTINCT t.tag)
+		FROM tags t
+		INNER JOIN requests r ON r.id = t.request_id
+		WHERE ` + notDeletedPredicateAliased + ` AND ` + s.inNamespace("r") + `
+	`).Scan(&stats.UniqueTags)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count unique tags: %w", err)
+	}
+
+	if err := s.countScrapeJobsByStatus(stats.ScrapeJobsByStatus); err != nil {
+		return nil, err
+	}
+
+	return stats, nil
+}
+
+// ScrapeJobStatusCounts counts scrape jobs per status in a single grouped query.
+// Statuses without jobs are absent from the map.
+func (s *Storage) ScrapeJobStatusCounts() (map[string]int, error) {
+	defer s.timeQuery("ScrapeJobStatusCounts")()
+	counts := make(map[string]int)
+	if err := s.countScrapeJobsByStatus(counts); err != nil {
+		return nil, err
+	}
+	return counts, nil
+}
+
+func (s *Storage) countScrapeJobsByStatus(counts map[string]int) error {
+	rows, err := s.db.Query(`
+		SELECT status, COUNT(*)
+		FROM scrape_jobs
+		WHERE ` + s.inNamespace("") + `
+		GROUP BY status
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to count scrape jobs by status: %w", err)
+	}
+	if err := scanCounts(rows, counts); err != nil {
+		return fmt.Errorf("failed to count scrape jobs by status: %w", err)
+	}
+	return nil
+}
+
+// DomainCount is the number of URL documents, or scrape jobs, from one domain
+type DomainCount struct {
+	Domain string `json:"domain"`
+	Count  int    `json:"count"`
+}
+
+// GetTopDomains returns the limit domains with the most visible (not deleted, not
+// tombstoned) URL documents, largest first, and how many URL documents come from every
+// other domain or have no parseable host. Domains are lowercased with "www." removed.
+func (s *Storage) GetTopDomains(limit int) (top []DomainCount, other int, err error) {
+	defer s.timeQuery("GetTopDomains", "limit", limit)()
+
+	return s.queryTopDomains("source_url", `
+		FROM requests
+		WHERE source_type = 'url'
+		AND `+notDeletedPredicate+`
+		AND `+s.inNamespace("")+`
+		AND (metadata_json->>'tombstone_datetime' IS NULL OR (metadata_json->>'tombstone_datetime')::timestamp > NOW())
+	`, limit)
+}
+
+// GetTopFailedScrapeDomains returns the limit domains with the most scrape jobs that failed
+// since the given time, largest first, and how many failed jobs came from every other domain
+func (s *Storage) GetTopFailedScrapeDomains(limit int, since time.Time) (top []DomainCount, other int, err error) {
+	defer s.timeQuery("GetTopFailedScrapeDomains", "limit", limit)()
+
+	return s.queryTopDomains("url", `
+		FROM scrape_jobs
+		WHERE status = 'failed'
+		AND updated_at >= $2
+		AND `+s.inNamespace("")+`
+	`, limit, since)
+}
+
+// GetTopBelowThresholdDomains returns the limit domains with the most URLs stored below the
+// link score threshold since the given time, largest first, and how many came from every
+// other domain. Those records are tombstoned by design, so tombstoned ones are counted.
+func (s *Storage) GetTopBelowThresholdDomains(limit int, since time.Time) (top []DomainCount, other int, err error) {
+	defer s.timeQuery("GetTopBelowThresholdDomains", "limit", limit)()
+
+	return s.queryTopDomains("source_url", `
+		FROM requests
+		WHERE metadata_json->>'below_threshold' = 'true'
+		AND created_at >= $2
+		AND `+notDeletedPredicate+`
+		AND `+s.inNamespace("")+`
+	`, limit, since)
+}
+
+// queryTopDomains groups the rows selected by from (a FROM ... WHERE clause whose parameters
+// start at $2) by the domain of urlColumn, and returns the limit largest domains and the
+// total of the rest
+func (s *Storage) queryTopDomains(urlColumn, from string, limit int, args ...interface{}) (top []DomainCount, other int, err error) {
+	// The window total is computed before LIMIT, so it covers every domain
+	rows, err := s.db.Query(`
+		WITH domains AS (
+			SELECT
+				COALESCE(regexp_replace(
+					lower(substring(`+urlColumn+` from '^[a-zA-Z][a-zA-Z0-9+.-]*://(?:[^@/]*@)?([^/:?#]+)')),
+					'^www\.', ''), '') AS domain,
+				COUNT(*) AS n
+			`+from+`
+			GROUP BY 1
+		)
+		SELECT domain, n, SUM(n) OVER ()
+		FROM domains
+		ORDER BY domain = '', n DESC, domain
+		LIMIT $1
+	`, append([]interface{}{limit}, args...)...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count by domain: %w", err)
+	}
+	defer rows.Close()
+
+	total, inTop := 0, 0
+	for rows.Next() {
+		var dc DomainCount
+		if err := rows.Scan(&dc.Domain, &dc.Count, &total); err != nil {
+			return nil, 0, fmt.Errorf("failed to scan domain count: %w", err)
+		}
+		if dc.Domain == "" {
+			continue
+		}
+		top = append(top, dc)
+		inTop += dc.Count
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("failed to count by domain: %w", err)
+	}
+	return top, total - inTop, nil
+}
+
+// scanCounts reads (key, count) rows into counts and closes rows
+func scanCounts(rows *sql.Rows, counts map[string]int) error {
+	defer rows.Close()
+	for rows.Next() {
+		var key string
+		var count int
+		if err := rows.Scan(&key, &count); err != nil {
+			return err
+		}
+		counts[key] = count
+	}
+	return rows.Err()
+}
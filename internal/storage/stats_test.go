@@ -0,0 +1,125 @@
+package storage
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestGetGlobalStats(t *testing.T) {
+	t.Parallel()
+	store, cleanup := setupTestStorage(t)
+	defer cleanup()
+
+	now := time.Now().UTC()
+	requests := []*Request{
+		{
+			ID:         "fresh",
+			CreatedAt:  now.Add(-time.Hour),
+			SourceType: "url",
+			Tags:       []string{"go", "news"},
+			SEOEnabled: true,
+			Metadata:   map[string]interface{}{"quality_score": map[string]interface{}{"score": 0.8}},
+		},
+		{
+			ID:         "this-week",
+			CreatedAt:  now.Add(-3 * 24 * time.Hour),
+			SourceType: "url",
+			Tags:       []string{"go"},
+			SEOEnabled: true,
+			Metadata: map[string]interface{}{
+				"quality_score":      map[string]interface{}{"score": 0.4},
+				"tombstone_datetime": now.Add(24 * time.Hour).Format(time.RFC3339), // scheduled, still visible
+			},
+		},
+		{
+			ID:         "old-tombstoned",
+			CreatedAt:  now.Add(-10 * 24 * time.Hour),
+			SourceType: "text",
+			Tags:       []string{"science"},
+			SEOEnabled: true,
+			Metadata:   map[string]interface{}{"tombstone_datetime": now.Add(-time.Hour).Format(time.RFC3339)},
+		},
+		{
+			ID:         "soft-deleted",
+			CreatedAt:  now,
+			SourceType: "url",
+			Tags:       []string{"deleted-only"},
+			SEOEnabled: true,
+			Metadata:   map[string]interface{}{"quality_score": map[string]interface{}{"score": 0.1}},
+		},
+	}
+	for _, req := range requests {
+		req.TextAnalyzerUUID = "ta-" + req.ID
+		if err := store.SaveRequest(req); err != nil {
+			t.Fatalf("Failed to save request %s: %v", req.ID, err)
+		}
+	}
+	if _, err := store.SoftDeleteRequest("soft-deleted"); err != nil {
+		t.Fatalf("Failed to soft delete request: %v", err)
+	}
+
+	for i, status := range []string{"completed", "completed", "failed", "queued"} {
+		job := &ScrapeJob{
+			ID:        "stats-job-" + string(rune('a'+i)),
+			URL:       "https://example.com/stats",
+			Status:    status,
+			CreatedAt: now,
+			UpdatedAt: now,
+		}
+		if err := store.SaveScrapeJob(job); err != nil {
+			t.Fatalf("Failed to save job: %v", err)
+		}
+	}
+
+	stats, err := store.GetGlobalStats()
+	if err != nil {
+		t.Fatalf("GetGlobalStats failed: %v", err)
+	}
+
+	checks := []struct {
+		name      string
+		got, want int
+	}{
+		{"total requests", stats.TotalRequests, 3},
+		{"url requests", stats.RequestsBySource["url"], 2},
+		{"text requests", stats.RequestsBySource["text"], 1},
+		{"unique tags", stats.UniqueTags, 3},
+		{"added last 24h", stats.AddedLast24h, 1},
+		{"added last 7d", stats.AddedLast7d, 2},
+		{"tombstoned", stats.Tombstoned, 1},
+		{"seo enabled", stats.SEOEnabled, 2},
+		{"completed jobs", stats.ScrapeJobsByStatus["completed"], 2},
+		{"failed jobs", stats.ScrapeJobsByStatus["failed"], 1},
+		{"queued jobs", stats.ScrapeJobsByStatus["queued"], 1},
+	}
+	for _, c := range checks {
+		if c.got != c.want {
+			t.Errorf("%s: expected %d, got %d", c.name, c.want, c.got)
+		}
+	}
+
+	if stats.AverageQualityScore == nil || math.Abs(*stats.AverageQualityScore-0.6) > 1e-9 {
+		t.Errorf("Expected average quality score 0.6, got %v", stats.AverageQualityScore)
+	}
+	if time.Since(stats.GeneratedAt) > time.Minute {
+		t.Errorf("Expected a fresh generation timestamp, got %v", stats.GeneratedAt)
+	}
+}
+
+func TestGetGlobalStatsEmpty(t *testing.T) {
+	t.Parallel()
+	store, cleanup := setupTestStorage(t)
+	defer cleanup()
+
+	stats, err := store.GetGlobalStats()
+	if err != nil {
+		t.Fatalf("GetGlobalStats failed: %v", err)
+	}
+	if stats.TotalRequests != 0 || stats.AverageQualityScore != nil {
+		t.Errorf("Expected empty stats, got %+v", stats)
+	}
+	if stats.RequestsBySource == nil || stats.ScrapeJobsByStatus == nil {
+		t.Error("Expected empty maps rather than nil so they encode as {}")
+	}
+}
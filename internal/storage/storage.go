@@ -3,24 +3,29 @@ package storage
 import (
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
-	"math/rand"
 	"strings"
 	"time"
 
-	"github.com/google/uuid"
-	_ "github.com/lib/pq"
+	"github.com/docutag/controller/internal/language"
+	"github.com/docutag/controller/internal/settings"
+	internalslug "github.com/docutag/controller/internal/slug"
+	"github.com/docutag/controller/internal/urlnorm"
+	"github.com/lib/pq"
 )
 
 // Storage handles all database operations
 type Storage struct {
-	db                      *sql.DB
-	tombstoneTags           []string // Tags that trigger auto-tombstone
-	tombstonePeriodLowScore int      // Days until deletion for low-score URLs
-	tombstonePeriodTagBased int      // Days until deletion for tagged content
-	tombstonePeriodManual   int      // Days until deletion for manual tombstones
-	businessMetrics         BusinessMetrics // Optional metrics interface
+	db                 *sql.DB
+	tombstoneTags      []string           // Tags that trigger auto-tombstone
+	settings           *settings.Settings // Runtime-tunable values such as tombstone periods
+	businessMetrics    BusinessMetrics    // Optional metrics interface
+	maxRequestVersions int                // Snapshots kept per request (0 = DefaultMaxRequestVersions)
+	slowQueryThreshold time.Duration      // Calls slower than this are logged (<= 0 disables the log)
+	namespace          string             // Namespace queries are scoped to; "" sees every namespace (see WithNamespace)
+	stmts              *stmtCache         // Prepared statements reused across calls
 }
 
 // BusinessMetrics defines the interface for recording tombstone metrics
@@ -40,12 +45,21 @@ type Request struct {
 	EffectiveDate    time.Time              `json:"effective_date"` // Normalized date from metadata or created_at
 	SourceType       string                 `json:"source_type"`    // "url" or "text"
 	SourceURL        *string                `json:"source_url,omitempty"`
+	NormalizedURL    *string                `json:"normalized_url,omitempty"` // Canonical form of SourceURL used for lookups
 	ScraperUUID      *string                `json:"scraper_uuid,omitempty"`
 	TextAnalyzerUUID string                 `json:"textanalyzer_uuid"`
 	Tags             []string               `json:"tags"`
 	Metadata         map[string]interface{} `json:"metadata,omitempty"`
 	Slug             *string                `json:"slug,omitempty"`     // SEO-friendly URL slug
 	SEOEnabled       bool                   `json:"seo_enabled"`        // Whether the SEO page is enabled for this document
+	DeletedAt        *time.Time             `json:"deleted_at,omitempty"` // Set when soft-deleted; hard-deleted after the grace period
+	ContentHash      string                 `json:"content_hash,omitempty"` // Normalized content hash used for duplicate detection
+	TextHash         string                 `json:"text_hash,omitempty"`    // TextHash of submitted text, used to spot repeated text submissions
+	Language         string                 `json:"language,omitempty"`     // Primary language subtag, or "und" when undetermined
+	Starred          bool                   `json:"starred"`                // Marked as a favourite by an editor
+	CreatedBy        string                 `json:"created_by"`             // Client, API key or worker that created the record
+	Namespace        string                 `json:"namespace"`              // Tenant the record belongs to
+	RowVersion       int64                  `json:"row_version,omitempty"`  // Bumped on every change; only loaded by GetRequest
 }
 
 // extractEffectiveDate extracts the effective date from metadata following a precedence order.
@@ -53,6 +67,7 @@ type Request struct {
 // Precedence: scraper_metadata.publish_date -> scraper_metadata.published_date ->
 //            additional_metadata.publish_date -> additional_metadata.published_date ->
 //            additional_metadata.date -> fallback (created_at)
+// The result is always in UTC; dates without a zone, such as "2006-01-02", are read as UTC.
 func extractEffectiveDate(metadata map[string]interface{}, fallback time.Time) time.Time {
 	// Common date formats to try
 	formats := []string{
@@ -105,32 +120,31 @@ func extractEffectiveDate(metadata map[string]interface{}, fallback time.Time) t
 	for _, path := range paths {
 		if dateStr, ok := getNestedString(path...); ok && dateStr != "" {
 			if t, ok := tryParseDate(dateStr); ok {
-				return t
+				return t.UTC()
 			}
 		}
 	}
 
 	// No valid date found in metadata, use fallback
-	return fallback
+	return fallback.UTC()
+}
+
+// parseEffectiveDate reads an effective_date column value, returning it in UTC
+func parseEffectiveDate(s string) (time.Time, bool) {
+	for _, format := range []string{time.RFC3339Nano, "2006-01-02 15:04:05Z07:00", "2006-01-02 15:04:05"} {
+		if t, err := time.Parse(format, s); err == nil {
+			return t.UTC(), true
+		}
+	}
+	return time.Time{}, false
 }
 
 // New creates a new Storage instance with PostgreSQL and runs migrations
 func New(connStr string, tombstoneTags []string, tombstonePeriodLowScore, tombstonePeriodTagBased, tombstonePeriodManual int) (*Storage, error) {
 	slog.Default().Info("opening postgresql database connection")
-	db, err := sql.Open("postgres", connStr)
+	db, err := OpenPostgres(connStr)
 	if err != nil {
-		return nil, fmt.Errorf("failed to open database: %w", err)
-	}
-
-	// Configure connection pool for PostgreSQL
-	db.SetMaxOpenConns(25)
-	db.SetMaxIdleConns(5)
-	db.SetConnMaxLifetime(5 * time.Minute)
-
-	slog.Default().Info("testing database connection")
-	if err := db.Ping(); err != nil {
-		db.Close()
-		return nil, fmt.Errorf("failed to ping database: %w", err)
+		return nil, err
 	}
 
 	slog.Default().Info("running migrations")
@@ -140,18 +154,24 @@ func New(connStr string, tombstoneTags []string, tombstonePeriodLowScore, tombst
 		return nil, fmt.Errorf("failed to run migrations: %w", err)
 	}
 
+	defaults := settings.Default()
+	defaults.TombstonePeriodLowScore = tombstonePeriodLowScore
+	defaults.TombstonePeriodTagBased = tombstonePeriodTagBased
+	defaults.TombstonePeriodManual = tombstonePeriodManual
+
 	slog.Default().Info("database initialization complete")
 	return &Storage{
-		db:                      db,
-		tombstoneTags:           tombstoneTags,
-		tombstonePeriodLowScore: tombstonePeriodLowScore,
-		tombstonePeriodTagBased: tombstonePeriodTagBased,
-		tombstonePeriodManual:   tombstonePeriodManual,
+		db:                 db,
+		stmts:              newStmtCache(db),
+		tombstoneTags:      tombstoneTags,
+		settings:           settings.New(defaults),
+		slowQueryThreshold: DefaultSlowQueryThreshold,
 	}, nil
 }
 
 // Close closes the database connection
 func (s *Storage) Close() error {
+	s.stmts.close()
 	return s.db.Close()
 }
 
@@ -160,8 +180,10 @@ func (s *Storage) DB() *sql.DB {
 	return s.db
 }
 
-// SaveRequest saves a new request record
+// SaveRequest saves a new request record. A slug another request already has is made unique
+// by appending the request ID, and req.Slug is updated to the slug that was saved.
 func (s *Storage) SaveRequest(req *Request) error {
+	defer s.timeQuery("SaveRequest", "id", req.ID)()
 	tagsJSON, err := json.Marshal(req.Tags)
 	if err != nil {
 		return fmt.Errorf("failed to marshal tags: %w", err)
@@ -181,34 +203,73 @@ func (s *Storage) SaveRequest(req *Request) error {
 		req.EffectiveDate = extractEffectiveDate(req.Metadata, req.CreatedAt)
 	}
 
+	var contentHash, textHash *string
+	if req.ContentHash != "" {
+		contentHash = &req.ContentHash
+	}
+	if req.TextHash != "" {
+		textHash = &req.TextHash
+	}
+
+	// Derive the normalized URL when the caller did not supply one
+	if req.NormalizedURL == nil && req.SourceURL != nil {
+		if normalized, err := urlnorm.Normalize(*req.SourceURL); err == nil {
+			req.NormalizedURL = &normalized
+		}
+	}
+
+	// Detect the language when the caller did not supply one
+	if req.Language == "" {
+		req.Language = language.Resolve(req.Metadata)
+	}
+
+	if req.CreatedBy == "" {
+		req.CreatedBy = CreatedByUnknown
+	}
+	req.Namespace = s.namespaceFor(req.Namespace)
+
+	// A slug generated from a common title can already be taken; retry with the request ID
+	// appended, first shortened and then in full
+	for attempt := 1; ; attempt++ {
+		err = s.insertRequest(req, tagsJSON, metadataJSON, contentHash, textHash)
+		if !slugTaken(err) || attempt > 2 {
+			return err
+		}
+		taken := *req.Slug
+		suffix := strings.ReplaceAll(req.ID, "-", "")
+		if attempt == 1 && len(suffix) > 8 {
+			suffix = suffix[:8]
+		}
+		unique := internalslug.WithSuffix(taken, suffix)
+		req.Slug = &unique
+		slog.Default().Info("slug already taken, retrying with the request ID",
+			"request_id", req.ID,
+			"taken", taken,
+			"slug", unique,
+		)
+	}
+}
+
+// insertRequest makes one attempt at inserting a request and its tags for SaveRequest
+func (s *Storage) insertRequest(req *Request, tagsJSON, metadataJSON []byte, contentHash, textHash *string) error {
 	tx, err := s.db.Begin()
 	if err != nil {
 		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
 	defer tx.Rollback()
 
-	// Insert request record with effective_date, slug, and seo_enabled
+	// Insert request record with effective_date, slug, seo_enabled, content and text hashes, normalized_url, language, provenance and namespace
 	_, err = tx.Exec(`
-		INSERT INTO requests (id, created_at, effective_date, source_type, source_url, scraper_uuid, textanalyzer_uuid, tags_json, metadata_json, slug, seo_enabled)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
-	`, req.ID, req.CreatedAt, req.EffectiveDate, req.SourceType, req.SourceURL, req.ScraperUUID, req.TextAnalyzerUUID, string(tagsJSON), string(metadataJSON), req.Slug, req.SEOEnabled)
+		INSERT INTO requests (id, created_at, effective_date, source_type, source_url, scraper_uuid, textanalyzer_uuid, tags_json, metadata_json, slug, seo_enabled, content_hash, normalized_url, language, starred, created_by, namespace, quality_score, text_hash)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19)
+	`, req.ID, req.CreatedAt, req.EffectiveDate, req.SourceType, req.SourceURL, req.ScraperUUID, req.TextAnalyzerUUID, string(tagsJSON), string(metadataJSON), req.Slug, req.SEOEnabled, contentHash, req.NormalizedURL, req.Language, req.Starred, req.CreatedBy, req.Namespace, extractQualityScore(req.Metadata), textHash)
 	if err != nil {
 		return fmt.Errorf("failed to insert request: %w", err)
 	}
 
 	// Insert individual tags for searching
-	if len(req.Tags) > 0 {
-		stmt, err := tx.Prepare("INSERT INTO tags (request_id, tag) VALUES ($1, $2)")
-		if err != nil {
-			return fmt.Errorf("failed to prepare tag insert: %w", err)
-		}
-		defer stmt.Close()
-
-		for _, tag := range req.Tags {
-			if _, err := stmt.Exec(req.ID, tag); err != nil {
-				return fmt.Errorf("failed to insert tag: %w", err)
-			}
-		}
+	if err := s.insertTags(tx, req.ID, req.Tags); err != nil {
+		return err
 	}
 
 	if err := tx.Commit(); err != nil {
@@ -218,20 +279,27 @@ func (s *Storage) SaveRequest(req *Request) error {
 	return nil
 }
 
-// GetRequest retrieves a request by ID
+// slugTaken reports whether err is the unique slug index rejecting a slug another request has
+func slugTaken(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505" && pqErr.Constraint == "idx_requests_slug"
+}
+
+// GetRequest retrieves a request by ID. Soft-deleted requests are reported as not found.
 func (s *Storage) GetRequest(id string) (*Request, error) {
+	defer s.timeQuery("GetRequest", "id", id)()
 	var req Request
-	var tagsJSON, metadataJSON, effectiveDateStr, slug sql.NullString
+	var tagsJSON, metadataJSON, effectiveDateStr, slug, contentHash, lang sql.NullString
 
 	err := s.db.QueryRow(`
-		SELECT id, created_at, effective_date, source_type, source_url, scraper_uuid, textanalyzer_uuid, tags_json, metadata_json, slug, seo_enabled
+		SELECT id, created_at, effective_date, source_type, source_url, scraper_uuid, textanalyzer_uuid, tags_json, metadata_json, slug, seo_enabled, content_hash, normalized_url, language, starred, created_by, namespace, row_version
 		FROM requests
-		WHERE id = $1
-	`, id).Scan(&req.ID, &req.CreatedAt, &effectiveDateStr, &req.SourceType, &req.SourceURL, &req.ScraperUUID, &req.TextAnalyzerUUID, &tagsJSON, &metadataJSON, &slug, &req.SEOEnabled)
+		WHERE id = $1 AND `+notDeletedPredicate+` AND `+s.inNamespace("")+`
+	`, id).Scan(&req.ID, &req.CreatedAt, &effectiveDateStr, &req.SourceType, &req.SourceURL, &req.ScraperUUID, &req.TextAnalyzerUUID, &tagsJSON, &metadataJSON, &slug, &req.SEOEnabled, &contentHash, &req.NormalizedURL, &lang, &req.Starred, &req.CreatedBy, &req.Namespace, &req.RowVersion)
 
 	// Parse effective_date from string
 	if effectiveDateStr.Valid && effectiveDateStr.String != "" {
-		if parsedDate, err := time.Parse(time.RFC3339, effectiveDateStr.String); err == nil {
+		if parsedDate, ok := parseEffectiveDate(effectiveDateStr.String); ok {
 			req.EffectiveDate = parsedDate
 		}
 	}
@@ -248,6 +316,8 @@ func (s *Storage) GetRequest(id string) (*Request, error) {
 		slugStr := slug.String
 		req.Slug = &slugStr
 	}
+	req.ContentHash = contentHash.String
+	req.Language = lang.String
 
 	// Unmarshal tags
 	if tagsJSON.Valid {
@@ -268,6 +338,7 @@ func (s *Storage) GetRequest(id string) (*Request, error) {
 
 // DeleteRequest deletes a request and all associated tags
 func (s *Storage) DeleteRequest(id string) error {
+	defer s.timeQuery("DeleteRequest", "id", id)()
 	tx, err := s.db.Begin()
 	if err != nil {
 		return fmt.Errorf("failed to begin transaction: %w", err)
@@ -275,13 +346,13 @@ func (s *Storage) DeleteRequest(id string) error {
 	defer tx.Rollback()
 
 	// Delete associated tags first (due to foreign key constraint)
-	_, err = tx.Exec("DELETE FROM tags WHERE request_id = $1", id)
+	_, err = tx.Exec("DELETE FROM tags WHERE request_id IN (SELECT id FROM requests WHERE id = $1 AND "+s.inNamespace("")+")", id)
 	if err != nil {
 		return fmt.Errorf("failed to delete tags: %w", err)
 	}
 
 	// Delete the request
-	result, err := tx.Exec("DELETE FROM requests WHERE id = $1", id)
+	result, err := tx.Exec("DELETE FROM requests WHERE id = $1 AND "+s.inNamespace(""), id)
 	if err != nil {
 		return fmt.Errorf("failed to delete request: %w", err)
 	}
@@ -302,60 +373,141 @@ func (s *Storage) DeleteRequest(id string) error {
 	return nil
 }
 
-// UpdateRequestMetadata updates the metadata field of a request
+// UpdateRequestMetadata updates the metadata field of a request, recording the change as made by the system
 func (s *Storage) UpdateRequestMetadata(id string, metadata map[string]interface{}) error {
+	return s.UpdateRequestMetadataAs(id, metadata, AuditActorSystem)
+}
+
+// UpdateRequestMetadataAs updates the metadata field of a request and records the changed keys
+// in the audit log under actor. A failure to record the change does not fail the update.
+func (s *Storage) UpdateRequestMetadataAs(id string, metadata map[string]interface{}, actor string) error {
+	defer s.timeQuery("UpdateRequestMetadata", "id", id)()
 	metadataJSON, err := json.Marshal(metadata)
 	if err != nil {
 		return fmt.Errorf("failed to marshal metadata: %w", err)
 	}
 
-	result, err := s.db.Exec(`
-		UPDATE requests
-		SET metadata_json = $1
-		WHERE id = $2
-	`, string(metadataJSON), id)
-	if err != nil {
-		return fmt.Errorf("failed to update request metadata: %w", err)
-	}
-
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
-		return fmt.Errorf("failed to get rows affected: %w", err)
-	}
+	return s.inTx("UpdateRequestMetadata", func(tx *sql.Tx) error {
+		var before sql.NullString
+		err := tx.QueryRow(`
+			SELECT metadata_json FROM requests
+			WHERE id = $1 AND `+s.inNamespace("")+`
+			FOR UPDATE
+		`, id).Scan(&before)
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("request not found")
+		}
+		if err != nil {
+			return fmt.Errorf("failed to update request metadata: %w", err)
+		}
 
-	if rowsAffected == 0 {
-		return fmt.Errorf("request not found")
-	}
+		if _, err := tx.Exec(`UPDATE requests SET metadata_json = $1, quality_score = $3, row_version = row_version + 1 WHERE id = $2`, string(metadataJSON), id, extractQualityScore(metadata)); err != nil {
+			return fmt.Errorf("failed to update request metadata: %w", err)
+		}
 
-	return nil
+		recordMetadataChangeTx(tx, id, actor, before.String, string(metadataJSON))
+		return nil
+	})
 }
 
 // SearchByTags searches for requests by tags with fuzzy matching
 func (s *Storage) SearchByTags(searchTags []string, fuzzy bool) ([]string, error) {
+	defer s.timeQuery("SearchByTags", "tags", len(searchTags), "fuzzy", fuzzy)()
 	if len(searchTags) == 0 {
 		return []string{}, nil
 	}
 
-	var conditions []string
+	query, args := s.searchByTagsQuery(searchTags, fuzzy)
+	return s.searchTagIDs(query, args)
+}
+
+// SearchByAllTags is SearchByTags for requests that match every one of searchTags rather than any
+func (s *Storage) SearchByAllTags(searchTags []string, fuzzy bool) ([]string, error) {
+	defer s.timeQuery("SearchByAllTags", "tags", len(searchTags), "fuzzy", fuzzy)()
+	if len(searchTags) == 0 {
+		return []string{}, nil
+	}
+
+	query, args := s.searchByAllTagsQuery(searchTags, fuzzy)
+	return s.searchTagIDs(query, args)
+}
+
+// searchByAllTagsQuery builds the SQL and arguments SearchByAllTags runs
+func (s *Storage) searchByAllTagsQuery(searchTags []string, fuzzy bool) (string, []interface{}) {
 	var args []interface{}
+	query := fmt.Sprintf(`
+		SELECT r.id
+		FROM requests r
+		WHERE %s AND %s AND %s
+		ORDER BY r.id
+	`, allTagsPredicate(searchTags, fuzzy, &args), notDeletedPredicateAliased, s.inNamespace("r"))
+	return query, args
+}
+
+// SearchRequestsByTags returns a page of the requests SearchByTags, or SearchByAllTags when
+// matchAll is set, finds, newest effective date first, with the number of matches in all. It
+// joins the same IDs back to their rows, so both shapes of a search agree on what matches.
+func (s *Storage) SearchRequestsByTags(searchTags []string, fuzzy, matchAll bool, limit, offset int) ([]*Request, int, error) {
+	defer s.timeQuery("SearchRequestsByTags", "tags", len(searchTags), "fuzzy", fuzzy, "limit", limit, "offset", offset)()
+	if len(searchTags) == 0 {
+		return []*Request{}, 0, nil
+	}
+
+	matched, args := s.searchByTagsQuery(searchTags, fuzzy)
+	if matchAll {
+		matched, args = s.searchByAllTagsQuery(searchTags, fuzzy)
+	}
+
+	var total int
+	if err := s.db.QueryRow("SELECT COUNT(*) FROM ("+matched+") matched", args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count tag matches: %w", err)
+	}
 
+	query := `
+		SELECT id, created_at, effective_date, source_type, source_url, scraper_uuid, textanalyzer_uuid, tags_json, metadata_json, slug, seo_enabled, language, starred, created_by, namespace
+		FROM requests
+		WHERE id IN (` + matched + `)
+		ORDER BY effective_date DESC, id`
+	if limit > 0 {
+		query += fmt.Sprintf(" LIMIT $%d", len(args)+1)
+		args = append(args, limit)
+	}
+	if offset > 0 {
+		query += fmt.Sprintf(" OFFSET $%d", len(args)+1)
+		args = append(args, offset)
+	}
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to search requests: %w", err)
+	}
+	defer rows.Close()
+
+	requests, err := scanRequestList(rows)
+	if err != nil {
+		return nil, 0, err
+	}
+	return requests, total, nil
+}
+
+// allTagsPredicate requires request r to have a tag matching each of searchTags, appending
+// the tags to args
+func allTagsPredicate(searchTags []string, fuzzy bool, args *[]interface{}) string {
+	conditions := make([]string, 0, len(searchTags))
 	for _, tag := range searchTags {
 		if fuzzy {
-			conditions = append(conditions, fmt.Sprintf("tag LIKE $%d", len(args)+1))
-			args = append(args, "%"+tag+"%")
+			conditions = append(conditions, fmt.Sprintf("EXISTS (SELECT 1 FROM tags t WHERE t.request_id = r.id AND t.tag LIKE $%d)", len(*args)+1))
+			*args = append(*args, "%"+tag+"%")
 		} else {
-			conditions = append(conditions, fmt.Sprintf("tag = $%d", len(args)+1))
-			args = append(args, tag)
+			conditions = append(conditions, fmt.Sprintf("EXISTS (SELECT 1 FROM tags t WHERE t.request_id = r.id AND t.tag = $%d)", len(*args)+1))
+			*args = append(*args, tag)
 		}
 	}
+	return "(" + strings.Join(conditions, " AND ") + ")"
+}
 
-	query := fmt.Sprintf(`
-		SELECT DISTINCT request_id
-		FROM tags
-		WHERE %s
-		ORDER BY request_id
-	`, strings.Join(conditions, " OR "))
-
+// searchTagIDs runs a tag search query that selects request IDs
+func (s *Storage) searchTagIDs(query string, args []interface{}) ([]string, error) {
 	rows, err := s.db.Query(query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to search tags: %w", err)
@@ -378,24 +530,125 @@ func (s *Storage) SearchByTags(searchTags []string, fuzzy bool) ([]string, error
 	return requestIDs, nil
 }
 
+// searchByTagsQuery builds the SQL and arguments SearchByTags runs
+func (s *Storage) searchByTagsQuery(searchTags []string, fuzzy bool) (string, []interface{}) {
+	var conditions []string
+	var args []interface{}
+
+	for _, tag := range searchTags {
+		if fuzzy {
+			conditions = append(conditions, fmt.Sprintf("t.tag LIKE $%d", len(args)+1))
+			args = append(args, "%"+tag+"%")
+		} else {
+			conditions = append(conditions, fmt.Sprintf("t.tag = $%d", len(args)+1))
+			args = append(args, tag)
+		}
+	}
+
+	query := fmt.Sprintf(`
+		SELECT DISTINCT t.request_id
+		FROM tags t
+		INNER JOIN requests r ON r.id = t.request_id
+		WHERE (%s) AND %s AND %s
+		ORDER BY t.request_id
+	`, strings.Join(conditions, " OR "), notDeletedPredicateAliased, s.inNamespace("r"))
+	return query, args
+}
+
 // FilterOptions contains all filter parameters for requests
 type FilterOptions struct {
-	Tags       []string
-	Fuzzy      bool
-	DateStart  *time.Time
-	DateEnd    *time.Time
-	SourceType *string
-	Limit      int
-	Offset     int
+	Tags             []string
+	Fuzzy            bool
+	DateStart        *time.Time
+	DateEnd          *time.Time
+	DateEndExclusive bool // Excludes DateEnd itself, as for a whole-day bound ending at the next midnight
+	SourceType       *string
+	Language         *string // Primary language subtag, or "und" for undetermined
+	Starred          *bool
+	CreatedBy        *string
+	MatchAll         bool // Requests must match every tag in Tags rather than any
+	Limit            int
+	Offset           int
 }
 
 // FilterRequests filters requests based on multiple criteria
 func (s *Storage) FilterRequests(opts FilterOptions) ([]*Request, error) {
+	defer s.timeQuery("FilterRequests", "tags", len(opts.Tags), "fuzzy", opts.Fuzzy, "limit", opts.Limit, "offset", opts.Offset)()
+	query, args := s.filterRequestsQuery(opts)
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to filter requests: %w", err)
+	}
+	defer rows.Close()
+
+	return scanRequestList(rows)
+}
+
+// scanRequestList reads the rows of a query selecting the columns FilterRequests lists
+func scanRequestList(rows *sql.Rows) ([]*Request, error) {
+	var requests []*Request
+	for rows.Next() {
+		var req Request
+		var tagsJSON, metadataJSON, effectiveDateStr, lang sql.NullString
+
+		err := rows.Scan(&req.ID, &req.CreatedAt, &effectiveDateStr, &req.SourceType, &req.SourceURL, &req.ScraperUUID, &req.TextAnalyzerUUID, &tagsJSON, &metadataJSON, &req.Slug, &req.SEOEnabled, &lang, &req.Starred, &req.CreatedBy, &req.Namespace)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan request: %w", err)
+		}
+		req.Language = lang.String
+
+		// Parse effective_date from string
+		if effectiveDateStr.Valid && effectiveDateStr.String != "" {
+			if parsedDate, ok := parseEffectiveDate(effectiveDateStr.String); ok {
+				req.EffectiveDate = parsedDate
+			}
+		}
+
+		if tagsJSON.Valid {
+			if err := json.Unmarshal([]byte(tagsJSON.String), &req.Tags); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal tags: %w", err)
+			}
+		}
+
+		if metadataJSON.Valid && metadataJSON.String != "" {
+			if err := json.Unmarshal([]byte(metadataJSON.String), &req.Metadata); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal metadata: %w", err)
+			}
+		}
+
+		requests = append(requests, &req)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+
+	return requests, nil
+}
+
+// CountFilteredRequests counts every request FilterRequests would return for opts, ignoring
+// its limit and offset
+func (s *Storage) CountFilteredRequests(opts FilterOptions) (int, error) {
+	defer s.timeQuery("CountFilteredRequests", "tags", len(opts.Tags), "fuzzy", opts.Fuzzy)()
+	opts.Limit, opts.Offset = 0, 0
+	query, args := s.filterRequestsQuery(opts)
+
+	var total int
+	if err := s.db.QueryRow("SELECT COUNT(*) FROM ("+query+") matched", args...).Scan(&total); err != nil {
+		return 0, fmt.Errorf("failed to count filtered requests: %w", err)
+	}
+	return total, nil
+}
+
+// filterRequestsQuery builds the SQL and arguments FilterRequests runs
+func (s *Storage) filterRequestsQuery(opts FilterOptions) (string, []interface{}) {
 	// Build the WHERE clause dynamically
 	var whereClauses []string
 	var args []interface{}
 
-	// Always filter out tombstoned content and SEO-disabled content
+	// Always filter out deleted, tombstoned and SEO-disabled content
+	whereClauses = append(whereClauses, notDeletedPredicateAliased, s.inNamespace("r"))
 	whereClauses = append(whereClauses, "r.seo_enabled = true")
 	whereClauses = append(whereClauses, "(r.metadata_json->>'tombstone_datetime' IS NULL OR (r.metadata_json->>'tombstone_datetime')::timestamp > NOW())")
 
@@ -405,7 +658,11 @@ func (s *Storage) FilterRequests(opts FilterOptions) ([]*Request, error) {
 		args = append(args, opts.DateStart)
 	}
 	if opts.DateEnd != nil {
-		whereClauses = append(whereClauses, fmt.Sprintf("r.effective_date <= $%d", len(args)+1))
+		op := "<="
+		if opts.DateEndExclusive {
+			op = "<"
+		}
+		whereClauses = append(whereClauses, fmt.Sprintf("r.effective_date %s $%d", op, len(args)+1))
 		args = append(args, opts.DateEnd)
 	}
 
@@ -415,9 +672,34 @@ func (s *Storage) FilterRequests(opts FilterOptions) ([]*Request, error) {
 		args = append(args, *opts.SourceType)
 	}
 
+	// Language filter
+	if opts.Language != nil {
+		whereClauses = append(whereClauses, fmt.Sprintf("r.language = $%d", len(args)+1))
+		args = append(args, *opts.Language)
+	}
+
+	// Starred filter
+	if opts.Starred != nil {
+		whereClauses = append(whereClauses, fmt.Sprintf("r.starred = $%d", len(args)+1))
+		args = append(args, *opts.Starred)
+	}
+
+	// Provenance filter
+	if opts.CreatedBy != nil {
+		whereClauses = append(whereClauses, fmt.Sprintf("r.created_by = $%d", len(args)+1))
+		args = append(args, *opts.CreatedBy)
+	}
+
 	// Build base query
 	var query string
-	if len(opts.Tags) > 0 {
+	if len(opts.Tags) > 0 && opts.MatchAll {
+		// Every tag must match, so test each with EXISTS instead of joining
+		whereClauses = append(whereClauses, allTagsPredicate(opts.Tags, opts.Fuzzy, &args))
+		query = `
+			SELECT id, created_at, effective_date, source_type, source_url, scraper_uuid, textanalyzer_uuid, tags_json, metadata_json, slug, seo_enabled, language, starred, created_by, namespace
+			FROM requests r
+			WHERE ` + strings.Join(whereClauses, " AND ")
+	} else if len(opts.Tags) > 0 {
 		// If tags are specified, join with tags table
 		var tagConditions []string
 		for _, tag := range opts.Tags {
@@ -432,7 +714,7 @@ func (s *Storage) FilterRequests(opts FilterOptions) ([]*Request, error) {
 
 		// Use INNER JOIN to filter by tags
 		query = `
-			SELECT DISTINCT r.id, r.created_at, r.effective_date, r.source_type, r.source_url, r.scraper_uuid, r.textanalyzer_uuid, r.tags_json, r.metadata_json, r.slug, r.seo_enabled
+			SELECT DISTINCT r.id, r.created_at, r.effective_date, r.source_type, r.source_url, r.scraper_uuid, r.textanalyzer_uuid, r.tags_json, r.metadata_json, r.slug, r.seo_enabled, r.language, r.starred, r.created_by, r.namespace
 			FROM requests r
 			INNER JOIN tags t ON r.id = t.request_id
 			WHERE (` + strings.Join(tagConditions, " OR ") + `)`
@@ -444,7 +726,7 @@ func (s *Storage) FilterRequests(opts FilterOptions) ([]*Request, error) {
 	} else {
 		// No tags specified, query requests table directly
 		query = `
-			SELECT id, created_at, effective_date, source_type, source_url, scraper_uuid, textanalyzer_uuid, tags_json, metadata_json, slug, seo_enabled
+			SELECT id, created_at, effective_date, source_type, source_url, scraper_uuid, textanalyzer_uuid, tags_json, metadata_json, slug, seo_enabled, language, starred, created_by, namespace
 			FROM requests r`
 
 		if len(whereClauses) > 0 {
@@ -462,67 +744,18 @@ func (s *Storage) FilterRequests(opts FilterOptions) ([]*Request, error) {
 		query += fmt.Sprintf(" OFFSET $%d", len(args)+1)
 		args = append(args, opts.Offset)
 	}
-
-	rows, err := s.db.Query(query, args...)
-	if err != nil {
-		return nil, fmt.Errorf("failed to filter requests: %w", err)
-	}
-	defer rows.Close()
-
-	var requests []*Request
-	for rows.Next() {
-		var req Request
-		var tagsJSON, metadataJSON, effectiveDateStr sql.NullString
-
-		err := rows.Scan(&req.ID, &req.CreatedAt, &effectiveDateStr, &req.SourceType, &req.SourceURL, &req.ScraperUUID, &req.TextAnalyzerUUID, &tagsJSON, &metadataJSON, &req.Slug, &req.SEOEnabled)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan request: %w", err)
-		}
-
-		// Parse effective_date from string
-		if effectiveDateStr.Valid && effectiveDateStr.String != "" {
-			if parsedDate, err := time.Parse(time.RFC3339, effectiveDateStr.String); err == nil {
-				req.EffectiveDate = parsedDate
-			} else {
-				// If RFC3339 fails, try other formats
-				formats := []string{time.RFC3339Nano, "2006-01-02 15:04:05"}
-				for _, format := range formats {
-					if parsedDate, err := time.Parse(format, effectiveDateStr.String); err == nil {
-						req.EffectiveDate = parsedDate
-						break
-					}
-				}
-			}
-		}
-
-		if tagsJSON.Valid {
-			if err := json.Unmarshal([]byte(tagsJSON.String), &req.Tags); err != nil {
-				return nil, fmt.Errorf("failed to unmarshal tags: %w", err)
-			}
-		}
-
-		if metadataJSON.Valid && metadataJSON.String != "" {
-			if err := json.Unmarshal([]byte(metadataJSON.String), &req.Metadata); err != nil {
-				return nil, fmt.Errorf("failed to unmarshal metadata: %w", err)
-			}
-		}
-
-		requests = append(requests, &req)
-	}
-
-	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("error iterating rows: %w", err)
-	}
-
-	return requests, nil
+	return query, args
 }
 
 // ListRequests returns all requests ordered by creation time
 func (s *Storage) ListRequests(limit, offset int) ([]*Request, error) {
+	defer s.timeQuery("ListRequests", "limit", limit, "offset", offset)()
 	query := `
-		SELECT id, created_at, effective_date, source_type, source_url, scraper_uuid, textanalyzer_uuid, tags_json, metadata_json, slug, seo_enabled
+		SELECT id, created_at, effective_date, source_type, source_url, scraper_uuid, textanalyzer_uuid, tags_json, metadata_json, slug, seo_enabled, language, starred, created_by, namespace
 		FROM requests
 		WHERE seo_enabled = true
+		  AND `+notDeletedPredicate+`
+		  AND `+s.inNamespace("")+`
 		  AND (
 		    metadata_json->>'tombstone_datetime' IS NULL
 		    OR (metadata_json->>'tombstone_datetime')::timestamp > NOW()
@@ -540,16 +773,17 @@ func (s *Storage) ListRequests(limit, offset int) ([]*Request, error) {
 	var requests []*Request
 	for rows.Next() {
 		var req Request
-		var tagsJSON, metadataJSON, effectiveDateStr sql.NullString
+		var tagsJSON, metadataJSON, effectiveDateStr, lang sql.NullString
 
-		err := rows.Scan(&req.ID, &req.CreatedAt, &effectiveDateStr, &req.SourceType, &req.SourceURL, &req.ScraperUUID, &req.TextAnalyzerUUID, &tagsJSON, &metadataJSON, &req.Slug, &req.SEOEnabled)
+		err := rows.Scan(&req.ID, &req.CreatedAt, &effectiveDateStr, &req.SourceType, &req.SourceURL, &req.ScraperUUID, &req.TextAnalyzerUUID, &tagsJSON, &metadataJSON, &req.Slug, &req.SEOEnabled, &lang, &req.Starred, &req.CreatedBy, &req.Namespace)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan request: %w", err)
 		}
+		req.Language = lang.String
 
 		// Parse effective_date from string
 		if effectiveDateStr.Valid && effectiveDateStr.String != "" {
-			if parsedDate, err := time.Parse(time.RFC3339, effectiveDateStr.String); err == nil {
+			if parsedDate, ok := parseEffectiveDate(effectiveDateStr.String); ok {
 				req.EffectiveDate = parsedDate
 			}
 		}
@@ -584,8 +818,9 @@ func (s *Storage) ListRequests(limit, offset int) ([]*Request, error) {
 //
 // Returns nil if no requests exist in the database.
 func (s *Storage) GetTimelineExtents() (*time.Time, error) {
+	defer s.timeQuery("GetTimelineExtents")()
 	// Simple query using the pre-normalized effective_date column
-	query := `SELECT MIN(effective_date) FROM requests`
+	query := `SELECT MIN(effective_date) FROM requests WHERE ` + notDeletedPredicate + ` AND ` + s.inNamespace("")
 
 	var earliestDateStr sql.NullString
 	err := s.db.QueryRow(query).Scan(&earliestDateStr)
@@ -599,199 +834,54 @@ func (s *Storage) GetTimelineExtents() (*time.Time, error) {
 	}
 
 	// Parse the date string
-	parsedDate, err := time.Parse(time.RFC3339, earliestDateStr.String)
-	if err != nil {
-		return nil, fmt.Errorf("failed to parse earliest date: %w", err)
+	parsedDate, ok := parseEffectiveDate(earliestDateStr.String)
+	if !ok {
+		return nil, fmt.Errorf("failed to parse earliest date %q", earliestDateStr.String)
 	}
 
 	return &parsedDate, nil
 }
 
-// GenerateMockData generates 6 months of realistic historical data for testing
-func (s *Storage) GenerateMockData() error {
-	slog.Default().Info("generating mock historical data")
-
-	// Check if we already have data
-	var count int
-	err := s.db.QueryRow("SELECT COUNT(*) FROM requests").Scan(&count)
-	if err != nil {
-		return fmt.Errorf("failed to count existing requests: %w", err)
-	}
-
-	if count > 0 {
-		slog.Default().Info("database already contains requests, skipping mock data generation", "count", count)
-		return nil
-	}
-
-	// Sample data for generating realistic entries
-	sampleURLs := []string{
-		"https://example.com/article/technology-trends-2024",
-		"https://news.example.org/science/quantum-computing-breakthrough",
-		"https://blog.example.net/programming/golang-best-practices",
-		"https://research.example.edu/papers/artificial-intelligence",
-		"https://docs.example.io/guides/docker-deployment",
-		"https://medium.example.com/data-science/machine-learning-basics",
-		"https://github.example.dev/projects/open-source-tools",
-		"https://stackoverflow.example.com/questions/database-optimization",
-		"https://arxiv.example.org/papers/distributed-systems",
-		"https://dev.example.to/tutorials/kubernetes-intro",
-	}
-
-	sampleTags := [][]string{
-		{"technology", "trends", "future"},
-		{"science", "quantum", "research"},
-		{"programming", "golang", "best-practices"},
-		{"ai", "machine-learning", "research"},
-		{"devops", "docker", "deployment"},
-		{"data-science", "ml", "tutorial"},
-		{"open-source", "tools", "development"},
-		{"database", "optimization", "performance"},
-		{"distributed-systems", "architecture", "scalability"},
-		{"kubernetes", "containers", "cloud"},
-	}
-
-	sampleTitles := []string{
-		"Technology Trends to Watch in 2024",
-		"Breakthrough in Quantum Computing Research",
-		"Go Programming Best Practices",
-		"Advances in Artificial Intelligence",
-		"Docker Deployment Strategies",
-		"Machine Learning Fundamentals",
-		"Top Open Source Development Tools",
-		"Database Optimization Techniques",
-		"Distributed Systems Architecture",
-		"Getting Started with Kubernetes",
-	}
-
-	sampleAuthors := []string{
-		"Dr. Jane Smith",
-		"Prof. John Doe",
-		"Alice Johnson",
-		"Bob Wilson",
-		"Carol Martinez",
-		"David Chen",
-		"Emma Brown",
-		"Frank Taylor",
-		"Grace Lee",
-		"Henry Anderson",
-	}
-
-	// Generate 600 mock requests spanning 6 months (180 days)
-	// This averages to ~3.3 documents per day
-	now := time.Now()
-	mockCount := 600
-	daysToGenerate := 180.0
-	rand.Seed(now.UnixNano())
-
-	for i := 0; i < mockCount; i++ {
-		// Random timestamp within the last 6 months (180 days)
-		daysAgo := rand.Float64() * daysToGenerate
-		hoursAgo := daysAgo * 24
-		createdAt := now.Add(-time.Duration(hoursAgo) * time.Hour)
-
-		// Randomly choose between URL scrape (70%) and text ingestion (30%)
-		isURL := rand.Float64() < 0.7
-		idx := rand.Intn(len(sampleURLs))
-
-		var sourceType string
-		var sourceURL *string
-		var scraperUUID *string
-
-		if isURL {
-			sourceType = "url"
-			url := sampleURLs[idx]
-			sourceURL = &url
-			scraperUUIDStr := uuid.New().String()
-			scraperUUID = &scraperUUIDStr
-		} else {
-			sourceType = "text"
-		}
-
-		// Generate metadata with varying quality scores and occasional tombstones
-		metadata := make(map[string]interface{})
-
-		// Link score (quality): higher quality more likely
-		qualityScore := 0.3 + rand.Float64()*0.7 // Range 0.3-1.0
-
-		metadata["link_score"] = map[string]interface{}{
-			"score": qualityScore,
-		}
-
-		// Add scraper metadata for URL sources
-		if isURL {
-			scraperMetadata := map[string]interface{}{
-				"title":        sampleTitles[idx],
-				"author":       sampleAuthors[rand.Intn(len(sampleAuthors))],
-				"publish_date": createdAt.Format(time.RFC3339),
-			}
-
-			// 30% chance of having images
-			if rand.Float64() < 0.3 {
-				scraperMetadata["images"] = []map[string]interface{}{
-					{
-						"url":      fmt.Sprintf("https://example.com/images/%s.jpg", uuid.New().String()[:8]),
-						"alt_text": sampleTitles[idx],
-					},
-				}
-			}
-
-			metadata["scraper_metadata"] = scraperMetadata
-		}
-
-		// 15% chance of being tombstoned
-		if rand.Float64() < 0.15 {
-			tombstoneTime := createdAt.Add(time.Duration(rand.Intn(72)) * time.Hour) // Tombstoned 0-3 days after creation
-			metadata["tombstone_datetime"] = tombstoneTime.Format(time.RFC3339)
-		}
-
-		// Generate slug for URL-based requests
-		var slug *string
-		if isURL {
-			// Use title as slug base
-			slugBase := sampleTitles[idx]
-			// Simple slug generation (lowercase, replace spaces with hyphens, remove special chars)
-			generatedSlug := strings.ToLower(slugBase)
-			generatedSlug = strings.ReplaceAll(generatedSlug, " ", "-")
-			// Add random suffix to ensure uniqueness
-			generatedSlug = fmt.Sprintf("%s-%d", generatedSlug, rand.Intn(10000))
-			slug = &generatedSlug
-		}
+// UpdateSEOEnabled updates the SEO enabled status of a request
+func (s *Storage) UpdateSEOEnabled(id string, enabled bool) error {
+	_, err := s.UpdateSEOEnabledIfVersion(id, enabled, 0)
+	return err
+}
 
-		// SEO enabled by default (90% of documents)
-		seoEnabled := rand.Float64() < 0.9
-
-		// Create request
-		req := &Request{
-			ID:               uuid.New().String(),
-			CreatedAt:        createdAt,
-			SourceType:       sourceType,
-			SourceURL:        sourceURL,
-			ScraperUUID:      scraperUUID,
-			TextAnalyzerUUID: uuid.New().String(),
-			Tags:             sampleTags[idx],
-			Metadata:         metadata,
-			Slug:             slug,
-			SEOEnabled:       seoEnabled,
+// UpdateSEOEnabledIfVersion is UpdateSEOEnabled guarded by the request's row version, like
+// UpdateRequestTagsIfVersion
+func (s *Storage) UpdateSEOEnabledIfVersion(id string, enabled bool, version int64) (int64, error) {
+	defer s.timeQuery("UpdateSEOEnabled", "id", id)()
+	var newVersion int64
+	err := s.inTx("UpdateSEOEnabled", func(tx *sql.Tx) error {
+		err := tx.QueryRow(`
+			UPDATE requests
+			SET seo_enabled = $1, row_version = row_version + 1
+			WHERE id = $2 AND ($3::bigint = 0 OR row_version = $3) AND `+s.inNamespace("")+`
+			RETURNING row_version
+		`, enabled, id, version).Scan(&newVersion)
+		if err == sql.ErrNoRows {
+			newVersion, err = s.versionConflict(tx, id)
+			return err
 		}
-
-		if err := s.SaveRequest(req); err != nil {
-			return fmt.Errorf("failed to save mock request: %w", err)
+		if err != nil {
+			return fmt.Errorf("failed to update SEO enabled status: %w", err)
 		}
-	}
-
-	slog.Default().Info("generated mock requests", "count", mockCount, "days", int(daysToGenerate), "period", "6 months")
-	return nil
+		return nil
+	})
+	return newVersion, err
 }
 
-// UpdateSEOEnabled updates the SEO enabled status of a request
-func (s *Storage) UpdateSEOEnabled(id string, enabled bool) error {
+// UpdateRequestLanguage sets the detected language of a request
+func (s *Storage) UpdateRequestLanguage(id, lang string) error {
+	defer s.timeQuery("UpdateRequestLanguage", "id", id)()
 	result, err := s.db.Exec(`
 		UPDATE requests
-		SET seo_enabled = $1
+		SET language = $1, row_version = row_version + 1
 		WHERE id = $2
-	`, enabled, id)
+	`, lang, id)
 	if err != nil {
-		return fmt.Errorf("failed to update SEO enabled status: %w", err)
+		return fmt.Errorf("failed to update request language: %w", err)
 	}
 
 	rowsAffected, err := result.RowsAffected()
@@ -808,27 +898,29 @@ func (s *Storage) UpdateSEOEnabled(id string, enabled bool) error {
 
 // GetRequestBySlug retrieves a request by its slug
 func (s *Storage) GetRequestBySlug(slug string) (*Request, error) {
+	defer s.timeQuery("GetRequestBySlug", "slug", slug)()
 	query := `
-		SELECT id, created_at, effective_date, source_type, source_url, scraper_uuid, textanalyzer_uuid, tags_json, metadata_json, slug, seo_enabled
+		SELECT id, created_at, effective_date, source_type, source_url, scraper_uuid, textanalyzer_uuid, tags_json, metadata_json, slug, seo_enabled, language, starred, created_by, namespace
 		FROM requests
-		WHERE slug = $1
+		WHERE slug = $1 AND `+notDeletedPredicate+` AND `+s.inNamespace("")+`
 		LIMIT 1
 	`
 
 	var req Request
-	var tagsJSON, metadataJSON, effectiveDateStr sql.NullString
+	var tagsJSON, metadataJSON, effectiveDateStr, lang sql.NullString
 
-	err := s.db.QueryRow(query, slug).Scan(&req.ID, &req.CreatedAt, &effectiveDateStr, &req.SourceType, &req.SourceURL, &req.ScraperUUID, &req.TextAnalyzerUUID, &tagsJSON, &metadataJSON, &req.Slug, &req.SEOEnabled)
+	err := s.db.QueryRow(query, slug).Scan(&req.ID, &req.CreatedAt, &effectiveDateStr, &req.SourceType, &req.SourceURL, &req.ScraperUUID, &req.TextAnalyzerUUID, &tagsJSON, &metadataJSON, &req.Slug, &req.SEOEnabled, &lang, &req.Starred, &req.CreatedBy, &req.Namespace)
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to query request by slug: %w", err)
 	}
+	req.Language = lang.String
 
 	// Parse effective_date from string
 	if effectiveDateStr.Valid && effectiveDateStr.String != "" {
-		if parsedDate, err := time.Parse(time.RFC3339, effectiveDateStr.String); err == nil {
+		if parsedDate, ok := parseEffectiveDate(effectiveDateStr.String); ok {
 			req.EffectiveDate = parsedDate
 		}
 	}
@@ -850,47 +942,68 @@ func (s *Storage) GetRequestBySlug(slug string) (*Request, error) {
 
 // UpdateRequestTags updates the tags for a specific request
 func (s *Storage) UpdateRequestTags(id string, tags []string) error {
+	_, err := s.UpdateRequestTagsIfVersion(id, tags, 0)
+	return err
+}
+
+// UpdateRequestTagsIfVersion replaces a request's tags only while its row version is still
+// version, and returns the new version. Version 0 updates unconditionally. When the request
+// has changed since, it returns the current version and ErrRequestVersionMismatch.
+func (s *Storage) UpdateRequestTagsIfVersion(id string, tags []string, version int64) (int64, error) {
+	defer s.timeQuery("UpdateRequestTags", "id", id, "tags", len(tags))()
 	// Marshal tags to JSON
 	tagsJSON, err := json.Marshal(tags)
 	if err != nil {
-		return fmt.Errorf("failed to marshal tags: %w", err)
+		return 0, fmt.Errorf("failed to marshal tags: %w", err)
 	}
 
 	// Begin transaction to ensure atomicity
 	tx, err := s.db.Begin()
 	if err != nil {
-		return fmt.Errorf("failed to begin transaction: %w", err)
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
 	}
 	defer tx.Rollback()
 
 	// Update tags in database
-	result, err := tx.Exec("UPDATE requests SET tags_json = $1 WHERE id = $2", string(tagsJSON), id)
-	if err != nil {
-		return fmt.Errorf("failed to update tags: %w", err)
+	var newVersion int64
+	err = tx.QueryRow(`
+		UPDATE requests SET tags_json = $1, row_version = row_version + 1
+		WHERE id = $2 AND ($3::bigint = 0 OR row_version = $3) AND `+s.inNamespace("")+`
+		RETURNING row_version
+	`, string(tagsJSON), id, version).Scan(&newVersion)
+	if err == sql.ErrNoRows {
+		return s.versionConflict(tx, id)
 	}
-
-	rows, err := result.RowsAffected()
 	if err != nil {
-		return fmt.Errorf("failed to get rows affected: %w", err)
-	}
-
-	if rows == 0 {
-		return fmt.Errorf("request not found")
+		return 0, fmt.Errorf("failed to update tags: %w", err)
 	}
 
 	// Delete existing tag associations
 	if _, err := tx.Exec("DELETE FROM tags WHERE request_id = $1", id); err != nil {
-		return fmt.Errorf("failed to delete old tag associations: %w", err)
+		return 0, fmt.Errorf("failed to delete old tag associations: %w", err)
 	}
 
 	// Insert new tag associations
-	for _, tag := range tags {
-		if _, err := tx.Exec("INSERT INTO tags (request_id, tag) VALUES ($1, $2)", id, tag); err != nil {
-			return fmt.Errorf("failed to insert tag association: %w", err)
-		}
+	if err := s.insertTags(tx, id, tags); err != nil {
+		return 0, err
 	}
 
 	// Check if tags contain any tombstone trigger tags and apply tag-based tombstone
+	if err := s.applyTagTombstoneTx(tx, id, tags); err != nil {
+		return 0, err
+	}
+
+	// Commit transaction
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return newVersion, nil
+}
+
+// applyTagTombstoneTx schedules a tag-based tombstone when tags include one of the configured
+// tombstone tags
+func (s *Storage) applyTagTombstoneTx(tx *sql.Tx, id string, tags []string) error {
 	hasTombstoneTag := false
 	matchedTag := ""
 	for _, tag := range tags {
@@ -920,15 +1033,16 @@ func (s *Storage) UpdateRequestTags(id string, tags []string) error {
 		}
 
 		// Add tag-based tombstone using configured period
-		tombstoneTime := time.Now().UTC().Add(time.Duration(s.tombstonePeriodTagBased) * 24 * time.Hour)
+		periodDays := s.settings.Get().TombstonePeriodTagBased
+		tombstoneTime := time.Now().UTC().Add(time.Duration(periodDays) * 24 * time.Hour)
 		metadata["tombstone_datetime"] = tombstoneTime.Format(time.RFC3339)
 		metadata["tombstone_reason"] = fmt.Sprintf("auto-tombstone: %s tag", matchedTag)
 
 		// Record metrics if available
 		if s.businessMetrics != nil {
-			s.businessMetrics.RecordTombstone("tag-based", matchedTag, s.tombstonePeriodTagBased)
+			s.businessMetrics.RecordTombstone("tag-based", matchedTag, periodDays)
 		}
-		slog.Default().Info("tag-based tombstone created", "request_id", id, "tag", matchedTag, "period_days", s.tombstonePeriodTagBased)
+		slog.Default().Info("tag-based tombstone created", "request_id", id, "tag", matchedTag, "period_days", periodDays)
 
 		// Marshal updated metadata
 		updatedMetadataJSON, err := json.Marshal(metadata)
@@ -941,13 +1055,19 @@ func (s *Storage) UpdateRequestTags(id string, tags []string) error {
 		if err != nil {
 			return fmt.Errorf("failed to update metadata with tombstone: %w", err)
 		}
-	}
 
-	// Commit transaction
-	if err := tx.Commit(); err != nil {
-		return fmt.Errorf("failed to commit transaction: %w", err)
+		recordAuditTx(tx, &AuditEntry{
+			Actor:      AuditActorSystem,
+			Action:     AuditActionTombstone,
+			EntityType: AuditEntityRequest,
+			EntityID:   id,
+			Details: map[string]interface{}{
+				"reason":      "tag-based",
+				"tag":         matchedTag,
+				"period_days": periodDays,
+			},
+		})
 	}
-
 	return nil
 }
 
@@ -962,6 +1082,7 @@ type DocumentStats struct {
 
 // GetDocumentStats returns statistics about documents for Prometheus metrics
 func (s *Storage) GetDocumentStats() (*DocumentStats, error) {
+	defer s.timeQuery("GetDocumentStats")()
 	stats := &DocumentStats{
 		TotalByType: make(map[string]int),
 	}
@@ -970,7 +1091,8 @@ func (s *Storage) GetDocumentStats() (*DocumentStats, error) {
 	rows, err := s.db.Query(`
 		SELECT source_type, COUNT(*)
 		FROM requests
-		WHERE (metadata_json->>'tombstone_datetime' IS NULL OR (metadata_json->>'tombstone_datetime')::timestamp > NOW())
+		WHERE `+notDeletedPredicate+` AND `+s.inNamespace("")+`
+		AND (metadata_json->>'tombstone_datetime' IS NULL OR (metadata_json->>'tombstone_datetime')::timestamp > NOW())
 		GROUP BY source_type
 	`)
 	if err != nil {
@@ -989,8 +1111,10 @@ func (s *Storage) GetDocumentStats() (*DocumentStats, error) {
 
 	// Get documents with tags
 	err = s.db.QueryRow(`
-		SELECT COUNT(DISTINCT request_id)
-		FROM tags
+		SELECT COUNT(DISTINCT t.request_id)
+		FROM tags t
+		INNER JOIN requests r ON r.id = t.request_id
+		WHERE `+notDeletedPredicateAliased+` AND `+s.inNamespace("r")+`
 	`).Scan(&stats.TotalWithTags)
 	if err != nil {
 		return nil, fmt.Errorf("failed to count documents with tags: %w", err)
@@ -998,8 +1122,10 @@ func (s *Storage) GetDocumentStats() (*DocumentStats, error) {
 
 	// Get unique tags count
 	err = s.db.QueryRow(`
-		SELECT COUNT(DISTINCT tag)
-		FROM tags
+		SELECT COUNT(DISTINCT t.tag)
+		FROM tags t
+		INNER JOIN requests r ON r.id = t.request_id
+		WHERE `+notDeletedPredicateAliased+` AND `+s.inNamespace("r")+`
 	`).Scan(&stats.UniqueTagsCount)
 	if err != nil {
 		return nil, fmt.Errorf("failed to count unique tags: %w", err)
@@ -1010,6 +1136,7 @@ func (s *Storage) GetDocumentStats() (*DocumentStats, error) {
 		SELECT COUNT(*)
 		FROM requests
 		WHERE seo_enabled = true
+		AND `+notDeletedPredicate+` AND `+s.inNamespace("")+`
 		AND (metadata_json->>'tombstone_datetime' IS NULL OR (metadata_json->>'tombstone_datetime')::timestamp > NOW())
 	`).Scan(&stats.TotalWithSEO)
 	if err != nil {
@@ -1021,6 +1148,7 @@ func (s *Storage) GetDocumentStats() (*DocumentStats, error) {
 		SELECT COUNT(*)
 		FROM requests
 		WHERE metadata_json->>'tombstone_datetime' IS NOT NULL
+		AND `+notDeletedPredicate+` AND `+s.inNamespace("")+`
 		AND (metadata_json->>'tombstone_datetime')::timestamp <= NOW()
 	`).Scan(&stats.TotalTombstoned)
 	if err != nil {
@@ -1055,14 +1183,26 @@ type TagTimelineResponse struct {
 
 // TagTimelineStats contains aggregate statistics for the timeline
 type TagTimelineStats struct {
-	TotalDocuments  int `json:"total_documents"`
-	TotalUniqueTags int `json:"total_unique_tags"`
-	BucketCount     int `json:"bucket_count"`
+	TotalDocuments   int `json:"total_documents"`
+	MatchedDocuments int `json:"matched_documents"` // Documents carrying a focus tag; equals TotalDocuments without a focus
+	TotalUniqueTags  int `json:"total_unique_tags"`
+	BucketCount      int `json:"bucket_count"`
 }
 
 // GetTagTimeline calculates tag frequency distribution over time buckets
 // This provides an efficient way to visualize tag trends without sending all documents to the client
+// Buckets are fixed-length and start at startDate; endDate is exclusive. Bucket timestamps are in UTC.
 func (s *Storage) GetTagTimeline(startDate, endDate time.Time, bucketDuration time.Duration, maxTagsPerBucket int) (*TagTimelineResponse, error) {
+	return s.GetTagTimelineFiltered(startDate, endDate, bucketDuration, maxTagsPerBucket, nil)
+}
+
+// GetTagTimelineFiltered is GetTagTimeline focused on a set of tags. With focusTags set, only
+// documents carrying at least one of them are counted and only those tags appear in buckets;
+// Stats.MatchedDocuments counts those documents against Stats.TotalDocuments. The tombstone and
+// SEO exclusions apply either way.
+func (s *Storage) GetTagTimelineFiltered(startDate, endDate time.Time, bucketDuration time.Duration, maxTagsPerBucket int, focusTags []string) (*TagTimelineResponse, error) {
+	defer s.timeQuery("GetTagTimeline", "start", startDate, "end", endDate, "bucket", bucketDuration, "focus_tags", len(focusTags))()
+	startDate, endDate = startDate.UTC(), endDate.UTC()
 	// Calculate number of buckets
 	totalDuration := endDate.Sub(startDate)
 	numBuckets := int(totalDuration / bucketDuration)
@@ -1076,12 +1216,19 @@ func (s *Storage) GetTagTimeline(startDate, endDate time.Time, bucketDuration ti
 		bucketDuration = totalDuration / time.Duration(numBuckets)
 	}
 
+	// A focus narrows both the documents and the tags counted for them
+	var documentFocus, tagFocus string
+	if len(focusTags) > 0 {
+		documentFocus = `AND EXISTS (SELECT 1 FROM tags ft WHERE ft.request_id = r.id AND ft.tag = ANY($5))`
+		tagFocus = `WHERE t.tag = ANY($5)`
+	}
+
 	// Query to get tag counts per time bucket
 	// This aggregates tags by time bucket and counts documents
 	query := `
 		WITH time_buckets AS (
 			SELECT
-				generate_series($1::timestamp, $2::timestamp, $3::interval) AS bucket_start
+				generate_series($1::timestamptz, $2::timestamptz, $3::interval) AS bucket_start
 		),
 		document_buckets AS (
 			SELECT
@@ -1093,10 +1240,13 @@ func (s *Storage) GetTagTimeline(startDate, endDate time.Time, bucketDuration ti
 			WHERE r.effective_date >= tb.bucket_start
 			  AND r.effective_date < tb.bucket_start + $3::interval
 			  AND r.effective_date >= $1
-			  AND r.effective_date <= $2
+			  AND r.effective_date < $2
 			  AND r.seo_enabled = true
+			  AND `+notDeletedPredicateAliased+`
+			  AND `+s.inNamespace("r")+`
 			  AND (r.metadata_json->>'tombstone_datetime' IS NULL
 			       OR (r.metadata_json->>'tombstone_datetime')::timestamp > NOW())
+			  `+documentFocus+`
 		),
 		tag_counts AS (
 			SELECT
@@ -1105,6 +1255,7 @@ func (s *Storage) GetTagTimeline(startDate, endDate time.Time, bucketDuration ti
 				COUNT(DISTINCT db.request_id) AS doc_count
 			FROM document_buckets db
 			INNER JOIN tags t ON t.request_id = db.request_id
+			`+tagFocus+`
 			GROUP BY db.bucket_start, t.tag
 		),
 		ranked_tags AS (
@@ -1132,20 +1283,18 @@ func (s *Storage) GetTagTimeline(startDate, endDate time.Time, bucketDuration ti
 	// Convert bucket duration to PostgreSQL interval string
 	bucketInterval := fmt.Sprintf("%d seconds", int(bucketDuration.Seconds()))
 
-	rows, err := s.db.Query(query, startDate, endDate, bucketInterval, maxTagsPerBucket)
+	args := []interface{}{startDate, endDate, bucketInterval, maxTagsPerBucket}
+	if len(focusTags) > 0 {
+		args = append(args, pq.Array(focusTags))
+	}
+	rows, err := s.db.Query(query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query tag timeline: %w", err)
 	}
 	defer rows.Close()
 
-	// Build buckets map using string keys to avoid timestamp precision issues
-	type bucketKey struct {
-		year  int
-		month int
-		day   int
-		hour  int
-	}
-	bucketsMap := make(map[bucketKey]struct {
+	// Build buckets map keyed by Unix second to avoid timestamp precision and time zone issues
+	bucketsMap := make(map[int64]struct {
 		timestamp time.Time
 		tags      []TagEntry
 	})
@@ -1174,17 +1323,11 @@ func (s *Storage) GetTagTimeline(startDate, endDate time.Time, bucketDuration ti
 			SizeFactor:     sizeFactor,
 		}
 
-		// Use rounded hour as key to avoid timestamp precision issues
-		key := bucketKey{
-			year:  bucketStart.Year(),
-			month: int(bucketStart.Month()),
-			day:   bucketStart.Day(),
-			hour:  bucketStart.Hour(),
-		}
+		key := bucketStart.Unix()
 
 		bucket := bucketsMap[key]
 		if bucket.timestamp.IsZero() {
-			bucket.timestamp = bucketStart
+			bucket.timestamp = bucketStart.UTC()
 		}
 		bucket.tags = append(bucket.tags, entry)
 		bucketsMap[key] = bucket
@@ -1199,12 +1342,7 @@ func (s *Storage) GetTagTimeline(startDate, endDate time.Time, bucketDuration ti
 	var buckets []TagBucket
 	currentTime := startDate
 	for i := 0; i < numBuckets; i++ {
-		key := bucketKey{
-			year:  currentTime.Year(),
-			month: int(currentTime.Month()),
-			day:   currentTime.Day(),
-			hour:  currentTime.Hour(),
-		}
+		key := currentTime.Unix()
 
 		bucket := TagBucket{
 			Timestamp:   currentTime,
@@ -1218,27 +1356,35 @@ func (s *Storage) GetTagTimeline(startDate, endDate time.Time, bucketDuration ti
 		currentTime = currentTime.Add(bucketDuration)
 	}
 
-	// Get total document count in range
-	var totalDocs int
+	// Get total and focused document counts in range
+	var totalDocs, matchedDocs int
 	countQuery := `
-		SELECT COUNT(*)
-		FROM requests
-		WHERE effective_date >= $1
-		  AND effective_date <= $2
-		  AND seo_enabled = true
-		  AND (metadata_json->>'tombstone_datetime' IS NULL
-		       OR (metadata_json->>'tombstone_datetime')::timestamp > NOW())
+		SELECT COUNT(*), COUNT(*) FILTER (WHERE $3::text[] IS NULL
+		       OR EXISTS (SELECT 1 FROM tags ft WHERE ft.request_id = r.id AND ft.tag = ANY($3)))
+		FROM requests r
+		WHERE r.effective_date >= $1
+		  AND r.effective_date < $2
+		  AND r.seo_enabled = true
+		  AND `+notDeletedPredicateAliased+`
+		  AND `+s.inNamespace("r")+`
+		  AND (r.metadata_json->>'tombstone_datetime' IS NULL
+		       OR (r.metadata_json->>'tombstone_datetime')::timestamp > NOW())
 	`
-	if err := s.db.QueryRow(countQuery, startDate, endDate).Scan(&totalDocs); err != nil {
+	var focus interface{}
+	if len(focusTags) > 0 {
+		focus = pq.Array(focusTags)
+	}
+	if err := s.db.QueryRow(countQuery, startDate, endDate, focus).Scan(&totalDocs, &matchedDocs); err != nil {
 		return nil, fmt.Errorf("failed to count documents: %w", err)
 	}
 
 	response := &TagTimelineResponse{
 		Buckets: buckets,
 		Stats: TagTimelineStats{
-			TotalDocuments:  totalDocs,
-			TotalUniqueTags: len(allTags),
-			BucketCount:     len(buckets),
+			TotalDocuments:   totalDocs,
+			MatchedDocuments: matchedDocs,
+			TotalUniqueTags:  len(allTags),
+			BucketCount:      len(buckets),
 		},
 	}
 
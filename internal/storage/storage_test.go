@@ -4,9 +4,12 @@ import (
 	"fmt"
 	"testing"
 	"time"
+
+	"github.com/docutag/controller/internal/language"
 )
 
 func TestNew(t *testing.T) {
+	t.Parallel()
 	connStr, cleanup := setupTestDB(t, "test_new")
 	defer cleanup()
 
@@ -22,6 +25,7 @@ func TestNew(t *testing.T) {
 }
 
 func TestSaveAndGetRequest(t *testing.T) {
+	t.Parallel()
 	connStr, cleanup := setupTestDB(t, "test_save_get")
 	defer cleanup()
 
@@ -83,6 +87,7 @@ func TestSaveAndGetRequest(t *testing.T) {
 }
 
 func TestSaveTextRequest(t *testing.T) {
+	t.Parallel()
 	connStr, cleanup := setupTestDB(t, "test_text_request")
 	defer cleanup()
 
@@ -124,6 +129,7 @@ func TestSaveTextRequest(t *testing.T) {
 }
 
 func TestSearchByTags(t *testing.T) {
+	t.Parallel()
 	connStr, cleanup := setupTestDB(t, "test_search_tags")
 	defer cleanup()
 
@@ -205,9 +211,33 @@ func TestSearchByTags(t *testing.T) {
 	if len(results) != 0 {
 		t.Errorf("Expected 0 results for non-existent tag, got %d", len(results))
 	}
+
+	// Test multiple tags (AND search)
+	results, err = store.SearchByAllTags([]string{"golang", "programming"}, false)
+	if err != nil {
+		t.Fatalf("Failed to search all tags: %v", err)
+	}
+	if len(results) != 1 || results[0] != "req-1" {
+		t.Errorf("Expected only req-1 for golang AND programming, got %v", results)
+	}
+	results, err = store.SearchByAllTags([]string{"prog", "data"}, true)
+	if err != nil {
+		t.Fatalf("Failed to fuzzy search all tags: %v", err)
+	}
+	if len(results) != 1 || results[0] != "req-2" {
+		t.Errorf("Expected only req-2 for fuzzy prog AND data, got %v", results)
+	}
+	filtered, err := store.FilterRequests(FilterOptions{Tags: []string{"programming", "backend"}, MatchAll: true})
+	if err != nil {
+		t.Fatalf("Failed to filter by all tags: %v", err)
+	}
+	if len(filtered) != 1 || filtered[0].ID != "req-1" {
+		t.Errorf("Expected only req-1 when filtering by programming AND backend, got %d", len(filtered))
+	}
 }
 
 func TestListRequests(t *testing.T) {
+	t.Parallel()
 	connStr, cleanup := setupTestDB(t, "test_list_requests")
 	defer cleanup()
 
@@ -258,6 +288,7 @@ func TestListRequests(t *testing.T) {
 }
 
 func TestGetRequestNotFound(t *testing.T) {
+	t.Parallel()
 	connStr, cleanup := setupTestDB(t, "test_not_found")
 	defer cleanup()
 
@@ -277,6 +308,7 @@ func TestGetRequestNotFound(t *testing.T) {
 }
 
 func TestUpdateRequestMetadata(t *testing.T) {
+	t.Parallel()
 	connStr, cleanup := setupTestDB(t, "test_update_metadata")
 	defer cleanup()
 
@@ -331,6 +363,7 @@ func TestUpdateRequestMetadata(t *testing.T) {
 }
 
 func TestUpdateRequestMetadataNotFound(t *testing.T) {
+	t.Parallel()
 	connStr, cleanup := setupTestDB(t, "test_update_metadata_notfound")
 	defer cleanup()
 
@@ -353,6 +386,7 @@ func TestUpdateRequestMetadataNotFound(t *testing.T) {
 }
 
 func TestDeleteRequest(t *testing.T) {
+	t.Parallel()
 	connStr, cleanup := setupTestDB(t, "test_delete_request")
 	defer cleanup()
 
@@ -407,6 +441,7 @@ func TestDeleteRequest(t *testing.T) {
 }
 
 func TestDeleteRequestNotFound(t *testing.T) {
+	t.Parallel()
 	connStr, cleanup := setupTestDB(t, "test_delete_notfound")
 	defer cleanup()
 
@@ -426,6 +461,7 @@ func TestDeleteRequestNotFound(t *testing.T) {
 }
 
 func TestGetTimelineExtents(t *testing.T) {
+	t.Parallel()
 	t.Run("empty database", func(t *testing.T) {
 		connStr, cleanup := setupTestDB(t, "test_timeline_extents_empty")
 		defer cleanup()
@@ -784,7 +820,33 @@ func TestGetTimelineExtents(t *testing.T) {
 	})
 }
 
+func TestExtractEffectiveDateUTC(t *testing.T) {
+	t.Parallel()
+	fallback := time.Date(2024, 3, 5, 12, 0, 0, 0, time.FixedZone("UTC+2", 2*60*60))
+
+	tests := []struct {
+		name string
+		date string
+		want time.Time
+	}{
+		{"offset", "2024-03-01T23:30:00-08:00", time.Date(2024, 3, 2, 7, 30, 0, 0, time.UTC)},
+		{"date only", "2024-03-01", time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)},
+		{"no zone", "2024-03-01 23:30:00", time.Date(2024, 3, 1, 23, 30, 0, 0, time.UTC)},
+		{"unparseable falls back", "early March", time.Date(2024, 3, 5, 10, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			metadata := map[string]interface{}{"scraper_metadata": map[string]interface{}{"publish_date": tt.date}}
+			got := extractEffectiveDate(metadata, fallback)
+			if !got.Equal(tt.want) || got.Location() != time.UTC {
+				t.Errorf("Expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
 func TestUpdateSEOEnabled(t *testing.T) {
+	t.Parallel()
 	connStr, cleanup := setupTestDB(t, "test_update_seo")
 	defer cleanup()
 
@@ -854,6 +916,7 @@ func TestUpdateSEOEnabled(t *testing.T) {
 }
 
 func TestUpdateSEOEnabledNotFound(t *testing.T) {
+	t.Parallel()
 	connStr, cleanup := setupTestDB(t, "test_update_seo_notfound")
 	defer cleanup()
 
@@ -872,7 +935,80 @@ func TestUpdateSEOEnabledNotFound(t *testing.T) {
 	}
 }
 
+func TestRequestLanguage(t *testing.T) {
+	t.Parallel()
+	store, cleanup := setupTestStorage(t)
+	defer cleanup()
+
+	english := "This is the article text that was scraped from the page and it is written in English."
+	requests := []struct {
+		req  *Request
+		want string
+	}{
+		{
+			req: &Request{ID: "lang-analyzer", Metadata: map[string]interface{}{
+				"analyzer_metadata": map[string]interface{}{"language": "de-DE", "cleaned_text": english},
+			}},
+			want: "de",
+		},
+		{
+			req:  &Request{ID: "lang-detected", Metadata: map[string]interface{}{"scraper_metadata": map[string]interface{}{"content": english}}},
+			want: "en",
+		},
+		{
+			req:  &Request{ID: "lang-unknown", Metadata: map[string]interface{}{}},
+			want: language.Unknown,
+		},
+		{
+			req:  &Request{ID: "lang-explicit", Language: "fr", Metadata: map[string]interface{}{"original_text": english}},
+			want: "fr",
+		},
+	}
+
+	for _, r := range requests {
+		r.req.CreatedAt = time.Now().UTC()
+		r.req.SourceType = "text"
+		r.req.SEOEnabled = true
+		if err := store.SaveRequest(r.req); err != nil {
+			t.Fatalf("Failed to save request %s: %v", r.req.ID, err)
+		}
+		got, err := store.GetRequest(r.req.ID)
+		if err != nil {
+			t.Fatalf("Failed to get request %s: %v", r.req.ID, err)
+		}
+		if got.Language != r.want {
+			t.Errorf("%s: expected language %q, got %q", r.req.ID, r.want, got.Language)
+		}
+	}
+
+	if err := store.UpdateRequestLanguage("lang-unknown", "es"); err != nil {
+		t.Fatalf("Failed to update language: %v", err)
+	}
+	if err := store.UpdateRequestLanguage("missing", "es"); err == nil {
+		t.Error("Expected error updating language of a missing request")
+	}
+
+	lang := "en"
+	filtered, err := store.FilterRequests(FilterOptions{Language: &lang, Limit: 10})
+	if err != nil {
+		t.Fatalf("FilterRequests failed: %v", err)
+	}
+	if len(filtered) != 1 || filtered[0].ID != "lang-detected" || filtered[0].Language != "en" {
+		t.Errorf("Expected only lang-detected for language en, got %+v", filtered)
+	}
+
+	lang = "es"
+	filtered, err = store.FilterRequests(FilterOptions{Language: &lang, Limit: 10})
+	if err != nil {
+		t.Fatalf("FilterRequests failed: %v", err)
+	}
+	if len(filtered) != 1 || filtered[0].ID != "lang-unknown" {
+		t.Errorf("Expected only lang-unknown after update, got %+v", filtered)
+	}
+}
+
 func TestGetRequestBySlug(t *testing.T) {
+	t.Parallel()
 	connStr, cleanup := setupTestDB(t, "test_get_by_slug")
 	defer cleanup()
 
@@ -938,6 +1074,7 @@ func TestGetRequestBySlug(t *testing.T) {
 }
 
 func TestSlugUniqueness(t *testing.T) {
+	t.Parallel()
 	connStr, cleanup := setupTestDB(t, "test_slug_uniqueness")
 	defer cleanup()
 
@@ -984,15 +1121,46 @@ func TestSlugUniqueness(t *testing.T) {
 		Metadata:         map[string]interface{}{},
 	}
 
-	// This should fail due to unique constraint on slug
-	err = store.SaveRequest(req2)
-	if err == nil {
-		t.Error("Expected error when saving duplicate slug, but got none")
+	// The taken slug is made unique with the request ID instead of failing the save
+	if err := store.SaveRequest(req2); err != nil {
+		t.Fatalf("Expected the duplicate slug to be made unique, got %v", err)
+	}
+	if req2.Slug == nil || *req2.Slug != "duplicate-slug-testdup2" {
+		t.Fatalf("Expected slug duplicate-slug-testdup2, got %v", req2.Slug)
+	}
+	if got, err := store.GetRequestBySlug("duplicate-slug-testdup2"); err != nil || got.ID != "test-dup-2" {
+		t.Errorf("Expected the suffixed slug to find test-dup-2, got %+v (err %v)", got, err)
+	}
+	if got, err := store.GetRequestBySlug("duplicate-slug"); err != nil || got.ID != "test-dup-1" {
+		t.Errorf("Expected the original slug to keep finding test-dup-1, got %+v (err %v)", got, err)
+	}
+
+	// When the shortened ID suffix collides too, the full ID is used
+	req3 := &Request{
+		ID:         "test-dup-2x",
+		CreatedAt:  time.Now().UTC(),
+		SourceType: "url",
+		Tags:       []string{"test"},
+		Slug:       &slug,
+		Metadata:   map[string]interface{}{},
+	}
+	if err := store.SaveRequest(req3); err != nil {
+		t.Fatalf("Expected the second collision to be made unique, got %v", err)
+	}
+	if req3.Slug == nil || *req3.Slug != "duplicate-slug-testdup2x" {
+		t.Errorf("Expected slug duplicate-slug-testdup2x, got %v", req3.Slug)
+	}
+
+	// Other unique violations still fail
+	req4 := &Request{ID: "test-dup-1", CreatedAt: time.Now().UTC(), SourceType: "url", Metadata: map[string]interface{}{}}
+	if err := store.SaveRequest(req4); err == nil {
+		t.Error("Expected an error when saving a duplicate ID, but got none")
 	}
 }
 
 // TestGetTagTimeline_EmptyDatabase verifies behavior with no documents
 func TestGetTagTimeline_EmptyDatabase(t *testing.T) {
+	t.Parallel()
 	connStr, cleanup := setupTestDB(t, "test_tag_timeline_empty")
 	defer cleanup()
 
@@ -1031,6 +1199,7 @@ func TestGetTagTimeline_EmptyDatabase(t *testing.T) {
 
 // TestGetTagTimeline_SingleBucket verifies tag frequency calculation in a single bucket
 func TestGetTagTimeline_SingleBucket(t *testing.T) {
+	t.Parallel()
 	connStr, cleanup := setupTestDB(t, "test_tag_timeline_single")
 	defer cleanup()
 
@@ -1135,6 +1304,7 @@ func TestGetTagTimeline_SingleBucket(t *testing.T) {
 
 // TestGetTagTimeline_MultipleBuckets verifies distribution across time buckets
 func TestGetTagTimeline_MultipleBuckets(t *testing.T) {
+	t.Parallel()
 	connStr, cleanup := setupTestDB(t, "test_tag_timeline_multiple")
 	defer cleanup()
 
@@ -1270,6 +1440,7 @@ func TestGetTagTimeline_MultipleBuckets(t *testing.T) {
 
 // TestGetTagTimeline_MaxTagsPerBucket verifies max_tags limiting
 func TestGetTagTimeline_MaxTagsPerBucket(t *testing.T) {
+	t.Parallel()
 	connStr, cleanup := setupTestDB(t, "test_tag_timeline_max_tags")
 	defer cleanup()
 
@@ -1318,8 +1489,89 @@ func TestGetTagTimeline_MaxTagsPerBucket(t *testing.T) {
 	}
 }
 
+// TestGetTagTimeline_FocusTags verifies that a tag focus narrows documents and tags together
+func TestGetTagTimeline_FocusTags(t *testing.T) {
+	t.Parallel()
+	connStr, cleanup := setupTestDB(t, "test_tag_timeline_focus")
+	defer cleanup()
+
+	store, err := New(connStr, []string{}, 30, 90, 90)
+	if err != nil {
+		t.Fatalf("Failed to create storage: %v", err)
+	}
+	defer store.Close()
+
+	baseTime := time.Date(2025, 10, 30, 12, 0, 0, 0, time.UTC)
+	docs := []struct {
+		id         string
+		tags       []string
+		tombstoned bool
+	}{
+		{"doc-1", []string{"politics", "news"}, false},
+		{"doc-2", []string{"politics", "economy"}, false},
+		{"doc-3", []string{"politics", "tech"}, false},
+		{"doc-4", []string{"sports"}, false},
+		{"doc-5", []string{"economy"}, true},
+	}
+	for i, doc := range docs {
+		metadata := map[string]interface{}{}
+		if doc.tombstoned {
+			metadata["tombstone_datetime"] = baseTime.Add(-time.Hour).Format(time.RFC3339)
+		}
+		if err := store.SaveRequest(&Request{
+			ID:               doc.id,
+			CreatedAt:        baseTime.Add(time.Duration(i) * time.Minute),
+			EffectiveDate:    baseTime.Add(time.Duration(i) * time.Minute),
+			SourceType:       "url",
+			TextAnalyzerUUID: "analyzer-" + doc.id,
+			Tags:             doc.tags,
+			SEOEnabled:       true,
+			Metadata:         metadata,
+		}); err != nil {
+			t.Fatalf("Failed to save request: %v", err)
+		}
+	}
+
+	timeline, err := store.GetTagTimelineFiltered(baseTime.Add(-10*time.Minute), baseTime.Add(50*time.Minute), time.Hour, 20, []string{"economy", "tech"})
+	if err != nil {
+		t.Fatalf("GetTagTimelineFiltered failed: %v", err)
+	}
+
+	if timeline.Stats.TotalDocuments != 4 {
+		t.Errorf("Expected 4 documents in range, got %d", timeline.Stats.TotalDocuments)
+	}
+	if timeline.Stats.MatchedDocuments != 2 {
+		t.Errorf("Expected 2 documents with a focus tag, got %d", timeline.Stats.MatchedDocuments)
+	}
+	if timeline.Stats.TotalUniqueTags != 2 {
+		t.Errorf("Expected only the 2 focus tags, got %d", timeline.Stats.TotalUniqueTags)
+	}
+
+	if len(timeline.Buckets) != 1 {
+		t.Fatalf("Expected 1 bucket, got %d", len(timeline.Buckets))
+	}
+	counts := map[string]int{}
+	for _, entry := range timeline.Buckets[0].Tags {
+		counts[entry.Tag] = entry.Count
+	}
+	// The tombstoned economy document is still excluded, and untracked tags are left out
+	if len(counts) != 2 || counts["economy"] != 1 || counts["tech"] != 1 {
+		t.Errorf("Expected economy and tech once each, got %v", counts)
+	}
+
+	// Without a focus every document matches
+	timeline, err = store.GetTagTimeline(baseTime.Add(-10*time.Minute), baseTime.Add(50*time.Minute), time.Hour, 20)
+	if err != nil {
+		t.Fatalf("GetTagTimeline failed: %v", err)
+	}
+	if timeline.Stats.MatchedDocuments != timeline.Stats.TotalDocuments {
+		t.Errorf("Expected every document to match without a focus, got %d of %d", timeline.Stats.MatchedDocuments, timeline.Stats.TotalDocuments)
+	}
+}
+
 // TestGetTagTimeline_ExcludesTombstonedAndSEODisabled verifies filtering
 func TestGetTagTimeline_ExcludesTombstonedAndSEODisabled(t *testing.T) {
+	t.Parallel()
 	connStr, cleanup := setupTestDB(t, "test_tag_timeline_filtering")
 	defer cleanup()
 
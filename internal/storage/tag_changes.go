@@ -0,0 +1,112 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+// TagChange is the outcome of ModifyRequestTags
+type TagChange struct {
+	Tags       []string // The request's tags afterwards
+	Added      []string // Tags that were not there before; adding a present tag is a no-op
+	Removed    []string // Tags that were there before; removing a missing tag is a no-op
+	RowVersion int64    // The request's row version afterwards
+}
+
+// ModifyRequestTags adds and removes tags in one transaction, so concurrent edits to
+// different tags do not overwrite each other. Tags are trimmed, empty ones are dropped and
+// the result is deduplicated, keeping the existing order with new tags appended. Only tags
+// that were actually added can trigger a tag-based tombstone. When nothing changes, the
+// request is left untouched.
+func (s *Storage) ModifyRequestTags(id string, add, remove []string) (*TagChange, error) {
+	defer s.timeQuery("ModifyRequestTags", "id", id, "add", len(add), "remove", len(remove))()
+	var change *TagChange
+	err := s.inTx("ModifyRequestTags", func(tx *sql.Tx) error {
+		var tagsJSON sql.NullString
+		change = &TagChange{}
+		err := tx.QueryRow(`
+			SELECT tags_json, row_version FROM requests
+			WHERE id = $1 AND `+notDeletedPredicate+` AND `+s.inNamespace("")+`
+			FOR UPDATE
+		`, id).Scan(&tagsJSON, &change.RowVersion)
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("request not found")
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read tags: %w", err)
+		}
+		var current []string
+		if tagsJSON.Valid && tagsJSON.String != "" {
+			if err := json.Unmarshal([]byte(tagsJSON.String), &current); err != nil {
+				return fmt.Errorf("failed to unmarshal tags: %w", err)
+			}
+		}
+
+		change.Tags, change.Added, change.Removed = applyTagChange(current, add, remove)
+		if len(change.Added) == 0 && len(change.Removed) == 0 {
+			return nil
+		}
+
+		updated, err := json.Marshal(change.Tags)
+		if err != nil {
+			return fmt.Errorf("failed to marshal tags: %w", err)
+		}
+		if err := tx.QueryRow(`
+			UPDATE requests SET tags_json = $1, row_version = row_version + 1
+			WHERE id = $2
+			RETURNING row_version
+		`, string(updated), id).Scan(&change.RowVersion); err != nil {
+			return fmt.Errorf("failed to update tags: %w", err)
+		}
+		if len(change.Removed) > 0 {
+			if _, err := tx.Exec("DELETE FROM tags WHERE request_id = $1 AND tag = ANY($2)", id, pq.Array(change.Removed)); err != nil {
+				return fmt.Errorf("failed to delete tag associations: %w", err)
+			}
+		}
+		if err := s.insertTags(tx, id, change.Added); err != nil {
+			return err
+		}
+		return s.applyTagTombstoneTx(tx, id, change.Added)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return change, nil
+}
+
+// applyTagChange removes and then adds tags, reporting which of them changed the set
+func applyTagChange(current, add, remove []string) (tags, added, removed []string) {
+	drop := make(map[string]bool)
+	for _, tag := range remove {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			drop[tag] = true
+		}
+	}
+	seen := make(map[string]bool)
+	tags = []string{}
+	for _, tag := range current {
+		if seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		if drop[tag] {
+			removed = append(removed, tag)
+			continue
+		}
+		tags = append(tags, tag)
+	}
+	for _, tag := range add {
+		tag = strings.TrimSpace(tag)
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		tags = append(tags, tag)
+		added = append(added, tag)
+	}
+	return tags, added, removed
+}
@@ -0,0 +1,82 @@
+package storage
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestApplyTagChange(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name                 string
+		current, add, remove []string
+		tags, added, removed []string
+	}{
+		{"add only", []string{"news"}, []string{"verified"}, nil, []string{"news", "verified"}, []string{"verified"}, nil},
+		{"remove only", []string{"news", "draft"}, nil, []string{"draft"}, []string{"news"}, nil, []string{"draft"}},
+		{"add existing is a no-op", []string{"news"}, []string{"news", " news "}, nil, []string{"news"}, nil, nil},
+		{"remove missing is a no-op", []string{"news"}, nil, []string{"draft"}, []string{"news"}, nil, nil},
+		{"trims, drops empty and dedupes", []string{"a", "a"}, []string{" b", "", "b"}, []string{" "}, []string{"a", "b"}, []string{"b"}, nil},
+	}
+	for _, tt := range tests {
+		tags, added, removed := applyTagChange(tt.current, tt.add, tt.remove)
+		if !reflect.DeepEqual(tags, tt.tags) || !reflect.DeepEqual(added, tt.added) || !reflect.DeepEqual(removed, tt.removed) {
+			t.Errorf("%s: got tags %v added %v removed %v", tt.name, tags, added, removed)
+		}
+	}
+}
+
+func TestModifyRequestTags(t *testing.T) {
+	t.Parallel()
+	store, cleanup := setupTestStorage(t)
+	defer cleanup()
+
+	req := &Request{ID: "tagmod-1", CreatedAt: time.Now(), SourceType: "text", Tags: []string{"news", "draft", "low-quality"}, Metadata: map[string]interface{}{}}
+	if err := store.SaveRequest(req); err != nil {
+		t.Fatalf("Failed to save request: %v", err)
+	}
+
+	change, err := store.ModifyRequestTags(req.ID, []string{"verified"}, []string{"draft", "missing"})
+	if err != nil {
+		t.Fatalf("ModifyRequestTags failed: %v", err)
+	}
+	if !reflect.DeepEqual(change.Tags, []string{"news", "low-quality", "verified"}) || len(change.Removed) != 1 {
+		t.Fatalf("Unexpected change %+v", change)
+	}
+	got, err := store.GetRequest(req.ID)
+	if err != nil {
+		t.Fatalf("GetRequest failed: %v", err)
+	}
+	if !reflect.DeepEqual(got.Tags, change.Tags) || got.RowVersion != change.RowVersion {
+		t.Errorf("Expected stored tags %v at version %d, got %v at %d", change.Tags, change.RowVersion, got.Tags, got.RowVersion)
+	}
+	// The low-quality tag was already there, so editing other tags does not tombstone
+	if _, ok := got.Metadata["tombstone_datetime"]; ok {
+		t.Errorf("Expected no tombstone from unrelated tag edits, got %v", got.Metadata)
+	}
+	ids, err := store.SearchByTags([]string{"draft"}, false)
+	if err != nil || len(ids) != 0 {
+		t.Errorf("Expected the draft tag row to be gone, got %v, %v", ids, err)
+	}
+
+	noop, err := store.ModifyRequestTags(req.ID, []string{"news"}, []string{"missing"})
+	if err != nil || noop.RowVersion != change.RowVersion || len(noop.Added)+len(noop.Removed) != 0 {
+		t.Errorf("Expected a no-op at version %d, got %+v, %v", change.RowVersion, noop, err)
+	}
+
+	if _, err := store.ModifyRequestTags(req.ID, []string{"sparse-content"}, nil); err != nil {
+		t.Fatalf("ModifyRequestTags failed: %v", err)
+	}
+	got, err = store.GetRequest(req.ID)
+	if err != nil {
+		t.Fatalf("GetRequest failed: %v", err)
+	}
+	if _, ok := got.Metadata["tombstone_datetime"]; !ok {
+		t.Errorf("Expected adding a tombstone tag to tombstone the request, got %v", got.Metadata)
+	}
+
+	if _, err := store.ModifyRequestTags("tagmod-missing", []string{"a"}, nil); err == nil || err.Error() != "request not found" {
+		t.Errorf("Expected request not found, got %v", err)
+	}
+}
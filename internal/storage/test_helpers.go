@@ -14,7 +14,7 @@ import (
 // setupTestDB creates a test PostgreSQL database connection string
 // It uses environment variables or defaults to localhost
 // Tests will skip if PostgreSQL is not available
-func setupTestDB(t *testing.T, testName string) (connStr string, cleanup func()) {
+func setupTestDB(t testing.TB, testName string) (connStr string, cleanup func()) {
 	t.Helper()
 
 	// Get PostgreSQL connection parameters from environment or use defaults
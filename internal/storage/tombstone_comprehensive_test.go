@@ -14,6 +14,7 @@ import (
 
 // TestTombstoneConfiguration_CustomTags tests tombstoning with custom tags
 func TestTombstoneConfiguration_CustomTags(t *testing.T) {
+	t.Parallel()
 	connStr, cleanup := setupTestDB(t, "tombstone_custom_tags")
 	defer cleanup()
 
@@ -84,6 +85,7 @@ func TestTombstoneConfiguration_CustomTags(t *testing.T) {
 
 // TestTombstoneConfiguration_CustomPeriods tests different tombstone periods
 func TestTombstoneConfiguration_CustomPeriods(t *testing.T) {
+	t.Parallel()
 	connStr, cleanup := setupTestDB(t, "tombstone_custom_periods")
 	defer cleanup()
 
@@ -131,6 +133,7 @@ func TestTombstoneConfiguration_CustomPeriods(t *testing.T) {
 
 // TestTombstoneConfiguration_MultipleTags tests behavior with multiple tombstone tags
 func TestTombstoneConfiguration_MultipleTags(t *testing.T) {
+	t.Parallel()
 	connStr, cleanup := setupTestDB(t, "tombstone_multiple")
 	defer cleanup()
 
@@ -177,6 +180,7 @@ func TestTombstoneConfiguration_MultipleTags(t *testing.T) {
 
 // TestTombstoneConfiguration_NoTombstoneForNormalTags ensures normal tags don't trigger tombstones
 func TestTombstoneConfiguration_NoTombstoneForNormalTags(t *testing.T) {
+	t.Parallel()
 	connStr, cleanup := setupTestDB(t, "tombstone_no_trigger")
 	defer cleanup()
 
@@ -218,6 +222,7 @@ func TestTombstoneConfiguration_NoTombstoneForNormalTags(t *testing.T) {
 
 // TestTombstoneConfiguration_EdgeCases tests edge cases
 func TestTombstoneConfiguration_EdgeCases(t *testing.T) {
+	t.Parallel()
 	connStr, cleanup := setupTestDB(t, "tombstone_edge")
 	defer cleanup()
 
@@ -264,6 +269,7 @@ func TestTombstoneConfiguration_EdgeCases(t *testing.T) {
 
 // TestTombstoneConfiguration_DefaultValues tests default configuration values
 func TestTombstoneConfiguration_DefaultValues(t *testing.T) {
+	t.Parallel()
 	connStr, cleanup := setupTestDB(t, "tombstone_defaults")
 	defer cleanup()
 
@@ -310,6 +316,7 @@ func TestTombstoneConfiguration_DefaultValues(t *testing.T) {
 
 // TestTombstoneConfiguration_CaseSensitivity tests tag matching is case-sensitive
 func TestTombstoneConfiguration_CaseSensitivity(t *testing.T) {
+	t.Parallel()
 	connStr, cleanup := setupTestDB(t, "tombstone_case")
 	defer cleanup()
 
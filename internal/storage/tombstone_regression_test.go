@@ -23,6 +23,7 @@ import (
  */
 
 func TestUpdateRequestTags_AutoTombstone_LowQuality(t *testing.T) {
+	t.Parallel()
 	connStr, cleanup := setupTestDB(t, "tombstone_lowquality")
 	defer cleanup()
 
@@ -104,6 +105,7 @@ func TestUpdateRequestTags_AutoTombstone_LowQuality(t *testing.T) {
 }
 
 func TestUpdateRequestTags_AutoTombstone_SparseContent(t *testing.T) {
+	t.Parallel()
 	connStr, cleanup := setupTestDB(t, "tombstone_sparse")
 	defer cleanup()
 
@@ -160,6 +162,7 @@ func TestUpdateRequestTags_AutoTombstone_SparseContent(t *testing.T) {
 }
 
 func TestUpdateRequestTags_AutoTombstone_BothTags(t *testing.T) {
+	t.Parallel()
 	connStr, cleanup := setupTestDB(t, "tombstone_both")
 	defer cleanup()
 
@@ -214,6 +217,7 @@ func TestUpdateRequestTags_AutoTombstone_BothTags(t *testing.T) {
 }
 
 func TestUpdateRequestTags_NoAutoTombstone_NormalTags(t *testing.T) {
+	t.Parallel()
 	connStr, cleanup := setupTestDB(t, "tombstone_normal")
 	defer cleanup()
 
@@ -257,6 +261,7 @@ func TestUpdateRequestTags_NoAutoTombstone_NormalTags(t *testing.T) {
 }
 
 func TestUpdateRequestTags_PreservesExistingMetadata(t *testing.T) {
+	t.Parallel()
 	connStr, cleanup := setupTestDB(t, "tombstone_preserve")
 	defer cleanup()
 
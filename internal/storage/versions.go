@@ -0,0 +1,179 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// DefaultMaxRequestVersions is the number of snapshots kept per request when no limit is configured
+const DefaultMaxRequestVersions = 5
+
+// Reasons recorded on a request version
+const (
+	VersionReasonRescrape = "rescrape"
+	VersionReasonAnalysis = "analysis"
+)
+
+// RequestVersion is a snapshot of a request taken before its content was overwritten
+type RequestVersion struct {
+	RequestID  string                 `json:"request_id"`
+	Version    int                    `json:"version"`
+	CapturedAt time.Time              `json:"captured_at"`
+	Reason     string                 `json:"reason"`
+	Tags       []string               `json:"tags"`
+	Metadata   map[string]interface{} `json:"metadata,omitempty"`
+	Slug       *string                `json:"slug,omitempty"`
+}
+
+// SetMaxRequestVersions sets how many snapshots are kept per request (values below 1 use the default)
+func (s *Storage) SetMaxRequestVersions(n int) {
+	s.maxRequestVersions = n
+}
+
+func (s *Storage) versionLimit() int {
+	if s.maxRequestVersions < 1 {
+		return DefaultMaxRequestVersions
+	}
+	return s.maxRequestVersions
+}
+
+// SaveRequestVersion snapshots the current metadata, tags and slug of a request.
+// Version numbers increase monotonically per request; once more than the configured
+// number of versions exist the oldest ones are pruned in the same transaction.
+func (s *Storage) SaveRequestVersion(requestID, reason string) (*RequestVersion, error) {
+	defer s.timeQuery("SaveRequestVersion", "request_id", requestID)()
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Lock the request row so concurrent snapshots cannot pick the same version number
+	var metadataJSON, tagsJSON, slug sql.NullString
+	err = tx.QueryRow(`
+		SELECT metadata_json, tags_json, slug
+		FROM requests
+		WHERE id = $1 AND `+s.inNamespace("")+`
+		FOR UPDATE
+	`, requestID).Scan(&metadataJSON, &tagsJSON, &slug)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("request not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query request: %w", err)
+	}
+
+	version := &RequestVersion{
+		RequestID:  requestID,
+		CapturedAt: time.Now().UTC(),
+		Reason:     reason,
+	}
+
+	err = tx.QueryRow(`
+		INSERT INTO request_versions (request_id, version, captured_at, reason, metadata_json, tags_json, slug)
+		SELECT $1, COALESCE(MAX(version), 0) + 1, $2, $3, $4, $5, $6
+		FROM request_versions
+		WHERE request_id = $1
+		RETURNING version
+	`, requestID, version.CapturedAt, reason, metadataJSON, tagsJSON, slug).Scan(&version.Version)
+	if err != nil {
+		return nil, fmt.Errorf("failed to insert request version: %w", err)
+	}
+
+	_, err = tx.Exec(`
+		DELETE FROM request_versions
+		WHERE request_id = $1 AND version <= $2
+	`, requestID, version.Version-s.versionLimit())
+	if err != nil {
+		return nil, fmt.Errorf("failed to prune request versions: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	if err := decodeRequestVersion(version, metadataJSON, tagsJSON, slug); err != nil {
+		return nil, err
+	}
+
+	return version, nil
+}
+
+// ListRequestVersions returns the stored snapshots of a request, newest first
+func (s *Storage) ListRequestVersions(requestID string) ([]*RequestVersion, error) {
+	defer s.timeQuery("ListRequestVersions", "request_id", requestID)()
+	rows, err := s.db.Query(`
+		SELECT version, captured_at, reason, metadata_json, tags_json, slug
+		FROM request_versions
+		WHERE request_id = $1
+		ORDER BY version DESC
+	`, requestID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list request versions: %w", err)
+	}
+	defer rows.Close()
+
+	versions := []*RequestVersion{}
+	for rows.Next() {
+		version := &RequestVersion{RequestID: requestID}
+		var metadataJSON, tagsJSON, slug sql.NullString
+		if err := rows.Scan(&version.Version, &version.CapturedAt, &version.Reason, &metadataJSON, &tagsJSON, &slug); err != nil {
+			return nil, fmt.Errorf("failed to scan request version: %w", err)
+		}
+		if err := decodeRequestVersion(version, metadataJSON, tagsJSON, slug); err != nil {
+			return nil, err
+		}
+		versions = append(versions, version)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating request versions: %w", err)
+	}
+
+	return versions, nil
+}
+
+// GetRequestVersion returns a single snapshot of a request
+func (s *Storage) GetRequestVersion(requestID string, versionNumber int) (*RequestVersion, error) {
+	defer s.timeQuery("GetRequestVersion", "request_id", requestID, "version", versionNumber)()
+	version := &RequestVersion{RequestID: requestID}
+	var metadataJSON, tagsJSON, slug sql.NullString
+
+	err := s.db.QueryRow(`
+		SELECT version, captured_at, reason, metadata_json, tags_json, slug
+		FROM request_versions
+		WHERE request_id = $1 AND version = $2
+	`, requestID, versionNumber).Scan(&version.Version, &version.CapturedAt, &version.Reason, &metadataJSON, &tagsJSON, &slug)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("version not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query request version: %w", err)
+	}
+
+	if err := decodeRequestVersion(version, metadataJSON, tagsJSON, slug); err != nil {
+		return nil, err
+	}
+
+	return version, nil
+}
+
+func decodeRequestVersion(version *RequestVersion, metadataJSON, tagsJSON, slug sql.NullString) error {
+	if tagsJSON.Valid && tagsJSON.String != "" {
+		if err := json.Unmarshal([]byte(tagsJSON.String), &version.Tags); err != nil {
+			return fmt.Errorf("failed to unmarshal version tags: %w", err)
+		}
+	}
+	if metadataJSON.Valid && metadataJSON.String != "" {
+		if err := json.Unmarshal([]byte(metadataJSON.String), &version.Metadata); err != nil {
+			return fmt.Errorf("failed to unmarshal version metadata: %w", err)
+		}
+	}
+	if slug.Valid {
+		slugStr := slug.String
+		version.Slug = &slugStr
+	}
+	return nil
+}
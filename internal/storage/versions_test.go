@@ -0,0 +1,148 @@
+package storage
+
+import (
+	"testing"
+	"time"
+)
+
+func saveVersionedRequest(t *testing.T, store *Storage, id string) {
+	t.Helper()
+	slug := id + "-slug"
+	req := &Request{
+		ID:               id,
+		CreatedAt:        time.Now().UTC(),
+		SourceType:       "url",
+		TextAnalyzerUUID: "ta-1",
+		Tags:             []string{"original"},
+		Metadata: map[string]interface{}{
+			"title":               "Original title",
+			"textanalyzer_status": "completed",
+		},
+		Slug: &slug,
+	}
+	if err := store.SaveRequest(req); err != nil {
+		t.Fatalf("Failed to save request: %v", err)
+	}
+}
+
+func TestRescrapeProducesOneVersion(t *testing.T) {
+	t.Parallel()
+	store, cleanup := setupTestStorage(t)
+	defer cleanup()
+
+	saveVersionedRequest(t, store, "version-req")
+
+	// A re-scrape snapshots once, then overwrites metadata and tags
+	version, err := store.SaveRequestVersion("version-req", VersionReasonRescrape)
+	if err != nil {
+		t.Fatalf("SaveRequestVersion failed: %v", err)
+	}
+	if version.Version != 1 {
+		t.Errorf("Expected version 1, got %d", version.Version)
+	}
+	if err := store.UpdateRequestMetadata("version-req", map[string]interface{}{"title": "New title"}); err != nil {
+		t.Fatalf("UpdateRequestMetadata failed: %v", err)
+	}
+	if err := store.UpdateRequestTags("version-req", []string{"updated"}); err != nil {
+		t.Fatalf("UpdateRequestTags failed: %v", err)
+	}
+
+	versions, err := store.ListRequestVersions("version-req")
+	if err != nil {
+		t.Fatalf("ListRequestVersions failed: %v", err)
+	}
+	if len(versions) != 1 {
+		t.Fatalf("Expected exactly 1 version after re-scrape, got %d", len(versions))
+	}
+
+	got, err := store.GetRequestVersion("version-req", 1)
+	if err != nil {
+		t.Fatalf("GetRequestVersion failed: %v", err)
+	}
+	if got.Reason != VersionReasonRescrape {
+		t.Errorf("Expected reason %q, got %q", VersionReasonRescrape, got.Reason)
+	}
+	if got.Metadata["title"] != "Original title" {
+		t.Errorf("Expected snapshot of original metadata, got %v", got.Metadata)
+	}
+	if len(got.Tags) != 1 || got.Tags[0] != "original" {
+		t.Errorf("Expected snapshot of original tags, got %v", got.Tags)
+	}
+	if got.Slug == nil || *got.Slug != "version-req-slug" {
+		t.Errorf("Expected snapshot of slug, got %v", got.Slug)
+	}
+
+	// The live request reflects the overwrite
+	current, err := store.GetRequest("version-req")
+	if err != nil {
+		t.Fatalf("GetRequest failed: %v", err)
+	}
+	if current.Metadata["title"] != "New title" {
+		t.Errorf("Expected live request to be updated, got %v", current.Metadata)
+	}
+}
+
+func TestRequestVersionPruning(t *testing.T) {
+	t.Parallel()
+	store, cleanup := setupTestStorage(t)
+	defer cleanup()
+
+	store.SetMaxRequestVersions(3)
+	saveVersionedRequest(t, store, "prune-req")
+
+	for i := 0; i < 5; i++ {
+		if _, err := store.SaveRequestVersion("prune-req", VersionReasonAnalysis); err != nil {
+			t.Fatalf("SaveRequestVersion %d failed: %v", i, err)
+		}
+	}
+
+	versions, err := store.ListRequestVersions("prune-req")
+	if err != nil {
+		t.Fatalf("ListRequestVersions failed: %v", err)
+	}
+	if len(versions) != 3 {
+		t.Fatalf("Expected 3 versions after pruning, got %d", len(versions))
+	}
+	// Newest first, oldest pruned
+	for i, want := range []int{5, 4, 3} {
+		if versions[i].Version != want {
+			t.Errorf("versions[%d] = %d, want %d", i, versions[i].Version, want)
+		}
+	}
+
+	if _, err := store.GetRequestVersion("prune-req", 1); err == nil || err.Error() != "version not found" {
+		t.Errorf("Expected pruned version to be not found, got %v", err)
+	}
+}
+
+func TestSaveRequestVersionNotFound(t *testing.T) {
+	t.Parallel()
+	store, cleanup := setupTestStorage(t)
+	defer cleanup()
+
+	if _, err := store.SaveRequestVersion("missing", VersionReasonRescrape); err == nil || err.Error() != "request not found" {
+		t.Errorf("Expected 'request not found', got %v", err)
+	}
+}
+
+func TestRequestVersionsDeletedWithRequest(t *testing.T) {
+	t.Parallel()
+	store, cleanup := setupTestStorage(t)
+	defer cleanup()
+
+	saveVersionedRequest(t, store, "cascade-req")
+	if _, err := store.SaveRequestVersion("cascade-req", VersionReasonRescrape); err != nil {
+		t.Fatalf("SaveRequestVersion failed: %v", err)
+	}
+	if err := store.DeleteRequest("cascade-req"); err != nil {
+		t.Fatalf("DeleteRequest failed: %v", err)
+	}
+
+	versions, err := store.ListRequestVersions("cascade-req")
+	if err != nil {
+		t.Fatalf("ListRequestVersions failed: %v", err)
+	}
+	if len(versions) != 0 {
+		t.Errorf("Expected versions to be removed with the request, got %d", len(versions))
+	}
+}
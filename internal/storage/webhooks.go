@@ -0,0 +1,262 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// MaxWebhookDeliveries is how many delivery log entries are kept per webhook
+const MaxWebhookDeliveries = 100
+
+// Webhook is a subscription that receives events of the listed types
+type Webhook struct {
+	ID                  string     `json:"id"`
+	URL                 string     `json:"url"`
+	Secret              string     `json:"-"` // Signs deliveries; only returned when the webhook is created
+	EventTypes          []string   `json:"event_types"`
+	Enabled             bool       `json:"enabled"`
+	ConsecutiveFailures int        `json:"consecutive_failures"`
+	DisabledAt          *time.Time `json:"disabled_at,omitempty"` // Set when repeated failures disabled the webhook
+	CreatedBy           string     `json:"created_by"`
+	Namespace           string     `json:"namespace"` // Only events about records in this namespace are delivered
+	CreatedAt           time.Time  `json:"created_at"`
+	UpdatedAt           time.Time  `json:"updated_at"`
+}
+
+// WebhookDelivery is the outcome of delivering one event to one webhook, after any retries
+type WebhookDelivery struct {
+	ID         int64     `json:"id"`
+	WebhookID  string    `json:"webhook_id"`
+	EventID    string    `json:"event_id"`
+	EventType  string    `json:"event_type"`
+	Success    bool      `json:"success"`
+	StatusCode int       `json:"status_code,omitempty"` // Status of the last attempt; 0 if no response was received
+	Attempts   int       `json:"attempts"`
+	Error      string    `json:"error,omitempty"`
+	DurationMS int64     `json:"duration_ms"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+const webhookColumns = "id, url, secret, event_types, enabled, consecutive_failures, disabled_at, created_by, namespace, created_at, updated_at"
+
+func scanWebhook(row interface{ Scan(...any) error }) (*Webhook, error) {
+	var hook Webhook
+	var disabledAt sql.NullTime
+	if err := row.Scan(&hook.ID, &hook.URL, &hook.Secret, pq.Array(&hook.EventTypes), &hook.Enabled,
+		&hook.ConsecutiveFailures, &disabledAt, &hook.CreatedBy, &hook.Namespace, &hook.CreatedAt, &hook.UpdatedAt); err != nil {
+		return nil, err
+	}
+	if disabledAt.Valid {
+		hook.DisabledAt = &disabledAt.Time
+	}
+	return &hook, nil
+}
+
+// CreateWebhook stores a new webhook in s's namespace unless it names its own, setting its
+// timestamps
+func (s *Storage) CreateWebhook(hook *Webhook) error {
+	defer s.timeQuery("CreateWebhook", "id", hook.ID)()
+	now := time.Now().UTC()
+	hook.CreatedAt, hook.UpdatedAt = now, now
+	hook.Namespace = s.namespaceFor(hook.Namespace)
+	_, err := s.db.Exec(`
+		INSERT INTO webhooks (id, url, secret, event_types, enabled, created_by, namespace, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	`, hook.ID, hook.URL, hook.Secret, pq.Array(hook.EventTypes), hook.Enabled, hook.CreatedBy, hook.Namespace, hook.CreatedAt, hook.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create webhook: %w", err)
+	}
+	return nil
+}
+
+// GetWebhook returns a webhook, or nil if there is none with the ID
+func (s *Storage) GetWebhook(id string) (*Webhook, error) {
+	defer s.timeQuery("GetWebhook", "id", id)()
+	hook, err := scanWebhook(s.db.QueryRow(`SELECT `+webhookColumns+` FROM webhooks WHERE id = $1 AND `+s.inNamespace(""), id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webhook: %w", err)
+	}
+	return hook, nil
+}
+
+// ListWebhooks returns every webhook in s's namespace, oldest first
+func (s *Storage) ListWebhooks() ([]*Webhook, error) {
+	defer s.timeQuery("ListWebhooks")()
+	return s.queryWebhooks(`SELECT ` + webhookColumns + ` FROM webhooks WHERE ` + s.inNamespace("") + ` ORDER BY created_at, id`)
+}
+
+// ListActiveWebhooks returns the enabled webhooks of namespace subscribed to eventType. An
+// empty namespace means s's namespace, or the default one.
+func (s *Storage) ListActiveWebhooks(namespace, eventType string) ([]*Webhook, error) {
+	defer s.timeQuery("ListActiveWebhooks", "namespace", namespace, "event_type", eventType)()
+	return s.queryWebhooks(`
+		SELECT `+webhookColumns+`
+		FROM webhooks
+		WHERE enabled AND $1 = ANY(event_types) AND namespace = $2
+		ORDER BY created_at, id
+	`, eventType, s.namespaceFor(namespace))
+}
+
+func (s *Storage) queryWebhooks(query string, args ...any) ([]*Webhook, error) {
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhooks: %w", err)
+	}
+	defer rows.Close()
+
+	hooks := []*Webhook{}
+	for rows.Next() {
+		hook, err := scanWebhook(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan webhook: %w", err)
+		}
+		hooks = append(hooks, hook)
+	}
+	return hooks, rows.Err()
+}
+
+// UpdateWebhook replaces a webhook's URL, event types and enabled flag, and its secret when
+// hook.Secret is not empty. Enabling a webhook clears its failure count. The stored row is
+// read back into hook.
+func (s *Storage) UpdateWebhook(hook *Webhook) error {
+	defer s.timeQuery("UpdateWebhook", "id", hook.ID)()
+	updated, err := scanWebhook(s.db.QueryRow(`
+		UPDATE webhooks
+		SET url = $2,
+		    secret = COALESCE(NULLIF($3, ''), secret),
+		    event_types = $4,
+		    consecutive_failures = CASE WHEN $5 AND NOT enabled THEN 0 ELSE consecutive_failures END,
+		    disabled_at = CASE WHEN $5 THEN NULL ELSE disabled_at END,
+		    enabled = $5,
+		    updated_at = NOW()
+		WHERE id = $1 AND `+s.inNamespace("")+`
+		RETURNING `+webhookColumns,
+		hook.ID, hook.URL, hook.Secret, pq.Array(hook.EventTypes), hook.Enabled))
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("webhook not found")
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update webhook: %w", err)
+	}
+	*hook = *updated
+	return nil
+}
+
+// DeleteWebhook removes a webhook and its delivery log
+func (s *Storage) DeleteWebhook(id string) error {
+	defer s.timeQuery("DeleteWebhook", "id", id)()
+	result, err := s.db.Exec("DELETE FROM webhooks WHERE id = $1 AND "+s.inNamespace(""), id)
+	if err != nil {
+		return fmt.Errorf("failed to delete webhook: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("webhook not found")
+	}
+	return nil
+}
+
+// RecordWebhookDelivery logs a delivery and updates the webhook's consecutive failure count:
+// a success resets it, a failure increments it, and reaching maxFailures disables the webhook
+// (maxFailures 0 never disables). Only the newest MaxWebhookDeliveries entries are kept.
+// It reports whether this delivery disabled the webhook.
+func (s *Storage) RecordWebhookDelivery(delivery *WebhookDelivery, maxFailures int) (bool, error) {
+	defer s.timeQuery("RecordWebhookDelivery", "webhook_id", delivery.WebhookID, "event_type", delivery.EventType)()
+	tx, err := s.db.Begin()
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRow(`
+		INSERT INTO webhook_deliveries (webhook_id, event_id, event_type, success, status_code, attempts, error, duration_ms)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id, created_at
+	`, delivery.WebhookID, delivery.EventID, delivery.EventType, delivery.Success, delivery.StatusCode,
+		delivery.Attempts, delivery.Error, delivery.DurationMS).Scan(&delivery.ID, &delivery.CreatedAt)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23503" {
+			return false, nil // The webhook was deleted while the event was in flight
+		}
+		return false, fmt.Errorf("failed to record webhook delivery: %w", err)
+	}
+
+	_, err = tx.Exec(`
+		DELETE FROM webhook_deliveries
+		WHERE webhook_id = $1 AND id NOT IN (
+			SELECT id FROM webhook_deliveries WHERE webhook_id = $1 ORDER BY id DESC LIMIT $2
+		)
+	`, delivery.WebhookID, MaxWebhookDeliveries)
+	if err != nil {
+		return false, fmt.Errorf("failed to prune webhook deliveries: %w", err)
+	}
+
+	var failures int
+	var enabled bool
+	err = tx.QueryRow(`SELECT consecutive_failures, enabled FROM webhooks WHERE id = $1 FOR UPDATE`,
+		delivery.WebhookID).Scan(&failures, &enabled)
+	if err != nil {
+		return false, fmt.Errorf("failed to read webhook failures: %w", err)
+	}
+	failures++
+	if delivery.Success {
+		failures = 0
+	}
+	disabled := enabled && maxFailures > 0 && failures >= maxFailures
+
+	_, err = tx.Exec(`
+		UPDATE webhooks
+		SET consecutive_failures = $2,
+		    enabled = enabled AND NOT $3,
+		    disabled_at = CASE WHEN $3 THEN NOW() ELSE disabled_at END
+		WHERE id = $1
+	`, delivery.WebhookID, failures, disabled)
+	if err != nil {
+		return false, fmt.Errorf("failed to update webhook failures: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to commit webhook delivery: %w", err)
+	}
+	return disabled, nil
+}
+
+// ListWebhookDeliveries returns the most recent deliveries of a webhook in s's namespace,
+// newest first
+func (s *Storage) ListWebhookDeliveries(webhookID string, limit, offset int) ([]*WebhookDelivery, error) {
+	defer s.timeQuery("ListWebhookDeliveries", "webhook_id", webhookID, "limit", limit, "offset", offset)()
+	rows, err := s.db.Query(`
+		SELECT id, webhook_id, event_id, event_type, success, status_code, attempts, error, duration_ms, created_at
+		FROM webhook_deliveries
+		WHERE webhook_id = $1
+		AND webhook_id IN (SELECT id FROM webhooks WHERE `+s.inNamespace("")+`)
+		ORDER BY id DESC
+		LIMIT $2 OFFSET $3
+	`, webhookID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhook deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	deliveries := []*WebhookDelivery{}
+	for rows.Next() {
+		var d WebhookDelivery
+		if err := rows.Scan(&d.ID, &d.WebhookID, &d.EventID, &d.EventType, &d.Success, &d.StatusCode,
+			&d.Attempts, &d.Error, &d.DurationMS, &d.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan webhook delivery: %w", err)
+		}
+		deliveries = append(deliveries, &d)
+	}
+	return deliveries, rows.Err()
+}
@@ -0,0 +1,82 @@
+package storage
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestWebhookDeliveriesDisableAfterFailures(t *testing.T) {
+	t.Parallel()
+	store, cleanup := setupTestStorage(t)
+	defer cleanup()
+
+	hook := &Webhook{ID: "hook-1", URL: "https://hooks.example.com/", Secret: "0123456789abcdef", EventTypes: []string{"scrape.completed"}, Enabled: true}
+	if err := store.CreateWebhook(hook); err != nil {
+		t.Fatalf("CreateWebhook failed: %v", err)
+	}
+	active, err := store.ListActiveWebhooks("", "scrape.completed")
+	if err != nil || len(active) != 1 {
+		t.Fatalf("Expected the webhook to be active, got %d (%v)", len(active), err)
+	}
+	if other, _ := store.ListActiveWebhooks("", "request.deleted"); len(other) != 0 {
+		t.Errorf("Expected no webhooks for an unsubscribed event, got %d", len(other))
+	}
+
+	record := func(success bool) bool {
+		t.Helper()
+		disabled, err := store.RecordWebhookDelivery(&WebhookDelivery{WebhookID: hook.ID, EventID: "e", EventType: "scrape.completed", Success: success, Attempts: 1}, 3)
+		if err != nil {
+			t.Fatalf("RecordWebhookDelivery failed: %v", err)
+		}
+		return disabled
+	}
+
+	// A success in between resets the count
+	record(false)
+	record(false)
+	record(true)
+	if record(false) || record(false) {
+		t.Fatal("Expected the webhook to stay enabled below 3 consecutive failures")
+	}
+	if !record(false) {
+		t.Fatal("Expected the third consecutive failure to disable the webhook")
+	}
+	got, _ := store.GetWebhook(hook.ID)
+	if got.Enabled || got.DisabledAt == nil || got.ConsecutiveFailures != 3 {
+		t.Errorf("Expected a disabled webhook with 3 failures, got %+v", got)
+	}
+	if active, _ := store.ListActiveWebhooks("", "scrape.completed"); len(active) != 0 {
+		t.Errorf("Expected disabled webhooks to be left out, got %d", len(active))
+	}
+
+	// Enabling it again clears the failures
+	got.Enabled = true
+	got.Secret = ""
+	if err := store.UpdateWebhook(got); err != nil {
+		t.Fatalf("UpdateWebhook failed: %v", err)
+	}
+	if !got.Enabled || got.DisabledAt != nil || got.ConsecutiveFailures != 0 || got.Secret != "0123456789abcdef" {
+		t.Errorf("Expected a re-enabled webhook with its secret kept, got %+v", got)
+	}
+
+	// The log keeps only the newest entries
+	for i := 0; i < MaxWebhookDeliveries+5; i++ {
+		if _, err := store.RecordWebhookDelivery(&WebhookDelivery{WebhookID: hook.ID, EventID: fmt.Sprintf("e%d", i), EventType: "scrape.completed", Success: true, Attempts: 1}, 3); err != nil {
+			t.Fatalf("RecordWebhookDelivery failed: %v", err)
+		}
+	}
+	deliveries, err := store.ListWebhookDeliveries(hook.ID, 1000, 0)
+	if err != nil {
+		t.Fatalf("ListWebhookDeliveries failed: %v", err)
+	}
+	if len(deliveries) != MaxWebhookDeliveries || deliveries[0].EventID != fmt.Sprintf("e%d", MaxWebhookDeliveries+4) {
+		t.Errorf("Expected the newest %d deliveries, got %d", MaxWebhookDeliveries, len(deliveries))
+	}
+
+	if err := store.DeleteWebhook(hook.ID); err != nil {
+		t.Fatalf("DeleteWebhook failed: %v", err)
+	}
+	if deliveries, _ := store.ListWebhookDeliveries(hook.ID, 10, 0); len(deliveries) != 0 {
+		t.Errorf("Expected the delivery log to be deleted with the webhook, got %d", len(deliveries))
+	}
+}
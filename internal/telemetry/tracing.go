@@ -0,0 +1,70 @@
+// Package telemetry initializes tracing from the controller's configuration
+package telemetry
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// DefaultServiceName is the service name traces are reported under
+const DefaultServiceName = "docutab-controller"
+
+// Standard OpenTelemetry environment variables read by the SDK tracer provider and the OTLP exporter
+const (
+	envTracesSampler    = "OTEL_TRACES_SAMPLER"
+	envTracesSamplerArg = "OTEL_TRACES_SAMPLER_ARG"
+	envOTLPEndpoint     = "OTEL_EXPORTER_OTLP_ENDPOINT"
+)
+
+// TracingConfig controls how tracing is initialized
+type TracingConfig struct {
+	Enabled     bool
+	SampleRatio float64 // Fraction of new traces recorded, 0.0-1.0; spans under a sampled remote parent always are
+	Endpoint    string  // OTLP collector endpoint; empty keeps the exporter's own default
+	ServiceName string  // Empty uses DefaultServiceName
+}
+
+// InitTracerFunc creates the tracer provider for a service, e.g. tracing.InitTracer
+type InitTracerFunc func(serviceName string) (*sdktrace.TracerProvider, error)
+
+// InitTracing installs the global tracer provider. When tracing is disabled it installs a no-op
+// provider and returns nil: spans cost nothing, and trace IDs in logs and task payloads stay
+// empty. Otherwise the provider is created with initTracer. That takes only a service name, so
+// the sample ratio and endpoint reach it through the standard OpenTelemetry environment
+// variables its SDK provider and OTLP exporter read.
+func InitTracing(cfg TracingConfig, initTracer InitTracerFunc) (*sdktrace.TracerProvider, error) {
+	if !cfg.Enabled {
+		otel.SetTracerProvider(noop.NewTracerProvider())
+		return nil, nil
+	}
+
+	// Parent-based, so a request continuing a sampled trace from another service stays in it
+	env := map[string]string{
+		envTracesSampler:    "parentbased_traceidratio",
+		envTracesSamplerArg: strconv.FormatFloat(cfg.SampleRatio, 'g', -1, 64),
+	}
+	if cfg.Endpoint != "" {
+		env[envOTLPEndpoint] = cfg.Endpoint
+	}
+	for key, value := range env {
+		if err := os.Setenv(key, value); err != nil {
+			return nil, fmt.Errorf("failed to set %s: %w", key, err)
+		}
+	}
+
+	serviceName := cfg.ServiceName
+	if serviceName == "" {
+		serviceName = DefaultServiceName
+	}
+	tp, err := initTracer(serviceName)
+	if err != nil {
+		return nil, err
+	}
+	otel.SetTracerProvider(tp)
+	return tp, nil
+}
@@ -1,32 +1,43 @@
 package templates
 
 import (
-	"bytes"
-	"fmt"
 	"html/template"
 	"math/rand"
-	"strings"
 	"time"
 )
 
-// ContentPageData contains data for rendering a content page
+// ContentPageData is the context content page templates are executed with. Custom templates
+// (see Renderer) may rely on every field documented here: fields are added over time but not
+// renamed or removed.
 type ContentPageData struct {
-	Title            string
-	Description      string
-	Content          string
-	Author           string
-	Keywords         []string
-	PublishedDate    string
-	ModifiedDate     string
-	CanonicalURL     string
-	OGImage          string
-	JSONLDSchema     string
-	BaseURL          string
-	WebInterfaceURL  string
-	BestImageSlug    string   // Best scored image for mid-article insertion
-	RequestID        string   // Request ID for linking to admin interface
-	ScraperBaseURL   string   // Scraper service URL for image serving
-	SourceURL        string   // Original source URL for the article
+	Title       string        // Document title, "Untitled" when unknown
+	Synopsis    string        // Analyzer synopsis, or the scraped description when there is none
+	ContentHTML template.HTML // Article body as HTML, with the best scored image inserted midway
+	PublishedAt time.Time     // Publish date of the document, or when it was added; never zero
+	Tags        []string      // Document tags
+	Images      []PageImage   // Document images, best scored first
+	Canonical   string        // Canonical URL of the page
+	Related     []RelatedPage // Other public documents sharing a tag, at most five
+
+	Author          string // Byline; empty when unknown
+	OGImage         string // Thumbnail URL for Open Graph and Twitter cards
+	JSONLDSchema    string // Article structured data (JSON-LD)
+	BaseURL         string // Public base URL of the site
+	WebInterfaceURL string // Web interface the page links back to
+	RequestID       string // Request ID for linking to admin interface
+	SourceURL       string // Original source URL for the article
+}
+
+// PageImage is an image of the document a content page shows
+type PageImage struct {
+	URL     string // Public URL of the image
+	AltText string
+}
+
+// RelatedPage links to another public document
+type RelatedPage struct {
+	Title string
+	URL   string // Canonical URL of the document's content page
 }
 
 // contentTemplate defines the HTML template for a content page
@@ -38,23 +49,23 @@ const contentTemplate = `<!DOCTYPE html>
 	<title>{{.Title}}</title>
 
 	<!-- Meta Tags -->
-	<meta name="description" content="{{.Description}}">
-	{{if .Keywords}}
-	<meta name="keywords" content="{{join .Keywords ", "}}">
+	<meta name="description" content="{{.Synopsis}}">
+	{{if .Tags}}
+	<meta name="keywords" content="{{join .Tags ", "}}">
 	{{end}}
 	{{if .Author}}
 	<meta name="author" content="{{.Author}}">
 	{{end}}
-	{{if .CanonicalURL}}
-	<link rel="canonical" href="{{.CanonicalURL}}">
+	{{if .Canonical}}
+	<link rel="canonical" href="{{.Canonical}}">
 	{{end}}
 
 	<!-- Open Graph Tags -->
 	<meta property="og:type" content="article">
 	<meta property="og:title" content="{{.Title}}">
-	<meta property="og:description" content="{{.Description}}">
-	{{if .CanonicalURL}}
-	<meta property="og:url" content="{{.CanonicalURL}}">
+	<meta property="og:description" content="{{.Synopsis}}">
+	{{if .Canonical}}
+	<meta property="og:url" content="{{.Canonical}}">
 	{{end}}
 	{{if .OGImage}}
 	<meta property="og:image" content="{{.OGImage}}">
@@ -63,7 +74,7 @@ const contentTemplate = `<!DOCTYPE html>
 	<!-- Twitter Card Tags -->
 	<meta name="twitter:card" content="summary_large_image">
 	<meta name="twitter:title" content="{{.Title}}">
-	<meta name="twitter:description" content="{{.Description}}">
+	<meta name="twitter:description" content="{{.Synopsis}}">
 	{{if .OGImage}}
 	<meta name="twitter:image" content="{{.OGImage}}">
 	{{end}}
@@ -249,24 +260,21 @@ const contentTemplate = `<!DOCTYPE html>
 			<article>
 				<h1>{{.Title}}</h1>
 
-				{{if or .Author .PublishedDate}}
 				<div class="meta">
-					{{if .Author}}<span>By <strong>{{.Author}}</strong></span>{{end}}
-					{{if and .Author .PublishedDate}} • {{end}}
-					{{if .PublishedDate}}<time datetime="{{.PublishedDate}}">{{.PublishedDate}}</time>{{end}}
+					{{if .Author}}<span>By <strong>{{.Author}}</strong></span> • {{end}}
+					{{$date := .PublishedAt.Format "2006-01-02"}}<time datetime="{{$date}}">{{$date}}</time>
 				</div>
-				{{end}}
 
-				{{if .Keywords}}
+				{{if .Tags}}
 				<div class="keywords">
-					{{range .Keywords}}
+					{{range .Tags}}
 					<span class="keyword">{{.}}</span>
 					{{end}}
 				</div>
 				{{end}}
 
 				<div class="content">
-					{{.Content | safeHTML}}
+					{{.ContentHTML}}
 				</div>
 
 				{{if .SourceURL}}
@@ -280,6 +288,17 @@ const contentTemplate = `<!DOCTYPE html>
 					</div>
 				</div>
 				{{end}}
+
+				{{if .Related}}
+				<aside class="related">
+					<h2 class="h5">Related</h2>
+					<ul>
+						{{range .Related}}
+						<li><a href="{{.URL}}">{{.Title}}</a></li>
+						{{end}}
+					</ul>
+				</aside>
+				{{end}}
 			</article>
 
 			<footer>
@@ -319,26 +338,8 @@ func getRandomPhrase() string {
 	return originalArticlePhrases[rand.Intn(len(originalArticlePhrases))]
 }
 
-// RenderContentPage renders a content page with SEO optimizations
+// RenderContentPage renders a content page with the built-in template
 func RenderContentPage(data ContentPageData) (string, error) {
-	// Create template with custom functions
-	funcMap := template.FuncMap{
-		"join": strings.Join,
-		"safeHTML": func(s string) template.HTML {
-			return template.HTML(s)
-		},
-		"randomPhrase": getRandomPhrase,
-	}
-
-	tmpl, err := template.New("content").Funcs(funcMap).Parse(contentTemplate)
-	if err != nil {
-		return "", fmt.Errorf("failed to parse template: %w", err)
-	}
-
-	var buf bytes.Buffer
-	if err := tmpl.Execute(&buf, data); err != nil {
-		return "", fmt.Errorf("failed to execute template: %w", err)
-	}
-
-	return buf.String(), nil
+	var r *Renderer
+	return r.RenderContent(data)
 }
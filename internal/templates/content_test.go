@@ -3,20 +3,22 @@ package templates
 import (
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestRenderContentPage(t *testing.T) {
 	data := ContentPageData{
-		Title:         "Test Article",
-		Description:   "This is a test article description",
-		Content:       "<p>Article content here</p>",
-		Author:        "John Doe",
-		Keywords:      []string{"technology", "programming", "web"},
-		PublishedDate: "2025-10-22",
-		CanonicalURL:  "https://example.com/content/test-article",
-		OGImage:       "https://example.com/images/test.jpg",
-		JSONLDSchema:  `{"@context": "https://schema.org", "@type": "Article"}`,
-		BaseURL:       "https://example.com",
+		Title:        "Test Article",
+		Synopsis:     "This is a test article description",
+		ContentHTML:  "<p>Article content here</p>",
+		Author:       "John Doe",
+		Tags:         []string{"technology", "programming", "web"},
+		PublishedAt:  time.Date(2025, 10, 22, 9, 30, 0, 0, time.UTC),
+		Canonical:    "https://example.com/content/test-article",
+		OGImage:      "https://example.com/images/test.jpg",
+		JSONLDSchema: `{"@context": "https://schema.org", "@type": "Article"}`,
+		BaseURL:      "https://example.com",
+		Related:      []RelatedPage{{Title: "Another Article", URL: "https://example.com/content/another"}},
 	}
 
 	html, err := RenderContentPage(data)
@@ -109,13 +111,18 @@ func TestRenderContentPage(t *testing.T) {
 	if !strings.Contains(html, `<time datetime="2025-10-22">2025-10-22</time>`) {
 		t.Error("Missing or incorrect time tag")
 	}
+
+	// Verify related pages
+	if !strings.Contains(html, `<a href="https://example.com/content/another">Another Article</a>`) {
+		t.Error("Missing related page link")
+	}
 }
 
 func TestRenderContentPageMinimal(t *testing.T) {
 	// Test with minimal required data
 	data := ContentPageData{
-		Title:   "Minimal Article",
-		Content: "<p>Content</p>",
+		Title:       "Minimal Article",
+		ContentHTML: "<p>Content</p>",
 	}
 
 	html, err := RenderContentPage(data)
@@ -139,9 +146,9 @@ func TestRenderContentPageMinimal(t *testing.T) {
 
 func TestRenderContentPageNoKeywords(t *testing.T) {
 	data := ContentPageData{
-		Title:    "No Keywords Article",
-		Content:  "<p>Content</p>",
-		Keywords: []string{},
+		Title:       "No Keywords Article",
+		ContentHTML: "<p>Content</p>",
+		Tags:        []string{},
 	}
 
 	html, err := RenderContentPage(data)
@@ -158,8 +165,8 @@ func TestRenderContentPageNoKeywords(t *testing.T) {
 
 func TestRenderContentPageNoAuthor(t *testing.T) {
 	data := ContentPageData{
-		Title:   "No Author Article",
-		Content: "<p>Content</p>",
+		Title:       "No Author Article",
+		ContentHTML: "<p>Content</p>",
 	}
 
 	html, err := RenderContentPage(data)
@@ -175,8 +182,8 @@ func TestRenderContentPageNoAuthor(t *testing.T) {
 
 func TestRenderContentPageNoImages(t *testing.T) {
 	data := ContentPageData{
-		Title:   "No Images Article",
-		Content: "<p>Content</p>",
+		Title:       "No Images Article",
+		ContentHTML: "<p>Content</p>",
 	}
 
 	html, err := RenderContentPage(data)
@@ -195,8 +202,8 @@ func TestRenderContentPageNoImages(t *testing.T) {
 
 func TestRenderContentPageNoJSONLD(t *testing.T) {
 	data := ContentPageData{
-		Title:   "No Schema Article",
-		Content: "<p>Content</p>",
+		Title:       "No Schema Article",
+		ContentHTML: "<p>Content</p>",
 	}
 
 	html, err := RenderContentPage(data)
@@ -213,8 +220,8 @@ func TestRenderContentPageNoJSONLD(t *testing.T) {
 func TestRenderContentPageHTMLEscaping(t *testing.T) {
 	data := ContentPageData{
 		Title:       "Article with <script>alert('xss')</script>",
-		Description: "Description with <script>alert('xss')</script>",
-		Content:     "<script>alert('This should be safe')</script>",
+		Synopsis:    "Description with <script>alert('xss')</script>",
+		ContentHTML: "<script>alert('This should be safe')</script>",
 	}
 
 	html, err := RenderContentPage(data)
@@ -233,15 +240,15 @@ func TestRenderContentPageHTMLEscaping(t *testing.T) {
 	}
 
 	if !contentAsIs {
-		t.Error("Content should be rendered as HTML (not escaped) due to safeHTML")
+		t.Error("Content should be rendered as HTML (not escaped)")
 	}
 }
 
 func TestRenderContentPageKeywordsSeparator(t *testing.T) {
 	data := ContentPageData{
-		Title:    "Keywords Test",
-		Content:  "<p>Content</p>",
-		Keywords: []string{"one", "two", "three"},
+		Title:       "Keywords Test",
+		ContentHTML: "<p>Content</p>",
+		Tags:        []string{"one", "two", "three"},
 	}
 
 	html, err := RenderContentPage(data)
@@ -257,8 +264,8 @@ func TestRenderContentPageKeywordsSeparator(t *testing.T) {
 
 func TestRenderContentPageResponsiveViewport(t *testing.T) {
 	data := ContentPageData{
-		Title:   "Responsive Test",
-		Content: "<p>Content</p>",
+		Title:       "Responsive Test",
+		ContentHTML: "<p>Content</p>",
 	}
 
 	html, err := RenderContentPage(data)
@@ -274,8 +281,8 @@ func TestRenderContentPageResponsiveViewport(t *testing.T) {
 
 func TestRenderContentPageCharset(t *testing.T) {
 	data := ContentPageData{
-		Title:   "Charset Test",
-		Content: "<p>Content</p>",
+		Title:       "Charset Test",
+		ContentHTML: "<p>Content</p>",
 	}
 
 	html, err := RenderContentPage(data)
@@ -0,0 +1,97 @@
+package templates
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ContentTemplateFile is the template a template directory must define for content pages.
+// Every other *.html file in the directory is parsed alongside it, so it can pull in shared
+// partials with {{template "footer.html" .}}.
+const ContentTemplateFile = "content.html"
+
+// funcMap holds the functions available to every page template
+var funcMap = template.FuncMap{
+	"join": strings.Join,
+	"safeHTML": func(s string) template.HTML {
+		return template.HTML(s)
+	},
+	"randomPhrase": getRandomPhrase,
+}
+
+// defaultTemplates is the built-in content template, parsed once
+var defaultTemplates = template.Must(template.New(ContentTemplateFile).Funcs(funcMap).Parse(contentTemplate))
+
+// Renderer renders public pages from a template directory, or from the built-in template when
+// it has none. A nil *Renderer renders with the built-in template.
+type Renderer struct {
+	dir    string
+	reload bool
+	tmpl   *template.Template // Parsed at startup; unused when reloading
+}
+
+// NewRenderer parses the templates in dir. An empty dir uses the built-in template. With reload
+// set the templates are parsed again on every render, so edits show up without a restart; a
+// template broken while running fails only the pages rendered with it.
+func NewRenderer(dir string, reload bool) (*Renderer, error) {
+	r := &Renderer{dir: dir, reload: reload}
+	tmpl, err := r.parse()
+	if err != nil {
+		return nil, err
+	}
+	r.tmpl = tmpl
+	return r, nil
+}
+
+// parse parses the templates of r and checks that the content template executes against an
+// empty page, so a misspelled field is reported at startup rather than on the first request
+func (r *Renderer) parse() (*template.Template, error) {
+	if r == nil || r.dir == "" {
+		return defaultTemplates, nil
+	}
+
+	path := filepath.Join(r.dir, ContentTemplateFile)
+	if _, err := os.Stat(path); err != nil {
+		return nil, fmt.Errorf("content template not found: %w", err)
+	}
+	// Parse errors name the file and line, e.g. "template: content.html:12: unexpected EOF"
+	tmpl, err := template.New(ContentTemplateFile).Funcs(funcMap).ParseGlob(filepath.Join(r.dir, "*.html"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse templates in %s: %w", r.dir, err)
+	}
+	if err := tmpl.ExecuteTemplate(io.Discard, ContentTemplateFile, ContentPageData{PublishedAt: time.Now()}); err != nil {
+		return nil, fmt.Errorf("failed to execute %s: %w", path, err)
+	}
+	return tmpl, nil
+}
+
+// templates returns the templates to render with, parsing them again when reloading
+func (r *Renderer) templates() (*template.Template, error) {
+	if r == nil {
+		return defaultTemplates, nil
+	}
+	if r.reload {
+		return r.parse()
+	}
+	return r.tmpl, nil
+}
+
+// RenderContent renders a content page
+func (r *Renderer) RenderContent(data ContentPageData) (string, error) {
+	tmpl, err := r.templates()
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.ExecuteTemplate(&buf, ContentTemplateFile, data); err != nil {
+		return "", fmt.Errorf("failed to execute template: %w", err)
+	}
+	return buf.String(), nil
+}
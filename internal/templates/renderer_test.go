@@ -0,0 +1,143 @@
+package templates
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testPageData() ContentPageData {
+	return ContentPageData{
+		Title:       "Field Guide to Ferns",
+		Synopsis:    "A survey of ferns found along the coast.",
+		ContentHTML: "<p>Ferns reproduce by spores.</p>",
+		PublishedAt: time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC),
+		Tags:        []string{"botany", "ferns"},
+		Images:      []PageImage{{URL: "https://scraper.example.com/images/fern-1", AltText: "Sword fern"}},
+		Canonical:   "https://example.com/content/field-guide-to-ferns",
+		Related:     []RelatedPage{{Title: "Mosses", URL: "https://example.com/content/mosses"}},
+	}
+}
+
+func TestRendererDefaultTemplate(t *testing.T) {
+	t.Parallel()
+	r, err := NewRenderer("", false)
+	if err != nil {
+		t.Fatalf("Failed to create renderer: %v", err)
+	}
+	got, err := r.RenderContent(testPageData())
+	if err != nil {
+		t.Fatalf("Failed to render: %v", err)
+	}
+
+	// The renderer and the package-level function render the same built-in template
+	want, err := RenderContentPage(testPageData())
+	if err != nil {
+		t.Fatalf("Failed to render: %v", err)
+	}
+	if got != want {
+		t.Error("Expected the default renderer to match RenderContentPage")
+	}
+	for _, s := range []string{
+		"<title>Field Guide to Ferns</title>",
+		"<p>Ferns reproduce by spores.</p>",
+		`<time datetime="2024-03-01">2024-03-01</time>`,
+		`<a href="https://example.com/content/mosses">Mosses</a>`,
+	} {
+		if !strings.Contains(got, s) {
+			t.Errorf("Expected page to contain %q", s)
+		}
+	}
+}
+
+func TestRendererCustomTemplate(t *testing.T) {
+	t.Parallel()
+	r, err := NewRenderer(filepath.Join("testdata", "custom"), false)
+	if err != nil {
+		t.Fatalf("Failed to create renderer: %v", err)
+	}
+	html, err := r.RenderContent(testPageData())
+	if err != nil {
+		t.Fatalf("Failed to render: %v", err)
+	}
+	for _, s := range []string{
+		"<title>Field Guide to Ferns | Field Notes</title>",
+		`<link rel="canonical" href="https://example.com/content/field-guide-to-ferns">`,
+		`<p class="synopsis">A survey of ferns found along the coast.</p>`,
+		`<p class="published">March 1, 2024</p>`,
+		`<img src="https://scraper.example.com/images/fern-1" alt="Sword fern">`,
+		"<main><p>Ferns reproduce by spores.</p></main>",
+		`<p class="tags">botany / ferns</p>`,
+		// Rendered by the footer.html partial
+		`<a class="related" href="https://example.com/content/mosses">Mosses</a>`,
+	} {
+		if !strings.Contains(html, s) {
+			t.Errorf("Expected page to contain %q", s)
+		}
+	}
+}
+
+func TestNewRendererErrors(t *testing.T) {
+	t.Parallel()
+	misspelled := t.TempDir()
+	if err := os.WriteFile(filepath.Join(misspelled, ContentTemplateFile), []byte("<h1>{{.Titel}}</h1>"), 0o644); err != nil {
+		t.Fatalf("Failed to write template: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		dir  string
+		want string
+	}{
+		// formatDate is not a template function
+		{"parse error names file and line", filepath.Join("testdata", "broken"), `content.html:8: function "formatDate" not defined`},
+		{"missing content template", t.TempDir(), "content template not found"},
+		{"unknown field", misspelled, "can't evaluate field Titel"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewRenderer(tt.dir, false)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Expected an error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestRendererReload(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	path := filepath.Join(dir, ContentTemplateFile)
+	write := func(body string) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(body), 0o644); err != nil {
+			t.Fatalf("Failed to write template: %v", err)
+		}
+	}
+
+	write("<h1>{{.Title}}</h1>")
+	cached, err := NewRenderer(dir, false)
+	if err != nil {
+		t.Fatalf("Failed to create renderer: %v", err)
+	}
+	reloading, err := NewRenderer(dir, true)
+	if err != nil {
+		t.Fatalf("Failed to create renderer: %v", err)
+	}
+
+	write("<h2>{{.Title}}</h2>")
+	if html, _ := cached.RenderContent(testPageData()); html != "<h1>Field Guide to Ferns</h1>" {
+		t.Errorf("Expected the cached template, got %q", html)
+	}
+	if html, _ := reloading.RenderContent(testPageData()); html != "<h2>Field Guide to Ferns</h2>" {
+		t.Errorf("Expected the edited template, got %q", html)
+	}
+
+	// A template broken while reloading fails the render instead of the process
+	write("<h2>{{.Title</h2>")
+	if _, err := reloading.RenderContent(testPageData()); err == nil {
+		t.Error("Expected an error rendering a broken template")
+	}
+}
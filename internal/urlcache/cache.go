@@ -5,11 +5,9 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
-	"net/url"
-	"sort"
-	"strings"
 	"time"
 
+	"github.com/docutag/controller/internal/urlnorm"
 	"github.com/redis/go-redis/v9"
 )
 
@@ -20,39 +18,6 @@ const (
 	KeyPrefix = "urlcache:"
 )
 
-// trackingParams are common tracking/analytics parameters that don't affect content
-var trackingParams = map[string]bool{
-	// UTM parameters (Google Analytics)
-	"utm_source":   true,
-	"utm_medium":   true,
-	"utm_campaign": true,
-	"utm_term":     true,
-	"utm_content":  true,
-	// Facebook
-	"fbclid": true,
-	// Google Ads
-	"gclid":  true,
-	"gclsrc": true,
-	// Other tracking
-	"ref":        true,
-	"source":     true,
-	"referrer":   true,
-	"campaign":   true,
-	"_ga":        true,
-	"mc_cid":     true,
-	"mc_eid":     true,
-	"msclkid":    true,
-	"yclid":      true,
-	"_openstat":  true,
-	"fb_action_ids": true,
-	"fb_action_types": true,
-	"fb_ref":     true,
-	"fb_source":  true,
-	"action_object_map": true,
-	"action_type_map":   true,
-	"action_ref_map":    true,
-}
-
 // Cache provides URL caching functionality using Redis
 type Cache struct {
 	client *redis.Client
@@ -69,63 +34,10 @@ func New(redisAddr string) *Cache {
 	}
 }
 
-// normalizeURL normalizes a URL for caching by:
-// 1. Converting scheme and host to lowercase
-// 2. Removing tracking parameters
-// 3. Sorting remaining query parameters
-// 4. Removing trailing slash
-// 5. Removing fragment (#)
+// normalizeURL normalizes a URL for caching using the shared URL normalization rules
+// (tracking parameters, fragments, host case, default ports and parameter order are ignored)
 func normalizeURL(rawURL string) (string, error) {
-	parsedURL, err := url.Parse(rawURL)
-	if err != nil {
-		return "", fmt.Errorf("invalid URL: %w", err)
-	}
-
-	// Validate that URL has scheme and host
-	if parsedURL.Scheme == "" || parsedURL.Host == "" {
-		return "", fmt.Errorf("invalid URL: missing scheme or host")
-	}
-
-	// Normalize scheme and host to lowercase
-	parsedURL.Scheme = strings.ToLower(parsedURL.Scheme)
-	parsedURL.Host = strings.ToLower(parsedURL.Host)
-
-	// Remove fragment
-	parsedURL.Fragment = ""
-
-	// Parse and filter query parameters
-	query := parsedURL.Query()
-	filteredQuery := url.Values{}
-
-	// Remove tracking parameters and sort the rest
-	for key, values := range query {
-		if !trackingParams[strings.ToLower(key)] {
-			filteredQuery[key] = values
-		}
-	}
-
-	// Sort query parameters for consistent hashing
-	var keys []string
-	for key := range filteredQuery {
-		keys = append(keys, key)
-	}
-	sort.Strings(keys)
-
-	// Rebuild query string with sorted parameters
-	sortedQuery := url.Values{}
-	for _, key := range keys {
-		values := filteredQuery[key]
-		sort.Strings(values) // Also sort values for each parameter
-		sortedQuery[key] = values
-	}
-	parsedURL.RawQuery = sortedQuery.Encode()
-
-	// Remove trailing slash from path (unless it's just "/")
-	if len(parsedURL.Path) > 1 && strings.HasSuffix(parsedURL.Path, "/") {
-		parsedURL.Path = strings.TrimSuffix(parsedURL.Path, "/")
-	}
-
-	return parsedURL.String(), nil
+	return urlnorm.Normalize(rawURL)
 }
 
 // hashURL creates a SHA256 hash of the normalized URL for use as a cache key
@@ -0,0 +1,145 @@
+package urlguard
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// Rules reported when a URL fails the domain policy
+const (
+	RuleDomainDenied     = "domain-denied"
+	RuleDomainNotAllowed = "domain-not-allowed"
+)
+
+// DomainPolicy restricts scraping to an allowlist and/or away from a denylist of domains.
+// Patterns are exact hostnames ("example.com") or subdomain wildcards ("*.example.com",
+// which matches news.example.com but not example.com itself). The denylist wins over the
+// allowlist, and an empty allowlist allows every domain that is not denied.
+type DomainPolicy struct {
+	allowlist []string
+	denylist  []string
+}
+
+// DomainDecision explains the outcome of evaluating a URL against the policy
+type DomainDecision struct {
+	Host           string `json:"host"`
+	Allowed        bool   `json:"allowed"`
+	Rule           string `json:"rule,omitempty"`
+	MatchedPattern string `json:"matched_pattern,omitempty"`
+}
+
+// NewDomainPolicy creates a policy from allow and deny patterns
+func NewDomainPolicy(allowlist, denylist []string) *DomainPolicy {
+	return &DomainPolicy{
+		allowlist: normalizePatterns(allowlist),
+		denylist:  normalizePatterns(denylist),
+	}
+}
+
+// ValidateDomainPattern reports whether a pattern is an exact hostname or a "*." wildcard
+func ValidateDomainPattern(pattern string) error {
+	p := strings.ToLower(strings.TrimSpace(pattern))
+	p = strings.TrimSuffix(strings.TrimPrefix(p, "*."), ".")
+	if p == "" || strings.ContainsAny(p, "*/:?# ") || strings.HasPrefix(p, ".") || strings.HasSuffix(p, ".") {
+		return fmt.Errorf("invalid domain pattern %q: use a hostname like example.com or a wildcard like *.example.com", pattern)
+	}
+	return nil
+}
+
+func normalizePatterns(patterns []string) []string {
+	normalized := make([]string, 0, len(patterns))
+	for _, p := range patterns {
+		p = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(p)), ".")
+		if p != "" {
+			normalized = append(normalized, p)
+		}
+	}
+	return normalized
+}
+
+// Enabled reports whether either list is configured
+func (p *DomainPolicy) Enabled() bool {
+	return p != nil && (len(p.allowlist) > 0 || len(p.denylist) > 0)
+}
+
+// Allowlist returns the effective allow patterns
+func (p *DomainPolicy) Allowlist() []string {
+	if p == nil {
+		return []string{}
+	}
+	return append([]string{}, p.allowlist...)
+}
+
+// Denylist returns the effective deny patterns
+func (p *DomainPolicy) Denylist() []string {
+	if p == nil {
+		return []string{}
+	}
+	return append([]string{}, p.denylist...)
+}
+
+// Evaluate decides whether the URL's host may be scraped.
+// An error is returned only when the URL has no parseable host.
+func (p *DomainPolicy) Evaluate(rawURL string) (DomainDecision, error) {
+	parsedURL, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil || parsedURL.Hostname() == "" {
+		return DomainDecision{}, &ValidationError{Rule: RuleHost, Reason: "URL has no hostname"}
+	}
+	host := strings.TrimSuffix(strings.ToLower(parsedURL.Hostname()), ".")
+
+	decision := DomainDecision{Host: host, Allowed: true}
+	if !p.Enabled() {
+		return decision, nil
+	}
+
+	if pattern, ok := matchDomain(host, p.denylist); ok {
+		decision.Allowed = false
+		decision.Rule = RuleDomainDenied
+		decision.MatchedPattern = pattern
+		return decision, nil
+	}
+
+	if len(p.allowlist) > 0 {
+		pattern, ok := matchDomain(host, p.allowlist)
+		if !ok {
+			decision.Allowed = false
+			decision.Rule = RuleDomainNotAllowed
+			return decision, nil
+		}
+		decision.MatchedPattern = pattern
+	}
+
+	return decision, nil
+}
+
+// Check returns a ValidationError when the URL's domain may not be scraped
+func (p *DomainPolicy) Check(rawURL string) error {
+	decision, err := p.Evaluate(rawURL)
+	if err != nil {
+		return err
+	}
+	switch decision.Rule {
+	case RuleDomainDenied:
+		return &ValidationError{Rule: RuleDomainDenied, Reason: fmt.Sprintf("domain %q matches denylist entry %q", decision.Host, decision.MatchedPattern)}
+	case RuleDomainNotAllowed:
+		return &ValidationError{Rule: RuleDomainNotAllowed, Reason: fmt.Sprintf("domain %q is not on the allowlist", decision.Host)}
+	}
+	return nil
+}
+
+// matchDomain returns the first pattern matching host
+func matchDomain(host string, patterns []string) (string, bool) {
+	for _, pattern := range patterns {
+		if suffix, ok := strings.CutPrefix(pattern, "*."); ok {
+			if strings.HasSuffix(host, "."+suffix) {
+				return pattern, true
+			}
+			continue
+		}
+		if host == pattern {
+			return pattern, true
+		}
+	}
+	return "", false
+}
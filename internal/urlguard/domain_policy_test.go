@@ -0,0 +1,84 @@
+package urlguard
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestDomainPolicyEvaluate(t *testing.T) {
+	tests := []struct {
+		name        string
+		allowlist   []string
+		denylist    []string
+		url         string
+		wantAllowed bool
+		wantRule    string
+		wantPattern string
+	}{
+		{name: "empty lists allow everything", url: "https://anything.org/", wantAllowed: true},
+		{name: "exact allow", allowlist: []string{"example.com"}, url: "https://example.com/a", wantAllowed: true, wantPattern: "example.com"},
+		{name: "exact allow does not cover subdomain", allowlist: []string{"example.com"}, url: "https://www.example.com/", wantRule: RuleDomainNotAllowed},
+		{name: "wildcard matches subdomain", allowlist: []string{"*.example.com"}, url: "https://news.example.com/", wantAllowed: true, wantPattern: "*.example.com"},
+		{name: "wildcard matches nested subdomain", allowlist: []string{"*.example.com"}, url: "https://a.b.example.com/", wantAllowed: true, wantPattern: "*.example.com"},
+		{name: "wildcard does not match apex", allowlist: []string{"*.example.com"}, url: "https://example.com/", wantRule: RuleDomainNotAllowed},
+		{name: "wildcard does not match lookalike", allowlist: []string{"*.example.com"}, url: "https://badexample.com/", wantRule: RuleDomainNotAllowed},
+		{name: "deny wins over allow", allowlist: []string{"*.example.com"}, denylist: []string{"ads.example.com"}, url: "https://ads.example.com/", wantRule: RuleDomainDenied, wantPattern: "ads.example.com"},
+		{name: "deny only", denylist: []string{"*.spam.net"}, url: "https://x.spam.net/", wantRule: RuleDomainDenied, wantPattern: "*.spam.net"},
+		{name: "deny only leaves others allowed", denylist: []string{"*.spam.net"}, url: "https://example.com/", wantAllowed: true},
+		{name: "case and trailing dot ignored", allowlist: []string{"Example.COM."}, url: "https://EXAMPLE.com./", wantAllowed: true, wantPattern: "example.com"},
+		{name: "port ignored", allowlist: []string{"example.com"}, url: "https://example.com:8443/", wantAllowed: true, wantPattern: "example.com"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewDomainPolicy(tt.allowlist, tt.denylist)
+			got, err := p.Evaluate(tt.url)
+			if err != nil {
+				t.Fatalf("Evaluate(%q) error: %v", tt.url, err)
+			}
+			if got.Allowed != tt.wantAllowed || got.Rule != tt.wantRule || got.MatchedPattern != tt.wantPattern {
+				t.Errorf("Evaluate(%q) = %+v, want allowed=%v rule=%q pattern=%q", tt.url, got, tt.wantAllowed, tt.wantRule, tt.wantPattern)
+			}
+
+			err = p.Check(tt.url)
+			if tt.wantAllowed && err != nil {
+				t.Errorf("Check(%q) unexpected error: %v", tt.url, err)
+			}
+			if !tt.wantAllowed {
+				var verr *ValidationError
+				if !errors.As(err, &verr) || verr.Rule != tt.wantRule {
+					t.Errorf("Check(%q) = %v, want rule %q", tt.url, err, tt.wantRule)
+				}
+			}
+		})
+	}
+}
+
+func TestDomainPolicyNil(t *testing.T) {
+	var p *DomainPolicy
+	if p.Enabled() {
+		t.Error("nil policy should not be enabled")
+	}
+	if err := p.Check("https://example.com/"); err != nil {
+		t.Errorf("nil policy should allow everything, got %v", err)
+	}
+	if NewDomainPolicy(nil, []string{" ", ""}).Enabled() {
+		t.Error("blank patterns should not enable the policy")
+	}
+}
+
+func TestValidateDomainPattern(t *testing.T) {
+	valid := []string{"example.com", "*.example.com", "Example.COM", "example.com.", "localhost"}
+	for _, p := range valid {
+		if err := ValidateDomainPattern(p); err != nil {
+			t.Errorf("ValidateDomainPattern(%q) unexpected error: %v", p, err)
+		}
+	}
+
+	invalid := []string{"", "*", "*.", "ex*ample.com", "*.*.example.com", "https://example.com", "example.com/path", "example.com:443", ".example.com"}
+	for _, p := range invalid {
+		if err := ValidateDomainPattern(p); err == nil {
+			t.Errorf("ValidateDomainPattern(%q) expected error", p)
+		}
+	}
+}
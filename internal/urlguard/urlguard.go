@@ -0,0 +1,190 @@
+// Package urlguard decides whether a URL is safe to hand to the scraper.
+// It rejects non-HTTP schemes and, unless explicitly allowed, targets on
+// loopback, private, link-local and other internal networks (SSRF protection).
+package urlguard
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"syscall"
+)
+
+// Rules reported by ValidationError
+const (
+	RuleSyntax        = "syntax"
+	RuleScheme        = "scheme"
+	RuleHost          = "host"
+	RuleResolve       = "resolve"
+	RulePrivateTarget = "private-target"
+)
+
+// ValidationError describes which rule a URL failed
+type ValidationError struct {
+	Rule   string
+	Reason string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("%s (rule: %s)", e.Reason, e.Rule)
+}
+
+// Resolver looks up the addresses of a host. *net.Resolver satisfies it.
+type Resolver interface {
+	LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error)
+}
+
+// Guard validates scrape targets
+type Guard struct {
+	allowPrivateTargets bool
+	resolver            Resolver
+}
+
+// New creates a Guard that resolves hosts with the system resolver.
+// allowPrivateTargets disables the private-network checks for development environments.
+func New(allowPrivateTargets bool) *Guard {
+	return NewWithResolver(allowPrivateTargets, net.DefaultResolver)
+}
+
+// NewWithResolver creates a Guard with a custom resolver
+func NewWithResolver(allowPrivateTargets bool, resolver Resolver) *Guard {
+	return &Guard{
+		allowPrivateTargets: allowPrivateTargets,
+		resolver:            resolver,
+	}
+}
+
+// AllowsPrivateTargets reports whether private-network targets are permitted
+func (g *Guard) AllowsPrivateTargets() bool {
+	return g.allowPrivateTargets
+}
+
+// CheckURL verifies that a URL parses, uses http or https and names a host.
+// It performs no network lookups.
+func CheckURL(rawURL string) (*url.URL, error) {
+	parsedURL, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil {
+		return nil, &ValidationError{Rule: RuleSyntax, Reason: "URL could not be parsed"}
+	}
+
+	scheme := strings.ToLower(parsedURL.Scheme)
+	if scheme != "http" && scheme != "https" {
+		if scheme == "" {
+			return nil, &ValidationError{Rule: RuleScheme, Reason: "URL must start with http:// or https://"}
+		}
+		return nil, &ValidationError{Rule: RuleScheme, Reason: fmt.Sprintf("scheme %q is not allowed, only http and https", scheme)}
+	}
+
+	if parsedURL.Hostname() == "" {
+		return nil, &ValidationError{Rule: RuleHost, Reason: "URL has no hostname"}
+	}
+
+	return parsedURL, nil
+}
+
+// CheckStatic runs every check that does not need DNS: scheme, hostname, and
+// (unless private targets are allowed) localhost names and private IP literals.
+func (g *Guard) CheckStatic(rawURL string) error {
+	_, err := g.checkStatic(rawURL)
+	return err
+}
+
+func (g *Guard) checkStatic(rawURL string) (string, error) {
+	parsedURL, err := CheckURL(rawURL)
+	if err != nil {
+		return "", err
+	}
+
+	host := strings.ToLower(strings.TrimSuffix(parsedURL.Hostname(), "."))
+	if g.allowPrivateTargets {
+		return host, nil
+	}
+
+	if isLocalhostName(host) {
+		return "", &ValidationError{Rule: RulePrivateTarget, Reason: fmt.Sprintf("host %q is a localhost alias", host)}
+	}
+	if ip := net.ParseIP(host); ip != nil && IsPrivateIP(ip) {
+		return "", &ValidationError{Rule: RulePrivateTarget, Reason: fmt.Sprintf("address %s is in a private, loopback or link-local range", ip)}
+	}
+
+	return host, nil
+}
+
+// Validate runs CheckStatic and then resolves the hostname, rejecting hosts that
+// do not resolve and (unless private targets are allowed) hosts that resolve to
+// any private address.
+func (g *Guard) Validate(ctx context.Context, rawURL string) error {
+	host, err := g.checkStatic(rawURL)
+	if err != nil {
+		return err
+	}
+
+	// IP literals need no lookup
+	if net.ParseIP(host) != nil {
+		return nil
+	}
+
+	addrs, err := g.resolver.LookupIPAddr(ctx, host)
+	if err != nil || len(addrs) == 0 {
+		return &ValidationError{Rule: RuleResolve, Reason: fmt.Sprintf("host %q could not be resolved", host)}
+	}
+
+	if g.allowPrivateTargets {
+		return nil
+	}
+	for _, addr := range addrs {
+		if IsPrivateIP(addr.IP) {
+			return &ValidationError{Rule: RulePrivateTarget, Reason: fmt.Sprintf("host %q resolves to %s, which is in a private, loopback or link-local range", host, addr.IP)}
+		}
+	}
+
+	return nil
+}
+
+// DialControl is a net.Dialer Control function that refuses connections to private
+// addresses unless private targets are allowed. It checks the address actually dialed, so a
+// host that passed Validate cannot be re-pointed at an internal network through DNS.
+func (g *Guard) DialControl(network, address string, _ syscall.RawConn) error {
+	if g.allowPrivateTargets {
+		return nil
+	}
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		host = address
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return &ValidationError{Rule: RuleResolve, Reason: fmt.Sprintf("dialed address %q is not an IP address", address)}
+	}
+	if IsPrivateIP(ip) {
+		return &ValidationError{Rule: RulePrivateTarget, Reason: fmt.Sprintf("address %s is in a private, loopback or link-local range", ip)}
+	}
+	return nil
+}
+
+// cgnatBlock is the carrier-grade NAT range (RFC 6598), not covered by net.IP.IsPrivate
+var cgnatBlock = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+// IsPrivateIP reports whether an address is loopback, RFC1918/ULA private,
+// link-local, unspecified, carrier-grade NAT or multicast
+func IsPrivateIP(ip net.IP) bool {
+	return ip.IsLoopback() ||
+		ip.IsPrivate() ||
+		ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() ||
+		ip.IsMulticast() ||
+		ip.IsUnspecified() ||
+		cgnatBlock.Contains(ip)
+}
+
+// isLocalhostName reports whether a hostname always refers to the local machine
+func isLocalhostName(host string) bool {
+	switch host {
+	case "localhost", "localhost.localdomain", "ip6-localhost", "ip6-loopback":
+		return true
+	}
+	return strings.HasSuffix(host, ".localhost")
+}
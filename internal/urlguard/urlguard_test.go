@@ -0,0 +1,198 @@
+package urlguard
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+)
+
+// fakeResolver returns canned addresses per host
+type fakeResolver map[string][]string
+
+func (f fakeResolver) LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error) {
+	ips, ok := f[host]
+	if !ok {
+		return nil, fmt.Errorf("no such host: %s", host)
+	}
+	addrs := make([]net.IPAddr, 0, len(ips))
+	for _, ip := range ips {
+		addrs = append(addrs, net.IPAddr{IP: net.ParseIP(ip)})
+	}
+	return addrs, nil
+}
+
+var testResolver = fakeResolver{
+	"example.com":        {"93.184.216.34"},
+	"localhost":          {"127.0.0.1", "::1"},
+	"dual.example.com":   {"93.184.216.34", "2606:2800:220:1:248:1893:25c8:1946"},
+	"internal.corp":      {"10.0.0.5"},
+	"mixed.example.com":  {"93.184.216.34", "192.168.1.10"},
+	"metadata.internal":  {"169.254.169.254"},
+	"v6private.example":  {"fd00::1"},
+	"mapped.example.com": {"::ffff:127.0.0.1"},
+}
+
+func TestValidate(t *testing.T) {
+	guard := NewWithResolver(false, testResolver)
+
+	tests := []struct {
+		name string
+		url  string
+		rule string // empty means valid
+	}{
+		// Valid
+		{"https URL", "https://example.com/article", ""},
+		{"http URL", "http://example.com/article", ""},
+		{"uppercase scheme", "HTTPS://example.com/article", ""},
+		{"mixed case scheme", "HtTp://example.com/", ""},
+		{"uppercase host", "https://EXAMPLE.COM/article", ""},
+		{"dual stack public", "https://dual.example.com/", ""},
+		{"public IPv4 literal", "http://93.184.216.34/", ""},
+		{"public IPv6 literal", "http://[2606:2800:220:1:248:1893:25c8:1946]/", ""},
+
+		// Scheme
+		{"javascript scheme", "javascript:alert(1)", RuleScheme},
+		{"file scheme", "file:///etc/passwd", RuleScheme},
+		{"uppercase file scheme", "FILE:///etc/passwd", RuleScheme},
+		{"ftp scheme", "ftp://example.com/file", RuleScheme},
+		{"data scheme", "data:text/html,<h1>hi</h1>", RuleScheme},
+		{"gopher scheme", "gopher://example.com/", RuleScheme},
+		{"missing scheme", "example.com/article", RuleScheme},
+
+		// Syntax and host
+		{"unparseable", "http://[::1", RuleSyntax},
+		{"missing host", "https:///article", RuleHost},
+		{"unresolvable host", "https://does-not-exist.example/", RuleResolve},
+
+		// Private targets - literals
+		{"cloud metadata", "http://169.254.169.254/latest/meta-data/", RulePrivateTarget},
+		{"loopback IPv4", "http://127.0.0.1:8080/", RulePrivateTarget},
+		{"loopback range", "http://127.1.2.3/", RulePrivateTarget},
+		{"RFC1918 10/8", "http://10.1.2.3/", RulePrivateTarget},
+		{"RFC1918 172.16/12", "http://172.16.0.1/", RulePrivateTarget},
+		{"RFC1918 192.168/16", "http://192.168.0.1/", RulePrivateTarget},
+		{"CGNAT", "http://100.64.0.1/", RulePrivateTarget},
+		{"unspecified", "http://0.0.0.0/", RulePrivateTarget},
+		{"IPv6 loopback", "http://[::1]/", RulePrivateTarget},
+		{"IPv6 loopback with port", "http://[::1]:8080/admin", RulePrivateTarget},
+		{"IPv6 link-local", "http://[fe80::1]/", RulePrivateTarget},
+		{"IPv6 unique local", "http://[fc00::1]/", RulePrivateTarget},
+		{"IPv4-mapped IPv6 loopback", "http://[::ffff:127.0.0.1]/", RulePrivateTarget},
+		{"IPv6 unspecified", "http://[::]/", RulePrivateTarget},
+
+		// Private targets - localhost aliases
+		{"localhost", "http://localhost/", RulePrivateTarget},
+		{"uppercase localhost", "http://LOCALHOST:3000/", RulePrivateTarget},
+		{"localhost trailing dot", "http://localhost./", RulePrivateTarget},
+		{"localhost subdomain", "http://api.localhost/", RulePrivateTarget},
+		{"localhost.localdomain", "http://localhost.localdomain/", RulePrivateTarget},
+		{"ip6-localhost", "http://ip6-localhost/", RulePrivateTarget},
+
+		// Private targets - via DNS
+		{"resolves to RFC1918", "https://internal.corp/", RulePrivateTarget},
+		{"resolves to metadata", "http://metadata.internal/", RulePrivateTarget},
+		{"any private address rejects", "https://mixed.example.com/", RulePrivateTarget},
+		{"resolves to IPv6 ULA", "https://v6private.example/", RulePrivateTarget},
+		{"resolves to mapped loopback", "https://mapped.example.com/", RulePrivateTarget},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := guard.Validate(context.Background(), tt.url)
+			if tt.rule == "" {
+				if err != nil {
+					t.Errorf("Validate(%q) = %v, want nil", tt.url, err)
+				}
+				return
+			}
+
+			var verr *ValidationError
+			if !errors.As(err, &verr) {
+				t.Fatalf("Validate(%q) = %v, want ValidationError with rule %q", tt.url, err, tt.rule)
+			}
+			if verr.Rule != tt.rule {
+				t.Errorf("Validate(%q) rule = %q, want %q (%v)", tt.url, verr.Rule, tt.rule, err)
+			}
+		})
+	}
+}
+
+func TestValidateAllowPrivateTargets(t *testing.T) {
+	guard := NewWithResolver(true, testResolver)
+
+	allowed := []string{
+		"http://localhost:8080/",
+		"http://127.0.0.1/",
+		"http://[::1]/",
+		"http://169.254.169.254/",
+		"https://internal.corp/",
+	}
+	for _, u := range allowed {
+		if err := guard.Validate(context.Background(), u); err != nil {
+			t.Errorf("Validate(%q) with private targets allowed = %v, want nil", u, err)
+		}
+	}
+
+	// Scheme and resolution rules still apply
+	if err := guard.Validate(context.Background(), "file:///etc/passwd"); err == nil {
+		t.Error("Expected file scheme to be rejected even when private targets are allowed")
+	}
+	if err := guard.Validate(context.Background(), "https://does-not-exist.example/"); err == nil {
+		t.Error("Expected unresolvable host to be rejected even when private targets are allowed")
+	}
+}
+
+func TestCheckStaticSkipsDNS(t *testing.T) {
+	// A resolver that fails everything proves CheckStatic never resolves
+	guard := NewWithResolver(false, fakeResolver{})
+
+	if err := guard.CheckStatic("https://unresolvable.example/"); err != nil {
+		t.Errorf("CheckStatic should not resolve hostnames, got %v", err)
+	}
+	if err := guard.CheckStatic("http://192.168.1.1/"); err == nil {
+		t.Error("CheckStatic should reject private IP literals")
+	}
+	if err := guard.CheckStatic("http://localhost/"); err == nil {
+		t.Error("CheckStatic should reject localhost")
+	}
+}
+
+func TestValidationErrorMessage(t *testing.T) {
+	guard := NewWithResolver(false, testResolver)
+	err := guard.Validate(context.Background(), "javascript:void(0)")
+	if err == nil {
+		t.Fatal("Expected error")
+	}
+	want := `scheme "javascript" is not allowed, only http and https (rule: scheme)`
+	if err.Error() != want {
+		t.Errorf("Error() = %q, want %q", err.Error(), want)
+	}
+}
+
+func TestDialControl(t *testing.T) {
+	guard := New(false)
+	tests := []struct {
+		address string
+		wantErr bool
+	}{
+		{"93.184.216.34:443", false},
+		{"[2606:2800:220:1:248:1893:25c8:1946]:443", false},
+		{"127.0.0.1:8080", true},
+		{"10.1.2.3:80", true},
+		{"169.254.169.254:80", true},
+		{"[::1]:443", true},
+		{"[fd00::1]:443", true},
+	}
+	for _, tt := range tests {
+		err := guard.DialControl("tcp", tt.address, nil)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("DialControl(%q) error = %v, wantErr %v", tt.address, err, tt.wantErr)
+		}
+	}
+
+	if err := New(true).DialControl("tcp", "127.0.0.1:8080", nil); err != nil {
+		t.Errorf("Expected private targets to be dialable when allowed, got %v", err)
+	}
+}
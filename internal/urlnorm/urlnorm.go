@@ -0,0 +1,163 @@
+// Package urlnorm normalizes URLs so that trivially different forms of the same
+// address (tracking parameters, fragments, host case, default ports, parameter
+// order) map to one canonical string for caching, deduplication and crawling.
+package urlnorm
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"sort"
+	"strings"
+	"sync/atomic"
+)
+
+// DefaultTrackingParams are analytics and click-tracking query parameters that never change page content.
+// A trailing "*" matches any parameter with that prefix.
+var DefaultTrackingParams = []string{
+	// UTM parameters (Google Analytics)
+	"utm_*",
+	// Click identifiers
+	"fbclid",
+	"gclid",
+	"gclsrc",
+	"dclid",
+	"msclkid",
+	"yclid",
+	// Mailchimp
+	"mc_cid",
+	"mc_eid",
+	// Other analytics
+	"_ga",
+	"_openstat",
+	// Facebook share parameters
+	"fb_action_ids",
+	"fb_action_types",
+	"fb_ref",
+	"fb_source",
+	"action_object_map",
+	"action_type_map",
+	"action_ref_map",
+}
+
+// defaultPorts maps schemes to the port that is implied when none is given
+var defaultPorts = map[string]string{
+	"http":  "80",
+	"https": "443",
+}
+
+// Normalizer canonicalizes URLs using a fixed set of tracking parameters
+type Normalizer struct {
+	exact    map[string]bool
+	prefixes []string
+}
+
+// New creates a Normalizer that strips the given tracking parameters (case-insensitive).
+// Entries ending in "*" are treated as prefixes.
+func New(trackingParams []string) *Normalizer {
+	n := &Normalizer{exact: make(map[string]bool)}
+	for _, param := range trackingParams {
+		param = strings.ToLower(strings.TrimSpace(param))
+		if param == "" {
+			continue
+		}
+		if strings.HasSuffix(param, "*") {
+			if prefix := strings.TrimSuffix(param, "*"); prefix != "" {
+				n.prefixes = append(n.prefixes, prefix)
+			}
+			continue
+		}
+		n.exact[param] = true
+	}
+	return n
+}
+
+// IsTrackingParam reports whether a query parameter is stripped during normalization
+func (n *Normalizer) IsTrackingParam(key string) bool {
+	key = strings.ToLower(key)
+	if n.exact[key] {
+		return true
+	}
+	for _, prefix := range n.prefixes {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Normalize returns the canonical form of a URL by:
+// 1. Converting scheme and host to lowercase
+// 2. Removing the port when it is the scheme's default
+// 3. Removing the fragment (#)
+// 4. Removing tracking parameters
+// 5. Sorting remaining query parameters and their values
+// 6. Removing a trailing slash from the path (except the root path)
+//
+// Path case, non-tracking parameters and parameter values are never altered.
+func (n *Normalizer) Normalize(rawURL string) (string, error) {
+	parsedURL, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil {
+		return "", fmt.Errorf("invalid URL: %w", err)
+	}
+
+	// Validate that URL has scheme and host
+	if parsedURL.Scheme == "" || parsedURL.Host == "" {
+		return "", fmt.Errorf("invalid URL: missing scheme or host")
+	}
+
+	// Normalize scheme and host to lowercase
+	parsedURL.Scheme = strings.ToLower(parsedURL.Scheme)
+	parsedURL.Host = strings.ToLower(parsedURL.Host)
+
+	// Strip default port (http://example.com:80 -> http://example.com)
+	if host, port, err := net.SplitHostPort(parsedURL.Host); err == nil && defaultPorts[parsedURL.Scheme] == port {
+		if strings.Contains(host, ":") {
+			host = "[" + host + "]" // IPv6 literal
+		}
+		parsedURL.Host = host
+	}
+
+	// Remove fragment
+	parsedURL.Fragment = ""
+	parsedURL.RawFragment = ""
+
+	// Remove tracking parameters; Encode sorts the remaining keys
+	query := parsedURL.Query()
+	filteredQuery := url.Values{}
+	for key, values := range query {
+		if n.IsTrackingParam(key) {
+			continue
+		}
+		sorted := append([]string(nil), values...)
+		sort.Strings(sorted)
+		filteredQuery[key] = sorted
+	}
+	parsedURL.RawQuery = filteredQuery.Encode()
+	parsedURL.ForceQuery = false
+
+	// Remove trailing slash from path (unless it's just "/")
+	if len(parsedURL.Path) > 1 && strings.HasSuffix(parsedURL.Path, "/") {
+		parsedURL.Path = strings.TrimSuffix(parsedURL.Path, "/")
+		parsedURL.RawPath = strings.TrimSuffix(parsedURL.RawPath, "/")
+	}
+
+	return parsedURL.String(), nil
+}
+
+var defaultNormalizer atomic.Pointer[Normalizer]
+
+func init() {
+	defaultNormalizer.Store(New(DefaultTrackingParams))
+}
+
+// SetTrackingParams replaces the tracking parameters used by the package-level Normalize.
+// It is called once at startup from configuration.
+func SetTrackingParams(params []string) {
+	defaultNormalizer.Store(New(params))
+}
+
+// Normalize canonicalizes a URL using the configured tracking parameters
+func Normalize(rawURL string) (string, error) {
+	return defaultNormalizer.Load().Normalize(rawURL)
+}
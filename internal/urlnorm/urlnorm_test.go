@@ -0,0 +1,153 @@
+package urlnorm
+
+import "testing"
+
+func TestNormalize(t *testing.T) {
+	n := New(DefaultTrackingParams)
+
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+		wantErr  bool
+	}{
+		// Unchanged
+		{"basic URL", "https://example.com/article", "https://example.com/article", false},
+		{"root path keeps slash", "https://example.com/", "https://example.com/", false},
+		{"no path", "https://example.com", "https://example.com", false},
+
+		// Scheme and host
+		{"uppercase scheme and host", "HTTPS://EXAMPLE.COM/Article", "https://example.com/Article", false},
+		{"path case preserved", "https://example.com/CaseSensitive/Path", "https://example.com/CaseSensitive/Path", false},
+
+		// Ports
+		{"default https port", "https://example.com:443/article", "https://example.com/article", false},
+		{"default http port", "http://example.com:80/article", "http://example.com/article", false},
+		{"non-default port kept", "https://example.com:8443/article", "https://example.com:8443/article", false},
+		{"http port on https kept", "https://example.com:80/article", "https://example.com:80/article", false},
+		{"IPv6 default port", "http://[::1]:80/article", "http://[::1]/article", false},
+		{"IPv6 custom port", "http://[::1]:8080/article", "http://[::1]:8080/article", false},
+
+		// Fragments
+		{"fragment removed", "https://example.com/article#section", "https://example.com/article", false},
+		{"fragment with query", "https://example.com/article?id=1#top", "https://example.com/article?id=1", false},
+
+		// Tracking parameters
+		{"utm parameters", "https://example.com/article?utm_source=x&utm_medium=y", "https://example.com/article", false},
+		{"utm wildcard matches custom", "https://example.com/article?utm_custom_field=1", "https://example.com/article", false},
+		{"uppercase tracking key", "https://example.com/article?UTM_SOURCE=x&FBCLID=1", "https://example.com/article", false},
+		{"click identifiers", "https://example.com/a?fbclid=1&gclid=2&msclkid=3", "https://example.com/a", false},
+		{"mixed tracking and real", "https://example.com/search?q=go&utm_source=x&page=2&fbclid=1", "https://example.com/search?page=2&q=go", false},
+		{"request example", "https://example.com/article?utm_source=x#section", "https://example.com/article", false},
+
+		// Meaningful parameters are never stripped
+		{"id parameter kept", "https://example.com/item?id=42", "https://example.com/item?id=42", false},
+		{"ref parameter kept", "https://github.com/org/repo?ref=main", "https://github.com/org/repo?ref=main", false},
+		{"source parameter kept", "https://example.com/view?source=archive", "https://example.com/view?source=archive", false},
+		{"utm lookalike kept", "https://example.com/a?utmost=1", "https://example.com/a?utmost=1", false},
+		{"empty value kept", "https://example.com/a?draft=", "https://example.com/a?draft=", false},
+		{"value case preserved", "https://example.com/a?q=GoLang", "https://example.com/a?q=GoLang", false},
+
+		// Ordering
+		{"sorted parameters", "https://example.com/a?z=1&a=2&m=3", "https://example.com/a?a=2&m=3&z=1", false},
+		{"sorted duplicate values", "https://example.com?tag=b&tag=a", "https://example.com?tag=a&tag=b", false},
+
+		// Trailing slash and query markers
+		{"trailing slash removed", "https://example.com/article/", "https://example.com/article", false},
+		{"empty query removed", "https://example.com/article?", "https://example.com/article", false},
+		{"only tracking params removed entirely", "https://example.com/article?utm_source=x", "https://example.com/article", false},
+		{"escaped path preserved", "https://example.com/a%2Fb/", "https://example.com/a%2Fb", false},
+		{"surrounding whitespace", "  https://example.com/article  ", "https://example.com/article", false},
+
+		// Errors
+		{"missing scheme", "example.com/article", "", true},
+		{"missing host", "https:///article", "", true},
+		{"not a URL", "not a valid url", "", true},
+		{"empty", "", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := n.Normalize(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Normalize(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if result != tt.expected {
+				t.Errorf("Normalize(%q) = %q, want %q", tt.input, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestNormalizeIdempotent(t *testing.T) {
+	n := New(DefaultTrackingParams)
+	inputs := []string{
+		"HTTPS://Example.com:443/a/b/?utm_source=x&z=2&a=1#frag",
+		"http://example.com/search?q=hello+world&lang=en",
+		"https://example.com/a%2Fb/",
+	}
+
+	for _, input := range inputs {
+		once, err := n.Normalize(input)
+		if err != nil {
+			t.Fatalf("Normalize(%q) failed: %v", input, err)
+		}
+		twice, err := n.Normalize(once)
+		if err != nil {
+			t.Fatalf("Normalize(%q) failed: %v", once, err)
+		}
+		if once != twice {
+			t.Errorf("Normalize is not idempotent: %q -> %q -> %q", input, once, twice)
+		}
+	}
+}
+
+func TestCustomTrackingParams(t *testing.T) {
+	n := New([]string{"ref", " Session* ", ""})
+
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"exact match stripped", "https://example.com/a?ref=home&id=1", "https://example.com/a?id=1"},
+		{"prefix match stripped", "https://example.com/a?sessionid=abc&session_ts=1&id=1", "https://example.com/a?id=1"},
+		{"defaults not applied", "https://example.com/a?utm_source=x", "https://example.com/a?utm_source=x"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := n.Normalize(tt.input)
+			if err != nil {
+				t.Fatalf("Normalize(%q) failed: %v", tt.input, err)
+			}
+			if result != tt.expected {
+				t.Errorf("Normalize(%q) = %q, want %q", tt.input, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestNoTrackingParams(t *testing.T) {
+	n := New(nil)
+	result, err := n.Normalize("https://example.com/a?utm_source=x&fbclid=1")
+	if err != nil {
+		t.Fatalf("Normalize failed: %v", err)
+	}
+	if result != "https://example.com/a?fbclid=1&utm_source=x" {
+		t.Errorf("Expected all parameters to be kept, got %q", result)
+	}
+}
+
+func TestSetTrackingParams(t *testing.T) {
+	defer SetTrackingParams(DefaultTrackingParams)
+
+	SetTrackingParams([]string{"session"})
+	result, err := Normalize("https://example.com/a?session=1&utm_source=x")
+	if err != nil {
+		t.Fatalf("Normalize failed: %v", err)
+	}
+	if result != "https://example.com/a?utm_source=x" {
+		t.Errorf("Expected configured params to apply, got %q", result)
+	}
+}
@@ -0,0 +1,306 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/docutag/controller/internal/storage"
+	"github.com/docutag/controller/internal/urlguard"
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const (
+	// DefaultWorkers is the number of deliveries made at once
+	DefaultWorkers = 4
+	// DefaultMaxAttempts is how many times a delivery is tried before it counts as failed
+	DefaultMaxAttempts = 3
+	// DefaultTimeout bounds a single delivery attempt
+	DefaultTimeout = 10 * time.Second
+	// DefaultRetryDelay is the wait before the first retry; it doubles for each later one
+	DefaultRetryDelay = 2 * time.Second
+
+	queueSize       = 1000
+	maxResponseBody = 64 << 10 // Response bytes read before the connection is released
+	userAgent       = "DocuTag-Controller-Webhooks/1.0"
+)
+
+// Delivery outcomes recorded in controller_webhook_deliveries_total
+const (
+	outcomeDelivered = "delivered"
+	outcomeFailed    = "failed"
+	outcomeDropped   = "dropped" // The delivery queue was full
+)
+
+var deliveriesTotal = promauto.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "controller_webhook_deliveries_total",
+		Help: "Webhook deliveries by event type and outcome (delivered, failed or dropped)",
+	},
+	[]string{"event_type", "outcome"},
+)
+
+var disabledTotal = promauto.NewCounter(
+	prometheus.CounterOpts{
+		Name: "controller_webhooks_disabled_total",
+		Help: "Webhooks disabled after too many consecutive failed deliveries",
+	},
+)
+
+// Store is the storage the dispatcher reads subscriptions from and logs deliveries to
+type Store interface {
+	ListActiveWebhooks(namespace, eventType string) ([]*storage.Webhook, error)
+	RecordWebhookDelivery(delivery *storage.WebhookDelivery, maxFailures int) (bool, error)
+}
+
+// Config tunes delivery. Zero values use the defaults.
+type Config struct {
+	Workers                int             // Deliveries made at once
+	MaxAttempts            int             // Tries per delivery, including the first
+	Timeout                time.Duration   // Limit on each attempt
+	RetryDelay             time.Duration   // Wait before the first retry, doubled for each later one
+	MaxConsecutiveFailures int             // Failed deliveries in a row that disable a webhook; 0 never disables
+	Guard                  *urlguard.Guard // Checks every address a delivery connects to; nil refuses private targets
+}
+
+// job is one event on its way to one webhook
+type job struct {
+	hook  *storage.Webhook
+	event Event
+	body  []byte
+}
+
+// Dispatcher delivers published events to the webhooks subscribed to them from a pool of
+// workers, so publishers never wait on a subscriber. A nil *Dispatcher accepts and drops
+// every event, which is how webhooks are turned off.
+type Dispatcher struct {
+	store  Store
+	cfg    Config
+	client *http.Client
+	logger *slog.Logger
+	jobs   chan job
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// New creates a dispatcher. Call Start to begin delivering.
+func New(store Store, cfg Config, logger *slog.Logger) *Dispatcher {
+	if cfg.Workers <= 0 {
+		cfg.Workers = DefaultWorkers
+	}
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = DefaultMaxAttempts
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = DefaultTimeout
+	}
+	if cfg.RetryDelay <= 0 {
+		cfg.RetryDelay = DefaultRetryDelay
+	}
+	if cfg.Guard == nil {
+		cfg.Guard = urlguard.New(false)
+	}
+	if logger == nil {
+		logger = slog.Default()
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Dispatcher{
+		store:  store,
+		cfg:    cfg,
+		client: newClient(cfg),
+		logger: logger,
+		jobs:   make(chan job, queueSize),
+		ctx:    ctx,
+		cancel: cancel,
+	}
+}
+
+// newClient returns the delivery client. Targets are validated when a webhook is saved, but
+// DNS can change afterwards, so the address of every connection, redirects included, is
+// checked again when it is dialed. Proxies are not used, as they would hide that address.
+func newClient(cfg Config) *http.Client {
+	dialer := &net.Dialer{Timeout: cfg.Timeout, Control: cfg.Guard.DialControl}
+	return &http.Client{
+		Timeout: cfg.Timeout,
+		Transport: &http.Transport{
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: cfg.Timeout,
+			MaxIdleConnsPerHost: 2,
+			IdleConnTimeout:     90 * time.Second,
+		},
+	}
+}
+
+// Start launches the delivery workers
+func (d *Dispatcher) Start() {
+	for i := 0; i < d.cfg.Workers; i++ {
+		d.wg.Add(1)
+		go func() {
+			defer d.wg.Done()
+			for {
+				select {
+				case <-d.ctx.Done():
+					return
+				case j := <-d.jobs:
+					d.deliver(j)
+				}
+			}
+		}()
+	}
+}
+
+// Stop abandons queued deliveries, interrupts those in flight and waits for the workers
+func (d *Dispatcher) Stop() {
+	d.cancel()
+	d.wg.Wait()
+}
+
+// Publish queues an event for every enabled webhook of namespace subscribed to eventType, so
+// subscribers only hear about records in their own namespace. data is serialized as the
+// event's data field. It never blocks: when the queue is full the delivery is dropped and
+// counted.
+func (d *Dispatcher) Publish(namespace, eventType string, data interface{}) {
+	if d == nil || d.ctx.Err() != nil {
+		return
+	}
+
+	hooks, err := d.store.ListActiveWebhooks(namespace, eventType)
+	if err != nil {
+		d.logger.Error("failed to list webhooks", "namespace", namespace, "event_type", eventType, "error", err)
+		return
+	}
+	if len(hooks) == 0 {
+		return
+	}
+
+	event := Event{ID: uuid.New().String(), Type: eventType, CreatedAt: time.Now().UTC(), Data: data}
+	body, err := json.Marshal(event)
+	if err != nil {
+		d.logger.Error("failed to encode webhook event", "event_type", eventType, "error", err)
+		return
+	}
+
+	for _, hook := range hooks {
+		select {
+		case d.jobs <- job{hook: hook, event: event, body: body}:
+		default:
+			deliveriesTotal.WithLabelValues(eventType, outcomeDropped).Inc()
+			d.logger.Warn("webhook delivery queue full, dropping event",
+				"webhook_id", hook.ID,
+				"event_type", eventType,
+				"event_id", event.ID,
+			)
+		}
+	}
+}
+
+// deliver sends a job, retrying with a doubling delay, and logs the outcome
+func (d *Dispatcher) deliver(j job) {
+	start := time.Now()
+	delivery := &storage.WebhookDelivery{WebhookID: j.hook.ID, EventID: j.event.ID, EventType: j.event.Type}
+
+	delay := d.cfg.RetryDelay
+	for {
+		delivery.Attempts++
+		status, err := d.send(j)
+		delivery.StatusCode = status
+		if err == nil {
+			delivery.Success = true
+			delivery.Error = ""
+			break
+		}
+		delivery.Error = err.Error()
+		if delivery.Attempts >= d.cfg.MaxAttempts || !d.sleep(delay) {
+			break
+		}
+		delay *= 2
+	}
+	delivery.DurationMS = time.Since(start).Milliseconds()
+
+	if !delivery.Success && d.ctx.Err() != nil {
+		// Shutting down is not the subscriber's fault, so it does not count against it
+		d.logger.Warn("webhook delivery abandoned at shutdown",
+			"webhook_id", j.hook.ID,
+			"event_id", j.event.ID,
+		)
+		return
+	}
+
+	outcome := outcomeDelivered
+	if !delivery.Success {
+		outcome = outcomeFailed
+		d.logger.Warn("webhook delivery failed",
+			"webhook_id", j.hook.ID,
+			"event_type", j.event.Type,
+			"event_id", j.event.ID,
+			"attempts", delivery.Attempts,
+			"error", delivery.Error,
+		)
+	}
+	deliveriesTotal.WithLabelValues(j.event.Type, outcome).Inc()
+
+	disabled, err := d.store.RecordWebhookDelivery(delivery, d.cfg.MaxConsecutiveFailures)
+	if err != nil {
+		d.logger.Error("failed to record webhook delivery", "webhook_id", j.hook.ID, "error", err)
+		return
+	}
+	if disabled {
+		disabledTotal.Inc()
+		d.logger.Warn("webhook disabled after consecutive failures",
+			"webhook_id", j.hook.ID,
+			"url", j.hook.URL,
+			"max_consecutive_failures", d.cfg.MaxConsecutiveFailures,
+		)
+	}
+}
+
+// send makes one signed delivery attempt, returning the response status if there was one
+func (d *Dispatcher) send(j job) (int, error) {
+	req, err := http.NewRequestWithContext(d.ctx, http.MethodPost, j.hook.URL, bytes.NewReader(j.body))
+	if err != nil {
+		return 0, fmt.Errorf("failed to create request: %w", err)
+	}
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", userAgent)
+	req.Header.Set(HeaderEvent, j.event.Type)
+	req.Header.Set(HeaderDelivery, j.event.ID)
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(HeaderSignature, Sign(j.hook.Secret, timestamp, j.body))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, maxResponseBody))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// sleep waits for delay, returning false if the dispatcher stopped first
+func (d *Dispatcher) sleep(delay time.Duration) bool {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-d.ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
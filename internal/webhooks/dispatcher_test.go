@@ -0,0 +1,222 @@
+package webhooks
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/docutag/controller/internal/storage"
+	"github.com/docutag/controller/internal/urlguard"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// testGuard lets deliveries reach httptest servers on loopback
+var testGuard = urlguard.New(true)
+
+// fakeStore serves fixed webhooks and keeps deliveries in memory
+type fakeStore struct {
+	hooks []*storage.Webhook
+
+	mu         sync.Mutex
+	deliveries []*storage.WebhookDelivery
+	failures   int
+	done       chan struct{}
+}
+
+func newFakeStore(hooks ...*storage.Webhook) *fakeStore {
+	return &fakeStore{hooks: hooks, done: make(chan struct{}, 100)}
+}
+
+func (f *fakeStore) ListActiveWebhooks(namespace, eventType string) ([]*storage.Webhook, error) {
+	var out []*storage.Webhook
+	for _, h := range f.hooks {
+		if h.Namespace != namespace {
+			continue
+		}
+		for _, t := range h.EventTypes {
+			if t == eventType {
+				out = append(out, h)
+			}
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeStore) RecordWebhookDelivery(d *storage.WebhookDelivery, maxFailures int) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.deliveries = append(f.deliveries, d)
+	if d.Success {
+		f.failures = 0
+	} else {
+		f.failures++
+	}
+	f.done <- struct{}{}
+	return !d.Success && maxFailures > 0 && f.failures == maxFailures, nil
+}
+
+func (f *fakeStore) wait(t *testing.T, n int) []*storage.WebhookDelivery {
+	t.Helper()
+	for i := 0; i < n; i++ {
+		select {
+		case <-f.done:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("Timed out waiting for delivery %d of %d", i+1, n)
+		}
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]*storage.WebhookDelivery(nil), f.deliveries...)
+}
+
+func TestDispatcherSignsAndDelivers(t *testing.T) {
+	var received atomic.Value
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		timestamp, _ := strconv.ParseInt(r.Header.Get(HeaderTimestamp), 10, 64)
+		if r.Header.Get(HeaderSignature) != Sign("0123456789abcdef", timestamp, body) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		received.Store(r.Header.Get(HeaderEvent) + " " + string(body))
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	store := newFakeStore(
+		&storage.Webhook{ID: "hook-1", URL: server.URL, Secret: "0123456789abcdef", Namespace: storage.DefaultNamespace, EventTypes: []string{EventScrapeCompleted}},
+		&storage.Webhook{ID: "hook-2", URL: server.URL, Secret: "other", Namespace: storage.DefaultNamespace, EventTypes: []string{EventRequestDeleted}},
+	)
+	d := New(store, Config{Workers: 2, Guard: testGuard}, nil)
+	d.Start()
+	defer d.Stop()
+
+	d.Publish(storage.DefaultNamespace, EventScrapeCompleted, storage.ScrapeJob{ID: "job-1", Status: "completed"})
+	deliveries := store.wait(t, 1)
+	if len(deliveries) != 1 || !deliveries[0].Success || deliveries[0].WebhookID != "hook-1" || deliveries[0].StatusCode != http.StatusNoContent {
+		t.Fatalf("Expected one successful delivery to hook-1, got %+v", deliveries[0])
+	}
+
+	got, _ := received.Load().(string)
+	var event struct {
+		Type string            `json:"type"`
+		Data storage.ScrapeJob `json:"data"`
+	}
+	if len(got) < len(EventScrapeCompleted)+1 || got[:len(EventScrapeCompleted)] != EventScrapeCompleted {
+		t.Fatalf("Unexpected event header in %q", got)
+	}
+	if err := json.Unmarshal([]byte(got[len(EventScrapeCompleted)+1:]), &event); err != nil {
+		t.Fatalf("Failed to decode event: %v", err)
+	}
+	if event.Type != EventScrapeCompleted || event.Data.ID != "job-1" {
+		t.Errorf("Unexpected event %+v", event)
+	}
+}
+
+func TestDispatcherRetriesAndDisables(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	store := newFakeStore(&storage.Webhook{ID: "hook-1", URL: server.URL, Secret: "s", Namespace: storage.DefaultNamespace, EventTypes: []string{EventScrapeFailed}})
+	d := New(store, Config{Workers: 1, MaxAttempts: 3, RetryDelay: time.Millisecond, MaxConsecutiveFailures: 2, Guard: testGuard}, nil)
+	d.Start()
+	defer d.Stop()
+	disabledBefore := testutil.ToFloat64(disabledTotal)
+
+	d.Publish(storage.DefaultNamespace, EventScrapeFailed, storage.ScrapeJob{ID: "job-1"})
+	d.Publish(storage.DefaultNamespace, EventScrapeFailed, storage.ScrapeJob{ID: "job-2"})
+	deliveries := store.wait(t, 2)
+	d.Stop() // Lets the worker finish handling the second result
+
+	if calls.Load() != 6 {
+		t.Errorf("Expected 3 attempts per event, got %d calls", calls.Load())
+	}
+	for _, delivery := range deliveries {
+		if delivery.Success || delivery.Attempts != 3 || delivery.StatusCode != http.StatusInternalServerError || delivery.Error == "" {
+			t.Errorf("Expected a failed delivery after 3 attempts, got %+v", delivery)
+		}
+	}
+	if n := testutil.ToFloat64(disabledTotal) - disabledBefore; n != 1 {
+		t.Errorf("Expected the second failure to disable the webhook once, got %v", n)
+	}
+}
+
+func TestDispatcherRefusesPrivateTargets(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	// The default guard refuses the loopback address the webhook was re-pointed at
+	store := newFakeStore(&storage.Webhook{ID: "hook-1", URL: server.URL, Secret: "s", Namespace: storage.DefaultNamespace, EventTypes: []string{EventScrapeCompleted}})
+	d := New(store, Config{Workers: 1, MaxAttempts: 1}, nil)
+	d.Start()
+	defer d.Stop()
+
+	d.Publish(storage.DefaultNamespace, EventScrapeCompleted, storage.ScrapeJob{ID: "job-1"})
+	deliveries := store.wait(t, 1)
+	if deliveries[0].Success || !strings.Contains(deliveries[0].Error, urlguard.RulePrivateTarget) {
+		t.Errorf("Expected a delivery refused as a private target, got %+v", deliveries[0])
+	}
+	if calls.Load() != 0 {
+		t.Errorf("Expected no request to reach the private target, got %d", calls.Load())
+	}
+}
+
+func TestNilDispatcherPublishes(t *testing.T) {
+	var d *Dispatcher
+	d.Publish(storage.DefaultNamespace, EventRequestDeleted, nil) // Must not panic
+}
+
+func TestSign(t *testing.T) {
+	a := Sign("secret", 1700000000, []byte(`{"id":"1"}`))
+	if a != Sign("secret", 1700000000, []byte(`{"id":"1"}`)) {
+		t.Error("Expected the signature to be deterministic")
+	}
+	if a == Sign("secret", 1700000001, []byte(`{"id":"1"}`)) || a == Sign("other", 1700000000, []byte(`{"id":"1"}`)) {
+		t.Error("Expected the timestamp and secret to change the signature")
+	}
+	if len(a) != len("sha256=")+64 || a[:7] != "sha256=" {
+		t.Errorf("Unexpected signature format %q", a)
+	}
+}
+
+func TestDispatcherPublishesWithinNamespace(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	store := newFakeStore(
+		&storage.Webhook{ID: "hook-a", URL: server.URL, Secret: "s", Namespace: "team-a", EventTypes: []string{EventScrapeCompleted}},
+		&storage.Webhook{ID: "hook-b", URL: server.URL, Secret: "s", Namespace: "team-b", EventTypes: []string{EventScrapeCompleted}},
+	)
+	d := New(store, Config{Workers: 1, Guard: testGuard}, nil)
+	d.Start()
+	defer d.Stop()
+
+	d.Publish("team-b", EventScrapeCompleted, storage.ScrapeJob{ID: "job-1", Namespace: "team-b"})
+	deliveries := store.wait(t, 1)
+	if deliveries[0].WebhookID != "hook-b" {
+		t.Errorf("Expected only the team-b webhook to receive the event, got %+v", deliveries[0])
+	}
+	d.Stop()
+	if calls.Load() != 1 {
+		t.Errorf("Expected one delivery, got %d", calls.Load())
+	}
+}
@@ -0,0 +1,58 @@
+package webhooks
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"time"
+)
+
+// Event types a webhook can subscribe to
+const (
+	EventScrapeCompleted   = "scrape.completed"   // Data is the scrape job
+	EventScrapeFailed      = "scrape.failed"      // Data is the scrape job, with its error
+	EventRequestTombstoned = "request.tombstoned" // Data is the request
+	EventRequestDeleted    = "request.deleted"    // Data is the request as it was before deletion
+)
+
+// EventTypes lists every event type, in the order they are documented
+var EventTypes = []string{EventScrapeCompleted, EventScrapeFailed, EventRequestTombstoned, EventRequestDeleted}
+
+// ValidEventType reports whether eventType is one of EventTypes
+func ValidEventType(eventType string) bool {
+	for _, t := range EventTypes {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// Headers sent with every delivery
+const (
+	HeaderEvent     = "X-Webhook-Event"     // The event type
+	HeaderDelivery  = "X-Webhook-Delivery"  // The event ID, the same on every retry
+	HeaderTimestamp = "X-Webhook-Timestamp" // Unix seconds when the attempt was signed
+	HeaderSignature = "X-Webhook-Signature" // "sha256=" and the hex HMAC from Sign
+)
+
+// Event is the JSON body POSTed to webhooks. Data uses the same shape the API returns for
+// the subject: a scrape job for scrape.* events and a request for request.* events.
+type Event struct {
+	ID        string      `json:"id"`
+	Type      string      `json:"type"`
+	CreatedAt time.Time   `json:"created_at"`
+	Data      interface{} `json:"data"`
+}
+
+// Sign returns the X-Webhook-Signature value for a body: the HMAC-SHA256, keyed with the
+// webhook's secret, of the timestamp, a ".", and the body. Receivers recompute it to check
+// that a delivery came from the controller and was not replayed with a different timestamp.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
@@ -8,10 +8,10 @@ import (
 	"github.com/docutag/platform/pkg/tracing"
 )
 
-// responseWriter wraps http.ResponseWriter to capture status code
+// responseWriter wraps http.ResponseWriter to capture the status code and body size
 type responseWriter struct {
 	http.ResponseWriter
-	status      int
+	status       int
 	bytesWritten int64
 }
 
@@ -26,45 +26,114 @@ func (rw *responseWriter) Write(b []byte) (int, error) {
 	return n, err
 }
 
-// HTTPLoggingMiddleware logs HTTP requests in structured JSON format
+// Flush passes through to the underlying writer so streamed responses (SSE) still flush
+func (rw *responseWriter) Flush() {
+	if f, ok := rw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer
+func (rw *responseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
+
+// DefaultQuietPaths are polled by probes and scrapers often enough to dominate request logs
+var DefaultQuietPaths = []string{"/health", "/ready", "/metrics"}
+
+// DefaultQuietSampleEvery is how many successful requests to a quiet path are logged per one at Info
+const DefaultQuietSampleEvery = 100
+
+// HTTPLoggingOptions configures HTTPLoggingMiddlewareWithOptions
+type HTTPLoggingOptions struct {
+	QuietPaths       []string // Exact paths whose successful requests are sampled; errors are always logged
+	QuietSampleEvery int      // After the first, one successful quiet request in this many is logged at Info and the rest at Debug; <= 1 logs them all
+}
+
+// HTTPLoggingMiddleware logs HTTP requests in structured JSON format, sampling the
+// DefaultQuietPaths
 func HTTPLoggingMiddleware(logger *slog.Logger) func(http.Handler) http.Handler {
+	return HTTPLoggingMiddlewareWithOptions(logger, HTTPLoggingOptions{
+		QuietPaths:       DefaultQuietPaths,
+		QuietSampleEvery: DefaultQuietSampleEvery,
+	})
+}
+
+// HTTPLoggingMiddlewareWithOptions logs one "http_request" record per request. The field
+// names are a stable schema that dashboards and log queries rely on:
+//
+//	method       request method
+//	route        pattern of the matched ServeMux route, e.g. "GET /api/v1/requests/{id}";
+//	             empty when no route matched. Aggregate on this rather than path.
+//	path         raw request path
+//	query        raw query string
+//	status       response status code
+//	bytes        response body bytes written
+//	duration_ms  time spent in the handler, in milliseconds with microsecond precision
+//	remote_addr  client address as seen by the server
+//	user_agent   User-Agent header
+//	referer      Referer header
+//	trace_id     trace ID, when tracing is enabled
+//	span_id      span ID, when tracing is enabled
+//	request_id   X-Request-ID assigned by RequestIDMiddleware
+//	protocol     HTTP protocol version
+//	host         Host header
+func HTTPLoggingMiddlewareWithOptions(logger *slog.Logger, opts HTTPLoggingOptions) func(http.Handler) http.Handler {
+	quiet := make(map[string]*Sampler, len(opts.QuietPaths))
+	for _, path := range opts.QuietPaths {
+		quiet[path] = NewSampler(logger, "http_request", SamplerOptions{First: 1, Every: opts.QuietSampleEvery})
+	}
+
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			start := time.Now()
 
-			// Wrap response writer to capture status
+			// Wrap response writer to capture status and size
 			wrapped := &responseWriter{
 				ResponseWriter: w,
-				status:        http.StatusOK,
-				bytesWritten:  0,
+				status:         http.StatusOK,
 			}
 
 			// Get trace context if available
 			traceID := tracing.TraceIDFromContext(r.Context())
 			spanID := tracing.SpanIDFromContext(r.Context())
 
-			// Call next handler
+			// Call next handler; a ServeMux sets r.Pattern on the request it is given
 			next.ServeHTTP(wrapped, r)
 
 			// Calculate request duration
 			duration := time.Since(start)
 
-			// Log structured request
-			logger.LogAttrs(r.Context(), slog.LevelInfo, "http_request",
+			attrs := []slog.Attr{
 				slog.String("method", r.Method),
+				slog.String("route", r.Pattern),
 				slog.String("path", r.URL.Path),
 				slog.String("query", r.URL.RawQuery),
 				slog.Int("status", wrapped.status),
 				slog.Int64("bytes", wrapped.bytesWritten),
-				slog.Float64("duration_ms", float64(duration.Milliseconds())),
+				slog.Float64("duration_ms", float64(duration.Microseconds())/1000),
 				slog.String("remote_addr", r.RemoteAddr),
 				slog.String("user_agent", r.UserAgent()),
 				slog.String("referer", r.Referer()),
 				slog.String("trace_id", traceID),
 				slog.String("span_id", spanID),
+				slog.String("request_id", RequestIDFromContext(r.Context())),
 				slog.String("protocol", r.Proto),
 				slog.String("host", r.Host),
-			)
+			}
+
+			// Probes and scrapes are sampled while they succeed; a failing one is always worth a line
+			if sampler, ok := quiet[r.URL.Path]; ok && wrapped.status < http.StatusBadRequest {
+				args := make([]any, len(attrs))
+				for i, attr := range attrs {
+					args[i] = attr
+				}
+				sampler.Log(r.Context(), args...)
+				return
+			}
+
+			// Log structured request
+			logger.LogAttrs(r.Context(), slog.LevelInfo, "http_request", attrs...)
 		})
 	}
 }
@@ -0,0 +1,130 @@
+package logging
+
+import (
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func newLoggedMux(logger *slog.Logger, opts HTTPLoggingOptions) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/requests/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("hello"))
+	})
+	mux.HandleFunc("GET /health", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	})
+	mux.HandleFunc("GET /ready", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+	mux.HandleFunc("GET /events", func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := w.(http.Flusher); !ok {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	})
+	return HTTPLoggingMiddlewareWithOptions(logger, opts)(mux)
+}
+
+func TestHTTPLoggingRecordsSchema(t *testing.T) {
+	handler := &recordingHandler{}
+	server := newLoggedMux(slog.New(handler), HTTPLoggingOptions{})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/requests/abc?full=1", nil)
+	req.Header.Set("User-Agent", "test-agent/1.0")
+	req.Header.Set("Referer", "https://example.com/")
+	server.ServeHTTP(httptest.NewRecorder(), req)
+
+	if len(handler.records) != 1 {
+		t.Fatalf("Expected 1 record, got %d", len(handler.records))
+	}
+	record := handler.records[0]
+	if record.Message != "http_request" || record.Level != slog.LevelInfo {
+		t.Fatalf("Expected an info http_request record, got %s %q", record.Level, record.Message)
+	}
+
+	got := attrs(record)
+	wantStrings := map[string]string{
+		"method":     "GET",
+		"route":      "GET /api/requests/{id}",
+		"path":       "/api/requests/abc",
+		"query":      "full=1",
+		"user_agent": "test-agent/1.0",
+		"referer":    "https://example.com/",
+	}
+	for key, want := range wantStrings {
+		if got[key].String() != want {
+			t.Errorf("Expected %s %q, got %q", key, want, got[key].String())
+		}
+	}
+	if got["status"].Int64() != http.StatusOK {
+		t.Errorf("Expected status 200, got %v", got["status"])
+	}
+	if got["bytes"].Int64() != int64(len("hello")) {
+		t.Errorf("Expected 5 bytes, got %v", got["bytes"])
+	}
+	for _, key := range []string{"duration_ms", "remote_addr", "trace_id", "span_id", "request_id", "protocol", "host"} {
+		if _, ok := got[key]; !ok {
+			t.Errorf("Expected field %s in the record", key)
+		}
+	}
+}
+
+func TestHTTPLoggingRouteEmptyWithoutMatch(t *testing.T) {
+	handler := &recordingHandler{}
+	server := newLoggedMux(slog.New(handler), HTTPLoggingOptions{})
+
+	server.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/nowhere", nil))
+
+	got := attrs(handler.records[0])
+	if got["route"].String() != "" || got["status"].Int64() != http.StatusNotFound {
+		t.Errorf("Expected an empty route on a 404, got %q (%v)", got["route"].String(), got["status"])
+	}
+}
+
+func TestHTTPLoggingSamplesQuietPaths(t *testing.T) {
+	handler := &recordingHandler{}
+	server := newLoggedMux(slog.New(handler), HTTPLoggingOptions{
+		QuietPaths:       []string{"/health", "/ready"},
+		QuietSampleEvery: 3,
+	})
+
+	for i := 0; i < 7; i++ {
+		server.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/health", nil))
+	}
+	var info int
+	for _, r := range handler.records {
+		if r.Level == slog.LevelInfo {
+			info++
+		}
+	}
+	// The first, fourth and seventh requests
+	if info != 3 {
+		t.Errorf("Expected 3 health checks at info, got %d", info)
+	}
+	if len(handler.records) != 7 {
+		t.Errorf("Expected the rest at debug, got %d records", len(handler.records))
+	}
+
+	// Failing probes are never sampled away
+	handler.records = nil
+	for i := 0; i < 3; i++ {
+		server.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/ready", nil))
+	}
+	for _, r := range handler.records {
+		if r.Level != slog.LevelInfo {
+			t.Errorf("Expected a failing readiness check at info, got %s", r.Level)
+		}
+	}
+}
+
+func TestHTTPLoggingKeepsFlusher(t *testing.T) {
+	handler := &recordingHandler{}
+	server := newLoggedMux(slog.New(handler), HTTPLoggingOptions{})
+
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/events", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected the wrapped writer to implement http.Flusher")
+	}
+}
@@ -0,0 +1,47 @@
+package logging
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+)
+
+// Log output formats accepted by NewHandler
+const (
+	FormatJSON = "json"
+	FormatText = "text"
+)
+
+// ParseLevel converts debug, info, warn or error (any case) to a slog level
+func ParseLevel(s string) (slog.Level, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "debug":
+		return slog.LevelDebug, nil
+	case "info":
+		return slog.LevelInfo, nil
+	case "warn", "warning":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
+	}
+	return slog.LevelInfo, fmt.Errorf("unknown log level %q (want debug, info, warn or error)", s)
+}
+
+// LevelName returns the lowercase name ParseLevel accepts for level
+func LevelName(level slog.Level) string {
+	return strings.ToLower(level.String())
+}
+
+// NewHandler returns a JSON or text handler writing to w. level is usually a *slog.LevelVar
+// so the threshold can be changed while the process runs.
+func NewHandler(w io.Writer, format string, level slog.Leveler) (slog.Handler, error) {
+	opts := &slog.HandlerOptions{Level: level}
+	switch strings.ToLower(format) {
+	case FormatJSON, "":
+		return slog.NewJSONHandler(w, opts), nil
+	case FormatText:
+		return slog.NewTextHandler(w, opts), nil
+	}
+	return nil, fmt.Errorf("unknown log format %q (want json or text)", format)
+}
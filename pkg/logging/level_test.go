@@ -0,0 +1,76 @@
+package logging
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestLevelVarControlsDebugOutput(t *testing.T) {
+	var buf bytes.Buffer
+	level := new(slog.LevelVar)
+	handler, err := NewHandler(&buf, FormatJSON, level)
+	if err != nil {
+		t.Fatalf("NewHandler failed: %v", err)
+	}
+	logger := slog.New(handler)
+
+	logger.Debug("hidden at info")
+	if buf.Len() != 0 {
+		t.Fatalf("debug record emitted at info level: %s", buf.String())
+	}
+
+	level.Set(slog.LevelDebug)
+	logger.Debug("shown at debug")
+	if !strings.Contains(buf.String(), "shown at debug") {
+		t.Fatalf("debug record not emitted after lowering the level: %q", buf.String())
+	}
+
+	buf.Reset()
+	level.Set(slog.LevelWarn)
+	logger.Info("hidden at warn")
+	if buf.Len() != 0 {
+		t.Errorf("info record emitted at warn level: %s", buf.String())
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    slog.Level
+		wantErr bool
+	}{
+		{"debug", slog.LevelDebug, false},
+		{"INFO", slog.LevelInfo, false},
+		{" warn ", slog.LevelWarn, false},
+		{"error", slog.LevelError, false},
+		{"verbose", slog.LevelInfo, true},
+		{"", slog.LevelInfo, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseLevel(tt.in)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseLevel(%q) = %v, %v; want %v, error %v", tt.in, got, err, tt.want, tt.wantErr)
+		}
+		if err == nil && LevelName(got) != strings.ToLower(strings.TrimSpace(tt.in)) {
+			t.Errorf("LevelName(%v) = %q, want %q", got, LevelName(got), strings.ToLower(strings.TrimSpace(tt.in)))
+		}
+	}
+}
+
+func TestNewHandlerFormats(t *testing.T) {
+	var buf bytes.Buffer
+	handler, err := NewHandler(&buf, FormatText, slog.LevelInfo)
+	if err != nil {
+		t.Fatalf("NewHandler failed: %v", err)
+	}
+	slog.New(handler).Info("hello", "k", "v")
+	if !strings.Contains(buf.String(), "msg=hello k=v") {
+		t.Errorf("expected text output, got %q", buf.String())
+	}
+
+	if _, err := NewHandler(&buf, "xml", slog.LevelInfo); err == nil {
+		t.Error("expected error for unknown format")
+	}
+}
@@ -0,0 +1,143 @@
+package logging
+
+import (
+	"context"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Defaults for sampling repetitive log lines
+const (
+	DefaultSampleFirst = 5  // Lines logged at Info before sampling starts
+	DefaultSampleEvery = 50 // After those, one line in this many is logged at Info
+)
+
+// Counter is incremented once for every line a Sampler demotes to Debug.
+// A prometheus.Counter satisfies it.
+type Counter interface {
+	Inc()
+}
+
+// SamplerOptions configures a Sampler
+type SamplerOptions struct {
+	First      int           // Lines logged at Info before sampling starts
+	Every      int           // After First, every Every-th line is logged at Info; <= 1 logs every line at Info
+	Window     time.Duration // Flush automatically once this much time has passed; 0 flushes only when Flush is called
+	Suppressed Counter       // Counts lines demoted to Debug; nil counts nothing
+}
+
+// Sampler thins out a repetitive Info line. The first First lines are logged at Info, then
+// every Every-th; the rest are logged at Debug, so nothing is lost at that level. Flush
+// ends a batch with one Info summary holding the line count and the totals added with Add.
+// Methods on a nil Sampler do nothing.
+type Sampler struct {
+	logger *slog.Logger
+	msg    string
+	opts   SamplerOptions
+	now    func() time.Time
+
+	mu      sync.Mutex
+	lines   int            // Lines seen since the last flush
+	demoted int            // Of those, lines logged at Debug only
+	totals  map[string]int // Summed by Add since the last flush
+	started time.Time      // When the current window began
+}
+
+// NewSampler returns a Sampler that logs msg through logger
+func NewSampler(logger *slog.Logger, msg string, opts SamplerOptions) *Sampler {
+	return &Sampler{logger: logger, msg: msg, opts: opts, now: time.Now}
+}
+
+// Log records one occurrence of the line with its attributes, at Info if it is sampled
+// and at Debug otherwise
+func (s *Sampler) Log(ctx context.Context, args ...any) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	s.rollWindow(ctx)
+	s.lines++
+	info := s.sampled(s.lines)
+	if !info {
+		s.demoted++
+	}
+	s.mu.Unlock()
+
+	if info {
+		s.logger.InfoContext(ctx, s.msg, args...)
+		return
+	}
+	if s.opts.Suppressed != nil {
+		s.opts.Suppressed.Inc()
+	}
+	s.logger.DebugContext(ctx, s.msg, args...)
+}
+
+// Add adds n to the named total reported by the next summary
+func (s *Sampler) Add(name string, n int) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.totals == nil {
+		s.totals = make(map[string]int)
+	}
+	s.totals[name] += n
+}
+
+// Flush logs the summary of the lines seen since the last flush, with args appended, and
+// starts a new batch. It logs nothing when no line was seen.
+func (s *Sampler) Flush(ctx context.Context, args ...any) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.flush(ctx, args...)
+}
+
+// sampled reports whether the n-th line of a batch is logged at Info
+func (s *Sampler) sampled(n int) bool {
+	if n <= s.opts.First || s.opts.Every <= 1 {
+		return true
+	}
+	return (n-s.opts.First)%s.opts.Every == 0
+}
+
+// rollWindow flushes the previous window once it has ended. Callers hold s.mu.
+func (s *Sampler) rollWindow(ctx context.Context) {
+	if s.opts.Window <= 0 {
+		return
+	}
+	now := s.now()
+	if s.started.IsZero() {
+		s.started = now
+		return
+	}
+	if now.Sub(s.started) >= s.opts.Window {
+		s.flush(ctx, "window", s.opts.Window.String())
+		s.started = now
+	}
+}
+
+// flush writes and resets the summary. Callers hold s.mu.
+func (s *Sampler) flush(ctx context.Context, args ...any) {
+	if s.lines == 0 {
+		return
+	}
+	attrs := []any{"lines", s.lines, "sampled_out", s.demoted}
+	names := make([]string, 0, len(s.totals))
+	for name := range s.totals {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		attrs = append(attrs, name, s.totals[name])
+	}
+	s.logger.InfoContext(ctx, s.msg+" (summary)", append(attrs, args...)...)
+
+	s.lines, s.demoted, s.totals = 0, 0, nil
+}
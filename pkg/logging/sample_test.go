@@ -0,0 +1,153 @@
+package logging
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recordingHandler keeps every record it handles, at any level
+type recordingHandler struct {
+	mu      sync.Mutex
+	records []slog.Record
+}
+
+func (h *recordingHandler) Enabled(context.Context, slog.Level) bool { return true }
+
+func (h *recordingHandler) Handle(_ context.Context, r slog.Record) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.records = append(h.records, r)
+	return nil
+}
+
+func (h *recordingHandler) WithAttrs([]slog.Attr) slog.Handler { return h }
+func (h *recordingHandler) WithGroup(string) slog.Handler      { return h }
+
+// attrs returns the attributes of a record by key
+func attrs(r slog.Record) map[string]slog.Value {
+	values := make(map[string]slog.Value)
+	r.Attrs(func(a slog.Attr) bool {
+		values[a.Key] = a.Value
+		return true
+	})
+	return values
+}
+
+type countingCounter struct{ n int }
+
+func (c *countingCounter) Inc() { c.n++ }
+
+func TestSamplerLogsFirstThenEveryNth(t *testing.T) {
+	handler := &recordingHandler{}
+	suppressed := &countingCounter{}
+	sampler := NewSampler(slog.New(handler), "queued child job", SamplerOptions{First: 3, Every: 4, Suppressed: suppressed})
+
+	for i := 1; i <= 12; i++ {
+		sampler.Log(context.Background(), "n", i)
+	}
+
+	var info []int64
+	for _, r := range handler.records {
+		if r.Level == slog.LevelInfo {
+			info = append(info, attrs(r)["n"].Int64())
+		}
+	}
+	want := []int64{1, 2, 3, 7, 11}
+	if len(info) != len(want) {
+		t.Fatalf("Expected lines %v at info, got %v", want, info)
+	}
+	for i := range want {
+		if info[i] != want[i] {
+			t.Fatalf("Expected lines %v at info, got %v", want, info)
+		}
+	}
+	if len(handler.records) != 12 {
+		t.Errorf("Expected every line to be logged at some level, got %d records", len(handler.records))
+	}
+	if suppressed.n != 7 {
+		t.Errorf("Expected 7 lines counted as suppressed, got %d", suppressed.n)
+	}
+}
+
+func TestSamplerEveryOneLogsEverything(t *testing.T) {
+	handler := &recordingHandler{}
+	sampler := NewSampler(slog.New(handler), "line", SamplerOptions{First: 1, Every: 1})
+	for i := 0; i < 5; i++ {
+		sampler.Log(context.Background())
+	}
+	for _, r := range handler.records {
+		if r.Level != slog.LevelInfo {
+			t.Fatalf("Expected every line at info, got %s", r.Level)
+		}
+	}
+}
+
+func TestSamplerFlushSummarizesBatch(t *testing.T) {
+	handler := &recordingHandler{}
+	sampler := NewSampler(slog.New(handler), "queued child job", SamplerOptions{First: 1, Every: 10})
+
+	sampler.Flush(context.Background())
+	if len(handler.records) != 0 {
+		t.Fatalf("Expected no summary for an empty batch, got %d records", len(handler.records))
+	}
+
+	for i := 0; i < 4; i++ {
+		sampler.Log(context.Background())
+		sampler.Add("enqueued", 1)
+	}
+	sampler.Add("failed", 2)
+	sampler.Flush(context.Background(), "parent_job_id", "p1")
+
+	summary := handler.records[len(handler.records)-1]
+	if summary.Level != slog.LevelInfo || summary.Message != "queued child job (summary)" {
+		t.Fatalf("Expected an info summary, got %s %q", summary.Level, summary.Message)
+	}
+	got := attrs(summary)
+	if got["lines"].Int64() != 4 || got["sampled_out"].Int64() != 3 {
+		t.Errorf("Expected 4 lines with 3 sampled out, got %v and %v", got["lines"], got["sampled_out"])
+	}
+	if got["enqueued"].Int64() != 4 || got["failed"].Int64() != 2 || got["parent_job_id"].String() != "p1" {
+		t.Errorf("Expected the totals and extra attributes in the summary, got %v", got)
+	}
+
+	// The batch starts over after a flush
+	handler.records = nil
+	sampler.Log(context.Background())
+	if handler.records[0].Level != slog.LevelInfo {
+		t.Errorf("Expected the first line after a flush at info, got %s", handler.records[0].Level)
+	}
+}
+
+func TestSamplerWindowFlushes(t *testing.T) {
+	handler := &recordingHandler{}
+	sampler := NewSampler(slog.New(handler), "cache hit for URL", SamplerOptions{First: 1, Every: 100, Window: time.Minute})
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	sampler.now = func() time.Time { return now }
+
+	sampler.Log(context.Background())
+	sampler.Log(context.Background())
+	now = now.Add(time.Minute)
+	sampler.Log(context.Background())
+
+	var summaries, info int
+	for _, r := range handler.records {
+		switch {
+		case r.Message == "cache hit for URL (summary)":
+			summaries++
+			if n := attrs(r)["lines"].Int64(); n != 2 {
+				t.Errorf("Expected the summary to cover the 2 lines of the first window, got %d", n)
+			}
+		case r.Level == slog.LevelInfo:
+			info++
+		}
+	}
+	if summaries != 1 {
+		t.Errorf("Expected 1 summary when the window ended, got %d", summaries)
+	}
+	if info != 2 {
+		t.Errorf("Expected the first line of each window at info, got %d", info)
+	}
+}